@@ -27,6 +27,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
+	m3dbruntime "github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/storage"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper"
@@ -56,9 +57,23 @@ type BootstrapConfiguration struct {
 	// Commitlog bootstrapper configuration.
 	Commitlog *BootstrapCommitlogConfiguration `yaml:"commitlog"`
 
+	// Peers bootstrapper configuration.
+	Peers *BootstrapPeersConfiguration `yaml:"peers"`
+
+	// Custom is the configuration of the registered bootstrappers by name.
+	Custom map[string]interface{} `yaml:"custom"`
+
 	// CacheSeriesMetadata determines whether individual bootstrappers cache
 	// series metadata across all calls (namespaces / shards / blocks).
 	CacheSeriesMetadata *bool `yaml:"cacheSeriesMetadata"`
+
+	// Checkpoint determines whether ranges bootstrapped with persistence are
+	// checkpointed so that a restarted bootstrap can skip them.
+	Checkpoint bool `yaml:"checkpoint"`
+
+	// PrioritizeRecent determines whether the most recent ranges are
+	// bootstrapped first so that they become available before older ranges.
+	PrioritizeRecent bool `yaml:"prioritizeRecent"`
 }
 
 // BootstrapFilesystemConfiguration specifies config for the fs bootstrapper.
@@ -86,6 +101,23 @@ type BootstrapCommitlogConfiguration struct {
 	// this to true allows the node to attempt a repair if the peers bootstrapper is configured
 	// after the commitlog bootstrapper.
 	ReturnUnfulfilledForCorruptCommitLogFiles bool `yaml:"returnUnfulfilledForCorruptCommitLogFiles"`
+
+	// SnapshotOnly controls whether the commitlog bootstrapper skips reading the
+	// commit log entirely and only loads the latest valid snapshot of each shard
+	// and block, for recovering as much data as possible when the commit logs
+	// have been lost.
+	SnapshotOnly bool `yaml:"snapshotOnly"`
+
+	// EncodingConcurrency is the number of workers encoding the datapoints
+	// read from the commit log, each worker owns a subset of the shards.
+	EncodingConcurrency int `yaml:"encodingConcurrency" validate:"min=0"`
+
+	// ReadConcurrency is the number of commit log files decoded concurrently.
+	ReadConcurrency int `yaml:"readConcurrency" validate:"min=0"`
+
+	// ReadBufferBytes bounds the estimated bytes of the commit log entries
+	// decoded ahead of being encoded.
+	ReadBufferBytes int64 `yaml:"readBufferBytes" validate:"min=0"`
 }
 
 func newDefaultBootstrapCommitlogConfiguration() BootstrapCommitlogConfiguration {
@@ -94,6 +126,44 @@ func newDefaultBootstrapCommitlogConfiguration() BootstrapCommitlogConfiguration
 	}
 }
 
+// BootstrapPeersConfiguration specifies config for the peers bootstrapper.
+type BootstrapPeersConfiguration struct {
+	// VerifyOnly controls whether the peers bootstrapper only compares block
+	// metadata and checksums across peers for the full retention and reports
+	// the divergence per shard and block, without fetching any data. All
+	// ranges are left unfulfilled for the bootstrappers configured after it.
+	VerifyOnly bool `yaml:"verifyOnly"`
+
+	// Limits throttle the streaming of blocks from peers, they can be
+	// changed at runtime with the peers bootstrap limits KV key.
+	Limits *BootstrapPeersLimitsConfiguration `yaml:"limits"`
+}
+
+// BootstrapPeersLimitsConfiguration specifies the limits throttling the
+// peers bootstrapper, a zero value for any of the limits means unlimited.
+type BootstrapPeersLimitsConfiguration struct {
+	// BytesPerSecondPerPeer is the max throughput of the blocks streamed
+	// from each peer.
+	BytesPerSecondPerPeer int64 `yaml:"bytesPerSecondPerPeer" validate:"min=0"`
+
+	// MaxConcurrentShards is the max number of shards bootstrapped
+	// concurrently.
+	MaxConcurrentShards int `yaml:"maxConcurrentShards" validate:"min=0"`
+
+	// MaxConcurrentBlockFetches is the max number of batches of blocks
+	// fetched from peers concurrently.
+	MaxConcurrentBlockFetches int `yaml:"maxConcurrentBlockFetches" validate:"min=0"`
+}
+
+// RuntimeLimits returns the peers bootstrap limits as runtime options.
+func (c BootstrapPeersLimitsConfiguration) RuntimeLimits() m3dbruntime.PeersBootstrapLimits {
+	return m3dbruntime.PeersBootstrapLimits{
+		BytesPerSecondPerPeer:     c.BytesPerSecondPerPeer,
+		MaxConcurrentShards:       c.MaxConcurrentShards,
+		MaxConcurrentBlockFetches: c.MaxConcurrentBlockFetches,
+	}
+}
+
 // BootstrapConfigurationValidator can be used to validate the option sets
 // that the  bootstrap configuration builds.
 // Useful for tests and perhaps verifying same options set across multiple
@@ -159,11 +229,22 @@ func (bsc BootstrapConfiguration) New(
 			}
 		case commitlog.CommitLogBootstrapperName:
 			cCfg := bsc.commitlogConfig()
+			clOpts := opts.CommitLogOptions()
+			if cCfg.ReadConcurrency > 0 {
+				clOpts = clOpts.SetReadConcurrency(cCfg.ReadConcurrency)
+			}
+			if cCfg.ReadBufferBytes > 0 {
+				clOpts = clOpts.SetReadBufferBytes(cCfg.ReadBufferBytes)
+			}
 			cOpts := commitlog.NewOptions().
 				SetResultOptions(rsOpts).
-				SetCommitLogOptions(opts.CommitLogOptions()).
+				SetCommitLogOptions(clOpts).
 				SetRuntimeOptionsManager(opts.RuntimeOptionsManager()).
-				SetReturnUnfulfilledForCorruptCommitLogFiles(cCfg.ReturnUnfulfilledForCorruptCommitLogFiles)
+				SetReturnUnfulfilledForCorruptCommitLogFiles(cCfg.ReturnUnfulfilledForCorruptCommitLogFiles).
+				SetSnapshotOnly(cCfg.SnapshotOnly)
+			if cCfg.EncodingConcurrency > 0 {
+				cOpts = cOpts.SetEncodingConcurrency(cCfg.EncodingConcurrency)
+			}
 			if err := validator.ValidateCommitLogBootstrapperOptions(cOpts); err != nil {
 				return nil, err
 			}
@@ -176,12 +257,14 @@ func (bsc BootstrapConfiguration) New(
 				return nil, err
 			}
 		case peers.PeersBootstrapperName:
+			pCfg := bsc.peersConfig()
 			pOpts := peers.NewOptions().
 				SetResultOptions(rsOpts).
 				SetAdminClient(adminClient).
 				SetPersistManager(opts.PersistManager()).
 				SetDatabaseBlockRetrieverManager(opts.DatabaseBlockRetrieverManager()).
-				SetRuntimeOptionsManager(opts.RuntimeOptionsManager())
+				SetRuntimeOptionsManager(opts.RuntimeOptionsManager()).
+				SetVerifyOnly(pCfg.VerifyOnly)
 			if err := validator.ValidatePeersBootstrapperOptions(pOpts); err != nil {
 				return nil, err
 			}
@@ -198,7 +281,22 @@ func (bsc BootstrapConfiguration) New(
 			}
 			bs = uninitialized.NewUninitializedTopologyBootstrapperProvider(uOpts, bs)
 		default:
-			return nil, fmt.Errorf("unknown bootstrapper: %s", bsc.Bootstrappers[i])
+			name := bsc.Bootstrappers[i]
+			registered, ok := bootstrapper.RegisteredBootstrapperByName(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown bootstrapper: %s", name)
+			}
+			rOpts := bootstrapper.RegisteredBootstrapperOptions{
+				ResultOptions:     rsOpts,
+				FilesystemOptions: fsOpts,
+				PersistManager:    opts.PersistManager(),
+				InstrumentOptions: opts.InstrumentOptions(),
+				Config:            bsc.Custom[name],
+			}
+			bs, err = registered.NewProviderFn(rOpts, bs)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -208,6 +306,13 @@ func (bsc BootstrapConfiguration) New(
 	if bsc.CacheSeriesMetadata != nil {
 		providerOpts = providerOpts.SetCacheSeriesMetadata(*bsc.CacheSeriesMetadata)
 	}
+	if bsc.Checkpoint {
+		providerOpts = providerOpts.SetCheckpointer(
+			bootstrap.NewFileSystemCheckpointer(fsOpts))
+	}
+	if bsc.PrioritizeRecent {
+		providerOpts = providerOpts.SetPrioritizeRecent(true)
+	}
 	return bootstrap.NewProcessProvider(bs, providerOpts, rsOpts)
 }
 
@@ -225,6 +330,13 @@ func (bsc BootstrapConfiguration) commitlogConfig() BootstrapCommitlogConfigurat
 	return newDefaultBootstrapCommitlogConfiguration()
 }
 
+func (bsc BootstrapConfiguration) peersConfig() BootstrapPeersConfiguration {
+	if cfg := bsc.Peers; cfg != nil {
+		return *cfg
+	}
+	return BootstrapPeersConfiguration{}
+}
+
 type bootstrapConfigurationValidator struct {
 }
 
@@ -269,9 +381,22 @@ func (v bootstrapConfigurationValidator) ValidateBootstrappersOrder(names []stri
 
 	validated := make(map[string]struct{})
 	for _, name := range names {
-		precedingAllowed, ok := precedingBootstrappersAllowedByBootstrapper[name]
-		if !ok {
-			return fmt.Errorf("unknown bootstrapper: %v", name)
+		if _, ok := validated[name]; ok {
+			return fmt.Errorf("bootstrapper %s cannot appear more than once", name)
+		}
+
+		precedingAllowed, builtin := precedingBootstrappersAllowedByBootstrapper[name]
+		if !builtin {
+			registered, ok := bootstrapper.RegisteredBootstrapperByName(name)
+			if !ok {
+				return fmt.Errorf("unknown bootstrapper: %v", name)
+			}
+			if registered.PrecedingAllowed == nil {
+				// Any bootstrapper may precede the registered bootstrapper.
+				validated[name] = struct{}{}
+				continue
+			}
+			precedingAllowed = registered.PrecedingAllowed
 		}
 
 		allowed := make(map[string]struct{})
@@ -280,10 +405,13 @@ func (v bootstrapConfigurationValidator) ValidateBootstrappersOrder(names []stri
 		}
 
 		for existing := range validated {
-			if _, ok := allowed[existing]; !ok {
-				return fmt.Errorf("bootstrapper %s cannot appear after %s: ",
-					name, existing)
+			// NB: Registered bootstrappers may precede any builtin bootstrapper.
+			_, existingBuiltin := precedingBootstrappersAllowedByBootstrapper[existing]
+			if _, ok := allowed[existing]; ok || (builtin && !existingBuiltin) {
+				continue
 			}
+			return fmt.Errorf("bootstrapper %s cannot appear after %s: ",
+				name, existing)
 		}
 
 		validated[name] = struct{}{}
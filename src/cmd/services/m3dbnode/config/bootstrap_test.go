@@ -22,13 +22,19 @@ package config
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/commitlog"
 	bfs "github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/fs"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/peers"
+	"github.com/m3db/m3/src/dbnode/topology"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -38,9 +44,38 @@ var (
 	commitLogBs = commitlog.CommitLogBootstrapperName
 	noOpAllBs   = bootstrapper.NoOpAllBootstrapperName
 	noOpNoneBs  = bootstrapper.NoOpNoneBootstrapperName
+
+	// Registered bootstrappers that any bootstrapper and only the filesystem
+	// bootstrapper may precede.
+	customBs        = "test-custom"
+	customAfterFsBs = "test-custom-after-fs"
+
+	registerTestBootstrappersOnce sync.Once
 )
 
+func registerTestBootstrappers(t *testing.T) {
+	registerTestBootstrappersOnce.Do(func() {
+		newProviderFn := func(
+			_ bootstrapper.RegisteredBootstrapperOptions,
+			next bootstrap.BootstrapperProvider,
+		) (bootstrap.BootstrapperProvider, error) {
+			return next, nil
+		}
+		require.NoError(t, bootstrapper.RegisterBootstrapper(bootstrapper.RegisteredBootstrapper{
+			Name:          customBs,
+			NewProviderFn: newProviderFn,
+		}))
+		require.NoError(t, bootstrapper.RegisterBootstrapper(bootstrapper.RegisteredBootstrapper{
+			Name:             customAfterFsBs,
+			PrecedingAllowed: []string{fsBs},
+			NewProviderFn:    newProviderFn,
+		}))
+	})
+}
+
 func TestValidatorValidateBootstrappersOrder(t *testing.T) {
+	registerTestBootstrappers(t)
+
 	tests := []struct {
 		valid         bool
 		bootstrappers []string
@@ -64,6 +99,15 @@ func TestValidatorValidateBootstrappersOrder(t *testing.T) {
 		{false, []string{commitLogBs, commitLogBs, noOpNoneBs}},
 		// Do not allow unknown bootstrappers
 		{false, []string{"foo"}},
+		// Allow registered bootstrappers
+		{true, []string{customBs}},
+		{true, []string{fsBs, customBs, commitLogBs, peersBs, noOpNoneBs}},
+		{true, []string{fsBs, customAfterFsBs, noOpNoneBs}},
+		// Do not allow registered bootstrappers after disallowed ones
+		{false, []string{commitLogBs, customAfterFsBs}},
+		{false, []string{customBs, customAfterFsBs}},
+		// Do not allow a registered bootstrapper twice
+		{false, []string{customBs, customBs}},
 	}
 
 	for _, tt := range tests {
@@ -79,3 +123,46 @@ func TestValidatorValidateBootstrappersOrder(t *testing.T) {
 		})
 	}
 }
+
+func TestBootstrapConfigurationNewRegisteredBootstrapper(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		name = "test-custom-new"
+		cfg  = BootstrapConfiguration{
+			Bootstrappers: []string{name, noOpAllBs},
+			Custom:        map[string]interface{}{name: "bucket"},
+		}
+		calls int
+	)
+	require.NoError(t, bootstrapper.RegisterBootstrapper(bootstrapper.RegisteredBootstrapper{
+		Name: name,
+		NewProviderFn: func(
+			opts bootstrapper.RegisteredBootstrapperOptions,
+			next bootstrap.BootstrapperProvider,
+		) (bootstrap.BootstrapperProvider, error) {
+			calls++
+			require.Equal(t, "bucket", opts.Config)
+			require.NotNil(t, opts.ResultOptions)
+			require.NotNil(t, opts.FilesystemOptions)
+			require.Equal(t, noOpAllBs, next.String())
+			return next, nil
+		},
+	}))
+
+	validator := NewMockBootstrapConfigurationValidator(ctrl)
+	validator.EXPECT().ValidateBootstrappersOrder(cfg.Bootstrappers).Return(nil)
+	_, err := cfg.New(validator, storage.DefaultTestOptions(),
+		topology.NewMockMapProvider(ctrl), topology.NewMockHost(ctrl),
+		client.NewMockAdminClient(ctrl))
+	require.NoError(t, err)
+	require.Equal(t, 1, calls)
+
+	cfg.Bootstrappers = []string{"test-custom-unknown"}
+	validator.EXPECT().ValidateBootstrappersOrder(cfg.Bootstrappers).Return(nil)
+	_, err = cfg.New(validator, storage.DefaultTestOptions(),
+		topology.NewMockMapProvider(ctrl), topology.NewMockHost(ctrl),
+		client.NewMockAdminClient(ctrl))
+	require.Error(t, err)
+}
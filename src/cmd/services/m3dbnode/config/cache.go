@@ -23,9 +23,10 @@ package config
 import "github.com/m3db/m3/src/dbnode/storage/series"
 
 var (
-	defaultPostingsListCacheSize   = 2 << 17 // 262,144
-	defaultPostingsListCacheRegexp = true
-	defaultPostingsListCacheTerms  = true
+	defaultPostingsListCacheSize              = 2 << 17 // 262,144
+	defaultPostingsListCacheRegexp            = true
+	defaultPostingsListCacheTerms             = true
+	defaultPostingsListCacheCompactedSegments = true
 )
 
 // CacheConfigurations is the cache configurations.
@@ -72,9 +73,10 @@ type LRUSeriesCachePolicyConfiguration struct {
 
 // PostingsListCacheConfiguration is the postings list cache configuration.
 type PostingsListCacheConfiguration struct {
-	Size        *int  `yaml:"size"`
-	CacheRegexp *bool `yaml:"cacheRegexp"`
-	CacheTerms  *bool `yaml:"cacheTerms"`
+	Size                   *int  `yaml:"size"`
+	CacheRegexp            *bool `yaml:"cacheRegexp"`
+	CacheTerms             *bool `yaml:"cacheTerms"`
+	CacheCompactedSegments *bool `yaml:"cacheCompactedSegments"`
 }
 
 // SizeOrDefault returns the provided size or the default value is none is
@@ -106,3 +108,13 @@ func (p *PostingsListCacheConfiguration) CacheTermsOrDefault() bool {
 
 	return *p.CacheTerms
 }
+
+// CacheCompactedSegmentsOrDefault returns the provided cache compacted
+// segments configuration value or the default value is none is provided.
+func (p *PostingsListCacheConfiguration) CacheCompactedSegmentsOrDefault() bool {
+	if p.CacheCompactedSegments == nil {
+		return defaultPostingsListCacheCompactedSegments
+	}
+
+	return *p.CacheCompactedSegments
+}
@@ -32,8 +32,12 @@ import (
 	coordinatorcfg "github.com/m3db/m3/src/cmd/services/m3query/config"
 	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/dbnode/environment"
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/dbnode/storage/index/compaction"
 	"github.com/m3db/m3/src/dbnode/storage/series"
+	"github.com/m3db/m3/src/dbnode/workload"
 	"github.com/m3db/m3/src/x/config/hostid"
+	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 	xlog "github.com/m3db/m3/src/x/log"
 	"github.com/m3db/m3/src/x/opentracing"
@@ -148,6 +152,67 @@ type DBConfiguration struct {
 
 	// Tracing configures opentracing. If not provided, tracing is disabled.
 	Tracing *opentracing.TracingConfiguration `yaml:"tracing"`
+
+	// WriteForwarding configures forwarding of single and batch writes to the
+	// peer replicas before they are acknowledged. If not provided, writes are
+	// not forwarded.
+	WriteForwarding *WriteForwardingConfiguration `yaml:"writeForwarding"`
+
+	// DecodeWorkerPool configures a worker pool shared by queries to decode
+	// series blocks. If not provided, blocks are decoded on the request goroutine.
+	DecodeWorkerPool *DecodeWorkerPoolConfiguration `yaml:"decodeWorkerPool"`
+
+	// SyntheticWorkload configures synthetic load generated against test
+	// namespaces from within the node for soak testing. If not provided, no
+	// load is generated.
+	SyntheticWorkload *SyntheticWorkloadConfiguration `yaml:"syntheticWorkload"`
+
+	// MemoryPressure configures the heap watermarks at which cached blocks are
+	// evicted and ticks are expedited. If not provided, memory pressure is not
+	// monitored.
+	MemoryPressure *MemoryPressureConfiguration `yaml:"memoryPressure"`
+
+	// DurabilityProbe configures the prober that periodically writes canary
+	// series and verifies they become durable, are readable and are flushed.
+	// If not provided, no canaries are written.
+	DurabilityProbe *DurabilityProbeConfiguration `yaml:"durabilityProbe"`
+
+	// HotSeries configures the tracking of the series of each shard with the
+	// highest write and read rates. If not provided, hot series are not
+	// tracked.
+	HotSeries *HotSeriesConfiguration `yaml:"hotSeries"`
+
+	// Cardinality configures the estimation of the number of distinct series
+	// of the data and index blocks of each shard. If not provided,
+	// cardinalities are not estimated.
+	Cardinality *CardinalityConfiguration `yaml:"cardinality"`
+
+	// Overload configures the thresholds of the load signals at which the
+	// node is overloaded and starts rejecting requests. If not provided, the
+	// node is only overloaded when its commit log queue is close to capacity.
+	Overload *OverloadConfiguration `yaml:"overload"`
+
+	// ColdFlush configures how often namespaces are cold flushed and how
+	// many of their shards are cold flushed concurrently. If not provided,
+	// namespaces are cold flushed on every flush one shard at a time.
+	ColdFlush *ColdFlushConfiguration `yaml:"coldFlush"`
+
+	// NamespaceIngestLimits are the initial per namespace limits on the rate
+	// of writes admitted to each namespace, writes exceeding the limits are
+	// rejected. If not provided, no limits are enforced.
+	NamespaceIngestLimits *NamespaceIngestLimitsPolicy `yaml:"namespaceIngestLimits"`
+
+	// WriteBlackoutWindows are the initial time windows during which the
+	// writes to specific namespaces are rejected or dropped, e.g. for
+	// maintenance or migration cutovers.
+	WriteBlackoutWindows []WriteBlackoutWindowPolicy `yaml:"writeBlackoutWindows"`
+
+	// ShutdownDrainTimeout is how long the database is drained for on
+	// shutdown: new writes are rejected, the data written is snapshotted
+	// unless no namespace has snapshots enabled and in-flight queries are
+	// waited on before the database is closed. If not provided, the database
+	// is closed without draining.
+	ShutdownDrainTimeout *time.Duration `yaml:"shutdownDrainTimeout"`
 }
 
 // InitDefaultsAndValidate initializes all default values and validates the Configuration.
@@ -199,6 +264,90 @@ type IndexConfiguration struct {
 	// block boundaries by eagerly writing the series to the next block
 	// preemptively.
 	ForwardIndexThreshold float64 `yaml:"forwardIndexThreshold" validate:"min=0.0,max=1.0"`
+
+	// RegexpCacheMaxBytes is the max estimated memory used by the cache of
+	// compiled regexps of index queries, which saves recompiling the identical
+	// regexps issued repeatedly by templated dashboards. Zero disables the cache.
+	RegexpCacheMaxBytes int64 `yaml:"regexpCacheMaxBytes" validate:"min=0"`
+
+	// Compaction configures the compactions of the index segments, which
+	// trade the CPU used for indexing against the number of segments queried.
+	Compaction *IndexCompactionConfiguration `yaml:"compaction"`
+
+	// ColdBlockAge is the age, past their end, after which the flushed index
+	// blocks evict their segments from memory and load them back from disk
+	// when queried. Zero keeps every index block in memory.
+	ColdBlockAge time.Duration `yaml:"coldBlockAge" validate:"min=0"`
+
+	// QuerySegmentsConcurrently enables searching the segments of an index
+	// block concurrently, using the query workers left idle by the blocks
+	// queried, to reduce the latency of queries over few blocks.
+	QuerySegmentsConcurrently bool `yaml:"querySegmentsConcurrently"`
+
+	// SnapshotEnabled enables snapshotting the index blocks not yet flushed
+	// so that bootstrapping loads them instead of indexing the whole commit
+	// log again.
+	SnapshotEnabled bool `yaml:"snapshotEnabled"`
+
+	// FieldCardinalityTopN if set computes the cardinality of each field of
+	// the index segments as they are built along with the top N values of
+	// each field with the most series, to report the cardinality of index
+	// blocks per field.
+	FieldCardinalityTopN int `yaml:"fieldCardinalityTopN"`
+}
+
+// IndexCompactionConfiguration is the configuration of the compactions of the
+// index segments of namespaces.
+type IndexCompactionConfiguration struct {
+	// ForegroundLevels are the levels of sizes, in documents, of the segments
+	// compacted together as they are written, if empty the default levels
+	// apply.
+	ForegroundLevels []IndexCompactionLevelConfiguration `yaml:"foregroundLevels"`
+
+	// BackgroundLevels are the levels of sizes, in documents, of the segments
+	// compacted together in the background, if empty the default levels apply.
+	BackgroundLevels []IndexCompactionLevelConfiguration `yaml:"backgroundLevels"`
+
+	// MaxConcurrentBackgroundCompactions is the max number of index blocks of
+	// a namespace background compacted concurrently, if zero it is unlimited.
+	MaxConcurrentBackgroundCompactions int `yaml:"maxConcurrentBackgroundCompactions" validate:"min=0"`
+}
+
+// IndexCompactionLevelConfiguration is a level of sizes of segments that are
+// compacted together.
+type IndexCompactionLevelConfiguration struct {
+	MinSizeInclusive int64 `yaml:"minSizeInclusive" validate:"min=0"`
+	MaxSizeExclusive int64 `yaml:"maxSizeExclusive" validate:"min=0"`
+}
+
+// ForegroundPlannerOptions returns the foreground compaction planner options
+// with the configured levels, if any, replacing those of the defaults.
+func (c IndexCompactionConfiguration) ForegroundPlannerOptions(
+	defaults compaction.PlannerOptions,
+) compaction.PlannerOptions {
+	return indexCompactionPlannerOptions(c.ForegroundLevels, defaults)
+}
+
+// BackgroundPlannerOptions returns the background compaction planner options
+// with the configured levels, if any, replacing those of the defaults.
+func (c IndexCompactionConfiguration) BackgroundPlannerOptions(
+	defaults compaction.PlannerOptions,
+) compaction.PlannerOptions {
+	return indexCompactionPlannerOptions(c.BackgroundLevels, defaults)
+}
+
+func indexCompactionPlannerOptions(
+	levels []IndexCompactionLevelConfiguration,
+	opts compaction.PlannerOptions,
+) compaction.PlannerOptions {
+	if len(levels) == 0 {
+		return opts
+	}
+	opts.Levels = make([]compaction.Level, 0, len(levels))
+	for _, level := range levels {
+		opts.Levels = append(opts.Levels, compaction.Level(level))
+	}
+	return opts
 }
 
 // TransformConfiguration contains configuration options that can transform
@@ -235,6 +384,37 @@ type TickConfiguration struct {
 
 	// Tick minimum interval controls the minimum tick interval for the node.
 	MinimumInterval time.Duration `yaml:"minimumInterval"`
+
+	// Tick buffer merge budget bounds the time each shard tick spends merging
+	// series buffers, merges not completed within it continue next tick.
+	// Zero disables the budget, when unset the runtime default is used.
+	BufferMergeBudget *time.Duration `yaml:"bufferMergeBudget"`
+
+	// Tick pacing spreads each tick across a target duration with the sleeps
+	// between shards lengthened while over a CPU budget, if unset ticks run
+	// through the shards at full speed.
+	Pacing *TickPacingConfiguration `yaml:"pacing"`
+}
+
+// TickPacingConfiguration is the configuration of the pacing of ticks.
+type TickPacingConfiguration struct {
+	// TargetDuration is the duration a tick is spread across, it takes
+	// precedence over the block size fraction.
+	TargetDuration time.Duration `yaml:"targetDuration"`
+
+	// BlockSizeFraction is the fraction of the smallest namespace block size
+	// a tick is spread across when no target duration is set.
+	BlockSizeFraction float64 `yaml:"blockSizeFraction" validate:"min=0,max=1"`
+
+	// CPUBudget is the fraction of the available CPU ticking can use before
+	// the sleeps between shards are lengthened, if zero the sleeps are not
+	// adjusted by the CPU usage.
+	CPUBudget float64 `yaml:"cpuBudget" validate:"min=0,max=1"`
+}
+
+// RuntimeOptions returns the tick pacing configuration as runtime options.
+func (c TickPacingConfiguration) RuntimeOptions() runtime.TickPacingOptions {
+	return runtime.TickPacingOptions(c)
 }
 
 // BlockRetrievePolicy is the block retrieve policy.
@@ -242,6 +422,11 @@ type BlockRetrievePolicy struct {
 	// FetchConcurrency is the concurrency to fetch blocks from disk. For
 	// spinning disks it is highly recommended to set this value to 1.
 	FetchConcurrency int `yaml:"fetchConcurrency" validate:"min=0"`
+
+	// IndexEntryCacheSize is the number of index entries cached per fileset
+	// volume so that repeated fetches of hot series skip the index lookup,
+	// zero disables the cache.
+	IndexEntryCacheSize int `yaml:"indexEntryCacheSize" validate:"min=0"`
 }
 
 // CommitLogPolicy is the commit log policy.
@@ -270,6 +455,14 @@ type CommitLogPolicy struct {
 	// enough for almost all workloads assuming a reasonable batch size is used.
 	QueueChannel *CommitLogQueuePolicy `yaml:"queueChannel"`
 
+	// NamespaceWriteLimits are the initial per namespace limits on the rate of
+	// writes admitted to the commit log, these can be updated at runtime.
+	NamespaceWriteLimits *CommitLogNamespaceWriteLimitsPolicy `yaml:"namespaceWriteLimits"`
+
+	// Fsync is the initial policy for fsyncing the commit log in addition to
+	// the periodic flushes, this can be updated at runtime.
+	Fsync *CommitLogFsyncPolicy `yaml:"fsync"`
+
 	// Deprecated. Left in struct to keep old YAMLs parseable.
 	// TODO(V1): remove
 	DeprecatedBlockSize *time.Duration `yaml:"blockSize"`
@@ -294,6 +487,124 @@ type CommitLogQueuePolicy struct {
 	Size int `yaml:"size" validate:"nonzero"`
 }
 
+// CommitLogWriteLimitPolicy is a commit log write rate limit, zero values
+// disable the respective limit.
+type CommitLogWriteLimitPolicy struct {
+	// The max number of writes admitted per second.
+	OpsPerSecond int64 `yaml:"opsPerSecond"`
+
+	// The max number of bytes admitted per second.
+	BytesPerSecond int64 `yaml:"bytesPerSecond"`
+}
+
+// CommitLogNamespaceWriteLimitsPolicy is the per namespace commit log write
+// rate limits policy.
+type CommitLogNamespaceWriteLimitsPolicy struct {
+	// The limit applied to each namespace without an override.
+	Default CommitLogWriteLimitPolicy `yaml:"default"`
+
+	// The limits for specific namespaces keyed by namespace ID.
+	Namespaces map[string]CommitLogWriteLimitPolicy `yaml:"namespaces"`
+
+	// The max time a write exceeding its namespace limit waits for
+	// capacity before being rejected, zero rejects immediately.
+	MaxQueueWait time.Duration `yaml:"maxQueueWait"`
+}
+
+// RuntimeLimits returns the commit log write limits as runtime options.
+func (p CommitLogNamespaceWriteLimitsPolicy) RuntimeLimits() runtime.CommitLogNamespaceWriteLimits {
+	limits := runtime.CommitLogNamespaceWriteLimits{
+		Default:      runtime.CommitLogWriteLimit(p.Default),
+		MaxQueueWait: p.MaxQueueWait,
+	}
+	if len(p.Namespaces) > 0 {
+		limits.Overrides = make(map[string]runtime.CommitLogWriteLimit, len(p.Namespaces))
+		for ns, limit := range p.Namespaces {
+			limits.Overrides[ns] = runtime.CommitLogWriteLimit(limit)
+		}
+	}
+	return limits
+}
+
+// NamespaceIngestLimitPolicy is a namespace ingest rate limit, zero values
+// disable the respective limit.
+type NamespaceIngestLimitPolicy struct {
+	// The max number of datapoints admitted per second.
+	DatapointsPerSecond int64 `yaml:"datapointsPerSecond"`
+
+	// The max number of bytes admitted per second.
+	BytesPerSecond int64 `yaml:"bytesPerSecond"`
+}
+
+// NamespaceIngestLimitsPolicy is the per namespace ingest rate limits policy.
+type NamespaceIngestLimitsPolicy struct {
+	// The limit applied to each namespace without an override.
+	Default NamespaceIngestLimitPolicy `yaml:"default"`
+
+	// The limits for specific namespaces keyed by namespace ID.
+	Namespaces map[string]NamespaceIngestLimitPolicy `yaml:"namespaces"`
+}
+
+// RuntimeLimits returns the namespace ingest limits as runtime options.
+func (p NamespaceIngestLimitsPolicy) RuntimeLimits() runtime.NamespaceIngestLimits {
+	limits := runtime.NamespaceIngestLimits{
+		Default: runtime.NamespaceIngestLimit(p.Default),
+	}
+	if len(p.Namespaces) > 0 {
+		limits.Overrides = make(map[string]runtime.NamespaceIngestLimit, len(p.Namespaces))
+		for ns, limit := range p.Namespaces {
+			limits.Overrides[ns] = runtime.NamespaceIngestLimit(limit)
+		}
+	}
+	return limits
+}
+
+// WriteBlackoutWindowPolicy is a time window during which the writes to a
+// namespace are rejected or dropped.
+type WriteBlackoutWindowPolicy struct {
+	// The ID of the namespace.
+	Namespace string `yaml:"namespace" validate:"nonzero"`
+
+	// The inclusive start of the window.
+	Start time.Time `yaml:"start"`
+
+	// The exclusive end of the window.
+	End time.Time `yaml:"end"`
+
+	// What is done with the writes during the window, one of reject or drop.
+	Action runtime.WriteBlackoutAction `yaml:"action"`
+}
+
+// RuntimeWriteBlackoutWindows returns the write blackout windows as runtime
+// options.
+func RuntimeWriteBlackoutWindows(
+	policies []WriteBlackoutWindowPolicy,
+) runtime.WriteBlackoutWindows {
+	windows := make(runtime.WriteBlackoutWindows, 0, len(policies))
+	for _, policy := range policies {
+		windows = append(windows, runtime.WriteBlackoutWindow(policy))
+	}
+	return windows
+}
+
+// CommitLogFsyncPolicy is the commit log fsync policy.
+type CommitLogFsyncPolicy struct {
+	// The fsync strategy, one of default, every_write, every_bytes or
+	// every_interval.
+	Strategy runtime.CommitLogFsyncStrategy `yaml:"strategy"`
+
+	// The number of bytes written between fsyncs with the every_bytes strategy.
+	Bytes int64 `yaml:"bytes"`
+
+	// The min interval between fsyncs with the every_interval strategy.
+	Interval time.Duration `yaml:"interval"`
+}
+
+// RuntimePolicy returns the commit log fsync policy as a runtime option.
+func (p CommitLogFsyncPolicy) RuntimePolicy() runtime.CommitLogFsyncPolicy {
+	return runtime.CommitLogFsyncPolicy(p)
+}
+
 // RepairPolicy is the repair policy.
 type RepairPolicy struct {
 	// Enabled or disabled.
@@ -321,6 +632,206 @@ type HashingConfiguration struct {
 	Seed uint32 `yaml:"seed"`
 }
 
+// WriteForwardingConfiguration is the configuration for forwarding writes
+// received by a node to the peer replicas that own the same shard, which
+// allows clients that cannot fan out writes themselves to still receive
+// a synchronous quorum acknowledgement.
+type WriteForwardingConfiguration struct {
+	// RequiredAcks is the number of peer replicas (excluding the node that
+	// received the write) that must acknowledge a write, the write is always
+	// forwarded to every peer replica.
+	RequiredAcks int `yaml:"requiredAcks" validate:"min=1"`
+
+	// Timeout is the maximum time to wait for the required peer acks.
+	Timeout time.Duration `yaml:"timeout" validate:"nonzero"`
+}
+
+// DecodeWorkerPoolConfiguration is the configuration for the worker pool
+// shared by queries to decode the blocks of the series they match.
+type DecodeWorkerPoolConfiguration struct {
+	// Size is the number of decode workers, if zero the pool is sized to the
+	// number of CPUs the process may be scheduled on.
+	Size int `yaml:"size" validate:"min=0"`
+
+	// PerQueryConcurrency is the maximum number of series a single query may
+	// decode concurrently, if zero the default is used.
+	PerQueryConcurrency int `yaml:"perQueryConcurrency" validate:"min=0"`
+}
+
+// SyntheticWorkloadConfiguration is the configuration for the generator of
+// synthetic load against test namespaces.
+type SyntheticWorkloadConfiguration struct {
+	// TickInterval is the interval at which each workload issues the
+	// operations accrued since the previous tick, if zero the default is used.
+	TickInterval time.Duration `yaml:"tickInterval" validate:"min=0"`
+
+	// Namespaces are the workloads to generate, one per namespace.
+	Namespaces []SyntheticNamespaceWorkloadConfiguration `yaml:"namespaces" validate:"nonzero"`
+}
+
+// SyntheticNamespaceWorkloadConfiguration is the configuration of the
+// synthetic load generated against a single namespace.
+type SyntheticNamespaceWorkloadConfiguration struct {
+	// Namespace is the namespace the load is generated against.
+	Namespace string `yaml:"namespace" validate:"nonzero"`
+
+	// Cardinality is the number of distinct series written at any time.
+	Cardinality int `yaml:"cardinality" validate:"min=1"`
+
+	// ChurnPercent is the fraction of the series replaced with new series
+	// every churn interval.
+	ChurnPercent float64 `yaml:"churnPercent" validate:"min=0.0,max=1.0"`
+
+	// ChurnInterval is the interval at which series are replaced.
+	ChurnInterval time.Duration `yaml:"churnInterval"`
+
+	// WritesPerSecond is the number of datapoints written per second.
+	WritesPerSecond int `yaml:"writesPerSecond" validate:"min=0"`
+
+	// ReadsPerSecond is the number of series read per second.
+	ReadsPerSecond int `yaml:"readsPerSecond" validate:"min=0"`
+
+	// ReadRange is the range of time before now that each read covers.
+	ReadRange time.Duration `yaml:"readRange"`
+}
+
+// NewOptions returns the workload generator options for the configuration.
+func (c SyntheticWorkloadConfiguration) NewOptions(
+	iOpts instrument.Options,
+) workload.Options {
+	workloads := make([]workload.NamespaceWorkload, 0, len(c.Namespaces))
+	for _, ns := range c.Namespaces {
+		workloads = append(workloads, workload.NamespaceWorkload{
+			Namespace:       ident.StringID(ns.Namespace),
+			Cardinality:     ns.Cardinality,
+			ChurnPercent:    ns.ChurnPercent,
+			ChurnInterval:   ns.ChurnInterval,
+			WritesPerSecond: ns.WritesPerSecond,
+			ReadsPerSecond:  ns.ReadsPerSecond,
+			ReadRange:       ns.ReadRange,
+		})
+	}
+	opts := workload.NewOptions().
+		SetInstrumentOptions(iOpts).
+		SetWorkloads(workloads)
+	if c.TickInterval > 0 {
+		opts = opts.SetTickInterval(c.TickInterval)
+	}
+	return opts
+}
+
+// MemoryPressureConfiguration is the configuration for the monitor that
+// relieves memory pressure as the heap in use crosses each watermark, a zero
+// watermark disables its level.
+type MemoryPressureConfiguration struct {
+	// CheckInterval is how often the heap in use is checked, if zero the
+	// default is used.
+	CheckInterval time.Duration `yaml:"checkInterval"`
+
+	// ElevatedHeapBytes is the heap in use at which a small fraction of the
+	// least recently read cached blocks are evicted.
+	ElevatedHeapBytes uint64 `yaml:"elevatedHeapBytes"`
+
+	// HighHeapBytes is the heap in use at which a larger fraction of cached
+	// blocks are evicted and ticks and flushes are expedited.
+	HighHeapBytes uint64 `yaml:"highHeapBytes"`
+
+	// CriticalHeapBytes is the heap in use at which all cached blocks are
+	// evicted and ticks and flushes are expedited.
+	CriticalHeapBytes uint64 `yaml:"criticalHeapBytes"`
+}
+
+// DurabilityProbeConfiguration is the configuration for the prober that
+// writes a canary series to each owned shard of every owned namespace and
+// reports the latency of each stage of the write pipeline verified for them.
+type DurabilityProbeConfiguration struct {
+	// Interval is how often canaries are written.
+	Interval time.Duration `yaml:"interval"`
+
+	// Timeout is how long canary writes are waited on to become durable in
+	// the commit log, if zero the default is used.
+	Timeout time.Duration `yaml:"timeout"`
+
+	// FlushTimeout is how long after their block can be flushed canaries must
+	// be found in a flushed fileset, if zero the default is used.
+	FlushTimeout time.Duration `yaml:"flushTimeout"`
+
+	// SeriesIDPrefix is the prefix of the IDs of the canary series, if empty
+	// the default is used.
+	SeriesIDPrefix string `yaml:"seriesIDPrefix"`
+}
+
+// HotSeriesConfiguration is the configuration for tracking the series of
+// each shard with the highest write and read rates.
+type HotSeriesConfiguration struct {
+	// Capacity is the number of series of each shard tracked for each of
+	// writes and reads.
+	Capacity int `yaml:"capacity" validate:"min=0"`
+
+	// SampleEvery records one of every sampleEvery writes and reads of each
+	// shard, if zero all of them are recorded.
+	SampleEvery int `yaml:"sampleEvery" validate:"min=0"`
+
+	// HalfLife is how long it takes the rate of a series to halve once it
+	// stops being written or read, if zero the default is used.
+	HalfLife time.Duration `yaml:"halfLife"`
+}
+
+// CardinalityConfiguration is the configuration for estimating the number of
+// distinct series of the data and index blocks of each shard.
+type CardinalityConfiguration struct {
+	// Precision is the precision of the HyperLogLog sketches estimating the
+	// cardinalities, each sketch uses 2^precision bytes and has a relative
+	// standard error of 1.04/sqrt(2^precision).
+	Precision int `yaml:"precision" validate:"min=4,max=16"`
+}
+
+// OverloadConfiguration is the configuration of the thresholds of the load
+// signals at which the node is overloaded, the node is overloaded as soon as
+// any of the enabled thresholds is reached.
+type OverloadConfiguration struct {
+	// CommitLogQueueFactor is the fraction of the capacity of the commit log
+	// queue at which the node is overloaded, if not provided the default is
+	// used and zero disables the threshold.
+	CommitLogQueueFactor *float64 `yaml:"commitLogQueueFactor" validate:"min=0.0,max=1.0"`
+
+	// MaxFlushLag is how long ago the last successful flush and snapshot
+	// started at which the node is overloaded, if zero the threshold is
+	// disabled.
+	MaxFlushLag time.Duration `yaml:"maxFlushLag"`
+
+	// MemoryPressure is the memory pressure level, one of elevated, high or
+	// critical, at which the node is overloaded, if empty the threshold is
+	// disabled.
+	MemoryPressure string `yaml:"memoryPressure"`
+
+	// MaxInFlightQueries is the number of in-flight index queries at which
+	// the node is overloaded, if zero the threshold is disabled.
+	MaxInFlightQueries int64 `yaml:"maxInFlightQueries" validate:"min=0"`
+}
+
+// ColdFlushConfiguration is the configuration of the scheduling of the cold
+// flushes of namespaces.
+type ColdFlushConfiguration struct {
+	// Interval is the min time between the cold flushes of a namespace, if
+	// zero namespaces are cold flushed on every flush.
+	Interval time.Duration `yaml:"interval"`
+
+	// Concurrency is the number of shards of a namespace cold flushed
+	// concurrently, if zero shards are cold flushed one at a time.
+	Concurrency int `yaml:"concurrency" validate:"min=0"`
+
+	// ColdWritesThreshold is the number of cold writes pending a cold flush
+	// in a namespace at which it is cold flushed before its interval
+	// elapses, if zero only the interval applies.
+	ColdWritesThreshold int64 `yaml:"coldWritesThreshold" validate:"min=0"`
+}
+
+// RuntimeOptions returns the cold flush configuration as runtime options.
+func (c ColdFlushConfiguration) RuntimeOptions() runtime.ColdFlushOptions {
+	return runtime.ColdFlushOptions(c)
+}
+
 // ProtoConfiguration is the configuration for running with ProtoDataMode enabled.
 type ProtoConfiguration struct {
 	// Enabled specifies whether proto is enabled.
@@ -25,11 +25,13 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/dbnode/environment"
 	"github.com/m3db/m3/src/dbnode/storage"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/commitlog"
+	"github.com/m3db/m3/src/dbnode/storage/index/compaction"
 	"github.com/m3db/m3/src/dbnode/topology"
 	xconfig "github.com/m3db/m3/src/x/config"
 	"github.com/m3db/m3/src/x/instrument"
@@ -338,6 +340,12 @@ func TestConfiguration(t *testing.T) {
     maxQueryIDsConcurrency: 0
     forwardIndexProbability: 0
     forwardIndexThreshold: 0
+    regexpCacheMaxBytes: 0
+    compaction: null
+    coldBlockAge: 0s
+    querySegmentsConcurrently: false
+    snapshotEnabled: false
+    fieldCardinalityTopN: 0
   transforms:
     truncateBy: 0
     forceValue: null
@@ -408,7 +416,15 @@ func TestConfiguration(t *testing.T) {
       numProcessorsPerCPU: 0.42
     commitlog:
       returnUnfulfilledForCorruptCommitLogFiles: false
+      snapshotOnly: false
+      encodingConcurrency: 0
+      readConcurrency: 0
+      readBufferBytes: 0
+    peers: null
+    custom: {}
     cacheSeriesMetadata: null
+    checkpoint: false
+    prioritizeRecent: false
   blockRetrieve: null
   cache:
     series: null
@@ -416,19 +432,33 @@ func TestConfiguration(t *testing.T) {
       size: 100
       cacheRegexp: false
       cacheTerms: false
+      cacheCompactedSegments: null
   fs:
     filePathPrefix: /var/lib/m3db
+    namespaceFilePathPrefixes: {}
     writeBufferSize: 65536
     dataReadBufferSize: 65536
     infoReadBufferSize: 128
     seekReadBufferSize: 4096
     throughputLimitMbps: 100
     throughputCheckEvery: 128
+    flushConcurrency: null
     newFileMode: null
     newDirectoryMode: null
     mmap: null
     force_index_summaries_mmap_memory: true
     force_bloom_filter_mmap_memory: true
+    share_index_summaries: null
+    seeker_data_file_mmap: null
+    sequential_read_ahead: null
+    drop_pages_after_write: null
+    data_compression: null
+    zstd_compression_level: null
+    objectStore: null
+    encryption: null
+    seekerManager: null
+    scrubInterval: null
+    snapshotRetention: null
   commitlog:
     flushMaxBytes: 524288
     flushEvery: 1s
@@ -436,6 +466,8 @@ func TestConfiguration(t *testing.T) {
       calculationType: fixed
       size: 2097152
     queueChannel: null
+    namespaceWriteLimits: null
+    fsync: null
     blockSize: null
   repair:
     enabled: false
@@ -655,6 +687,18 @@ func TestConfiguration(t *testing.T) {
       headers: null
       baggage_restrictions: null
       throttler: null
+  writeForwarding: null
+  decodeWorkerPool: null
+  syntheticWorkload: null
+  memoryPressure: null
+  durabilityProbe: null
+  hotSeries: null
+  cardinality: null
+  overload: null
+  coldFlush: null
+  namespaceIngestLimits: null
+  writeBlackoutWindows: []
+  shutdownDrainTimeout: null
 coordinator: null
 `
 
@@ -915,6 +959,66 @@ db:
 		}}, cfg.DB.Proto.SchemaRegistry)
 }
 
+func TestSyntheticWorkloadConfig(t *testing.T) {
+	testConf := `
+db:
+  metrics:
+      samplingRate: 1.0
+
+  listenAddress: 0.0.0.0:9000
+  clusterListenAddress: 0.0.0.0:9001
+  httpNodeListenAddress: 0.0.0.0:9002
+  httpClusterListenAddress: 0.0.0.0:9003
+
+  bootstrap:
+      bootstrappers:
+          - noop-all
+
+  commitlog:
+      flushMaxBytes: 524288
+      flushEvery: 1s
+      queue:
+          size: 2097152
+
+  syntheticWorkload:
+      namespaces:
+          - namespace: soak
+            cardinality: 1000
+            churnPercent: 0.1
+            churnInterval: 1m
+            writesPerSecond: 500
+            readsPerSecond: 10
+            readRange: 1h
+`
+	fd, err := ioutil.TempFile("", "config_workload.yaml")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, fd.Close())
+		assert.NoError(t, os.Remove(fd.Name()))
+	}()
+
+	_, err = fd.Write([]byte(testConf))
+	require.NoError(t, err)
+
+	// Verify is valid
+	var cfg Configuration
+	err = xconfig.LoadFile(&cfg, fd.Name(), xconfig.Options{})
+	require.NoError(t, err)
+	require.NotNil(t, cfg.DB.SyntheticWorkload)
+
+	opts := cfg.DB.SyntheticWorkload.NewOptions(instrument.NewOptions())
+	require.NoError(t, opts.Validate())
+	require.Len(t, opts.Workloads(), 1)
+	w := opts.Workloads()[0]
+	require.Equal(t, "soak", w.Namespace.String())
+	require.Equal(t, 1000, w.Cardinality)
+	require.Equal(t, 0.1, w.ChurnPercent)
+	require.Equal(t, time.Minute, w.ChurnInterval)
+	require.Equal(t, 500, w.WritesPerSecond)
+	require.Equal(t, 10, w.ReadsPerSecond)
+	require.Equal(t, time.Hour, w.ReadRange)
+}
+
 func TestBootstrapCommitLogConfig(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -985,3 +1089,23 @@ db:
 		storage.DefaultTestOptions(), mapProvider, origin, adminClient)
 	require.NoError(t, err)
 }
+
+func TestIndexCompactionConfigurationPlannerOptions(t *testing.T) {
+	defaults := compaction.DefaultOptions
+	cfg := IndexCompactionConfiguration{
+		BackgroundLevels: []IndexCompactionLevelConfiguration{
+			{MinSizeInclusive: 0, MaxSizeExclusive: 1 << 16},
+			{MinSizeInclusive: 1 << 16, MaxSizeExclusive: 1 << 20},
+		},
+	}
+
+	require.Equal(t, defaults, cfg.ForegroundPlannerOptions(defaults))
+
+	opts := cfg.BackgroundPlannerOptions(defaults)
+	require.Equal(t, []compaction.Level{
+		{MinSizeInclusive: 0, MaxSizeExclusive: 1 << 16},
+		{MinSizeInclusive: 1 << 16, MaxSizeExclusive: 1 << 20},
+	}, opts.Levels)
+	require.Equal(t, defaults.OrderBy, opts.OrderBy)
+	require.NoError(t, opts.Validate())
+}
@@ -23,6 +23,10 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
 )
 
 const (
@@ -38,8 +42,15 @@ const (
 	defaultSeekReadBufferSize            = 4096
 	defaultThroughputLimitMbps           = 100.0
 	defaultThroughputCheckEvery          = 128
+	defaultFlushConcurrency              = 1
 	defaultForceIndexSummariesMmapMemory = false
 	defaultForceBloomFilterMmapMemory    = false
+	defaultShareIndexSummaries           = false
+	defaultSeekerDataFileMmap            = false
+	defaultSequentialReadAhead           = false
+	defaultDropPagesAfterWrite           = false
+	defaultDataCompression               = persist.NoDataCompression
+	defaultZstdCompressionLevel          = 3
 )
 
 // DefaultMmapConfiguration is the default mmap configuration.
@@ -57,6 +68,11 @@ type FilesystemConfiguration struct {
 	// File path prefix for reading/writing TSDB files
 	FilePathPrefix *string `yaml:"filePathPrefix"`
 
+	// NamespaceFilePathPrefixes routes the filesets of namespaces to other
+	// file path prefixes, keyed by namespace ID, to place them on other disks.
+	// Commit logs and snapshot metadata always remain beneath FilePathPrefix.
+	NamespaceFilePathPrefixes map[string]string `yaml:"namespaceFilePathPrefixes"`
+
 	// Write buffer size
 	WriteBufferSize *int `yaml:"writeBufferSize"`
 
@@ -75,6 +91,10 @@ type FilesystemConfiguration struct {
 	// Disk flush throughput check interval
 	ThroughputCheckEvery *int `yaml:"throughputCheckEvery"`
 
+	// Number of shards flushed concurrently, the throughput limit applies
+	// to each file path prefix on its own
+	FlushConcurrency *int `yaml:"flushConcurrency"`
+
 	// NewFileMode is the new file permissions mode to use when
 	// creating files - specify as three digits, e.g. 666.
 	NewFileMode *string `yaml:"newFileMode"`
@@ -93,6 +113,141 @@ type FilesystemConfiguration struct {
 	// ForceBloomFilterMmapMemory forces the mmap that stores the index lookup bytes
 	// to be an anonymous region in memory as opposed to a file-based mmap.
 	ForceBloomFilterMmapMemory *bool `yaml:"force_bloom_filter_mmap_memory"`
+
+	// ShareIndexSummaries shares the index summaries lookups between all the seekers
+	// of a namespace instead of each opened fileset volume holding its own.
+	ShareIndexSummaries *bool `yaml:"share_index_summaries"`
+
+	// SeekerDataFileMmap mmaps the data file of each opened seeker and serves
+	// reads from the mapping instead of issuing a pread per lookup.
+	SeekerDataFileMmap *bool `yaml:"seeker_data_file_mmap"`
+
+	// SequentialReadAhead advises the kernel that filesets read in full, such
+	// as during bootstrap and repair, are read sequentially.
+	SequentialReadAhead *bool `yaml:"sequential_read_ahead"`
+
+	// DropPagesAfterWrite syncs written filesets and evicts their pages from
+	// the page cache so that flushes do not evict pages that are being read.
+	DropPagesAfterWrite *bool `yaml:"drop_pages_after_write"`
+
+	// DataCompression is the compression applied to each data segment of newly
+	// written filesets, existing filesets are read with the compression recorded
+	// in their info file regardless of this setting.
+	DataCompression *persist.DataCompressionType `yaml:"data_compression"`
+
+	// ZstdCompressionLevel is the zstd level used when data compression is zstd.
+	ZstdCompressionLevel *int `yaml:"zstd_compression_level"`
+
+	// ObjectStore is the configuration for the object store that flushed filesets
+	// are uploaded to, if set local disk acts as a cache of the object store.
+	ObjectStore *ObjectStoreConfiguration `yaml:"objectStore"`
+
+	// Encryption is the configuration for encrypting the data, index and summaries
+	// files of newly written filesets at rest, if not set they are not encrypted.
+	Encryption *EncryptionConfiguration `yaml:"encryption"`
+
+	// SeekerManager is the configuration for the loop that opens and closes
+	// seekers in the background, if not set the defaults are used.
+	SeekerManager *SeekerManagerConfiguration `yaml:"seekerManager"`
+
+	// ScrubInterval is the interval at which all flushed data filesets are
+	// verified in the background to detect corruption, if not set they are
+	// not scrubbed.
+	ScrubInterval *time.Duration `yaml:"scrubInterval"`
+
+	// SnapshotRetention is the configuration for how many and how old snapshots
+	// are retained by cleanup, if not set only the most recent one is retained.
+	SnapshotRetention *SnapshotRetentionConfiguration `yaml:"snapshotRetention"`
+}
+
+// ObjectStoreConfiguration is the configuration for the object store that
+// flushed filesets are persisted to in addition to local disk, which is a
+// bucket of a service implementing the S3 API such as S3, GCS or MinIO.
+type ObjectStoreConfiguration struct {
+	// Endpoint is the URL of the service, such as https://s3.us-east-1.amazonaws.com
+	// or https://storage.googleapis.com.
+	Endpoint string `yaml:"endpoint" validate:"nonzero"`
+
+	// Region is the region of the bucket.
+	Region string `yaml:"region" validate:"nonzero"`
+
+	// Bucket is the bucket the filesets are kept in.
+	Bucket string `yaml:"bucket" validate:"nonzero"`
+
+	// KeyPrefix is prepended to the keys of the filesets so that a bucket can
+	// be shared by multiple clusters.
+	KeyPrefix string `yaml:"keyPrefix"`
+
+	// AccessKeyID is the ID of the access key, if not set it is read from the
+	// AWS_ACCESS_KEY_ID environment variable.
+	AccessKeyID string `yaml:"accessKeyID"`
+
+	// SecretAccessKey is the secret of the access key, if not set it is read
+	// from the AWS_SECRET_ACCESS_KEY environment variable.
+	SecretAccessKey string `yaml:"secretAccessKey"`
+
+	// LocalRetention is how long the filesets of a block are kept on local
+	// disk, the filesets of older blocks are evicted from local disk once
+	// uploaded and downloaded again when read. Zero never evicts filesets.
+	LocalRetention time.Duration `yaml:"localRetention" validate:"min=0"`
+}
+
+// NewObjectStore returns the object store for the configuration.
+func (c ObjectStoreConfiguration) NewObjectStore() (fs.ObjectStore, error) {
+	opts := fs.S3ObjectStoreOptions{
+		Endpoint:        c.Endpoint,
+		Region:          c.Region,
+		Bucket:          c.Bucket,
+		KeyPrefix:       c.KeyPrefix,
+		AccessKeyID:     c.AccessKeyID,
+		SecretAccessKey: c.SecretAccessKey,
+	}
+	if opts.AccessKeyID == "" && opts.SecretAccessKey == "" {
+		opts.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		opts.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		opts.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	return fs.NewS3ObjectStore(opts)
+}
+
+// EncryptionConfiguration is the configuration for encrypting filesets at rest,
+// each volume is encrypted with its own data key which is wrapped with the key
+// encryption key read from the key file.
+type EncryptionConfiguration struct {
+	// KeyID identifies the key encryption key, it is recorded with each wrapped
+	// data key so that data keys wrapped with a different key can be detected.
+	KeyID string `yaml:"keyID" validate:"nonzero"`
+
+	// KeyFile is the path to the file containing the hex encoded AES key
+	// encryption key, typically provisioned by a key management system.
+	KeyFile string `yaml:"keyFile" validate:"nonzero"`
+}
+
+// SeekerManagerConfiguration is the configuration for the background loop of the
+// seeker manager which opens seekers for accessed shards and closes expired ones.
+type SeekerManagerConfiguration struct {
+	// CloseInterval is the interval between iterations of the loop.
+	CloseInterval time.Duration `yaml:"closeInterval" validate:"nonzero"`
+
+	// MaxOpenedPerIteration is the max number of seekers opened per iteration,
+	// zero means unlimited.
+	MaxOpenedPerIteration int `yaml:"maxOpenedPerIteration" validate:"min=0"`
+
+	// MaxClosedPerIteration is the max number of block starts whose seekers are
+	// closed per iteration, zero means unlimited.
+	MaxClosedPerIteration int `yaml:"maxClosedPerIteration" validate:"min=0"`
+}
+
+// SnapshotRetentionConfiguration is the configuration for the retention of
+// snapshots, the most recent snapshot is always retained since it is required
+// to recover from a node failure.
+type SnapshotRetentionConfiguration struct {
+	// Count is the number of most recent snapshots retained.
+	Count int `yaml:"count" validate:"min=1"`
+
+	// Period is the age after which snapshots other than the most recent are
+	// deleted even if within the count, zero means no age limit.
+	Period time.Duration `yaml:"period" validate:"min=0"`
 }
 
 // Validate validates the Filesystem configuration. We use this method to validate
@@ -134,6 +289,12 @@ func (f FilesystemConfiguration) Validate() error {
 			*f.ThroughputCheckEvery)
 	}
 
+	if f.FlushConcurrency != nil && *f.FlushConcurrency < 1 {
+		return fmt.Errorf(
+			"fs flushConcurrency is set to: %d, but must be at least 1",
+			*f.FlushConcurrency)
+	}
+
 	return nil
 }
 
@@ -207,6 +368,16 @@ func (f FilesystemConfiguration) ThroughputCheckEveryOrDefault() int {
 	return defaultThroughputCheckEvery
 }
 
+// FlushConcurrencyOrDefault returns the configured flush concurrency if configured, or a
+// default value otherwise.
+func (f FilesystemConfiguration) FlushConcurrencyOrDefault() int {
+	if f.FlushConcurrency != nil {
+		return *f.FlushConcurrency
+	}
+
+	return defaultFlushConcurrency
+}
+
 // MmapConfigurationOrDefault returns the configured mmap configuration if configured, or a
 // default value otherwise.
 func (f FilesystemConfiguration) MmapConfigurationOrDefault() MmapConfiguration {
@@ -236,6 +407,66 @@ func (f FilesystemConfiguration) ForceBloomFilterMmapMemoryOrDefault() bool {
 	return defaultForceBloomFilterMmapMemory
 }
 
+// ShareIndexSummariesOrDefault returns the configured value for sharing the index
+// summaries lookups between seekers if configured, or a default value otherwise.
+func (f FilesystemConfiguration) ShareIndexSummariesOrDefault() bool {
+	if f.ShareIndexSummaries != nil {
+		return *f.ShareIndexSummaries
+	}
+
+	return defaultShareIndexSummaries
+}
+
+// SeekerDataFileMmapOrDefault returns the configured value for mmap'ing the data
+// file of seekers if configured, or a default value otherwise.
+func (f FilesystemConfiguration) SeekerDataFileMmapOrDefault() bool {
+	if f.SeekerDataFileMmap != nil {
+		return *f.SeekerDataFileMmap
+	}
+
+	return defaultSeekerDataFileMmap
+}
+
+// SequentialReadAheadOrDefault returns the configured value for advising
+// sequential reads of filesets if configured, or a default value otherwise.
+func (f FilesystemConfiguration) SequentialReadAheadOrDefault() bool {
+	if f.SequentialReadAhead != nil {
+		return *f.SequentialReadAhead
+	}
+
+	return defaultSequentialReadAhead
+}
+
+// DropPagesAfterWriteOrDefault returns the configured value for evicting the
+// pages of written filesets if configured, or a default value otherwise.
+func (f FilesystemConfiguration) DropPagesAfterWriteOrDefault() bool {
+	if f.DropPagesAfterWrite != nil {
+		return *f.DropPagesAfterWrite
+	}
+
+	return defaultDropPagesAfterWrite
+}
+
+// DataCompressionOrDefault returns the configured data compression type if
+// configured, or a default value otherwise.
+func (f FilesystemConfiguration) DataCompressionOrDefault() persist.DataCompressionType {
+	if f.DataCompression != nil {
+		return *f.DataCompression
+	}
+
+	return defaultDataCompression
+}
+
+// ZstdCompressionLevelOrDefault returns the configured zstd compression level
+// if configured, or a default value otherwise.
+func (f FilesystemConfiguration) ZstdCompressionLevelOrDefault() int {
+	if f.ZstdCompressionLevel != nil {
+		return *f.ZstdCompressionLevel
+	}
+
+	return defaultZstdCompressionLevel
+}
+
 // MmapConfiguration is the mmap configuration.
 type MmapConfiguration struct {
 	// HugeTLB is the huge pages configuration which will only take affect
@@ -54,6 +54,36 @@ func IsBadRequestError(err error) bool {
 	return false
 }
 
+// IsResourceExhaustedError determines if the error is a resource exhausted
+// error, raised when a node rejects a request to apply backpressure.
+func IsResourceExhaustedError(err error) bool {
+	for err != nil {
+		if e, ok := err.(*rpc.Error); ok && tterrors.IsResourceExhaustedError(e) {
+			return true
+		}
+		if e := xerrors.GetInnerResourceExhaustedError(err); e != nil {
+			return true
+		}
+		err = xerrors.InnerError(err)
+	}
+	return false
+}
+
+// IsBlockedError determines if the error is a blocked error, raised when a
+// node rejects a request because an operator blocked it.
+func IsBlockedError(err error) bool {
+	for err != nil {
+		if e, ok := err.(*rpc.Error); ok && tterrors.IsBlockedError(e) {
+			return true
+		}
+		if e := xerrors.GetInnerBlockedError(err); e != nil {
+			return true
+		}
+		err = xerrors.InnerError(err)
+	}
+	return false
+}
+
 // IsConsistencyResultError determines if the error is a consistency result error.
 func IsConsistencyResultError(err error) bool {
 	_, ok := err.(consistencyResultErr)
@@ -138,7 +168,8 @@ func newConsistencyResultError(
 	errs []error,
 ) consistencyResultError {
 	// NB(r): if any errors are bad request errors, encapsulate that error
-	// to ensure the error itself is wholly classified as a bad request error
+	// to ensure the error itself is wholly classified as a bad request error,
+	// otherwise prefer resource exhausted errors so callers can back off.
 	var topLevelErr error
 	for i := 0; i < len(errs); i++ {
 		if topLevelErr == nil {
@@ -149,6 +180,9 @@ func newConsistencyResultError(
 			topLevelErr = errs[i]
 			break
 		}
+		if IsResourceExhaustedError(errs[i]) && !IsResourceExhaustedError(topLevelErr) {
+			topLevelErr = errs[i]
+		}
 	}
 	return consistencyResultErr{
 		level:       level,
@@ -26,6 +26,7 @@ import (
 	"testing"
 
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	tterrors "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/errors"
 	"github.com/m3db/m3/src/dbnode/topology"
 	xerrors "github.com/m3db/m3/src/x/errors"
 
@@ -52,3 +53,33 @@ func TestConsistencyResultError(t *testing.T) {
 	assert.Equal(t, 1, NumSuccess(err))
 	assert.Equal(t, 2, NumError(err))
 }
+
+func TestConsistencyResultErrorResourceExhausted(t *testing.T) {
+	resourceExhaustedErr := tterrors.NewResourceExhaustedError(
+		fmt.Errorf("namespace write limit exceeded"))
+
+	level := topology.ConsistencyLevelMajority
+	errs := []error{fmt.Errorf("another error"), resourceExhaustedErr}
+
+	err := error(newConsistencyResultError(level, 3, 3, errs))
+
+	assert.Equal(t, resourceExhaustedErr, xerrors.InnerError(err))
+	assert.True(t, IsResourceExhaustedError(err))
+	assert.False(t, IsBadRequestError(err))
+	assert.True(t, IsInternalServerError(err))
+}
+
+func TestConsistencyResultErrorBlocked(t *testing.T) {
+	blockedErr := tterrors.NewBlockedError(
+		fmt.Errorf("namespace reads are blocked"))
+
+	level := topology.ReadConsistencyLevelMajority
+	errs := []error{fmt.Errorf("another error"), blockedErr}
+
+	err := error(newConsistencyResultError(level, 3, 3, errs))
+
+	assert.Equal(t, blockedErr, xerrors.InnerError(err))
+	assert.True(t, IsBlockedError(err))
+	assert.True(t, IsBadRequestError(err))
+	assert.False(t, IsResourceExhaustedError(err))
+}
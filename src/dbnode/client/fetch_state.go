@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/storage/durability"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
 	"github.com/m3db/m3/src/x/serialize"
@@ -219,6 +220,12 @@ func (f *fetchState) asEncodingSeriesIterators(pools fetchTaggedPools, descr nam
 	return f.tagResultAccumulator.AsEncodingSeriesIterators(limit, pools, descr)
 }
 
+func (f *fetchState) durabilityRanges() durability.Ranges {
+	f.Lock()
+	defer f.Unlock()
+	return f.tagResultAccumulator.DurabilityRanges()
+}
+
 func (f *fetchState) asAggregatedTagsIterator(pools fetchTaggedPools) (AggregatedTagsIterator, bool, error) {
 	f.Lock()
 	defer f.Unlock()
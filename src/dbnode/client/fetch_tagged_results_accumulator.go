@@ -29,6 +29,8 @@ import (
 	"github.com/m3db/m3/src/cluster/shard"
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/convert"
+	"github.com/m3db/m3/src/dbnode/storage/durability"
 	"github.com/m3db/m3/src/dbnode/topology"
 	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
@@ -64,6 +66,12 @@ type fetchTaggedResultAccumulator struct {
 	aggResponses   aggregateResults
 	exhaustive     bool
 
+	// durabilityRanges is the lowest durability of the data of the fetch
+	// responses at each point in time, responses without durability ranges
+	// leave the durability of the result unknown.
+	durabilityRanges   durability.Ranges
+	durabilityReported bool
+
 	startTime        time.Time
 	endTime          time.Time
 	majority         int
@@ -86,6 +94,9 @@ func (accum *fetchTaggedResultAccumulator) AddFetchTaggedResponse(
 	opts fetchTaggedResultAccumulatorOpts,
 	resultErr error,
 ) (bool, error) {
+	if opts.response != nil && resultErr == nil {
+		resultErr = accum.addDurabilityRanges(opts.response.DurabilityRanges)
+	}
 	if opts.response != nil && resultErr == nil {
 		accum.exhaustive = accum.exhaustive && opts.response.Exhaustive
 		for _, elem := range opts.response.Elements {
@@ -96,6 +107,28 @@ func (accum *fetchTaggedResultAccumulator) AddFetchTaggedResponse(
 	return accum.accumulatedResult(opts.host, resultErr)
 }
 
+func (accum *fetchTaggedResultAccumulator) addDurabilityRanges(
+	rpcRanges []*rpc.DataDurabilityRange,
+) error {
+	ranges, err := convert.FromRPCDataDurabilityRanges(rpcRanges)
+	if err != nil {
+		return err
+	}
+	if !accum.durabilityReported {
+		accum.durabilityReported = true
+		accum.durabilityRanges = ranges
+		return nil
+	}
+	accum.durabilityRanges = accum.durabilityRanges.Min(ranges)
+	return nil
+}
+
+// DurabilityRanges returns the durability of the data of the fetch responses
+// accumulated.
+func (accum *fetchTaggedResultAccumulator) DurabilityRanges() durability.Ranges {
+	return accum.durabilityRanges
+}
+
 func (accum *fetchTaggedResultAccumulator) AddAggregateResponse(
 	opts aggregateResultAccumulatorOpts,
 	resultErr error,
@@ -212,6 +245,7 @@ func (accum *fetchTaggedResultAccumulator) Clear() {
 	accum.startTime, accum.endTime = time.Time{}, time.Time{}
 	accum.topoMap = nil
 	accum.exhaustive = true
+	accum.durabilityRanges, accum.durabilityReported = nil, false
 }
 
 func (accum *fetchTaggedResultAccumulator) Reset(
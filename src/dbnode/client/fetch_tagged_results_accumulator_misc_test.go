@@ -29,9 +29,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/m3db/m3/src/cluster/shard"
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/convert"
+	"github.com/m3db/m3/src/dbnode/storage/durability"
+	"github.com/m3db/m3/src/dbnode/topology"
+	tu "github.com/m3db/m3/src/dbnode/topology/testutil"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
 	"github.com/m3db/m3/src/x/serialize"
 	"github.com/m3db/m3/src/x/ident"
@@ -60,6 +65,90 @@ func TestFetchTaggedResultsAccumulatorClearResetsState(t *testing.T) {
 	require.NoError(t, resultsIter.Err())
 }
 
+func TestFetchTaggedResultsAccumulatorDurabilityRanges(t *testing.T) {
+	topoMap := tu.MustNewTopologyMap(2, map[string][]shard.Shard{
+		"testhost0": tu.ShardsRange(0, 29, shard.Available),
+		"testhost1": tu.ShardsRange(0, 29, shard.Available),
+	})
+
+	var (
+		start    = time.Now().Truncate(time.Hour)
+		mid      = start.Add(time.Hour)
+		end      = start.Add(2 * time.Hour)
+		response = func(first, second durability.Level) *rpc.FetchTaggedResult_ {
+			return &rpc.FetchTaggedResult_{
+				Exhaustive: true,
+				DurabilityRanges: convert.ToRPCDataDurabilityRanges(durability.Ranges{
+					{Start: start, End: mid, Level: first},
+					{Start: mid, End: end, Level: second},
+				}),
+			}
+		}
+	)
+
+	// The result is only as durable as the least durable response.
+	accum := testFetchStateWorkflow{
+		t:       t,
+		topoMap: topoMap,
+		level:   topology.ReadConsistencyLevelAll,
+		steps: []testFetchStateWorklowStep{
+			{
+				hostname:          "testhost0",
+				fetchTaggedResult: response(durability.Flushed, durability.BufferOnly),
+			},
+			{
+				hostname:          "testhost1",
+				fetchTaggedResult: response(durability.Snapshot, durability.Snapshot),
+				expectedDone:      true,
+			},
+		},
+	}.run()
+	require.Equal(t, durability.Ranges{
+		{Start: start, End: mid, Level: durability.Snapshot},
+		{Start: mid, End: end, Level: durability.BufferOnly},
+	}, accum.DurabilityRanges())
+
+	// A response without durability leaves the durability unknown.
+	accum = testFetchStateWorkflow{
+		t:       t,
+		topoMap: topoMap,
+		level:   topology.ReadConsistencyLevelAll,
+		steps: []testFetchStateWorklowStep{
+			{
+				hostname:          "testhost0",
+				fetchTaggedResult: response(durability.Flushed, durability.Flushed),
+			},
+			{
+				hostname:          "testhost1",
+				fetchTaggedResult: &rpc.FetchTaggedResult_{Exhaustive: true},
+				expectedDone:      true,
+			},
+		},
+	}.run()
+	require.Empty(t, accum.DurabilityRanges())
+
+	// A response with an invalid durability is a failed response.
+	invalid := response(durability.Flushed, durability.Flushed)
+	invalid.DurabilityRanges[0].Durability = "durable"
+	testFetchStateWorkflow{
+		t:       t,
+		topoMap: topoMap,
+		level:   topology.ReadConsistencyLevelAll,
+		steps: []testFetchStateWorklowStep{
+			{
+				hostname:          "testhost0",
+				fetchTaggedResult: invalid,
+			},
+			{
+				hostname:          "testhost1",
+				fetchTaggedResult: response(durability.Flushed, durability.Flushed),
+				expectedDone:      true,
+				expectedErr:       true,
+			},
+		},
+	}.run()
+}
+
 func TestFetchTaggedShardConsistencyResultsInitializeLength(t *testing.T) {
 	var results fetchTaggedShardConsistencyResults
 	require.Len(t, results, 0)
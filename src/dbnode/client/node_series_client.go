@@ -0,0 +1,267 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/convert"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/x/checked"
+	xclose "github.com/m3db/m3/src/x/close"
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/pool"
+	"github.com/m3db/m3/src/x/serialize"
+
+	"github.com/uber/tchannel-go/thrift"
+)
+
+const (
+	nodeSeriesChannelName = "NodeSeriesClient"
+)
+
+var (
+	errNodeSeriesClientClosed = errors.New("node series client is closed")
+)
+
+type nodeSeriesClient struct {
+	opts       Options
+	client     rpc.TChanNode
+	closer     xclose.SimpleCloser
+	tagDecoder serialize.TagDecoderPool
+	closed     bool
+}
+
+// NewNodeSeriesClient returns a new client streaming series directly from
+// the dbnode listening at the given TChannel address.
+func NewNodeSeriesClient(address string, opts Options) (NodeSeriesClient, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	closer, client, err := newConn(nodeSeriesChannelName, address, opts)
+	if err != nil {
+		return nil, err
+	}
+	return newNodeSeriesClient(client, closer, opts), nil
+}
+
+func newNodeSeriesClient(
+	client rpc.TChanNode,
+	closer xclose.SimpleCloser,
+	opts Options,
+) *nodeSeriesClient {
+	// NB: At most a single batch of series is held by an iterator at a time.
+	tagDecoderPoolOpts := pool.NewObjectPoolOptions().
+		SetSize(opts.FetchBatchSize()).
+		SetInstrumentOptions(opts.InstrumentOptions().SetMetricsScope(
+			opts.InstrumentOptions().MetricsScope().SubScope("node-series-tag-decoder-pool"),
+		))
+	tagDecoder := serialize.NewTagDecoderPool(opts.TagDecoderOptions(), tagDecoderPoolOpts)
+	tagDecoder.Init()
+	return &nodeSeriesClient{
+		opts:       opts,
+		client:     client,
+		closer:     closer,
+		tagDecoder: tagDecoder,
+	}
+}
+
+func (c *nodeSeriesClient) FetchTagged(
+	ns ident.ID,
+	q index.Query,
+	opts index.QueryOptions,
+) (NodeSeriesIterator, error) {
+	if c.closed {
+		return nil, errNodeSeriesClientClosed
+	}
+
+	// Only resolve the IDs matching the query upfront, the data is fetched
+	// in batches as the iterator is consumed.
+	req, err := convert.ToRPCFetchTaggedRequest(ns, q, opts, false)
+	if err != nil {
+		return nil, err
+	}
+	tctx, _ := thrift.NewContext(c.opts.FetchRequestTimeout())
+	result, err := c.client.FetchTagged(tctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	rangeStart, err := convert.ToValue(opts.StartInclusive, rpc.TimeType_UNIX_NANOSECONDS)
+	if err != nil {
+		return nil, err
+	}
+	rangeEnd, err := convert.ToValue(opts.EndExclusive, rpc.TimeType_UNIX_NANOSECONDS)
+	if err != nil {
+		return nil, err
+	}
+
+	return &nodeSeriesIterator{
+		client:   c,
+		nsCtx:    namespace.NewContextFor(ns, c.opts.SchemaRegistry()),
+		opts:     opts,
+		elements: result.Elements,
+		request: rpc.FetchBatchRawRequest{
+			RangeStart:    rangeStart,
+			RangeEnd:      rangeEnd,
+			NameSpace:     req.NameSpace,
+			RangeTimeType: rpc.TimeType_UNIX_NANOSECONDS,
+		},
+	}, nil
+}
+
+func (c *nodeSeriesClient) Close() error {
+	if c.closed {
+		return errNodeSeriesClientClosed
+	}
+	c.closed = true
+	c.closer.Close()
+	return nil
+}
+
+type nodeSeriesIterator struct {
+	client   *nodeSeriesClient
+	nsCtx    namespace.Context
+	opts     index.QueryOptions
+	request  rpc.FetchBatchRawRequest
+	elements []*rpc.FetchTaggedIDResult_
+
+	// next is the index of the next element to fetch the data of.
+	next     int
+	batch    []encoding.SeriesIterator
+	batchIdx int
+	curr     encoding.SeriesIterator
+	err      error
+	done     bool
+}
+
+func (it *nodeSeriesIterator) Next() bool {
+	if it.curr != nil {
+		it.curr.Close()
+		it.curr = nil
+	}
+	if it.done || it.err != nil {
+		return false
+	}
+	if it.batchIdx >= len(it.batch) {
+		if it.next >= len(it.elements) {
+			it.done = true
+			return false
+		}
+		if err := it.fetchNextBatch(); err != nil {
+			it.err = err
+			return false
+		}
+	}
+	it.curr = it.batch[it.batchIdx]
+	it.batch[it.batchIdx] = nil
+	it.batchIdx++
+	return true
+}
+
+// fetchNextBatch fetches the data of the next batch of series, this is only
+// done once the previous batch has been consumed so that no more than a
+// single batch is ever held in memory.
+func (it *nodeSeriesIterator) fetchNextBatch() error {
+	end := it.next + it.client.opts.FetchBatchSize()
+	if end > len(it.elements) {
+		end = len(it.elements)
+	}
+	elements := it.elements[it.next:end]
+	it.next = end
+
+	it.request.Ids = it.request.Ids[:0]
+	for _, elem := range elements {
+		it.request.Ids = append(it.request.Ids, elem.ID)
+	}
+	tctx, _ := thrift.NewContext(it.client.opts.FetchRequestTimeout())
+	result, err := it.client.client.FetchBatchRaw(tctx, &it.request)
+	if err != nil {
+		return err
+	}
+	if len(result.Elements) != len(elements) {
+		return fmt.Errorf("fetched %d series from node, expected %d",
+			len(result.Elements), len(elements))
+	}
+
+	it.batch = it.batch[:0]
+	it.batchIdx = 0
+	for i, elem := range elements {
+		if err := result.Elements[i].Err; err != nil {
+			it.closeBatch()
+			return err
+		}
+		it.batch = append(it.batch, it.newSeriesIterator(elem, result.Elements[i]))
+	}
+	return nil
+}
+
+func (it *nodeSeriesIterator) newSeriesIterator(
+	elem *rpc.FetchTaggedIDResult_,
+	fetched *rpc.FetchRawResult_,
+) encoding.SeriesIterator {
+	slicesIter := newReaderSliceOfSlicesIterator(fetched.Segments, nil)
+	multiIter := encoding.NewMultiReaderIterator(it.client.opts.ReaderIteratorAllocate(), nil)
+	multiIter.ResetSliceOfSlices(slicesIter, it.nsCtx.Schema)
+
+	decoder := it.client.tagDecoder.Get()
+	decoder.Reset(checked.NewBytes(elem.EncodedTags, nil))
+
+	return encoding.NewSeriesIterator(encoding.SeriesIteratorOptions{
+		ID:             ident.BytesID(elem.ID),
+		Namespace:      ident.BytesID(it.request.NameSpace),
+		Tags:           decoder,
+		StartInclusive: it.opts.StartInclusive,
+		EndExclusive:   it.opts.EndExclusive,
+		Replicas:       []encoding.MultiReaderIterator{multiIter},
+	}, nil)
+}
+
+func (it *nodeSeriesIterator) closeBatch() {
+	for i := it.batchIdx; i < len(it.batch); i++ {
+		if it.batch[i] != nil {
+			it.batch[i].Close()
+		}
+	}
+	it.batch = it.batch[:0]
+	it.batchIdx = 0
+}
+
+func (it *nodeSeriesIterator) Current() encoding.SeriesIterator {
+	return it.curr
+}
+
+func (it *nodeSeriesIterator) Err() error {
+	return it.err
+}
+
+func (it *nodeSeriesIterator) Finalize() {
+	if it.curr != nil {
+		it.curr.Close()
+		it.curr = nil
+	}
+	it.closeBatch()
+	it.elements = nil
+	it.done = true
+}
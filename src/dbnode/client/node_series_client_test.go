@@ -0,0 +1,187 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/m3ninx/idx"
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/serialize"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/tchannel-go/thrift"
+)
+
+type testNodeSeriesCloser struct {
+	closed bool
+}
+
+func (c *testNodeSeriesCloser) Close() {
+	c.closed = true
+}
+
+func newTestNodeSeriesEncodedTags(t *testing.T, tags ident.Tags) []byte {
+	enc := serialize.NewTagEncoderPool(serialize.NewTagEncoderOptions(), nil)
+	enc.Init()
+	encoder := enc.Get()
+	require.NoError(t, encoder.Encode(ident.NewTagsIterator(tags)))
+	data, ok := encoder.Data()
+	require.True(t, ok)
+	return append([]byte(nil), data.Bytes()...)
+}
+
+func newTestNodeSeriesSegments(t *testing.T, start time.Time, value float64) []*rpc.Segments {
+	encoder := m3tsz.NewEncoder(start, nil, true, nil)
+	dp := ts.Datapoint{Timestamp: start, Value: value}
+	require.NoError(t, encoder.Encode(dp, xtime.Second, nil))
+	seg := encoder.Discard()
+	return []*rpc.Segments{&rpc.Segments{
+		Merged: &rpc.Segment{Head: bytesIfNotNil(seg.Head), Tail: bytesIfNotNil(seg.Tail)},
+	}}
+}
+
+func TestNodeSeriesClientFetchTagged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		opts   = newSessionTestOptions().SetFetchBatchSize(2)
+		node   = rpc.NewMockTChanNode(ctrl)
+		closer = &testNodeSeriesCloser{}
+		client = newNodeSeriesClient(node, closer, opts)
+		start  = time.Now().Truncate(time.Hour)
+		end    = start.Add(time.Hour)
+		ids    = []string{"foo", "bar", "baz"}
+	)
+
+	q, err := idx.NewRegexpQuery([]byte("city"), []byte("new.*"))
+	require.NoError(t, err)
+
+	var elements []*rpc.FetchTaggedIDResult_
+	for _, id := range ids {
+		elements = append(elements, &rpc.FetchTaggedIDResult_{
+			NameSpace: []byte("metrics"),
+			ID:        []byte(id),
+			EncodedTags: newTestNodeSeriesEncodedTags(t, ident.NewTags(
+				ident.StringTag("city", "new_york"),
+				ident.StringTag("id", id),
+			)),
+		})
+	}
+	node.EXPECT().
+		FetchTagged(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ thrift.Context, req *rpc.FetchTaggedRequest) (*rpc.FetchTaggedResult_, error) {
+			// The IDs are resolved without data, the data is fetched in batches.
+			require.False(t, req.FetchData)
+			return &rpc.FetchTaggedResult_{Elements: elements, Exhaustive: true}, nil
+		})
+
+	// Each batch is only fetched once the previous batch has been consumed.
+	var fetched [][]string
+	node.EXPECT().
+		FetchBatchRaw(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ thrift.Context, req *rpc.FetchBatchRawRequest) (*rpc.FetchBatchRawResult_, error) {
+			var (
+				batch  []string
+				result = &rpc.FetchBatchRawResult_{}
+			)
+			for _, id := range req.Ids {
+				batch = append(batch, string(id))
+				result.Elements = append(result.Elements, &rpc.FetchRawResult_{
+					Segments: newTestNodeSeriesSegments(t, start, float64(len(id))),
+				})
+			}
+			fetched = append(fetched, batch)
+			return result, nil
+		}).
+		Times(2)
+
+	iter, err := client.FetchTagged(ident.StringID("metrics"), index.Query{Query: q},
+		index.QueryOptions{StartInclusive: start, EndExclusive: end})
+	require.NoError(t, err)
+
+	var read []string
+	for iter.Next() {
+		series := iter.Current()
+		read = append(read, series.ID().String())
+		require.Len(t, fetched, (len(read)+1)/2)
+
+		require.True(t, series.Tags().Next())
+		require.Equal(t, "city", series.Tags().Current().Name.String())
+
+		require.True(t, series.Next())
+		dp, _, _ := series.Current()
+		require.True(t, dp.Timestamp.Equal(start))
+		require.Equal(t, float64(len(series.ID().String())), dp.Value)
+		require.False(t, series.Next())
+		require.NoError(t, series.Err())
+	}
+	require.NoError(t, iter.Err())
+	iter.Finalize()
+
+	require.Equal(t, ids, read)
+	require.Equal(t, [][]string{{"foo", "bar"}, {"baz"}}, fetched)
+
+	require.NoError(t, client.Close())
+	require.True(t, closer.closed)
+	_, err = client.FetchTagged(ident.StringID("metrics"), index.Query{Query: q},
+		index.QueryOptions{StartInclusive: start, EndExclusive: end})
+	require.Equal(t, errNodeSeriesClientClosed, err)
+}
+
+func TestNodeSeriesClientFetchTaggedBatchError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		opts   = newSessionTestOptions()
+		node   = rpc.NewMockTChanNode(ctrl)
+		client = newNodeSeriesClient(node, &testNodeSeriesCloser{}, opts)
+		start  = time.Now().Truncate(time.Hour)
+		end    = start.Add(time.Hour)
+		errRPC = errors.New("an error")
+	)
+
+	q, err := idx.NewRegexpQuery([]byte("city"), []byte("new.*"))
+	require.NoError(t, err)
+
+	node.EXPECT().
+		FetchTagged(gomock.Any(), gomock.Any()).
+		Return(&rpc.FetchTaggedResult_{Elements: []*rpc.FetchTaggedIDResult_{
+			{NameSpace: []byte("metrics"), ID: []byte("foo")},
+		}}, nil)
+	node.EXPECT().FetchBatchRaw(gomock.Any(), gomock.Any()).Return(nil, errRPC)
+
+	iter, err := client.FetchTagged(ident.StringID("metrics"), index.Query{Query: q},
+		index.QueryOptions{StartInclusive: start, EndExclusive: end})
+	require.NoError(t, err)
+	require.False(t, iter.Next())
+	require.Equal(t, errRPC, iter.Err())
+	iter.Finalize()
+}
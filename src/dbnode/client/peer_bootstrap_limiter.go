@@ -0,0 +1,187 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package client
+
+import (
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/runtime"
+	xsync "github.com/m3db/m3/src/x/sync"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	// peerBootstrapMaxIdle is how long the blocks streamed from a peer can
+	// stay below the throughput limit before the throughput is measured
+	// afresh, so that being idle does not allow a burst beyond the limit.
+	peerBootstrapMaxIdle = time.Second
+)
+
+// peerBootstrapLimiter throttles the fetches of blocks from peers when
+// bootstrapping, its limits are set by the runtime options.
+type peerBootstrapLimiter struct {
+	sync.RWMutex
+
+	nowFn   clock.NowFn
+	sleepFn func(time.Duration)
+	fetches xsync.ConcurrencyLimiter
+
+	bytesPerSecondPerPeer int64
+	peers                 map[string]*peerBootstrapThroughput
+
+	metrics peerBootstrapLimiterMetrics
+}
+
+// peerBootstrapThroughput tracks the throughput of the blocks fetched from
+// a single peer, concurrent fetches from the peer share its limit.
+type peerBootstrapThroughput struct {
+	sync.Mutex
+
+	start time.Time
+	bytes int64
+}
+
+type peerBootstrapLimiterMetrics struct {
+	fetchedBytes              tally.Counter
+	throttled                 tally.Counter
+	throttledLatency          tally.Timer
+	bytesPerSecondPerPeer     tally.Gauge
+	maxConcurrentBlockFetches tally.Gauge
+}
+
+func newPeerBootstrapLimiterMetrics(scope tally.Scope) peerBootstrapLimiterMetrics {
+	scope = scope.SubScope("stream-from-peers-limits")
+	return peerBootstrapLimiterMetrics{
+		fetchedBytes:              scope.Counter("fetched-bytes"),
+		throttled:                 scope.Counter("throttled"),
+		throttledLatency:          scope.Timer("throttled-latency"),
+		bytesPerSecondPerPeer:     scope.Gauge("bytes-per-second-per-peer"),
+		maxConcurrentBlockFetches: scope.Gauge("max-concurrent-block-fetches"),
+	}
+}
+
+func newPeerBootstrapLimiter(
+	nowFn clock.NowFn,
+	scope tally.Scope,
+) *peerBootstrapLimiter {
+	return &peerBootstrapLimiter{
+		nowFn:   nowFn,
+		sleepFn: time.Sleep,
+		fetches: xsync.NewConcurrencyLimiter(0),
+		peers:   make(map[string]*peerBootstrapThroughput),
+		metrics: newPeerBootstrapLimiterMetrics(scope),
+	}
+}
+
+func (l *peerBootstrapLimiter) setLimits(limits runtime.PeersBootstrapLimits) {
+	l.fetches.SetLimit(limits.MaxConcurrentBlockFetches)
+
+	l.Lock()
+	if l.bytesPerSecondPerPeer != limits.BytesPerSecondPerPeer {
+		// Measure the throughput afresh against the new limit.
+		l.bytesPerSecondPerPeer = limits.BytesPerSecondPerPeer
+		l.peers = make(map[string]*peerBootstrapThroughput)
+	}
+	l.Unlock()
+
+	l.metrics.bytesPerSecondPerPeer.Update(float64(limits.BytesPerSecondPerPeer))
+	l.metrics.maxConcurrentBlockFetches.Update(float64(limits.MaxConcurrentBlockFetches))
+}
+
+// acquireFetch waits until a fetch of blocks is allowed by the limit of
+// concurrent block fetches.
+func (l *peerBootstrapLimiter) acquireFetch() {
+	l.fetches.Acquire()
+}
+
+func (l *peerBootstrapLimiter) releaseFetch() {
+	l.fetches.Release()
+}
+
+// throttle accounts for the bytes fetched from a peer and sleeps for as
+// long as the throughput of the peer is above its limit.
+func (l *peerBootstrapLimiter) throttle(hostID string, bytes int64) {
+	l.metrics.fetchedBytes.Inc(bytes)
+
+	l.RLock()
+	limit := l.bytesPerSecondPerPeer
+	peer, ok := l.peers[hostID]
+	l.RUnlock()
+	if limit <= 0 {
+		return
+	}
+	if !ok {
+		l.Lock()
+		peer, ok = l.peers[hostID]
+		if !ok {
+			peer = &peerBootstrapThroughput{}
+			l.peers[hostID] = peer
+		}
+		l.Unlock()
+	}
+
+	now := l.nowFn()
+	peer.Lock()
+	if elapsed := now.Sub(peer.start); peer.start.IsZero() ||
+		elapsed-peerBootstrapThroughputDuration(peer.bytes, limit) > peerBootstrapMaxIdle {
+		peer.start = now
+		peer.bytes = 0
+	}
+	peer.bytes += bytes
+	wait := peerBootstrapThroughputDuration(peer.bytes, limit) - now.Sub(peer.start)
+	peer.Unlock()
+
+	if wait <= 0 {
+		return
+	}
+	l.metrics.throttled.Inc(1)
+	l.metrics.throttledLatency.Record(wait)
+	l.sleepFn(wait)
+}
+
+// peerBootstrapThroughputDuration returns the duration it takes to fetch
+// the bytes at the limit.
+func peerBootstrapThroughputDuration(bytes, bytesPerSecond int64) time.Duration {
+	return time.Duration(float64(time.Second) * float64(bytes) / float64(bytesPerSecond))
+}
+
+// fetchBlocksRawResultBytes returns the number of bytes of the segments of
+// the blocks of a fetch blocks raw result.
+func fetchBlocksRawResultBytes(result *rpc.FetchBlocksRawResult_) int64 {
+	var bytes int64
+	for _, elem := range result.Elements {
+		for _, block := range elem.Blocks {
+			if block.Segments == nil {
+				continue
+			}
+			if seg := block.Segments.Merged; seg != nil {
+				bytes += int64(len(seg.Head) + len(seg.Tail))
+			}
+			for _, seg := range block.Segments.Unmerged {
+				bytes += int64(len(seg.Head) + len(seg.Tail))
+			}
+		}
+	}
+	return bytes
+}
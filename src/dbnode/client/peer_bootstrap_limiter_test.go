@@ -0,0 +1,104 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/runtime"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestPeerBootstrapLimiterThrottle(t *testing.T) {
+	var (
+		now   = time.Now()
+		slept []time.Duration
+	)
+	l := newPeerBootstrapLimiter(func() time.Time { return now }, tally.NoopScope)
+	l.sleepFn = func(d time.Duration) {
+		slept = append(slept, d)
+		now = now.Add(d)
+	}
+
+	// Unlimited by default.
+	l.throttle("a", 1<<20)
+	require.Equal(t, 0, len(slept))
+
+	l.setLimits(runtime.PeersBootstrapLimits{BytesPerSecondPerPeer: 1000})
+
+	// The first fetch from a peer at twice the limit waits for two seconds.
+	l.throttle("a", 2000)
+	require.Equal(t, []time.Duration{2 * time.Second}, slept)
+
+	// Each peer has its own limit.
+	l.throttle("b", 500)
+	require.Equal(t, []time.Duration{2 * time.Second, 500 * time.Millisecond}, slept)
+
+	// Once idle for long enough the throughput is measured afresh.
+	now = now.Add(time.Minute)
+	l.throttle("a", 1000)
+	require.Equal(t, time.Second, slept[len(slept)-1])
+}
+
+func TestPeerBootstrapLimiterMaxConcurrentBlockFetches(t *testing.T) {
+	l := newPeerBootstrapLimiter(time.Now, tally.NoopScope)
+	l.setLimits(runtime.PeersBootstrapLimits{MaxConcurrentBlockFetches: 1})
+	l.acquireFetch()
+
+	acquired := make(chan struct{})
+	go func() {
+		l.acquireFetch()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		require.FailNow(t, "acquired beyond the limit")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	l.releaseFetch()
+	<-acquired
+	l.releaseFetch()
+}
+
+func TestFetchBlocksRawResultBytes(t *testing.T) {
+	result := &rpc.FetchBlocksRawResult_{
+		Elements: []*rpc.Blocks{
+			{Blocks: []*rpc.Block{
+				{Segments: &rpc.Segments{
+					Merged: &rpc.Segment{Head: []byte{1, 2}, Tail: []byte{3}},
+				}},
+				{Segments: &rpc.Segments{
+					Unmerged: []*rpc.Segment{
+						{Head: []byte{1}, Tail: []byte{2}},
+						{Head: []byte{3, 4}},
+					},
+				}},
+				{},
+			}},
+		},
+	}
+	require.Equal(t, int64(7), fetchBlocksRawResultBytes(result))
+}
@@ -153,6 +153,7 @@ type session struct {
 	streamBlocksBatchSize            int
 	streamBlocksMetadataBatchTimeout time.Duration
 	streamBlocksBatchTimeout         time.Duration
+	streamBlocksLimiter              *peerBootstrapLimiter
 	metrics                          sessionMetrics
 }
 
@@ -252,6 +253,8 @@ func newSession(opts Options) (clientSession, error) {
 			context: opts.ContextPool(),
 			id:      opts.IdentifierPool(),
 		},
+		streamBlocksLimiter: newPeerBootstrapLimiter(
+			opts.ClockOptions().NowFn(), scope),
 		metrics: newSessionMetrics(scope),
 	}
 	s.reattemptStreamBlocksFromPeersFn = s.streamBlocksReattemptFromPeers
@@ -336,6 +339,7 @@ func (s *session) SetRuntimeOptions(value runtime.Options) {
 	s.state.readLevel = value.ClientReadConsistencyLevel()
 	s.state.writeLevel = value.ClientWriteConsistencyLevel()
 	s.state.Unlock()
+	s.streamBlocksLimiter.setLimits(value.PeersBootstrapLimits())
 }
 
 func (s *session) ShardID(id ident.ID) (uint32, error) {
@@ -1249,6 +1253,9 @@ func (s *session) fetchTaggedAttempt(
 	// the fetchState Lock
 	fetchState.Unlock()
 	iters, exhaustive, err := fetchState.asEncodingSeriesIterators(s.pools, nsCtx.Schema)
+	if err == nil && opts.IncludeDurability {
+		opts.DurabilityTracker.Track(fetchState.durabilityRanges())
+	}
 
 	// must Unlock() before decRef'ing, as the latter releases the fetchState back into a
 	// pool if ref count == 0.
@@ -2822,6 +2829,10 @@ func (s *session) streamBlocksBatchFromPeer(
 		result       *rpc.FetchBlocksRawResult_
 		reqBlocksLen uint
 
+		// NB: Peers that do not support segment checksums ignore the option
+		// and return segments without checksums, which are not verified.
+		optionIncludeSegmentChecksums = true
+
 		nowFn              = opts.ClockOptions().NowFn()
 		ropts              = namespaceMetadata.Options().RetentionOptions()
 		retention          = ropts.RetentionPeriod()
@@ -2829,6 +2840,7 @@ func (s *session) streamBlocksBatchFromPeer(
 	)
 	req.NameSpace = namespaceMetadata.ID().Bytes()
 	req.Shard = int32(shard)
+	req.IncludeSegmentChecksums = &optionIncludeSegmentChecksums
 	req.Elements = make([]*rpc.FetchBlocksRawRequestElement, 0, len(batch))
 	for i := range batch {
 		blockStart := batch[i].block.start
@@ -2847,7 +2859,8 @@ func (s *session) streamBlocksBatchFromPeer(
 	}
 
 	// Attempt request
-	if err := retrier.Attempt(func() error {
+	s.streamBlocksLimiter.acquireFetch()
+	err := retrier.Attempt(func() error {
 		var attemptErr error
 		borrowErr := peer.BorrowConnection(func(client rpc.TChanNode) {
 			tctx, _ := thrift.NewContext(s.streamBlocksBatchTimeout)
@@ -2855,7 +2868,15 @@ func (s *session) streamBlocksBatchFromPeer(
 		})
 		err := xerrors.FirstError(borrowErr, attemptErr)
 		return err
-	}); err != nil {
+	})
+	if err == nil {
+		// NB: Throttle while still holding the fetch so that a fetch does
+		// not start until the peer is back within its limit.
+		s.streamBlocksLimiter.throttle(peer.Host().ID(),
+			fetchBlocksRawResultBytes(result))
+	}
+	s.streamBlocksLimiter.releaseFetch()
+	if err != nil {
 		blocksErr := fmt.Errorf(
 			"stream blocks request error: error=%s, peer=%s",
 			err.Error(), peer.Host().String(),
@@ -2995,6 +3016,32 @@ func (s *session) verifyFetchedBlock(block *rpc.Block) error {
 		}
 	}
 
+	if merged := block.Segments.Merged; merged != nil {
+		if err := verifyFetchedSegment(merged); err != nil {
+			return err
+		}
+	}
+	for _, s := range block.Segments.Unmerged {
+		if err := verifyFetchedSegment(s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func verifyFetchedSegment(segment *rpc.Segment) error {
+	if segment.Checksum == nil {
+		return nil
+	}
+
+	var (
+		expected = uint32(*segment.Checksum)
+		actual   = digest.NewDigest().Update(segment.Head).Update(segment.Tail).Sum32()
+	)
+	if actual != expected {
+		return fmt.Errorf("segment checksum is bad: expected=%d, actual=%d", expected, actual)
+	}
 	return nil
 }
 
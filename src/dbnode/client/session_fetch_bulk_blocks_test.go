@@ -1706,6 +1706,36 @@ func TestStreamBlocksBatchFromPeerVerifiesBlockChecksum(t *testing.T) {
 	assert.NoError(t, session.Close())
 }
 
+func TestVerifyFetchedBlockSegmentChecksums(t *testing.T) {
+	var (
+		session = &session{}
+		first   = &rpc.Segment{Head: []byte{1, 2}, Tail: []byte{3}}
+		second  = &rpc.Segment{Head: []byte{4, 5}, Tail: []byte{6}}
+		block   = &rpc.Block{Segments: &rpc.Segments{
+			Unmerged: []*rpc.Segment{first, second},
+		}}
+	)
+
+	// Segments without checksums from peers that do not support them are
+	// not verified.
+	require.NoError(t, session.verifyFetchedBlock(block))
+
+	firstChecksum := int64(digest.NewDigest().Update(first.Head).Update(first.Tail).Sum32())
+	secondChecksum := int64(digest.NewDigest().Update(second.Head).Update(second.Tail).Sum32())
+	first.Checksum = &firstChecksum
+	second.Checksum = &secondChecksum
+	require.NoError(t, session.verifyFetchedBlock(block))
+
+	invalidChecksum := secondChecksum + 1
+	second.Checksum = &invalidChecksum
+	require.Error(t, session.verifyFetchedBlock(block))
+
+	merged := &rpc.Block{Segments: &rpc.Segments{
+		Merged: &rpc.Segment{Head: first.Head, Tail: first.Tail, Checksum: &invalidChecksum},
+	}}
+	require.Error(t, session.verifyFetchedBlock(merged))
+}
+
 func TestBlocksResultAddBlockFromPeerReadMerged(t *testing.T) {
 	opts := newSessionTestAdminOptions()
 	bopts := newResultTestOptions()
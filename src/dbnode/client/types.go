@@ -129,6 +129,39 @@ type TaggedIDsIterator interface {
 	Finalize()
 }
 
+// NodeSeriesClient streams series directly from a single dbnode, bypassing
+// the topology aware session and the query engine, for jobs colocated with a
+// dbnode that only need the data owned by that node.
+type NodeSeriesClient interface {
+	// FetchTagged returns an iterator over the series on the node matching the
+	// query. The data of the series is only fetched from the node as the
+	// iterator is advanced, so a slow consumer applies backpressure to the node.
+	FetchTagged(
+		namespace ident.ID,
+		q index.Query,
+		opts index.QueryOptions,
+	) (NodeSeriesIterator, error)
+
+	// Close closes the connection to the node.
+	Close() error
+}
+
+// NodeSeriesIterator iterates over the series streamed from a single dbnode.
+type NodeSeriesIterator interface {
+	// Next returns whether there are more series.
+	Next() bool
+
+	// Current returns an iterator of the decoded datapoints of the current
+	// series, it remains valid until Next() is called again.
+	Current() encoding.SeriesIterator
+
+	// Err returns any error encountered.
+	Err() error
+
+	// Finalize releases any held resources.
+	Finalize()
+}
+
 // AdminClient can create administration sessions.
 type AdminClient interface {
 	Client
@@ -43,6 +43,9 @@ const (
 	// maxCustomFieldNum is included for the same rationale as maxMarshalledProtoMessageSize.
 	maxCustomFieldNum = 10000
 
+	// maxSchemaDeployIDSize is included for the same rationale as maxMarshalledProtoMessageSize.
+	maxSchemaDeployIDSize = 1 << 16
+
 	protoFieldTypeNotFound dpb.FieldDescriptorProto_Type = -1
 )
 
@@ -43,7 +43,11 @@ import (
 var _ encoding.Encoder = &Encoder{}
 
 const (
-	currentEncodingSchemeVersion = 1
+	// Starting from version 2 of the encoding scheme the deploy ID of the
+	// schema is encoded whenever the schema changes so that streams can be
+	// decoded with the version of the schema they were encoded with.
+	encodingSchemeVersionWithSchemaDeployID = 2
+	currentEncodingSchemeVersion            = encodingSchemeVersionWithSchemaDeployID
 )
 
 var (
@@ -207,6 +211,7 @@ func (enc *Encoder) encodeSchemaAndOrTimeUnit(
 	}
 
 	if needToEncodeSchema {
+		enc.encodeSchemaDeployID()
 		enc.encodeCustomSchemaTypes()
 		enc.hasEncodedSchema = true
 	}
@@ -290,6 +295,15 @@ func (enc *Encoder) encodeStreamHeader() {
 	enc.encodeVarInt(uint64(enc.opts.ByteFieldDictionaryLRUSize()))
 }
 
+func (enc *Encoder) encodeSchemaDeployID() {
+	var deployID string
+	if enc.schemaDesc != nil {
+		deployID = enc.schemaDesc.DeployId()
+	}
+	enc.encodeVarInt(uint64(len(deployID)))
+	enc.stream.WriteBytes([]byte(deployID))
+}
+
 func (enc *Encoder) encodeCustomSchemaTypes() {
 	if len(enc.customFields) == 0 {
 		enc.encodeVarInt(0)
@@ -876,3 +890,18 @@ func (enc *Encoder) newBuffer(capacity int) checked.Bytes {
 	}
 	return checked.NewBytes(make([]byte, 0, capacity), nil)
 }
+
+// ValidateMessage validates that the marshalled protobuf message would be
+// accepted by an encoder using the given schema without encoding it.
+func ValidateMessage(schema namespace.SchemaDescr, protoBytes []byte) error {
+	if schema == nil {
+		return errEncoderSchemaIsRequired
+	}
+
+	unmarshaller := newCustomFieldUnmarshaller(customUnmarshallerOptions{})
+	if err := unmarshaller.resetAndUnmarshal(schema.Get().MessageDescriptor, protoBytes); err != nil {
+		return fmt.Errorf(
+			"%s error unmarshalling message: %v", encErrPrefix, err)
+	}
+	return nil
+}
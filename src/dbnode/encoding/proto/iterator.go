@@ -50,14 +50,20 @@ var (
 )
 
 type iterator struct {
-	nsID                 ident.ID
-	opts                 encoding.Options
-	err                  error
-	schema               *desc.MessageDescriptor
-	schemaDesc           namespace.SchemaDescr
-	stream               encoding.IStream
-	marshaller           customFieldMarshaller
-	byteFieldDictLRUSize int
+	nsID       ident.ID
+	opts       encoding.Options
+	err        error
+	schema     *desc.MessageDescriptor
+	schemaDesc namespace.SchemaDescr
+	// readerSchemaDesc is the schema the iterator was reset with, messages
+	// of the stream encoded with a different version of the schema are
+	// decoded with that version and then migrated to the reader schema.
+	readerSchemaDesc      namespace.SchemaDescr
+	stream                encoding.IStream
+	marshaller            customFieldMarshaller
+	migrated              []byte
+	encodingSchemeVersion uint64
+	byteFieldDictLRUSize  int
 	// TODO(rartoul): Update these as we traverse the stream if we encounter
 	// a mid-stream schema change: https://github.com/m3db/m3/issues/1471
 	customFields    []customFieldState
@@ -181,6 +187,12 @@ func (it *iterator) Next() bool {
 		}
 
 		if schemaHasChangedControlBit == opCodeSchemaChange {
+			if it.encodingSchemeVersion >= encodingSchemeVersionWithSchemaDeployID {
+				if err := it.readSchemaDeployID(); err != nil {
+					it.err = fmt.Errorf("%s error reading schema deploy ID: %v", itErrPrefix, err)
+					return false
+				}
+			}
 			if err := it.readCustomFieldsSchema(); err != nil {
 				it.err = fmt.Errorf("%s error reading custom fields schema: %v", itErrPrefix, err)
 				return false
@@ -223,6 +235,18 @@ func (it *iterator) Next() bool {
 		it.marshaller.encPartialProto(marshalledField.marshalled)
 	}
 
+	if it.schemaDesc != it.readerSchemaDesc {
+		migrated, err := migrateMessage(
+			it.schema, it.readerSchemaDesc.Get().MessageDescriptor, it.marshaller.bytes())
+		if err != nil {
+			it.err = fmt.Errorf(
+				"%s error migrating message from schema %s to %s: %v", itErrPrefix,
+				it.schemaDesc.DeployId(), it.readerSchemaDesc.DeployId(), err)
+			return false
+		}
+		it.migrated = migrated
+	}
+
 	it.consumedFirstMessage = true
 	return it.hasNext()
 }
@@ -235,6 +259,9 @@ func (it *iterator) Current() (ts.Datapoint, xtime.Unit, ts.Annotation) {
 		unit = it.tsIterator.TimeUnit
 	)
 
+	if it.schemaDesc != it.readerSchemaDesc {
+		return dp, unit, it.migrated
+	}
 	return dp, unit, it.marshaller.bytes()
 }
 
@@ -251,11 +278,19 @@ func (it *iterator) Reset(reader io.Reader, descr namespace.SchemaDescr) {
 	it.consumedFirstMessage = false
 	it.done = false
 	it.closed = false
+	it.encodingSchemeVersion = 0
 	it.byteFieldDictLRUSize = 0
+	it.migrated = nil
 }
 
 // setSchema sets the schema for the iterator.
 func (it *iterator) resetSchema(schemaDesc namespace.SchemaDescr) {
+	it.readerSchemaDesc = schemaDesc
+	it.setStreamSchema(schemaDesc)
+}
+
+// setStreamSchema sets the schema the stream is decoded with.
+func (it *iterator) setStreamSchema(schemaDesc namespace.SchemaDescr) {
 	if schemaDesc == nil {
 		it.schemaDesc = nil
 		it.schema = nil
@@ -291,11 +326,11 @@ func (it *iterator) Close() {
 }
 
 func (it *iterator) readStreamHeader() error {
-	// Can ignore the version number for now because we only have one.
-	_, err := it.readVarInt()
+	version, err := it.readVarInt()
 	if err != nil {
 		return err
 	}
+	it.encodingSchemeVersion = version
 
 	byteFieldDictLRUSize, err := it.readVarInt()
 	if err != nil {
@@ -306,6 +341,48 @@ func (it *iterator) readStreamHeader() error {
 	return nil
 }
 
+// readSchemaDeployID reads the deploy ID of the schema the following messages
+// were encoded with and switches to decoding with that version of the schema.
+func (it *iterator) readSchemaDeployID() error {
+	deployIDLen, err := it.readVarInt()
+	if err != nil {
+		return err
+	}
+
+	if deployIDLen > maxSchemaDeployIDSize {
+		return fmt.Errorf(
+			"schema deploy ID size is %d but maximum allowed is %d",
+			deployIDLen, maxSchemaDeployIDSize)
+	}
+
+	buf := make([]byte, deployIDLen)
+	n, err := it.stream.Read(buf)
+	if err != nil {
+		return err
+	}
+	if uint64(n) != deployIDLen {
+		return fmt.Errorf("tried to read %d bytes but only read: %d", deployIDLen, n)
+	}
+
+	deployID := string(buf)
+	if deployID == it.schemaDesc.DeployId() {
+		return nil
+	}
+
+	schemaDesc, ok := namespace.SchemaForDeployID(it.readerSchemaDesc, deployID)
+	if !ok {
+		// Schemas that are not part of the schema history of the reader schema
+		// (or streams encoded without a deploy ID) are decoded with the reader
+		// schema as a best effort, the same as streams encoded before the
+		// deploy ID was encoded.
+		schemaDesc = it.readerSchemaDesc
+	}
+	if schemaDesc != it.schemaDesc {
+		it.setStreamSchema(schemaDesc)
+	}
+	return nil
+}
+
 func (it *iterator) readCustomFieldsSchema() error {
 	numCustomFields, err := it.readVarInt()
 	if err != nil {
@@ -293,6 +293,95 @@ func TestRoundTripMidStreamSchemaChanges(t *testing.T) {
 	require.NoError(t, iter.Err())
 }
 
+func TestRoundTripBreakingSchemaChangeMigratesToReaderSchema(t *testing.T) {
+	const (
+		protoFile = "vehicle_location.proto"
+		msgName   = "VehicleLocation"
+		v1Proto   = `syntax = "proto3";
+
+message VehicleLocation {
+  double latitude = 1;
+  double longitude = 2;
+  int64 epoch = 3;
+  bytes deliveryID = 4;
+  map<string, string> attributes = 5;
+}
+`
+		// Changes the type of the epoch field which is not wire compatible.
+		v2Proto = `syntax = "proto3";
+
+message VehicleLocation {
+  double latitude = 1;
+  double longitude = 2;
+  string epoch = 3;
+  bytes deliveryID = 4;
+  map<string, string> attributes = 5;
+}
+`
+	)
+	schemaOpts, err := namespace.AppendSchemaOptions(nil, protoFile, msgName,
+		map[string]string{protoFile: v1Proto}, "v1")
+	require.NoError(t, err)
+	schemaOpts, err = namespace.AppendSchemaOptions(schemaOpts, protoFile, msgName,
+		map[string]string{protoFile: v2Proto}, "v2")
+	require.NoError(t, err)
+	history, err := namespace.LoadSchemaHistory(schemaOpts)
+	require.NoError(t, err)
+	v1Schema, ok := history.Get("v1")
+	require.True(t, ok)
+	v2Schema, ok := history.Get("v2")
+	require.True(t, ok)
+
+	enc := newTestEncoder(time.Now().Truncate(time.Second))
+	enc.SetSchema(v1Schema)
+
+	attrs := map[string]string{"key1": "val1"}
+	v1Write := dynamic.NewMessage(v1Schema.Get().MessageDescriptor)
+	v1Write.SetFieldByName("latitude", 26.0)
+	v1Write.SetFieldByName("longitude", 27.0)
+	v1Write.SetFieldByName("epoch", int64(10))
+	v1Write.SetFieldByName("deliveryID", []byte("some_delivery_id"))
+	v1Write.SetFieldByName("attributes", attrs)
+	marshalled, err := v1Write.Marshal()
+	require.NoError(t, err)
+
+	v1WriteTime := time.Now().Truncate(time.Second)
+	err = enc.Encode(ts.Datapoint{Timestamp: v1WriteTime}, xtime.Second, marshalled)
+	require.NoError(t, err)
+
+	rawBytes, err := enc.Bytes()
+	require.NoError(t, err)
+
+	// Reading with the schema the stream was encoded with returns the
+	// message unchanged.
+	iter := NewIterator(bytes.NewBuffer(rawBytes), v1Schema, testEncodingOptions)
+	require.True(t, iter.Next(), "iter err: %v", iter.Err())
+	_, _, annotation := iter.Current()
+	m := dynamic.NewMessage(v1Schema.Get().MessageDescriptor)
+	require.NoError(t, m.Unmarshal(annotation))
+	require.True(t, dynamic.MessagesEqual(v1Write, m))
+	require.False(t, iter.Next())
+	require.NoError(t, iter.Err())
+
+	// Reading with the latest schema decodes the stream with the schema it
+	// was encoded with and migrates the message to the latest schema.
+	iter = NewIterator(bytes.NewBuffer(rawBytes), v2Schema, testEncodingOptions)
+	require.True(t, iter.Next(), "iter err: %v", iter.Err())
+	dp, unit, annotation := iter.Current()
+	require.Equal(t, xtime.Second, unit)
+	require.Equal(t, v1WriteTime, dp.Timestamp)
+	m = dynamic.NewMessage(v2Schema.Get().MessageDescriptor)
+	require.NoError(t, m.Unmarshal(annotation))
+	require.Equal(t, 26.0, m.GetFieldByName("latitude"))
+	require.Equal(t, 27.0, m.GetFieldByName("longitude"))
+	require.Equal(t, []byte("some_delivery_id"), m.GetFieldByName("deliveryID"))
+	assertAttributesEqual(t, attrs, m.GetFieldByName("attributes").(map[interface{}]interface{}))
+	// The type of epoch changed so it is dropped.
+	require.Equal(t, "", m.GetFieldByName("epoch"))
+	require.False(t, iter.Next())
+	require.NoError(t, iter.Err())
+}
+
 func newTestEncoder(t time.Time) *Encoder {
 	e := NewEncoder(t, testEncodingOptions)
 	e.Reset(t, 0, nil)
@@ -0,0 +1,121 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package proto
+
+import (
+	dpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+// migrateMessage converts a message marshalled with one version of a schema
+// to another version of the schema. Fields are matched by field number, and
+// fields that do not exist in the target schema or whose type has changed in
+// an incompatible way are dropped.
+func migrateMessage(
+	from *desc.MessageDescriptor,
+	to *desc.MessageDescriptor,
+	marshalled []byte,
+) ([]byte, error) {
+	src := dynamic.NewMessage(from)
+	if err := src.Unmarshal(marshalled); err != nil {
+		return nil, err
+	}
+	return migrateDynamicMessage(src, to).Marshal()
+}
+
+func migrateDynamicMessage(src *dynamic.Message, to *desc.MessageDescriptor) *dynamic.Message {
+	dst := dynamic.NewMessage(to)
+	for _, fromField := range src.GetKnownFields() {
+		if !src.HasField(fromField) {
+			continue
+		}
+
+		toField := to.FindFieldByNumber(fromField.GetNumber())
+		if !fieldsCompatible(fromField, toField) {
+			continue
+		}
+
+		value := migrateFieldValue(toField, src.GetField(fromField))
+		// Values that can not be set on the target schema are dropped the
+		// same as fields whose type has changed.
+		_ = dst.TrySetField(toField, value)
+	}
+	return dst
+}
+
+// fieldsCompatible returns whether values of the from field can be set on the
+// to field, nested messages are migrated field by field so only the shape of
+// message fields has to match.
+func fieldsCompatible(from, to *desc.FieldDescriptor) bool {
+	if to == nil ||
+		from.GetType() != to.GetType() ||
+		from.IsRepeated() != to.IsRepeated() ||
+		from.IsMap() != to.IsMap() {
+		return false
+	}
+	if from.IsMap() {
+		return fieldsCompatible(from.GetMapKeyType(), to.GetMapKeyType()) &&
+			fieldsCompatible(from.GetMapValueType(), to.GetMapValueType())
+	}
+	return true
+}
+
+func migrateFieldValue(to *desc.FieldDescriptor, value interface{}) interface{} {
+	if to.IsMap() {
+		valueField := to.GetMapValueType()
+		if valueField.GetType() != dpb.FieldDescriptorProto_TYPE_MESSAGE {
+			return value
+		}
+		entries, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return value
+		}
+		migrated := make(map[interface{}]interface{}, len(entries))
+		for k, v := range entries {
+			migrated[k] = migrateMessageValue(valueField.GetMessageType(), v)
+		}
+		return migrated
+	}
+
+	if to.GetType() != dpb.FieldDescriptorProto_TYPE_MESSAGE {
+		return value
+	}
+	if to.IsRepeated() {
+		values, ok := value.([]interface{})
+		if !ok {
+			return value
+		}
+		migrated := make([]interface{}, 0, len(values))
+		for _, v := range values {
+			migrated = append(migrated, migrateMessageValue(to.GetMessageType(), v))
+		}
+		return migrated
+	}
+	return migrateMessageValue(to.GetMessageType(), value)
+}
+
+func migrateMessageValue(to *desc.MessageDescriptor, value interface{}) interface{} {
+	msg, ok := value.(*dynamic.Message)
+	if !ok {
+		return value
+	}
+	return migrateDynamicMessage(msg, to)
+}
@@ -31,6 +31,7 @@
 	It has these top-level messages:
 		RetentionOptions
 		IndexOptions
+		BloomFilterOptions
 		NamespaceOptions
 		Registry
 		SchemaOptions
@@ -43,6 +44,8 @@ import proto "github.com/gogo/protobuf/proto"
 import fmt "fmt"
 import math "math"
 
+import binary "encoding/binary"
+
 import io "io"
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -121,8 +124,9 @@ func (m *RetentionOptions) GetFutureRetentionPeriodNanos() int64 {
 }
 
 type IndexOptions struct {
-	Enabled        bool  `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
-	BlockSizeNanos int64 `protobuf:"varint,2,opt,name=blockSizeNanos,proto3" json:"blockSizeNanos,omitempty"`
+	Enabled          bool     `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	BlockSizeNanos   int64    `protobuf:"varint,2,opt,name=blockSizeNanos,proto3" json:"blockSizeNanos,omitempty"`
+	AnnotationFields []string `protobuf:"bytes,3,rep,name=annotationFields" json:"annotationFields,omitempty"`
 }
 
 func (m *IndexOptions) Reset()                    { *m = IndexOptions{} }
@@ -144,23 +148,55 @@ func (m *IndexOptions) GetBlockSizeNanos() int64 {
 	return 0
 }
 
+func (m *IndexOptions) GetAnnotationFields() []string {
+	if m != nil {
+		return m.AnnotationFields
+	}
+	return nil
+}
+
+type BloomFilterOptions struct {
+	Enabled              bool    `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	FalsePositivePercent float64 `protobuf:"fixed64,2,opt,name=falsePositivePercent,proto3" json:"falsePositivePercent,omitempty"`
+}
+
+func (m *BloomFilterOptions) Reset()                    { *m = BloomFilterOptions{} }
+func (m *BloomFilterOptions) String() string            { return proto.CompactTextString(m) }
+func (*BloomFilterOptions) ProtoMessage()               {}
+func (*BloomFilterOptions) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{2} }
+
+func (m *BloomFilterOptions) GetEnabled() bool {
+	if m != nil {
+		return m.Enabled
+	}
+	return false
+}
+
+func (m *BloomFilterOptions) GetFalsePositivePercent() float64 {
+	if m != nil {
+		return m.FalsePositivePercent
+	}
+	return 0
+}
+
 type NamespaceOptions struct {
-	BootstrapEnabled  bool              `protobuf:"varint,1,opt,name=bootstrapEnabled,proto3" json:"bootstrapEnabled,omitempty"`
-	FlushEnabled      bool              `protobuf:"varint,2,opt,name=flushEnabled,proto3" json:"flushEnabled,omitempty"`
-	WritesToCommitLog bool              `protobuf:"varint,3,opt,name=writesToCommitLog,proto3" json:"writesToCommitLog,omitempty"`
-	CleanupEnabled    bool              `protobuf:"varint,4,opt,name=cleanupEnabled,proto3" json:"cleanupEnabled,omitempty"`
-	RepairEnabled     bool              `protobuf:"varint,5,opt,name=repairEnabled,proto3" json:"repairEnabled,omitempty"`
-	RetentionOptions  *RetentionOptions `protobuf:"bytes,6,opt,name=retentionOptions" json:"retentionOptions,omitempty"`
-	SnapshotEnabled   bool              `protobuf:"varint,7,opt,name=snapshotEnabled,proto3" json:"snapshotEnabled,omitempty"`
-	IndexOptions      *IndexOptions     `protobuf:"bytes,8,opt,name=indexOptions" json:"indexOptions,omitempty"`
-	SchemaOptions     *SchemaOptions    `protobuf:"bytes,9,opt,name=schemaOptions" json:"schemaOptions,omitempty"`
-	ColdWritesEnabled bool              `protobuf:"varint,10,opt,name=coldWritesEnabled,proto3" json:"coldWritesEnabled,omitempty"`
+	BootstrapEnabled   bool                `protobuf:"varint,1,opt,name=bootstrapEnabled,proto3" json:"bootstrapEnabled,omitempty"`
+	FlushEnabled       bool                `protobuf:"varint,2,opt,name=flushEnabled,proto3" json:"flushEnabled,omitempty"`
+	WritesToCommitLog  bool                `protobuf:"varint,3,opt,name=writesToCommitLog,proto3" json:"writesToCommitLog,omitempty"`
+	CleanupEnabled     bool                `protobuf:"varint,4,opt,name=cleanupEnabled,proto3" json:"cleanupEnabled,omitempty"`
+	RepairEnabled      bool                `protobuf:"varint,5,opt,name=repairEnabled,proto3" json:"repairEnabled,omitempty"`
+	RetentionOptions   *RetentionOptions   `protobuf:"bytes,6,opt,name=retentionOptions" json:"retentionOptions,omitempty"`
+	SnapshotEnabled    bool                `protobuf:"varint,7,opt,name=snapshotEnabled,proto3" json:"snapshotEnabled,omitempty"`
+	IndexOptions       *IndexOptions       `protobuf:"bytes,8,opt,name=indexOptions" json:"indexOptions,omitempty"`
+	SchemaOptions      *SchemaOptions      `protobuf:"bytes,9,opt,name=schemaOptions" json:"schemaOptions,omitempty"`
+	ColdWritesEnabled  bool                `protobuf:"varint,10,opt,name=coldWritesEnabled,proto3" json:"coldWritesEnabled,omitempty"`
+	BloomFilterOptions *BloomFilterOptions `protobuf:"bytes,11,opt,name=bloomFilterOptions" json:"bloomFilterOptions,omitempty"`
 }
 
 func (m *NamespaceOptions) Reset()                    { *m = NamespaceOptions{} }
 func (m *NamespaceOptions) String() string            { return proto.CompactTextString(m) }
 func (*NamespaceOptions) ProtoMessage()               {}
-func (*NamespaceOptions) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{2} }
+func (*NamespaceOptions) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{3} }
 
 func (m *NamespaceOptions) GetBootstrapEnabled() bool {
 	if m != nil {
@@ -232,6 +268,13 @@ func (m *NamespaceOptions) GetColdWritesEnabled() bool {
 	return false
 }
 
+func (m *NamespaceOptions) GetBloomFilterOptions() *BloomFilterOptions {
+	if m != nil {
+		return m.BloomFilterOptions
+	}
+	return nil
+}
+
 type Registry struct {
 	Namespaces map[string]*NamespaceOptions `protobuf:"bytes,1,rep,name=namespaces" json:"namespaces,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value"`
 }
@@ -239,7 +282,7 @@ type Registry struct {
 func (m *Registry) Reset()                    { *m = Registry{} }
 func (m *Registry) String() string            { return proto.CompactTextString(m) }
 func (*Registry) ProtoMessage()               {}
-func (*Registry) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{3} }
+func (*Registry) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{4} }
 
 func (m *Registry) GetNamespaces() map[string]*NamespaceOptions {
 	if m != nil {
@@ -251,6 +294,7 @@ func (m *Registry) GetNamespaces() map[string]*NamespaceOptions {
 func init() {
 	proto.RegisterType((*RetentionOptions)(nil), "namespace.RetentionOptions")
 	proto.RegisterType((*IndexOptions)(nil), "namespace.IndexOptions")
+	proto.RegisterType((*BloomFilterOptions)(nil), "namespace.BloomFilterOptions")
 	proto.RegisterType((*NamespaceOptions)(nil), "namespace.NamespaceOptions")
 	proto.RegisterType((*Registry)(nil), "namespace.Registry")
 }
@@ -342,6 +386,55 @@ func (m *IndexOptions) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintNamespace(dAtA, i, uint64(m.BlockSizeNanos))
 	}
+	if len(m.AnnotationFields) > 0 {
+		for _, s := range m.AnnotationFields {
+			dAtA[i] = 0x1a
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	return i, nil
+}
+
+func (m *BloomFilterOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BloomFilterOptions) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Enabled {
+		dAtA[i] = 0x8
+		i++
+		if m.Enabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.FalsePositivePercent != 0 {
+		dAtA[i] = 0x11
+		i++
+		binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.FalsePositivePercent))))
+		i += 8
+	}
 	return i, nil
 }
 
@@ -460,6 +553,16 @@ func (m *NamespaceOptions) MarshalTo(dAtA []byte) (int, error) {
 		}
 		i++
 	}
+	if m.BloomFilterOptions != nil {
+		dAtA[i] = 0x5a
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.BloomFilterOptions.Size()))
+		n4, err := m.BloomFilterOptions.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n4
+	}
 	return i, nil
 }
 
@@ -554,6 +657,24 @@ func (m *IndexOptions) Size() (n int) {
 	if m.BlockSizeNanos != 0 {
 		n += 1 + sovNamespace(uint64(m.BlockSizeNanos))
 	}
+	if len(m.AnnotationFields) > 0 {
+		for _, s := range m.AnnotationFields {
+			l = len(s)
+			n += 1 + l + sovNamespace(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *BloomFilterOptions) Size() (n int) {
+	var l int
+	_ = l
+	if m.Enabled {
+		n += 2
+	}
+	if m.FalsePositivePercent != 0 {
+		n += 9
+	}
 	return n
 }
 
@@ -593,6 +714,10 @@ func (m *NamespaceOptions) Size() (n int) {
 	if m.ColdWritesEnabled {
 		n += 2
 	}
+	if m.BloomFilterOptions != nil {
+		l = m.BloomFilterOptions.Size()
+		n += 1 + l + sovNamespace(uint64(l))
+	}
 	return n
 }
 
@@ -880,6 +1005,116 @@ func (m *IndexOptions) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AnnotationFields", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.AnnotationFields = append(m.AnnotationFields, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNamespace(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BloomFilterOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNamespace
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BloomFilterOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BloomFilterOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Enabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Enabled = bool(v != 0)
+		case 2:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FalsePositivePercent", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.FalsePositivePercent = float64(math.Float64frombits(v))
 		default:
 			iNdEx = preIndex
 			skippy, err := skipNamespace(dAtA[iNdEx:])
@@ -1169,6 +1404,39 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.ColdWritesEnabled = bool(v != 0)
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BloomFilterOptions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.BloomFilterOptions == nil {
+				m.BloomFilterOptions = &BloomFilterOptions{}
+			}
+			if err := m.BloomFilterOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipNamespace(dAtA[iNdEx:])
@@ -1473,41 +1741,45 @@ func init() {
 }
 
 var fileDescriptorNamespace = []byte{
-	// 575 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x94, 0x51, 0x6b, 0xd3, 0x50,
-	0x14, 0xc7, 0x4d, 0xb3, 0xad, 0xed, 0x59, 0xe7, 0xe2, 0x45, 0xb0, 0x54, 0x28, 0xa3, 0x8a, 0x14,
-	0x91, 0x06, 0xdb, 0x17, 0x51, 0x18, 0xcc, 0xad, 0x0e, 0x41, 0x6a, 0xb9, 0x13, 0x84, 0xbd, 0xdd,
-	0x24, 0xa7, 0x6d, 0x58, 0x92, 0x1b, 0xee, 0xbd, 0xd1, 0xd5, 0xcf, 0xe0, 0x83, 0xdf, 0xc3, 0x2f,
-	0xe2, 0xa3, 0x1f, 0x41, 0xea, 0xd7, 0xf0, 0x41, 0x72, 0x63, 0xba, 0x24, 0x1d, 0x32, 0xf6, 0x52,
-	0x6e, 0xff, 0xe7, 0x77, 0xce, 0xb9, 0x3d, 0xff, 0x73, 0x0b, 0xa7, 0x73, 0x5f, 0x2d, 0x12, 0x67,
-	0xe0, 0xf2, 0xd0, 0x0e, 0x47, 0x9e, 0x63, 0x87, 0x23, 0x5b, 0x0a, 0xd7, 0xf6, 0x9c, 0x88, 0x7b,
-	0x68, 0xcf, 0x31, 0x42, 0xc1, 0x14, 0x7a, 0x76, 0x2c, 0xb8, 0xe2, 0x76, 0xc4, 0x42, 0x94, 0x31,
-	0x73, 0xf1, 0xea, 0x34, 0xd0, 0x11, 0xd2, 0x5c, 0x0b, 0x9d, 0x93, 0xdb, 0xd6, 0x94, 0xee, 0x02,
-	0x43, 0x96, 0x15, 0xec, 0x7d, 0x35, 0xc1, 0xa2, 0xa8, 0x30, 0x52, 0x3e, 0x8f, 0xde, 0xc7, 0xe9,
-	0xa7, 0x24, 0x43, 0xb8, 0x2f, 0x72, 0x6d, 0x8a, 0xc2, 0xe7, 0xde, 0x84, 0x45, 0x5c, 0xb6, 0x8d,
-	0x03, 0xa3, 0x6f, 0xd2, 0x6b, 0x63, 0xe4, 0x09, 0xdc, 0x75, 0x02, 0xee, 0x5e, 0x9c, 0xf9, 0x5f,
-	0x30, 0xa3, 0x6b, 0x9a, 0xae, 0xa8, 0xe4, 0x19, 0xdc, 0x73, 0x92, 0xd9, 0x0c, 0xc5, 0x9b, 0x44,
-	0x25, 0xe2, 0x1f, 0x6a, 0x6a, 0x74, 0x33, 0x40, 0xfa, 0xb0, 0x9f, 0x89, 0x53, 0x26, 0x55, 0xc6,
-	0x6e, 0x69, 0xb6, 0x2a, 0x6b, 0x32, 0xed, 0x74, 0xc2, 0x14, 0x1b, 0x5f, 0xc6, 0xbe, 0x58, 0xb6,
-	0xb7, 0x0f, 0x8c, 0x7e, 0x83, 0x56, 0x65, 0x72, 0x0e, 0xfd, 0x8a, 0x74, 0x34, 0x53, 0x28, 0x26,
-	0x5c, 0x1d, 0xb9, 0x2e, 0x4a, 0x59, 0xfc, 0xc5, 0x3b, 0xba, 0xd9, 0x8d, 0x79, 0x72, 0x08, 0x9d,
-	0x99, 0xbe, 0x3e, 0xbd, 0x6e, 0x7e, 0x75, 0x5d, 0xed, 0x3f, 0x44, 0x6f, 0x0a, 0xad, 0xb7, 0x91,
-	0x87, 0x97, 0xb9, 0x13, 0x6d, 0xa8, 0x63, 0xc4, 0x9c, 0x00, 0x3d, 0x3d, 0xfc, 0x06, 0xcd, 0xbf,
-	0xde, 0x74, 0xde, 0xbd, 0x3f, 0x26, 0x58, 0x93, 0xdc, 0xfb, 0xbc, 0xec, 0x53, 0xb0, 0x1c, 0xce,
-	0x95, 0x54, 0x82, 0xc5, 0xe3, 0x52, 0xfd, 0x0d, 0x9d, 0xf4, 0xa0, 0x35, 0x0b, 0x12, 0xb9, 0xc8,
-	0xb9, 0x9a, 0xe6, 0x4a, 0x5a, 0x6a, 0xea, 0x67, 0xe1, 0x2b, 0x94, 0x1f, 0xf8, 0x31, 0x0f, 0x43,
-	0x5f, 0xbd, 0xe3, 0x73, 0x6d, 0x6a, 0x83, 0x6e, 0x06, 0xd2, 0xab, 0xbb, 0x01, 0xb2, 0x28, 0x59,
-	0xf7, 0xde, 0xd2, 0x68, 0x45, 0x25, 0x8f, 0x61, 0x4f, 0x60, 0xcc, 0x7c, 0x91, 0x63, 0x99, 0xa1,
-	0x65, 0x91, 0x9c, 0x82, 0x25, 0x2a, 0x0b, 0xac, 0x6d, 0xdb, 0x1d, 0x3e, 0x1c, 0x5c, 0x3d, 0x9f,
-	0xea, 0x8e, 0xd3, 0x8d, 0xa4, 0x74, 0x83, 0x64, 0xc4, 0x62, 0xb9, 0xe0, 0x2a, 0x6f, 0x58, 0xcf,
-	0x36, 0xa8, 0x22, 0x93, 0x57, 0xd0, 0xf2, 0x0b, 0x2e, 0xb5, 0x1b, 0xba, 0xdd, 0x83, 0x42, 0xbb,
-	0xa2, 0x89, 0xb4, 0x04, 0x93, 0x43, 0xd8, 0xcb, 0x5e, 0x60, 0x9e, 0xdd, 0xd4, 0xd9, 0xed, 0x42,
-	0xf6, 0x59, 0x31, 0x4e, 0xcb, 0x78, 0x3a, 0x6b, 0x97, 0x07, 0xde, 0x47, 0x3d, 0xd6, 0xfc, 0xa2,
-	0x90, 0xcd, 0x7a, 0x23, 0xd0, 0xfb, 0x6e, 0x40, 0x83, 0xe2, 0xdc, 0x97, 0x4a, 0x2c, 0xc9, 0x31,
-	0xc0, 0xba, 0x49, 0xfa, 0x9a, 0xcd, 0xfe, 0xee, 0xf0, 0x51, 0x69, 0x48, 0x19, 0x38, 0x58, 0x2f,
-	0x8c, 0x1c, 0x47, 0x4a, 0x2c, 0x69, 0x21, 0xad, 0x73, 0x0e, 0xfb, 0x95, 0x30, 0xb1, 0xc0, 0xbc,
-	0xc0, 0xa5, 0xde, 0xa0, 0x26, 0x4d, 0x8f, 0xe4, 0x39, 0x6c, 0x7f, 0x62, 0x41, 0x82, 0x7a, 0x5b,
-	0xca, 0x4e, 0x54, 0x97, 0x91, 0x66, 0xe4, 0xcb, 0xda, 0x0b, 0xe3, 0xb5, 0xf5, 0x63, 0xd5, 0x35,
-	0x7e, 0xae, 0xba, 0xc6, 0xaf, 0x55, 0xd7, 0xf8, 0xf6, 0xbb, 0x7b, 0xc7, 0xd9, 0xd1, 0x7f, 0x53,
-	0xa3, 0xbf, 0x01, 0x00, 0x00, 0xff, 0xff, 0x60, 0xfa, 0xeb, 0x0c, 0x42, 0x05, 0x00, 0x00,
+	// 638 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9d, 0x54, 0xdd, 0x6a, 0x13, 0x41,
+	0x14, 0x36, 0x4d, 0xda, 0x24, 0xa7, 0xad, 0x8d, 0x83, 0x60, 0xa8, 0x58, 0x24, 0x8a, 0x84, 0x22,
+	0x09, 0xb6, 0x37, 0xa2, 0x20, 0xf4, 0x1f, 0x41, 0x6b, 0x99, 0x0a, 0x42, 0xef, 0x66, 0x77, 0x4f,
+	0x92, 0xa1, 0xbb, 0x3b, 0xcb, 0xcc, 0x6c, 0x6d, 0xc4, 0x47, 0xf0, 0xc2, 0xf7, 0xf0, 0x45, 0x04,
+	0x6f, 0x7c, 0x04, 0xd1, 0x17, 0x71, 0x76, 0xd6, 0x4d, 0xf7, 0x27, 0x68, 0xf1, 0x62, 0x97, 0x9d,
+	0xef, 0x7c, 0xe7, 0x7c, 0x67, 0xce, 0x7e, 0x33, 0x70, 0x34, 0xe6, 0x7a, 0x12, 0x3b, 0x03, 0x57,
+	0x04, 0xc3, 0x60, 0xdb, 0x73, 0xcc, 0x6b, 0xa8, 0xa4, 0x3b, 0xf4, 0x9c, 0x50, 0x78, 0x38, 0x1c,
+	0x63, 0x88, 0x92, 0x69, 0xf4, 0x86, 0x91, 0x14, 0x5a, 0x0c, 0x43, 0x16, 0xa0, 0x8a, 0x98, 0x8b,
+	0x57, 0x5f, 0x03, 0x1b, 0x21, 0xed, 0x19, 0xb0, 0xbe, 0xff, 0xbf, 0x35, 0x95, 0x3b, 0xc1, 0x80,
+	0xa5, 0x05, 0x7b, 0x9f, 0xea, 0xd0, 0xa1, 0xa8, 0x31, 0xd4, 0x5c, 0x84, 0x6f, 0xa2, 0xe4, 0xad,
+	0xc8, 0x16, 0xdc, 0x96, 0x19, 0x76, 0x82, 0x92, 0x0b, 0xef, 0x98, 0x85, 0x42, 0x75, 0x6b, 0xf7,
+	0x6b, 0xfd, 0x3a, 0x9d, 0x1b, 0x23, 0x8f, 0xe0, 0xa6, 0xe3, 0x0b, 0xf7, 0xfc, 0x94, 0x7f, 0xc0,
+	0x94, 0xbd, 0x60, 0xd9, 0x25, 0x94, 0x3c, 0x86, 0x5b, 0x4e, 0x3c, 0x1a, 0xa1, 0x3c, 0x8c, 0x75,
+	0x2c, 0xff, 0x50, 0xeb, 0x96, 0x5a, 0x0d, 0x90, 0x3e, 0xac, 0xa5, 0xe0, 0x09, 0x53, 0x3a, 0xe5,
+	0x36, 0x2c, 0xb7, 0x0c, 0x5b, 0x66, 0xa2, 0xb4, 0xcf, 0x34, 0x3b, 0xb8, 0x8c, 0xb8, 0x9c, 0x76,
+	0x17, 0x0d, 0xb3, 0x45, 0xcb, 0x30, 0x39, 0x83, 0x7e, 0x09, 0xda, 0x19, 0x69, 0x94, 0xc7, 0x42,
+	0xef, 0xb8, 0x2e, 0x2a, 0x95, 0xdf, 0xf1, 0x92, 0x15, 0xbb, 0x36, 0x9f, 0xbc, 0x80, 0xf5, 0x91,
+	0x6d, 0x9f, 0xce, 0x9b, 0x5f, 0xd3, 0x56, 0xfb, 0x0b, 0xa3, 0xf7, 0x11, 0x56, 0x5e, 0x86, 0x1e,
+	0x5e, 0x66, 0x7f, 0xa2, 0x0b, 0x4d, 0x0c, 0x99, 0xe3, 0xa3, 0x67, 0x87, 0xdf, 0xa2, 0xd9, 0xf2,
+	0xda, 0xf3, 0xde, 0x84, 0x0e, 0x0b, 0x43, 0xa1, 0x59, 0x52, 0xf0, 0x90, 0xa3, 0xef, 0x25, 0xe3,
+	0xae, 0xf7, 0xdb, 0xb4, 0x82, 0xf7, 0x1c, 0x20, 0xbb, 0xbe, 0x10, 0xc1, 0x21, 0xf7, 0xcd, 0x06,
+	0xff, 0xdd, 0x83, 0xf1, 0xc9, 0x88, 0xf9, 0x0a, 0x4f, 0x84, 0xe2, 0x9a, 0x5f, 0xa0, 0xd9, 0x89,
+	0x6b, 0xf6, 0x64, 0x3b, 0xa9, 0xd1, 0xb9, 0xb1, 0xde, 0xb7, 0x06, 0x74, 0x8e, 0x33, 0x2f, 0x66,
+	0x12, 0xa6, 0x49, 0x47, 0x08, 0xad, 0xb4, 0x64, 0xd1, 0x41, 0x41, 0xab, 0x82, 0x93, 0x1e, 0xac,
+	0x8c, 0xfc, 0x58, 0x4d, 0x32, 0xde, 0x82, 0xe5, 0x15, 0xb0, 0xc4, 0x64, 0xef, 0x25, 0xd7, 0xa8,
+	0xde, 0x8a, 0x3d, 0x11, 0x04, 0x5c, 0xbf, 0x12, 0x63, 0x6b, 0xb2, 0x16, 0xad, 0x06, 0x92, 0x51,
+	0xba, 0x3e, 0xb2, 0x30, 0x9e, 0x69, 0x37, 0x2c, 0xb5, 0x84, 0x92, 0x87, 0xb0, 0x2a, 0x31, 0x62,
+	0x5c, 0x66, 0xb4, 0xd4, 0x60, 0x45, 0x90, 0x1c, 0x41, 0x47, 0x96, 0x0e, 0x94, 0xb5, 0xd1, 0xf2,
+	0xd6, 0xdd, 0xc1, 0xd5, 0x71, 0x2e, 0x9f, 0x39, 0x5a, 0x49, 0x4a, 0x1c, 0xad, 0x42, 0x16, 0xa9,
+	0x89, 0xd0, 0x99, 0x60, 0x33, 0x75, 0x74, 0x09, 0x26, 0xcf, 0x61, 0x85, 0xe7, 0x5c, 0xd3, 0x6d,
+	0x59, 0xb9, 0x3b, 0x39, 0xb9, 0xbc, 0xa9, 0x68, 0x81, 0x6c, 0x2c, 0xbb, 0x9a, 0xde, 0x08, 0x59,
+	0x76, 0xdb, 0x66, 0x77, 0x73, 0xd9, 0xa7, 0xf9, 0x38, 0x2d, 0xd2, 0x93, 0x59, 0xbb, 0xc2, 0xf7,
+	0xde, 0xd9, 0xb1, 0x66, 0x8d, 0x42, 0x3a, 0xeb, 0x4a, 0x80, 0xbc, 0x06, 0xe2, 0x54, 0x2c, 0xd6,
+	0x5d, 0xb6, 0x92, 0xf7, 0x72, 0x92, 0x55, 0x1f, 0xd2, 0x39, 0x89, 0xbd, 0x2f, 0x35, 0x68, 0x51,
+	0x1c, 0x73, 0xe3, 0x90, 0x29, 0xd9, 0x03, 0x98, 0x15, 0x48, 0x2e, 0xab, 0xba, 0xa9, 0xf9, 0xa0,
+	0x30, 0xf3, 0x94, 0x38, 0x98, 0xf9, 0xcf, 0xb4, 0x65, 0xd6, 0x34, 0x97, 0xb6, 0x7e, 0x06, 0x6b,
+	0xa5, 0x30, 0xe9, 0x40, 0xfd, 0x1c, 0xa7, 0xd6, 0x90, 0x6d, 0x9a, 0x7c, 0x92, 0x27, 0xb0, 0x78,
+	0xc1, 0xfc, 0x18, 0xad, 0xf9, 0x8a, 0x3f, 0xb6, 0xec, 0x6d, 0x9a, 0x32, 0x9f, 0x2d, 0x3c, 0xad,
+	0xed, 0x76, 0xbe, 0xfe, 0xdc, 0xa8, 0x7d, 0x37, 0xcf, 0x0f, 0xf3, 0x7c, 0xfe, 0xb5, 0x71, 0xc3,
+	0x59, 0xb2, 0xb7, 0xf0, 0xf6, 0x6f, 0xef, 0xde, 0x7e, 0x65, 0x21, 0x06, 0x00, 0x00,
 }
@@ -35,6 +35,10 @@ enum ErrorType {
 exception Error {
 	1: required ErrorType type = ErrorType.INTERNAL_ERROR
 	2: required string message
+	// flags further classify the error as a bit set, 0x01 is set when the
+	// request was rejected to apply backpressure as resources are exhausted
+	// and 0x02 when the request was rejected because an operator blocked it.
+	3: optional i64 flags
 }
 
 exception WriteBatchRawErrors {
@@ -50,6 +54,7 @@ service Node {
 	FetchTaggedResult fetchTagged(1: FetchTaggedRequest req) throws (1: Error err)
 	void write(1: WriteRequest req) throws (1: Error err)
 	void writeTagged(1: WriteTaggedRequest req) throws (1: Error err)
+	void validateWriteTagged(1: WriteTaggedRequest req) throws (1: Error err)
 
 	// Performant read/write endpoints
 	FetchBatchRawResult fetchBatchRaw(1: FetchBatchRawRequest req) throws (1: Error err)
@@ -75,6 +80,18 @@ service Node {
 	NodeWriteNewSeriesBackoffDurationResult setWriteNewSeriesBackoffDuration(1: NodeSetWriteNewSeriesBackoffDurationRequest req) throws (1: Error err)
 	NodeWriteNewSeriesLimitPerShardPerSecondResult getWriteNewSeriesLimitPerShardPerSecond() throws (1: Error err)
 	NodeWriteNewSeriesLimitPerShardPerSecondResult setWriteNewSeriesLimitPerShardPerSecond(1: NodeSetWriteNewSeriesLimitPerShardPerSecondRequest req) throws (1: Error err)
+	NodePausedBackgroundTasksResult getPausedBackgroundTasks() throws (1: Error err)
+	NodePausedBackgroundTasksResult pauseBackgroundTask(1: NodePauseBackgroundTaskRequest req) throws (1: Error err)
+	NodePausedBackgroundTasksResult resumeBackgroundTask(1: NodeResumeBackgroundTaskRequest req) throws (1: Error err)
+	NodeRPCByteUsageResult getRPCByteUsage() throws (1: Error err)
+	NodeLiveQueriesResult getLiveQueries() throws (1: Error err)
+	NodeLiveQueriesResult cancelLiveQuery(1: NodeCancelLiveQueryRequest req) throws (1: Error err)
+	NodeUndeleteExpiredFileSetsResult undeleteExpiredFileSets(1: NodeUndeleteExpiredFileSetsRequest req) throws (1: Error err)
+	NodeHotSeriesResult getHotSeries(1: NodeHotSeriesRequest req) throws (1: Error err)
+	NodeFlushStatesResult getFlushStates(1: NodeFlushStatesRequest req) throws (1: Error err)
+	NodeBootstrapProgressResult getBootstrapProgress() throws (1: Error err)
+	NodeRebootstrapShardResult rebootstrapShard(1: NodeRebootstrapShardRequest req) throws (1: Error err)
+	NodeBootstrapDryRunResult bootstrapDryRun() throws (1: Error err)
 }
 
 struct FetchRequest {
@@ -101,6 +118,7 @@ struct WriteRequest {
 	1: required string nameSpace
 	2: required string id
 	3: required Datapoint datapoint
+	4: optional bool strictlyIncreasing
 }
 
 struct WriteTaggedRequest {
@@ -108,6 +126,7 @@ struct WriteTaggedRequest {
 	2: required string id
 	3: required list<Tag> tags
 	4: required Datapoint datapoint
+	5: optional bool strictlyIncreasing
 }
 
 struct FetchBatchRawRequest {
@@ -137,6 +156,8 @@ struct Segment {
 	2: required binary tail
 	3: optional i64 startTime
 	4: optional i64 blockSize
+	5: optional i64 checksum
+	6: optional string source
 }
 
 struct FetchTaggedRequest {
@@ -147,11 +168,13 @@ struct FetchTaggedRequest {
 	5: required bool fetchData
 	6: optional i64 limit
 	7: optional TimeType rangeTimeType = TimeType.UNIX_SECONDS
+	8: optional bool includeDurability
 }
 
 struct FetchTaggedResult {
 	1: required list<FetchTaggedIDResult> elements
 	2: required bool exhaustive
+	3: optional list<DataDurabilityRange> durabilityRanges
 }
 
 struct FetchTaggedIDResult {
@@ -162,10 +185,18 @@ struct FetchTaggedIDResult {
 	5: optional Error err
 }
 
+struct DataDurabilityRange {
+	1: required i64 rangeStart
+	2: required i64 rangeEnd
+	3: required string durability
+}
+
 struct FetchBlocksRawRequest {
 	1: required binary nameSpace
 	2: required i32 shard
 	3: required list<FetchBlocksRawRequestElement> elements
+	4: optional bool includeSegmentChecksums
+	5: optional bool includeSegmentSources
 }
 
 struct FetchBlocksRawRequestElement {
@@ -204,11 +235,18 @@ struct FetchBlocksMetadataRawV2Request {
 	7: optional bool includeSizes
 	8: optional bool includeChecksums
 	9: optional bool includeLastRead
+	10: optional i64 minBlockStart
+	11: optional i64 minSize
+	12: optional bool summaryOnly
+	13: optional bool includeCardinality
 }
 
 struct FetchBlocksMetadataRawV2Result {
 	1: required list<BlockMetadataV2> elements
 	2: optional binary nextPageToken
+	3: optional list<BlockMetadataV2Summary> summaries
+	4: optional list<BlockCardinality> cardinalities
+	5: optional list<BlockCardinality> indexCardinalities
 }
 
 struct BlockMetadataV2 {
@@ -222,6 +260,17 @@ struct BlockMetadataV2 {
 	8: optional binary encodedTags
 }
 
+struct BlockMetadataV2Summary {
+	1: required i64 start
+	2: required i64 count
+	3: required i64 size
+}
+
+struct BlockCardinality {
+	1: required i64 start
+	2: required i64 series
+}
+
 struct WriteBatchRawRequest {
 	1: required binary nameSpace
 	2: required list<WriteBatchRawRequestElement> elements
@@ -304,6 +353,171 @@ struct NodeSetWriteNewSeriesLimitPerShardPerSecondRequest {
 	1: required i64 writeNewSeriesLimitPerShardPerSecond
 }
 
+struct NodePauseBackgroundTaskRequest {
+	1: required string nameSpace
+	2: required string task
+	3: required i64 pauseDuration
+	4: optional TimeType durationType = TimeType.UNIX_SECONDS
+}
+
+struct NodeResumeBackgroundTaskRequest {
+	1: required string nameSpace
+	2: required string task
+}
+
+struct NodePausedBackgroundTask {
+	1: required string nameSpace
+	2: required string task
+	3: required i64 pausedUntilNanos
+}
+
+struct NodePausedBackgroundTasksResult {
+	1: required list<NodePausedBackgroundTask> pausedBackgroundTasks
+}
+
+struct NodeRPCByteUsage {
+	1: required string nameSpace
+	2: required string tenant
+	3: required i64 bytesIn
+	4: required i64 bytesOut
+}
+
+struct NodeRPCByteUsageResult {
+	1: required list<NodeRPCByteUsage> usage
+}
+
+struct NodeLiveQuery {
+	1: required string id
+	2: required string query
+	3: required i64 startTime
+	4: required i64 elapsed
+	5: required i64 series
+	6: required i64 datapoints
+	7: required i64 bytes
+}
+
+struct NodeLiveQueriesResult {
+	1: required list<NodeLiveQuery> queries
+}
+
+struct NodeCancelLiveQueryRequest {
+	1: required string id
+}
+
+struct NodeUndeleteExpiredFileSetsRequest {
+	1: required string nameSpace
+}
+
+struct NodeUndeleteExpiredFileSetsResult {
+	1: required string nameSpace
+	2: required i64 retentionPeriodNanos
+	3: required i64 expiredFileSetGracePeriodNanos
+}
+
+struct NodeHotSeriesRequest {
+	1: required string nameSpace
+	2: optional i64 limit = 0
+}
+
+struct NodeHotSeries {
+	1: required string id
+	2: required i64 shard
+	3: required double rate
+}
+
+struct NodeHotSeriesResult {
+	1: required string nameSpace
+	2: required list<NodeHotSeries> writes
+	3: required list<NodeHotSeries> reads
+}
+
+struct NodeFlushStatesRequest {
+	1: required string nameSpace
+	2: optional i64 shard = -1
+}
+
+struct NodeBlockFlushState {
+	1: required i64 shard
+	2: required i64 blockStartNanos
+	3: required bool warmFlushed
+	4: required i64 coldVersion
+	5: required i64 numFailures
+}
+
+struct NodeFlushStatesResult {
+	1: required string nameSpace
+	2: required list<NodeBlockFlushState> blocks
+	3: required i64 numShards
+	4: required i64 numBlocks
+	5: required i64 numWarmFlushed
+	6: required i64 numColdFlushed
+	7: required i64 numWarmFailed
+	8: required list<i64> coldBlockStartsNanos
+}
+
+struct NodeBootstrapRange {
+	1: required i64 startNanos
+	2: required i64 endNanos
+}
+
+struct NodeShardBootstrapProgress {
+	1: required i64 shard
+	2: required double percentComplete
+	3: required list<NodeBootstrapRange> remainingRanges
+	4: optional list<NodeBootstrapRange> availableRanges
+}
+
+struct NodeNamespaceBootstrapProgress {
+	1: required string nameSpace
+	2: required string bootstrapper
+	3: required double percentComplete
+	4: required list<NodeShardBootstrapProgress> shards
+	5: optional i64 availableFromNanos = 0
+}
+
+struct NodeBootstrapProgressResult {
+	1: required string state
+	2: required i64 startNanos
+	3: required double percentComplete
+	4: required i64 estimatedCompletionNanos
+	5: required list<NodeNamespaceBootstrapProgress> namespaces
+}
+
+struct NodeRebootstrapShardRequest {
+	1: required string nameSpace
+	2: required i64 shard
+	3: required i64 startNanos
+	4: required i64 endNanos
+}
+
+struct NodeRebootstrapShardResult {
+	1: required string nameSpace
+	2: required i64 shard
+	3: required i64 tookNanos
+}
+
+struct NodeShardBootstrapRanges {
+	1: required i64 shard
+	2: required list<NodeBootstrapRange> ranges
+}
+
+struct NodeBootstrapperAvailability {
+	1: required string bootstrapper
+	2: required list<NodeShardBootstrapRanges> fulfilled
+}
+
+struct NodeNamespaceBootstrapDryRun {
+	1: required string nameSpace
+	2: required list<NodeBootstrapperAvailability> data
+	3: required list<NodeShardBootstrapRanges> dataUnfulfilled
+	4: required list<NodeBootstrapperAvailability> index
+	5: required list<NodeShardBootstrapRanges> indexUnfulfilled
+}
+
+struct NodeBootstrapDryRunResult {
+	1: required list<NodeNamespaceBootstrapDryRun> namespaces
+}
+
 service Cluster {
 	HealthResult health() throws (1: Error err)
 	void write(1: WriteRequest req) throws (1: Error err)
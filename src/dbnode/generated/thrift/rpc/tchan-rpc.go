@@ -47,25 +47,38 @@ type TChanCluster interface {
 type TChanNode interface {
 	Aggregate(ctx thrift.Context, req *AggregateQueryRequest) (*AggregateQueryResult_, error)
 	AggregateRaw(ctx thrift.Context, req *AggregateQueryRawRequest) (*AggregateQueryRawResult_, error)
+	BootstrapDryRun(ctx thrift.Context) (*NodeBootstrapDryRunResult_, error)
 	Bootstrapped(ctx thrift.Context) (*NodeBootstrappedResult_, error)
 	BootstrappedInPlacementOrNoPlacement(ctx thrift.Context) (*NodeBootstrappedInPlacementOrNoPlacementResult_, error)
+	CancelLiveQuery(ctx thrift.Context, req *NodeCancelLiveQueryRequest) (*NodeLiveQueriesResult_, error)
 	Fetch(ctx thrift.Context, req *FetchRequest) (*FetchResult_, error)
 	FetchBatchRaw(ctx thrift.Context, req *FetchBatchRawRequest) (*FetchBatchRawResult_, error)
 	FetchBlocksMetadataRawV2(ctx thrift.Context, req *FetchBlocksMetadataRawV2Request) (*FetchBlocksMetadataRawV2Result_, error)
 	FetchBlocksRaw(ctx thrift.Context, req *FetchBlocksRawRequest) (*FetchBlocksRawResult_, error)
 	FetchTagged(ctx thrift.Context, req *FetchTaggedRequest) (*FetchTaggedResult_, error)
+	GetBootstrapProgress(ctx thrift.Context) (*NodeBootstrapProgressResult_, error)
+	GetFlushStates(ctx thrift.Context, req *NodeFlushStatesRequest) (*NodeFlushStatesResult_, error)
+	GetHotSeries(ctx thrift.Context, req *NodeHotSeriesRequest) (*NodeHotSeriesResult_, error)
+	GetLiveQueries(ctx thrift.Context) (*NodeLiveQueriesResult_, error)
+	GetPausedBackgroundTasks(ctx thrift.Context) (*NodePausedBackgroundTasksResult_, error)
 	GetPersistRateLimit(ctx thrift.Context) (*NodePersistRateLimitResult_, error)
+	GetRPCByteUsage(ctx thrift.Context) (*NodeRPCByteUsageResult_, error)
 	GetWriteNewSeriesAsync(ctx thrift.Context) (*NodeWriteNewSeriesAsyncResult_, error)
 	GetWriteNewSeriesBackoffDuration(ctx thrift.Context) (*NodeWriteNewSeriesBackoffDurationResult_, error)
 	GetWriteNewSeriesLimitPerShardPerSecond(ctx thrift.Context) (*NodeWriteNewSeriesLimitPerShardPerSecondResult_, error)
 	Health(ctx thrift.Context) (*NodeHealthResult_, error)
+	PauseBackgroundTask(ctx thrift.Context, req *NodePauseBackgroundTaskRequest) (*NodePausedBackgroundTasksResult_, error)
 	Query(ctx thrift.Context, req *QueryRequest) (*QueryResult_, error)
+	RebootstrapShard(ctx thrift.Context, req *NodeRebootstrapShardRequest) (*NodeRebootstrapShardResult_, error)
 	Repair(ctx thrift.Context) error
+	ResumeBackgroundTask(ctx thrift.Context, req *NodeResumeBackgroundTaskRequest) (*NodePausedBackgroundTasksResult_, error)
 	SetPersistRateLimit(ctx thrift.Context, req *NodeSetPersistRateLimitRequest) (*NodePersistRateLimitResult_, error)
 	SetWriteNewSeriesAsync(ctx thrift.Context, req *NodeSetWriteNewSeriesAsyncRequest) (*NodeWriteNewSeriesAsyncResult_, error)
 	SetWriteNewSeriesBackoffDuration(ctx thrift.Context, req *NodeSetWriteNewSeriesBackoffDurationRequest) (*NodeWriteNewSeriesBackoffDurationResult_, error)
 	SetWriteNewSeriesLimitPerShardPerSecond(ctx thrift.Context, req *NodeSetWriteNewSeriesLimitPerShardPerSecondRequest) (*NodeWriteNewSeriesLimitPerShardPerSecondResult_, error)
 	Truncate(ctx thrift.Context, req *TruncateRequest) (*TruncateResult_, error)
+	UndeleteExpiredFileSets(ctx thrift.Context, req *NodeUndeleteExpiredFileSetsRequest) (*NodeUndeleteExpiredFileSetsResult_, error)
+	ValidateWriteTagged(ctx thrift.Context, req *WriteTaggedRequest) error
 	Write(ctx thrift.Context, req *WriteRequest) error
 	WriteBatchRaw(ctx thrift.Context, req *WriteBatchRawRequest) error
 	WriteTagged(ctx thrift.Context, req *WriteTaggedRequest) error
@@ -512,6 +525,22 @@ func (c *tchanNodeClient) AggregateRaw(ctx thrift.Context, req *AggregateQueryRa
 	return resp.GetSuccess(), err
 }
 
+func (c *tchanNodeClient) BootstrapDryRun(ctx thrift.Context) (*NodeBootstrapDryRunResult_, error) {
+	var resp NodeBootstrapDryRunResult
+	args := NodeBootstrapDryRunArgs{}
+	success, err := c.client.Call(ctx, c.thriftService, "bootstrapDryRun", &args, &resp)
+	if err == nil && !success {
+		switch {
+		case resp.Err != nil:
+			err = resp.Err
+		default:
+			err = fmt.Errorf("received no result or unknown exception for bootstrapDryRun")
+		}
+	}
+
+	return resp.GetSuccess(), err
+}
+
 func (c *tchanNodeClient) Bootstrapped(ctx thrift.Context) (*NodeBootstrappedResult_, error) {
 	var resp NodeBootstrappedResult
 	args := NodeBootstrappedArgs{}
@@ -544,6 +573,24 @@ func (c *tchanNodeClient) BootstrappedInPlacementOrNoPlacement(ctx thrift.Contex
 	return resp.GetSuccess(), err
 }
 
+func (c *tchanNodeClient) CancelLiveQuery(ctx thrift.Context, req *NodeCancelLiveQueryRequest) (*NodeLiveQueriesResult_, error) {
+	var resp NodeCancelLiveQueryResult
+	args := NodeCancelLiveQueryArgs{
+		Req: req,
+	}
+	success, err := c.client.Call(ctx, c.thriftService, "cancelLiveQuery", &args, &resp)
+	if err == nil && !success {
+		switch {
+		case resp.Err != nil:
+			err = resp.Err
+		default:
+			err = fmt.Errorf("received no result or unknown exception for cancelLiveQuery")
+		}
+	}
+
+	return resp.GetSuccess(), err
+}
+
 func (c *tchanNodeClient) Fetch(ctx thrift.Context, req *FetchRequest) (*FetchResult_, error) {
 	var resp NodeFetchResult
 	args := NodeFetchArgs{
@@ -634,6 +681,90 @@ func (c *tchanNodeClient) FetchTagged(ctx thrift.Context, req *FetchTaggedReques
 	return resp.GetSuccess(), err
 }
 
+func (c *tchanNodeClient) GetBootstrapProgress(ctx thrift.Context) (*NodeBootstrapProgressResult_, error) {
+	var resp NodeGetBootstrapProgressResult
+	args := NodeGetBootstrapProgressArgs{}
+	success, err := c.client.Call(ctx, c.thriftService, "getBootstrapProgress", &args, &resp)
+	if err == nil && !success {
+		switch {
+		case resp.Err != nil:
+			err = resp.Err
+		default:
+			err = fmt.Errorf("received no result or unknown exception for getBootstrapProgress")
+		}
+	}
+
+	return resp.GetSuccess(), err
+}
+
+func (c *tchanNodeClient) GetFlushStates(ctx thrift.Context, req *NodeFlushStatesRequest) (*NodeFlushStatesResult_, error) {
+	var resp NodeGetFlushStatesResult
+	args := NodeGetFlushStatesArgs{
+		Req: req,
+	}
+	success, err := c.client.Call(ctx, c.thriftService, "getFlushStates", &args, &resp)
+	if err == nil && !success {
+		switch {
+		case resp.Err != nil:
+			err = resp.Err
+		default:
+			err = fmt.Errorf("received no result or unknown exception for getFlushStates")
+		}
+	}
+
+	return resp.GetSuccess(), err
+}
+
+func (c *tchanNodeClient) GetHotSeries(ctx thrift.Context, req *NodeHotSeriesRequest) (*NodeHotSeriesResult_, error) {
+	var resp NodeGetHotSeriesResult
+	args := NodeGetHotSeriesArgs{
+		Req: req,
+	}
+	success, err := c.client.Call(ctx, c.thriftService, "getHotSeries", &args, &resp)
+	if err == nil && !success {
+		switch {
+		case resp.Err != nil:
+			err = resp.Err
+		default:
+			err = fmt.Errorf("received no result or unknown exception for getHotSeries")
+		}
+	}
+
+	return resp.GetSuccess(), err
+}
+
+func (c *tchanNodeClient) GetLiveQueries(ctx thrift.Context) (*NodeLiveQueriesResult_, error) {
+	var resp NodeGetLiveQueriesResult
+	args := NodeGetLiveQueriesArgs{}
+	success, err := c.client.Call(ctx, c.thriftService, "getLiveQueries", &args, &resp)
+	if err == nil && !success {
+		switch {
+		case resp.Err != nil:
+			err = resp.Err
+		default:
+			err = fmt.Errorf("received no result or unknown exception for getLiveQueries")
+		}
+	}
+
+	return resp.GetSuccess(), err
+}
+
+func (c *tchanNodeClient) GetPausedBackgroundTasks(ctx thrift.Context) (*NodePausedBackgroundTasksResult_, error) {
+	var resp NodeGetPausedBackgroundTasksResult
+	args := NodeGetPausedBackgroundTasksArgs{}
+	success, err := c.client.Call(ctx, c.thriftService, "getPausedBackgroundTasks", &args, &resp)
+	if err == nil && !success {
+		switch {
+		case resp.Err != nil:
+			err = resp.Err
+		default:
+			err = fmt.Errorf("received no result or unknown exception for getPausedBackgroundTasks")
+		}
+	}
+
+	return resp.GetSuccess(), err
+}
+
 func (c *tchanNodeClient) GetPersistRateLimit(ctx thrift.Context) (*NodePersistRateLimitResult_, error) {
 	var resp NodeGetPersistRateLimitResult
 	args := NodeGetPersistRateLimitArgs{}
@@ -650,6 +781,22 @@ func (c *tchanNodeClient) GetPersistRateLimit(ctx thrift.Context) (*NodePersistR
 	return resp.GetSuccess(), err
 }
 
+func (c *tchanNodeClient) GetRPCByteUsage(ctx thrift.Context) (*NodeRPCByteUsageResult_, error) {
+	var resp NodeGetRPCByteUsageResult
+	args := NodeGetRPCByteUsageArgs{}
+	success, err := c.client.Call(ctx, c.thriftService, "getRPCByteUsage", &args, &resp)
+	if err == nil && !success {
+		switch {
+		case resp.Err != nil:
+			err = resp.Err
+		default:
+			err = fmt.Errorf("received no result or unknown exception for getRPCByteUsage")
+		}
+	}
+
+	return resp.GetSuccess(), err
+}
+
 func (c *tchanNodeClient) GetWriteNewSeriesAsync(ctx thrift.Context) (*NodeWriteNewSeriesAsyncResult_, error) {
 	var resp NodeGetWriteNewSeriesAsyncResult
 	args := NodeGetWriteNewSeriesAsyncArgs{}
@@ -714,6 +861,24 @@ func (c *tchanNodeClient) Health(ctx thrift.Context) (*NodeHealthResult_, error)
 	return resp.GetSuccess(), err
 }
 
+func (c *tchanNodeClient) PauseBackgroundTask(ctx thrift.Context, req *NodePauseBackgroundTaskRequest) (*NodePausedBackgroundTasksResult_, error) {
+	var resp NodePauseBackgroundTaskResult
+	args := NodePauseBackgroundTaskArgs{
+		Req: req,
+	}
+	success, err := c.client.Call(ctx, c.thriftService, "pauseBackgroundTask", &args, &resp)
+	if err == nil && !success {
+		switch {
+		case resp.Err != nil:
+			err = resp.Err
+		default:
+			err = fmt.Errorf("received no result or unknown exception for pauseBackgroundTask")
+		}
+	}
+
+	return resp.GetSuccess(), err
+}
+
 func (c *tchanNodeClient) Query(ctx thrift.Context, req *QueryRequest) (*QueryResult_, error) {
 	var resp NodeQueryResult
 	args := NodeQueryArgs{
@@ -732,6 +897,24 @@ func (c *tchanNodeClient) Query(ctx thrift.Context, req *QueryRequest) (*QueryRe
 	return resp.GetSuccess(), err
 }
 
+func (c *tchanNodeClient) RebootstrapShard(ctx thrift.Context, req *NodeRebootstrapShardRequest) (*NodeRebootstrapShardResult_, error) {
+	var resp NodeRebootstrapShardResult
+	args := NodeRebootstrapShardArgs{
+		Req: req,
+	}
+	success, err := c.client.Call(ctx, c.thriftService, "rebootstrapShard", &args, &resp)
+	if err == nil && !success {
+		switch {
+		case resp.Err != nil:
+			err = resp.Err
+		default:
+			err = fmt.Errorf("received no result or unknown exception for rebootstrapShard")
+		}
+	}
+
+	return resp.GetSuccess(), err
+}
+
 func (c *tchanNodeClient) Repair(ctx thrift.Context) error {
 	var resp NodeRepairResult
 	args := NodeRepairArgs{}
@@ -748,6 +931,24 @@ func (c *tchanNodeClient) Repair(ctx thrift.Context) error {
 	return err
 }
 
+func (c *tchanNodeClient) ResumeBackgroundTask(ctx thrift.Context, req *NodeResumeBackgroundTaskRequest) (*NodePausedBackgroundTasksResult_, error) {
+	var resp NodeResumeBackgroundTaskResult
+	args := NodeResumeBackgroundTaskArgs{
+		Req: req,
+	}
+	success, err := c.client.Call(ctx, c.thriftService, "resumeBackgroundTask", &args, &resp)
+	if err == nil && !success {
+		switch {
+		case resp.Err != nil:
+			err = resp.Err
+		default:
+			err = fmt.Errorf("received no result or unknown exception for resumeBackgroundTask")
+		}
+	}
+
+	return resp.GetSuccess(), err
+}
+
 func (c *tchanNodeClient) SetPersistRateLimit(ctx thrift.Context, req *NodeSetPersistRateLimitRequest) (*NodePersistRateLimitResult_, error) {
 	var resp NodeSetPersistRateLimitResult
 	args := NodeSetPersistRateLimitArgs{
@@ -838,6 +1039,42 @@ func (c *tchanNodeClient) Truncate(ctx thrift.Context, req *TruncateRequest) (*T
 	return resp.GetSuccess(), err
 }
 
+func (c *tchanNodeClient) UndeleteExpiredFileSets(ctx thrift.Context, req *NodeUndeleteExpiredFileSetsRequest) (*NodeUndeleteExpiredFileSetsResult_, error) {
+	var resp NodeUndeleteExpiredFileSetsResult
+	args := NodeUndeleteExpiredFileSetsArgs{
+		Req: req,
+	}
+	success, err := c.client.Call(ctx, c.thriftService, "undeleteExpiredFileSets", &args, &resp)
+	if err == nil && !success {
+		switch {
+		case resp.Err != nil:
+			err = resp.Err
+		default:
+			err = fmt.Errorf("received no result or unknown exception for undeleteExpiredFileSets")
+		}
+	}
+
+	return resp.GetSuccess(), err
+}
+
+func (c *tchanNodeClient) ValidateWriteTagged(ctx thrift.Context, req *WriteTaggedRequest) error {
+	var resp NodeValidateWriteTaggedResult
+	args := NodeValidateWriteTaggedArgs{
+		Req: req,
+	}
+	success, err := c.client.Call(ctx, c.thriftService, "validateWriteTagged", &args, &resp)
+	if err == nil && !success {
+		switch {
+		case resp.Err != nil:
+			err = resp.Err
+		default:
+			err = fmt.Errorf("received no result or unknown exception for validateWriteTagged")
+		}
+	}
+
+	return err
+}
+
 func (c *tchanNodeClient) Write(ctx thrift.Context, req *WriteRequest) error {
 	var resp NodeWriteResult
 	args := NodeWriteArgs{
@@ -930,25 +1167,38 @@ func (s *tchanNodeServer) Methods() []string {
 	return []string{
 		"aggregate",
 		"aggregateRaw",
+		"bootstrapDryRun",
 		"bootstrapped",
 		"bootstrappedInPlacementOrNoPlacement",
+		"cancelLiveQuery",
 		"fetch",
 		"fetchBatchRaw",
 		"fetchBlocksMetadataRawV2",
 		"fetchBlocksRaw",
 		"fetchTagged",
+		"getBootstrapProgress",
+		"getFlushStates",
+		"getHotSeries",
+		"getLiveQueries",
+		"getPausedBackgroundTasks",
 		"getPersistRateLimit",
+		"getRPCByteUsage",
 		"getWriteNewSeriesAsync",
 		"getWriteNewSeriesBackoffDuration",
 		"getWriteNewSeriesLimitPerShardPerSecond",
 		"health",
+		"pauseBackgroundTask",
 		"query",
+		"rebootstrapShard",
 		"repair",
+		"resumeBackgroundTask",
 		"setPersistRateLimit",
 		"setWriteNewSeriesAsync",
 		"setWriteNewSeriesBackoffDuration",
 		"setWriteNewSeriesLimitPerShardPerSecond",
 		"truncate",
+		"undeleteExpiredFileSets",
+		"validateWriteTagged",
 		"write",
 		"writeBatchRaw",
 		"writeTagged",
@@ -962,10 +1212,14 @@ func (s *tchanNodeServer) Handle(ctx thrift.Context, methodName string, protocol
 		return s.handleAggregate(ctx, protocol)
 	case "aggregateRaw":
 		return s.handleAggregateRaw(ctx, protocol)
+	case "bootstrapDryRun":
+		return s.handleBootstrapDryRun(ctx, protocol)
 	case "bootstrapped":
 		return s.handleBootstrapped(ctx, protocol)
 	case "bootstrappedInPlacementOrNoPlacement":
 		return s.handleBootstrappedInPlacementOrNoPlacement(ctx, protocol)
+	case "cancelLiveQuery":
+		return s.handleCancelLiveQuery(ctx, protocol)
 	case "fetch":
 		return s.handleFetch(ctx, protocol)
 	case "fetchBatchRaw":
@@ -976,8 +1230,20 @@ func (s *tchanNodeServer) Handle(ctx thrift.Context, methodName string, protocol
 		return s.handleFetchBlocksRaw(ctx, protocol)
 	case "fetchTagged":
 		return s.handleFetchTagged(ctx, protocol)
+	case "getBootstrapProgress":
+		return s.handleGetBootstrapProgress(ctx, protocol)
+	case "getFlushStates":
+		return s.handleGetFlushStates(ctx, protocol)
+	case "getHotSeries":
+		return s.handleGetHotSeries(ctx, protocol)
+	case "getLiveQueries":
+		return s.handleGetLiveQueries(ctx, protocol)
+	case "getPausedBackgroundTasks":
+		return s.handleGetPausedBackgroundTasks(ctx, protocol)
 	case "getPersistRateLimit":
 		return s.handleGetPersistRateLimit(ctx, protocol)
+	case "getRPCByteUsage":
+		return s.handleGetRPCByteUsage(ctx, protocol)
 	case "getWriteNewSeriesAsync":
 		return s.handleGetWriteNewSeriesAsync(ctx, protocol)
 	case "getWriteNewSeriesBackoffDuration":
@@ -986,10 +1252,16 @@ func (s *tchanNodeServer) Handle(ctx thrift.Context, methodName string, protocol
 		return s.handleGetWriteNewSeriesLimitPerShardPerSecond(ctx, protocol)
 	case "health":
 		return s.handleHealth(ctx, protocol)
+	case "pauseBackgroundTask":
+		return s.handlePauseBackgroundTask(ctx, protocol)
 	case "query":
 		return s.handleQuery(ctx, protocol)
+	case "rebootstrapShard":
+		return s.handleRebootstrapShard(ctx, protocol)
 	case "repair":
 		return s.handleRepair(ctx, protocol)
+	case "resumeBackgroundTask":
+		return s.handleResumeBackgroundTask(ctx, protocol)
 	case "setPersistRateLimit":
 		return s.handleSetPersistRateLimit(ctx, protocol)
 	case "setWriteNewSeriesAsync":
@@ -1000,6 +1272,10 @@ func (s *tchanNodeServer) Handle(ctx thrift.Context, methodName string, protocol
 		return s.handleSetWriteNewSeriesLimitPerShardPerSecond(ctx, protocol)
 	case "truncate":
 		return s.handleTruncate(ctx, protocol)
+	case "undeleteExpiredFileSets":
+		return s.handleUndeleteExpiredFileSets(ctx, protocol)
+	case "validateWriteTagged":
+		return s.handleValidateWriteTagged(ctx, protocol)
 	case "write":
 		return s.handleWrite(ctx, protocol)
 	case "writeBatchRaw":
@@ -1070,6 +1346,34 @@ func (s *tchanNodeServer) handleAggregateRaw(ctx thrift.Context, protocol athrif
 	return err == nil, &res, nil
 }
 
+func (s *tchanNodeServer) handleBootstrapDryRun(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
+	var req NodeBootstrapDryRunArgs
+	var res NodeBootstrapDryRunResult
+
+	if err := req.Read(protocol); err != nil {
+		return false, nil, err
+	}
+
+	r, err :=
+		s.handler.BootstrapDryRun(ctx)
+
+	if err != nil {
+		switch v := err.(type) {
+		case *Error:
+			if v == nil {
+				return false, nil, fmt.Errorf("Handler for err returned non-nil error type *Error but nil value")
+			}
+			res.Err = v
+		default:
+			return false, nil, err
+		}
+	} else {
+		res.Success = r
+	}
+
+	return err == nil, &res, nil
+}
+
 func (s *tchanNodeServer) handleBootstrapped(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req NodeBootstrappedArgs
 	var res NodeBootstrappedResult
@@ -1126,6 +1430,34 @@ func (s *tchanNodeServer) handleBootstrappedInPlacementOrNoPlacement(ctx thrift.
 	return err == nil, &res, nil
 }
 
+func (s *tchanNodeServer) handleCancelLiveQuery(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
+	var req NodeCancelLiveQueryArgs
+	var res NodeCancelLiveQueryResult
+
+	if err := req.Read(protocol); err != nil {
+		return false, nil, err
+	}
+
+	r, err :=
+		s.handler.CancelLiveQuery(ctx, req.Req)
+
+	if err != nil {
+		switch v := err.(type) {
+		case *Error:
+			if v == nil {
+				return false, nil, fmt.Errorf("Handler for err returned non-nil error type *Error but nil value")
+			}
+			res.Err = v
+		default:
+			return false, nil, err
+		}
+	} else {
+		res.Success = r
+	}
+
+	return err == nil, &res, nil
+}
+
 func (s *tchanNodeServer) handleFetch(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req NodeFetchArgs
 	var res NodeFetchResult
@@ -1266,6 +1598,146 @@ func (s *tchanNodeServer) handleFetchTagged(ctx thrift.Context, protocol athrift
 	return err == nil, &res, nil
 }
 
+func (s *tchanNodeServer) handleGetBootstrapProgress(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
+	var req NodeGetBootstrapProgressArgs
+	var res NodeGetBootstrapProgressResult
+
+	if err := req.Read(protocol); err != nil {
+		return false, nil, err
+	}
+
+	r, err :=
+		s.handler.GetBootstrapProgress(ctx)
+
+	if err != nil {
+		switch v := err.(type) {
+		case *Error:
+			if v == nil {
+				return false, nil, fmt.Errorf("Handler for err returned non-nil error type *Error but nil value")
+			}
+			res.Err = v
+		default:
+			return false, nil, err
+		}
+	} else {
+		res.Success = r
+	}
+
+	return err == nil, &res, nil
+}
+
+func (s *tchanNodeServer) handleGetFlushStates(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
+	var req NodeGetFlushStatesArgs
+	var res NodeGetFlushStatesResult
+
+	if err := req.Read(protocol); err != nil {
+		return false, nil, err
+	}
+
+	r, err :=
+		s.handler.GetFlushStates(ctx, req.Req)
+
+	if err != nil {
+		switch v := err.(type) {
+		case *Error:
+			if v == nil {
+				return false, nil, fmt.Errorf("Handler for err returned non-nil error type *Error but nil value")
+			}
+			res.Err = v
+		default:
+			return false, nil, err
+		}
+	} else {
+		res.Success = r
+	}
+
+	return err == nil, &res, nil
+}
+
+func (s *tchanNodeServer) handleGetHotSeries(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
+	var req NodeGetHotSeriesArgs
+	var res NodeGetHotSeriesResult
+
+	if err := req.Read(protocol); err != nil {
+		return false, nil, err
+	}
+
+	r, err :=
+		s.handler.GetHotSeries(ctx, req.Req)
+
+	if err != nil {
+		switch v := err.(type) {
+		case *Error:
+			if v == nil {
+				return false, nil, fmt.Errorf("Handler for err returned non-nil error type *Error but nil value")
+			}
+			res.Err = v
+		default:
+			return false, nil, err
+		}
+	} else {
+		res.Success = r
+	}
+
+	return err == nil, &res, nil
+}
+
+func (s *tchanNodeServer) handleGetLiveQueries(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
+	var req NodeGetLiveQueriesArgs
+	var res NodeGetLiveQueriesResult
+
+	if err := req.Read(protocol); err != nil {
+		return false, nil, err
+	}
+
+	r, err :=
+		s.handler.GetLiveQueries(ctx)
+
+	if err != nil {
+		switch v := err.(type) {
+		case *Error:
+			if v == nil {
+				return false, nil, fmt.Errorf("Handler for err returned non-nil error type *Error but nil value")
+			}
+			res.Err = v
+		default:
+			return false, nil, err
+		}
+	} else {
+		res.Success = r
+	}
+
+	return err == nil, &res, nil
+}
+
+func (s *tchanNodeServer) handleGetPausedBackgroundTasks(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
+	var req NodeGetPausedBackgroundTasksArgs
+	var res NodeGetPausedBackgroundTasksResult
+
+	if err := req.Read(protocol); err != nil {
+		return false, nil, err
+	}
+
+	r, err :=
+		s.handler.GetPausedBackgroundTasks(ctx)
+
+	if err != nil {
+		switch v := err.(type) {
+		case *Error:
+			if v == nil {
+				return false, nil, fmt.Errorf("Handler for err returned non-nil error type *Error but nil value")
+			}
+			res.Err = v
+		default:
+			return false, nil, err
+		}
+	} else {
+		res.Success = r
+	}
+
+	return err == nil, &res, nil
+}
+
 func (s *tchanNodeServer) handleGetPersistRateLimit(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req NodeGetPersistRateLimitArgs
 	var res NodeGetPersistRateLimitResult
@@ -1294,6 +1766,34 @@ func (s *tchanNodeServer) handleGetPersistRateLimit(ctx thrift.Context, protocol
 	return err == nil, &res, nil
 }
 
+func (s *tchanNodeServer) handleGetRPCByteUsage(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
+	var req NodeGetRPCByteUsageArgs
+	var res NodeGetRPCByteUsageResult
+
+	if err := req.Read(protocol); err != nil {
+		return false, nil, err
+	}
+
+	r, err :=
+		s.handler.GetRPCByteUsage(ctx)
+
+	if err != nil {
+		switch v := err.(type) {
+		case *Error:
+			if v == nil {
+				return false, nil, fmt.Errorf("Handler for err returned non-nil error type *Error but nil value")
+			}
+			res.Err = v
+		default:
+			return false, nil, err
+		}
+	} else {
+		res.Success = r
+	}
+
+	return err == nil, &res, nil
+}
+
 func (s *tchanNodeServer) handleGetWriteNewSeriesAsync(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req NodeGetWriteNewSeriesAsyncArgs
 	var res NodeGetWriteNewSeriesAsyncResult
@@ -1406,6 +1906,34 @@ func (s *tchanNodeServer) handleHealth(ctx thrift.Context, protocol athrift.TPro
 	return err == nil, &res, nil
 }
 
+func (s *tchanNodeServer) handlePauseBackgroundTask(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
+	var req NodePauseBackgroundTaskArgs
+	var res NodePauseBackgroundTaskResult
+
+	if err := req.Read(protocol); err != nil {
+		return false, nil, err
+	}
+
+	r, err :=
+		s.handler.PauseBackgroundTask(ctx, req.Req)
+
+	if err != nil {
+		switch v := err.(type) {
+		case *Error:
+			if v == nil {
+				return false, nil, fmt.Errorf("Handler for err returned non-nil error type *Error but nil value")
+			}
+			res.Err = v
+		default:
+			return false, nil, err
+		}
+	} else {
+		res.Success = r
+	}
+
+	return err == nil, &res, nil
+}
+
 func (s *tchanNodeServer) handleQuery(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req NodeQueryArgs
 	var res NodeQueryResult
@@ -1434,6 +1962,34 @@ func (s *tchanNodeServer) handleQuery(ctx thrift.Context, protocol athrift.TProt
 	return err == nil, &res, nil
 }
 
+func (s *tchanNodeServer) handleRebootstrapShard(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
+	var req NodeRebootstrapShardArgs
+	var res NodeRebootstrapShardResult
+
+	if err := req.Read(protocol); err != nil {
+		return false, nil, err
+	}
+
+	r, err :=
+		s.handler.RebootstrapShard(ctx, req.Req)
+
+	if err != nil {
+		switch v := err.(type) {
+		case *Error:
+			if v == nil {
+				return false, nil, fmt.Errorf("Handler for err returned non-nil error type *Error but nil value")
+			}
+			res.Err = v
+		default:
+			return false, nil, err
+		}
+	} else {
+		res.Success = r
+	}
+
+	return err == nil, &res, nil
+}
+
 func (s *tchanNodeServer) handleRepair(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req NodeRepairArgs
 	var res NodeRepairResult
@@ -1461,6 +2017,34 @@ func (s *tchanNodeServer) handleRepair(ctx thrift.Context, protocol athrift.TPro
 	return err == nil, &res, nil
 }
 
+func (s *tchanNodeServer) handleResumeBackgroundTask(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
+	var req NodeResumeBackgroundTaskArgs
+	var res NodeResumeBackgroundTaskResult
+
+	if err := req.Read(protocol); err != nil {
+		return false, nil, err
+	}
+
+	r, err :=
+		s.handler.ResumeBackgroundTask(ctx, req.Req)
+
+	if err != nil {
+		switch v := err.(type) {
+		case *Error:
+			if v == nil {
+				return false, nil, fmt.Errorf("Handler for err returned non-nil error type *Error but nil value")
+			}
+			res.Err = v
+		default:
+			return false, nil, err
+		}
+	} else {
+		res.Success = r
+	}
+
+	return err == nil, &res, nil
+}
+
 func (s *tchanNodeServer) handleSetPersistRateLimit(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req NodeSetPersistRateLimitArgs
 	var res NodeSetPersistRateLimitResult
@@ -1601,6 +2185,61 @@ func (s *tchanNodeServer) handleTruncate(ctx thrift.Context, protocol athrift.TP
 	return err == nil, &res, nil
 }
 
+func (s *tchanNodeServer) handleUndeleteExpiredFileSets(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
+	var req NodeUndeleteExpiredFileSetsArgs
+	var res NodeUndeleteExpiredFileSetsResult
+
+	if err := req.Read(protocol); err != nil {
+		return false, nil, err
+	}
+
+	r, err :=
+		s.handler.UndeleteExpiredFileSets(ctx, req.Req)
+
+	if err != nil {
+		switch v := err.(type) {
+		case *Error:
+			if v == nil {
+				return false, nil, fmt.Errorf("Handler for err returned non-nil error type *Error but nil value")
+			}
+			res.Err = v
+		default:
+			return false, nil, err
+		}
+	} else {
+		res.Success = r
+	}
+
+	return err == nil, &res, nil
+}
+
+func (s *tchanNodeServer) handleValidateWriteTagged(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
+	var req NodeValidateWriteTaggedArgs
+	var res NodeValidateWriteTaggedResult
+
+	if err := req.Read(protocol); err != nil {
+		return false, nil, err
+	}
+
+	err :=
+		s.handler.ValidateWriteTagged(ctx, req.Req)
+
+	if err != nil {
+		switch v := err.(type) {
+		case *Error:
+			if v == nil {
+				return false, nil, fmt.Errorf("Handler for err returned non-nil error type *Error but nil value")
+			}
+			res.Err = v
+		default:
+			return false, nil, err
+		}
+	} else {
+	}
+
+	return err == nil, &res, nil
+}
+
 func (s *tchanNodeServer) handleWrite(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req NodeWriteArgs
 	var res NodeWriteResult
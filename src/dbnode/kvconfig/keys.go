@@ -44,4 +44,24 @@ const (
 	// ClientWriteConsistencyLevel is the KV config key for the runtime
 	// configuration specifying the client write consistency level
 	ClientWriteConsistencyLevel = "m3db.client.write-consistency-level"
+
+	// CommitLogFsyncPolicyKey is the KV config key for the runtime
+	// configuration specifying the commit log fsync policy, e.g.
+	// "every_bytes:1048576".
+	CommitLogFsyncPolicyKey = "m3db.node.commitlog-fsync-policy"
+
+	// WriteBlackoutWindowsKey is the KV config key for the runtime
+	// configuration specifying the namespace write blackout windows, e.g.
+	// "metrics,reject,2020-01-01T00:00:00Z,2020-01-01T01:00:00Z".
+	WriteBlackoutWindowsKey = "m3db.node.write-blackout-windows"
+
+	// ReadBlockRulesKey is the KV config key for the runtime configuration
+	// specifying the rules rejecting namespace reads and queries, e.g.
+	// "metrics,app=dashboards".
+	ReadBlockRulesKey = "m3db.node.read-block-rules"
+
+	// PeersBootstrapLimitsKey is the KV config key for the runtime
+	// configuration specifying the limits throttling the peers bootstrap, e.g.
+	// "bytes_per_second_per_peer:52428800,max_concurrent_shards:4".
+	PeersBootstrapLimitsKey = "m3db.node.peers-bootstrap-limits"
 )
@@ -48,15 +48,16 @@ func (m *MapConfiguration) Map() (Map, error) {
 
 // MetadataConfiguration is the configuration for a single namespace
 type MetadataConfiguration struct {
-	ID                string                  `yaml:"id" validate:"nonzero"`
-	BootstrapEnabled  *bool                   `yaml:"bootstrapEnabled"`
-	FlushEnabled      *bool                   `yaml:"flushEnabled"`
-	WritesToCommitLog *bool                   `yaml:"writesToCommitLog"`
-	CleanupEnabled    *bool                   `yaml:"cleanupEnabled"`
-	RepairEnabled     *bool                   `yaml:"repairEnabled"`
-	ColdWritesEnabled *bool                   `yaml:"coldWritesEnabled"`
-	Retention         retention.Configuration `yaml:"retention" validate:"nonzero"`
-	Index             IndexConfiguration      `yaml:"index"`
+	ID                string                    `yaml:"id" validate:"nonzero"`
+	BootstrapEnabled  *bool                     `yaml:"bootstrapEnabled"`
+	FlushEnabled      *bool                     `yaml:"flushEnabled"`
+	WritesToCommitLog *bool                     `yaml:"writesToCommitLog"`
+	CleanupEnabled    *bool                     `yaml:"cleanupEnabled"`
+	RepairEnabled     *bool                     `yaml:"repairEnabled"`
+	ColdWritesEnabled *bool                     `yaml:"coldWritesEnabled"`
+	BloomFilter       *BloomFilterConfiguration `yaml:"bloomFilter"`
+	Retention         retention.Configuration   `yaml:"retention" validate:"nonzero"`
+	Index             IndexConfiguration        `yaml:"index"`
 }
 
 // Metadata returns a Metadata corresponding to the receiver struct
@@ -84,18 +85,47 @@ func (mc *MetadataConfiguration) Metadata() (Metadata, error) {
 	if v := mc.ColdWritesEnabled; v != nil {
 		opts = opts.SetColdWritesEnabled(*v)
 	}
+	if v := mc.BloomFilter; v != nil {
+		opts = v.Options(opts)
+	}
 	return NewMetadata(ident.StringID(mc.ID), opts)
 }
 
+// BloomFilterConfiguration controls the ID bloom filters of filesets.
+type BloomFilterConfiguration struct {
+	// Enabled sets whether bloom filters are loaded, defaults to true.
+	Enabled *bool `yaml:"enabled"`
+
+	// FalsePositivePercent is the target false positive percent of the bloom
+	// filters written, defaults to the filesystem configuration.
+	FalsePositivePercent *float64 `yaml:"falsePositivePercent"`
+}
+
+// Options returns the namespace options with the bloom filter configuration applied.
+func (bc *BloomFilterConfiguration) Options(opts Options) Options {
+	if v := bc.Enabled; v != nil {
+		opts = opts.SetBloomFilterEnabled(*v)
+	}
+	if v := bc.FalsePositivePercent; v != nil {
+		opts = opts.SetBloomFilterFalsePositivePercent(*v)
+	}
+	return opts
+}
+
 // IndexConfiguration controls the knobs to tweak indexing configuration.
 type IndexConfiguration struct {
 	Enabled   bool          `yaml:"enabled" validate:"nonzero"`
 	BlockSize time.Duration `yaml:"blockSize" validate:"nonzero"`
+
+	// AnnotationFields are the fields of proto annotations to index as
+	// additional tags of the series they are written to.
+	AnnotationFields []string `yaml:"annotationFields"`
 }
 
 // Options returns the IndexOptions corresponding to the receiver struct.
 func (ic *IndexConfiguration) Options() IndexOptions {
 	return NewIndexOptions().
 		SetEnabled(ic.Enabled).
-		SetBlockSize(ic.BlockSize)
+		SetBlockSize(ic.BlockSize).
+		SetAnnotationFields(ic.AnnotationFields)
 }
@@ -98,8 +98,9 @@ func TestMetadataConfig(t *testing.T) {
 			BufferPast:      time.Minute,
 		}
 		index = IndexConfiguration{
-			Enabled:   true,
-			BlockSize: time.Hour,
+			Enabled:          true,
+			BlockSize:        time.Hour,
+			AnnotationFields: []string{"a.b"},
 		}
 		config = &MetadataConfiguration{
 			ID:                id,
@@ -74,7 +74,8 @@ func ToIndexOptions(
 	}
 
 	iopts = iopts.SetEnabled(io.Enabled).
-		SetBlockSize(fromNanos(io.BlockSizeNanos))
+		SetBlockSize(fromNanos(io.BlockSizeNanos)).
+		SetAnnotationFields(io.AnnotationFields)
 
 	return iopts, nil
 }
@@ -115,6 +116,14 @@ func ToMetadata(
 		SetIndexOptions(iopts).
 		SetColdWritesEnabled(opts.ColdWritesEnabled)
 
+	// Namespaces registered before bloom filter options were added keep the
+	// default bloom filter options.
+	if bopts := opts.BloomFilterOptions; bopts != nil {
+		mopts = mopts.
+			SetBloomFilterEnabled(bopts.Enabled).
+			SetBloomFilterFalsePositivePercent(bopts.FalsePositivePercent)
+	}
+
 	return NewMetadata(ident.StringID(id), mopts)
 }
 
@@ -167,9 +176,14 @@ func OptionsToProto(opts Options) *nsproto.NamespaceOptions {
 			BlockDataExpiryAfterNotAccessPeriodNanos: ropts.BlockDataExpiryAfterNotAccessedPeriod().Nanoseconds(),
 		},
 		IndexOptions: &nsproto.IndexOptions{
-			Enabled:        iopts.Enabled(),
-			BlockSizeNanos: iopts.BlockSize().Nanoseconds(),
+			Enabled:          iopts.Enabled(),
+			BlockSizeNanos:   iopts.BlockSize().Nanoseconds(),
+			AnnotationFields: iopts.AnnotationFields(),
 		},
 		ColdWritesEnabled: opts.ColdWritesEnabled(),
+		BloomFilterOptions: &nsproto.BloomFilterOptions{
+			Enabled:              opts.BloomFilterEnabled(),
+			FalsePositivePercent: opts.BloomFilterFalsePositivePercent(),
+		},
 	}
 }
@@ -82,13 +82,15 @@ func genMap() gopter.Gen {
 func genMetadata() gopter.Gen {
 	return gopter.CombineGens(
 		gen.Identifier(),
-		gen.SliceOfN(7, gen.Bool()),
+		gen.SliceOfN(8, gen.Bool()),
 		genRetention(),
+		gen.Float64Range(0, 1),
 	).Map(func(values []interface{}) namespace.Metadata {
 		var (
-			id        = values[0].(string)
-			bools     = values[1].([]bool)
-			retention = values[2].(retention.Options)
+			id                   = values[0].(string)
+			bools                = values[1].([]bool)
+			retention            = values[2].(retention.Options)
+			falsePositivePercent = values[3].(float64)
 		)
 		testSchemaReg, _ := namespace.LoadSchemaHistory(testSchemaOptions)
 		md, err := namespace.NewMetadata(ident.StringID(id), namespace.NewOptions().
@@ -98,6 +100,8 @@ func genMetadata() gopter.Gen {
 			SetRepairEnabled(bools[3]).
 			SetWritesToCommitLog(bools[4]).
 			SetSnapshotEnabled(bools[5]).
+			SetBloomFilterEnabled(bools[7]).
+			SetBloomFilterFalsePositivePercent(falsePositivePercent).
 			SetSchemaHistory(testSchemaReg).
 			SetRetentionOptions(retention).
 			SetIndexOptions(namespace.NewIndexOptions().
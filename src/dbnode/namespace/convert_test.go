@@ -271,6 +271,90 @@ func TestFromProtoSnapshotEnabled(t *testing.T) {
 	require.Equal(t, !namespace.NewOptions().SnapshotEnabled(), md.Options().SnapshotEnabled())
 }
 
+func TestToProtoBloomFilterOptions(t *testing.T) {
+	md, err := namespace.NewMetadata(
+		ident.StringID("ns1"),
+		namespace.NewOptions().
+			// Don't use default values
+			SetBloomFilterEnabled(!namespace.NewOptions().BloomFilterEnabled()).
+			SetBloomFilterFalsePositivePercent(0.05),
+	)
+	require.NoError(t, err)
+	nsMap, err := namespace.NewMap([]namespace.Metadata{md})
+	require.NoError(t, err)
+
+	reg := namespace.ToProto(nsMap)
+	require.Len(t, reg.Namespaces, 1)
+	require.Equal(t, &nsproto.BloomFilterOptions{
+		Enabled:              !namespace.NewOptions().BloomFilterEnabled(),
+		FalsePositivePercent: 0.05,
+	}, reg.Namespaces["ns1"].BloomFilterOptions)
+
+	// Round trip back to the namespace options.
+	nsMap, err = namespace.FromProto(*reg)
+	require.NoError(t, err)
+	observed, err := nsMap.Get(ident.StringID("ns1"))
+	require.NoError(t, err)
+	require.True(t, md.Equal(observed))
+}
+
+func TestFromProtoBloomFilterOptions(t *testing.T) {
+	validRegistry := nsproto.Registry{
+		Namespaces: map[string]*nsproto.NamespaceOptions{
+			"testns1": &nsproto.NamespaceOptions{
+				RetentionOptions: &validRetentionOpts,
+				BloomFilterOptions: &nsproto.BloomFilterOptions{
+					// Use non-default values
+					Enabled:              !namespace.NewOptions().BloomFilterEnabled(),
+					FalsePositivePercent: 0.05,
+				},
+			},
+			"testns2": &nsproto.NamespaceOptions{
+				// Bloom filter options not set
+				RetentionOptions: &validRetentionOpts,
+			},
+		},
+	}
+	nsMap, err := namespace.FromProto(validRegistry)
+	require.NoError(t, err)
+
+	md, err := nsMap.Get(ident.StringID("testns1"))
+	require.NoError(t, err)
+	require.Equal(t, !namespace.NewOptions().BloomFilterEnabled(), md.Options().BloomFilterEnabled())
+	require.Equal(t, 0.05, md.Options().BloomFilterFalsePositivePercent())
+
+	md, err = nsMap.Get(ident.StringID("testns2"))
+	require.NoError(t, err)
+	require.Equal(t, namespace.NewOptions().BloomFilterEnabled(), md.Options().BloomFilterEnabled())
+	require.Equal(t, namespace.NewOptions().BloomFilterFalsePositivePercent(),
+		md.Options().BloomFilterFalsePositivePercent())
+}
+
+func TestIndexOptionsAnnotationFieldsRoundTrip(t *testing.T) {
+	fields := []string{"region", "payload.kind"}
+	md, err := namespace.NewMetadata(
+		ident.StringID("ns1"),
+		namespace.NewOptions().
+			SetIndexOptions(namespace.NewIndexOptions().
+				SetEnabled(true).
+				SetAnnotationFields(fields)),
+	)
+	require.NoError(t, err)
+	nsMap, err := namespace.NewMap([]namespace.Metadata{md})
+	require.NoError(t, err)
+
+	reg := namespace.ToProto(nsMap)
+	require.Len(t, reg.Namespaces, 1)
+	require.Equal(t, fields, reg.Namespaces["ns1"].IndexOptions.AnnotationFields)
+
+	nsMap, err = namespace.FromProto(*reg)
+	require.NoError(t, err)
+	observed, err := nsMap.Get(ident.StringID("ns1"))
+	require.NoError(t, err)
+	require.Equal(t, fields, observed.Options().IndexOptions().AnnotationFields())
+	require.True(t, md.Equal(observed))
+}
+
 func assertEqualMetadata(t *testing.T, name string, expected nsproto.NamespaceOptions, observed namespace.Metadata) {
 	require.Equal(t, name, observed.ID().String())
 	opts := observed.Options()
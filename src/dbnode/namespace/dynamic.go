@@ -220,6 +220,10 @@ func (r *dynamicRegistry) Watch() (Watch, error) {
 	return NewWatch(w), err
 }
 
+func (r *dynamicRegistry) Static() bool {
+	return false
+}
+
 func (r *dynamicRegistry) Close() error {
 	r.Lock()
 	defer r.Unlock()
@@ -33,8 +33,9 @@ var (
 )
 
 type indexOpts struct {
-	enabled   bool
-	blockSize time.Duration
+	enabled          bool
+	blockSize        time.Duration
+	annotationFields []string
 }
 
 // NewIndexOptions returns a new IndexOptions.
@@ -46,6 +47,14 @@ func NewIndexOptions() IndexOptions {
 }
 
 func (i *indexOpts) Equal(value IndexOptions) bool {
+	if len(i.annotationFields) != len(value.AnnotationFields()) {
+		return false
+	}
+	for idx, field := range value.AnnotationFields() {
+		if i.annotationFields[idx] != field {
+			return false
+		}
+	}
 	return i.Enabled() == value.Enabled() &&
 		i.BlockSize() == value.BlockSize()
 }
@@ -69,3 +78,13 @@ func (i *indexOpts) SetBlockSize(value time.Duration) IndexOptions {
 func (i *indexOpts) BlockSize() time.Duration {
 	return i.blockSize
 }
+
+func (i *indexOpts) SetAnnotationFields(value []string) IndexOptions {
+	io := *i
+	io.annotationFields = value
+	return &io
+}
+
+func (i *indexOpts) AnnotationFields() []string {
+	return i.annotationFields
+}
@@ -45,3 +45,15 @@ func TestIndexOptionsBlockSize(t *testing.T) {
 	opts := NewIndexOptions()
 	require.Equal(t, time.Hour, opts.SetBlockSize(time.Hour).BlockSize())
 }
+
+func TestIndexOptionsAnnotationFields(t *testing.T) {
+	opts := NewIndexOptions()
+	require.Empty(t, opts.AnnotationFields())
+
+	fields := []string{"a", "b.c"}
+	withFields := opts.SetAnnotationFields(fields)
+	require.Equal(t, fields, withFields.AnnotationFields())
+	require.True(t, withFields.Equal(opts.SetAnnotationFields([]string{"a", "b.c"})))
+	require.False(t, withFields.Equal(opts))
+	require.False(t, withFields.Equal(opts.SetAnnotationFields([]string{"a", "c"})))
+}
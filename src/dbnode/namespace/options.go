@@ -22,6 +22,7 @@ package namespace
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/m3db/m3/src/dbnode/retention"
 )
@@ -47,6 +48,12 @@ const (
 
 	// Namespace with cold writes disabled by default.
 	defaultColdWritesEnabled = false
+
+	// Namespace loads fileset bloom filters by default.
+	defaultBloomFilterEnabled = true
+
+	// Namespace uses the filesystem bloom filter false positive percent by default.
+	defaultBloomFilterFalsePositivePercent = 0
 )
 
 var (
@@ -56,16 +63,18 @@ var (
 )
 
 type options struct {
-	bootstrapEnabled  bool
-	flushEnabled      bool
-	snapshotEnabled   bool
-	writesToCommitLog bool
-	cleanupEnabled    bool
-	repairEnabled     bool
-	coldWritesEnabled bool
-	retentionOpts     retention.Options
-	indexOpts         IndexOptions
-	schemaHis         SchemaHistory
+	bootstrapEnabled                bool
+	flushEnabled                    bool
+	snapshotEnabled                 bool
+	writesToCommitLog               bool
+	cleanupEnabled                  bool
+	repairEnabled                   bool
+	coldWritesEnabled               bool
+	bloomFilterEnabled              bool
+	bloomFilterFalsePositivePercent float64
+	retentionOpts                   retention.Options
+	indexOpts                       IndexOptions
+	schemaHis                       SchemaHistory
 }
 
 // NewSchemaHistory returns an empty schema history.
@@ -76,16 +85,18 @@ func NewSchemaHistory() SchemaHistory {
 // NewOptions creates a new namespace options
 func NewOptions() Options {
 	return &options{
-		bootstrapEnabled:  defaultBootstrapEnabled,
-		flushEnabled:      defaultFlushEnabled,
-		snapshotEnabled:   defaultSnapshotEnabled,
-		writesToCommitLog: defaultWritesToCommitLog,
-		cleanupEnabled:    defaultCleanupEnabled,
-		repairEnabled:     defaultRepairEnabled,
-		coldWritesEnabled: defaultColdWritesEnabled,
-		retentionOpts:     retention.NewOptions(),
-		indexOpts:         NewIndexOptions(),
-		schemaHis:         NewSchemaHistory(),
+		bootstrapEnabled:                defaultBootstrapEnabled,
+		flushEnabled:                    defaultFlushEnabled,
+		snapshotEnabled:                 defaultSnapshotEnabled,
+		writesToCommitLog:               defaultWritesToCommitLog,
+		cleanupEnabled:                  defaultCleanupEnabled,
+		repairEnabled:                   defaultRepairEnabled,
+		coldWritesEnabled:               defaultColdWritesEnabled,
+		bloomFilterEnabled:              defaultBloomFilterEnabled,
+		bloomFilterFalsePositivePercent: defaultBloomFilterFalsePositivePercent,
+		retentionOpts:                   retention.NewOptions(),
+		indexOpts:                       NewIndexOptions(),
+		schemaHis:                       NewSchemaHistory(),
 	}
 }
 
@@ -93,6 +104,10 @@ func (o *options) Validate() error {
 	if err := o.retentionOpts.Validate(); err != nil {
 		return err
 	}
+	if p := o.bloomFilterFalsePositivePercent; p < 0 || p > 1.0 {
+		return fmt.Errorf(
+			"invalid bloom filter false positive percent, must be >= 0 and <= 1: instead %f", p)
+	}
 	if !o.indexOpts.Enabled() {
 		return nil
 	}
@@ -122,6 +137,8 @@ func (o *options) Equal(value Options) bool {
 		o.cleanupEnabled == value.CleanupEnabled() &&
 		o.repairEnabled == value.RepairEnabled() &&
 		o.coldWritesEnabled == value.ColdWritesEnabled() &&
+		o.bloomFilterEnabled == value.BloomFilterEnabled() &&
+		o.bloomFilterFalsePositivePercent == value.BloomFilterFalsePositivePercent() &&
 		o.retentionOpts.Equal(value.RetentionOptions()) &&
 		o.indexOpts.Equal(value.IndexOptions()) &&
 		o.schemaHis.Equal(value.SchemaHistory())
@@ -197,6 +214,26 @@ func (o *options) ColdWritesEnabled() bool {
 	return o.coldWritesEnabled
 }
 
+func (o *options) SetBloomFilterEnabled(value bool) Options {
+	opts := *o
+	opts.bloomFilterEnabled = value
+	return &opts
+}
+
+func (o *options) BloomFilterEnabled() bool {
+	return o.bloomFilterEnabled
+}
+
+func (o *options) SetBloomFilterFalsePositivePercent(value float64) Options {
+	opts := *o
+	opts.bloomFilterFalsePositivePercent = value
+	return &opts
+}
+
+func (o *options) BloomFilterFalsePositivePercent() float64 {
+	return o.bloomFilterFalsePositivePercent
+}
+
 func (o *options) SetRetentionOptions(value retention.Options) Options {
 	opts := *o
 	opts.retentionOpts = value
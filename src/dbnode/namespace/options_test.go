@@ -180,3 +180,19 @@ func TestOptionsValidateNoIndexing(t *testing.T) {
 	rOpts.EXPECT().Validate().Return(nil)
 	require.NoError(t, o1.Validate())
 }
+
+func TestOptionsValidateBloomFilterFalsePositivePercent(t *testing.T) {
+	o1 := NewOptions().SetIndexOptions(NewIndexOptions().SetEnabled(false))
+	require.NoError(t, o1.SetBloomFilterFalsePositivePercent(0.01).Validate())
+	require.Error(t, o1.SetBloomFilterFalsePositivePercent(-0.01).Validate())
+	require.Error(t, o1.SetBloomFilterFalsePositivePercent(1.01).Validate())
+}
+
+func TestOptionsEqualsBloomFilter(t *testing.T) {
+	o1 := NewOptions()
+	o2 := o1.SetBloomFilterEnabled(false)
+	o3 := o1.SetBloomFilterFalsePositivePercent(0.01)
+	require.False(t, o1.Equal(o2))
+	require.False(t, o1.Equal(o3))
+	require.False(t, o2.Equal(o3))
+}
@@ -55,6 +55,8 @@ type schemaDescr struct {
 	deployId     string
 	prevDeployId string
 	md           MessageDescriptor
+	// history is the schema history the schema was loaded as part of, if any.
+	history *schemaHistory
 }
 
 func newSchemaDescr(deployId, prevId string, md MessageDescriptor) *schemaDescr {
@@ -90,6 +92,23 @@ func (s *schemaDescr) String() string {
 	return s.md.MessageDescriptor.String()
 }
 
+// SchemaForDeployID returns the version of the schema with the given deploy ID
+// from the schema history the given schema was loaded as part of, so that data
+// encoded with an earlier version of the schema can still be decoded.
+func SchemaForDeployID(descr SchemaDescr, deployID string) (SchemaDescr, bool) {
+	if descr == nil {
+		return nil, false
+	}
+	if descr.DeployId() == deployID {
+		return descr, true
+	}
+	sd, ok := descr.(*schemaDescr)
+	if !ok || sd.history == nil {
+		return nil, false
+	}
+	return sd.history.Get(deployID)
+}
+
 type schemaHistory struct {
 	options  *nsproto.SchemaOptions
 	latestId string
@@ -190,6 +209,7 @@ func LoadSchemaHistory(options *nsproto.SchemaOptions) (SchemaHistory, error) {
 		if err != nil {
 			return nil, err
 		}
+		sd.history = sr
 		sr.versions[sd.DeployId()] = sd
 		prevId = sd.DeployId()
 	}
@@ -228,6 +228,41 @@ func TestSchemaHistoryCheckLineage(t *testing.T) {
 	require.False(t, sr3.Extends(sr2))
 }
 
+func TestSchemaForDeployID(t *testing.T) {
+	out, _ := parseProto("mainpkg/main.proto", nil, "testdata")
+
+	dlist, _ := marshalFileDescriptors(out)
+
+	schemaOpt := &nsproto.SchemaOptions{
+		History: &nsproto.SchemaHistory{
+			Versions: []*nsproto.FileDescriptorSet{
+				{DeployId: "first", Descriptors: dlist},
+				{DeployId: "second", PrevId: "first", Descriptors: dlist},
+			},
+		},
+		DefaultMessageName: "mainpkg.TestMessage",
+	}
+	sr, err := LoadSchemaHistory(schemaOpt)
+	require.NoError(t, err)
+
+	latest, ok := sr.GetLatest()
+	require.True(t, ok)
+
+	schema, ok := SchemaForDeployID(latest, "second")
+	require.True(t, ok)
+	require.Equal(t, "second", schema.DeployId())
+
+	schema, ok = SchemaForDeployID(latest, "first")
+	require.True(t, ok)
+	require.Equal(t, "first", schema.DeployId())
+
+	_, ok = SchemaForDeployID(latest, "unknown")
+	require.False(t, ok)
+
+	_, ok = SchemaForDeployID(nil, "first")
+	require.False(t, ok)
+}
+
 const (
 	mainProtoStr = `syntax = "proto3";
 
@@ -63,6 +63,10 @@ func (r *staticReg) Watch() (Watch, error) {
 	return NewWatch(w), nil
 }
 
+func (r *staticReg) Static() bool {
+	return true
+}
+
 func (r *staticReg) Close() error {
 	r.Watchable.Close()
 	return nil
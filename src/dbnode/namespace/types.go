@@ -80,6 +80,24 @@ type Options interface {
 	// ColdWritesEnabled returns whether cold writes are enabled for this namespace.
 	ColdWritesEnabled() bool
 
+	// SetBloomFilterEnabled sets whether the ID bloom filters of filesets are
+	// loaded for this namespace, disabling them saves memory for small namespaces
+	// at the cost of seeking the index for IDs that are not in a fileset.
+	SetBloomFilterEnabled(value bool) Options
+
+	// BloomFilterEnabled returns whether the ID bloom filters of filesets are
+	// loaded for this namespace.
+	BloomFilterEnabled() bool
+
+	// SetBloomFilterFalsePositivePercent sets the target false positive percent
+	// of the ID bloom filters written for this namespace, zero uses the
+	// filesystem default.
+	SetBloomFilterFalsePositivePercent(value float64) Options
+
+	// BloomFilterFalsePositivePercent returns the target false positive percent
+	// of the ID bloom filters written for this namespace.
+	BloomFilterFalsePositivePercent() float64
+
 	// SetRetentionOptions sets the retention options for this namespace
 	SetRetentionOptions(value retention.Options) Options
 
@@ -115,6 +133,16 @@ type IndexOptions interface {
 
 	// BlockSize returns the block size.
 	BlockSize() time.Duration
+
+	// SetAnnotationFields sets the fields of proto annotations to index as
+	// additional tags, nested fields are named by their dot separated path.
+	// The fields are taken from the annotation of the write that inserts a
+	// series and only set scalar fields are indexed.
+	SetAnnotationFields(value []string) IndexOptions
+
+	// AnnotationFields returns the fields of proto annotations to index as
+	// additional tags.
+	AnnotationFields() []string
 }
 
 // SchemaDescr describes the schema for a complex type value.
@@ -221,6 +249,10 @@ type Registry interface {
 	// Watch for the Registry changes
 	Watch() (Watch, error)
 
+	// Static returns whether the namespaces are fixed by the configuration
+	// rather than read from the namespace registry kept in the config service.
+	Static() bool
+
 	// Close closes the registry
 	Close() error
 }
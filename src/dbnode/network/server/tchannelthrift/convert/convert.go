@@ -28,6 +28,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/digest"
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
 	tterrors "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/errors"
+	"github.com/m3db/m3/src/dbnode/storage/durability"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
@@ -122,9 +123,42 @@ type ToSegmentsResult struct {
 
 // ToSegments converts a list of blocks to segments.
 func ToSegments(blocks []xio.BlockReader) (ToSegmentsResult, error) {
+	return ToSegmentsWithChecksums(blocks, nil)
+}
+
+// ToSegmentsWithChecksums converts a list of blocks to segments, setting the
+// checksum of each segment from the corresponding checksum if checksums is
+// not nil.
+func ToSegmentsWithChecksums(
+	blocks []xio.BlockReader,
+	checksums []uint32,
+) (ToSegmentsResult, error) {
+	return ToSegmentsWithOptions(blocks, ToSegmentsOptions{Checksums: checksums})
+}
+
+// ToSegmentsOptions are options used when converting blocks to segments.
+type ToSegmentsOptions struct {
+	// Checksums are the checksums to set on each segment, if not nil.
+	Checksums []uint32
+	// IncludeSources sets the source each segment was read from.
+	IncludeSources bool
+}
+
+// ToSegmentsWithOptions converts a list of blocks to segments with the
+// given options.
+func ToSegmentsWithOptions(
+	blocks []xio.BlockReader,
+	opts ToSegmentsOptions,
+) (ToSegmentsResult, error) {
+	checksums := opts.Checksums
 	if len(blocks) == 0 {
 		return ToSegmentsResult{}, nil
 	}
+	if checksums != nil && len(checksums) != len(blocks) {
+		return ToSegmentsResult{}, fmt.Errorf(
+			"mismatched number of checksums: blocks=%d, checksums=%d",
+			len(blocks), len(checksums))
+	}
 
 	s := &rpc.Segments{}
 
@@ -143,6 +177,8 @@ func ToSegments(blocks []xio.BlockReader) (ToSegmentsResult, error) {
 			Tail:      bytesRef(seg.Tail),
 			StartTime: &startTime,
 			BlockSize: &blockSize,
+			Checksum:  segmentChecksum(checksums, 0),
+			Source:    segmentSource(blocks[0], opts.IncludeSources),
 		}
 		checksum := int64(digest.SegmentChecksum(seg))
 		return ToSegmentsResult{
@@ -151,7 +187,7 @@ func ToSegments(blocks []xio.BlockReader) (ToSegmentsResult, error) {
 		}, nil
 	}
 
-	for _, block := range blocks {
+	for i, block := range blocks {
 		seg, err := block.Segment()
 		if err != nil {
 			return ToSegmentsResult{}, err
@@ -166,6 +202,8 @@ func ToSegments(blocks []xio.BlockReader) (ToSegmentsResult, error) {
 			Tail:      bytesRef(seg.Tail),
 			StartTime: &startTime,
 			BlockSize: &blockSize,
+			Checksum:  segmentChecksum(checksums, i),
+			Source:    segmentSource(block, opts.IncludeSources),
 		})
 	}
 	if len(s.Unmerged) == 0 {
@@ -175,6 +213,22 @@ func ToSegments(blocks []xio.BlockReader) (ToSegmentsResult, error) {
 	return ToSegmentsResult{Segments: s}, nil
 }
 
+func segmentSource(block xio.BlockReader, include bool) *string {
+	if !include {
+		return nil
+	}
+	source := block.Source.String()
+	return &source
+}
+
+func segmentChecksum(checksums []uint32, idx int) *int64 {
+	if checksums == nil {
+		return nil
+	}
+	checksum := int64(checksums[idx])
+	return &checksum
+}
+
 func bytesRef(data checked.Bytes) []byte {
 	if data != nil {
 		return data.Bytes()
@@ -187,9 +241,15 @@ func ToRPCError(err error) *rpc.Error {
 	if err == nil {
 		return nil
 	}
+	if xerrors.IsBlockedError(err) {
+		return tterrors.NewBlockedError(err)
+	}
 	if xerrors.IsInvalidParams(err) {
 		return tterrors.NewBadRequestError(err)
 	}
+	if xerrors.IsResourceExhaustedError(err) {
+		return tterrors.NewResourceExhaustedError(err)
+	}
 	return tterrors.NewInternalError(err)
 }
 
@@ -217,8 +277,9 @@ func FromRPCFetchTaggedRequest(
 	}
 
 	opts := index.QueryOptions{
-		StartInclusive: start,
-		EndExclusive:   end,
+		StartInclusive:    start,
+		EndExclusive:      end,
+		IncludeDurability: req.GetIncludeDurability(),
 	}
 	if l := req.Limit; l != nil {
 		opts.Limit = int(*l)
@@ -274,9 +335,43 @@ func ToRPCFetchTaggedRequest(
 		request.Limit = &l
 	}
 
+	if opts.IncludeDurability {
+		includeDurability := true
+		request.IncludeDurability = &includeDurability
+	}
+
 	return request, nil
 }
 
+// ToRPCDataDurabilityRanges converts durability ranges into the rpc type.
+func ToRPCDataDurabilityRanges(ranges durability.Ranges) []*rpc.DataDurabilityRange {
+	result := make([]*rpc.DataDurabilityRange, 0, len(ranges))
+	for _, r := range ranges {
+		result = append(result, &rpc.DataDurabilityRange{
+			RangeStart: r.Start.UnixNano(),
+			RangeEnd:   r.End.UnixNano(),
+			Durability: r.Level.String(),
+		})
+	}
+	return result
+}
+
+// FromRPCDataDurabilityRanges converts rpc durability ranges into the
+// corresponding Go API type.
+func FromRPCDataDurabilityRanges(
+	ranges []*rpc.DataDurabilityRange,
+) (durability.Ranges, error) {
+	var result durability.Ranges
+	for _, r := range ranges {
+		level, err := durability.ParseLevel(r.Durability)
+		if err != nil {
+			return nil, err
+		}
+		result = result.Add(time.Unix(0, r.RangeStart), time.Unix(0, r.RangeEnd), level)
+	}
+	return result, nil
+}
+
 // FromRPCAggregateQueryRequest converts the rpc request type for AggregateRawQueryRequest into corresponding Go API types.
 func FromRPCAggregateQueryRequest(
 	req *rpc.AggregateQueryRequest,
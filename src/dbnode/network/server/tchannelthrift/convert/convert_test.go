@@ -103,18 +103,21 @@ func conjunctionQueryATestCase(t *testing.T) (idx.Query, []byte) {
 func TestConvertFetchTaggedRequest(t *testing.T) {
 	ns := ident.StringID("abc")
 	opts := index.QueryOptions{
-		StartInclusive: time.Now().Add(-900 * time.Hour),
-		EndExclusive:   time.Now(),
-		Limit:          10,
+		StartInclusive:    time.Now().Add(-900 * time.Hour),
+		EndExclusive:      time.Now(),
+		Limit:             10,
+		IncludeDurability: true,
 	}
 	fetchData := true
 	var limit int64 = 10
+	includeDurability := true
 	requestSkeleton := &rpc.FetchTaggedRequest{
-		NameSpace:  ns.Bytes(),
-		RangeStart: mustToRpcTime(t, opts.StartInclusive),
-		RangeEnd:   mustToRpcTime(t, opts.EndExclusive),
-		FetchData:  fetchData,
-		Limit:      &limit,
+		NameSpace:         ns.Bytes(),
+		RangeStart:        mustToRpcTime(t, opts.StartInclusive),
+		RangeEnd:          mustToRpcTime(t, opts.EndExclusive),
+		FetchData:         fetchData,
+		Limit:             &limit,
+		IncludeDurability: &includeDurability,
 	}
 	requireEqual := func(a, b interface{}) {
 		d := cmp.Diff(a, b)
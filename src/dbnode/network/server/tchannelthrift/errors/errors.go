@@ -26,6 +26,15 @@ import (
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
 )
 
+const (
+	// resourceExhaustedFlag is the error flag set when a request was rejected
+	// to apply backpressure as resources are exhausted.
+	resourceExhaustedFlag int64 = 1 << 0
+	// blockedFlag is the error flag set when a request was rejected because
+	// an operator blocked it.
+	blockedFlag int64 = 1 << 1
+)
+
 func newError(errType rpc.ErrorType, err error) *rpc.Error {
 	rpcErr := rpc.NewError()
 	rpcErr.Type = errType
@@ -43,6 +52,11 @@ func IsBadRequestError(err *rpc.Error) bool {
 	return err != nil && err.Type == rpc.ErrorType_BAD_REQUEST
 }
 
+// IsResourceExhaustedError returns whether the error is a resource exhausted error
+func IsResourceExhaustedError(err *rpc.Error) bool {
+	return err != nil && err.GetFlags()&resourceExhaustedFlag != 0
+}
+
 // NewInternalError creates a new internal error
 func NewInternalError(err error) *rpc.Error {
 	return newError(rpc.ErrorType_INTERNAL_ERROR, err)
@@ -53,6 +67,29 @@ func NewBadRequestError(err error) *rpc.Error {
 	return newError(rpc.ErrorType_BAD_REQUEST, err)
 }
 
+// NewResourceExhaustedError creates a new resource exhausted error, it is an
+// internal error flagged so that clients can back off rather than retry
+func NewResourceExhaustedError(err error) *rpc.Error {
+	rpcErr := newError(rpc.ErrorType_INTERNAL_ERROR, err)
+	flags := resourceExhaustedFlag
+	rpcErr.Flags = &flags
+	return rpcErr
+}
+
+// IsBlockedError returns whether the error is a blocked error
+func IsBlockedError(err *rpc.Error) bool {
+	return err != nil && err.GetFlags()&blockedFlag != 0
+}
+
+// NewBlockedError creates a new blocked error, it is a bad request error
+// flagged so that clients can tell an operator blocked the request
+func NewBlockedError(err error) *rpc.Error {
+	rpcErr := newError(rpc.ErrorType_BAD_REQUEST, err)
+	flags := blockedFlag
+	rpcErr.Flags = &flags
+	return rpcErr
+}
+
 // NewWriteBatchRawError creates a new write batch error
 func NewWriteBatchRawError(index int, err error) *rpc.WriteBatchRawError {
 	batchErr := rpc.NewWriteBatchRawError()
@@ -61,6 +98,14 @@ func NewWriteBatchRawError(index int, err error) *rpc.WriteBatchRawError {
 	return batchErr
 }
 
+// NewResourceExhaustedWriteBatchRawError creates a new resource exhausted write batch error
+func NewResourceExhaustedWriteBatchRawError(index int, err error) *rpc.WriteBatchRawError {
+	batchErr := rpc.NewWriteBatchRawError()
+	batchErr.Index = int64(index)
+	batchErr.Err = NewResourceExhaustedError(err)
+	return batchErr
+}
+
 // NewBadRequestWriteBatchRawError creates a new bad request write batch error
 func NewBadRequestWriteBatchRawError(index int, err error) *rpc.WriteBatchRawError {
 	batchErr := rpc.NewWriteBatchRawError()
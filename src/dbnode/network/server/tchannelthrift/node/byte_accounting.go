@@ -0,0 +1,193 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
+
+	"github.com/uber-go/tally"
+	"github.com/uber/tchannel-go/thrift"
+)
+
+const (
+	// maxByteAccountingEntries bounds the number of distinct namespace and
+	// tenant pairs tracked so that clients setting arbitrary tenant headers
+	// cannot grow the accounting state or the metric cardinality unbounded.
+	maxByteAccountingEntries = 4096
+
+	// overflowNamespace and overflowTenant are the namespace and tenant
+	// that bytes are attributed to once maxByteAccountingEntries distinct
+	// pairs are being tracked.
+	overflowNamespace = "_overflow"
+	overflowTenant    = "_overflow"
+)
+
+type byteAccountingEntry struct {
+	bytesIn     int64
+	bytesOut    int64
+	bytesInCtr  tally.Counter
+	bytesOutCtr tally.Counter
+}
+
+// byteAccounting tracks the number of bytes received and sent by RPCs
+// attributed to the namespace and the tenant the RPC was made on behalf of.
+type byteAccounting struct {
+	sync.RWMutex
+
+	scope      tally.Scope
+	maxEntries int
+	numEntries int
+	entries    map[string]map[string]*byteAccountingEntry
+}
+
+func newByteAccounting(scope tally.Scope, maxEntries int) *byteAccounting {
+	return &byteAccounting{
+		scope:      scope,
+		maxEntries: maxEntries,
+		entries:    make(map[string]map[string]*byteAccountingEntry),
+	}
+}
+
+// record attributes the bytes in and out to the namespace and the tenant
+// specified by the RPC headers, if any.
+func (a *byteAccounting) record(
+	tctx thrift.Context,
+	namespace string,
+	bytesIn, bytesOut int64,
+) {
+	if bytesIn == 0 && bytesOut == 0 {
+		return
+	}
+	entry := a.entry(namespace, tenantFromContext(tctx))
+	if bytesIn > 0 {
+		atomic.AddInt64(&entry.bytesIn, bytesIn)
+		entry.bytesInCtr.Inc(bytesIn)
+	}
+	if bytesOut > 0 {
+		atomic.AddInt64(&entry.bytesOut, bytesOut)
+		entry.bytesOutCtr.Inc(bytesOut)
+	}
+}
+
+func (a *byteAccounting) entry(namespace, tenant string) *byteAccountingEntry {
+	a.RLock()
+	entry, ok := a.entries[namespace][tenant]
+	a.RUnlock()
+	if ok {
+		return entry
+	}
+
+	a.Lock()
+	defer a.Unlock()
+
+	if entry, ok := a.entries[namespace][tenant]; ok {
+		return entry
+	}
+	if a.numEntries >= a.maxEntries {
+		namespace, tenant = overflowNamespace, overflowTenant
+		if entry, ok := a.entries[namespace][tenant]; ok {
+			return entry
+		}
+	}
+	tenants, ok := a.entries[namespace]
+	if !ok {
+		tenants = make(map[string]*byteAccountingEntry)
+		a.entries[namespace] = tenants
+	}
+
+	scope := a.scope.Tagged(map[string]string{
+		"namespace": namespace,
+		"tenant":    tenant,
+	})
+	entry = &byteAccountingEntry{
+		bytesInCtr:  scope.Counter("rpc-bytes-in"),
+		bytesOutCtr: scope.Counter("rpc-bytes-out"),
+	}
+	tenants[tenant] = entry
+	a.numEntries++
+	return entry
+}
+
+// usage returns the bytes accounted so far sorted by namespace and tenant.
+func (a *byteAccounting) usage() []*rpc.NodeRPCByteUsage {
+	a.RLock()
+	result := make([]*rpc.NodeRPCByteUsage, 0, a.numEntries)
+	for namespace, tenants := range a.entries {
+		for tenant, entry := range tenants {
+			result = append(result, &rpc.NodeRPCByteUsage{
+				NameSpace: namespace,
+				Tenant:    tenant,
+				BytesIn:   atomic.LoadInt64(&entry.bytesIn),
+				BytesOut:  atomic.LoadInt64(&entry.bytesOut),
+			})
+		}
+	}
+	a.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].NameSpace != result[j].NameSpace {
+			return result[i].NameSpace < result[j].NameSpace
+		}
+		return result[i].Tenant < result[j].Tenant
+	})
+	return result
+}
+
+func tenantFromContext(tctx thrift.Context) string {
+	if tctx == nil {
+		return ""
+	}
+	return tctx.Headers()[tchannelthrift.TenantHeader]
+}
+
+func segmentsSize(segments []*rpc.Segments) int64 {
+	var size int64
+	for _, s := range segments {
+		if s == nil {
+			continue
+		}
+		size += segmentSize(s.Merged)
+		for _, u := range s.Unmerged {
+			size += segmentSize(u)
+		}
+	}
+	return size
+}
+
+func segmentSize(s *rpc.Segment) int64 {
+	if s == nil {
+		return 0
+	}
+	return int64(len(s.Head) + len(s.Tail))
+}
+
+func datapointSize(dp *rpc.Datapoint) int64 {
+	if dp == nil {
+		return 0
+	}
+	// Timestamp and value are both encoded as eight bytes.
+	return 16 + int64(len(dp.Annotation))
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	"github.com/uber/tchannel-go/thrift"
+)
+
+func newTestTenantContext(t *testing.T, tenant string) thrift.Context {
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	if tenant == "" {
+		return tctx
+	}
+	return thrift.WithHeaders(tctx, map[string]string{
+		tchannelthrift.TenantHeader: tenant,
+	})
+}
+
+func TestByteAccountingRecord(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	a := newByteAccounting(scope, maxByteAccountingEntries)
+
+	a.record(newTestTenantContext(t, ""), "metrics", 10, 0)
+	a.record(newTestTenantContext(t, "foo"), "metrics", 5, 20)
+	a.record(newTestTenantContext(t, "foo"), "metrics", 1, 2)
+	a.record(newTestTenantContext(t, "bar"), "aggregated", 0, 7)
+	a.record(newTestTenantContext(t, "bar"), "aggregated", 0, 0)
+
+	require.Equal(t, []*rpc.NodeRPCByteUsage{
+		{NameSpace: "aggregated", Tenant: "bar", BytesIn: 0, BytesOut: 7},
+		{NameSpace: "metrics", Tenant: "", BytesIn: 10, BytesOut: 0},
+		{NameSpace: "metrics", Tenant: "foo", BytesIn: 6, BytesOut: 22},
+	}, a.usage())
+
+	counters := scope.Snapshot().Counters()
+	c, ok := counters["rpc-bytes-out+namespace=metrics,tenant=foo"]
+	require.True(t, ok)
+	require.Equal(t, int64(22), c.Value())
+	c, ok = counters["rpc-bytes-in+namespace=metrics,tenant=foo"]
+	require.True(t, ok)
+	require.Equal(t, int64(6), c.Value())
+}
+
+func TestByteAccountingOverflow(t *testing.T) {
+	a := newByteAccounting(tally.NoopScope, 2)
+
+	a.record(newTestTenantContext(t, "a"), "metrics", 1, 0)
+	a.record(newTestTenantContext(t, "b"), "metrics", 2, 0)
+	a.record(newTestTenantContext(t, "c"), "metrics", 3, 0)
+	a.record(newTestTenantContext(t, "d"), "metrics", 4, 0)
+	a.record(newTestTenantContext(t, "a"), "metrics", 5, 0)
+
+	require.Equal(t, []*rpc.NodeRPCByteUsage{
+		{NameSpace: overflowNamespace, Tenant: overflowTenant, BytesIn: 7},
+		{NameSpace: "metrics", Tenant: "a", BytesIn: 6},
+		{NameSpace: "metrics", Tenant: "b", BytesIn: 2},
+	}, a.usage())
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"runtime"
+
+	xsync "github.com/m3db/m3/src/x/sync"
+)
+
+// DefaultDecodeWorkerPoolSize returns the default size of the decode worker
+// pool, which is the number of CPUs the process may be scheduled on. This
+// respects the CPU affinity of the process so that a node pinned to a single
+// NUMA node (i.e. with numactl) only sizes the pool to the CPUs of that node.
+func DefaultDecodeWorkerPoolSize() int {
+	return runtime.NumCPU()
+}
+
+// NewDecodeWorkerPool returns a new initialized worker pool to be shared by
+// queries to decode series blocks, a non-positive size uses the default size.
+func NewDecodeWorkerPool(size int) xsync.WorkerPool {
+	if size <= 0 {
+		size = DefaultDecodeWorkerPoolSize()
+	}
+	pool := xsync.NewWorkerPool(size)
+	pool.Init()
+	return pool
+}
@@ -21,8 +21,11 @@
 package node
 
 import (
+	stdctx "context"
 	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"sync"
 	"time"
 
@@ -34,16 +37,21 @@ import (
 	tterrors "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/errors"
 	"github.com/m3db/m3/src/dbnode/storage"
 	"github.com/m3db/m3/src/dbnode/storage/block"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/dbnode/tracepoint"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
+	"github.com/m3db/m3/src/m3ninx/idx"
 	"github.com/m3db/m3/src/x/checked"
 	"github.com/m3db/m3/src/x/context"
 	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/livequery"
 	xopentracing "github.com/m3db/m3/src/x/opentracing"
 	"github.com/m3db/m3/src/x/pool"
 	"github.com/m3db/m3/src/x/resource"
@@ -97,6 +105,15 @@ var (
 
 	// errHealthNotSet is raised when server health data structure is not set.
 	errHealthNotSet = errors.New("server health not set")
+
+	// errInvalidFlushStatesShard is raised when the flush states of a negative shard are requested.
+	errInvalidFlushStatesShard = errors.New("flush states shard must not be negative")
+
+	// errInvalidRebootstrapShard is raised when a rebootstrap of a negative shard is requested.
+	errInvalidRebootstrapShard = errors.New("rebootstrap shard must not be negative")
+
+	// errInvalidRebootstrapRange is raised when a rebootstrap of an empty time range is requested.
+	errInvalidRebootstrapRange = errors.New("rebootstrap range start must be before end")
 )
 
 type serviceMetrics struct {
@@ -112,6 +129,7 @@ type serviceMetrics struct {
 	fetchBatchRaw       instrument.BatchMethodMetrics
 	writeBatchRaw       instrument.BatchMethodMetrics
 	writeTaggedBatchRaw instrument.BatchMethodMetrics
+	validateWriteTagged instrument.MethodMetrics
 	overloadRejected    tally.Counter
 }
 
@@ -129,6 +147,7 @@ func newServiceMetrics(scope tally.Scope, samplingRate float64) serviceMetrics {
 		fetchBatchRaw:       instrument.NewBatchMethodMetrics(scope, "fetchBatchRaw", samplingRate),
 		writeBatchRaw:       instrument.NewBatchMethodMetrics(scope, "writeBatchRaw", samplingRate),
 		writeTaggedBatchRaw: instrument.NewBatchMethodMetrics(scope, "writeTaggedBatchRaw", samplingRate),
+		validateWriteTagged: instrument.NewMethodMetrics(scope, "validateWriteTagged", samplingRate),
 		overloadRejected:    scope.Counter("overload-rejected"),
 	}
 }
@@ -143,12 +162,16 @@ type service struct {
 	nowFn   clock.NowFn
 	pools   pools
 	metrics serviceMetrics
+
+	byteAccounting *byteAccounting
+	liveQueries    livequery.Registry
 }
 
 type serviceState struct {
 	sync.RWMutex
-	db     storage.Database
-	health *rpc.NodeHealthResult_
+	db             storage.Database
+	health         *rpc.NodeHealthResult_
+	writeForwarder tchannelthrift.WriteForwarder
 }
 
 func (s *serviceState) DB() (storage.Database, bool) {
@@ -165,6 +188,13 @@ func (s *serviceState) Health() (*rpc.NodeHealthResult_, bool) {
 	return v, v != nil
 }
 
+func (s *serviceState) WriteForwarder() (tchannelthrift.WriteForwarder, bool) {
+	s.RLock()
+	v := s.writeForwarder
+	s.RUnlock()
+	return v, v != nil
+}
+
 type pools struct {
 	id                      ident.Pool
 	tagEncoder              serialize.TagEncoderPool
@@ -188,6 +218,10 @@ type Service interface {
 
 	// Only safe to be called one time once the service has started.
 	SetDatabase(db storage.Database) error
+
+	// SetWriteForwarder sets the forwarder of writes to the peer replicas, a
+	// nil value disables forwarding writes.
+	SetWriteForwarder(value tchannelthrift.WriteForwarder)
 }
 
 // NewService creates a new node TChannel Thrift service
@@ -240,6 +274,9 @@ func NewService(db storage.Database, opts tchannelthrift.Options) Service {
 		opts:    opts,
 		nowFn:   opts.ClockOptions().NowFn(),
 		metrics: newServiceMetrics(scope, iopts.MetricsSamplingRate()),
+		byteAccounting: newByteAccounting(scope.SubScope("byte-accounting"),
+			maxByteAccountingEntries),
+		liveQueries: livequery.NewRegistry(opts.ClockOptions().NowFn()),
 		pools: pools{
 			id:                      opts.IdentifierPool(),
 			checkedBytesWrapper:     wrapperPool,
@@ -346,7 +383,7 @@ func (s *service) BootstrappedInPlacementOrNoPlacement(ctx thrift.Context) (*rpc
 }
 
 func (s *service) Query(tctx thrift.Context, req *rpc.QueryRequest) (*rpc.QueryResult_, error) {
-	db, err := s.startRPCWithDB()
+	db, err := s.startReadRPCWithDB(tctx)
 	if err != nil {
 		return nil, err
 	}
@@ -401,6 +438,10 @@ func (s *service) query(ctx context.Context, db storage.Database, req *rpc.Query
 	if req.NoData != nil && *req.NoData {
 		fetchData = false
 	}
+	var tsIDs []ident.ID
+	if fetchData {
+		tsIDs = make([]ident.ID, 0, queryResult.Results.Map().Len())
+	}
 	for _, entry := range queryResult.Results.Map().Iter() {
 		elem := &rpc.QueryResultElement{
 			ID:   entry.Key().String(),
@@ -413,23 +454,98 @@ func (s *service) query(ctx context.Context, db storage.Database, req *rpc.Query
 				Value: tag.Value.String(),
 			})
 		}
-		if !fetchData {
-			continue
+		if fetchData {
+			tsIDs = append(tsIDs, entry.Key())
 		}
-		tsID := entry.Key()
-		datapoints, err := s.readDatapoints(ctx, db, nsID, tsID, start, end,
-			req.ResultTimeType)
+	}
+
+	if fetchData {
+		err := s.readQueryDatapoints(ctx, db, nsID, tsIDs, result.Results,
+			start, end, req.ResultTimeType)
 		if err != nil {
 			return nil, convert.ToRPCError(err)
 		}
-		elem.Datapoints = datapoints
 	}
 
 	return result, nil
 }
 
+// readQueryDatapoints reads and decodes the datapoints of each series matched
+// by a query into the corresponding result element. If a decode worker pool
+// is set the series are decoded using the shared pool, with each query only
+// decoding up to a capped number of series concurrently so that a query that
+// matches many series cannot starve the other queries of decode workers.
+func (s *service) readQueryDatapoints(
+	ctx context.Context,
+	db storage.Database,
+	nsID ident.ID,
+	tsIDs []ident.ID,
+	elems []*rpc.QueryResultElement,
+	start, end time.Time,
+	timeType rpc.TimeType,
+) error {
+	var (
+		workers     = s.opts.DecodeWorkerPool()
+		concurrency = s.opts.DecodeConcurrencyPerQuery()
+	)
+	if workers == nil || concurrency <= 1 || len(tsIDs) <= 1 {
+		for i, tsID := range tsIDs {
+			datapoints, err := s.readDatapoints(ctx, db, nsID, tsID, start, end,
+				timeType)
+			if err != nil {
+				return err
+			}
+			elems[i].Datapoints = datapoints
+		}
+		return nil
+	}
+
+	var (
+		wg       sync.WaitGroup
+		tokens   = make(chan struct{}, concurrency)
+		errLock  sync.Mutex
+		firstErr error
+	)
+	for i, tsID := range tsIDs {
+		tokens <- struct{}{}
+
+		errLock.Lock()
+		err := firstErr
+		errLock.Unlock()
+		if err != nil {
+			// No point decoding the remaining series.
+			<-tokens
+			break
+		}
+
+		i, tsID := i, tsID
+		wg.Add(1)
+		workers.Go(func() {
+			defer func() {
+				<-tokens
+				wg.Done()
+			}()
+
+			datapoints, err := s.readDatapoints(ctx, db, nsID, tsID, start, end,
+				timeType)
+			if err != nil {
+				errLock.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				errLock.Unlock()
+				return
+			}
+			elems[i].Datapoints = datapoints
+		})
+	}
+
+	wg.Wait()
+	return firstErr
+}
+
 func (s *service) Fetch(tctx thrift.Context, req *rpc.FetchRequest) (*rpc.FetchResult_, error) {
-	db, err := s.startRPCWithDB()
+	db, err := s.startReadRPCWithDB(tctx)
 	if err != nil {
 		return nil, err
 	}
@@ -446,16 +562,30 @@ func (s *service) Fetch(tctx thrift.Context, req *rpc.FetchRequest) (*rpc.FetchR
 		return nil, tterrors.NewBadRequestError(xerrors.FirstError(rangeStartErr, rangeEndErr))
 	}
 
+	liveQuery := s.registerLiveQuery(ctx, func() string {
+		return fmt.Sprintf("fetch %s in %s", req.ID, req.NameSpace)
+	})
+	defer liveQuery.Close()
+
 	tsID := s.pools.id.GetStringID(ctx, req.ID)
 	nsID := s.pools.id.GetStringID(ctx, req.NameSpace)
 
 	// Make datapoints an initialized empty array for JSON serialization as empty array than null
 	datapoints, err := s.readDatapoints(ctx, db, nsID, tsID, start, end,
 		req.ResultTimeType)
+	if err == nil {
+		err = liveQuery.Err()
+	}
 	if err != nil {
 		s.metrics.fetch.ReportError(s.nowFn().Sub(callStart))
-		return nil, convert.ToRPCError(err)
+		return nil, s.liveQueryRPCError(liveQuery, err)
+	}
+
+	var bytesOut int64
+	for _, dp := range datapoints {
+		bytesOut += datapointSize(dp)
 	}
+	s.byteAccounting.record(tctx, req.NameSpace, int64(len(req.ID)), bytesOut)
 
 	s.metrics.fetch.ReportSuccess(s.nowFn().Sub(callStart))
 	return &rpc.FetchResult_{Datapoints: datapoints}, nil
@@ -505,7 +635,7 @@ func (s *service) readDatapoints(
 }
 
 func (s *service) FetchTagged(tctx thrift.Context, req *rpc.FetchTaggedRequest) (*rpc.FetchTaggedResult_, error) {
-	db, err := s.startRPCWithDB()
+	db, err := s.startReadRPCWithDB(tctx)
 	if err != nil {
 		return nil, err
 	}
@@ -518,16 +648,35 @@ func (s *service) FetchTagged(tctx thrift.Context, req *rpc.FetchTaggedRequest)
 		xopentracing.Time("end", time.Unix(0, req.RangeEnd)),
 	)
 
-	result, err := s.fetchTagged(ctx, db, req)
+	liveQuery := s.registerLiveQuery(ctx, func() string {
+		return fmt.Sprintf("fetch tagged %s in %s",
+			describeQuery(req.Query), req.NameSpace)
+	})
+	defer liveQuery.Close()
+
+	result, err := s.fetchTagged(ctx, db, req, liveQuery)
 	if err != nil {
 		sp.LogFields(opentracinglog.Error(err))
+	} else {
+		var bytesOut int64
+		for _, elem := range result.Elements {
+			bytesOut += int64(len(elem.ID) + len(elem.EncodedTags))
+			bytesOut += segmentsSize(elem.Segments)
+		}
+		s.byteAccounting.record(tctx, string(req.NameSpace),
+			int64(len(req.Query)), bytesOut)
 	}
 	sp.Finish()
 
 	return result, err
 }
 
-func (s *service) fetchTagged(ctx context.Context, db storage.Database, req *rpc.FetchTaggedRequest) (*rpc.FetchTaggedResult_, error) {
+func (s *service) fetchTagged(
+	ctx context.Context,
+	db storage.Database,
+	req *rpc.FetchTaggedRequest,
+	liveQuery *livequery.Handle,
+) (*rpc.FetchTaggedResult_, error) {
 	callStart := s.nowFn()
 
 	ns, query, opts, fetchData, err := convert.FromRPCFetchTaggedRequest(req, s.pools)
@@ -536,19 +685,41 @@ func (s *service) fetchTagged(ctx context.Context, db storage.Database, req *rpc
 		return nil, tterrors.NewBadRequestError(err)
 	}
 
+	// NB: The durability is determined before reading so that it can only
+	// under report the durability of the data returned, never over report it.
+	var durabilityRanges []*rpc.DataDurabilityRange
+	if opts.IncludeDurability {
+		ranges, err := db.DataDurability(ns, opts.StartInclusive, opts.EndExclusive)
+		if err != nil {
+			s.metrics.fetchTagged.ReportError(s.nowFn().Sub(callStart))
+			return nil, convert.ToRPCError(err)
+		}
+		durabilityRanges = convert.ToRPCDataDurabilityRanges(ranges)
+	}
+
 	queryResult, err := db.QueryIDs(ctx, ns, query, opts)
 	if err != nil {
 		s.metrics.fetchTagged.ReportError(s.nowFn().Sub(callStart))
-		return nil, convert.ToRPCError(err)
+		return nil, s.liveQueryRPCError(liveQuery, err)
 	}
 
 	response := &rpc.FetchTaggedResult_{
-		Exhaustive: queryResult.Exhaustive,
+		Exhaustive:       queryResult.Exhaustive,
+		DurabilityRanges: durabilityRanges,
 	}
 	results := queryResult.Results
 	nsID := results.Namespace()
 	tagsIter := ident.NewTagsIterator(ident.Tags{})
+	var ids []ident.ID
+	if fetchData {
+		ids = make([]ident.ID, 0, results.Size())
+	}
 	for _, entry := range results.Map().Iter() {
+		if err := liveQuery.Err(); err != nil {
+			s.metrics.fetchTagged.ReportError(s.nowFn().Sub(callStart))
+			return nil, s.liveQueryRPCError(liveQuery, err)
+		}
+
 		tsID := entry.Key()
 		tags := entry.Value()
 		enc := s.pools.tagEncoder.Get()
@@ -566,15 +737,37 @@ func (s *service) fetchTagged(ctx context.Context, db storage.Database, req *rpc
 			EncodedTags: encodedTags.Bytes(),
 		}
 		response.Elements = append(response.Elements, elem)
-		if !fetchData {
-			continue
+		liveQuery.AddSeries(1)
+		if fetchData {
+			ids = append(ids, tsID)
 		}
-		segments, rpcErr := s.readEncoded(ctx, db, nsID, tsID, opts.StartInclusive, opts.EndExclusive)
-		if rpcErr != nil {
-			elem.Err = rpcErr
-			continue
+	}
+
+	if fetchData && len(ids) > 0 {
+		// Hydrate all the series in a single batched read rather than
+		// reading each series individually.
+		encoded, err := db.ReadEncodedBatch(ctx, nsID, ids,
+			opts.StartInclusive, opts.EndExclusive)
+		if err != nil {
+			rpcErr := convert.ToRPCError(err)
+			for _, elem := range response.Elements {
+				elem.Err = rpcErr
+			}
+		}
+		for i, result := range encoded {
+			elem := response.Elements[i]
+			if result.Err != nil {
+				elem.Err = convert.ToRPCError(result.Err)
+				continue
+			}
+			segments, rpcErr := s.toSegments(ctx, result.Readers)
+			if rpcErr != nil {
+				elem.Err = rpcErr
+				continue
+			}
+			elem.Segments = segments
+			liveQuery.AddBytes(segmentsSize(segments))
 		}
-		elem.Segments = segments
 	}
 
 	s.metrics.fetchTagged.ReportSuccess(s.nowFn().Sub(callStart))
@@ -582,7 +775,7 @@ func (s *service) fetchTagged(ctx context.Context, db storage.Database, req *rpc
 }
 
 func (s *service) Aggregate(tctx thrift.Context, req *rpc.AggregateQueryRequest) (*rpc.AggregateQueryResult_, error) {
-	db, err := s.startRPCWithDB()
+	db, err := s.startReadRPCWithDB(tctx)
 	if err != nil {
 		return nil, err
 	}
@@ -625,7 +818,7 @@ func (s *service) Aggregate(tctx thrift.Context, req *rpc.AggregateQueryRequest)
 }
 
 func (s *service) AggregateRaw(tctx thrift.Context, req *rpc.AggregateQueryRawRequest) (*rpc.AggregateQueryRawResult_, error) {
-	db, err := s.startRPCWithDB()
+	db, err := s.startReadRPCWithDB(tctx)
 	if err != nil {
 		return nil, err
 	}
@@ -692,7 +885,7 @@ func (s *service) encodeTags(
 }
 
 func (s *service) FetchBatchRaw(tctx thrift.Context, req *rpc.FetchBatchRawRequest) (*rpc.FetchBatchRawResult_, error) {
-	db, err := s.startRPCWithDB()
+	db, err := s.startReadRPCWithDB(tctx)
 	if err != nil {
 		return nil, err
 	}
@@ -709,6 +902,11 @@ func (s *service) FetchBatchRaw(tctx thrift.Context, req *rpc.FetchBatchRawReque
 		return nil, tterrors.NewBadRequestError(xerrors.FirstError(rangeStartErr, rangeEndErr))
 	}
 
+	liveQuery := s.registerLiveQuery(ctx, func() string {
+		return fmt.Sprintf("fetch batch of %d in %s", len(req.Ids), req.NameSpace)
+	})
+	defer liveQuery.Close()
+
 	nsID := s.newID(ctx, req.NameSpace)
 
 	result := rpc.NewFetchBatchRawResult_()
@@ -720,11 +918,18 @@ func (s *service) FetchBatchRaw(tctx thrift.Context, req *rpc.FetchBatchRawReque
 	)
 
 	for i := range req.Ids {
+		if err := liveQuery.Err(); err != nil {
+			s.metrics.fetchBatchRaw.ReportNonRetryableErrors(len(req.Ids) - i)
+			s.metrics.fetchBatchRaw.ReportLatency(s.nowFn().Sub(callStart))
+			return nil, s.liveQueryRPCError(liveQuery, err)
+		}
+
 		rawResult := rpc.NewFetchRawResult_()
 		result.Elements = append(result.Elements, rawResult)
 
 		tsID := s.newID(ctx, req.Ids[i])
 		segments, rpcErr := s.readEncoded(ctx, db, nsID, tsID, start, end)
+		liveQuery.AddSeries(1)
 		if rpcErr != nil {
 			rawResult.Err = rpcErr
 			if tterrors.IsBadRequestError(rawResult.Err) {
@@ -737,8 +942,16 @@ func (s *service) FetchBatchRaw(tctx thrift.Context, req *rpc.FetchBatchRawReque
 
 		success++
 		rawResult.Segments = segments
+		liveQuery.AddBytes(segmentsSize(segments))
 	}
 
+	var bytesIn, bytesOut int64
+	for i := range req.Ids {
+		bytesIn += int64(len(req.Ids[i]))
+		bytesOut += segmentsSize(result.Elements[i].Segments)
+	}
+	s.byteAccounting.record(tctx, string(req.NameSpace), bytesIn, bytesOut)
+
 	s.metrics.fetchBatchRaw.ReportSuccess(success)
 	s.metrics.fetchBatchRaw.ReportRetryableErrors(retryableErrors)
 	s.metrics.fetchBatchRaw.ReportNonRetryableErrors(nonRetryableErrors)
@@ -748,7 +961,7 @@ func (s *service) FetchBatchRaw(tctx thrift.Context, req *rpc.FetchBatchRawReque
 }
 
 func (s *service) FetchBlocksRaw(tctx thrift.Context, req *rpc.FetchBlocksRawRequest) (*rpc.FetchBlocksRawResult_, error) {
-	db, err := s.startRPCWithDB()
+	db, err := s.startReadRPCWithDB(tctx)
 	if err != nil {
 		return nil, err
 	}
@@ -764,9 +977,22 @@ func (s *service) FetchBlocksRaw(tctx thrift.Context, req *rpc.FetchBlocksRawReq
 		return nil, tterrors.NewBadRequestError(fmt.Errorf("unable to find specified namespace: %v", nsID.String()))
 	}
 
+	liveQuery := s.registerLiveQuery(ctx, func() string {
+		return fmt.Sprintf("fetch blocks of %d in %s shard %d",
+			len(req.Elements), req.NameSpace, req.Shard)
+	})
+	defer liveQuery.Close()
+
 	res := rpc.NewFetchBlocksRawResult_()
 	res.Elements = make([]*rpc.Blocks, len(req.Elements))
 
+	// NB: Segment checksums are only computed for clients that request them
+	// so older clients do not pay for checksums that they will not verify.
+	includeSegmentChecksums := req.GetIncludeSegmentChecksums()
+	// NB: Segment sources are only used for debugging where data was read
+	// from, so they are also only returned when requested.
+	includeSegmentSources := req.GetIncludeSegmentSources()
+
 	// Preallocate starts to maximum size since at least one element will likely
 	// be fetching most blocks for peer bootstrapping
 	ropts := nsMetadata.Options().RetentionOptions()
@@ -774,6 +1000,11 @@ func (s *service) FetchBlocksRaw(tctx thrift.Context, req *rpc.FetchBlocksRawReq
 		(ropts.RetentionPeriod()+ropts.FutureRetentionPeriod())/ropts.BlockSize())
 
 	for i, request := range req.Elements {
+		if err := liveQuery.Err(); err != nil {
+			s.metrics.fetchBlocks.ReportError(s.nowFn().Sub(callStart))
+			return nil, s.liveQueryRPCError(liveQuery, err)
+		}
+
 		blockStarts = blockStarts[:0]
 
 		for _, start := range request.Starts {
@@ -798,8 +1029,17 @@ func (s *service) FetchBlocksRaw(tctx thrift.Context, req *rpc.FetchBlocksRawReq
 			if err := fetchedBlock.Err; err != nil {
 				block.Err = convert.ToRPCError(err)
 			} else {
+				if includeSegmentChecksums {
+					err = fetchedBlock.ComputeChecksums()
+				}
 				var converted convert.ToSegmentsResult
-				converted, err = convert.ToSegments(fetchedBlock.Blocks)
+				if err == nil {
+					converted, err = convert.ToSegmentsWithOptions(
+						fetchedBlock.Blocks, convert.ToSegmentsOptions{
+							Checksums:      fetchedBlock.Checksums,
+							IncludeSources: includeSegmentSources,
+						})
+				}
 				if err != nil {
 					block.Err = convert.ToRPCError(err)
 				}
@@ -809,13 +1049,24 @@ func (s *service) FetchBlocksRaw(tctx thrift.Context, req *rpc.FetchBlocksRawReq
 				}
 				block.Segments = converted.Segments
 				block.Checksum = converted.Checksum
+				liveQuery.AddBytes(segmentsSize([]*rpc.Segments{block.Segments}))
 			}
 
 			blocks.Blocks = append(blocks.Blocks, block)
 		}
 
 		res.Elements[i] = blocks
+		liveQuery.AddSeries(1)
+	}
+
+	var bytesIn, bytesOut int64
+	for i, request := range req.Elements {
+		bytesIn += int64(len(request.ID))
+		for _, block := range res.Elements[i].Blocks {
+			bytesOut += segmentsSize([]*rpc.Segments{block.Segments})
+		}
 	}
+	s.byteAccounting.record(tctx, string(req.NameSpace), bytesIn, bytesOut)
 
 	s.metrics.fetchBlocks.ReportSuccess(s.nowFn().Sub(callStart))
 
@@ -823,7 +1074,7 @@ func (s *service) FetchBlocksRaw(tctx thrift.Context, req *rpc.FetchBlocksRawReq
 }
 
 func (s *service) FetchBlocksMetadataRawV2(tctx thrift.Context, req *rpc.FetchBlocksMetadataRawV2Request) (*rpc.FetchBlocksMetadataRawV2Result_, error) {
-	db, err := s.startRPCWithDB()
+	db, err := s.startReadRPCWithDB(tctx)
 	if err != nil {
 		return nil, err
 	}
@@ -850,54 +1101,172 @@ func (s *service) FetchBlocksMetadataRawV2(tctx thrift.Context, req *rpc.FetchBl
 		opts.IncludeLastRead = *req.IncludeLastRead
 	}
 
+	var shaping blocksMetadataV2Shaping
+	if req.MinBlockStart != nil {
+		shaping.minBlockStart = *req.MinBlockStart
+	}
+	if req.MinSize != nil {
+		shaping.minSize = *req.MinSize
+	}
+	if req.SummaryOnly != nil {
+		shaping.summaryOnly = *req.SummaryOnly
+	}
+
+	// Sizes are required to filter by size and to total the summaries even
+	// if the caller did not ask for them to be returned per block.
+	fetchOpts := opts
+	if shaping.minSize > 0 || shaping.summaryOnly {
+		fetchOpts.IncludeSizes = true
+	}
+
 	var (
 		nsID  = s.newID(ctx, req.NameSpace)
 		start = time.Unix(0, req.RangeStart)
 		end   = time.Unix(0, req.RangeEnd)
 	)
 	fetchedMetadata, nextPageToken, err := db.FetchBlocksMetadataV2(
-		ctx, nsID, uint32(req.Shard), start, end, req.Limit, req.PageToken, opts)
+		ctx, nsID, uint32(req.Shard), start, end, req.Limit, req.PageToken, fetchOpts)
 	if err != nil {
 		return nil, convert.ToRPCError(err)
 	}
 
 	ctx.RegisterCloser(fetchedMetadata)
 
-	result, err := s.getFetchBlocksMetadataRawV2Result(ctx, nextPageToken, opts, fetchedMetadata)
+	includeCardinality := req.IncludeCardinality != nil && *req.IncludeCardinality
+	var cardinalities storage.BlockCardinalities
+	if includeCardinality {
+		cardinalities, err = db.BlockCardinalities(nsID, uint32(req.Shard), start, end)
+		if err != nil {
+			return nil, convert.ToRPCError(err)
+		}
+	}
+
+	result, err := s.getFetchBlocksMetadataRawV2Result(ctx, nextPageToken, opts,
+		shaping, fetchedMetadata)
 	if err != nil {
 		return nil, convert.ToRPCError(err)
 	}
+	if includeCardinality {
+		result.Cardinalities = toRPCBlockCardinalities(cardinalities.Data)
+		result.IndexCardinalities = toRPCBlockCardinalities(cardinalities.Index)
+	}
 
 	ctx.RegisterFinalizer(s.newCloseableMetadataV2Result(result))
 	return result, nil
 }
 
+func toRPCBlockCardinalities(
+	cardinalities []storage.BlockCardinality,
+) []*rpc.BlockCardinality {
+	result := make([]*rpc.BlockCardinality, 0, len(cardinalities))
+	for _, c := range cardinalities {
+		result = append(result, &rpc.BlockCardinality{
+			Start:  c.BlockStart.UnixNano(),
+			Series: int64(c.Series),
+		})
+	}
+	return result
+}
+
+// blocksMetadataV2Shaping describes how a fetch blocks metadata response
+// should be trimmed before it is returned to the caller.
+type blocksMetadataV2Shaping struct {
+	// minBlockStart drops blocks that start before this unix nanos time.
+	minBlockStart int64
+	// minSize drops blocks smaller than this many bytes.
+	minSize int64
+	// summaryOnly returns counts and sizes per block start instead of
+	// the metadata for each block.
+	summaryOnly bool
+}
+
+func (s blocksMetadataV2Shaping) include(b block.FetchBlockMetadataResult) bool {
+	if s.minBlockStart > 0 && b.Start.UnixNano() < s.minBlockStart {
+		return false
+	}
+	if s.minSize > 0 && b.Size < s.minSize {
+		return false
+	}
+	return true
+}
+
 func (s *service) getFetchBlocksMetadataRawV2Result(
 	ctx context.Context,
 	nextPageToken storage.PageToken,
 	opts block.FetchBlocksMetadataOptions,
+	shaping blocksMetadataV2Shaping,
 	results block.FetchBlocksMetadataResults,
 ) (*rpc.FetchBlocksMetadataRawV2Result_, error) {
-	elements, err := s.getBlocksMetadataV2FromResult(ctx, opts, results)
+	result := rpc.NewFetchBlocksMetadataRawV2Result_()
+	result.NextPageToken = nextPageToken
+
+	if shaping.summaryOnly {
+		result.Elements = s.pools.blockMetadataV2Slice.Get()
+		result.Summaries = getBlocksMetadataV2Summaries(shaping, results)
+		return result, nil
+	}
+
+	elements, err := s.getBlocksMetadataV2FromResult(ctx, opts, shaping, results)
 	if err != nil {
 		return nil, err
 	}
 
-	result := rpc.NewFetchBlocksMetadataRawV2Result_()
-	result.NextPageToken = nextPageToken
 	result.Elements = elements
 	return result, nil
 }
 
+func getBlocksMetadataV2Summaries(
+	shaping blocksMetadataV2Shaping,
+	results block.FetchBlocksMetadataResults,
+) []*rpc.BlockMetadataV2Summary {
+	byStart := make(map[int64]*rpc.BlockMetadataV2Summary)
+	for _, fetchedMetadata := range results.Results() {
+		for _, fetchedMetadataBlock := range fetchedMetadata.Blocks.Results() {
+			if !shaping.include(fetchedMetadataBlock) {
+				continue
+			}
+
+			start := fetchedMetadataBlock.Start.UnixNano()
+			summary, ok := byStart[start]
+			if !ok {
+				summary = &rpc.BlockMetadataV2Summary{Start: start}
+				byStart[start] = summary
+			}
+			summary.Count++
+			summary.Size += fetchedMetadataBlock.Size
+		}
+	}
+
+	summaries := make([]*rpc.BlockMetadataV2Summary, 0, len(byStart))
+	for _, summary := range byStart {
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Start < summaries[j].Start
+	})
+	return summaries
+}
+
 func (s *service) getBlocksMetadataV2FromResult(
 	ctx context.Context,
 	opts block.FetchBlocksMetadataOptions,
+	shaping blocksMetadataV2Shaping,
 	results block.FetchBlocksMetadataResults,
 ) ([]*rpc.BlockMetadataV2, error) {
 	blocks := s.pools.blockMetadataV2Slice.Get()
 	for _, fetchedMetadata := range results.Results() {
 		fetchedMetadataBlocks := fetchedMetadata.Blocks.Results()
 
+		included := 0
+		for _, fetchedMetadataBlock := range fetchedMetadataBlocks {
+			if shaping.include(fetchedMetadataBlock) {
+				included++
+			}
+		}
+		if included == 0 {
+			continue
+		}
+
 		var (
 			id          = fetchedMetadata.ID.Bytes()
 			tags        = fetchedMetadata.Tags
@@ -914,6 +1283,10 @@ func (s *service) getBlocksMetadataV2FromResult(
 		}
 
 		for _, fetchedMetadataBlock := range fetchedMetadataBlocks {
+			if !shaping.include(fetchedMetadataBlock) {
+				continue
+			}
+
 			blockMetadata := s.pools.blockMetadataV2.Get()
 			blockMetadata.ID = id
 			blockMetadata.EncodedTags = encodedTags
@@ -965,6 +1338,9 @@ func (s *service) Write(tctx thrift.Context, req *rpc.WriteRequest) error {
 	callStart := s.nowFn()
 	ctx := tchannelthrift.Context(tctx)
 
+	s.byteAccounting.record(tctx, req.NameSpace,
+		int64(len(req.ID))+datapointSize(req.Datapoint), 0)
+
 	if req.Datapoint == nil {
 		s.metrics.write.ReportError(s.nowFn().Sub(callStart))
 		return tterrors.NewBadRequestError(errRequiresDatapoint)
@@ -984,10 +1360,15 @@ func (s *service) Write(tctx thrift.Context, req *rpc.WriteRequest) error {
 		return tterrors.NewBadRequestError(err)
 	}
 
+	if req.GetStrictlyIncreasing() {
+		series.SetStrictlyIncreasingWrites(ctx)
+	}
+
+	id := s.pools.id.GetStringID(ctx, req.ID)
 	if err = db.Write(
 		ctx,
 		s.pools.id.GetStringID(ctx, req.NameSpace),
-		s.pools.id.GetStringID(ctx, req.ID),
+		id,
 		xtime.FromNormalizedTime(dp.Timestamp, d),
 		dp.Value,
 		unit,
@@ -997,6 +1378,13 @@ func (s *service) Write(tctx thrift.Context, req *rpc.WriteRequest) error {
 		return convert.ToRPCError(err)
 	}
 
+	if err := s.forwardWrite(tctx, id, func(ctx thrift.Context, peer rpc.TChanNode) error {
+		return peer.Write(ctx, req)
+	}); err != nil {
+		s.metrics.write.ReportError(s.nowFn().Sub(callStart))
+		return convert.ToRPCError(err)
+	}
+
 	s.metrics.write.ReportSuccess(s.nowFn().Sub(callStart))
 
 	return nil
@@ -1011,6 +1399,12 @@ func (s *service) WriteTagged(tctx thrift.Context, req *rpc.WriteTaggedRequest)
 	callStart := s.nowFn()
 	ctx := tchannelthrift.Context(tctx)
 
+	bytesIn := int64(len(req.ID)) + datapointSize(req.Datapoint)
+	for _, tag := range req.Tags {
+		bytesIn += int64(len(tag.Name) + len(tag.Value))
+	}
+	s.byteAccounting.record(tctx, req.NameSpace, bytesIn, 0)
+
 	if req.Datapoint == nil {
 		s.metrics.writeTagged.ReportError(s.nowFn().Sub(callStart))
 		return tterrors.NewBadRequestError(errRequiresDatapoint)
@@ -1041,20 +1435,176 @@ func (s *service) WriteTagged(tctx thrift.Context, req *rpc.WriteTaggedRequest)
 		return tterrors.NewBadRequestError(err)
 	}
 
+	if req.GetStrictlyIncreasing() {
+		series.SetStrictlyIncreasingWrites(ctx)
+	}
+
+	id := s.pools.id.GetStringID(ctx, req.ID)
 	if err = db.WriteTagged(ctx,
 		s.pools.id.GetStringID(ctx, req.NameSpace),
-		s.pools.id.GetStringID(ctx, req.ID),
+		id,
 		iter, xtime.FromNormalizedTime(dp.Timestamp, d),
 		dp.Value, unit, dp.Annotation); err != nil {
 		s.metrics.writeTagged.ReportError(s.nowFn().Sub(callStart))
 		return convert.ToRPCError(err)
 	}
 
+	if err := s.forwardWrite(tctx, id, func(ctx thrift.Context, peer rpc.TChanNode) error {
+		return peer.WriteTagged(ctx, req)
+	}); err != nil {
+		s.metrics.writeTagged.ReportError(s.nowFn().Sub(callStart))
+		return convert.ToRPCError(err)
+	}
+
 	s.metrics.writeTagged.ReportSuccess(s.nowFn().Sub(callStart))
 
 	return nil
 }
 
+func (s *service) ValidateWriteTagged(tctx thrift.Context, req *rpc.WriteTaggedRequest) error {
+	db, err := s.startRPCWithDB()
+	if err != nil {
+		return err
+	}
+
+	callStart := s.nowFn()
+	ctx := tchannelthrift.Context(tctx)
+
+	if req.Datapoint == nil {
+		s.metrics.validateWriteTagged.ReportError(s.nowFn().Sub(callStart))
+		return tterrors.NewBadRequestError(errRequiresDatapoint)
+	}
+
+	dp := req.Datapoint
+	unit, unitErr := convert.ToUnit(dp.TimestampTimeType)
+
+	if unitErr != nil {
+		s.metrics.validateWriteTagged.ReportError(s.nowFn().Sub(callStart))
+		return tterrors.NewBadRequestError(unitErr)
+	}
+
+	d, err := unit.Value()
+	if err != nil {
+		s.metrics.validateWriteTagged.ReportError(s.nowFn().Sub(callStart))
+		return tterrors.NewBadRequestError(err)
+	}
+
+	// A request without tags is validated as an untagged write.
+	var iter ident.TagIterator
+	if req.Tags != nil {
+		iter, err = convert.ToTagsIter(req)
+		if err != nil {
+			s.metrics.validateWriteTagged.ReportError(s.nowFn().Sub(callStart))
+			return tterrors.NewBadRequestError(err)
+		}
+	}
+
+	if err = db.ValidateWrite(
+		s.pools.id.GetStringID(ctx, req.NameSpace),
+		s.pools.id.GetStringID(ctx, req.ID),
+		iter, xtime.FromNormalizedTime(dp.Timestamp, d),
+		dp.Annotation); err != nil {
+		s.metrics.validateWriteTagged.ReportError(s.nowFn().Sub(callStart))
+		return convert.ToRPCError(err)
+	}
+
+	s.metrics.validateWriteTagged.ReportSuccess(s.nowFn().Sub(callStart))
+
+	return nil
+}
+
+// forwardWrite forwards a write that has been applied locally to the peer
+// replicas if a write forwarder is configured and the write was not itself
+// forwarded from another node.
+func (s *service) forwardWrite(
+	tctx thrift.Context,
+	id ident.ID,
+	writeFn tchannelthrift.PeerWriteFn,
+) error {
+	if !s.shouldForwardWrite(tctx) {
+		return nil
+	}
+	forwarder, _ := s.state.WriteForwarder()
+	return forwarder.ForwardWrite(id, writeFn)
+}
+
+// shouldForwardWrite returns whether a write should be forwarded to the peer
+// replicas after it has been applied locally.
+func (s *service) shouldForwardWrite(tctx thrift.Context) bool {
+	_, ok := s.state.WriteForwarder()
+	return ok && !isForwardedWrite(tctx)
+}
+
+// forwardWriteBatch forwards the elements of a batch that have been applied
+// locally to the peer replicas, elements that were not acknowledged by the
+// required number of peers are recorded as errors on the pooled request.
+// The element IDs and the memory referenced by writeFn must not be pooled
+// since the write to the remaining peers outlives the request.
+func (s *service) forwardWriteBatch(
+	pooledReq *writeBatchPooledReq,
+	elementIDs [][]byte,
+	writeFn tchannelthrift.PeerWriteBatchFn,
+) {
+	ids := make([]ident.ID, len(elementIDs))
+	for i, id := range elementIDs {
+		ids[i] = ident.BytesID(id)
+	}
+	// Elements that failed to be written locally are not forwarded.
+	for _, err := range pooledReq.writeBatchRawErrors() {
+		ids[err.Index] = nil
+	}
+	forwarder, _ := s.state.WriteForwarder()
+	forwarder.ForwardWriteBatch(ids, writeFn, pooledReq.HandleError)
+}
+
+// copyWriteBatchRawElements copies the elements of a batch request so they
+// can be forwarded once the request bytes have been returned to the pools.
+func copyWriteBatchRawElements(
+	elems []*rpc.WriteBatchRawRequestElement,
+) []*rpc.WriteBatchRawRequestElement {
+	copied := make([]*rpc.WriteBatchRawRequestElement, 0, len(elems))
+	for _, elem := range elems {
+		copied = append(copied, &rpc.WriteBatchRawRequestElement{
+			ID:        copyBytes(elem.ID),
+			Datapoint: copyDatapoint(elem.Datapoint),
+		})
+	}
+	return copied
+}
+
+// copyWriteTaggedBatchRawElements copies the elements of a tagged batch
+// request so they can be forwarded once the request bytes have been returned
+// to the pools.
+func copyWriteTaggedBatchRawElements(
+	elems []*rpc.WriteTaggedBatchRawRequestElement,
+) []*rpc.WriteTaggedBatchRawRequestElement {
+	copied := make([]*rpc.WriteTaggedBatchRawRequestElement, 0, len(elems))
+	for _, elem := range elems {
+		copied = append(copied, &rpc.WriteTaggedBatchRawRequestElement{
+			ID:          copyBytes(elem.ID),
+			EncodedTags: copyBytes(elem.EncodedTags),
+			Datapoint:   copyDatapoint(elem.Datapoint),
+		})
+	}
+	return copied
+}
+
+func copyDatapoint(dp *rpc.Datapoint) *rpc.Datapoint {
+	if dp == nil {
+		return nil
+	}
+	copied := *dp
+	copied.Annotation = copyBytes(dp.Annotation)
+	return &copied
+}
+
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append(make([]byte, 0, len(b)), b...)
+}
+
 func (s *service) WriteBatchRaw(tctx thrift.Context, req *rpc.WriteBatchRawRequest) error {
 	db, err := s.startRPCWithDB()
 	if err != nil {
@@ -1072,8 +1622,15 @@ func (s *service) WriteBatchRaw(tctx thrift.Context, req *rpc.WriteBatchRawReque
 	pooledReq.writeReq = req
 	ctx.RegisterFinalizer(pooledReq)
 
+	var bytesIn int64
+	for _, elem := range req.Elements {
+		bytesIn += int64(len(elem.ID)) + datapointSize(elem.Datapoint)
+	}
+	s.byteAccounting.record(tctx, string(req.NameSpace), bytesIn, 0)
+
 	var (
 		nsID               = s.newPooledID(ctx, req.NameSpace, pooledReq)
+		forward            = s.shouldForwardWrite(tctx)
 		retryableErrors    int
 		nonRetryableErrors int
 	)
@@ -1087,6 +1644,18 @@ func (s *service) WriteBatchRaw(tctx thrift.Context, req *rpc.WriteBatchRawReque
 	// let the database take care of returning them to the pool.
 	batchWriter.SetFinalizeAnnotationFn(finalizeAnnotationFn)
 
+	// Copy the elements to forward before the local write since the request
+	// bytes are returned to the pools while the forwarded writes to the
+	// remaining peers may still be in flight.
+	var (
+		fwdNameSpace []byte
+		fwdElements  []*rpc.WriteBatchRawRequestElement
+	)
+	if forward {
+		fwdNameSpace = copyBytes(req.NameSpace)
+		fwdElements = copyWriteBatchRawElements(req.Elements)
+	}
+
 	for i, elem := range req.Elements {
 		unit, unitErr := convert.ToUnit(elem.Datapoint.TimestampTimeType)
 		if unitErr != nil {
@@ -1119,6 +1688,24 @@ func (s *service) WriteBatchRaw(tctx thrift.Context, req *rpc.WriteBatchRawReque
 		return convert.ToRPCError(err)
 	}
 
+	if forward {
+		elementIDs := make([][]byte, 0, len(fwdElements))
+		for _, elem := range fwdElements {
+			elementIDs = append(elementIDs, elem.ID)
+		}
+		s.forwardWriteBatch(pooledReq, elementIDs,
+			func(ctx thrift.Context, peer rpc.TChanNode, indexes []int) error {
+				fwdReq := &rpc.WriteBatchRawRequest{
+					NameSpace: fwdNameSpace,
+					Elements:  make([]*rpc.WriteBatchRawRequestElement, 0, len(indexes)),
+				}
+				for _, i := range indexes {
+					fwdReq.Elements = append(fwdReq.Elements, fwdElements[i])
+				}
+				return peer.WriteBatchRaw(ctx, fwdReq)
+			})
+	}
+
 	nonRetryableErrors += pooledReq.numNonRetryableErrors()
 	retryableErrors += pooledReq.numRetryableErrors()
 	totalErrors := nonRetryableErrors + retryableErrors
@@ -1155,8 +1742,16 @@ func (s *service) WriteTaggedBatchRaw(tctx thrift.Context, req *rpc.WriteTaggedB
 	pooledReq.writeTaggedReq = req
 	ctx.RegisterFinalizer(pooledReq)
 
+	var bytesIn int64
+	for _, elem := range req.Elements {
+		bytesIn += int64(len(elem.ID)+len(elem.EncodedTags)) +
+			datapointSize(elem.Datapoint)
+	}
+	s.byteAccounting.record(tctx, string(req.NameSpace), bytesIn, 0)
+
 	var (
 		nsID               = s.newPooledID(ctx, req.NameSpace, pooledReq)
+		forward            = s.shouldForwardWrite(tctx)
 		retryableErrors    int
 		nonRetryableErrors int
 	)
@@ -1170,6 +1765,18 @@ func (s *service) WriteTaggedBatchRaw(tctx thrift.Context, req *rpc.WriteTaggedB
 	// let the database take care of returning them to the pool.
 	batchWriter.SetFinalizeAnnotationFn(finalizeAnnotationFn)
 
+	// Copy the elements to forward before the local write since the request
+	// bytes are returned to the pools while the forwarded writes to the
+	// remaining peers may still be in flight.
+	var (
+		fwdNameSpace []byte
+		fwdElements  []*rpc.WriteTaggedBatchRawRequestElement
+	)
+	if forward {
+		fwdNameSpace = copyBytes(req.NameSpace)
+		fwdElements = copyWriteTaggedBatchRawElements(req.Elements)
+	}
+
 	for i, elem := range req.Elements {
 		unit, unitErr := convert.ToUnit(elem.Datapoint.TimestampTimeType)
 		if unitErr != nil {
@@ -1208,6 +1815,24 @@ func (s *service) WriteTaggedBatchRaw(tctx thrift.Context, req *rpc.WriteTaggedB
 		return convert.ToRPCError(err)
 	}
 
+	if forward {
+		elementIDs := make([][]byte, 0, len(fwdElements))
+		for _, elem := range fwdElements {
+			elementIDs = append(elementIDs, elem.ID)
+		}
+		s.forwardWriteBatch(pooledReq, elementIDs,
+			func(ctx thrift.Context, peer rpc.TChanNode, indexes []int) error {
+				fwdReq := &rpc.WriteTaggedBatchRawRequest{
+					NameSpace: fwdNameSpace,
+					Elements:  make([]*rpc.WriteTaggedBatchRawRequestElement, 0, len(indexes)),
+				}
+				for _, i := range indexes {
+					fwdReq.Elements = append(fwdReq.Elements, fwdElements[i])
+				}
+				return peer.WriteTaggedBatchRaw(ctx, fwdReq)
+			})
+	}
+
 	nonRetryableErrors += pooledReq.numNonRetryableErrors()
 	retryableErrors += pooledReq.numRetryableErrors()
 	totalErrors := nonRetryableErrors + retryableErrors
@@ -1430,6 +2055,391 @@ func (s *service) SetWriteNewSeriesLimitPerShardPerSecond(
 	return s.GetWriteNewSeriesLimitPerShardPerSecond(ctx)
 }
 
+func (s *service) GetPausedBackgroundTasks(
+	ctx thrift.Context,
+) (*rpc.NodePausedBackgroundTasksResult_, error) {
+	db, err := s.startRPCWithDB()
+	if err != nil {
+		return nil, err
+	}
+
+	paused := db.PausedBackgroundTasks()
+	result := &rpc.NodePausedBackgroundTasksResult_{
+		PausedBackgroundTasks: make([]*rpc.NodePausedBackgroundTask, 0, len(paused)),
+	}
+	for _, p := range paused {
+		result.PausedBackgroundTasks = append(result.PausedBackgroundTasks,
+			&rpc.NodePausedBackgroundTask{
+				NameSpace:        p.Namespace.String(),
+				Task:             p.Task.String(),
+				PausedUntilNanos: p.Until.UnixNano(),
+			})
+	}
+	return result, nil
+}
+
+func (s *service) PauseBackgroundTask(
+	ctx thrift.Context,
+	req *rpc.NodePauseBackgroundTaskRequest,
+) (*rpc.NodePausedBackgroundTasksResult_, error) {
+	db, err := s.startRPCWithDB()
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := storage.ParseBackgroundTask(req.Task)
+	if err != nil {
+		return nil, tterrors.NewBadRequestError(err)
+	}
+	unit, err := convert.ToDuration(req.DurationType)
+	if err != nil {
+		return nil, tterrors.NewBadRequestError(xerrors.NewInvalidParamsError(err))
+	}
+	duration := time.Duration(req.PauseDuration) * unit
+	if err := db.PauseBackgroundTask(ident.StringID(req.NameSpace), task, duration); err != nil {
+		return nil, convert.ToRPCError(err)
+	}
+	return s.GetPausedBackgroundTasks(ctx)
+}
+
+func (s *service) ResumeBackgroundTask(
+	ctx thrift.Context,
+	req *rpc.NodeResumeBackgroundTaskRequest,
+) (*rpc.NodePausedBackgroundTasksResult_, error) {
+	db, err := s.startRPCWithDB()
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := storage.ParseBackgroundTask(req.Task)
+	if err != nil {
+		return nil, tterrors.NewBadRequestError(err)
+	}
+	if err := db.ResumeBackgroundTask(ident.StringID(req.NameSpace), task); err != nil {
+		return nil, convert.ToRPCError(err)
+	}
+	return s.GetPausedBackgroundTasks(ctx)
+}
+
+func (s *service) GetRPCByteUsage(
+	ctx thrift.Context,
+) (*rpc.NodeRPCByteUsageResult_, error) {
+	return &rpc.NodeRPCByteUsageResult_{
+		Usage: s.byteAccounting.usage(),
+	}, nil
+}
+
+func (s *service) GetLiveQueries(
+	ctx thrift.Context,
+) (*rpc.NodeLiveQueriesResult_, error) {
+	queries := s.liveQueries.Queries()
+	result := &rpc.NodeLiveQueriesResult_{
+		Queries: make([]*rpc.NodeLiveQuery, 0, len(queries)),
+	}
+	for _, q := range queries {
+		result.Queries = append(result.Queries, &rpc.NodeLiveQuery{
+			ID:         q.ID,
+			Query:      q.Query,
+			StartTime:  q.Start.UnixNano(),
+			Elapsed:    int64(q.Elapsed),
+			Series:     q.Resources.Series,
+			Datapoints: q.Resources.Datapoints,
+			Bytes:      q.Resources.Bytes,
+		})
+	}
+	return result, nil
+}
+
+func (s *service) CancelLiveQuery(
+	ctx thrift.Context,
+	req *rpc.NodeCancelLiveQueryRequest,
+) (*rpc.NodeLiveQueriesResult_, error) {
+	if err := s.liveQueries.Cancel(req.ID); err != nil {
+		return nil, tterrors.NewBadRequestError(err)
+	}
+
+	s.logger.Info("cancelled live query", zap.String("id", req.ID))
+	return s.GetLiveQueries(ctx)
+}
+
+func (s *service) UndeleteExpiredFileSets(
+	ctx thrift.Context,
+	req *rpc.NodeUndeleteExpiredFileSetsRequest,
+) (*rpc.NodeUndeleteExpiredFileSetsResult_, error) {
+	db, err := s.startRPCWithDB()
+	if err != nil {
+		return nil, err
+	}
+
+	ropts, err := db.UndeleteExpiredFileSets(ident.StringID(req.NameSpace))
+	if err != nil {
+		return nil, convert.ToRPCError(err)
+	}
+	return &rpc.NodeUndeleteExpiredFileSetsResult_{
+		NameSpace:                      req.NameSpace,
+		RetentionPeriodNanos:           int64(ropts.RetentionPeriod()),
+		ExpiredFileSetGracePeriodNanos: int64(ropts.ExpiredFileSetGracePeriod()),
+	}, nil
+}
+
+func (s *service) GetHotSeries(
+	ctx thrift.Context,
+	req *rpc.NodeHotSeriesRequest,
+) (*rpc.NodeHotSeriesResult_, error) {
+	db, err := s.startRPCWithDB()
+	if err != nil {
+		return nil, err
+	}
+
+	hot, err := db.HotSeries(ident.StringID(req.NameSpace), int(req.Limit))
+	if err != nil {
+		return nil, convert.ToRPCError(err)
+	}
+	return &rpc.NodeHotSeriesResult_{
+		NameSpace: req.NameSpace,
+		Writes:    toRPCHotSeries(hot.Writes),
+		Reads:     toRPCHotSeries(hot.Reads),
+	}, nil
+}
+
+func toRPCHotSeries(series []storage.HotSeries) []*rpc.NodeHotSeries {
+	result := make([]*rpc.NodeHotSeries, 0, len(series))
+	for _, s := range series {
+		result = append(result, &rpc.NodeHotSeries{
+			ID:    s.ID.String(),
+			Shard: int64(s.Shard),
+			Rate:  s.Rate,
+		})
+	}
+	return result
+}
+
+func (s *service) GetFlushStates(
+	ctx thrift.Context,
+	req *rpc.NodeFlushStatesRequest,
+) (*rpc.NodeFlushStatesResult_, error) {
+	db, err := s.startRPCWithDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		nsID   = ident.StringID(req.NameSpace)
+		blocks []storage.BlockFlushState
+	)
+	// The flush states of each block are only listed for the requested shard,
+	// the summary covers every owned shard of the namespace.
+	if req.IsSetShard() {
+		if req.Shard < 0 || req.Shard > math.MaxUint32 {
+			return nil, tterrors.NewBadRequestError(errInvalidFlushStatesShard)
+		}
+		blocks, err = db.FlushStates(nsID, uint32(req.Shard))
+		if err != nil {
+			return nil, convert.ToRPCError(err)
+		}
+	}
+	summary, err := db.FlushStateSummary(nsID)
+	if err != nil {
+		return nil, convert.ToRPCError(err)
+	}
+
+	result := &rpc.NodeFlushStatesResult_{
+		NameSpace:            req.NameSpace,
+		Blocks:               make([]*rpc.NodeBlockFlushState, 0, len(blocks)),
+		NumShards:            int64(summary.NumShards),
+		NumBlocks:            int64(summary.NumBlocks),
+		NumWarmFlushed:       int64(summary.NumWarmFlushed),
+		NumColdFlushed:       int64(summary.NumColdFlushed),
+		NumWarmFailed:        int64(summary.NumWarmFailed),
+		ColdBlockStartsNanos: make([]int64, 0, len(summary.ColdBlockStarts)),
+	}
+	for _, state := range blocks {
+		result.Blocks = append(result.Blocks, &rpc.NodeBlockFlushState{
+			Shard:           int64(state.Shard),
+			BlockStartNanos: state.BlockStart.UnixNano(),
+			WarmFlushed:     state.WarmFlushed,
+			ColdVersion:     int64(state.ColdVersion),
+			NumFailures:     int64(state.NumFailures),
+		})
+	}
+	for _, blockStart := range summary.ColdBlockStarts {
+		result.ColdBlockStartsNanos = append(result.ColdBlockStartsNanos, blockStart.UnixNano())
+	}
+	return result, nil
+}
+
+func (s *service) GetBootstrapProgress(
+	ctx thrift.Context,
+) (*rpc.NodeBootstrapProgressResult_, error) {
+	db, err := s.startRPCWithDB()
+	if err != nil {
+		return nil, err
+	}
+
+	progress := db.BootstrapProgress()
+	result := &rpc.NodeBootstrapProgressResult_{
+		State:           bootstrapStateString(progress.State),
+		PercentComplete: progress.PercentComplete,
+		Namespaces:      make([]*rpc.NodeNamespaceBootstrapProgress, 0, len(progress.Namespaces)),
+	}
+	if !progress.Start.IsZero() {
+		result.StartNanos = progress.Start.UnixNano()
+	}
+	if !progress.EstimatedCompletion.IsZero() {
+		result.EstimatedCompletionNanos = progress.EstimatedCompletion.UnixNano()
+	}
+	for _, ns := range progress.Namespaces {
+		nsResult := &rpc.NodeNamespaceBootstrapProgress{
+			NameSpace:       ns.Namespace,
+			Bootstrapper:    ns.Bootstrapper,
+			PercentComplete: ns.PercentComplete,
+			Shards:          make([]*rpc.NodeShardBootstrapProgress, 0, len(ns.Shards)),
+		}
+		if !ns.AvailableFrom.IsZero() {
+			nsResult.AvailableFromNanos = ns.AvailableFrom.UnixNano()
+		}
+		for _, shard := range ns.Shards {
+			shardResult := &rpc.NodeShardBootstrapProgress{
+				Shard:           int64(shard.Shard),
+				PercentComplete: shard.PercentComplete,
+				RemainingRanges: make([]*rpc.NodeBootstrapRange, 0, len(shard.RemainingRanges)),
+			}
+			for _, r := range shard.RemainingRanges {
+				shardResult.RemainingRanges = append(shardResult.RemainingRanges, &rpc.NodeBootstrapRange{
+					StartNanos: r.Start.UnixNano(),
+					EndNanos:   r.End.UnixNano(),
+				})
+			}
+			if len(shard.AvailableRanges) > 0 {
+				shardResult.AvailableRanges = make([]*rpc.NodeBootstrapRange, 0, len(shard.AvailableRanges))
+				for _, r := range shard.AvailableRanges {
+					shardResult.AvailableRanges = append(shardResult.AvailableRanges, &rpc.NodeBootstrapRange{
+						StartNanos: r.Start.UnixNano(),
+						EndNanos:   r.End.UnixNano(),
+					})
+				}
+			}
+			nsResult.Shards = append(nsResult.Shards, shardResult)
+		}
+		result.Namespaces = append(result.Namespaces, nsResult)
+	}
+	return result, nil
+}
+
+func bootstrapStateString(state storage.BootstrapState) string {
+	switch state {
+	case storage.Bootstrapping:
+		return "bootstrapping"
+	case storage.Bootstrapped:
+		return "bootstrapped"
+	default:
+		return "not_started"
+	}
+}
+
+func (s *service) RebootstrapShard(
+	ctx thrift.Context,
+	req *rpc.NodeRebootstrapShardRequest,
+) (*rpc.NodeRebootstrapShardResult_, error) {
+	db, err := s.startRPCWithDB()
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Shard < 0 || req.Shard > math.MaxUint32 {
+		return nil, tterrors.NewBadRequestError(errInvalidRebootstrapShard)
+	}
+	if req.StartNanos >= req.EndNanos {
+		return nil, tterrors.NewBadRequestError(errInvalidRebootstrapRange)
+	}
+
+	var (
+		start  = s.nowFn()
+		window = xtime.Range{
+			Start: time.Unix(0, req.StartNanos),
+			End:   time.Unix(0, req.EndNanos),
+		}
+	)
+	err = db.RebootstrapShard(ident.StringID(req.NameSpace), uint32(req.Shard), window)
+	if err != nil {
+		return nil, convert.ToRPCError(err)
+	}
+	return &rpc.NodeRebootstrapShardResult_{
+		NameSpace: req.NameSpace,
+		Shard:     req.Shard,
+		TookNanos: int64(s.nowFn().Sub(start)),
+	}, nil
+}
+
+func (s *service) BootstrapDryRun(
+	ctx thrift.Context,
+) (*rpc.NodeBootstrapDryRunResult_, error) {
+	db, err := s.startRPCWithDB()
+	if err != nil {
+		return nil, err
+	}
+
+	dryRuns, err := db.BootstrapDryRun()
+	if err != nil {
+		return nil, convert.ToRPCError(err)
+	}
+
+	res := &rpc.NodeBootstrapDryRunResult_{
+		Namespaces: make([]*rpc.NodeNamespaceBootstrapDryRun, 0, len(dryRuns)),
+	}
+	for _, dryRun := range dryRuns {
+		res.Namespaces = append(res.Namespaces, &rpc.NodeNamespaceBootstrapDryRun{
+			NameSpace:        dryRun.Namespace,
+			Data:             toRPCBootstrapperAvailability(dryRun.DataResult),
+			DataUnfulfilled:  toRPCShardBootstrapRanges(dryRun.DataResult.Unfulfilled),
+			Index:            toRPCBootstrapperAvailability(dryRun.IndexResult),
+			IndexUnfulfilled: toRPCShardBootstrapRanges(dryRun.IndexResult.Unfulfilled),
+		})
+	}
+	return res, nil
+}
+
+func toRPCBootstrapperAvailability(
+	availability bootstrap.AvailabilityResult,
+) []*rpc.NodeBootstrapperAvailability {
+	bootstrappers := make([]*rpc.NodeBootstrapperAvailability, 0, len(availability.Bootstrappers))
+	for _, bs := range availability.Bootstrappers {
+		bootstrappers = append(bootstrappers, &rpc.NodeBootstrapperAvailability{
+			Bootstrapper: bs.Bootstrapper,
+			Fulfilled:    toRPCShardBootstrapRanges(bs.Fulfilled),
+		})
+	}
+	return bootstrappers
+}
+
+func toRPCShardBootstrapRanges(
+	shardsTimeRanges result.ShardTimeRanges,
+) []*rpc.NodeShardBootstrapRanges {
+	shards := make([]*rpc.NodeShardBootstrapRanges, 0, len(shardsTimeRanges))
+	for shard, ranges := range shardsTimeRanges {
+		if ranges.IsEmpty() {
+			continue
+		}
+		shardRanges := &rpc.NodeShardBootstrapRanges{
+			Shard:  int64(shard),
+			Ranges: make([]*rpc.NodeBootstrapRange, 0, ranges.Len()),
+		}
+		it := ranges.Iter()
+		for it.Next() {
+			r := it.Value()
+			shardRanges.Ranges = append(shardRanges.Ranges, &rpc.NodeBootstrapRange{
+				StartNanos: r.Start.UnixNano(),
+				EndNanos:   r.End.UnixNano(),
+			})
+		}
+		shards = append(shards, shardRanges)
+	}
+	sort.Slice(shards, func(i, j int) bool {
+		return shards[i].Shard < shards[j].Shard
+	})
+	return shards
+}
+
 func (s *service) SetDatabase(db storage.Database) error {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -1442,6 +2452,12 @@ func (s *service) SetDatabase(db storage.Database) error {
 	return nil
 }
 
+func (s *service) SetWriteForwarder(value tchannelthrift.WriteForwarder) {
+	s.state.Lock()
+	s.state.writeForwarder = value
+	s.state.Unlock()
+}
+
 func (s *service) startRPCWithDB() (storage.Database, error) {
 	db, ok := s.state.DB()
 	if !ok {
@@ -1456,6 +2472,53 @@ func (s *service) startRPCWithDB() (storage.Database, error) {
 	return db, nil
 }
 
+// startReadRPCWithDB starts an RPC that reads from the database, the read is
+// tracked as an in-flight query until the RPC completes so that a drain of
+// the database waits for it.
+func (s *service) startReadRPCWithDB(tctx thrift.Context) (storage.Database, error) {
+	db, err := s.startRPCWithDB()
+	if err != nil {
+		return nil, err
+	}
+
+	db.TrackQuery(tchannelthrift.Context(tctx))
+	return db, nil
+}
+
+// registerLiveQuery registers a fetch so that it can be listed and cancelled
+// by ID, cancelling it cancels the Go context of the M3DB context. The handle
+// must be closed once the fetch completes.
+func (s *service) registerLiveQuery(
+	ctx context.Context,
+	queryFn livequery.QueryFn,
+) *livequery.Handle {
+	goCtx, ok := ctx.GoContext()
+	if !ok {
+		goCtx = stdctx.Background()
+	}
+	goCtx, liveQuery := s.liveQueries.Register(goCtx, queryFn, nil)
+	ctx.SetGoContext(goCtx)
+	return liveQuery
+}
+
+// describeQuery returns the readable form of a serialized index query.
+func describeQuery(query []byte) string {
+	q, err := idx.Unmarshal(query)
+	if err != nil {
+		return fmt.Sprintf("<invalid query: %v>", err)
+	}
+	return q.String()
+}
+
+// liveQueryRPCError returns the RPC error for a failed fetch, a fetch that
+// was cancelled by ID fails with a non-retryable error.
+func (s *service) liveQueryRPCError(liveQuery *livequery.Handle, err error) error {
+	if cancelErr := liveQuery.Err(); cancelErr != nil {
+		return tterrors.NewBadRequestError(cancelErr)
+	}
+	return convert.ToRPCError(err)
+}
+
 func (s *service) newID(ctx context.Context, id []byte) ident.ID {
 	checkedBytes := s.pools.checkedBytesWrapper.Get(id)
 	return s.pools.id.GetBinaryID(ctx, checkedBytes)
@@ -1483,6 +2546,13 @@ func (s *service) readEncoded(
 		return nil, convert.ToRPCError(err)
 	}
 
+	return s.toSegments(ctx, encoded)
+}
+
+func (s *service) toSegments(
+	ctx context.Context,
+	encoded [][]xio.BlockReader,
+) ([]*rpc.Segments, *rpc.Error) {
 	segments := s.pools.segmentsArray.Get()
 	segments = segmentsArr(segments).grow(len(encoded))
 	segments = segments[:0]
@@ -1653,6 +2723,14 @@ func (r *writeBatchPooledReq) HandleError(index int, err error) {
 		return
 	}
 
+	if xerrors.IsResourceExhaustedError(err) {
+		r.retryableErrors++
+		r.errs = append(
+			r.errs,
+			tterrors.NewResourceExhaustedWriteBatchRawError(index, err))
+		return
+	}
+
 	r.retryableErrors++
 	r.errs = append(
 		r.errs,
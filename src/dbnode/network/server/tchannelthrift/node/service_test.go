@@ -35,10 +35,16 @@ import (
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/convert"
 	tterrors "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/errors"
+	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/storage"
 	"github.com/m3db/m3/src/dbnode/storage/block"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/durability"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/tracepoint"
@@ -46,7 +52,10 @@ import (
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/m3ninx/idx"
 	"github.com/m3db/m3/src/x/checked"
+	xcontext "github.com/m3db/m3/src/x/context"
+	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/livequery"
 	"github.com/m3db/m3/src/x/serialize"
 	xtime "github.com/m3db/m3/src/x/time"
 
@@ -216,14 +225,25 @@ func TestServiceBootstrappedInPlacementOrNoPlacement(t *testing.T) {
 }
 
 func TestServiceQuery(t *testing.T) {
+	testServiceQuery(t, testTChannelThriftOptions)
+}
+
+func TestServiceQueryDecodeWorkerPool(t *testing.T) {
+	testServiceQuery(t, testTChannelThriftOptions.
+		SetDecodeWorkerPool(NewDecodeWorkerPool(2)).
+		SetDecodeConcurrencyPerQuery(2))
+}
+
+func testServiceQuery(t *testing.T, opts tchannelthrift.Options) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockDB := storage.NewMockDatabase(ctrl)
 	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
 	mockDB.EXPECT().IsOverloaded().Return(false)
+	mockDB.EXPECT().TrackQuery(gomock.Any())
 
-	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+	service := NewService(mockDB, opts).(*service)
 
 	tctx, _ := tchannelthrift.NewContext(time.Minute)
 	ctx := tchannelthrift.Context(tctx)
@@ -433,6 +453,7 @@ func TestServiceQueryUnknownErr(t *testing.T) {
 	mockDB := storage.NewMockDatabase(ctrl)
 	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
 	mockDB.EXPECT().IsOverloaded().Return(false)
+	mockDB.EXPECT().TrackQuery(gomock.Any())
 
 	service := NewService(mockDB, testTChannelThriftOptions).(*service)
 
@@ -488,6 +509,7 @@ func TestServiceFetch(t *testing.T) {
 	mockDB := storage.NewMockDatabase(ctrl)
 	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
 	mockDB.EXPECT().IsOverloaded().Return(false)
+	mockDB.EXPECT().TrackQuery(gomock.Any())
 
 	service := NewService(mockDB, testTChannelThriftOptions).(*service)
 
@@ -616,6 +638,7 @@ func TestServiceFetchUnknownErr(t *testing.T) {
 	mockDB := storage.NewMockDatabase(ctrl)
 	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
 	mockDB.EXPECT().IsOverloaded().Return(false)
+	mockDB.EXPECT().TrackQuery(gomock.Any())
 
 	service := NewService(mockDB, testTChannelThriftOptions).(*service)
 	tctx, _ := tchannelthrift.NewContext(time.Minute)
@@ -652,6 +675,7 @@ func TestServiceFetchBatchRaw(t *testing.T) {
 	mockDB := storage.NewMockDatabase(ctrl)
 	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
 	mockDB.EXPECT().IsOverloaded().Return(false)
+	mockDB.EXPECT().TrackQuery(gomock.Any())
 
 	service := NewService(mockDB, testTChannelThriftOptions).(*service)
 
@@ -749,6 +773,7 @@ func TestServiceFetchBatchRawUnknownError(t *testing.T) {
 	mockDB := storage.NewMockDatabase(ctrl)
 	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
 	mockDB.EXPECT().IsOverloaded().Return(false)
+	mockDB.EXPECT().TrackQuery(gomock.Any())
 
 	service := NewService(mockDB, testTChannelThriftOptions).(*service)
 	tctx, _ := tchannelthrift.NewContext(time.Minute)
@@ -862,6 +887,7 @@ func TestServiceFetchBlocksRaw(t *testing.T) {
 	mockDB.EXPECT().Namespace(ident.NewIDMatcher(nsID)).Return(mockNs, true).AnyTimes()
 	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
 	mockDB.EXPECT().IsOverloaded().Return(false)
+	mockDB.EXPECT().TrackQuery(gomock.Any())
 
 	service := NewService(mockDB, testTChannelThriftOptions).(*service)
 
@@ -965,7 +991,161 @@ func TestServiceFetchBlocksRaw(t *testing.T) {
 
 		assert.Equal(t, expectHead, seg.Merged.Head)
 		assert.Equal(t, expectTail, seg.Merged.Tail)
+		// Segment checksums are only included when requested.
+		assert.Nil(t, seg.Merged.Checksum)
+	}
+}
+
+func TestServiceFetchBlocksRawSegmentChecksums(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	nsID := "metrics"
+	mockNs := storage.NewMockNamespace(ctrl)
+	mockNs.EXPECT().Options().Return(testNamespaceOptions).AnyTimes()
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Namespace(ident.NewIDMatcher(nsID)).Return(mockNs, true).AnyTimes()
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false)
+	mockDB.EXPECT().TrackQuery(gomock.Any())
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	var (
+		start     = time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+		readers   []xio.BlockReader
+		checksums []uint32
+	)
+	for i, v := range []float64{1.0, 2.0} {
+		enc := testStorageOpts.EncoderPool().Get()
+		enc.Reset(start, 0, nil)
+		require.NoError(t, enc.Encode(ts.Datapoint{
+			Timestamp: start.Add(time.Duration(i+1) * time.Second),
+			Value:     v,
+		}, xtime.Second, nil))
+
+		stream, _ := enc.Stream(encoding.StreamOptions{})
+		seg, err := stream.Segment()
+		require.NoError(t, err)
+
+		readers = append(readers, xio.BlockReader{
+			SegmentReader: stream,
+			Start:         start,
+		})
+		checksums = append(checksums, digest.SegmentChecksum(seg))
+	}
+
+	mockDB.EXPECT().
+		FetchBlocks(ctx, ident.NewIDMatcher(nsID), uint32(0), ident.NewIDMatcher("foo"),
+			[]time.Time{start}).
+		Return([]block.FetchBlockResult{
+			block.NewFetchBlockResult(start, readers, nil),
+		}, nil)
+
+	includeSegmentChecksums := true
+	r, err := service.FetchBlocksRaw(tctx, &rpc.FetchBlocksRawRequest{
+		NameSpace: []byte(nsID),
+		Shard:     0,
+		Elements: []*rpc.FetchBlocksRawRequestElement{
+			&rpc.FetchBlocksRawRequestElement{
+				ID:     []byte("foo"),
+				Starts: []int64{start.UnixNano()},
+			},
+		},
+		IncludeSegmentChecksums: &includeSegmentChecksums,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, 1, len(r.Elements))
+	require.Equal(t, 1, len(r.Elements[0].Blocks))
+	fetched := r.Elements[0].Blocks[0]
+	require.Nil(t, fetched.Err)
+	require.Nil(t, fetched.Checksum)
+	require.NotNil(t, fetched.Segments)
+	require.Equal(t, len(checksums), len(fetched.Segments.Unmerged))
+	for i, seg := range fetched.Segments.Unmerged {
+		require.NotNil(t, seg.Checksum)
+		require.Equal(t, checksums[i], uint32(*seg.Checksum))
+	}
+}
+
+func TestServiceFetchBlocksRawSegmentSources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	nsID := "metrics"
+	mockNs := storage.NewMockNamespace(ctrl)
+	mockNs.EXPECT().Options().Return(testNamespaceOptions).AnyTimes()
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Namespace(ident.NewIDMatcher(nsID)).Return(mockNs, true).AnyTimes()
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false)
+	mockDB.EXPECT().TrackQuery(gomock.Any())
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	var (
+		start   = time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+		sources = []xio.BlockSource{
+			xio.NewDiskBlockSource(1),
+			{Type: xio.BufferColdBlockSource},
+		}
+		readers []xio.BlockReader
+	)
+	for i, v := range []float64{1.0, 2.0} {
+		enc := testStorageOpts.EncoderPool().Get()
+		enc.Reset(start, 0, nil)
+		require.NoError(t, enc.Encode(ts.Datapoint{
+			Timestamp: start.Add(time.Duration(i+1) * time.Second),
+			Value:     v,
+		}, xtime.Second, nil))
+
+		stream, _ := enc.Stream(encoding.StreamOptions{})
+		readers = append(readers, xio.BlockReader{
+			SegmentReader: stream,
+			Start:         start,
+			Source:        sources[i],
+		})
 	}
+
+	mockDB.EXPECT().
+		FetchBlocks(ctx, ident.NewIDMatcher(nsID), uint32(0), ident.NewIDMatcher("foo"),
+			[]time.Time{start}).
+		Return([]block.FetchBlockResult{
+			block.NewFetchBlockResult(start, readers, nil),
+		}, nil)
+
+	includeSegmentSources := true
+	r, err := service.FetchBlocksRaw(tctx, &rpc.FetchBlocksRawRequest{
+		NameSpace: []byte(nsID),
+		Shard:     0,
+		Elements: []*rpc.FetchBlocksRawRequestElement{
+			&rpc.FetchBlocksRawRequestElement{
+				ID:     []byte("foo"),
+				Starts: []int64{start.UnixNano()},
+			},
+		},
+		IncludeSegmentSources: &includeSegmentSources,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, 1, len(r.Elements))
+	require.Equal(t, 1, len(r.Elements[0].Blocks))
+	fetched := r.Elements[0].Blocks[0]
+	require.Nil(t, fetched.Err)
+	require.NotNil(t, fetched.Segments)
+	require.Equal(t, []string{"disk-volume-1", "buffer-cold"}, []string{
+		fetched.Segments.Unmerged[0].GetSource(),
+		fetched.Segments.Unmerged[1].GetSource(),
+	})
 }
 
 func TestServiceFetchBlocksRawIsOverloaded(t *testing.T) {
@@ -1055,6 +1235,7 @@ func TestServiceFetchBlocksMetadataEndpointV2Raw(t *testing.T) {
 	mockDB := storage.NewMockDatabase(ctrl)
 	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
 	mockDB.EXPECT().IsOverloaded().Return(false)
+	mockDB.EXPECT().TrackQuery(gomock.Any())
 	service := NewService(mockDB, testTChannelThriftOptions).(*service)
 	tctx, _ := tchannelthrift.NewContext(time.Minute)
 	ctx := tchannelthrift.Context(tctx)
@@ -1188,6 +1369,153 @@ func TestServiceFetchBlocksMetadataEndpointV2Raw(t *testing.T) {
 	}
 }
 
+func TestServiceFetchBlocksMetadataEndpointV2RawShaping(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	var (
+		start = now.Truncate(time.Hour)
+		end   = now.Add(4 * time.Hour).Truncate(time.Hour)
+		limit = int64(10)
+		nsID  = "metrics"
+	)
+
+	newMockResult := func() block.FetchBlocksMetadataResults {
+		results := block.NewFetchBlocksMetadataResults()
+		for _, id := range []string{"foo", "bar"} {
+			blocks := block.NewFetchBlockMetadataResults()
+			blocks.Add(block.FetchBlockMetadataResult{Start: start, Size: 16})
+			blocks.Add(block.FetchBlockMetadataResult{Start: start.Add(2 * time.Hour), Size: 64})
+			results.Add(block.NewFetchBlocksMetadataResult(ident.StringID(id),
+				ident.EmptyTagIterator, blocks))
+		}
+		// Series with only blocks that are filtered out below.
+		blocks := block.NewFetchBlockMetadataResults()
+		blocks.Add(block.FetchBlockMetadataResult{Start: start, Size: 8})
+		results.Add(block.NewFetchBlocksMetadataResult(ident.StringID("baz"),
+			ident.EmptyTagIterator, blocks))
+		return results
+	}
+
+	t.Run("filter", func(t *testing.T) {
+		mockDB := storage.NewMockDatabase(ctrl)
+		mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+		mockDB.EXPECT().IsOverloaded().Return(false)
+		mockDB.EXPECT().TrackQuery(gomock.Any())
+		service := NewService(mockDB, testTChannelThriftOptions).(*service)
+		tctx, _ := tchannelthrift.NewContext(time.Minute)
+		ctx := tchannelthrift.Context(tctx)
+		defer ctx.Close()
+
+		// Sizes are fetched to filter on even though they are not returned.
+		mockDB.EXPECT().
+			FetchBlocksMetadataV2(ctx, ident.NewIDMatcher(nsID), uint32(0), start, end,
+				limit, nil, block.FetchBlocksMetadataOptions{IncludeSizes: true}).
+			Return(newMockResult(), nil, nil)
+
+		minBlockStart := start.Add(time.Hour).UnixNano()
+		minSize := int64(32)
+		r, err := service.FetchBlocksMetadataRawV2(tctx, &rpc.FetchBlocksMetadataRawV2Request{
+			NameSpace:     []byte(nsID),
+			RangeStart:    start.UnixNano(),
+			RangeEnd:      end.UnixNano(),
+			Limit:         limit,
+			MinBlockStart: &minBlockStart,
+			MinSize:       &minSize,
+		})
+		require.NoError(t, err)
+
+		require.Equal(t, 2, len(r.Elements))
+		require.Nil(t, r.Summaries)
+		for _, elem := range r.Elements {
+			require.Equal(t, start.Add(2*time.Hour).UnixNano(), elem.Start)
+			require.Nil(t, elem.Size)
+		}
+	})
+
+	t.Run("summary", func(t *testing.T) {
+		mockDB := storage.NewMockDatabase(ctrl)
+		mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+		mockDB.EXPECT().IsOverloaded().Return(false)
+		mockDB.EXPECT().TrackQuery(gomock.Any())
+		service := NewService(mockDB, testTChannelThriftOptions).(*service)
+		tctx, _ := tchannelthrift.NewContext(time.Minute)
+		ctx := tchannelthrift.Context(tctx)
+		defer ctx.Close()
+
+		mockDB.EXPECT().
+			FetchBlocksMetadataV2(ctx, ident.NewIDMatcher(nsID), uint32(0), start, end,
+				limit, nil, block.FetchBlocksMetadataOptions{IncludeSizes: true}).
+			Return(newMockResult(), []byte("page_next"), nil)
+
+		minSize := int64(16)
+		summaryOnly := true
+		r, err := service.FetchBlocksMetadataRawV2(tctx, &rpc.FetchBlocksMetadataRawV2Request{
+			NameSpace:   []byte(nsID),
+			RangeStart:  start.UnixNano(),
+			RangeEnd:    end.UnixNano(),
+			Limit:       limit,
+			MinSize:     &minSize,
+			SummaryOnly: &summaryOnly,
+		})
+		require.NoError(t, err)
+
+		require.Equal(t, 0, len(r.Elements))
+		require.Equal(t, []byte("page_next"), r.NextPageToken)
+		require.Equal(t, []*rpc.BlockMetadataV2Summary{
+			{Start: start.UnixNano(), Count: 2, Size: 32},
+			{Start: start.Add(2 * time.Hour).UnixNano(), Count: 2, Size: 128},
+		}, r.Summaries)
+	})
+
+	t.Run("cardinality", func(t *testing.T) {
+		mockDB := storage.NewMockDatabase(ctrl)
+		mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+		mockDB.EXPECT().IsOverloaded().Return(false)
+		mockDB.EXPECT().TrackQuery(gomock.Any())
+		service := NewService(mockDB, testTChannelThriftOptions).(*service)
+		tctx, _ := tchannelthrift.NewContext(time.Minute)
+		ctx := tchannelthrift.Context(tctx)
+		defer ctx.Close()
+
+		mockDB.EXPECT().
+			FetchBlocksMetadataV2(ctx, ident.NewIDMatcher(nsID), uint32(0), start, end,
+				limit, nil, block.FetchBlocksMetadataOptions{}).
+			Return(newMockResult(), nil, nil)
+		mockDB.EXPECT().
+			BlockCardinalities(ident.NewIDMatcher(nsID), uint32(0), start, end).
+			Return(storage.BlockCardinalities{
+				Data: []storage.BlockCardinality{
+					{BlockStart: start, Series: 3},
+					{BlockStart: start.Add(2 * time.Hour), Series: 2},
+				},
+				Index: []storage.BlockCardinality{
+					{BlockStart: start, Series: 3},
+				},
+			}, nil)
+
+		includeCardinality := true
+		r, err := service.FetchBlocksMetadataRawV2(tctx, &rpc.FetchBlocksMetadataRawV2Request{
+			NameSpace:          []byte(nsID),
+			RangeStart:         start.UnixNano(),
+			RangeEnd:           end.UnixNano(),
+			Limit:              limit,
+			IncludeCardinality: &includeCardinality,
+		})
+		require.NoError(t, err)
+
+		require.Equal(t, 5, len(r.Elements))
+		require.Equal(t, []*rpc.BlockCardinality{
+			{Start: start.UnixNano(), Series: 3},
+			{Start: start.Add(2 * time.Hour).UnixNano(), Series: 2},
+		}, r.Cardinalities)
+		require.Equal(t, []*rpc.BlockCardinality{
+			{Start: start.UnixNano(), Series: 3},
+		}, r.IndexCardinalities)
+	})
+}
+
 func TestServiceFetchBlocksMetadataEndpointV2RawIsOverloaded(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1272,6 +1600,7 @@ func TestServiceFetchTagged(t *testing.T) {
 	mockDB := storage.NewMockDatabase(ctrl)
 	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
 	mockDB.EXPECT().IsOverloaded().Return(false)
+	mockDB.EXPECT().TrackQuery(gomock.Any())
 
 	service := NewService(mockDB, testTChannelThriftOptions).(*service)
 
@@ -1317,15 +1646,31 @@ func TestServiceFetchTagged(t *testing.T) {
 
 		stream, _ := enc.Stream(encoding.StreamOptions{})
 		streams[id] = stream
-		mockDB.EXPECT().
-			ReadEncoded(gomock.Any(), ident.NewIDMatcher(nsID), ident.NewIDMatcher(id), start, end).
-			Return([][]xio.BlockReader{{
-				xio.BlockReader{
-					SegmentReader: stream,
-				},
-			}}, nil)
 	}
 
+	// All the series are hydrated with a single batched read.
+	mockDB.EXPECT().
+		ReadEncodedBatch(gomock.Any(), ident.NewIDMatcher(nsID), gomock.Any(), start, end).
+		DoAndReturn(func(
+			_ xcontext.Context,
+			_ ident.ID,
+			ids []ident.ID,
+			_, _ time.Time,
+		) ([]storage.ReadEncodedResult, error) {
+			require.Equal(t, len(series), len(ids))
+			results := make([]storage.ReadEncodedResult, 0, len(ids))
+			for _, id := range ids {
+				results = append(results, storage.ReadEncodedResult{
+					Readers: [][]xio.BlockReader{{
+						xio.BlockReader{
+							SegmentReader: streams[id.String()],
+						},
+					}},
+				})
+			}
+			return results, nil
+		})
+
 	req, err := idx.NewRegexpQuery([]byte("foo"), []byte("b.*"))
 	require.NoError(t, err)
 	qry := index.Query{Query: req}
@@ -1510,6 +1855,7 @@ func TestServiceFetchTaggedNoData(t *testing.T) {
 	mockDB := storage.NewMockDatabase(ctrl)
 	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
 	mockDB.EXPECT().IsOverloaded().Return(false)
+	mockDB.EXPECT().TrackQuery(gomock.Any())
 
 	service := NewService(mockDB, testTChannelThriftOptions).(*service)
 
@@ -1572,6 +1918,73 @@ func TestServiceFetchTaggedNoData(t *testing.T) {
 	}
 }
 
+func TestServiceFetchTaggedIncludeDurability(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false)
+	mockDB.EXPECT().TrackQuery(gomock.Any())
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	var (
+		end      = time.Now().Truncate(time.Second)
+		start    = end.Add(-2 * time.Hour)
+		snapshot = end.Add(-30 * time.Minute)
+		nsID     = "metrics"
+	)
+
+	req, err := idx.NewRegexpQuery([]byte("foo"), []byte("b.*"))
+	require.NoError(t, err)
+	qry := index.Query{Query: req}
+	opts := index.QueryOptions{
+		StartInclusive: start,
+		EndExclusive:   end,
+	}
+
+	mockDB.EXPECT().DataDurability(ident.NewIDMatcher(nsID), start, end).
+		Return(durability.Ranges{
+			{Start: start, End: snapshot, Level: durability.Snapshot},
+			{Start: snapshot, End: end, Level: durability.BufferOnly},
+		}, nil)
+	resMap := index.NewQueryResults(ident.StringID(nsID),
+		index.QueryResultsOptions{}, testIndexOptions)
+	mockDB.EXPECT().QueryIDs(ctx, ident.NewIDMatcher(nsID),
+		index.NewQueryMatcher(qry), opts).
+		Return(index.QueryResult{Results: resMap, Exhaustive: true}, nil)
+
+	data, err := idx.Marshal(req)
+	require.NoError(t, err)
+	includeDurability := true
+	r, err := service.FetchTagged(tctx, &rpc.FetchTaggedRequest{
+		NameSpace:         []byte(nsID),
+		Query:             data,
+		RangeStart:        start.UnixNano(),
+		RangeEnd:          end.UnixNano(),
+		RangeTimeType:     rpc.TimeType_UNIX_NANOSECONDS,
+		IncludeDurability: &includeDurability,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*rpc.DataDurabilityRange{
+		{
+			RangeStart: start.UnixNano(),
+			RangeEnd:   snapshot.UnixNano(),
+			Durability: "snapshot",
+		},
+		{
+			RangeStart: snapshot.UnixNano(),
+			RangeEnd:   end.UnixNano(),
+			Durability: "buffer-only",
+		},
+	}, r.DurabilityRanges)
+}
+
 func TestServiceFetchTaggedErrs(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1579,6 +1992,7 @@ func TestServiceFetchTaggedErrs(t *testing.T) {
 	mockDB := storage.NewMockDatabase(ctrl)
 	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
 	mockDB.EXPECT().IsOverloaded().Return(false)
+	mockDB.EXPECT().TrackQuery(gomock.Any())
 
 	service := NewService(mockDB, testTChannelThriftOptions).(*service)
 
@@ -1631,6 +2045,7 @@ func TestServiceAggregate(t *testing.T) {
 	mockDB := storage.NewMockDatabase(ctrl)
 	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
 	mockDB.EXPECT().IsOverloaded().Return(false)
+	mockDB.EXPECT().TrackQuery(gomock.Any())
 
 	service := NewService(mockDB, testTChannelThriftOptions).(*service)
 
@@ -1746,7 +2161,7 @@ func TestServiceWrite(t *testing.T) {
 	require.NoError(t, err)
 }
 
-func TestServiceWriteOverloaded(t *testing.T) {
+func TestServiceWriteStrictlyIncreasing(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -1759,44 +2174,39 @@ func TestServiceWriteOverloaded(t *testing.T) {
 	ctx := tchannelthrift.Context(tctx)
 	defer ctx.Close()
 
-	mockDB.EXPECT().IsOverloaded().Return(true)
-	err := service.Write(tctx, &rpc.WriteRequest{
-		NameSpace: "metrics",
-		ID:        "foo",
-		Datapoint: &rpc.Datapoint{
-			Timestamp:         time.Now().Unix(),
-			TimestampTimeType: rpc.TimeType_UNIX_SECONDS,
-			Value:             42.42,
-		},
-	})
-	require.Equal(t, tterrors.NewInternalError(errServerIsOverloaded), err)
-}
+	nsID := "metrics"
 
-func TestServiceWriteDatabaseNotSet(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
+	id := "foo"
 
-	var (
-		service = NewService(nil, testTChannelThriftOptions).(*service)
-		tctx, _ = tchannelthrift.NewContext(time.Minute)
-		ctx     = tchannelthrift.Context(tctx)
-	)
+	at := time.Now().Truncate(time.Second)
+	value := 42.42
 
-	defer ctx.Close()
+	mockDB.EXPECT().
+		Write(ctx, ident.NewIDMatcher(nsID), ident.NewIDMatcher(id), at, value,
+			xtime.Second, nil).
+		DoAndReturn(func(ctx xcontext.Context, _, _ ident.ID, _ time.Time,
+			_ float64, _ xtime.Unit, _ []byte) error {
+			require.True(t, series.StrictlyIncreasingWrites(ctx))
+			return m3dberrors.ErrNotStrictlyIncreasing
+		})
 
+	mockDB.EXPECT().IsOverloaded().Return(false)
+	strictlyIncreasing := true
 	err := service.Write(tctx, &rpc.WriteRequest{
-		NameSpace: "metrics",
-		ID:        "foo",
+		NameSpace: nsID,
+		ID:        id,
 		Datapoint: &rpc.Datapoint{
-			Timestamp:         time.Now().Unix(),
+			Timestamp:         at.Unix(),
 			TimestampTimeType: rpc.TimeType_UNIX_SECONDS,
-			Value:             42.42,
+			Value:             value,
 		},
+		StrictlyIncreasing: &strictlyIncreasing,
 	})
-	require.Equal(t, tterrors.NewInternalError(errDatabaseIsNotInitializedYet), err)
+	require.Error(t, err)
+	require.True(t, tterrors.IsBadRequestError(err.(*rpc.Error)))
 }
 
-func TestServiceWriteTagged(t *testing.T) {
+func TestServiceGetRPCByteUsage(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -1806,40 +2216,253 @@ func TestServiceWriteTagged(t *testing.T) {
 	service := NewService(mockDB, testTChannelThriftOptions).(*service)
 
 	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	tctx = thrift.WithHeaders(tctx, map[string]string{
+		tchannelthrift.TenantHeader: "tenant-a",
+	})
 	ctx := tchannelthrift.Context(tctx)
 	defer ctx.Close()
 
-	var (
-		nsID      = "metrics"
-		id        = "foo"
-		tagNames  = []string{"foo", "bar", "baz"}
-		tagValues = []string{"cmon", "keep", "going"}
-		at        = time.Now().Truncate(time.Second)
-		value     = 42.42
-	)
+	nsID := "metrics"
 
-	mockDB.EXPECT().WriteTagged(ctx,
-		ident.NewIDMatcher(nsID),
-		ident.NewIDMatcher(id),
-		gomock.Any(),
-		at, value, xtime.Second, nil,
-	).Return(nil)
+	id := "foo"
 
-	request := &rpc.WriteTaggedRequest{
+	at := time.Now().Truncate(time.Second)
+	value := 42.42
+
+	mockDB.EXPECT().
+		Write(ctx, ident.NewIDMatcher(nsID), ident.NewIDMatcher(id), at, value,
+			xtime.Second, []byte("bar")).
+		Return(nil)
+
+	mockDB.EXPECT().IsOverloaded().Return(false)
+	err := service.Write(tctx, &rpc.WriteRequest{
 		NameSpace: nsID,
 		ID:        id,
 		Datapoint: &rpc.Datapoint{
 			Timestamp:         at.Unix(),
 			TimestampTimeType: rpc.TimeType_UNIX_SECONDS,
 			Value:             value,
+			Annotation:        []byte("bar"),
 		},
-		Tags: []*rpc.Tag{},
-	}
+	})
+	require.NoError(t, err)
 
-	for i := range tagNames {
-		request.Tags = append(request.Tags, &rpc.Tag{
-			Name:  tagNames[i],
-			Value: tagValues[i],
+	result, err := service.GetRPCByteUsage(tctx)
+	require.NoError(t, err)
+	require.Equal(t, []*rpc.NodeRPCByteUsage{
+		{
+			NameSpace: nsID,
+			Tenant:    "tenant-a",
+			BytesIn:   int64(len(id)) + 16 + 3,
+		},
+	}, result.Usage)
+}
+
+func TestServiceLiveQueries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	liveQuery := service.registerLiveQuery(ctx, func() string {
+		return "fetch foo in metrics"
+	})
+	defer liveQuery.Close()
+	liveQuery.AddSeries(1)
+	liveQuery.AddBytes(42)
+
+	result, err := service.GetLiveQueries(tctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(result.Queries))
+	q := result.Queries[0]
+	assert.Equal(t, liveQuery.ID(), q.ID)
+	assert.Equal(t, "fetch foo in metrics", q.Query)
+	assert.Equal(t, int64(1), q.Series)
+	assert.Equal(t, int64(42), q.Bytes)
+
+	_, err = service.CancelLiveQuery(tctx, &rpc.NodeCancelLiveQueryRequest{
+		ID: liveQuery.ID(),
+	})
+	require.NoError(t, err)
+
+	goCtx, ok := ctx.GoContext()
+	require.True(t, ok)
+	require.Equal(t, context.Canceled, goCtx.Err())
+	require.Equal(t, livequery.ErrQueryCancelled, liveQuery.Err())
+
+	_, err = service.CancelLiveQuery(tctx, &rpc.NodeCancelLiveQueryRequest{
+		ID: "unknown",
+	})
+	require.Error(t, err)
+	require.True(t, tterrors.IsBadRequestError(err.(*rpc.Error)))
+}
+
+func TestServiceFetchTaggedCancelledByID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false)
+	mockDB.EXPECT().TrackQuery(gomock.Any())
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	start := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	end := start.Add(2 * time.Hour)
+	nsID := "metrics"
+
+	req, err := idx.NewRegexpQuery([]byte("foo"), []byte("b.*"))
+	require.NoError(t, err)
+
+	resMap := index.NewQueryResults(ident.StringID(nsID),
+		index.QueryResultsOptions{}, testIndexOptions)
+	resMap.Map().Set(ident.StringID("foo"), ident.NewTags(
+		ident.StringTag("foo", "bar"),
+	))
+
+	// Cancel the fetch by ID while it is querying the index.
+	mockDB.EXPECT().
+		QueryIDs(gomock.Any(), ident.NewIDMatcher(nsID), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ xcontext.Context,
+			_ ident.ID,
+			_ index.Query,
+			_ index.QueryOptions,
+		) (index.QueryResult, error) {
+			queries := service.liveQueries.Queries()
+			require.Equal(t, 1, len(queries))
+			require.Equal(t, "fetch tagged "+req.String()+" in metrics",
+				queries[0].Query)
+			require.NoError(t, service.liveQueries.Cancel(queries[0].ID))
+			return index.QueryResult{Results: resMap, Exhaustive: true}, nil
+		})
+
+	startNanos, err := convert.ToValue(start, rpc.TimeType_UNIX_NANOSECONDS)
+	require.NoError(t, err)
+	endNanos, err := convert.ToValue(end, rpc.TimeType_UNIX_NANOSECONDS)
+	require.NoError(t, err)
+	data, err := idx.Marshal(req)
+	require.NoError(t, err)
+	_, err = service.FetchTagged(tctx, &rpc.FetchTaggedRequest{
+		NameSpace:  []byte(nsID),
+		Query:      data,
+		RangeStart: startNanos,
+		RangeEnd:   endNanos,
+		FetchData:  true,
+	})
+	require.Error(t, err)
+	require.True(t, tterrors.IsBadRequestError(err.(*rpc.Error)))
+
+	// The fetch is no longer live once it has returned.
+	require.Equal(t, 0, len(service.liveQueries.Queries()))
+}
+
+func TestServiceWriteOverloaded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	mockDB.EXPECT().IsOverloaded().Return(true)
+	err := service.Write(tctx, &rpc.WriteRequest{
+		NameSpace: "metrics",
+		ID:        "foo",
+		Datapoint: &rpc.Datapoint{
+			Timestamp:         time.Now().Unix(),
+			TimestampTimeType: rpc.TimeType_UNIX_SECONDS,
+			Value:             42.42,
+		},
+	})
+	require.Equal(t, tterrors.NewInternalError(errServerIsOverloaded), err)
+}
+
+func TestServiceWriteDatabaseNotSet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		service = NewService(nil, testTChannelThriftOptions).(*service)
+		tctx, _ = tchannelthrift.NewContext(time.Minute)
+		ctx     = tchannelthrift.Context(tctx)
+	)
+
+	defer ctx.Close()
+
+	err := service.Write(tctx, &rpc.WriteRequest{
+		NameSpace: "metrics",
+		ID:        "foo",
+		Datapoint: &rpc.Datapoint{
+			Timestamp:         time.Now().Unix(),
+			TimestampTimeType: rpc.TimeType_UNIX_SECONDS,
+			Value:             42.42,
+		},
+	})
+	require.Equal(t, tterrors.NewInternalError(errDatabaseIsNotInitializedYet), err)
+}
+
+func TestServiceWriteTagged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	var (
+		nsID      = "metrics"
+		id        = "foo"
+		tagNames  = []string{"foo", "bar", "baz"}
+		tagValues = []string{"cmon", "keep", "going"}
+		at        = time.Now().Truncate(time.Second)
+		value     = 42.42
+	)
+
+	mockDB.EXPECT().WriteTagged(ctx,
+		ident.NewIDMatcher(nsID),
+		ident.NewIDMatcher(id),
+		gomock.Any(),
+		at, value, xtime.Second, nil,
+	).Return(nil)
+
+	request := &rpc.WriteTaggedRequest{
+		NameSpace: nsID,
+		ID:        id,
+		Datapoint: &rpc.Datapoint{
+			Timestamp:         at.Unix(),
+			TimestampTimeType: rpc.TimeType_UNIX_SECONDS,
+			Value:             value,
+		},
+		Tags: []*rpc.Tag{},
+	}
+
+	for i := range tagNames {
+		request.Tags = append(request.Tags, &rpc.Tag{
+			Name:  tagNames[i],
+			Value: tagValues[i],
 		})
 	}
 	mockDB.EXPECT().IsOverloaded().Return(false)
@@ -1847,6 +2470,62 @@ func TestServiceWriteTagged(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestServiceValidateWriteTagged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	var (
+		nsID       = "metrics"
+		id         = "foo"
+		at         = time.Now().Truncate(time.Second)
+		annotation = []byte("annotation")
+	)
+
+	request := &rpc.WriteTaggedRequest{
+		NameSpace: nsID,
+		ID:        id,
+		Datapoint: &rpc.Datapoint{
+			Timestamp:         at.Unix(),
+			TimestampTimeType: rpc.TimeType_UNIX_SECONDS,
+			Value:             42.42,
+			Annotation:        annotation,
+		},
+		Tags: []*rpc.Tag{{Name: "foo", Value: "bar"}},
+	}
+
+	mockDB.EXPECT().ValidateWrite(
+		ident.NewIDMatcher(nsID),
+		ident.NewIDMatcher(id),
+		gomock.Not(gomock.Nil()),
+		at, annotation,
+	).Return(nil)
+	require.NoError(t, service.ValidateWriteTagged(tctx, request))
+
+	// Requests without tags are validated as untagged writes.
+	request.Tags = nil
+	mockDB.EXPECT().ValidateWrite(
+		ident.NewIDMatcher(nsID),
+		ident.NewIDMatcher(id),
+		gomock.Nil(),
+		at, annotation,
+	).Return(xerrors.NewInvalidParamsError(errors.New("invalid")))
+	err := service.ValidateWriteTagged(tctx, request)
+	require.Error(t, err)
+	require.True(t, tterrors.IsBadRequestError(err.(*rpc.Error)))
+
+	request.Datapoint = nil
+	require.Error(t, service.ValidateWriteTagged(tctx, request))
+}
+
 func TestServiceWriteTaggedOverloaded(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1950,6 +2629,105 @@ func TestServiceWriteBatchRaw(t *testing.T) {
 	require.NoError(t, err)
 }
 
+type testWriteForwarder struct {
+	ids     []ident.ID
+	writeFn tchannelthrift.PeerWriteBatchFn
+}
+
+func (f *testWriteForwarder) ForwardWrite(
+	id ident.ID,
+	writeFn tchannelthrift.PeerWriteFn,
+) error {
+	return nil
+}
+
+func (f *testWriteForwarder) ForwardWriteBatch(
+	ids []ident.ID,
+	writeFn tchannelthrift.PeerWriteBatchFn,
+	errFn tchannelthrift.ForwardWriteErrorFn,
+) {
+	f.ids = ids
+	f.writeFn = writeFn
+}
+
+func (f *testWriteForwarder) Close() error {
+	return nil
+}
+
+func TestServiceWriteBatchRawForwardsCopiedElements(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+
+	forwarder := &testWriteForwarder{}
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+	service.SetWriteForwarder(forwarder)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	var (
+		nsID     = "metrics"
+		now      = time.Now().Truncate(time.Second)
+		elements = []*rpc.WriteBatchRawRequestElement{
+			{
+				ID: []byte("foo"),
+				Datapoint: &rpc.Datapoint{
+					Timestamp:         now.Unix(),
+					TimestampTimeType: rpc.TimeType_UNIX_SECONDS,
+					Value:             12.34,
+					Annotation:        []byte("annotation"),
+				},
+			},
+		}
+		req = &rpc.WriteBatchRawRequest{
+			NameSpace: []byte(nsID),
+			Elements:  elements,
+		}
+	)
+
+	writeBatch := ts.NewWriteBatch(len(elements), ident.StringID(nsID), nil)
+	mockDB.EXPECT().
+		BatchWriter(ident.NewIDMatcher(nsID), len(elements)).
+		Return(writeBatch, nil)
+	mockDB.EXPECT().
+		WriteBatch(ctx, ident.NewIDMatcher(nsID), writeBatch, gomock.Any()).
+		Return(nil)
+	mockDB.EXPECT().IsOverloaded().Return(false)
+
+	require.NoError(t, service.WriteBatchRaw(tctx, req))
+	require.Equal(t, 1, len(forwarder.ids))
+	require.Equal(t, "foo", forwarder.ids[0].String())
+
+	// The forwarded write may outlive the request so it must not reference
+	// the request bytes that are returned to the pools.
+	copy(req.NameSpace, "xxxxxxx")
+	copy(elements[0].ID, "xxx")
+	copy(elements[0].Datapoint.Annotation, "xxxxxxxxxx")
+
+	peer := rpc.NewMockTChanNode(ctrl)
+	peer.EXPECT().
+		WriteBatchRaw(gomock.Any(), &rpc.WriteBatchRawRequest{
+			NameSpace: []byte(nsID),
+			Elements: []*rpc.WriteBatchRawRequestElement{
+				{
+					ID: []byte("foo"),
+					Datapoint: &rpc.Datapoint{
+						Timestamp:         now.Unix(),
+						TimestampTimeType: rpc.TimeType_UNIX_SECONDS,
+						Value:             12.34,
+						Annotation:        []byte("annotation"),
+					},
+				},
+			},
+		}).
+		Return(nil)
+	require.NoError(t, forwarder.writeFn(tctx, peer, []int{0}))
+}
+
 func TestServiceWriteBatchRawOverloaded(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -2330,3 +3108,449 @@ func TestServiceSetWriteNewSeriesLimitPerShardPerSecond(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, int64(84), setResp.WriteNewSeriesLimitPerShardPerSecond)
 }
+
+func TestServicePauseBackgroundTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false).AnyTimes()
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	until := time.Now().Add(time.Minute)
+	mockDB.EXPECT().PauseBackgroundTask(ident.NewIDMatcher("metrics"),
+		storage.ColdFlushBackgroundTask, time.Minute).Return(nil)
+	mockDB.EXPECT().PausedBackgroundTasks().Return([]storage.PausedBackgroundTask{
+		{
+			Namespace: ident.StringID("metrics"),
+			Task:      storage.ColdFlushBackgroundTask,
+			Until:     until,
+		},
+	})
+
+	result, err := service.PauseBackgroundTask(tctx, &rpc.NodePauseBackgroundTaskRequest{
+		NameSpace:     "metrics",
+		Task:          "cold-flush",
+		PauseDuration: 60,
+		DurationType:  rpc.TimeType_UNIX_SECONDS,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*rpc.NodePausedBackgroundTask{
+		{
+			NameSpace:        "metrics",
+			Task:             "cold-flush",
+			PausedUntilNanos: until.UnixNano(),
+		},
+	}, result.PausedBackgroundTasks)
+
+	_, err = service.PauseBackgroundTask(tctx, &rpc.NodePauseBackgroundTaskRequest{
+		NameSpace:     "metrics",
+		Task:          "flush",
+		PauseDuration: 60,
+		DurationType:  rpc.TimeType_UNIX_SECONDS,
+	})
+	require.Error(t, err)
+	require.True(t, tterrors.IsBadRequestError(err.(*rpc.Error)))
+
+	mockDB.EXPECT().ResumeBackgroundTask(ident.NewIDMatcher("metrics"),
+		storage.ColdFlushBackgroundTask).Return(nil)
+	mockDB.EXPECT().PausedBackgroundTasks().Return(nil)
+
+	result, err = service.ResumeBackgroundTask(tctx, &rpc.NodeResumeBackgroundTaskRequest{
+		NameSpace: "metrics",
+		Task:      "cold-flush",
+	})
+	require.NoError(t, err)
+	require.Empty(t, result.PausedBackgroundTasks)
+}
+
+func TestServiceUndeleteExpiredFileSets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false).AnyTimes()
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	ropts := retention.NewOptions().
+		SetRetentionPeriod(36 * time.Hour).
+		SetExpiredFileSetGracePeriod(12 * time.Hour)
+	mockDB.EXPECT().UndeleteExpiredFileSets(ident.NewIDMatcher("metrics")).
+		Return(ropts, nil)
+
+	result, err := service.UndeleteExpiredFileSets(tctx, &rpc.NodeUndeleteExpiredFileSetsRequest{
+		NameSpace: "metrics",
+	})
+	require.NoError(t, err)
+	require.Equal(t, &rpc.NodeUndeleteExpiredFileSetsResult_{
+		NameSpace:                      "metrics",
+		RetentionPeriodNanos:           int64(36 * time.Hour),
+		ExpiredFileSetGracePeriodNanos: int64(12 * time.Hour),
+	}, result)
+
+	mockDB.EXPECT().UndeleteExpiredFileSets(ident.NewIDMatcher("metrics")).
+		Return(nil, xerrors.NewInvalidParamsError(errors.New("no grace period")))
+
+	_, err = service.UndeleteExpiredFileSets(tctx, &rpc.NodeUndeleteExpiredFileSetsRequest{
+		NameSpace: "metrics",
+	})
+	require.Error(t, err)
+	require.True(t, tterrors.IsBadRequestError(err.(*rpc.Error)))
+}
+
+func TestServiceGetHotSeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false).AnyTimes()
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	mockDB.EXPECT().HotSeries(ident.NewIDMatcher("metrics"), 2).
+		Return(storage.HotSeriesResult{
+			Writes: []storage.HotSeries{
+				{ID: ident.StringID("foo"), Shard: 3, Rate: 120},
+				{ID: ident.StringID("bar"), Shard: 1, Rate: 40},
+			},
+			Reads: []storage.HotSeries{
+				{ID: ident.StringID("baz"), Shard: 2, Rate: 7.5},
+			},
+		}, nil)
+
+	result, err := service.GetHotSeries(tctx, &rpc.NodeHotSeriesRequest{
+		NameSpace: "metrics",
+		Limit:     2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, &rpc.NodeHotSeriesResult_{
+		NameSpace: "metrics",
+		Writes: []*rpc.NodeHotSeries{
+			{ID: "foo", Shard: 3, Rate: 120},
+			{ID: "bar", Shard: 1, Rate: 40},
+		},
+		Reads: []*rpc.NodeHotSeries{
+			{ID: "baz", Shard: 2, Rate: 7.5},
+		},
+	}, result)
+
+	mockDB.EXPECT().HotSeries(ident.NewIDMatcher("metrics"), 0).
+		Return(storage.HotSeriesResult{}, xerrors.NewInvalidParamsError(errors.New("disabled")))
+
+	_, err = service.GetHotSeries(tctx, &rpc.NodeHotSeriesRequest{
+		NameSpace: "metrics",
+	})
+	require.Error(t, err)
+	require.True(t, tterrors.IsBadRequestError(err.(*rpc.Error)))
+}
+
+func TestServiceGetFlushStates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false).AnyTimes()
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	var (
+		t0      = time.Unix(0, 0)
+		t1      = t0.Add(2 * time.Hour)
+		summary = storage.NamespaceFlushStateSummary{
+			NumShards:       2,
+			NumBlocks:       3,
+			NumWarmFlushed:  2,
+			NumColdFlushed:  1,
+			NumWarmFailed:   1,
+			ColdBlockStarts: []time.Time{t0},
+		}
+	)
+	mockDB.EXPECT().FlushStates(ident.NewIDMatcher("metrics"), uint32(1)).
+		Return([]storage.BlockFlushState{
+			{Shard: 1, BlockStart: t0, WarmFlushed: true, ColdVersion: 2},
+			{Shard: 1, BlockStart: t1, NumFailures: 1},
+		}, nil)
+	mockDB.EXPECT().FlushStateSummary(ident.NewIDMatcher("metrics")).
+		Return(summary, nil).
+		Times(2)
+
+	req := rpc.NewNodeFlushStatesRequest()
+	req.NameSpace = "metrics"
+	req.Shard = 1
+	result, err := service.GetFlushStates(tctx, req)
+	require.NoError(t, err)
+	require.Equal(t, &rpc.NodeFlushStatesResult_{
+		NameSpace: "metrics",
+		Blocks: []*rpc.NodeBlockFlushState{
+			{Shard: 1, BlockStartNanos: t0.UnixNano(), WarmFlushed: true, ColdVersion: 2},
+			{Shard: 1, BlockStartNanos: t1.UnixNano(), NumFailures: 1},
+		},
+		NumShards:            2,
+		NumBlocks:            3,
+		NumWarmFlushed:       2,
+		NumColdFlushed:       1,
+		NumWarmFailed:        1,
+		ColdBlockStartsNanos: []int64{t0.UnixNano()},
+	}, result)
+
+	// Only the summary is returned when no shard is requested.
+	req = rpc.NewNodeFlushStatesRequest()
+	req.NameSpace = "metrics"
+	result, err = service.GetFlushStates(tctx, req)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(result.Blocks))
+	require.Equal(t, int64(3), result.NumBlocks)
+
+	req.Shard = -2
+	_, err = service.GetFlushStates(tctx, req)
+	require.Error(t, err)
+	require.True(t, tterrors.IsBadRequestError(err.(*rpc.Error)))
+}
+
+func TestServiceGetBootstrapProgress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false).AnyTimes()
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	var (
+		start     = time.Unix(0, 0).Add(time.Hour)
+		remaining = xtime.Range{Start: start.Add(-time.Hour), End: start}
+		available = xtime.Range{Start: start, End: start.Add(time.Hour)}
+	)
+	mockDB.EXPECT().BootstrapProgress().Return(storage.BootstrapProgress{
+		State:               storage.Bootstrapping,
+		Start:               start,
+		PercentComplete:     75,
+		EstimatedCompletion: start.Add(time.Minute),
+		Namespaces: []storage.NamespaceBootstrapProgress{
+			{
+				Namespace:       "metrics",
+				Bootstrapper:    "commitlog",
+				PercentComplete: 75,
+				AvailableFrom:   available.Start,
+				Shards: []storage.ShardBootstrapProgress{
+					{
+						Shard:           3,
+						PercentComplete: 75,
+						RemainingRanges: []xtime.Range{remaining},
+						AvailableRanges: []xtime.Range{available},
+					},
+				},
+			},
+		},
+	})
+
+	result, err := service.GetBootstrapProgress(tctx)
+	require.NoError(t, err)
+	require.Equal(t, &rpc.NodeBootstrapProgressResult_{
+		State:                    "bootstrapping",
+		StartNanos:               start.UnixNano(),
+		PercentComplete:          75,
+		EstimatedCompletionNanos: start.Add(time.Minute).UnixNano(),
+		Namespaces: []*rpc.NodeNamespaceBootstrapProgress{
+			{
+				NameSpace:          "metrics",
+				Bootstrapper:       "commitlog",
+				PercentComplete:    75,
+				AvailableFromNanos: available.Start.UnixNano(),
+				Shards: []*rpc.NodeShardBootstrapProgress{
+					{
+						Shard:           3,
+						PercentComplete: 75,
+						RemainingRanges: []*rpc.NodeBootstrapRange{
+							{
+								StartNanos: remaining.Start.UnixNano(),
+								EndNanos:   remaining.End.UnixNano(),
+							},
+						},
+						AvailableRanges: []*rpc.NodeBootstrapRange{
+							{
+								StartNanos: available.Start.UnixNano(),
+								EndNanos:   available.End.UnixNano(),
+							},
+						},
+					},
+				},
+			},
+		},
+	}, result)
+}
+
+func TestServiceRebootstrapShard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false).AnyTimes()
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	start := time.Now().Truncate(2 * time.Hour).Add(-4 * time.Hour)
+	end := start.Add(4 * time.Hour)
+	mockDB.EXPECT().RebootstrapShard(ident.NewIDMatcher("metrics"), uint32(3),
+		xtime.Range{Start: start, End: end}).Return(nil)
+
+	result, err := service.RebootstrapShard(tctx, &rpc.NodeRebootstrapShardRequest{
+		NameSpace:  "metrics",
+		Shard:      3,
+		StartNanos: start.UnixNano(),
+		EndNanos:   end.UnixNano(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "metrics", result.NameSpace)
+	require.Equal(t, int64(3), result.Shard)
+	require.True(t, result.TookNanos >= 0)
+
+	// Invalid shards and empty ranges are rejected before reaching the database.
+	_, err = service.RebootstrapShard(tctx, &rpc.NodeRebootstrapShardRequest{
+		NameSpace:  "metrics",
+		Shard:      -1,
+		StartNanos: start.UnixNano(),
+		EndNanos:   end.UnixNano(),
+	})
+	require.Error(t, err)
+	require.True(t, tterrors.IsBadRequestError(err.(*rpc.Error)))
+
+	_, err = service.RebootstrapShard(tctx, &rpc.NodeRebootstrapShardRequest{
+		NameSpace:  "metrics",
+		Shard:      3,
+		StartNanos: end.UnixNano(),
+		EndNanos:   start.UnixNano(),
+	})
+	require.Error(t, err)
+	require.True(t, tterrors.IsBadRequestError(err.(*rpc.Error)))
+
+	mockDB.EXPECT().RebootstrapShard(ident.NewIDMatcher("metrics"), uint32(3),
+		xtime.Range{Start: start, End: end}).Return(errors.New("shard not bootstrapped"))
+
+	_, err = service.RebootstrapShard(tctx, &rpc.NodeRebootstrapShardRequest{
+		NameSpace:  "metrics",
+		Shard:      3,
+		StartNanos: start.UnixNano(),
+		EndNanos:   end.UnixNano(),
+	})
+	require.Error(t, err)
+}
+
+func TestServiceBootstrapDryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false).AnyTimes()
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	start := time.Now().Truncate(2 * time.Hour).Add(-4 * time.Hour)
+	mid := start.Add(2 * time.Hour)
+	end := start.Add(4 * time.Hour)
+	mockDB.EXPECT().BootstrapDryRun().Return([]storage.NamespaceBootstrapDryRun{{
+		Namespace: "metrics",
+		DataResult: bootstrap.AvailabilityResult{
+			Bootstrappers: []bootstrap.BootstrapperAvailability{
+				{
+					Bootstrapper: "filesystem",
+					Fulfilled: result.ShardTimeRanges{
+						1: xtime.NewRanges(xtime.Range{Start: start, End: mid}),
+						0: xtime.NewRanges(xtime.Range{Start: start, End: end}),
+					},
+				},
+				{
+					Bootstrapper: "peers",
+					Fulfilled:    result.ShardTimeRanges{},
+				},
+			},
+			Unfulfilled: result.ShardTimeRanges{
+				1: xtime.NewRanges(xtime.Range{Start: mid, End: end}),
+			},
+		},
+	}}, nil)
+
+	res, err := service.BootstrapDryRun(tctx)
+	require.NoError(t, err)
+	require.Equal(t, &rpc.NodeBootstrapDryRunResult_{
+		Namespaces: []*rpc.NodeNamespaceBootstrapDryRun{{
+			NameSpace: "metrics",
+			Data: []*rpc.NodeBootstrapperAvailability{
+				{
+					Bootstrapper: "filesystem",
+					Fulfilled: []*rpc.NodeShardBootstrapRanges{
+						{
+							Shard: 0,
+							Ranges: []*rpc.NodeBootstrapRange{{
+								StartNanos: start.UnixNano(),
+								EndNanos:   end.UnixNano(),
+							}},
+						},
+						{
+							Shard: 1,
+							Ranges: []*rpc.NodeBootstrapRange{{
+								StartNanos: start.UnixNano(),
+								EndNanos:   mid.UnixNano(),
+							}},
+						},
+					},
+				},
+				{
+					Bootstrapper: "peers",
+					Fulfilled:    []*rpc.NodeShardBootstrapRanges{},
+				},
+			},
+			DataUnfulfilled: []*rpc.NodeShardBootstrapRanges{{
+				Shard: 1,
+				Ranges: []*rpc.NodeBootstrapRange{{
+					StartNanos: mid.UnixNano(),
+					EndNanos:   end.UnixNano(),
+				}},
+			}},
+			Index:            []*rpc.NodeBootstrapperAvailability{},
+			IndexUnfulfilled: []*rpc.NodeShardBootstrapRanges{},
+		}},
+	}, res)
+
+	mockDB.EXPECT().BootstrapDryRun().Return(nil, errors.New("an error"))
+
+	_, err = service.BootstrapDryRun(tctx)
+	require.Error(t, err)
+}
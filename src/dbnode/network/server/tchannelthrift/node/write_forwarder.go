@@ -0,0 +1,378 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
+	nchannel "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/node/channel"
+	"github.com/m3db/m3/src/dbnode/topology"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/uber-go/tally"
+	"github.com/uber/tchannel-go"
+	"github.com/uber/tchannel-go/thrift"
+)
+
+const (
+	writeForwarderChannelName = "m3db-write-forwarder"
+)
+
+var (
+	errWriteForwarderClosed         = errors.New("write forwarder is closed")
+	errWriteForwarderTimeout        = errors.New("write forwarder timed out waiting for peer acks")
+	errWriteForwarderNotEnoughPeers = errors.New("write forwarder does not have enough peers to satisfy required acks")
+	errWriteForwarderNoTopology     = errors.New("write forwarder requires a topology")
+	errWriteForwarderNoHostID       = errors.New("write forwarder requires a host ID")
+	errWriteForwarderInvalidAcks    = errors.New("write forwarder required acks must be positive")
+	errWriteForwarderInvalidTimeout = errors.New("write forwarder timeout must be positive")
+)
+
+type newPeerConnFn func(host topology.Host) (*tchannel.Channel, rpc.TChanNode, error)
+
+// WriteForwarderOptions are the options for a write forwarder.
+type WriteForwarderOptions struct {
+	// HostID is the ID of the local host which is excluded from forwarding.
+	HostID string
+	// Topology is used to resolve the peer replicas of a shard, it is owned
+	// by the caller and not closed by the forwarder.
+	Topology topology.Topology
+	// RequiredAcks is the number of peer replicas (excluding the local host)
+	// that must acknowledge a write before it is acknowledged to the client,
+	// the write is always forwarded to every peer replica.
+	RequiredAcks int
+	// Timeout is the maximum time to wait for the required peer acks.
+	Timeout time.Duration
+	// ChannelOptions are the tchannel options used to connect to peers.
+	ChannelOptions *tchannel.ChannelOptions
+	// InstrumentOptions are the instrumentation options.
+	InstrumentOptions instrument.Options
+}
+
+// Validate validates the write forwarder options.
+func (o WriteForwarderOptions) Validate() error {
+	if o.Topology == nil {
+		return errWriteForwarderNoTopology
+	}
+	if o.HostID == "" {
+		return errWriteForwarderNoHostID
+	}
+	if o.RequiredAcks <= 0 {
+		return errWriteForwarderInvalidAcks
+	}
+	if o.Timeout <= 0 {
+		return errWriteForwarderInvalidTimeout
+	}
+	return nil
+}
+
+type writeForwarderMetrics struct {
+	forwarded tally.Counter
+	acked     tally.Counter
+	errors    tally.Counter
+	timeouts  tally.Counter
+	latency   tally.Timer
+}
+
+func newWriteForwarderMetrics(scope tally.Scope) writeForwarderMetrics {
+	scope = scope.SubScope("write-forwarder")
+	return writeForwarderMetrics{
+		forwarded: scope.Counter("forwarded"),
+		acked:     scope.Counter("peer-acked"),
+		errors:    scope.Counter("peer-errors"),
+		timeouts:  scope.Counter("timeouts"),
+		latency:   scope.Timer("latency"),
+	}
+}
+
+type writeForwarderPeer struct {
+	channel *tchannel.Channel
+	client  rpc.TChanNode
+}
+
+type writeForwarder struct {
+	sync.RWMutex
+
+	opts          WriteForwarderOptions
+	metrics       writeForwarderMetrics
+	newPeerConnFn newPeerConnFn
+	nowFn         func() time.Time
+
+	peers  map[string]writeForwarderPeer
+	closed bool
+}
+
+// NewWriteForwarder returns a new write forwarder that forwards writes to the
+// peer replicas of the shard a series belongs to.
+func NewWriteForwarder(opts WriteForwarderOptions) (tchannelthrift.WriteForwarder, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.InstrumentOptions == nil {
+		opts.InstrumentOptions = instrument.NewOptions()
+	}
+
+	f := &writeForwarder{
+		opts:    opts,
+		metrics: newWriteForwarderMetrics(opts.InstrumentOptions.MetricsScope()),
+		nowFn:   time.Now,
+		peers:   make(map[string]writeForwarderPeer),
+	}
+	f.newPeerConnFn = f.newPeerConn
+	return f, nil
+}
+
+func (f *writeForwarder) ForwardWrite(
+	id ident.ID,
+	writeFn tchannelthrift.PeerWriteFn,
+) error {
+	start := f.nowFn()
+	defer func() {
+		f.metrics.latency.Record(f.nowFn().Sub(start))
+	}()
+
+	_, hosts, err := f.opts.Topology.Get().Route(id)
+	if err != nil {
+		return err
+	}
+
+	return f.forwardToHosts(hosts, writeFn)
+}
+
+func (f *writeForwarder) ForwardWriteBatch(
+	ids []ident.ID,
+	writeFn tchannelthrift.PeerWriteBatchFn,
+	errFn tchannelthrift.ForwardWriteErrorFn,
+) {
+	start := f.nowFn()
+	defer func() {
+		f.metrics.latency.Record(f.nowFn().Sub(start))
+	}()
+
+	topoMap := f.opts.Topology.Get()
+
+	// Group the elements by shard since the elements of a shard share the
+	// same peer replicas and are acknowledged together.
+	shards := make(map[uint32]*writeForwarderShardBatch)
+	for idx, id := range ids {
+		if id == nil {
+			continue
+		}
+		shardID, hosts, err := topoMap.Route(id)
+		if err != nil {
+			errFn(idx, err)
+			continue
+		}
+		shard, ok := shards[shardID]
+		if !ok {
+			shard = &writeForwarderShardBatch{hosts: hosts}
+			shards[shardID] = shard
+		}
+		shard.indexes = append(shard.indexes, idx)
+	}
+
+	var wg sync.WaitGroup
+	for _, shard := range shards {
+		shard := shard
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			shard.err = f.forwardToHosts(shard.hosts,
+				func(ctx thrift.Context, peer rpc.TChanNode) error {
+					return writeFn(ctx, peer, shard.indexes)
+				})
+		}()
+	}
+	wg.Wait()
+
+	for _, shard := range shards {
+		if shard.err == nil {
+			continue
+		}
+		for _, idx := range shard.indexes {
+			errFn(idx, shard.err)
+		}
+	}
+}
+
+type writeForwarderShardBatch struct {
+	hosts   []topology.Host
+	indexes []int
+	err     error
+}
+
+// forwardToHosts forwards a write to all the given replicas, excluding the
+// local host, and blocks until the required number of peers have acknowledged
+// it. The write to the remaining peers continues in the background after this
+// call returns, so writeFn must only reference memory that is not reused by
+// the caller once this call returns.
+func (f *writeForwarder) forwardToHosts(
+	hosts []topology.Host,
+	writeFn tchannelthrift.PeerWriteFn,
+) error {
+	peers := make([]topology.Host, 0, len(hosts))
+	for _, host := range hosts {
+		if host.ID() == f.opts.HostID {
+			continue
+		}
+		peers = append(peers, host)
+	}
+	if len(peers) < f.opts.RequiredAcks {
+		return errWriteForwarderNotEnoughPeers
+	}
+
+	f.metrics.forwarded.Inc(1)
+
+	// Results channel is buffered by the number of peers so that late
+	// responses never block once this call has returned.
+	results := make(chan error, len(peers))
+	for _, host := range peers {
+		go func(host topology.Host) {
+			results <- f.forwardToPeer(host, writeFn)
+		}(host)
+	}
+
+	timeout := time.NewTimer(f.opts.Timeout)
+	defer timeout.Stop()
+
+	var (
+		pending  = len(peers)
+		acks     int
+		multiErr = xerrors.NewMultiError()
+	)
+	for {
+		select {
+		case err := <-results:
+			pending--
+			if err != nil {
+				f.metrics.errors.Inc(1)
+				multiErr = multiErr.Add(err)
+			} else {
+				f.metrics.acked.Inc(1)
+				acks++
+			}
+			if acks >= f.opts.RequiredAcks {
+				return nil
+			}
+			if pending+acks < f.opts.RequiredAcks {
+				// Not enough outstanding peers left to satisfy required acks.
+				return multiErr.FinalError()
+			}
+		case <-timeout.C:
+			f.metrics.timeouts.Inc(1)
+			return errWriteForwarderTimeout
+		}
+	}
+}
+
+func (f *writeForwarder) forwardToPeer(
+	host topology.Host,
+	writeFn tchannelthrift.PeerWriteFn,
+) error {
+	peer, err := f.peer(host)
+	if err != nil {
+		return err
+	}
+
+	tctx, cancel := thrift.NewContext(f.opts.Timeout)
+	defer cancel()
+
+	tctx = thrift.WithHeaders(tctx, map[string]string{
+		tchannelthrift.ForwardedWriteHeader: "true",
+	})
+	if err := writeFn(tctx, peer); err != nil {
+		return fmt.Errorf("error forwarding write to peer %s: %v", host.ID(), err)
+	}
+	return nil
+}
+
+func (f *writeForwarder) peer(host topology.Host) (rpc.TChanNode, error) {
+	f.RLock()
+	if f.closed {
+		f.RUnlock()
+		return nil, errWriteForwarderClosed
+	}
+	peer, ok := f.peers[host.ID()]
+	f.RUnlock()
+	if ok {
+		return peer.client, nil
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	if f.closed {
+		return nil, errWriteForwarderClosed
+	}
+	// Check if raced with another call to this method.
+	if peer, ok := f.peers[host.ID()]; ok {
+		return peer.client, nil
+	}
+
+	channel, client, err := f.newPeerConnFn(host)
+	if err != nil {
+		return nil, err
+	}
+	f.peers[host.ID()] = writeForwarderPeer{channel: channel, client: client}
+	return client, nil
+}
+
+func (f *writeForwarder) newPeerConn(
+	host topology.Host,
+) (*tchannel.Channel, rpc.TChanNode, error) {
+	channel, err := tchannel.NewChannel(writeForwarderChannelName, f.opts.ChannelOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	endpoint := &thrift.ClientOptions{HostPort: host.Address()}
+	thriftClient := thrift.NewClient(channel, nchannel.ChannelName, endpoint)
+	return channel, rpc.NewTChanNodeClient(thriftClient), nil
+}
+
+func (f *writeForwarder) Close() error {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.closed {
+		return errWriteForwarderClosed
+	}
+	f.closed = true
+
+	for _, peer := range f.peers {
+		if peer.channel != nil {
+			peer.channel.Close()
+		}
+	}
+	f.peers = nil
+	return nil
+}
+
+func isForwardedWrite(tctx thrift.Context) bool {
+	_, ok := tctx.Headers()[tchannelthrift.ForwardedWriteHeader]
+	return ok
+}
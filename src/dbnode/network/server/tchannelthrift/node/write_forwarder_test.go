@@ -0,0 +1,266 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/shard"
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/tchannel-go"
+	"github.com/uber/tchannel-go/thrift"
+)
+
+const testWriteForwarderReplicas = 3
+
+func newTestWriteForwarder(
+	t *testing.T,
+	opts WriteForwarderOptions,
+	peers map[string]rpc.TChanNode,
+) *writeForwarder {
+	shards := sharding.NewShards([]uint32{0}, shard.Available)
+	shardSet, err := sharding.NewShardSet(shards, func(id ident.ID) uint32 { return 0 })
+	require.NoError(t, err)
+
+	var hostShardSets []topology.HostShardSet
+	for i := 0; i < testWriteForwarderReplicas; i++ {
+		id := fmt.Sprintf("testhost%d", i)
+		host := topology.NewHost(id, fmt.Sprintf("%s:9000", id))
+		hostShardSets = append(hostShardSets, topology.NewHostShardSet(host, shardSet))
+	}
+
+	opts.HostID = "testhost0"
+	topo, err := topology.NewStaticInitializer(
+		topology.NewStaticOptions().
+			SetReplicas(testWriteForwarderReplicas).
+			SetShardSet(shardSet).
+			SetHostShardSets(hostShardSets)).Init()
+	require.NoError(t, err)
+	opts.Topology = topo
+
+	forwarder, err := NewWriteForwarder(opts)
+	require.NoError(t, err)
+
+	f := forwarder.(*writeForwarder)
+	f.newPeerConnFn = func(host topology.Host) (*tchannel.Channel, rpc.TChanNode, error) {
+		peer, ok := peers[host.ID()]
+		require.True(t, ok)
+		return nil, peer, nil
+	}
+	return f
+}
+
+func TestWriteForwarderOptionsValidate(t *testing.T) {
+	_, err := NewWriteForwarder(WriteForwarderOptions{})
+	require.Equal(t, errWriteForwarderNoTopology, err)
+}
+
+func TestWriteForwarderForwardWriteQuorum(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		peer1 = rpc.NewMockTChanNode(ctrl)
+		peer2 = rpc.NewMockTChanNode(ctrl)
+		req   = &rpc.WriteRequest{NameSpace: "ns", ID: "foo"}
+	)
+	for _, peer := range []*rpc.MockTChanNode{peer1, peer2} {
+		peer.EXPECT().
+			Write(gomock.Any(), req).
+			DoAndReturn(func(ctx thrift.Context, _ *rpc.WriteRequest) error {
+				_, ok := ctx.Headers()[tchannelthrift.ForwardedWriteHeader]
+				require.True(t, ok)
+				return nil
+			})
+	}
+
+	f := newTestWriteForwarder(t, WriteForwarderOptions{
+		RequiredAcks: 2,
+		Timeout:      time.Minute,
+	}, map[string]rpc.TChanNode{"testhost1": peer1, "testhost2": peer2})
+
+	err := f.ForwardWrite(ident.StringID("foo"), func(ctx thrift.Context, peer rpc.TChanNode) error {
+		return peer.Write(ctx, req)
+	})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func TestWriteForwarderForwardWriteNotEnoughAcks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		peer1 = rpc.NewMockTChanNode(ctrl)
+		peer2 = rpc.NewMockTChanNode(ctrl)
+		req   = &rpc.WriteRequest{NameSpace: "ns", ID: "foo"}
+	)
+	peer1.EXPECT().Write(gomock.Any(), req).Return(nil)
+	peer2.EXPECT().Write(gomock.Any(), req).Return(errors.New("an error"))
+
+	f := newTestWriteForwarder(t, WriteForwarderOptions{
+		RequiredAcks: 2,
+		Timeout:      time.Minute,
+	}, map[string]rpc.TChanNode{"testhost1": peer1, "testhost2": peer2})
+
+	err := f.ForwardWrite(ident.StringID("foo"), func(ctx thrift.Context, peer rpc.TChanNode) error {
+		return peer.Write(ctx, req)
+	})
+	require.Error(t, err)
+	require.NoError(t, f.Close())
+}
+
+func TestWriteForwarderForwardWriteAllPeers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		peer1   = rpc.NewMockTChanNode(ctrl)
+		peer2   = rpc.NewMockTChanNode(ctrl)
+		req     = &rpc.WriteRequest{NameSpace: "ns", ID: "foo"}
+		blockCh = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+	// Whichever peer receives the write first blocks until the write has been
+	// acknowledged by the other peer, which must still receive the write.
+	var (
+		first sync.Once
+	)
+	for _, peer := range []*rpc.MockTChanNode{peer1, peer2} {
+		wg.Add(1)
+		peer.EXPECT().
+			Write(gomock.Any(), req).
+			DoAndReturn(func(ctx thrift.Context, _ *rpc.WriteRequest) error {
+				defer wg.Done()
+				blocked := false
+				first.Do(func() { blocked = true })
+				if blocked {
+					<-blockCh
+				}
+				return nil
+			})
+	}
+
+	f := newTestWriteForwarder(t, WriteForwarderOptions{
+		RequiredAcks: 1,
+		Timeout:      time.Minute,
+	}, map[string]rpc.TChanNode{"testhost1": peer1, "testhost2": peer2})
+
+	err := f.ForwardWrite(ident.StringID("foo"), func(ctx thrift.Context, peer rpc.TChanNode) error {
+		return peer.Write(ctx, req)
+	})
+	require.NoError(t, err)
+
+	close(blockCh)
+	wg.Wait()
+	require.NoError(t, f.Close())
+}
+
+func TestWriteForwarderForwardWriteBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		peer1 = rpc.NewMockTChanNode(ctrl)
+		peer2 = rpc.NewMockTChanNode(ctrl)
+		req   = &rpc.WriteBatchRawRequest{
+			NameSpace: []byte("ns"),
+			Elements: []*rpc.WriteBatchRawRequestElement{
+				{ID: []byte("foo")},
+				{ID: []byte("bar")},
+				{ID: []byte("baz")},
+			},
+		}
+	)
+	for _, peer := range []*rpc.MockTChanNode{peer1, peer2} {
+		peer.EXPECT().
+			WriteBatchRaw(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx thrift.Context, fwdReq *rpc.WriteBatchRawRequest) error {
+				_, ok := ctx.Headers()[tchannelthrift.ForwardedWriteHeader]
+				require.True(t, ok)
+				// The element that failed locally is not forwarded.
+				require.Equal(t, []*rpc.WriteBatchRawRequestElement{
+					req.Elements[0], req.Elements[2],
+				}, fwdReq.Elements)
+				return nil
+			})
+	}
+
+	f := newTestWriteForwarder(t, WriteForwarderOptions{
+		RequiredAcks: 2,
+		Timeout:      time.Minute,
+	}, map[string]rpc.TChanNode{"testhost1": peer1, "testhost2": peer2})
+
+	ids := []ident.ID{ident.StringID("foo"), nil, ident.StringID("baz")}
+	f.ForwardWriteBatch(ids,
+		func(ctx thrift.Context, peer rpc.TChanNode, indexes []int) error {
+			fwdReq := &rpc.WriteBatchRawRequest{NameSpace: req.NameSpace}
+			for _, idx := range indexes {
+				fwdReq.Elements = append(fwdReq.Elements, req.Elements[idx])
+			}
+			return peer.WriteBatchRaw(ctx, fwdReq)
+		},
+		func(idx int, err error) {
+			require.FailNow(t, "unexpected forward error", "idx=%d, err=%v", idx, err)
+		})
+	require.NoError(t, f.Close())
+}
+
+func TestWriteForwarderForwardWriteBatchNotEnoughAcks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		peer1 = rpc.NewMockTChanNode(ctrl)
+		peer2 = rpc.NewMockTChanNode(ctrl)
+	)
+	peer1.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).Return(nil)
+	peer2.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).Return(errors.New("an error"))
+
+	f := newTestWriteForwarder(t, WriteForwarderOptions{
+		RequiredAcks: 2,
+		Timeout:      time.Minute,
+	}, map[string]rpc.TChanNode{"testhost1": peer1, "testhost2": peer2})
+
+	var failed []int
+	ids := []ident.ID{ident.StringID("foo"), ident.StringID("bar")}
+	f.ForwardWriteBatch(ids,
+		func(ctx thrift.Context, peer rpc.TChanNode, indexes []int) error {
+			return peer.WriteBatchRaw(ctx, &rpc.WriteBatchRawRequest{})
+		},
+		func(idx int, err error) {
+			require.Error(t, err)
+			failed = append(failed, idx)
+		})
+	require.Equal(t, []int{0, 1}, failed)
+	require.NoError(t, f.Close())
+}
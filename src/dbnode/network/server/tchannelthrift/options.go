@@ -27,17 +27,26 @@ import (
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/pool"
 	"github.com/m3db/m3/src/x/serialize"
+	xsync "github.com/m3db/m3/src/x/sync"
+)
+
+const (
+	// defaultDecodeConcurrencyPerQuery is the default maximum number of series
+	// a single query decodes concurrently when a decode worker pool is set.
+	defaultDecodeConcurrencyPerQuery = 4
 )
 
 type options struct {
-	clockOpts                clock.Options
-	instrumentOpts           instrument.Options
-	topologyInitializer      topology.Initializer
-	idPool                   ident.Pool
-	blockMetadataV2Pool      BlockMetadataV2Pool
-	blockMetadataV2SlicePool BlockMetadataV2SlicePool
-	tagEncoderPool           serialize.TagEncoderPool
-	tagDecoderPool           serialize.TagDecoderPool
+	clockOpts                 clock.Options
+	instrumentOpts            instrument.Options
+	topologyInitializer       topology.Initializer
+	idPool                    ident.Pool
+	blockMetadataV2Pool       BlockMetadataV2Pool
+	blockMetadataV2SlicePool  BlockMetadataV2SlicePool
+	tagEncoderPool            serialize.TagEncoderPool
+	tagDecoderPool            serialize.TagDecoderPool
+	decodeWorkerPool          xsync.WorkerPool
+	decodeConcurrencyPerQuery int
 }
 
 // NewOptions creates new options
@@ -60,13 +69,14 @@ func NewOptions() Options {
 	tagDecoderPool.Init()
 
 	return &options{
-		clockOpts:                clock.NewOptions(),
-		instrumentOpts:           instrument.NewOptions(),
-		idPool:                   idPool,
-		blockMetadataV2Pool:      NewBlockMetadataV2Pool(nil),
-		blockMetadataV2SlicePool: NewBlockMetadataV2SlicePool(nil, 0),
-		tagEncoderPool:           tagEncoderPool,
-		tagDecoderPool:           tagDecoderPool,
+		clockOpts:                 clock.NewOptions(),
+		instrumentOpts:            instrument.NewOptions(),
+		idPool:                    idPool,
+		blockMetadataV2Pool:       NewBlockMetadataV2Pool(nil),
+		blockMetadataV2SlicePool:  NewBlockMetadataV2SlicePool(nil, 0),
+		tagEncoderPool:            tagEncoderPool,
+		tagDecoderPool:            tagDecoderPool,
+		decodeConcurrencyPerQuery: defaultDecodeConcurrencyPerQuery,
 	}
 }
 
@@ -149,3 +159,23 @@ func (o *options) SetTagDecoderPool(value serialize.TagDecoderPool) Options {
 func (o *options) TagDecoderPool() serialize.TagDecoderPool {
 	return o.tagDecoderPool
 }
+
+func (o *options) SetDecodeWorkerPool(value xsync.WorkerPool) Options {
+	opts := *o
+	opts.decodeWorkerPool = value
+	return &opts
+}
+
+func (o *options) DecodeWorkerPool() xsync.WorkerPool {
+	return o.decodeWorkerPool
+}
+
+func (o *options) SetDecodeConcurrencyPerQuery(value int) Options {
+	opts := *o
+	opts.decodeConcurrencyPerQuery = value
+	return &opts
+}
+
+func (o *options) DecodeConcurrencyPerQuery() int {
+	return o.decodeConcurrencyPerQuery
+}
@@ -22,12 +22,63 @@ package tchannelthrift
 
 import (
 	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/serialize"
+	xsync "github.com/m3db/m3/src/x/sync"
+
+	"github.com/uber/tchannel-go/thrift"
 )
 
+const (
+	// ForwardedWriteHeader is the header set on writes that have been forwarded
+	// from another node so that they are not forwarded again by the receiver.
+	ForwardedWriteHeader = "m3db-forwarded-write"
+
+	// TenantHeader is the optional header set by clients to attribute the
+	// bytes sent and received by an RPC to a tenant in addition to the
+	// namespace the RPC targets.
+	TenantHeader = "m3db-tenant"
+)
+
+// PeerWriteFn performs a write against a peer replica.
+type PeerWriteFn func(ctx thrift.Context, peer rpc.TChanNode) error
+
+// PeerWriteBatchFn performs a write of the batch elements at the given
+// indexes against a peer replica.
+type PeerWriteBatchFn func(ctx thrift.Context, peer rpc.TChanNode, indexes []int) error
+
+// ForwardWriteErrorFn is called with the index of each batch element whose
+// write was not acknowledged by the required number of peers.
+type ForwardWriteErrorFn func(idx int, err error)
+
+// WriteForwarder forwards writes received by a node to the peer replicas
+// that own the same shard so that clients which cannot fan out writes
+// themselves can still receive a synchronous quorum acknowledgement.
+type WriteForwarder interface {
+	// ForwardWrite forwards a write for the given ID to all the peer replicas
+	// and blocks until the required number of peers have acknowledged it,
+	// writeFn may still be called for the remaining peers once it returns.
+	ForwardWrite(id ident.ID, writeFn PeerWriteFn) error
+
+	// ForwardWriteBatch forwards the writes of a batch to the peer replicas,
+	// elements with a nil ID are skipped. The remaining elements are grouped
+	// by shard and writeFn is called once per peer with the indexes of the
+	// elements the peer owns. It blocks until the elements of each shard
+	// have been acknowledged by the required number of peers and calls
+	// errFn for the elements of each shard that were not.
+	ForwardWriteBatch(
+		ids []ident.ID,
+		writeFn PeerWriteBatchFn,
+		errFn ForwardWriteErrorFn,
+	)
+
+	// Close closes the forwarder and any connections to peers.
+	Close() error
+}
+
 // Options controls server behavior
 type Options interface {
 	// SetClockOptions sets the clock options.
@@ -77,4 +128,19 @@ type Options interface {
 
 	// TagDecoderPool returns the tag encoder pool.
 	TagDecoderPool() serialize.TagDecoderPool
+
+	// SetDecodeWorkerPool sets the worker pool shared by all queries to decode
+	// series blocks, a nil value decodes on the request goroutine.
+	SetDecodeWorkerPool(value xsync.WorkerPool) Options
+
+	// DecodeWorkerPool returns the decode worker pool.
+	DecodeWorkerPool() xsync.WorkerPool
+
+	// SetDecodeConcurrencyPerQuery sets the maximum number of series a single
+	// query may decode concurrently using the decode worker pool.
+	SetDecodeConcurrencyPerQuery(value int) Options
+
+	// DecodeConcurrencyPerQuery returns the maximum number of series a single
+	// query may decode concurrently using the decode worker pool.
+	DecodeConcurrencyPerQuery() int
 }
@@ -0,0 +1,104 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package fs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/m3db/m3/src/x/ident"
+)
+
+const bootstrapCheckpointFileSuffix = ".json"
+
+// BootstrapCheckpoint is the checkpoint of the ranges of the shards of a
+// namespace that completed bootstrapping with persistence.
+type BootstrapCheckpoint struct {
+	Data  map[uint32][]BootstrapCheckpointRange `json:"data"`
+	Index map[uint32][]BootstrapCheckpointRange `json:"index"`
+}
+
+// BootstrapCheckpointRange is a range of a bootstrap checkpoint, the start
+// is inclusive and the end exclusive, both in unix nanoseconds.
+type BootstrapCheckpointRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// BootstrapCheckpointFilePath returns the path of the bootstrap checkpoint
+// file of a namespace.
+func BootstrapCheckpointFilePath(prefix string, namespace ident.ID) string {
+	return path.Join(prefix, bootstrapDirName, namespace.String()+bootstrapCheckpointFileSuffix)
+}
+
+// ReadBootstrapCheckpoint reads the bootstrap checkpoint of a namespace, it
+// returns false if there is none.
+func ReadBootstrapCheckpoint(
+	prefix string,
+	namespace ident.ID,
+) (BootstrapCheckpoint, bool, error) {
+	data, err := ioutil.ReadFile(BootstrapCheckpointFilePath(prefix, namespace))
+	if os.IsNotExist(err) {
+		return BootstrapCheckpoint{}, false, nil
+	}
+	if err != nil {
+		return BootstrapCheckpoint{}, false, err
+	}
+
+	var checkpoint BootstrapCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return BootstrapCheckpoint{}, false, err
+	}
+	return checkpoint, true, nil
+}
+
+// WriteBootstrapCheckpoint atomically writes the bootstrap checkpoint of a
+// namespace, replacing any existing one.
+func WriteBootstrapCheckpoint(
+	prefix string,
+	namespace ident.ID,
+	checkpoint BootstrapCheckpoint,
+	newFileMode os.FileMode,
+	newDirectoryMode os.FileMode,
+) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	filePath := BootstrapCheckpointFilePath(prefix, namespace)
+	if err := os.MkdirAll(path.Dir(filePath), newDirectoryMode); err != nil {
+		return err
+	}
+	return writeFileAtomically(filePath, bytes.NewReader(data), newFileMode)
+}
+
+// RemoveBootstrapCheckpoint removes the bootstrap checkpoint of a namespace,
+// if any.
+func RemoveBootstrapCheckpoint(prefix string, namespace ident.ID) error {
+	err := os.Remove(BootstrapCheckpointFilePath(prefix, namespace))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package fs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootstrapCheckpointReadWriteRemove(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	ns := ident.StringID("testns")
+	_, ok, err := ReadBootstrapCheckpoint(dir, ns)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	checkpoint := BootstrapCheckpoint{
+		Data: map[uint32][]BootstrapCheckpointRange{
+			1: {{Start: 0, End: 10}, {Start: 20, End: 30}},
+		},
+		Index: map[uint32][]BootstrapCheckpointRange{
+			2: {{Start: 0, End: 20}},
+		},
+	}
+	require.NoError(t, WriteBootstrapCheckpoint(dir, ns, checkpoint,
+		defaultNewFileMode, defaultNewDirectoryMode))
+
+	read, ok, err := ReadBootstrapCheckpoint(dir, ns)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, checkpoint, read)
+
+	// Other namespaces have no checkpoint.
+	_, ok, err = ReadBootstrapCheckpoint(dir, ident.StringID("otherns"))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, RemoveBootstrapCheckpoint(dir, ns))
+	_, ok, err = ReadBootstrapCheckpoint(dir, ns)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, RemoveBootstrapCheckpoint(dir, ns))
+}
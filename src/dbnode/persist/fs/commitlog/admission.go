@@ -0,0 +1,190 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package commitlog
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	// approxEntryOverheadBytes approximates the encoded size of a commit log
+	// entry excluding its annotation, the series metadata is written once per
+	// series per file so it is not accounted for each write.
+	approxEntryOverheadBytes = 32
+)
+
+var (
+	// ErrNamespaceWriteLimitExceeded is raised when writes to the commit log
+	// for a namespace exceed the write limits of the namespace.
+	ErrNamespaceWriteLimitExceeded = errors.New("commit log namespace write limit exceeded")
+)
+
+type sleepFn func(d time.Duration)
+
+// namespaceAdmission enforces per namespace limits on the rate of writes
+// admitted to the commit log, tracking usage in one second windows.
+type namespaceAdmission struct {
+	sync.Mutex
+
+	nowFn   clock.NowFn
+	sleepFn sleepFn
+	scope   tally.Scope
+
+	// enabled is accessed atomically so that writes do not need to acquire
+	// the lock when no limits are enforced.
+	enabled int32
+	limits  runtime.CommitLogNamespaceWriteLimits
+	states  map[string]*namespaceAdmissionState
+}
+
+type namespaceAdmissionState struct {
+	windowNanos int64
+	ops         int64
+	bytes       int64
+	metrics     namespaceAdmissionMetrics
+}
+
+type namespaceAdmissionMetrics struct {
+	queued   tally.Counter
+	rejected tally.Counter
+}
+
+func newNamespaceAdmission(scope tally.Scope, nowFn clock.NowFn) *namespaceAdmission {
+	return &namespaceAdmission{
+		nowFn:   nowFn,
+		sleepFn: time.Sleep,
+		scope:   scope,
+		states:  make(map[string]*namespaceAdmissionState),
+	}
+}
+
+func (a *namespaceAdmission) SetRuntimeOptions(value runtime.Options) {
+	limits := value.CommitLogNamespaceWriteLimits()
+	enabled := limits.Default.Enabled()
+	for _, limit := range limits.Overrides {
+		enabled = enabled || limit.Enabled()
+	}
+
+	a.Lock()
+	a.limits = limits
+	if enabled {
+		atomic.StoreInt32(&a.enabled, 1)
+	} else {
+		atomic.StoreInt32(&a.enabled, 0)
+	}
+	a.Unlock()
+}
+
+// Enabled returns whether any namespace write limits are enforced.
+func (a *namespaceAdmission) Enabled() bool {
+	return atomic.LoadInt32(&a.enabled) == 1
+}
+
+// Admit admits writes for a namespace, if the writes exceed the limits of
+// the namespace it waits up to the max queue wait for capacity in a later
+// window before returning ErrNamespaceWriteLimitExceeded.
+func (a *namespaceAdmission) Admit(namespace ident.ID, ops, bytes int64) error {
+	if !a.Enabled() {
+		return nil
+	}
+
+	var deadline time.Time
+	for attempt := 0; ; attempt++ {
+		now := a.nowFn()
+		admitted, maxQueueWait, state := a.tryAdmit(namespace, ops, bytes, now)
+		if admitted {
+			if attempt > 0 && state != nil {
+				state.metrics.queued.Inc(1)
+			}
+			return nil
+		}
+
+		if attempt == 0 {
+			deadline = now.Add(maxQueueWait)
+		}
+		nextWindow := now.Truncate(time.Second).Add(time.Second)
+		if nextWindow.After(deadline) {
+			state.metrics.rejected.Inc(1)
+			return ErrNamespaceWriteLimitExceeded
+		}
+		a.sleepFn(nextWindow.Sub(now))
+	}
+}
+
+func (a *namespaceAdmission) tryAdmit(
+	namespace ident.ID,
+	ops, bytes int64,
+	now time.Time,
+) (bool, time.Duration, *namespaceAdmissionState) {
+	a.Lock()
+	defer a.Unlock()
+
+	limit := a.limits.Limit(namespace.String())
+	if !limit.Enabled() {
+		return true, 0, nil
+	}
+
+	state, ok := a.states[string(namespace.Bytes())]
+	if !ok {
+		scope := a.scope.Tagged(map[string]string{"namespace": namespace.String()})
+		state = &namespaceAdmissionState{
+			metrics: namespaceAdmissionMetrics{
+				queued:   scope.Counter("writes.limit-queued"),
+				rejected: scope.Counter("writes.limit-rejected"),
+			},
+		}
+		a.states[namespace.String()] = state
+	}
+
+	windowNanos := now.Truncate(time.Second).UnixNano()
+	if state.windowNanos != windowNanos {
+		// Rolled into a new window
+		state.windowNanos = windowNanos
+		state.ops = 0
+		state.bytes = 0
+	}
+
+	// Always admit writes into an empty window, otherwise writes that are
+	// larger than the limit by themselves could never be admitted.
+	exceeded := state.ops > 0 &&
+		((limit.OpsPerSecond > 0 && state.ops+ops > limit.OpsPerSecond) ||
+			(limit.BytesPerSecond > 0 && state.bytes+bytes > limit.BytesPerSecond))
+	if exceeded {
+		return false, a.limits.MaxQueueWait, state
+	}
+
+	state.ops += ops
+	state.bytes += bytes
+	return true, 0, state
+}
+
+func approxWriteBytes(annotation ts.Annotation) int64 {
+	return int64(approxEntryOverheadBytes + len(annotation))
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package commitlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestNamespaceAdmission(
+	limits runtime.CommitLogNamespaceWriteLimits,
+) (*namespaceAdmission, *time.Time, *[]time.Duration) {
+	now := time.Unix(1000, 0)
+	var sleeps []time.Duration
+	a := newNamespaceAdmission(tally.NoopScope, func() time.Time {
+		return now
+	})
+	a.sleepFn = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+		now = now.Add(d)
+	}
+	a.SetRuntimeOptions(runtime.NewOptions().SetCommitLogNamespaceWriteLimits(limits))
+	return a, &now, &sleeps
+}
+
+func TestNamespaceAdmissionDisabledByDefault(t *testing.T) {
+	a, _, _ := newTestNamespaceAdmission(runtime.CommitLogNamespaceWriteLimits{})
+	require.False(t, a.Enabled())
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, a.Admit(ident.StringID("foo"), 1, 1<<20))
+	}
+}
+
+func TestNamespaceAdmissionRejectsExcessOps(t *testing.T) {
+	a, now, _ := newTestNamespaceAdmission(runtime.CommitLogNamespaceWriteLimits{
+		Default: runtime.CommitLogWriteLimit{OpsPerSecond: 10},
+	})
+	require.True(t, a.Enabled())
+
+	foo, bar := ident.StringID("foo"), ident.StringID("bar")
+	require.NoError(t, a.Admit(foo, 6, 0))
+	require.NoError(t, a.Admit(foo, 4, 0))
+	require.Equal(t, ErrNamespaceWriteLimitExceeded, a.Admit(foo, 1, 0))
+
+	// Limits are tracked separately for each namespace.
+	require.NoError(t, a.Admit(bar, 10, 0))
+
+	// Capacity is restored in the next window.
+	*now = now.Add(time.Second)
+	require.NoError(t, a.Admit(foo, 10, 0))
+}
+
+func TestNamespaceAdmissionRejectsExcessBytesWithOverride(t *testing.T) {
+	a, _, _ := newTestNamespaceAdmission(runtime.CommitLogNamespaceWriteLimits{
+		Default: runtime.CommitLogWriteLimit{BytesPerSecond: 100},
+		Overrides: map[string]runtime.CommitLogWriteLimit{
+			"unlimited": {},
+		},
+	})
+
+	foo := ident.StringID("foo")
+	require.NoError(t, a.Admit(foo, 1, 80))
+	require.Equal(t, ErrNamespaceWriteLimitExceeded, a.Admit(foo, 1, 40))
+
+	// Writes into an empty window are always admitted.
+	bar := ident.StringID("bar")
+	require.NoError(t, a.Admit(bar, 1, 1000))
+
+	unlimited := ident.StringID("unlimited")
+	for i := 0; i < 10; i++ {
+		require.NoError(t, a.Admit(unlimited, 1, 1000))
+	}
+}
+
+func TestNamespaceAdmissionQueuesUntilNextWindow(t *testing.T) {
+	a, now, sleeps := newTestNamespaceAdmission(runtime.CommitLogNamespaceWriteLimits{
+		Default:      runtime.CommitLogWriteLimit{OpsPerSecond: 1},
+		MaxQueueWait: time.Second,
+	})
+
+	*now = now.Add(250 * time.Millisecond)
+	foo := ident.StringID("foo")
+	require.NoError(t, a.Admit(foo, 1, 0))
+
+	// Waits for the next window to be admitted.
+	require.NoError(t, a.Admit(foo, 1, 0))
+	require.Equal(t, []time.Duration{750 * time.Millisecond}, *sleeps)
+}
+
+func TestNamespaceAdmissionRejectsWhenNextWindowBeyondMaxQueueWait(t *testing.T) {
+	a, now, sleeps := newTestNamespaceAdmission(runtime.CommitLogNamespaceWriteLimits{
+		Default:      runtime.CommitLogWriteLimit{OpsPerSecond: 1},
+		MaxQueueWait: 500 * time.Millisecond,
+	})
+
+	*now = now.Add(250 * time.Millisecond)
+	foo := ident.StringID("foo")
+	require.NoError(t, a.Admit(foo, 1, 0))
+	require.Equal(t, ErrNamespaceWriteLimitExceeded, a.Admit(foo, 1, 0))
+	require.Equal(t, 0, len(*sleeps))
+}
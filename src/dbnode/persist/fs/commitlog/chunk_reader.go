@@ -22,6 +22,8 @@ package commitlog
 
 import (
 	"bufio"
+	"bytes"
+	"io"
 	"os"
 
 	"github.com/m3db/m3/src/dbnode/digest"
@@ -41,6 +43,10 @@ type chunkReader struct {
 	buffer    *bufio.Reader
 	remaining int
 	charBuff  []byte
+	// offset is the offset in the file of the next byte read from the buffer.
+	offset int64
+	// chunkStart is the offset in the file of the header of the current chunk.
+	chunkStart int64
 }
 
 func newChunkReader(bufferLen int) *chunkReader {
@@ -54,13 +60,20 @@ func (r *chunkReader) reset(fd *os.File) {
 	r.fd = fd
 	r.buffer.Reset(fd)
 	r.remaining = 0
+	r.offset = 0
+	r.chunkStart = 0
 }
 
 func (r *chunkReader) readHeader() error {
+	r.chunkStart = r.offset
 	header, err := r.buffer.Peek(chunkHeaderLen)
 	if err != nil {
 		return err
 	}
+	if bytes.Equal(header, footerStartMarker) {
+		// Reached the footer that follows the last chunk.
+		return io.EOF
+	}
 
 	size := endianness.Uint32(header[sizeStart:sizeEnd])
 	checksumSize := digest.
@@ -79,6 +92,7 @@ func (r *chunkReader) readHeader() error {
 	if _, err := r.buffer.Discard(chunkHeaderLen); err != nil {
 		return err
 	}
+	r.offset += chunkHeaderLen
 
 	// Verify data checksum
 	data, err := r.buffer.Peek(int(size))
@@ -105,6 +119,7 @@ func (r *chunkReader) Read(p []byte) (int, error) {
 		if r.remaining > 0 {
 			n, err := r.buffer.Read(p[:r.remaining])
 			r.remaining -= n
+			r.offset += int64(n)
 			read += n
 			if err != nil {
 				return read, err
@@ -127,6 +142,7 @@ func (r *chunkReader) Read(p []byte) (int, error) {
 
 	n, err := r.buffer.Read(p)
 	r.remaining -= n
+	r.offset += int64(n)
 	read += n
 	return read, err
 }
@@ -137,3 +153,90 @@ func (r *chunkReader) ReadByte() (c byte, err error) {
 	}
 	return r.charBuff[0], nil
 }
+
+// seek positions the reader at the chunk starting at the given offset.
+func (r *chunkReader) seek(offset int64) error {
+	if _, err := r.fd.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	r.buffer.Reset(r.fd)
+	r.remaining = 0
+	r.offset = offset
+	r.chunkStart = offset
+	return nil
+}
+
+// resync positions the reader at the first chunk after the start of the
+// current chunk that has a valid header and data checksum, returning its
+// offset in the file. If there are no further valid chunks it returns the
+// size of the file and io.EOF.
+func (r *chunkReader) resync() (int64, error) {
+	info, err := r.fd.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		fileSize = info.Size()
+		pos      = r.chunkStart + 1
+	)
+	// Scan with the buffer reading from a section of the file so that the
+	// file descriptor offset is only moved once a valid chunk is found.
+	r.buffer.Reset(io.NewSectionReader(r.fd, pos, fileSize-pos))
+	for ; pos+chunkHeaderLen <= fileSize; pos++ {
+		header, err := r.buffer.Peek(chunkHeaderLen)
+		if err != nil {
+			break
+		}
+		valid, err := r.validChunk(header, pos, fileSize)
+		if err != nil {
+			return 0, err
+		}
+		if valid {
+			if _, err := r.fd.Seek(pos, io.SeekStart); err != nil {
+				return 0, err
+			}
+			r.buffer.Reset(r.fd)
+			r.remaining = 0
+			r.offset = pos
+			r.chunkStart = pos
+			return pos, nil
+		}
+		if _, err := r.buffer.Discard(1); err != nil {
+			break
+		}
+	}
+
+	if _, err := r.fd.Seek(fileSize, io.SeekStart); err != nil {
+		return 0, err
+	}
+	r.buffer.Reset(r.fd)
+	r.remaining = 0
+	r.offset = fileSize
+	r.chunkStart = fileSize
+	return fileSize, io.EOF
+}
+
+func (r *chunkReader) validChunk(header []byte, pos, fileSize int64) (bool, error) {
+	size := endianness.Uint32(header[sizeStart:sizeEnd])
+	checksumSize := digest.
+		Buffer(header[checksumSizeStart:checksumSizeEnd]).
+		ReadDigest()
+	if size == 0 || digest.Checksum(header[sizeStart:sizeEnd]) != checksumSize {
+		return false, nil
+	}
+
+	dataStart := pos + chunkHeaderLen
+	if dataStart+int64(size) > fileSize {
+		return false, nil
+	}
+
+	data := make([]byte, size)
+	if _, err := r.fd.ReadAt(data, dataStart); err != nil {
+		return false, err
+	}
+	checksumData := digest.
+		Buffer(header[checksumDataStart:checksumDataEnd]).
+		ReadDigest()
+	return digest.Checksum(data) == checksumData, nil
+}
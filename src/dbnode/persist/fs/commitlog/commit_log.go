@@ -30,9 +30,12 @@ import (
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/ts"
+	xclose "github.com/m3db/m3/src/x/close"
 	"github.com/m3db/m3/src/x/context"
 	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
 	xtime "github.com/m3db/m3/src/x/time"
 
 	"github.com/uber-go/tally"
@@ -103,6 +106,10 @@ type commitLog struct {
 
 	metrics commitLogMetrics
 
+	admission            *namespaceAdmission
+	fsyncPolicy          *fsyncPolicy
+	runtimeOptsListeners []xclose.SimpleCloser
+
 	numWritesInQueue int64
 }
 
@@ -126,6 +133,27 @@ func (f *flushState) getLastFlushAt() time.Time {
 	return lastFlush
 }
 
+// fsyncPolicy is the runtime policy for fsyncing the commit log, it is
+// updated by the runtime options manager and read by the single-threaded
+// writer.
+type fsyncPolicy struct {
+	value atomic.Value
+}
+
+func newFsyncPolicy() *fsyncPolicy {
+	p := &fsyncPolicy{}
+	p.value.Store(runtime.CommitLogFsyncPolicy{})
+	return p
+}
+
+func (p *fsyncPolicy) SetRuntimeOptions(value runtime.Options) {
+	p.value.Store(value.CommitLogFsyncPolicy())
+}
+
+func (p *fsyncPolicy) get() runtime.CommitLogFsyncPolicy {
+	return p.value.Load().(runtime.CommitLogFsyncPolicy)
+}
+
 type writerState struct {
 	// See "Rotating Files" section of README.md for an explanation of how the
 	// primary and secondary fields are used during commitlog rotation.
@@ -137,6 +165,14 @@ type writerState struct {
 	// only be used when the order of operations does not matter.
 	writers     []commitLogWriter
 	activeFiles persist.CommitLogFiles
+	// Sequence number assigned to the last write batch and the last time the
+	// primary writer was synced to make the write batches written to it durable,
+	// both are only accessed by the single-threaded writer.
+	batchSequence uint64
+	lastSyncAt    time.Time
+	// Size of the primary writer when it was last synced, also only accessed
+	// by the single-threaded writer.
+	syncedSize int64
 }
 
 type asyncResettableWriter struct {
@@ -160,12 +196,24 @@ type asyncResettableWriter struct {
 	// with commitlog 1 should be called as the writer associated with commitlog 2 may not have been
 	// flushed at all yet.
 	pendingFlushFns []callbackFn
+	// The durable callbacks of the write batches written to the writer since it was
+	// last synced, these are only invoked once the writer has been fsync'd (or closed)
+	// since a flush does not guarantee durability with the write behind strategy.
+	pendingDurableFns []durableFn
 }
 
 func (w *asyncResettableWriter) onFlush(err error) {
 	w.commitlog.onFlush(w, err)
 }
 
+func (w *asyncResettableWriter) onSync(err error) {
+	for i := range w.pendingDurableFns {
+		w.pendingDurableFns[i](err)
+		w.pendingDurableFns[i] = nil
+	}
+	w.pendingDurableFns = w.pendingDurableFns[:0]
+}
+
 type closedState struct {
 	sync.RWMutex
 	closed bool
@@ -181,6 +229,7 @@ type commitLogMetrics struct {
 	closeErrors      tally.Counter
 	flushErrors      tally.Counter
 	flushDone        tally.Counter
+	fsyncLatency     tally.Histogram
 }
 
 type eventType int
@@ -195,6 +244,8 @@ const (
 
 type callbackFn func(callbackResult)
 
+type durableFn func(err error)
+
 type callbackResult struct {
 	eventType  eventType
 	err        error
@@ -253,9 +304,10 @@ func NewCommitLog(opts Options) (CommitLog, error) {
 		opts.InstrumentOptions().MetricsScope().SubScope("commitlog"))
 	scope := iopts.MetricsScope()
 
+	nowFn := opts.ClockOptions().NowFn()
 	commitLog := &commitLog{
 		opts:                 opts,
-		nowFn:                opts.ClockOptions().NowFn(),
+		nowFn:                nowFn,
 		log:                  iopts.Logger(),
 		newCommitLogWriterFn: newCommitLogWriter,
 		writes:               make(chan commitLogWrite, opts.BacklogQueueChannelSize()),
@@ -279,7 +331,12 @@ func NewCommitLog(opts Options) (CommitLog, error) {
 			closeErrors:      scope.Counter("writes.close-errors"),
 			flushErrors:      scope.Counter("writes.flush-errors"),
 			flushDone:        scope.Counter("writes.flush-done"),
+			fsyncLatency: scope.Histogram("writes.fsync-latency",
+				append(tally.DurationBuckets{0},
+					tally.MustMakeExponentialDurationBuckets(100*time.Microsecond, 2, 16)...)),
 		},
+		admission:   newNamespaceAdmission(scope, nowFn),
+		fsyncPolicy: newFsyncPolicy(),
 	}
 	// Setup backreferences for onFlush().
 	commitLog.writerState.primary.commitlog = commitLog
@@ -316,6 +373,13 @@ func (l *commitLog) Open() error {
 		l.log.Fatal("fatal commit log error", zap.Error(err))
 	}
 
+	if runtimeOptsMgr := l.opts.RuntimeOptionsManager(); runtimeOptsMgr != nil {
+		l.runtimeOptsListeners = []xclose.SimpleCloser{
+			runtimeOptsMgr.RegisterListener(l.admission),
+			runtimeOptsMgr.RegisterListener(l.fsyncPolicy),
+		}
+	}
+
 	// Asynchronously write
 	go l.write()
 
@@ -454,7 +518,12 @@ func (l *commitLog) write() {
 
 	for write := range l.writes {
 		if write.eventType == flushEventType {
+			if len(l.writerState.primary.pendingDurableFns) > 0 {
+				l.syncPrimary()
+				continue
+			}
 			l.writerState.primary.writer.Flush(false)
+			l.maybeSyncPrimary()
 			continue
 		}
 
@@ -502,6 +571,7 @@ func (l *commitLog) write() {
 		var (
 			numWritesSuccess int64
 			numDequeued      int
+			writeErr         error
 		)
 
 		if write.write.writeBatch == nil {
@@ -534,6 +604,7 @@ func (l *commitLog) write() {
 				write.Datapoint, write.Unit, write.Annotation)
 			if err != nil {
 				l.handleWriteErr(err)
+				writeErr = err
 				continue
 			}
 			numWritesSuccess++
@@ -541,8 +612,10 @@ func (l *commitLog) write() {
 
 		// Return the write batch to the pool.
 		if write.write.writeBatch != nil {
+			l.addDurableFn(write.write.writeBatch, int(numWritesSuccess), writeErr)
 			write.write.writeBatch.Finalize()
 		}
+		l.maybeSyncPrimary()
 
 		atomic.AddInt64(&l.numWritesInQueue, int64(-numDequeued))
 		l.metrics.success.Inc(numWritesSuccess)
@@ -566,9 +639,88 @@ func (l *commitLog) write() {
 	l.writerState.primary.writer = nil
 	l.writerState.secondary.writer = nil
 
+	// Closing the writers syncs them so the pending write batches are durable.
+	l.writerState.primary.onSync(multiErr.FinalError())
+	l.writerState.secondary.onSync(multiErr.FinalError())
+
 	l.closeErr <- multiErr.FinalError()
 }
 
+// addDurableFn registers the durable callback of a write batch, if any, to be
+// called once the primary writer the batch was written to is next synced.
+func (l *commitLog) addDurableFn(writes ts.WriteBatch, numWrites int, writeErr error) {
+	l.writerState.batchSequence++
+	fn := writes.DurableFn()
+	if fn == nil {
+		return
+	}
+
+	durability := ts.BatchDurability{
+		Sequence:       l.writerState.batchSequence,
+		CommitLogIndex: l.writerState.activeFiles[0].Index,
+		NumWrites:      numWrites,
+	}
+	if writeErr != nil {
+		// Some of the writes never made it to the commitlog, so the batch as a
+		// whole can never become durable.
+		fn(durability, writeErr)
+		return
+	}
+
+	primary := &l.writerState.primary
+	primary.pendingDurableFns = append(primary.pendingDurableFns, func(err error) {
+		fn(durability, err)
+	})
+
+	// Under sustained load the periodic flush may never be requested since the
+	// writer flushes whenever its buffer fills, so sync here at most once per
+	// flush interval to bound the time until the batches are durable.
+	if l.nowFn().Sub(l.writerState.lastSyncAt) >= l.opts.FlushInterval() {
+		l.syncPrimary()
+	}
+}
+
+// syncPrimary flushes and fsyncs the primary writer, then calls the durable
+// callbacks of the write batches written to it.
+func (l *commitLog) syncPrimary() {
+	start := l.nowFn()
+	l.writerState.lastSyncAt = start
+	err := l.writerState.primary.writer.Flush(true)
+	l.writerState.syncedSize = l.writerState.primary.writer.Size()
+	l.metrics.fsyncLatency.RecordDuration(l.nowFn().Sub(start))
+	if err != nil {
+		l.metrics.errors.Inc(1)
+		l.metrics.flushErrors.Inc(1)
+		l.log.Error("failed to sync commit log", zap.Error(err))
+	}
+	l.writerState.primary.onSync(err)
+}
+
+// maybeSyncPrimary syncs the primary writer if the runtime fsync policy
+// requires it given what has been written since it was last synced.
+func (l *commitLog) maybeSyncPrimary() {
+	unsynced := l.writerState.primary.writer.Size() - l.writerState.syncedSize
+	if unsynced <= 0 {
+		return
+	}
+
+	policy := l.fsyncPolicy.get()
+	switch policy.Strategy {
+	case runtime.CommitLogFsyncEveryWrite:
+	case runtime.CommitLogFsyncEveryBytes:
+		if unsynced < policy.Bytes {
+			return
+		}
+	case runtime.CommitLogFsyncEveryInterval:
+		if l.nowFn().Sub(l.writerState.lastSyncAt) < policy.Interval {
+			return
+		}
+	default:
+		return
+	}
+	l.syncPrimary()
+}
+
 func (l *commitLog) onFlush(writer *asyncResettableWriter, err error) {
 	l.flushState.setLastFlushAt(l.nowFn())
 
@@ -650,6 +802,7 @@ func (l *commitLog) openWriters() (persist.CommitLogFile, persist.CommitLogFile,
 		l.writerState.writers = []commitLogWriter{
 			l.writerState.primary.writer,
 			l.writerState.secondary.writer}
+		l.writerState.syncedSize = l.writerState.primary.writer.Size()
 
 		return primaryFile, secondaryFile, nil
 	}
@@ -658,6 +811,7 @@ func (l *commitLog) openWriters() (persist.CommitLogFile, persist.CommitLogFile,
 	// This consumes the standby secondary writer, but a new one will be prepared asynchronously by
 	// resetting the formerly primary writer.
 	l.writerState.primary, l.writerState.secondary = l.writerState.secondary, l.writerState.primary
+	l.writerState.syncedSize = l.writerState.primary.writer.Size()
 	l.startSecondaryWriterAsyncReset()
 
 	var (
@@ -695,7 +849,11 @@ func (l *commitLog) startSecondaryWriterAsyncReset() {
 			l.writerState.secondary.Done()
 		}()
 
-		if err = l.writerState.secondary.writer.Close(); err != nil {
+		err = l.writerState.secondary.writer.Close()
+		// Closing the writer syncs it so the write batches written to it
+		// before it was swapped out are now durable.
+		l.writerState.secondary.onSync(err)
+		if err != nil {
 			l.commitLogFailFn(err)
 			return
 		}
@@ -719,6 +877,11 @@ func (l *commitLog) Write(
 	unit xtime.Unit,
 	annotation ts.Annotation,
 ) error {
+	err := l.admission.Admit(series.Namespace, 1, approxWriteBytes(annotation))
+	if err != nil {
+		return err
+	}
+
 	return l.writeFn(ctx, writeOrWriteBatch{
 		write: ts.Write{
 			Series:     series,
@@ -733,11 +896,42 @@ func (l *commitLog) WriteBatch(
 	ctx context.Context,
 	writes ts.WriteBatch,
 ) error {
+	if l.admission.Enabled() {
+		if err := l.admitWriteBatch(writes); err != nil {
+			// Make sure to finalize the write batch even though we didn't accept the writes
+			// so it can be returned to the pool.
+			writes.Finalize()
+			return err
+		}
+	}
+
 	return l.writeFn(ctx, writeOrWriteBatch{
 		writeBatch: writes,
 	})
 }
 
+func (l *commitLog) admitWriteBatch(writes ts.WriteBatch) error {
+	var (
+		namespace  ident.ID
+		ops, bytes int64
+	)
+	// Write batches only ever contain writes for a single namespace.
+	for _, write := range writes.Iter() {
+		if write.SkipWrite {
+			continue
+		}
+		if namespace == nil {
+			namespace = write.Write.Series.Namespace
+		}
+		ops++
+		bytes += approxWriteBytes(write.Write.Annotation)
+	}
+	if ops == 0 {
+		return nil
+	}
+	return l.admission.Admit(namespace, ops, bytes)
+}
+
 func (l *commitLog) writeWait(
 	ctx context.Context,
 	write writeOrWriteBatch,
@@ -853,6 +1047,10 @@ func (l *commitLog) Close() error {
 	close(l.writes)
 	l.closedState.Unlock()
 
+	for _, listener := range l.runtimeOptsListeners {
+		listener.Close()
+	}
+
 	// Receive the result of closing the writer from asynchronous writer
 	return <-l.closeErr
 }
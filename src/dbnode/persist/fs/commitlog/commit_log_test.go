@@ -35,6 +35,7 @@ import (
 	"github.com/m3db/bitset"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/x/context"
 	"github.com/m3db/m3/src/x/ident"
@@ -174,6 +175,7 @@ type mockCommitLogWriter struct {
 	openFn  func() (persist.CommitLogFile, error)
 	writeFn func(ts.Series, ts.Datapoint, xtime.Unit, ts.Annotation) error
 	flushFn func(sync bool) error
+	sizeFn  func() int64
 	closeFn func() error
 }
 
@@ -188,6 +190,9 @@ func newMockCommitLogWriter() *mockCommitLogWriter {
 		flushFn: func(sync bool) error {
 			return nil
 		},
+		sizeFn: func() int64 {
+			return 0
+		},
 		closeFn: func() error {
 			return nil
 		},
@@ -211,6 +216,10 @@ func (w *mockCommitLogWriter) Flush(sync bool) error {
 	return w.flushFn(sync)
 }
 
+func (w *mockCommitLogWriter) Size() int64 {
+	return w.sizeFn()
+}
+
 func (w *mockCommitLogWriter) Close() error {
 	return w.closeFn()
 }
@@ -381,7 +390,7 @@ func TestReadCommitLogMissingMetadata(t *testing.T) {
 		strategy: StrategyWriteWait,
 	})
 	// Set read concurrency so that the parallel path is definitely tested
-	opts.SetReadConcurrency(readConc)
+	opts = opts.SetReadConcurrency(readConc)
 	defer cleanup(t, opts)
 
 	// Replace bitset in writer with one that configurably returns true or false
@@ -477,7 +486,10 @@ func TestCommitLogReaderIsNotReusable(t *testing.T) {
 	require.Equal(t, 2, len(files))
 
 	// Assert commitlog cannot be opened more than once
-	reader := newCommitLogReader(opts, readAllSeriesPredicateTest())
+	reader := newCommitLogReader(commitLogReaderOptions{
+		commitLogOptions: opts,
+		seriesPredicate:  readAllSeriesPredicateTest(),
+	})
 	_, err = reader.Open(files[0])
 	require.NoError(t, err)
 	reader.Close()
@@ -606,6 +618,204 @@ func TestCommitLogIteratorUsesPredicateFilterForCorruptFiles(t *testing.T) {
 	require.Equal(t, 2, len(iterStruct.files))
 }
 
+func TestCommitLogIteratorConcurrentDecodePreservesOrder(t *testing.T) {
+	// Make sure we're not leaking goroutines
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	opts, scope := newTestOptions(t, overrides{
+		strategy: StrategyWriteWait,
+	})
+	opts = opts.SetReadConcurrency(3)
+	defer cleanup(t, opts)
+
+	commitLog := newTestCommitLog(t, opts)
+
+	// Spread writes for the same series across several files, with enough
+	// writes per file to span multiple decoded batches.
+	var (
+		numFiles      = 4
+		writesPerFile = iteratorBatchSize + iteratorBatchSize/2
+		allSeries     []ts.Series
+		writes        []testWrite
+		start         = time.Now().Truncate(time.Second)
+	)
+	for i := 0; i < 10; i++ {
+		allSeries = append(allSeries, testSeries(uint64(i),
+			fmt.Sprintf("foo.%d", i), testTags1, uint32(i)))
+	}
+	for f := 0; f < numFiles; f++ {
+		var fileWrites []testWrite
+		for i := 0; i < writesPerFile; i++ {
+			idx := f*writesPerFile + i
+			fileWrites = append(fileWrites, testWrite{
+				series: allSeries[idx%len(allSeries)],
+				t:      start.Add(time.Duration(idx) * time.Millisecond),
+				v:      float64(idx),
+				u:      xtime.Millisecond,
+			})
+		}
+		writeCommitLogs(t, scope, commitLog, fileWrites).Wait()
+		writes = append(writes, fileWrites...)
+
+		_, err := commitLog.RotateLogs()
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, commitLog.Close())
+
+	iter, corruptFiles, err := NewIterator(IteratorOpts{
+		CommitLogOptions:      opts,
+		FileFilterPredicate:   ReadAllPredicate(),
+		SeriesFilterPredicate: readAllSeriesPredicateTest(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, len(corruptFiles))
+	defer iter.Close()
+
+	writesBySeries := make(map[string][]testWrite)
+	for _, write := range writes {
+		id := write.series.ID.String()
+		writesBySeries[id] = append(writesBySeries[id], write)
+	}
+
+	read := 0
+	for iter.Next() {
+		series, datapoint, unit, annotation := iter.Current()
+		id := series.ID.String()
+		require.True(t, len(writesBySeries[id]) > 0)
+		writesBySeries[id][0].assert(t, series, datapoint, unit, annotation)
+		writesBySeries[id] = writesBySeries[id][1:]
+		read++
+	}
+	require.NoError(t, iter.Err())
+	require.Equal(t, len(writes), read)
+}
+
+func TestCommitLogIteratorReadBufferBytesBounded(t *testing.T) {
+	// Make sure we're not leaking goroutines
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	opts, scope := newTestOptions(t, overrides{
+		strategy: StrategyWriteWait,
+	})
+	// A budget smaller than a single batch so that only the file being
+	// consumed is ever admitted.
+	opts = opts.
+		SetReadConcurrency(3).
+		SetReadBufferBytes(1)
+	defer cleanup(t, opts)
+
+	commitLog := newTestCommitLog(t, opts)
+
+	var (
+		numFiles = 3
+		writes   []testWrite
+		start    = time.Now().Truncate(time.Second)
+	)
+	for f := 0; f < numFiles; f++ {
+		var fileWrites []testWrite
+		for i := 0; i < 2*iteratorBatchSize; i++ {
+			idx := f*2*iteratorBatchSize + i
+			fileWrites = append(fileWrites, testWrite{
+				series: testSeries(0, "foo.bar", testTags1, 127),
+				t:      start.Add(time.Duration(idx) * time.Millisecond),
+				v:      float64(idx),
+				u:      xtime.Millisecond,
+			})
+		}
+		writeCommitLogs(t, scope, commitLog, fileWrites).Wait()
+		writes = append(writes, fileWrites...)
+
+		_, err := commitLog.RotateLogs()
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, commitLog.Close())
+
+	iter, _, err := NewIterator(IteratorOpts{
+		CommitLogOptions:      opts,
+		FileFilterPredicate:   ReadAllPredicate(),
+		SeriesFilterPredicate: readAllSeriesPredicateTest(),
+	})
+	require.NoError(t, err)
+	defer iter.Close()
+
+	read := 0
+	for iter.Next() {
+		series, datapoint, unit, annotation := iter.Current()
+		writes[read].assert(t, series, datapoint, unit, annotation)
+		read++
+	}
+	require.NoError(t, iter.Err())
+	require.Equal(t, len(writes), read)
+}
+
+func TestIteratorBudgetAdmitsCurrentFile(t *testing.T) {
+	budget := newIteratorBudget(10)
+
+	// The file being consumed is admitted even when over budget.
+	require.True(t, budget.acquire(0, 20))
+
+	acquired := make(chan bool)
+	go func() {
+		acquired <- budget.acquire(1, 5)
+	}()
+
+	select {
+	case <-acquired:
+		require.FailNow(t, "acquired over budget for a file not being consumed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	budget.release(20)
+	require.True(t, <-acquired)
+
+	// Closing the budget unblocks waiting acquires.
+	go func() {
+		acquired <- budget.acquire(2, 10)
+	}()
+	budget.close()
+	require.False(t, <-acquired)
+}
+
+func TestCommitLogIteratorCloseBeforeExhausted(t *testing.T) {
+	// Make sure we're not leaking goroutines
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	opts, scope := newTestOptions(t, overrides{
+		strategy: StrategyWriteWait,
+	})
+	opts = opts.SetReadConcurrency(2)
+	defer cleanup(t, opts)
+
+	commitLog := newTestCommitLog(t, opts)
+
+	var writes []testWrite
+	for i := 0; i < 4*iteratorBatchSize; i++ {
+		writes = append(writes, testWrite{
+			series: testSeries(0, "foo.bar", testTags1, 127),
+			t:      time.Now(),
+			v:      float64(i),
+			u:      xtime.Second,
+		})
+	}
+	writeCommitLogs(t, scope, commitLog, writes).Wait()
+	require.NoError(t, commitLog.Close())
+
+	iter, _, err := NewIterator(IteratorOpts{
+		CommitLogOptions:      opts,
+		FileFilterPredicate:   ReadAllPredicate(),
+		SeriesFilterPredicate: readAllSeriesPredicateTest(),
+	})
+	require.NoError(t, err)
+
+	// Only consume the first entry so that decoding is still in progress
+	// when the iterator is closed.
+	require.True(t, iter.Next())
+	iter.Close()
+	require.False(t, iter.Next())
+}
+
 func TestCommitLogWriteBehind(t *testing.T) {
 	opts, scope := newTestOptions(t, overrides{
 		strategy: StrategyWriteBehind,
@@ -1048,3 +1258,147 @@ func TestCommitLogBatchWriteDoesNotAddErroredOrSkippedSeries(t *testing.T) {
 	assertCommitLogWritesByIterating(t, commitLog, expected)
 	require.Equal(t, 1, finalized)
 }
+
+func TestCommitLogWriteBatchDurableFn(t *testing.T) {
+	// Use a mock clock and a long flush interval so that only the first batch
+	// triggers a sync and the second one is only made durable by the close.
+	clock := mclock.NewMock()
+	flushInterval := time.Hour
+	opts, _ := newTestOptions(t, overrides{
+		clock:         clock,
+		flushInterval: &flushInterval,
+		strategy:      StrategyWriteBehind,
+	})
+	defer cleanup(t, opts)
+
+	commitLog := newTestCommitLog(t, opts)
+	activeLogs, err := commitLog.ActiveLogs()
+	require.NoError(t, err)
+
+	type durableResult struct {
+		durability ts.BatchDurability
+		err        error
+	}
+	durableCh := make(chan durableResult, 2)
+	newWrites := func(values ...float64) ts.WriteBatch {
+		writes := ts.NewWriteBatch(len(values), ident.StringID("ns"), func(ts.WriteBatch) {})
+		for i, v := range values {
+			writes.Add(i, ident.StringID(fmt.Sprint(i)), clock.Now(), v, xtime.Second, nil)
+			writes.SetOutcome(i, testSeries(uint64(i), fmt.Sprint(i), testTags1, 127), nil)
+		}
+		writes.SetDurableFn(func(durability ts.BatchDurability, err error) {
+			durableCh <- durableResult{durability: durability, err: err}
+		})
+		return writes
+	}
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	require.NoError(t, commitLog.WriteBatch(ctx, newWrites(1, 2)))
+	select {
+	case result := <-durableCh:
+		require.NoError(t, result.err)
+		require.Equal(t, ts.BatchDurability{
+			Sequence:       1,
+			CommitLogIndex: activeLogs[0].Index,
+			NumWrites:      2,
+		}, result.durability)
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "timed out waiting for first batch to be durable")
+	}
+
+	require.NoError(t, commitLog.WriteBatch(ctx, newWrites(3)))
+	require.NoError(t, commitLog.Close())
+
+	result := <-durableCh
+	require.NoError(t, result.err)
+	require.Equal(t, ts.BatchDurability{
+		Sequence:       2,
+		CommitLogIndex: activeLogs[0].Index,
+		NumWrites:      1,
+	}, result.durability)
+}
+
+func TestCommitLogRuntimeFsyncPolicy(t *testing.T) {
+	flushInterval := time.Hour
+	opts, _ := newTestOptions(t, overrides{
+		flushInterval: &flushInterval,
+		strategy:      StrategyWriteBehind,
+	})
+	defer cleanup(t, opts)
+
+	runtimeOptsMgr := runtime.NewOptionsManager()
+	defer runtimeOptsMgr.Close()
+	opts = opts.SetRuntimeOptionsManager(runtimeOptsMgr)
+
+	commitLogI, err := NewCommitLog(opts)
+	require.NoError(t, err)
+	commitLog := commitLogI.(*commitLog)
+
+	// Each write adds ten bytes to the size of the mock writer.
+	var size, syncs int64
+	writer := newMockCommitLogWriter()
+	writer.writeFn = func(ts.Series, ts.Datapoint, xtime.Unit, ts.Annotation) error {
+		atomic.AddInt64(&size, 10)
+		return nil
+	}
+	writer.flushFn = func(sync bool) error {
+		if sync {
+			atomic.AddInt64(&syncs, 1)
+		}
+		return nil
+	}
+	writer.sizeFn = func() int64 {
+		return atomic.LoadInt64(&size)
+	}
+	commitLog.newCommitLogWriterFn = func(_ flushFn, _ Options) commitLogWriter {
+		return writer
+	}
+
+	require.NoError(t, commitLog.Open())
+	defer commitLog.Close()
+
+	// Both writers are synced when opened.
+	require.Equal(t, int64(2), atomic.LoadInt64(&syncs))
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	write := func(n int) {
+		for i := 0; i < n; i++ {
+			series := testSeries(0, "foo.bar", testTags1, 127)
+			datapoint := ts.Datapoint{Timestamp: time.Now(), Value: 1}
+			require.NoError(t, commitLog.Write(ctx, series, datapoint, xtime.Second, nil))
+		}
+		// Active logs are served by the writer after the writes.
+		_, err := commitLog.ActiveLogs()
+		require.NoError(t, err)
+	}
+
+	setPolicy := func(policy runtime.CommitLogFsyncPolicy) {
+		require.NoError(t, runtimeOptsMgr.Update(
+			runtimeOptsMgr.Get().SetCommitLogFsyncPolicy(policy)))
+		// Runtime options are delivered to listeners asynchronously.
+		for commitLog.fsyncPolicy.get() != policy {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// The default policy does not sync with the write behind strategy.
+	write(3)
+	require.Equal(t, int64(2), atomic.LoadInt64(&syncs))
+
+	setPolicy(runtime.CommitLogFsyncPolicy{
+		Strategy: runtime.CommitLogFsyncEveryBytes,
+		Bytes:    45,
+	})
+	write(4)
+	require.Equal(t, int64(3), atomic.LoadInt64(&syncs))
+
+	setPolicy(runtime.CommitLogFsyncPolicy{
+		Strategy: runtime.CommitLogFsyncEveryWrite,
+	})
+	write(2)
+	require.Equal(t, int64(5), atomic.LoadInt64(&syncs))
+}
@@ -0,0 +1,366 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package commitlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/m3db/m3/src/dbnode/digest"
+)
+
+// Commit log files are closed with a footer that indexes the writes in each
+// section of the file, a section being a run of chunks that starts with a
+// whole entry. The footer is laid out as:
+// - start marker [12]byte, where the next chunk header would be
+// - payload []byte
+// - payload size uint32
+// - payload checksum uint32
+// - trailer magic [8]byte
+const (
+	footerVersion = 1
+
+	footerTrailerSizeLen     = 4
+	footerTrailerChecksumLen = 4
+	footerTrailerMagicLen    = 8
+	footerTrailerLen         = footerTrailerSizeLen +
+		footerTrailerChecksumLen +
+		footerTrailerMagicLen
+)
+
+var (
+	// footerStartMarker marks the end of the chunks in a file, its size is
+	// larger than any chunk so readers stop at it even if the rest of the
+	// footer cannot be read.
+	footerStartMarker  = []byte{0xff, 0xff, 0xff, 0xff, 'c', 'l', 'f', 'o', 'o', 't', 'e', 'r'}
+	footerTrailerMagic = []byte("m3clidx1")
+
+	// var instead of const so we can modify it in tests.
+	footerSectionMinSize int64 = 1 << 20
+
+	errFooterUnknownVersion    = errors.New("commit log footer has unknown version")
+	errFooterInvalidNamespace  = errors.New("commit log footer section references unknown namespace")
+	errFooterSectionsUnordered = errors.New("commit log footer sections are not in file order")
+)
+
+// footer indexes the sections of a commit log file.
+type footer struct {
+	namespaces [][]byte
+	sections   []footerSection
+}
+
+type footerSection struct {
+	offset  int64
+	entries []footerSectionEntry
+}
+
+// footerSectionEntry summarizes the writes to a namespace and shard within a
+// section of the file.
+type footerSectionEntry struct {
+	namespace int
+	shard     uint32
+	minTime   int64
+	maxTime   int64
+	// hasMetadata is set if any of the writes carry the metadata for their
+	// series, such sections must be read to read later writes to the series.
+	hasMetadata bool
+}
+
+type footerEntryKey struct {
+	namespace int
+	shard     uint32
+}
+
+// footerBuilder builds the footer for a commit log file as it is written.
+type footerBuilder struct {
+	footer
+	lookup  map[footerEntryKey]int
+	buf     []byte
+	scratch []byte
+}
+
+func newFooterBuilder() *footerBuilder {
+	return &footerBuilder{
+		lookup:  make(map[footerEntryKey]int),
+		scratch: make([]byte, binary.MaxVarintLen64),
+	}
+}
+
+func (b *footerBuilder) reset() {
+	b.namespaces = nil
+	b.sections = nil
+	for k := range b.lookup {
+		delete(b.lookup, k)
+	}
+}
+
+// maybeStartSection starts a new section at the given offset of the next
+// chunk if the current section has reached the minimum section size.
+func (b *footerBuilder) maybeStartSection(offset int64) {
+	if n := len(b.sections); n > 0 && offset-b.sections[n-1].offset < footerSectionMinSize {
+		return
+	}
+	b.sections = append(b.sections, footerSection{offset: offset})
+	for k := range b.lookup {
+		delete(b.lookup, k)
+	}
+}
+
+func (b *footerBuilder) add(
+	namespace []byte,
+	shard uint32,
+	timestamp int64,
+	hasMetadata bool,
+) {
+	if len(b.sections) == 0 {
+		b.maybeStartSection(0)
+	}
+
+	var (
+		section = &b.sections[len(b.sections)-1]
+		key     = footerEntryKey{namespace: b.namespaceIndex(namespace), shard: shard}
+	)
+	idx, ok := b.lookup[key]
+	if !ok {
+		b.lookup[key] = len(section.entries)
+		section.entries = append(section.entries, footerSectionEntry{
+			namespace:   key.namespace,
+			shard:       shard,
+			minTime:     timestamp,
+			maxTime:     timestamp,
+			hasMetadata: hasMetadata,
+		})
+		return
+	}
+
+	entry := &section.entries[idx]
+	if timestamp < entry.minTime {
+		entry.minTime = timestamp
+	}
+	if timestamp > entry.maxTime {
+		entry.maxTime = timestamp
+	}
+	entry.hasMetadata = entry.hasMetadata || hasMetadata
+}
+
+func (b *footerBuilder) namespaceIndex(namespace []byte) int {
+	for i, ns := range b.namespaces {
+		if bytes.Equal(ns, namespace) {
+			return i
+		}
+	}
+	b.namespaces = append(b.namespaces, append([]byte(nil), namespace...))
+	return len(b.namespaces) - 1
+}
+
+// encode returns the footer as it is written to the end of the file.
+func (b *footerBuilder) encode() []byte {
+	buf := append(b.buf[:0], footerStartMarker...)
+	payloadStart := len(buf)
+
+	buf = b.appendUvarint(buf, footerVersion)
+	buf = b.appendUvarint(buf, uint64(len(b.namespaces)))
+	for _, ns := range b.namespaces {
+		buf = b.appendUvarint(buf, uint64(len(ns)))
+		buf = append(buf, ns...)
+	}
+	buf = b.appendUvarint(buf, uint64(len(b.sections)))
+	for _, section := range b.sections {
+		buf = b.appendUvarint(buf, uint64(section.offset))
+		buf = b.appendUvarint(buf, uint64(len(section.entries)))
+		for _, entry := range section.entries {
+			buf = b.appendUvarint(buf, uint64(entry.namespace))
+			buf = b.appendUvarint(buf, uint64(entry.shard))
+			buf = b.appendVarint(buf, entry.minTime)
+			buf = b.appendUvarint(buf, uint64(entry.maxTime-entry.minTime))
+			hasMetadata := uint64(0)
+			if entry.hasMetadata {
+				hasMetadata = 1
+			}
+			buf = b.appendUvarint(buf, hasMetadata)
+		}
+	}
+
+	payload := buf[payloadStart:]
+	var trailer [footerTrailerSizeLen + footerTrailerChecksumLen]byte
+	endianness.PutUint32(trailer[:footerTrailerSizeLen], uint32(len(payload)))
+	endianness.PutUint32(trailer[footerTrailerSizeLen:], digest.Checksum(payload))
+	buf = append(buf, trailer[:]...)
+	buf = append(buf, footerTrailerMagic...)
+
+	b.buf = buf
+	return buf
+}
+
+func (b *footerBuilder) appendUvarint(buf []byte, v uint64) []byte {
+	n := binary.PutUvarint(b.scratch, v)
+	return append(buf, b.scratch[:n]...)
+}
+
+func (b *footerBuilder) appendVarint(buf []byte, v int64) []byte {
+	n := binary.PutVarint(b.scratch, v)
+	return append(buf, b.scratch[:n]...)
+}
+
+// readFooter reads the footer of a commit log file, returning the footer and
+// the offset of the end of the chunks in the file. A nil footer is returned
+// if the file has no footer or it cannot be decoded.
+func readFooter(r io.ReaderAt, fileSize int64) (*footer, int64, error) {
+	minLen := int64(len(footerStartMarker) + footerTrailerLen)
+	if fileSize < minLen {
+		return nil, fileSize, nil
+	}
+
+	trailer := make([]byte, footerTrailerLen)
+	if _, err := r.ReadAt(trailer, fileSize-footerTrailerLen); err != nil {
+		return nil, 0, err
+	}
+	checksumEnd := footerTrailerSizeLen + footerTrailerChecksumLen
+	if !bytes.Equal(trailer[checksumEnd:], footerTrailerMagic) {
+		return nil, fileSize, nil
+	}
+
+	var (
+		size     = int64(endianness.Uint32(trailer[:footerTrailerSizeLen]))
+		checksum = endianness.Uint32(trailer[footerTrailerSizeLen:checksumEnd])
+		start    = fileSize - minLen - size
+	)
+	if start < 0 {
+		return nil, fileSize, nil
+	}
+
+	buf := make([]byte, int64(len(footerStartMarker))+size)
+	if _, err := r.ReadAt(buf, start); err != nil {
+		return nil, 0, err
+	}
+	payload := buf[len(footerStartMarker):]
+	if !bytes.Equal(buf[:len(footerStartMarker)], footerStartMarker) ||
+		digest.Checksum(payload) != checksum {
+		return nil, fileSize, nil
+	}
+
+	f, err := decodeFooter(payload)
+	if err != nil {
+		return nil, fileSize, nil
+	}
+	return f, start, nil
+}
+
+func decodeFooter(payload []byte) (*footer, error) {
+	var (
+		r = bytes.NewReader(payload)
+		f = &footer{}
+	)
+	version, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if version != footerVersion {
+		return nil, errFooterUnknownVersion
+	}
+
+	numNamespaces, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < numNamespaces; i++ {
+		nsLen, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if nsLen > uint64(r.Len()) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		ns := make([]byte, nsLen)
+		if _, err := io.ReadFull(r, ns); err != nil {
+			return nil, err
+		}
+		f.namespaces = append(f.namespaces, ns)
+	}
+
+	numSections, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < numSections; i++ {
+		offset, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if n := len(f.sections); n > 0 && int64(offset) <= f.sections[n-1].offset {
+			return nil, errFooterSectionsUnordered
+		}
+		numEntries, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		section := footerSection{offset: int64(offset)}
+		for j := uint64(0); j < numEntries; j++ {
+			entry, err := decodeFooterSectionEntry(r, len(f.namespaces))
+			if err != nil {
+				return nil, err
+			}
+			section.entries = append(section.entries, entry)
+		}
+		f.sections = append(f.sections, section)
+	}
+
+	return f, nil
+}
+
+func decodeFooterSectionEntry(
+	r *bytes.Reader,
+	numNamespaces int,
+) (footerSectionEntry, error) {
+	var entry footerSectionEntry
+	namespace, err := binary.ReadUvarint(r)
+	if err != nil {
+		return entry, err
+	}
+	if namespace >= uint64(numNamespaces) {
+		return entry, errFooterInvalidNamespace
+	}
+	shard, err := binary.ReadUvarint(r)
+	if err != nil {
+		return entry, err
+	}
+	minTime, err := binary.ReadVarint(r)
+	if err != nil {
+		return entry, err
+	}
+	timeRange, err := binary.ReadUvarint(r)
+	if err != nil {
+		return entry, err
+	}
+	hasMetadata, err := binary.ReadUvarint(r)
+	if err != nil {
+		return entry, err
+	}
+
+	entry.namespace = int(namespace)
+	entry.shard = uint32(shard)
+	entry.minTime = minTime
+	entry.maxTime = minTime + int64(timeRange)
+	entry.hasMetadata = hasMetadata != 0
+	return entry, nil
+}
@@ -23,6 +23,7 @@ package commitlog
 import (
 	"errors"
 	"io"
+	"sync"
 
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/ts"
@@ -32,6 +33,21 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// iteratorBatchSize is the number of entries decoded from a commit log
+	// file before they are handed to the iterator.
+	iteratorBatchSize = 1024
+
+	// iteratorBatchesPerFile is the number of decoded batches that can be
+	// buffered per commit log file ahead of the consumer of the iterator.
+	iteratorBatchesPerFile = 4
+
+	// iteratorReadOverheadBytes is the approximate size of a decoded entry
+	// excluding its annotation, the series ID and tags are shared by all the
+	// entries of a series and so are not accounted for.
+	iteratorReadOverheadBytes = 192
+)
+
 var (
 	errIndexDoesNotMatch = errors.New("commit log file index does not match filename")
 )
@@ -40,18 +56,45 @@ type iteratorMetrics struct {
 	readsErrors tally.Counter
 }
 
+// iterator decodes commit log files concurrently, up to the read concurrency
+// of the commit log options, while returning entries strictly in file order
+// so that callers observe every series' writes in the order they were made.
 type iterator struct {
 	opts       Options
 	scope      tally.Scope
 	metrics    iteratorMetrics
 	log        *zap.Logger
 	files      []persist.CommitLogFile
-	reader     commitLogReader
-	read       iteratorRead
-	err        error
 	seriesPred SeriesFilterPredicate
-	setRead    bool
-	closed     bool
+	recover    bool
+	filter     ReadFilter
+
+	decoded  []chan iteratorBatch
+	budget   *iteratorBudget
+	doneCh   chan struct{}
+	wg       sync.WaitGroup
+	fileIdx  int
+	batch    iteratorBatch
+	batchIdx int
+	started  bool
+
+	read    iteratorRead
+	err     error
+	reports []CorruptionReport
+	setRead bool
+	closed  bool
+}
+
+type iteratorBatch struct {
+	reads []iteratorRead
+	// bytes is the estimated size of the reads acquired from the budget.
+	bytes int64
+	// err is set on the last batch decoded from a file if decoding
+	// the file did not complete cleanly.
+	err error
+	// reports is set on the last batch decoded from a file if any corrupt
+	// ranges of the file were skipped.
+	reports []CorruptionReport
 }
 
 type iteratorRead struct {
@@ -90,6 +133,10 @@ func NewIterator(iterOpts IteratorOpts) (iter Iterator, corruptFiles []ErrorWith
 		log:        iops.Logger(),
 		files:      filteredFiles,
 		seriesPred: iterOpts.SeriesFilterPredicate,
+		recover:    iterOpts.RecoverFromCorruption,
+		filter:     iterOpts.ReadFilter,
+		budget:     newIteratorBudget(opts.ReadBufferBytes()),
+		doneCh:     make(chan struct{}),
 	}, filteredCorruptFiles, nil
 }
 
@@ -97,34 +144,35 @@ func (i *iterator) Next() bool {
 	if i.hasError() || i.closed {
 		return false
 	}
-	if i.reader == nil {
-		if !i.nextReader() {
+	if !i.started {
+		i.startDecoding()
+	}
+	for {
+		if i.batchIdx < len(i.batch.reads) {
+			i.read = i.batch.reads[i.batchIdx]
+			i.batchIdx++
+			i.setRead = true
+			return true
+		}
+		if i.batch.err != nil {
+			i.err = i.batch.err
 			return false
 		}
-	}
-	var err error
-	i.read.series, i.read.datapoint, i.read.unit, i.read.annotation, err = i.reader.Read()
-	if err == io.EOF {
-		closeErr := i.closeAndResetReader()
-		if closeErr != nil {
-			i.err = closeErr
+		if i.fileIdx >= len(i.decoded) {
+			return false
 		}
-		// Try the next reader
-		return i.Next()
-	}
-	if err != nil {
-		// Try the next reader, this enables restoring with best effort from commit logs
-		i.metrics.readsErrors.Inc(1)
-		i.log.Error("commit log reader returned error, iterator moving to next file", zap.Error(err))
-		i.err = err
-		closeErr := i.closeAndResetReader()
-		if closeErr != nil {
-			i.err = closeErr
+		i.budget.release(i.batch.bytes)
+		batch, ok := <-i.decoded[i.fileIdx]
+		if !ok {
+			// Decoded all entries for this file, move to the next file.
+			i.fileIdx++
+			i.budget.advance(i.fileIdx)
+			batch = iteratorBatch{}
 		}
-		return i.Next()
+		i.batch = batch
+		i.batchIdx = 0
+		i.reports = append(i.reports, batch.reports...)
 	}
-	i.setRead = true
-	return true
 }
 
 func (i *iterator) Current() (ts.Series, ts.Datapoint, xtime.Unit, ts.Annotation) {
@@ -139,48 +187,206 @@ func (i *iterator) Err() error {
 	return i.err
 }
 
+func (i *iterator) CorruptionReports() []CorruptionReport {
+	return i.reports
+}
+
 // TODO: Refactor codebase so that it can handle Close() returning an error
 func (i *iterator) Close() {
 	if i.closed {
 		return
 	}
 	i.closed = true
-	i.closeAndResetReader()
+	if i.started {
+		close(i.doneCh)
+		i.budget.close()
+		i.wg.Wait()
+	}
 }
 
 func (i *iterator) hasError() bool {
 	return i.err != nil
 }
 
-func (i *iterator) nextReader() bool {
-	if len(i.files) == 0 {
-		return false
+// startDecoding starts decoding the commit log files in order, with at most
+// the read concurrency number of files being decoded at any one time and the
+// batches decoded ahead of the consumer bounded by the read buffer bytes.
+// Since files are started in order and the earliest file being decoded always
+// holds a slot and is admitted by the budget the consumer can always make
+// progress.
+func (i *iterator) startDecoding() {
+	i.started = true
+	i.decoded = make([]chan iteratorBatch, 0, len(i.files))
+	for range i.files {
+		i.decoded = append(i.decoded, make(chan iteratorBatch, iteratorBatchesPerFile))
 	}
 
-	err := i.closeAndResetReader()
-	if err != nil {
-		i.err = err
-		return false
-	}
+	i.wg.Add(1)
+	go func() {
+		defer i.wg.Done()
+		slots := make(chan struct{}, i.opts.ReadConcurrency())
+		for idx, file := range i.files {
+			select {
+			case slots <- struct{}{}:
+			case <-i.doneCh:
+				return
+			}
 
-	file := i.files[0]
-	i.files = i.files[1:]
+			i.wg.Add(1)
+			go func(idx int, file persist.CommitLogFile) {
+				defer func() {
+					<-slots
+					i.wg.Done()
+				}()
+				i.decodeFile(idx, file, i.decoded[idx])
+			}(idx, file)
+		}
+	}()
+}
+
+func (i *iterator) decodeFile(
+	fileIdx int,
+	file persist.CommitLogFile,
+	out chan<- iteratorBatch,
+) {
+	defer close(out)
 
-	reader := newCommitLogReader(i.opts, i.seriesPred)
+	reader := newCommitLogReader(commitLogReaderOptions{
+		commitLogOptions:      i.opts,
+		seriesPredicate:       i.seriesPred,
+		recoverFromCorruption: i.recover,
+		filter:                i.filter,
+	})
 	index, err := reader.Open(file.FilePath)
 	if err != nil {
-		i.err = err
-		return false
+		i.send(fileIdx, out, iteratorBatch{err: err})
+		return
 	}
 	if index != file.Index {
-		i.err = errIndexDoesNotMatch
+		reader.Close()
+		i.send(fileIdx, out, iteratorBatch{err: errIndexDoesNotMatch})
+		return
+	}
+
+	reads := make([]iteratorRead, 0, iteratorBatchSize)
+	for {
+		series, datapoint, unit, annotation, err := reader.Read()
+		if err != nil {
+			closeErr := reader.Close()
+			if err == io.EOF {
+				err = closeErr
+			} else {
+				i.metrics.readsErrors.Inc(1)
+				i.log.Error("commit log reader returned error",
+					zap.String("file", file.FilePath), zap.Error(err))
+				if closeErr != nil {
+					err = closeErr
+				}
+			}
+			i.send(fileIdx, out, iteratorBatch{
+				reads:   reads,
+				err:     err,
+				reports: reader.CorruptionReports(),
+			})
+			return
+		}
+
+		reads = append(reads, iteratorRead{
+			series:     series,
+			datapoint:  datapoint,
+			unit:       unit,
+			annotation: annotation,
+		})
+		if len(reads) < iteratorBatchSize {
+			continue
+		}
+		if !i.send(fileIdx, out, iteratorBatch{reads: reads}) {
+			reader.Close()
+			return
+		}
+		reads = make([]iteratorRead, 0, iteratorBatchSize)
+	}
+}
+
+// send returns false if the iterator was closed before the batch was sent.
+func (i *iterator) send(
+	fileIdx int,
+	out chan<- iteratorBatch,
+	batch iteratorBatch,
+) bool {
+	for _, read := range batch.reads {
+		batch.bytes += iteratorReadOverheadBytes + int64(len(read.annotation))
+	}
+	if !i.budget.acquire(fileIdx, batch.bytes) {
 		return false
 	}
+	select {
+	case out <- batch:
+		return true
+	case <-i.doneCh:
+		return false
+	}
+}
+
+// iteratorBudget bounds the estimated bytes of the batches decoded ahead of
+// the consumer of an iterator. Batches of the file the consumer is reading
+// are always admitted so that the consumer is never blocked by batches of
+// the files after it.
+type iteratorBudget struct {
+	sync.Mutex
+	cond    *sync.Cond
+	limit   int64
+	used    int64
+	current int
+	closed  bool
+}
+
+func newIteratorBudget(limit int64) *iteratorBudget {
+	b := &iteratorBudget{limit: limit}
+	b.cond = sync.NewCond(b)
+	return b
+}
 
-	i.reader = reader
+// acquire blocks until the bytes of a batch of the file fit in the budget,
+// returning false if the budget was closed.
+func (b *iteratorBudget) acquire(fileIdx int, bytes int64) bool {
+	b.Lock()
+	defer b.Unlock()
+	for !b.closed && fileIdx != b.current && b.used+bytes > b.limit {
+		b.cond.Wait()
+	}
+	if b.closed {
+		return false
+	}
+	b.used += bytes
 	return true
 }
 
+func (b *iteratorBudget) release(bytes int64) {
+	if bytes == 0 {
+		return
+	}
+	b.Lock()
+	b.used -= bytes
+	b.Unlock()
+	b.cond.Broadcast()
+}
+
+// advance sets the file the consumer is reading.
+func (b *iteratorBudget) advance(fileIdx int) {
+	b.Lock()
+	b.current = fileIdx
+	b.Unlock()
+	b.cond.Broadcast()
+}
+
+func (b *iteratorBudget) close() {
+	b.Lock()
+	b.closed = true
+	b.Unlock()
+	b.cond.Broadcast()
+}
+
 func filterFiles(files []persist.CommitLogFile, predicate FileFilterPredicate) []persist.CommitLogFile {
 	filtered := make([]persist.CommitLogFile, 0, len(files))
 	for _, f := range files {
@@ -205,12 +411,3 @@ func filterCorruptFiles(corruptFiles []ErrorWithPath, predicate FileFilterPredic
 	}
 	return filtered
 }
-
-func (i *iterator) closeAndResetReader() error {
-	if i.reader == nil {
-		return nil
-	}
-	reader := i.reader
-	i.reader = nil
-	return reader.Close()
-}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
+	m3dbruntime "github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/pool"
@@ -49,6 +50,9 @@ const (
 	// defaultReadConcurrency is the default read concurrency
 	defaultReadConcurrency = 4
 
+	// defaultReadBufferBytes is the default read buffer bytes
+	defaultReadBufferBytes = 256 * 1024 * 1024
+
 	// MaximumQueueSizeQueueChannelSizeRatio is the maximum ratio between the
 	// backlog queue size and backlog queue channel size.
 	MaximumQueueSizeQueueChannelSizeRatio = 8.0
@@ -66,6 +70,7 @@ var (
 	errFlushIntervalNonNegative = errors.New("flush interval must be non-negative")
 	errBlockSizePositive        = errors.New("block size must be a positive duration")
 	errReadConcurrencyPositive  = errors.New("read concurrency must be a positive integer")
+	errReadBufferBytesPositive  = errors.New("read buffer bytes must be a positive integer")
 )
 
 type options struct {
@@ -81,6 +86,8 @@ type options struct {
 	bytesPool               pool.CheckedBytesPool
 	identPool               ident.Pool
 	readConcurrency         int
+	readBufferBytes         int64
+	runtimeOptsMgr          m3dbruntime.OptionsManager
 }
 
 // NewOptions creates new commit log options
@@ -99,6 +106,7 @@ func NewOptions() Options {
 			return pool.NewBytesPool(s, nil)
 		}),
 		readConcurrency: defaultReadConcurrency,
+		readBufferBytes: defaultReadBufferBytes,
 	}
 	o.bytesPool.Init()
 	o.identPool = ident.NewPool(o.bytesPool, ident.PoolOptions{})
@@ -118,6 +126,10 @@ func (o *options) Validate() error {
 		return errReadConcurrencyPositive
 	}
 
+	if o.ReadBufferBytes() <= 0 {
+		return errReadBufferBytesPositive
+	}
+
 	if float64(o.BacklogQueueSize())/float64(o.BacklogQueueChannelSize()) > MaximumQueueSizeQueueChannelSizeRatio {
 		return fmt.Errorf(
 			"BacklogQueueSize / BacklogQueueChannelSize ratio must be at most: %f, but was: %f",
@@ -237,6 +249,16 @@ func (o *options) ReadConcurrency() int {
 	return o.readConcurrency
 }
 
+func (o *options) SetReadBufferBytes(value int64) Options {
+	opts := *o
+	opts.readBufferBytes = value
+	return &opts
+}
+
+func (o *options) ReadBufferBytes() int64 {
+	return o.readBufferBytes
+}
+
 func (o *options) SetIdentifierPool(value ident.Pool) Options {
 	opts := *o
 	opts.identPool = value
@@ -246,3 +268,13 @@ func (o *options) SetIdentifierPool(value ident.Pool) Options {
 func (o *options) IdentifierPool() ident.Pool {
 	return o.identPool
 }
+
+func (o *options) SetRuntimeOptionsManager(value m3dbruntime.OptionsManager) Options {
+	opts := *o
+	opts.runtimeOptsMgr = value
+	return &opts
+}
+
+func (o *options) RuntimeOptionsManager() m3dbruntime.OptionsManager {
+	return o.runtimeOptsMgr
+}
@@ -661,3 +661,11 @@ func (c *corruptingChunkWriter) isOpen() bool {
 func (c *corruptingChunkWriter) sync() error {
 	return c.chunkWriter.sync()
 }
+
+func (c *corruptingChunkWriter) offset() int64 {
+	return c.chunkWriter.offset()
+}
+
+func (c *corruptingChunkWriter) writeFooter(p []byte) error {
+	return c.chunkWriter.writeFooter(p)
+}
@@ -25,6 +25,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"io"
+	"math"
 	"os"
 	"time"
 
@@ -52,6 +53,7 @@ var (
 	errCommitLogReaderIsNotReusable             = errors.New("commit log reader is not reusable")
 	errCommitLogReaderMultipleReadloops         = errors.New("commit log reader tried to open multiple readLoops, do not call Read() concurrently")
 	errCommitLogReaderMissingMetadata           = errors.New("commit log reader encountered a datapoint without corresponding metadata")
+	errCommitLogReaderEntryLargerThanFile       = errors.New("commit log reader encountered an entry larger than the file")
 )
 
 // ReadAllSeriesPredicate can be passed as the seriesPredicate for callers
@@ -72,14 +74,83 @@ type commitLogReader interface {
 	// Read returns the next id and data pair or error, will return io.EOF at end of volume
 	Read() (ts.Series, ts.Datapoint, xtime.Unit, ts.Annotation, error)
 
+	// CorruptionReports returns the ranges of the file skipped due to
+	// corruption, only populated when recovering from corruption.
+	CorruptionReports() []CorruptionReport
+
 	// Close the reader
 	Close() error
 }
 
+type commitLogReaderOptions struct {
+	commitLogOptions Options
+	seriesPredicate  SeriesFilterPredicate
+	// recoverFromCorruption specifies whether the reader should skip past
+	// corrupt chunks and report them rather than returning an error.
+	recoverFromCorruption bool
+	filter                ReadFilter
+}
+
+// readFilter is a ReadFilter prepared for matching writes as they are read.
+type readFilter struct {
+	enabled    bool
+	namespaces []ident.ID
+	shards     map[uint32]struct{}
+	start      int64
+	end        int64
+}
+
+func newReadFilter(f ReadFilter) readFilter {
+	r := readFilter{
+		enabled:    len(f.Namespaces) > 0 || len(f.Shards) > 0 || !f.Start.IsZero() || !f.End.IsZero(),
+		namespaces: f.Namespaces,
+		start:      math.MinInt64,
+		end:        math.MaxInt64,
+	}
+	if len(f.Shards) > 0 {
+		r.shards = make(map[uint32]struct{}, len(f.Shards))
+		for _, shard := range f.Shards {
+			r.shards[shard] = struct{}{}
+		}
+	}
+	if !f.Start.IsZero() {
+		r.start = f.Start.UnixNano()
+	}
+	if !f.End.IsZero() {
+		r.end = f.End.UnixNano()
+	}
+	return r
+}
+
+func (f readFilter) matchesNamespace(namespace []byte) bool {
+	if len(f.namespaces) == 0 {
+		return true
+	}
+	for _, ns := range f.namespaces {
+		if bytes.Equal(ns.Bytes(), namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f readFilter) matchesShard(shard uint32) bool {
+	if f.shards == nil {
+		return true
+	}
+	_, ok := f.shards[shard]
+	return ok
+}
+
+func (f readFilter) overlaps(minTime, maxTime int64) bool {
+	return minTime < f.end && maxTime >= f.start
+}
+
 type reader struct {
 	opts Options
 
-	seriesPredicate SeriesFilterPredicate
+	seriesPredicate       SeriesFilterPredicate
+	recoverFromCorruption bool
 
 	logEntryBytes          []byte
 	tagDecoder             serialize.TagDecoder
@@ -92,14 +163,34 @@ type reader struct {
 
 	metadataLookup map[uint64]seriesMetadata
 	namespacesRead []ident.ID
+
+	filePath string
+	// fileSize is the size of the chunk data in the file, excluding any
+	// footer.
+	fileSize int64
+
+	filter readFilter
+	// footer is only set if the file has a footer and a filter is set.
+	footer            *footer
+	namespaceMatches  []bool
+	nextFooterSection int
+
+	corruptionReports []CorruptionReport
+	// inCorruption is true from when a corrupt range is skipped until the
+	// next entry is successfully read.
+	inCorruption  bool
+	lastTimestamp time.Time
 }
 
-func newCommitLogReader(opts Options, seriesPredicate SeriesFilterPredicate) commitLogReader {
+func newCommitLogReader(readerOpts commitLogReaderOptions) commitLogReader {
+	opts := readerOpts.commitLogOptions
 	tagDecoderCheckedBytes := checked.NewBytes(nil, nil)
 	tagDecoderCheckedBytes.IncRef()
 	return &reader{
 		opts:                   opts,
-		seriesPredicate:        seriesPredicate,
+		seriesPredicate:        readerOpts.seriesPredicate,
+		recoverFromCorruption:  readerOpts.recoverFromCorruption,
+		filter:                 newReadFilter(readerOpts.filter),
 		logEntryBytes:          make([]byte, 0, opts.FlushSize()),
 		metadataLookup:         make(map[uint64]seriesMetadata),
 		tagDecoder:             opts.FilesystemOptions().TagDecoderPool().Get(),
@@ -122,6 +213,26 @@ func (r *reader) Open(filePath string) (int64, error) {
 	if err != nil {
 		return 0, err
 	}
+	stat, err := fd.Stat()
+	if err != nil {
+		fd.Close()
+		return 0, err
+	}
+	r.filePath = filePath
+
+	footer, dataEnd, err := readFooter(fd, stat.Size())
+	if err != nil {
+		fd.Close()
+		return 0, err
+	}
+	r.fileSize = dataEnd
+	if footer != nil && r.filter.enabled {
+		r.footer = footer
+		r.namespaceMatches = make([]bool, 0, len(footer.namespaces))
+		for _, ns := range footer.namespaces {
+			r.namespaceMatches = append(r.namespaceMatches, r.filter.matchesNamespace(ns))
+		}
+	}
 
 	r.chunkReader.reset(fd)
 	info, err := r.readInfo()
@@ -154,20 +265,49 @@ func (r *reader) Read() (
 	var (
 		entry    schema.LogEntry
 		metadata seriesMetadata
+		matched  bool
 	)
-	for !metadata.passedPredicate {
-		err = r.readLogEntry()
-		if err != nil {
-			return ts.Series{}, ts.Datapoint{}, xtime.Unit(0), ts.Annotation(nil), err
+	for !matched {
+		err = nil
+		if r.footer != nil {
+			err = r.skipUnmatchedSections()
 		}
-
-		entry, err = msgpack.DecodeLogEntryFast(r.logEntryBytes)
-		if err != nil {
+		if err == nil {
+			err = r.readLogEntry()
+		}
+		if err == nil {
+			entry, err = msgpack.DecodeLogEntryFast(r.logEntryBytes)
+		}
+		if err == nil {
+			metadata, err = r.seriesMetadataForEntry(entry)
+		}
+		if err == nil {
+			r.lastTimestamp = time.Unix(0, entry.Timestamp)
+			if r.inCorruption {
+				r.corruptionReports[len(r.corruptionReports)-1].Before = r.lastTimestamp
+				r.inCorruption = false
+			}
+			matched = metadata.passedPredicate &&
+				r.filter.overlaps(entry.Timestamp, entry.Timestamp)
+			continue
+		}
+		if err == io.EOF && r.recoverFromCorruption && r.chunkReader.offset < r.fileSize {
+			// Trailing data that could not be read as a complete chunk, most
+			// likely a torn write, record it and mark the file as consumed.
+			r.recordCorruption(r.chunkReader.chunkStart, r.fileSize, io.ErrUnexpectedEOF)
+			r.chunkReader.offset = r.fileSize
+		}
+		if err == io.EOF || !r.recoverFromCorruption {
 			return ts.Series{}, ts.Datapoint{}, xtime.Unit(0), ts.Annotation(nil), err
 		}
 
-		metadata, err = r.seriesMetadataForEntry(entry)
-		if err != nil {
+		metadata = seriesMetadata{}
+		if err == errCommitLogReaderMissingMetadata && len(r.corruptionReports) > 0 {
+			// The metadata for the series was most likely in a skipped range.
+			r.corruptionReports[len(r.corruptionReports)-1].EntriesWithoutMetadata++
+			continue
+		}
+		if err = r.skipCorruption(err); err != nil {
 			return ts.Series{}, ts.Datapoint{}, xtime.Unit(0), ts.Annotation(nil), err
 		}
 	}
@@ -196,6 +336,10 @@ func (r *reader) readLogEntry() error {
 		return err
 	}
 
+	if size > uint64(r.fileSize) {
+		return errCommitLogReaderEntryLargerThanFile
+	}
+
 	// Extend buffer as necessary
 	r.logEntryBytes = resizeBufferOrGrowIfNeeded(r.logEntryBytes, int(size))
 
@@ -273,7 +417,9 @@ func (r *reader) seriesMetadataForEntry(
 			Shard:       decoded.Shard,
 			Tags:        tags,
 		},
-		passedPredicate: r.seriesPredicate(seriesID, namespaceID),
+		passedPredicate: r.filter.matchesNamespace(namespaceID.Bytes()) &&
+			r.filter.matchesShard(decoded.Shard) &&
+			r.seriesPredicate(seriesID, namespaceID),
 	}
 
 	r.metadataLookup[entry.Index] = metadata
@@ -283,6 +429,84 @@ func (r *reader) seriesMetadataForEntry(
 	return metadata, nil
 }
 
+// skipUnmatchedSections moves the reader past the sections of the file that
+// contain no writes matching the filter, it must only be called between
+// entries since sections start with a whole entry.
+func (r *reader) skipUnmatchedSections() error {
+	sections := r.footer.sections
+	if r.nextFooterSection >= len(sections) ||
+		r.chunkReader.offset < sections[r.nextFooterSection].offset {
+		// Still within the current section.
+		return nil
+	}
+
+	for r.nextFooterSection < len(sections) &&
+		sections[r.nextFooterSection].offset <= r.chunkReader.offset {
+		r.nextFooterSection++
+	}
+	if r.sectionMatches(sections[r.nextFooterSection-1]) {
+		return nil
+	}
+
+	next := r.nextFooterSection
+	for next < len(sections) && !r.sectionMatches(sections[next]) {
+		next++
+	}
+	target := r.fileSize
+	if next < len(sections) {
+		target = sections[next].offset
+	}
+	return r.chunkReader.seek(target)
+}
+
+func (r *reader) sectionMatches(section footerSection) bool {
+	for _, entry := range section.entries {
+		if !r.namespaceMatches[entry.namespace] || !r.filter.matchesShard(entry.shard) {
+			continue
+		}
+		// Writes carrying series metadata are needed to read any later
+		// writes to the series so are read regardless of their timestamps.
+		if entry.hasMetadata || r.filter.overlaps(entry.minTime, entry.maxTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipCorruption skips to the next valid chunk and records the skipped range,
+// it returns io.EOF if there are no further valid chunks.
+func (r *reader) skipCorruption(cause error) error {
+	start := r.chunkReader.chunkStart
+	end, err := r.chunkReader.resync()
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	r.recordCorruption(start, end, cause)
+	return err
+}
+
+func (r *reader) recordCorruption(start, end int64, cause error) {
+	if n := len(r.corruptionReports); r.inCorruption && n > 0 {
+		// No entries could be read since the last skipped range, most likely
+		// the resync landed part way through an entry, extend the range.
+		r.corruptionReports[n-1].EndOffset = end
+	} else {
+		r.corruptionReports = append(r.corruptionReports, CorruptionReport{
+			FilePath:    r.filePath,
+			StartOffset: start,
+			EndOffset:   end,
+			Err:         cause,
+			After:       r.lastTimestamp,
+		})
+	}
+	r.inCorruption = true
+}
+
+func (r *reader) CorruptionReports() []CorruptionReport {
+	return r.corruptionReports
+}
+
 func (r *reader) Close() error {
 	return r.chunkReader.fd.Close()
 }
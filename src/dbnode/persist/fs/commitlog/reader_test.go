@@ -0,0 +1,298 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package commitlog
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestChunks writes each group of writes to its own chunk and returns
+// the path of the file along with the offset of the end of each chunk.
+func writeTestChunks(
+	t *testing.T,
+	opts Options,
+	chunks [][]testWrite,
+) (string, []int64) {
+	w := newCommitLogWriter(func(err error) {}, opts)
+	file, err := w.Open()
+	require.NoError(t, err)
+
+	var ends []int64
+	for _, writes := range chunks {
+		for _, write := range writes {
+			require.NoError(t, w.Write(write.series,
+				ts.Datapoint{Timestamp: write.t, Value: write.v}, write.u, write.a))
+		}
+		require.NoError(t, w.Flush(false))
+
+		info, err := os.Stat(file.FilePath)
+		require.NoError(t, err)
+		ends = append(ends, info.Size())
+	}
+	require.NoError(t, w.Close())
+	return file.FilePath, ends
+}
+
+func readAllTestEntries(
+	t *testing.T,
+	opts Options,
+	filePath string,
+	recoverFromCorruption bool,
+) ([]testWrite, []CorruptionReport, error) {
+	return readFilteredTestEntries(t, opts, filePath, recoverFromCorruption, ReadFilter{})
+}
+
+func readFilteredTestEntries(
+	t *testing.T,
+	opts Options,
+	filePath string,
+	recoverFromCorruption bool,
+	filter ReadFilter,
+) ([]testWrite, []CorruptionReport, error) {
+	r := newCommitLogReader(commitLogReaderOptions{
+		commitLogOptions:      opts,
+		seriesPredicate:       readAllSeriesPredicateTest(),
+		recoverFromCorruption: recoverFromCorruption,
+		filter:                filter,
+	})
+	_, err := r.Open(filePath)
+	require.NoError(t, err)
+	defer r.Close()
+
+	var reads []testWrite
+	for {
+		series, dp, unit, annotation, err := r.Read()
+		if err == io.EOF {
+			return reads, r.CorruptionReports(), nil
+		}
+		if err != nil {
+			return reads, r.CorruptionReports(), err
+		}
+		reads = append(reads, testWrite{series, dp.Timestamp, dp.Value, unit, annotation, nil})
+	}
+}
+
+func newCorruptionTestWrites() [][]testWrite {
+	var (
+		start = time.Unix(1500000000, 0)
+		foo   = testSeries(0, "foo", ident.NewTags(ident.StringTag("a", "b")), 1)
+		bar   = testSeries(1, "bar", ident.NewTags(ident.StringTag("c", "d")), 2)
+	)
+	return [][]testWrite{
+		{
+			{foo, start, 1, xtime.Second, nil, nil},
+			{foo, start.Add(time.Second), 2, xtime.Second, nil, nil},
+		},
+		{
+			{foo, start.Add(2 * time.Second), 3, xtime.Second, nil, nil},
+			{bar, start.Add(3 * time.Second), 4, xtime.Second, nil, nil},
+		},
+		{
+			{foo, start.Add(4 * time.Second), 5, xtime.Second, nil, nil},
+			{bar, start.Add(5 * time.Second), 6, xtime.Second, nil, nil},
+		},
+	}
+}
+
+func TestReaderRecoversFromCorruptChunk(t *testing.T) {
+	opts, _ := newTestOptions(t, overrides{strategy: StrategyWriteWait})
+	defer cleanup(t, opts)
+
+	chunks := newCorruptionTestWrites()
+	filePath, ends := writeTestChunks(t, opts, chunks)
+
+	// Corrupt the data of the second chunk.
+	fd, err := os.OpenFile(filePath, os.O_RDWR, 0)
+	require.NoError(t, err)
+	_, err = fd.WriteAt([]byte{0xff, 0xff, 0xff}, ends[0]+chunkHeaderLen+1)
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+
+	// Without recovery reading stops at the corrupt chunk.
+	reads, reports, err := readAllTestEntries(t, opts, filePath, false)
+	require.Equal(t, errCommitLogReaderChunkSizeChecksumMismatch, err)
+	require.Equal(t, 2, len(reads))
+	require.Equal(t, 0, len(reports))
+
+	// With recovery the corrupt chunk is skipped and reported, the write for
+	// bar in the last chunk is dropped since its metadata was in the skipped
+	// chunk.
+	reads, reports, err = readAllTestEntries(t, opts, filePath, true)
+	require.NoError(t, err)
+	expected := []testWrite{chunks[0][0], chunks[0][1], chunks[2][0]}
+	require.Equal(t, len(expected), len(reads))
+	for i, write := range expected {
+		write.assert(t, reads[i].series, ts.Datapoint{Timestamp: reads[i].t, Value: reads[i].v},
+			reads[i].u, reads[i].a)
+	}
+
+	require.Equal(t, []CorruptionReport{
+		{
+			FilePath:               filePath,
+			StartOffset:            ends[0],
+			EndOffset:              ends[1],
+			Err:                    errCommitLogReaderChunkSizeChecksumMismatch,
+			After:                  chunks[0][1].t,
+			Before:                 chunks[2][0].t,
+			EntriesWithoutMetadata: 1,
+		},
+	}, reports)
+}
+
+func TestReaderRecoversFromTornTrailingChunk(t *testing.T) {
+	opts, _ := newTestOptions(t, overrides{strategy: StrategyWriteWait})
+	defer cleanup(t, opts)
+
+	chunks := newCorruptionTestWrites()
+	filePath, ends := writeTestChunks(t, opts, chunks)
+
+	// Truncate the file part way through the last chunk.
+	truncateAt := ends[1] + chunkHeaderLen + 3
+	require.NoError(t, os.Truncate(filePath, truncateAt))
+
+	reads, reports, err := readAllTestEntries(t, opts, filePath, true)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(reads))
+	require.Equal(t, []CorruptionReport{
+		{
+			FilePath:    filePath,
+			StartOffset: ends[1],
+			EndOffset:   truncateAt,
+			Err:         io.ErrUnexpectedEOF,
+			After:       chunks[1][1].t,
+		},
+	}, reports)
+}
+
+func requireTestWritesEqual(t *testing.T, expected, actual []testWrite) {
+	require.Equal(t, len(expected), len(actual))
+	for i, write := range expected {
+		write.assert(t, actual[i].series, ts.Datapoint{Timestamp: actual[i].t, Value: actual[i].v},
+			actual[i].u, actual[i].a)
+	}
+}
+
+func TestReaderFilterSkipsUnmatchedSections(t *testing.T) {
+	opts, _ := newTestOptions(t, overrides{strategy: StrategyWriteWait})
+	defer cleanup(t, opts)
+
+	// Start a new section with each chunk.
+	defaultSectionMinSize := footerSectionMinSize
+	footerSectionMinSize = 1
+	defer func() {
+		footerSectionMinSize = defaultSectionMinSize
+	}()
+
+	var (
+		start  = time.Unix(1500000000, 0)
+		foo    = testSeries(0, "foo", ident.NewTags(ident.StringTag("a", "b")), 1)
+		bar    = testSeries(1, "bar", ident.NewTags(ident.StringTag("c", "d")), 2)
+		chunks = [][]testWrite{
+			{
+				{foo, start, 1, xtime.Second, nil, nil},
+				{bar, start.Add(time.Second), 2, xtime.Second, nil, nil},
+			},
+			{
+				{foo, start.Add(2 * time.Second), 3, xtime.Second, nil, nil},
+				{foo, start.Add(3 * time.Second), 4, xtime.Second, nil, nil},
+			},
+			{
+				{bar, start.Add(4 * time.Second), 5, xtime.Second, nil, nil},
+				{bar, start.Add(5 * time.Second), 6, xtime.Second, nil, nil},
+			},
+			{
+				{foo, start.Add(10 * time.Second), 7, xtime.Second, nil, nil},
+			},
+		}
+	)
+	filePath, ends := writeTestChunks(t, opts, chunks)
+
+	fd, err := os.Open(filePath)
+	require.NoError(t, err)
+	info, err := fd.Stat()
+	require.NoError(t, err)
+	footer, dataEnd, err := readFooter(fd, info.Size())
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+	require.NotNil(t, footer)
+	require.Equal(t, ends[len(ends)-1], dataEnd)
+	require.Equal(t, [][]byte{[]byte("testNS")}, footer.namespaces)
+	require.Equal(t, 4, len(footer.sections))
+	for i, section := range footer.sections[1:] {
+		require.Equal(t, ends[i], section.offset)
+	}
+
+	// Corrupt the second chunk which only contains writes for shard 1, reads
+	// of shard 2 skip it and so succeed even without recovery.
+	fd, err = os.OpenFile(filePath, os.O_RDWR, 0)
+	require.NoError(t, err)
+	_, err = fd.WriteAt([]byte{0xff, 0xff, 0xff}, ends[0]+chunkHeaderLen+1)
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+
+	reads, _, err := readFilteredTestEntries(t, opts, filePath, false, ReadFilter{
+		Shards: []uint32{2},
+	})
+	require.NoError(t, err)
+	requireTestWritesEqual(t, []testWrite{chunks[0][1], chunks[2][0], chunks[2][1]}, reads)
+
+	// The first section is read for the series metadata but its writes are
+	// outside of the time range.
+	reads, _, err = readFilteredTestEntries(t, opts, filePath, false, ReadFilter{
+		Start: start.Add(4 * time.Second),
+		End:   start.Add(5 * time.Second),
+	})
+	require.NoError(t, err)
+	requireTestWritesEqual(t, []testWrite{chunks[2][0]}, reads)
+
+	reads, _, err = readFilteredTestEntries(t, opts, filePath, false, ReadFilter{
+		Namespaces: []ident.ID{ident.StringID("otherNS")},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, len(reads))
+}
+
+func TestReaderFilterWithoutFooter(t *testing.T) {
+	opts, _ := newTestOptions(t, overrides{strategy: StrategyWriteWait})
+	defer cleanup(t, opts)
+
+	chunks := newCorruptionTestWrites()
+	filePath, ends := writeTestChunks(t, opts, chunks)
+
+	// Remove the footer so that the whole file is read and filtered.
+	require.NoError(t, os.Truncate(filePath, ends[len(ends)-1]))
+
+	reads, reports, err := readFilteredTestEntries(t, opts, filePath, true, ReadFilter{
+		Shards: []uint32{2},
+		Start:  chunks[1][1].t,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, len(reports))
+	requireTestWritesEqual(t, []testWrite{chunks[1][1], chunks[2][1]}, reads)
+}
@@ -0,0 +1,350 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package commitlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultTailerPollInterval = time.Second
+)
+
+var (
+	errTailerCommitLogNotSet    = errors.New("commit log tailer requires a commit log")
+	errTailerPollIntervalNonNeg = errors.New("commit log tailer poll interval must be non-negative")
+	errTailerAlreadyTailing     = errors.New("commit log tailer is already tailing")
+	errTailerClosed             = errors.New("commit log tailer is closed")
+)
+
+type tailerMetrics struct {
+	writes         tally.Counter
+	filesCompleted tally.Counter
+	readsErrors    tally.Counter
+}
+
+type tailer struct {
+	sync.RWMutex
+
+	opts            Options
+	commitLog       CommitLog
+	seriesPredicate SeriesFilterPredicate
+	pollInterval    time.Duration
+	metrics         tailerMetrics
+	log             *zap.Logger
+
+	position     TailerPosition
+	resolveStart bool
+	// lastSize is the size of the current file when it was last polled, a file
+	// that is no longer active is only completed once its size is stable as the
+	// writer that was rotated out is closed and flushed asynchronously.
+	lastSize int64
+	tailing  bool
+	closed   bool
+	closedCh chan struct{}
+}
+
+// NewTailer creates a new commit log tailer.
+func NewTailer(tailerOpts TailerOpts) (Tailer, error) {
+	opts := tailerOpts.CommitLogOptions
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if tailerOpts.CommitLog == nil {
+		return nil, errTailerCommitLogNotSet
+	}
+	if tailerOpts.PollInterval < 0 {
+		return nil, errTailerPollIntervalNonNeg
+	}
+
+	pollInterval := tailerOpts.PollInterval
+	if pollInterval == 0 {
+		pollInterval = defaultTailerPollInterval
+	}
+	seriesPredicate := tailerOpts.SeriesFilterPredicate
+	if seriesPredicate == nil {
+		seriesPredicate = ReadAllSeriesPredicate()
+	}
+
+	iops := opts.InstrumentOptions()
+	scope := iops.MetricsScope().SubScope("tailer")
+	return &tailer{
+		opts:            opts,
+		commitLog:       tailerOpts.CommitLog,
+		seriesPredicate: seriesPredicate,
+		pollInterval:    pollInterval,
+		metrics: tailerMetrics{
+			writes:         scope.Counter("writes"),
+			filesCompleted: scope.Counter("files-completed"),
+			readsErrors:    scope.Counter("reads.errors"),
+		},
+		log:          iops.Logger(),
+		position:     tailerOpts.StartPosition,
+		resolveStart: tailerOpts.StartPosition == TailerPosition{},
+		lastSize:     -1,
+		closedCh:     make(chan struct{}),
+	}, nil
+}
+
+func (t *tailer) Tail(fn TailerConsumeFn) error {
+	t.Lock()
+	if t.closed {
+		t.Unlock()
+		return errTailerClosed
+	}
+	if t.tailing {
+		t.Unlock()
+		return errTailerAlreadyTailing
+	}
+	t.tailing = true
+	t.Unlock()
+
+	defer func() {
+		t.Lock()
+		t.tailing = false
+		t.Unlock()
+	}()
+
+	for !t.isClosed() {
+		advanced, err := t.poll(fn)
+		if err != nil {
+			return err
+		}
+		if advanced {
+			// Move straight on to the next file, it may already have writes.
+			continue
+		}
+
+		select {
+		case <-t.closedCh:
+		case <-time.After(t.pollInterval):
+		}
+	}
+
+	return nil
+}
+
+func (t *tailer) Position() TailerPosition {
+	t.RLock()
+	position := t.position
+	t.RUnlock()
+	return position
+}
+
+func (t *tailer) Close() error {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.closed {
+		return errTailerClosed
+	}
+	t.closed = true
+	close(t.closedCh)
+	return nil
+}
+
+func (t *tailer) isClosed() bool {
+	t.RLock()
+	closed := t.closed
+	t.RUnlock()
+	return closed
+}
+
+func (t *tailer) setPosition(position TailerPosition) {
+	t.Lock()
+	t.position = position
+	t.Unlock()
+}
+
+// poll streams any new writes in the current commit log file and returns
+// whether the tailer advanced to the next commit log file.
+func (t *tailer) poll(fn TailerConsumeFn) (bool, error) {
+	files, _, err := Files(t.opts)
+	if err != nil {
+		return false, err
+	}
+
+	if t.resolveStart {
+		if len(files) == 0 {
+			return false, nil
+		}
+		t.setPosition(TailerPosition{Index: files[0].Index})
+		t.resolveStart = false
+	}
+
+	position := t.Position()
+	file, ok := tailerFileWithIndex(files, position.Index)
+	if !ok {
+		if _, ok := tailerNextFile(files, position.Index); ok {
+			// Newer commit logs exist so the file at the position has been
+			// removed and the writes it contained can no longer be streamed.
+			return false, fmt.Errorf(
+				"commit log tailer position not found: index=%d", position.Index)
+		}
+		// Not yet created or its info not yet written.
+		return false, nil
+	}
+
+	// Check whether the file is active before draining it so that any writes
+	// made before it was rotated out are streamed.
+	activeFiles, err := t.commitLog.ActiveLogs()
+	if err != nil {
+		return false, err
+	}
+	active := activeFiles.Contains(file.FilePath)
+
+	info, err := os.Stat(file.FilePath)
+	if err != nil {
+		return false, err
+	}
+	size := info.Size()
+
+	if err := t.drain(file, position, fn); err != nil {
+		return false, err
+	}
+
+	stable := size == t.lastSize
+	t.lastSize = size
+	if active || !stable {
+		return false, nil
+	}
+
+	next, ok := tailerNextFile(files, file.Index)
+	if !ok {
+		return false, nil
+	}
+
+	t.metrics.filesCompleted.Inc(1)
+	t.log.Debug("commit log tailer completed file",
+		zap.String("filePath", file.FilePath),
+		zap.Int64("entries", t.Position().Entry))
+
+	t.setPosition(TailerPosition{Index: next.Index})
+	t.lastSize = -1
+	return true, nil
+}
+
+// drain streams the writes in the file from the position to the end of the
+// data that has been flushed to it so far.
+func (t *tailer) drain(
+	file persist.CommitLogFile,
+	position TailerPosition,
+	fn TailerConsumeFn,
+) error {
+	// The reader is opened from the start of the file each time as the series
+	// metadata for a write is only encoded alongside the first write to the
+	// series in each file.
+	reader := newCommitLogReader(commitLogReaderOptions{
+		commitLogOptions: t.opts,
+		seriesPredicate:  t.seriesPredicate,
+	})
+	index, err := reader.Open(file.FilePath)
+	if err != nil {
+		if isTailerEndOfData(err) {
+			return nil
+		}
+		return err
+	}
+	defer reader.Close()
+
+	if index != file.Index {
+		return errIndexDoesNotMatch
+	}
+
+	for i := int64(0); i < position.Entry; i++ {
+		if _, _, _, _, err := reader.Read(); err != nil {
+			if isTailerEndOfData(err) {
+				return nil
+			}
+			t.metrics.readsErrors.Inc(1)
+			return err
+		}
+	}
+
+	for !t.isClosed() {
+		series, datapoint, unit, annotation, err := reader.Read()
+		if isTailerEndOfData(err) {
+			return nil
+		}
+		if err != nil {
+			t.metrics.readsErrors.Inc(1)
+			return err
+		}
+
+		write := TailerWrite{
+			Series:     series,
+			Datapoint:  datapoint,
+			Unit:       unit,
+			Annotation: annotation,
+			Position:   position,
+		}
+		if err := fn(write); err != nil {
+			return err
+		}
+
+		t.metrics.writes.Inc(1)
+		position = position.Next()
+		t.setPosition(position)
+	}
+
+	return nil
+}
+
+// isTailerEndOfData returns whether the error marks the end of the data that
+// has been flushed to a commit log file, which may end with a partial chunk
+// that is still being written.
+func isTailerEndOfData(err error) bool {
+	return err == io.EOF || err == io.ErrUnexpectedEOF
+}
+
+func tailerFileWithIndex(
+	files persist.CommitLogFiles,
+	index int64,
+) (persist.CommitLogFile, bool) {
+	for _, file := range files {
+		if file.Index == index {
+			return file, true
+		}
+	}
+	return persist.CommitLogFile{}, false
+}
+
+func tailerNextFile(
+	files persist.CommitLogFiles,
+	index int64,
+) (persist.CommitLogFile, bool) {
+	// Files are sorted by index.
+	for _, file := range files {
+		if file.Index > index {
+			return file, true
+		}
+	}
+	return persist.CommitLogFile{}, false
+}
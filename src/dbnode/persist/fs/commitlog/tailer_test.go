@@ -0,0 +1,193 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package commitlog
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type tailerTestConsumer struct {
+	sync.Mutex
+	writes []TailerWrite
+}
+
+func (c *tailerTestConsumer) consume(write TailerWrite) error {
+	c.Lock()
+	c.writes = append(c.writes, write)
+	c.Unlock()
+	return nil
+}
+
+func (c *tailerTestConsumer) waitForWrites(t *testing.T, n int) []TailerWrite {
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		c.Lock()
+		if len(c.writes) >= n {
+			writes := append([]TailerWrite(nil), c.writes...)
+			c.Unlock()
+			return writes
+		}
+		c.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.FailNow(t, "timed out waiting for tailed writes")
+	return nil
+}
+
+func startTestTailer(tailer Tailer, fn TailerConsumeFn) chan error {
+	doneCh := make(chan error, 1)
+	go func() {
+		doneCh <- tailer.Tail(fn)
+	}()
+	return doneCh
+}
+
+func TestTailerStreamsWritesAcrossRotations(t *testing.T) {
+	opts, scope := newTestOptions(t, overrides{
+		strategy: StrategyWriteWait,
+	})
+	defer cleanup(t, opts)
+
+	commitLog := newTestCommitLog(t, opts)
+	defer commitLog.Close()
+
+	tailer, err := NewTailer(TailerOpts{
+		CommitLogOptions: opts,
+		CommitLog:        commitLog,
+		PollInterval:     10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	var consumer tailerTestConsumer
+	doneCh := startTestTailer(tailer, consumer.consume)
+
+	now := time.Now()
+	writes := []testWrite{
+		{testSeries(0, "foo.bar", testTags1, 127), now, 123.456, xtime.Second, []byte{1, 2, 3}, nil},
+		{testSeries(1, "foo.baz", testTags2, 150), now, 456.789, xtime.Second, nil, nil},
+		{testSeries(2, "foo.qux", testTags3, 291), now, 789.123, xtime.Second, nil, nil},
+	}
+
+	writeCommitLogs(t, scope, commitLog, writes[:2]).Wait()
+	tailed := consumer.waitForWrites(t, 2)
+
+	_, err = commitLog.RotateLogs()
+	require.NoError(t, err)
+
+	writeCommitLogs(t, scope, commitLog, writes[2:]).Wait()
+	tailed = consumer.waitForWrites(t, 3)
+	require.Equal(t, len(writes), len(tailed))
+
+	for i, write := range writes {
+		write.assert(t, tailed[i].Series, tailed[i].Datapoint, tailed[i].Unit, tailed[i].Annotation)
+	}
+	require.Equal(t, TailerPosition{Index: 0, Entry: 0}, tailed[0].Position)
+	require.Equal(t, TailerPosition{Index: 0, Entry: 1}, tailed[1].Position)
+	require.Equal(t, TailerPosition{Index: 1, Entry: 0}, tailed[2].Position)
+	require.Equal(t, tailed[2].Position.Next(), tailer.Position())
+
+	require.NoError(t, tailer.Close())
+	require.NoError(t, <-doneCh)
+}
+
+func TestTailerResumesFromPosition(t *testing.T) {
+	opts, scope := newTestOptions(t, overrides{
+		strategy: StrategyWriteWait,
+	})
+	defer cleanup(t, opts)
+
+	commitLog := newTestCommitLog(t, opts)
+	defer commitLog.Close()
+
+	now := time.Now()
+	writes := []testWrite{
+		{testSeries(0, "foo.bar", testTags1, 127), now, 123.456, xtime.Second, nil, nil},
+		{testSeries(1, "foo.baz", testTags2, 150), now, 456.789, xtime.Second, nil, nil},
+		{testSeries(0, "foo.bar", testTags1, 127), now.Add(time.Second), 789.123, xtime.Second, nil, nil},
+	}
+	writeCommitLogs(t, scope, commitLog, writes).Wait()
+
+	tailer, err := NewTailer(TailerOpts{
+		CommitLogOptions: opts,
+		CommitLog:        commitLog,
+		SeriesFilterPredicate: func(id ident.ID, namespace ident.ID) bool {
+			return id.String() == "foo.bar"
+		},
+		StartPosition: TailerPosition{Index: 0, Entry: 1},
+		PollInterval:  10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	var consumer tailerTestConsumer
+	doneCh := startTestTailer(tailer, consumer.consume)
+
+	tailed := consumer.waitForWrites(t, 1)
+	require.Equal(t, 1, len(tailed))
+	writes[2].assert(t, tailed[0].Series, tailed[0].Datapoint, tailed[0].Unit, tailed[0].Annotation)
+	require.Equal(t, TailerPosition{Index: 0, Entry: 1}, tailed[0].Position)
+
+	require.NoError(t, tailer.Close())
+	require.NoError(t, <-doneCh)
+}
+
+func TestTailerStopsOnConsumeError(t *testing.T) {
+	opts, scope := newTestOptions(t, overrides{
+		strategy: StrategyWriteWait,
+	})
+	defer cleanup(t, opts)
+
+	commitLog := newTestCommitLog(t, opts)
+	defer commitLog.Close()
+
+	writes := []testWrite{
+		{testSeries(0, "foo.bar", testTags1, 127), time.Now(), 123.456, xtime.Second, nil, nil},
+	}
+	writeCommitLogs(t, scope, commitLog, writes).Wait()
+
+	tailer, err := NewTailer(TailerOpts{
+		CommitLogOptions: opts,
+		CommitLog:        commitLog,
+		PollInterval:     10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	consumeErr := errors.New("consume error")
+	err = tailer.Tail(func(write TailerWrite) error {
+		return consumeErr
+	})
+	require.Equal(t, consumeErr, err)
+
+	// The write was not acknowledged so it is streamed again.
+	require.Equal(t, TailerPosition{Index: 0, Entry: 0}, tailer.Position())
+	require.NoError(t, tailer.Close())
+}
+
+func TestNewTailerRequiresCommitLog(t *testing.T) {
+	_, err := NewTailer(TailerOpts{CommitLogOptions: testOpts})
+	require.Equal(t, errTailerCommitLogNotSet, err)
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/x/context"
 	"github.com/m3db/m3/src/x/ident"
@@ -95,6 +96,10 @@ type Iterator interface {
 	// Err returns an error if an error occurred
 	Err() error
 
+	// CorruptionReports returns the corrupt ranges of the files read so far
+	// that were skipped, only populated when recovering from corruption.
+	CorruptionReports() []CorruptionReport
+
 	// Close the iterator
 	Close()
 }
@@ -104,6 +109,107 @@ type IteratorOpts struct {
 	CommitLogOptions      Options
 	FileFilterPredicate   FileFilterPredicate
 	SeriesFilterPredicate SeriesFilterPredicate
+	// RecoverFromCorruption specifies whether to skip corrupt chunks in commit
+	// log files, continuing from the next valid chunk and reporting the range
+	// that was skipped, rather than stopping with an error.
+	RecoverFromCorruption bool
+	// ReadFilter restricts the writes read to those matching the filter,
+	// whole sections of files that contain no matching writes are skipped
+	// using the index in the footer of the file.
+	ReadFilter ReadFilter
+}
+
+// ReadFilter restricts the writes read from commit log files to those for a
+// set of namespaces and shards with timestamps in the range [Start, End).
+// Empty namespaces or shards and zero start or end times are not filtered on.
+type ReadFilter struct {
+	Namespaces []ident.ID
+	Shards     []uint32
+	Start      time.Time
+	End        time.Time
+}
+
+// CorruptionReport describes a range of a commit log file that was skipped
+// since it was corrupt.
+type CorruptionReport struct {
+	// FilePath is the path of the commit log file.
+	FilePath string
+	// StartOffset is the offset in the file of the start of the skipped range.
+	StartOffset int64
+	// EndOffset is the offset in the file of the end of the skipped range.
+	EndOffset int64
+	// Err is the error encountered at the start of the skipped range.
+	Err error
+	// After is the timestamp of the last entry read before the skipped
+	// range, zero if the range is at the start of the file.
+	After time.Time
+	// Before is the timestamp of the first entry read after the skipped
+	// range, zero if the range extends to the end of the file.
+	Before time.Time
+	// EntriesWithoutMetadata is the number of entries read after the skipped
+	// range that were dropped since their series metadata was in the range.
+	EntriesWithoutMetadata int
+}
+
+// TailerOpts is a struct that contains the options for the Tailer.
+type TailerOpts struct {
+	CommitLogOptions Options
+	// CommitLog is the commit log being tailed, it is used to determine
+	// which commit log files are still being written to.
+	CommitLog CommitLog
+	// SeriesFilterPredicate selects the series to stream, the same predicate
+	// must be used when resuming from a previously returned position.
+	SeriesFilterPredicate SeriesFilterPredicate
+	// StartPosition is the position of the first write to stream, the zero
+	// value starts from the oldest commit log file on disk.
+	StartPosition TailerPosition
+	// PollInterval is how often the active commit log file is checked for
+	// new writes once all the writes in it have been streamed.
+	PollInterval time.Duration
+}
+
+// TailerPosition identifies a write in the commit logs.
+type TailerPosition struct {
+	// Index is the index of the commit log file containing the write.
+	Index int64
+	// Entry is the number of writes that precede the write in the
+	// commit log file.
+	Entry int64
+}
+
+// Next returns the position of the write following the write at this position,
+// this is the position to resume tailing from once the write has been consumed.
+func (p TailerPosition) Next() TailerPosition {
+	return TailerPosition{Index: p.Index, Entry: p.Entry + 1}
+}
+
+// TailerWrite is a write streamed by the Tailer.
+type TailerWrite struct {
+	Series     ts.Series
+	Datapoint  ts.Datapoint
+	Unit       xtime.Unit
+	Annotation ts.Annotation
+	Position   TailerPosition
+}
+
+// TailerConsumeFn consumes a write streamed by the Tailer, if it returns an
+// error the write is not acknowledged and tailing stops.
+type TailerConsumeFn func(write TailerWrite) error
+
+// Tailer streams the writes to the commit logs as they are written, following
+// the active commit log file across rotations.
+type Tailer interface {
+	// Tail streams writes in commit log order to the consume function, it blocks
+	// until the tailer is closed or the consume function returns an error. Writes
+	// are delivered at least once: a consumer that restarts from the position
+	// following the last write it durably processed will not miss any writes.
+	Tail(fn TailerConsumeFn) error
+
+	// Position returns the position of the next write to be streamed.
+	Position() TailerPosition
+
+	// Close stops the tailer.
+	Close() error
 }
 
 // Options represents the options for the commit log.
@@ -173,17 +279,35 @@ type Options interface {
 	// BytesPool returns the checked bytes pool.
 	BytesPool() pool.CheckedBytesPool
 
-	// SetReadConcurrency sets the concurrency of the reader.
+	// SetReadConcurrency sets the number of commit log files the
+	// iterator decodes concurrently.
 	SetReadConcurrency(concurrency int) Options
 
-	// ReadConcurrency returns the concurrency of the reader.
+	// ReadConcurrency returns the number of commit log files the
+	// iterator decodes concurrently.
 	ReadConcurrency() int
 
+	// SetReadBufferBytes sets the estimated bytes of entries the iterator
+	// buffers ahead of its consumer across the files it decodes concurrently.
+	SetReadBufferBytes(value int64) Options
+
+	// ReadBufferBytes returns the estimated bytes of entries the iterator
+	// buffers ahead of its consumer across the files it decodes concurrently.
+	ReadBufferBytes() int64
+
 	// SetIdentifierPool sets the IdentifierPool to use for pooling identifiers.
 	SetIdentifierPool(value ident.Pool) Options
 
 	// IdentifierPool returns the IdentifierPool to use for pooling identifiers.
 	IdentifierPool() ident.Pool
+
+	// SetRuntimeOptionsManager sets the runtime options manager, which supplies
+	// the per namespace write limits enforced by the commit log.
+	SetRuntimeOptionsManager(value runtime.OptionsManager) Options
+
+	// RuntimeOptionsManager returns the runtime options manager, which supplies
+	// the per namespace write limits enforced by the commit log.
+	RuntimeOptionsManager() runtime.OptionsManager
 }
 
 // FileFilterInfo contains information about a commitog file that can be used to
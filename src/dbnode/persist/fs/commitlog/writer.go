@@ -81,6 +81,10 @@ type commitLogWriter interface {
 	// a new chunk to be created. Optionally forces the data to be FSync'd to disk.
 	Flush(sync bool) error
 
+	// Size returns the number of bytes written to the commit log, including
+	// any bytes still buffered.
+	Size() int64
+
 	// Close the reader
 	Close() error
 }
@@ -92,6 +96,10 @@ type chunkWriter interface {
 	close() error
 	isOpen() bool
 	sync() error
+	// offset returns the offset in the file of the next chunk.
+	offset() int64
+	// writeFooter writes the footer after the last chunk.
+	writeFooter(p []byte) error
 }
 
 type flushFn func(err error)
@@ -111,6 +119,7 @@ type writer struct {
 	metadataEncoderBuff []byte
 	tagEncoder          serialize.TagEncoder
 	tagSliceIter        ident.TagsIterator
+	footer              *footerBuilder
 	opts                Options
 }
 
@@ -135,6 +144,7 @@ func newCommitLogWriter(
 		metadataEncoderBuff: make([]byte, 0, defaultEncoderBuffSize),
 		tagEncoder:          opts.FilesystemOptions().TagEncoderPool().Get(),
 		tagSliceIter:        ident.NewTagsIterator(ident.Tags{}),
+		footer:              newFooterBuilder(),
 		opts:                opts,
 	}
 }
@@ -176,6 +186,7 @@ func (w *writer) Open() (persist.CommitLogFile, error) {
 
 	w.chunkWriter.reset(fd)
 	w.buffer.Reset(w.chunkWriter)
+	w.footer.reset()
 	if err := w.write(w.logEncoder.Bytes()); err != nil {
 		w.Close()
 		return persist.CommitLogFile{}, err
@@ -254,6 +265,7 @@ func (w *writer) Write(
 	if err := w.write(w.logEncoderBuff); err != nil {
 		return err
 	}
+	w.footer.add(series.Namespace.Bytes(), series.Shard, logEntry.Timestamp, !seen)
 
 	if !seen {
 		// Record we have written this series and metadata to this commit log
@@ -275,6 +287,10 @@ func (w *writer) Flush(sync bool) error {
 	return w.sync()
 }
 
+func (w *writer) Size() int64 {
+	return w.chunkWriter.offset() + int64(w.buffer.Buffered())
+}
+
 func (w *writer) sync() error {
 	return w.chunkWriter.sync()
 }
@@ -284,7 +300,13 @@ func (w *writer) Close() error {
 		return nil
 	}
 
-	if err := w.Flush(true); err != nil {
+	if err := w.Flush(false); err != nil {
+		return err
+	}
+	if err := w.chunkWriter.writeFooter(w.footer.encode()); err != nil {
+		return err
+	}
+	if err := w.sync(); err != nil {
 		return err
 	}
 	if err := w.chunkWriter.close(); err != nil {
@@ -308,6 +330,11 @@ func (w *writer) write(data []byte) error {
 		return w.write(data)
 	}
 
+	if w.buffer.Buffered() == 0 {
+		// The entry starts a new chunk so can start a new footer section.
+		w.footer.maybeStartSection(w.chunkWriter.offset())
+	}
+
 	// Write size and then data
 	if _, err := w.buffer.Write(w.sizeBuffer[:sizeLen]); err != nil {
 		return err
@@ -321,6 +348,7 @@ type fsChunkWriter struct {
 	flushFn flushFn
 	buff    []byte
 	fsync   bool
+	written int64
 }
 
 func newChunkWriter(flushFn flushFn, fsync bool) chunkWriter {
@@ -333,6 +361,7 @@ func newChunkWriter(flushFn flushFn, fsync bool) chunkWriter {
 
 func (w *fsChunkWriter) reset(f xos.File) {
 	w.fd = f
+	w.written = 0
 }
 
 func (w *fsChunkWriter) close() error {
@@ -349,6 +378,16 @@ func (w *fsChunkWriter) sync() error {
 	return w.fd.Sync()
 }
 
+func (w *fsChunkWriter) offset() int64 {
+	return w.written
+}
+
+func (w *fsChunkWriter) writeFooter(p []byte) error {
+	n, err := w.fd.Write(p)
+	w.written += int64(n)
+	return err
+}
+
 func (w *fsChunkWriter) Write(p []byte) (int, error) {
 	size := len(p)
 
@@ -379,6 +418,7 @@ func (w *fsChunkWriter) Write(p []byte) (int, error) {
 
 	// Write contents to file descriptor
 	n, err := w.fd.Write(w.buff)
+	w.written += int64(n)
 	if err != nil {
 		w.flushFn(err)
 		return n, err
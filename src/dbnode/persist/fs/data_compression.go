@@ -0,0 +1,117 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/pool"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// minZstdCompressionLevel is the lowest (fastest) supported zstd level.
+	minZstdCompressionLevel = 1
+	// maxZstdCompressionLevel is the highest (smallest output) supported zstd level.
+	maxZstdCompressionLevel = 22
+)
+
+var (
+	errZstdCompressionLevelInvalid = fmt.Errorf(
+		"zstd compression level must be between %d and %d",
+		minZstdCompressionLevel, maxZstdCompressionLevel)
+
+	errDataSegmentDecompressNotSupported = errors.New(
+		"data segment compression type not supported")
+)
+
+var (
+	// The zstd decoder is safe to use concurrently via DecodeAll so a
+	// single lazily created decoder is shared by all readers and seekers.
+	zstdDecoderOnce sync.Once
+	zstdDecoder     *zstd.Decoder
+	zstdDecoderErr  error
+
+	// Scratch buffers for compressed and decompressed data segments.
+	dataSegmentBufPool = sync.Pool{
+		New: func() interface{} {
+			return new([]byte)
+		},
+	}
+)
+
+func newZstdEncoder(level int) (*zstd.Encoder, error) {
+	return zstd.NewWriter(nil,
+		zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)),
+		zstd.WithEncoderConcurrency(1))
+}
+
+func sharedZstdDecoder() (*zstd.Decoder, error) {
+	zstdDecoderOnce.Do(func() {
+		zstdDecoder, zstdDecoderErr = zstd.NewReader(nil)
+	})
+	return zstdDecoder, zstdDecoderErr
+}
+
+// decompressDataSegment decompresses a data segment that was written with
+// the given compression, the result is taken from the bytes pool if set.
+func decompressDataSegment(
+	compression persist.DataCompressionType,
+	compressed []byte,
+	bytesPool pool.CheckedBytesPool,
+) (checked.Bytes, error) {
+	if compression != persist.ZstdDataCompression {
+		return nil, errDataSegmentDecompressNotSupported
+	}
+
+	decoder, err := sharedZstdDecoder()
+	if err != nil {
+		return nil, err
+	}
+
+	bufPtr := dataSegmentBufPool.Get().(*[]byte)
+	defer dataSegmentBufPool.Put(bufPtr)
+
+	decompressed, err := decoder.DecodeAll(compressed, (*bufPtr)[:0])
+	if err != nil {
+		return nil, err
+	}
+	// Keep the grown buffer around for the next decompression.
+	*bufPtr = decompressed
+
+	var result checked.Bytes
+	if bytesPool != nil {
+		result = bytesPool.Get(len(decompressed))
+		result.IncRef()
+		result.Resize(len(decompressed))
+	} else {
+		result = checked.NewBytes(make([]byte, len(decompressed)), nil)
+		result.IncRef()
+	}
+	copy(result.Bytes(), decompressed)
+	result.DecRef()
+	return result, nil
+}
@@ -57,6 +57,7 @@ const (
 	indexDirName      = "index"
 	snapshotDirName   = "snapshots"
 	commitLogsDirName = "commitlogs"
+	bootstrapDirName  = "bootstrap"
 
 	// The maximum number of delimeters ('-' or '.') that is expected in a
 	// (base) filename.
@@ -841,11 +842,32 @@ func ReadIndexInfoFiles(
 	filePathPrefix string,
 	namespace ident.ID,
 	readerBufferSize int,
+) []ReadIndexInfoFileResult {
+	return readIndexInfoFiles(persist.FileSetFlushType, filePathPrefix,
+		namespace, readerBufferSize)
+}
+
+// ReadIndexSnapshotInfoFiles reads all the valid index snapshot info entries,
+// only snapshots with a complete checkpoint file are returned.
+func ReadIndexSnapshotInfoFiles(
+	filePathPrefix string,
+	namespace ident.ID,
+	readerBufferSize int,
+) []ReadIndexInfoFileResult {
+	return readIndexInfoFiles(persist.FileSetSnapshotType, filePathPrefix,
+		namespace, readerBufferSize)
+}
+
+func readIndexInfoFiles(
+	fileSetType persist.FileSetType,
+	filePathPrefix string,
+	namespace ident.ID,
+	readerBufferSize int,
 ) []ReadIndexInfoFileResult {
 	var infoFileResults []ReadIndexInfoFileResult
 	forEachInfoFile(
 		forEachInfoFileSelector{
-			fileSetType:    persist.FileSetFlushType,
+			fileSetType:    fileSetType,
 			contentType:    persist.FileSetIndexContentType,
 			filePathPrefix: filePathPrefix,
 			namespace:      namespace,
@@ -966,6 +988,18 @@ func SnapshotFiles(filePathPrefix string, namespace ident.ID, shard uint32) (Fil
 	})
 }
 
+// IndexFiles returns a slice of all the names for all the index fileset files
+// for a given namespace.
+func IndexFiles(filePathPrefix string, namespace ident.ID) (FileSetFilesSlice, error) {
+	return filesetFiles(filesetFilesSelector{
+		fileSetType:    persist.FileSetFlushType,
+		contentType:    persist.FileSetIndexContentType,
+		filePathPrefix: filePathPrefix,
+		namespace:      namespace,
+		pattern:        filesetFilePattern,
+	})
+}
+
 // IndexSnapshotFiles returns a slice of all the names for all the index fileset files
 // for a given namespace.
 func IndexSnapshotFiles(filePathPrefix string, namespace ident.ID) (FileSetFilesSlice, error) {
@@ -1486,11 +1520,12 @@ func NextIndexSnapshotFileIndex(filePathPrefix string, namespace ident.ID, block
 		return -1, err
 	}
 
+	// Incomplete snapshots count too so that they are never overwritten.
 	var currentSnapshotIndex = -1
 	for _, snapshot := range snapshotFiles {
-		if snapshot.ID.BlockStart.Equal(blockStart) {
+		if snapshot.ID.BlockStart.Equal(blockStart) &&
+			snapshot.ID.VolumeIndex > currentSnapshotIndex {
 			currentSnapshotIndex = snapshot.ID.VolumeIndex
-			break
 		}
 	}
 
@@ -788,6 +788,33 @@ func TestNextIndexFileSetVolumeIndex(t *testing.T) {
 	}
 }
 
+func TestNextIndexSnapshotFileIndex(t *testing.T) {
+	// Make empty directory
+	dir := createTempDir(t)
+	snapshotDir := NamespaceIndexSnapshotDirPath(dir, testNs1ID)
+	require.NoError(t, os.MkdirAll(snapshotDir, 0755))
+	defer os.RemoveAll(dir)
+
+	blockStart := time.Now().Truncate(time.Hour)
+
+	// Check increments properly past every existing volume, including the
+	// ones without a checkpoint file.
+	curr := -1
+	for i := 0; i <= 10; i++ {
+		index, err := NextIndexSnapshotFileIndex(dir, testNs1ID, blockStart)
+		require.NoError(t, err)
+		require.Equal(t, curr+1, index)
+		curr = index
+
+		suffix := checkpointFileSuffix
+		if i%2 == 1 {
+			suffix = infoFileSuffix
+		}
+		p := filesetPathFromTimeAndIndex(snapshotDir, blockStart, index, suffix)
+		require.NoError(t, ioutil.WriteFile(p, []byte("bar"), defaultNewFileMode))
+	}
+}
+
 func TestMultipleForBlockStart(t *testing.T) {
 	numSnapshots := 20
 	numSnapshotsPerBlock := 4
@@ -1083,6 +1110,45 @@ func TestIndexFileSetsBefore(t *testing.T) {
 	}
 }
 
+func TestIndexFiles(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	var (
+		ns1     = ident.StringID("abc")
+		now     = time.Now().Truncate(time.Hour)
+		timeFor = func(n int) time.Time { return now.Add(time.Hour * time.Duration(n)) }
+	)
+
+	files := indexFileSetFileIdentifiers{
+		indexFileSetFileIdentifier{
+			FileSetFileIdentifier: FileSetFileIdentifier{
+				BlockStart:         timeFor(1),
+				Namespace:          ns1,
+				VolumeIndex:        0,
+				FileSetContentType: persist.FileSetIndexContentType,
+			},
+			Suffix: checkpointFileSuffix,
+		},
+		indexFileSetFileIdentifier{
+			FileSetFileIdentifier: FileSetFileIdentifier{
+				BlockStart:         timeFor(2),
+				Namespace:          ns1,
+				VolumeIndex:        0,
+				FileSetContentType: persist.FileSetIndexContentType,
+			},
+			Suffix: checkpointFileSuffix,
+		},
+	}
+	files.create(t, dir)
+
+	results, err := IndexFiles(dir, ns1)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.True(t, timeFor(1).Equal(results[0].ID.BlockStart))
+	require.True(t, timeFor(2).Equal(results[1].ID.BlockStart))
+}
+
 func TestSnapshotFileSnapshotTimeAndID(t *testing.T) {
 	var (
 		dir            = createTempDir(t)
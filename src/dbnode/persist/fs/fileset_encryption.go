@@ -0,0 +1,463 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+const (
+	// encryptionChunkSize is the size of the plaintext chunks that fileset
+	// files are encrypted in, each chunk is sealed separately so that random
+	// reads only need to decrypt the chunks they touch.
+	encryptionChunkSize = 4096
+
+	// encryptionTagSize is the size of the authentication tag appended
+	// to each encrypted chunk.
+	encryptionTagSize = 16
+
+	// encryptedChunkSize is the size of a full encrypted chunk on disk.
+	encryptedChunkSize = encryptionChunkSize + encryptionTagSize
+
+	// encryptionNonceSize is the size of the nonce of each chunk which is
+	// made up of the file type followed by the chunk index.
+	encryptionNonceSize = 12
+
+	// dataKeySize is the size of the per volume data keys, AES-256 is used.
+	dataKeySize = 32
+)
+
+// encryptedFileType distinguishes the files of a volume that are encrypted
+// with the same data key so that their chunks never share a nonce.
+type encryptedFileType uint32
+
+const (
+	encryptedIndexFile encryptedFileType = iota + 1
+	encryptedSummariesFile
+	encryptedDataFile
+)
+
+var (
+	errDataKeyWrapperNotSet = errors.New(
+		"fileset is encrypted but no data key wrapper is set")
+
+	errEncryptedFileTruncated = errors.New(
+		"encrypted fileset file is truncated")
+
+	errDataKeyWrapperKeyIDMismatch = errors.New(
+		"data key was wrapped with a different key ID")
+
+	errWrappedDataKeyTooShort = errors.New(
+		"wrapped data key is too short")
+
+	// Scratch buffers for a single encrypted chunk and its plaintext used
+	// by concurrent random reads of encrypted files.
+	encryptedChunkBufPool = sync.Pool{
+		New: func() interface{} {
+			b := make([]byte, encryptedChunkSize+encryptionChunkSize)
+			return &b
+		},
+	}
+)
+
+// filesetCipher encrypts and decrypts the chunks of the files of a single
+// volume with the volume's data key, it is safe for concurrent use.
+type filesetCipher struct {
+	aead cipher.AEAD
+}
+
+func newFilesetCipher(dataKey []byte) (*filesetCipher, error) {
+	aead, err := newAESGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return &filesetCipher{aead: aead}, nil
+}
+
+// newDataKey returns a new random data key and a cipher using it.
+func newDataKey() ([]byte, *filesetCipher, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, nil, err
+	}
+	c, err := newFilesetCipher(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dataKey, c, nil
+}
+
+// newFilesetCipherFromInfo returns the cipher for a volume with the wrapped
+// data key recorded in its info file, or nil if the volume is not encrypted.
+func newFilesetCipherFromInfo(
+	wrapper DataKeyWrapper,
+	keyID []byte,
+	wrappedDataKey []byte,
+) (*filesetCipher, error) {
+	if len(wrappedDataKey) == 0 {
+		return nil, nil
+	}
+	if wrapper == nil {
+		return nil, errDataKeyWrapperNotSet
+	}
+	dataKey, err := wrapper.UnwrapKey(string(keyID), wrappedDataKey)
+	if err != nil {
+		return nil, fmt.Errorf("could not unwrap fileset data key: %v", err)
+	}
+	return newFilesetCipher(dataKey)
+}
+
+func (c *filesetCipher) nonce(
+	buf *[encryptionNonceSize]byte,
+	fileType encryptedFileType,
+	chunk int64,
+) []byte {
+	binary.BigEndian.PutUint32(buf[:4], uint32(fileType))
+	binary.BigEndian.PutUint64(buf[4:], uint64(chunk))
+	return buf[:]
+}
+
+func (c *filesetCipher) sealChunk(
+	dst []byte,
+	plaintext []byte,
+	fileType encryptedFileType,
+	chunk int64,
+) []byte {
+	var nonce [encryptionNonceSize]byte
+	return c.aead.Seal(dst, c.nonce(&nonce, fileType, chunk), plaintext, nil)
+}
+
+func (c *filesetCipher) openChunk(
+	dst []byte,
+	ciphertext []byte,
+	fileType encryptedFileType,
+	chunk int64,
+) ([]byte, error) {
+	var nonce [encryptionNonceSize]byte
+	return c.aead.Open(dst, c.nonce(&nonce, fileType, chunk), ciphertext, nil)
+}
+
+// decryptAll decrypts the entire contents of an encrypted file appending
+// the plaintext to dst.
+func (c *filesetCipher) decryptAll(
+	dst []byte,
+	ciphertext []byte,
+	fileType encryptedFileType,
+) ([]byte, error) {
+	var err error
+	for chunk := int64(0); len(ciphertext) > 0; chunk++ {
+		end := encryptedChunkSize
+		if len(ciphertext) < end {
+			end = len(ciphertext)
+		}
+		dst, err = c.openChunk(dst, ciphertext[:end], fileType, chunk)
+		if err != nil {
+			return nil, err
+		}
+		ciphertext = ciphertext[end:]
+	}
+	return dst, nil
+}
+
+// decryptedFileSize returns the size of the plaintext of an encrypted file.
+func decryptedFileSize(encryptedSize int64) (int64, error) {
+	var (
+		fullChunks = encryptedSize / encryptedChunkSize
+		remaining  = encryptedSize % encryptedChunkSize
+		size       = fullChunks * encryptionChunkSize
+	)
+	if remaining == 0 {
+		return size, nil
+	}
+	if remaining <= encryptionTagSize {
+		return 0, errEncryptedFileTruncated
+	}
+	return size + remaining - encryptionTagSize, nil
+}
+
+// encryptingWriter buffers plaintext and writes it to the underlying
+// writer as encrypted chunks, flush must be called to write the last chunk.
+type encryptingWriter struct {
+	cipher   *filesetCipher
+	fileType encryptedFileType
+	writer   io.Writer
+	chunk    int64
+	buf      []byte
+	sealed   []byte
+}
+
+func newEncryptingWriter() *encryptingWriter {
+	return &encryptingWriter{
+		buf:    make([]byte, 0, encryptionChunkSize),
+		sealed: make([]byte, 0, encryptedChunkSize),
+	}
+}
+
+func (w *encryptingWriter) reset(
+	c *filesetCipher,
+	fileType encryptedFileType,
+	writer io.Writer,
+) {
+	w.cipher = c
+	w.fileType = fileType
+	w.writer = writer
+	w.chunk = 0
+	w.buf = w.buf[:0]
+}
+
+func (w *encryptingWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := copy(w.buf[len(w.buf):encryptionChunkSize], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+		if len(w.buf) == encryptionChunkSize {
+			if err := w.flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+func (w *encryptingWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	w.sealed = w.cipher.sealChunk(w.sealed[:0], w.buf, w.fileType, w.chunk)
+	if _, err := w.writer.Write(w.sealed); err != nil {
+		return err
+	}
+	w.chunk++
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// decryptingReader reads and decrypts an encrypted file sequentially.
+type decryptingReader struct {
+	cipher   *filesetCipher
+	fileType encryptedFileType
+	reader   io.Reader
+	chunk    int64
+	sealed   []byte
+	plain    []byte
+	pos      int
+}
+
+func newDecryptingReader() *decryptingReader {
+	return &decryptingReader{
+		sealed: make([]byte, encryptedChunkSize),
+		plain:  make([]byte, 0, encryptionChunkSize),
+	}
+}
+
+func (r *decryptingReader) reset(
+	c *filesetCipher,
+	fileType encryptedFileType,
+	reader io.Reader,
+) {
+	r.cipher = c
+	r.fileType = fileType
+	r.reader = reader
+	r.chunk = 0
+	r.plain = r.plain[:0]
+	r.pos = 0
+}
+
+func (r *decryptingReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if r.pos == len(r.plain) {
+			if err := r.readChunk(); err != nil {
+				if err == io.EOF && n > 0 {
+					return n, nil
+				}
+				return n, err
+			}
+		}
+		copied := copy(p[n:], r.plain[r.pos:])
+		r.pos += copied
+		n += copied
+	}
+	return n, nil
+}
+
+func (r *decryptingReader) readChunk() error {
+	n, err := io.ReadFull(r.reader, r.sealed)
+	if err == io.ErrUnexpectedEOF {
+		// The last chunk of a file is allowed to be short.
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	r.plain, err = r.cipher.openChunk(r.plain[:0], r.sealed[:n], r.fileType, r.chunk)
+	if err != nil {
+		return err
+	}
+	r.chunk++
+	r.pos = 0
+	return nil
+}
+
+// decryptingReaderAt decrypts the chunks touched by each read of an
+// encrypted file, it is safe for concurrent use so that it can be shared
+// by a seeker and its clones like the file descriptor it wraps.
+type decryptingReaderAt struct {
+	cipher   *filesetCipher
+	fileType encryptedFileType
+	reader   io.ReaderAt
+	size     int64
+}
+
+func newDecryptingReaderAt(
+	c *filesetCipher,
+	fileType encryptedFileType,
+	reader io.ReaderAt,
+	encryptedSize int64,
+) (*decryptingReaderAt, error) {
+	size, err := decryptedFileSize(encryptedSize)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptingReaderAt{
+		cipher:   c,
+		fileType: fileType,
+		reader:   reader,
+		size:     size,
+	}, nil
+}
+
+func (r *decryptingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.size {
+		return 0, io.EOF
+	}
+
+	bufPtr := encryptedChunkBufPool.Get().(*[]byte)
+	defer encryptedChunkBufPool.Put(bufPtr)
+	var (
+		sealedBuf = (*bufPtr)[:encryptedChunkSize]
+		plainBuf  = (*bufPtr)[encryptedChunkSize:encryptedChunkSize]
+		n         int
+	)
+	for n < len(p) && off < r.size {
+		chunk := off / encryptionChunkSize
+		read, err := r.reader.ReadAt(sealedBuf, chunk*encryptedChunkSize)
+		if err != nil && err != io.EOF {
+			return n, err
+		}
+		plain, err := r.cipher.openChunk(plainBuf, sealedBuf[:read], r.fileType, chunk)
+		if err != nil {
+			return n, err
+		}
+		withinChunk := int(off - chunk*encryptionChunkSize)
+		if withinChunk >= len(plain) {
+			return n, errEncryptedFileTruncated
+		}
+		copied := copy(p[n:], plain[withinChunk:])
+		n += copied
+		off += int64(copied)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// staticDataKeyWrapper wraps data keys with AES-GCM using a single key
+// encryption key that is provided up front, e.g. read from a key file
+// that is provisioned by an external key management system.
+type staticDataKeyWrapper struct {
+	keyID string
+	aead  cipher.AEAD
+}
+
+// NewStaticDataKeyWrapper returns a data key wrapper that wraps data keys
+// with the given AES key encryption key which is identified by key ID.
+func NewStaticDataKeyWrapper(
+	keyID string,
+	keyEncryptionKey []byte,
+) (DataKeyWrapper, error) {
+	aead, err := newAESGCM(keyEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	return &staticDataKeyWrapper{keyID: keyID, aead: aead}, nil
+}
+
+// NewKeyFileDataKeyWrapper returns a static data key wrapper with the hex
+// encoded AES key encryption key read from the key file.
+func NewKeyFileDataKeyWrapper(
+	keyID string,
+	keyFilePath string,
+) (DataKeyWrapper, error) {
+	contents, err := ioutil.ReadFile(keyFilePath)
+	if err != nil {
+		return nil, err
+	}
+	keyEncryptionKey, err := hex.DecodeString(string(bytes.TrimSpace(contents)))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode key file %s: %v", keyFilePath, err)
+	}
+	return NewStaticDataKeyWrapper(keyID, keyEncryptionKey)
+}
+
+func (w *staticDataKeyWrapper) KeyID() string {
+	return w.keyID
+}
+
+func (w *staticDataKeyWrapper) WrapKey(dataKey []byte) ([]byte, error) {
+	nonce := make([]byte, w.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return w.aead.Seal(nonce, nonce, dataKey, []byte(w.keyID)), nil
+}
+
+func (w *staticDataKeyWrapper) UnwrapKey(keyID string, wrappedKey []byte) ([]byte, error) {
+	if keyID != w.keyID {
+		return nil, errDataKeyWrapperKeyIDMismatch
+	}
+	nonceSize := w.aead.NonceSize()
+	if len(wrappedKey) < nonceSize {
+		return nil, errWrappedDataKeyTooShort
+	}
+	return w.aead.Open(nil, wrappedKey[:nonceSize], wrappedKey[nonceSize:], []byte(keyID))
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
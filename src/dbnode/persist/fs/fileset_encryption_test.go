@@ -0,0 +1,221 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDataKeyWrapper(t *testing.T) DataKeyWrapper {
+	wrapper, err := NewStaticDataKeyWrapper("test-key",
+		bytes.Repeat([]byte{0x42}, dataKeySize))
+	require.NoError(t, err)
+	return wrapper
+}
+
+func newTestFilesetCipher(t *testing.T) *filesetCipher {
+	_, c, err := newDataKey()
+	require.NoError(t, err)
+	return c
+}
+
+func TestEncryptingWriterDecryptingReaderRoundTrip(t *testing.T) {
+	c := newTestFilesetCipher(t)
+	for _, size := range []int{
+		0, 1, encryptionChunkSize - 1, encryptionChunkSize,
+		encryptionChunkSize + 1, 3*encryptionChunkSize + 17,
+	} {
+		plaintext := make([]byte, size)
+		rand.Read(plaintext)
+
+		var (
+			encrypted bytes.Buffer
+			w         = newEncryptingWriter()
+		)
+		w.reset(c, encryptedDataFile, &encrypted)
+		// Write in uneven pieces to cross chunk boundaries mid write.
+		for remaining := plaintext; len(remaining) > 0; {
+			n := 1000
+			if len(remaining) < n {
+				n = len(remaining)
+			}
+			written, err := w.Write(remaining[:n])
+			require.NoError(t, err)
+			require.Equal(t, n, written)
+			remaining = remaining[n:]
+		}
+		require.NoError(t, w.flush())
+
+		decryptedSize, err := decryptedFileSize(int64(encrypted.Len()))
+		require.NoError(t, err)
+		require.Equal(t, int64(size), decryptedSize)
+
+		r := newDecryptingReader()
+		r.reset(c, encryptedDataFile, bytes.NewReader(encrypted.Bytes()))
+		decrypted, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, len(plaintext), len(decrypted))
+		require.True(t, bytes.Equal(plaintext, decrypted))
+
+		decrypted, err = c.decryptAll(nil, encrypted.Bytes(), encryptedDataFile)
+		require.NoError(t, err)
+		require.True(t, bytes.Equal(plaintext, decrypted))
+
+		// Chunks are bound to the file type they were written for.
+		if size > 0 {
+			_, err = c.decryptAll(nil, encrypted.Bytes(), encryptedIndexFile)
+			require.Error(t, err)
+		}
+	}
+}
+
+func TestDecryptingReaderAt(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	c := newTestFilesetCipher(t)
+	plaintext := make([]byte, 5*encryptionChunkSize+123)
+	rand.Read(plaintext)
+
+	var encrypted bytes.Buffer
+	w := newEncryptingWriter()
+	w.reset(c, encryptedIndexFile, &encrypted)
+	_, err := w.Write(plaintext)
+	require.NoError(t, err)
+	require.NoError(t, w.flush())
+
+	filePath := filepath.Join(dir, "encrypted")
+	require.NoError(t, ioutil.WriteFile(filePath, encrypted.Bytes(), 0644))
+	fd, err := os.Open(filePath)
+	require.NoError(t, err)
+	defer fd.Close()
+
+	r, err := newDecryptingReaderAt(c, encryptedIndexFile, fd, int64(encrypted.Len()))
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		offset int
+		length int
+	}{
+		{0, 10},
+		{encryptionChunkSize - 5, 10},
+		{encryptionChunkSize, encryptionChunkSize},
+		{100, 3 * encryptionChunkSize},
+		{len(plaintext) - 50, 50},
+	} {
+		buf := make([]byte, test.length)
+		n, err := r.ReadAt(buf, int64(test.offset))
+		require.NoError(t, err)
+		require.Equal(t, test.length, n)
+		require.Equal(t, plaintext[test.offset:test.offset+test.length], buf)
+	}
+
+	// Reads past the end return what is available and io.EOF.
+	buf := make([]byte, 100)
+	n, err := r.ReadAt(buf, int64(len(plaintext)-50))
+	require.Equal(t, io.EOF, err)
+	require.Equal(t, 50, n)
+
+	_, err = r.ReadAt(buf, int64(len(plaintext)))
+	require.Equal(t, io.EOF, err)
+}
+
+func TestDecryptingTamperedChunkFails(t *testing.T) {
+	c := newTestFilesetCipher(t)
+	plaintext := make([]byte, 2*encryptionChunkSize)
+
+	var encrypted bytes.Buffer
+	w := newEncryptingWriter()
+	w.reset(c, encryptedSummariesFile, &encrypted)
+	_, err := w.Write(plaintext)
+	require.NoError(t, err)
+	require.NoError(t, w.flush())
+
+	tampered := encrypted.Bytes()
+	tampered[encryptedChunkSize+10] ^= 0xff
+	_, err = c.decryptAll(nil, tampered, encryptedSummariesFile)
+	require.Error(t, err)
+}
+
+func TestDecryptedFileSizeTruncated(t *testing.T) {
+	_, err := decryptedFileSize(encryptedChunkSize + encryptionTagSize)
+	require.Equal(t, errEncryptedFileTruncated, err)
+}
+
+func TestStaticDataKeyWrapper(t *testing.T) {
+	wrapper := newTestDataKeyWrapper(t)
+	require.Equal(t, "test-key", wrapper.KeyID())
+
+	dataKey, _, err := newDataKey()
+	require.NoError(t, err)
+
+	wrapped, err := wrapper.WrapKey(dataKey)
+	require.NoError(t, err)
+	require.False(t, bytes.Contains(wrapped, dataKey))
+
+	unwrapped, err := wrapper.UnwrapKey("test-key", wrapped)
+	require.NoError(t, err)
+	require.Equal(t, dataKey, unwrapped)
+
+	_, err = wrapper.UnwrapKey("other-key", wrapped)
+	require.Equal(t, errDataKeyWrapperKeyIDMismatch, err)
+
+	_, err = wrapper.UnwrapKey("test-key", wrapped[:4])
+	require.Equal(t, errWrappedDataKeyTooShort, err)
+
+	// A different key encryption key cannot unwrap the data key.
+	other, err := NewStaticDataKeyWrapper("test-key",
+		bytes.Repeat([]byte{0x24}, dataKeySize))
+	require.NoError(t, err)
+	_, err = other.UnwrapKey("test-key", wrapped)
+	require.Error(t, err)
+}
+
+func TestKeyFileDataKeyWrapper(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	keyFilePath := filepath.Join(dir, "key")
+	require.NoError(t, ioutil.WriteFile(keyFilePath,
+		[]byte(" 4242424242424242424242424242424242424242424242424242424242424242\n"), 0600))
+
+	wrapper, err := NewKeyFileDataKeyWrapper("test-key", keyFilePath)
+	require.NoError(t, err)
+
+	// The key file holds the same key as the test data key wrapper.
+	wrapped, err := newTestDataKeyWrapper(t).WrapKey([]byte("data-key"))
+	require.NoError(t, err)
+	unwrapped, err := wrapper.UnwrapKey("test-key", wrapped)
+	require.NoError(t, err)
+	require.Equal(t, []byte("data-key"), unwrapped)
+
+	require.NoError(t, ioutil.WriteFile(keyFilePath, []byte("not hex"), 0600))
+	_, err = NewKeyFileDataKeyWrapper("test-key", keyFilePath)
+	require.Error(t, err)
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/pool"
+
+	"github.com/uber-go/tally"
+)
+
+// indexEntryCache is a fixed size, concurrency safe LRU cache of the index
+// entries of the most recently seeked IDs of a single fileset volume. It is
+// shared by the seeker and its clones so that repeated fetches of hot series
+// skip the index summaries search and the index file scan.
+type indexEntryCache struct {
+	sync.Mutex
+
+	size      int
+	evictList *list.List
+	items     map[string]*list.Element
+	metrics   indexEntryCacheMetrics
+}
+
+// indexEntryCacheEntry is used to hold a value in the evictList, the encoded
+// tags are copied out of the checked bytes of the seeked entry so that the
+// cache does not share ownership with the callers.
+type indexEntryCacheEntry struct {
+	id          string
+	size        uint32
+	checksum    uint32
+	offset      int64
+	encodedTags []byte
+}
+
+type indexEntryCacheMetrics struct {
+	hits      tally.Counter
+	misses    tally.Counter
+	evictions tally.Counter
+}
+
+func newIndexEntryCacheMetrics(scope tally.Scope) indexEntryCacheMetrics {
+	scope = scope.SubScope("index-entry-cache")
+	return indexEntryCacheMetrics{
+		hits:      scope.Counter("hits"),
+		misses:    scope.Counter("misses"),
+		evictions: scope.Counter("evictions"),
+	}
+}
+
+func newIndexEntryCache(size int, metrics indexEntryCacheMetrics) *indexEntryCache {
+	return &indexEntryCache{
+		size:      size,
+		evictList: list.New(),
+		items:     make(map[string]*list.Element, size),
+		metrics:   metrics,
+	}
+}
+
+// get returns the cached index entry for an ID, the encoded tags of the
+// returned entry are a copy owned by the caller.
+func (c *indexEntryCache) get(id []byte, bytesPool pool.CheckedBytesPool) (IndexEntry, bool) {
+	c.Lock()
+	elem, ok := c.items[string(id)]
+	if !ok {
+		c.Unlock()
+		c.metrics.misses.Inc(1)
+		return IndexEntry{}, false
+	}
+
+	c.evictList.MoveToFront(elem)
+	entry := elem.Value.(*indexEntryCacheEntry)
+	indexEntry := IndexEntry{
+		Size:     entry.size,
+		Checksum: entry.checksum,
+		Offset:   entry.offset,
+	}
+	if len(entry.encodedTags) > 0 {
+		indexEntry.EncodedTags = newIndexEntryTags(entry.encodedTags, bytesPool)
+	}
+	c.Unlock()
+
+	c.metrics.hits.Inc(1)
+	return indexEntry, true
+}
+
+// put adds the index entry for an ID to the cache, evicting the least
+// recently used entry if the cache is full.
+func (c *indexEntryCache) put(id []byte, indexEntry IndexEntry) {
+	c.Lock()
+	defer c.Unlock()
+
+	if elem, ok := c.items[string(id)]; ok {
+		// Entries of a fileset volume are immutable so the existing
+		// entry is identical.
+		c.evictList.MoveToFront(elem)
+		return
+	}
+
+	entry := &indexEntryCacheEntry{
+		id:       string(id),
+		size:     indexEntry.Size,
+		checksum: indexEntry.Checksum,
+		offset:   indexEntry.Offset,
+	}
+	if tags := indexEntry.EncodedTags; tags != nil && tags.Len() > 0 {
+		entry.encodedTags = append([]byte(nil), tags.Bytes()...)
+	}
+	c.items[entry.id] = c.evictList.PushFront(entry)
+
+	if c.evictList.Len() > c.size {
+		oldest := c.evictList.Back()
+		c.evictList.Remove(oldest)
+		delete(c.items, oldest.Value.(*indexEntryCacheEntry).id)
+		c.metrics.evictions.Inc(1)
+	}
+}
+
+// len returns the number of cached index entries.
+func (c *indexEntryCache) len() int {
+	c.Lock()
+	defer c.Unlock()
+	return c.evictList.Len()
+}
+
+func newIndexEntryTags(encodedTags []byte, bytesPool pool.CheckedBytesPool) checked.Bytes {
+	var tags checked.Bytes
+	if bytesPool != nil {
+		tags = bytesPool.Get(len(encodedTags))
+		tags.IncRef()
+		tags.AppendAll(encodedTags)
+	} else {
+		tags = checked.NewBytes(append([]byte(nil), encodedTags...), nil)
+		tags.IncRef()
+	}
+	return tags
+}
+
+// indexEntryCachingSeeker is a seeker that serves index entry lookups from an
+// index entry cache shared with the other seekers of the same fileset volume.
+type indexEntryCachingSeeker struct {
+	ConcurrentDataFileSetSeeker
+
+	cache     *indexEntryCache
+	bytesPool pool.CheckedBytesPool
+}
+
+func newIndexEntryCachingSeeker(
+	seeker ConcurrentDataFileSetSeeker,
+	cache *indexEntryCache,
+	bytesPool pool.CheckedBytesPool,
+) *indexEntryCachingSeeker {
+	return &indexEntryCachingSeeker{
+		ConcurrentDataFileSetSeeker: seeker,
+		cache:                       cache,
+		bytesPool:                   bytesPool,
+	}
+}
+
+func (s *indexEntryCachingSeeker) SeekIndexEntry(
+	id ident.ID,
+	resources ReusableSeekerResources,
+) (IndexEntry, error) {
+	if entry, ok := s.cache.get(id.Bytes(), s.bytesPool); ok {
+		return entry, nil
+	}
+
+	entry, err := s.ConcurrentDataFileSetSeeker.SeekIndexEntry(id, resources)
+	if err != nil {
+		return IndexEntry{}, err
+	}
+
+	s.cache.put(id.Bytes(), entry)
+	return entry, nil
+}
+
+func (s *indexEntryCachingSeeker) SeekByID(
+	id ident.ID,
+	resources ReusableSeekerResources,
+) (checked.Bytes, error) {
+	entry, err := s.SeekIndexEntry(id, resources)
+	if err != nil {
+		return nil, err
+	}
+	if tags := entry.EncodedTags; tags != nil {
+		tags.DecRef()
+		tags.Finalize()
+	}
+
+	return s.SeekByIndexEntry(entry, resources)
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestIndexEntryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	cache := newIndexEntryCache(2, newIndexEntryCacheMetrics(scope))
+
+	cache.put([]byte("foo"), IndexEntry{Offset: 1})
+	cache.put([]byte("bar"), IndexEntry{Offset: 2})
+
+	// Accessing foo makes bar the least recently used entry.
+	entry, ok := cache.get([]byte("foo"), nil)
+	require.True(t, ok)
+	require.Equal(t, int64(1), entry.Offset)
+
+	cache.put([]byte("baz"), IndexEntry{Offset: 3})
+	require.Equal(t, 2, cache.len())
+
+	_, ok = cache.get([]byte("bar"), nil)
+	require.False(t, ok)
+	entry, ok = cache.get([]byte("baz"), nil)
+	require.True(t, ok)
+	require.Equal(t, int64(3), entry.Offset)
+
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(2), counters["index-entry-cache.hits+"].Value())
+	require.Equal(t, int64(1), counters["index-entry-cache.misses+"].Value())
+	require.Equal(t, int64(1), counters["index-entry-cache.evictions+"].Value())
+}
+
+func TestIndexEntryCachingSeekerSeekIndexEntry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		id        = ident.StringID("foo")
+		resources = newTestReusableSeekerResources()
+		tags      = checked.NewBytes([]byte("tags"), nil)
+		cache     = newIndexEntryCache(8, newIndexEntryCacheMetrics(tally.NoopScope))
+		mock      = NewMockConcurrentDataFileSetSeeker(ctrl)
+		seeker    = newIndexEntryCachingSeeker(mock, cache, testBytesPool)
+	)
+	tags.IncRef()
+
+	// Only the first lookup is served by the underlying seeker.
+	mock.EXPECT().SeekIndexEntry(id, resources).Return(IndexEntry{
+		Size:        3,
+		Checksum:    4,
+		Offset:      5,
+		EncodedTags: tags,
+	}, nil)
+	mock.EXPECT().SeekIndexEntry(ident.StringID("bar"), resources).Return(IndexEntry{}, errSeekIDNotFound)
+
+	first, err := seeker.SeekIndexEntry(id, resources)
+	require.NoError(t, err)
+	require.True(t, tags == first.EncodedTags)
+
+	second, err := seeker.SeekIndexEntry(id, resources)
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), second.Size)
+	require.Equal(t, uint32(4), second.Checksum)
+	require.Equal(t, int64(5), second.Offset)
+
+	// The cached tags are copied so the caller owns them independently.
+	require.False(t, tags == second.EncodedTags)
+	require.Equal(t, []byte("tags"), second.EncodedTags.Bytes())
+
+	// Lookups of missing IDs are not cached.
+	_, err = seeker.SeekIndexEntry(ident.StringID("bar"), resources)
+	require.Equal(t, errSeekIDNotFound, err)
+	require.Equal(t, 1, cache.len())
+}
@@ -150,10 +150,12 @@ func (il *nearestIndexOffsetLookup) close() error {
 // from an index summaries file by reading the summaries file into an anonymous
 // mmap'd region, and also creating the slice of summaries offsets which is
 // required to binary search the data structure. It will also make sure that
-// the summaries file is sorted (which it always should be).
+// the summaries file is sorted (which it always should be). If the fileset is
+// encrypted the summaries are decrypted into the anonymous mmap'd region.
 func newNearestIndexOffsetLookupFromSummariesFile(
 	summariesFdWithDigest digest.FdWithDigestReader,
 	expectedDigest uint32,
+	fileCipher *filesetCipher,
 	decoder *xmsgpack.Decoder,
 	decoderStream xmsgpack.ByteDecoderStream,
 	numEntries int,
@@ -163,6 +165,12 @@ func newNearestIndexOffsetLookupFromSummariesFile(
 	if err != nil {
 		return nil, err
 	}
+	if fileCipher != nil {
+		summariesMmap, err = decryptSummariesMmap(fileCipher, summariesMmap)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// Msgpack decode the entire summaries file (we need to store the offsets
 	// for the entries so we can binary-search it)
@@ -193,3 +201,27 @@ func newNearestIndexOffsetLookupFromSummariesFile(
 
 	return newNearestIndexOffsetLookup(summaryTokens, summariesMmap), nil
 }
+
+// decryptSummariesMmap decrypts the summaries into a new anonymous mmap'd
+// region and releases the region holding the encrypted summaries.
+func decryptSummariesMmap(
+	fileCipher *filesetCipher,
+	encrypted []byte,
+) ([]byte, error) {
+	defer mmap.Munmap(encrypted)
+
+	size, err := decryptedFileSize(int64(len(encrypted)))
+	if err != nil {
+		return nil, err
+	}
+	mmapResult, err := mmap.Bytes(size, mmap.Options{Read: true, Write: true})
+	if err != nil {
+		return nil, err
+	}
+	decrypted := mmapResult.Result
+	if _, err := fileCipher.decryptAll(decrypted[:0], encrypted, encryptedSummariesFile); err != nil {
+		mmap.Munmap(decrypted)
+		return nil, fmt.Errorf("could not decrypt summaries file: %v", err)
+	}
+	return decrypted, nil
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"errors"
+	"sync"
+
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+var errIndexLookupCacheEntryNotFound = errors.New("index lookup cache entry not found")
+
+// indexLookupCacheKey identifies the index summaries of a fileset volume. The
+// volume index is intentionally not part of the key so that volumes of the same
+// block whose summaries files are identical share a single lookup.
+type indexLookupCacheKey struct {
+	shard           uint32
+	blockStart      xtime.UnixNano
+	summariesDigest uint32
+	numSummaries    int
+}
+
+type indexLookupCacheEntry struct {
+	lookup *nearestIndexOffsetLookup
+	refs   int
+}
+
+// indexLookupCache shares the read-only index summaries lookups between all
+// the seekers of a namespace (and their clones) so that the summaries for a
+// fileset are only held in memory once. Lookups are reference counted and
+// closed once the last seeker referencing them is closed.
+type indexLookupCache struct {
+	sync.Mutex
+	entries map[indexLookupCacheKey]*indexLookupCacheEntry
+}
+
+func newIndexLookupCache() *indexLookupCache {
+	return &indexLookupCache{
+		entries: make(map[indexLookupCacheKey]*indexLookupCacheEntry),
+	}
+}
+
+// acquire returns the cached lookup for the key if present and takes a
+// reference to it which must be released with release().
+func (c *indexLookupCache) acquire(
+	key indexLookupCacheKey,
+) (*nearestIndexOffsetLookup, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry.refs++
+	return entry.lookup, true
+}
+
+// insert caches a newly created lookup and takes a reference to it. If a lookup
+// was inserted concurrently for the same key then the provided lookup is closed
+// and the cached lookup is returned instead.
+func (c *indexLookupCache) insert(
+	key indexLookupCacheKey,
+	lookup *nearestIndexOffsetLookup,
+) (*nearestIndexOffsetLookup, error) {
+	c.Lock()
+	entry, ok := c.entries[key]
+	if ok {
+		entry.refs++
+		c.Unlock()
+		return entry.lookup, lookup.close()
+	}
+	c.entries[key] = &indexLookupCacheEntry{lookup: lookup, refs: 1}
+	c.Unlock()
+	return lookup, nil
+}
+
+// release releases a reference to the lookup for the key, closing the lookup
+// once there are no remaining references.
+func (c *indexLookupCache) release(key indexLookupCacheKey) error {
+	c.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		c.Unlock()
+		return errIndexLookupCacheEntryNotFound
+	}
+	entry.refs--
+	if entry.refs > 0 {
+		c.Unlock()
+		return nil
+	}
+	delete(c.entries, key)
+	c.Unlock()
+
+	// Close outside of the lock since it unmaps the summaries.
+	return entry.lookup.close()
+}
@@ -119,7 +119,7 @@ func TestIndexLookupWriteRead(t *testing.T) {
 		decoder := msgpack.NewDecoder(options.DecodingOptions())
 		decoderStream := msgpack.NewByteDecoderStream(nil)
 		indexLookup, err := newNearestIndexOffsetLookupFromSummariesFile(
-			summariesFdWithDigest, expectedSummariesDigest,
+			summariesFdWithDigest, expectedSummariesDigest, nil,
 			decoder, decoderStream, len(writes), input.forceMmapMemory)
 		if err != nil {
 			return false, fmt.Errorf("err reading index lookup from summaries file: %v, ", err)
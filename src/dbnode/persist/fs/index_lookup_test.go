@@ -77,6 +77,7 @@ func TestNewNearestIndexOffsetDetectsUnsortedFiles(t *testing.T) {
 	_, err = newNearestIndexOffsetLookupFromSummariesFile(
 		summariesFdWithDigest,
 		expectedDigest,
+		nil,
 		msgpack.NewDecoder(nil),
 		msgpack.NewByteDecoderStream(nil),
 		len(outOfOrderSummaries),
@@ -220,6 +221,7 @@ func newIndexLookupWithSummaries(
 	indexLookup, err := newNearestIndexOffsetLookupFromSummariesFile(
 		summariesFdWithDigest,
 		expectedDigest,
+		nil,
 		msgpack.NewDecoder(nil),
 		msgpack.NewByteDecoderStream(nil),
 		len(indexSummaries),
@@ -106,6 +106,7 @@ func (r *indexReader) Open(
 		infoFilepath       string
 		digestFilepath     string
 	)
+	r.filePathPrefix = r.opts.NamespaceFilePathPrefix(namespace)
 	r.start = opts.Identifier.BlockStart
 	r.fileSetType = opts.FileSetType
 	r.volumeIndex = opts.Identifier.VolumeIndex
@@ -106,6 +106,7 @@ func (w *indexWriter) Open(opts IndexWriterOpenOptions) error {
 		blockStart = opts.Identifier.BlockStart
 	)
 	w.err = nil
+	w.filePathPrefix = w.opts.NamespaceFilePathPrefix(namespace)
 	w.blockSize = opts.BlockSize
 	w.start = blockStart
 	w.fileSetType = opts.FileSetType
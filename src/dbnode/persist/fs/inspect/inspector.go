@@ -0,0 +1,252 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package inspect
+
+import (
+	"bytes"
+	"io"
+	"sort"
+
+	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/x/ident"
+)
+
+type inspector struct {
+	opts Options
+}
+
+// NewInspector returns a new inspector
+func NewInspector(opts Options) Inspector {
+	return &inspector{opts: opts}
+}
+
+func (i *inspector) Open(id VolumeID) (Volume, error) {
+	reader, err := fs.NewReader(nil, i.opts.FilesystemOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	v := &volume{
+		id:     id,
+		opts:   i.opts,
+		reader: reader,
+	}
+	// Open the volume once upfront so that a missing or unreadable volume
+	// is reported immediately and its info can be served without reading.
+	if err := v.open(); err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	blockRange := reader.Range()
+	v.info = VolumeInfo{
+		BlockStart: blockRange.Start,
+		BlockSize:  blockRange.End.Sub(blockRange.Start),
+		Entries:    reader.Entries(),
+	}
+	return v, nil
+}
+
+type volume struct {
+	id     VolumeID
+	opts   Options
+	info   VolumeInfo
+	reader fs.DataFileSetReader
+}
+
+func (v *volume) fileSetID() fs.FileSetFileIdentifier {
+	return fs.FileSetFileIdentifier{
+		Namespace:   ident.StringID(v.id.Namespace),
+		Shard:       v.id.Shard,
+		BlockStart:  v.id.BlockStart,
+		VolumeIndex: v.id.Volume,
+	}
+}
+
+// open opens the reader at the start of the volume, each pass over the
+// volume reopens the reader since it can only be read through once.
+func (v *volume) open() error {
+	return v.reader.Open(fs.DataReaderOpenOptions{
+		Identifier:  v.fileSetID(),
+		FileSetType: v.id.FileSetType,
+	})
+}
+
+func (v *volume) ID() VolumeID {
+	return v.id
+}
+
+func (v *volume) Info() VolumeInfo {
+	return v.info
+}
+
+func (v *volume) ForEachSeries(fn func(series Series) error) error {
+	if err := v.open(); err != nil {
+		return err
+	}
+	defer v.reader.Close()
+
+	for {
+		id, tagsIter, length, checksum, err := v.reader.ReadMetadata()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		series := Series{
+			ID:       id.String(),
+			Size:     length,
+			Checksum: checksum,
+		}
+		id.Finalize()
+		series.Tags, err = tagsFromIter(tagsIter)
+		if err != nil {
+			return err
+		}
+		if err := fn(series); err != nil {
+			return err
+		}
+	}
+}
+
+func (v *volume) ReadSeries(seriesID string) (SeriesBlock, error) {
+	if err := v.open(); err != nil {
+		return SeriesBlock{}, err
+	}
+	defer v.reader.Close()
+
+	for {
+		id, tagsIter, data, checksum, err := v.reader.Read()
+		if err == io.EOF {
+			return SeriesBlock{}, ErrSeriesNotFound
+		}
+		if err != nil {
+			return SeriesBlock{}, err
+		}
+
+		match := id.String() == seriesID
+		id.Finalize()
+		if !match {
+			tagsIter.Close()
+			data.Finalize()
+			continue
+		}
+
+		block := SeriesBlock{
+			ID:       seriesID,
+			Checksum: checksum,
+			Start:    v.info.BlockStart,
+		}
+		data.IncRef()
+		block.Data = append([]byte(nil), data.Bytes()...)
+		data.DecRef()
+		data.Finalize()
+
+		block.Tags, err = tagsFromIter(tagsIter)
+		if err != nil {
+			return SeriesBlock{}, err
+		}
+		block.Datapoints, err = v.decode(block.Data)
+		if err != nil {
+			return SeriesBlock{}, err
+		}
+		return block, nil
+	}
+}
+
+func (v *volume) decode(data []byte) ([]Datapoint, error) {
+	iter := m3tsz.NewReaderIterator(bytes.NewReader(data),
+		m3tsz.DefaultIntOptimizationEnabled, v.opts.EncodingOptions())
+	defer iter.Close()
+
+	var datapoints []Datapoint
+	for iter.Next() {
+		dp, unit, annotation := iter.Current()
+		datapoints = append(datapoints, Datapoint{
+			Timestamp:  dp.Timestamp,
+			Value:      dp.Value,
+			Unit:       unit,
+			Annotation: append([]byte(nil), annotation...),
+		})
+	}
+	return datapoints, iter.Err()
+}
+
+func (v *volume) Verify() (fs.VerifyResult, error) {
+	return fs.VerifyFileSet(v.opts.FilesystemOptions(), v.fileSetID(),
+		v.id.FileSetType)
+}
+
+func (v *volume) Close() error {
+	return nil
+}
+
+// Compare compares the series of two volumes by ID and checksum, the
+// volumes may be opened from different inspectors, e.g. to compare a
+// volume before and after a migration.
+func Compare(a, b Volume) (Comparison, error) {
+	checksums := make(map[string]uint32, a.Info().Entries)
+	if err := a.ForEachSeries(func(series Series) error {
+		checksums[series.ID] = series.Checksum
+		return nil
+	}); err != nil {
+		return Comparison{}, err
+	}
+
+	var result Comparison
+	if err := b.ForEachSeries(func(series Series) error {
+		checksum, ok := checksums[series.ID]
+		if !ok {
+			result.OnlyInB = append(result.OnlyInB, series.ID)
+			return nil
+		}
+		delete(checksums, series.ID)
+		if checksum != series.Checksum {
+			result.Different = append(result.Different, series.ID)
+			return nil
+		}
+		result.Equal++
+		return nil
+	}); err != nil {
+		return Comparison{}, err
+	}
+
+	for id := range checksums {
+		result.OnlyInA = append(result.OnlyInA, id)
+	}
+	sort.Strings(result.OnlyInA)
+	sort.Strings(result.OnlyInB)
+	sort.Strings(result.Different)
+	return result, nil
+}
+
+func tagsFromIter(iter ident.TagIterator) (ident.Tags, error) {
+	defer iter.Close()
+
+	tags := ident.NewTags()
+	for iter.Next() {
+		tag := iter.Current()
+		tags.Append(ident.StringTag(tag.Name.String(), tag.Value.String()))
+	}
+	return tags, iter.Err()
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package inspect
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testNamespace = "testns"
+	testBlockSize = 2 * time.Hour
+)
+
+var testBlockStart = time.Unix(0, 0).Add(10 * testBlockSize)
+
+type testSeries struct {
+	id     string
+	tags   ident.Tags
+	values []float64
+}
+
+func newTestOptions(t *testing.T) (Options, func()) {
+	dir, err := ioutil.TempDir("", "inspect")
+	require.NoError(t, err)
+	opts := NewOptions().SetFilesystemOptions(
+		fs.NewOptions().SetFilePathPrefix(dir))
+	return opts, func() { os.RemoveAll(dir) }
+}
+
+func encodeTestSeries(t *testing.T, values []float64) []byte {
+	enc := m3tsz.NewEncoder(testBlockStart, nil,
+		m3tsz.DefaultIntOptimizationEnabled, encoding.NewOptions())
+	for i, v := range values {
+		dp := ts.Datapoint{
+			Timestamp: testBlockStart.Add(time.Duration(i) * time.Second),
+			Value:     v,
+		}
+		require.NoError(t, enc.Encode(dp, xtime.Second, nil))
+	}
+
+	seg := enc.Discard()
+	var data []byte
+	if seg.Head != nil {
+		data = append(data, seg.Head.Bytes()...)
+	}
+	if seg.Tail != nil {
+		data = append(data, seg.Tail.Bytes()...)
+	}
+	return data
+}
+
+func writeTestVolume(t *testing.T, opts Options, series []testSeries) VolumeID {
+	w, err := fs.NewWriter(opts.FilesystemOptions())
+	require.NoError(t, err)
+
+	id := VolumeID{
+		Namespace:   testNamespace,
+		BlockStart:  testBlockStart,
+		FileSetType: persist.FileSetFlushType,
+	}
+	require.NoError(t, w.Open(fs.DataWriterOpenOptions{
+		Identifier: fs.FileSetFileIdentifier{
+			Namespace:  ident.StringID(id.Namespace),
+			BlockStart: id.BlockStart,
+		},
+		BlockSize:   testBlockSize,
+		FileSetType: id.FileSetType,
+	}))
+	for _, s := range series {
+		data := encodeTestSeries(t, s.values)
+		bytes := checked.NewBytes(data, nil)
+		bytes.IncRef()
+		require.NoError(t, w.Write(ident.StringID(s.id), s.tags, bytes,
+			digest.Checksum(data)))
+	}
+	require.NoError(t, w.Close())
+	return id
+}
+
+func TestInspectorVolume(t *testing.T) {
+	opts, cleanup := newTestOptions(t)
+	defer cleanup()
+
+	id := writeTestVolume(t, opts, []testSeries{
+		{id: "foo", tags: ident.NewTags(ident.StringTag("a", "b")), values: []float64{1, 2, 3}},
+		{id: "bar", values: []float64{4.5}},
+	})
+
+	v, err := NewInspector(opts).Open(id)
+	require.NoError(t, err)
+	defer v.Close()
+
+	require.Equal(t, id, v.ID())
+	require.Equal(t, VolumeInfo{
+		BlockStart: testBlockStart,
+		BlockSize:  testBlockSize,
+		Entries:    2,
+	}, v.Info())
+
+	var ids []string
+	require.NoError(t, v.ForEachSeries(func(series Series) error {
+		ids = append(ids, series.ID)
+		if series.ID == "foo" {
+			require.True(t, ident.NewTags(ident.StringTag("a", "b")).Equal(series.Tags))
+		}
+		require.True(t, series.Size > 0)
+		return nil
+	}))
+	require.ElementsMatch(t, []string{"foo", "bar"}, ids)
+
+	block, err := v.ReadSeries("foo")
+	require.NoError(t, err)
+	require.Equal(t, "foo", block.ID)
+	require.Equal(t, testBlockStart, block.Start)
+	require.Equal(t, digest.Checksum(block.Data), block.Checksum)
+	require.Equal(t, 3, len(block.Datapoints))
+	for i, dp := range block.Datapoints {
+		require.True(t, testBlockStart.Add(time.Duration(i)*time.Second).Equal(dp.Timestamp))
+		require.Equal(t, float64(i+1), dp.Value)
+		require.Equal(t, xtime.Second, dp.Unit)
+	}
+
+	_, err = v.ReadSeries("baz")
+	require.Equal(t, ErrSeriesNotFound, err)
+
+	result, err := v.Verify()
+	require.NoError(t, err)
+	require.True(t, result.Healthy(), "issues: %v", result.Issues)
+	require.Equal(t, 2, result.Entries)
+}
+
+func TestInspectorOpenMissingVolume(t *testing.T) {
+	opts, cleanup := newTestOptions(t)
+	defer cleanup()
+
+	_, err := NewInspector(opts).Open(VolumeID{
+		Namespace:   testNamespace,
+		BlockStart:  testBlockStart,
+		FileSetType: persist.FileSetFlushType,
+	})
+	require.Equal(t, fs.ErrCheckpointFileNotFound, err)
+}
+
+func TestCompare(t *testing.T) {
+	optsA, cleanupA := newTestOptions(t)
+	defer cleanupA()
+	optsB, cleanupB := newTestOptions(t)
+	defer cleanupB()
+
+	idA := writeTestVolume(t, optsA, []testSeries{
+		{id: "foo", values: []float64{1}},
+		{id: "bar", values: []float64{2}},
+		{id: "baz", values: []float64{3}},
+	})
+	idB := writeTestVolume(t, optsB, []testSeries{
+		{id: "foo", values: []float64{1}},
+		{id: "bar", values: []float64{20}},
+		{id: "qux", values: []float64{4}},
+	})
+
+	a, err := NewInspector(optsA).Open(idA)
+	require.NoError(t, err)
+	defer a.Close()
+	b, err := NewInspector(optsB).Open(idB)
+	require.NoError(t, err)
+	defer b.Close()
+
+	result, err := Compare(a, b)
+	require.NoError(t, err)
+	require.False(t, result.Identical())
+	require.Equal(t, Comparison{
+		OnlyInA:   []string{"baz"},
+		OnlyInB:   []string{"qux"},
+		Different: []string{"bar"},
+		Equal:     1,
+	}, result)
+
+	result, err = Compare(a, a)
+	require.NoError(t, err)
+	require.True(t, result.Identical())
+	require.Equal(t, 3, result.Equal)
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+// Package inspect opens fileset volumes offline so that tooling can enumerate,
+// verify, dump and compare them without reimplementing the file format.
+package inspect
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+var (
+	// ErrSeriesNotFound is returned when a series is not present in a volume.
+	ErrSeriesNotFound = errors.New("series not found in volume")
+)
+
+// VolumeID is the collection of identifiers required to
+// uniquely identify a fileset volume
+type VolumeID struct {
+	Namespace   string
+	Shard       uint32
+	BlockStart  time.Time
+	Volume      int
+	FileSetType persist.FileSetType
+}
+
+// VolumeInfo describes a fileset volume.
+type VolumeInfo struct {
+	BlockStart time.Time
+	BlockSize  time.Duration
+	Entries    int
+}
+
+// Series is a series stored in a fileset volume.
+type Series struct {
+	ID   string
+	Tags ident.Tags
+	// Size is the size of the series data as stored on disk, i.e. after
+	// compression if the volume is compressed.
+	Size int
+	// Checksum is the checksum of the uncompressed series data.
+	Checksum uint32
+}
+
+// Datapoint is a datapoint decoded from a series block.
+type Datapoint struct {
+	Timestamp  time.Time
+	Value      float64
+	Unit       xtime.Unit
+	Annotation []byte
+}
+
+// SeriesBlock is the data stored for a series in a fileset volume.
+type SeriesBlock struct {
+	ID       string
+	Tags     ident.Tags
+	Checksum uint32
+	Start    time.Time
+	// Data is the encoded block data, uncompressed if the volume is compressed.
+	Data       []byte
+	Datapoints []Datapoint
+}
+
+// Comparison is the result of comparing the series of two volumes.
+type Comparison struct {
+	// OnlyInA are the IDs of series only present in the first volume.
+	OnlyInA []string
+	// OnlyInB are the IDs of series only present in the second volume.
+	OnlyInB []string
+	// Different are the IDs of series present in both volumes whose data differ.
+	Different []string
+	// Equal is the number of series present in both volumes with equal data.
+	Equal int
+}
+
+// Identical returns whether both volumes contain exactly the same series data.
+func (c Comparison) Identical() bool {
+	return len(c.OnlyInA) == 0 && len(c.OnlyInB) == 0 && len(c.Different) == 0
+}
+
+// Inspector opens fileset volumes for offline inspection
+type Inspector interface {
+	// Open opens the given fileset volume
+	Open(id VolumeID) (Volume, error)
+}
+
+// Volume is a fileset volume opened for inspection, it is not safe for
+// concurrent use
+type Volume interface {
+	io.Closer
+
+	// ID returns the identifier of the volume
+	ID() VolumeID
+
+	// Info returns information about the volume
+	Info() VolumeInfo
+
+	// ForEachSeries calls fn with each series in the volume in the order they
+	// are stored on disk, iteration stops at the first error returned by fn
+	ForEachSeries(fn func(series Series) error) error
+
+	// ReadSeries returns the block stored for a series along with its decoded
+	// datapoints, or ErrSeriesNotFound if the series is not in the volume
+	ReadSeries(id string) (SeriesBlock, error)
+
+	// Verify validates the digests, index and bloom filter of the volume
+	Verify() (fs.VerifyResult, error)
+}
+
+// Options represents the knobs available while inspecting
+type Options interface {
+	// SetFilesystemOptions sets the filesystem options, including the path
+	// prefix the volumes are opened from
+	SetFilesystemOptions(value fs.Options) Options
+
+	// FilesystemOptions returns the filesystem options
+	FilesystemOptions() fs.Options
+
+	// SetEncodingOptions sets the encoding options used to decode datapoints
+	SetEncodingOptions(value encoding.Options) Options
+
+	// EncodingOptions returns the encoding options used to decode datapoints
+	EncodingOptions() encoding.Options
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+// multiMergeWith implements MergeWith by combining several merge targets. The
+// data of a series from each of the merge targets is merged in the order the
+// merge targets were provided, so for a timestamp that exists in more than one
+// merge target the data from the last of them is used.
+type multiMergeWith struct {
+	mergeWiths []MergeWith
+}
+
+// NewMultiMergeWith returns a MergeWith that merges with each of the given
+// merge targets, with the later merge targets taking precedence over the
+// earlier ones. This allows sources other than the series in memory (such as
+// imported corrections of historical data) to take part in a merge.
+//
+// Each merge target must consider a series handled once it has been Read(),
+// so that each series is only passed once to a ForEachRemainingFn.
+func NewMultiMergeWith(mergeWiths ...MergeWith) MergeWith {
+	if len(mergeWiths) == 1 {
+		return mergeWiths[0]
+	}
+	return &multiMergeWith{mergeWiths: mergeWiths}
+}
+
+func (m *multiMergeWith) Read(
+	ctx context.Context,
+	seriesID ident.ID,
+	blockStart xtime.UnixNano,
+	nsCtx namespace.Context,
+) ([]xio.BlockReader, bool, error) {
+	return m.readFrom(m.mergeWiths, ctx, seriesID, blockStart, nsCtx)
+}
+
+func (m *multiMergeWith) readFrom(
+	mergeWiths []MergeWith,
+	ctx context.Context,
+	seriesID ident.ID,
+	blockStart xtime.UnixNano,
+	nsCtx namespace.Context,
+) ([]xio.BlockReader, bool, error) {
+	var (
+		result  []xio.BlockReader
+		hasData bool
+	)
+	for _, mergeWith := range mergeWiths {
+		data, ok, err := mergeWith.Read(ctx, seriesID, blockStart, nsCtx)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			result = append(result, data...)
+			hasData = true
+		}
+	}
+	return result, hasData, nil
+}
+
+// ForEachRemaining loops through the remaining series of each merge target in
+// turn. Any series remaining in a merge target is also read from the merge
+// targets after it, which marks the series as handled by them, so that the
+// data of a series from all the merge targets is passed to fn at once. None of
+// the merge targets before it can still have the series remaining since it
+// would have already been read from this merge target when they were looped
+// through.
+func (m *multiMergeWith) ForEachRemaining(
+	ctx context.Context,
+	blockStart xtime.UnixNano,
+	fn ForEachRemainingFn,
+	nsCtx namespace.Context,
+) error {
+	for i, mergeWith := range m.mergeWiths {
+		rest := m.mergeWiths[i+1:]
+		err := mergeWith.ForEachRemaining(ctx, blockStart,
+			func(seriesID ident.ID, tags ident.Tags, data []xio.BlockReader) error {
+				restData, hasData, err := m.readFrom(rest, ctx, seriesID, blockStart, nsCtx)
+				if err != nil {
+					return err
+				}
+				if hasData {
+					// Copy rather than append to avoid writing to the backing
+					// array of the slice owned by the merge target.
+					merged := make([]xio.BlockReader, 0, len(data)+len(restData))
+					merged = append(merged, data...)
+					data = append(merged, restData...)
+				}
+				return fn(seriesID, tags, data)
+			}, nsCtx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
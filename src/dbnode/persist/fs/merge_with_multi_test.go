@@ -0,0 +1,116 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiMergeWithRead(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		ctx        = context.NewContext()
+		nsCtx      = namespace.Context{}
+		id         = ident.StringID("foo")
+		blockStart = xtime.ToUnixNano(time.Unix(0, 0))
+		first      = NewMockMergeWith(ctrl)
+		second     = NewMockMergeWith(ctrl)
+		third      = NewMockMergeWith(ctrl)
+		firstData  = []xio.BlockReader{{BlockSize: time.Hour}}
+		thirdData  = []xio.BlockReader{{BlockSize: 2 * time.Hour}}
+		mergeWith  = NewMultiMergeWith(first, second, third)
+	)
+
+	first.EXPECT().Read(ctx, id, blockStart, nsCtx).Return(firstData, true, nil)
+	second.EXPECT().Read(ctx, id, blockStart, nsCtx).Return(nil, false, nil)
+	third.EXPECT().Read(ctx, id, blockStart, nsCtx).Return(thirdData, true, nil)
+
+	// The data is ordered the same as the merge targets so that the later
+	// merge targets take precedence.
+	data, ok, err := mergeWith.Read(ctx, id, blockStart, nsCtx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, append(firstData, thirdData...), data)
+}
+
+func TestMultiMergeWithForEachRemaining(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		ctx        = context.NewContext()
+		nsCtx      = namespace.Context{}
+		blockStart = xtime.ToUnixNano(time.Unix(0, 0))
+		first      = NewMockMergeWith(ctrl)
+		second     = NewMockMergeWith(ctrl)
+		mergeWith  = NewMultiMergeWith(first, second)
+		fooFirst   = []xio.BlockReader{{BlockSize: time.Hour}}
+		fooSecond  = []xio.BlockReader{{BlockSize: 2 * time.Hour}}
+		barSecond  = []xio.BlockReader{{BlockSize: 3 * time.Hour}}
+	)
+
+	// Series remaining in the first merge target are also read from the second
+	// so that it does not return them again as remaining.
+	first.EXPECT().ForEachRemaining(ctx, blockStart, gomock.Any(), nsCtx).
+		DoAndReturn(func(
+			_ context.Context,
+			_ xtime.UnixNano,
+			fn ForEachRemainingFn,
+			_ namespace.Context,
+		) error {
+			return fn(ident.StringID("foo"), ident.Tags{}, fooFirst)
+		})
+	second.EXPECT().Read(ctx, ident.StringID("foo"), blockStart, nsCtx).
+		Return(fooSecond, true, nil)
+	second.EXPECT().ForEachRemaining(ctx, blockStart, gomock.Any(), nsCtx).
+		DoAndReturn(func(
+			_ context.Context,
+			_ xtime.UnixNano,
+			fn ForEachRemainingFn,
+			_ namespace.Context,
+		) error {
+			return fn(ident.StringID("bar"), ident.Tags{}, barSecond)
+		})
+
+	remaining := make(map[string][]xio.BlockReader)
+	err := mergeWith.ForEachRemaining(ctx, blockStart,
+		func(seriesID ident.ID, tags ident.Tags, data []xio.BlockReader) error {
+			remaining[seriesID.String()] = data
+			return nil
+		}, nsCtx)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]xio.BlockReader{
+		"foo": append(fooFirst, fooSecond...),
+		"bar": barSecond,
+	}, remaining)
+}
@@ -183,6 +183,18 @@ func (m *merger) Merge(
 			res.Finalize()
 		}
 	}()
+	defer func() {
+		if err != nil {
+			// Abort the flush preparer so that its writer is released to
+			// prepare other filesets without checkpointing the partially
+			// merged volume, the merge error takes precedence.
+			prepared.Abort()
+			return
+		}
+		// Close the flush preparer, which writes the rest of the files in the
+		// fileset.
+		err = prepared.Close()
+	}()
 
 	// The merge is performed in two stages. The first stage is to loop through
 	// series on disk and merge it with what's in the merge target. Looping
@@ -254,13 +266,7 @@ func (m *merger) Merge(
 			tmpCtx.Reset()
 			return err
 		}, nsCtx)
-	if err != nil {
-		return err
-	}
-
-	// Close the flush preparer, which writes the rest of the files in the
-	// fileset.
-	return prepared.Close()
+	return err
 }
 
 func blockReaderFromData(
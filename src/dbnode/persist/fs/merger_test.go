@@ -21,6 +21,7 @@
 package fs
 
 import (
+	"errors"
 	"io"
 	"testing"
 	"time"
@@ -420,6 +421,49 @@ func TestMergeWithNoData(t *testing.T) {
 	testMergeWith(t, diskData, mergeTargetData, expected)
 }
 
+func TestMergeWithErrorAbortsPreparedPersist(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	emptyData := newCheckedBytesByIDMap(newCheckedBytesByIDMapOptions{})
+	reader := mockReaderFromData(ctrl, emptyData)
+
+	// A failed merge must not checkpoint the partially merged volume so the
+	// prepared persist is aborted rather than closed.
+	var aborted int
+	preparer := persist.NewMockFlushPreparer(ctrl)
+	preparer.EXPECT().PrepareData(gomock.Any()).Return(
+		persist.PreparedDataPersist{
+			Persist: func(id ident.ID, tags ident.Tags, segment ts.Segment, checksum uint32) error {
+				return nil
+			},
+			Close: func() error {
+				require.FailNow(t, "unexpected close of a failed merge")
+				return nil
+			},
+			Abort: func() error {
+				aborted++
+				return nil
+			},
+		}, nil)
+
+	mergeErr := errors.New("an error")
+	mergeWith := NewMockMergeWith(ctrl)
+	mergeWith.EXPECT().
+		ForEachRemaining(gomock.Any(), xtime.ToUnixNano(startTime), gomock.Any(), gomock.Any()).
+		Return(mergeErr)
+
+	merger := NewMerger(reader, 0, srPool, multiIterPool, identPool, encoderPool, namespace.NewOptions())
+	fsID := FileSetFileIdentifier{
+		Namespace:  ident.StringID("test-ns"),
+		Shard:      uint32(8),
+		BlockStart: startTime,
+	}
+	err := merger.Merge(fsID, mergeWith, 1, preparer, namespace.Context{})
+	require.Equal(t, mergeErr, err)
+	require.Equal(t, 1, aborted)
+}
+
 func testMergeWith(
 	t *testing.T,
 	diskData *checkedBytesMap,
@@ -258,6 +258,16 @@ func (dec *Decoder) decodeIndexInfo() schema.IndexInfo {
 		opts.override = true
 		opts.numExpectedMinFields = 6
 		opts.numExpectedCurrFields = 9
+	case legacyEncodingIndexVersionV4:
+		// V4 had 10 fields.
+		opts.override = true
+		opts.numExpectedMinFields = 6
+		opts.numExpectedCurrFields = 10
+	case legacyEncodingIndexVersionV5:
+		// V5 had 11 fields.
+		opts.override = true
+		opts.numExpectedMinFields = 6
+		opts.numExpectedCurrFields = 11
 	}
 
 	numFieldsToSkip, actual, ok := dec.checkNumFieldsFor(indexInfoType, opts)
@@ -301,6 +311,25 @@ func (dec *Decoder) decodeIndexInfo() schema.IndexInfo {
 	// Decode fields added in V4.
 	indexInfo.VolumeIndex = int(dec.decodeVarint())
 
+	// At this point if its a V4 file we've decoded all the available fields.
+	if dec.legacy.decodeLegacyIndexInfoVersion == legacyEncodingIndexVersionV4 || actual < 11 {
+		dec.skip(numFieldsToSkip)
+		return indexInfo
+	}
+
+	// Decode fields added in V5.
+	indexInfo.DataCompression = persist.DataCompressionType(dec.decodeVarint())
+
+	// At this point if its a V5 file we've decoded all the available fields.
+	if dec.legacy.decodeLegacyIndexInfoVersion == legacyEncodingIndexVersionV5 || actual < 13 {
+		dec.skip(numFieldsToSkip)
+		return indexInfo
+	}
+
+	// Decode fields added in V6.
+	indexInfo.EncryptionKeyID, _, _ = dec.decodeBytes()
+	indexInfo.WrappedDataKey, _, _ = dec.decodeBytes()
+
 	dec.skip(numFieldsToSkip)
 	return indexInfo
 }
@@ -44,10 +44,13 @@ var (
 			NumElementsM: 2075674,
 			NumHashesK:   7,
 		},
-		SnapshotTime: time.Now().UnixNano(),
-		FileType:     persist.FileSetSnapshotType,
-		SnapshotID:   []byte("some_bytes"),
-		VolumeIndex:  1,
+		SnapshotTime:    time.Now().UnixNano(),
+		FileType:        persist.FileSetSnapshotType,
+		SnapshotID:      []byte("some_bytes"),
+		VolumeIndex:     1,
+		DataCompression: persist.ZstdDataCompression,
+		EncryptionKeyID: []byte("some_key_id"),
+		WrappedDataKey:  []byte("some_wrapped_key"),
 	}
 
 	testIndexEntry = schema.IndexEntry{
@@ -99,7 +102,7 @@ func TestIndexInfoRoundtrip(t *testing.T) {
 	require.Equal(t, testIndexInfo, res)
 }
 
-// Make sure the V4 decoding code can handle the V1 file format.
+// Make sure the V6 decoding code can handle the V1 file format.
 func TestIndexInfoRoundTripBackwardsCompatibilityV1(t *testing.T) {
 	var (
 		opts = legacyEncodingOptions{encodeLegacyIndexInfoVersion: legacyEncodingIndexVersionV1}
@@ -113,20 +116,29 @@ func TestIndexInfoRoundTripBackwardsCompatibilityV1(t *testing.T) {
 	// because the new decoder won't try and read the new fields from
 	// the old file format
 	var (
-		currSnapshotTime = testIndexInfo.SnapshotTime
-		currFileType     = testIndexInfo.FileType
-		currSnapshotID   = testIndexInfo.SnapshotID
-		currVolumeIndex  = testIndexInfo.VolumeIndex
+		currSnapshotTime    = testIndexInfo.SnapshotTime
+		currFileType        = testIndexInfo.FileType
+		currSnapshotID      = testIndexInfo.SnapshotID
+		currVolumeIndex     = testIndexInfo.VolumeIndex
+		currDataCompression = testIndexInfo.DataCompression
+		currEncryptionKeyID = testIndexInfo.EncryptionKeyID
+		currWrappedDataKey  = testIndexInfo.WrappedDataKey
 	)
 	testIndexInfo.SnapshotTime = 0
 	testIndexInfo.FileType = 0
 	testIndexInfo.SnapshotID = nil
 	testIndexInfo.VolumeIndex = 0
+	testIndexInfo.DataCompression = 0
+	testIndexInfo.EncryptionKeyID = nil
+	testIndexInfo.WrappedDataKey = nil
 	defer func() {
 		testIndexInfo.SnapshotTime = currSnapshotTime
 		testIndexInfo.FileType = currFileType
 		testIndexInfo.SnapshotID = currSnapshotID
 		testIndexInfo.VolumeIndex = currVolumeIndex
+		testIndexInfo.DataCompression = currDataCompression
+		testIndexInfo.EncryptionKeyID = currEncryptionKeyID
+		testIndexInfo.WrappedDataKey = currWrappedDataKey
 	}()
 
 	enc.EncodeIndexInfo(testIndexInfo)
@@ -136,7 +148,7 @@ func TestIndexInfoRoundTripBackwardsCompatibilityV1(t *testing.T) {
 	require.Equal(t, testIndexInfo, res)
 }
 
-// Make sure the V1 decoder code can handle the V4 file format.
+// Make sure the V1 decoder code can handle the V6 file format.
 func TestIndexInfoRoundTripForwardsCompatibilityV1(t *testing.T) {
 	var (
 		opts = legacyEncodingOptions{decodeLegacyIndexInfoVersion: legacyEncodingIndexVersionV1}
@@ -148,10 +160,13 @@ func TestIndexInfoRoundTripForwardsCompatibilityV1(t *testing.T) {
 	// and then restore them at the end of the test - This is required
 	// because the old decoder won't read the new fields
 	var (
-		currSnapshotTime = testIndexInfo.SnapshotTime
-		currFileType     = testIndexInfo.FileType
-		currSnapshotID   = testIndexInfo.SnapshotID
-		currVolumeIndex  = testIndexInfo.VolumeIndex
+		currSnapshotTime    = testIndexInfo.SnapshotTime
+		currFileType        = testIndexInfo.FileType
+		currSnapshotID      = testIndexInfo.SnapshotID
+		currVolumeIndex     = testIndexInfo.VolumeIndex
+		currDataCompression = testIndexInfo.DataCompression
+		currEncryptionKeyID = testIndexInfo.EncryptionKeyID
+		currWrappedDataKey  = testIndexInfo.WrappedDataKey
 	)
 
 	enc.EncodeIndexInfo(testIndexInfo)
@@ -162,11 +177,17 @@ func TestIndexInfoRoundTripForwardsCompatibilityV1(t *testing.T) {
 	testIndexInfo.FileType = 0
 	testIndexInfo.SnapshotID = nil
 	testIndexInfo.VolumeIndex = 0
+	testIndexInfo.DataCompression = 0
+	testIndexInfo.EncryptionKeyID = nil
+	testIndexInfo.WrappedDataKey = nil
 	defer func() {
 		testIndexInfo.SnapshotTime = currSnapshotTime
 		testIndexInfo.FileType = currFileType
 		testIndexInfo.SnapshotID = currSnapshotID
 		testIndexInfo.VolumeIndex = currVolumeIndex
+		testIndexInfo.DataCompression = currDataCompression
+		testIndexInfo.EncryptionKeyID = currEncryptionKeyID
+		testIndexInfo.WrappedDataKey = currWrappedDataKey
 	}()
 
 	dec.Reset(NewByteDecoderStream(enc.Bytes()))
@@ -175,7 +196,7 @@ func TestIndexInfoRoundTripForwardsCompatibilityV1(t *testing.T) {
 	require.Equal(t, testIndexInfo, res)
 }
 
-// Make sure the V4 decoding code can handle the V2 file format.
+// Make sure the V6 decoding code can handle the V2 file format.
 func TestIndexInfoRoundTripBackwardsCompatibilityV2(t *testing.T) {
 	var (
 		opts = legacyEncodingOptions{encodeLegacyIndexInfoVersion: legacyEncodingIndexVersionV2}
@@ -188,20 +209,29 @@ func TestIndexInfoRoundTripBackwardsCompatibilityV2(t *testing.T) {
 	// because the new decoder won't try and read the new fields from
 	// the old file format.
 	var (
-		currSnapshotTime = testIndexInfo.SnapshotTime
-		currFileType     = testIndexInfo.FileType
-		currSnapshotID   = testIndexInfo.SnapshotID
-		currVolumeIndex  = testIndexInfo.VolumeIndex
+		currSnapshotTime    = testIndexInfo.SnapshotTime
+		currFileType        = testIndexInfo.FileType
+		currSnapshotID      = testIndexInfo.SnapshotID
+		currVolumeIndex     = testIndexInfo.VolumeIndex
+		currDataCompression = testIndexInfo.DataCompression
+		currEncryptionKeyID = testIndexInfo.EncryptionKeyID
+		currWrappedDataKey  = testIndexInfo.WrappedDataKey
 	)
 	testIndexInfo.SnapshotTime = 0
 	testIndexInfo.FileType = 0
 	testIndexInfo.SnapshotID = nil
 	testIndexInfo.VolumeIndex = 0
+	testIndexInfo.DataCompression = 0
+	testIndexInfo.EncryptionKeyID = nil
+	testIndexInfo.WrappedDataKey = nil
 	defer func() {
 		testIndexInfo.SnapshotTime = currSnapshotTime
 		testIndexInfo.FileType = currFileType
 		testIndexInfo.SnapshotID = currSnapshotID
 		testIndexInfo.VolumeIndex = currVolumeIndex
+		testIndexInfo.DataCompression = currDataCompression
+		testIndexInfo.EncryptionKeyID = currEncryptionKeyID
+		testIndexInfo.WrappedDataKey = currWrappedDataKey
 	}()
 
 	enc.EncodeIndexInfo(testIndexInfo)
@@ -211,7 +241,7 @@ func TestIndexInfoRoundTripBackwardsCompatibilityV2(t *testing.T) {
 	require.Equal(t, testIndexInfo, res)
 }
 
-// Make sure the V2 decoder code can handle the V4 file format.
+// Make sure the V2 decoder code can handle the V6 file format.
 func TestIndexInfoRoundTripForwardsCompatibilityV2(t *testing.T) {
 	var (
 		opts = legacyEncodingOptions{decodeLegacyIndexInfoVersion: legacyEncodingIndexVersionV2}
@@ -224,6 +254,9 @@ func TestIndexInfoRoundTripForwardsCompatibilityV2(t *testing.T) {
 	// because the old decoder won't read the new fields.
 	currSnapshotID := testIndexInfo.SnapshotID
 	currVolumeIndex := testIndexInfo.VolumeIndex
+	currDataCompression := testIndexInfo.DataCompression
+	currEncryptionKeyID := testIndexInfo.EncryptionKeyID
+	currWrappedDataKey := testIndexInfo.WrappedDataKey
 
 	enc.EncodeIndexInfo(testIndexInfo)
 
@@ -231,9 +264,15 @@ func TestIndexInfoRoundTripForwardsCompatibilityV2(t *testing.T) {
 	// encoded the data.
 	testIndexInfo.SnapshotID = nil
 	testIndexInfo.VolumeIndex = 0
+	testIndexInfo.DataCompression = 0
+	testIndexInfo.EncryptionKeyID = nil
+	testIndexInfo.WrappedDataKey = nil
 	defer func() {
 		testIndexInfo.SnapshotID = currSnapshotID
 		testIndexInfo.VolumeIndex = currVolumeIndex
+		testIndexInfo.DataCompression = currDataCompression
+		testIndexInfo.EncryptionKeyID = currEncryptionKeyID
+		testIndexInfo.WrappedDataKey = currWrappedDataKey
 	}()
 
 	dec.Reset(NewByteDecoderStream(enc.Bytes()))
@@ -242,7 +281,7 @@ func TestIndexInfoRoundTripForwardsCompatibilityV2(t *testing.T) {
 	require.Equal(t, testIndexInfo, res)
 }
 
-// Make sure the V4 decoding code can handle the V3 file format.
+// Make sure the V6 decoding code can handle the V3 file format.
 func TestIndexInfoRoundTripBackwardsCompatibilityV3(t *testing.T) {
 	var (
 		opts = legacyEncodingOptions{encodeLegacyIndexInfoVersion: legacyEncodingIndexVersionV3}
@@ -255,11 +294,20 @@ func TestIndexInfoRoundTripBackwardsCompatibilityV3(t *testing.T) {
 	// because the new decoder won't try and read the new fields from
 	// the old file format.
 	var (
-		currVolumeIndex = testIndexInfo.VolumeIndex
+		currVolumeIndex     = testIndexInfo.VolumeIndex
+		currDataCompression = testIndexInfo.DataCompression
+		currEncryptionKeyID = testIndexInfo.EncryptionKeyID
+		currWrappedDataKey  = testIndexInfo.WrappedDataKey
 	)
 	testIndexInfo.VolumeIndex = 0
+	testIndexInfo.DataCompression = 0
+	testIndexInfo.EncryptionKeyID = nil
+	testIndexInfo.WrappedDataKey = nil
 	defer func() {
 		testIndexInfo.VolumeIndex = currVolumeIndex
+		testIndexInfo.DataCompression = currDataCompression
+		testIndexInfo.EncryptionKeyID = currEncryptionKeyID
+		testIndexInfo.WrappedDataKey = currWrappedDataKey
 	}()
 
 	enc.EncodeIndexInfo(testIndexInfo)
@@ -269,7 +317,7 @@ func TestIndexInfoRoundTripBackwardsCompatibilityV3(t *testing.T) {
 	require.Equal(t, testIndexInfo, res)
 }
 
-// Make sure the V3 decoder code can handle the V4 file format.
+// Make sure the V3 decoder code can handle the V6 file format.
 func TestIndexInfoRoundTripForwardsCompatibilityV3(t *testing.T) {
 	var (
 		opts = legacyEncodingOptions{decodeLegacyIndexInfoVersion: legacyEncodingIndexVersionV3}
@@ -281,14 +329,151 @@ func TestIndexInfoRoundTripForwardsCompatibilityV3(t *testing.T) {
 	// and then restore them at the end of the test - This is required
 	// because the old decoder won't read the new fields.
 	currVolumeIndex := testIndexInfo.VolumeIndex
+	currDataCompression := testIndexInfo.DataCompression
+	currEncryptionKeyID := testIndexInfo.EncryptionKeyID
+	currWrappedDataKey := testIndexInfo.WrappedDataKey
 
 	enc.EncodeIndexInfo(testIndexInfo)
 
 	// Make sure to zero them before we compare, but after we have
 	// encoded the data.
 	testIndexInfo.VolumeIndex = 0
+	testIndexInfo.DataCompression = 0
+	testIndexInfo.EncryptionKeyID = nil
+	testIndexInfo.WrappedDataKey = nil
 	defer func() {
 		testIndexInfo.VolumeIndex = currVolumeIndex
+		testIndexInfo.DataCompression = currDataCompression
+		testIndexInfo.EncryptionKeyID = currEncryptionKeyID
+		testIndexInfo.WrappedDataKey = currWrappedDataKey
+	}()
+
+	dec.Reset(NewByteDecoderStream(enc.Bytes()))
+	res, err := dec.DecodeIndexInfo()
+	require.NoError(t, err)
+	require.Equal(t, testIndexInfo, res)
+}
+
+// Make sure the V6 decoding code can handle the V4 file format.
+func TestIndexInfoRoundTripBackwardsCompatibilityV4(t *testing.T) {
+	var (
+		opts = legacyEncodingOptions{encodeLegacyIndexInfoVersion: legacyEncodingIndexVersionV4}
+		enc  = newEncoder(opts)
+		dec  = newDecoder(opts, nil)
+	)
+
+	// Set the default values on the fields that did not exist in V4,
+	// and then restore them at the end of the test - This is required
+	// because the new decoder won't try and read the new fields from
+	// the old file format.
+	currDataCompression := testIndexInfo.DataCompression
+	currEncryptionKeyID := testIndexInfo.EncryptionKeyID
+	currWrappedDataKey := testIndexInfo.WrappedDataKey
+	testIndexInfo.DataCompression = 0
+	testIndexInfo.EncryptionKeyID = nil
+	testIndexInfo.WrappedDataKey = nil
+	defer func() {
+		testIndexInfo.DataCompression = currDataCompression
+		testIndexInfo.EncryptionKeyID = currEncryptionKeyID
+		testIndexInfo.WrappedDataKey = currWrappedDataKey
+	}()
+
+	enc.EncodeIndexInfo(testIndexInfo)
+	dec.Reset(NewByteDecoderStream(enc.Bytes()))
+	res, err := dec.DecodeIndexInfo()
+	require.NoError(t, err)
+	require.Equal(t, testIndexInfo, res)
+}
+
+// Make sure the V4 decoder code can handle the V6 file format.
+func TestIndexInfoRoundTripForwardsCompatibilityV4(t *testing.T) {
+	var (
+		opts = legacyEncodingOptions{decodeLegacyIndexInfoVersion: legacyEncodingIndexVersionV4}
+		enc  = newEncoder(opts)
+		dec  = newDecoder(opts, nil)
+	)
+
+	// Set the default values on the fields that did not exist in V4
+	// and then restore them at the end of the test - This is required
+	// because the old decoder won't read the new fields.
+	currDataCompression := testIndexInfo.DataCompression
+	currEncryptionKeyID := testIndexInfo.EncryptionKeyID
+	currWrappedDataKey := testIndexInfo.WrappedDataKey
+
+	enc.EncodeIndexInfo(testIndexInfo)
+
+	// Make sure to zero them before we compare, but after we have
+	// encoded the data.
+	testIndexInfo.DataCompression = 0
+	testIndexInfo.EncryptionKeyID = nil
+	testIndexInfo.WrappedDataKey = nil
+	defer func() {
+		testIndexInfo.DataCompression = currDataCompression
+		testIndexInfo.EncryptionKeyID = currEncryptionKeyID
+		testIndexInfo.WrappedDataKey = currWrappedDataKey
+	}()
+
+	dec.Reset(NewByteDecoderStream(enc.Bytes()))
+	res, err := dec.DecodeIndexInfo()
+	require.NoError(t, err)
+	require.Equal(t, testIndexInfo, res)
+}
+
+// Make sure the V6 decoding code can handle the V5 file format.
+func TestIndexInfoRoundTripBackwardsCompatibilityV5(t *testing.T) {
+	var (
+		opts = legacyEncodingOptions{encodeLegacyIndexInfoVersion: legacyEncodingIndexVersionV5}
+		enc  = newEncoder(opts)
+		dec  = newDecoder(opts, nil)
+	)
+
+	// Set the default values on the fields that did not exist in V5,
+	// and then restore them at the end of the test - This is required
+	// because the new decoder won't try and read the new fields from
+	// the old file format.
+	var (
+		currEncryptionKeyID = testIndexInfo.EncryptionKeyID
+		currWrappedDataKey  = testIndexInfo.WrappedDataKey
+	)
+	testIndexInfo.EncryptionKeyID = nil
+	testIndexInfo.WrappedDataKey = nil
+	defer func() {
+		testIndexInfo.EncryptionKeyID = currEncryptionKeyID
+		testIndexInfo.WrappedDataKey = currWrappedDataKey
+	}()
+
+	enc.EncodeIndexInfo(testIndexInfo)
+	dec.Reset(NewByteDecoderStream(enc.Bytes()))
+	res, err := dec.DecodeIndexInfo()
+	require.NoError(t, err)
+	require.Equal(t, testIndexInfo, res)
+}
+
+// Make sure the V5 decoder code can handle the V6 file format.
+func TestIndexInfoRoundTripForwardsCompatibilityV5(t *testing.T) {
+	var (
+		opts = legacyEncodingOptions{decodeLegacyIndexInfoVersion: legacyEncodingIndexVersionV5}
+		enc  = newEncoder(opts)
+		dec  = newDecoder(opts, nil)
+	)
+
+	// Set the default values on the fields that did not exist in V5
+	// and then restore them at the end of the test - This is required
+	// because the old decoder won't read the new fields.
+	var (
+		currEncryptionKeyID = testIndexInfo.EncryptionKeyID
+		currWrappedDataKey  = testIndexInfo.WrappedDataKey
+	)
+
+	enc.EncodeIndexInfo(testIndexInfo)
+
+	// Make sure to zero them before we compare, but after we have
+	// encoded the data.
+	testIndexInfo.EncryptionKeyID = nil
+	testIndexInfo.WrappedDataKey = nil
+	defer func() {
+		testIndexInfo.EncryptionKeyID = currEncryptionKeyID
+		testIndexInfo.WrappedDataKey = currWrappedDataKey
 	}()
 
 	dec.Reset(NewByteDecoderStream(enc.Bytes()))
@@ -0,0 +1,503 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+)
+
+var (
+	// ErrObjectNotFound is returned by an ObjectStore when an object does not exist.
+	ErrObjectNotFound = errors.New("object not found")
+
+	errImportVolumeExists = errors.New("fileset volume to import as already exists")
+
+	// evictableDataFileSetSuffixes are the suffixes of the files of a data
+	// fileset volume that are evicted from local disk once it has been uploaded
+	// to an object store. The info, digest and checkpoint files are small and
+	// kept so that the volume is still found by scans of local disk.
+	evictableDataFileSetSuffixes = []string{
+		indexFileSuffix,
+		summariesFileSuffix,
+		bloomFilterFileSuffix,
+		dataFileSuffix,
+	}
+
+	// dataFileSetObjectSuffixes are the suffixes of the files of a data fileset
+	// volume in the order they are transferred to and from an object store, the
+	// checkpoint file is last so that a volume is only ever considered complete
+	// once every other file has been transferred.
+	dataFileSetObjectSuffixes = []string{
+		infoFileSuffix,
+		indexFileSuffix,
+		summariesFileSuffix,
+		bloomFilterFileSuffix,
+		dataFileSuffix,
+		digestFileSuffix,
+		checkpointFileSuffix,
+	}
+)
+
+// writeFileAtomically writes to a temporary file that is renamed into place
+// so that a partially written file is never observed at the path, the
+// temporary file is unique so that concurrent writes of the same path do not
+// interleave.
+func writeFileAtomically(path string, r io.Reader, perm os.FileMode) error {
+	fd, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := fd.Name()
+	if err := fd.Chmod(perm); err != nil {
+		fd.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if _, err := io.Copy(fd, r); err != nil {
+		// NB: Failure to write takes precedence over failure to close.
+		fd.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := fd.Sync(); err != nil {
+		fd.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := fd.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// objectStoreKey returns the key of the object for a file beneath the file
+// path prefix, which is its slash separated path relative to the prefix so
+// that the layout of the object store mirrors that of local disk.
+func objectStoreKey(filePathPrefix string, filePath string) (string, error) {
+	rel, err := filepath.Rel(filePathPrefix, filePath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func dataFileSetObjectPaths(
+	filePathPrefix string,
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+	volume int,
+) []string {
+	shardDir := ShardDataDirPath(filePathPrefix, namespace, shard)
+	paths := make([]string, 0, len(dataFileSetObjectSuffixes))
+	for _, suffix := range dataFileSetObjectSuffixes {
+		paths = append(paths, dataFilesetPathFromTimeAndIndex(
+			shardDir, blockStart, volume, suffix, false))
+	}
+	return paths
+}
+
+// UploadDataFileSet uploads the files of a complete data fileset volume on
+// local disk to the object store.
+func UploadDataFileSet(
+	store ObjectStore,
+	filePathPrefix string,
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+	volume int,
+) error {
+	paths := dataFileSetObjectPaths(filePathPrefix, namespace, shard, blockStart, volume)
+	for _, path := range paths {
+		key, err := objectStoreKey(filePathPrefix, path)
+		if err != nil {
+			return err
+		}
+		fd, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		stat, err := fd.Stat()
+		if err != nil {
+			fd.Close()
+			return err
+		}
+		err = store.Put(key, fd, stat.Size())
+		// NB: Failure to upload takes precedence over failure to close.
+		closeErr := fd.Close()
+		if err != nil {
+			return err
+		}
+		if closeErr != nil {
+			return closeErr
+		}
+	}
+	return nil
+}
+
+// DownloadDataFileSet downloads a data fileset volume from the object store
+// to local disk, returning false if the store holds no complete volume.
+func DownloadDataFileSet(
+	store ObjectStore,
+	opts Options,
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+	volume int,
+) (bool, error) {
+	var (
+		filePathPrefix = opts.NamespaceFilePathPrefix(namespace)
+		paths          = dataFileSetObjectPaths(filePathPrefix, namespace, shard, blockStart, volume)
+		checkpointPath = paths[len(paths)-1]
+	)
+	checkpointKey, err := objectStoreKey(filePathPrefix, checkpointPath)
+	if err != nil {
+		return false, err
+	}
+	exists, err := store.Exists(checkpointKey)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	shardDir := ShardDataDirPath(filePathPrefix, namespace, shard)
+	if err := os.MkdirAll(shardDir, opts.NewDirectoryMode()); err != nil {
+		return false, err
+	}
+	for _, path := range paths {
+		key, err := objectStoreKey(filePathPrefix, path)
+		if err != nil {
+			return false, err
+		}
+		r, err := store.Get(key)
+		if err != nil {
+			return false, err
+		}
+		err = writeFileAtomically(path, r, opts.NewFileMode())
+		// NB: Failure to download takes precedence over failure to close.
+		closeErr := r.Close()
+		if err != nil {
+			return false, err
+		}
+		if closeErr != nil {
+			return false, closeErr
+		}
+	}
+	return true, nil
+}
+
+// DeleteDataFileSetObjects deletes the objects of the data fileset volumes
+// that the given files beneath the file path prefix belong to from the object
+// store, such as when the filesets are cleaned up from local disk once expired
+// or compacted. Every file of a volume is deleted even if only some of its
+// files are given since evicted files are not found by scans of local disk.
+func DeleteDataFileSetObjects(
+	store ObjectStore,
+	filePathPrefix string,
+	paths []string,
+) error {
+	var (
+		multiErr = xerrors.NewMultiError()
+		deleted  = make(map[string]struct{}, len(paths))
+	)
+	for _, path := range paths {
+		for _, volumePath := range dataFileSetVolumePaths(path) {
+			if _, ok := deleted[volumePath]; ok {
+				continue
+			}
+			deleted[volumePath] = struct{}{}
+
+			key, err := objectStoreKey(filePathPrefix, volumePath)
+			if err != nil {
+				multiErr = multiErr.Add(err)
+				continue
+			}
+			multiErr = multiErr.Add(store.Delete(key))
+		}
+	}
+	return multiErr.FinalError()
+}
+
+// dataFileSetVolumePaths returns the paths of all the files of the data
+// fileset volume that the file at the given path belongs to.
+func dataFileSetVolumePaths(path string) []string {
+	base := strings.TrimSuffix(path, fileSuffix)
+	idx := strings.LastIndex(base, separator)
+	if base == path || idx < 0 {
+		return []string{path}
+	}
+
+	paths := make([]string, 0, len(dataFileSetObjectSuffixes))
+	for _, suffix := range dataFileSetObjectSuffixes {
+		paths = append(paths, base[:idx+len(separator)]+suffix+fileSuffix)
+	}
+	return paths
+}
+
+// ensureDataFileSetLocal downloads a data fileset volume from the object store
+// if it is missing from local disk or its files were evicted, returning false
+// if the volume exists neither on local disk nor in the object store.
+func ensureDataFileSetLocal(
+	opts Options,
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+	volume int,
+) (exists bool, downloaded bool, err error) {
+	filePathPrefix := opts.NamespaceFilePathPrefix(namespace)
+	exists, err = DataFileSetExists(filePathPrefix, namespace, shard, blockStart, volume)
+	if err != nil {
+		return false, false, err
+	}
+	store := opts.ObjectStore()
+	if store == nil {
+		return exists, false, nil
+	}
+	if exists {
+		evicted, err := dataFileSetEvicted(filePathPrefix, namespace, shard, blockStart, volume)
+		if err != nil || !evicted {
+			return exists, false, err
+		}
+	}
+
+	exists, err = DownloadDataFileSet(store, opts, namespace, shard, blockStart, volume)
+	if err != nil {
+		return false, false, err
+	}
+	return exists, exists, nil
+}
+
+// dataFileSetEvicted returns whether the evictable files of a data fileset
+// volume are missing from local disk while its checkpoint file is not, legacy
+// volumes are never evicted.
+func dataFileSetEvicted(
+	filePathPrefix string,
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+	volume int,
+) (bool, error) {
+	shardDir := ShardDataDirPath(filePathPrefix, namespace, shard)
+	_, err := os.Stat(dataFilesetPathFromTimeAndIndex(
+		shardDir, blockStart, volume, checkpointFileSuffix, false))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for _, suffix := range evictableDataFileSetSuffixes {
+		_, err := os.Stat(dataFilesetPathFromTimeAndIndex(
+			shardDir, blockStart, volume, suffix, false))
+		if os.IsNotExist(err) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// dataFileSetUploaded returns whether a data fileset volume has been uploaded
+// to the object store in full.
+func dataFileSetUploaded(
+	store ObjectStore,
+	filePathPrefix string,
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+	volume int,
+) (bool, error) {
+	// NB: The checkpoint file is uploaded last so its object only exists
+	// once every other file of the volume has been uploaded.
+	paths := dataFileSetObjectPaths(filePathPrefix, namespace, shard, blockStart, volume)
+	checkpointKey, err := objectStoreKey(filePathPrefix, paths[len(paths)-1])
+	if err != nil {
+		return false, err
+	}
+	return store.Exists(checkpointKey)
+}
+
+// removeEvictableDataFileSetFiles evicts the files of a data fileset volume
+// that has been uploaded to the object store from local disk, keeping its
+// info, digest and checkpoint files so that it is still found by scans of
+// local disk. The volume is downloaded again when next opened for reading.
+func removeEvictableDataFileSetFiles(
+	filePathPrefix string,
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+	volume int,
+) error {
+	var (
+		shardDir = ShardDataDirPath(filePathPrefix, namespace, shard)
+		multiErr = xerrors.NewMultiError()
+	)
+	for _, suffix := range evictableDataFileSetSuffixes {
+		err := os.Remove(dataFilesetPathFromTimeAndIndex(
+			shardDir, blockStart, volume, suffix, false))
+		if err != nil && !os.IsNotExist(err) {
+			multiErr = multiErr.Add(err)
+		}
+	}
+	return multiErr.FinalError()
+}
+
+// ImportDataFileSet imports a data fileset volume from the object store to
+// local disk as the given volume, which need not be the volume it was uploaded
+// as, returning false if the store holds no complete volume. Since the info
+// file records the volume index it is rewritten for the new volume and the
+// digests and checkpoint file are recomputed to match.
+func ImportDataFileSet(
+	store ObjectStore,
+	opts Options,
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+	exportedVolume int,
+	volume int,
+) (bool, error) {
+	var (
+		filePathPrefix = opts.NamespaceFilePathPrefix(namespace)
+		exportedPaths  = dataFileSetObjectPaths(filePathPrefix, namespace, shard, blockStart, exportedVolume)
+		paths          = dataFileSetObjectPaths(filePathPrefix, namespace, shard, blockStart, volume)
+		keys           = make([]string, 0, len(exportedPaths))
+	)
+	for _, path := range exportedPaths {
+		key, err := objectStoreKey(filePathPrefix, path)
+		if err != nil {
+			return false, err
+		}
+		keys = append(keys, key)
+	}
+	exists, err := store.Exists(keys[len(keys)-1])
+	if err != nil || !exists {
+		return false, err
+	}
+	for _, path := range paths {
+		_, err := os.Stat(path)
+		if err == nil {
+			return false, errImportVolumeExists
+		}
+		if !os.IsNotExist(err) {
+			return false, err
+		}
+	}
+
+	contents := make(map[string][]byte, 3)
+	for i, suffix := range dataFileSetObjectSuffixes {
+		if suffix != infoFileSuffix && suffix != digestFileSuffix {
+			continue
+		}
+		data, err := readObject(store, keys[i])
+		if err != nil {
+			return false, err
+		}
+		contents[suffix] = data
+	}
+
+	info, digests := contents[infoFileSuffix], contents[digestFileSuffix]
+	if len(digests) < digest.DigestLenBytes {
+		return false, fmt.Errorf("fileset digests too short: %d bytes", len(digests))
+	}
+	if expected, actual := digest.ToBuffer(digests).ReadDigest(), digest.Checksum(info); expected != actual {
+		return false, fmt.Errorf("fileset info file digest mismatch: expected=%d, actual=%d",
+			expected, actual)
+	}
+
+	decoder := msgpack.NewDecoder(opts.DecodingOptions())
+	decoder.Reset(msgpack.NewByteDecoderStream(info))
+	indexInfo, err := decoder.DecodeIndexInfo()
+	if err != nil {
+		return false, err
+	}
+	indexInfo.VolumeIndex = volume
+	encoder := msgpack.NewEncoder()
+	if err := encoder.EncodeIndexInfo(indexInfo); err != nil {
+		return false, err
+	}
+	info = encoder.Bytes()
+
+	// NB: The info file digest is the first of the digests.
+	digests = append([]byte(nil), digests...)
+	digest.ToBuffer(digests).WriteDigest(digest.Checksum(info))
+	checkpoint := digest.NewBuffer()
+	checkpoint.WriteDigest(digest.Checksum(digests))
+	contents[infoFileSuffix] = info
+	contents[digestFileSuffix] = digests
+	contents[checkpointFileSuffix] = checkpoint
+
+	shardDir := ShardDataDirPath(filePathPrefix, namespace, shard)
+	if err := os.MkdirAll(shardDir, opts.NewDirectoryMode()); err != nil {
+		return false, err
+	}
+	for i, suffix := range dataFileSetObjectSuffixes {
+		if data, ok := contents[suffix]; ok {
+			if err := writeFileAtomically(paths[i], bytes.NewReader(data), opts.NewFileMode()); err != nil {
+				return false, err
+			}
+			continue
+		}
+		r, err := store.Get(keys[i])
+		if err != nil {
+			return false, err
+		}
+		err = writeFileAtomically(paths[i], r, opts.NewFileMode())
+		// NB: Failure to import takes precedence over failure to close.
+		closeErr := r.Close()
+		if err != nil {
+			return false, err
+		}
+		if closeErr != nil {
+			return false, closeErr
+		}
+	}
+	return true, nil
+}
+
+func readObject(store ObjectStore, key string) ([]byte, error) {
+	r, err := store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(r)
+	// NB: Failure to read takes precedence over failure to close.
+	closeErr := r.Close()
+	if err != nil {
+		return nil, err
+	}
+	return data, closeErr
+}
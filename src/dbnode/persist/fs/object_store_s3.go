@@ -0,0 +1,348 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3SigningAlgorithm   = "AWS4-HMAC-SHA256"
+	s3SigningService     = "s3"
+	s3UnsignedPayload    = "UNSIGNED-PAYLOAD"
+	s3EmptyPayloadHash   = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	s3AmzDateFormat      = "20060102T150405Z"
+	s3ErrorBodyMaxLength = 512
+	s3DefaultTimeout     = 5 * time.Minute
+)
+
+var (
+	errS3ObjectStoreEndpointEmpty    = errors.New("s3 object store endpoint is empty")
+	errS3ObjectStoreRegionEmpty      = errors.New("s3 object store region is empty")
+	errS3ObjectStoreBucketEmpty      = errors.New("s3 object store bucket is empty")
+	errS3ObjectStoreCredentialsEmpty = errors.New("s3 object store credentials are empty")
+)
+
+// S3ObjectStoreOptions are the options for an object store that keeps objects
+// in a bucket of a service implementing the S3 API, such as S3 itself, GCS
+// with HMAC keys or MinIO.
+type S3ObjectStoreOptions struct {
+	// Endpoint is the URL of the service, such as https://s3.us-east-1.amazonaws.com
+	// or https://storage.googleapis.com, buckets are addressed by path.
+	Endpoint string
+	// Region is the region the requests are signed for.
+	Region string
+	// Bucket is the bucket the objects are kept in.
+	Bucket string
+	// KeyPrefix is prepended to the key of each object so that a bucket can
+	// be shared, such as by multiple clusters.
+	KeyPrefix string
+	// AccessKeyID is the ID of the access key the requests are signed with.
+	AccessKeyID string
+	// SecretAccessKey is the secret of the access key the requests are
+	// signed with.
+	SecretAccessKey string
+	// SessionToken is the token of temporary credentials, if any.
+	SessionToken string
+	// HTTPClient is the client the requests are made with, if nil a client
+	// with a default timeout is used.
+	HTTPClient *http.Client
+}
+
+type s3ObjectStore struct {
+	opts     S3ObjectStoreOptions
+	endpoint *url.URL
+	client   *http.Client
+	nowFn    func() time.Time
+}
+
+// NewS3ObjectStore returns an object store that keeps objects in a bucket of
+// a service implementing the S3 API, signing requests with signature V4.
+// Objects are uploaded with a single request so each must be within the
+// maximum object size of a single upload of the service.
+func NewS3ObjectStore(opts S3ObjectStoreOptions) (ObjectStore, error) {
+	if opts.Endpoint == "" {
+		return nil, errS3ObjectStoreEndpointEmpty
+	}
+	if opts.Region == "" {
+		return nil, errS3ObjectStoreRegionEmpty
+	}
+	if opts.Bucket == "" {
+		return nil, errS3ObjectStoreBucketEmpty
+	}
+	if opts.AccessKeyID == "" || opts.SecretAccessKey == "" {
+		return nil, errS3ObjectStoreCredentialsEmpty
+	}
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 object store endpoint: %v", err)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 object store endpoint: %s", opts.Endpoint)
+	}
+
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: s3DefaultTimeout}
+	}
+	return &s3ObjectStore{
+		opts:     opts,
+		endpoint: endpoint,
+		client:   client,
+		nowFn:    time.Now,
+	}, nil
+}
+
+func (s *s3ObjectStore) Put(key string, r io.Reader, size int64) error {
+	req, err := s.newRequest(http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		// NB: Otherwise a content length of zero is treated as unknown and
+		// the body is sent chunked, which is not supported.
+		req.Body = http.NoBody
+	}
+
+	resp, err := s.do(req, s3UnsignedPayload)
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return s3ResponseError(req, resp)
+	}
+	return nil
+}
+
+func (s *s3ObjectStore) Get(key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req, s3EmptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		drainAndClose(resp.Body)
+		return nil, ErrObjectNotFound
+	default:
+		defer drainAndClose(resp.Body)
+		return nil, s3ResponseError(req, resp)
+	}
+}
+
+func (s *s3ObjectStore) Exists(key string) (bool, error) {
+	req, err := s.newRequest(http.MethodHead, key, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := s.do(req, s3EmptyPayloadHash)
+	if err != nil {
+		return false, err
+	}
+	defer drainAndClose(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, s3ResponseError(req, resp)
+	}
+}
+
+func (s *s3ObjectStore) Delete(key string) error {
+	req, err := s.newRequest(http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req, s3EmptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return s3ResponseError(req, resp)
+	}
+}
+
+func (s *s3ObjectStore) newRequest(method, key string, body io.Reader) (*http.Request, error) {
+	path := "/" + s.opts.Bucket + "/" + s.opts.KeyPrefix + key
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawPath = s3EscapePath(u.Path)
+	return http.NewRequest(method, u.String(), body)
+}
+
+func (s *s3ObjectStore) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.sign(req, payloadHash, s.nowFn())
+	return s.client.Do(req)
+}
+
+// sign signs the request with signature V4, signing the host and every
+// header set on the request.
+func (s *s3ObjectStore) sign(req *http.Request, payloadHash string, now time.Time) {
+	var (
+		amzDate = now.UTC().Format(s3AmzDateFormat)
+		date    = amzDate[:8]
+		scope   = strings.Join([]string{
+			date, s.opts.Region, s3SigningService, "aws4_request",
+		}, "/")
+	)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.opts.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.opts.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		trimmed := make([]string, 0, len(values))
+		for _, value := range values {
+			trimmed = append(trimmed, strings.Join(strings.Fields(value), " "))
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		s3SigningAlgorithm,
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	key := s3HMAC([]byte("AWS4"+s.opts.SecretAccessKey), date)
+	key = s3HMAC(key, s.opts.Region)
+	key = s3HMAC(key, s3SigningService)
+	key = s3HMAC(key, "aws4_request")
+	signature := hex.EncodeToString(s3HMAC(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s,SignedHeaders=%s,Signature=%s",
+		s3SigningAlgorithm, s.opts.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func s3CanonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	params := make([]string, 0, len(values))
+	for _, key := range keys {
+		vals := append([]string(nil), values[key]...)
+		sort.Strings(vals)
+		for _, val := range vals {
+			params = append(params, s3Escape(key)+"="+s3Escape(val))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// s3EscapePath escapes each segment of a path as required by signature V4.
+func s3EscapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3Escape escapes every byte except the unreserved characters of RFC 3986.
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') ||
+			(c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func s3ResponseError(req *http.Request, resp *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, s3ErrorBodyMaxLength))
+	return fmt.Errorf("s3 object store %s %s failed: status=%d, body=%s",
+		req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+func drainAndClose(body io.ReadCloser) {
+	// NB: Drain the body so that the connection can be reused.
+	io.Copy(ioutil.Discard, body)
+	body.Close()
+}
@@ -0,0 +1,330 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testObjectStore struct {
+	sync.Mutex
+	objects map[string][]byte
+}
+
+func newTestObjectStore() *testObjectStore {
+	return &testObjectStore{objects: make(map[string][]byte)}
+}
+
+func (s *testObjectStore) Put(key string, r io.Reader, size int64) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != size {
+		return fmt.Errorf("object size mismatch: expected=%d, actual=%d", size, len(data))
+	}
+	s.Lock()
+	s.objects[key] = data
+	s.Unlock()
+	return nil
+}
+
+func (s *testObjectStore) Get(key string) (io.ReadCloser, error) {
+	s.Lock()
+	data, ok := s.objects[key]
+	s.Unlock()
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *testObjectStore) Exists(key string) (bool, error) {
+	s.Lock()
+	_, ok := s.objects[key]
+	s.Unlock()
+	return ok, nil
+}
+
+func (s *testObjectStore) Delete(key string) error {
+	s.Lock()
+	delete(s.objects, key)
+	s.Unlock()
+	return nil
+}
+
+func TestWriterUploadsToObjectStore(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	store := newTestObjectStore()
+
+	entries := []testEntry{
+		{"foo", nil, []byte{1, 2, 3}},
+		{"bar", nil, []byte{4, 5, 6}},
+		{"foo+bar=baz,qux=qaz", map[string]string{
+			"bar": "baz",
+			"qux": "qaz",
+		}, []byte{7, 8, 9}},
+	}
+
+	opts := testDefaultOpts.
+		SetFilePathPrefix(filePathPrefix).
+		SetWriterBufferSize(testWriterBufferSize).
+		SetObjectStore(store)
+	w, err := NewWriter(opts)
+	require.NoError(t, err)
+
+	// Snapshots are not uploaded.
+	writeTestData(t, w, 0, testWriterStart, entries, persist.FileSetSnapshotType)
+	for _, path := range dataFileSetObjectPaths(filePathPrefix, testNs1ID, 0, testWriterStart, 0) {
+		key, err := objectStoreKey(filePathPrefix, path)
+		require.NoError(t, err)
+		exists, err := store.Exists(key)
+		require.NoError(t, err)
+		require.False(t, exists)
+	}
+
+	writeTestData(t, w, 0, testWriterStart, entries, persist.FileSetFlushType)
+	for _, path := range dataFileSetObjectPaths(filePathPrefix, testNs1ID, 0, testWriterStart, 0) {
+		key, err := objectStoreKey(filePathPrefix, path)
+		require.NoError(t, err)
+		exists, err := store.Exists(key)
+		require.NoError(t, err)
+		require.True(t, exists)
+	}
+
+	// Remove the local copy and fetch it back from the object store.
+	require.NoError(t, os.RemoveAll(ShardDataDirPath(filePathPrefix, testNs1ID, 0)))
+	exists, err := DataFileSetExists(filePathPrefix, testNs1ID, 0, testWriterStart, 0)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	downloaded, err := DownloadDataFileSet(store, opts, testNs1ID, 0, testWriterStart, 0)
+	require.NoError(t, err)
+	require.True(t, downloaded)
+	exists, err = DataFileSetExists(filePathPrefix, testNs1ID, 0, testWriterStart, 0)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	r := newTestReader(t, filePathPrefix)
+	readTestData(t, r, 0, testWriterStart, entries)
+
+	// Volumes that were never uploaded are not found.
+	downloaded, err = DownloadDataFileSet(store, opts, testNs1ID, 0, testWriterStart, 1)
+	require.NoError(t, err)
+	require.False(t, downloaded)
+
+	// Deleting any file of the volume deletes the objects of all its files.
+	paths := dataFileSetObjectPaths(filePathPrefix, testNs1ID, 0, testWriterStart, 0)
+	require.NoError(t, DeleteDataFileSetObjects(store, filePathPrefix, paths[:1]))
+	downloaded, err = DownloadDataFileSet(store, opts, testNs1ID, 0, testWriterStart, 0)
+	require.NoError(t, err)
+	require.False(t, downloaded)
+	for _, path := range paths {
+		key, err := objectStoreKey(filePathPrefix, path)
+		require.NoError(t, err)
+		exists, err := store.Exists(key)
+		require.NoError(t, err)
+		require.False(t, exists)
+	}
+}
+
+func TestSeekerManagerOpensSeekerFromObjectStore(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	store := newTestObjectStore()
+
+	opts := testDefaultOpts.
+		SetFilePathPrefix(filePathPrefix).
+		SetWriterBufferSize(testWriterBufferSize).
+		SetObjectStore(store)
+	w, err := NewWriter(opts)
+	require.NoError(t, err)
+	writeTestData(t, w, 0, testWriterStart, []testEntry{
+		{"foo", nil, []byte{1, 2, 3}},
+	}, persist.FileSetFlushType)
+	require.NoError(t, os.RemoveAll(ShardDataDirPath(filePathPrefix, testNs1ID, 0)))
+
+	m := NewSeekerManager(nil, opts, defaultTestBlockRetrieverOptions).(*seekerManager)
+	require.NoError(t, m.Open(testNs1Metadata(t)))
+
+	seeker, err := m.newOpenSeeker(0, testWriterStart, 0)
+	require.NoError(t, err)
+
+	resources := newTestReusableSeekerResources()
+	data, err := seeker.SeekByID(ident.StringID("foo"), resources)
+	require.NoError(t, err)
+	data.IncRef()
+	require.Equal(t, []byte{1, 2, 3}, data.Bytes())
+	data.DecRef()
+	require.NoError(t, seeker.Close())
+
+	_, err = m.newOpenSeeker(0, testWriterStart, 1)
+	require.Equal(t, errSeekerManagerFileSetNotFound, err)
+
+	require.NoError(t, m.Close())
+}
+
+func TestSeekerManagerEvictsLocalFileSets(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	store := newTestObjectStore()
+	entries := []testEntry{
+		{"foo", nil, []byte{1, 2, 3}},
+	}
+
+	opts := testDefaultOpts.
+		SetFilePathPrefix(filePathPrefix).
+		SetWriterBufferSize(testWriterBufferSize).
+		SetInfoReaderBufferSize(testReaderBufferSize).
+		SetDataReaderBufferSize(testReaderBufferSize).
+		SetObjectStore(store).
+		SetObjectStoreLocalRetention(time.Hour)
+	w, err := NewWriter(opts)
+	require.NoError(t, err)
+	writeTestData(t, w, 0, testWriterStart, entries, persist.FileSetFlushType)
+
+	// Filesets that were never uploaded are not evicted.
+	w, err = NewWriter(opts.SetObjectStore(nil))
+	require.NoError(t, err)
+	writeTestData(t, w, 1, testWriterStart, entries, persist.FileSetFlushType)
+
+	m := NewSeekerManager(nil, opts, defaultTestBlockRetrieverOptions).(*seekerManager)
+	require.NoError(t, m.Open(testNs1Metadata(t)))
+	defer func() {
+		require.NoError(t, m.Close())
+	}()
+
+	var (
+		earliestLocalBlockStart = testWriterStart.Add(time.Hour)
+		now                     = time.Now().Add(seekManagerEvictIdleTimeout)
+	)
+	for _, shard := range []uint32{0, 1} {
+		byTime := m.seekersByTime(shard)
+		require.NoError(t, m.evictLocalFileSetsForShard(byTime,
+			earliestLocalBlockStart, now))
+	}
+
+	// Only the uploaded fileset is evicted and it is still found on local disk.
+	for shard, expected := range []bool{true, false} {
+		evicted, err := dataFileSetEvicted(filePathPrefix, testNs1ID,
+			uint32(shard), testWriterStart, 0)
+		require.NoError(t, err)
+		require.Equal(t, expected, evicted)
+
+		exists, err := DataFileSetExists(filePathPrefix, testNs1ID,
+			uint32(shard), testWriterStart, 0)
+		require.NoError(t, err)
+		require.True(t, exists)
+	}
+
+	// Filesets are downloaded again when read.
+	reader, err := NewReader(testBytesPool, opts)
+	require.NoError(t, err)
+	readTestData(t, reader, 0, testWriterStart, entries)
+	evicted, err := dataFileSetEvicted(filePathPrefix, testNs1ID, 0, testWriterStart, 0)
+	require.NoError(t, err)
+	require.False(t, evicted)
+
+	// Filesets that were just downloaded are kept for a while.
+	byTime := m.seekersByTime(0)
+	require.NoError(t, m.evictLocalFileSetsForShard(byTime,
+		earliestLocalBlockStart, time.Now()))
+	evicted, err = dataFileSetEvicted(filePathPrefix, testNs1ID, 0, testWriterStart, 0)
+	require.NoError(t, err)
+	require.False(t, evicted)
+
+	// Filesets with open seekers are not evicted.
+	byTime.Lock()
+	_, err = m.getOrOpenSeekersWithLock(xtime.ToUnixNano(testWriterStart), byTime)
+	byTime.Unlock()
+	require.NoError(t, err)
+	require.NoError(t, m.evictLocalFileSetsForShard(byTime,
+		earliestLocalBlockStart, now))
+	evicted, err = dataFileSetEvicted(filePathPrefix, testNs1ID, 0, testWriterStart, 0)
+	require.NoError(t, err)
+	require.False(t, evicted)
+}
+
+func TestImportDataFileSetAsNewVolume(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	store := newTestObjectStore()
+
+	entries := []testEntry{
+		{"foo", nil, []byte{1, 2, 3}},
+		{"bar", nil, []byte{4, 5, 6}},
+	}
+
+	opts := testDefaultOpts.
+		SetFilePathPrefix(filePathPrefix).
+		SetWriterBufferSize(testWriterBufferSize).
+		SetObjectStore(store)
+	w, err := NewWriter(opts)
+	require.NoError(t, err)
+	writeTestData(t, w, 0, testWriterStart, entries, persist.FileSetFlushType)
+	require.NoError(t, os.RemoveAll(ShardDataDirPath(filePathPrefix, testNs1ID, 0)))
+
+	imported, err := ImportDataFileSet(store, opts, testNs1ID, 0, testWriterStart, 0, 2)
+	require.NoError(t, err)
+	require.True(t, imported)
+
+	// The imported volume is complete and consistent with its new volume index.
+	result, err := Verify(opts, testNs1ID, 0, testWriterStart, 2)
+	require.NoError(t, err)
+	require.True(t, result.Healthy())
+	infoFiles := ReadInfoFiles(filePathPrefix, testNs1ID, 0,
+		opts.InfoReaderBufferSize(), opts.DecodingOptions())
+	require.Equal(t, 1, len(infoFiles))
+	require.NoError(t, infoFiles[0].Err.Error())
+	require.Equal(t, 2, infoFiles[0].Info.VolumeIndex)
+
+	// Volumes are never imported over existing volumes.
+	_, err = ImportDataFileSet(store, opts, testNs1ID, 0, testWriterStart, 0, 2)
+	require.Equal(t, errImportVolumeExists, err)
+
+	// Volumes that were never uploaded are not found.
+	imported, err = ImportDataFileSet(store, opts, testNs1ID, 0, testWriterStart, 1, 3)
+	require.NoError(t, err)
+	require.False(t, imported)
+}
@@ -24,11 +24,14 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/m3ninx/index/segment/fst"
+	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/pool"
 	"github.com/m3db/m3/src/x/serialize"
@@ -66,6 +69,42 @@ const (
 	// defaultForceIndexBloomFilterMmapMemory is the default configuration for whether the bytes for the bloom filter
 	// should be mmap'd as an anonymous region (forced completely into memory) or mmap'd as a file.
 	defaultForceIndexBloomFilterMmapMemory = false
+
+	// defaultShareIndexSummaries is the default configuration for whether the index summaries
+	// lookups are shared between all the seekers of a namespace.
+	defaultShareIndexSummaries = false
+
+	// defaultSeekerDataFileMmapEnabled is the default configuration for whether seekers
+	// mmap the data file and serve reads from the mapping rather than with pread.
+	defaultSeekerDataFileMmapEnabled = false
+
+	// defaultSequentialReadAheadEnabled is the default configuration for whether
+	// readers advise the kernel that filesets are read sequentially.
+	defaultSequentialReadAheadEnabled = false
+
+	// defaultDropPagesAfterWriteEnabled is the default configuration for whether
+	// writers evict the pages of written filesets from the page cache.
+	defaultDropPagesAfterWriteEnabled = false
+
+	// defaultZstdCompressionLevel is the default zstd level used when data
+	// segments are compressed with zstd.
+	defaultZstdCompressionLevel = 3
+
+	// defaultSeekerManagerCloseInterval is the default interval between iterations of the
+	// seeker manager loop that opens and closes seekers.
+	defaultSeekerManagerCloseInterval = time.Second
+
+	// defaultSeekerManagerMaxOpenedPerIteration is the default max number of seekers opened
+	// per iteration of the seeker manager loop, zero means unlimited.
+	defaultSeekerManagerMaxOpenedPerIteration = 0
+
+	// defaultSeekerManagerMaxClosedPerIteration is the default max number of block starts
+	// whose seekers are closed per iteration of the seeker manager loop, zero means unlimited.
+	defaultSeekerManagerMaxClosedPerIteration = 0
+
+	// defaultFlushConcurrency is the default number of data filesets that are
+	// persisted concurrently by the persist manager.
+	defaultFlushConcurrency = 1
 )
 
 var (
@@ -75,6 +114,12 @@ var (
 
 	errTagEncoderPoolNotSet = errors.New("tag encoder pool is not set")
 	errTagDecoderPoolNotSet = errors.New("tag decoder pool is not set")
+
+	errSeekerManagerCloseIntervalNotPositive = errors.New("seeker manager close interval must be positive")
+	errSeekerManagerMaxOpenedPerIterationNeg = errors.New("seeker manager max opened per iteration must not be negative")
+	errSeekerManagerMaxClosedPerIterationNeg = errors.New("seeker manager max closed per iteration must not be negative")
+	errFlushConcurrencyNotPositive           = errors.New("flush concurrency must be positive")
+	errObjectStoreLocalRetentionNegative     = errors.New("object store local retention must not be negative")
 )
 
 type options struct {
@@ -83,6 +128,7 @@ type options struct {
 	runtimeOptsMgr                       runtime.OptionsManager
 	decodingOpts                         msgpack.DecodingOptions
 	filePathPrefix                       string
+	namespaceFilePathPrefixes            map[string]string
 	newFileMode                          os.FileMode
 	newDirectoryMode                     os.FileMode
 	indexSummariesPercent                float64
@@ -97,7 +143,20 @@ type options struct {
 	fstOptions                           fst.Options
 	forceIndexSummariesMmapMemory        bool
 	forceBloomFilterMmapMemory           bool
+	shareIndexSummaries                  bool
+	seekerDataFileMmapEnabled            bool
+	sequentialReadAheadEnabled           bool
+	dropPagesAfterWriteEnabled           bool
+	dataCompression                      persist.DataCompressionType
+	zstdCompressionLevel                 int
+	objectStore                          ObjectStore
+	objectStoreLocalRetention            time.Duration
+	dataKeyWrapper                       DataKeyWrapper
 	mmapEnableHugePages                  bool
+	seekerManagerCloseInterval           time.Duration
+	seekerManagerMaxOpenedPerIteration   int
+	seekerManagerMaxClosedPerIteration   int
+	flushConcurrency                     int
 }
 
 // NewOptions creates a new set of fs options
@@ -122,6 +181,12 @@ func NewOptions() Options {
 		indexBloomFilterFalsePositivePercent: defaultIndexBloomFilterFalsePositivePercent,
 		forceIndexSummariesMmapMemory:        defaultForceIndexSummariesMmapMemory,
 		forceBloomFilterMmapMemory:           defaultForceIndexBloomFilterMmapMemory,
+		shareIndexSummaries:                  defaultShareIndexSummaries,
+		seekerDataFileMmapEnabled:            defaultSeekerDataFileMmapEnabled,
+		sequentialReadAheadEnabled:           defaultSequentialReadAheadEnabled,
+		dropPagesAfterWriteEnabled:           defaultDropPagesAfterWriteEnabled,
+		dataCompression:                      persist.DefaultDataCompression,
+		zstdCompressionLevel:                 defaultZstdCompressionLevel,
 		writerBufferSize:                     defaultWriterBufferSize,
 		dataReaderBufferSize:                 defaultDataReaderBufferSize,
 		infoReaderBufferSize:                 defaultInfoReaderBufferSize,
@@ -131,6 +196,10 @@ func NewOptions() Options {
 		tagEncoderPool:                       tagEncoderPool,
 		tagDecoderPool:                       tagDecoderPool,
 		fstOptions:                           fstOptions,
+		seekerManagerCloseInterval:           defaultSeekerManagerCloseInterval,
+		seekerManagerMaxOpenedPerIteration:   defaultSeekerManagerMaxOpenedPerIteration,
+		seekerManagerMaxClosedPerIteration:   defaultSeekerManagerMaxClosedPerIteration,
+		flushConcurrency:                     defaultFlushConcurrency,
 	}
 }
 
@@ -151,6 +220,28 @@ func (o *options) Validate() error {
 	if o.tagDecoderPool == nil {
 		return errTagDecoderPoolNotSet
 	}
+	if err := persist.ValidateDataCompressionType(o.dataCompression); err != nil {
+		return err
+	}
+	if o.zstdCompressionLevel < minZstdCompressionLevel ||
+		o.zstdCompressionLevel > maxZstdCompressionLevel {
+		return errZstdCompressionLevelInvalid
+	}
+	if o.seekerManagerCloseInterval <= 0 {
+		return errSeekerManagerCloseIntervalNotPositive
+	}
+	if o.seekerManagerMaxOpenedPerIteration < 0 {
+		return errSeekerManagerMaxOpenedPerIterationNeg
+	}
+	if o.seekerManagerMaxClosedPerIteration < 0 {
+		return errSeekerManagerMaxClosedPerIterationNeg
+	}
+	if o.flushConcurrency <= 0 {
+		return errFlushConcurrencyNotPositive
+	}
+	if o.objectStoreLocalRetention < 0 {
+		return errObjectStoreLocalRetentionNegative
+	}
 	return nil
 }
 
@@ -204,6 +295,23 @@ func (o *options) FilePathPrefix() string {
 	return o.filePathPrefix
 }
 
+func (o *options) SetNamespaceFilePathPrefixes(value map[string]string) Options {
+	opts := *o
+	opts.namespaceFilePathPrefixes = value
+	return &opts
+}
+
+func (o *options) NamespaceFilePathPrefixes() map[string]string {
+	return o.namespaceFilePathPrefixes
+}
+
+func (o *options) NamespaceFilePathPrefix(namespace ident.ID) string {
+	if prefix, ok := o.namespaceFilePathPrefixes[namespace.String()]; ok {
+		return prefix
+	}
+	return o.filePathPrefix
+}
+
 func (o *options) SetNewFileMode(value os.FileMode) Options {
 	opts := *o
 	opts.newFileMode = value
@@ -264,6 +372,136 @@ func (o *options) ForceBloomFilterMmapMemory() bool {
 	return o.forceBloomFilterMmapMemory
 }
 
+func (o *options) SetShareIndexSummaries(value bool) Options {
+	opts := *o
+	opts.shareIndexSummaries = value
+	return &opts
+}
+
+func (o *options) ShareIndexSummaries() bool {
+	return o.shareIndexSummaries
+}
+
+func (o *options) SetSeekerDataFileMmapEnabled(value bool) Options {
+	opts := *o
+	opts.seekerDataFileMmapEnabled = value
+	return &opts
+}
+
+func (o *options) SeekerDataFileMmapEnabled() bool {
+	return o.seekerDataFileMmapEnabled
+}
+
+func (o *options) SetSequentialReadAheadEnabled(value bool) Options {
+	opts := *o
+	opts.sequentialReadAheadEnabled = value
+	return &opts
+}
+
+func (o *options) SequentialReadAheadEnabled() bool {
+	return o.sequentialReadAheadEnabled
+}
+
+func (o *options) SetDropPagesAfterWriteEnabled(value bool) Options {
+	opts := *o
+	opts.dropPagesAfterWriteEnabled = value
+	return &opts
+}
+
+func (o *options) DropPagesAfterWriteEnabled() bool {
+	return o.dropPagesAfterWriteEnabled
+}
+
+func (o *options) SetDataCompression(value persist.DataCompressionType) Options {
+	opts := *o
+	opts.dataCompression = value
+	return &opts
+}
+
+func (o *options) DataCompression() persist.DataCompressionType {
+	return o.dataCompression
+}
+
+func (o *options) SetZstdCompressionLevel(value int) Options {
+	opts := *o
+	opts.zstdCompressionLevel = value
+	return &opts
+}
+
+func (o *options) ZstdCompressionLevel() int {
+	return o.zstdCompressionLevel
+}
+
+func (o *options) SetObjectStore(value ObjectStore) Options {
+	opts := *o
+	opts.objectStore = value
+	return &opts
+}
+
+func (o *options) ObjectStore() ObjectStore {
+	return o.objectStore
+}
+
+func (o *options) SetObjectStoreLocalRetention(value time.Duration) Options {
+	opts := *o
+	opts.objectStoreLocalRetention = value
+	return &opts
+}
+
+func (o *options) ObjectStoreLocalRetention() time.Duration {
+	return o.objectStoreLocalRetention
+}
+
+func (o *options) SetDataKeyWrapper(value DataKeyWrapper) Options {
+	opts := *o
+	opts.dataKeyWrapper = value
+	return &opts
+}
+
+func (o *options) DataKeyWrapper() DataKeyWrapper {
+	return o.dataKeyWrapper
+}
+
+func (o *options) SetSeekerManagerCloseInterval(value time.Duration) Options {
+	opts := *o
+	opts.seekerManagerCloseInterval = value
+	return &opts
+}
+
+func (o *options) SeekerManagerCloseInterval() time.Duration {
+	return o.seekerManagerCloseInterval
+}
+
+func (o *options) SetSeekerManagerMaxOpenedPerIteration(value int) Options {
+	opts := *o
+	opts.seekerManagerMaxOpenedPerIteration = value
+	return &opts
+}
+
+func (o *options) SeekerManagerMaxOpenedPerIteration() int {
+	return o.seekerManagerMaxOpenedPerIteration
+}
+
+func (o *options) SetSeekerManagerMaxClosedPerIteration(value int) Options {
+	opts := *o
+	opts.seekerManagerMaxClosedPerIteration = value
+	return &opts
+}
+
+func (o *options) SeekerManagerMaxClosedPerIteration() int {
+	return o.seekerManagerMaxClosedPerIteration
+}
+
+func (o *options) SetFlushConcurrency(value int) Options {
+	opts := *o
+	opts.flushConcurrency = value
+	return &opts
+}
+
+func (o *options) FlushConcurrency() int {
+	return o.flushConcurrency
+}
+
 func (o *options) SetWriterBufferSize(value int) Options {
 	opts := *o
 	opts.writerBufferSize = value
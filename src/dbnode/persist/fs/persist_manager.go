@@ -70,7 +70,8 @@ type sleepFn func(time.Duration)
 type nextSnapshotMetadataFileIndexFn func(opts Options) (index int64, err error)
 
 // persistManager is responsible for persisting series segments onto local filesystem.
-// It is not thread-safe.
+// Data filesets may be prepared and persisted concurrently up to the flush
+// concurrency, the remaining methods are not thread-safe.
 type persistManager struct {
 	sync.RWMutex
 
@@ -84,26 +85,21 @@ type persistManager struct {
 
 	status            persistManagerStatus
 	currRateLimitOpts ratelimit.Options
-
-	start        time.Time
-	count        int
-	bytesWritten int64
-	worked       time.Duration
-	slept        time.Duration
+	rateLimiters      map[string]*persistRateLimiter
 
 	metrics persistManagerMetrics
 }
 
 type dataPersistManager struct {
 	// Injected types.
-	writer                        DataFileSetWriter
+	writers                       []*dataPersistWriter
 	nextSnapshotMetadataFileIndex nextSnapshotMetadataFileIndexFn
 	snapshotMetadataWriter        SnapshotMetadataFileWriter
 
-	// segmentHolder is a two-item slice that's reused to hold pointers to the
-	// head and the tail of each segment so we don't need to allocate memory
-	// and gc it shortly after.
-	segmentHolder []checked.Bytes
+	// freeWriters holds the writers that are not persisting a fileset, preparing
+	// a fileset waits for one to be available which bounds the number of
+	// filesets that are persisted concurrently.
+	freeWriters chan *dataPersistWriter
 
 	// The type of files that are being persisted. Assists with decision making
 	// in the "done" phase.
@@ -113,6 +109,33 @@ type dataPersistManager struct {
 	snapshotID uuid.UUID
 }
 
+// dataPersistWriter is a data writer along with the resources it requires to
+// persist a single fileset at a time.
+type dataPersistWriter struct {
+	writer DataFileSetWriter
+
+	// segmentHolder is a two-item slice that's reused to hold pointers to the
+	// head and the tail of each segment so we don't need to allocate memory
+	// and gc it shortly after.
+	segmentHolder []checked.Bytes
+
+	// inUse is only accessed by the holder of the writer.
+	inUse bool
+}
+
+// persistRateLimiter tracks the throughput of the filesets persisted beneath a
+// single file path prefix, each prefix is rate limited on its own as it is
+// typically a separate disk while concurrent writers to it share its limit.
+type persistRateLimiter struct {
+	sync.Mutex
+
+	start        time.Time
+	count        int
+	bytesWritten int64
+	worked       time.Duration
+	slept        time.Duration
+}
+
 type indexPersistManager struct {
 	writer        IndexFileSetWriter
 	segmentWriter m3ninxpersist.MutableSegmentFileSetWriter
@@ -156,9 +179,22 @@ func NewPersistManager(opts Options) (persist.Manager, error) {
 		filePathPrefix = opts.FilePathPrefix()
 		scope          = opts.InstrumentOptions().MetricsScope().SubScope("persist")
 	)
-	dataWriter, err := NewWriter(opts)
-	if err != nil {
-		return nil, err
+	var (
+		concurrency = opts.FlushConcurrency()
+		writers     = make([]*dataPersistWriter, 0, concurrency)
+		freeWriters = make(chan *dataPersistWriter, concurrency)
+	)
+	for i := 0; i < concurrency; i++ {
+		dataWriter, err := NewWriter(opts)
+		if err != nil {
+			return nil, err
+		}
+		w := &dataPersistWriter{
+			writer:        dataWriter,
+			segmentHolder: make([]checked.Bytes, 2),
+		}
+		writers = append(writers, w)
+		freeWriters <- w
 	}
 
 	idxWriter, err := NewIndexWriter(opts)
@@ -175,9 +211,10 @@ func NewPersistManager(opts Options) (persist.Manager, error) {
 		filePathPrefix: filePathPrefix,
 		nowFn:          opts.ClockOptions().NowFn(),
 		sleepFn:        time.Sleep,
+		rateLimiters:   make(map[string]*persistRateLimiter),
 		dataPM: dataPersistManager{
-			writer:                        dataWriter,
-			segmentHolder:                 make([]checked.Bytes, 2),
+			writers:                       writers,
+			freeWriters:                   freeWriters,
 			nextSnapshotMetadataFileIndex: NextSnapshotMetadataFileIndex,
 			snapshotMetadataWriter:        NewSnapshotMetadataWriter(opts),
 		},
@@ -196,11 +233,7 @@ func NewPersistManager(opts Options) (persist.Manager, error) {
 
 func (pm *persistManager) reset() {
 	pm.status = persistManagerIdle
-	pm.start = timeZero
-	pm.count = 0
-	pm.bytesWritten = 0
-	pm.worked = 0
-	pm.slept = 0
+	pm.rateLimiters = make(map[string]*persistRateLimiter)
 	pm.indexPM.segmentWriter.Reset(nil)
 	pm.indexPM.writeErr = nil
 	pm.indexPM.initialized = false
@@ -230,8 +263,8 @@ func (pm *persistManager) PrepareIndex(opts persist.IndexPrepareOptions) (persis
 		prepared   persist.PreparedIndexPersist
 	)
 
-	// only support persistence of index flush files for now
-	if opts.FileSetType != persist.FileSetFlushType {
+	if opts.FileSetType != persist.FileSetFlushType &&
+		opts.FileSetType != persist.FileSetSnapshotType {
 		return prepared, fmt.Errorf("unable to PrepareIndex, unsupported file set type: %v", opts.FileSetType)
 	}
 
@@ -255,7 +288,12 @@ func (pm *persistManager) PrepareIndex(opts persist.IndexPrepareOptions) (persis
 	// to uniquely identify a single FileSetFile on disk.
 
 	// work out the volume index for the next Index FileSetFile for the given namespace/blockstart
-	volumeIndex, err := NextIndexFileSetVolumeIndex(pm.opts.FilePathPrefix(), nsMetadata.ID(), blockStart)
+	nextVolumeIndexFn := NextIndexFileSetVolumeIndex
+	if opts.FileSetType == persist.FileSetSnapshotType {
+		nextVolumeIndexFn = NextIndexSnapshotFileIndex
+	}
+	volumeIndex, err := nextVolumeIndexFn(
+		pm.opts.NamespaceFilePathPrefix(nsMetadata.ID()), nsMetadata.ID(), blockStart)
 	if err != nil {
 		return prepared, err
 	}
@@ -273,6 +311,9 @@ func (pm *persistManager) PrepareIndex(opts persist.IndexPrepareOptions) (persis
 		FileSetType: opts.FileSetType,
 		Identifier:  fileSetID,
 		Shards:      opts.Shards,
+		Snapshot: IndexWriterSnapshotOptions{
+			SnapshotTime: opts.Snapshot.SnapshotTime,
+		},
 	}
 
 	// create writer for required fileset file.
@@ -357,8 +398,7 @@ func (pm *persistManager) DoneIndex() error {
 	}
 
 	// Emit timing metrics
-	pm.metrics.writeDurationMs.Update(float64(pm.worked / time.Millisecond))
-	pm.metrics.throttleDurationMs.Update(float64(pm.slept / time.Millisecond))
+	pm.emitDurationMetrics()
 
 	// Reset state
 	pm.reset()
@@ -429,7 +469,7 @@ func (pm *persistManager) PrepareData(opts persist.DataPrepareOptions) (persist.
 		volumeIndex = opts.VolumeIndex
 	case persist.FileSetSnapshotType:
 		// Need to work out the volume index for the next snapshot.
-		volumeIndex, err = NextSnapshotFileSetVolumeIndex(pm.opts.FilePathPrefix(),
+		volumeIndex, err = NextSnapshotFileSetVolumeIndex(pm.opts.NamespaceFilePathPrefix(nsMetadata.ID()),
 			nsMetadata.ID(), shard, blockStart)
 		if err != nil {
 			return prepared, err
@@ -458,7 +498,7 @@ func (pm *persistManager) PrepareData(opts persist.DataPrepareOptions) (persist.
 	}
 
 	if exists && opts.DeleteIfExists {
-		err := DeleteFileSetAt(pm.opts.FilePathPrefix(), nsID, shard, blockStart, volumeIndex)
+		err := DeleteFileSetAt(pm.opts.NamespaceFilePathPrefix(nsID), nsID, shard, blockStart, volumeIndex)
 		if err != nil {
 			return prepared, err
 		}
@@ -466,7 +506,8 @@ func (pm *persistManager) PrepareData(opts persist.DataPrepareOptions) (persist.
 
 	blockSize := nsMetadata.Options().RetentionOptions().BlockSize()
 	dataWriterOpts := DataWriterOpenOptions{
-		BlockSize: blockSize,
+		BlockSize:                       blockSize,
+		BloomFilterFalsePositivePercent: nsMetadata.Options().BloomFilterFalsePositivePercent(),
 		Snapshot: DataWriterSnapshotOptions{
 			SnapshotTime: snapshotTime,
 			SnapshotID:   snapshotID,
@@ -479,17 +520,49 @@ func (pm *persistManager) PrepareData(opts persist.DataPrepareOptions) (persist.
 			VolumeIndex: volumeIndex,
 		},
 	}
-	if err := pm.dataPM.writer.Open(dataWriterOpts); err != nil {
+	// Wait for a writer to be available, it is returned once the prepared
+	// fileset is closed.
+	w := <-pm.dataPM.freeWriters
+	w.inUse = true
+	if err := w.writer.Open(dataWriterOpts); err != nil {
+		pm.releaseWriter(w)
 		return prepared, err
 	}
 
-	prepared.Persist = pm.persist
-	prepared.Close = pm.closeData
+	limiter := pm.rateLimiter(pm.opts.NamespaceFilePathPrefix(nsID))
+	prepared.Persist = func(
+		id ident.ID,
+		tags ident.Tags,
+		segment ts.Segment,
+		checksum uint32,
+	) error {
+		return pm.persist(w, limiter, id, tags, segment, checksum)
+	}
+	prepared.Close = func() error {
+		return pm.closeData(w)
+	}
+	prepared.Abort = func() error {
+		return pm.abortData(w)
+	}
 
 	return prepared, nil
 }
 
+func (pm *persistManager) rateLimiter(filePathPrefix string) *persistRateLimiter {
+	pm.Lock()
+	defer pm.Unlock()
+
+	limiter, ok := pm.rateLimiters[filePathPrefix]
+	if !ok {
+		limiter = &persistRateLimiter{}
+		pm.rateLimiters[filePathPrefix] = limiter
+	}
+	return limiter
+}
+
 func (pm *persistManager) persist(
+	w *dataPersistWriter,
+	limiter *persistRateLimiter,
 	id ident.ID,
 	tags ident.Tags,
 	segment ts.Segment,
@@ -500,43 +573,69 @@ func (pm *persistManager) persist(
 	opts := pm.currRateLimitOpts
 	pm.RUnlock()
 
+	// NB: Sleep while holding the limiter so that all the writers persisting
+	// to the same disk are throttled together.
+	limiter.Lock()
 	var (
 		start = pm.nowFn()
 		slept time.Duration
 	)
 	rateLimitMbps := opts.LimitMbps()
 	if opts.LimitEnabled() && rateLimitMbps > 0.0 {
-		if pm.start.IsZero() {
-			pm.start = start
-		} else if pm.count >= opts.LimitCheckEvery() {
-			target := time.Duration(float64(time.Second) * float64(pm.bytesWritten) / (rateLimitMbps * bytesPerMegabit))
-			if elapsed := start.Sub(pm.start); elapsed < target {
+		if limiter.start.IsZero() {
+			limiter.start = start
+		} else if limiter.count >= opts.LimitCheckEvery() {
+			target := time.Duration(float64(time.Second) * float64(limiter.bytesWritten) / (rateLimitMbps * bytesPerMegabit))
+			if elapsed := start.Sub(limiter.start); elapsed < target {
 				pm.sleepFn(target - elapsed)
 				// Recapture start for precise timing, might take some time to "wakeup"
 				now := pm.nowFn()
 				slept = now.Sub(start)
 				start = now
 			}
-			pm.count = 0
+			limiter.count = 0
 		}
 	}
+	limiter.Unlock()
 
-	pm.dataPM.segmentHolder[0] = segment.Head
-	pm.dataPM.segmentHolder[1] = segment.Tail
-	err := pm.dataPM.writer.WriteAll(id, tags, pm.dataPM.segmentHolder, checksum)
-	pm.count++
-	pm.bytesWritten += int64(segment.Len())
+	w.segmentHolder[0] = segment.Head
+	w.segmentHolder[1] = segment.Tail
+	err := w.writer.WriteAll(id, tags, w.segmentHolder, checksum)
 
-	pm.worked += pm.nowFn().Sub(start)
+	limiter.Lock()
+	limiter.count++
+	limiter.bytesWritten += int64(segment.Len())
+	limiter.worked += pm.nowFn().Sub(start)
 	if slept > 0 {
-		pm.slept += slept
+		limiter.slept += slept
 	}
+	limiter.Unlock()
 
 	return err
 }
 
-func (pm *persistManager) closeData() error {
-	return pm.dataPM.writer.Close()
+func (pm *persistManager) closeData(w *dataPersistWriter) error {
+	err := w.writer.Close()
+	pm.releaseWriter(w)
+	return err
+}
+
+func (pm *persistManager) abortData(w *dataPersistWriter) error {
+	if !w.inUse {
+		return nil
+	}
+	err := w.writer.Abort()
+	pm.releaseWriter(w)
+	return err
+}
+
+func (pm *persistManager) releaseWriter(w *dataPersistWriter) {
+	// Guard against the prepared fileset being closed more than once.
+	if !w.inUse {
+		return
+	}
+	w.inUse = false
+	pm.dataPM.freeWriters <- w
 }
 
 // DoneFlush is called by the databaseFlushManager to finish the data persist process.
@@ -594,8 +693,7 @@ func (pm *persistManager) DoneSnapshot(
 
 func (pm *persistManager) doneShared() error {
 	// Emit timing metrics
-	pm.metrics.writeDurationMs.Update(float64(pm.worked / time.Millisecond))
-	pm.metrics.throttleDurationMs.Update(float64(pm.slept / time.Millisecond))
+	pm.emitDurationMetrics()
 
 	// Reset state
 	pm.reset()
@@ -603,6 +701,18 @@ func (pm *persistManager) doneShared() error {
 	return nil
 }
 
+func (pm *persistManager) emitDurationMetrics() {
+	var worked, slept time.Duration
+	for _, limiter := range pm.rateLimiters {
+		limiter.Lock()
+		worked += limiter.worked
+		slept += limiter.slept
+		limiter.Unlock()
+	}
+	pm.metrics.writeDurationMs.Update(float64(worked / time.Millisecond))
+	pm.metrics.throttleDurationMs.Update(float64(slept / time.Millisecond))
+}
+
 func (pm *persistManager) dataFilesetExists(prepareOpts persist.DataPrepareOptions) (bool, error) {
 	var (
 		nsID       = prepareOpts.NamespaceMetadata.ID()
@@ -621,7 +731,7 @@ func (pm *persistManager) dataFilesetExists(prepareOpts persist.DataPrepareOptio
 		// trying to write new snapshot files.
 		return false, nil
 	case persist.FileSetFlushType:
-		return DataFileSetExists(pm.filePathPrefix, nsID, shard, blockStart, volume)
+		return DataFileSetExists(pm.opts.NamespaceFilePathPrefix(nsID), nsID, shard, blockStart, volume)
 	default:
 		return false, fmt.Errorf(
 			"unable to determine if fileset exists in persist manager for fileset type: %s",
@@ -25,10 +25,12 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/m3ninx/index/segment"
@@ -207,9 +209,10 @@ func TestPersistenceManagerPrepareSuccess(t *testing.T) {
 	}()
 
 	now := time.Now()
-	pm.start = now
-	pm.count = 123
-	pm.bytesWritten = 100
+	limiter := pm.rateLimiter(pm.filePathPrefix)
+	limiter.start = now
+	limiter.count = 123
+	limiter.bytesWritten = 100
 
 	prepareOpts := persist.DataPrepareOptions{
 		NamespaceMetadata: testNs1Metadata(t),
@@ -223,9 +226,9 @@ func TestPersistenceManagerPrepareSuccess(t *testing.T) {
 
 	require.Nil(t, prepared.Persist(id, tags, segment, checksum))
 
-	require.True(t, pm.start.Equal(now))
-	require.Equal(t, 124, pm.count)
-	require.Equal(t, int64(104), pm.bytesWritten)
+	require.True(t, limiter.start.Equal(now))
+	require.Equal(t, 124, limiter.count)
+	require.Equal(t, int64(104), limiter.bytesWritten)
 }
 
 func TestPersistenceManagerPrepareSnapshotSuccess(t *testing.T) {
@@ -277,9 +280,10 @@ func TestPersistenceManagerPrepareSnapshotSuccess(t *testing.T) {
 	}()
 
 	now := time.Now()
-	pm.start = now
-	pm.count = 123
-	pm.bytesWritten = 100
+	limiter := pm.rateLimiter(pm.filePathPrefix)
+	limiter.start = now
+	limiter.count = 123
+	limiter.bytesWritten = 100
 
 	prepareOpts := persist.DataPrepareOptions{
 		NamespaceMetadata: testNs1Metadata(t),
@@ -293,9 +297,9 @@ func TestPersistenceManagerPrepareSnapshotSuccess(t *testing.T) {
 
 	require.Nil(t, prepared.Persist(id, tags, segment, checksum))
 
-	require.True(t, pm.start.Equal(now))
-	require.Equal(t, 124, pm.count)
-	require.Equal(t, int64(104), pm.bytesWritten)
+	require.True(t, limiter.start.Equal(now))
+	require.Equal(t, 124, limiter.count)
+	require.Equal(t, int64(104), limiter.bytesWritten)
 }
 
 func TestPersistenceManagerCloseData(t *testing.T) {
@@ -306,7 +310,26 @@ func TestPersistenceManagerCloseData(t *testing.T) {
 	defer os.RemoveAll(pm.filePathPrefix)
 
 	writer.EXPECT().Close()
-	pm.closeData()
+	pm.closeData(pm.dataPM.writers[0])
+}
+
+func TestPersistenceManagerAbortData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pm, writer, _, _ := testDataPersistManager(t, ctrl)
+	defer os.RemoveAll(pm.filePathPrefix)
+
+	w := <-pm.dataPM.freeWriters
+	w.inUse = true
+
+	// Aborting closes the writer without checkpointing and releases it to
+	// prepare other filesets, aborting again is a no-op.
+	writer.EXPECT().Abort().Return(nil)
+	require.NoError(t, pm.abortData(w))
+	require.NoError(t, pm.abortData(w))
+	require.False(t, w.inUse)
+	require.Equal(t, w, <-pm.dataPM.freeWriters)
 }
 
 func TestPersistenceManagerCloseIndex(t *testing.T) {
@@ -471,6 +494,51 @@ func TestPersistenceManagerPrepareIndexSuccess(t *testing.T) {
 	require.Equal(t, fsSeg, segs[0])
 }
 
+func TestPersistenceManagerPrepareIndexSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{T: t})
+	defer ctrl.Finish()
+
+	pm, writer, segWriter, _ := testIndexPersistManager(t, ctrl)
+	defer os.RemoveAll(pm.filePathPrefix)
+
+	blockStart := time.Unix(1000, 0)
+	snapshotTime := blockStart.Add(time.Minute)
+	writerOpts := IndexWriterOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			FileSetContentType: persist.FileSetIndexContentType,
+			Namespace:          testNs1ID,
+			BlockStart:         blockStart,
+		},
+		BlockSize:   testBlockSize,
+		FileSetType: persist.FileSetSnapshotType,
+		Snapshot: IndexWriterSnapshotOptions{
+			SnapshotTime: snapshotTime,
+		},
+	}
+	writer.EXPECT().Open(xtest.CmpMatcher(writerOpts, m3test.IdentTransformer)).Return(nil)
+
+	flush, err := pm.StartIndexPersist()
+	require.NoError(t, err)
+
+	defer func() {
+		segWriter.EXPECT().Reset(nil)
+		assert.NoError(t, flush.DoneIndex())
+	}()
+
+	prepared, err := flush.PrepareIndex(persist.IndexPrepareOptions{
+		NamespaceMetadata: testNs1Metadata(t),
+		BlockStart:        blockStart,
+		FileSetType:       persist.FileSetSnapshotType,
+		Snapshot: persist.DataPrepareSnapshotOptions{
+			SnapshotTime: snapshotTime,
+		},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, prepared.Persist)
+	require.NotNil(t, prepared.Close)
+	require.Equal(t, persist.FileSetSnapshotType, pm.indexPM.fileSetType)
+}
+
 func TestPersistenceManagerNoRateLimit(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -504,7 +572,7 @@ func TestPersistenceManagerNoRateLimit(t *testing.T) {
 	pm.nowFn = func() time.Time { return now }
 	pm.sleepFn = func(d time.Duration) { slept += d }
 
-	writer.EXPECT().WriteAll(id, tags, pm.dataPM.segmentHolder, checksum).Return(nil).Times(2)
+	writer.EXPECT().WriteAll(id, tags, pm.dataPM.writers[0].segmentHolder, checksum).Return(nil).Times(2)
 
 	flush, err := pm.StartFlushPersist()
 	require.NoError(t, err)
@@ -532,7 +600,7 @@ func TestPersistenceManagerNoRateLimit(t *testing.T) {
 
 	// Check there is no rate limiting
 	require.Equal(t, time.Duration(0), slept)
-	require.Equal(t, int64(6), pm.bytesWritten)
+	require.Equal(t, int64(6), pm.rateLimiter(pm.filePathPrefix).bytesWritten)
 }
 
 func TestPersistenceManagerWithRateLimit(t *testing.T) {
@@ -568,7 +636,7 @@ func TestPersistenceManagerWithRateLimit(t *testing.T) {
 		BlockSize: testBlockSize,
 	}, m3test.IdentTransformer)
 	writer.EXPECT().Open(writerOpts).Return(nil).Times(iter)
-	writer.EXPECT().WriteAll(id, ident.Tags{}, pm.dataPM.segmentHolder, checksum).Return(nil).AnyTimes()
+	writer.EXPECT().WriteAll(id, ident.Tags{}, pm.dataPM.writers[0].segmentHolder, checksum).Return(nil).AnyTimes()
 	writer.EXPECT().Close().Times(iter)
 
 	// Enable rate limiting
@@ -624,7 +692,7 @@ func TestPersistenceManagerWithRateLimit(t *testing.T) {
 		require.NoError(t, prepared.Persist(id, ident.Tags{}, segment, checksum))
 		require.Equal(t, time.Duration(1861), slept)
 
-		require.Equal(t, int64(15), pm.bytesWritten)
+		require.Equal(t, int64(15), pm.rateLimiter(pm.filePathPrefix).bytesWritten)
 
 		require.NoError(t, prepared.Close())
 
@@ -668,6 +736,10 @@ func TestPersistenceManagerNamespaceSwitch(t *testing.T) {
 	require.NotNil(t, prepared.Persist)
 	require.NotNil(t, prepared.Close)
 
+	// The writer is only available to prepare the next fileset once closed.
+	writer.EXPECT().Close()
+	require.NoError(t, prepared.Close())
+
 	writerOpts = xtest.CmpMatcher(DataWriterOpenOptions{
 		Identifier: FileSetFileIdentifier{
 			Namespace:  testNs2ID,
@@ -688,6 +760,83 @@ func TestPersistenceManagerNamespaceSwitch(t *testing.T) {
 	require.NotNil(t, prepared.Close)
 }
 
+func TestPersistenceManagerConcurrentPrepareData(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	var (
+		filePathPrefix       = filepath.Join(dir, "default")
+		routedFilePathPrefix = filepath.Join(dir, "routed")
+		blockStart           = time.Unix(1000, 0)
+		segment              = ts.NewSegment(checked.NewBytes([]byte{0x1, 0x2, 0x3}, nil), nil, ts.FinalizeNone)
+		checksum             = digest.SegmentChecksum(segment)
+	)
+	opts := testDefaultOpts.
+		SetFilePathPrefix(filePathPrefix).
+		SetNamespaceFilePathPrefixes(map[string]string{
+			testNs2ID.String(): routedFilePathPrefix,
+		}).
+		SetWriterBufferSize(10).
+		SetFlushConcurrency(2)
+	mgr, err := NewPersistManager(opts)
+	require.NoError(t, err)
+	pm := mgr.(*persistManager)
+
+	flush, err := pm.StartFlushPersist()
+	require.NoError(t, err)
+
+	prepare := func(md namespace.Metadata, shard uint32) persist.PreparedDataPersist {
+		prepared, err := flush.PrepareData(persist.DataPrepareOptions{
+			NamespaceMetadata: md,
+			Shard:             shard,
+			BlockStart:        blockStart,
+		})
+		require.NoError(t, err)
+		require.NoError(t, prepared.Persist(ident.StringID("foo"), ident.Tags{}, segment, checksum))
+		return prepared
+	}
+
+	// Both writers are in use once two filesets are prepared.
+	first := prepare(testNs1Metadata(t), 0)
+	second := prepare(testNs2Metadata(t), 0)
+
+	// Each disk is rate limited on its own.
+	require.Equal(t, int64(3), pm.rateLimiter(filePathPrefix).bytesWritten)
+	require.Equal(t, int64(3), pm.rateLimiter(routedFilePathPrefix).bytesWritten)
+
+	// Preparing another fileset waits for a writer to be available.
+	thirdCh := make(chan persist.PreparedDataPersist)
+	go func() {
+		thirdCh <- prepare(testNs1Metadata(t), 1)
+	}()
+	select {
+	case <-thirdCh:
+		require.FailNow(t, "prepared fileset without an available writer")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.NoError(t, first.Close())
+	third := <-thirdCh
+	require.NoError(t, second.Close())
+	require.NoError(t, third.Close())
+	require.NoError(t, flush.DoneFlush())
+
+	for _, fileset := range []struct {
+		filePathPrefix string
+		namespace      ident.ID
+		shard          uint32
+	}{
+		{filePathPrefix: filePathPrefix, namespace: testNs1ID, shard: 0},
+		{filePathPrefix: routedFilePathPrefix, namespace: testNs2ID, shard: 0},
+		{filePathPrefix: filePathPrefix, namespace: testNs1ID, shard: 1},
+	} {
+		exists, err := DataFileSetExists(fileset.filePathPrefix,
+			fileset.namespace, fileset.shard, blockStart, 0)
+		require.NoError(t, err)
+		require.True(t, exists)
+	}
+}
+
 func createDataShardDir(t *testing.T, prefix string, namespace ident.ID, shard uint32) string {
 	shardDirPath := ShardDataDirPath(prefix, namespace, shard)
 	err := os.MkdirAll(shardDirPath, os.ModeDir|os.FileMode(0755))
@@ -721,7 +870,7 @@ func testDataPersistManager(
 	require.NoError(t, err)
 
 	manager := mgr.(*persistManager)
-	manager.dataPM.writer = fileSetWriter
+	manager.dataPM.writers[0].writer = fileSetWriter
 	manager.dataPM.snapshotMetadataWriter = snapshotMetadataWriter
 	manager.dataPM.nextSnapshotMetadataFileIndex = func(Options) (int64, error) {
 		return 0, nil
@@ -56,8 +56,7 @@ type reader struct {
 	opts          Options
 	hugePagesOpts mmap.HugeTLBOptions
 
-	filePathPrefix string
-	namespace      ident.ID
+	namespace ident.ID
 
 	start     time.Time
 	blockSize time.Duration
@@ -75,10 +74,20 @@ type reader struct {
 	dataMmap   []byte
 	dataReader digest.ReaderWithDigest
 
+	// If the fileset is encrypted the index is decrypted up front into the
+	// decrypted index buffer and data is read through the decrypting reader
+	// which reads from the data reader so the digest remains of the file.
+	fileCipher       *filesetCipher
+	indexDecrypted   []byte
+	dataDecrypter    *decryptingReader
+	dataStreamReader io.Reader
+
 	bloomFilterFd *os.File
 
 	entries         int
 	bloomFilterInfo schema.IndexBloomFilterInfo
+	dataCompression persist.DataCompressionType
+	compressedBuf   []byte
 	entriesRead     int
 	metadataRead    int
 	decoder         *msgpack.Decoder
@@ -109,8 +118,7 @@ func NewReader(
 	return &reader{
 		// When initializing new fields that should be static, be sure to save
 		// and reset them after Close() resets the fields to all default values.
-		opts:           opts,
-		filePathPrefix: opts.FilePathPrefix(),
+		opts: opts,
 		hugePagesOpts: mmap.HugeTLBOptions{
 			Enabled:   opts.MmapEnableHugeTLB(),
 			Threshold: opts.MmapHugeTLBThreshold(),
@@ -120,6 +128,7 @@ func NewReader(
 		bloomFilterWithDigest:      digest.NewFdWithDigestReader(opts.InfoReaderBufferSize()),
 		indexDecoderStream:         newReaderDecoderStream(),
 		dataReader:                 digest.NewReaderWithDigest(nil),
+		dataDecrypter:              newDecryptingReader(),
 		decoder:                    msgpack.NewDecoder(opts.DecodingOptions()),
 		digestBuf:                  digest.NewBuffer(),
 		bytesPool:                  bytesPool,
@@ -137,6 +146,7 @@ func (r *reader) Open(opts DataReaderOpenOptions) error {
 	)
 
 	var (
+		filePathPrefix      = r.opts.NamespaceFilePathPrefix(namespace)
 		shardDir            string
 		checkpointFilepath  string
 		infoFilepath        string
@@ -148,7 +158,7 @@ func (r *reader) Open(opts DataReaderOpenOptions) error {
 
 	switch opts.FileSetType {
 	case persist.FileSetSnapshotType:
-		shardDir = ShardSnapshotsDirPath(r.filePathPrefix, namespace, shard)
+		shardDir = ShardSnapshotsDirPath(filePathPrefix, namespace, shard)
 		checkpointFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, volumeIndex, checkpointFileSuffix)
 		infoFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, volumeIndex, infoFileSuffix)
 		digestFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, volumeIndex, digestFileSuffix)
@@ -156,7 +166,16 @@ func (r *reader) Open(opts DataReaderOpenOptions) error {
 		indexFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, volumeIndex, indexFileSuffix)
 		dataFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, volumeIndex, dataFileSuffix)
 	case persist.FileSetFlushType:
-		shardDir = ShardDataDirPath(r.filePathPrefix, namespace, shard)
+		shardDir = ShardDataDirPath(filePathPrefix, namespace, shard)
+
+		if r.opts.ObjectStore() != nil {
+			// Fetch the fileset if it has been evicted from local disk.
+			_, _, err := ensureDataFileSetLocal(r.opts, namespace, shard,
+				blockStart, volumeIndex)
+			if err != nil {
+				return err
+			}
+		}
 
 		isLegacy := false
 		if volumeIndex == 0 {
@@ -223,7 +242,13 @@ func (r *reader) Open(opts DataReaderOpenOptions) error {
 		logger.Warn("warning while mmapping files in reader", zap.Error(warning))
 	}
 
-	r.indexDecoderStream.Reset(r.indexMmap)
+	if r.opts.SequentialReadAheadEnabled() {
+		if err := r.adviseSequential(); err != nil {
+			r.Close()
+			return err
+		}
+	}
+
 	r.dataReader.Reset(bytes.NewReader(r.dataMmap))
 
 	if err := r.readDigest(); err != nil {
@@ -240,6 +265,10 @@ func (r *reader) Open(opts DataReaderOpenOptions) error {
 		r.Close()
 		return err
 	}
+	if err := r.resetIndexAndDataStreams(); err != nil {
+		r.Close()
+		return err
+	}
 	if err := r.readIndexAndSortByOffsetAsc(); err != nil {
 		r.Close()
 		return err
@@ -283,6 +312,23 @@ func (r *reader) readDigest() error {
 	return nil
 }
 
+// adviseSequential advises the kernel that the index and data files are read
+// sequentially from start to end so that readahead of both the files and their
+// mappings is aggressive.
+func (r *reader) adviseSequential() error {
+	for _, fd := range []*os.File{r.indexFd, r.dataFd} {
+		if err := fadviseSequential(fd); err != nil {
+			return err
+		}
+	}
+	for _, b := range [][]byte{r.indexMmap, r.dataMmap} {
+		if err := mmap.Madvise(b, mmap.AdviceSequential); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *reader) readInfo(size int) error {
 	buf := make([]byte, size)
 	n, err := r.infoFdWithDigest.ReadAllAndValidate(buf, r.expectedInfoDigest)
@@ -301,6 +347,28 @@ func (r *reader) readInfo(size int) error {
 	r.entriesRead = 0
 	r.metadataRead = 0
 	r.bloomFilterInfo = info.BloomFilter
+	r.dataCompression = info.DataCompression
+	r.fileCipher, err = newFilesetCipherFromInfo(r.opts.DataKeyWrapper(),
+		info.EncryptionKeyID, info.WrappedDataKey)
+	return err
+}
+
+func (r *reader) resetIndexAndDataStreams() error {
+	if r.fileCipher == nil {
+		r.indexDecoderStream.Reset(r.indexMmap)
+		r.dataStreamReader = r.dataReader
+		return nil
+	}
+
+	var err error
+	r.indexDecrypted, err = r.fileCipher.decryptAll(r.indexDecrypted[:0],
+		r.indexMmap, encryptedIndexFile)
+	if err != nil {
+		return fmt.Errorf("could not decrypt index file: %v", err)
+	}
+	r.indexDecoderStream.Reset(r.indexDecrypted)
+	r.dataDecrypter.reset(r.fileCipher, encryptedDataFile, r.dataReader)
+	r.dataStreamReader = r.dataDecrypter
 	return nil
 }
 
@@ -334,31 +402,63 @@ func (r *reader) Read() (ident.ID, ident.TagIterator, checked.Bytes, uint32, err
 
 	entry := r.indexEntriesByOffsetAsc[r.entriesRead]
 
+	var (
+		data checked.Bytes
+		err  error
+	)
+	if r.dataCompression != persist.NoDataCompression {
+		data, err = r.readCompressedData(int(entry.Size))
+	} else {
+		data, err = r.readData(int(entry.Size))
+	}
+	if err != nil {
+		return nil, nil, nil, 0, err
+	}
+
+	id := r.entryClonedID(entry.ID)
+	tags := r.entryClonedEncodedTagsIter(entry.EncodedTags)
+
+	r.entriesRead++
+	return id, tags, data, uint32(entry.Checksum), nil
+}
+
+func (r *reader) readData(size int) (checked.Bytes, error) {
 	var data checked.Bytes
 	if r.bytesPool != nil {
-		data = r.bytesPool.Get(int(entry.Size))
+		data = r.bytesPool.Get(size)
 		data.IncRef()
 		defer data.DecRef()
-		data.Resize(int(entry.Size))
+		data.Resize(size)
 	} else {
-		data = checked.NewBytes(make([]byte, entry.Size), nil)
+		data = checked.NewBytes(make([]byte, size), nil)
 		data.IncRef()
 		defer data.DecRef()
 	}
 
-	n, err := r.dataReader.Read(data.Bytes())
+	n, err := r.dataStreamReader.Read(data.Bytes())
 	if err != nil {
-		return nil, nil, nil, 0, err
+		return nil, err
 	}
-	if n != int(entry.Size) {
-		return nil, nil, nil, 0, errReadNotExpectedSize
+	if n != size {
+		return nil, errReadNotExpectedSize
 	}
+	return data, nil
+}
 
-	id := r.entryClonedID(entry.ID)
-	tags := r.entryClonedEncodedTagsIter(entry.EncodedTags)
+func (r *reader) readCompressedData(size int) (checked.Bytes, error) {
+	if cap(r.compressedBuf) < size {
+		r.compressedBuf = make([]byte, size)
+	}
+	r.compressedBuf = r.compressedBuf[:size]
 
-	r.entriesRead++
-	return id, tags, data, uint32(entry.Checksum), nil
+	n, err := r.dataStreamReader.Read(r.compressedBuf)
+	if err != nil {
+		return nil, err
+	}
+	if n != size {
+		return nil, errReadNotExpectedSize
+	}
+	return decompressDataSegment(r.dataCompression, r.compressedBuf, r.bytesPool)
 }
 
 func (r *reader) ReadMetadata() (ident.ID, ident.TagIterator, int, uint32, error) {
@@ -426,6 +526,15 @@ func (r *reader) Validate() error {
 // NB(r): ValidateMetadata can be called immediately after Open(...) since
 // the metadata is read upfront.
 func (r *reader) ValidateMetadata() error {
+	if r.fileCipher != nil {
+		// The index was decrypted in full when opened so validate the
+		// digest of the encrypted file directly.
+		if actual := digest.Checksum(r.indexMmap); actual != r.expectedIndexDigest {
+			return fmt.Errorf("could not validate index file: expected digest %d, actual %d",
+				r.expectedIndexDigest, actual)
+		}
+		return nil
+	}
 	err := r.indexDecoderStream.reader().Validate(r.expectedIndexDigest)
 	if err != nil {
 		return fmt.Errorf("could not validate index file: %v", err)
@@ -469,6 +578,7 @@ func (r *reader) Close() error {
 	multiErr = multiErr.Add(r.bloomFilterFd.Close())
 	r.indexDecoderStream.Reset(nil)
 	r.dataReader.Reset(nil)
+	r.dataDecrypter.reset(nil, 0, nil)
 	for i := 0; i < len(r.indexEntriesByOffsetAsc); i++ {
 		r.indexEntriesByOffsetAsc[i].ID = nil
 	}
@@ -476,7 +586,6 @@ func (r *reader) Close() error {
 
 	// Save fields we want to reassign after resetting struct
 	opts := r.opts
-	filePathPrefix := r.filePathPrefix
 	hugePagesOpts := r.hugePagesOpts
 	infoFdWithDigest := r.infoFdWithDigest
 	digestFdWithDigestContents := r.digestFdWithDigestContents
@@ -488,13 +597,15 @@ func (r *reader) Close() error {
 	bytesPool := r.bytesPool
 	tagDecoderPool := r.tagDecoderPool
 	indexEntriesByOffsetAsc := r.indexEntriesByOffsetAsc
+	compressedBuf := r.compressedBuf
+	indexDecrypted := r.indexDecrypted
+	dataDecrypter := r.dataDecrypter
 
 	// Reset struct
 	*r = reader{}
 
 	// Reset the saved fields
 	r.opts = opts
-	r.filePathPrefix = filePathPrefix
 	r.hugePagesOpts = hugePagesOpts
 	r.infoFdWithDigest = infoFdWithDigest
 	r.digestFdWithDigestContents = digestFdWithDigestContents
@@ -506,6 +617,9 @@ func (r *reader) Close() error {
 	r.bytesPool = bytesPool
 	r.tagDecoderPool = tagDecoderPool
 	r.indexEntriesByOffsetAsc = indexEntriesByOffsetAsc
+	r.compressedBuf = compressedBuf
+	r.indexDecrypted = indexDecrypted
+	r.dataDecrypter = dataDecrypter
 
 	return multiErr.FinalError()
 }
@@ -23,6 +23,7 @@ package fs
 import (
 	"bytes"
 	"errors"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
@@ -526,3 +527,218 @@ func TestWriterOnlyWritesNonNilBytes(t *testing.T) {
 		{"foo", nil, []byte{1, 2, 3, 4, 5, 6}},
 	})
 }
+
+func TestReadWriteDataCompression(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	entries := []testEntry{
+		{"foo", nil, []byte{1, 2, 3}},
+		{"bar", nil, []byte{4, 5, 6}},
+		{"baz", nil, make([]byte, 65536)},
+		{"cat", nil, make([]byte, 100000)},
+		{"foo+bar=baz,qux=qaz", map[string]string{
+			"bar": "baz",
+			"qux": "qaz",
+		}, []byte{7, 8, 9}},
+	}
+
+	w, err := NewWriter(testDefaultOpts.
+		SetFilePathPrefix(filePathPrefix).
+		SetWriterBufferSize(testWriterBufferSize).
+		SetDataCompression(persist.ZstdDataCompression))
+	require.NoError(t, err)
+	writeTestData(t, w, 0, testWriterStart, entries, persist.FileSetFlushType)
+
+	// The compression is recorded in the info file.
+	readInfoFileResults := ReadInfoFiles(filePathPrefix, testNs1ID, 0, 16, nil)
+	require.Equal(t, 1, len(readInfoFileResults))
+	require.NoError(t, readInfoFileResults[0].Err.Error())
+	require.Equal(t, persist.ZstdDataCompression,
+		readInfoFileResults[0].Info.DataCompression)
+
+	// The zeroed segments compress down to a fraction of their size.
+	shardDir := ShardDataDirPath(filePathPrefix, testNs1ID, 0)
+	dataFilePath := dataFilesetPathFromTimeAndIndex(shardDir,
+		testWriterStart, 0, dataFileSuffix, false)
+	stat, err := os.Stat(dataFilePath)
+	require.NoError(t, err)
+	require.True(t, stat.Size() < 65536)
+
+	// Readers decompress transparently and validate the checksum of the
+	// uncompressed data.
+	r := newTestReader(t, filePathPrefix)
+	require.NoError(t, r.Open(DataReaderOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+	}))
+	for i := 0; i < r.Entries(); i++ {
+		id, tags, data, checksum, err := r.Read()
+		require.NoError(t, err)
+
+		data.IncRef()
+		assert.Equal(t, entries[i].id, id.String())
+		assert.True(t, bytes.Equal(entries[i].data, data.Bytes()))
+		assert.Equal(t, digest.Checksum(entries[i].data), checksum)
+
+		id.Finalize()
+		tags.Close()
+		data.DecRef()
+		data.Finalize()
+	}
+	require.NoError(t, r.Validate())
+	require.NoError(t, r.Close())
+}
+
+func TestReadWriteEncryption(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	secret := bytes.Repeat([]byte("secret"), 2048)
+	entries := []testEntry{
+		{"foo", nil, []byte{1, 2, 3}},
+		{"bar", nil, secret},
+		{"baz", nil, make([]byte, 65536)},
+		{"foo+bar=baz,qux=qaz", map[string]string{
+			"bar": "baz",
+			"qux": "qaz",
+		}, []byte{7, 8, 9}},
+	}
+
+	wrapper := newTestDataKeyWrapper(t)
+	for _, compression := range []persist.DataCompressionType{
+		persist.NoDataCompression,
+		persist.ZstdDataCompression,
+	} {
+		w, err := NewWriter(testDefaultOpts.
+			SetFilePathPrefix(filePathPrefix).
+			SetWriterBufferSize(testWriterBufferSize).
+			SetDataCompression(compression).
+			SetDataKeyWrapper(wrapper))
+		require.NoError(t, err)
+		writeTestData(t, w, 0, testWriterStart, entries, persist.FileSetFlushType)
+
+		// Only the wrapped data key is recorded in the info file.
+		readInfoFileResults := ReadInfoFiles(filePathPrefix, testNs1ID, 0, 16, nil)
+		require.Equal(t, 1, len(readInfoFileResults))
+		require.NoError(t, readInfoFileResults[0].Err.Error())
+		info := readInfoFileResults[0].Info
+		require.Equal(t, []byte(wrapper.KeyID()), info.EncryptionKeyID)
+		require.NotEmpty(t, info.WrappedDataKey)
+
+		// The data file does not contain any of the plaintext.
+		shardDir := ShardDataDirPath(filePathPrefix, testNs1ID, 0)
+		for _, suffix := range []string{dataFileSuffix, indexFileSuffix} {
+			contents, err := ioutil.ReadFile(dataFilesetPathFromTimeAndIndex(shardDir,
+				testWriterStart, 0, suffix, false))
+			require.NoError(t, err)
+			require.False(t, bytes.Contains(contents, []byte("secretsecret")))
+			require.False(t, bytes.Contains(contents, []byte("foo+bar=baz")))
+		}
+
+		// Readers decrypt transparently and validate the digests of the
+		// encrypted files.
+		r, err := NewReader(testBytesPool, testDefaultOpts.
+			SetFilePathPrefix(filePathPrefix).
+			SetDataKeyWrapper(wrapper))
+		require.NoError(t, err)
+		rOpenOpts := DataReaderOpenOptions{
+			Identifier: FileSetFileIdentifier{
+				Namespace:  testNs1ID,
+				Shard:      0,
+				BlockStart: testWriterStart,
+			},
+		}
+		require.NoError(t, r.Open(rOpenOpts))
+		require.NoError(t, r.ValidateMetadata())
+		for i := 0; i < r.Entries(); i++ {
+			id, tags, data, checksum, err := r.Read()
+			require.NoError(t, err)
+
+			data.IncRef()
+			assert.Equal(t, entries[i].id, id.String())
+			assert.True(t, bytes.Equal(entries[i].data, data.Bytes()))
+			assert.Equal(t, digest.Checksum(entries[i].data), checksum)
+
+			id.Finalize()
+			tags.Close()
+			data.DecRef()
+			data.Finalize()
+		}
+		require.NoError(t, r.Validate())
+		require.NoError(t, r.Close())
+
+		// Encrypted filesets cannot be read without the data key wrapper.
+		r = newTestReader(t, filePathPrefix)
+		require.Equal(t, errDataKeyWrapperNotSet, r.Open(rOpenOpts))
+	}
+}
+
+func TestReadWritePageCacheHints(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	entries := []testEntry{
+		{"foo", nil, []byte{1, 2, 3}},
+		{"bar", nil, []byte{4, 5, 6}},
+		{"baz", nil, make([]byte, 65536)},
+		{"foo+bar=baz,qux=qaz", map[string]string{
+			"bar": "baz",
+			"qux": "qaz",
+		}, []byte{7, 8, 9}},
+	}
+
+	// The hints do not change the contents of the written or read filesets.
+	opts := testDefaultOpts.
+		SetFilePathPrefix(filePathPrefix).
+		SetDropPagesAfterWriteEnabled(true).
+		SetSequentialReadAheadEnabled(true)
+	w, err := NewWriter(opts.SetWriterBufferSize(testWriterBufferSize))
+	require.NoError(t, err)
+	writeTestData(t, w, 0, testWriterStart, entries, persist.FileSetFlushType)
+
+	r, err := NewReader(testBytesPool, opts)
+	require.NoError(t, err)
+	readTestData(t, r, 0, testWriterStart, entries)
+}
+
+func TestReadWriteNamespaceFilePathPrefix(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "default")
+	routedFilePathPrefix := filepath.Join(dir, "routed")
+	defer os.RemoveAll(dir)
+
+	entries := []testEntry{
+		{"foo", nil, []byte{1, 2, 3}},
+		{"bar", nil, []byte{4, 5, 6}},
+	}
+
+	opts := testDefaultOpts.
+		SetFilePathPrefix(filePathPrefix).
+		SetNamespaceFilePathPrefixes(map[string]string{
+			testNs1ID.String(): routedFilePathPrefix,
+		})
+	require.Equal(t, routedFilePathPrefix, opts.NamespaceFilePathPrefix(testNs1ID))
+	require.Equal(t, filePathPrefix, opts.NamespaceFilePathPrefix(testNs2ID))
+
+	w, err := NewWriter(opts.SetWriterBufferSize(testWriterBufferSize))
+	require.NoError(t, err)
+	writeTestData(t, w, 0, testWriterStart, entries, persist.FileSetFlushType)
+
+	exists, err := DataFileSetExists(routedFilePathPrefix, testNs1ID, 0, testWriterStart, 0)
+	require.NoError(t, err)
+	require.True(t, exists)
+	exists, err = DataFileSetExists(filePathPrefix, testNs1ID, 0, testWriterStart, 0)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	r, err := NewReader(testBytesPool, opts)
+	require.NoError(t, err)
+	readTestData(t, r, 0, testWriterStart, entries)
+}
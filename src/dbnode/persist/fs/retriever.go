@@ -155,6 +155,20 @@ func (r *blockRetriever) Open(ns namespace.Metadata) error {
 	return nil
 }
 
+func (r *blockRetriever) UpdateNamespaceMetadata(ns namespace.Metadata) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.status != blockRetrieverOpen {
+		return errBlockRetrieverNotOpen
+	}
+	if err := r.seekerMgr.UpdateNamespaceMetadata(ns); err != nil {
+		return err
+	}
+	r.nsMetadata = ns
+	return nil
+}
+
 func (r *blockRetriever) CacheShardIndices(shards []uint32) error {
 	r.RLock()
 	defer r.RUnlock()
@@ -165,6 +179,19 @@ func (r *blockRetriever) CacheShardIndices(shards []uint32) error {
 	return r.seekerMgr.CacheShardIndices(shards)
 }
 
+func (r *blockRetriever) CacheShardIndicesForRange(
+	shards []uint32,
+	start, end time.Time,
+) error {
+	r.RLock()
+	defer r.RUnlock()
+
+	if r.status != blockRetrieverOpen {
+		return errBlockRetrieverNotOpen
+	}
+	return r.seekerMgr.CacheShardIndicesForRange(shards, start, end)
+}
+
 func (r *blockRetriever) fetchLoop(seekerMgr DataFileSetSeekerManager) {
 	var (
 		seekerResources = NewReusableSeekerResources(r.fsOpts)
@@ -284,6 +311,7 @@ func (r *blockRetriever) fetchBatch(
 	for _, req := range reqs {
 		entry, err := seeker.SeekIndexEntry(req.id, seekerResources)
 		if err != nil && err != errSeekIDNotFound {
+			seekerMgr.ReportReadError(shard, blockStart, seeker, err)
 			req.onError(err)
 			continue
 		}
@@ -310,6 +338,7 @@ func (r *blockRetriever) fetchBatch(
 		if req.foundAndHasNoError() {
 			data, err = seeker.SeekByIndexEntry(req.indexEntry, seekerResources)
 			if err != nil && err != errSeekIDNotFound {
+				seekerMgr.ReportReadError(shard, blockStart, seeker, err)
 				req.onError(err)
 				continue
 			}
@@ -414,8 +443,9 @@ func (r *blockRetriever) Stream(
 	}
 
 	// If the ID is not in the seeker's bloom filter, then it's definitely not on
-	// disk and we can return immediately.
-	if !bloomFilter.Test(id.Bytes()) {
+	// disk and we can return immediately. The bloom filter is nil if loading
+	// bloom filters is disabled for the namespace.
+	if bloomFilter != nil && !bloomFilter.Test(id.Bytes()) {
 		// No need to call req.onRetrieve.OnRetrieveBlock if there is no data.
 		req.onRetrieved(ts.Segment{}, namespace.Context{})
 		return req.toBlock(), nil
@@ -32,19 +32,25 @@ import (
 const (
 	defaultRequestPoolSize  = 16384
 	defaultFetchConcurrency = 2
+
+	// defaultIndexEntryCacheSize is the default number of index entries cached
+	// per fileset volume, zero means the cache is disabled.
+	defaultIndexEntryCacheSize = 0
 )
 
 var (
-	errBlockLeaseManagerNotSet = errors.New("block lease manager is not set")
+	errBlockLeaseManagerNotSet     = errors.New("block lease manager is not set")
+	errIndexEntryCacheSizeNegative = errors.New("index entry cache size must not be negative")
 )
 
 type blockRetrieverOptions struct {
-	requestPoolOpts   pool.ObjectPoolOptions
-	bytesPool         pool.CheckedBytesPool
-	segmentReaderPool xio.SegmentReaderPool
-	fetchConcurrency  int
-	identifierPool    ident.Pool
-	blockLeaseManager block.LeaseManager
+	requestPoolOpts     pool.ObjectPoolOptions
+	bytesPool           pool.CheckedBytesPool
+	segmentReaderPool   xio.SegmentReaderPool
+	fetchConcurrency    int
+	identifierPool      ident.Pool
+	blockLeaseManager   block.LeaseManager
+	indexEntryCacheSize int
 }
 
 // NewBlockRetrieverOptions creates a new set of block retriever options
@@ -58,10 +64,11 @@ func NewBlockRetrieverOptions() BlockRetrieverOptions {
 	o := &blockRetrieverOptions{
 		requestPoolOpts: pool.NewObjectPoolOptions().
 			SetSize(defaultRequestPoolSize),
-		bytesPool:         bytesPool,
-		segmentReaderPool: xio.NewSegmentReaderPool(nil),
-		fetchConcurrency:  defaultFetchConcurrency,
-		identifierPool:    ident.NewPool(bytesPool, ident.PoolOptions{}),
+		bytesPool:           bytesPool,
+		segmentReaderPool:   xio.NewSegmentReaderPool(nil),
+		fetchConcurrency:    defaultFetchConcurrency,
+		identifierPool:      ident.NewPool(bytesPool, ident.PoolOptions{}),
+		indexEntryCacheSize: defaultIndexEntryCacheSize,
 	}
 	o.segmentReaderPool.Init()
 	return o
@@ -71,6 +78,9 @@ func (o *blockRetrieverOptions) Validate() error {
 	if o.blockLeaseManager == nil {
 		return errBlockLeaseManagerNotSet
 	}
+	if o.indexEntryCacheSize < 0 {
+		return errIndexEntryCacheSizeNegative
+	}
 	return nil
 }
 
@@ -133,3 +143,13 @@ func (o *blockRetrieverOptions) SetBlockLeaseManager(leaseMgr block.LeaseManager
 func (o *blockRetrieverOptions) BlockLeaseManager() block.LeaseManager {
 	return o.blockLeaseManager
 }
+
+func (o *blockRetrieverOptions) SetIndexEntryCacheSize(value int) BlockRetrieverOptions {
+	opts := *o
+	opts.indexEntryCacheSize = value
+	return &opts
+}
+
+func (o *blockRetrieverOptions) IndexEntryCacheSize() int {
+	return o.indexEntryCacheSize
+}
@@ -662,6 +662,7 @@ func testBlockRetrieverHandlesSeekErrors(t *testing.T, ctrl *gomock.Controller,
 	mockSeekerManager.EXPECT().Open(gomock.Any()).Return(nil)
 	mockSeekerManager.EXPECT().ConcurrentIDBloomFilter(gomock.Any(), gomock.Any()).Return(managedBloomFilter, nil)
 	mockSeekerManager.EXPECT().Borrow(gomock.Any(), gomock.Any()).Return(mockSeeker, nil)
+	mockSeekerManager.EXPECT().ReportReadError(gomock.Any(), gomock.Any(), mockSeeker, errSeekErr)
 	mockSeekerManager.EXPECT().Return(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 	mockSeekerManager.EXPECT().Close().Return(nil)
 
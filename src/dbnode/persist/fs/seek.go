@@ -30,12 +30,14 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/persist"
 	xmsgpack "github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3/src/dbnode/persist/schema"
 	"github.com/m3db/m3/src/x/checked"
 	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/mmap"
 	"github.com/m3db/m3/src/x/pool"
 	xtime "github.com/m3db/m3/src/x/time"
 
@@ -51,6 +53,9 @@ var (
 
 	// errClonesShouldNotBeOpened returned when Open() is called on a clone
 	errClonesShouldNotBeOpened = errors.New("clone should not be opened")
+
+	// errSeekEntryOutOfBounds returned when an index entry points past the end of the data file
+	errSeekEntryOutOfBounds = errors.New("index entry out of bounds of data file")
 )
 
 const (
@@ -59,6 +64,12 @@ const (
 	maxSimpleBytesPoolSize = 2
 )
 
+// IsSeekIDNotFoundError returns whether the error is returned by a seeker
+// when the ID cannot be found in the shard.
+func IsSeekIDNotFoundError(err error) bool {
+	return err == errSeekIDNotFound
+}
+
 type seeker struct {
 	opts seekerOpts
 
@@ -71,6 +82,20 @@ type seeker struct {
 	indexFd       *os.File
 	indexFileSize int64
 
+	// If set the data file is mmap'd and reads by index entry are served
+	// from the mapping instead of with pread through the data fd.
+	dataMmap []byte
+
+	// Compression of the data segments as recorded in the info file.
+	dataCompression persist.DataCompressionType
+
+	// Random reads of the index and data files go through these readers which
+	// are the fds themselves, or decrypt the chunks read from the fds if the
+	// fileset is encrypted.
+	fileCipher  *filesetCipher
+	indexReader io.ReaderAt
+	dataReader  io.ReaderAt
+
 	unreadBuf []byte
 
 	// Bloom filter associated with the shard / block the seeker is responsible
@@ -78,6 +103,14 @@ type seeker struct {
 	bloomFilter *ManagedConcurrentBloomFilter
 	indexLookup *nearestIndexOffsetLookup
 
+	// If set the bloom filter is not loaded when the seeker is opened.
+	bloomFilterDisabled bool
+
+	// If set the index lookup is shared with other seekers via the cache and
+	// is released to the cache rather than closed.
+	indexLookupCache    *indexLookupCache
+	indexLookupCacheKey indexLookupCacheKey
+
 	isClone bool
 }
 
@@ -129,6 +162,12 @@ type fileSetSeeker interface {
 
 	// setUnreadBuffer sets the unread buffer
 	setUnreadBuffer(buf []byte)
+
+	// setIndexLookupCache sets the cache used to share index lookups
+	setIndexLookupCache(cache *indexLookupCache)
+
+	// disableBloomFilter disables loading the bloom filter on open
+	disableBloomFilter()
 }
 
 func newSeeker(opts seekerOpts) fileSetSeeker {
@@ -224,6 +263,16 @@ func (s *seeker) Open(
 	}
 	s.start = xtime.UnixNano(info.BlockStart)
 	s.blockSize = time.Duration(info.BlockSize)
+	s.dataCompression = info.DataCompression
+
+	// NB: The wrapped data key must be unwrapped before the unread buffer,
+	// which the decoded info references, is reused.
+	s.fileCipher, err = newFilesetCipherFromInfo(s.opts.opts.DataKeyWrapper(),
+		info.EncryptionKeyID, info.WrappedDataKey)
+	if err != nil {
+		s.Close()
+		return err
+	}
 
 	err = s.validateIndexFileDigest(
 		indexFdWithDigest, expectedDigests.indexDigest)
@@ -242,33 +291,51 @@ func (s *seeker) Open(
 	}
 	s.indexFileSize = indexFdStat.Size()
 
-	s.bloomFilter, err = newManagedConcurrentBloomFilterFromFile(
-		bloomFilterFd,
-		bloomFilterFdWithDigest,
-		expectedDigests.bloomFilterDigest,
-		uint(info.BloomFilter.NumElementsM),
-		uint(info.BloomFilter.NumHashesK),
-		s.opts.opts.ForceBloomFilterMmapMemory(),
-	)
-	if err != nil {
+	if err := s.openIndexAndDataReaders(); err != nil {
 		s.Close()
 		return err
 	}
 
+	if s.bloomFilterDisabled {
+		// Only need to make sure the bloom filter fd is closed.
+		bloomFilterFdWithDigest.Reset(bloomFilterFd)
+	} else {
+		s.bloomFilter, err = newManagedConcurrentBloomFilterFromFile(
+			bloomFilterFd,
+			bloomFilterFdWithDigest,
+			expectedDigests.bloomFilterDigest,
+			uint(info.BloomFilter.NumElementsM),
+			uint(info.BloomFilter.NumHashesK),
+			s.opts.opts.ForceBloomFilterMmapMemory(),
+		)
+		if err != nil {
+			s.Close()
+			return err
+		}
+	}
+
 	summariesFdWithDigest.Reset(summariesFd)
-	s.indexLookup, err = newNearestIndexOffsetLookupFromSummariesFile(
+	err = s.openIndexLookup(
+		shard,
 		summariesFdWithDigest,
 		expectedDigests.summariesDigest,
-		resources.xmsgpackDecoder,
-		resources.byteDecoderStream,
 		int(info.Summaries.Summaries),
-		s.opts.opts.ForceIndexSummariesMmapMemory(),
+		resources,
 	)
 	if err != nil {
 		s.Close()
 		return err
 	}
 
+	// Encrypted data files are read through the decrypting reader so
+	// there is no use in mmapping them.
+	if s.opts.opts.SeekerDataFileMmapEnabled() && s.fileCipher == nil {
+		if err := s.mmapDataFile(); err != nil {
+			s.Close()
+			return err
+		}
+	}
+
 	if !s.opts.keepUnreadBuf {
 		// NB(r): Free the unread buffer and reset the decoder as unless
 		// using this seeker in the seeker manager we never use this buffer again.
@@ -278,6 +345,97 @@ func (s *seeker) Open(
 	return err
 }
 
+func (s *seeker) openIndexLookup(
+	shard uint32,
+	summariesFdWithDigest digest.FdWithDigestReader,
+	expectedDigest uint32,
+	numSummaries int,
+	resources ReusableSeekerResources,
+) error {
+	var key indexLookupCacheKey
+	if s.indexLookupCache != nil {
+		key = indexLookupCacheKey{
+			shard:           shard,
+			blockStart:      s.start,
+			summariesDigest: expectedDigest,
+			numSummaries:    numSummaries,
+		}
+		if lookup, ok := s.indexLookupCache.acquire(key); ok {
+			// The summaries file has the same digest as the cached lookup so
+			// there is no need to read and validate it again.
+			s.indexLookup = lookup
+			s.indexLookupCacheKey = key
+			return nil
+		}
+	}
+
+	lookup, err := newNearestIndexOffsetLookupFromSummariesFile(
+		summariesFdWithDigest,
+		expectedDigest,
+		s.fileCipher,
+		resources.xmsgpackDecoder,
+		resources.byteDecoderStream,
+		numSummaries,
+		s.opts.opts.ForceIndexSummariesMmapMemory(),
+	)
+	if err != nil {
+		return err
+	}
+
+	if s.indexLookupCache == nil {
+		s.indexLookup = lookup
+		return nil
+	}
+
+	// A reference is held regardless of any error so that the seeker
+	// releases it when closed.
+	s.indexLookup, err = s.indexLookupCache.insert(key, lookup)
+	s.indexLookupCacheKey = key
+	return err
+}
+
+func (s *seeker) openIndexAndDataReaders() error {
+	if s.fileCipher == nil {
+		s.indexReader = s.indexFd
+		s.dataReader = s.dataFd
+		return nil
+	}
+
+	dataFdStat, err := s.dataFd.Stat()
+	if err != nil {
+		return err
+	}
+	s.indexReader, err = newDecryptingReaderAt(s.fileCipher,
+		encryptedIndexFile, s.indexFd, s.indexFileSize)
+	if err != nil {
+		return err
+	}
+	s.dataReader, err = newDecryptingReaderAt(s.fileCipher,
+		encryptedDataFile, s.dataFd, dataFdStat.Size())
+	return err
+}
+
+func (s *seeker) mmapDataFile() error {
+	result, err := mmap.File(s.dataFd, mmap.Options{Read: true})
+	if err != nil {
+		return err
+	}
+	s.dataMmap = result.Result
+
+	// Lookups by index entry touch a few pages at arbitrary offsets so
+	// readahead would only pull in pages that are never read.
+	if err := mmap.Madvise(s.dataMmap, mmap.AdviceRandom); err != nil {
+		return err
+	}
+
+	// The mapping remains valid after the fd is closed and all reads are
+	// served from it so there is no need to hold onto the fd.
+	err = s.dataFd.Close()
+	s.dataFd = nil
+	s.dataReader = nil
+	return err
+}
+
 func (s *seeker) prepareUnreadBuf(size int) {
 	if len(s.unreadBuf) < size {
 		// NB(r): Make a little larger so unlikely to occur multiple times
@@ -293,6 +451,14 @@ func (s *seeker) setUnreadBuffer(buf []byte) {
 	s.unreadBuf = buf
 }
 
+func (s *seeker) setIndexLookupCache(cache *indexLookupCache) {
+	s.indexLookupCache = cache
+}
+
+func (s *seeker) disableBloomFilter() {
+	s.bloomFilterDisabled = true
+}
+
 func (s *seeker) readInfo(
 	size int,
 	infoDigestReader digest.FdWithDigestReader,
@@ -327,7 +493,9 @@ func (s *seeker) SeekByIndexEntry(
 	entry IndexEntry,
 	resources ReusableSeekerResources,
 ) (checked.Bytes, error) {
-	resources.offsetFileReader.reset(s.dataFd, entry.Offset)
+	if s.dataCompression != persist.NoDataCompression {
+		return s.seekCompressedByIndexEntry(entry, resources)
+	}
 
 	// Obtain an appropriately sized buffer.
 	var buffer checked.Bytes
@@ -344,15 +512,24 @@ func (s *seeker) SeekByIndexEntry(
 
 	// Copy the actual data into the underlying buffer.
 	underlyingBuf := buffer.Bytes()
-	n, err := io.ReadFull(resources.offsetFileReader, underlyingBuf)
-	if err != nil {
-		return nil, err
-	}
-	if n != int(entry.Size) {
-		// This check is redundant because io.ReadFull will return an error if
-		// its not able to read the specified number of bytes, but we keep it
-		// in for posterity.
-		return nil, fmt.Errorf("tried to read: %d bytes but read: %d", entry.Size, n)
+	if s.dataMmap != nil {
+		end := entry.Offset + int64(entry.Size)
+		if entry.Offset < 0 || end > int64(len(s.dataMmap)) {
+			return nil, errSeekEntryOutOfBounds
+		}
+		copy(underlyingBuf, s.dataMmap[entry.Offset:end])
+	} else {
+		resources.offsetFileReader.reset(s.dataReader, entry.Offset)
+		n, err := io.ReadFull(resources.offsetFileReader, underlyingBuf)
+		if err != nil {
+			return nil, err
+		}
+		if n != int(entry.Size) {
+			// This check is redundant because io.ReadFull will return an error if
+			// its not able to read the specified number of bytes, but we keep it
+			// in for posterity.
+			return nil, fmt.Errorf("tried to read: %d bytes but read: %d", entry.Size, n)
+		}
 	}
 
 	// NB(r): _must_ check the checksum against known checksum as the data
@@ -364,6 +541,50 @@ func (s *seeker) SeekByIndexEntry(
 	return buffer, nil
 }
 
+func (s *seeker) seekCompressedByIndexEntry(
+	entry IndexEntry,
+	resources ReusableSeekerResources,
+) (checked.Bytes, error) {
+	var compressed []byte
+	if s.dataMmap != nil {
+		// Decompress straight out of the mapping, no need to copy first.
+		end := entry.Offset + int64(entry.Size)
+		if entry.Offset < 0 || end > int64(len(s.dataMmap)) {
+			return nil, errSeekEntryOutOfBounds
+		}
+		compressed = s.dataMmap[entry.Offset:end]
+	} else {
+		bufPtr := dataSegmentBufPool.Get().(*[]byte)
+		defer dataSegmentBufPool.Put(bufPtr)
+		if cap(*bufPtr) < int(entry.Size) {
+			*bufPtr = make([]byte, entry.Size)
+		}
+		compressed = (*bufPtr)[:entry.Size]
+
+		resources.offsetFileReader.reset(s.dataReader, entry.Offset)
+		if _, err := io.ReadFull(resources.offsetFileReader, compressed); err != nil {
+			return nil, err
+		}
+	}
+
+	buffer, err := decompressDataSegment(s.dataCompression, compressed, s.opts.bytesPool)
+	if err != nil {
+		return nil, err
+	}
+
+	// NB: The checksum is of the uncompressed segment so that it matches
+	// the checksum of the same series on other replicas regardless of
+	// whether they compress their filesets.
+	buffer.IncRef()
+	checksum := digest.Checksum(buffer.Bytes())
+	buffer.DecRef()
+	if entry.Checksum != checksum {
+		return nil, errSeekChecksumMismatch
+	}
+
+	return buffer, nil
+}
+
 // SeekIndexEntry performs the following steps:
 //
 //     1. Go to the indexLookup and it will give us an offset that is a good starting
@@ -385,7 +606,7 @@ func (s *seeker) SeekIndexEntry(
 		return IndexEntry{}, err
 	}
 
-	resources.offsetFileReader.reset(s.indexFd, offset)
+	resources.offsetFileReader.reset(s.indexReader, offset)
 	resources.fileDecoderStream.Reset(resources.offsetFileReader)
 	resources.xmsgpackDecoder.Reset(resources.fileDecoderStream)
 
@@ -470,7 +691,11 @@ func (s *seeker) Close() error {
 		s.bloomFilter = nil
 	}
 	if s.indexLookup != nil {
-		multiErr = multiErr.Add(s.indexLookup.close())
+		if s.indexLookupCache != nil {
+			multiErr = multiErr.Add(s.indexLookupCache.release(s.indexLookupCacheKey))
+		} else {
+			multiErr = multiErr.Add(s.indexLookup.close())
+		}
 		s.indexLookup = nil
 	}
 	if s.indexFd != nil {
@@ -481,6 +706,13 @@ func (s *seeker) Close() error {
 		multiErr = multiErr.Add(s.dataFd.Close())
 		s.dataFd = nil
 	}
+	if s.dataMmap != nil {
+		multiErr = multiErr.Add(mmap.Munmap(s.dataMmap))
+		s.dataMmap = nil
+	}
+	s.fileCipher = nil
+	s.indexReader = nil
+	s.dataReader = nil
 	return multiErr.FinalError()
 }
 
@@ -501,9 +733,16 @@ func (s *seeker) ConcurrentClone() (ConcurrentDataFileSetSeeker, error) {
 		isClone:     true,
 
 		// Index and data fd's are always accessed via the ReadAt() / pread APIs so
-		// they are concurrency safe and can be shared among clones.
-		indexFd: s.indexFd,
-		dataFd:  s.dataFd,
+		// they are concurrency safe and can be shared among clones, as can the
+		// readers that wrap them.
+		indexFd:     s.indexFd,
+		dataFd:      s.dataFd,
+		fileCipher:  s.fileCipher,
+		indexReader: s.indexReader,
+		dataReader:  s.dataReader,
+		// The data mmap is read only so it can also be shared among clones.
+		dataMmap:        s.dataMmap,
+		dataCompression: s.dataCompression,
 	}
 
 	return seeker, nil
@@ -609,14 +848,15 @@ func (s *simpleBytesPool) Put(b []byte) {
 
 var _ io.Reader = &offsetFileReader{}
 
-// offsetFileReader implements io.Reader() and allows an *os.File to be wrapped
-// such that any calls to Read() are issued at the provided offset. This is used
-// to issue reads to specific portions of the index and data files without having
-// to first call Seek(). This reduces the number of syscalls that need to be made
-// and also allows the fds to be shared among concurrent goroutines since the
-// internal F.D offset managed by the kernel is not being used.
+// offsetFileReader implements io.Reader() and allows an *os.File, or any other
+// io.ReaderAt such as a decrypting reader, to be wrapped such that any calls to
+// Read() are issued at the provided offset. This is used to issue reads to specific
+// portions of the index and data files without having to first call Seek(). This
+// reduces the number of syscalls that need to be made and also allows the fds to be
+// shared among concurrent goroutines since the internal F.D offset managed by the
+// kernel is not being used.
 type offsetFileReader struct {
-	fd     *os.File
+	fd     io.ReaderAt
 	offset int64
 }
 
@@ -630,7 +870,7 @@ func (p *offsetFileReader) Read(b []byte) (n int, err error) {
 	return n, err
 }
 
-func (p *offsetFileReader) reset(fd *os.File, offset int64) {
+func (p *offsetFileReader) reset(fd io.ReaderAt, offset int64) {
 	p.fd = fd
 	p.offset = offset
 }
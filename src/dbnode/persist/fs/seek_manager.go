@@ -23,6 +23,8 @@ package fs
 import (
 	"errors"
 	"fmt"
+	"os"
+	"strconv"
 	"sync"
 	"time"
 
@@ -34,12 +36,27 @@ import (
 	"github.com/m3db/m3/src/x/pool"
 	xtime "github.com/m3db/m3/src/x/time"
 
+	"github.com/uber-go/tally"
 	"go.uber.org/zap"
 )
 
 const (
-	seekManagerCloseInterval        = time.Second
 	reusableSeekerResourcesPoolSize = 10
+
+	// seekManagerReopenErrorThreshold is the number of read errors reported for
+	// the seekers of a shard/blockStart before they are closed and reopened.
+	seekManagerReopenErrorThreshold = 3
+	// seekManagerMinReopenInterval is the minimum amount of time seekers must
+	// have been open for before they're reopened again, this avoids constantly
+	// reopening seekers for filesets that are persistently corrupt.
+	seekManagerMinReopenInterval = time.Minute
+	// seekManagerEvictInterval is the interval between scans of local disk for
+	// filesets of blocks older than the object store local retention to evict.
+	seekManagerEvictInterval = time.Minute
+	// seekManagerEvictIdleTimeout is how long the seekers of blocks older than
+	// the object store local retention are kept open since last borrowed, and
+	// how long their filesets are kept on local disk once downloaded.
+	seekManagerEvictIdleTimeout = 5 * time.Minute
 )
 
 var (
@@ -51,11 +68,16 @@ var (
 	errCantCloseSeekerManagerWhileSeekersAreBorrowed = errors.New("cant close seeker manager while seekers are borrowed")
 	errReturnedUnmanagedSeeker                       = errors.New("cant return a seeker not managed by the seeker manager")
 	errUpdateOpenLeaseSeekerManagerNotOpen           = errors.New("cant update open lease because seeker manager is not open")
-	errConcurrentUpdateOpenLeaseNotAllowed           = errors.New("concurrent open lease updates are not allowed")
+	errConcurrentUpdateOpenLeaseNotAllowed           = errors.New("concurrent open lease updates for the same shard and block start are not allowed")
 	errOutOfOrderUpdateOpenLease                     = errors.New("received update open lease volumes out of order")
+	errCacheShardIndicesInvalidRange                 = errors.New("cant cache shard indices for range with end not after start")
+	errUpdateNamespaceSeekerManagerNotOpen           = errors.New("cant update namespace because seeker manager is not open")
+	errUpdateNamespaceMismatch                       = errors.New("cant update namespace to a different namespace or block size")
 )
 
-type openAnyUnopenSeekersFn func(*seekersByTime) error
+// openAnyUnopenSeekersFn opens any unopened seekers for a shard, opening at most
+// limit seekers if the limit is positive, and returns the number opened.
+type openAnyUnopenSeekersFn func(byTime *seekersByTime, limit int) (int, error)
 
 type newOpenSeekerFn func(
 	shard uint32,
@@ -83,17 +105,82 @@ type seekerManager struct {
 	filePathPrefix string
 
 	status                 seekerManagerStatus
-	isUpdatingLease        bool
+	updatingLeases         map[seekerManagerLeaseKey]struct{}
 	seekersByShardIdx      []*seekersByTime
 	namespace              ident.ID
-	namespaceMetadata      namespace.Metadata
 	unreadBuf              seekerUnreadBuf
 	openAnyUnopenSeekersFn openAnyUnopenSeekersFn
 	newOpenSeekerFn        newOpenSeekerFn
+	indexLookupCache       *indexLookupCache
 	sleepFn                func(d time.Duration)
 	openCloseLoopDoneCh    chan struct{}
+	metrics                seekerManagerMetrics
 	// Pool of seeker resources that can be used to open new seekers.
 	reusableSeekerResourcesPool pool.ObjectPool
+
+	// namespaceMetadata is guarded by its own lock since it is read on
+	// paths that do not hold the seeker manager lock and may be updated
+	// when the namespace retention changes at runtime.
+	namespaceMetadataLock sync.RWMutex
+	namespaceMetadata     namespace.Metadata
+}
+
+type seekerManagerMetrics struct {
+	scope           tally.Scope
+	readErrors      tally.Counter
+	reopens         tally.Counter
+	reopenErrors    tally.Counter
+	indexEntryCache indexEntryCacheMetrics
+
+	objectStoreDownloads      tally.Counter
+	objectStoreDownloadErrors tally.Counter
+	objectStoreEvictions      tally.Counter
+	objectStoreEvictErrors    tally.Counter
+}
+
+func newSeekerManagerMetrics(scope tally.Scope) seekerManagerMetrics {
+	scope = scope.SubScope("seeker-manager")
+	return seekerManagerMetrics{
+		scope:           scope,
+		readErrors:      scope.Counter("read-errors"),
+		reopens:         scope.Counter("reopens"),
+		reopenErrors:    scope.Counter("reopen-errors"),
+		indexEntryCache: newIndexEntryCacheMetrics(scope),
+
+		objectStoreDownloads:      scope.Counter("object-store-downloads"),
+		objectStoreDownloadErrors: scope.Counter("object-store-download-errors"),
+		objectStoreEvictions:      scope.Counter("object-store-evictions"),
+		objectStoreEvictErrors:    scope.Counter("object-store-evict-errors"),
+	}
+}
+
+// seekersByTimeMetrics are the metrics for the seekers of a single shard, used
+// to diagnose read stalls while seekers are hot-swapped for a new volume.
+type seekersByTimeMetrics struct {
+	// borrowLatency is the time spent waiting to borrow a seeker, including
+	// waiting for the seekers to be opened.
+	borrowLatency tally.Timer
+	// cloneUtilization is the fraction of the seekers (the original and its
+	// clones) of the most recently borrowed or returned block that are borrowed.
+	cloneUtilization tally.Gauge
+	// inactiveSeekersAwaitingReturn is the number of borrowed inactive seekers
+	// that an open lease update is waiting to be returned.
+	inactiveSeekersAwaitingReturn tally.Gauge
+	// hotSwapLatency is the wall time of an open lease update from opening the
+	// new seekers until all the inactive seekers have been returned and closed.
+	hotSwapLatency tally.Timer
+}
+
+func newSeekersByTimeMetrics(scope tally.Scope, shard uint32) seekersByTimeMetrics {
+	scope = scope.Tagged(map[string]string{
+		"shard": strconv.Itoa(int(shard)),
+	})
+	return seekersByTimeMetrics{
+		borrowLatency:                 scope.Timer("borrow-latency"),
+		cloneUtilization:              scope.Gauge("clone-utilization"),
+		inactiveSeekersAwaitingReturn: scope.Gauge("inactive-seekers-awaiting-return"),
+		hotSwapLatency:                scope.Timer("hot-swap-latency"),
+	}
 }
 
 type seekerUnreadBuf struct {
@@ -109,6 +196,13 @@ type seekersAndBloom struct {
 	seekers     []borrowableSeeker
 	bloomFilter *ManagedConcurrentBloomFilter
 	volume      int
+	// indexEntryCache is shared by the seekers, nil if it is disabled. It is
+	// discarded along with the seekers so it never outlives the volume.
+	indexEntryCache *indexEntryCache
+	// openedAt and readErrors are used to determine whether the seekers are
+	// unhealthy and need to be reopened.
+	openedAt   time.Time
+	readErrors int
 }
 
 // borrowableSeeker is just a seeker with an additional field for keeping track of whether or not it has been borrowed.
@@ -122,11 +216,22 @@ type seekersByTime struct {
 	shard    uint32
 	accessed bool
 	seekers  map[xtime.UnixNano]rotatableSeekers
+	metrics  seekersByTimeMetrics
 }
 
 type rotatableSeekers struct {
 	active   seekersAndBloom
 	inactive seekersAndBloom
+	// lastBorrowedAt is used to close the idle seekers of blocks older than
+	// the object store local retention so their filesets can be evicted.
+	lastBorrowedAt time.Time
+}
+
+// seekerManagerLeaseKey identifies a shard/blockStart combination that has an
+// in-flight call to UpdateOpenLease().
+type seekerManagerLeaseKey struct {
+	shard      uint32
+	blockStart xtime.UnixNano
 }
 
 type seekerManagerPendingClose struct {
@@ -134,6 +239,11 @@ type seekerManagerPendingClose struct {
 	blockStart time.Time
 }
 
+type seekerManagerPendingReopen struct {
+	shard      uint32
+	blockStart time.Time
+}
+
 // NewSeekerManager returns a new TSDB file set seeker manager.
 func NewSeekerManager(
 	bytesPool pool.CheckedBytesPool,
@@ -156,11 +266,16 @@ func NewSeekerManager(
 		blockRetrieverOpts:          blockRetrieverOpts,
 		fetchConcurrency:            blockRetrieverOpts.FetchConcurrency(),
 		logger:                      opts.InstrumentOptions().Logger(),
+		updatingLeases:              make(map[seekerManagerLeaseKey]struct{}),
 		openCloseLoopDoneCh:         make(chan struct{}),
+		metrics:                     newSeekerManagerMetrics(opts.InstrumentOptions().MetricsScope()),
 		reusableSeekerResourcesPool: reusableSeekerResourcesPool,
 	}
 	m.openAnyUnopenSeekersFn = m.openAnyUnopenSeekers
 	m.newOpenSeekerFn = m.newOpenSeeker
+	if opts.ShareIndexSummaries() {
+		m.indexLookupCache = newIndexLookupCache()
+	}
 	m.sleepFn = time.Sleep
 	return m
 }
@@ -175,7 +290,10 @@ func (m *seekerManager) Open(
 	}
 
 	m.namespace = nsMetadata.ID()
+	m.filePathPrefix = m.opts.NamespaceFilePathPrefix(m.namespace)
+	m.namespaceMetadataLock.Lock()
 	m.namespaceMetadata = nsMetadata
+	m.namespaceMetadataLock.Unlock()
 	m.status = seekerManagerOpen
 	go m.openCloseLoop()
 	m.Unlock()
@@ -190,6 +308,38 @@ func (m *seekerManager) Open(
 	return nil
 }
 
+// UpdateNamespaceMetadata updates the namespace metadata used to determine
+// the range of seekable block starts, the open/close loop then opens or closes
+// seekers on its next iteration to match the updated retention.
+func (m *seekerManager) UpdateNamespaceMetadata(nsMetadata namespace.Metadata) error {
+	m.RLock()
+	status, nsID := m.status, m.namespace
+	m.RUnlock()
+	if status != seekerManagerOpen {
+		return errUpdateNamespaceSeekerManagerNotOpen
+	}
+	if !nsID.Equal(nsMetadata.ID()) {
+		return errUpdateNamespaceMismatch
+	}
+
+	m.namespaceMetadataLock.Lock()
+	defer m.namespaceMetadataLock.Unlock()
+
+	currBlockSize := m.namespaceMetadata.Options().RetentionOptions().BlockSize()
+	if nsMetadata.Options().RetentionOptions().BlockSize() != currBlockSize {
+		return errUpdateNamespaceMismatch
+	}
+	m.namespaceMetadata = nsMetadata
+	return nil
+}
+
+func (m *seekerManager) nsMetadata() namespace.Metadata {
+	m.namespaceMetadataLock.RLock()
+	md := m.namespaceMetadata
+	m.namespaceMetadataLock.RUnlock()
+	return md
+}
+
 func (m *seekerManager) CacheShardIndices(shards []uint32) error {
 	multiErr := xerrors.NewMultiError()
 
@@ -201,7 +351,44 @@ func (m *seekerManager) CacheShardIndices(shards []uint32) error {
 		byTime.accessed = true
 		byTime.Unlock()
 
-		if err := m.openAnyUnopenSeekersFn(byTime); err != nil {
+		if _, err := m.openAnyUnopenSeekersFn(byTime, 0); err != nil {
+			multiErr = multiErr.Add(err)
+		}
+	}
+
+	return multiErr.FinalError()
+}
+
+// CacheShardIndicesForRange opens the seekers for the given shards only for
+// the blocks that overlap the range [start, end). Unlike CacheShardIndices it
+// does not mark the shards as accessed, so the open/close loop does not go on
+// to open the seekers for the rest of retention.
+func (m *seekerManager) CacheShardIndicesForRange(
+	shards []uint32,
+	start, end time.Time,
+) error {
+	if !end.After(start) {
+		return errCacheShardIndicesInvalidRange
+	}
+
+	var (
+		blockSize     = m.nsMetadata().Options().RetentionOptions().BlockSize()
+		rangeStart    = start.Truncate(blockSize)
+		rangeEnd      = end.Add(-1).Truncate(blockSize)
+		earliestStart = m.earliestSeekableBlockStart()
+		latestStart   = m.latestSeekableBlockStart()
+		multiErr      = xerrors.NewMultiError()
+	)
+	if rangeStart.Before(earliestStart) {
+		rangeStart = earliestStart
+	}
+	if rangeEnd.After(latestStart) {
+		rangeEnd = latestStart
+	}
+
+	for _, shard := range shards {
+		byTime := m.seekersByTime(shard)
+		if _, err := m.openUnopenSeekersInRange(byTime, rangeStart, rangeEnd, 0); err != nil {
 			multiErr = multiErr.Add(err)
 		}
 	}
@@ -230,6 +417,8 @@ func (m *seekerManager) ConcurrentIDBloomFilter(shard uint32, start time.Time) (
 
 func (m *seekerManager) Borrow(shard uint32, start time.Time) (ConcurrentDataFileSetSeeker, error) {
 	byTime := m.seekersByTime(shard)
+	sw := byTime.metrics.borrowLatency.Start()
+	defer sw.Stop()
 
 	byTime.Lock()
 	defer byTime.Unlock()
@@ -260,6 +449,12 @@ func (m *seekerManager) Borrow(shard uint32, start time.Time) (ConcurrentDataFil
 
 	availableSeeker.isBorrowed = true
 	seekers[availableSeekerIdx] = availableSeeker
+	byTime.metrics.cloneUtilization.Update(borrowedFraction(seekers))
+
+	if rotatable, ok := byTime.seekers[startNano]; ok {
+		rotatable.lastBorrowedAt = m.opts.ClockOptions().NowFn()()
+		byTime.seekers[startNano] = rotatable
+	}
 	return availableSeeker.seeker, nil
 }
 
@@ -282,6 +477,7 @@ func (m *seekerManager) Return(shard uint32, start time.Time, seeker ConcurrentD
 	if err != nil {
 		return err
 	}
+	byTime.metrics.cloneUtilization.Update(borrowedFraction(seekers.active.seekers))
 
 	// Should never happen with a well behaved caller. Either they are trying to return a seeker
 	// that we're not managing, or they provided the wrong shard/start.
@@ -292,6 +488,42 @@ func (m *seekerManager) Return(shard uint32, start time.Time, seeker ConcurrentD
 	return nil
 }
 
+// ReportReadError records a read error for the active seekers of a shard/blockStart,
+// once enough errors have been reported the openCloseLoop will close and reopen
+// the seekers so that a transiently corrupt mmap or fd doesn't fail all reads
+// for the block until the process is restarted.
+func (m *seekerManager) ReportReadError(
+	shard uint32,
+	start time.Time,
+	seeker ConcurrentDataFileSetSeeker,
+	err error,
+) {
+	if err == nil || err == errSeekIDNotFound {
+		return
+	}
+	m.metrics.readErrors.Inc(1)
+
+	byTime := m.seekersByTime(shard)
+	byTime.Lock()
+	defer byTime.Unlock()
+
+	startNano := xtime.ToUnixNano(start)
+	seekers, ok := byTime.seekers[startNano]
+	if !ok || seekers.active.wg != nil {
+		return
+	}
+
+	// Errors from inactive seekers are not relevant since they're about to
+	// be closed anyways.
+	for _, compareSeeker := range seekers.active.seekers {
+		if seeker == compareSeeker.seeker {
+			seekers.active.readErrors++
+			byTime.seekers[startNano] = seekers
+			return
+		}
+	}
+}
+
 // returnSeekerWithLock encapsulates all the logic for returning a seeker, including distinguishing between active
 // and inactive seekers. For more details on this read the comment above the UpdateOpenLease() method.
 func (m *seekerManager) returnSeekerWithLock(seekers rotatableSeekers, seeker ConcurrentDataFileSetSeeker) (bool, error) {
@@ -371,6 +603,9 @@ func (m *seekerManager) returnSeekerWithLock(seekers rotatableSeekers, seeker Co
 //      and if so, will close all the inactive seekers and call wg.Done() which will notify the goroutine
 //      running the UpdateOpenlease() function that all inactive seekers have been returned and closed at
 //      which point the function will return sucessfully.
+//
+// Concurrent calls are permitted as long as they are for different shard/blockStart combinations
+// since each hot-swap only touches the seekers for its own shard/blockStart.
 func (m *seekerManager) UpdateOpenLease(
 	descriptor block.LeaseDescriptor,
 	state block.LeaseState,
@@ -382,13 +617,10 @@ func (m *seekerManager) UpdateOpenLease(
 	if noop {
 		return block.NoOpenLease, nil
 	}
-	defer func() {
-		m.Lock()
-		// Was already set to true by startUpdateOpenLease().
-		m.isUpdatingLease = false
-		m.Unlock()
-	}()
+	defer m.finishUpdateOpenLease(descriptor)
 
+	byTime := m.seekersByTime(descriptor.Shard)
+	sw := byTime.metrics.hotSwapLatency.Start()
 	wg, updateLeaseResult, err := m.updateOpenLeaseHotSwapSeekers(descriptor, state)
 	if err != nil {
 		return 0, err
@@ -398,7 +630,9 @@ func (m *seekerManager) UpdateOpenLease(
 		// of this API is that the Leaser (SeekerManager) should have relinquished any resources
 		// associated with the old lease by the time this function returns.
 		wg.Wait()
+		byTime.metrics.inactiveSeekersAwaitingReturn.Update(0)
 	}
+	sw.Stop()
 
 	return updateLeaseResult, nil
 }
@@ -410,22 +644,35 @@ func (m *seekerManager) startUpdateOpenLease(descriptor block.LeaseDescriptor) (
 	if m.status != seekerManagerOpen {
 		return false, errUpdateOpenLeaseSeekerManagerNotOpen
 	}
-	if m.isUpdatingLease {
-		// This guard is a little overly aggressive. In practice, the algorithm remains correct even in the presence
-		// of concurrent UpdateOpenLease() calls as long as they are for different shard/blockStart combinations.
-		// However, the calling code currently has no need to call this method concurrently at all so use the
-		// simpler check for now.
-		return false, errConcurrentUpdateOpenLeaseNotAllowed
-	}
 	if !m.namespace.Equal(descriptor.Namespace) {
 		return true, nil
 	}
 
-	m.isUpdatingLease = true
+	// The algorithm remains correct in the presence of concurrent UpdateOpenLease() calls as long as they
+	// are for different shard/blockStart combinations so only reject concurrent updates for the same one.
+	key := newSeekerManagerLeaseKey(descriptor)
+	if _, ok := m.updatingLeases[key]; ok {
+		return false, errConcurrentUpdateOpenLeaseNotAllowed
+	}
+	m.updatingLeases[key] = struct{}{}
 
 	return false, nil
 }
 
+func (m *seekerManager) finishUpdateOpenLease(descriptor block.LeaseDescriptor) {
+	m.Lock()
+	// Was already added by startUpdateOpenLease().
+	delete(m.updatingLeases, newSeekerManagerLeaseKey(descriptor))
+	m.Unlock()
+}
+
+func newSeekerManagerLeaseKey(descriptor block.LeaseDescriptor) seekerManagerLeaseKey {
+	return seekerManagerLeaseKey{
+		shard:      descriptor.Shard,
+		blockStart: xtime.ToUnixNano(descriptor.BlockStart),
+	}
+}
+
 // updateOpenLeaseHotSwapSeekers encapsulates all of the logic for swapping the existing seekers with the new ones
 // as dictated by the call to UpdateOpenLease(). For details of the algorithm review the comment above the
 // UpdateOpenLease() method.
@@ -465,22 +712,27 @@ func (m *seekerManager) updateOpenLeaseHotSwapSeekers(
 
 	// If any of the previous seekers are still borrowed this function will need to wait for
 	// them to be returned.
-	anySeekersAreBorrowed := false
+	numBorrowed := 0
 	for _, seeker := range seekers.inactive.seekers {
 		if seeker.isBorrowed {
-			anySeekersAreBorrowed = true
-			break
+			numBorrowed++
 		}
 	}
 
 	var wg *sync.WaitGroup
-	if anySeekersAreBorrowed {
+	if numBorrowed > 0 {
+		byTime.metrics.inactiveSeekersAwaitingReturn.Update(float64(numBorrowed))
 		// If any of the seekers are borrowed setup a waitgroup which will be used to
 		// signal when they've all been returned (the last seeker that is returned via
 		// the Return() API will call wg.Done()).
 		wg = &sync.WaitGroup{}
 		wg.Add(1)
 		seekers.inactive.wg = wg
+	} else {
+		// If none of the previous seekers are borrowed then they can be closed
+		// immediately, otherwise they would be leaked by a subsequent swap.
+		m.closeSeekersAndLogError(descriptor, seekers.inactive.seekers)
+		seekers.inactive = seekersAndBloom{}
 	}
 	byTime.seekers[blockStartNano] = seekers
 
@@ -518,6 +770,20 @@ func (m *seekerManager) acquireByTimeLockWaitGroupAware(
 	}
 }
 
+// borrowedFraction returns the fraction of the seekers that are borrowed.
+func borrowedFraction(seekers []borrowableSeeker) float64 {
+	if len(seekers) == 0 {
+		return 0
+	}
+	borrowed := 0
+	for _, seeker := range seekers {
+		if seeker.isBorrowed {
+			borrowed++
+		}
+	}
+	return float64(borrowed) / float64(len(seekers))
+}
+
 // closeSeekersAndLogError is a helper function that closes all the seekers in a slice of borrowableSeeker
 // and emits a log if any errors occurred.
 func (m *seekerManager) closeSeekersAndLogError(descriptor block.LeaseDescriptor, seekers []borrowableSeeker) {
@@ -647,29 +913,127 @@ func (m *seekerManager) seekersAndBloomFromSeeker(seeker DataFileSetSeeker, volu
 		borrowableSeekers = append(borrowableSeekers, borrowableSeeker{seeker: clone})
 	}
 
+	var cache *indexEntryCache
+	if size := m.blockRetrieverOpts.IndexEntryCacheSize(); size > 0 {
+		cache = newIndexEntryCache(size, m.metrics.indexEntryCache)
+		for i := range borrowableSeekers {
+			borrowableSeekers[i].seeker = newIndexEntryCachingSeeker(
+				borrowableSeekers[i].seeker, cache, m.bytesPool)
+		}
+	}
+
 	return seekersAndBloom{
-		seekers:     borrowableSeekers,
-		bloomFilter: borrowableSeekers[0].seeker.ConcurrentIDBloomFilter(),
-		volume:      volume,
+		seekers:         borrowableSeekers,
+		bloomFilter:     borrowableSeekers[0].seeker.ConcurrentIDBloomFilter(),
+		volume:          volume,
+		openedAt:        m.opts.ClockOptions().NowFn()(),
+		indexEntryCache: cache,
 	}, nil
 }
 
-func (m *seekerManager) openAnyUnopenSeekers(byTime *seekersByTime) error {
+// reopenSeekers closes and reopens the seekers for a shard/blockStart that have
+// been reported as unhealthy. The seekers are swapped using the same mechanism
+// as UpdateOpenLease() so that reads are not interrupted, and it is treated as
+// an in-flight lease update so that it cannot race with one for the same
+// shard/blockStart.
+func (m *seekerManager) reopenSeekers(shard uint32, blockStart time.Time) error {
+	descriptor := block.LeaseDescriptor{
+		Namespace:  m.namespace,
+		Shard:      shard,
+		BlockStart: blockStart,
+	}
+	noop, err := m.startUpdateOpenLease(descriptor)
+	if err == errConcurrentUpdateOpenLeaseNotAllowed {
+		// A lease update is already swapping the seekers.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if noop {
+		return nil
+	}
+	defer m.finishUpdateOpenLease(descriptor)
+
+	byTime := m.seekersByTime(shard)
+	byTime.RLock()
+	seekers, ok := byTime.seekers[xtime.ToUnixNano(blockStart)]
+	byTime.RUnlock()
+	if !ok {
+		// Seekers were closed in the meantime.
+		return nil
+	}
+
+	wg, _, err := m.updateOpenLeaseHotSwapSeekers(descriptor, block.LeaseState{
+		Volume: seekers.active.volume,
+	})
+	if err != nil {
+		// Reset the error count so that the seekers are only reopened again
+		// once further read errors are reported.
+		m.resetReadErrors(shard, blockStart)
+		return err
+	}
+	if wg != nil {
+		wg.Wait()
+	}
+	return nil
+}
+
+func (m *seekerManager) resetReadErrors(shard uint32, blockStart time.Time) {
+	byTime := m.seekersByTime(shard)
+	byTime.Lock()
+	defer byTime.Unlock()
+
+	startNano := xtime.ToUnixNano(blockStart)
+	if seekers, ok := byTime.seekers[startNano]; ok && seekers.active.wg == nil {
+		seekers.active.readErrors = 0
+		byTime.seekers[startNano] = seekers
+	}
+}
+
+func (m *seekerManager) openAnyUnopenSeekers(byTime *seekersByTime, limit int) (int, error) {
+	// Seekers of blocks whose filesets are evicted from local disk are only
+	// opened when borrowed to avoid downloading the filesets.
 	start := m.earliestSeekableBlockStart()
-	end := m.latestSeekableBlockStart()
-	blockSize := m.namespaceMetadata.Options().RetentionOptions().BlockSize()
+	now := m.opts.ClockOptions().NowFn()()
+	if local := m.earliestLocalBlockStart(now); local.After(start) {
+		start = local
+	}
+	return m.openUnopenSeekersInRange(byTime, start,
+		m.latestSeekableBlockStart(), limit)
+}
+
+// openUnopenSeekersInRange opens any unopened seekers for a shard with block
+// starts between start and end inclusive, opening at most limit seekers if
+// the limit is positive, and returns the number opened.
+func (m *seekerManager) openUnopenSeekersInRange(
+	byTime *seekersByTime,
+	start, end time.Time,
+	limit int,
+) (int, error) {
+	blockSize := m.nsMetadata().Options().RetentionOptions().BlockSize()
 	multiErr := xerrors.NewMultiError()
+	opened := 0
 
 	for t := start; !t.After(end); t = t.Add(blockSize) {
+		if limit > 0 && opened >= limit {
+			break
+		}
+
+		startNano := xtime.ToUnixNano(t)
 		byTime.Lock()
-		_, err := m.getOrOpenSeekersWithLock(xtime.ToUnixNano(t), byTime)
+		_, alreadyOpen := byTime.seekers[startNano]
+		_, err := m.getOrOpenSeekersWithLock(startNano, byTime)
 		byTime.Unlock()
 		if err != nil && err != errSeekerManagerFileSetNotFound {
 			multiErr = multiErr.Add(err)
 		}
+		if err == nil && !alreadyOpen {
+			opened++
+		}
 	}
 
-	return multiErr.FinalError()
+	return opened, multiErr.FinalError()
 }
 
 func (m *seekerManager) newOpenSeeker(
@@ -677,11 +1041,19 @@ func (m *seekerManager) newOpenSeeker(
 	blockStart time.Time,
 	volume int,
 ) (DataFileSetSeeker, error) {
-	exists, err := DataFileSetExists(
-		m.filePathPrefix, m.namespace, shard, blockStart, volume)
+	// Local disk acts as a cache of the object store, if any, so fetch the
+	// fileset if it has been evicted or was never held locally.
+	exists, downloaded, err := ensureDataFileSetLocal(
+		m.opts, m.namespace, shard, blockStart, volume)
 	if err != nil {
+		if m.opts.ObjectStore() != nil {
+			m.metrics.objectStoreDownloadErrors.Inc(1)
+		}
 		return nil, err
 	}
+	if downloaded {
+		m.metrics.objectStoreDownloads.Inc(1)
+	}
 	if !exists {
 		return nil, errSeekerManagerFileSetNotFound
 	}
@@ -704,6 +1076,12 @@ func (m *seekerManager) newOpenSeeker(
 
 	// Set the unread buffer to reuse it amongst all seekers.
 	seeker.setUnreadBuffer(m.unreadBuf.value)
+	if m.indexLookupCache != nil {
+		seeker.setIndexLookupCache(m.indexLookupCache)
+	}
+	if !m.nsMetadata().Options().BloomFilterEnabled() {
+		seeker.disableBloomFilter()
+	}
 
 	resources := m.getSeekerResources()
 	err = seeker.Open(m.namespace, shard, blockStart, volume, resources)
@@ -748,6 +1126,7 @@ func (m *seekerManager) seekersByTime(shard uint32) *seekersByTime {
 		seekersByShardIdx[i] = &seekersByTime{
 			shard:   uint32(i),
 			seekers: make(map[xtime.UnixNano]rotatableSeekers),
+			metrics: newSeekersByTimeMetrics(m.metrics.scope, uint32(i)),
 		}
 	}
 
@@ -809,7 +1188,7 @@ func (m *seekerManager) Close() error {
 func (m *seekerManager) earliestSeekableBlockStart() time.Time {
 	nowFn := m.opts.ClockOptions().NowFn()
 	now := nowFn()
-	ropts := m.namespaceMetadata.Options().RetentionOptions()
+	ropts := m.nsMetadata().Options().RetentionOptions()
 	blockSize := ropts.BlockSize()
 	earliestReachableBlockStart := retention.FlushTimeStart(ropts, now)
 	earliestSeekableBlockStart := earliestReachableBlockStart.Add(-blockSize)
@@ -819,15 +1198,141 @@ func (m *seekerManager) earliestSeekableBlockStart() time.Time {
 func (m *seekerManager) latestSeekableBlockStart() time.Time {
 	nowFn := m.opts.ClockOptions().NowFn()
 	now := nowFn()
-	ropts := m.namespaceMetadata.Options().RetentionOptions()
+	ropts := m.nsMetadata().Options().RetentionOptions()
 	return now.Truncate(ropts.BlockSize())
 }
 
+// earliestLocalBlockStart returns the earliest block start whose filesets are
+// kept on local disk, the filesets of earlier blocks are evicted from local
+// disk once uploaded to the object store. Zero if filesets are never evicted.
+func (m *seekerManager) earliestLocalBlockStart(now time.Time) time.Time {
+	retention := m.opts.ObjectStoreLocalRetention()
+	if m.opts.ObjectStore() == nil || retention <= 0 {
+		return time.Time{}
+	}
+	blockSize := m.nsMetadata().Options().RetentionOptions().BlockSize()
+	return now.Add(-retention).Truncate(blockSize)
+}
+
+// evictLocalFileSets evicts the filesets of blocks before the earliest local
+// block start of the accessed shards from local disk.
+func (m *seekerManager) evictLocalFileSets(earliestLocalBlockStart, now time.Time) {
+	m.RLock()
+	shards := make([]*seekersByTime, 0, len(m.seekersByShardIdx))
+	for _, byTime := range m.seekersByShardIdx {
+		byTime.RLock()
+		accessed := byTime.accessed
+		byTime.RUnlock()
+		if accessed {
+			shards = append(shards, byTime)
+		}
+	}
+	m.RUnlock()
+
+	for _, byTime := range shards {
+		if err := m.evictLocalFileSetsForShard(byTime, earliestLocalBlockStart, now); err != nil {
+			m.metrics.objectStoreEvictErrors.Inc(1)
+			m.logger.Error("err evicting filesets in SeekerManager openCloseLoop",
+				zap.Error(err),
+				zap.Uint32("shard", byTime.shard))
+		}
+	}
+}
+
+func (m *seekerManager) evictLocalFileSetsForShard(
+	byTime *seekersByTime,
+	earliestLocalBlockStart time.Time,
+	now time.Time,
+) error {
+	files, err := DataFiles(m.filePathPrefix, m.namespace, byTime.shard)
+	if err != nil {
+		return err
+	}
+
+	var (
+		store    = m.opts.ObjectStore()
+		shardDir = ShardDataDirPath(m.filePathPrefix, m.namespace, byTime.shard)
+		multiErr = xerrors.NewMultiError()
+	)
+	for _, file := range files {
+		var (
+			blockStart = file.ID.BlockStart
+			volume     = file.ID.VolumeIndex
+		)
+		if !blockStart.Before(earliestLocalBlockStart) || !file.HasCompleteCheckpointFile() {
+			continue
+		}
+		evicted, err := dataFileSetEvicted(m.filePathPrefix, m.namespace,
+			byTime.shard, blockStart, volume)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		if evicted {
+			continue
+		}
+
+		// Keep filesets that were just downloaded so that the readers that
+		// downloaded them can still open them.
+		dataPath := dataFilesetPathFromTimeAndIndex(shardDir, blockStart, volume,
+			dataFileSuffix, false)
+		stat, err := os.Stat(dataPath)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		if now.Sub(stat.ModTime()) < seekManagerEvictIdleTimeout {
+			continue
+		}
+
+		uploaded, err := dataFileSetUploaded(store, m.filePathPrefix, m.namespace,
+			byTime.shard, blockStart, volume)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		if !uploaded {
+			continue
+		}
+
+		// NB: Hold the lock while evicting so that seekers for the block are
+		// not concurrently opened, they download the fileset again once the
+		// lock is released.
+		byTime.Lock()
+		_, open := byTime.seekers[xtime.ToUnixNano(blockStart)]
+		if !open {
+			err = removeEvictableDataFileSetFiles(m.filePathPrefix, m.namespace,
+				byTime.shard, blockStart, volume)
+		}
+		byTime.Unlock()
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		if !open {
+			m.metrics.objectStoreEvictions.Inc(1)
+		}
+	}
+	return multiErr.FinalError()
+}
+
 func (m *seekerManager) openCloseLoop() {
 	var (
 		shouldTryOpen []*seekersByTime
 		shouldClose   []seekerManagerPendingClose
+		shouldReopen  []seekerManagerPendingReopen
 		closing       []borrowableSeeker
+		// openOffset is the index into the accessed shards to start opening
+		// seekers from so that shards are opened fairly when the number of
+		// seekers opened per iteration is limited.
+		openOffset int
+
+		// lastEvictAt is when local disk was last scanned for filesets to evict.
+		lastEvictAt time.Time
+
+		closeInterval = m.opts.SeekerManagerCloseInterval()
+		maxOpened     = m.opts.SeekerManagerMaxOpenedPerIteration()
+		maxClosed     = m.opts.SeekerManagerMaxClosedPerIteration()
 	)
 	resetSlices := func() {
 		for i := range shouldTryOpen {
@@ -838,6 +1343,10 @@ func (m *seekerManager) openCloseLoop() {
 			shouldClose[i] = seekerManagerPendingClose{}
 		}
 		shouldClose = shouldClose[:0]
+		for i := range shouldReopen {
+			shouldReopen[i] = seekerManagerPendingReopen{}
+		}
+		shouldReopen = shouldReopen[:0]
 		for i := range closing {
 			closing[i] = borrowableSeeker{}
 		}
@@ -847,6 +1356,8 @@ func (m *seekerManager) openCloseLoop() {
 	for {
 		earliestSeekableBlockStart :=
 			m.earliestSeekableBlockStart()
+		now := m.opts.ClockOptions().NowFn()()
+		earliestLocalBlockStart := m.earliestLocalBlockStart(now)
 
 		m.RLock()
 		if m.status != seekerManagerOpen {
@@ -866,27 +1377,63 @@ func (m *seekerManager) openCloseLoop() {
 		m.RUnlock()
 
 		// Try opening any unopened times for accessed seekers
-		for _, byTime := range shouldTryOpen {
-			m.openAnyUnopenSeekersFn(byTime)
+		remaining := maxOpened
+		for i := range shouldTryOpen {
+			idx := (openOffset + i) % len(shouldTryOpen)
+			opened, _ := m.openAnyUnopenSeekersFn(shouldTryOpen[idx], remaining)
+			if maxOpened <= 0 {
+				continue
+			}
+			remaining -= opened
+			if remaining <= 0 {
+				// Resume from this shard next iteration since it may
+				// still have unopened seekers.
+				openOffset = idx
+				break
+			}
 		}
 
 		m.RLock()
 		for shard, byTime := range m.seekersByShardIdx {
 			byTime.RLock()
-			for blockStartNano := range byTime.seekers {
+			for blockStartNano, seekers := range byTime.seekers {
 				blockStart := blockStartNano.ToTime()
 				if blockStart.Before(earliestSeekableBlockStart) {
 					shouldClose = append(shouldClose, seekerManagerPendingClose{
 						shard:      uint32(shard),
 						blockStart: blockStart,
 					})
+					continue
+				}
+				if blockStart.Before(earliestLocalBlockStart) &&
+					seekers.active.wg == nil &&
+					now.Sub(seekers.lastBorrowedAt) >= seekManagerEvictIdleTimeout {
+					// Close idle seekers so the fileset can be evicted.
+					shouldClose = append(shouldClose, seekerManagerPendingClose{
+						shard:      uint32(shard),
+						blockStart: blockStart,
+					})
+					continue
+				}
+				if seekers.active.wg == nil &&
+					seekers.active.readErrors >= seekManagerReopenErrorThreshold &&
+					now.Sub(seekers.active.openedAt) >= seekManagerMinReopenInterval {
+					shouldReopen = append(shouldReopen, seekerManagerPendingReopen{
+						shard:      uint32(shard),
+						blockStart: blockStart,
+					})
 				}
 			}
 			byTime.RUnlock()
 		}
 
 		if len(shouldClose) > 0 {
+			closed := 0
 			for _, elem := range shouldClose {
+				if maxClosed > 0 && closed >= maxClosed {
+					// Remaining seekers will be closed in subsequent iterations.
+					break
+				}
 				byTime := m.seekersByShardIdx[elem.shard]
 				blockStartNano := xtime.ToUnixNano(elem.blockStart)
 				byTime.Lock()
@@ -915,6 +1462,7 @@ func (m *seekerManager) openCloseLoop() {
 					closing = append(closing, seekers.active.seekers...)
 					closing = append(closing, seekers.inactive.seekers...)
 					delete(byTime.seekers, blockStartNano)
+					closed++
 				}
 				byTime.Unlock()
 			}
@@ -929,7 +1477,28 @@ func (m *seekerManager) openCloseLoop() {
 			}
 		}
 
-		m.sleepFn(seekManagerCloseInterval)
+		// Reopen any unhealthy seekers, this also happens outside of the lock
+		// since reopening a seeker is I/O heavy.
+		for _, elem := range shouldReopen {
+			if err := m.reopenSeekers(elem.shard, elem.blockStart); err != nil {
+				m.metrics.reopenErrors.Inc(1)
+				m.logger.Error("err reopening unhealthy seekers in SeekerManager openCloseLoop",
+					zap.Error(err),
+					zap.Uint32("shard", elem.shard),
+					zap.Time("blockStart", elem.blockStart))
+				continue
+			}
+			m.metrics.reopens.Inc(1)
+		}
+
+		// Evict the filesets of blocks older than the local retention, this is
+		// done at most every evict interval since it scans local disk.
+		if !earliestLocalBlockStart.IsZero() && now.Sub(lastEvictAt) >= seekManagerEvictInterval {
+			m.evictLocalFileSets(earliestLocalBlockStart, now)
+			lastEvictAt = now
+		}
+
+		m.sleepFn(closeInterval)
 
 		resetSlices()
 	}
@@ -25,13 +25,17 @@ import (
 	"testing"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/instrument"
 	xtime "github.com/m3db/m3/src/x/time"
 
 	"github.com/fortytw2/leaktest"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
 )
 
 var (
@@ -45,9 +49,9 @@ func TestSeekerManagerCacheShardIndices(t *testing.T) {
 	shards := []uint32{2, 5, 9, 478, 1023}
 	m := NewSeekerManager(nil, testDefaultOpts, defaultTestBlockRetrieverOptions).(*seekerManager)
 	var byTimes []*seekersByTime
-	m.openAnyUnopenSeekersFn = func(byTime *seekersByTime) error {
+	m.openAnyUnopenSeekersFn = func(byTime *seekersByTime, _ int) (int, error) {
 		byTimes = append(byTimes, byTime)
-		return nil
+		return 0, nil
 	}
 
 	require.NoError(t, m.CacheShardIndices(shards))
@@ -74,6 +78,230 @@ func TestSeekerManagerCacheShardIndices(t *testing.T) {
 	}
 }
 
+func TestSeekerManagerCacheShardIndicesForRange(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 1*time.Minute)()
+
+	var (
+		ctrl     = gomock.NewController(t)
+		shards   = []uint32{2, 5}
+		metadata = testNs1Metadata(t)
+		now      = time.Now()
+		opts     = testDefaultOpts.SetClockOptions(clock.NewOptions().
+				SetNowFn(func() time.Time { return now }))
+		m      = NewSeekerManager(nil, opts, defaultTestBlockRetrieverOptions).(*seekerManager)
+		opened = make(map[uint32][]time.Time)
+		lock   sync.Mutex
+	)
+	defer ctrl.Finish()
+
+	m.newOpenSeekerFn = func(
+		shard uint32,
+		blockStart time.Time,
+		volume int,
+	) (DataFileSetSeeker, error) {
+		lock.Lock()
+		opened[shard] = append(opened[shard], blockStart)
+		lock.Unlock()
+
+		mock := NewMockDataFileSetSeeker(ctrl)
+		for i := 0; i < defaultFetchConcurrency-1; i++ {
+			mock.EXPECT().ConcurrentClone().Return(mock, nil)
+		}
+		for i := 0; i < defaultFetchConcurrency; i++ {
+			mock.EXPECT().Close().Return(nil)
+			mock.EXPECT().ConcurrentIDBloomFilter().Return(nil).AnyTimes()
+		}
+		return mock, nil
+	}
+	m.sleepFn = func(_ time.Duration) {
+		time.Sleep(time.Millisecond)
+	}
+	require.NoError(t, m.Open(metadata))
+
+	var (
+		blockSize = metadata.Options().RetentionOptions().BlockSize()
+		latest    = m.latestSeekableBlockStart()
+	)
+	require.Equal(t, errCacheShardIndicesInvalidRange,
+		m.CacheShardIndicesForRange(shards, latest, latest))
+
+	// The range start is truncated to its block start and the range end is
+	// clamped to the latest seekable block.
+	require.NoError(t, m.CacheShardIndicesForRange(shards,
+		latest.Add(-blockSize).Add(time.Second), latest.Add(2*blockSize)))
+
+	lock.Lock()
+	for _, shard := range shards {
+		require.Equal(t, []time.Time{latest.Add(-blockSize), latest}, opened[shard])
+
+		byTime := m.seekersByTime(shard)
+		byTime.RLock()
+		require.False(t, byTime.accessed)
+		require.Equal(t, 2, len(byTime.seekers))
+		byTime.RUnlock()
+	}
+	lock.Unlock()
+
+	require.NoError(t, m.Close())
+}
+
+func TestSeekerManagerUpdateNamespaceMetadata(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 1*time.Minute)()
+
+	var (
+		metadata = testNs1Metadata(t)
+		now      = time.Now()
+		opts     = testDefaultOpts.SetClockOptions(clock.NewOptions().
+				SetNowFn(func() time.Time { return now }))
+		m = NewSeekerManager(nil, opts, defaultTestBlockRetrieverOptions).(*seekerManager)
+	)
+	require.Equal(t, errUpdateNamespaceSeekerManagerNotOpen,
+		m.UpdateNamespaceMetadata(metadata))
+
+	m.sleepFn = func(_ time.Duration) {
+		time.Sleep(time.Millisecond)
+	}
+	require.NoError(t, m.Open(metadata))
+
+	ropts := metadata.Options().RetentionOptions()
+	earliest := m.earliestSeekableBlockStart()
+
+	// Shortening the retention moves the earliest seekable block start later.
+	shorter := ropts.SetRetentionPeriod(ropts.RetentionPeriod() / 2)
+	updated, err := namespace.NewMetadata(testNs1ID,
+		metadata.Options().SetRetentionOptions(shorter))
+	require.NoError(t, err)
+	require.NoError(t, m.UpdateNamespaceMetadata(updated))
+	require.True(t, m.earliestSeekableBlockStart().After(earliest))
+
+	// The namespace and block size cannot change.
+	require.Equal(t, errUpdateNamespaceMismatch,
+		m.UpdateNamespaceMetadata(testNs2Metadata(t)))
+	changedBlockSize, err := namespace.NewMetadata(testNs1ID, metadata.Options().
+		SetRetentionOptions(shorter.SetBlockSize(2*ropts.BlockSize())))
+	require.NoError(t, err)
+	require.Equal(t, errUpdateNamespaceMismatch,
+		m.UpdateNamespaceMetadata(changedBlockSize))
+	require.Equal(t, updated, m.nsMetadata())
+
+	require.NoError(t, m.Close())
+}
+
+func TestSeekerManagerBorrowMetrics(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 1*time.Minute)()
+
+	var (
+		ctrl     = gomock.NewController(t)
+		scope    = tally.NewTestScope("", nil)
+		metadata = testNs1Metadata(t)
+		opts     = testDefaultOpts.SetInstrumentOptions(
+			instrument.NewOptions().SetMetricsScope(scope))
+		m = NewSeekerManager(nil, opts, defaultTestBlockRetrieverOptions).(*seekerManager)
+	)
+	defer ctrl.Finish()
+
+	m.newOpenSeekerFn = func(
+		shard uint32,
+		blockStart time.Time,
+		volume int,
+	) (DataFileSetSeeker, error) {
+		mock := NewMockDataFileSetSeeker(ctrl)
+		for i := 0; i < defaultFetchConcurrency-1; i++ {
+			mock.EXPECT().ConcurrentClone().Return(mock, nil)
+		}
+		for i := 0; i < defaultFetchConcurrency; i++ {
+			mock.EXPECT().Close().Return(nil)
+			mock.EXPECT().ConcurrentIDBloomFilter().Return(nil).AnyTimes()
+		}
+		return mock, nil
+	}
+	m.openAnyUnopenSeekersFn = func(_ *seekersByTime, _ int) (int, error) {
+		return 0, nil
+	}
+	m.sleepFn = func(_ time.Duration) {
+		time.Sleep(time.Millisecond)
+	}
+	require.NoError(t, m.Open(metadata))
+
+	blockStart := m.latestSeekableBlockStart()
+	seeker, err := m.Borrow(3, blockStart)
+	require.NoError(t, err)
+
+	snapshot := scope.Snapshot()
+	utilization, ok := snapshot.Gauges()["seeker-manager.clone-utilization+shard=3"]
+	require.True(t, ok)
+	require.Equal(t, 1/float64(defaultFetchConcurrency), utilization.Value())
+	borrowLatency, ok := snapshot.Timers()["seeker-manager.borrow-latency+shard=3"]
+	require.True(t, ok)
+	require.Equal(t, 1, len(borrowLatency.Values()))
+
+	require.NoError(t, m.Return(3, blockStart, seeker))
+	utilization, ok = scope.Snapshot().Gauges()["seeker-manager.clone-utilization+shard=3"]
+	require.True(t, ok)
+	require.Equal(t, float64(0), utilization.Value())
+
+	require.NoError(t, m.Close())
+}
+
+func TestSeekerManagerIndexEntryCache(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 1*time.Minute)()
+
+	var (
+		ctrl     = gomock.NewController(t)
+		metadata = testNs1Metadata(t)
+		m        = NewSeekerManager(nil, testDefaultOpts,
+			defaultTestBlockRetrieverOptions.SetIndexEntryCacheSize(16)).(*seekerManager)
+	)
+	defer ctrl.Finish()
+
+	m.newOpenSeekerFn = func(
+		shard uint32,
+		blockStart time.Time,
+		volume int,
+	) (DataFileSetSeeker, error) {
+		mock := NewMockDataFileSetSeeker(ctrl)
+		for i := 0; i < defaultFetchConcurrency-1; i++ {
+			mock.EXPECT().ConcurrentClone().Return(mock, nil)
+		}
+		for i := 0; i < defaultFetchConcurrency; i++ {
+			mock.EXPECT().Close().Return(nil)
+			mock.EXPECT().ConcurrentIDBloomFilter().Return(nil).AnyTimes()
+		}
+		return mock, nil
+	}
+	m.openAnyUnopenSeekersFn = func(_ *seekersByTime, _ int) (int, error) {
+		return 0, nil
+	}
+	m.sleepFn = func(_ time.Duration) {
+		time.Sleep(time.Millisecond)
+	}
+	require.NoError(t, m.Open(metadata))
+
+	// All the seekers of the block start share the same cache.
+	blockStart := m.latestSeekableBlockStart()
+	var borrowed []ConcurrentDataFileSetSeeker
+	for i := 0; i < defaultFetchConcurrency; i++ {
+		seeker, err := m.Borrow(0, blockStart)
+		require.NoError(t, err)
+		borrowed = append(borrowed, seeker)
+	}
+
+	byTime := m.seekersByTime(0)
+	byTime.RLock()
+	cache := byTime.seekers[xtime.ToUnixNano(blockStart)].active.indexEntryCache
+	byTime.RUnlock()
+	require.NotNil(t, cache)
+
+	for _, seeker := range borrowed {
+		cachingSeeker, ok := seeker.(*indexEntryCachingSeeker)
+		require.True(t, ok)
+		require.True(t, cache == cachingSeeker.cache)
+		require.NoError(t, m.Return(0, blockStart, seeker))
+	}
+
+	require.NoError(t, m.Close())
+}
+
 func TestSeekerManagerUpdateOpenLease(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 1*time.Minute)()
 
@@ -239,20 +467,20 @@ func TestSeekerManagerOpenCloseLoop(t *testing.T) {
 	m.opts = m.opts.SetClockOptions(clockOpts)
 
 	// Initialize some seekers for a time period
-	m.openAnyUnopenSeekersFn = func(byTime *seekersByTime) error {
+	m.openAnyUnopenSeekersFn = func(byTime *seekersByTime, _ int) (int, error) {
 		byTime.Lock()
 		defer byTime.Unlock()
 
 		// Don't overwrite if called again
 		if len(byTime.seekers) != 0 {
-			return nil
+			return 0, nil
 		}
 
 		// Don't re-open if they should have expired
 		fakeTimeLock.Lock()
 		defer fakeTimeLock.Unlock()
 		if !fakeTime.Equal(now) {
-			return nil
+			return 0, nil
 		}
 
 		mock := NewMockDataFileSetSeeker(ctrl)
@@ -265,7 +493,7 @@ func TestSeekerManagerOpenCloseLoop(t *testing.T) {
 				bloomFilter: nil,
 			},
 		}
-		return nil
+		return 1, nil
 	}
 
 	// Force all the seekers to be opened
@@ -380,3 +608,210 @@ func TestSeekerManagerOpenCloseLoop(t *testing.T) {
 	// to prevent the test itself from interfering with the goroutine leak test
 	close(cleanupCh)
 }
+
+// TestSeekerManagerUpdateOpenLeaseConcurrentNotAllowed tests that concurrent calls to
+// UpdateOpenLease() are rejected for the same shard/blockStart but allowed for
+// different ones.
+func TestSeekerManagerUpdateOpenLeaseConcurrentNotAllowed(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 1*time.Minute)()
+
+	var (
+		ctrl     = gomock.NewController(t)
+		metadata = testNs1Metadata(t)
+		m        = NewSeekerManager(nil, testDefaultOpts, defaultTestBlockRetrieverOptions).(*seekerManager)
+	)
+	defer ctrl.Finish()
+
+	m.newOpenSeekerFn = func(
+		shard uint32,
+		blockStart time.Time,
+		volume int,
+	) (DataFileSetSeeker, error) {
+		mock := NewMockDataFileSetSeeker(ctrl)
+		for i := 0; i < defaultFetchConcurrency-1; i++ {
+			mock.EXPECT().ConcurrentClone().Return(mock, nil)
+		}
+		for i := 0; i < defaultFetchConcurrency; i++ {
+			mock.EXPECT().Close().Return(nil)
+			mock.EXPECT().ConcurrentIDBloomFilter().Return(nil).AnyTimes()
+		}
+		return mock, nil
+	}
+	m.sleepFn = func(_ time.Duration) {
+		time.Sleep(time.Millisecond)
+	}
+	require.NoError(t, m.Open(metadata))
+
+	// Simulate an in-flight update for shard 1.
+	inFlight := block.LeaseDescriptor{
+		Namespace:  metadata.ID(),
+		Shard:      1,
+		BlockStart: time.Time{},
+	}
+	m.Lock()
+	m.updatingLeases[newSeekerManagerLeaseKey(inFlight)] = struct{}{}
+	m.Unlock()
+
+	_, err := m.UpdateOpenLease(inFlight, block.LeaseState{Volume: 1})
+	require.Equal(t, errConcurrentUpdateOpenLeaseNotAllowed, err)
+
+	// Updates for other shards should proceed concurrently.
+	var wg sync.WaitGroup
+	for _, shard := range []uint32{2, 3, 4} {
+		shard := shard
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			updateResult, err := m.UpdateOpenLease(block.LeaseDescriptor{
+				Namespace:  metadata.ID(),
+				Shard:      shard,
+				BlockStart: time.Time{},
+			}, block.LeaseState{Volume: 1})
+			require.NoError(t, err)
+			require.Equal(t, block.NoOpenLease, updateResult)
+		}()
+	}
+	wg.Wait()
+
+	m.finishUpdateOpenLease(inFlight)
+	m.RLock()
+	require.Equal(t, 0, len(m.updatingLeases))
+	m.RUnlock()
+
+	require.NoError(t, m.Close())
+}
+
+// TestSeekerManagerReopenUnhealthySeekers tests that seekers which have read
+// errors reported against them are swapped for newly opened seekers.
+func TestSeekerManagerReopenUnhealthySeekers(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 1*time.Minute)()
+
+	var (
+		ctrl     = gomock.NewController(t)
+		metadata = testNs1Metadata(t)
+		m        = NewSeekerManager(nil, testDefaultOpts, defaultTestBlockRetrieverOptions).(*seekerManager)
+		opened   int
+	)
+	defer ctrl.Finish()
+
+	m.newOpenSeekerFn = func(
+		shard uint32,
+		blockStart time.Time,
+		volume int,
+	) (DataFileSetSeeker, error) {
+		opened++
+		mock := NewMockDataFileSetSeeker(ctrl)
+		for i := 0; i < defaultFetchConcurrency-1; i++ {
+			mock.EXPECT().ConcurrentClone().Return(mock, nil)
+		}
+		for i := 0; i < defaultFetchConcurrency; i++ {
+			mock.EXPECT().Close().Return(nil)
+			mock.EXPECT().ConcurrentIDBloomFilter().Return(nil).AnyTimes()
+		}
+		return mock, nil
+	}
+	m.openAnyUnopenSeekersFn = func(_ *seekersByTime, _ int) (int, error) {
+		return 0, nil
+	}
+	m.sleepFn = func(_ time.Duration) {
+		time.Sleep(time.Millisecond)
+	}
+	require.NoError(t, m.Open(metadata))
+
+	// Use a block start within retention so the openCloseLoop doesn't close the seekers.
+	var (
+		blockStart     = m.latestSeekableBlockStart()
+		blockStartNano = xtime.ToUnixNano(blockStart)
+	)
+
+	seeker, err := m.Borrow(0, blockStart)
+	require.NoError(t, err)
+	require.Equal(t, 1, opened)
+
+	// Errors for IDs that don't exist or from unmanaged seekers are ignored.
+	m.ReportReadError(0, blockStart, seeker, errSeekIDNotFound)
+	m.ReportReadError(0, blockStart, NewMockConcurrentDataFileSetSeeker(ctrl), errSeekChecksumMismatch)
+	for i := 0; i < seekManagerReopenErrorThreshold; i++ {
+		m.ReportReadError(0, blockStart, seeker, errSeekChecksumMismatch)
+	}
+
+	byTime := m.seekersByTime(0)
+	byTime.RLock()
+	seekers := byTime.seekers[blockStartNano]
+	require.Equal(t, seekManagerReopenErrorThreshold, seekers.active.readErrors)
+	byTime.RUnlock()
+
+	// Reopen while the seeker is still borrowed, the reopen should wait for it
+	// to be returned before completing.
+	doneCh := make(chan error)
+	go func() {
+		doneCh <- m.reopenSeekers(0, blockStart)
+	}()
+	for {
+		byTime.RLock()
+		seekers = byTime.seekers[blockStartNano]
+		byTime.RUnlock()
+		if seekers.inactive.wg != nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	require.NoError(t, m.Return(0, blockStart, seeker))
+	require.NoError(t, <-doneCh)
+	require.Equal(t, 2, opened)
+
+	byTime.RLock()
+	seekers = byTime.seekers[blockStartNano]
+	require.Equal(t, 0, seekers.active.readErrors)
+	require.Equal(t, defaultFetchConcurrency, len(seekers.active.seekers))
+	require.NotEqual(t, seeker, seekers.active.seekers[0].seeker)
+	require.Equal(t, 0, len(seekers.inactive.seekers))
+	byTime.RUnlock()
+
+	require.NoError(t, m.Close())
+}
+
+// TestSeekerManagerOpenAnyUnopenSeekersLimit tests that openAnyUnopenSeekers
+// respects the limit of seekers to open.
+func TestSeekerManagerOpenAnyUnopenSeekersLimit(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 1*time.Minute)()
+
+	var (
+		ctrl = gomock.NewController(t)
+		m    = NewSeekerManager(nil, testDefaultOpts, defaultTestBlockRetrieverOptions).(*seekerManager)
+	)
+	defer ctrl.Finish()
+
+	m.newOpenSeekerFn = func(
+		shard uint32,
+		blockStart time.Time,
+		volume int,
+	) (DataFileSetSeeker, error) {
+		mock := NewMockDataFileSetSeeker(ctrl)
+		for i := 0; i < defaultFetchConcurrency-1; i++ {
+			mock.EXPECT().ConcurrentClone().Return(mock, nil)
+		}
+		for i := 0; i < defaultFetchConcurrency; i++ {
+			mock.EXPECT().Close().Return(nil)
+			mock.EXPECT().ConcurrentIDBloomFilter().Return(nil).AnyTimes()
+		}
+		return mock, nil
+	}
+	m.sleepFn = func(_ time.Duration) {
+		time.Sleep(time.Millisecond)
+	}
+	require.NoError(t, m.Open(testNs1Metadata(t)))
+
+	byTime := m.seekersByTime(0)
+	for i := 1; i <= 2; i++ {
+		opened, err := m.openAnyUnopenSeekers(byTime, 2)
+		require.NoError(t, err)
+		require.Equal(t, 2, opened)
+
+		byTime.RLock()
+		require.Equal(t, 2*i, len(byTime.seekers))
+		byTime.RUnlock()
+	}
+
+	require.NoError(t, m.Close())
+}
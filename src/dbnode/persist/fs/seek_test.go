@@ -21,14 +21,18 @@
 package fs
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/m3db/bloom"
 	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/x/ident"
 
 	"github.com/stretchr/testify/assert"
@@ -402,6 +406,340 @@ func TestCloneSeeker(t *testing.T) {
 	assert.Equal(t, []byte{1, 2, 1}, data.Bytes())
 }
 
+func TestSeekerSharedIndexLookup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	w := newTestWriter(t, filePathPrefix)
+	writerOpts := DataWriterOpenOptions{
+		BlockSize: testBlockSize,
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+	}
+	err = w.Open(writerOpts)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Write(
+		ident.StringID("foo"), ident.Tags{},
+		bytesRefd([]byte{1, 2, 3}),
+		digest.Checksum([]byte{1, 2, 3})))
+	assert.NoError(t, w.Close())
+
+	var (
+		resources = newTestReusableSeekerResources()
+		cache     = newIndexLookupCache()
+		seekers   []*seeker
+	)
+	for i := 0; i < 2; i++ {
+		s := newTestSeeker(filePathPrefix).(*seeker)
+		s.setIndexLookupCache(cache)
+		require.NoError(t, s.Open(testNs1ID, 0, testWriterStart, 0, resources))
+		seekers = append(seekers, s)
+	}
+
+	// Both seekers should share the same lookup.
+	require.True(t, seekers[0].indexLookup == seekers[1].indexLookup)
+	require.Equal(t, 1, len(cache.entries))
+
+	clone, err := seekers[0].ConcurrentClone()
+	require.NoError(t, err)
+
+	// Closing the first seeker should not release the lookup for the second.
+	require.NoError(t, seekers[0].Close())
+	require.Equal(t, 1, len(cache.entries))
+
+	data, err := seekers[1].SeekByID(ident.StringID("foo"), resources)
+	require.NoError(t, err)
+	data.IncRef()
+	assert.Equal(t, []byte{1, 2, 3}, data.Bytes())
+	data.DecRef()
+
+	require.NoError(t, clone.Close())
+	require.NoError(t, seekers[1].Close())
+	require.Equal(t, 0, len(cache.entries))
+}
+
+func TestSeekerBloomFilterOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	falsePositivePercent := 0.001
+	w := newTestWriter(t, filePathPrefix)
+	writerOpts := DataWriterOpenOptions{
+		BlockSize: testBlockSize,
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+		BloomFilterFalsePositivePercent: falsePositivePercent,
+	}
+	err = w.Open(writerOpts)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Write(
+		ident.StringID("foo"), ident.Tags{},
+		bytesRefd([]byte{1, 2, 3}),
+		digest.Checksum([]byte{1, 2, 3})))
+	assert.NoError(t, w.Close())
+
+	// The bloom filter should be sized for the false positive percent of the
+	// writer open options rather than the writer default.
+	resources := newTestReusableSeekerResources()
+	s := newTestSeeker(filePathPrefix)
+	require.NoError(t, s.Open(testNs1ID, 0, testWriterStart, 0, resources))
+	m, k := bloom.EstimateFalsePositiveRate(1, falsePositivePercent)
+	require.NotNil(t, s.ConcurrentIDBloomFilter())
+	require.Equal(t, m, s.ConcurrentIDBloomFilter().M())
+	require.Equal(t, k, s.ConcurrentIDBloomFilter().K())
+	require.NoError(t, s.Close())
+
+	// With the bloom filter disabled no bloom filter is loaded but IDs can
+	// still be seeked.
+	disabled := newTestSeeker(filePathPrefix).(*seeker)
+	disabled.disableBloomFilter()
+	require.NoError(t, disabled.Open(testNs1ID, 0, testWriterStart, 0, resources))
+	require.Nil(t, disabled.ConcurrentIDBloomFilter())
+
+	data, err := disabled.SeekByID(ident.StringID("foo"), resources)
+	require.NoError(t, err)
+	data.IncRef()
+	assert.Equal(t, []byte{1, 2, 3}, data.Bytes())
+	data.DecRef()
+
+	_, err = disabled.SeekByID(ident.StringID("bar"), resources)
+	require.Equal(t, errSeekIDNotFound, err)
+	require.NoError(t, disabled.Close())
+}
+
+func TestSeekDataFileMmap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	w := newTestWriter(t, filePathPrefix)
+	writerOpts := DataWriterOpenOptions{
+		BlockSize: testBlockSize,
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+	}
+	err = w.Open(writerOpts)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Write(
+		ident.StringID("foo1"), ident.Tags{},
+		bytesRefd([]byte{1, 2, 1}),
+		digest.Checksum([]byte{1, 2, 1})))
+	assert.NoError(t, w.Write(
+		ident.StringID("foo2"), ident.Tags{},
+		bytesRefd([]byte{1, 2, 2}),
+		digest.Checksum([]byte{1, 2, 2})))
+	assert.NoError(t, w.Close())
+
+	resources := newTestReusableSeekerResources()
+	s := NewSeeker(
+		filePathPrefix, testReaderBufferSize, testReaderBufferSize,
+		testBytesPool, false, testDefaultOpts.SetSeekerDataFileMmapEnabled(true),
+	).(*seeker)
+	require.NoError(t, s.Open(testNs1ID, 0, testWriterStart, 0, resources))
+
+	// The data fd is not needed once the data file is mmap'd.
+	require.Nil(t, s.dataFd)
+	require.NotNil(t, s.dataMmap)
+
+	data, err := s.SeekByID(ident.StringID("foo2"), resources)
+	require.NoError(t, err)
+	data.IncRef()
+	assert.Equal(t, []byte{1, 2, 2}, data.Bytes())
+	data.DecRef()
+
+	// Clones share the mapping of the parent.
+	clone, err := s.ConcurrentClone()
+	require.NoError(t, err)
+	data, err = clone.SeekByID(ident.StringID("foo1"), resources)
+	require.NoError(t, err)
+	data.IncRef()
+	assert.Equal(t, []byte{1, 2, 1}, data.Bytes())
+	data.DecRef()
+	require.NoError(t, clone.Close())
+
+	entry, err := s.SeekIndexEntry(ident.StringID("foo1"), resources)
+	require.NoError(t, err)
+	entry.Offset = int64(len(s.dataMmap))
+	_, err = s.SeekByIndexEntry(entry, resources)
+	require.Equal(t, errSeekEntryOutOfBounds, err)
+
+	require.NoError(t, s.Close())
+	require.Nil(t, s.dataMmap)
+}
+
+func TestSeekDataCompression(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	w, err := NewWriter(testDefaultOpts.
+		SetFilePathPrefix(filePathPrefix).
+		SetWriterBufferSize(testWriterBufferSize).
+		SetDataCompression(persist.ZstdDataCompression).
+		SetZstdCompressionLevel(9))
+	require.NoError(t, err)
+	writerOpts := DataWriterOpenOptions{
+		BlockSize: testBlockSize,
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+	}
+	foo2Data := bytes.Repeat([]byte{1, 2, 2}, 1024)
+	require.NoError(t, w.Open(writerOpts))
+	require.NoError(t, w.Write(
+		ident.StringID("foo1"), ident.Tags{},
+		bytesRefd([]byte{1, 2, 1}),
+		digest.Checksum([]byte{1, 2, 1})))
+	require.NoError(t, w.Write(
+		ident.StringID("foo2"), ident.Tags{},
+		bytesRefd(foo2Data),
+		digest.Checksum(foo2Data)))
+	require.NoError(t, w.Close())
+
+	for _, mmapEnabled := range []bool{false, true} {
+		resources := newTestReusableSeekerResources()
+		s := NewSeeker(
+			filePathPrefix, testReaderBufferSize, testReaderBufferSize,
+			testBytesPool, false, testDefaultOpts.SetSeekerDataFileMmapEnabled(mmapEnabled),
+		).(*seeker)
+		require.NoError(t, s.Open(testNs1ID, 0, testWriterStart, 0, resources))
+		require.Equal(t, persist.ZstdDataCompression, s.dataCompression)
+
+		data, err := s.SeekByID(ident.StringID("foo1"), resources)
+		require.NoError(t, err)
+		data.IncRef()
+		assert.Equal(t, []byte{1, 2, 1}, data.Bytes())
+		data.DecRef()
+
+		// The index entry holds the size of the compressed segment.
+		entry, err := s.SeekIndexEntry(ident.StringID("foo2"), resources)
+		require.NoError(t, err)
+		require.True(t, int(entry.Size) < len(foo2Data))
+
+		clone, err := s.ConcurrentClone()
+		require.NoError(t, err)
+		data, err = clone.SeekByIndexEntry(entry, resources)
+		require.NoError(t, err)
+		data.IncRef()
+		assert.Equal(t, foo2Data, data.Bytes())
+		data.DecRef()
+		require.NoError(t, clone.Close())
+
+		// The checksum is verified against the decompressed data.
+		entry.Checksum++
+		_, err = s.SeekByIndexEntry(entry, resources)
+		require.Equal(t, errSeekChecksumMismatch, err)
+
+		require.NoError(t, s.Close())
+	}
+}
+
 func newTestReusableSeekerResources() ReusableSeekerResources {
 	return NewReusableSeekerResources(testDefaultOpts)
 }
+
+func TestSeekEncryption(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	wrapper := newTestDataKeyWrapper(t)
+	w, err := NewWriter(testDefaultOpts.
+		SetFilePathPrefix(filePathPrefix).
+		SetWriterBufferSize(testWriterBufferSize).
+		SetIndexSummariesPercent(0.1).
+		SetDataKeyWrapper(wrapper))
+	require.NoError(t, err)
+	writerOpts := DataWriterOpenOptions{
+		BlockSize: testBlockSize,
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+	}
+
+	// Write enough series that the index, summaries and data files each
+	// span several encrypted chunks.
+	const numSeries = 500
+	require.NoError(t, w.Open(writerOpts))
+	for i := 0; i < numSeries; i++ {
+		data := bytes.Repeat([]byte{byte(i)}, 10+i)
+		require.NoError(t, w.Write(
+			ident.StringID(fmt.Sprintf("foo%d", i)), ident.Tags{},
+			bytesRefd(data),
+			digest.Checksum(data)))
+	}
+	require.NoError(t, w.Close())
+
+	// The data file mmap option is ignored for encrypted filesets.
+	for _, mmapEnabled := range []bool{false, true} {
+		resources := newTestReusableSeekerResources()
+		s := NewSeeker(
+			filePathPrefix, testReaderBufferSize, testReaderBufferSize,
+			testBytesPool, false, testDefaultOpts.
+				SetSeekerDataFileMmapEnabled(mmapEnabled).
+				SetDataKeyWrapper(wrapper),
+		).(*seeker)
+		require.NoError(t, s.Open(testNs1ID, 0, testWriterStart, 0, resources))
+		require.NotNil(t, s.fileCipher)
+		require.Nil(t, s.dataMmap)
+
+		clone, err := s.ConcurrentClone()
+		require.NoError(t, err)
+		for i := 0; i < numSeries; i++ {
+			expected := bytes.Repeat([]byte{byte(i)}, 10+i)
+			seekerOrClone := ConcurrentDataFileSetSeeker(s)
+			if i%2 == 0 {
+				seekerOrClone = clone
+			}
+			data, err := seekerOrClone.SeekByID(ident.StringID(fmt.Sprintf("foo%d", i)), resources)
+			require.NoError(t, err)
+			data.IncRef()
+			assert.Equal(t, expected, data.Bytes())
+			data.DecRef()
+		}
+
+		_, err = s.SeekByID(ident.StringID("bar"), resources)
+		require.Equal(t, errSeekIDNotFound, err)
+
+		require.NoError(t, clone.Close())
+		require.NoError(t, s.Close())
+	}
+
+	// Encrypted filesets cannot be opened without the data key wrapper.
+	s := NewSeeker(
+		filePathPrefix, testReaderBufferSize, testReaderBufferSize,
+		testBytesPool, false, testDefaultOpts,
+	)
+	err = s.Open(testNs1ID, 0, testWriterStart, 0, newTestReusableSeekerResources())
+	require.Equal(t, errDataKeyWrapperNotSet, err)
+}
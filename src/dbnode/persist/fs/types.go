@@ -63,6 +63,9 @@ type DataWriterOpenOptions struct {
 	BlockSize          time.Duration
 	// Only used when writing snapshot files
 	Snapshot DataWriterSnapshotOptions
+	// BloomFilterFalsePositivePercent is the target false positive percent of
+	// the bloom filter, if zero the writer's configured default is used.
+	BloomFilterFalsePositivePercent float64
 }
 
 // DataWriterSnapshotOptions is the options struct for Open method on the DataFileSetWriter
@@ -89,6 +92,10 @@ type DataFileSetWriter interface {
 	// WriteAll will write the id and all byte slices and returns an error on a write error.
 	// Callers must not call this method with a given ID more than once.
 	WriteAll(id ident.ID, tags ident.Tags, data []checked.Bytes, checksum uint32) error
+
+	// Abort closes the files opened for writing without writing the checkpoint
+	// file, so the partially written volume is never considered complete.
+	Abort() error
 }
 
 // SnapshotMetadataFileWriter writes out snapshot metadata files.
@@ -135,7 +142,8 @@ type DataFileSetReader interface {
 	// ReadMetadata returns the next id and metadata or error, will return io.EOF at end of volume.
 	// Use either Read or ReadMetadata to progress through a volume, but not both.
 	// Note: make sure to finalize the ID, and close the Tags when done with them so they can
-	// be returned to their respective pools.
+	// be returned to their respective pools. If the data segments are compressed the length
+	// is that of the compressed segment on disk.
 	ReadMetadata() (id ident.ID, tags ident.TagIterator, length int, checksum uint32, err error)
 
 	// ReadBloomFilter returns the bloom filter stored on disk in a container object that is safe
@@ -198,7 +206,8 @@ type DataFileSetSeeker interface {
 	// ConcurrentIDBloomFilter returns a concurrency-safe bloom filter that can
 	// be used to quickly disqualify ID's that definitely do not exist. I.E if the
 	// Test() method returns true, the ID may exist on disk, but if it returns
-	// false, it definitely does not.
+	// false, it definitely does not. Returns nil if bloom filter loading is
+	// disabled for the namespace.
 	ConcurrentIDBloomFilter() *ManagedConcurrentBloomFilter
 
 	// ConcurrentClone clones a seeker, creating a copy that uses the same underlying resources
@@ -234,18 +243,31 @@ type DataFileSetSeekerManager interface {
 	// Open opens the seekers for a given namespace.
 	Open(md namespace.Metadata) error
 
+	// UpdateNamespaceMetadata updates the metadata of the open namespace, such
+	// as when its retention changes, the block size must remain unchanged.
+	UpdateNamespaceMetadata(md namespace.Metadata) error
+
 	// CacheShardIndices will pre-parse the indexes for given shards
 	// to improve times when seeking to a block.
 	CacheShardIndices(shards []uint32) error
 
+	// CacheShardIndicesForRange will pre-parse the indexes for given shards
+	// for only the blocks that overlap the range [start, end).
+	CacheShardIndicesForRange(shards []uint32, start, end time.Time) error
+
 	// Borrow returns an open seeker for a given shard, block start time, and volume.
 	Borrow(shard uint32, start time.Time) (ConcurrentDataFileSetSeeker, error)
 
 	// Return returns an open seeker for a given shard, block start time, and volume.
 	Return(shard uint32, start time.Time, seeker ConcurrentDataFileSetSeeker) error
 
+	// ReportReadError reports an error encountered while reading from a borrowed
+	// seeker so that seekers which repeatedly fail reads can be reopened.
+	ReportReadError(shard uint32, start time.Time, seeker ConcurrentDataFileSetSeeker, err error)
+
 	// ConcurrentIDBloomFilter returns a concurrent ID bloom filter for a given
-	// shard, block start time, and volume.
+	// shard, block start time, and volume, or nil if bloom filter loading is
+	// disabled for the namespace.
 	ConcurrentIDBloomFilter(shard uint32, start time.Time) (*ManagedConcurrentBloomFilter, error)
 }
 
@@ -328,6 +350,44 @@ type IndexFileSetReader interface {
 	Validate() error
 }
 
+// ObjectStore is a store of objects, such as an S3 or GCS bucket, that the
+// files of flushed data filesets are persisted to in addition to local disk.
+// Filesets are uploaded once written and deleted from the store when cleanup
+// deletes them from local disk. Local disk acts as a cache of the store, the
+// filesets of blocks older than the local retention are evicted from local
+// disk once uploaded and downloaded again when read.
+type ObjectStore interface {
+	// Put writes the object with the given key and size, replacing any
+	// existing object.
+	Put(key string, r io.Reader, size int64) error
+
+	// Get returns a reader for the object with the given key or ErrObjectNotFound
+	// if it does not exist, the reader must be closed when done with.
+	Get(key string) (io.ReadCloser, error)
+
+	// Exists returns whether an object with the given key exists.
+	Exists(key string) (bool, error)
+
+	// Delete removes the object with the given key if it exists.
+	Delete(key string) error
+}
+
+// DataKeyWrapper wraps and unwraps the per volume data keys that fileset
+// files are encrypted with using a key encryption key, typically held by a
+// key management system, so that only wrapped data keys are stored on disk.
+type DataKeyWrapper interface {
+	// KeyID returns the ID of the key encryption key that new data keys
+	// are wrapped with, it is recorded alongside each wrapped data key.
+	KeyID() string
+
+	// WrapKey wraps a data key with the current key encryption key.
+	WrapKey(dataKey []byte) ([]byte, error)
+
+	// UnwrapKey unwraps a data key that was wrapped with the key
+	// encryption key with the given ID.
+	UnwrapKey(keyID string, wrappedKey []byte) ([]byte, error)
+}
+
 // Options represents the options for filesystem persistence.
 type Options interface {
 	// Validate will validate the options and return an error if not valid.
@@ -363,6 +423,21 @@ type Options interface {
 	// FilePathPrefix returns the file path prefix for sharded TSDB files.
 	FilePathPrefix() string
 
+	// SetNamespaceFilePathPrefixes sets the file path prefixes that the
+	// filesets of namespaces are routed to, keyed by namespace ID, namespaces
+	// without one use the file path prefix.
+	SetNamespaceFilePathPrefixes(value map[string]string) Options
+
+	// NamespaceFilePathPrefixes returns the file path prefixes that the
+	// filesets of namespaces are routed to, keyed by namespace ID, namespaces
+	// without one use the file path prefix.
+	NamespaceFilePathPrefixes() map[string]string
+
+	// NamespaceFilePathPrefix returns the file path prefix of the filesets of
+	// a namespace, commit logs and snapshot metadata always use the file path
+	// prefix as they are shared by all namespaces.
+	NamespaceFilePathPrefix(namespace ident.ID) string
+
 	// SetNewFileMode sets the new file mode.
 	SetNewFileMode(value os.FileMode) Options
 
@@ -405,6 +480,118 @@ type Options interface {
 	// as an anonymous region, or as a file.
 	ForceBloomFilterMmapMemory() bool
 
+	// SetShareIndexSummaries sets whether the index summaries lookups are shared
+	// between all the seekers of a namespace, including across volumes of a block
+	// whose summaries are identical.
+	SetShareIndexSummaries(value bool) Options
+
+	// ShareIndexSummaries returns whether the index summaries lookups are shared
+	// between all the seekers of a namespace, including across volumes of a block
+	// whose summaries are identical.
+	ShareIndexSummaries() bool
+
+	// SetSeekerDataFileMmapEnabled sets whether seekers mmap the data file and
+	// serve reads from the mapping rather than issuing a pread per lookup.
+	SetSeekerDataFileMmapEnabled(value bool) Options
+
+	// SeekerDataFileMmapEnabled returns whether seekers mmap the data file and
+	// serve reads from the mapping rather than issuing a pread per lookup.
+	SeekerDataFileMmapEnabled() bool
+
+	// SetSequentialReadAheadEnabled sets whether readers advise the kernel that
+	// filesets are read sequentially in full so that readahead is aggressive.
+	SetSequentialReadAheadEnabled(value bool) Options
+
+	// SequentialReadAheadEnabled returns whether readers advise the kernel that
+	// filesets are read sequentially in full so that readahead is aggressive.
+	SequentialReadAheadEnabled() bool
+
+	// SetDropPagesAfterWriteEnabled sets whether writers sync written filesets
+	// and evict their pages from the page cache so that writes do not evict
+	// pages that are being read.
+	SetDropPagesAfterWriteEnabled(value bool) Options
+
+	// DropPagesAfterWriteEnabled returns whether writers sync written filesets
+	// and evict their pages from the page cache so that writes do not evict
+	// pages that are being read.
+	DropPagesAfterWriteEnabled() bool
+
+	// SetDataCompression sets the compression applied to each data segment
+	// of newly written filesets, readers detect it from the info file.
+	SetDataCompression(value persist.DataCompressionType) Options
+
+	// DataCompression returns the compression applied to each data segment
+	// of newly written filesets, readers detect it from the info file.
+	DataCompression() persist.DataCompressionType
+
+	// SetZstdCompressionLevel sets the zstd level used when data segments
+	// are compressed with zstd.
+	SetZstdCompressionLevel(value int) Options
+
+	// ZstdCompressionLevel returns the zstd level used when data segments
+	// are compressed with zstd.
+	ZstdCompressionLevel() int
+
+	// SetObjectStore sets the object store that flushed filesets are uploaded
+	// to and that seekers download filesets missing from local disk from.
+	SetObjectStore(value ObjectStore) Options
+
+	// ObjectStore returns the object store that flushed filesets are uploaded
+	// to and that seekers download filesets missing from local disk from.
+	ObjectStore() ObjectStore
+
+	// SetObjectStoreLocalRetention sets how long the flushed filesets of a
+	// block are kept on local disk when an object store is set, the filesets
+	// of older blocks are evicted from local disk. Zero never evicts them.
+	SetObjectStoreLocalRetention(value time.Duration) Options
+
+	// ObjectStoreLocalRetention returns how long the flushed filesets of a
+	// block are kept on local disk when an object store is set, the filesets
+	// of older blocks are evicted from local disk. Zero never evicts them.
+	ObjectStoreLocalRetention() time.Duration
+
+	// SetDataKeyWrapper sets the data key wrapper used to encrypt newly written
+	// filesets, if nil filesets are written unencrypted. Encrypted filesets
+	// can only be read when it is set.
+	SetDataKeyWrapper(value DataKeyWrapper) Options
+
+	// DataKeyWrapper returns the data key wrapper used to encrypt newly written
+	// filesets, if nil filesets are written unencrypted. Encrypted filesets
+	// can only be read when it is set.
+	DataKeyWrapper() DataKeyWrapper
+
+	// SetSeekerManagerCloseInterval sets the interval between iterations of the
+	// seeker manager loop that opens and closes seekers.
+	SetSeekerManagerCloseInterval(value time.Duration) Options
+
+	// SeekerManagerCloseInterval returns the interval between iterations of the
+	// seeker manager loop that opens and closes seekers.
+	SeekerManagerCloseInterval() time.Duration
+
+	// SetSeekerManagerMaxOpenedPerIteration sets the max number of seekers opened
+	// per iteration of the seeker manager loop, zero means unlimited.
+	SetSeekerManagerMaxOpenedPerIteration(value int) Options
+
+	// SeekerManagerMaxOpenedPerIteration returns the max number of seekers opened
+	// per iteration of the seeker manager loop, zero means unlimited.
+	SeekerManagerMaxOpenedPerIteration() int
+
+	// SetSeekerManagerMaxClosedPerIteration sets the max number of block starts whose
+	// seekers are closed per iteration of the seeker manager loop, zero means unlimited.
+	SetSeekerManagerMaxClosedPerIteration(value int) Options
+
+	// SeekerManagerMaxClosedPerIteration returns the max number of block starts whose
+	// seekers are closed per iteration of the seeker manager loop, zero means unlimited.
+	SeekerManagerMaxClosedPerIteration() int
+
+	// SetFlushConcurrency sets the number of data filesets that the persist
+	// manager persists concurrently, each with its own writer.
+	SetFlushConcurrency(value int) Options
+
+	// FlushConcurrency returns the number of data filesets that the persist
+	// manager persists concurrently, each with its own writer.
+	FlushConcurrency() int
+
 	// SetWriterBufferSize sets the buffer size for writing TSDB files.
 	SetWriterBufferSize(value int) Options
 
@@ -500,6 +687,16 @@ type BlockRetrieverOptions interface {
 
 	// BlockLeaseManager returns the block leaser.
 	BlockLeaseManager() block.LeaseManager
+
+	// SetIndexEntryCacheSize sets the number of index entries cached per fileset
+	// volume so that repeated fetches of the same series skip the index lookup,
+	// zero disables the cache.
+	SetIndexEntryCacheSize(value int) BlockRetrieverOptions
+
+	// IndexEntryCacheSize returns the number of index entries cached per fileset
+	// volume so that repeated fetches of the same series skip the index lookup,
+	// zero disables the cache.
+	IndexEntryCacheSize() int
 }
 
 // ForEachRemainingFn is the function that is run on each of the remaining
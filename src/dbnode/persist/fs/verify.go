@@ -0,0 +1,244 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/x/ident"
+)
+
+const (
+	// maxVerifyIssues is the maximum number of issues recorded for a single
+	// volume so that a badly corrupted volume does not produce an unbounded
+	// result, the total number of issues found is always counted.
+	maxVerifyIssues = 100
+)
+
+// VerifyIssueType is the type of an issue found when verifying a fileset.
+type VerifyIssueType int
+
+const (
+	// VerifyIssueUnreadable means the fileset could not be opened, e.g. its
+	// info or digest files are corrupt.
+	VerifyIssueUnreadable VerifyIssueType = iota
+	// VerifyIssueDigestMismatch means the contents of a file do not match the
+	// digest recorded for it.
+	VerifyIssueDigestMismatch
+	// VerifyIssueIndexDataMismatch means the index entries do not match the
+	// data file, e.g. an entry's checksum does not match its data.
+	VerifyIssueIndexDataMismatch
+	// VerifyIssueBloomFilter means the bloom filter could not be read or does
+	// not contain an ID present in the index.
+	VerifyIssueBloomFilter
+)
+
+func (t VerifyIssueType) String() string {
+	switch t {
+	case VerifyIssueUnreadable:
+		return "unreadable"
+	case VerifyIssueDigestMismatch:
+		return "digest-mismatch"
+	case VerifyIssueIndexDataMismatch:
+		return "index-data-mismatch"
+	case VerifyIssueBloomFilter:
+		return "bloom-filter"
+	}
+	return "unknown"
+}
+
+// VerifyIssue is an issue found when verifying a fileset.
+type VerifyIssue struct {
+	Type VerifyIssueType
+	// ID is the series the issue relates to, empty if the issue relates
+	// to the volume as a whole.
+	ID      string
+	Message string
+}
+
+// VerifyResult is the result of verifying a fileset volume.
+type VerifyResult struct {
+	Namespace  ident.ID
+	Shard      uint32
+	BlockStart time.Time
+	Volume     int
+	Entries    int
+	// Issues are the issues found, capped at a fixed number per volume.
+	Issues []VerifyIssue
+	// NumIssues is the total number of issues found.
+	NumIssues int
+}
+
+// Healthy returns whether the volume was verified without any issues.
+func (r VerifyResult) Healthy() bool {
+	return r.NumIssues == 0
+}
+
+func (r *VerifyResult) addIssue(t VerifyIssueType, id []byte, err error) {
+	r.NumIssues++
+	if len(r.Issues) >= maxVerifyIssues {
+		return
+	}
+	r.Issues = append(r.Issues, VerifyIssue{
+		Type:    t,
+		ID:      string(id),
+		Message: err.Error(),
+	})
+}
+
+// Verify reads a flushed data fileset volume in full and validates the
+// digests of each of its files, the consistency of its index with its data
+// and the integrity of its bloom filter. Corruption is reported as issues in
+// the result, an error is only returned if the volume could not be verified,
+// e.g. if it does not exist.
+func Verify(
+	opts Options,
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+	volume int,
+) (VerifyResult, error) {
+	return VerifyFileSet(opts, FileSetFileIdentifier{
+		Namespace:   namespace,
+		Shard:       shard,
+		BlockStart:  blockStart,
+		VolumeIndex: volume,
+	}, persist.FileSetFlushType)
+}
+
+// VerifyFileSet is the same as Verify but verifies a data fileset volume of
+// any fileset type, i.e. it can also verify snapshot filesets.
+func VerifyFileSet(
+	opts Options,
+	id FileSetFileIdentifier,
+	fileSetType persist.FileSetType,
+) (VerifyResult, error) {
+	result := VerifyResult{
+		Namespace:  id.Namespace,
+		Shard:      id.Shard,
+		BlockStart: id.BlockStart,
+		Volume:     id.VolumeIndex,
+	}
+
+	dataReader, err := NewReader(nil, opts)
+	if err != nil {
+		return result, err
+	}
+	r := dataReader.(*reader)
+
+	err = r.Open(DataReaderOpenOptions{
+		Identifier:  id,
+		FileSetType: fileSetType,
+	})
+	if err == ErrCheckpointFileNotFound {
+		return result, err
+	}
+	if err != nil {
+		result.addIssue(VerifyIssueUnreadable, nil, err)
+		return result, nil
+	}
+	defer r.Close()
+
+	result.Entries = r.Entries()
+	if err := r.ValidateMetadata(); err != nil {
+		result.addIssue(VerifyIssueDigestMismatch, nil, err)
+	}
+
+	// Index entries must describe the data file contiguously, any gap or
+	// overlap means the index and data files disagree.
+	var expectedOffset int64
+	for _, entry := range r.indexEntriesByOffsetAsc {
+		if entry.Offset != expectedOffset {
+			result.addIssue(VerifyIssueIndexDataMismatch, entry.ID, fmt.Errorf(
+				"entry offset %d does not match expected offset %d",
+				entry.Offset, expectedOffset))
+		}
+		expectedOffset = entry.Offset + entry.Size
+	}
+
+	if verifyData(r, &result) {
+		if err := r.ValidateData(); err != nil {
+			result.addIssue(VerifyIssueDigestMismatch, nil, err)
+		}
+	}
+
+	bloomFilter, err := r.ReadBloomFilter()
+	if err != nil {
+		result.addIssue(VerifyIssueBloomFilter, nil, err)
+		return result, nil
+	}
+	defer bloomFilter.Close()
+
+	for _, entry := range r.indexEntriesByOffsetAsc {
+		if !bloomFilter.Test(entry.ID) {
+			result.addIssue(VerifyIssueBloomFilter, entry.ID,
+				fmt.Errorf("bloom filter does not contain ID"))
+		}
+	}
+
+	return result, nil
+}
+
+// verifyData reads the data of each index entry and compares it to the
+// entry's checksum, returning whether the whole data file was read.
+func verifyData(r *reader, result *VerifyResult) bool {
+	for {
+		id, tags, data, checksum, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			result.addIssue(VerifyIssueIndexDataMismatch, nil,
+				fmt.Errorf("unable to read data: %v", err))
+			return false
+		}
+
+		data.IncRef()
+		if actual := digest.Checksum(data.Bytes()); actual != checksum {
+			result.addIssue(VerifyIssueIndexDataMismatch, id.Bytes(), fmt.Errorf(
+				"data checksum %d does not match index checksum %d",
+				actual, checksum))
+		}
+		data.DecRef()
+		data.Finalize()
+		tags.Close()
+		id.Finalize()
+	}
+
+	// Any data past the last index entry is not referenced by the index, it
+	// also needs to be consumed for the data digest to be validated.
+	trailing, err := io.Copy(ioutil.Discard, r.dataStreamReader)
+	if err != nil {
+		result.addIssue(VerifyIssueIndexDataMismatch, nil,
+			fmt.Errorf("unable to read data: %v", err))
+		return false
+	}
+	if trailing > 0 {
+		result.addIssue(VerifyIssueIndexDataMismatch, nil, fmt.Errorf(
+			"data file has %d bytes not referenced by the index", trailing))
+	}
+	return true
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testVerifyEntries = []testEntry{
+	{"foo", nil, []byte{1, 2, 3}},
+	{"bar", nil, []byte{4, 5, 6}},
+	{"baz", map[string]string{"qux": "qaz"}, []byte{7, 8, 9}},
+}
+
+func corruptTestFileSetFile(t *testing.T, filePathPrefix, suffix string) {
+	shardDir := ShardDataDirPath(filePathPrefix, testNs1ID, 0)
+	path := dataFilesetPathFromTimeAndIndex(shardDir, testWriterStart, 0,
+		suffix, false)
+	contents, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	contents[0] ^= 0xff
+	require.NoError(t, ioutil.WriteFile(path, contents, 0644))
+}
+
+func TestVerifyHealthy(t *testing.T) {
+	for _, opts := range []Options{
+		testDefaultOpts,
+		testDefaultOpts.SetDataCompression(persist.ZstdDataCompression),
+		testDefaultOpts.SetDataKeyWrapper(newTestDataKeyWrapper(t)),
+	} {
+		dir := createTempDir(t)
+		filePathPrefix := filepath.Join(dir, "")
+		opts = opts.
+			SetFilePathPrefix(filePathPrefix).
+			SetWriterBufferSize(testWriterBufferSize)
+
+		w, err := NewWriter(opts)
+		require.NoError(t, err)
+		writeTestData(t, w, 0, testWriterStart, testVerifyEntries,
+			persist.FileSetFlushType)
+
+		result, err := Verify(opts, testNs1ID, 0, testWriterStart, 0)
+		require.NoError(t, err)
+		require.True(t, result.Healthy(), "issues: %v", result.Issues)
+		require.Equal(t, len(testVerifyEntries), result.Entries)
+
+		os.RemoveAll(dir)
+	}
+}
+
+func TestVerifyCorruptData(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	w := newTestWriter(t, filePathPrefix)
+	writeTestData(t, w, 0, testWriterStart, testVerifyEntries,
+		persist.FileSetFlushType)
+	corruptTestFileSetFile(t, filePathPrefix, dataFileSuffix)
+
+	opts := testDefaultOpts.SetFilePathPrefix(filePathPrefix)
+	result, err := Verify(opts, testNs1ID, 0, testWriterStart, 0)
+	require.NoError(t, err)
+	require.False(t, result.Healthy())
+	require.Equal(t, 2, result.NumIssues)
+
+	// The first series written is the first in the data file.
+	require.Equal(t, VerifyIssueIndexDataMismatch, result.Issues[0].Type)
+	require.Equal(t, "foo", result.Issues[0].ID)
+	require.Equal(t, VerifyIssueDigestMismatch, result.Issues[1].Type)
+	require.Equal(t, "", result.Issues[1].ID)
+}
+
+func TestVerifyCorruptBloomFilter(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	w := newTestWriter(t, filePathPrefix)
+	writeTestData(t, w, 0, testWriterStart, testVerifyEntries,
+		persist.FileSetFlushType)
+	corruptTestFileSetFile(t, filePathPrefix, bloomFilterFileSuffix)
+
+	opts := testDefaultOpts.SetFilePathPrefix(filePathPrefix)
+	result, err := Verify(opts, testNs1ID, 0, testWriterStart, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.NumIssues)
+	require.Equal(t, VerifyIssueBloomFilter, result.Issues[0].Type)
+}
+
+func TestVerifyCorruptInfo(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	w := newTestWriter(t, filePathPrefix)
+	writeTestData(t, w, 0, testWriterStart, testVerifyEntries,
+		persist.FileSetFlushType)
+	corruptTestFileSetFile(t, filePathPrefix, infoFileSuffix)
+
+	opts := testDefaultOpts.SetFilePathPrefix(filePathPrefix)
+	result, err := Verify(opts, testNs1ID, 0, testWriterStart, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.NumIssues)
+	require.Equal(t, VerifyIssueUnreadable, result.Issues[0].Type)
+}
+
+func TestVerifyMissingFileSet(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	opts := testDefaultOpts.SetFilePathPrefix(filePathPrefix)
+	_, err := Verify(opts, testNs1ID, 0, testWriterStart, 0)
+	require.Equal(t, ErrCheckpointFileNotFound, err)
+}
@@ -24,6 +24,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"sort"
@@ -39,6 +40,7 @@ import (
 	"github.com/m3db/m3/src/x/serialize"
 	xtime "github.com/m3db/m3/src/x/time"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/pborman/uuid"
 )
 
@@ -53,6 +55,7 @@ var (
 )
 
 type writer struct {
+	opts             Options
 	blockSize        time.Duration
 	filePathPrefix   string
 	newFileMode      os.FileMode
@@ -60,6 +63,7 @@ type writer struct {
 
 	summariesPercent                float64
 	bloomFilterFalsePositivePercent float64
+	currBloomFilterFalsePositive    float64
 
 	infoFdWithDigest           digest.FdWithDigestWriter
 	indexFdWithDigest          digest.FdWithDigestWriter
@@ -70,6 +74,9 @@ type writer struct {
 	checkpointFilePath         string
 	indexEntries               indexEntries
 
+	namespace    ident.ID
+	shard        uint32
+	fileSetType  persist.FileSetType
 	start        time.Time
 	volumeIndex  int
 	snapshotTime time.Time
@@ -82,6 +89,36 @@ type writer struct {
 	singleCheckedBytes []checked.Bytes
 	tagEncoderPool     serialize.TagEncoderPool
 	err                error
+
+	// If data compression is enabled each segment is concatenated into the
+	// uncompressed buffer and compressed into the compressed buffer before
+	// being written, the index entry size is the size of the compressed
+	// segment while the checksum remains that of the uncompressed segment.
+	dataCompression persist.DataCompressionType
+	zstdEncoder     *zstd.Encoder
+	uncompressedBuf []byte
+	compressedBuf   []byte
+
+	// If set the written files are synced and their pages evicted from the
+	// page cache on close so that writes do not evict pages being read.
+	dropPagesAfterWrite bool
+
+	// If set flushed filesets are uploaded to the object store once they
+	// have been completely written to local disk.
+	objectStore ObjectStore
+
+	// If the data key wrapper is set the index, summaries and data files are
+	// written through the encrypting writers with a new data key for each
+	// volume, offsets in the index and summaries remain those of the plaintext.
+	dataKeyWrapper     DataKeyWrapper
+	encryptionKeyID    []byte
+	wrappedDataKey     []byte
+	indexEncrypter     *encryptingWriter
+	summariesEncrypter *encryptingWriter
+	dataEncrypter      *encryptingWriter
+	indexWriter        io.Writer
+	summariesWriter    io.Writer
+	dataWriter         io.Writer
 }
 
 type indexEntry struct {
@@ -121,8 +158,17 @@ func NewWriter(opts Options) (DataFileSetWriter, error) {
 	if err := opts.Validate(); err != nil {
 		return nil, err
 	}
+	var zstdEncoder *zstd.Encoder
+	if opts.DataCompression() == persist.ZstdDataCompression {
+		encoder, err := newZstdEncoder(opts.ZstdCompressionLevel())
+		if err != nil {
+			return nil, err
+		}
+		zstdEncoder = encoder
+	}
 	bufferSize := opts.WriterBufferSize()
-	return &writer{
+	w := &writer{
+		opts:                            opts,
 		filePathPrefix:                  opts.FilePathPrefix(),
 		newFileMode:                     opts.NewFileMode(),
 		newDirectoryMode:                opts.NewDirectoryMode(),
@@ -138,7 +184,18 @@ func NewWriter(opts Options) (DataFileSetWriter, error) {
 		digestBuf:                       digest.NewBuffer(),
 		singleCheckedBytes:              make([]checked.Bytes, 1),
 		tagEncoderPool:                  opts.TagEncoderPool(),
-	}, nil
+		dataCompression:                 opts.DataCompression(),
+		zstdEncoder:                     zstdEncoder,
+		objectStore:                     opts.ObjectStore(),
+		dropPagesAfterWrite:             opts.DropPagesAfterWriteEnabled(),
+		dataKeyWrapper:                  opts.DataKeyWrapper(),
+	}
+	if w.dataKeyWrapper != nil {
+		w.indexEncrypter = newEncryptingWriter()
+		w.summariesEncrypter = newEncryptingWriter()
+		w.dataEncrypter = newEncryptingWriter()
+	}
+	return w, nil
 }
 
 // Open initializes the internal state for writing to the given shard,
@@ -154,6 +211,14 @@ func (w *writer) Open(opts DataWriterOpenOptions) error {
 	)
 
 	w.blockSize = opts.BlockSize
+	w.currBloomFilterFalsePositive = opts.BloomFilterFalsePositivePercent
+	if w.currBloomFilterFalsePositive == 0 {
+		w.currBloomFilterFalsePositive = w.bloomFilterFalsePositivePercent
+	}
+	w.filePathPrefix = w.opts.NamespaceFilePathPrefix(namespace)
+	w.namespace = namespace
+	w.shard = shard
+	w.fileSetType = opts.FileSetType
 	w.start = blockStart
 	w.volumeIndex = volumeIndex
 	w.snapshotTime = opts.Snapshot.SnapshotTime
@@ -204,6 +269,18 @@ func (w *writer) Open(opts DataWriterOpenOptions) error {
 		return fmt.Errorf("unable to open reader with fileset type: %s", opts.FileSetType)
 	}
 
+	// Each volume is encrypted with its own data key so that the nonces
+	// derived from the chunk indexes are never reused with the same key.
+	var fileCipher *filesetCipher
+	w.encryptionKeyID = nil
+	w.wrappedDataKey = nil
+	if w.dataKeyWrapper != nil {
+		fileCipher, err = w.newDataKey()
+		if err != nil {
+			return err
+		}
+	}
+
 	var infoFd, indexFd, summariesFd, bloomFilterFd, dataFd, digestFd *os.File
 	err = openFiles(w.openWritable,
 		map[string]**os.File{
@@ -226,14 +303,40 @@ func (w *writer) Open(opts DataWriterOpenOptions) error {
 	w.dataFdWithDigest.Reset(dataFd)
 	w.digestFdWithDigestContents.Reset(digestFd)
 
+	w.indexWriter = w.indexFdWithDigest
+	w.summariesWriter = w.summariesFdWithDigest
+	w.dataWriter = w.dataFdWithDigest
+	if fileCipher != nil {
+		w.indexEncrypter.reset(fileCipher, encryptedIndexFile, w.indexFdWithDigest)
+		w.summariesEncrypter.reset(fileCipher, encryptedSummariesFile, w.summariesFdWithDigest)
+		w.dataEncrypter.reset(fileCipher, encryptedDataFile, w.dataFdWithDigest)
+		w.indexWriter = w.indexEncrypter
+		w.summariesWriter = w.summariesEncrypter
+		w.dataWriter = w.dataEncrypter
+	}
+
 	return nil
 }
 
+func (w *writer) newDataKey() (*filesetCipher, error) {
+	dataKey, fileCipher, err := newDataKey()
+	if err != nil {
+		return nil, err
+	}
+	wrappedDataKey, err := w.dataKeyWrapper.WrapKey(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("could not wrap fileset data key: %v", err)
+	}
+	w.encryptionKeyID = []byte(w.dataKeyWrapper.KeyID())
+	w.wrappedDataKey = wrappedDataKey
+	return fileCipher, nil
+}
+
 func (w *writer) writeData(data []byte) error {
 	if len(data) == 0 {
 		return nil
 	}
-	written, err := w.dataFdWithDigest.Write(data)
+	written, err := w.dataWriter.Write(data)
 	if err != nil {
 		return err
 	}
@@ -293,13 +396,21 @@ func (w *writer) writeAll(
 		size:           uint32(size),
 		checksum:       checksum,
 	}
-	for _, d := range data {
-		if d == nil {
-			continue
-		}
-		if err := w.writeData(d.Bytes()); err != nil {
+	if w.dataCompression == persist.ZstdDataCompression {
+		compressed := w.compressData(data)
+		if err := w.writeData(compressed); err != nil {
 			return err
 		}
+		entry.size = uint32(len(compressed))
+	} else {
+		for _, d := range data {
+			if d == nil {
+				continue
+			}
+			if err := w.writeData(d.Bytes()); err != nil {
+				return err
+			}
+		}
 	}
 
 	w.indexEntries = append(w.indexEntries, entry)
@@ -308,6 +419,18 @@ func (w *writer) writeAll(
 	return nil
 }
 
+func (w *writer) compressData(data []checked.Bytes) []byte {
+	w.uncompressedBuf = w.uncompressedBuf[:0]
+	for _, d := range data {
+		if d == nil {
+			continue
+		}
+		w.uncompressedBuf = append(w.uncompressedBuf, d.Bytes()...)
+	}
+	w.compressedBuf = w.zstdEncoder.EncodeAll(w.uncompressedBuf, w.compressedBuf[:0])
+	return w.compressedBuf
+}
+
 func (w *writer) Close() error {
 	err := w.close()
 	if w.err != nil {
@@ -323,14 +446,48 @@ func (w *writer) Close() error {
 		w.err = err
 		return err
 	}
+	// Snapshots are only needed locally until the next flush so only
+	// filesets that are flushed are uploaded to the object store.
+	if w.objectStore != nil && w.fileSetType == persist.FileSetFlushType {
+		if err := UploadDataFileSet(w.objectStore, w.filePathPrefix,
+			w.namespace, w.shard, w.start, w.volumeIndex); err != nil {
+			w.err = err
+			return err
+		}
+	}
 	return nil
 }
 
+func (w *writer) Abort() error {
+	return closeAll(
+		w.infoFdWithDigest,
+		w.indexFdWithDigest,
+		w.summariesFdWithDigest,
+		w.bloomFilterFdWithDigest,
+		w.dataFdWithDigest,
+		w.digestFdWithDigestContents,
+	)
+}
+
 func (w *writer) close() error {
 	if err := w.writeIndexRelatedFiles(); err != nil {
 		return err
 	}
 
+	if w.wrappedDataKey != nil {
+		// The digests are of the encrypted files so the last chunk of
+		// each must be written before the digests are taken.
+		for _, e := range []*encryptingWriter{
+			w.indexEncrypter,
+			w.summariesEncrypter,
+			w.dataEncrypter,
+		} {
+			if err := e.flush(); err != nil {
+				return err
+			}
+		}
+	}
+
 	if err := w.digestFdWithDigestContents.WriteDigests(
 		w.infoFdWithDigest.Digest().Sum32(),
 		w.indexFdWithDigest.Digest().Sum32(),
@@ -341,6 +498,12 @@ func (w *writer) close() error {
 		return err
 	}
 
+	if w.dropPagesAfterWrite {
+		if err := w.dropWrittenPages(); err != nil {
+			return err
+		}
+	}
+
 	return closeAll(
 		w.infoFdWithDigest,
 		w.indexFdWithDigest,
@@ -351,6 +514,32 @@ func (w *writer) close() error {
 	)
 }
 
+// dropWrittenPages syncs each of the written files and then advises the kernel
+// that their pages are not needed, pages can only be evicted once they have
+// been written back which is why the files are synced first.
+func (w *writer) dropWrittenPages() error {
+	for _, fdWithDigest := range []digest.FdWithDigestWriter{
+		w.infoFdWithDigest,
+		w.indexFdWithDigest,
+		w.summariesFdWithDigest,
+		w.bloomFilterFdWithDigest,
+		w.dataFdWithDigest,
+		w.digestFdWithDigestContents,
+	} {
+		if err := fdWithDigest.Flush(); err != nil {
+			return err
+		}
+		fd := fdWithDigest.Fd()
+		if err := fd.Sync(); err != nil {
+			return err
+		}
+		if err := fadviseDontNeed(fd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (w *writer) writeCheckpointFile() error {
 	fd, err := w.openWritable(w.checkpointFilePath)
 	if err != nil {
@@ -378,7 +567,7 @@ func (w *writer) writeIndexRelatedFiles() error {
 	}
 
 	// Write the index entries and calculate the bloom filter
-	n, p := uint(w.currIdx), w.bloomFilterFalsePositivePercent
+	n, p := uint(w.currIdx), w.currBloomFilterFalsePositive
 	m, k := bloom.EstimateFalsePositiveRate(n, p)
 	bloomFilter := bloom.NewBloomFilter(m, k)
 
@@ -464,7 +653,7 @@ func (w *writer) writeIndexFileContents(
 		}
 
 		data := w.encoder.Bytes()
-		if _, err := w.indexFdWithDigest.Write(data); err != nil {
+		if _, err := w.indexWriter.Write(data); err != nil {
 			return err
 		}
 
@@ -508,7 +697,7 @@ func (w *writer) writeSummariesFileContents(
 		}
 
 		data := w.encoder.Bytes()
-		if _, err := w.summariesFdWithDigest.Write(data); err != nil {
+		if _, err := w.summariesWriter.Write(data); err != nil {
 			return 0, err
 		}
 
@@ -534,13 +723,16 @@ func (w *writer) writeInfoFileContents(
 	}
 
 	info := schema.IndexInfo{
-		BlockStart:   xtime.ToNanoseconds(w.start),
-		VolumeIndex:  w.volumeIndex,
-		SnapshotTime: xtime.ToNanoseconds(w.snapshotTime),
-		SnapshotID:   snapshotBytes,
-		BlockSize:    int64(w.blockSize),
-		Entries:      w.currIdx,
-		MajorVersion: schema.MajorVersion,
+		BlockStart:      xtime.ToNanoseconds(w.start),
+		VolumeIndex:     w.volumeIndex,
+		SnapshotTime:    xtime.ToNanoseconds(w.snapshotTime),
+		SnapshotID:      snapshotBytes,
+		BlockSize:       int64(w.blockSize),
+		Entries:         w.currIdx,
+		MajorVersion:    schema.MajorVersion,
+		DataCompression: w.dataCompression,
+		EncryptionKeyID: w.encryptionKeyID,
+		WrappedDataKey:  w.wrappedDataKey,
 		Summaries: schema.IndexSummariesInfo{
 			Summaries: int64(summaries),
 		},
@@ -31,16 +31,21 @@ const MajorVersion = 1
 
 // IndexInfo stores metadata information about block filesets
 type IndexInfo struct {
-	MajorVersion int64
-	BlockStart   int64
-	BlockSize    int64
-	Entries      int64
-	Summaries    IndexSummariesInfo
-	BloomFilter  IndexBloomFilterInfo
-	SnapshotTime int64
-	FileType     persist.FileSetType
-	SnapshotID   []byte
-	VolumeIndex  int
+	MajorVersion    int64
+	BlockStart      int64
+	BlockSize       int64
+	Entries         int64
+	Summaries       IndexSummariesInfo
+	BloomFilter     IndexBloomFilterInfo
+	SnapshotTime    int64
+	FileType        persist.FileSetType
+	SnapshotID      []byte
+	VolumeIndex     int
+	DataCompression persist.DataCompressionType
+	// EncryptionKeyID and WrappedDataKey are set if the fileset is encrypted,
+	// the data key is wrapped by the key encryption key with the key ID.
+	EncryptionKeyID []byte
+	WrappedDataKey  []byte
 }
 
 // IndexSummariesInfo stores metadata about the summaries
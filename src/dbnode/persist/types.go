@@ -39,10 +39,16 @@ type DataFn func(id ident.ID, tags ident.Tags, segment ts.Segment, checksum uint
 // blocks for a (shard, blockStart) combination.
 type DataCloser func() error
 
+// DataAborter is a function that releases the resources held to persist the
+// data blocks for a (shard, blockStart) combination without completing the
+// fileset, used when persisting fails part way through.
+type DataAborter func() error
+
 // PreparedDataPersist is an object that wraps holds a persist function and a closer.
 type PreparedDataPersist struct {
 	Persist DataFn
 	Close   DataCloser
+	Abort   DataAborter
 }
 
 // CommitLogFiles represents a slice of commitlog files.
@@ -153,6 +159,8 @@ type IndexPrepareOptions struct {
 	BlockStart        time.Time
 	FileSetType       FileSetType
 	Shards            map[uint32]struct{}
+	// Snapshot options are applicable to snapshots.
+	Snapshot DataPrepareSnapshotOptions
 }
 
 // DataPrepareSnapshotOptions is the options struct for the Prepare method that contains
@@ -202,3 +210,69 @@ const (
 	// FileSetIndexContentType indicates that the fileset files contain time series index metadata
 	FileSetIndexContentType
 )
+
+// DataCompressionType is an enum that indicates how the data segments
+// of a fileset are compressed on disk.
+type DataCompressionType int
+
+const (
+	// NoDataCompression indicates that data segments are written as is.
+	NoDataCompression DataCompressionType = iota
+	// ZstdDataCompression indicates that each data segment is compressed
+	// with zstd.
+	ZstdDataCompression
+
+	// DefaultDataCompression is the default data compression type.
+	DefaultDataCompression = NoDataCompression
+)
+
+// ValidDataCompressionTypes returns the valid data compression types.
+func ValidDataCompressionTypes() []DataCompressionType {
+	return []DataCompressionType{NoDataCompression, ZstdDataCompression}
+}
+
+func (t DataCompressionType) String() string {
+	switch t {
+	case NoDataCompression:
+		return "none"
+	case ZstdDataCompression:
+		return "zstd"
+	}
+	return fmt.Sprintf("unknown: %d", t)
+}
+
+// ValidateDataCompressionType validates a data compression type.
+func ValidateDataCompressionType(v DataCompressionType) error {
+	for _, valid := range ValidDataCompressionTypes() {
+		if valid == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid data compression type '%d' valid types are: %v",
+		int(v), ValidDataCompressionTypes())
+}
+
+// ParseDataCompressionType parses a DataCompressionType from a string.
+func ParseDataCompressionType(str string) (DataCompressionType, error) {
+	for _, valid := range ValidDataCompressionTypes() {
+		if str == valid.String() {
+			return valid, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid data compression type '%s' valid types are: %v",
+		str, ValidDataCompressionTypes())
+}
+
+// UnmarshalYAML unmarshals a DataCompressionType into a valid type from string.
+func (t *DataCompressionType) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	r, err := ParseDataCompressionType(str)
+	if err != nil {
+		return err
+	}
+	*t = r
+	return nil
+}
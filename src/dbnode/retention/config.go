@@ -33,6 +33,7 @@ type Configuration struct {
 	BufferPast                            time.Duration  `yaml:"bufferPast" validate:"nonzero"`
 	BlockDataExpiry                       *bool          `yaml:"blockDataExpiry"`
 	BlockDataExpiryAfterNotAccessedPeriod *time.Duration `yaml:"blockDataExpiryAfterNotAccessedPeriod"`
+	ExpiredFileSetGracePeriod             *time.Duration `yaml:"expiredFileSetGracePeriod"`
 }
 
 // Options returns `Options` corresponding to the provided struct values
@@ -49,5 +50,8 @@ func (c *Configuration) Options() Options {
 	if v := c.BlockDataExpiryAfterNotAccessedPeriod; v != nil {
 		opts = opts.SetBlockDataExpiryAfterNotAccessedPeriod(*v)
 	}
+	if v := c.ExpiredFileSetGracePeriod; v != nil {
+		opts = opts.SetExpiredFileSetGracePeriod(*v)
+	}
 	return opts
 }
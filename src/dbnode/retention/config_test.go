@@ -35,6 +35,7 @@ func TestConfigurationAssignment(t *testing.T) {
 		bufferPast                            = 4 * time.Hour
 		blockDataExpiry                       = true
 		blockDataExpiryAfterNotAccessedPeriod = 6 * time.Hour
+		expiredFileSetGracePeriod             = 7 * time.Hour
 		config                                = &Configuration{
 			RetentionPeriod:                       retentionPeriod,
 			BlockSize:                             blockSize,
@@ -42,6 +43,7 @@ func TestConfigurationAssignment(t *testing.T) {
 			BufferPast:                            bufferPast,
 			BlockDataExpiry:                       &blockDataExpiry,
 			BlockDataExpiryAfterNotAccessedPeriod: &blockDataExpiryAfterNotAccessedPeriod,
+			ExpiredFileSetGracePeriod:             &expiredFileSetGracePeriod,
 		}
 	)
 
@@ -52,4 +54,5 @@ func TestConfigurationAssignment(t *testing.T) {
 	require.Equal(t, bufferPast, opts.BufferPast())
 	require.Equal(t, blockDataExpiry, opts.BlockDataExpiry())
 	require.Equal(t, blockDataExpiryAfterNotAccessedPeriod, opts.BlockDataExpiryAfterNotAccessedPeriod())
+	require.Equal(t, expiredFileSetGracePeriod, opts.ExpiredFileSetGracePeriod())
 }
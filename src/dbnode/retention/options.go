@@ -49,6 +49,10 @@ const (
 
 	// defaultDataExpiryAfterNotAccessedPeriod is the default data expiry after not accessed period
 	defaultDataExpiryAfterNotAccessedPeriod = 5 * time.Minute
+
+	// defaultExpiredFileSetGracePeriod is the default period that expired
+	// filesets are kept on disk, by default they are deleted immediately
+	defaultExpiredFileSetGracePeriod = time.Duration(0)
 )
 
 var (
@@ -58,6 +62,7 @@ var (
 	errBufferFutureTooLarge    = errors.New("buffer future must be smaller than block size")
 	errBufferPastTooLarge      = errors.New("buffer past must be smaller than block size")
 	errRetentionPeriodTooSmall = errors.New("retention period must not be smaller than block size")
+	errGracePeriodNonNegative  = errors.New("expired fileset grace period must be non-negative")
 )
 
 type options struct {
@@ -67,6 +72,7 @@ type options struct {
 	bufferFuture                     time.Duration
 	bufferPast                       time.Duration
 	dataExpiryAfterNotAccessedPeriod time.Duration
+	expiredFileSetGracePeriod        time.Duration
 	dataExpiry                       bool
 }
 
@@ -80,6 +86,7 @@ func NewOptions() Options {
 		bufferPast:                       defaultBufferPast,
 		dataExpiry:                       defaultDataExpiry,
 		dataExpiryAfterNotAccessedPeriod: defaultDataExpiryAfterNotAccessedPeriod,
+		expiredFileSetGracePeriod:        defaultExpiredFileSetGracePeriod,
 	}
 }
 
@@ -102,6 +109,9 @@ func (o *options) Validate() error {
 	if o.retentionPeriod < o.blockSize {
 		return errRetentionPeriodTooSmall
 	}
+	if o.expiredFileSetGracePeriod < 0 {
+		return errGracePeriodNonNegative
+	}
 	return nil
 }
 
@@ -112,7 +122,8 @@ func (o *options) Equal(value Options) bool {
 		o.bufferFuture == value.BufferFuture() &&
 		o.bufferPast == value.BufferPast() &&
 		o.dataExpiry == value.BlockDataExpiry() &&
-		o.dataExpiryAfterNotAccessedPeriod == value.BlockDataExpiryAfterNotAccessedPeriod()
+		o.dataExpiryAfterNotAccessedPeriod == value.BlockDataExpiryAfterNotAccessedPeriod() &&
+		o.expiredFileSetGracePeriod == value.ExpiredFileSetGracePeriod()
 }
 
 func (o *options) SetRetentionPeriod(value time.Duration) Options {
@@ -184,3 +195,13 @@ func (o *options) SetBlockDataExpiryAfterNotAccessedPeriod(value time.Duration)
 func (o *options) BlockDataExpiryAfterNotAccessedPeriod() time.Duration {
 	return o.dataExpiryAfterNotAccessedPeriod
 }
+
+func (o *options) SetExpiredFileSetGracePeriod(value time.Duration) Options {
+	opts := *o
+	opts.expiredFileSetGracePeriod = value
+	return &opts
+}
+
+func (o *options) ExpiredFileSetGracePeriod() time.Duration {
+	return o.expiredFileSetGracePeriod
+}
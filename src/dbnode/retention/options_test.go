@@ -42,3 +42,19 @@ func TestEqualsFalse(t *testing.T) {
 	require.False(t, opts.Equal(otherOpts))
 	require.False(t, otherOpts.Equal(opts))
 }
+
+func TestEqualsFalseGracePeriod(t *testing.T) {
+	opts := NewOptions()
+	otherOpts := NewOptions().SetExpiredFileSetGracePeriod(time.Hour)
+	require.False(t, opts.Equal(otherOpts))
+	require.False(t, otherOpts.Equal(opts))
+}
+
+func TestValidateGracePeriodNegative(t *testing.T) {
+	opts := NewOptions()
+	require.NoError(t, opts.Validate())
+	require.Equal(t, time.Duration(0), opts.ExpiredFileSetGracePeriod())
+
+	opts = opts.SetExpiredFileSetGracePeriod(-time.Hour)
+	require.Equal(t, errGracePeriodNonNegative, opts.Validate())
+}
@@ -32,6 +32,13 @@ func FlushTimeStartForRetentionPeriod(retentionPeriod time.Duration, blockSize t
 	return t.Add(-retentionPeriod).Truncate(blockSize)
 }
 
+// CleanupTimeStart is the earliest block start whose filesets are kept on
+// disk, which trails the earliest flushable time by the expired fileset grace period
+func CleanupTimeStart(opts Options, t time.Time) time.Time {
+	return FlushTimeStartForRetentionPeriod(
+		opts.RetentionPeriod()+opts.ExpiredFileSetGracePeriod(), opts.BlockSize(), t)
+}
+
 // FlushTimeEnd is the latest flushable time
 func FlushTimeEnd(opts Options, t time.Time) time.Time {
 	return FlushTimeEndForBlockSize(opts.BlockSize(),
@@ -77,4 +77,12 @@ type Options interface {
 	// BlockDataExpiryAfterNotAccessedPeriod returns the period that blocks data should
 	// be expired after not being accessed for a given duration
 	BlockDataExpiryAfterNotAccessedPeriod() time.Duration
+
+	// SetExpiredFileSetGracePeriod sets the period that filesets are kept on
+	// disk after falling out of retention before they are deleted by cleanup
+	SetExpiredFileSetGracePeriod(value time.Duration) Options
+
+	// ExpiredFileSetGracePeriod returns the period that filesets are kept on
+	// disk after falling out of retention before they are deleted by cleanup
+	ExpiredFileSetGracePeriod() time.Duration
 }
@@ -0,0 +1,79 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package runtime
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	errColdFlushIntervalIsNegative = errors.New(
+		"cold flush interval cannot be negative")
+	errColdFlushConcurrencyIsNegative = errors.New(
+		"cold flush concurrency cannot be negative")
+	errColdFlushColdWritesThresholdIsNegative = errors.New(
+		"cold flush cold writes threshold cannot be negative")
+)
+
+// ColdFlushOptions schedule the cold flushes of namespaces, a zero value for
+// any of the options means that it is not set.
+type ColdFlushOptions struct {
+	// Interval is the min time between the cold flushes of a namespace, when
+	// not set a namespace is cold flushed on every flush.
+	Interval time.Duration
+	// Concurrency is the number of shards of a namespace that are cold
+	// flushed concurrently, when not set shards are cold flushed one at a
+	// time.
+	Concurrency int
+	// ColdWritesThreshold is the number of cold writes pending a cold flush
+	// in a namespace at which the namespace is cold flushed before its
+	// interval elapses, when not set only the interval applies.
+	ColdWritesThreshold int64
+}
+
+// Validate validates the cold flush options.
+func (o ColdFlushOptions) Validate() error {
+	if o.Interval < 0 {
+		return errColdFlushIntervalIsNegative
+	}
+	if o.Concurrency < 0 {
+		return errColdFlushConcurrencyIsNegative
+	}
+	if o.ColdWritesThreshold < 0 {
+		return errColdFlushColdWritesThresholdIsNegative
+	}
+	return nil
+}
+
+// Override returns the options with each option set by the overrides
+// replaced by the override.
+func (o ColdFlushOptions) Override(overrides ColdFlushOptions) ColdFlushOptions {
+	if overrides.Interval > 0 {
+		o.Interval = overrides.Interval
+	}
+	if overrides.Concurrency > 0 {
+		o.Concurrency = overrides.Concurrency
+	}
+	if overrides.ColdWritesThreshold > 0 {
+		o.ColdWritesThreshold = overrides.ColdWritesThreshold
+	}
+	return o
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errCommitLogFsyncStrategyUnspecified = errors.New("commit log fsync strategy unspecified")
+	errCommitLogFsyncBytesMustBePositive = errors.New(
+		"commit log fsync bytes must be positive")
+	errCommitLogFsyncIntervalMustBePositive = errors.New(
+		"commit log fsync interval must be positive")
+)
+
+// CommitLogFsyncStrategy is the strategy for fsyncing the commit log in
+// addition to the fsyncs the commit log write strategy requires.
+type CommitLogFsyncStrategy uint
+
+const (
+	// CommitLogFsyncDefault only fsyncs the commit log when its write
+	// strategy requires it.
+	CommitLogFsyncDefault CommitLogFsyncStrategy = iota
+	// CommitLogFsyncEveryWrite fsyncs the commit log after every write or
+	// write batch.
+	CommitLogFsyncEveryWrite
+	// CommitLogFsyncEveryBytes fsyncs the commit log once a number of bytes
+	// have been written since it was last fsync'd.
+	CommitLogFsyncEveryBytes
+	// CommitLogFsyncEveryInterval fsyncs the commit log at most once per
+	// interval while it is being written to.
+	CommitLogFsyncEveryInterval
+)
+
+// ValidCommitLogFsyncStrategies returns the valid commit log fsync strategies.
+func ValidCommitLogFsyncStrategies() []CommitLogFsyncStrategy {
+	return []CommitLogFsyncStrategy{
+		CommitLogFsyncDefault,
+		CommitLogFsyncEveryWrite,
+		CommitLogFsyncEveryBytes,
+		CommitLogFsyncEveryInterval,
+	}
+}
+
+func (s CommitLogFsyncStrategy) String() string {
+	switch s {
+	case CommitLogFsyncDefault:
+		return "default"
+	case CommitLogFsyncEveryWrite:
+		return "every_write"
+	case CommitLogFsyncEveryBytes:
+		return "every_bytes"
+	case CommitLogFsyncEveryInterval:
+		return "every_interval"
+	}
+	return "unknown"
+}
+
+// ParseCommitLogFsyncStrategy parses a CommitLogFsyncStrategy from a string.
+func ParseCommitLogFsyncStrategy(str string) (CommitLogFsyncStrategy, error) {
+	var r CommitLogFsyncStrategy
+	if str == "" {
+		return r, errCommitLogFsyncStrategyUnspecified
+	}
+	for _, valid := range ValidCommitLogFsyncStrategies() {
+		if str == valid.String() {
+			r = valid
+			return r, nil
+		}
+	}
+	return r, fmt.Errorf("invalid CommitLogFsyncStrategy '%s' valid types are: %v",
+		str, ValidCommitLogFsyncStrategies())
+}
+
+// UnmarshalYAML unmarshals a CommitLogFsyncStrategy into a valid type from string.
+func (s *CommitLogFsyncStrategy) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	r, err := ParseCommitLogFsyncStrategy(str)
+	if err != nil {
+		return err
+	}
+	*s = r
+	return nil
+}
+
+// CommitLogFsyncPolicy is the policy for fsyncing the commit log.
+type CommitLogFsyncPolicy struct {
+	// Strategy is the fsync strategy.
+	Strategy CommitLogFsyncStrategy
+	// Bytes is the number of bytes written between fsyncs with the
+	// every bytes strategy.
+	Bytes int64
+	// Interval is the min interval between fsyncs with the every interval
+	// strategy.
+	Interval time.Duration
+}
+
+// Validate validates the commit log fsync policy.
+func (p CommitLogFsyncPolicy) Validate() error {
+	switch p.Strategy {
+	case CommitLogFsyncDefault, CommitLogFsyncEveryWrite:
+		return nil
+	case CommitLogFsyncEveryBytes:
+		if p.Bytes <= 0 {
+			return errCommitLogFsyncBytesMustBePositive
+		}
+		return nil
+	case CommitLogFsyncEveryInterval:
+		if p.Interval <= 0 {
+			return errCommitLogFsyncIntervalMustBePositive
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid CommitLogFsyncStrategy '%d' valid types are: %v",
+		uint(p.Strategy), ValidCommitLogFsyncStrategies())
+}
+
+func (p CommitLogFsyncPolicy) String() string {
+	switch p.Strategy {
+	case CommitLogFsyncEveryBytes:
+		return fmt.Sprintf("%s:%d", p.Strategy.String(), p.Bytes)
+	case CommitLogFsyncEveryInterval:
+		return fmt.Sprintf("%s:%s", p.Strategy.String(), p.Interval.String())
+	}
+	return p.Strategy.String()
+}
+
+// ParseCommitLogFsyncPolicy parses a CommitLogFsyncPolicy from a string of
+// the form "<strategy>[:<bytes or interval>]", e.g. "every_bytes:1048576" or
+// "every_interval:100ms".
+func ParseCommitLogFsyncPolicy(str string) (CommitLogFsyncPolicy, error) {
+	var policy CommitLogFsyncPolicy
+	parts := strings.SplitN(str, ":", 2)
+	strategy, err := ParseCommitLogFsyncStrategy(parts[0])
+	if err != nil {
+		return policy, err
+	}
+	policy.Strategy = strategy
+
+	switch strategy {
+	case CommitLogFsyncEveryBytes:
+		if len(parts) != 2 {
+			return policy, errCommitLogFsyncBytesMustBePositive
+		}
+		policy.Bytes, err = strconv.ParseInt(parts[1], 10, 64)
+	case CommitLogFsyncEveryInterval:
+		if len(parts) != 2 {
+			return policy, errCommitLogFsyncIntervalMustBePositive
+		}
+		policy.Interval, err = time.ParseDuration(parts[1])
+	default:
+		if len(parts) != 1 {
+			return policy, fmt.Errorf(
+				"commit log fsync strategy '%s' does not take an argument", strategy)
+		}
+	}
+	if err != nil {
+		return policy, err
+	}
+	return policy, policy.Validate()
+}
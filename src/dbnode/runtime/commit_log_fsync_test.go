@@ -0,0 +1,87 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestParseCommitLogFsyncPolicy(t *testing.T) {
+	tests := []struct {
+		str      string
+		expected CommitLogFsyncPolicy
+	}{
+		{
+			str:      "default",
+			expected: CommitLogFsyncPolicy{Strategy: CommitLogFsyncDefault},
+		},
+		{
+			str:      "every_write",
+			expected: CommitLogFsyncPolicy{Strategy: CommitLogFsyncEveryWrite},
+		},
+		{
+			str:      "every_bytes:1048576",
+			expected: CommitLogFsyncPolicy{Strategy: CommitLogFsyncEveryBytes, Bytes: 1 << 20},
+		},
+		{
+			str:      "every_interval:100ms",
+			expected: CommitLogFsyncPolicy{Strategy: CommitLogFsyncEveryInterval, Interval: 100 * time.Millisecond},
+		},
+	}
+	for _, test := range tests {
+		policy, err := ParseCommitLogFsyncPolicy(test.str)
+		require.NoError(t, err, test.str)
+		assert.Equal(t, test.expected, policy)
+		assert.Equal(t, test.str, policy.String())
+	}
+}
+
+func TestParseCommitLogFsyncPolicyInvalid(t *testing.T) {
+	for _, str := range []string{
+		"",
+		"unknown",
+		"every_write:10",
+		"every_bytes",
+		"every_bytes:0",
+		"every_bytes:abc",
+		"every_interval",
+		"every_interval:abc",
+		"every_interval:-1s",
+	} {
+		_, err := ParseCommitLogFsyncPolicy(str)
+		assert.Error(t, err, str)
+	}
+}
+
+func TestCommitLogFsyncStrategyUnmarshalYAML(t *testing.T) {
+	for _, valid := range ValidCommitLogFsyncStrategies() {
+		var strategy CommitLogFsyncStrategy
+		require.NoError(t, yaml.Unmarshal([]byte(valid.String()), &strategy))
+		assert.Equal(t, valid, strategy)
+	}
+
+	var strategy CommitLogFsyncStrategy
+	require.Error(t, yaml.Unmarshal([]byte("unknown"), &strategy))
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package runtime
+
+import "errors"
+
+var (
+	errIndexMaxConcurrentBackgroundCompactionsIsNegative = errors.New(
+		"index max concurrent background compactions cannot be negative")
+)
+
+// IndexCompactionOptions tune the background compactions of the index
+// segments of namespaces, a zero value for any of the options means that it
+// is not set and the index options apply.
+type IndexCompactionOptions struct {
+	// MaxConcurrentBackgroundCompactions is the max number of index blocks of
+	// a namespace that are background compacted concurrently, fewer
+	// concurrent compactions use less CPU for indexing at the cost of more
+	// segments to query until the compactions catch up.
+	MaxConcurrentBackgroundCompactions int
+}
+
+// Validate validates the index compaction options.
+func (o IndexCompactionOptions) Validate() error {
+	if o.MaxConcurrentBackgroundCompactions < 0 {
+		return errIndexMaxConcurrentBackgroundCompactionsIsNegative
+	}
+	return nil
+}
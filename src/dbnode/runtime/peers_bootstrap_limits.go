@@ -0,0 +1,116 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	peersBootstrapBytesPerSecondPerPeerKey     = "bytes_per_second_per_peer"
+	peersBootstrapMaxConcurrentShardsKey       = "max_concurrent_shards"
+	peersBootstrapMaxConcurrentBlockFetchesKey = "max_concurrent_block_fetches"
+)
+
+var (
+	errPeersBootstrapBytesPerSecondPerPeerIsNegative = errors.New(
+		"peers bootstrap bytes per second per peer cannot be negative")
+	errPeersBootstrapMaxConcurrentShardsIsNegative = errors.New(
+		"peers bootstrap max concurrent shards cannot be negative")
+	errPeersBootstrapMaxConcurrentBlockFetchesIsNegative = errors.New(
+		"peers bootstrap max concurrent block fetches cannot be negative")
+)
+
+// PeersBootstrapLimits throttle the streaming of blocks from peers when
+// bootstrapping so that it does not saturate the network or degrade the
+// reads of the peers streamed from, a zero value for any of the limits
+// means that it is not limited.
+type PeersBootstrapLimits struct {
+	// BytesPerSecondPerPeer is the max throughput of the blocks streamed
+	// from each peer.
+	BytesPerSecondPerPeer int64
+	// MaxConcurrentShards is the max number of shards bootstrapped from
+	// peers concurrently.
+	MaxConcurrentShards int
+	// MaxConcurrentBlockFetches is the max number of batches of blocks
+	// fetched from peers concurrently.
+	MaxConcurrentBlockFetches int
+}
+
+// Validate validates the peers bootstrap limits.
+func (l PeersBootstrapLimits) Validate() error {
+	if l.BytesPerSecondPerPeer < 0 {
+		return errPeersBootstrapBytesPerSecondPerPeerIsNegative
+	}
+	if l.MaxConcurrentShards < 0 {
+		return errPeersBootstrapMaxConcurrentShardsIsNegative
+	}
+	if l.MaxConcurrentBlockFetches < 0 {
+		return errPeersBootstrapMaxConcurrentBlockFetchesIsNegative
+	}
+	return nil
+}
+
+// ParsePeersBootstrapLimits parses PeersBootstrapLimits from a string of
+// comma separated limits, e.g.
+// "bytes_per_second_per_peer:52428800,max_concurrent_shards:4", the limits
+// not in the string are not limited.
+func ParsePeersBootstrapLimits(str string) (PeersBootstrapLimits, error) {
+	var limits PeersBootstrapLimits
+	for _, part := range strings.Split(str, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, ":", 2)
+		if len(kv) != 2 {
+			return PeersBootstrapLimits{}, fmt.Errorf(
+				"invalid peers bootstrap limit '%s', expected key:value", part)
+		}
+		value, err := strconv.ParseInt(strings.TrimSpace(kv[1]), 10, 64)
+		if err != nil {
+			return PeersBootstrapLimits{}, fmt.Errorf(
+				"invalid peers bootstrap limit '%s': %v", part, err)
+		}
+		switch key := strings.TrimSpace(kv[0]); key {
+		case peersBootstrapBytesPerSecondPerPeerKey:
+			limits.BytesPerSecondPerPeer = value
+		case peersBootstrapMaxConcurrentShardsKey:
+			limits.MaxConcurrentShards = int(value)
+		case peersBootstrapMaxConcurrentBlockFetchesKey:
+			limits.MaxConcurrentBlockFetches = int(value)
+		default:
+			return PeersBootstrapLimits{}, fmt.Errorf(
+				"invalid peers bootstrap limit key '%s' valid keys are: %v", key,
+				[]string{
+					peersBootstrapBytesPerSecondPerPeerKey,
+					peersBootstrapMaxConcurrentShardsKey,
+					peersBootstrapMaxConcurrentBlockFetchesKey,
+				})
+		}
+	}
+	if err := limits.Validate(); err != nil {
+		return PeersBootstrapLimits{}, err
+	}
+	return limits, nil
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePeersBootstrapLimits(t *testing.T) {
+	limits, err := ParsePeersBootstrapLimits("")
+	require.NoError(t, err)
+	assert.Equal(t, PeersBootstrapLimits{}, limits)
+
+	limits, err = ParsePeersBootstrapLimits(
+		"bytes_per_second_per_peer:1048576, max_concurrent_shards:4,max_concurrent_block_fetches:8")
+	require.NoError(t, err)
+	assert.Equal(t, PeersBootstrapLimits{
+		BytesPerSecondPerPeer:     1048576,
+		MaxConcurrentShards:       4,
+		MaxConcurrentBlockFetches: 8,
+	}, limits)
+
+	_, err = ParsePeersBootstrapLimits("max_concurrent_shards")
+	assert.Error(t, err)
+
+	_, err = ParsePeersBootstrapLimits("max_concurrent_shards:four")
+	assert.Error(t, err)
+
+	_, err = ParsePeersBootstrapLimits("max_concurrent_peers:4")
+	assert.Error(t, err)
+
+	_, err = ParsePeersBootstrapLimits("max_concurrent_shards:-1")
+	assert.Equal(t, errPeersBootstrapMaxConcurrentShardsIsNegative, err)
+}
+
+func TestRuntimeOptionsPeersBootstrapLimitsValidate(t *testing.T) {
+	v := NewOptions().SetPeersBootstrapLimits(PeersBootstrapLimits{
+		BytesPerSecondPerPeer: 1 << 20,
+	})
+	assert.NoError(t, v.Validate())
+	assert.Equal(t, int64(1<<20), v.PeersBootstrapLimits().BytesPerSecondPerPeer)
+
+	v = v.SetPeersBootstrapLimits(PeersBootstrapLimits{
+		BytesPerSecondPerPeer: -1,
+	})
+	assert.Equal(t, errPeersBootstrapBytesPerSecondPerPeerIsNegative, v.Validate())
+}
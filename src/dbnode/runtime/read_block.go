@@ -0,0 +1,139 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	errReadBlockNamespaceEmpty = errors.New(
+		"read block rule namespace cannot be empty")
+	errReadBlockTagNameEmpty = errors.New(
+		"read block rule tag name cannot be empty")
+)
+
+// ReadBlockRule rejects the reads of a namespace, e.g. to cut off a runaway
+// dashboard. Without tags every read of the namespace is rejected, with tags
+// only the index queries that match each of the tags are rejected.
+type ReadBlockRule struct {
+	// Namespace is the ID of the namespace the rule applies to.
+	Namespace string
+	// Tags are the tag names and values an index query must match, a query
+	// matches a tag when it requires the tag to equal, or match the regexp
+	// of, the value.
+	Tags map[string]string
+}
+
+// Validate validates the read block rule.
+func (r ReadBlockRule) Validate() error {
+	if r.Namespace == "" {
+		return errReadBlockNamespaceEmpty
+	}
+	for name := range r.Tags {
+		if name == "" {
+			return errReadBlockTagNameEmpty
+		}
+	}
+	return nil
+}
+
+func (r ReadBlockRule) String() string {
+	names := make([]string, 0, len(r.Tags))
+	for name := range r.Tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, 1+len(names))
+	parts = append(parts, r.Namespace)
+	for _, name := range names {
+		parts = append(parts, name+"="+r.Tags[name])
+	}
+	return strings.Join(parts, ",")
+}
+
+// ReadBlockRules is a set of read block rules.
+type ReadBlockRules []ReadBlockRule
+
+// Validate validates each of the rules.
+func (r ReadBlockRules) Validate() error {
+	for _, rule := range r {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ForNamespace returns the rules that apply to the namespace.
+func (r ReadBlockRules) ForNamespace(namespace string) ReadBlockRules {
+	var result ReadBlockRules
+	for _, rule := range r {
+		if rule.Namespace == namespace {
+			result = append(result, rule)
+		}
+	}
+	return result
+}
+
+func (r ReadBlockRules) String() string {
+	strs := make([]string, 0, len(r))
+	for _, rule := range r {
+		strs = append(strs, rule.String())
+	}
+	return strings.Join(strs, ";")
+}
+
+// ParseReadBlockRules parses ReadBlockRules from a string of semicolon
+// separated rules each of the form "<namespace>[,<tag>=<value>...]", e.g.
+// "metrics;metrics,app=dashboards" rejects every read of the namespace
+// metrics with the first rule and only its index queries matching the tag
+// app=dashboards with the second. An empty string is parsed as no rules.
+func ParseReadBlockRules(str string) (ReadBlockRules, error) {
+	var rules ReadBlockRules
+	if strings.TrimSpace(str) == "" {
+		return rules, nil
+	}
+	for _, ruleStr := range strings.Split(str, ";") {
+		parts := strings.Split(strings.TrimSpace(ruleStr), ",")
+		rule := ReadBlockRule{Namespace: parts[0]}
+		for _, tag := range parts[1:] {
+			kv := strings.SplitN(tag, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf(
+					"invalid read block rule '%s': expected <namespace>[,<tag>=<value>...]",
+					ruleStr)
+			}
+			if rule.Tags == nil {
+				rule.Tags = make(map[string]string)
+			}
+			rule.Tags[kv[0]] = kv[1]
+		}
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReadBlockRules(t *testing.T) {
+	rules, err := ParseReadBlockRules("")
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(rules))
+
+	rules, err = ParseReadBlockRules("foo; bar,app=dashboards,city=n.*")
+	require.NoError(t, err)
+	require.Equal(t, ReadBlockRules{
+		{Namespace: "foo"},
+		{
+			Namespace: "bar",
+			Tags:      map[string]string{"app": "dashboards", "city": "n.*"},
+		},
+	}, rules)
+	assert.Equal(t, "foo;bar,app=dashboards,city=n.*", rules.String())
+
+	assert.Equal(t, ReadBlockRules{rules[1]}, rules.ForNamespace("bar"))
+	assert.Equal(t, 0, len(rules.ForNamespace("baz")))
+
+	_, err = ParseReadBlockRules("foo,app")
+	assert.Error(t, err)
+
+	_, err = ParseReadBlockRules(",app=dashboards")
+	assert.Equal(t, errReadBlockNamespaceEmpty, err)
+
+	_, err = ParseReadBlockRules("foo,=dashboards")
+	assert.Equal(t, errReadBlockTagNameEmpty, err)
+}
+
+func TestRuntimeOptionsReadBlockRulesValidate(t *testing.T) {
+	v := NewOptions().SetReadBlockRules(ReadBlockRules{{Namespace: "foo"}})
+	assert.NoError(t, v.Validate())
+
+	v = v.SetReadBlockRules(ReadBlockRules{{}})
+	assert.Equal(t, errReadBlockNamespaceEmpty, v.Validate())
+}
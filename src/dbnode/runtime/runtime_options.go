@@ -54,6 +54,7 @@ const (
 	defaultTickSeriesBatchSize                  = 512
 	defaultTickPerSeriesSleepDuration           = 100 * time.Microsecond
 	defaultTickMinimumInterval                  = 10 * time.Second
+	defaultTickBufferMergeBudget                = time.Duration(0)
 	defaultMaxWiredBlocks                       = uint(1 << 18) // 262,144
 )
 
@@ -66,6 +67,14 @@ var (
 		"tick series batch size must be positive")
 	errTickPerSeriesSleepDurationMustBePositive = errors.New(
 		"tick per series sleep duration must be positive")
+	errTickBufferMergeBudgetIsNegative = errors.New(
+		"tick buffer merge budget cannot be negative")
+	errCommitLogWriteLimitIsNegative = errors.New(
+		"commit log write limit cannot be negative")
+	errCommitLogMaxQueueWaitIsNegative = errors.New(
+		"commit log max queue wait cannot be negative")
+	errNamespaceIngestLimitIsNegative = errors.New(
+		"namespace ingest limit cannot be negative")
 )
 
 type options struct {
@@ -76,12 +85,22 @@ type options struct {
 	tickSeriesBatchSize                  int
 	tickPerSeriesSleepDuration           time.Duration
 	tickMinimumInterval                  time.Duration
+	tickBufferMergeBudget                time.Duration
 	maxWiredBlocks                       uint
 	clientBootstrapConsistencyLevel      topology.ReadConsistencyLevel
 	clientReadConsistencyLevel           topology.ReadConsistencyLevel
 	clientWriteConsistencyLevel          topology.ConsistencyLevel
 	indexDefaultQueryTimeout             time.Duration
 	flushIndexBlockNumSegments           uint
+	commitLogNamespaceWriteLimits        CommitLogNamespaceWriteLimits
+	commitLogFsyncPolicy                 CommitLogFsyncPolicy
+	namespaceIngestLimits                NamespaceIngestLimits
+	writeBlackoutWindows                 WriteBlackoutWindows
+	coldFlushOptions                     ColdFlushOptions
+	tickPacingOptions                    TickPacingOptions
+	readBlockRules                       ReadBlockRules
+	indexCompactionOptions               IndexCompactionOptions
+	peersBootstrapLimits                 PeersBootstrapLimits
 }
 
 // NewOptions creates a new set of runtime options with defaults
@@ -94,6 +113,7 @@ func NewOptions() Options {
 		tickSeriesBatchSize:                  defaultTickSeriesBatchSize,
 		tickPerSeriesSleepDuration:           defaultTickPerSeriesSleepDuration,
 		tickMinimumInterval:                  defaultTickMinimumInterval,
+		tickBufferMergeBudget:                defaultTickBufferMergeBudget,
 		maxWiredBlocks:                       defaultMaxWiredBlocks,
 		clientBootstrapConsistencyLevel:      DefaultBootstrapConsistencyLevel,
 		clientReadConsistencyLevel:           DefaultReadConsistencyLevel,
@@ -125,6 +145,81 @@ func (o *options) Validate() error {
 
 	// tickMinimumInterval can be zero if user desires
 
+	// tickBufferMergeBudget can be zero to specify that buffer merges
+	// are not limited
+	if o.tickBufferMergeBudget < 0 {
+		return errTickBufferMergeBudgetIsNegative
+	}
+
+	// Commit log write limits can be zero to specify that no limit
+	// should be enforced
+	limits := o.commitLogNamespaceWriteLimits
+	if err := validateCommitLogWriteLimit(limits.Default); err != nil {
+		return err
+	}
+	for _, limit := range limits.Overrides {
+		if err := validateCommitLogWriteLimit(limit); err != nil {
+			return err
+		}
+	}
+	if limits.MaxQueueWait < 0 {
+		return errCommitLogMaxQueueWaitIsNegative
+	}
+
+	if err := o.commitLogFsyncPolicy.Validate(); err != nil {
+		return err
+	}
+
+	// Namespace ingest limits can be zero to specify that no limit
+	// should be enforced
+	ingestLimits := o.namespaceIngestLimits
+	if err := validateNamespaceIngestLimit(ingestLimits.Default); err != nil {
+		return err
+	}
+	for _, limit := range ingestLimits.Overrides {
+		if err := validateNamespaceIngestLimit(limit); err != nil {
+			return err
+		}
+	}
+
+	if err := o.writeBlackoutWindows.Validate(); err != nil {
+		return err
+	}
+
+	if err := o.coldFlushOptions.Validate(); err != nil {
+		return err
+	}
+
+	if err := o.tickPacingOptions.Validate(); err != nil {
+		return err
+	}
+
+	if err := o.readBlockRules.Validate(); err != nil {
+		return err
+	}
+
+	if err := o.indexCompactionOptions.Validate(); err != nil {
+		return err
+	}
+
+	if err := o.peersBootstrapLimits.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func validateCommitLogWriteLimit(limit CommitLogWriteLimit) error {
+	if limit.OpsPerSecond < 0 || limit.BytesPerSecond < 0 {
+		return errCommitLogWriteLimitIsNegative
+	}
+	return nil
+}
+
+func validateNamespaceIngestLimit(limit NamespaceIngestLimit) error {
+	if limit.DatapointsPerSecond < 0 || limit.BytesPerSecond < 0 {
+		return errNamespaceIngestLimitIsNegative
+	}
 	return nil
 }
 
@@ -198,6 +293,16 @@ func (o *options) TickMinimumInterval() time.Duration {
 	return o.tickMinimumInterval
 }
 
+func (o *options) SetTickBufferMergeBudget(value time.Duration) Options {
+	opts := *o
+	opts.tickBufferMergeBudget = value
+	return &opts
+}
+
+func (o *options) TickBufferMergeBudget() time.Duration {
+	return o.tickBufferMergeBudget
+}
+
 func (o *options) SetMaxWiredBlocks(value uint) Options {
 	opts := *o
 	opts.maxWiredBlocks = value
@@ -257,3 +362,93 @@ func (o *options) SetFlushIndexBlockNumSegments(value uint) Options {
 func (o *options) FlushIndexBlockNumSegments() uint {
 	return o.flushIndexBlockNumSegments
 }
+
+func (o *options) SetCommitLogNamespaceWriteLimits(value CommitLogNamespaceWriteLimits) Options {
+	opts := *o
+	opts.commitLogNamespaceWriteLimits = value
+	return &opts
+}
+
+func (o *options) CommitLogNamespaceWriteLimits() CommitLogNamespaceWriteLimits {
+	return o.commitLogNamespaceWriteLimits
+}
+
+func (o *options) SetCommitLogFsyncPolicy(value CommitLogFsyncPolicy) Options {
+	opts := *o
+	opts.commitLogFsyncPolicy = value
+	return &opts
+}
+
+func (o *options) CommitLogFsyncPolicy() CommitLogFsyncPolicy {
+	return o.commitLogFsyncPolicy
+}
+
+func (o *options) SetNamespaceIngestLimits(value NamespaceIngestLimits) Options {
+	opts := *o
+	opts.namespaceIngestLimits = value
+	return &opts
+}
+
+func (o *options) NamespaceIngestLimits() NamespaceIngestLimits {
+	return o.namespaceIngestLimits
+}
+
+func (o *options) SetWriteBlackoutWindows(value WriteBlackoutWindows) Options {
+	opts := *o
+	opts.writeBlackoutWindows = value
+	return &opts
+}
+
+func (o *options) WriteBlackoutWindows() WriteBlackoutWindows {
+	return o.writeBlackoutWindows
+}
+
+func (o *options) SetColdFlushOptions(value ColdFlushOptions) Options {
+	opts := *o
+	opts.coldFlushOptions = value
+	return &opts
+}
+
+func (o *options) ColdFlushOptions() ColdFlushOptions {
+	return o.coldFlushOptions
+}
+
+func (o *options) SetTickPacingOptions(value TickPacingOptions) Options {
+	opts := *o
+	opts.tickPacingOptions = value
+	return &opts
+}
+
+func (o *options) TickPacingOptions() TickPacingOptions {
+	return o.tickPacingOptions
+}
+
+func (o *options) SetReadBlockRules(value ReadBlockRules) Options {
+	opts := *o
+	opts.readBlockRules = value
+	return &opts
+}
+
+func (o *options) ReadBlockRules() ReadBlockRules {
+	return o.readBlockRules
+}
+
+func (o *options) SetIndexCompactionOptions(value IndexCompactionOptions) Options {
+	opts := *o
+	opts.indexCompactionOptions = value
+	return &opts
+}
+
+func (o *options) IndexCompactionOptions() IndexCompactionOptions {
+	return o.indexCompactionOptions
+}
+
+func (o *options) SetPeersBootstrapLimits(value PeersBootstrapLimits) Options {
+	opts := *o
+	opts.peersBootstrapLimits = value
+	return &opts
+}
+
+func (o *options) PeersBootstrapLimits() PeersBootstrapLimits {
+	return o.peersBootstrapLimits
+}
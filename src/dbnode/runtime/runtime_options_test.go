@@ -22,6 +22,7 @@ package runtime
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -30,3 +31,137 @@ func TestRuntimeOptionsDefaultsIsValid(t *testing.T) {
 	v := NewOptions()
 	assert.NoError(t, v.Validate())
 }
+
+func TestRuntimeOptionsCommitLogNamespaceWriteLimitsValidate(t *testing.T) {
+	v := NewOptions().SetCommitLogNamespaceWriteLimits(CommitLogNamespaceWriteLimits{
+		Default: CommitLogWriteLimit{OpsPerSecond: 100, BytesPerSecond: 1 << 20},
+	})
+	assert.NoError(t, v.Validate())
+
+	v = v.SetCommitLogNamespaceWriteLimits(CommitLogNamespaceWriteLimits{
+		Overrides: map[string]CommitLogWriteLimit{
+			"foo": {BytesPerSecond: -1},
+		},
+	})
+	assert.Equal(t, errCommitLogWriteLimitIsNegative, v.Validate())
+
+	v = v.SetCommitLogNamespaceWriteLimits(CommitLogNamespaceWriteLimits{
+		MaxQueueWait: -time.Second,
+	})
+	assert.Equal(t, errCommitLogMaxQueueWaitIsNegative, v.Validate())
+}
+
+func TestRuntimeOptionsTickBufferMergeBudgetValidate(t *testing.T) {
+	v := NewOptions().SetTickBufferMergeBudget(0)
+	assert.NoError(t, v.Validate())
+
+	v = v.SetTickBufferMergeBudget(-time.Second)
+	assert.Equal(t, errTickBufferMergeBudgetIsNegative, v.Validate())
+}
+
+func TestRuntimeOptionsCommitLogFsyncPolicyValidate(t *testing.T) {
+	v := NewOptions().SetCommitLogFsyncPolicy(CommitLogFsyncPolicy{
+		Strategy: CommitLogFsyncEveryBytes,
+		Bytes:    1 << 20,
+	})
+	assert.NoError(t, v.Validate())
+
+	v = v.SetCommitLogFsyncPolicy(CommitLogFsyncPolicy{
+		Strategy: CommitLogFsyncEveryBytes,
+	})
+	assert.Equal(t, errCommitLogFsyncBytesMustBePositive, v.Validate())
+
+	v = v.SetCommitLogFsyncPolicy(CommitLogFsyncPolicy{
+		Strategy: CommitLogFsyncEveryInterval,
+		Interval: -time.Second,
+	})
+	assert.Equal(t, errCommitLogFsyncIntervalMustBePositive, v.Validate())
+
+	v = v.SetCommitLogFsyncPolicy(CommitLogFsyncPolicy{
+		Strategy: CommitLogFsyncStrategy(100),
+	})
+	assert.Error(t, v.Validate())
+}
+
+func TestRuntimeOptionsNamespaceIngestLimitsValidate(t *testing.T) {
+	v := NewOptions().SetNamespaceIngestLimits(NamespaceIngestLimits{
+		Default: NamespaceIngestLimit{DatapointsPerSecond: 100, BytesPerSecond: 1 << 20},
+	})
+	assert.NoError(t, v.Validate())
+	assert.Equal(t, int64(100), v.NamespaceIngestLimits().Limit("foo").DatapointsPerSecond)
+
+	v = v.SetNamespaceIngestLimits(NamespaceIngestLimits{
+		Overrides: map[string]NamespaceIngestLimit{
+			"foo": {DatapointsPerSecond: -1},
+		},
+	})
+	assert.Equal(t, errNamespaceIngestLimitIsNegative, v.Validate())
+}
+
+func TestRuntimeOptionsColdFlushOptionsValidate(t *testing.T) {
+	v := NewOptions().SetColdFlushOptions(ColdFlushOptions{
+		Interval:    time.Minute,
+		Concurrency: 4,
+	})
+	assert.NoError(t, v.Validate())
+
+	v = v.SetColdFlushOptions(ColdFlushOptions{Concurrency: -1})
+	assert.Equal(t, errColdFlushConcurrencyIsNegative, v.Validate())
+
+	v = v.SetColdFlushOptions(ColdFlushOptions{ColdWritesThreshold: -1})
+	assert.Equal(t, errColdFlushColdWritesThresholdIsNegative, v.Validate())
+}
+
+func TestColdFlushOptionsOverride(t *testing.T) {
+	opts := ColdFlushOptions{
+		Interval:            time.Hour,
+		Concurrency:         2,
+		ColdWritesThreshold: 1000,
+	}
+	assert.Equal(t, opts, opts.Override(ColdFlushOptions{}))
+	assert.Equal(t, ColdFlushOptions{
+		Interval:            time.Hour,
+		Concurrency:         8,
+		ColdWritesThreshold: 1000,
+	}, opts.Override(ColdFlushOptions{Concurrency: 8}))
+}
+
+func TestRuntimeOptionsTickPacingOptionsValidate(t *testing.T) {
+	v := NewOptions().SetTickPacingOptions(TickPacingOptions{
+		BlockSizeFraction: 0.25,
+		CPUBudget:         0.5,
+	})
+	assert.NoError(t, v.Validate())
+
+	v = v.SetTickPacingOptions(TickPacingOptions{TargetDuration: -time.Second})
+	assert.Equal(t, errTickPacingTargetDurationIsNegative, v.Validate())
+
+	v = v.SetTickPacingOptions(TickPacingOptions{BlockSizeFraction: 1.5})
+	assert.Equal(t, errTickPacingBlockSizeFractionInvalid, v.Validate())
+
+	v = v.SetTickPacingOptions(TickPacingOptions{CPUBudget: -0.1})
+	assert.Equal(t, errTickPacingCPUBudgetInvalid, v.Validate())
+}
+
+func TestTickPacingOptionsTarget(t *testing.T) {
+	assert.Equal(t, time.Duration(0), TickPacingOptions{}.Target(2*time.Hour))
+	assert.Equal(t, 30*time.Minute,
+		TickPacingOptions{BlockSizeFraction: 0.25}.Target(2*time.Hour))
+	assert.Equal(t, time.Minute, TickPacingOptions{
+		TargetDuration:    time.Minute,
+		BlockSizeFraction: 0.25,
+	}.Target(2*time.Hour))
+}
+
+func TestRuntimeOptionsIndexCompactionOptionsValidate(t *testing.T) {
+	v := NewOptions().SetIndexCompactionOptions(IndexCompactionOptions{
+		MaxConcurrentBackgroundCompactions: 2,
+	})
+	assert.NoError(t, v.Validate())
+	assert.Equal(t, 2, v.IndexCompactionOptions().MaxConcurrentBackgroundCompactions)
+
+	v = v.SetIndexCompactionOptions(IndexCompactionOptions{
+		MaxConcurrentBackgroundCompactions: -1,
+	})
+	assert.Equal(t, errIndexMaxConcurrentBackgroundCompactionsIsNegative, v.Validate())
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package runtime
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	errTickPacingTargetDurationIsNegative = errors.New(
+		"tick pacing target duration cannot be negative")
+	errTickPacingBlockSizeFractionInvalid = errors.New(
+		"tick pacing block size fraction must be between 0 and 1")
+	errTickPacingCPUBudgetInvalid = errors.New(
+		"tick pacing cpu budget must be between 0 and 1")
+)
+
+// TickPacingOptions pace the ticks so that a tick is spread across a target
+// duration instead of ticking through the shards at full speed, a zero value
+// for all of the options disables pacing.
+type TickPacingOptions struct {
+	// TargetDuration is the duration a tick is spread across, when set it
+	// takes precedence over the block size fraction.
+	TargetDuration time.Duration
+	// BlockSizeFraction is the fraction of the smallest block size of the
+	// ticked namespaces a tick is spread across when no target duration is
+	// set.
+	BlockSizeFraction float64
+	// CPUBudget is the fraction of the available CPU the process can use
+	// while ticking before the sleeps between shards are lengthened, when
+	// not set the sleeps are not adjusted by the CPU usage.
+	CPUBudget float64
+}
+
+// Validate validates the tick pacing options.
+func (o TickPacingOptions) Validate() error {
+	if o.TargetDuration < 0 {
+		return errTickPacingTargetDurationIsNegative
+	}
+	if o.BlockSizeFraction < 0 || o.BlockSizeFraction > 1 {
+		return errTickPacingBlockSizeFractionInvalid
+	}
+	if o.CPUBudget < 0 || o.CPUBudget > 1 {
+		return errTickPacingCPUBudgetInvalid
+	}
+	return nil
+}
+
+// Target returns the duration a tick is spread across given the smallest
+// block size of the ticked namespaces, zero means the tick is not paced.
+func (o TickPacingOptions) Target(blockSize time.Duration) time.Duration {
+	if o.TargetDuration > 0 {
+		return o.TargetDuration
+	}
+	return time.Duration(o.BlockSizeFraction * float64(blockSize))
+}
@@ -117,6 +117,16 @@ type Options interface {
 	// on a per series basis is short.
 	TickMinimumInterval() time.Duration
 
+	// SetTickBufferMergeBudget sets the time a shard tick may spend merging
+	// series buffers, merges that do not complete within the budget are
+	// continued on the next tick. Zero, the default, specifies no budget.
+	SetTickBufferMergeBudget(value time.Duration) Options
+
+	// TickBufferMergeBudget returns the time a shard tick may spend merging
+	// series buffers, merges that do not complete within the budget are
+	// continued on the next tick. Zero, the default, specifies no budget.
+	TickBufferMergeBudget() time.Duration
+
 	// SetMaxWiredBlocks sets the max blocks to keep wired; zero is used
 	// to specify no limit. Wired blocks that are in the buffer, I.E are
 	// being written to, cannot be unwired. Similarly, blocks which have
@@ -180,6 +190,86 @@ type Options interface {
 	// greater amount of segments that need to be searched independently but
 	// a higher number reduces the memory pressure when flushing an index block.
 	FlushIndexBlockNumSegments() uint
+
+	// SetCommitLogNamespaceWriteLimits sets the per namespace limits on the
+	// rate of writes admitted to the commit log, these protect the commit log
+	// disk from a single namespace generating excessive write volume.
+	SetCommitLogNamespaceWriteLimits(value CommitLogNamespaceWriteLimits) Options
+
+	// CommitLogNamespaceWriteLimits returns the per namespace limits on the
+	// rate of writes admitted to the commit log, these protect the commit log
+	// disk from a single namespace generating excessive write volume.
+	CommitLogNamespaceWriteLimits() CommitLogNamespaceWriteLimits
+
+	// SetCommitLogFsyncPolicy sets the policy for fsyncing the commit log,
+	// this trades the durability of writes acknowledged before they are
+	// fsync'd for write latency and disk load.
+	SetCommitLogFsyncPolicy(value CommitLogFsyncPolicy) Options
+
+	// CommitLogFsyncPolicy returns the policy for fsyncing the commit log,
+	// this trades the durability of writes acknowledged before they are
+	// fsync'd for write latency and disk load.
+	CommitLogFsyncPolicy() CommitLogFsyncPolicy
+
+	// SetNamespaceIngestLimits sets the per namespace limits on the rate of
+	// writes admitted to a namespace, these protect a shared cluster from a
+	// spike in the traffic of a single namespace.
+	SetNamespaceIngestLimits(value NamespaceIngestLimits) Options
+
+	// NamespaceIngestLimits returns the per namespace limits on the rate of
+	// writes admitted to a namespace, these protect a shared cluster from a
+	// spike in the traffic of a single namespace.
+	NamespaceIngestLimits() NamespaceIngestLimits
+
+	// SetWriteBlackoutWindows sets the time windows during which the writes
+	// to specific namespaces are rejected or dropped, e.g. for maintenance.
+	SetWriteBlackoutWindows(value WriteBlackoutWindows) Options
+
+	// WriteBlackoutWindows returns the time windows during which the writes
+	// to specific namespaces are rejected or dropped, e.g. for maintenance.
+	WriteBlackoutWindows() WriteBlackoutWindows
+
+	// SetColdFlushOptions sets the options scheduling the cold flushes of
+	// namespaces, the options that are set override those of the storage
+	// options.
+	SetColdFlushOptions(value ColdFlushOptions) Options
+
+	// ColdFlushOptions returns the options scheduling the cold flushes of
+	// namespaces, the options that are set override those of the storage
+	// options.
+	ColdFlushOptions() ColdFlushOptions
+
+	// SetTickPacingOptions sets the options pacing the ticks across a target
+	// duration with the sleeps between shards adjusted by the CPU usage.
+	SetTickPacingOptions(value TickPacingOptions) Options
+
+	// TickPacingOptions returns the options pacing the ticks across a target
+	// duration with the sleeps between shards adjusted by the CPU usage.
+	TickPacingOptions() TickPacingOptions
+
+	// SetReadBlockRules sets the rules rejecting the reads of specific
+	// namespaces, or only their index queries matching specific tags.
+	SetReadBlockRules(value ReadBlockRules) Options
+
+	// ReadBlockRules returns the rules rejecting the reads of specific
+	// namespaces, or only their index queries matching specific tags.
+	ReadBlockRules() ReadBlockRules
+
+	// SetIndexCompactionOptions sets the options tuning the background
+	// compactions of the index segments of namespaces.
+	SetIndexCompactionOptions(value IndexCompactionOptions) Options
+
+	// IndexCompactionOptions returns the options tuning the background
+	// compactions of the index segments of namespaces.
+	IndexCompactionOptions() IndexCompactionOptions
+
+	// SetPeersBootstrapLimits sets the limits throttling the streaming of
+	// blocks from peers when bootstrapping.
+	SetPeersBootstrapLimits(value PeersBootstrapLimits) Options
+
+	// PeersBootstrapLimits returns the limits throttling the streaming of
+	// blocks from peers when bootstrapping.
+	PeersBootstrapLimits() PeersBootstrapLimits
 }
 
 // OptionsManager updates and supplies runtime options.
@@ -205,3 +295,70 @@ type OptionsListener interface {
 	// and when any updates occurred passing the new runtime options.
 	SetRuntimeOptions(value Options)
 }
+
+// CommitLogWriteLimit is a limit on the rate of writes admitted to the
+// commit log, a zero value for either limit means that it is not enforced.
+type CommitLogWriteLimit struct {
+	// OpsPerSecond is the max number of writes admitted per second.
+	OpsPerSecond int64
+	// BytesPerSecond is the max number of bytes admitted per second.
+	BytesPerSecond int64
+}
+
+// Enabled returns whether either of the limits is enforced.
+func (l CommitLogWriteLimit) Enabled() bool {
+	return l.OpsPerSecond > 0 || l.BytesPerSecond > 0
+}
+
+// CommitLogNamespaceWriteLimits is the set of commit log write limits
+// applied to each namespace.
+type CommitLogNamespaceWriteLimits struct {
+	// Default is the limit applied to each namespace without an override.
+	Default CommitLogWriteLimit
+	// Overrides are limits keyed by namespace ID that take precedence
+	// over the default limit.
+	Overrides map[string]CommitLogWriteLimit
+	// MaxQueueWait is the max time a write exceeding its namespace limit
+	// waits for capacity before it is rejected, zero rejects immediately.
+	MaxQueueWait time.Duration
+}
+
+// Limit returns the commit log write limit for a namespace.
+func (l CommitLogNamespaceWriteLimits) Limit(namespace string) CommitLogWriteLimit {
+	if limit, ok := l.Overrides[namespace]; ok {
+		return limit
+	}
+	return l.Default
+}
+
+// NamespaceIngestLimit is a limit on the rate of writes admitted to a
+// namespace, a zero value for either limit means that it is not enforced.
+// Writes are admitted from token buckets holding up to a second of capacity.
+type NamespaceIngestLimit struct {
+	// DatapointsPerSecond is the max number of datapoints admitted per second.
+	DatapointsPerSecond int64
+	// BytesPerSecond is the max number of bytes admitted per second.
+	BytesPerSecond int64
+}
+
+// Enabled returns whether either of the limits is enforced.
+func (l NamespaceIngestLimit) Enabled() bool {
+	return l.DatapointsPerSecond > 0 || l.BytesPerSecond > 0
+}
+
+// NamespaceIngestLimits is the set of ingest limits applied to each namespace.
+type NamespaceIngestLimits struct {
+	// Default is the limit applied to each namespace without an override.
+	Default NamespaceIngestLimit
+	// Overrides are limits keyed by namespace ID that take precedence
+	// over the default limit.
+	Overrides map[string]NamespaceIngestLimit
+}
+
+// Limit returns the ingest limit for a namespace.
+func (l NamespaceIngestLimits) Limit(namespace string) NamespaceIngestLimit {
+	if limit, ok := l.Overrides[namespace]; ok {
+		return limit
+	}
+	return l.Default
+}
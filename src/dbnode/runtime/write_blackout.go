@@ -0,0 +1,225 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	errWriteBlackoutActionUnspecified = errors.New("write blackout action unspecified")
+	errWriteBlackoutNamespaceEmpty    = errors.New(
+		"write blackout window namespace cannot be empty")
+	errWriteBlackoutWindowEmpty = errors.New(
+		"write blackout window end must be after its start")
+)
+
+// WriteBlackoutAction is what is done with the writes to a namespace during
+// a write blackout window.
+type WriteBlackoutAction uint
+
+const (
+	// WriteBlackoutReject rejects writes with an error so that clients can
+	// retry them once the window ends.
+	WriteBlackoutReject WriteBlackoutAction = iota
+	// WriteBlackoutDrop acknowledges writes without writing them.
+	WriteBlackoutDrop
+)
+
+// ValidWriteBlackoutActions returns the valid write blackout actions.
+func ValidWriteBlackoutActions() []WriteBlackoutAction {
+	return []WriteBlackoutAction{
+		WriteBlackoutReject,
+		WriteBlackoutDrop,
+	}
+}
+
+func (a WriteBlackoutAction) String() string {
+	switch a {
+	case WriteBlackoutReject:
+		return "reject"
+	case WriteBlackoutDrop:
+		return "drop"
+	}
+	return "unknown"
+}
+
+// ParseWriteBlackoutAction parses a WriteBlackoutAction from a string.
+func ParseWriteBlackoutAction(str string) (WriteBlackoutAction, error) {
+	var r WriteBlackoutAction
+	if str == "" {
+		return r, errWriteBlackoutActionUnspecified
+	}
+	for _, valid := range ValidWriteBlackoutActions() {
+		if str == valid.String() {
+			r = valid
+			return r, nil
+		}
+	}
+	return r, fmt.Errorf("invalid WriteBlackoutAction '%s' valid types are: %v",
+		str, ValidWriteBlackoutActions())
+}
+
+// UnmarshalYAML unmarshals a WriteBlackoutAction into a valid type from string.
+func (a *WriteBlackoutAction) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	r, err := ParseWriteBlackoutAction(str)
+	if err != nil {
+		return err
+	}
+	*a = r
+	return nil
+}
+
+// WriteBlackoutWindow is a time window during which the writes to a
+// namespace are rejected or dropped, e.g. for maintenance or while cutting
+// over writes to a migrated namespace.
+type WriteBlackoutWindow struct {
+	// Namespace is the ID of the namespace the window applies to.
+	Namespace string
+	// Start is the inclusive start of the window.
+	Start time.Time
+	// End is the exclusive end of the window.
+	End time.Time
+	// Action is what is done with the writes during the window.
+	Action WriteBlackoutAction
+}
+
+// Validate validates the write blackout window.
+func (w WriteBlackoutWindow) Validate() error {
+	if w.Namespace == "" {
+		return errWriteBlackoutNamespaceEmpty
+	}
+	if !w.End.After(w.Start) {
+		return errWriteBlackoutWindowEmpty
+	}
+	for _, valid := range ValidWriteBlackoutActions() {
+		if w.Action == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid WriteBlackoutAction '%d' valid types are: %v",
+		uint(w.Action), ValidWriteBlackoutActions())
+}
+
+// Contains returns whether the window contains the time.
+func (w WriteBlackoutWindow) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+func (w WriteBlackoutWindow) String() string {
+	return strings.Join([]string{
+		w.Namespace,
+		w.Action.String(),
+		w.Start.Format(time.RFC3339),
+		w.End.Format(time.RFC3339),
+	}, ",")
+}
+
+// WriteBlackoutWindows is a set of write blackout windows.
+type WriteBlackoutWindows []WriteBlackoutWindow
+
+// Validate validates each of the windows.
+func (w WriteBlackoutWindows) Validate() error {
+	for _, window := range w {
+		if err := window.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ForNamespace returns the windows that apply to the namespace.
+func (w WriteBlackoutWindows) ForNamespace(namespace string) WriteBlackoutWindows {
+	var result WriteBlackoutWindows
+	for _, window := range w {
+		if window.Namespace == namespace {
+			result = append(result, window)
+		}
+	}
+	return result
+}
+
+// Active returns the first window that contains the time, if any.
+func (w WriteBlackoutWindows) Active(t time.Time) (WriteBlackoutWindow, bool) {
+	for _, window := range w {
+		if window.Contains(t) {
+			return window, true
+		}
+	}
+	return WriteBlackoutWindow{}, false
+}
+
+func (w WriteBlackoutWindows) String() string {
+	strs := make([]string, 0, len(w))
+	for _, window := range w {
+		strs = append(strs, window.String())
+	}
+	return strings.Join(strs, ";")
+}
+
+// ParseWriteBlackoutWindows parses WriteBlackoutWindows from a string of
+// semicolon separated windows each of the form
+// "<namespace>,<action>,<start>,<end>" with RFC3339 start and end times, e.g.
+// "metrics,reject,2020-01-01T00:00:00Z,2020-01-01T01:00:00Z". An empty string
+// is parsed as no windows.
+func ParseWriteBlackoutWindows(str string) (WriteBlackoutWindows, error) {
+	var windows WriteBlackoutWindows
+	if strings.TrimSpace(str) == "" {
+		return windows, nil
+	}
+	for _, windowStr := range strings.Split(str, ";") {
+		parts := strings.Split(strings.TrimSpace(windowStr), ",")
+		if len(parts) != 4 {
+			return nil, fmt.Errorf(
+				"invalid write blackout window '%s': expected <namespace>,<action>,<start>,<end>",
+				windowStr)
+		}
+		action, err := ParseWriteBlackoutAction(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		start, err := time.Parse(time.RFC3339, parts[2])
+		if err != nil {
+			return nil, err
+		}
+		end, err := time.Parse(time.RFC3339, parts[3])
+		if err != nil {
+			return nil, err
+		}
+		window := WriteBlackoutWindow{
+			Namespace: parts[0],
+			Start:     start,
+			End:       end,
+			Action:    action,
+		}
+		if err := window.Validate(); err != nil {
+			return nil, err
+		}
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
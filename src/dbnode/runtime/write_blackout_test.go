@@ -0,0 +1,129 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestWriteBlackoutWindowsActive(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	windows := WriteBlackoutWindows{
+		{Namespace: "foo", Start: start, End: start.Add(time.Hour), Action: WriteBlackoutReject},
+		{Namespace: "bar", Start: start, End: start.Add(time.Hour), Action: WriteBlackoutDrop},
+		{Namespace: "foo", Start: start.Add(2 * time.Hour), End: start.Add(3 * time.Hour), Action: WriteBlackoutDrop},
+	}
+	require.NoError(t, windows.Validate())
+
+	foo := windows.ForNamespace("foo")
+	require.Equal(t, 2, len(foo))
+
+	_, ok := foo.Active(start.Add(-time.Second))
+	assert.False(t, ok)
+
+	window, ok := foo.Active(start)
+	require.True(t, ok)
+	assert.Equal(t, WriteBlackoutReject, window.Action)
+
+	_, ok = foo.Active(start.Add(time.Hour))
+	assert.False(t, ok)
+
+	window, ok = foo.Active(start.Add(150 * time.Minute))
+	require.True(t, ok)
+	assert.Equal(t, WriteBlackoutDrop, window.Action)
+
+	assert.Equal(t, 0, len(windows.ForNamespace("baz")))
+}
+
+func TestWriteBlackoutWindowValidate(t *testing.T) {
+	start := time.Now()
+	assert.Equal(t, errWriteBlackoutNamespaceEmpty, WriteBlackoutWindow{
+		Start: start,
+		End:   start.Add(time.Hour),
+	}.Validate())
+	assert.Equal(t, errWriteBlackoutWindowEmpty, WriteBlackoutWindow{
+		Namespace: "foo",
+		Start:     start,
+		End:       start,
+	}.Validate())
+	assert.Error(t, WriteBlackoutWindow{
+		Namespace: "foo",
+		Start:     start,
+		End:       start.Add(time.Hour),
+		Action:    WriteBlackoutAction(10),
+	}.Validate())
+
+	v := NewOptions().SetWriteBlackoutWindows(WriteBlackoutWindows{
+		{Namespace: "foo", Start: start, End: start.Add(-time.Hour)},
+	})
+	assert.Equal(t, errWriteBlackoutWindowEmpty, v.Validate())
+}
+
+func TestParseWriteBlackoutWindows(t *testing.T) {
+	windows, err := ParseWriteBlackoutWindows("")
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(windows))
+
+	str := "foo,reject,2020-01-01T00:00:00Z,2020-01-01T01:00:00Z;" +
+		"bar,drop,2020-01-02T00:00:00Z,2020-01-02T01:00:00Z"
+	windows, err = ParseWriteBlackoutWindows(str)
+	require.NoError(t, err)
+	require.Equal(t, WriteBlackoutWindows{
+		{
+			Namespace: "foo",
+			Start:     time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			End:       time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC),
+			Action:    WriteBlackoutReject,
+		},
+		{
+			Namespace: "bar",
+			Start:     time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+			End:       time.Date(2020, 1, 2, 1, 0, 0, 0, time.UTC),
+			Action:    WriteBlackoutDrop,
+		},
+	}, windows)
+	assert.Equal(t, str, windows.String())
+
+	for _, invalid := range []string{
+		"foo,reject,2020-01-01T00:00:00Z",
+		"foo,unknown,2020-01-01T00:00:00Z,2020-01-01T01:00:00Z",
+		"foo,drop,yesterday,2020-01-01T01:00:00Z",
+		"foo,drop,2020-01-01T01:00:00Z,2020-01-01T00:00:00Z",
+	} {
+		_, err := ParseWriteBlackoutWindows(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestWriteBlackoutActionUnmarshalYAML(t *testing.T) {
+	for _, valid := range ValidWriteBlackoutActions() {
+		var action WriteBlackoutAction
+		require.NoError(t, yaml.Unmarshal([]byte(valid.String()), &action))
+		assert.Equal(t, valid, action)
+	}
+
+	var action WriteBlackoutAction
+	require.Error(t, yaml.Unmarshal([]byte("unknown"), &action))
+}
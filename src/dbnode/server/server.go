@@ -21,6 +21,7 @@
 package server
 
 import (
+	stdctx "context"
 	"errors"
 	"fmt"
 	"io"
@@ -63,8 +64,10 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/workload"
 	xtchannel "github.com/m3db/m3/src/dbnode/x/tchannel"
 	"github.com/m3db/m3/src/dbnode/x/xio"
+	m3ninxindex "github.com/m3db/m3/src/m3ninx/index"
 	"github.com/m3db/m3/src/m3ninx/postings"
 	"github.com/m3db/m3/src/m3ninx/postings/roaring"
 	xconfig "github.com/m3db/m3/src/x/config"
@@ -318,6 +321,26 @@ func Run(runOpts RunOptions) {
 	if lruCfg := cfg.Cache.SeriesConfiguration().LRU; lruCfg != nil {
 		runtimeOpts = runtimeOpts.SetMaxWiredBlocks(lruCfg.MaxBlocks)
 	}
+	if limits := cfg.CommitLog.NamespaceWriteLimits; limits != nil {
+		runtimeOpts = runtimeOpts.
+			SetCommitLogNamespaceWriteLimits(limits.RuntimeLimits())
+	}
+	if policy := cfg.CommitLog.Fsync; policy != nil {
+		runtimeOpts = runtimeOpts.
+			SetCommitLogFsyncPolicy(policy.RuntimePolicy())
+	}
+	if limits := cfg.NamespaceIngestLimits; limits != nil {
+		runtimeOpts = runtimeOpts.
+			SetNamespaceIngestLimits(limits.RuntimeLimits())
+	}
+	if windows := cfg.WriteBlackoutWindows; len(windows) > 0 {
+		runtimeOpts = runtimeOpts.
+			SetWriteBlackoutWindows(config.RuntimeWriteBlackoutWindows(windows))
+	}
+	if peers := cfg.Bootstrap.Peers; peers != nil && peers.Limits != nil {
+		runtimeOpts = runtimeOpts.
+			SetPeersBootstrapLimits(peers.Limits.RuntimeLimits())
+	}
 
 	// Setup postings list cache.
 	var (
@@ -343,9 +366,44 @@ func Run(runOpts RunOptions) {
 	indexOpts = indexOpts.SetInsertMode(insertMode).
 		SetPostingsListCache(postingsListCache).
 		SetReadThroughSegmentOptions(index.ReadThroughSegmentOptions{
-			CacheRegexp: plCacheConfig.CacheRegexpOrDefault(),
-			CacheTerms:  plCacheConfig.CacheTermsOrDefault(),
+			CacheRegexp:            plCacheConfig.CacheRegexpOrDefault(),
+			CacheTerms:             plCacheConfig.CacheTermsOrDefault(),
+			CacheCompactedSegments: plCacheConfig.CacheCompactedSegmentsOrDefault(),
+		}).
+		SetRegexpCacheOptions(m3ninxindex.RegexpCacheOptions{
+			MaxBytes: cfg.Index.RegexpCacheMaxBytes,
+			Scope:    iopts.MetricsScope().SubScope("index"),
 		})
+	if compactionCfg := cfg.Index.Compaction; compactionCfg != nil {
+		var (
+			foregroundOpts = compactionCfg.ForegroundPlannerOptions(
+				indexOpts.ForegroundCompactionPlannerOptions())
+			backgroundOpts = compactionCfg.BackgroundPlannerOptions(
+				indexOpts.BackgroundCompactionPlannerOptions())
+		)
+		if err := foregroundOpts.Validate(); err != nil {
+			logger.Fatal("invalid index foreground compaction levels", zap.Error(err))
+		}
+		if err := backgroundOpts.Validate(); err != nil {
+			logger.Fatal("invalid index background compaction levels", zap.Error(err))
+		}
+		indexOpts = indexOpts.
+			SetForegroundCompactionPlannerOptions(foregroundOpts).
+			SetBackgroundCompactionPlannerOptions(backgroundOpts).
+			SetMaxConcurrentBackgroundCompactions(compactionCfg.MaxConcurrentBackgroundCompactions)
+	}
+	if cfg.Index.ColdBlockAge > 0 {
+		indexOpts = indexOpts.SetColdBlockAge(cfg.Index.ColdBlockAge)
+	}
+	if cfg.Index.QuerySegmentsConcurrently {
+		indexOpts = indexOpts.SetQuerySegmentsConcurrently(true)
+	}
+	if cfg.Index.SnapshotEnabled {
+		indexOpts = indexOpts.SetSnapshotEnabled(true)
+	}
+	if n := cfg.Index.FieldCardinalityTopN; n > 0 {
+		indexOpts = indexOpts.SetFieldCardinalityTopN(n)
+	}
 	opts = opts.SetIndexOptions(indexOpts)
 
 	if tick := cfg.Tick; tick != nil {
@@ -353,6 +411,12 @@ func Run(runOpts RunOptions) {
 			SetTickSeriesBatchSize(tick.SeriesBatchSize).
 			SetTickPerSeriesSleepDuration(tick.PerSeriesSleepDuration).
 			SetTickMinimumInterval(tick.MinimumInterval)
+		if budget := tick.BufferMergeBudget; budget != nil {
+			runtimeOpts = runtimeOpts.SetTickBufferMergeBudget(*budget)
+		}
+		if pacing := tick.Pacing; pacing != nil {
+			runtimeOpts = runtimeOpts.SetTickPacingOptions(pacing.RuntimeOptions())
+		}
 	}
 
 	runtimeOptsMgr := m3dbruntime.NewOptionsManager()
@@ -402,6 +466,7 @@ func Run(runOpts RunOptions) {
 		SetInstrumentOptions(opts.InstrumentOptions().
 			SetMetricsScope(scope.SubScope("database.fs"))).
 		SetFilePathPrefix(cfg.Filesystem.FilePathPrefixOrDefault()).
+		SetNamespaceFilePathPrefixes(cfg.Filesystem.NamespaceFilePathPrefixes).
 		SetNewFileMode(newFileMode).
 		SetNewDirectoryMode(newDirectoryMode).
 		SetWriterBufferSize(cfg.Filesystem.WriteBufferSizeOrDefault()).
@@ -414,7 +479,100 @@ func Run(runOpts RunOptions) {
 		SetTagEncoderPool(tagEncoderPool).
 		SetTagDecoderPool(tagDecoderPool).
 		SetForceIndexSummariesMmapMemory(cfg.Filesystem.ForceIndexSummariesMmapMemoryOrDefault()).
-		SetForceBloomFilterMmapMemory(cfg.Filesystem.ForceBloomFilterMmapMemoryOrDefault())
+		SetForceBloomFilterMmapMemory(cfg.Filesystem.ForceBloomFilterMmapMemoryOrDefault()).
+		SetShareIndexSummaries(cfg.Filesystem.ShareIndexSummariesOrDefault()).
+		SetSeekerDataFileMmapEnabled(cfg.Filesystem.SeekerDataFileMmapOrDefault()).
+		SetSequentialReadAheadEnabled(cfg.Filesystem.SequentialReadAheadOrDefault()).
+		SetDropPagesAfterWriteEnabled(cfg.Filesystem.DropPagesAfterWriteOrDefault()).
+		SetDataCompression(cfg.Filesystem.DataCompressionOrDefault()).
+		SetZstdCompressionLevel(cfg.Filesystem.ZstdCompressionLevelOrDefault()).
+		SetFlushConcurrency(cfg.Filesystem.FlushConcurrencyOrDefault())
+	if seekerMgrCfg := cfg.Filesystem.SeekerManager; seekerMgrCfg != nil {
+		fsopts = fsopts.
+			SetSeekerManagerCloseInterval(seekerMgrCfg.CloseInterval).
+			SetSeekerManagerMaxOpenedPerIteration(seekerMgrCfg.MaxOpenedPerIteration).
+			SetSeekerManagerMaxClosedPerIteration(seekerMgrCfg.MaxClosedPerIteration)
+	}
+	if objectStoreCfg := cfg.Filesystem.ObjectStore; objectStoreCfg != nil {
+		objectStore, err := objectStoreCfg.NewObjectStore()
+		if err != nil {
+			logger.Fatal("could not create fileset object store", zap.Error(err))
+		}
+		fsopts = fsopts.
+			SetObjectStore(objectStore).
+			SetObjectStoreLocalRetention(objectStoreCfg.LocalRetention)
+	}
+	if encryptionCfg := cfg.Filesystem.Encryption; encryptionCfg != nil {
+		dataKeyWrapper, err := fs.NewKeyFileDataKeyWrapper(
+			encryptionCfg.KeyID, encryptionCfg.KeyFile)
+		if err != nil {
+			logger.Fatal("could not create fileset data key wrapper", zap.Error(err))
+		}
+		fsopts = fsopts.SetDataKeyWrapper(dataKeyWrapper)
+	}
+	if scrubInterval := cfg.Filesystem.ScrubInterval; scrubInterval != nil {
+		opts = opts.SetFileSetScrubInterval(*scrubInterval)
+	}
+	if snapshotRetention := cfg.Filesystem.SnapshotRetention; snapshotRetention != nil {
+		opts = opts.
+			SetSnapshotRetentionCount(snapshotRetention.Count).
+			SetSnapshotRetentionPeriod(snapshotRetention.Period)
+	}
+	if memoryPressure := cfg.MemoryPressure; memoryPressure != nil {
+		opts = opts.SetMemoryPressureOptions(storage.MemoryPressureOptions{
+			CheckInterval:     memoryPressure.CheckInterval,
+			ElevatedHeapBytes: memoryPressure.ElevatedHeapBytes,
+			HighHeapBytes:     memoryPressure.HighHeapBytes,
+			CriticalHeapBytes: memoryPressure.CriticalHeapBytes,
+		})
+	}
+	if durabilityProbe := cfg.DurabilityProbe; durabilityProbe != nil {
+		opts = opts.SetDurabilityProbeOptions(storage.DurabilityProbeOptions{
+			Interval:       durabilityProbe.Interval,
+			Timeout:        durabilityProbe.Timeout,
+			FlushTimeout:   durabilityProbe.FlushTimeout,
+			SeriesIDPrefix: durabilityProbe.SeriesIDPrefix,
+		})
+	}
+	if hotSeries := cfg.HotSeries; hotSeries != nil {
+		opts = opts.SetHotSeriesOptions(storage.HotSeriesOptions{
+			Capacity:    hotSeries.Capacity,
+			SampleEvery: hotSeries.SampleEvery,
+			HalfLife:    hotSeries.HalfLife,
+		})
+	}
+	if cardinality := cfg.Cardinality; cardinality != nil {
+		opts = opts.SetCardinalityOptions(storage.CardinalityOptions{
+			Precision: cardinality.Precision,
+		})
+	}
+	if overload := cfg.Overload; overload != nil {
+		thresholds := storage.DefaultOverloadThresholds()
+		if overload.CommitLogQueueFactor != nil {
+			thresholds.CommitLogQueueFactor = *overload.CommitLogQueueFactor
+		}
+		thresholds.MaxFlushLag = overload.MaxFlushLag
+		thresholds.MaxInFlightQueries = overload.MaxInFlightQueries
+		if overload.MemoryPressure != "" {
+			level, err := storage.ParseMemoryPressureLevel(overload.MemoryPressure)
+			if err != nil {
+				logger.Fatal("could not parse overload memory pressure level", zap.Error(err))
+			}
+			thresholds.MemoryPressure = level
+		}
+		if err := thresholds.Validate(); err != nil {
+			logger.Fatal("invalid overload thresholds", zap.Error(err))
+		}
+		opts = opts.SetOverloadPolicy(storage.NewThresholdOverloadPolicy(thresholds))
+	}
+
+	if coldFlush := cfg.ColdFlush; coldFlush != nil {
+		coldFlushOpts := coldFlush.RuntimeOptions()
+		if err := coldFlushOpts.Validate(); err != nil {
+			logger.Fatal("invalid cold flush options", zap.Error(err))
+		}
+		opts = opts.SetColdFlushOptions(coldFlushOpts)
+	}
 
 	var commitLogQueueSize int
 	specified := cfg.CommitLog.Queue.Size
@@ -458,7 +616,8 @@ func Run(runOpts RunOptions) {
 		SetFlushSize(cfg.CommitLog.FlushMaxBytes).
 		SetFlushInterval(cfg.CommitLog.FlushEvery).
 		SetBacklogQueueSize(commitLogQueueSize).
-		SetBacklogQueueChannelSize(commitLogQueueChannelSize))
+		SetBacklogQueueChannelSize(commitLogQueueChannelSize).
+		SetRuntimeOptionsManager(runtimeOptsMgr))
 
 	// Setup the block retriever
 	switch seriesCachePolicy {
@@ -474,7 +633,8 @@ func Run(runOpts RunOptions) {
 			SetBlockLeaseManager(blockLeaseManager)
 		if blockRetrieveCfg := cfg.BlockRetrieve; blockRetrieveCfg != nil {
 			retrieverOpts = retrieverOpts.
-				SetFetchConcurrency(blockRetrieveCfg.FetchConcurrency)
+				SetFetchConcurrency(blockRetrieveCfg.FetchConcurrency).
+				SetIndexEntryCacheSize(blockRetrieveCfg.IndexEntryCacheSize)
 		}
 		blockRetrieverMgr := block.NewDatabaseBlockRetrieverManager(
 			func(md namespace.Metadata) (block.DatabaseBlockRetriever, error) {
@@ -537,6 +697,14 @@ func Run(runOpts RunOptions) {
 		SetTagEncoderPool(tagEncoderPool).
 		SetTagDecoderPool(tagDecoderPool)
 
+	if cfg.DecodeWorkerPool != nil {
+		ttopts = ttopts.SetDecodeWorkerPool(
+			ttnode.NewDecodeWorkerPool(cfg.DecodeWorkerPool.Size))
+		if c := cfg.DecodeWorkerPool.PerQueryConcurrency; c > 0 {
+			ttopts = ttopts.SetDecodeConcurrencyPerQuery(c)
+		}
+	}
+
 	// Start servers before constructing the DB so orchestration tools can check health endpoints
 	// before topology is set.
 	var (
@@ -578,6 +746,21 @@ func Run(runOpts RunOptions) {
 		logger.Fatal("could not initialize m3db topology", zap.Error(err))
 	}
 
+	if cfg.WriteForwarding != nil {
+		writeForwarder, err := ttnode.NewWriteForwarder(ttnode.WriteForwarderOptions{
+			HostID:            hostID,
+			Topology:          topo,
+			RequiredAcks:      cfg.WriteForwarding.RequiredAcks,
+			Timeout:           cfg.WriteForwarding.Timeout,
+			InstrumentOptions: iopts,
+		})
+		if err != nil {
+			logger.Fatal("could not create write forwarder", zap.Error(err))
+		}
+		defer writeForwarder.Close()
+		service.SetWriteForwarder(writeForwarder)
+	}
+
 	var protoEnabled bool
 	if cfg.Proto != nil && cfg.Proto.Enabled {
 		protoEnabled = true
@@ -636,6 +819,14 @@ func Run(runOpts RunOptions) {
 	clientAdminOpts := m3dbClient.Options().(client.AdminOptions)
 	kvWatchClientConsistencyLevels(envCfg.KVStore, logger,
 		clientAdminOpts, runtimeOptsMgr)
+	kvWatchCommitLogFsyncPolicy(envCfg.KVStore, logger,
+		runtimeOptsMgr.Get().CommitLogFsyncPolicy(), runtimeOptsMgr)
+	kvWatchWriteBlackoutWindows(envCfg.KVStore, logger,
+		runtimeOptsMgr.Get().WriteBlackoutWindows(), runtimeOptsMgr)
+	kvWatchReadBlockRules(envCfg.KVStore, logger,
+		runtimeOptsMgr.Get().ReadBlockRules(), runtimeOptsMgr)
+	kvWatchPeersBootstrapLimits(envCfg.KVStore, logger,
+		runtimeOptsMgr.Get().PeersBootstrapLimits(), runtimeOptsMgr)
 
 	opts = opts.SetRepairEnabled(false)
 	if cfg.Repair != nil {
@@ -730,6 +921,16 @@ func Run(runOpts RunOptions) {
 	// Now that we've initialized the database we can set it on the service.
 	service.SetDatabase(db)
 
+	var workloadGenerator workload.Generator
+	if workloadCfg := cfg.SyntheticWorkload; workloadCfg != nil {
+		workloadGenerator, err = workload.NewGenerator(db,
+			workloadCfg.NewOptions(iopts).
+				SetClockOptions(opts.ClockOptions()))
+		if err != nil {
+			logger.Fatal("could not create synthetic workload generator", zap.Error(err))
+		}
+	}
+
 	go func() {
 		if runOpts.BootstrapCh != nil {
 			// Notify on bootstrap chan if specified.
@@ -744,6 +945,14 @@ func Run(runOpts RunOptions) {
 		}
 		logger.Info("bootstrapped")
 
+		// Only generate synthetic load once bootstrapped so that the load
+		// measures the steady state of the storage path.
+		if workloadGenerator != nil {
+			if err := workloadGenerator.Start(); err != nil {
+				logger.Error("could not start synthetic workload generator", zap.Error(err))
+			}
+		}
+
 		// Only set the write new series limit after bootstrapping
 		kvWatchNewSeriesLimitPerShard(envCfg.KVStore, logger, topo,
 			runtimeOptsMgr, cfg.WriteNewSeriesLimitPerSecond)
@@ -754,6 +963,18 @@ func Run(runOpts RunOptions) {
 		InterruptCh: runOpts.InterruptCh,
 	})
 
+	if workloadGenerator != nil {
+		if err := workloadGenerator.Stop(); err != nil {
+			logger.Error("could not stop synthetic workload generator", zap.Error(err))
+		}
+	}
+
+	// Drain the database so that the buffered data does not need to be
+	// recovered from the commit log on restart.
+	if timeout := cfg.ShutdownDrainTimeout; timeout != nil && *timeout > 0 {
+		drainDatabase(db, *timeout, logger)
+	}
+
 	// Attempt graceful server close.
 	closedCh := make(chan struct{})
 	go func() {
@@ -774,6 +995,24 @@ func Run(runOpts RunOptions) {
 	}
 }
 
+func drainDatabase(db storage.Database, timeout time.Duration, logger *zap.Logger) {
+	goCtx, cancel := stdctx.WithTimeout(stdctx.Background(), timeout)
+	defer cancel()
+
+	ctx := context.NewContext()
+	ctx.SetGoContext(goCtx)
+	defer ctx.Close()
+
+	if err := db.Drain(ctx); err != nil {
+		state := db.DrainState()
+		logger.Error("could not drain database before close",
+			zap.Duration("timeout", timeout),
+			zap.Stringer("phase", state.Phase),
+			zap.Int64("inFlightQueries", state.InFlightQueries),
+			zap.Error(err))
+	}
+}
+
 func bgValidateProcessLimits(logger *zap.Logger) {
 	// If unable to validate process limits on the current configuration,
 	// do not run background validator task.
@@ -935,6 +1174,94 @@ func kvWatchClientConsistencyLevels(
 		})
 }
 
+func kvWatchCommitLogFsyncPolicy(
+	store kv.Store,
+	logger *zap.Logger,
+	defaultPolicy m3dbruntime.CommitLogFsyncPolicy,
+	runtimeOptsMgr m3dbruntime.OptionsManager,
+) {
+	kvWatchStringValue(store, logger,
+		kvconfig.CommitLogFsyncPolicyKey,
+		func(value string) error {
+			policy, err := m3dbruntime.ParseCommitLogFsyncPolicy(value)
+			if err != nil {
+				return err
+			}
+			return runtimeOptsMgr.Update(runtimeOptsMgr.Get().
+				SetCommitLogFsyncPolicy(policy))
+		},
+		func() error {
+			return runtimeOptsMgr.Update(runtimeOptsMgr.Get().
+				SetCommitLogFsyncPolicy(defaultPolicy))
+		})
+}
+
+func kvWatchWriteBlackoutWindows(
+	store kv.Store,
+	logger *zap.Logger,
+	defaultWindows m3dbruntime.WriteBlackoutWindows,
+	runtimeOptsMgr m3dbruntime.OptionsManager,
+) {
+	kvWatchStringValue(store, logger,
+		kvconfig.WriteBlackoutWindowsKey,
+		func(value string) error {
+			windows, err := m3dbruntime.ParseWriteBlackoutWindows(value)
+			if err != nil {
+				return err
+			}
+			return runtimeOptsMgr.Update(runtimeOptsMgr.Get().
+				SetWriteBlackoutWindows(windows))
+		},
+		func() error {
+			return runtimeOptsMgr.Update(runtimeOptsMgr.Get().
+				SetWriteBlackoutWindows(defaultWindows))
+		})
+}
+
+func kvWatchReadBlockRules(
+	store kv.Store,
+	logger *zap.Logger,
+	defaultRules m3dbruntime.ReadBlockRules,
+	runtimeOptsMgr m3dbruntime.OptionsManager,
+) {
+	kvWatchStringValue(store, logger,
+		kvconfig.ReadBlockRulesKey,
+		func(value string) error {
+			rules, err := m3dbruntime.ParseReadBlockRules(value)
+			if err != nil {
+				return err
+			}
+			return runtimeOptsMgr.Update(runtimeOptsMgr.Get().
+				SetReadBlockRules(rules))
+		},
+		func() error {
+			return runtimeOptsMgr.Update(runtimeOptsMgr.Get().
+				SetReadBlockRules(defaultRules))
+		})
+}
+
+func kvWatchPeersBootstrapLimits(
+	store kv.Store,
+	logger *zap.Logger,
+	defaultLimits m3dbruntime.PeersBootstrapLimits,
+	runtimeOptsMgr m3dbruntime.OptionsManager,
+) {
+	kvWatchStringValue(store, logger,
+		kvconfig.PeersBootstrapLimitsKey,
+		func(value string) error {
+			limits, err := m3dbruntime.ParsePeersBootstrapLimits(value)
+			if err != nil {
+				return err
+			}
+			return runtimeOptsMgr.Update(runtimeOptsMgr.Get().
+				SetPeersBootstrapLimits(limits))
+		},
+		func() error {
+			return runtimeOptsMgr.Update(runtimeOptsMgr.Get().
+				SetPeersBootstrapLimits(defaultLimits))
+		})
+}
+
 func kvWatchStringValue(
 	store kv.Store,
 	logger *zap.Logger,
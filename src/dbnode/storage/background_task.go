@@ -0,0 +1,213 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/x/ident"
+)
+
+// BackgroundTask is a background task of a namespace that can be paused.
+type BackgroundTask uint
+
+const (
+	// TickBackgroundTask is the periodic tick of a namespace.
+	TickBackgroundTask BackgroundTask = iota
+	// ColdFlushBackgroundTask is the cold flush of a namespace.
+	ColdFlushBackgroundTask
+	// RepairBackgroundTask is the repair of a namespace.
+	RepairBackgroundTask
+	// IndexCompactionBackgroundTask is the background compaction of the
+	// index segments of a namespace.
+	IndexCompactionBackgroundTask
+	// WarmFlushBackgroundTask is the warm flush of a namespace.
+	WarmFlushBackgroundTask
+)
+
+const (
+	// maxBackgroundTaskPauseDuration is the longest a background task can be
+	// paused for, so that a forgotten pause cannot stop a task indefinitely.
+	maxBackgroundTaskPauseDuration = 24 * time.Hour
+)
+
+var (
+	validBackgroundTasks = []BackgroundTask{
+		TickBackgroundTask,
+		ColdFlushBackgroundTask,
+		RepairBackgroundTask,
+		IndexCompactionBackgroundTask,
+		WarmFlushBackgroundTask,
+	}
+
+	// flushBackgroundTasks are the background tasks that can also be paused
+	// for individual shards of a namespace.
+	flushBackgroundTasks = []BackgroundTask{
+		WarmFlushBackgroundTask,
+		ColdFlushBackgroundTask,
+	}
+
+	// errBackgroundTaskPaused is returned by a namespace task that was not
+	// run because it is paused.
+	errBackgroundTaskPaused = errors.New("background task is paused")
+
+	errBackgroundTaskPauseDurationInvalid = fmt.Errorf(
+		"background task pause duration must be positive and at most %v",
+		maxBackgroundTaskPauseDuration)
+)
+
+// Validate validates that the background task is known.
+func (t BackgroundTask) Validate() error {
+	for _, task := range validBackgroundTasks {
+		if t == task {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid background task: %d", uint(t))
+}
+
+// ValidateFlush validates that the background task is a flush that can be
+// paused for individual shards.
+func (t BackgroundTask) ValidateFlush() error {
+	for _, task := range flushBackgroundTasks {
+		if t == task {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid flush background task '%s': valid tasks are %v",
+		t, flushBackgroundTasks)
+}
+
+func (t BackgroundTask) String() string {
+	switch t {
+	case TickBackgroundTask:
+		return "tick"
+	case ColdFlushBackgroundTask:
+		return "cold-flush"
+	case RepairBackgroundTask:
+		return "repair"
+	case IndexCompactionBackgroundTask:
+		return "index-compaction"
+	case WarmFlushBackgroundTask:
+		return "warm-flush"
+	}
+	return "unknown"
+}
+
+// ParseBackgroundTask parses a background task from its string representation.
+func ParseBackgroundTask(str string) (BackgroundTask, error) {
+	for _, task := range validBackgroundTasks {
+		if str == task.String() {
+			return task, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid background task '%s': valid tasks are %v",
+		str, validBackgroundTasks)
+}
+
+// PausedBackgroundTask is a background task of a namespace that is paused
+// until the pause is resumed or expires.
+type PausedBackgroundTask struct {
+	Namespace ident.ID
+	Task      BackgroundTask
+	Until     time.Time
+}
+
+// PausedShardFlush is a warm or cold flush of a shard of a namespace that is
+// paused until the pause is resumed or expires.
+type PausedShardFlush struct {
+	Namespace ident.ID
+	Shard     uint32
+	Task      BackgroundTask
+	Until     time.Time
+}
+
+// backgroundTaskPauses tracks the paused background tasks of a namespace, or
+// the paused flushes of a shard, a pause is treated as resumed as soon as it
+// expires.
+type backgroundTaskPauses struct {
+	sync.RWMutex
+
+	nowFn clock.NowFn
+	until map[BackgroundTask]time.Time
+}
+
+func newBackgroundTaskPauses(nowFn clock.NowFn) *backgroundTaskPauses {
+	return &backgroundTaskPauses{
+		nowFn: nowFn,
+		until: make(map[BackgroundTask]time.Time),
+	}
+}
+
+// Pause pauses the task until the given time, replacing any existing pause.
+func (p *backgroundTaskPauses) Pause(task BackgroundTask, until time.Time) {
+	p.Lock()
+	p.until[task] = until
+	p.Unlock()
+}
+
+// Resume resumes the task if it is paused.
+func (p *backgroundTaskPauses) Resume(task BackgroundTask) {
+	p.Lock()
+	delete(p.until, task)
+	p.Unlock()
+}
+
+// IsPaused returns whether the task is paused.
+func (p *backgroundTaskPauses) IsPaused(task BackgroundTask) bool {
+	p.RLock()
+	until, ok := p.until[task]
+	p.RUnlock()
+	return ok && p.nowFn().Before(until)
+}
+
+// Paused returns the paused tasks and when each pause expires, removing any
+// expired pauses.
+func (p *backgroundTaskPauses) Paused() map[BackgroundTask]time.Time {
+	p.Lock()
+	defer p.Unlock()
+
+	now := p.nowFn()
+	paused := make(map[BackgroundTask]time.Time, len(p.until))
+	for task, until := range p.until {
+		if !now.Before(until) {
+			delete(p.until, task)
+			continue
+		}
+		paused[task] = until
+	}
+	return paused
+}
+
+// indexBlockOptions returns the options for the index blocks of the
+// namespace so that their background compactions can be paused.
+func (p *backgroundTaskPauses) indexBlockOptions() index.BlockOptions {
+	return index.BlockOptions{
+		BackgroundCompactionPausedFn: func() bool {
+			return p.IsPaused(IndexCompactionBackgroundTask)
+		},
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBackgroundTask(t *testing.T) {
+	for _, task := range validBackgroundTasks {
+		parsed, err := ParseBackgroundTask(task.String())
+		require.NoError(t, err)
+		require.Equal(t, task, parsed)
+		require.NoError(t, parsed.Validate())
+	}
+
+	_, err := ParseBackgroundTask("flush")
+	require.Error(t, err)
+	require.Error(t, BackgroundTask(len(validBackgroundTasks)).Validate())
+}
+
+func TestBackgroundTaskValidateFlush(t *testing.T) {
+	require.NoError(t, WarmFlushBackgroundTask.ValidateFlush())
+	require.NoError(t, ColdFlushBackgroundTask.ValidateFlush())
+	require.Error(t, TickBackgroundTask.ValidateFlush())
+	require.Error(t, RepairBackgroundTask.ValidateFlush())
+}
+
+func TestBackgroundTaskPausesExpire(t *testing.T) {
+	var (
+		now    = time.Now()
+		pauses = newBackgroundTaskPauses(func() time.Time { return now })
+		until  = now.Add(time.Minute)
+	)
+
+	require.False(t, pauses.IsPaused(ColdFlushBackgroundTask))
+
+	pauses.Pause(ColdFlushBackgroundTask, until)
+	require.True(t, pauses.IsPaused(ColdFlushBackgroundTask))
+	require.False(t, pauses.IsPaused(TickBackgroundTask))
+	require.Equal(t, map[BackgroundTask]time.Time{
+		ColdFlushBackgroundTask: until,
+	}, pauses.Paused())
+
+	now = until
+	require.False(t, pauses.IsPaused(ColdFlushBackgroundTask))
+	require.Empty(t, pauses.Paused())
+	require.Empty(t, pauses.until)
+}
+
+func TestBackgroundTaskPausesResume(t *testing.T) {
+	var (
+		now    = time.Now()
+		pauses = newBackgroundTaskPauses(func() time.Time { return now })
+	)
+
+	pauses.Pause(IndexCompactionBackgroundTask, now.Add(time.Hour))
+	pausedFn := pauses.indexBlockOptions().BackgroundCompactionPausedFn
+	require.True(t, pausedFn())
+
+	pauses.Resume(IndexCompactionBackgroundTask)
+	require.False(t, pausedFn())
+	require.Empty(t, pauses.Paused())
+}
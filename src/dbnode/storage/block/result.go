@@ -24,6 +24,7 @@ import (
 	"sort"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/digest"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/x/ident"
 )
@@ -41,6 +42,30 @@ func NewFetchBlockResult(
 	}
 }
 
+// ComputeChecksums computes the checksums of the segments of each of the
+// block readers so that consumers can verify the segments after transfer.
+func (r *FetchBlockResult) ComputeChecksums() error {
+	checksums := make([]uint32, 0, len(r.Blocks))
+	for _, block := range r.Blocks {
+		seg, err := block.Segment()
+		if err != nil {
+			return err
+		}
+		checksums = append(checksums, digest.SegmentChecksum(seg))
+	}
+	r.Checksums = checksums
+	return nil
+}
+
+// Sources returns where the data of each of the block readers was read from.
+func (r FetchBlockResult) Sources() []xio.BlockSource {
+	sources := make([]xio.BlockSource, 0, len(r.Blocks))
+	for _, block := range r.Blocks {
+		sources = append(sources, block.Source)
+	}
+	return sources
+}
+
 type fetchBlockResultByTimeAscending []FetchBlockResult
 
 func (e fetchBlockResultByTimeAscending) Len() int           { return len(e) }
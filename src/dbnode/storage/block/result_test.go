@@ -26,6 +26,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/x/checked"
 	"github.com/m3db/m3/src/x/ident"
 
 	"github.com/stretchr/testify/assert"
@@ -44,6 +48,28 @@ func TestSortFetchBlockResultByTimeAscending(t *testing.T) {
 	require.Equal(t, expected, input)
 }
 
+func TestFetchBlockResultComputeChecksums(t *testing.T) {
+	segments := []ts.Segment{
+		ts.NewSegment(checked.NewBytes([]byte{1, 2}, nil),
+			checked.NewBytes([]byte{3}, nil), ts.FinalizeNone),
+		ts.NewSegment(checked.NewBytes([]byte{4, 5, 6}, nil), nil, ts.FinalizeNone),
+	}
+	blocks := make([]xio.BlockReader, 0, len(segments))
+	for _, seg := range segments {
+		blocks = append(blocks, xio.BlockReader{
+			SegmentReader: xio.NewSegmentReader(seg),
+		})
+	}
+
+	result := NewFetchBlockResult(time.Now(), blocks, nil)
+	require.Nil(t, result.Checksums)
+	require.NoError(t, result.ComputeChecksums())
+	require.Equal(t, []uint32{
+		digest.SegmentChecksum(segments[0]),
+		digest.SegmentChecksum(segments[1]),
+	}, result.Checksums)
+}
+
 func TestSortFetchBlockMetadataResultByTimeAscending(t *testing.T) {
 	now := time.Now()
 	inputs := []FetchBlockMetadataResult{
@@ -72,6 +72,9 @@ type FetchBlockResult struct {
 	Start  time.Time
 	Blocks []xio.BlockReader
 	Err    error
+	// Checksums are the checksums of the segments of each of the block readers,
+	// computed at the source of the blocks, or nil if they were not computed.
+	Checksums []uint32
 }
 
 // FetchBlocksMetadataOptions are options used when fetching blocks metadata.
@@ -271,6 +274,15 @@ type DatabaseBlockRetriever interface {
 	// to improve times when streaming a block.
 	CacheShardIndices(shards []uint32) error
 
+	// CacheShardIndicesForRange will pre-parse the indexes for given shards
+	// for only the blocks that overlap the range [start, end), such as the
+	// recent blocks that most queries read.
+	CacheShardIndicesForRange(shards []uint32, start, end time.Time) error
+
+	// UpdateNamespaceMetadata updates the namespace metadata the retriever
+	// was opened with, such as when the namespace retention changes.
+	UpdateNamespaceMetadata(nsMetadata namespace.Metadata) error
+
 	// Stream will stream a block for a given shard, id and start.
 	Stream(
 		ctx context.Context,
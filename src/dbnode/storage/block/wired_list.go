@@ -51,6 +51,7 @@ package block
 
 import (
 	"errors"
+	"math"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -86,6 +87,7 @@ type WiredList struct {
 	length        int
 	updatesChSize int
 	updatesCh     chan DatabaseBlock
+	evictCh       chan float64
 	doneCh        chan struct{}
 
 	metrics wiredListMetrics
@@ -96,6 +98,7 @@ type wiredListMetrics struct {
 	unwireable           tally.Gauge
 	limit                tally.Gauge
 	evicted              tally.Counter
+	evictedOnHint        tally.Counter
 	pushedBack           tally.Counter
 	inserted             tally.Counter
 	evictedAfterDuration tally.Timer
@@ -108,6 +111,8 @@ func newWiredListMetrics(scope tally.Scope) wiredListMetrics {
 		limit:      scope.Gauge("limit"),
 		// Incremented when a block is evicted
 		evicted: scope.Counter("evicted"),
+		// Incremented when a block is evicted due to an eviction hint
+		evictedOnHint: scope.Counter("evicted-on-hint"),
 		// Incremented when a block is "pushed back" in the list, I.E
 		// it was already in the list
 		pushedBack: scope.Counter("pushed-back"),
@@ -162,19 +167,28 @@ func (l *WiredList) Start() error {
 	}
 
 	l.updatesCh = make(chan DatabaseBlock, l.updatesChSize)
+	l.evictCh = make(chan float64, 1)
 	l.doneCh = make(chan struct{}, 1)
-	go func() {
+	go func(updatesCh <-chan DatabaseBlock, evictCh <-chan float64) {
 		i := 0
-		for v := range l.updatesCh {
-			l.processUpdateBlock(v)
-			if i%wiredListSampleGaugesEvery == 0 {
-				l.metrics.unwireable.Update(float64(l.length))
-				l.metrics.limit.Update(float64(atomic.LoadInt64(&l.maxWired)))
+		for {
+			select {
+			case v, ok := <-updatesCh:
+				if !ok {
+					l.doneCh <- struct{}{}
+					return
+				}
+				l.processUpdateBlock(v)
+				if i%wiredListSampleGaugesEvery == 0 {
+					l.metrics.unwireable.Update(float64(l.length))
+					l.metrics.limit.Update(float64(atomic.LoadInt64(&l.maxWired)))
+				}
+				i++
+			case fraction := <-evictCh:
+				l.processEvict(fraction)
 			}
-			i++
 		}
-		l.doneCh <- struct{}{}
-	}()
+	}(l.updatesCh, l.evictCh)
 
 	return nil
 }
@@ -192,6 +206,7 @@ func (l *WiredList) Stop() error {
 	<-l.doneCh
 
 	l.updatesCh = nil
+	l.evictCh = nil
 	close(l.doneCh)
 	l.doneCh = nil
 
@@ -219,6 +234,45 @@ func (l *WiredList) NonBlockingUpdate(v DatabaseBlock) bool {
 	}
 }
 
+// Evict hints to the wired list that the given fraction (between zero and one)
+// of the blocks currently in the list should be evicted, starting with the
+// least recently read blocks. The hint is processed asynchronously and will be
+// dropped if the wired list is not started or an earlier hint is still pending,
+// it returns whether the hint was accepted.
+func (l *WiredList) Evict(fraction float64) bool {
+	if fraction <= 0 {
+		return false
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+
+	l.Lock()
+	evictCh := l.evictCh
+	l.Unlock()
+	if evictCh == nil {
+		return false
+	}
+
+	select {
+	case evictCh <- fraction:
+		return true
+	default:
+		return false
+	}
+}
+
+// processEvict evicts the requested fraction of the blocks in the wired list.
+func (l *WiredList) processEvict(fraction float64) {
+	n := int(math.Ceil(float64(l.length) * fraction))
+	if n <= 0 {
+		return
+	}
+	evicted := l.evictUntil(l.length - n)
+	l.metrics.evictedOnHint.Inc(int64(evicted))
+	l.metrics.unwireable.Update(float64(l.length))
+}
+
 // processUpdateBlock inspects a block that has been modified or read recently
 // and determines what outcome its state should have on the wired list.
 func (l *WiredList) processUpdateBlock(v DatabaseBlock) {
@@ -263,9 +317,21 @@ func (l *WiredList) insertAfter(v, at DatabaseBlock) {
 		return
 	}
 
+	l.evictUntil(maxWired)
+}
+
+// evictUntil evicts blocks from the front of the wired list, I.E the least
+// recently read blocks, until the list holds at most target blocks and
+// returns the number of blocks evicted.
+func (l *WiredList) evictUntil(target int) int {
+	var (
+		now     = l.nowFn()
+		evicted = 0
+	)
+
 	// Try to unwire all blocks possible
 	bl := l.root.next()
-	for l.length > maxWired && bl != &l.root {
+	for l.length > target && bl != &l.root {
 		entry := bl.wiredListEntry()
 		if !entry.wasRetrievedFromDisk {
 			// This should never happen because processUpdateBlock performs the same
@@ -317,12 +383,15 @@ func (l *WiredList) insertAfter(v, at DatabaseBlock) {
 		}
 
 		l.metrics.evicted.Inc(1)
+		evicted++
 
 		enteredListAt := time.Unix(0, bl.enteredListAtUnixNano())
 		l.metrics.evictedAfterDuration.Record(now.Sub(enteredListAt))
 
 		bl = nextBl
 	}
+
+	return evicted
 }
 
 func (l *WiredList) remove(v DatabaseBlock) {
@@ -167,6 +167,51 @@ func TestWiredListRemovesUnwiredBlocks(t *testing.T) {
 	require.Equal(t, &l.root, l.root.prev())
 }
 
+func TestWiredListEvictsLeastRecentlyReadBlocks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	l, _ := newTestWiredList(nil, nil)
+
+	opts := testOptions.SetWiredList(l)
+
+	// Hints are dropped when the list is not processing.
+	require.False(t, l.Evict(0.5))
+
+	l.Start()
+
+	var blocks []*dbBlock
+	for i := 0; i < 4; i++ {
+		bl := newTestUnwireableBlock(ctrl, fmt.Sprintf("foo.%d", i), opts)
+		blocks = append(blocks, bl)
+	}
+
+	l.BlockingUpdate(blocks[0])
+	l.BlockingUpdate(blocks[1])
+	l.BlockingUpdate(blocks[2])
+	l.BlockingUpdate(blocks[3])
+	l.BlockingUpdate(blocks[0])
+
+	require.False(t, l.Evict(0))
+
+	l.Stop()
+
+	// Order due to LRU should be: 1, 2, 3, 0 so evicting half
+	// of the list should evict 1 and 2.
+	l.processEvict(0.5)
+
+	require.Equal(t, 2, l.length)
+	require.True(t, blocks[1].closed)
+	require.True(t, blocks[2].closed)
+	require.Equal(t, blocks[3], l.root.next())
+	require.Equal(t, blocks[0], l.root.next().next())
+
+	l.processEvict(1)
+
+	require.Equal(t, 0, l.length)
+	require.Equal(t, &l.root, l.root.next())
+}
+
 // wiredListTestWiredBlocksString is used to debug the order of the wired list
 func wiredListTestWiredBlocksString(l *WiredList) string { // nolint: unused
 	b := bytes.NewBuffer(nil)
@@ -28,6 +28,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	xerrors "github.com/m3db/m3/src/x/errors"
+	xtime "github.com/m3db/m3/src/x/time"
 
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
@@ -57,11 +58,18 @@ var (
 
 	// errBootstrapEnqueued raised when trying to bootstrap and bootstrap becomes enqueued.
 	errBootstrapEnqueued = errors.New("database bootstrapping enqueued bootstrap")
+
+	// errDatabaseNotBootstrappedToRebootstrap raised when trying to rebootstrap a shard before the database is bootstrapped.
+	errDatabaseNotBootstrappedToRebootstrap = errors.New("database is not yet bootstrapped to rebootstrap a shard")
 )
 
 type bootstrapManager struct {
 	sync.RWMutex
 
+	// runLock serializes the bootstrap runs with the shard rebootstraps so
+	// that a bootstrap never attempts to bootstrap a rebootstrapping shard.
+	runLock sync.Mutex
+
 	database                    database
 	mediator                    databaseMediator
 	opts                        Options
@@ -70,7 +78,11 @@ type bootstrapManager struct {
 	processProvider             bootstrap.ProcessProvider
 	state                       BootstrapState
 	hasPending                  bool
+	progress                    *bootstrapProgress
+	scope                       tally.Scope
 	status                      tally.Gauge
+	percentComplete             tally.Gauge
+	estimatedRemaining          tally.Gauge
 	lastBootstrapCompletionTime time.Time
 }
 
@@ -79,15 +91,27 @@ func newBootstrapManager(
 	mediator databaseMediator,
 	opts Options,
 ) databaseBootstrapManager {
-	scope := opts.InstrumentOptions().MetricsScope()
+	var (
+		scope           = opts.InstrumentOptions().MetricsScope()
+		nowFn           = opts.ClockOptions().NowFn()
+		processProvider = opts.BootstrapProcessProvider()
+		progress        = newBootstrapProgress(nowFn)
+	)
+	if processProvider != nil {
+		processProvider.SetProgressReporter(progress)
+	}
 	return &bootstrapManager{
-		database:        database,
-		mediator:        mediator,
-		opts:            opts,
-		log:             opts.InstrumentOptions().Logger(),
-		nowFn:           opts.ClockOptions().NowFn(),
-		processProvider: opts.BootstrapProcessProvider(),
-		status:          scope.Gauge("bootstrapped"),
+		database:           database,
+		mediator:           mediator,
+		opts:               opts,
+		log:                opts.InstrumentOptions().Logger(),
+		nowFn:              nowFn,
+		processProvider:    processProvider,
+		progress:           progress,
+		scope:              scope,
+		status:             scope.Gauge("bootstrapped"),
+		percentComplete:    scope.Gauge("bootstrap-percent-complete"),
+		estimatedRemaining: scope.Gauge("bootstrap-estimated-remaining-seconds"),
 	}
 }
 
@@ -117,6 +141,7 @@ func (m *bootstrapManager) Bootstrap() error {
 		return errBootstrapEnqueued
 	default:
 		m.state = Bootstrapping
+		m.progress.reset()
 	}
 	m.Unlock()
 
@@ -161,15 +186,112 @@ func (m *bootstrapManager) Bootstrap() error {
 	return multiErr.FinalError()
 }
 
+func (m *bootstrapManager) BootstrapProgress() BootstrapProgress {
+	m.RLock()
+	state := m.state
+	m.RUnlock()
+	return m.progress.snapshot(state)
+}
+
+func (m *bootstrapManager) RebootstrapShard(
+	ns databaseNamespace,
+	shard uint32,
+	window xtime.Range,
+) error {
+	if !m.IsBootstrapped() {
+		return errDatabaseNotBootstrappedToRebootstrap
+	}
+
+	m.runLock.Lock()
+	defer m.runLock.Unlock()
+
+	// NB(r): construct new instance of the bootstrap process to avoid
+	// state being kept around by bootstrappers.
+	process, err := m.processProvider.Provide()
+	if err != nil {
+		return err
+	}
+
+	var (
+		start     = m.nowFn()
+		logFields = []zap.Field{
+			zap.Stringer("namespace", ns.ID()),
+			zap.Uint32("shard", shard),
+			zap.Time("from", window.Start),
+			zap.Time("to", window.End),
+		}
+	)
+	m.log.Info("shard rebootstrap starting", logFields...)
+	err = ns.RebootstrapShard(process, shard, window)
+	logFields = append(logFields, zap.Duration("took", m.nowFn().Sub(start)))
+	if err != nil {
+		logFields = append(logFields, zap.Error(err))
+		m.log.Error("shard rebootstrap failed", logFields...)
+		return err
+	}
+	m.log.Info("shard rebootstrap completed", logFields...)
+	return nil
+}
+
+func (m *bootstrapManager) BootstrapDryRun() ([]NamespaceBootstrapDryRun, error) {
+	// NB: The dry run only determines the time ranges available from each
+	// bootstrapper and so does not need to wait for a running bootstrap.
+	process, err := m.processProvider.Provide()
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces, err := m.database.GetOwnedNamespaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		start   = m.nowFn()
+		results = make([]NamespaceBootstrapDryRun, 0, len(namespaces))
+	)
+	for _, ns := range namespaces {
+		res, err := ns.BootstrapDryRun(start, process)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, NamespaceBootstrapDryRun{
+			Namespace:   ns.ID().String(),
+			DataResult:  res.DataResult,
+			IndexResult: res.IndexResult,
+		})
+	}
+	return results, nil
+}
+
 func (m *bootstrapManager) Report() {
 	if m.IsBootstrapped() {
 		m.status.Update(1)
 	} else {
 		m.status.Update(0)
 	}
+
+	progress := m.BootstrapProgress()
+	m.percentComplete.Update(progress.PercentComplete)
+	var remaining time.Duration
+	if !progress.EstimatedCompletion.IsZero() {
+		remaining = progress.EstimatedCompletion.Sub(m.nowFn())
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	m.estimatedRemaining.Update(remaining.Seconds())
+	for _, ns := range progress.Namespaces {
+		m.scope.Tagged(map[string]string{"namespace": ns.Namespace}).
+			Gauge("bootstrap-percent-complete").
+			Update(ns.PercentComplete)
+	}
 }
 
 func (m *bootstrapManager) bootstrap() error {
+	m.runLock.Lock()
+	defer m.runLock.Unlock()
+
 	// NB(r): construct new instance of the bootstrap process to avoid
 	// state being kept around by bootstrappers.
 	process, err := m.processProvider.Provide()
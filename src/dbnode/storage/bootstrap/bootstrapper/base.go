@@ -89,7 +89,7 @@ func (b baseBootstrapper) BootstrapData(
 		return result.NewDataBootstrapResult(), nil
 	}
 	step := newBootstrapDataStep(namespace, b.src, b.next, opts)
-	err := b.runBootstrapStep(namespace, shardsTimeRanges, step)
+	err := b.runBootstrapStep(namespace, shardsTimeRanges, step, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -105,17 +105,77 @@ func (b baseBootstrapper) BootstrapIndex(
 		return result.NewIndexBootstrapResult(), nil
 	}
 	step := newBootstrapIndexStep(namespace, b.src, b.next, opts)
-	err := b.runBootstrapStep(namespace, shardsTimeRanges, step)
+	err := b.runBootstrapStep(namespace, shardsTimeRanges, step, opts)
 	if err != nil {
 		return nil, err
 	}
 	return step.result(), nil
 }
 
+func (b baseBootstrapper) AvailableData(
+	namespace namespace.Metadata,
+	shardsTimeRanges result.ShardTimeRanges,
+	opts bootstrap.RunOptions,
+) (bootstrap.AvailabilityResult, error) {
+	if shardsTimeRanges.IsEmpty() {
+		return bootstrap.AvailabilityResult{Unfulfilled: result.ShardTimeRanges{}}, nil
+	}
+	available, err := b.src.AvailableData(namespace, shardsTimeRanges, opts)
+	if err != nil {
+		return bootstrap.AvailabilityResult{}, err
+	}
+	remaining := shardsTimeRanges.Copy()
+	remaining.Subtract(available)
+	next, err := b.next.AvailableData(namespace, remaining, opts)
+	if err != nil {
+		return bootstrap.AvailabilityResult{}, err
+	}
+	return b.availabilityResult(available, next), nil
+}
+
+func (b baseBootstrapper) AvailableIndex(
+	namespace namespace.Metadata,
+	shardsTimeRanges result.ShardTimeRanges,
+	opts bootstrap.RunOptions,
+) (bootstrap.AvailabilityResult, error) {
+	if shardsTimeRanges.IsEmpty() {
+		return bootstrap.AvailabilityResult{Unfulfilled: result.ShardTimeRanges{}}, nil
+	}
+	available, err := b.src.AvailableIndex(namespace, shardsTimeRanges, opts)
+	if err != nil {
+		return bootstrap.AvailabilityResult{}, err
+	}
+	remaining := shardsTimeRanges.Copy()
+	remaining.Subtract(available)
+	next, err := b.next.AvailableIndex(namespace, remaining, opts)
+	if err != nil {
+		return bootstrap.AvailabilityResult{}, err
+	}
+	return b.availabilityResult(available, next), nil
+}
+
+// availabilityResult prepends the time ranges available from the source to
+// the availability of the next bootstrappers.
+func (b baseBootstrapper) availabilityResult(
+	available result.ShardTimeRanges,
+	next bootstrap.AvailabilityResult,
+) bootstrap.AvailabilityResult {
+	bootstrappers := make([]bootstrap.BootstrapperAvailability, 0, 1+len(next.Bootstrappers))
+	bootstrappers = append(bootstrappers, bootstrap.BootstrapperAvailability{
+		Bootstrapper: b.name,
+		Fulfilled:    available,
+	})
+	return bootstrap.AvailabilityResult{
+		Bootstrappers: append(bootstrappers, next.Bootstrappers...),
+		Unfulfilled:   next.Unfulfilled,
+	}
+}
+
 func (b baseBootstrapper) runBootstrapStep(
 	namespace namespace.Metadata,
 	totalRanges result.ShardTimeRanges,
 	step bootstrapStep,
+	opts bootstrap.RunOptions,
 ) error {
 	prepareResult, err := step.prepare(totalRanges)
 	if err != nil {
@@ -131,6 +191,12 @@ func (b baseBootstrapper) runBootstrapStep(
 	currRanges := prepareResult.currAvailable
 	nextRanges := totalRanges.Copy()
 	nextRanges.Subtract(currRanges)
+	// NB: Ranges checkpointed by a previous bootstrap have already been
+	// persisted so only the current source needs to read them.
+	checkpointed := opts.CheckpointedShardTimeRanges()
+	if checkpointed != nil {
+		nextRanges.Subtract(checkpointed)
+	}
 	if !nextRanges.IsEmpty() &&
 		b.Can(bootstrap.BootstrapParallel) &&
 		b.next.Can(bootstrap.BootstrapParallel) {
@@ -153,6 +219,7 @@ func (b baseBootstrapper) runBootstrapStep(
 		zap.Int("shards", len(currRanges)),
 	}
 	b.log.Info("bootstrapping from source starting", logFields...)
+	opts.ProgressReporter().ReportBootstrapperStarted(namespace, b.name)
 
 	nowFn := b.opts.ClockOptions().NowFn()
 	begin := nowFn()
@@ -178,6 +245,9 @@ func (b baseBootstrapper) runBootstrapStep(
 	fulfilledRanges.AddRanges(nextStatus.fulfilled)
 	unfulfilled := totalRanges.Copy()
 	unfulfilled.Subtract(fulfilledRanges)
+	if checkpointed != nil {
+		unfulfilled.Subtract(checkpointed)
+	}
 
 	step.mergeResults(unfulfilled)
 
@@ -188,6 +258,9 @@ func (b baseBootstrapper) runBootstrapStep(
 		// attempt the ranges we didn't even try to attempt
 		unattemptedNextRanges.AddRanges(nextRanges)
 	}
+	if checkpointed != nil {
+		unattemptedNextRanges.Subtract(checkpointed)
+	}
 
 	// If there are some time ranges the current bootstrapper could not fulfill,
 	// that we can attempt then pass it along to the next bootstrapper.
@@ -312,6 +312,44 @@ func TestBaseBootstrapperCurrentSomeUnfulfilled(t *testing.T) {
 	validateResult(t, expectedResult, res)
 }
 
+func TestBaseBootstrapperCurrentCheckpointedSkipsNext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	source, _, base := testBaseBootstrapper(t, ctrl)
+
+	testNs := testNsMetadata(t)
+	entries := []testBlockEntry{
+		{"foo", []string{"foo", "foe"}, testTargetStart},
+	}
+	targetRanges := testShardTimeRanges()
+	currUnfulfilled := xtime.NewRanges(xtime.Range{
+		Start: testTargetStart.Add(time.Hour),
+		End:   testTargetStart.Add(time.Hour * 2),
+	})
+	currResult := testResult(map[uint32]testShardResult{
+		testShard: {result: shardResult(entries...), unfulfilled: currUnfulfilled},
+	})
+	runOpts := testDefaultRunOpts.SetCheckpointedShardTimeRanges(
+		map[uint32]xtime.Ranges{testShard: currUnfulfilled})
+
+	source.EXPECT().
+		AvailableData(testNs, targetRanges, runOpts).
+		Return(targetRanges, nil)
+	source.EXPECT().
+		ReadData(testNs, targetRanges, runOpts).
+		Return(currResult, nil)
+
+	// The next bootstrapper is not asked for the checkpointed ranges
+	// and they are not reported as unfulfilled.
+	expectedResult := testResult(map[uint32]testShardResult{
+		testShard: {result: shardResult(entries...)},
+	})
+
+	res, err := base.BootstrapData(testNs, targetRanges, runOpts)
+	require.NoError(t, err)
+	validateResult(t, expectedResult, res)
+}
+
 func testBasebootstrapperNext(t *testing.T, nextUnfulfilled result.ShardTimeRanges) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -506,3 +544,61 @@ func TestBaseBootstrapperIndexHalfCurrentHalfNext(t *testing.T) {
 	assert.True(t, segSecond == second.Segments()[0])
 	assert.Equal(t, secondHalf, map[uint32]xtime.Ranges(second.Fulfilled()))
 }
+
+func TestBaseBootstrapperAvailableData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	source, next, base := testBaseBootstrapper(t, ctrl)
+	testNs := testNsMetadata(t)
+	targetRanges := testShardTimeRanges()
+	available := map[uint32]xtime.Ranges{testShard: xtime.NewRanges(xtime.Range{
+		Start: testTargetStart,
+		End:   testTargetStart.Add(time.Hour),
+	})}
+	remaining := map[uint32]xtime.Ranges{testShard: xtime.NewRanges(xtime.Range{
+		Start: testTargetStart.Add(time.Hour),
+		End:   testTargetStart.Add(2 * time.Hour),
+	})}
+
+	source.EXPECT().
+		AvailableData(testNs, shardTimeRangesMatcher{targetRanges}, testDefaultRunOpts).
+		Return(available, nil)
+	next.EXPECT().
+		AvailableData(testNs, shardTimeRangesMatcher{remaining}, testDefaultRunOpts).
+		Return(bootstrap.AvailabilityResult{Unfulfilled: remaining}, nil)
+
+	res, err := base.AvailableData(testNs, targetRanges, testDefaultRunOpts)
+	require.NoError(t, err)
+	require.Equal(t, []bootstrap.BootstrapperAvailability{{
+		Bootstrapper: "mock",
+		Fulfilled:    available,
+	}}, res.Bootstrappers)
+	require.True(t, res.Unfulfilled.Equal(remaining))
+}
+
+func TestBaseBootstrapperAvailableIndexChain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	source := bootstrap.NewMockSource(ctrl)
+	next, err := NewNoOpAllBootstrapperProvider().Provide()
+	require.NoError(t, err)
+	base, err := NewBaseBootstrapper("mock", source, result.NewOptions(), next)
+	require.NoError(t, err)
+
+	testNs := testNsMetadata(t)
+	targetRanges := testShardTimeRanges()
+	source.EXPECT().
+		AvailableIndex(testNs, shardTimeRangesMatcher{targetRanges}, testDefaultRunOpts).
+		Return(result.ShardTimeRanges{}, nil)
+
+	res, err := base.AvailableIndex(testNs, targetRanges, testDefaultRunOpts)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(res.Bootstrappers))
+	require.Equal(t, "mock", res.Bootstrappers[0].Bootstrapper)
+	require.True(t, res.Bootstrappers[0].Fulfilled.IsEmpty())
+	require.Equal(t, NoOpAllBootstrapperName, res.Bootstrappers[1].Bootstrapper)
+	require.True(t, res.Bootstrappers[1].Fulfilled.Equal(targetRanges))
+	require.True(t, res.Unfulfilled.IsEmpty())
+}
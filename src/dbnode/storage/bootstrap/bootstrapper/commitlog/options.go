@@ -51,6 +51,7 @@ type options struct {
 	mergeShardConcurrency                     int
 	runtimeOptsMgr                            runtime.OptionsManager
 	returnUnfulfilledForCorruptCommitLogFiles bool
+	snapshotOnly                              bool
 }
 
 // NewOptions creates new bootstrap options
@@ -136,3 +137,13 @@ func (o *options) SetReturnUnfulfilledForCorruptCommitLogFiles(value bool) Optio
 func (o *options) ReturnUnfulfilledForCorruptCommitLogFiles() bool {
 	return o.returnUnfulfilledForCorruptCommitLogFiles
 }
+
+func (o *options) SetSnapshotOnly(value bool) Options {
+	opts := *o
+	opts.snapshotOnly = value
+	return &opts
+}
+
+func (o *options) SnapshotOnly() bool {
+	return o.snapshotOnly
+}
@@ -24,7 +24,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/m3db/m3/src/cluster/shard"
@@ -40,6 +42,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/m3ninx/index/segment"
 	"github.com/m3db/m3/src/x/checked"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
@@ -56,13 +59,23 @@ var (
 )
 
 const (
-	encoderChanBufSize = 1000
+	// encoderBatchSize is the number of datapoints handed to an encoding
+	// worker at a time.
+	encoderBatchSize = 128
+	// encoderChanBufSize is the number of batches buffered per encoding worker.
+	encoderChanBufSize = 8
 )
 
 type newIteratorFn func(opts commitlog.IteratorOpts) (
 	iter commitlog.Iterator, corruptFiles []commitlog.ErrorWithPath, err error)
 type snapshotFilesFn func(filePathPrefix string, namespace ident.ID, shard uint32) (fs.FileSetFilesSlice, error)
 type newReaderFn func(bytesPool pool.CheckedBytesPool, opts fs.Options) (fs.DataFileSetReader, error)
+type readIndexSnapshotInfoFilesFn func(
+	filePathPrefix string,
+	namespace ident.ID,
+	readerBufferSize int,
+) []fs.ReadIndexInfoFileResult
+type readIndexSegmentsFn func(opts fs.ReadIndexSegmentsOptions) ([]segment.Segment, error)
 
 type commitLogSource struct {
 	opts Options
@@ -71,9 +84,11 @@ type commitLogSource struct {
 	// Filesystem inspection capture before node was started.
 	inspection fs.Inspection
 
-	newIteratorFn   newIteratorFn
-	snapshotFilesFn snapshotFilesFn
-	newReaderFn     newReaderFn
+	newIteratorFn                newIteratorFn
+	snapshotFilesFn              snapshotFilesFn
+	newReaderFn                  newReaderFn
+	readIndexSnapshotInfoFilesFn readIndexSnapshotInfoFilesFn
+	readIndexSegmentsFn          readIndexSegmentsFn
 
 	metrics commitLogSourceDataAndIndexMetrics
 }
@@ -100,9 +115,11 @@ func newCommitLogSource(opts Options, inspection fs.Inspection) bootstrap.Source
 
 		inspection: inspection,
 
-		newIteratorFn:   commitlog.NewIterator,
-		snapshotFilesFn: fs.SnapshotFiles,
-		newReaderFn:     fs.NewReader,
+		newIteratorFn:                commitlog.NewIterator,
+		snapshotFilesFn:              fs.SnapshotFiles,
+		newReaderFn:                  fs.NewReader,
+		readIndexSnapshotInfoFilesFn: fs.ReadIndexSnapshotInfoFiles,
+		readIndexSegmentsFn:          fs.ReadIndexSegments,
 
 		metrics: newCommitLogSourceDataAndIndexMetrics(scope),
 	}
@@ -142,7 +159,7 @@ func (s *commitLogSource) ReadData(
 		doneReadingData        = s.metrics.data.emitBootstrapping()
 		encounteredCorruptData = false
 		fsOpts                 = s.opts.CommitLogOptions().FilesystemOptions()
-		filePathPrefix         = fsOpts.FilePathPrefix()
+		filePathPrefix         = fsOpts.NamespaceFilePathPrefix(ns.ID())
 	)
 	defer doneReadingData()
 
@@ -168,7 +185,7 @@ func (s *commitLogSource) ReadData(
 	// Setup the commit log iterator.
 	var (
 		nsID              = ns.ID()
-		seriesSkipped     int
+		seriesSkipped     int64
 		datapointsSkipped int
 		datapointsRead    int
 
@@ -176,10 +193,12 @@ func (s *commitLogSource) ReadData(
 		// to be commitlog.ReadAllSeriesPredicate() if CacheSeriesMetadata() is enabled
 		// because we'll need to read data for all namespaces, not just the one we're currently
 		// bootstrapping.
+		// NB: The predicate is called concurrently by the iterator since it
+		// decodes commit log files in parallel.
 		readSeriesPredicate = func(id ident.ID, namespace ident.ID) bool {
 			shouldReadSeries := nsID.Equal(namespace)
 			if !shouldReadSeries {
-				seriesSkipped++
+				atomic.AddInt64(&seriesSkipped, 1)
 			}
 			return shouldReadSeries
 		}
@@ -188,12 +207,14 @@ func (s *commitLogSource) ReadData(
 			CommitLogOptions:      s.opts.CommitLogOptions(),
 			FileFilterPredicate:   readCommitLogPred,
 			SeriesFilterPredicate: readSeriesPredicate,
+			RecoverFromCorruption: true,
+			ReadFilter:            s.newReadFilter(nsID, shardsTimeRanges, blockSize),
 		}
 	)
 
 	defer func() {
 		s.log.Info("ReadData finished",
-			zap.Int("seriesSkipped", seriesSkipped),
+			zap.Int64("seriesSkipped", atomic.LoadInt64(&seriesSkipped)),
 			zap.Int("datapointsSkipped", datapointsSkipped),
 			zap.Int("datapointsRead", datapointsRead))
 	}()
@@ -221,9 +242,13 @@ func (s *commitLogSource) ReadData(
 		shardDataByShard = s.newShardDataByShard(shardsTimeRanges, numShards)
 	)
 
-	encoderChans := make([]chan encoderArg, numConc)
+	var (
+		encoderChans   = make([]chan []encoderArg, numConc)
+		encoderBatches = make([][]encoderArg, numConc)
+	)
 	for i := 0; i < numConc; i++ {
-		encoderChans[i] = make(chan encoderArg, encoderChanBufSize)
+		encoderChans[i] = make(chan []encoderArg, encoderChanBufSize)
+		encoderBatches[i] = make([]encoderArg, 0, encoderBatchSize)
 	}
 
 	// Spin up numConc background go-routines to handle M3TSZ encoding. This must
@@ -254,13 +279,21 @@ func (s *commitLogSource) ReadData(
 		// because it means that all accesses to the shardDataByShard slice don't need
 		// to be synchronized because each index belongs to a single shard so it
 		// will only be accessed serially from a single worker routine.
-		encoderChans[series.Shard%uint32(numConc)] <- encoderArg{
+		// Datapoints are handed to the workers in batches to amortize the
+		// cost of the channel operations.
+		workerNum := series.Shard % uint32(numConc)
+		encoderBatches[workerNum] = append(encoderBatches[workerNum], encoderArg{
 			series:     series,
 			dp:         dp,
 			unit:       unit,
 			annotation: annotation,
 			blockStart: dp.Timestamp.Truncate(blockSize),
+		})
+		if len(encoderBatches[workerNum]) < encoderBatchSize {
+			continue
 		}
+		encoderChans[workerNum] <- encoderBatches[workerNum]
+		encoderBatches[workerNum] = make([]encoderArg, 0, encoderBatchSize)
 	}
 
 	if iterErr := iter.Err(); iterErr != nil {
@@ -272,8 +305,15 @@ func (s *commitLogSource) ReadData(
 		s.metrics.data.corruptCommitlogFile.Inc(1)
 		encounteredCorruptData = true
 	}
+	if reports := iter.CorruptionReports(); len(reports) > 0 {
+		s.logAndEmitCorruptionReports(reports, true)
+		encounteredCorruptData = true
+	}
 
-	for _, encoderChan := range encoderChans {
+	for workerNum, encoderChan := range encoderChans {
+		if batch := encoderBatches[workerNum]; len(batch) > 0 {
+			encoderChan <- batch
+		}
 		close(encoderChan)
 	}
 
@@ -469,6 +509,15 @@ func (s *commitLogSource) bootstrapShardSnapshots(
 		}
 
 		for blockStart := currRange.Start.Truncate(blockSize); blockStart.Before(currRange.End); blockStart = blockStart.Add(blockSize) {
+			if s.opts.SnapshotOnly() {
+				shardResult, err = s.bootstrapShardBlockLatestValidSnapshot(
+					ns, shard, blockStart, metadataOnly, shardResult, blockSize, snapshotFiles)
+				if err != nil {
+					return shardResult, err
+				}
+				continue
+			}
+
 			snapshotsForBlock := mostRecentCompleteSnapshotByBlockShard[xtime.ToUnixNano(blockStart)]
 			mostRecentCompleteSnapshotForShardBlock := snapshotsForBlock[shard]
 
@@ -500,6 +549,65 @@ func (s *commitLogSource) bootstrapShardSnapshots(
 	return shardResult, nil
 }
 
+// bootstrapShardBlockLatestValidSnapshot adds the latest snapshot volume of
+// the shard and block that can be read in full to the shard result, falling
+// back to the earlier volumes of the block when a volume cannot be read.
+func (s *commitLogSource) bootstrapShardBlockLatestValidSnapshot(
+	ns namespace.Metadata,
+	shard uint32,
+	blockStart time.Time,
+	metadataOnly bool,
+	shardResult result.ShardResult,
+	blockSize time.Duration,
+	snapshotFiles fs.FileSetFilesSlice,
+) (result.ShardResult, error) {
+	var volumes fs.FileSetFilesSlice
+	for _, f := range snapshotFiles {
+		if f.ID.BlockStart.Equal(blockStart) && f.HasCompleteCheckpointFile() {
+			volumes = append(volumes, f)
+		}
+	}
+	sort.Slice(volumes, func(i, j int) bool {
+		return volumes[i].ID.VolumeIndex > volumes[j].ID.VolumeIndex
+	})
+
+	metrics := s.metrics.data
+	if metadataOnly {
+		metrics = s.metrics.index
+	}
+	for _, volume := range volumes {
+		blockResult, err := s.bootstrapShardBlockSnapshot(
+			ns, shard, blockStart, metadataOnly, nil, nil, blockSize,
+			snapshotFiles, volume)
+		if err != nil {
+			s.log.Warn("unable to read snapshot volume, falling back to an earlier volume",
+				zap.Uint32("shard", shard),
+				zap.Time("blockStart", blockStart),
+				zap.Int("volume", volume.ID.VolumeIndex),
+				zap.Error(err))
+			metrics.corruptSnapshotFile.Inc(1)
+			if blockResult != nil {
+				blockResult.Close()
+			}
+			continue
+		}
+
+		if blockResult == nil {
+			// The volume had no series.
+			return shardResult, nil
+		}
+		if shardResult == nil {
+			return blockResult, nil
+		}
+		shardResult.AddResult(blockResult)
+		return shardResult, nil
+	}
+
+	s.log.Debug("no valid snapshots for shard and blockStart",
+		zap.Uint32("shard", shard), zap.Time("blockStart", blockStart))
+	return shardResult, nil
+}
+
 func (s *commitLogSource) bootstrapShardBlockSnapshot(
 	ns namespace.Metadata,
 	shard uint32,
@@ -636,6 +744,36 @@ func (s *commitLogSource) bootstrapShardBlockSnapshot(
 	return shardResult, nil
 }
 
+// newReadFilter returns a filter for the writes to the shards and blocks being
+// bootstrapped so that commit log sections without any can be skipped.
+func (s *commitLogSource) newReadFilter(
+	nsID ident.ID,
+	shardsTimeRanges result.ShardTimeRanges,
+	blockSize time.Duration,
+) commitlog.ReadFilter {
+	shards := make([]uint32, 0, len(shardsTimeRanges))
+	for shard, ranges := range shardsTimeRanges {
+		if !ranges.IsEmpty() {
+			shards = append(shards, shard)
+		}
+	}
+
+	// Writes are read if their block overlaps a range to bootstrap, so extend
+	// the range to block boundaries.
+	start, end := shardsTimeRanges.MinMax()
+	start = start.Truncate(blockSize)
+	if truncated := end.Truncate(blockSize); !truncated.Equal(end) {
+		end = truncated.Add(blockSize)
+	}
+
+	return commitlog.ReadFilter{
+		Namespaces: []ident.ID{nsID},
+		Shards:     shards,
+		Start:      start,
+		End:        end,
+	}
+}
+
 func (s *commitLogSource) newReadCommitlogPredAndMostRecentSnapshotByBlockShard(
 	ns namespace.Metadata,
 	shardsTimeRanges result.ShardTimeRanges,
@@ -666,6 +804,16 @@ func (s *commitLogSource) newReadCommitlogPredAndMostRecentSnapshotByBlockShard(
 		}
 	}
 
+	if s.opts.SnapshotOnly() {
+		// None of the commit log files are read, including any corrupt files
+		// so that they cannot cause the bootstrap to return unfulfilled.
+		s.log.Info("bootstrapping from snapshots only, skipping the commit log",
+			zap.Stringer("namespace", ns.ID()))
+		return func(commitlog.FileFilterInfo) bool {
+			return false
+		}, mostRecentCompleteSnapshotByBlockShard, nil
+	}
+
 	// TODO(rartoul): Refactor this to take the SnapshotMetadata files into account to reduce
 	// the number of commitlog files that need to be read.
 	return func(f commitlog.FileFilterInfo) bool {
@@ -696,7 +844,7 @@ func (s *commitLogSource) startM3TSZEncodingWorker(
 	ns namespace.Metadata,
 	runOpts bootstrap.RunOptions,
 	workerNum int,
-	ec <-chan encoderArg,
+	ec <-chan []encoderArg,
 	unmerged []shardData,
 	encoderPool encoding.EncoderPool,
 	workerErrs []int,
@@ -704,66 +852,68 @@ func (s *commitLogSource) startM3TSZEncodingWorker(
 	wg *sync.WaitGroup,
 ) {
 	nsCtx := namespace.NewContextFrom(ns)
-	for arg := range ec {
-		var (
-			series     = arg.series
-			dp         = arg.dp
-			unit       = arg.unit
-			annotation = arg.annotation
-			blockStart = arg.blockStart
-		)
-
-		var (
-			unmergedShard      = unmerged[series.Shard].series
-			unmergedSeries, ok = unmergedShard.Get(series.ID)
-		)
-		if !ok {
-			unmergedSeries = metadataAndEncodersByTime{
-				id:       series.ID,
-				tags:     series.Tags,
-				encoders: make(map[xtime.UnixNano][]encoder)}
-			// Have to use unsafe because we don't want to copy the IDs we put
-			// into this map because its lifecycle is much shorter than that of
-			// the IDs we're putting into it so copying would waste too much
-			// memory unnecessarily, and we don't want to finalize the IDs for the
-			// same reason.
-			unmergedShard.SetUnsafe(
-				series.ID, unmergedSeries,
-				SetUnsafeOptions{NoCopyKey: true, NoFinalizeKey: true})
-		}
+	for batch := range ec {
+		for _, arg := range batch {
+			var (
+				series     = arg.series
+				dp         = arg.dp
+				unit       = arg.unit
+				annotation = arg.annotation
+				blockStart = arg.blockStart
+			)
 
-		var (
-			err            error
-			blockStartNano = xtime.ToUnixNano(blockStart)
-			unmergedBlock  = unmergedSeries.encoders[blockStartNano]
-			wroteExisting  = false
-		)
-		for i := range unmergedBlock {
-			// TODO(r): Write unit test to ensure that different values that arrive
-			// later in the commit log will upsert the previous value when bootstrapping
-			// Tracking with issue: https://github.com/m3db/m3/issues/898
-			if unmergedBlock[i].lastWriteAt.Before(dp.Timestamp) {
-				unmergedBlock[i].lastWriteAt = dp.Timestamp
-				err = unmergedBlock[i].enc.Encode(dp, unit, annotation)
-				wroteExisting = true
-				break
+			var (
+				unmergedShard      = unmerged[series.Shard].series
+				unmergedSeries, ok = unmergedShard.Get(series.ID)
+			)
+			if !ok {
+				unmergedSeries = metadataAndEncodersByTime{
+					id:       series.ID,
+					tags:     series.Tags,
+					encoders: make(map[xtime.UnixNano][]encoder)}
+				// Have to use unsafe because we don't want to copy the IDs we put
+				// into this map because its lifecycle is much shorter than that of
+				// the IDs we're putting into it so copying would waste too much
+				// memory unnecessarily, and we don't want to finalize the IDs for the
+				// same reason.
+				unmergedShard.SetUnsafe(
+					series.ID, unmergedSeries,
+					SetUnsafeOptions{NoCopyKey: true, NoFinalizeKey: true})
 			}
-		}
-		if !wroteExisting {
-			enc := encoderPool.Get()
-			enc.Reset(blockStart, blopts.DatabaseBlockAllocSize(), nsCtx.Schema)
 
-			err = enc.Encode(dp, unit, annotation)
-			if err == nil {
-				unmergedBlock = append(unmergedBlock, encoder{
-					lastWriteAt: dp.Timestamp,
-					enc:         enc,
-				})
-				unmergedSeries.encoders[blockStartNano] = unmergedBlock
+			var (
+				err            error
+				blockStartNano = xtime.ToUnixNano(blockStart)
+				unmergedBlock  = unmergedSeries.encoders[blockStartNano]
+				wroteExisting  = false
+			)
+			for i := range unmergedBlock {
+				// TODO(r): Write unit test to ensure that different values that arrive
+				// later in the commit log will upsert the previous value when bootstrapping
+				// Tracking with issue: https://github.com/m3db/m3/issues/898
+				if unmergedBlock[i].lastWriteAt.Before(dp.Timestamp) {
+					unmergedBlock[i].lastWriteAt = dp.Timestamp
+					err = unmergedBlock[i].enc.Encode(dp, unit, annotation)
+					wroteExisting = true
+					break
+				}
+			}
+			if !wroteExisting {
+				enc := encoderPool.Get()
+				enc.Reset(blockStart, blopts.DatabaseBlockAllocSize(), nsCtx.Schema)
+
+				err = enc.Encode(dp, unit, annotation)
+				if err == nil {
+					unmergedBlock = append(unmergedBlock, encoder{
+						lastWriteAt: dp.Timestamp,
+						enc:         enc,
+					})
+					unmergedSeries.encoders[blockStartNano] = unmergedBlock
+				}
+			}
+			if err != nil {
+				workerErrs[workerNum]++
 			}
-		}
-		if err != nil {
-			workerErrs[workerNum]++
 		}
 	}
 	wg.Done()
@@ -1158,7 +1308,7 @@ func (s *commitLogSource) ReadIndex(
 		doneReadingIndex       = s.metrics.index.emitBootstrapping()
 		encounteredCorruptData = false
 		fsOpts                 = s.opts.CommitLogOptions().FilesystemOptions()
-		filePathPrefix         = fsOpts.FilePathPrefix()
+		filePathPrefix         = fsOpts.NamespaceFilePathPrefix(ns.ID())
 	)
 	defer doneReadingIndex()
 
@@ -1205,9 +1355,15 @@ func (s *commitLogSource) ReadIndex(
 			CommitLogOptions:      s.opts.CommitLogOptions(),
 			FileFilterPredicate:   readCommitLogPredicate,
 			SeriesFilterPredicate: readSeriesPredicate,
+			RecoverFromCorruption: true,
+			ReadFilter:            s.newReadFilter(ns.ID(), shardsTimeRanges, indexBlockSize),
 		}
 	)
 
+	// Load the latest index snapshots first so that only the series not
+	// already in them need to be indexed.
+	s.bootstrapIndexSnapshots(ns, shardsTimeRanges, indexResults)
+
 	// Start by reading any available snapshot files.
 	for shard, tr := range shardsTimeRanges {
 		shardResult, err := s.bootstrapShardSnapshots(
@@ -1259,6 +1415,10 @@ func (s *commitLogSource) ReadIndex(
 		encounteredCorruptData = true
 		s.metrics.index.corruptCommitlogFile.Inc(1)
 	}
+	if reports := iter.CorruptionReports(); len(reports) > 0 {
+		s.logAndEmitCorruptionReports(reports, false)
+		encounteredCorruptData = true
+	}
 
 	// If all successful then we mark each index block as fulfilled
 	for _, block := range indexResult.IndexResults() {
@@ -1297,6 +1457,85 @@ func (s *commitLogSource) ReadIndex(
 	return indexResult, nil
 }
 
+// bootstrapIndexSnapshots adds the segments of the latest index snapshot of
+// each index block being bootstrapped to the index results. Snapshots that
+// fail to read are skipped since the commit log is indexed in their place.
+func (s *commitLogSource) bootstrapIndexSnapshots(
+	ns namespace.Metadata,
+	shardsTimeRanges result.ShardTimeRanges,
+	indexResults result.IndexResults,
+) {
+	var (
+		fsOpts         = s.opts.CommitLogOptions().FilesystemOptions()
+		indexBlockSize = ns.Options().IndexOptions().BlockSize()
+		infoFiles      = s.readIndexSnapshotInfoFilesFn(
+			fsOpts.NamespaceFilePathPrefix(ns.ID()), ns.ID(),
+			fsOpts.InfoReaderBufferSize())
+		latest = make(map[xtime.UnixNano]fs.ReadIndexInfoFileResult)
+	)
+	for _, infoFile := range infoFiles {
+		if err := infoFile.Err.Error(); err != nil {
+			s.log.Warn("skipping unreadable index snapshot info file",
+				zap.String("filepath", infoFile.Err.Filepath()),
+				zap.Error(err))
+			continue
+		}
+
+		blockStart := xtime.UnixNano(infoFile.Info.BlockStart)
+		blockRange := xtime.Range{
+			Start: blockStart.ToTime(),
+			End:   blockStart.ToTime().Add(indexBlockSize),
+		}
+		if !s.shouldBootstrapIndexSnapshot(infoFile.Info.Shards, blockRange, shardsTimeRanges) {
+			continue
+		}
+		if curr, ok := latest[blockStart]; ok &&
+			curr.ID.VolumeIndex > infoFile.ID.VolumeIndex {
+			continue
+		}
+		latest[blockStart] = infoFile
+	}
+
+	for blockStart, infoFile := range latest {
+		segs, err := s.readIndexSegmentsFn(fs.ReadIndexSegmentsOptions{
+			ReaderOptions: fs.IndexReaderOpenOptions{
+				Identifier:  infoFile.ID,
+				FileSetType: persist.FileSetSnapshotType,
+			},
+			FilesystemOptions: fsOpts,
+		})
+		if err != nil {
+			s.log.Warn("unable to read index snapshot, indexing commit log instead",
+				zap.Time("blockStart", blockStart.ToTime()),
+				zap.Int("volume", infoFile.ID.VolumeIndex),
+				zap.Error(err))
+			s.metrics.index.corruptSnapshotFile.Inc(1)
+			continue
+		}
+		indexResults.Add(result.NewIndexSnapshotBlock(blockStart.ToTime(), segs, nil))
+	}
+}
+
+// shouldBootstrapIndexSnapshot returns whether an index snapshot of the given
+// shards covers any of the ranges being bootstrapped. Snapshots of shards not
+// being bootstrapped are skipped as they contain series of shards that are no
+// longer owned.
+func (s *commitLogSource) shouldBootstrapIndexSnapshot(
+	shards []uint32,
+	blockRange xtime.Range,
+	shardsTimeRanges result.ShardTimeRanges,
+) bool {
+	var overlaps bool
+	for _, shard := range shards {
+		ranges, ok := shardsTimeRanges[shard]
+		if !ok {
+			return false
+		}
+		overlaps = overlaps || ranges.Overlaps(blockRange)
+	}
+	return overlaps
+}
+
 // If we encountered any corrupt data and there is a possibility of the
 // peers bootstrapper being able to correct it, we want to mark the entire range
 // as unfulfilled so the peers bootstrapper can attempt a repair, but keep
@@ -1358,6 +1597,21 @@ func (s commitLogSource) maybeAddToIndex(
 		return nil
 	}
 
+	// Skip the series already contained by the index snapshot of the block.
+	blockStartNanos := xtime.ToUnixNano(blockStart.Truncate(indexBlockSize))
+	for _, seg := range indexResults[blockStartNanos].Segments() {
+		if seg == segment {
+			continue
+		}
+		exists, err := seg.ContainsID(id.Bytes())
+		if err != nil {
+			return err
+		}
+		if exists {
+			return nil
+		}
+	}
+
 	// We can use the NoClone variant here because the IDs/Tags read from the commit log files
 	// by the ReadIndex() method won't be finalized because this code path doesn't finalize them.
 	d, err := convert.FromMetricNoClone(id, tags)
@@ -1381,6 +1635,25 @@ func (s *commitLogSource) logAndEmitCorruptFiles(
 	}
 }
 
+func (s *commitLogSource) logAndEmitCorruptionReports(
+	reports []commitlog.CorruptionReport, isData bool) {
+	for _, r := range reports {
+		s.log.Error("skipped corrupt commit log range",
+			zap.String("file", r.FilePath),
+			zap.Int64("startOffset", r.StartOffset),
+			zap.Int64("endOffset", r.EndOffset),
+			zap.Time("after", r.After),
+			zap.Time("before", r.Before),
+			zap.Int("entriesWithoutMetadata", r.EntriesWithoutMetadata),
+			zap.Error(r.Err))
+		if isData {
+			s.metrics.data.corruptCommitlogRange.Inc(1)
+		} else {
+			s.metrics.index.corruptCommitlogRange.Inc(1)
+		}
+	}
+}
+
 // The commitlog bootstrapper determines availability primarily by checking if the
 // origin host has ever reached the "Available" state for the shard that is being
 // bootstrapped. If not, then it can't provide data for that shard because it doesn't
@@ -1549,8 +1822,10 @@ func newCommitLogSourceDataAndIndexMetrics(scope tally.Scope) commitLogSourceDat
 }
 
 type commitLogSourceMetrics struct {
-	corruptCommitlogFile tally.Counter
-	bootstrapping        tally.Gauge
+	corruptCommitlogFile  tally.Counter
+	corruptCommitlogRange tally.Counter
+	corruptSnapshotFile   tally.Counter
+	bootstrapping         tally.Gauge
 }
 
 type gaugeLoopCloserFn func()
@@ -1575,7 +1850,9 @@ func (m commitLogSourceMetrics) emitBootstrapping() gaugeLoopCloserFn {
 
 func newCommitLogSourceMetrics(scope tally.Scope) commitLogSourceMetrics {
 	return commitLogSourceMetrics{
-		corruptCommitlogFile: scope.SubScope("commitlog").Counter("corrupt"),
-		bootstrapping:        scope.SubScope("status").Gauge("bootstrapping"),
+		corruptCommitlogFile:  scope.SubScope("commitlog").Counter("corrupt"),
+		corruptCommitlogRange: scope.SubScope("commitlog").Counter("corrupt-ranges"),
+		corruptSnapshotFile:   scope.SubScope("snapshot").Counter("corrupt"),
+		bootstrapping:         scope.SubScope("status").Gauge("bootstrapping"),
 	}
 }
@@ -441,6 +441,123 @@ func testItMergesSnapshotsAndCommitLogs(t *testing.T, opts Options, md namespace
 		expectedValues, blockSize, res.ShardResults(), opts))
 }
 
+func TestReadSnapshotOnlyFallsBackToEarlierSnapshotVolume(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		opts      = testDefaultOpts.SetSnapshotOnly(true)
+		md        = testNsMetadata(t)
+		nsCtx     = namespace.NewContextFrom(md)
+		src       = newCommitLogSource(opts, fs.Inspection{}).(*commitLogSource)
+		blockSize = md.Options().RetentionOptions().BlockSize()
+		now       = time.Now()
+		start     = now.Truncate(blockSize).Add(-blockSize)
+		end       = now.Truncate(blockSize)
+		ranges    = xtime.Ranges{}
+
+		foo = ts.Series{Namespace: nsCtx.ID, Shard: 0, ID: ident.StringID("foo")}
+	)
+	ranges = ranges.AddRange(xtime.Range{
+		Start: start,
+		End:   end,
+	})
+
+	src.newIteratorFn = func(iterOpts commitlog.IteratorOpts) (commitlog.Iterator, []commitlog.ErrorWithPath, error) {
+		// No commit log files should be read when bootstrapping from snapshots only.
+		require.False(t, iterOpts.FileFilterPredicate(commitlog.FileFilterInfo{}))
+		require.False(t, iterOpts.FileFilterPredicate(commitlog.FileFilterInfo{IsCorrupt: true}))
+		return newTestCommitLogIterator(nil, nil), nil, nil
+	}
+	src.snapshotFilesFn = func(filePathPrefix string, namespace ident.ID, shard uint32) (fs.FileSetFilesSlice, error) {
+		var files fs.FileSetFilesSlice
+		for _, volume := range []int{0, 1} {
+			files = append(files, fs.FileSetFile{
+				ID: fs.FileSetFileIdentifier{
+					Namespace:   namespace,
+					BlockStart:  start,
+					Shard:       shard,
+					VolumeIndex: volume,
+				},
+				// Make sure path passes the "is snapshot" check in SnapshotTimeAndID method.
+				AbsoluteFilepaths:               []string{"snapshots/checkpoint"},
+				CachedHasCompleteCheckpointFile: fs.EvalTrue,
+				CachedSnapshotTime:              start.Add(time.Duration(volume+1) * time.Minute),
+			})
+		}
+		return files, nil
+	}
+
+	snapshotValues := []testValue{
+		{foo, start.Add(1 * time.Minute), 1.0, xtime.Nanosecond, nil},
+	}
+	encoder := opts.ResultOptions().DatabaseBlockOptions().EncoderPool().Get()
+	encoder.Reset(snapshotValues[0].t, 10, nsCtx.Schema)
+	for _, value := range snapshotValues {
+		dp := ts.Datapoint{
+			Timestamp: value.t,
+			Value:     value.v,
+		}
+		require.NoError(t, encoder.Encode(dp, value.u, value.a))
+	}
+
+	reader, ok := encoder.Stream(encoding.StreamOptions{})
+	require.True(t, ok)
+
+	seg, err := reader.Segment()
+	require.NoError(t, err)
+
+	bytes := make([]byte, seg.Len())
+	_, err = reader.Read(bytes)
+	require.NoError(t, err)
+
+	// The latest volume fails to read so the bootstrapper should fall back
+	// to the earlier volume.
+	mockReader := fs.NewMockDataFileSetReader(ctrl)
+	mockReader.EXPECT().Open(fs.ReaderOpenOptionsMatcher{
+		ID: fs.FileSetFileIdentifier{
+			Namespace:   nsCtx.ID,
+			BlockStart:  start,
+			Shard:       0,
+			VolumeIndex: 1,
+		},
+		FileSetType: persist.FileSetSnapshotType,
+	}).Return(fmt.Errorf("corrupt volume"))
+	mockReader.EXPECT().Open(fs.ReaderOpenOptionsMatcher{
+		ID: fs.FileSetFileIdentifier{
+			Namespace:   nsCtx.ID,
+			BlockStart:  start,
+			Shard:       0,
+			VolumeIndex: 0,
+		},
+		FileSetType: persist.FileSetSnapshotType,
+	}).Return(nil)
+	mockReader.EXPECT().Entries().Return(1).AnyTimes()
+	mockReader.EXPECT().Close().Return(nil).AnyTimes()
+	mockReader.EXPECT().Read().Return(
+		foo.ID,
+		ident.EmptyTagIterator,
+		checked.NewBytes(bytes, nil),
+		digest.Checksum(bytes),
+		nil,
+	)
+	mockReader.EXPECT().Read().Return(nil, nil, nil, uint32(0), io.EOF)
+
+	src.newReaderFn = func(bytesPool pool.CheckedBytesPool, opts fs.Options) (fs.DataFileSetReader, error) {
+		return mockReader, nil
+	}
+
+	targetRanges := result.ShardTimeRanges{0: ranges}
+	res, err := src.ReadData(md, targetRanges, testDefaultRunOpts)
+	require.NoError(t, err)
+	require.NotNil(t, res)
+	require.Equal(t, 1, len(res.ShardResults()))
+	require.Equal(t, 0, len(res.Unfulfilled()))
+
+	require.NoError(t, verifyShardResultsAreCorrect(nsCtx,
+		snapshotValues, blockSize, res.ShardResults(), opts))
+}
+
 type setAnnotation func([]testValue) []testValue
 type annotationEqual func([]byte, []byte) bool
 
@@ -719,10 +836,11 @@ func verifyBlocksAreEqual(nsCtx namespace.Context, opts Options, expectedAllBloc
 }
 
 type testCommitLogIterator struct {
-	values []testValue
-	idx    int
-	err    error
-	closed bool
+	values  []testValue
+	idx     int
+	err     error
+	reports []commitlog.CorruptionReport
+	closed  bool
 }
 
 type testValuesByTime []testValue
@@ -755,6 +873,10 @@ func (i *testCommitLogIterator) Err() error {
 	return i.err
 }
 
+func (i *testCommitLogIterator) CorruptionReports() []commitlog.CorruptionReport {
+	return i.reports
+}
+
 func (i *testCommitLogIterator) Close() {
 	i.closed = true
 }
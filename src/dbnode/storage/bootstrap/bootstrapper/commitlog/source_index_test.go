@@ -25,11 +25,16 @@ import (
 	"testing"
 	"time"
 
+	indexpb "github.com/m3db/m3/src/dbnode/generated/proto/index"
+	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/index/segment"
+	"github.com/m3db/m3/src/m3ninx/index/segment/mem"
 	"github.com/m3db/m3/src/x/ident"
 	xtime "github.com/m3db/m3/src/x/time"
 
@@ -270,6 +275,113 @@ func TestBootstrapIndexEmptyShardTimeRanges(t *testing.T) {
 	require.Equal(t, 0, len(res.Unfulfilled()))
 }
 
+type testReadInfoFileResultError struct {
+	err error
+}
+
+func (e testReadInfoFileResultError) Error() error     { return e.err }
+func (e testReadInfoFileResultError) Filepath() string { return "" }
+
+// testImmutableSegment hides the mutable methods of a segment the same as
+// the segments read from disk.
+type testImmutableSegment struct {
+	segment.Segment
+}
+
+func TestBootstrapIndexSnapshots(t *testing.T) {
+	var (
+		opts             = testDefaultOpts
+		src              = newCommitLogSource(opts, fs.Inspection{}).(*commitLogSource)
+		dataBlockSize    = 2 * time.Hour
+		indexBlockSize   = 4 * time.Hour
+		namespaceOptions = namespace.NewOptions().
+					SetRetentionOptions(
+				namespace.NewOptions().
+					RetentionOptions().
+					SetBlockSize(dataBlockSize),
+			).
+			SetIndexOptions(
+				namespace.NewOptions().
+					IndexOptions().
+					SetBlockSize(indexBlockSize).
+					SetEnabled(true),
+			)
+	)
+	md, err := namespace.NewMetadata(testNamespaceID, namespaceOptions)
+	require.NoError(t, err)
+
+	var (
+		start     = time.Now().Truncate(indexBlockSize)
+		prevStart = start.Add(-indexBlockSize)
+		foo       = ts.Series{UniqueIndex: 0, Namespace: testNamespaceID, Shard: 0,
+			ID: ident.StringID("foo"), Tags: ident.NewTags(ident.StringTag("city", "ny"))}
+		bar = ts.Series{UniqueIndex: 1, Namespace: testNamespaceID, Shard: 0,
+			ID: ident.StringID("bar"), Tags: ident.NewTags(ident.StringTag("city", "sf"))}
+		values = []testValue{
+			{foo, start, 1.0, xtime.Second, nil},
+			{bar, start, 1.0, xtime.Second, nil},
+		}
+	)
+	src.newIteratorFn = func(_ commitlog.IteratorOpts) (commitlog.Iterator, []commitlog.ErrorWithPath, error) {
+		return newTestCommitLogIterator(values, nil), nil, nil
+	}
+
+	snapshotSeg, err := mem.NewSegment(0, mem.NewOptions())
+	require.NoError(t, err)
+	_, err = snapshotSeg.Insert(doc.Document{ID: []byte("foo")})
+	require.NoError(t, err)
+
+	// Only the latest snapshot of the block is read, the snapshot of the
+	// previous block includes a shard not bootstrapped so is skipped.
+	src.readIndexSnapshotInfoFilesFn = func(string, ident.ID, int) []fs.ReadIndexInfoFileResult {
+		return []fs.ReadIndexInfoFileResult{
+			{
+				ID:   fs.FileSetFileIdentifier{BlockStart: start, VolumeIndex: 0},
+				Info: indexpb.IndexInfo{BlockStart: start.UnixNano(), Shards: []uint32{0}},
+				Err:  testReadInfoFileResultError{},
+			},
+			{
+				ID:   fs.FileSetFileIdentifier{BlockStart: start, VolumeIndex: 1},
+				Info: indexpb.IndexInfo{BlockStart: start.UnixNano(), Shards: []uint32{0}},
+				Err:  testReadInfoFileResultError{},
+			},
+			{
+				ID:   fs.FileSetFileIdentifier{BlockStart: prevStart, VolumeIndex: 0},
+				Info: indexpb.IndexInfo{BlockStart: prevStart.UnixNano(), Shards: []uint32{0, 9}},
+				Err:  testReadInfoFileResultError{},
+			},
+		}
+	}
+	var reads int
+	src.readIndexSegmentsFn = func(opts fs.ReadIndexSegmentsOptions) ([]segment.Segment, error) {
+		reads++
+		require.True(t, start.Equal(opts.ReaderOptions.Identifier.BlockStart))
+		require.Equal(t, 1, opts.ReaderOptions.Identifier.VolumeIndex)
+		require.Equal(t, persist.FileSetSnapshotType, opts.ReaderOptions.FileSetType)
+		return []segment.Segment{testImmutableSegment{snapshotSeg}}, nil
+	}
+
+	ranges := xtime.NewRanges(xtime.Range{Start: prevStart, End: start.Add(indexBlockSize)})
+	res, err := src.ReadIndex(md, result.ShardTimeRanges{0: ranges}, testDefaultRunOpts)
+	require.NoError(t, err)
+	require.Equal(t, 1, reads)
+
+	indexResults := res.IndexResults()
+	require.Equal(t, 1, len(indexResults))
+	block, ok := indexResults[xtime.ToUnixNano(start)]
+	require.True(t, ok)
+	require.True(t, block.Snapshotted())
+	require.Equal(t, 2, len(block.Segments()))
+
+	// Only the series not in the snapshot are indexed again.
+	mutable, ok := block.Segments()[1].(segment.MutableSegment)
+	require.True(t, ok)
+	require.Equal(t, int64(1), mutable.Size())
+	exists, err := mutable.ContainsID([]byte("bar"))
+	require.NoError(t, err)
+	require.True(t, exists)
+}
+
 func TestBootstrapIndexNamespaceIndexNotEnabled(t *testing.T) {
 	var (
 		opts             = testDefaultOpts
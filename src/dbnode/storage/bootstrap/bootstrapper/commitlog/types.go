@@ -63,6 +63,16 @@ type Options interface {
 	// should return unfulfilled if it encounters corrupt commitlog files.
 	ReturnUnfulfilledForCorruptCommitLogFiles() bool
 
+	// SetSnapshotOnly sets whether the bootstrapper only loads the latest
+	// valid snapshot of each shard and block without reading the commit log,
+	// to recover as much data as possible when the commit logs are lost.
+	SetSnapshotOnly(value bool) Options
+
+	// SnapshotOnly returns whether the bootstrapper only loads the latest
+	// valid snapshot of each shard and block without reading the commit log,
+	// to recover as much data as possible when the commit logs are lost.
+	SnapshotOnly() bool
+
 	// SetRuntimeOptionsManagers sets the RuntimeOptionsManager.
 	SetRuntimeOptionsManager(value runtime.OptionsManager) Options
 
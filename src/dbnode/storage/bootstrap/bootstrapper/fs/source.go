@@ -183,7 +183,7 @@ func (s *fileSystemSource) shardAvailability(
 		return xtime.Ranges{}
 	}
 
-	readInfoFilesResults := fs.ReadInfoFiles(s.fsopts.FilePathPrefix(),
+	readInfoFilesResults := fs.ReadInfoFiles(s.fsopts.NamespaceFilePathPrefix(namespace),
 		namespace, shard, s.fsopts.InfoReaderBufferSize(), s.fsopts.DecodingOptions())
 
 	var tr xtime.Ranges
@@ -299,7 +299,7 @@ func (s *fileSystemSource) newShardReaders(
 	shard uint32,
 	tr xtime.Ranges,
 ) shardReaders {
-	readInfoFilesResults := fs.ReadInfoFiles(s.fsopts.FilePathPrefix(),
+	readInfoFilesResults := fs.ReadInfoFiles(s.fsopts.NamespaceFilePathPrefix(ns.ID()),
 		ns.ID(), shard, s.fsopts.InfoReaderBufferSize(), s.fsopts.DecodingOptions())
 	if len(readInfoFilesResults) == 0 {
 		// No readers.
@@ -1068,7 +1068,7 @@ func (s *fileSystemSource) bootstrapFromIndexPersistedBlocks(
 	}
 
 	indexBlockSize := ns.Options().IndexOptions().BlockSize()
-	infoFiles := fs.ReadIndexInfoFiles(s.fsopts.FilePathPrefix(), ns.ID(),
+	infoFiles := fs.ReadIndexInfoFiles(s.fsopts.NamespaceFilePathPrefix(ns.ID()), ns.ID(),
 		s.fsopts.InfoReaderBufferSize())
 
 	for _, infoFile := range infoFiles {
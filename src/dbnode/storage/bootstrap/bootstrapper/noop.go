@@ -84,6 +84,22 @@ func (noop noOpNoneBootstrapper) BootstrapIndex(
 	return res, nil
 }
 
+func (noop noOpNoneBootstrapper) AvailableData(
+	_ namespace.Metadata,
+	targetRanges result.ShardTimeRanges,
+	_ bootstrap.RunOptions,
+) (bootstrap.AvailabilityResult, error) {
+	return bootstrap.AvailabilityResult{Unfulfilled: targetRanges.Copy()}, nil
+}
+
+func (noop noOpNoneBootstrapper) AvailableIndex(
+	_ namespace.Metadata,
+	targetRanges result.ShardTimeRanges,
+	_ bootstrap.RunOptions,
+) (bootstrap.AvailabilityResult, error) {
+	return bootstrap.AvailabilityResult{Unfulfilled: targetRanges.Copy()}, nil
+}
+
 // noOpAllBootstrapperProvider is the no-op bootstrapper provider that pretends
 // it can bootstrap any time ranges.
 type noOpAllBootstrapperProvider struct{}
@@ -129,3 +145,31 @@ func (noop noOpAllBootstrapper) BootstrapIndex(
 ) (result.IndexBootstrapResult, error) {
 	return result.NewIndexBootstrapResult(), nil
 }
+
+func (noop noOpAllBootstrapper) AvailableData(
+	_ namespace.Metadata,
+	targetRanges result.ShardTimeRanges,
+	_ bootstrap.RunOptions,
+) (bootstrap.AvailabilityResult, error) {
+	return noop.availabilityResult(targetRanges), nil
+}
+
+func (noop noOpAllBootstrapper) AvailableIndex(
+	_ namespace.Metadata,
+	targetRanges result.ShardTimeRanges,
+	_ bootstrap.RunOptions,
+) (bootstrap.AvailabilityResult, error) {
+	return noop.availabilityResult(targetRanges), nil
+}
+
+func (noop noOpAllBootstrapper) availabilityResult(
+	targetRanges result.ShardTimeRanges,
+) bootstrap.AvailabilityResult {
+	return bootstrap.AvailabilityResult{
+		Bootstrappers: []bootstrap.BootstrapperAvailability{{
+			Bootstrapper: NoOpAllBootstrapperName,
+			Fulfilled:    targetRanges.Copy(),
+		}},
+		Unfulfilled: result.ShardTimeRanges{},
+	}
+}
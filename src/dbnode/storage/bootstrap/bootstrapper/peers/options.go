@@ -53,6 +53,8 @@ type options struct {
 	persistManager              persist.Manager
 	blockRetrieverManager       block.DatabaseBlockRetrieverManager
 	runtimeOptionsManager       m3dbruntime.OptionsManager
+	verifyOnly                  bool
+	verificationReportFn        VerificationReportFn
 }
 
 // NewOptions creates new bootstrap options
@@ -159,3 +161,23 @@ func (o *options) SetRuntimeOptionsManager(value m3dbruntime.OptionsManager) Opt
 func (o *options) RuntimeOptionsManager() m3dbruntime.OptionsManager {
 	return o.runtimeOptionsManager
 }
+
+func (o *options) SetVerifyOnly(value bool) Options {
+	opts := *o
+	opts.verifyOnly = value
+	return &opts
+}
+
+func (o *options) VerifyOnly() bool {
+	return o.verifyOnly
+}
+
+func (o *options) SetVerificationReportFn(value VerificationReportFn) Options {
+	opts := *o
+	opts.verificationReportFn = value
+	return &opts
+}
+
+func (o *options) VerificationReportFn() VerificationReportFn {
+	return o.verificationReportFn
+}
@@ -41,13 +41,29 @@ import (
 	xsync "github.com/m3db/m3/src/x/sync"
 	xtime "github.com/m3db/m3/src/x/time"
 
+	"github.com/uber-go/tally"
 	"go.uber.org/zap"
 )
 
 type peersSource struct {
-	opts  Options
-	log   *zap.Logger
-	nowFn clock.NowFn
+	opts    Options
+	log     *zap.Logger
+	nowFn   clock.NowFn
+	shards  xsync.ConcurrencyLimiter
+	metrics peersSourceMetrics
+}
+
+type peersSourceMetrics struct {
+	shardsInProgress    tally.Gauge
+	maxConcurrentShards tally.Gauge
+}
+
+func newPeersSourceMetrics(scope tally.Scope) peersSourceMetrics {
+	scope = scope.SubScope("peers-bootstrapper")
+	return peersSourceMetrics{
+		shardsInProgress:    scope.Gauge("shards-inprogress"),
+		maxConcurrentShards: scope.Gauge("max-concurrent-shards"),
+	}
 }
 
 type persistenceFlush struct {
@@ -59,10 +75,13 @@ type persistenceFlush struct {
 }
 
 func newPeersSource(opts Options) (bootstrap.Source, error) {
+	iOpts := opts.ResultOptions().InstrumentOptions()
 	return &peersSource{
-		opts:  opts,
-		log:   opts.ResultOptions().InstrumentOptions().Logger(),
-		nowFn: opts.ResultOptions().ClockOptions().NowFn(),
+		opts:    opts,
+		log:     iOpts.Logger(),
+		nowFn:   opts.ResultOptions().ClockOptions().NowFn(),
+		shards:  xsync.NewConcurrencyLimiter(0),
+		metrics: newPeersSourceMetrics(iOpts.MetricsScope()),
 	}, nil
 }
 
@@ -103,6 +122,10 @@ func (s *peersSource) ReadData(
 		return result.NewDataBootstrapResult(), nil
 	}
 
+	if s.opts.VerifyOnly() {
+		return s.verifyData(nsMetadata, shardsTimeRanges, opts)
+	}
+
 	var (
 		namespace         = nsMetadata.ID()
 		shardRetrieverMgr block.DatabaseShardBlockRetrieverManager
@@ -184,6 +207,8 @@ func (s *peersSource) ReadData(
 		wg.Add(1)
 		workers.Go(func() {
 			defer wg.Done()
+			s.acquireShard()
+			defer s.releaseShard()
 			s.fetchBootstrapBlocksFromPeers(shard, ranges, nsMetadata, session,
 				resultOpts, result, &resultLock, shouldPersist, persistenceQueue,
 				shardRetrieverMgr, blockSize)
@@ -200,6 +225,24 @@ func (s *peersSource) ReadData(
 	return result, nil
 }
 
+// acquireShard waits until a shard can be bootstrapped within the limit of
+// shards bootstrapped concurrently, the limit is read from the runtime
+// options each time so that it can be changed while bootstrapping.
+func (s *peersSource) acquireShard() {
+	if runtimeOptsMgr := s.opts.RuntimeOptionsManager(); runtimeOptsMgr != nil {
+		limits := runtimeOptsMgr.Get().PeersBootstrapLimits()
+		s.shards.SetLimit(limits.MaxConcurrentShards)
+		s.metrics.maxConcurrentShards.Update(float64(limits.MaxConcurrentShards))
+	}
+	s.shards.Acquire()
+	s.metrics.shardsInProgress.Update(float64(s.shards.Holders()))
+}
+
+func (s *peersSource) releaseShard() {
+	s.shards.Release()
+	s.metrics.shardsInProgress.Update(float64(s.shards.Holders()))
+}
+
 // startPersistenceQueueWorkerLoop is meant to be run in its own goroutine, and it creates a worker that
 // loops through the persistenceQueue and performs a flush for each entry, ensuring that
 // no more than one flush is ever happening at once. Once the persistenceQueue channel
@@ -533,6 +576,13 @@ func (s *peersSource) ReadIndex(
 		return r, nil
 	}
 
+	if s.opts.VerifyOnly() {
+		// Verification only compares data block metadata, leave the index
+		// to the remaining bootstrappers.
+		r.SetUnfulfilled(shardsTimeRanges)
+		return r, nil
+	}
+
 	session, err := s.opts.AdminClient().DefaultAdminSession()
 	if err != nil {
 		s.log.Error("peers bootstrapper cannot get default admin session", zap.Error(err))
@@ -561,6 +611,8 @@ func (s *peersSource) ReadIndex(
 		wg.Add(1)
 		workers.Go(func() {
 			defer wg.Done()
+			s.acquireShard()
+			defer s.releaseShard()
 
 			iter := ranges.Iter()
 			for iter.Next() {
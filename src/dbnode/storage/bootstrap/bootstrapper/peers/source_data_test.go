@@ -92,6 +92,11 @@ func newValidMockRuntimeOptionsManager(t *testing.T, ctrl *gomock.Controller) m3
 		ClientBootstrapConsistencyLevel().
 		Return(topology.ReadConsistencyLevelAll).
 		AnyTimes()
+	mockRuntimeOpts.
+		EXPECT().
+		PeersBootstrapLimits().
+		Return(m3dbruntime.PeersBootstrapLimits{}).
+		AnyTimes()
 
 	mockRuntimeOptsMgr := m3dbruntime.NewMockOptionsManager(ctrl)
 	mockRuntimeOptsMgr.
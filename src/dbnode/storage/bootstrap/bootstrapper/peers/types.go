@@ -21,11 +21,14 @@
 package peers
 
 import (
+	"time"
+
 	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/dbnode/persist"
 	m3dbruntime "github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/x/ident"
 )
 
 // Options represents the options for bootstrapping from peers
@@ -100,4 +103,66 @@ type Options interface {
 
 	// RuntimeOptionsManagers returns the RuntimeOptionsManager.
 	RuntimeOptionsManager() m3dbruntime.OptionsManager
+
+	// SetVerifyOnly sets whether the bootstrapper only compares block
+	// metadata and checksums across peers rather than fetching data, in
+	// which case all ranges are returned as unfulfilled.
+	SetVerifyOnly(value bool) Options
+
+	// VerifyOnly returns whether the bootstrapper only compares block
+	// metadata and checksums across peers rather than fetching data, in
+	// which case all ranges are returned as unfulfilled.
+	VerifyOnly() bool
+
+	// SetVerificationReportFn sets the function called with the divergence
+	// report of each namespace verified in verify only mode.
+	SetVerificationReportFn(value VerificationReportFn) Options
+
+	// VerificationReportFn returns the function called with the divergence
+	// report of each namespace verified in verify only mode.
+	VerificationReportFn() VerificationReportFn
+}
+
+// VerificationReportFn is called with the divergence report of a namespace
+// verified in verify only mode.
+type VerificationReportFn func(report VerificationReport)
+
+// VerificationReport is the divergence between peers for the shards of
+// a namespace verified in verify only mode.
+type VerificationReport struct {
+	// Namespace is the namespace that was verified.
+	Namespace ident.ID
+
+	// Shards is the divergence of each verified shard, ordered by shard.
+	Shards []ShardDivergence
+}
+
+// ShardDivergence is the divergence between peers for a single shard.
+type ShardDivergence struct {
+	// Shard is the shard that was verified.
+	Shard uint32
+
+	// Blocks is the divergence of each verified block, ordered by block start.
+	Blocks []BlockDivergence
+}
+
+// BlockDivergence is the divergence between peers for a single block of a shard.
+type BlockDivergence struct {
+	// BlockStart is the start of the block.
+	BlockStart time.Time
+
+	// NumSeries is the number of series with the block on at least one peer.
+	NumSeries int64
+
+	// SizeDifferences is the number of series where the block is missing
+	// from a peer or the block size differs between peers.
+	SizeDifferences int64
+
+	// ChecksumDifferences is the number of series where the block checksum
+	// is missing from a peer or differs between peers.
+	ChecksumDifferences int64
+
+	// Err is set if the block metadata could not be fetched from peers,
+	// in which case the block was not compared.
+	Err error
 }
@@ -0,0 +1,207 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package peers
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/repair"
+	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/x/ident"
+	xsync "github.com/m3db/m3/src/x/sync"
+
+	"go.uber.org/zap"
+)
+
+// verifyData compares the block metadata and checksums held by the peers
+// of each shard across the requested ranges without fetching any data, and
+// reports the divergence. All ranges are returned unfulfilled so that the
+// remaining bootstrappers are still responsible for the data.
+func (s *peersSource) verifyData(
+	nsMetadata namespace.Metadata,
+	shardsTimeRanges result.ShardTimeRanges,
+	runOpts bootstrap.RunOptions,
+) (result.DataBootstrapResult, error) {
+	res := result.NewDataBootstrapResult()
+	res.SetUnfulfilled(shardsTimeRanges)
+
+	session, err := s.opts.AdminClient().DefaultAdminSession()
+	if err != nil {
+		s.log.Error("peers bootstrapper cannot get default admin session", zap.Error(err))
+		return nil, err
+	}
+
+	var (
+		nsID        = nsMetadata.ID()
+		blockSize   = nsMetadata.Options().RetentionOptions().BlockSize()
+		level       = s.opts.RuntimeOptionsManager().Get().ClientBootstrapConsistencyLevel()
+		repairOpts  = repair.NewOptions()
+		concurrency = s.opts.DefaultShardConcurrency()
+		topoState   = runOpts.InitialTopologyState()
+		report      = VerificationReport{Namespace: nsID}
+		reportLock  sync.Mutex
+		wg          sync.WaitGroup
+	)
+	s.log.Info("peers bootstrapper verifying shards for ranges",
+		zap.Stringer("namespace", nsID),
+		zap.Int("shards", len(shardsTimeRanges)),
+		zap.Int("concurrency", concurrency),
+	)
+
+	workers := xsync.NewWorkerPool(concurrency)
+	workers.Init()
+	for shard, ranges := range shardsTimeRanges {
+		shard, ranges := shard, ranges
+		wg.Add(1)
+		workers.Go(func() {
+			defer wg.Done()
+
+			divergence := ShardDivergence{Shard: shard}
+			replicas := numPeers(topoState, shard)
+			it := ranges.Iter()
+			for it.Next() {
+				currRange := it.Value()
+				for blockStart := currRange.Start; blockStart.Before(currRange.End); blockStart = blockStart.Add(blockSize) {
+					blockEnd := blockStart.Add(blockSize)
+					divergence.Blocks = append(divergence.Blocks,
+						s.verifyBlock(session, nsID, shard, blockStart, blockEnd,
+							replicas, level, repairOpts))
+				}
+			}
+
+			s.recordShardDivergence(nsID, divergence)
+
+			reportLock.Lock()
+			report.Shards = append(report.Shards, divergence)
+			reportLock.Unlock()
+		})
+	}
+
+	wg.Wait()
+
+	sort.Slice(report.Shards, func(i, j int) bool {
+		return report.Shards[i].Shard < report.Shards[j].Shard
+	})
+	if fn := s.opts.VerificationReportFn(); fn != nil {
+		fn(report)
+	}
+
+	return res, nil
+}
+
+func (s *peersSource) verifyBlock(
+	session client.AdminSession,
+	nsID ident.ID,
+	shard uint32,
+	blockStart, blockEnd time.Time,
+	replicas int,
+	level topology.ReadConsistencyLevel,
+	repairOpts repair.Options,
+) BlockDivergence {
+	divergence := BlockDivergence{BlockStart: blockStart}
+
+	peerIter, err := session.FetchBlocksMetadataFromPeers(nsID, shard,
+		blockStart, blockEnd, level, s.opts.ResultOptions())
+	if err != nil {
+		divergence.Err = err
+		return divergence
+	}
+
+	comparer := repair.NewReplicaMetadataComparer(replicas, repairOpts)
+	defer comparer.Finalize()
+
+	if err := comparer.AddPeerMetadata(peerIter); err != nil {
+		divergence.Err = err
+		return divergence
+	}
+
+	compared := comparer.Compare()
+	divergence.NumSeries = compared.NumSeries
+	divergence.SizeDifferences = compared.SizeDifferences.NumSeries()
+	divergence.ChecksumDifferences = compared.ChecksumDifferences.NumSeries()
+	return divergence
+}
+
+func (s *peersSource) recordShardDivergence(
+	nsID ident.ID,
+	divergence ShardDivergence,
+) {
+	var (
+		scope = s.opts.ResultOptions().InstrumentOptions().MetricsScope().
+			SubScope("peers-verify").
+			Tagged(map[string]string{
+				"namespace": nsID.String(),
+				"shard":     strconv.Itoa(int(divergence.Shard)),
+			})
+		totalScope        = scope.Tagged(map[string]string{"resultType": "total"})
+		sizeDiffScope     = scope.Tagged(map[string]string{"resultType": "sizeDiff"})
+		checksumDiffScope = scope.Tagged(map[string]string{"resultType": "checksumDiff"})
+		errorsCounter     = scope.Counter("errors")
+	)
+	for _, block := range divergence.Blocks {
+		if block.Err != nil {
+			errorsCounter.Inc(1)
+			s.log.Error("peers bootstrapper could not verify block",
+				zap.Stringer("namespace", nsID),
+				zap.Uint32("shard", divergence.Shard),
+				zap.Time("blockStart", block.BlockStart),
+				zap.Error(block.Err),
+			)
+			continue
+		}
+
+		totalScope.Counter("series").Inc(block.NumSeries)
+		sizeDiffScope.Counter("series").Inc(block.SizeDifferences)
+		checksumDiffScope.Counter("series").Inc(block.ChecksumDifferences)
+		if block.SizeDifferences == 0 && block.ChecksumDifferences == 0 {
+			continue
+		}
+
+		s.log.Warn("peers bootstrapper found divergent block",
+			zap.Stringer("namespace", nsID),
+			zap.Uint32("shard", divergence.Shard),
+			zap.Time("blockStart", block.BlockStart),
+			zap.Int64("numSeries", block.NumSeries),
+			zap.Int64("sizeDifferences", block.SizeDifferences),
+			zap.Int64("checksumDifferences", block.ChecksumDifferences),
+		)
+	}
+}
+
+// numPeers returns the number of hosts other than the origin that own
+// the shard in the topology, which is the number of replicas expected to
+// agree on every block.
+func numPeers(topoState *topology.StateSnapshot, shard uint32) int {
+	var n int
+	for _, hostShardState := range topoState.ShardStates[topology.ShardID(shard)] {
+		if hostShardState.Host.ID() == topoState.Origin.ID() {
+			continue
+		}
+		n++
+	}
+	return n
+}
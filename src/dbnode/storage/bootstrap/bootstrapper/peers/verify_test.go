@@ -0,0 +1,148 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package peers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/shard"
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/storage/block"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/topology"
+	tu "github.com/m3db/m3/src/dbnode/topology/testutil"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeersSourceVerifyOnlyReportsDivergence(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		nsMetadata = testNamespaceMetadata(t)
+		ropts      = nsMetadata.Options().RetentionOptions()
+		blockSize  = ropts.BlockSize()
+		start      = time.Now().Add(-ropts.RetentionPeriod()).Truncate(blockSize)
+		mid        = start.Add(blockSize)
+		end        = mid.Add(blockSize)
+		peer1      = topology.NewHost(notSelfID1, "127.0.0.1:9001")
+		peer2      = topology.NewHost(notSelfID2, "127.0.0.1:9002")
+		checksum1  = uint32(1)
+		checksum2  = uint32(2)
+		fetchErr   = errors.New("an error")
+	)
+
+	metadata := func(id string, checksum *uint32) block.Metadata {
+		return block.NewMetadata(ident.StringID(id), ident.Tags{},
+			start, 1, checksum, time.Time{})
+	}
+	peerBlocks := []struct {
+		host     topology.Host
+		metadata block.Metadata
+	}{
+		{peer1, metadata("foo", &checksum1)},
+		{peer2, metadata("foo", &checksum1)},
+		{peer1, metadata("bar", &checksum1)},
+		{peer2, metadata("bar", &checksum2)},
+		{peer1, metadata("baz", &checksum1)},
+	}
+
+	mockIter := client.NewMockPeerBlockMetadataIter(ctrl)
+	var iterCalls []*gomock.Call
+	for _, b := range peerBlocks {
+		iterCalls = append(iterCalls,
+			mockIter.EXPECT().Next().Return(true),
+			mockIter.EXPECT().Current().Return(b.host, b.metadata))
+	}
+	iterCalls = append(iterCalls,
+		mockIter.EXPECT().Next().Return(false),
+		mockIter.EXPECT().Err().Return(nil))
+	gomock.InOrder(iterCalls...)
+
+	mockAdminSession := client.NewMockAdminSession(ctrl)
+	mockAdminSession.EXPECT().
+		FetchBlocksMetadataFromPeers(ident.NewIDMatcher(testNamespace.String()),
+			uint32(0), start, mid, topology.ReadConsistencyLevelAll, gomock.Any()).
+		Return(mockIter, nil)
+	mockAdminSession.EXPECT().
+		FetchBlocksMetadataFromPeers(ident.NewIDMatcher(testNamespace.String()),
+			uint32(0), mid, end, topology.ReadConsistencyLevelAll, gomock.Any()).
+		Return(nil, fetchErr)
+
+	mockAdminClient := client.NewMockAdminClient(ctrl)
+	mockAdminClient.EXPECT().DefaultAdminSession().Return(mockAdminSession, nil)
+
+	var reports []VerificationReport
+	opts := testDefaultOpts.
+		SetAdminClient(mockAdminClient).
+		SetRuntimeOptionsManager(newValidMockRuntimeOptionsManager(t, ctrl)).
+		SetVerifyOnly(true).
+		SetVerificationReportFn(func(report VerificationReport) {
+			reports = append(reports, report)
+		})
+
+	src, err := newPeersSource(opts)
+	require.NoError(t, err)
+
+	target := result.ShardTimeRanges{
+		0: xtime.NewRanges(xtime.Range{Start: start, End: end}),
+	}
+	runOpts := testDefaultRunOpts.SetInitialTopologyState(
+		tu.NewStateSnapshot(2, tu.HostShardStates{
+			tu.SelfID:  tu.ShardsRange(0, 1, shard.Initializing),
+			notSelfID1: tu.ShardsRange(0, 1, shard.Available),
+			notSelfID2: tu.ShardsRange(0, 1, shard.Available),
+		}))
+
+	r, err := src.ReadData(nsMetadata, target, runOpts)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(r.ShardResults()))
+	assertShardRangesEqual(t, target, r.Unfulfilled())
+
+	idx, err := src.ReadIndex(nsMetadata, target, runOpts)
+	require.NoError(t, err)
+	assertShardRangesEqual(t, target, idx.Unfulfilled())
+
+	require.Equal(t, 1, len(reports))
+	require.True(t, reports[0].Namespace.Equal(testNamespace))
+	require.Equal(t, []ShardDivergence{
+		{
+			Shard: 0,
+			Blocks: []BlockDivergence{
+				{
+					BlockStart:          start,
+					NumSeries:           3,
+					SizeDifferences:     1,
+					ChecksumDifferences: 2,
+				},
+				{
+					BlockStart: mid,
+					Err:        fetchErr,
+				},
+			},
+		},
+	}, reports[0].Shards)
+}
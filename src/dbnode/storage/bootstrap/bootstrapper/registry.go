@@ -0,0 +1,124 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package bootstrapper
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+var (
+	errRegisteredBootstrapperNoName       = errors.New("registered bootstrapper has no name")
+	errRegisteredBootstrapperNoProviderFn = errors.New("registered bootstrapper has no new provider function")
+
+	registry = struct {
+		sync.RWMutex
+		bootstrappers map[string]RegisteredBootstrapper
+	}{
+		bootstrappers: make(map[string]RegisteredBootstrapper),
+	}
+)
+
+// RegisteredBootstrapperOptions are the options a registered bootstrapper
+// provider is created with.
+type RegisteredBootstrapperOptions struct {
+	ResultOptions     result.Options
+	FilesystemOptions fs.Options
+	PersistManager    persist.Manager
+	InstrumentOptions instrument.Options
+	// Config is the configuration of the bootstrapper, if any, as
+	// unmarshalled from the bootstrap configuration.
+	Config interface{}
+}
+
+// NewRegisteredBootstrapperProviderFn creates a bootstrapper provider that
+// falls back to the next provider for the ranges it does not fulfill.
+type NewRegisteredBootstrapperProviderFn func(
+	opts RegisteredBootstrapperOptions,
+	next bootstrap.BootstrapperProvider,
+) (bootstrap.BootstrapperProvider, error)
+
+// RegisteredBootstrapper is a bootstrapper registered by name so that it can
+// be composed into the bootstrap chain by configuration.
+type RegisteredBootstrapper struct {
+	// Name is the name the bootstrapper is configured by.
+	Name string
+	// PrecedingAllowed are the names of the bootstrappers that may appear
+	// before the bootstrapper in the bootstrap chain, any bootstrapper may
+	// precede it if nil.
+	PrecedingAllowed []string
+	// NewProviderFn creates the bootstrapper provider.
+	NewProviderFn NewRegisteredBootstrapperProviderFn
+}
+
+// RegisterBootstrapper registers a bootstrapper by name, it is expected to be
+// called at initialization, i.e. from an init function of the package that
+// implements the bootstrapper. It fails if a bootstrapper with the same name
+// is already registered, the builtin bootstrappers take precedence over
+// registered bootstrappers of the same name.
+func RegisterBootstrapper(b RegisteredBootstrapper) error {
+	if b.Name == "" {
+		return errRegisteredBootstrapperNoName
+	}
+	if b.NewProviderFn == nil {
+		return errRegisteredBootstrapperNoProviderFn
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.bootstrappers[b.Name]; ok {
+		return fmt.Errorf("bootstrapper %s is already registered", b.Name)
+	}
+	registry.bootstrappers[b.Name] = b
+	return nil
+}
+
+// RegisteredBootstrapperByName returns the bootstrapper registered with
+// the name, if any.
+func RegisteredBootstrapperByName(name string) (RegisteredBootstrapper, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	b, ok := registry.bootstrappers[name]
+	return b, ok
+}
+
+// RegisteredBootstrapperNames returns the names of the registered
+// bootstrappers in order.
+func RegisteredBootstrapperNames() []string {
+	registry.RLock()
+	names := make([]string, 0, len(registry.bootstrappers))
+	for name := range registry.bootstrappers {
+		names = append(names, name)
+	}
+	registry.RUnlock()
+
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package bootstrapper
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterBootstrapper(t *testing.T) {
+	newProviderFn := func(
+		_ RegisteredBootstrapperOptions,
+		next bootstrap.BootstrapperProvider,
+	) (bootstrap.BootstrapperProvider, error) {
+		return next, nil
+	}
+
+	require.Equal(t, errRegisteredBootstrapperNoName,
+		RegisterBootstrapper(RegisteredBootstrapper{NewProviderFn: newProviderFn}))
+	require.Equal(t, errRegisteredBootstrapperNoProviderFn,
+		RegisterBootstrapper(RegisteredBootstrapper{Name: "test-registry-b"}))
+
+	_, ok := RegisteredBootstrapperByName("test-registry-a")
+	require.False(t, ok)
+
+	for _, name := range []string{"test-registry-b", "test-registry-a"} {
+		require.NoError(t, RegisterBootstrapper(RegisteredBootstrapper{
+			Name:             name,
+			PrecedingAllowed: []string{NoOpNoneBootstrapperName},
+			NewProviderFn:    newProviderFn,
+		}))
+	}
+	require.Error(t, RegisterBootstrapper(RegisteredBootstrapper{
+		Name:          "test-registry-a",
+		NewProviderFn: newProviderFn,
+	}))
+
+	b, ok := RegisteredBootstrapperByName("test-registry-a")
+	require.True(t, ok)
+	require.Equal(t, "test-registry-a", b.Name)
+	require.Equal(t, []string{NoOpNoneBootstrapperName}, b.PrecedingAllowed)
+
+	next := NewNoOpAllBootstrapperProvider()
+	provider, err := b.NewProviderFn(RegisteredBootstrapperOptions{}, next)
+	require.NoError(t, err)
+	require.Equal(t, next, provider)
+
+	names := RegisteredBootstrapperNames()
+	require.Contains(t, names, "test-registry-a")
+	require.Contains(t, names, "test-registry-b")
+}
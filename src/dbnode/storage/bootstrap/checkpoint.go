@@ -0,0 +1,118 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+type fileSystemCheckpointer struct {
+	opts fs.Options
+}
+
+// NewFileSystemCheckpointer returns a checkpointer that persists the
+// checkpoints of namespaces to files beneath the file path prefix.
+func NewFileSystemCheckpointer(opts fs.Options) Checkpointer {
+	return fileSystemCheckpointer{opts: opts}
+}
+
+func (c fileSystemCheckpointer) Read(namespace ident.ID) (Checkpoint, error) {
+	checkpoint, ok, err := fs.ReadBootstrapCheckpoint(c.opts.FilePathPrefix(), namespace)
+	if err != nil || !ok {
+		return newCheckpoint(), err
+	}
+	return Checkpoint{
+		Data:  checkpointShardTimeRanges(checkpoint.Data),
+		Index: checkpointShardTimeRanges(checkpoint.Index),
+	}, nil
+}
+
+func (c fileSystemCheckpointer) Write(namespace ident.ID, checkpoint Checkpoint) error {
+	return fs.WriteBootstrapCheckpoint(c.opts.FilePathPrefix(), namespace,
+		fs.BootstrapCheckpoint{
+			Data:  checkpointRanges(checkpoint.Data),
+			Index: checkpointRanges(checkpoint.Index),
+		}, c.opts.NewFileMode(), c.opts.NewDirectoryMode())
+}
+
+func (c fileSystemCheckpointer) Remove(namespace ident.ID) error {
+	return fs.RemoveBootstrapCheckpoint(c.opts.FilePathPrefix(), namespace)
+}
+
+func newCheckpoint() Checkpoint {
+	return Checkpoint{
+		Data:  result.ShardTimeRanges{},
+		Index: result.ShardTimeRanges{},
+	}
+}
+
+// isEmpty returns whether the checkpoint has no time ranges.
+func (c Checkpoint) isEmpty() bool {
+	return c.Data.IsEmpty() && c.Index.IsEmpty()
+}
+
+// removeShards removes the time ranges of the shards from the checkpoint.
+func (c Checkpoint) removeShards(shards []uint32) {
+	for _, shard := range shards {
+		delete(c.Data, shard)
+		delete(c.Index, shard)
+	}
+}
+
+func checkpointShardTimeRanges(
+	ranges map[uint32][]fs.BootstrapCheckpointRange,
+) result.ShardTimeRanges {
+	r := make(result.ShardTimeRanges, len(ranges))
+	for shard, shardRanges := range ranges {
+		var tr xtime.Ranges
+		for _, shardRange := range shardRanges {
+			tr = tr.AddRange(xtime.Range{
+				Start: time.Unix(0, shardRange.Start),
+				End:   time.Unix(0, shardRange.End),
+			})
+		}
+		r[shard] = tr
+	}
+	return r
+}
+
+func checkpointRanges(
+	r result.ShardTimeRanges,
+) map[uint32][]fs.BootstrapCheckpointRange {
+	ranges := make(map[uint32][]fs.BootstrapCheckpointRange, len(r))
+	for shard, tr := range r {
+		shardRanges := make([]fs.BootstrapCheckpointRange, 0, tr.Len())
+		it := tr.Iter()
+		for it.Next() {
+			value := it.Value()
+			shardRanges = append(shardRanges, fs.BootstrapCheckpointRange{
+				Start: value.Start.UnixNano(),
+				End:   value.End.UnixNano(),
+			})
+		}
+		ranges[shard] = shardRanges
+	}
+	return ranges
+}
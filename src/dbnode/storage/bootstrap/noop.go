@@ -25,6 +25,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/namespace"
+	xtime "github.com/m3db/m3/src/x/time"
 )
 
 type noOpBootstrapProcessProvider struct{}
@@ -41,6 +42,13 @@ func (b noOpBootstrapProcessProvider) BootstrapperProvider() BootstrapperProvide
 	return nil
 }
 
+func (b noOpBootstrapProcessProvider) SetProgressReporter(value ProgressReporter) {
+}
+
+func (b noOpBootstrapProcessProvider) ProgressReporter() ProgressReporter {
+	return NewNoOpProgressReporter()
+}
+
 func (b noOpBootstrapProcessProvider) Provide() (Process, error) {
 	return noOpBootstrapProcess{}, nil
 }
@@ -57,3 +65,52 @@ func (b noOpBootstrapProcess) Run(
 		IndexResult: result.NewIndexBootstrapResult(),
 	}, nil
 }
+
+func (b noOpBootstrapProcess) RunRange(
+	ns namespace.Metadata,
+	shards []uint32,
+	window xtime.Range,
+) (ProcessResult, error) {
+	return ProcessResult{
+		DataResult:  result.NewDataBootstrapResult(),
+		IndexResult: result.NewIndexBootstrapResult(),
+	}, nil
+}
+
+func (b noOpBootstrapProcess) DryRun(
+	start time.Time,
+	ns namespace.Metadata,
+	shards []uint32,
+) (DryRunResult, error) {
+	return DryRunResult{
+		DataResult:  newAvailabilityResult(),
+		IndexResult: newAvailabilityResult(),
+	}, nil
+}
+
+type noOpProgressReporter struct{}
+
+// NewNoOpProgressReporter creates a no-op bootstrap progress reporter.
+func NewNoOpProgressReporter() ProgressReporter {
+	return noOpProgressReporter{}
+}
+
+func (r noOpProgressReporter) ReportPlanned(
+	ns namespace.Metadata,
+	shards []uint32,
+	ranges []xtime.Range,
+) {
+}
+
+func (r noOpProgressReporter) ReportBootstrapperStarted(
+	ns namespace.Metadata,
+	bootstrapper string,
+) {
+}
+
+func (r noOpProgressReporter) ReportCompleted(
+	ns namespace.Metadata,
+	shards []uint32,
+	window xtime.Range,
+) {
+}
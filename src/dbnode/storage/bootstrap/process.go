@@ -21,6 +21,7 @@
 package bootstrap
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -43,6 +44,7 @@ type bootstrapProcessProvider struct {
 	resultOpts           result.Options
 	log                  *zap.Logger
 	bootstrapperProvider BootstrapperProvider
+	progressReporter     ProgressReporter
 }
 
 type bootstrapRunType string
@@ -67,6 +69,7 @@ func NewProcessProvider(
 		resultOpts:           resultOpts,
 		log:                  resultOpts.InstrumentOptions().Logger(),
 		bootstrapperProvider: bootstrapperProvider,
+		progressReporter:     NewNoOpProgressReporter(),
 	}, nil
 }
 
@@ -82,6 +85,18 @@ func (b *bootstrapProcessProvider) BootstrapperProvider() BootstrapperProvider {
 	return b.bootstrapperProvider
 }
 
+func (b *bootstrapProcessProvider) SetProgressReporter(value ProgressReporter) {
+	b.Lock()
+	defer b.Unlock()
+	b.progressReporter = value
+}
+
+func (b *bootstrapProcessProvider) ProgressReporter() ProgressReporter {
+	b.RLock()
+	defer b.RUnlock()
+	return b.progressReporter
+}
+
 func (b *bootstrapProcessProvider) Provide() (Process, error) {
 	b.RLock()
 	defer b.RUnlock()
@@ -102,6 +117,7 @@ func (b *bootstrapProcessProvider) Provide() (Process, error) {
 		log:                  b.log,
 		bootstrapper:         bootstrapper,
 		initialTopologyState: initialTopologyState,
+		progressReporter:     b.progressReporter,
 	}, nil
 }
 
@@ -147,6 +163,7 @@ type bootstrapProcess struct {
 	log                  *zap.Logger
 	bootstrapper         Bootstrapper
 	initialTopologyState *topology.StateSnapshot
+	progressReporter     ProgressReporter
 }
 
 func (b bootstrapProcess) Run(
@@ -154,84 +171,365 @@ func (b bootstrapProcess) Run(
 	namespace namespace.Metadata,
 	shards []uint32,
 ) (ProcessResult, error) {
-	dataResult, err := b.bootstrapData(start, namespace, shards)
-	if err != nil {
-		return ProcessResult{}, err
+	var (
+		ropts        = namespace.Options().RetentionOptions()
+		idxopts      = namespace.Options().IndexOptions()
+		dataTargets  = b.targetRangesForData(start, ropts)
+		indexTargets []TargetRange
+	)
+	if idxopts.Enabled() {
+		indexTargets = b.targetRangesForIndex(start, ropts, idxopts)
+	}
+
+	planned := make([]xtime.Range, 0, len(dataTargets)+len(indexTargets))
+	for _, target := range dataTargets {
+		planned = append(planned, target.Range)
+	}
+	for _, target := range indexTargets {
+		planned = append(planned, target.Range)
 	}
+	b.progressReporter.ReportPlanned(namespace, shards, planned)
 
-	indexResult, err := b.bootstrapIndex(start, namespace, shards)
+	checkpoint := b.readCheckpoint(namespace)
+	processResult, err := b.run(namespace, shards, dataTargets, indexTargets,
+		checkpoint)
 	if err != nil {
 		return ProcessResult{}, err
 	}
 
+	// The shards are now bootstrapped, a restarted bootstrap of these shards
+	// should no longer skip any of the ranges.
+	b.removeCheckpoint(namespace, shards, checkpoint)
+
+	return processResult, nil
+}
+
+func (b bootstrapProcess) RunRange(
+	namespace namespace.Metadata,
+	shards []uint32,
+	window xtime.Range,
+) (ProcessResult, error) {
+	// NB: The range is bootstrapped into memory rather than persisted and
+	// is not reported as progress of the bootstrap of the node.
+	b.progressReporter = NewNoOpProgressReporter()
+
+	var (
+		ropts       = namespace.Options().RetentionOptions()
+		idxopts     = namespace.Options().IndexOptions()
+		runOpts     = b.newRunOptions()
+		dataTargets = []TargetRange{{
+			Range:      blockAlignedRange(window, ropts.BlockSize()),
+			RunOptions: runOpts,
+		}}
+		indexTargets []TargetRange
+	)
+	if idxopts.Enabled() {
+		indexTargets = []TargetRange{{
+			Range:      blockAlignedRange(window, idxopts.BlockSize()),
+			RunOptions: runOpts,
+		}}
+	}
+
+	return b.run(namespace, shards, dataTargets, indexTargets, newCheckpoint())
+}
+
+func (b bootstrapProcess) DryRun(
+	start time.Time,
+	namespace namespace.Metadata,
+	shards []uint32,
+) (DryRunResult, error) {
+	var (
+		ropts   = namespace.Options().RetentionOptions()
+		idxopts = namespace.Options().IndexOptions()
+		res     = DryRunResult{
+			DataResult:  newAvailabilityResult(),
+			IndexResult: newAvailabilityResult(),
+		}
+	)
+	for _, target := range b.targetRangesForData(start, ropts) {
+		shardsTimeRanges := b.newShardTimeRanges(target.Range, shards)
+		available, err := b.bootstrapper.AvailableData(namespace,
+			shardsTimeRanges, target.RunOptions)
+		if err != nil {
+			return DryRunResult{}, err
+		}
+		res.DataResult = mergedAvailabilityResult(res.DataResult, available)
+	}
+	if !idxopts.Enabled() {
+		return res, nil
+	}
+	for _, target := range b.targetRangesForIndex(start, ropts, idxopts) {
+		shardsTimeRanges := b.newShardTimeRanges(target.Range, shards)
+		available, err := b.bootstrapper.AvailableIndex(namespace,
+			shardsTimeRanges, target.RunOptions)
+		if err != nil {
+			return DryRunResult{}, err
+		}
+		res.IndexResult = mergedAvailabilityResult(res.IndexResult, available)
+	}
+	return res, nil
+}
+
+func newAvailabilityResult() AvailabilityResult {
+	return AvailabilityResult{Unfulfilled: result.ShardTimeRanges{}}
+}
+
+// mergedAvailabilityResult returns the availability of both results, the
+// time ranges of the same bootstrapper are merged together.
+func mergedAvailabilityResult(a, b AvailabilityResult) AvailabilityResult {
+	merged := AvailabilityResult{
+		Bootstrappers: make([]BootstrapperAvailability, 0, len(a.Bootstrappers)),
+		Unfulfilled:   result.ShardTimeRanges{},
+	}
+	for _, r := range []AvailabilityResult{a, b} {
+		for _, bs := range r.Bootstrappers {
+			idx := -1
+			for i := range merged.Bootstrappers {
+				if merged.Bootstrappers[i].Bootstrapper == bs.Bootstrapper {
+					idx = i
+					break
+				}
+			}
+			if idx < 0 {
+				merged.Bootstrappers = append(merged.Bootstrappers, BootstrapperAvailability{
+					Bootstrapper: bs.Bootstrapper,
+					Fulfilled:    result.ShardTimeRanges{},
+				})
+				idx = len(merged.Bootstrappers) - 1
+			}
+			merged.Bootstrappers[idx].Fulfilled.AddRanges(bs.Fulfilled)
+		}
+		merged.Unfulfilled.AddRanges(r.Unfulfilled)
+	}
+	return merged
+}
+
+func (b bootstrapProcess) run(
+	namespace namespace.Metadata,
+	shards []uint32,
+	dataTargets []TargetRange,
+	indexTargets []TargetRange,
+	checkpoint Checkpoint,
+) (ProcessResult, error) {
+	var (
+		dataResult  = result.NewDataBootstrapResult()
+		indexResult = result.NewIndexBootstrapResult()
+	)
+	for _, target := range b.orderTargets(dataTargets, indexTargets) {
+		switch target.runType {
+		case bootstrapDataRunType:
+			res, err := b.bootstrapData(namespace, shards, target.TargetRange,
+				checkpoint)
+			if err != nil {
+				return ProcessResult{}, err
+			}
+			dataResult = result.MergedDataBootstrapResult(dataResult, res)
+		case bootstrapIndexRunType:
+			res, err := b.bootstrapIndex(namespace, shards, target.TargetRange,
+				checkpoint)
+			if err != nil {
+				return ProcessResult{}, err
+			}
+			indexResult = result.MergedIndexBootstrapResult(indexResult, res)
+		}
+	}
+
 	return ProcessResult{
 		DataResult:  dataResult,
 		IndexResult: indexResult,
 	}, nil
 }
 
+// processTarget is a target range along with whether it bootstraps
+// data or the index.
+type processTarget struct {
+	TargetRange
+	runType bootstrapRunType
+}
+
+// orderTargets returns the order in which the data and index target ranges
+// are bootstrapped, by default all data target ranges are bootstrapped
+// before the index target ranges. When prioritizing recent time ranges the
+// target ranges are bootstrapped from the most recent to the oldest so that
+// the hot query window is available as early as possible, data is still
+// bootstrapped before the index of the same time range.
+//
+// NB: Reads are only served by shards once bootstrapping of all their
+// target ranges completes, the available time ranges are surfaced by the
+// bootstrap progress reported to the coordinator.
+func (b bootstrapProcess) orderTargets(
+	dataTargets []TargetRange,
+	indexTargets []TargetRange,
+) []processTarget {
+	targets := make([]processTarget, 0, len(dataTargets)+len(indexTargets))
+	for _, target := range dataTargets {
+		targets = append(targets, processTarget{
+			TargetRange: target,
+			runType:     bootstrapDataRunType,
+		})
+	}
+	for _, target := range indexTargets {
+		targets = append(targets, processTarget{
+			TargetRange: target,
+			runType:     bootstrapIndexRunType,
+		})
+	}
+	if !b.processOpts.PrioritizeRecent() {
+		return targets
+	}
+
+	// NB: The sort is stable so that on the same end data remains
+	// bootstrapped before the index.
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].Range.End.After(targets[j].Range.End)
+	})
+	return targets
+}
+
 func (b bootstrapProcess) bootstrapData(
-	at time.Time,
 	namespace namespace.Metadata,
 	shards []uint32,
+	target TargetRange,
+	checkpoint Checkpoint,
 ) (result.DataBootstrapResult, error) {
-	bootstrapResult := result.NewDataBootstrapResult()
-	ropts := namespace.Options().RetentionOptions()
-	targetRanges := b.targetRangesForData(at, ropts)
-	for _, target := range targetRanges {
-		logFields := b.logFields(bootstrapDataRunType, namespace,
-			shards, target.Range)
-		b.logBootstrapRun(logFields)
-
-		begin := b.nowFn()
-		shardsTimeRanges := b.newShardTimeRanges(target.Range, shards)
-		res, err := b.bootstrapper.BootstrapData(namespace,
-			shardsTimeRanges, target.RunOptions)
-
-		b.logBootstrapResult(logFields, err, begin)
-		if err != nil {
-			return nil, err
-		}
+	logFields := b.logFields(bootstrapDataRunType, namespace,
+		shards, target.Range)
+	b.logBootstrapRun(logFields)
+
+	begin := b.nowFn()
+	shardsTimeRanges := b.newShardTimeRanges(target.Range, shards)
+	runOpts := target.RunOptions.
+		SetCheckpointedShardTimeRanges(checkpoint.Data)
+	res, err := b.bootstrapper.BootstrapData(namespace,
+		shardsTimeRanges, runOpts)
+
+	b.logBootstrapResult(logFields, err, begin)
+	if err != nil {
+		return nil, err
+	}
 
-		bootstrapResult = result.MergedDataBootstrapResult(bootstrapResult, res)
+	if b.shouldCheckpoint(target) {
+		fulfilled := shardsTimeRanges.Copy()
+		fulfilled.Subtract(res.Unfulfilled())
+		checkpoint.Data.AddRanges(fulfilled)
+		b.writeCheckpoint(namespace, checkpoint)
 	}
 
-	return bootstrapResult, nil
+	b.progressReporter.ReportCompleted(namespace, shards, target.Range)
+	return res, nil
 }
 
 func (b bootstrapProcess) bootstrapIndex(
-	at time.Time,
 	namespace namespace.Metadata,
 	shards []uint32,
+	target TargetRange,
+	checkpoint Checkpoint,
 ) (result.IndexBootstrapResult, error) {
-	bootstrapResult := result.NewIndexBootstrapResult()
-	ropts := namespace.Options().RetentionOptions()
-	idxopts := namespace.Options().IndexOptions()
-	if !idxopts.Enabled() {
-		// NB(r): If indexing not enable we just return an empty result
-		return result.NewIndexBootstrapResult(), nil
+	logFields := b.logFields(bootstrapIndexRunType, namespace,
+		shards, target.Range)
+	b.logBootstrapRun(logFields)
+
+	begin := b.nowFn()
+	shardsTimeRanges := b.newShardTimeRanges(target.Range, shards)
+	runOpts := target.RunOptions.
+		SetCheckpointedShardTimeRanges(checkpoint.Index)
+	res, err := b.bootstrapper.BootstrapIndex(namespace,
+		shardsTimeRanges, runOpts)
+
+	b.logBootstrapResult(logFields, err, begin)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.shouldCheckpoint(target) {
+		fulfilled := shardsTimeRanges.Copy()
+		fulfilled.Subtract(res.Unfulfilled())
+		checkpoint.Index.AddRanges(fulfilled)
+		b.writeCheckpoint(namespace, checkpoint)
 	}
 
-	targetRanges := b.targetRangesForIndex(at, ropts, idxopts)
-	for _, target := range targetRanges {
-		logFields := b.logFields(bootstrapIndexRunType, namespace,
-			shards, target.Range)
-		b.logBootstrapRun(logFields)
+	b.progressReporter.ReportCompleted(namespace, shards, target.Range)
+	return res, nil
+}
 
-		begin := b.nowFn()
-		shardsTimeRanges := b.newShardTimeRanges(target.Range, shards)
-		res, err := b.bootstrapper.BootstrapIndex(namespace,
-			shardsTimeRanges, target.RunOptions)
+func (b bootstrapProcess) readCheckpoint(
+	namespace namespace.Metadata,
+) Checkpoint {
+	checkpointer := b.processOpts.Checkpointer()
+	if checkpointer == nil {
+		return newCheckpoint()
+	}
 
-		b.logBootstrapResult(logFields, err, begin)
-		if err != nil {
-			return nil, err
-		}
+	checkpoint, err := checkpointer.Read(namespace.ID())
+	if err != nil {
+		// NB: A checkpoint that cannot be read only means that ranges
+		// bootstrapped previously are bootstrapped again.
+		b.log.Warn("could not read bootstrap checkpoint",
+			zap.String("namespace", namespace.ID().String()),
+			zap.Error(err))
+		return newCheckpoint()
+	}
+	if checkpoint.Data == nil {
+		checkpoint.Data = result.ShardTimeRanges{}
+	}
+	if checkpoint.Index == nil {
+		checkpoint.Index = result.ShardTimeRanges{}
+	}
+	if !checkpoint.isEmpty() {
+		b.log.Info("resuming bootstrap from checkpoint",
+			zap.String("namespace", namespace.ID().String()),
+			zap.String("data", checkpoint.Data.SummaryString()),
+			zap.String("index", checkpoint.Index.SummaryString()))
+	}
+	return checkpoint
+}
 
-		bootstrapResult = result.MergedIndexBootstrapResult(bootstrapResult, res)
+func (b bootstrapProcess) writeCheckpoint(
+	namespace namespace.Metadata,
+	checkpoint Checkpoint,
+) {
+	err := b.processOpts.Checkpointer().Write(namespace.ID(), checkpoint)
+	if err != nil {
+		b.log.Warn("could not write bootstrap checkpoint",
+			zap.String("namespace", namespace.ID().String()),
+			zap.Error(err))
+	}
+}
+
+func (b bootstrapProcess) removeCheckpoint(
+	namespace namespace.Metadata,
+	shards []uint32,
+	checkpoint Checkpoint,
+) {
+	checkpointer := b.processOpts.Checkpointer()
+	if checkpointer == nil {
+		return
 	}
 
-	return bootstrapResult, nil
+	checkpoint.removeShards(shards)
+	if !checkpoint.isEmpty() {
+		// Other shards of the namespace are still to be bootstrapped.
+		b.writeCheckpoint(namespace, checkpoint)
+		return
+	}
+
+	if err := checkpointer.Remove(namespace.ID()); err != nil {
+		b.log.Warn("could not remove bootstrap checkpoint",
+			zap.String("namespace", namespace.ID().String()),
+			zap.Error(err))
+	}
+}
+
+// shouldCheckpoint returns whether a target range is persisted as flushed
+// filesets once bootstrapped, only these can be skipped when a bootstrap
+// is restarted.
+func (b bootstrapProcess) shouldCheckpoint(target TargetRange) bool {
+	if b.processOpts.Checkpointer() == nil {
+		return false
+	}
+	persistConfig := target.RunOptions.PersistConfig()
+	return persistConfig.Enabled &&
+		persistConfig.FileSetType == persist.FileSetFlushType
 }
 
 func (b bootstrapProcess) logFields(
@@ -340,18 +638,34 @@ func (b bootstrapProcess) targetRanges(
 	// bootstrap with persistence so we don't keep the full raw
 	// data in process until we finish bootstrapping which could
 	// cause the process to OOM.
-	return []TargetRange{
-		{
-			Range: xtime.Range{Start: start, End: midPoint},
-			RunOptions: b.newRunOptions().SetPersistConfig(PersistConfig{
-				Enabled: true,
-				// These blocks are no longer active, so we want to flush them
-				// to disk as we receive them so that we don't hold too much
-				// data in memory at once.
-				FileSetType: persist.FileSetFlushType,
-			}),
-		},
-		{
+	flushRunOpts := b.newRunOptions().SetPersistConfig(PersistConfig{
+		Enabled: true,
+		// These blocks are no longer active, so we want to flush them
+		// to disk as we receive them so that we don't hold too much
+		// data in memory at once.
+		FileSetType: persist.FileSetFlushType,
+	})
+	flushRanges := []xtime.Range{{Start: start, End: midPoint}}
+	if b.processOpts.Checkpointer() != nil || b.processOpts.PrioritizeRecent() {
+		// When checkpointing or prioritizing recent time ranges bootstrap
+		// each block on its own so that each block is checkpointed and
+		// available as soon as it completes.
+		flushRanges = flushRanges[:0]
+		for t := start; t.Before(midPoint); t = t.Add(opts.blockSize) {
+			flushRanges = append(flushRanges,
+				xtime.Range{Start: t, End: t.Add(opts.blockSize)})
+		}
+	}
+
+	targets := make([]TargetRange, 0, len(flushRanges)+1)
+	for _, r := range flushRanges {
+		targets = append(targets, TargetRange{
+			Range:      r,
+			RunOptions: flushRunOpts,
+		})
+	}
+	return append(targets,
+		TargetRange{
 			Range: xtime.Range{Start: midPoint, End: cutover},
 			RunOptions: b.newRunOptions().SetPersistConfig(PersistConfig{
 				Enabled: true,
@@ -361,8 +675,17 @@ func (b bootstrapProcess) targetRanges(
 				// from just the commit log bootstrapper.
 				FileSetType: persist.FileSetSnapshotType,
 			}),
-		},
+		})
+}
+
+// blockAlignedRange returns the range extended to the block boundaries of
+// the block size.
+func blockAlignedRange(window xtime.Range, blockSize time.Duration) xtime.Range {
+	end := window.End.Truncate(blockSize)
+	if end.Before(window.End) {
+		end = end.Add(blockSize)
 	}
+	return xtime.Range{Start: window.Start.Truncate(blockSize), End: end}
 }
 
 func (b bootstrapProcess) newRunOptions() RunOptions {
@@ -370,5 +693,6 @@ func (b bootstrapProcess) newRunOptions() RunOptions {
 		SetCacheSeriesMetadata(
 			b.processOpts.CacheSeriesMetadata(),
 		).
-		SetInitialTopologyState(b.initialTopologyState)
+		SetInitialTopologyState(b.initialTopologyState).
+		SetProgressReporter(b.progressReporter)
 }
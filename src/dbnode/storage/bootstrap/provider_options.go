@@ -41,6 +41,8 @@ type processOptions struct {
 	cacheSeriesMetadata bool
 	topoMapProvider     topology.MapProvider
 	origin              topology.Host
+	checkpointer        Checkpointer
+	prioritizeRecent    bool
 }
 
 // NewProcessOptions creates new bootstrap run options
@@ -93,3 +95,23 @@ func (o *processOptions) SetOrigin(value topology.Host) ProcessOptions {
 func (o *processOptions) Origin() topology.Host {
 	return o.origin
 }
+
+func (o *processOptions) SetCheckpointer(value Checkpointer) ProcessOptions {
+	opts := *o
+	opts.checkpointer = value
+	return &opts
+}
+
+func (o *processOptions) Checkpointer() Checkpointer {
+	return o.checkpointer
+}
+
+func (o *processOptions) SetPrioritizeRecent(value bool) ProcessOptions {
+	opts := *o
+	opts.prioritizeRecent = value
+	return &opts
+}
+
+func (o *processOptions) PrioritizeRecent() bool {
+	return o.prioritizeRecent
+}
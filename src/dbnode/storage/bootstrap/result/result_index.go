@@ -206,6 +206,19 @@ func NewIndexBlock(
 	}
 }
 
+// NewIndexSnapshotBlock returns a new bootstrap index block result of
+// segments read from an index snapshot, the data of which is yet to be
+// flushed to index filesets.
+func NewIndexSnapshotBlock(
+	blockStart time.Time,
+	segments []segment.Segment,
+	fulfilled ShardTimeRanges,
+) IndexBlock {
+	b := NewIndexBlock(blockStart, segments, fulfilled)
+	b.snapshotted = true
+	return b
+}
+
 // BlockStart returns the block start.
 func (b IndexBlock) BlockStart() time.Time {
 	return b.blockStart
@@ -221,6 +234,12 @@ func (b IndexBlock) Fulfilled() ShardTimeRanges {
 	return b.fulfilled
 }
 
+// Snapshotted returns whether any of the segments were read from an index
+// snapshot and so still need flushing.
+func (b IndexBlock) Snapshotted() bool {
+	return b.snapshotted
+}
+
 // Merged returns a new merged index block, currently it just appends the
 // list of segments from the other index block and the caller merges
 // as they see necessary.
@@ -233,5 +252,6 @@ func (b IndexBlock) Merged(other IndexBlock) IndexBlock {
 		r.fulfilled = b.fulfilled.Copy()
 		r.fulfilled.AddRanges(other.fulfilled)
 	}
+	r.snapshotted = b.snapshotted || other.snapshotted
 	return r
 }
@@ -67,9 +67,10 @@ type IndexResults map[xtime.UnixNano]IndexBlock
 
 // IndexBlock contains the bootstrap data structures for an index block.
 type IndexBlock struct {
-	blockStart time.Time
-	segments   []segment.Segment
-	fulfilled  ShardTimeRanges
+	blockStart  time.Time
+	segments    []segment.Segment
+	fulfilled   ShardTimeRanges
+	snapshotted bool
 }
 
 // MutableSegmentAllocator allocates a new MutableSegment type when
@@ -20,7 +20,10 @@
 
 package bootstrap
 
-import "github.com/m3db/m3/src/dbnode/topology"
+import (
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/topology"
+)
 
 var (
 	// defaultPersistConfig declares the intent to by default to perform
@@ -34,6 +37,8 @@ type runOptions struct {
 	persistConfig        PersistConfig
 	cacheSeriesMetadata  bool
 	initialTopologyState *topology.StateSnapshot
+	progressReporter     ProgressReporter
+	checkpointed         result.ShardTimeRanges
 }
 
 // NewRunOptions creates new bootstrap run options
@@ -42,6 +47,7 @@ func NewRunOptions() RunOptions {
 		persistConfig:        defaultPersistConfig,
 		cacheSeriesMetadata:  defaultCacheSeriesMetadata,
 		initialTopologyState: nil,
+		progressReporter:     NewNoOpProgressReporter(),
 	}
 }
 
@@ -74,3 +80,23 @@ func (o *runOptions) SetInitialTopologyState(value *topology.StateSnapshot) RunO
 func (o *runOptions) InitialTopologyState() *topology.StateSnapshot {
 	return o.initialTopologyState
 }
+
+func (o *runOptions) SetProgressReporter(value ProgressReporter) RunOptions {
+	opts := *o
+	opts.progressReporter = value
+	return &opts
+}
+
+func (o *runOptions) ProgressReporter() ProgressReporter {
+	return o.progressReporter
+}
+
+func (o *runOptions) SetCheckpointedShardTimeRanges(value result.ShardTimeRanges) RunOptions {
+	opts := *o
+	opts.checkpointed = value
+	return &opts
+}
+
+func (o *runOptions) CheckpointedShardTimeRanges() result.ShardTimeRanges {
+	return o.checkpointed
+}
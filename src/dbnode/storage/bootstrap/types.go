@@ -27,6 +27,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/x/ident"
 	xtime "github.com/m3db/m3/src/x/time"
 )
 
@@ -41,16 +42,51 @@ type ProcessProvider interface {
 	// running the process.
 	BootstrapperProvider() BootstrapperProvider
 
+	// SetProgressReporter sets the reporter notified of the progress of the
+	// processes constructed.
+	SetProgressReporter(value ProgressReporter)
+
+	// ProgressReporter returns the reporter notified of the progress of the
+	// processes constructed.
+	ProgressReporter() ProgressReporter
+
 	// Provide constructs a bootstrap process.
 	Provide() (Process, error)
 }
 
+// ProgressReporter is notified of the progress of bootstrap processes, the
+// time ranges of the shards of a namespace are planned when the process
+// begins bootstrapping the namespace and each is completed once bootstrapped
+// by the bootstrappers, whether or not the bootstrappers fulfilled it.
+type ProgressReporter interface {
+	// ReportPlanned reports the time ranges, data and index time ranges
+	// separately, that are going to be bootstrapped for the shards of a
+	// namespace.
+	ReportPlanned(ns namespace.Metadata, shards []uint32, ranges []xtime.Range)
+
+	// ReportBootstrapperStarted reports that a bootstrapper started
+	// bootstrapping time ranges of the shards of a namespace.
+	ReportBootstrapperStarted(ns namespace.Metadata, bootstrapper string)
+
+	// ReportCompleted reports that a planned time range of the shards of a
+	// namespace completed bootstrapping.
+	ReportCompleted(ns namespace.Metadata, shards []uint32, window xtime.Range)
+}
+
 // Process represents the bootstrap process. Note that a bootstrap process can and will
 // be reused so it is important to not rely on state stored in the bootstrap itself
 // with the mindset that it will always be set to default values from the constructor.
 type Process interface {
 	// Run runs the bootstrap process, returning the bootstrap result and any error encountered.
 	Run(start time.Time, ns namespace.Metadata, shards []uint32) (ProcessResult, error)
+
+	// RunRange runs the bootstrap process for a time range of the shards only,
+	// extended to the block boundaries, without persisting the results.
+	RunRange(ns namespace.Metadata, shards []uint32, window xtime.Range) (ProcessResult, error)
+
+	// DryRun runs the availability phase of the bootstrap process only, returning
+	// the time ranges each bootstrapper could fulfill without reading any data.
+	DryRun(start time.Time, ns namespace.Metadata, shards []uint32) (DryRunResult, error)
 }
 
 // ProcessResult is the result of a bootstrap process.
@@ -59,6 +95,49 @@ type ProcessResult struct {
 	IndexResult result.IndexBootstrapResult
 }
 
+// DryRunResult is the result of a bootstrap process dry run.
+type DryRunResult struct {
+	DataResult  AvailabilityResult
+	IndexResult AvailabilityResult
+}
+
+// AvailabilityResult describes the time ranges the bootstrappers of a
+// bootstrapper chain could fulfill.
+type AvailabilityResult struct {
+	// Bootstrappers are the time ranges fulfilled by each bootstrapper,
+	// in the order of the bootstrapper chain.
+	Bootstrappers []BootstrapperAvailability
+	// Unfulfilled are the time ranges no bootstrapper could fulfill.
+	Unfulfilled result.ShardTimeRanges
+}
+
+// BootstrapperAvailability is the time ranges a bootstrapper could fulfill.
+type BootstrapperAvailability struct {
+	Bootstrapper string
+	Fulfilled    result.ShardTimeRanges
+}
+
+// Checkpointer persists the shard time ranges of namespaces that completed
+// bootstrapping with persistence so that a bootstrap restarted before it
+// completes only reads them back from disk.
+type Checkpointer interface {
+	// Read returns the checkpoint of a namespace, it is empty if there is none.
+	Read(namespace ident.ID) (Checkpoint, error)
+
+	// Write persists the checkpoint of a namespace.
+	Write(namespace ident.ID, checkpoint Checkpoint) error
+
+	// Remove removes the checkpoint of a namespace.
+	Remove(namespace ident.ID) error
+}
+
+// Checkpoint is the shard time ranges of a namespace that completed
+// bootstrapping with persistence, data and index time ranges separately.
+type Checkpoint struct {
+	Data  result.ShardTimeRanges
+	Index result.ShardTimeRanges
+}
+
 // TargetRange is a bootstrap target range.
 type TargetRange struct {
 	// Range is the time range to bootstrap for.
@@ -100,6 +179,24 @@ type ProcessOptions interface {
 	// Origin returns the origin.
 	Origin() topology.Host
 
+	// SetCheckpointer sets the checkpointer of the time ranges that complete
+	// bootstrapping with persistence, bootstraps are not checkpointed if nil.
+	SetCheckpointer(value Checkpointer) ProcessOptions
+
+	// Checkpointer returns the checkpointer of the time ranges that complete
+	// bootstrapping with persistence, bootstraps are not checkpointed if nil.
+	Checkpointer() Checkpointer
+
+	// SetPrioritizeRecent sets whether the most recent time ranges are
+	// bootstrapped first, block by block, so that they become available
+	// before the older time ranges.
+	SetPrioritizeRecent(value bool) ProcessOptions
+
+	// PrioritizeRecent returns whether the most recent time ranges are
+	// bootstrapped first, block by block, so that they become available
+	// before the older time ranges.
+	PrioritizeRecent() bool
+
 	// Validate validates that the ProcessOptions are correct.
 	Validate() error
 }
@@ -127,6 +224,24 @@ type RunOptions interface {
 	// InitialTopologyState returns the initial topology as it was measured
 	// before the bootstrap process began.
 	InitialTopologyState() *topology.StateSnapshot
+
+	// SetProgressReporter sets the reporter notified of the progress of the
+	// bootstrap.
+	SetProgressReporter(value ProgressReporter) RunOptions
+
+	// ProgressReporter returns the reporter notified of the progress of the
+	// bootstrap.
+	ProgressReporter() ProgressReporter
+
+	// SetCheckpointedShardTimeRanges sets the shard time ranges that completed
+	// bootstrapping with persistence before the bootstrap was restarted, they
+	// are only bootstrapped by the first bootstrapper.
+	SetCheckpointedShardTimeRanges(value result.ShardTimeRanges) RunOptions
+
+	// CheckpointedShardTimeRanges returns the shard time ranges that completed
+	// bootstrapping with persistence before the bootstrap was restarted, they
+	// are only bootstrapped by the first bootstrapper.
+	CheckpointedShardTimeRanges() result.ShardTimeRanges
 }
 
 // BootstrapperProvider constructs a bootstrapper.
@@ -177,6 +292,22 @@ type Bootstrapper interface {
 		shardsTimeRanges result.ShardTimeRanges,
 		opts RunOptions,
 	) (result.IndexBootstrapResult, error)
+
+	// AvailableData returns the time ranges of data the bootstrapper and the
+	// bootstrappers that follow it could fulfill, without reading any data.
+	AvailableData(
+		ns namespace.Metadata,
+		shardsTimeRanges result.ShardTimeRanges,
+		opts RunOptions,
+	) (AvailabilityResult, error)
+
+	// AvailableIndex returns the time ranges of index blocks the bootstrapper and
+	// the bootstrappers that follow it could fulfill, without reading any data.
+	AvailableIndex(
+		ns namespace.Metadata,
+		shardsTimeRanges result.ShardTimeRanges,
+		opts RunOptions,
+	) (AvailabilityResult, error)
 }
 
 // Source represents a bootstrap source. Note that a source can and will be reused so
@@ -0,0 +1,270 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+// bootstrapProgress tracks the progress of the bootstrap of the database as
+// reported by the bootstrap processes, the progress of a shard is the share
+// of the duration of its planned time ranges that completed bootstrapping.
+type bootstrapProgress struct {
+	sync.RWMutex
+
+	nowFn      clock.NowFn
+	start      time.Time
+	namespaces []*namespaceBootstrapProgress
+}
+
+type namespaceBootstrapProgress struct {
+	namespace    string
+	bootstrapper string
+	shards       map[uint32]*shardBootstrapProgress
+}
+
+type shardBootstrapProgress struct {
+	planned       time.Duration
+	plannedRanges xtime.Ranges
+	completed     time.Duration
+	remaining     []xtime.Range
+}
+
+// availableRanges returns the planned time ranges that are no longer
+// covered by any remaining data or index time range.
+func (s *shardBootstrapProgress) availableRanges() xtime.Ranges {
+	available := s.plannedRanges
+	for _, r := range s.remaining {
+		available = available.RemoveRange(r)
+	}
+	return available
+}
+
+func newBootstrapProgress(nowFn clock.NowFn) *bootstrapProgress {
+	return &bootstrapProgress{nowFn: nowFn}
+}
+
+// reset resets the progress at the start of a bootstrap.
+func (p *bootstrapProgress) reset() {
+	p.Lock()
+	p.start = p.nowFn()
+	p.namespaces = nil
+	p.Unlock()
+}
+
+func (p *bootstrapProgress) ReportPlanned(
+	ns namespace.Metadata,
+	shards []uint32,
+	ranges []xtime.Range,
+) {
+	var (
+		planned       time.Duration
+		plannedRanges xtime.Ranges
+	)
+	for _, r := range ranges {
+		planned += r.Duration()
+		plannedRanges = plannedRanges.AddRange(r)
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	nsProgress := p.namespaceWithLock(ns)
+	for _, shard := range shards {
+		nsProgress.shards[shard] = &shardBootstrapProgress{
+			planned:       planned,
+			plannedRanges: plannedRanges,
+			remaining:     append([]xtime.Range(nil), ranges...),
+		}
+	}
+}
+
+func (p *bootstrapProgress) ReportBootstrapperStarted(
+	ns namespace.Metadata,
+	bootstrapper string,
+) {
+	p.Lock()
+	p.namespaceWithLock(ns).bootstrapper = bootstrapper
+	p.Unlock()
+}
+
+func (p *bootstrapProgress) ReportCompleted(
+	ns namespace.Metadata,
+	shards []uint32,
+	window xtime.Range,
+) {
+	p.Lock()
+	defer p.Unlock()
+
+	nsProgress := p.namespaceWithLock(ns)
+	for _, shard := range shards {
+		shardProgress, ok := nsProgress.shards[shard]
+		if !ok {
+			continue
+		}
+		for i, r := range shardProgress.remaining {
+			if r.Equal(window) {
+				shardProgress.remaining = append(shardProgress.remaining[:i],
+					shardProgress.remaining[i+1:]...)
+				shardProgress.completed += window.Duration()
+				break
+			}
+		}
+	}
+}
+
+func (p *bootstrapProgress) namespaceWithLock(
+	ns namespace.Metadata,
+) *namespaceBootstrapProgress {
+	id := ns.ID().String()
+	for _, nsProgress := range p.namespaces {
+		if nsProgress.namespace == id {
+			return nsProgress
+		}
+	}
+	nsProgress := &namespaceBootstrapProgress{
+		namespace: id,
+		shards:    make(map[uint32]*shardBootstrapProgress),
+	}
+	p.namespaces = append(p.namespaces, nsProgress)
+	return nsProgress
+}
+
+// snapshot returns the progress of the bootstrap given its state.
+func (p *bootstrapProgress) snapshot(state BootstrapState) BootstrapProgress {
+	p.RLock()
+	defer p.RUnlock()
+
+	var (
+		result = BootstrapProgress{
+			State:      state,
+			Start:      p.start,
+			Namespaces: make([]NamespaceBootstrapProgress, 0, len(p.namespaces)),
+		}
+		planned, completed time.Duration
+	)
+	for _, nsProgress := range p.namespaces {
+		var (
+			nsResult = NamespaceBootstrapProgress{
+				Namespace:    nsProgress.namespace,
+				Bootstrapper: nsProgress.bootstrapper,
+				Shards:       make([]ShardBootstrapProgress, 0, len(nsProgress.shards)),
+			}
+			nsPlanned, nsCompleted time.Duration
+			nsAvailable            xtime.Ranges
+		)
+		for shard, shardProgress := range nsProgress.shards {
+			available := shardProgress.availableRanges()
+			nsResult.Shards = append(nsResult.Shards, ShardBootstrapProgress{
+				Shard: shard,
+				PercentComplete: percentComplete(shardProgress.completed,
+					shardProgress.planned, state),
+				RemainingRanges: append([]xtime.Range(nil),
+					shardProgress.remaining...),
+				AvailableRanges: rangesSlice(available),
+			})
+			nsPlanned += shardProgress.planned
+			nsCompleted += shardProgress.completed
+			nsAvailable = nsAvailable.AddRanges(shardProgress.plannedRanges)
+		}
+		// A time range is available for the namespace once it is available
+		// for all of its shards.
+		for _, shardProgress := range nsProgress.shards {
+			for _, r := range shardProgress.remaining {
+				nsAvailable = nsAvailable.RemoveRange(r)
+			}
+		}
+		nsResult.AvailableFrom = availableFrom(nsAvailable, nsProgress.shards)
+		sort.Slice(nsResult.Shards, func(i, j int) bool {
+			return nsResult.Shards[i].Shard < nsResult.Shards[j].Shard
+		})
+		nsResult.PercentComplete = percentComplete(nsCompleted, nsPlanned, state)
+		result.Namespaces = append(result.Namespaces, nsResult)
+		planned += nsPlanned
+		completed += nsCompleted
+	}
+
+	result.PercentComplete = percentComplete(completed, planned, state)
+	if state == Bootstrapping && completed > 0 && completed < planned {
+		// Estimate the completion assuming the remaining time ranges
+		// bootstrap at the same rate as those completed so far.
+		elapsed := p.nowFn().Sub(p.start)
+		total := time.Duration(float64(elapsed) * float64(planned) / float64(completed))
+		result.EstimatedCompletion = p.start.Add(total)
+	}
+	return result
+}
+
+func percentComplete(
+	completed, planned time.Duration,
+	state BootstrapState,
+) float64 {
+	if planned == 0 {
+		if state == Bootstrapped {
+			return 100
+		}
+		return 0
+	}
+	return 100 * float64(completed) / float64(planned)
+}
+
+// availableFrom returns the start of the available time range that extends
+// to the end of the planned time ranges, or zero if the most recent planned
+// time range is not yet available.
+func availableFrom(
+	available xtime.Ranges,
+	shards map[uint32]*shardBootstrapProgress,
+) time.Time {
+	var plannedEnd time.Time
+	for _, shardProgress := range shards {
+		it := shardProgress.plannedRanges.Iter()
+		for it.Next() {
+			if end := it.Value().End; end.After(plannedEnd) {
+				plannedEnd = end
+			}
+		}
+	}
+
+	it := available.Iter()
+	for it.Next() {
+		if r := it.Value(); r.End.Equal(plannedEnd) {
+			return r.Start
+		}
+	}
+	return time.Time{}
+}
+
+func rangesSlice(ranges xtime.Ranges) []xtime.Range {
+	if ranges.IsEmpty() {
+		return nil
+	}
+	result := make([]xtime.Range, 0, ranges.Len())
+	it := ranges.Iter()
+	for it.Next() {
+		result = append(result, it.Value())
+	}
+	return result
+}
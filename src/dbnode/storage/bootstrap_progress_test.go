@@ -0,0 +1,137 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootstrapProgress(t *testing.T) {
+	start := time.Now().Truncate(time.Hour)
+	now := start
+	progress := newBootstrapProgress(func() time.Time { return now })
+
+	snapshot := progress.snapshot(BootstrapNotStarted)
+	require.Equal(t, float64(0), snapshot.PercentComplete)
+	require.Empty(t, snapshot.Namespaces)
+
+	ns, err := namespace.NewMetadata(ident.StringID("ns"), namespace.NewOptions())
+	require.NoError(t, err)
+
+	var (
+		first  = xtime.Range{Start: start.Add(-4 * time.Hour), End: start.Add(-time.Hour)}
+		second = xtime.Range{Start: start.Add(-time.Hour), End: start}
+	)
+	progress.reset()
+	progress.ReportPlanned(ns, []uint32{2, 1}, []xtime.Range{first, second})
+	progress.ReportBootstrapperStarted(ns, "filesystem")
+
+	now = now.Add(time.Minute)
+	progress.ReportCompleted(ns, []uint32{1, 2}, first)
+
+	snapshot = progress.snapshot(Bootstrapping)
+	require.Equal(t, Bootstrapping, snapshot.State)
+	require.Equal(t, start, snapshot.Start)
+	require.Equal(t, float64(75), snapshot.PercentComplete)
+	require.True(t, start.Add(80*time.Second).Equal(snapshot.EstimatedCompletion))
+	require.Equal(t, []NamespaceBootstrapProgress{
+		{
+			Namespace:       "ns",
+			Bootstrapper:    "filesystem",
+			PercentComplete: 75,
+			Shards: []ShardBootstrapProgress{
+				{
+					Shard:           1,
+					PercentComplete: 75,
+					RemainingRanges: []xtime.Range{second},
+					AvailableRanges: []xtime.Range{first},
+				},
+				{
+					Shard:           2,
+					PercentComplete: 75,
+					RemainingRanges: []xtime.Range{second},
+					AvailableRanges: []xtime.Range{first},
+				},
+			},
+		},
+	}, snapshot.Namespaces)
+
+	progress.ReportCompleted(ns, []uint32{1, 2}, second)
+
+	snapshot = progress.snapshot(Bootstrapped)
+	require.Equal(t, float64(100), snapshot.PercentComplete)
+	require.True(t, snapshot.EstimatedCompletion.IsZero())
+	require.Equal(t, first.Start, snapshot.Namespaces[0].AvailableFrom)
+	for _, shard := range snapshot.Namespaces[0].Shards {
+		require.Equal(t, float64(100), shard.PercentComplete)
+		require.Empty(t, shard.RemainingRanges)
+		require.Equal(t, []xtime.Range{{Start: first.Start, End: second.End}},
+			shard.AvailableRanges)
+	}
+}
+
+func TestBootstrapProgressAvailableFromMostRecent(t *testing.T) {
+	start := time.Now().Truncate(time.Hour)
+	progress := newBootstrapProgress(time.Now)
+
+	ns, err := namespace.NewMetadata(ident.StringID("ns"), namespace.NewOptions())
+	require.NoError(t, err)
+
+	var (
+		oldest = xtime.Range{Start: start.Add(-3 * time.Hour), End: start.Add(-2 * time.Hour)}
+		older  = xtime.Range{Start: start.Add(-2 * time.Hour), End: start.Add(-time.Hour)}
+		recent = xtime.Range{Start: start.Add(-time.Hour), End: start}
+		index  = xtime.Range{Start: start.Add(-2 * time.Hour), End: start}
+	)
+	progress.reset()
+	progress.ReportPlanned(ns, []uint32{1, 2},
+		[]xtime.Range{recent, older, oldest, index})
+
+	// The most recent data is not available until its index completes.
+	progress.ReportCompleted(ns, []uint32{1, 2}, recent)
+	snapshot := progress.snapshot(Bootstrapping)
+	require.True(t, snapshot.Namespaces[0].AvailableFrom.IsZero())
+	require.Empty(t, snapshot.Namespaces[0].Shards[0].AvailableRanges)
+
+	progress.ReportCompleted(ns, []uint32{1, 2}, index)
+	snapshot = progress.snapshot(Bootstrapping)
+	require.Equal(t, recent.Start, snapshot.Namespaces[0].AvailableFrom)
+	require.Equal(t, []xtime.Range{recent},
+		snapshot.Namespaces[0].Shards[0].AvailableRanges)
+
+	// Older ranges extend the available window once they complete for all
+	// of the shards.
+	progress.ReportCompleted(ns, []uint32{1}, older)
+	snapshot = progress.snapshot(Bootstrapping)
+	require.Equal(t, recent.Start, snapshot.Namespaces[0].AvailableFrom)
+	require.Equal(t, []xtime.Range{{Start: older.Start, End: recent.End}},
+		snapshot.Namespaces[0].Shards[0].AvailableRanges)
+
+	progress.ReportCompleted(ns, []uint32{2}, older)
+	snapshot = progress.snapshot(Bootstrapping)
+	require.Equal(t, older.Start, snapshot.Namespaces[0].AvailableFrom)
+}
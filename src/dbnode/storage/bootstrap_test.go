@@ -26,7 +26,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -114,3 +117,73 @@ func TestDatabaseBootstrapSubsequentCallsQueued(t *testing.T) {
 	err := bsm.Bootstrap()
 	require.Nil(t, err)
 }
+
+func TestDatabaseBootstrapDryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := DefaultTestOptions()
+	now := time.Now()
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return now
+	}))
+
+	unfulfilled := result.ShardTimeRanges{
+		0: xtime.NewRanges(xtime.Range{Start: now.Add(-time.Hour), End: now}),
+	}
+	dryRun := bootstrap.DryRunResult{
+		DataResult: bootstrap.AvailabilityResult{
+			Bootstrappers: []bootstrap.BootstrapperAvailability{{
+				Bootstrapper: "filesystem",
+				Fulfilled:    result.ShardTimeRanges{},
+			}},
+			Unfulfilled: unfulfilled,
+		},
+	}
+
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().BootstrapDryRun(now, gomock.Any()).Return(dryRun, nil)
+	ns.EXPECT().ID().Return(ident.StringID("test"))
+
+	db := NewMockdatabase(ctrl)
+	db.EXPECT().GetOwnedNamespaces().Return([]databaseNamespace{ns}, nil)
+
+	m := NewMockdatabaseMediator(ctrl)
+	bsm := newBootstrapManager(db, m, opts).(*bootstrapManager)
+
+	results, err := bsm.BootstrapDryRun()
+	require.NoError(t, err)
+	require.Equal(t, []NamespaceBootstrapDryRun{{
+		Namespace:   "test",
+		DataResult:  dryRun.DataResult,
+		IndexResult: dryRun.IndexResult,
+	}}, results)
+
+	// The dry run does not bootstrap the database.
+	require.False(t, bsm.IsBootstrapped())
+}
+
+func TestDatabaseBootstrapDryRunError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := DefaultTestOptions()
+	now := time.Now()
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return now
+	}))
+
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().BootstrapDryRun(now, gomock.Any()).
+		Return(bootstrap.DryRunResult{}, fmt.Errorf("an error"))
+
+	db := NewMockdatabase(ctrl)
+	db.EXPECT().GetOwnedNamespaces().Return([]databaseNamespace{ns}, nil)
+
+	m := NewMockdatabaseMediator(ctrl)
+	bsm := newBootstrapManager(db, m, opts).(*bootstrapManager)
+
+	_, err := bsm.BootstrapDryRun()
+	require.Error(t, err)
+	require.Equal(t, "an error", err.Error())
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/x/hll"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/cespare/xxhash"
+	"github.com/uber-go/tally"
+)
+
+var (
+	errCardinalityPrecision = fmt.Errorf("cardinality precision must be zero or between %d and %d",
+		hll.MinPrecision, hll.MaxPrecision)
+
+	// errCardinalityDisabled is returned when the cardinalities are requested
+	// while they are not tracked.
+	errCardinalityDisabled = errors.New("cardinality tracking is disabled")
+)
+
+// CardinalityOptions are the options for estimating the number of distinct
+// series written to each data block and indexed in each index block of each
+// shard.
+type CardinalityOptions struct {
+	// Precision is the precision of the HyperLogLog sketches estimating the
+	// cardinalities, each sketch uses 2^Precision bytes and has a relative
+	// standard error of 1.04/sqrt(2^Precision), zero disables tracking.
+	Precision int
+}
+
+// Enabled returns whether cardinalities are tracked.
+func (o CardinalityOptions) Enabled() bool {
+	return o.Precision > 0
+}
+
+// Validate validates the cardinality options.
+func (o CardinalityOptions) Validate() error {
+	if o.Precision != 0 &&
+		(o.Precision < hll.MinPrecision || o.Precision > hll.MaxPrecision) {
+		return errCardinalityPrecision
+	}
+	return nil
+}
+
+// BlockCardinality is the estimated number of distinct series of a block.
+type BlockCardinality struct {
+	BlockStart time.Time
+	Series     uint64
+}
+
+// BlockCardinalities are the estimated cardinalities of the data and index
+// blocks of a shard, ordered by block start.
+type BlockCardinalities struct {
+	Data  []BlockCardinality
+	Index []BlockCardinality
+}
+
+// shardCardinality estimates the number of distinct series written to each
+// data block and indexed in each index block of a shard, all methods are
+// no-ops on a nil shardCardinality so that shards need not check whether
+// tracking is enabled.
+type shardCardinality struct {
+	sync.Mutex
+
+	precision int
+	data      map[xtime.UnixNano]*hll.Sketch
+	index     map[xtime.UnixNano]*hll.Sketch
+	metrics   shardCardinalityMetrics
+}
+
+type shardCardinalityMetrics struct {
+	blocks        tally.Gauge
+	series        tally.Gauge
+	indexedSeries tally.Gauge
+}
+
+func newShardCardinality(
+	shard uint32,
+	opts CardinalityOptions,
+	scope tally.Scope,
+) *shardCardinality {
+	if !opts.Enabled() {
+		return nil
+	}
+
+	scope = scope.SubScope("cardinality").Tagged(map[string]string{
+		"shard": fmt.Sprintf("%d", shard),
+	})
+	return &shardCardinality{
+		precision: opts.Precision,
+		data:      make(map[xtime.UnixNano]*hll.Sketch),
+		index:     make(map[xtime.UnixNano]*hll.Sketch),
+		metrics: shardCardinalityMetrics{
+			blocks:        scope.Gauge("blocks"),
+			series:        scope.Gauge("series"),
+			indexedSeries: scope.Gauge("indexed-series"),
+		},
+	}
+}
+
+// RecordWrite records a write of the series to the data block and, if the
+// series is indexed, to the index block.
+func (c *shardCardinality) RecordWrite(
+	id ident.ID,
+	blockStart time.Time,
+	indexed bool,
+	indexBlockStart time.Time,
+) {
+	if c == nil {
+		return
+	}
+
+	hash := xxhash.Sum64(id.Bytes())
+	c.Lock()
+	c.sketchWithLock(c.data, blockStart).AddHash(hash)
+	if indexed {
+		c.sketchWithLock(c.index, indexBlockStart).AddHash(hash)
+	}
+	c.Unlock()
+}
+
+func (c *shardCardinality) sketchWithLock(
+	sketches map[xtime.UnixNano]*hll.Sketch,
+	blockStart time.Time,
+) *hll.Sketch {
+	key := xtime.ToUnixNano(blockStart)
+	sketch, ok := sketches[key]
+	if !ok {
+		// The precision was validated with the options.
+		sketch, _ = hll.NewSketch(c.precision)
+		sketches[key] = sketch
+	}
+	return sketch
+}
+
+// Tick removes the sketches of the blocks starting before the earliest block
+// start still retained and reports the cardinalities of the latest blocks.
+func (c *shardCardinality) Tick(earliestBlockStart, earliestIndexBlockStart time.Time) {
+	if c == nil {
+		return
+	}
+
+	c.Lock()
+	expireSketchesWithLock(c.data, earliestBlockStart)
+	expireSketchesWithLock(c.index, earliestIndexBlockStart)
+	var (
+		numBlocks     = len(c.data) + len(c.index)
+		series        = latestEstimateWithLock(c.data)
+		indexedSeries = latestEstimateWithLock(c.index)
+	)
+	c.Unlock()
+
+	c.metrics.blocks.Update(float64(numBlocks))
+	c.metrics.series.Update(float64(series))
+	c.metrics.indexedSeries.Update(float64(indexedSeries))
+}
+
+// Cardinalities returns the estimated cardinalities of the blocks starting
+// in the range [start, end).
+func (c *shardCardinality) Cardinalities(start, end time.Time) BlockCardinalities {
+	if c == nil {
+		return BlockCardinalities{}
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	return BlockCardinalities{
+		Data:  estimatesWithLock(c.data, start, end),
+		Index: estimatesWithLock(c.index, start, end),
+	}
+}
+
+func expireSketchesWithLock(
+	sketches map[xtime.UnixNano]*hll.Sketch,
+	earliestBlockStart time.Time,
+) {
+	earliest := xtime.ToUnixNano(earliestBlockStart)
+	for blockStart := range sketches {
+		if blockStart < earliest {
+			delete(sketches, blockStart)
+		}
+	}
+}
+
+func latestEstimateWithLock(sketches map[xtime.UnixNano]*hll.Sketch) uint64 {
+	var (
+		latest xtime.UnixNano
+		sketch *hll.Sketch
+	)
+	for blockStart, s := range sketches {
+		if sketch == nil || blockStart > latest {
+			latest, sketch = blockStart, s
+		}
+	}
+	if sketch == nil {
+		return 0
+	}
+	return sketch.Estimate()
+}
+
+func estimatesWithLock(
+	sketches map[xtime.UnixNano]*hll.Sketch,
+	start, end time.Time,
+) []BlockCardinality {
+	var (
+		from    = xtime.ToUnixNano(start)
+		to      = xtime.ToUnixNano(end)
+		results []BlockCardinality
+	)
+	for blockStart, sketch := range sketches {
+		if blockStart < from || blockStart >= to {
+			continue
+		}
+		results = append(results, BlockCardinality{
+			BlockStart: blockStart.ToTime(),
+			Series:     sketch.Estimate(),
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].BlockStart.Before(results[j].BlockStart)
+	})
+	return results
+}
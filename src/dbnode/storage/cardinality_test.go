@@ -0,0 +1,103 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestCardinalityOptionsValidate(t *testing.T) {
+	require.NoError(t, CardinalityOptions{}.Validate())
+	require.NoError(t, CardinalityOptions{Precision: 12}.Validate())
+	require.Error(t, CardinalityOptions{Precision: 2}.Validate())
+	require.Error(t, CardinalityOptions{Precision: 20}.Validate())
+}
+
+func TestShardCardinalityDisabled(t *testing.T) {
+	c := newShardCardinality(0, CardinalityOptions{}, tally.NoopScope)
+	require.Nil(t, c)
+
+	// All methods are no-ops on a nil tracker.
+	now := time.Now()
+	c.RecordWrite(ident.StringID("foo"), now, true, now)
+	c.Tick(now, now)
+	require.Equal(t, BlockCardinalities{}, c.Cardinalities(now, now.Add(time.Hour)))
+}
+
+func TestShardCardinality(t *testing.T) {
+	var (
+		scope          = tally.NewTestScope("", nil)
+		c              = newShardCardinality(3, CardinalityOptions{Precision: 14}, scope)
+		blockSize      = 2 * time.Hour
+		indexBlockSize = 4 * time.Hour
+		start          = time.Now().Truncate(indexBlockSize)
+	)
+
+	// Every series is written to both data blocks of the index block, only
+	// the first half of them are indexed.
+	for i := 0; i < 1000; i++ {
+		id := ident.StringID(fmt.Sprintf("series-%d", i))
+		for j := 0; j < 2; j++ {
+			c.RecordWrite(id, start, i < 500, start)
+			c.RecordWrite(id, start.Add(blockSize), i < 500, start)
+		}
+	}
+	c.RecordWrite(ident.StringID("new"), start.Add(indexBlockSize), false, time.Time{})
+
+	requireCardinality := func(expected uint64, actual BlockCardinality) {
+		require.InDelta(t, float64(expected), float64(actual.Series), 0.05*float64(expected))
+	}
+
+	result := c.Cardinalities(start, start.Add(2*indexBlockSize))
+	require.Len(t, result.Data, 3)
+	require.Equal(t, start, result.Data[0].BlockStart)
+	requireCardinality(1000, result.Data[0])
+	require.Equal(t, start.Add(blockSize), result.Data[1].BlockStart)
+	requireCardinality(1000, result.Data[1])
+	require.Equal(t, start.Add(indexBlockSize), result.Data[2].BlockStart)
+	require.Equal(t, uint64(1), result.Data[2].Series)
+	require.Len(t, result.Index, 1)
+	require.Equal(t, start, result.Index[0].BlockStart)
+	requireCardinality(500, result.Index[0])
+
+	// Only the blocks starting in the range are returned.
+	result = c.Cardinalities(start.Add(blockSize), start.Add(indexBlockSize))
+	require.Len(t, result.Data, 1)
+	require.Len(t, result.Index, 0)
+
+	// Blocks no longer retained are expired on tick and the latest blocks
+	// are reported.
+	c.Tick(start.Add(blockSize), start.Add(indexBlockSize))
+	result = c.Cardinalities(start, start.Add(2*indexBlockSize))
+	require.Len(t, result.Data, 2)
+	require.Len(t, result.Index, 0)
+
+	gauges := scope.Snapshot().Gauges()
+	require.Equal(t, float64(2), gauges["cardinality.blocks+shard=3"].Value())
+	require.Equal(t, float64(1), gauges["cardinality.series+shard=3"].Value())
+	require.Equal(t, float64(0), gauges["cardinality.indexed-series+shard=3"].Value())
+}
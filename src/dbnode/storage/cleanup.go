@@ -34,7 +34,6 @@ import (
 	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
 
-	"github.com/pborman/uuid"
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
 )
@@ -44,6 +43,10 @@ type snapshotMetadataFilesFn func(fs.Options) ([]fs.SnapshotMetadata, []fs.Snaps
 
 type snapshotFilesFn func(filePathPrefix string, namespace ident.ID, shard uint32) (fs.FileSetFilesSlice, error)
 
+type dataFilesFn func(filePathPrefix string, namespace ident.ID, shard uint32) (fs.FileSetFilesSlice, error)
+
+type indexFilesFn func(filePathPrefix string, namespace ident.ID) (fs.FileSetFilesSlice, error)
+
 type deleteFilesFn func(files []string) error
 
 type deleteInactiveDirectoriesFn func(parentDirPath string, activeDirNames []string) error
@@ -62,11 +65,16 @@ type cleanupManager struct {
 
 	opts                    Options
 	nowFn                   clock.NowFn
+	fsOpts                  fs.Options
 	filePathPrefix          string
 	commitLogsDir           string
 	commitLogFilesFn        commitLogFilesFn
 	snapshotMetadataFilesFn snapshotMetadataFilesFn
 	snapshotFilesFn         snapshotFilesFn
+	dataFilesFn             dataFilesFn
+	indexFilesFn            indexFilesFn
+	dataAge                 *dataAgeTracker
+	diskUsage               *diskUsageTracker
 
 	deleteFilesFn               deleteFilesFn
 	deleteInactiveDirectoriesFn deleteInactiveDirectoriesFn
@@ -101,8 +109,11 @@ func newCleanupManagerMetrics(scope tally.Scope) cleanupManagerMetrics {
 
 func newCleanupManager(
 	database database, activeLogs activeCommitlogs, scope tally.Scope) databaseCleanupManager {
-	opts := database.Options()
-	filePathPrefix := opts.CommitLogOptions().FilesystemOptions().FilePathPrefix()
+	var (
+		opts           = database.Options()
+		fsOpts         = opts.CommitLogOptions().FilesystemOptions()
+		filePathPrefix = fsOpts.FilePathPrefix()
+	)
 	commitLogsDir := fs.CommitLogsDirPath(filePathPrefix)
 
 	return &cleanupManager{
@@ -111,11 +122,16 @@ func newCleanupManager(
 
 		opts:                        opts,
 		nowFn:                       opts.ClockOptions().NowFn(),
+		fsOpts:                      fsOpts,
 		filePathPrefix:              filePathPrefix,
 		commitLogsDir:               commitLogsDir,
 		commitLogFilesFn:            commitlog.Files,
 		snapshotMetadataFilesFn:     fs.SortedSnapshotMetadataFiles,
 		snapshotFilesFn:             fs.SnapshotFiles,
+		dataFilesFn:                 fs.DataFiles,
+		indexFilesFn:                fs.IndexFiles,
+		dataAge:                     newDataAgeTracker(opts.DataAgeBucketBoundaries()),
+		diskUsage:                   newDiskUsageTracker(),
 		deleteFilesFn:               fs.DeleteFiles,
 		deleteInactiveDirectoriesFn: fs.DeleteInactiveDirectories,
 		metrics:                     newCleanupManagerMetrics(scope),
@@ -164,9 +180,122 @@ func (m *cleanupManager) Cleanup(t time.Time) error {
 			"encountered errors when cleaning up snapshot and commitlog files: %v", err))
 	}
 
+	if err := m.updateDataAgeHeatmaps(t); err != nil {
+		multiErr = multiErr.Add(fmt.Errorf(
+			"encountered errors when updating data age heatmaps for %v: %v", t, err))
+	}
+
+	if err := m.updateDiskUsage(t); err != nil {
+		multiErr = multiErr.Add(fmt.Errorf(
+			"encountered errors when updating disk usage for %v: %v", t, err))
+	}
+
+	return multiErr.FinalError()
+}
+
+func (m *cleanupManager) DataAgeHeatmap(namespace ident.ID) (DataAgeHeatmap, bool) {
+	return m.dataAge.Heatmap(namespace)
+}
+
+// updateDataAgeHeatmaps updates the data age heatmaps of all owned namespaces
+// once the expired and compacted filesets have been removed. Only the sizes of
+// volumes that were not observed during a previous cleanup are computed.
+func (m *cleanupManager) updateDataAgeHeatmaps(t time.Time) error {
+	namespaces, err := m.database.GetOwnedNamespaces()
+	if err != nil {
+		return err
+	}
+
+	var (
+		multiErr = xerrors.NewMultiError()
+		owned    = make(map[string]map[uint32]struct{}, len(namespaces))
+	)
+	for _, n := range namespaces {
+		ownedShards := make(map[uint32]struct{})
+		owned[n.ID().String()] = ownedShards
+		for _, shard := range n.GetOwnedShards() {
+			ownedShards[shard.ID()] = struct{}{}
+			files, err := m.dataFilesFn(m.fsOpts.NamespaceFilePathPrefix(n.ID()), n.ID(), shard.ID())
+			if err != nil {
+				multiErr = multiErr.Add(err)
+				continue
+			}
+			if err := m.dataAge.UpdateShard(n.ID(), shard.ID(), files); err != nil {
+				multiErr = multiErr.Add(err)
+			}
+		}
+	}
+
+	m.dataAge.RetainShards(owned)
+	for _, n := range namespaces {
+		m.dataAge.Recompute(n.ID(), t)
+	}
+
 	return multiErr.FinalError()
 }
 
+func (m *cleanupManager) DiskUsage(namespace ident.ID) (NamespaceDiskUsage, bool) {
+	return m.diskUsage.Usage(namespace)
+}
+
+// updateDiskUsage refreshes the disk usage breakdown of all owned namespaces
+// once expired files have been removed. The usage is only updated if all the
+// files could be listed so that a partial listing is never reported.
+func (m *cleanupManager) updateDiskUsage(t time.Time) error {
+	namespaces, err := m.database.GetOwnedNamespaces()
+	if err != nil {
+		return err
+	}
+
+	var (
+		multiErr = xerrors.NewMultiError()
+		nsFiles  = make([]diskUsageNamespaceFiles, 0, len(namespaces))
+	)
+	for _, n := range namespaces {
+		index, err := m.indexFilesFn(m.fsOpts.NamespaceFilePathPrefix(n.ID()), n.ID())
+		if err != nil {
+			multiErr = multiErr.Add(err)
+		}
+
+		shards := n.GetOwnedShards()
+		files := diskUsageNamespaceFiles{
+			namespace: n.ID(),
+			index:     index,
+			shards:    make([]diskUsageShardFiles, 0, len(shards)),
+		}
+		for _, shard := range shards {
+			data, err := m.dataFilesFn(m.fsOpts.NamespaceFilePathPrefix(n.ID()), n.ID(), shard.ID())
+			if err != nil {
+				multiErr = multiErr.Add(err)
+			}
+			snapshots, err := m.snapshotFilesFn(m.fsOpts.NamespaceFilePathPrefix(n.ID()), n.ID(), shard.ID())
+			if err != nil {
+				multiErr = multiErr.Add(err)
+			}
+			files.shards = append(files.shards, diskUsageShardFiles{
+				shard:     shard.ID(),
+				data:      data,
+				snapshots: snapshots,
+			})
+		}
+		nsFiles = append(nsFiles, files)
+	}
+
+	commitLogs, _, err := m.commitLogFilesFn(m.opts.CommitLogOptions())
+	if err != nil {
+		multiErr = multiErr.Add(err)
+	}
+	if !multiErr.Empty() {
+		return multiErr.FinalError()
+	}
+
+	commitLogFiles := make([]string, 0, len(commitLogs))
+	for _, commitLog := range commitLogs {
+		commitLogFiles = append(commitLogFiles, commitLog.FilePath)
+	}
+	return m.diskUsage.Update(t, nsFiles, commitLogFiles)
+}
+
 func (m *cleanupManager) Report() {
 	m.RLock()
 	cleanupInProgress := m.cleanupInProgress
@@ -181,8 +310,7 @@ func (m *cleanupManager) Report() {
 
 func (m *cleanupManager) deleteInactiveNamespaceFiles() error {
 	var namespaceDirNames []string
-	filePathPrefix := m.database.Options().CommitLogOptions().FilesystemOptions().FilePathPrefix()
-	dataDirPath := fs.DataDirPath(filePathPrefix)
+	fsOpts := m.database.Options().CommitLogOptions().FilesystemOptions()
 	namespaces, err := m.database.GetOwnedNamespaces()
 	if err != nil {
 		return err
@@ -192,7 +320,25 @@ func (m *cleanupManager) deleteInactiveNamespaceFiles() error {
 		namespaceDirNames = append(namespaceDirNames, n.ID().String())
 	}
 
-	return m.deleteInactiveDirectoriesFn(dataDirPath, namespaceDirNames)
+	// Namespaces routed to another file path prefix keep their data directory
+	// under that prefix, so inactive namespaces are removed from each of them.
+	var (
+		multiErr       = xerrors.NewMultiError()
+		filePathPrefix = fsOpts.FilePathPrefix()
+		visited        = map[string]struct{}{filePathPrefix: {}}
+	)
+	multiErr = multiErr.Add(m.deleteInactiveDirectoriesFn(
+		fs.DataDirPath(filePathPrefix), namespaceDirNames))
+	for _, prefix := range fsOpts.NamespaceFilePathPrefixes() {
+		if _, ok := visited[prefix]; ok {
+			continue
+		}
+		visited[prefix] = struct{}{}
+		multiErr = multiErr.Add(m.deleteInactiveDirectoriesFn(
+			fs.DataDirPath(prefix), namespaceDirNames))
+	}
+
+	return multiErr.FinalError()
 }
 
 // deleteInactiveDataFiles will delete data files for shards that the node no longer owns
@@ -209,14 +355,14 @@ func (m *cleanupManager) deleteInactiveDataSnapshotFiles() error {
 
 func (m *cleanupManager) deleteInactiveDataFileSetFiles(filesetFilesDirPathFn func(string, ident.ID) string) error {
 	multiErr := xerrors.NewMultiError()
-	filePathPrefix := m.database.Options().CommitLogOptions().FilesystemOptions().FilePathPrefix()
+	fsOpts := m.database.Options().CommitLogOptions().FilesystemOptions()
 	namespaces, err := m.database.GetOwnedNamespaces()
 	if err != nil {
 		return err
 	}
 	for _, n := range namespaces {
 		var activeShards []string
-		namespaceDirPath := filesetFilesDirPathFn(filePathPrefix, n.ID())
+		namespaceDirPath := filesetFilesDirPathFn(fsOpts.NamespaceFilePathPrefix(n.ID()), n.ID())
 		for _, s := range n.GetOwnedShards() {
 			shard := fmt.Sprintf("%d", s.ID())
 			activeShards = append(activeShards, shard)
@@ -237,7 +383,7 @@ func (m *cleanupManager) cleanupDataFiles(t time.Time) error {
 		if !n.Options().CleanupEnabled() {
 			continue
 		}
-		earliestToRetain := retention.FlushTimeStart(n.Options().RetentionOptions(), t)
+		earliestToRetain := retention.CleanupTimeStart(n.Options().RetentionOptions(), t)
 		shards := n.GetOwnedShards()
 		multiErr = multiErr.Add(m.cleanupExpiredNamespaceDataFiles(earliestToRetain, shards))
 		multiErr = multiErr.Add(m.cleanupCompactedNamespaceDataFiles(shards))
@@ -287,6 +433,38 @@ func (m *cleanupManager) cleanupCompactedNamespaceDataFiles(shards []databaseSha
 	return multiErr.FinalError()
 }
 
+// retainedSnapshots returns the IDs of the snapshots retained by the snapshot
+// retention policy, given the snapshot metadatas sorted by index and the time
+// of each snapshot. The most recent snapshot is always retained since it is
+// required to recover from a node failure, older snapshots are retained up to
+// the retention count as long as they are within the retention period.
+func (m *cleanupManager) retainedSnapshots(
+	sortedSnapshotMetadatas []fs.SnapshotMetadata,
+	snapshotTimes map[string]time.Time,
+) map[string]struct{} {
+	var (
+		count    = m.opts.SnapshotRetentionCount()
+		period   = m.opts.SnapshotRetentionPeriod()
+		now      = m.nowFn()
+		retained = make(map[string]struct{}, count)
+	)
+	for i := len(sortedSnapshotMetadatas) - 1; i >= 0; i-- {
+		id := sortedSnapshotMetadatas[i].ID.UUID.String()
+		if len(retained) > 0 {
+			if len(retained) >= count {
+				break
+			}
+			// Snapshots with no files have no time and are treated as expired.
+			snapshotTime, ok := snapshotTimes[id]
+			if period > 0 && (!ok || now.Sub(snapshotTime) > period) {
+				break
+			}
+		}
+		retained[id] = struct{}{}
+	}
+	return retained
+}
+
 // The goal of the cleanupSnapshotsAndCommitlogs function is to delete all snapshots files, snapshot metadata
 // files, and commitlog files except for those that are currently required for recovery from a node failure.
 // According to the snapshotting / commitlog rotation logic, the files that are required for a complete
@@ -305,10 +483,10 @@ func (m *cleanupManager) cleanupCompactedNamespaceDataFiles(shards []databaseSha
 //     1. List all the snapshot metadata files on disk.
 //     2. Identify the most recent one (highest index).
 //     3. For every namespace/shard/block combination, delete all snapshot files that match one of the following criteria:
-//         1. Snapshot files whose associated snapshot ID does not match the snapshot ID of the most recent
-//            snapshot metadata file.
+//         1. Snapshot files whose associated snapshot ID does not match the snapshot ID of a snapshot metadata
+//            file retained by the snapshot retention policy (by default only the most recent one).
 //         2. Snapshot files that are corrupt.
-//     4. Delete all snapshot metadata files prior to the most recent once.
+//     4. Delete all snapshot metadata files prior to the most recent one not retained by the retention policy.
 //     5. Delete corrupt snapshot metadata files.
 //     6. List all the commitlog files on disk.
 //     7. List all the commitlog files that are being actively written to.
@@ -375,16 +553,24 @@ func (m *cleanupManager) cleanupSnapshotsAndCommitlogs() (finalErr error) {
 		finalErr = multiErr.FinalError()
 	}()
 
+	type parsedSnapshot struct {
+		id    string
+		files []string
+	}
+	var (
+		snapshots     []parsedSnapshot
+		snapshotTimes = make(map[string]time.Time)
+	)
 	for _, ns := range namespaces {
 		for _, s := range ns.GetOwnedShards() {
-			shardSnapshots, err := m.snapshotFilesFn(fsOpts.FilePathPrefix(), ns.ID(), s.ID())
+			shardSnapshots, err := m.snapshotFilesFn(fsOpts.NamespaceFilePathPrefix(ns.ID()), ns.ID(), s.ID())
 			if err != nil {
 				multiErr = multiErr.Add(fmt.Errorf("err reading snapshot files for ns: %s and shard: %d, err: %v", ns.ID(), s.ID(), err))
 				continue
 			}
 
 			for _, snapshot := range shardSnapshots {
-				_, snapshotID, err := snapshot.SnapshotTimeAndID()
+				snapshotTime, snapshotID, err := snapshot.SnapshotTimeAndID()
 				if err != nil {
 					// If we can't parse the snapshotID, assume the snapshot is corrupt and delete it. This could be caused
 					// by a variety of situations, like a node crashing while writing out a set of snapshot files and should
@@ -399,18 +585,34 @@ func (m *cleanupManager) cleanupSnapshotsAndCommitlogs() (finalErr error) {
 					continue
 				}
 
-				if !uuid.Equal(snapshotID, mostRecentSnapshot.ID.UUID) {
-					// If the UUID of the snapshot files doesn't match the most recent snapshot
-					// then its safe to delete because it means we have a more recently complete set.
-					m.metrics.deletedSnapshotFile.Inc(1)
-					filesToDelete = append(filesToDelete, snapshot.AbsoluteFilepaths...)
+				id := snapshotID.String()
+				snapshots = append(snapshots, parsedSnapshot{
+					id:    id,
+					files: snapshot.AbsoluteFilepaths,
+				})
+				if snapshotTime.After(snapshotTimes[id]) {
+					snapshotTimes[id] = snapshotTime
 				}
 			}
 		}
 	}
 
-	// Delete all snapshot metadatas prior to the most recent one.
+	retained := m.retainedSnapshots(sortedSnapshotMetadatas, snapshotTimes)
+	for _, snapshot := range snapshots {
+		if _, ok := retained[snapshot.id]; !ok {
+			// If the snapshot is not retained by the retention policy then its safe
+			// to delete because it means we have a more recently complete set.
+			m.metrics.deletedSnapshotFile.Inc(1)
+			filesToDelete = append(filesToDelete, snapshot.files...)
+		}
+	}
+
+	// Delete all snapshot metadatas that are not retained, the most recent one is
+	// always retained.
 	for _, snapshot := range sortedSnapshotMetadatas[:len(sortedSnapshotMetadatas)-1] {
+		if _, ok := retained[snapshot.ID.UUID.String()]; ok {
+			continue
+		}
 		m.metrics.deletedSnapshotMetadataFile.Inc(1)
 		filesToDelete = append(filesToDelete, snapshot.AbsoluteFilepaths()...)
 	}
@@ -57,6 +57,9 @@ func TestCleanupManagerCleanupCommitlogsAndSnapshots(t *testing.T) {
 	testSnapshotUUID1 := uuid.Parse("bed2156f-182a-47ea-83ff-0a55d34c8a82")
 	require.NotNil(t, testSnapshotUUID1)
 
+	testSnapshotUUID2 := uuid.Parse("0c7f5e4c-47a1-4b7e-9d1c-3f5d2a8e6b90")
+	require.NotNil(t, testSnapshotUUID2)
+
 	testCommitlogFileIdentifier := persist.CommitLogFile{
 		FilePath: "commitlog-filepath-1",
 		Index:    1,
@@ -69,6 +72,10 @@ func TestCleanupManagerCleanupCommitlogsAndSnapshots(t *testing.T) {
 		Index: 1,
 		UUID:  testSnapshotUUID1,
 	}
+	testSnapshotMetadataIdentifier3 := fs.SnapshotMetadataIdentifier{
+		Index: 2,
+		UUID:  testSnapshotUUID2,
+	}
 	testSnapshotMetadata0 := fs.SnapshotMetadata{
 		ID:                  testSnapshotMetadataIdentifier1,
 		CommitlogIdentifier: testCommitlogFileIdentifier,
@@ -81,12 +88,48 @@ func TestCleanupManagerCleanupCommitlogsAndSnapshots(t *testing.T) {
 		MetadataFilePath:    "metadata-filepath-1",
 		CheckpointFilePath:  "checkpoint-filepath-1",
 	}
+	testSnapshotMetadata2 := fs.SnapshotMetadata{
+		ID:                  testSnapshotMetadataIdentifier3,
+		CommitlogIdentifier: testCommitlogFileIdentifier,
+		MetadataFilePath:    "metadata-filepath-2",
+		CheckpointFilePath:  "checkpoint-filepath-2",
+	}
+	testSnapshotMetadatas := func(fs.Options) ([]fs.SnapshotMetadata, []fs.SnapshotMetadataErrorWithPaths, error) {
+		return []fs.SnapshotMetadata{testSnapshotMetadata0, testSnapshotMetadata1, testSnapshotMetadata2}, nil, nil
+	}
+	// testSnapshotsWithTimes returns a snapshot file per shard for each of the
+	// three snapshots, taken at the given times.
+	testSnapshotsWithTimes := func(times ...time.Time) snapshotFilesFn {
+		ids := []uuid.UUID{testSnapshotUUID0, testSnapshotUUID1, testSnapshotUUID2}
+		return func(filePathPrefix string, namespace ident.ID, shard uint32) (fs.FileSetFilesSlice, error) {
+			var files fs.FileSetFilesSlice
+			for i, id := range ids {
+				files = append(files, fs.FileSetFile{
+					ID: fs.FileSetFileIdentifier{
+						Namespace:   namespace,
+						BlockStart:  testBlockStart,
+						Shard:       shard,
+						VolumeIndex: i,
+					},
+					AbsoluteFilepaths:  []string{fmt.Sprintf("/snapshots/%s/snapshot-filepath-%d-%d", namespace, shard, i)},
+					CachedSnapshotTime: times[i],
+					CachedSnapshotID:   id,
+				})
+			}
+			return files, nil
+		}
+	}
+	noCommitlogs := func(commitlog.Options) (persist.CommitLogFiles, []commitlog.ErrorWithPath, error) {
+		return nil, nil, nil
+	}
 
 	testCases := []struct {
 		title                string
 		snapshotMetadata     snapshotMetadataFilesFn
 		commitlogs           commitLogFilesFn
 		snapshots            snapshotFilesFn
+		retentionCount       int
+		retentionPeriod      time.Duration
 		expectedDeletedFiles []string
 		expectErr            bool
 	}{
@@ -270,6 +313,82 @@ func TestCleanupManagerCleanupCommitlogsAndSnapshots(t *testing.T) {
 			expectedDeletedFiles: []string{"corrupt-commitlog-file-0", "corrupt-commitlog-file-1"},
 			expectErr:            true,
 		},
+		{
+			title:            "Retains snapshots and metadata up to the snapshot retention count",
+			snapshotMetadata: testSnapshotMetadatas,
+			snapshots: testSnapshotsWithTimes(
+				testBlockStart.Add(-2*time.Hour), testBlockStart.Add(-time.Hour), testBlockStart),
+			commitlogs:     noCommitlogs,
+			retentionCount: 2,
+			expectedDeletedFiles: []string{
+				"/snapshots/ns0/snapshot-filepath-0-0",
+				"/snapshots/ns0/snapshot-filepath-1-0",
+				"/snapshots/ns0/snapshot-filepath-2-0",
+				"/snapshots/ns1/snapshot-filepath-0-0",
+				"/snapshots/ns1/snapshot-filepath-1-0",
+				"/snapshots/ns1/snapshot-filepath-2-0",
+				"/snapshots/ns2/snapshot-filepath-0-0",
+				"/snapshots/ns2/snapshot-filepath-1-0",
+				"/snapshots/ns2/snapshot-filepath-2-0",
+				"metadata-filepath-0",
+				"checkpoint-filepath-0",
+			},
+		},
+		{
+			title:            "Deletes snapshots and metadata older than the snapshot retention period",
+			snapshotMetadata: testSnapshotMetadatas,
+			snapshots: testSnapshotsWithTimes(
+				testBlockStart.Add(-6*time.Hour), testBlockStart.Add(-time.Hour), testBlockStart),
+			commitlogs:      noCommitlogs,
+			retentionCount:  3,
+			retentionPeriod: 4 * time.Hour,
+			expectedDeletedFiles: []string{
+				"/snapshots/ns0/snapshot-filepath-0-0",
+				"/snapshots/ns0/snapshot-filepath-1-0",
+				"/snapshots/ns0/snapshot-filepath-2-0",
+				"/snapshots/ns1/snapshot-filepath-0-0",
+				"/snapshots/ns1/snapshot-filepath-1-0",
+				"/snapshots/ns1/snapshot-filepath-2-0",
+				"/snapshots/ns2/snapshot-filepath-0-0",
+				"/snapshots/ns2/snapshot-filepath-1-0",
+				"/snapshots/ns2/snapshot-filepath-2-0",
+				"metadata-filepath-0",
+				"checkpoint-filepath-0",
+			},
+		},
+		{
+			title:            "Always retains the most recent snapshot regardless of the snapshot retention period",
+			snapshotMetadata: testSnapshotMetadatas,
+			snapshots: testSnapshotsWithTimes(
+				testBlockStart.Add(-8*time.Hour), testBlockStart.Add(-7*time.Hour), testBlockStart.Add(-6*time.Hour)),
+			commitlogs:      noCommitlogs,
+			retentionCount:  3,
+			retentionPeriod: time.Hour,
+			expectedDeletedFiles: []string{
+				"/snapshots/ns0/snapshot-filepath-0-0",
+				"/snapshots/ns0/snapshot-filepath-0-1",
+				"/snapshots/ns0/snapshot-filepath-1-0",
+				"/snapshots/ns0/snapshot-filepath-1-1",
+				"/snapshots/ns0/snapshot-filepath-2-0",
+				"/snapshots/ns0/snapshot-filepath-2-1",
+				"/snapshots/ns1/snapshot-filepath-0-0",
+				"/snapshots/ns1/snapshot-filepath-0-1",
+				"/snapshots/ns1/snapshot-filepath-1-0",
+				"/snapshots/ns1/snapshot-filepath-1-1",
+				"/snapshots/ns1/snapshot-filepath-2-0",
+				"/snapshots/ns1/snapshot-filepath-2-1",
+				"/snapshots/ns2/snapshot-filepath-0-0",
+				"/snapshots/ns2/snapshot-filepath-0-1",
+				"/snapshots/ns2/snapshot-filepath-1-0",
+				"/snapshots/ns2/snapshot-filepath-1-1",
+				"/snapshots/ns2/snapshot-filepath-2-0",
+				"/snapshots/ns2/snapshot-filepath-2-1",
+				"metadata-filepath-0",
+				"checkpoint-filepath-0",
+				"metadata-filepath-1",
+				"checkpoint-filepath-1",
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -307,6 +426,12 @@ func TestCleanupManagerCleanupCommitlogsAndSnapshots(t *testing.T) {
 				mgr.opts.CommitLogOptions().
 					SetBlockSize(rOpts.BlockSize()))
 
+			if tc.retentionCount > 0 {
+				mgr.opts = mgr.opts.
+					SetSnapshotRetentionCount(tc.retentionCount).
+					SetSnapshotRetentionPeriod(tc.retentionPeriod)
+			}
+
 			mgr.snapshotMetadataFilesFn = tc.snapshotMetadata
 			mgr.commitLogFilesFn = tc.commitlogs
 			mgr.snapshotFilesFn = tc.snapshots
@@ -400,12 +525,15 @@ func TestCleanupDataAndSnapshotFileSetFiles(t *testing.T) {
 	defer ctrl.Finish()
 	ts := timeFor(36000)
 
-	nsOpts := namespaceOptions
+	// Filesets that expired within the grace period are retained.
+	grace := 6 * time.Hour
+	nsOpts := namespaceOptions.SetRetentionOptions(
+		namespaceOptions.RetentionOptions().SetExpiredFileSetGracePeriod(grace))
 	ns := NewMockdatabaseNamespace(ctrl)
 	ns.EXPECT().Options().Return(nsOpts).AnyTimes()
 
 	shard := NewMockdatabaseShard(ctrl)
-	expectedEarliestToRetain := retention.FlushTimeStart(ns.Options().RetentionOptions(), ts)
+	expectedEarliestToRetain := retention.FlushTimeStart(ns.Options().RetentionOptions(), ts).Add(-grace)
 	shard.EXPECT().CleanupExpiredFileSets(expectedEarliestToRetain).Return(nil)
 	shard.EXPECT().CleanupCompactedFileSets().Return(nil)
 	shard.EXPECT().ID().Return(uint32(0)).AnyTimes()
@@ -486,6 +614,48 @@ func TestDeleteInactiveDataAndSnapshotFileSetFiles(t *testing.T) {
 	}
 }
 
+func TestDeleteInactiveFileSetFilesNamespaceFilePathPrefix(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	shard := NewMockdatabaseShard(ctrl)
+	shard.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{shard}).AnyTimes()
+	ns.EXPECT().ID().Return(ident.StringID("nsID")).AnyTimes()
+
+	var (
+		opts   = DefaultTestOptions()
+		fsOpts = opts.CommitLogOptions().FilesystemOptions().
+			SetNamespaceFilePathPrefixes(map[string]string{"nsID": "/routed"})
+	)
+	opts = opts.SetCommitLogOptions(opts.CommitLogOptions().SetFilesystemOptions(fsOpts))
+	db := NewMockdatabase(ctrl)
+	db.EXPECT().Options().Return(opts).AnyTimes()
+	db.EXPECT().GetOwnedNamespaces().Return([]databaseNamespace{ns}, nil).AnyTimes()
+	mgr := newCleanupManager(db, newNoopFakeActiveLogs(), tally.NoopScope).(*cleanupManager)
+
+	var calls []deleteInactiveDirectoriesCall
+	mgr.deleteInactiveDirectoriesFn = func(parentDirPath string, activeDirNames []string) error {
+		calls = append(calls, deleteInactiveDirectoriesCall{
+			parentDirPath:  parentDirPath,
+			activeDirNames: activeDirNames,
+		})
+		return nil
+	}
+
+	require.NoError(t, mgr.deleteInactiveDataFiles())
+	require.NoError(t, mgr.deleteInactiveNamespaceFiles())
+
+	// Shards are cleaned up beneath the routed prefix of the namespace while
+	// inactive namespaces are cleaned up beneath every prefix.
+	require.Equal(t, []deleteInactiveDirectoriesCall{
+		{parentDirPath: "/routed/data/nsID", activeDirNames: []string{"0"}},
+		{parentDirPath: fs.DataDirPath(fsOpts.FilePathPrefix()), activeDirNames: []string{"nsID"}},
+		{parentDirPath: "/routed/data", activeDirNames: []string{"nsID"}},
+	}, calls)
+}
+
 func TestCleanupManagerPropagatesGetOwnedNamespacesError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -526,3 +696,101 @@ func newFakeActiveLogs(activeLogs persist.CommitLogFiles) fakeActiveLogs {
 		activeLogs: activeLogs,
 	}
 }
+
+func TestCleanupManagerUpdatesDataAgeHeatmap(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ts := timeFor(36000)
+
+	nsOpts := namespaceOptions.SetCleanupEnabled(false)
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().Options().Return(nsOpts).AnyTimes()
+
+	shard := NewMockdatabaseShard(ctrl)
+	shard.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{shard}).AnyTimes()
+	ns.EXPECT().ID().Return(ident.StringID("nsID")).AnyTimes()
+	ns.EXPECT().NeedsFlush(gomock.Any(), gomock.Any()).Return(false).AnyTimes()
+	namespaces := []databaseNamespace{ns}
+
+	db := newMockdatabase(ctrl, namespaces...)
+	db.EXPECT().GetOwnedNamespaces().Return(namespaces, nil).AnyTimes()
+	mgr := newCleanupManager(db, newNoopFakeActiveLogs(), tally.NoopScope).(*cleanupManager)
+
+	blockStart := ts.Add(-2 * time.Hour)
+	mgr.dataFilesFn = func(_ string, _ ident.ID, _ uint32) (fs.FileSetFilesSlice, error) {
+		file := fs.NewFileSetFile(fs.FileSetFileIdentifier{BlockStart: blockStart}, "")
+		file.AbsoluteFilepaths = []string{"data"}
+		return fs.FileSetFilesSlice{file}, nil
+	}
+	mgr.dataAge.fileSetSizeFn = func(files []string) (int64, error) {
+		return 42, nil
+	}
+
+	_, ok := mgr.DataAgeHeatmap(ident.StringID("nsID"))
+	require.False(t, ok)
+
+	require.NoError(t, mgr.Cleanup(ts))
+
+	heatmap, ok := mgr.DataAgeHeatmap(ident.StringID("nsID"))
+	require.True(t, ok)
+	require.Equal(t, ts, heatmap.ComputedAt)
+	require.Equal(t, int64(42), heatmap.Buckets[0].Bytes)
+	require.Equal(t, int64(42), heatmap.Shards[0][0].Bytes)
+}
+
+func TestCleanupManagerUpdatesDiskUsage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ts := timeFor(36000)
+
+	nsOpts := namespaceOptions.SetCleanupEnabled(false)
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().Options().Return(nsOpts).AnyTimes()
+
+	shard := NewMockdatabaseShard(ctrl)
+	shard.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{shard}).AnyTimes()
+	ns.EXPECT().ID().Return(ident.StringID("nsID")).AnyTimes()
+	ns.EXPECT().NeedsFlush(gomock.Any(), gomock.Any()).Return(false).AnyTimes()
+	namespaces := []databaseNamespace{ns}
+
+	db := newMockdatabase(ctrl, namespaces...)
+	db.EXPECT().GetOwnedNamespaces().Return(namespaces, nil).AnyTimes()
+	mgr := newCleanupManager(db, newNoopFakeActiveLogs(), tally.NoopScope).(*cleanupManager)
+
+	newFiles := func(path string) fs.FileSetFilesSlice {
+		file := fs.NewFileSetFile(fs.FileSetFileIdentifier{BlockStart: ts}, "")
+		file.AbsoluteFilepaths = []string{path}
+		return fs.FileSetFilesSlice{file}
+	}
+	mgr.dataFilesFn = func(_ string, _ ident.ID, _ uint32) (fs.FileSetFilesSlice, error) {
+		return newFiles("data"), nil
+	}
+	mgr.indexFilesFn = func(_ string, _ ident.ID) (fs.FileSetFilesSlice, error) {
+		return newFiles("index"), nil
+	}
+	mgr.commitLogFilesFn = func(_ commitlog.Options) (persist.CommitLogFiles, []commitlog.ErrorWithPath, error) {
+		return persist.CommitLogFiles{{FilePath: "commitlog"}}, nil, nil
+	}
+	mgr.diskUsage.fileSetSizeFn = func(files []string) (int64, error) {
+		sizes := map[string]int64{"data": 42, "index": 7, "commitlog": 3}
+		var size int64
+		for _, file := range files {
+			size += sizes[file]
+		}
+		return size, nil
+	}
+
+	_, ok := mgr.DiskUsage(ident.StringID("nsID"))
+	require.False(t, ok)
+
+	require.NoError(t, mgr.Cleanup(ts))
+
+	usage, ok := mgr.DiskUsage(ident.StringID("nsID"))
+	require.True(t, ok)
+	require.Equal(t, ts, usage.ComputedAt)
+	expected := DiskUsage{DataBytes: 42, IndexBytes: 7, CommitLogBytes: 3}
+	require.Equal(t, expected, usage.Total)
+	require.Equal(t, expected, usage.Shards[0])
+}
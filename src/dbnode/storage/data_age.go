@@ -0,0 +1,220 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+var (
+	// defaultDataAgeBucketBoundaries are the default block age boundaries used
+	// to bucket fileset data, i.e. 0-1d, 1-7d, 7-30d, 30-90d and 90d+.
+	defaultDataAgeBucketBoundaries = []time.Duration{
+		24 * time.Hour,
+		7 * 24 * time.Hour,
+		30 * 24 * time.Hour,
+		90 * 24 * time.Hour,
+	}
+)
+
+type fileSetSizeFn func(files []string) (int64, error)
+
+// dataAgeVolume identifies a single fileset volume of a shard.
+type dataAgeVolume struct {
+	blockStart xtime.UnixNano
+	volume     int
+}
+
+// dataAgeShardState is the incrementally maintained state for a single shard,
+// the size of each volume is only computed the first time it is observed.
+type dataAgeShardState struct {
+	volumeBytes map[dataAgeVolume]int64
+}
+
+// dataAgeTracker maintains the data age heatmaps for all namespaces. It is
+// updated by the cleanup manager after each cleanup so that the cost of
+// computing the heatmaps is amortized with the file listing cleanup already
+// performs.
+type dataAgeTracker struct {
+	sync.RWMutex
+
+	boundaries    []time.Duration
+	fileSetSizeFn fileSetSizeFn
+	shards        map[string]map[uint32]*dataAgeShardState
+	heatmaps      map[string]DataAgeHeatmap
+}
+
+func newDataAgeTracker(boundaries []time.Duration) *dataAgeTracker {
+	sorted := append([]time.Duration(nil), boundaries...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	return &dataAgeTracker{
+		boundaries:    sorted,
+		fileSetSizeFn: fileSetSize,
+		shards:        make(map[string]map[uint32]*dataAgeShardState),
+		heatmaps:      make(map[string]DataAgeHeatmap),
+	}
+}
+
+// UpdateShard updates the tracked volume sizes of a shard to reflect the
+// provided set of data fileset files, only computing the size of new volumes.
+func (t *dataAgeTracker) UpdateShard(
+	namespace ident.ID,
+	shard uint32,
+	files fs.FileSetFilesSlice,
+) error {
+	t.Lock()
+	defer t.Unlock()
+
+	byShard, ok := t.shards[namespace.String()]
+	if !ok {
+		byShard = make(map[uint32]*dataAgeShardState)
+		t.shards[namespace.String()] = byShard
+	}
+	state, ok := byShard[shard]
+	if !ok {
+		state = &dataAgeShardState{volumeBytes: make(map[dataAgeVolume]int64)}
+		byShard[shard] = state
+	}
+
+	present := make(map[dataAgeVolume]struct{}, len(files))
+	for _, file := range files {
+		volume := dataAgeVolume{
+			blockStart: xtime.ToUnixNano(file.ID.BlockStart),
+			volume:     file.ID.VolumeIndex,
+		}
+		present[volume] = struct{}{}
+		if _, ok := state.volumeBytes[volume]; ok {
+			// Filesets are immutable once written so the size only needs
+			// to be computed once.
+			continue
+		}
+		size, err := t.fileSetSizeFn(file.AbsoluteFilepaths)
+		if err != nil {
+			return err
+		}
+		state.volumeBytes[volume] = size
+	}
+
+	// Forget any volumes that have since been cleaned up.
+	for volume := range state.volumeBytes {
+		if _, ok := present[volume]; !ok {
+			delete(state.volumeBytes, volume)
+		}
+	}
+	return nil
+}
+
+// RetainShards removes any tracked state for namespaces and shards that are
+// no longer owned by the node.
+func (t *dataAgeTracker) RetainShards(owned map[string]map[uint32]struct{}) {
+	t.Lock()
+	defer t.Unlock()
+
+	for ns, byShard := range t.shards {
+		ownedShards, ok := owned[ns]
+		if !ok {
+			delete(t.shards, ns)
+			delete(t.heatmaps, ns)
+			continue
+		}
+		for shard := range byShard {
+			if _, ok := ownedShards[shard]; !ok {
+				delete(byShard, shard)
+			}
+		}
+	}
+}
+
+// Recompute rebuilds the heatmap for a namespace from the tracked volume
+// sizes using the provided time as the reference for block ages.
+func (t *dataAgeTracker) Recompute(namespace ident.ID, now time.Time) {
+	t.Lock()
+	defer t.Unlock()
+
+	heatmap := DataAgeHeatmap{
+		Namespace:  namespace,
+		ComputedAt: now,
+		Buckets:    t.newBucketsWithLock(),
+		Shards:     make(map[uint32][]DataAgeBucket),
+	}
+	for shard, state := range t.shards[namespace.String()] {
+		shardBuckets := t.newBucketsWithLock()
+		for volume, bytes := range state.volumeBytes {
+			idx := t.bucketIndexWithLock(now.Sub(volume.blockStart.ToTime()))
+			shardBuckets[idx].Bytes += bytes
+			heatmap.Buckets[idx].Bytes += bytes
+		}
+		heatmap.Shards[shard] = shardBuckets
+	}
+	t.heatmaps[namespace.String()] = heatmap
+}
+
+// Heatmap returns the last computed heatmap for a namespace.
+func (t *dataAgeTracker) Heatmap(namespace ident.ID) (DataAgeHeatmap, bool) {
+	t.RLock()
+	heatmap, ok := t.heatmaps[namespace.String()]
+	t.RUnlock()
+	return heatmap, ok
+}
+
+func (t *dataAgeTracker) newBucketsWithLock() []DataAgeBucket {
+	buckets := make([]DataAgeBucket, 0, len(t.boundaries)+1)
+	var minAge time.Duration
+	for _, boundary := range t.boundaries {
+		buckets = append(buckets, DataAgeBucket{MinAge: minAge, MaxAge: boundary})
+		minAge = boundary
+	}
+	return append(buckets, DataAgeBucket{MinAge: minAge})
+}
+
+func (t *dataAgeTracker) bucketIndexWithLock(age time.Duration) int {
+	for i, boundary := range t.boundaries {
+		if age < boundary {
+			return i
+		}
+	}
+	return len(t.boundaries)
+}
+
+func fileSetSize(files []string) (int64, error) {
+	var size int64
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// Raced with a cleanup of the file.
+				continue
+			}
+			return 0, err
+		}
+		size += info.Size()
+	}
+	return size, nil
+}
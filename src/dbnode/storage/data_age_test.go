@@ -0,0 +1,107 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDataAgeFileSetFile(blockStart time.Time, volume int, path string) fs.FileSetFile {
+	file := fs.NewFileSetFile(fs.FileSetFileIdentifier{
+		BlockStart:  blockStart,
+		VolumeIndex: volume,
+	}, "")
+	file.AbsoluteFilepaths = []string{path}
+	return file
+}
+
+func TestDataAgeTrackerHeatmap(t *testing.T) {
+	var (
+		ns      = ident.StringID("ns")
+		now     = time.Now().Truncate(time.Hour)
+		tracker = newDataAgeTracker([]time.Duration{7 * 24 * time.Hour, 24 * time.Hour})
+		sizes   = map[string]int64{"a": 10, "b": 20, "c": 40, "d": 80}
+		statted []string
+	)
+	tracker.fileSetSizeFn = func(files []string) (int64, error) {
+		var size int64
+		for _, file := range files {
+			statted = append(statted, file)
+			size += sizes[file]
+		}
+		return size, nil
+	}
+
+	require.NoError(t, tracker.UpdateShard(ns, 0, fs.FileSetFilesSlice{
+		newTestDataAgeFileSetFile(now.Add(-time.Hour), 0, "a"),
+		newTestDataAgeFileSetFile(now.Add(-2*24*time.Hour), 0, "b"),
+	}))
+	require.NoError(t, tracker.UpdateShard(ns, 1, fs.FileSetFilesSlice{
+		newTestDataAgeFileSetFile(now.Add(-30*24*time.Hour), 0, "c"),
+	}))
+
+	_, ok := tracker.Heatmap(ns)
+	require.False(t, ok)
+
+	tracker.Recompute(ns, now)
+	heatmap, ok := tracker.Heatmap(ns)
+	require.True(t, ok)
+	require.Equal(t, now, heatmap.ComputedAt)
+	require.Equal(t, []DataAgeBucket{
+		{MinAge: 0, MaxAge: 24 * time.Hour, Bytes: 10},
+		{MinAge: 24 * time.Hour, MaxAge: 7 * 24 * time.Hour, Bytes: 20},
+		{MinAge: 7 * 24 * time.Hour, Bytes: 40},
+	}, heatmap.Buckets)
+	require.Equal(t, int64(40), heatmap.Shards[1][2].Bytes)
+
+	// Update shard 0 with a volume removed and a new volume added, only the
+	// new volume should be statted.
+	statted = nil
+	require.NoError(t, tracker.UpdateShard(ns, 0, fs.FileSetFilesSlice{
+		newTestDataAgeFileSetFile(now.Add(-2*24*time.Hour), 0, "b"),
+		newTestDataAgeFileSetFile(now.Add(-2*24*time.Hour), 1, "d"),
+	}))
+	require.Equal(t, []string{"d"}, statted)
+
+	// Shard 1 is no longer owned.
+	tracker.RetainShards(map[string]map[uint32]struct{}{
+		ns.String(): {0: struct{}{}},
+	})
+
+	tracker.Recompute(ns, now)
+	heatmap, ok = tracker.Heatmap(ns)
+	require.True(t, ok)
+	require.Equal(t, int64(0), heatmap.Buckets[0].Bytes)
+	require.Equal(t, int64(100), heatmap.Buckets[1].Bytes)
+	require.Equal(t, int64(0), heatmap.Buckets[2].Bytes)
+	require.Len(t, heatmap.Shards, 1)
+
+	// Namespace is no longer owned.
+	tracker.RetainShards(map[string]map[uint32]struct{}{})
+	_, ok = tracker.Heatmap(ns)
+	require.False(t, ok)
+}
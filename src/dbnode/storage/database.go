@@ -24,15 +24,19 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
+	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage/block"
+	"github.com/m3db/m3/src/dbnode/storage/durability"
 	dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/tracepoint"
@@ -49,14 +53,6 @@ import (
 	"go.uber.org/zap"
 )
 
-const (
-	// The database is considered overloaded if the queue size is 90% or more
-	// of the maximum capacity. We set this below 1.0 because checking the queue
-	// lengthy is racey so we're gonna burst past this value anyways and the buffer
-	// gives us breathing room to recover.
-	commitLogQueueCapacityOverloadedFactor = 0.9
-)
-
 var (
 	// errDatabaseAlreadyOpen raised when trying to open a database that is already open.
 	errDatabaseAlreadyOpen = errors.New("database is already open")
@@ -73,6 +69,31 @@ var (
 	// errWriterDoesNotImplementWriteBatch is raised when the provided ts.BatchWriter does not implement
 	// ts.WriteBatch.
 	errWriterDoesNotImplementWriteBatch = errors.New("provided writer does not implement ts.WriteBatch")
+
+	// errDataAgeHeatmapNotComputed raised when the data age heatmap of a namespace
+	// has not been computed yet by a cleanup.
+	errDataAgeHeatmapNotComputed = errors.New("data age heatmap has not been computed yet")
+
+	// errDiskUsageNotComputed raised when the disk usage of a namespace has not
+	// been computed yet by a cleanup.
+	errDiskUsageNotComputed = errors.New("disk usage has not been computed yet")
+
+	// errDataDurabilityInvalidRange raised when the data durability is requested
+	// for a range that does not end after it starts.
+	errDataDurabilityInvalidRange = errors.New("data durability range end must be after start")
+
+	// errNamespaceCopyNotBootstrapped raised when a namespace is copied before
+	// the database has bootstrapped and the history of the namespace is complete.
+	errNamespaceCopyNotBootstrapped = errors.New("namespace cannot be copied before the database is bootstrapped")
+
+	// errWriteBatchNotWrittenToCommitLog raised to the durable callback of a write
+	// batch for a namespace that does not write to the commit log.
+	errWriteBatchNotWrittenToCommitLog = errors.New("write batch not written to commit log, namespace does not write to commit log")
+
+	// errUndeleteNamespaceRegistry raised when expired filesets are undeleted
+	// for namespaces read from the namespace registry, whose next update or a
+	// restart would revert the extended retention.
+	errUndeleteNamespaceRegistry = errors.New("expired filesets cannot be undeleted for namespaces of the namespace registry, extend the namespace retention in the registry instead")
 )
 
 type databaseState int
@@ -93,6 +114,7 @@ type db struct {
 	opts  Options
 	nowFn clock.NowFn
 
+	nsRegistry namespace.Registry
 	nsWatch    databaseNamespaceWatch
 	namespaces *databaseNamespacesMap
 
@@ -112,6 +134,10 @@ type db struct {
 	log     *zap.Logger
 
 	writeBatchPool *ts.WriteBatchPool
+
+	rebalanceAdvisor *shardRebalanceAdvisor
+	migrations       *namespaceMigrationManager
+	drainer          *databaseDrainer
 }
 
 type databaseMetrics struct {
@@ -124,6 +150,7 @@ type databaseMetrics struct {
 	unknownNamespaceFetchBlocks         tally.Counter
 	unknownNamespaceFetchBlocksMetadata tally.Counter
 	unknownNamespaceQueryIDs            tally.Counter
+	unknownNamespaceValidateWrite       tally.Counter
 	errQueryIDsIndexDisabled            tally.Counter
 	errWriteTaggedIndexDisabled         tally.Counter
 }
@@ -141,6 +168,7 @@ func newDatabaseMetrics(scope tally.Scope) databaseMetrics {
 		unknownNamespaceFetchBlocks:         unknownNamespaceScope.Counter("fetch-blocks"),
 		unknownNamespaceFetchBlocksMetadata: unknownNamespaceScope.Counter("fetch-blocks-metadata"),
 		unknownNamespaceQueryIDs:            unknownNamespaceScope.Counter("query-ids"),
+		unknownNamespaceValidateWrite:       unknownNamespaceScope.Counter("validate-write"),
 		errQueryIDsIndexDisabled:            indexDisabledScope.Counter("err-query-ids"),
 		errWriteTaggedIndexDisabled:         indexDisabledScope.Counter("err-write-tagged"),
 	}
@@ -181,6 +209,8 @@ func NewDatabase(
 		metrics:               newDatabaseMetrics(scope),
 		log:                   logger,
 		writeBatchPool:        opts.WriteBatchPool(),
+		rebalanceAdvisor:      newShardRebalanceAdvisor(nowFn),
+		drainer:               newDatabaseDrainer(),
 	}
 
 	databaseIOpts := iopts.SetMetricsScope(scope)
@@ -207,6 +237,7 @@ func NewDatabase(
 	// in the background Tick think it can clean up files that it shouldn't.
 	logger.Info("resolving namespaces with namespace watch")
 	<-watch.C()
+	d.nsRegistry = nsReg
 	d.nsWatch = newDatabaseNamespaceWatch(d, watch, databaseIOpts)
 	nsMap := watch.Get()
 	if err := d.UpdateOwnedNamespaces(nsMap); err != nil {
@@ -223,6 +254,7 @@ func NewDatabase(
 		return nil, err
 	}
 	d.mediator = mediator
+	d.migrations = newNamespaceMigrationManager(d, opts.SetInstrumentOptions(databaseIOpts))
 
 	return d, nil
 }
@@ -298,9 +330,14 @@ func (d *db) UpdateOwnedNamespaces(newNamespaces namespace.Map) error {
 		return err
 	}
 
+	// apply any updates that only change whether a namespace is indexed
+	// and/or its retention
+	updates = d.updateNamespacesIndexEnabledWithLock(updates)
+	updates = d.updateNamespacesRetentionWithLock(updates)
+
 	// log that updates and removals are skipped
 	if len(removes) > 0 || len(updates) > 0 {
-		d.log.Warn("skipping namespace removals and updates (except schema updates), restart process if you want changes to take effect.")
+		d.log.Warn("skipping namespace removals and updates (except schema, retention and indexing enabled updates), restart process if you want changes to take effect.")
 	}
 
 	// enqueue bootstraps if new namespaces
@@ -311,6 +348,100 @@ func (d *db) UpdateOwnedNamespaces(newNamespaces namespace.Map) error {
 	return nil
 }
 
+// updateNamespacesIndexEnabledWithLock enables or disables the indexing of
+// the namespaces for the updates that only change whether a namespace is
+// indexed and/or its retention, it returns the updates that remain, which
+// include the retention changes still to apply.
+func (d *db) updateNamespacesIndexEnabledWithLock(updates []namespace.Metadata) []namespace.Metadata {
+	var remaining []namespace.Metadata
+	for _, newMd := range updates {
+		ns, ok := d.namespaces.Get(newMd.ID())
+		if !ok {
+			remaining = append(remaining, newMd)
+			continue
+		}
+
+		var (
+			curr        = ns.Options()
+			currEnabled = curr.IndexOptions().Enabled()
+			newOpts     = newMd.Options()
+			newEnabled  = newOpts.IndexOptions().Enabled()
+		)
+		// NB: Schema updates are applied through the schema registry.
+		otherwiseSame := newOpts.
+			SetIndexOptions(newOpts.IndexOptions().SetEnabled(currEnabled)).
+			SetRetentionOptions(curr.RetentionOptions()).
+			SetSchemaHistory(curr.SchemaHistory()).
+			Equal(curr)
+		if currEnabled == newEnabled || !otherwiseSame {
+			remaining = append(remaining, newMd)
+			continue
+		}
+
+		if err := ns.UpdateIndexEnabled(newEnabled); err != nil {
+			d.log.Error("unable to update namespace indexing",
+				zap.Stringer("namespace", newMd.ID()), zap.Error(err))
+			remaining = append(remaining, newMd)
+			continue
+		}
+
+		if !newOpts.RetentionOptions().Equal(curr.RetentionOptions()) {
+			// Leave the retention change to be applied next.
+			remaining = append(remaining, newMd)
+		}
+	}
+	return remaining
+}
+
+// updateNamespacesRetentionWithLock applies the updates that only change the
+// retention options of a namespace and returns the updates that remain.
+func (d *db) updateNamespacesRetentionWithLock(updates []namespace.Metadata) []namespace.Metadata {
+	var (
+		remaining        []namespace.Metadata
+		retentionShorter bool
+	)
+	for _, newMd := range updates {
+		ns, ok := d.namespaces.Get(newMd.ID())
+		if !ok {
+			remaining = append(remaining, newMd)
+			continue
+		}
+
+		var (
+			curr      = ns.Options()
+			currRopts = curr.RetentionOptions()
+			newRopts  = newMd.Options().RetentionOptions()
+		)
+		// NB: Schema updates are applied through the schema registry.
+		otherwiseSame := newMd.Options().
+			SetRetentionOptions(currRopts).
+			SetSchemaHistory(curr.SchemaHistory()).
+			Equal(curr)
+		if currRopts.Equal(newRopts) || !otherwiseSame {
+			remaining = append(remaining, newMd)
+			continue
+		}
+
+		if err := ns.UpdateRetentionOptions(newRopts); err != nil {
+			d.log.Error("unable to update namespace retention",
+				zap.Stringer("namespace", newMd.ID()), zap.Error(err))
+			remaining = append(remaining, newMd)
+			continue
+		}
+
+		if newRopts.RetentionPeriod() < currRopts.RetentionPeriod() {
+			retentionShorter = true
+		}
+	}
+
+	if retentionShorter {
+		// Expedite the next tick so that the data and filesets past the
+		// shortened retention are expired and cleaned up early.
+		d.mediator.Expedite()
+	}
+	return remaining
+}
+
 func (d *db) namespaceDeltaWithLock(newNamespaces namespace.Map) ([]ident.ID, []namespace.Metadata, []namespace.Metadata) {
 	var (
 		existing = d.namespaces
@@ -527,6 +658,11 @@ func (d *db) Open() error {
 		}
 	}
 
+	// Resume the namespace migrations that were in progress.
+	if err := d.migrations.Open(); err != nil {
+		return err
+	}
+
 	return d.mediator.Open()
 }
 
@@ -540,6 +676,10 @@ func (d *db) terminateWithLock() error {
 	}
 	d.state = databaseClosed
 
+	// Stop the namespace migrations, without waiting for them as they may be
+	// waiting on the database lock held here to write.
+	d.migrations.Close()
+
 	// close the mediator
 	if err := d.mediator.Close(); err != nil {
 		return err
@@ -603,6 +743,9 @@ func (d *db) Write(
 	unit xtime.Unit,
 	annotation []byte,
 ) error {
+	if err := d.drainer.checkWrite(); err != nil {
+		return err
+	}
 	n, err := d.namespaceFor(namespace)
 	if err != nil {
 		d.metrics.unknownNamespaceWrite.Inc(1)
@@ -614,12 +757,17 @@ func (d *db) Write(
 		return err
 	}
 
-	if !n.Options().WritesToCommitLog() || !wasWritten {
-		return nil
+	if n.Options().WritesToCommitLog() && wasWritten {
+		dp := ts.Datapoint{Timestamp: timestamp, Value: value}
+		if err := d.commitLog.Write(ctx, series, dp, unit, annotation); err != nil {
+			return err
+		}
 	}
 
-	dp := ts.Datapoint{Timestamp: timestamp, Value: value}
-	return d.commitLog.Write(ctx, series, dp, unit, annotation)
+	if target, ok := d.migrations.MirrorTarget(namespace); ok {
+		return d.mirrorWrite(ctx, target, id, nil, timestamp, value, unit, annotation)
+	}
+	return nil
 }
 
 func (d *db) WriteTagged(
@@ -632,23 +780,80 @@ func (d *db) WriteTagged(
 	unit xtime.Unit,
 	annotation []byte,
 ) error {
+	if err := d.drainer.checkWrite(); err != nil {
+		return err
+	}
 	n, err := d.namespaceFor(namespace)
 	if err != nil {
 		d.metrics.unknownNamespaceWriteTagged.Inc(1)
 		return err
 	}
 
+	// Duplicate the tags before the write consumes them to mirror the write
+	// to the target of the migration of the namespace, if any.
+	target, mirror := d.migrations.MirrorTarget(namespace)
+	var mirrorTags ident.TagIterator
+	if mirror {
+		mirrorTags = tags.Duplicate()
+		defer mirrorTags.Close()
+	}
+
 	series, wasWritten, err := n.WriteTagged(ctx, id, tags, timestamp, value, unit, annotation)
 	if err != nil {
 		return err
 	}
 
-	if !n.Options().WritesToCommitLog() || !wasWritten {
-		return nil
+	if n.Options().WritesToCommitLog() && wasWritten {
+		dp := ts.Datapoint{Timestamp: timestamp, Value: value}
+		if err := d.commitLog.Write(ctx, series, dp, unit, annotation); err != nil {
+			return err
+		}
+	}
+
+	if mirror {
+		return d.mirrorWrite(ctx, target, id, mirrorTags, timestamp, value, unit, annotation)
+	}
+	return nil
+}
+
+// mirrorWrite writes a datapoint written to the source namespace of a
+// migration to its target namespace as well.
+func (d *db) mirrorWrite(
+	ctx context.Context,
+	target ident.ID,
+	id ident.ID,
+	tags ident.TagIterator,
+	timestamp time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
+) error {
+	var err error
+	if tags != nil {
+		err = d.WriteTagged(ctx, target, id, tags, timestamp, value, unit, annotation)
+	} else {
+		err = d.Write(ctx, target, id, timestamp, value, unit, annotation)
+	}
+	if err != nil {
+		d.migrations.metrics.mirrorErrors.Inc(1)
+	}
+	return err
+}
+
+func (d *db) ValidateWrite(
+	namespace ident.ID,
+	id ident.ID,
+	tags ident.TagIterator,
+	timestamp time.Time,
+	annotation []byte,
+) error {
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		d.metrics.unknownNamespaceValidateWrite.Inc(1)
+		return err
 	}
 
-	dp := ts.Datapoint{Timestamp: timestamp, Value: value}
-	return d.commitLog.Write(ctx, series, dp, unit, annotation)
+	return n.ValidateWrite(id, tags, timestamp, annotation)
 }
 
 func (d *db) BatchWriter(namespace ident.ID, batchSize int) (ts.BatchWriter, error) {
@@ -695,6 +900,9 @@ func (d *db) writeBatch(
 	if !ok {
 		return errWriterDoesNotImplementWriteBatch
 	}
+	if err := d.drainer.checkWrite(); err != nil {
+		return err
+	}
 
 	n, err := d.namespaceFor(namespace)
 	if err != nil {
@@ -706,14 +914,21 @@ func (d *db) writeBatch(
 		return err
 	}
 
+	target, mirror := d.migrations.MirrorTarget(namespace)
+
 	iter := writes.Iter()
 	for i, write := range iter {
 		var (
 			series     ts.Series
 			wasWritten bool
 			err        error
+			mirrorTags ident.TagIterator
 		)
 
+		if mirror && tagged {
+			mirrorTags = write.TagIter.Duplicate()
+		}
+
 		if tagged {
 			series, wasWritten, err = n.WriteTagged(
 				ctx,
@@ -734,6 +949,20 @@ func (d *db) writeBatch(
 				write.Write.Annotation,
 			)
 		}
+		if err == nil && mirror {
+			err = d.mirrorWrite(ctx, target, write.Write.Series.ID, mirrorTags,
+				write.Write.Datapoint.Timestamp, write.Write.Datapoint.Value,
+				write.Write.Unit, write.Write.Annotation)
+			if err != nil {
+				// The write to this namespace still goes to the commit log as it
+				// was written, the caller retries the write as a whole.
+				errHandler.HandleError(write.OriginalIndex, err)
+				err = nil
+			}
+		}
+		if mirrorTags != nil {
+			mirrorTags.Close()
+		}
 		if err != nil {
 			// Return errors with the original index provided by the caller so they
 			// can associate the error with the write that caused it.
@@ -753,6 +982,11 @@ func (d *db) writeBatch(
 		}
 	}
 	if !n.Options().WritesToCommitLog() {
+		// The writes will never be durable before they are flushed, let the
+		// caller know instead of never calling back.
+		if fn := writes.DurableFn(); fn != nil {
+			fn(ts.BatchDurability{}, errWriteBatchNotWrittenToCommitLog)
+		}
 		// Finalize here because we can't rely on the commitlog to do it since
 		// we're not using it.
 		writes.Finalize()
@@ -779,7 +1013,7 @@ func (d *db) QueryIDs(
 
 	defer sp.Finish()
 
-	n, err := d.namespaceFor(namespace)
+	n, err := d.namespaceFor(d.migrations.ReadNamespace(namespace))
 	if err != nil {
 		sp.LogFields(opentracinglog.Error(err))
 		d.metrics.unknownNamespaceQueryIDs.Inc(1)
@@ -789,13 +1023,175 @@ func (d *db) QueryIDs(
 	return n.QueryIDs(ctx, query, opts)
 }
 
+func (d *db) QueryIDsMultiNamespace(
+	ctx context.Context,
+	namespaces []ident.ID,
+	query index.Query,
+	opts index.QueryOptions,
+) (MultiNamespaceQueryResult, error) {
+	ctx, sp := ctx.StartTraceSpan(tracepoint.DBQueryIDsMultiNamespace)
+	sp.LogFields(
+		opentracinglog.String("query", query.String()),
+		opentracinglog.Int("namespaces", len(namespaces)),
+		opentracinglog.Int("limit", opts.Limit),
+		xopentracing.Time("start", opts.StartInclusive),
+		xopentracing.Time("end", opts.EndExclusive),
+	)
+
+	defer sp.Finish()
+
+	// Resolve all the namespaces upfront so that an unknown namespace fails
+	// the query before any of the namespaces are queried.
+	nses, _, err := d.readNamespacesFor(namespaces)
+	if err != nil {
+		sp.LogFields(opentracinglog.Error(err))
+		d.metrics.unknownNamespaceQueryIDs.Inc(1)
+		return MultiNamespaceQueryResult{}, err
+	}
+
+	results, exhaustive, err := d.queryIDsMultiNamespace(ctx, nses, nil, query, opts)
+	if err != nil {
+		sp.LogFields(opentracinglog.Error(err))
+		return MultiNamespaceQueryResult{}, err
+	}
+
+	result := MultiNamespaceQueryResult{
+		Results:    make([]index.QueryResults, 0, len(results)),
+		Exhaustive: exhaustive,
+	}
+	for _, res := range results {
+		if res.Results != nil {
+			result.Results = append(result.Results, res.Results)
+		}
+	}
+	return result, nil
+}
+
+// queryIDsMultiNamespace queries each of the given namespaces, for the range
+// given for it if any, and returns the result of each namespace queried with
+// the limit of the query options applying to the union of the results.
+func (d *db) queryIDsMultiNamespace(
+	ctx context.Context,
+	nses []databaseNamespace,
+	ranges []xtime.Range,
+	query index.Query,
+	opts index.QueryOptions,
+) ([]index.QueryResult, bool, error) {
+	var (
+		results    = make([]index.QueryResult, len(nses))
+		exhaustive = true
+		size       = 0
+	)
+	for i, n := range nses {
+		nsOpts := opts
+		if ranges != nil {
+			if ranges[i].IsEmpty() {
+				continue
+			}
+			nsOpts.StartInclusive = ranges[i].Start
+			nsOpts.EndExclusive = ranges[i].End
+		}
+		if opts.Limit > 0 {
+			if size >= opts.Limit {
+				// Limit reached before querying all the namespaces.
+				exhaustive = false
+				break
+			}
+			nsOpts.Limit = opts.Limit - size
+		}
+
+		res, err := n.QueryIDs(ctx, query, nsOpts)
+		if err != nil {
+			return nil, false, err
+		}
+
+		results[i] = res
+		exhaustive = exhaustive && res.Exhaustive
+		size += res.Results.Size()
+	}
+	return results, exhaustive, nil
+}
+
+func (d *db) QueryIDsFederated(
+	ctx context.Context,
+	namespaces []ident.ID,
+	query index.Query,
+	opts index.QueryOptions,
+	policy FederatedQueryPolicy,
+) (FederatedQueryResult, error) {
+	if len(namespaces) == 0 {
+		return FederatedQueryResult{}, xerrors.NewInvalidParamsError(errFederatedQueryNoNamespaces)
+	}
+	if err := policy.Validate(); err != nil {
+		return FederatedQueryResult{}, xerrors.NewInvalidParamsError(err)
+	}
+
+	ctx, sp := ctx.StartTraceSpan(tracepoint.DBQueryIDsFederated)
+	sp.LogFields(
+		opentracinglog.String("query", query.String()),
+		opentracinglog.Int("namespaces", len(namespaces)),
+		opentracinglog.String("policy", policy.String()),
+		opentracinglog.Int("limit", opts.Limit),
+		xopentracing.Time("start", opts.StartInclusive),
+		xopentracing.Time("end", opts.EndExclusive),
+	)
+
+	defer sp.Finish()
+
+	seen := make(map[string]struct{}, len(namespaces))
+	for _, namespace := range namespaces {
+		if _, ok := seen[namespace.String()]; ok {
+			return FederatedQueryResult{}, xerrors.NewInvalidParamsError(errFederatedQueryDuplicateNamespaces)
+		}
+		seen[namespace.String()] = struct{}{}
+	}
+
+	// Resolve all the namespaces upfront so that an unknown namespace fails
+	// the query before any of the namespaces are queried.
+	nses, requested, err := d.readNamespacesFor(namespaces)
+	if err != nil {
+		sp.LogFields(opentracinglog.Error(err))
+		d.metrics.unknownNamespaceQueryIDs.Inc(1)
+		return FederatedQueryResult{}, err
+	}
+
+	retentions := make([]time.Duration, 0, len(nses))
+	for _, n := range nses {
+		retentions = append(retentions, n.Options().RetentionOptions().RetentionPeriod())
+	}
+	ranges := federatedQueryRanges(retentions, opts.StartInclusive,
+		opts.EndExclusive, d.nowFn(), policy)
+
+	results, exhaustive, err := d.queryIDsMultiNamespace(ctx, nses, ranges, query, opts)
+	if err != nil {
+		sp.LogFields(opentracinglog.Error(err))
+		return FederatedQueryResult{}, err
+	}
+
+	result := FederatedQueryResult{
+		Results:    make(map[string]FederatedNamespaceQueryResult, len(results)),
+		Exhaustive: exhaustive,
+	}
+	for i, res := range results {
+		if res.Results == nil {
+			continue
+		}
+		result.Results[namespaces[requested[i]].String()] = FederatedNamespaceQueryResult{
+			Range:      ranges[i],
+			Results:    res.Results,
+			Exhaustive: res.Exhaustive,
+		}
+	}
+	return result, nil
+}
+
 func (d *db) AggregateQuery(
 	ctx context.Context,
 	namespace ident.ID,
 	query index.Query,
 	aggResultOpts index.AggregationOptions,
 ) (index.AggregateQueryResult, error) {
-	n, err := d.namespaceFor(namespace)
+	n, err := d.namespaceFor(d.migrations.ReadNamespace(namespace))
 	if err != nil {
 		d.metrics.unknownNamespaceQueryIDs.Inc(1)
 		return index.AggregateQueryResult{}, err
@@ -810,7 +1206,7 @@ func (d *db) ReadEncoded(
 	id ident.ID,
 	start, end time.Time,
 ) ([][]xio.BlockReader, error) {
-	n, err := d.namespaceFor(namespace)
+	n, err := d.namespaceFor(d.migrations.ReadNamespace(namespace))
 	if err != nil {
 		d.metrics.unknownNamespaceRead.Inc(1)
 		return nil, err
@@ -819,6 +1215,61 @@ func (d *db) ReadEncoded(
 	return n.ReadEncoded(ctx, id, start, end)
 }
 
+func (d *db) ReadEncodedBatch(
+	ctx context.Context,
+	namespace ident.ID,
+	ids []ident.ID,
+	start, end time.Time,
+) ([]ReadEncodedResult, error) {
+	n, err := d.namespaceFor(d.migrations.ReadNamespace(namespace))
+	if err != nil {
+		d.metrics.unknownNamespaceRead.Inc(1)
+		return nil, err
+	}
+
+	return n.ReadEncodedBatch(ctx, ids, start, end), nil
+}
+
+func (d *db) ReadEncodedMultiNamespace(
+	ctx context.Context,
+	namespaces []ident.ID,
+	id ident.ID,
+	start, end time.Time,
+	policy MultiNamespaceReadPolicy,
+) ([][]xio.BlockReader, error) {
+	if len(namespaces) == 0 {
+		return nil, xerrors.NewInvalidParamsError(errMultiNamespaceReadNoNamespaces)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, xerrors.NewInvalidParamsError(err)
+	}
+
+	// Resolve all the namespaces upfront so that an unknown namespace or
+	// mismatched block sizes fail the read before any namespace is read.
+	nses, _, err := d.readNamespacesFor(namespaces)
+	if err != nil {
+		d.metrics.unknownNamespaceRead.Inc(1)
+		return nil, err
+	}
+	for _, n := range nses[1:] {
+		if n.Options().RetentionOptions().BlockSize() !=
+			nses[0].Options().RetentionOptions().BlockSize() {
+			return nil, xerrors.NewInvalidParamsError(errMultiNamespaceReadBlockSizesMismatch)
+		}
+	}
+
+	reads := make([][][]xio.BlockReader, 0, len(nses))
+	for _, n := range nses {
+		readers, err := n.ReadEncoded(ctx, id, start, end)
+		if err != nil {
+			return nil, err
+		}
+		reads = append(reads, readers)
+	}
+
+	return mergeMultiNamespaceReads(reads, policy), nil
+}
+
 func (d *db) FetchBlocks(
 	ctx context.Context,
 	namespace ident.ID,
@@ -826,6 +1277,9 @@ func (d *db) FetchBlocks(
 	id ident.ID,
 	starts []time.Time,
 ) ([]block.FetchBlockResult, error) {
+	// NB: Peers stream the data of the namespace itself rather than of the
+	// namespace its reads are served from so that a peer bootstrapping or
+	// repairing a migrated namespace does not receive the data of another.
 	n, err := d.namespaceFor(namespace)
 	if err != nil {
 		d.metrics.unknownNamespaceFetchBlocks.Inc(1)
@@ -865,6 +1319,14 @@ func (d *db) IsBootstrapped() bool {
 	return d.mediator.IsBootstrapped()
 }
 
+func (d *db) BootstrapProgress() BootstrapProgress {
+	return d.mediator.BootstrapProgress()
+}
+
+func (d *db) BootstrapDryRun() ([]NamespaceBootstrapDryRun, error) {
+	return d.mediator.BootstrapDryRun()
+}
+
 // IsBootstrappedAndDurable should only return true if the following conditions are met:
 //    1. The database is bootstrapped.
 //    2. The last successful snapshot began AFTER the last bootstrap completed.
@@ -930,10 +1392,113 @@ func (d *db) Truncate(namespace ident.ID) (int64, error) {
 	return n.Truncate()
 }
 
+func (d *db) DeleteSeries(
+	ctx context.Context,
+	namespace ident.ID,
+	id ident.ID,
+) error {
+	return d.DeleteSeriesBatch(ctx, namespace, []ident.ID{id})
+}
+
+func (d *db) DeleteSeriesBatch(
+	ctx context.Context,
+	namespace ident.ID,
+	ids []ident.ID,
+) error {
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		return xerrors.NewInvalidParamsError(err)
+	}
+	return n.DeleteSeries(ctx, ids)
+}
+
 func (d *db) IsOverloaded() bool {
-	queueSize := float64(d.commitLog.QueueLength())
-	queueCapacity := float64(d.opts.CommitLogOptions().BacklogQueueSize())
-	return queueSize >= commitLogQueueCapacityOverloadedFactor*queueCapacity
+	return d.opts.OverloadPolicy().IsOverloaded(d.overloadSignals())
+}
+
+func (d *db) overloadSignals() OverloadSignals {
+	signals := OverloadSignals{
+		CommitLogQueueLength:   d.commitLog.QueueLength(),
+		CommitLogQueueCapacity: int64(d.opts.CommitLogOptions().BacklogQueueSize()),
+		MemoryPressure:         d.mediator.MemoryPressureLevel(),
+		InFlightQueries:        d.drainer.inFlightQueries(),
+	}
+	if start, ok := d.mediator.LastSuccessfulSnapshotStartTime(); ok {
+		signals.FlushLag = d.nowFn().Sub(start)
+	}
+	return signals
+}
+
+func (d *db) TrackQuery(ctx context.Context) {
+	d.drainer.trackQuery(ctx)
+}
+
+func (d *db) Drain(ctx context.Context) error {
+	if !d.mediator.IsBootstrapped() {
+		return errDatabaseDrainNotBootstrapped
+	}
+	start := d.nowFn()
+	if err := d.drainer.begin(start); err != nil {
+		return err
+	}
+
+	complete := false
+	defer func() {
+		d.drainer.end(complete)
+	}()
+
+	// Snapshot the data written before the drain so that it does not need
+	// to be recovered from the commit log when the node restarts.
+	if d.snapshotsEnabled() {
+		d.log.Info("draining database, waiting for snapshot", zap.Time("start", start))
+		d.mediator.Expedite()
+		err := waitUntil(ctx, drainPollInterval, func() bool {
+			snapshotStart, ok := d.mediator.LastSuccessfulSnapshotStartTime()
+			return ok && !snapshotStart.Before(start)
+		})
+		if err != nil {
+			d.log.Warn("database drain did not complete", zap.Stringer("phase", DrainSnapshotting))
+			return err
+		}
+	} else {
+		// NB: No namespace snapshots its data so the data written before the
+		// drain is only recovered from the commit log.
+		d.log.Info("draining database, snapshots disabled, skipping snapshot",
+			zap.Time("start", start))
+	}
+
+	queries := d.drainer.awaitQueries()
+	d.log.Info("draining database, waiting for in-flight queries",
+		zap.Int64("inFlightQueries", atomic.LoadInt64(&queries.inFlight)))
+	err := waitUntil(ctx, drainPollInterval, func() bool {
+		return atomic.LoadInt64(&queries.inFlight) == 0
+	})
+	if err != nil {
+		d.log.Warn("database drain did not complete",
+			zap.Stringer("phase", DrainWaitingForQueries),
+			zap.Int64("inFlightQueries", atomic.LoadInt64(&queries.inFlight)))
+		return err
+	}
+
+	complete = true
+	d.log.Info("drained database", zap.Duration("took", d.nowFn().Sub(start)))
+	return nil
+}
+
+// snapshotsEnabled returns whether any of the namespaces snapshot their data.
+func (d *db) snapshotsEnabled() bool {
+	d.RLock()
+	defer d.RUnlock()
+	for _, n := range d.namespaces.Iter() {
+		if n.Value().Options().SnapshotEnabled() {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *db) DrainState() DatabaseDrainState {
+	return d.drainer.state()
 }
 
 func (d *db) BootstrapState() DatabaseBootstrapState {
@@ -963,6 +1528,361 @@ func (d *db) FlushState(
 	return n.FlushState(shardID, blockStart)
 }
 
+func (d *db) FlushStates(
+	namespace ident.ID,
+	shardID uint32,
+) ([]BlockFlushState, error) {
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		return nil, err
+	}
+	return n.FlushStates(shardID)
+}
+
+func (d *db) FlushStateSummary(namespace ident.ID) (NamespaceFlushStateSummary, error) {
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		return NamespaceFlushStateSummary{}, err
+	}
+	return n.FlushStateSummary(), nil
+}
+
+func (d *db) DataAgeHeatmap(namespace ident.ID) (DataAgeHeatmap, error) {
+	if _, err := d.namespaceFor(namespace); err != nil {
+		return DataAgeHeatmap{}, err
+	}
+	heatmap, ok := d.mediator.DataAgeHeatmap(namespace)
+	if !ok {
+		return DataAgeHeatmap{}, errDataAgeHeatmapNotComputed
+	}
+	return heatmap, nil
+}
+
+func (d *db) DiskUsage(namespace ident.ID) (NamespaceDiskUsage, error) {
+	if _, err := d.namespaceFor(namespace); err != nil {
+		return NamespaceDiskUsage{}, err
+	}
+	usage, ok := d.mediator.DiskUsage(namespace)
+	if !ok {
+		return NamespaceDiskUsage{}, errDiskUsageNotComputed
+	}
+	return usage, nil
+}
+
+func (d *db) ExportFileSets(
+	namespace ident.ID,
+	start, end time.Time,
+	store fs.ObjectStore,
+) (FileSetExportManifest, error) {
+	if _, err := d.namespaceFor(namespace); err != nil {
+		return FileSetExportManifest{}, err
+	}
+	return d.mediator.ExportFileSets(namespace, start, end, store)
+}
+
+func (d *db) ImportFileSets(
+	namespace ident.ID,
+	store fs.ObjectStore,
+) (FileSetExportManifest, error) {
+	if _, err := d.namespaceFor(namespace); err != nil {
+		return FileSetExportManifest{}, err
+	}
+	return d.mediator.ImportFileSets(namespace, store)
+}
+
+func (d *db) PauseBackgroundTask(
+	namespace ident.ID,
+	task BackgroundTask,
+	duration time.Duration,
+) error {
+	if err := task.Validate(); err != nil {
+		return xerrors.NewInvalidParamsError(err)
+	}
+	if duration <= 0 || duration > maxBackgroundTaskPauseDuration {
+		return xerrors.NewInvalidParamsError(errBackgroundTaskPauseDurationInvalid)
+	}
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		return err
+	}
+
+	until := d.nowFn().Add(duration)
+	n.PauseBackgroundTask(task, until)
+	d.log.Info("paused namespace background task",
+		zap.Stringer("namespace", namespace),
+		zap.Stringer("task", task),
+		zap.Time("until", until))
+	return nil
+}
+
+func (d *db) ResumeBackgroundTask(namespace ident.ID, task BackgroundTask) error {
+	if err := task.Validate(); err != nil {
+		return xerrors.NewInvalidParamsError(err)
+	}
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		return err
+	}
+
+	n.ResumeBackgroundTask(task)
+	d.log.Info("resumed namespace background task",
+		zap.Stringer("namespace", namespace),
+		zap.Stringer("task", task))
+	return nil
+}
+
+func (d *db) PauseShardFlush(
+	namespace ident.ID,
+	shards []uint32,
+	task BackgroundTask,
+	duration time.Duration,
+) error {
+	if err := task.ValidateFlush(); err != nil {
+		return xerrors.NewInvalidParamsError(err)
+	}
+	if duration <= 0 || duration > maxBackgroundTaskPauseDuration {
+		return xerrors.NewInvalidParamsError(errBackgroundTaskPauseDurationInvalid)
+	}
+
+	until := d.nowFn().Add(duration)
+	if err := d.mediator.PauseShardFlush(namespace, shards, task, until); err != nil {
+		return err
+	}
+	d.log.Info("paused shard flushes",
+		zap.Stringer("namespace", namespace),
+		zap.Uint32s("shards", shards),
+		zap.Stringer("task", task),
+		zap.Time("until", until))
+	return nil
+}
+
+func (d *db) ResumeShardFlush(
+	namespace ident.ID,
+	shards []uint32,
+	task BackgroundTask,
+) error {
+	if err := task.ValidateFlush(); err != nil {
+		return xerrors.NewInvalidParamsError(err)
+	}
+	if err := d.mediator.ResumeShardFlush(namespace, shards, task); err != nil {
+		return err
+	}
+	d.log.Info("resumed shard flushes",
+		zap.Stringer("namespace", namespace),
+		zap.Uint32s("shards", shards),
+		zap.Stringer("task", task))
+	return nil
+}
+
+func (d *db) PausedShardFlushes() []PausedShardFlush {
+	return d.mediator.PausedShardFlushes()
+}
+
+func (d *db) BlockCardinalities(
+	namespace ident.ID,
+	shard uint32,
+	start, end time.Time,
+) (BlockCardinalities, error) {
+	if !d.opts.CardinalityOptions().Enabled() {
+		return BlockCardinalities{}, xerrors.NewInvalidParamsError(errCardinalityDisabled)
+	}
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		return BlockCardinalities{}, err
+	}
+	return n.BlockCardinalities(shard, start, end)
+}
+
+func (d *db) SeriesMetadata(
+	ctx context.Context,
+	namespace ident.ID,
+	id ident.ID,
+) (SeriesMetadata, error) {
+	n, err := d.namespaceFor(d.migrations.ReadNamespace(namespace))
+	if err != nil {
+		return SeriesMetadata{}, err
+	}
+	return n.SeriesMetadata(id)
+}
+
+func (d *db) HotSeries(namespace ident.ID, limit int) (HotSeriesResult, error) {
+	if !d.opts.HotSeriesOptions().Enabled() {
+		return HotSeriesResult{}, xerrors.NewInvalidParamsError(errHotSeriesDisabled)
+	}
+	if limit < 0 {
+		return HotSeriesResult{}, xerrors.NewInvalidParamsError(errHotSeriesLimit)
+	}
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		return HotSeriesResult{}, err
+	}
+	return n.HotSeries(limit), nil
+}
+
+func (d *db) UndeleteExpiredFileSets(namespace ident.ID) (retention.Options, error) {
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		return nil, err
+	}
+	if !d.nsRegistry.Static() {
+		// NB: The retention extended in memory would not survive the next
+		// registry update, and each replica undeleting would otherwise extend
+		// the retention in the registry again.
+		return nil, xerrors.NewInvalidParamsError(errUndeleteNamespaceRegistry)
+	}
+
+	ropts, err := n.UndeleteExpiredFileSets()
+	if err != nil {
+		return nil, err
+	}
+	d.log.Info("undeleted namespace expired filesets",
+		zap.Stringer("namespace", namespace),
+		zap.Duration("retentionPeriod", ropts.RetentionPeriod()),
+		zap.Duration("expiredFileSetGracePeriod", ropts.ExpiredFileSetGracePeriod()))
+	return ropts, nil
+}
+
+func (d *db) RebootstrapShard(
+	namespace ident.ID,
+	shard uint32,
+	window xtime.Range,
+) error {
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		return err
+	}
+	return d.mediator.RebootstrapShard(n, shard, window)
+}
+
+func (d *db) PausedBackgroundTasks() []PausedBackgroundTask {
+	d.RLock()
+	namespaces := d.ownedNamespacesWithLock()
+	d.RUnlock()
+
+	var paused []PausedBackgroundTask
+	for _, n := range namespaces {
+		for task, until := range n.PausedBackgroundTasks() {
+			paused = append(paused, PausedBackgroundTask{
+				Namespace: n.ID(),
+				Task:      task,
+				Until:     until,
+			})
+		}
+	}
+	sort.Slice(paused, func(i, j int) bool {
+		if cmp := bytes.Compare(paused[i].Namespace.Bytes(), paused[j].Namespace.Bytes()); cmp != 0 {
+			return cmp < 0
+		}
+		return paused[i].Task < paused[j].Task
+	})
+	return paused
+}
+
+func (d *db) DataDurability(
+	namespace ident.ID,
+	start, end time.Time,
+) (durability.Ranges, error) {
+	if !end.After(start) {
+		return nil, xerrors.NewInvalidParamsError(errDataDurabilityInvalidRange)
+	}
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	lastSnapshotStart, _ := d.mediator.LastSuccessfulSnapshotStartTime()
+	return n.DataDurability(start, end, lastSnapshotStart), nil
+}
+
+func (d *db) ShardRebalanceAdvice(
+	opts ShardRebalanceAdviceOptions,
+) (ShardRebalanceAdvice, error) {
+	if err := opts.Validate(); err != nil {
+		return ShardRebalanceAdvice{}, xerrors.NewInvalidParamsError(err)
+	}
+
+	d.RLock()
+	namespaces := d.ownedNamespacesWithLock()
+	d.RUnlock()
+
+	samples := make(map[uint32]shardLoadSample)
+	for _, n := range namespaces {
+		// NB: Disk usage is taken from the data age heatmap computed at the
+		// last cleanup rather than walking the filesets again.
+		heatmap, _ := d.mediator.DataAgeHeatmap(n.ID())
+		for _, shard := range n.GetOwnedShards() {
+			sample := samples[shard.ID()]
+			sample.numSeries += shard.NumSeries()
+			sample.numWrites += shard.NumWrites()
+			for _, bucket := range heatmap.Shards[shard.ID()] {
+				sample.diskBytes += bucket.Bytes
+			}
+			samples[shard.ID()] = sample
+		}
+	}
+	return d.rebalanceAdvisor.advise(samples, opts), nil
+}
+
+func (d *db) StartNamespaceMigration(
+	source, target ident.ID,
+) (NamespaceMigrationState, error) {
+	return d.migrations.Start(source, target)
+}
+
+func (d *db) CutoverNamespaceMigration(source ident.ID) (NamespaceMigrationState, error) {
+	return d.migrations.Cutover(source)
+}
+
+func (d *db) AbortNamespaceMigration(source ident.ID) error {
+	return d.migrations.Abort(source)
+}
+
+func (d *db) NamespaceMigrations() []NamespaceMigrationState {
+	return d.migrations.States()
+}
+
+func (d *db) CopyNamespace(
+	source, target ident.ID,
+	start, end time.Time,
+) (NamespaceCopyResult, error) {
+	if !d.IsBootstrapped() {
+		return NamespaceCopyResult{}, errNamespaceCopyNotBootstrapped
+	}
+	return d.migrations.Copy(source, target, start, end)
+}
+
+// readNamespacesFor resolves the namespaces that reads of the given
+// namespaces are served from along with the index of the given namespace
+// each was resolved from. A namespace served from a namespace resolved for
+// an earlier one, e.g. the source of a migration cutover to another of the
+// given namespaces, is skipped.
+func (d *db) readNamespacesFor(
+	namespaces []ident.ID,
+) ([]databaseNamespace, []int, error) {
+	var (
+		nses      = make([]databaseNamespace, 0, len(namespaces))
+		requested = make([]int, 0, len(namespaces))
+	)
+	for i, namespace := range namespaces {
+		n, err := d.namespaceFor(d.migrations.ReadNamespace(namespace))
+		if err != nil {
+			return nil, nil, err
+		}
+		duplicate := false
+		for _, existing := range nses {
+			if existing.ID().Equal(n.ID()) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			nses = append(nses, n)
+			requested = append(requested, i)
+		}
+	}
+	return nses, requested, nil
+}
+
 func (d *db) namespaceFor(namespace ident.ID) (databaseNamespace, error) {
 	d.RLock()
 	n, exists := d.namespaces.Get(namespace)
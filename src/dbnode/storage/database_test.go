@@ -42,6 +42,8 @@ import (
 	"github.com/m3db/m3/src/dbnode/tracepoint"
 	"github.com/m3db/m3/src/dbnode/ts"
 	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/m3ninx/idx"
 	xclock "github.com/m3db/m3/src/x/clock"
 	"github.com/m3db/m3/src/x/context"
@@ -108,6 +110,7 @@ func newMockNsInitializer(
 	nsWatch := namespace.NewWatch(w)
 	reg := namespace.NewMockRegistry(ctrl)
 	reg.EXPECT().Watch().Return(nsWatch, nil).AnyTimes()
+	reg.EXPECT().Static().Return(true).AnyTimes()
 
 	return &mockNsInitializer{
 		registry: reg,
@@ -282,6 +285,66 @@ func TestDatabaseReadEncodedNamespaceOwned(t *testing.T) {
 	require.Nil(t, err)
 }
 
+func TestDatabaseReadEncodedBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	d, mapCh, _ := defaultTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	ns := ident.StringID("testns1")
+	ids := []ident.ID{ident.StringID("foo"), ident.StringID("bar")}
+	end := time.Now()
+	start := end.Add(-time.Hour)
+
+	_, err := d.ReadEncodedBatch(ctx, ident.StringID("nonexistent"), ids, start, end)
+	require.True(t, dberrors.IsUnknownNamespaceError(err))
+
+	expected := make([]ReadEncodedResult, len(ids))
+	mockNamespace := NewMockdatabaseNamespace(ctrl)
+	mockNamespace.EXPECT().ReadEncodedBatch(ctx, ids, start, end).Return(expected)
+	d.namespaces.Set(ns, mockNamespace)
+
+	res, err := d.ReadEncodedBatch(ctx, ns, ids, start, end)
+	require.NoError(t, err)
+	require.Equal(t, expected, res)
+}
+
+func TestDatabaseUndeleteExpiredFileSets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := defaultTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	_, err := d.UndeleteExpiredFileSets(ident.StringID("nonexistent"))
+	require.True(t, dberrors.IsUnknownNamespaceError(err))
+
+	ropts := defaultTestRetentionOpts.SetExpiredFileSetGracePeriod(time.Hour)
+	ns := dbAddNewMockNamespace(ctrl, d, "testns1")
+	ns.EXPECT().UndeleteExpiredFileSets().Return(ropts, nil)
+
+	res, err := d.UndeleteExpiredFileSets(ident.StringID("testns1"))
+	require.NoError(t, err)
+	require.Equal(t, ropts, res)
+
+	// Namespaces of the namespace registry cannot be undeleted.
+	reg := namespace.NewMockRegistry(ctrl)
+	reg.EXPECT().Static().Return(false)
+	d.nsRegistry = reg
+
+	_, err = d.UndeleteExpiredFileSets(ident.StringID("testns1"))
+	require.Error(t, err)
+	require.True(t, xerrors.IsInvalidParams(err))
+}
+
 func TestDatabaseFetchBlocksNamespaceNotOwned(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -348,6 +411,293 @@ func TestDatabaseNamespaces(t *testing.T) {
 	assert.Equal(t, "testns2", result[1].ID().String())
 }
 
+func TestDatabasePauseBackgroundTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := defaultTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	now := time.Now()
+	d.nowFn = func() time.Time { return now }
+
+	ns := dbAddNewMockNamespace(ctrl, d, "testns1")
+	ns.EXPECT().PauseBackgroundTask(RepairBackgroundTask, now.Add(time.Hour))
+	require.NoError(t, d.PauseBackgroundTask(ident.StringID("testns1"),
+		RepairBackgroundTask, time.Hour))
+
+	for _, duration := range []time.Duration{0, -time.Minute, 25 * time.Hour} {
+		err := d.PauseBackgroundTask(ident.StringID("testns1"), RepairBackgroundTask, duration)
+		require.Error(t, err)
+		require.True(t, xerrors.IsInvalidParams(err))
+	}
+
+	err := d.PauseBackgroundTask(ident.StringID("testns1"), BackgroundTask(100), time.Hour)
+	require.True(t, xerrors.IsInvalidParams(err))
+
+	err = d.PauseBackgroundTask(ident.StringID("unknown"), RepairBackgroundTask, time.Hour)
+	require.Error(t, err)
+
+	ns.EXPECT().ResumeBackgroundTask(RepairBackgroundTask)
+	require.NoError(t, d.ResumeBackgroundTask(ident.StringID("testns1"), RepairBackgroundTask))
+}
+
+func TestDatabasePauseShardFlush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := defaultTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	now := time.Now()
+	d.nowFn = func() time.Time { return now }
+
+	mediator := NewMockdatabaseMediator(ctrl)
+	d.mediator = mediator
+
+	mediator.EXPECT().PauseShardFlush(ident.NewIDMatcher("testns1"),
+		[]uint32{1, 2}, WarmFlushBackgroundTask, now.Add(time.Hour))
+	require.NoError(t, d.PauseShardFlush(ident.StringID("testns1"),
+		[]uint32{1, 2}, WarmFlushBackgroundTask, time.Hour))
+
+	err := d.PauseShardFlush(ident.StringID("testns1"),
+		[]uint32{1}, RepairBackgroundTask, time.Hour)
+	require.True(t, xerrors.IsInvalidParams(err))
+
+	err = d.PauseShardFlush(ident.StringID("testns1"),
+		[]uint32{1}, ColdFlushBackgroundTask, 25*time.Hour)
+	require.True(t, xerrors.IsInvalidParams(err))
+
+	mediator.EXPECT().ResumeShardFlush(ident.NewIDMatcher("testns1"),
+		[]uint32{1, 2}, WarmFlushBackgroundTask)
+	require.NoError(t, d.ResumeShardFlush(ident.StringID("testns1"),
+		[]uint32{1, 2}, WarmFlushBackgroundTask))
+}
+
+func TestDatabasePausedBackgroundTasks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := defaultTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	until := time.Now().Add(time.Hour)
+	ns1 := dbAddNewMockNamespace(ctrl, d, "testns1")
+	ns1.EXPECT().PausedBackgroundTasks().Return(map[BackgroundTask]time.Time{
+		IndexCompactionBackgroundTask: until,
+		TickBackgroundTask:            until,
+	})
+	ns2 := dbAddNewMockNamespace(ctrl, d, "testns2")
+	ns2.EXPECT().PausedBackgroundTasks().Return(map[BackgroundTask]time.Time{
+		ColdFlushBackgroundTask: until,
+	})
+
+	paused := d.PausedBackgroundTasks()
+	require.Equal(t, 3, len(paused))
+	require.Equal(t, "testns1", paused[0].Namespace.String())
+	require.Equal(t, TickBackgroundTask, paused[0].Task)
+	require.Equal(t, "testns1", paused[1].Namespace.String())
+	require.Equal(t, IndexCompactionBackgroundTask, paused[1].Task)
+	require.Equal(t, "testns2", paused[2].Namespace.String())
+	require.Equal(t, ColdFlushBackgroundTask, paused[2].Task)
+	require.True(t, until.Equal(paused[2].Until))
+}
+
+func TestDatabaseDrain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := defaultTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	now := time.Now()
+	d.nowFn = func() time.Time { return now }
+
+	mediator := NewMockdatabaseMediator(ctrl)
+	d.mediator = mediator
+
+	mediator.EXPECT().IsBootstrapped().Return(true)
+	mediator.EXPECT().Expedite()
+	gomock.InOrder(
+		mediator.EXPECT().LastSuccessfulSnapshotStartTime().
+			Return(now.Add(-time.Minute), true),
+		mediator.EXPECT().LastSuccessfulSnapshotStartTime().
+			Return(now, true),
+	)
+
+	// A query in-flight before the drain is waited on by the drain.
+	queryCtx := context.NewContext()
+	d.drainer.trackQuery(queryCtx)
+
+	drainCtx := context.NewContext()
+	defer drainCtx.Close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- d.Drain(drainCtx)
+	}()
+
+	for {
+		state := d.DrainState()
+		if state.Phase == DrainWaitingForQueries {
+			require.Equal(t, int64(1), state.InFlightQueries)
+			require.True(t, now.Equal(state.StartTime))
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+	err := d.Write(ctx, ident.StringID("testns1"), ident.StringID("foo"),
+		now, 1.0, xtime.Second, nil)
+	require.Equal(t, errDatabaseDraining, err)
+
+	queryCtx.BlockingClose()
+	require.NoError(t, <-errCh)
+	require.Equal(t, DrainComplete, d.DrainState().Phase)
+}
+
+func TestDatabaseDrainCancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := defaultTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	mediator := NewMockdatabaseMediator(ctrl)
+	d.mediator = mediator
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	mediator.EXPECT().IsBootstrapped().Return(false)
+	require.Equal(t, errDatabaseDrainNotBootstrapped, d.Drain(ctx))
+	require.Equal(t, DrainNotStarted, d.DrainState().Phase)
+
+	mediator.EXPECT().IsBootstrapped().Return(true)
+	mediator.EXPECT().Expedite()
+	mediator.EXPECT().LastSuccessfulSnapshotStartTime().
+		Return(time.Time{}, false).AnyTimes()
+
+	ctx.Cancel()
+	require.Equal(t, errDatabaseDrainCancelled, d.Drain(ctx))
+	require.Equal(t, DrainSnapshotting, d.DrainState().Phase)
+
+	// Writes are accepted again once a drain did not complete.
+	require.NoError(t, d.drainer.checkWrite())
+}
+
+func TestDatabaseDrainSnapshotsDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	md, err := namespace.NewMetadata(defaultTestNs1ID,
+		defaultTestNs1Opts.SetSnapshotEnabled(false))
+	require.NoError(t, err)
+	nsMap, err := namespace.NewMap([]namespace.Metadata{md})
+	require.NoError(t, err)
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, newTestDatabaseOpt{
+		bs:    Bootstrapped,
+		nsMap: nsMap,
+		dbOpt: DefaultTestOptions(),
+	})
+	defer func() {
+		close(mapCh)
+	}()
+
+	mediator := NewMockdatabaseMediator(ctrl)
+	d.mediator = mediator
+
+	// The drain does not wait for a snapshot that would never be taken.
+	mediator.EXPECT().IsBootstrapped().Return(true)
+
+	queryCtx := context.NewContext()
+	d.TrackQuery(queryCtx)
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- d.Drain(ctx)
+	}()
+
+	for d.DrainState().Phase != DrainWaitingForQueries {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	queryCtx.BlockingClose()
+	require.NoError(t, <-errCh)
+	require.Equal(t, DrainComplete, d.DrainState().Phase)
+}
+
+func TestDatabaseShardRebalanceAdvice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := defaultTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	mediator := NewMockdatabaseMediator(ctrl)
+	d.mediator = mediator
+
+	newMockShard := func(id uint32, numSeries, numWrites int64) databaseShard {
+		shard := NewMockdatabaseShard(ctrl)
+		shard.EXPECT().ID().Return(id).AnyTimes()
+		shard.EXPECT().NumSeries().Return(numSeries)
+		shard.EXPECT().NumWrites().Return(numWrites)
+		return shard
+	}
+
+	ns1 := dbAddNewMockNamespace(ctrl, d, "testns1")
+	ns1.EXPECT().GetOwnedShards().Return([]databaseShard{
+		newMockShard(0, 10, 0),
+		newMockShard(1, 10, 0),
+	})
+	ns2 := dbAddNewMockNamespace(ctrl, d, "testns2")
+	ns2.EXPECT().GetOwnedShards().Return([]databaseShard{
+		newMockShard(0, 20, 0),
+		newMockShard(1, 100, 0),
+	})
+	mediator.EXPECT().DataAgeHeatmap(ns1.ID()).Return(DataAgeHeatmap{}, false)
+	mediator.EXPECT().DataAgeHeatmap(ns2.ID()).Return(DataAgeHeatmap{
+		Shards: map[uint32][]DataAgeBucket{
+			0: {{Bytes: 100}},
+			1: {{Bytes: 50}, {Bytes: 50}},
+		},
+	}, true)
+
+	advice, err := d.ShardRebalanceAdvice(ShardRebalanceAdviceOptions{MaxLoadSkew: 1.2})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(advice.Shards))
+	require.Equal(t, uint32(0), advice.Shards[0].Shard)
+	require.Equal(t, int64(30), advice.Shards[0].NumSeries)
+	require.Equal(t, int64(100), advice.Shards[0].DiskBytes)
+	require.Equal(t, uint32(1), advice.Shards[1].Shard)
+	require.Equal(t, int64(110), advice.Shards[1].NumSeries)
+	require.Equal(t, int64(100), advice.Shards[1].DiskBytes)
+	require.Equal(t, 1, len(advice.Moves))
+	require.Equal(t, uint32(1), advice.Moves[0].Shard)
+
+	_, err = d.ShardRebalanceAdvice(ShardRebalanceAdviceOptions{MaxLoadSkew: 0.5})
+	require.Error(t, err)
+	require.True(t, xerrors.IsInvalidParams(err))
+}
+
 func TestGetOwnedNamespacesErrorIfClosed(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -565,6 +915,57 @@ func TestDatabaseAddNamespace(t *testing.T) {
 	require.Equal(t, md1.Options(), ns3.Options())
 }
 
+func TestDatabaseUpdateNamespacesIndexEnabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := defaultTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	var (
+		nopts    = defaultTestNs1Opts.SetIndexOptions(namespace.NewIndexOptions().SetEnabled(true))
+		disabled = nopts.SetIndexOptions(nopts.IndexOptions().SetEnabled(false))
+		ropts    = nopts.RetentionOptions().SetRetentionPeriod(2000 * time.Hour)
+	)
+	newMetadata := func(id string, opts namespace.Options) namespace.Metadata {
+		md, err := namespace.NewMetadata(ident.StringID(id), opts)
+		require.NoError(t, err)
+		return md
+	}
+
+	// Only the indexing is updated.
+	indexOnly := dbAddNewMockNamespace(ctrl, d, "indexOnly")
+	indexOnly.EXPECT().Options().Return(nopts)
+	indexOnly.EXPECT().UpdateIndexEnabled(false).Return(nil)
+
+	// The retention update remains to be applied.
+	withRetention := dbAddNewMockNamespace(ctrl, d, "withRetention")
+	withRetention.EXPECT().Options().Return(nopts)
+	withRetention.EXPECT().UpdateIndexEnabled(false).Return(nil)
+	withRetentionMd := newMetadata("withRetention", disabled.SetRetentionOptions(ropts))
+
+	// Other changes need a restart.
+	other := dbAddNewMockNamespace(ctrl, d, "other")
+	other.EXPECT().Options().Return(nopts)
+	otherMd := newMetadata("other", disabled.SetSnapshotEnabled(!nopts.SnapshotEnabled()))
+
+	// Failed updates remain.
+	failed := dbAddNewMockNamespace(ctrl, d, "failed")
+	failed.EXPECT().Options().Return(disabled)
+	failed.EXPECT().UpdateIndexEnabled(true).Return(errors.New("not created"))
+	failedMd := newMetadata("failed", nopts)
+
+	remaining := d.updateNamespacesIndexEnabledWithLock([]namespace.Metadata{
+		newMetadata("indexOnly", disabled),
+		withRetentionMd,
+		otherMd,
+		failedMd,
+	})
+	require.Equal(t, []namespace.Metadata{withRetentionMd, otherMd, failedMd}, remaining)
+}
+
 func TestDatabaseUpdateNamespace(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -601,12 +1002,12 @@ func TestDatabaseUpdateNamespace(t *testing.T) {
 	<-updateCh
 	time.Sleep(10 * time.Millisecond)
 
-	// ensure the namespaces have old properties
+	// ensure the retention update has been applied at runtime
 	nses = d.Namespaces()
 	require.Len(t, nses, 2)
 	ns1, ok := d.Namespace(defaultTestNs1ID)
 	require.True(t, ok)
-	require.Equal(t, defaultTestNs1Opts, ns1.Options())
+	require.Equal(t, defaultTestNs1Opts.SetRetentionOptions(ropts), ns1.Options())
 	ns2, ok := d.Namespace(defaultTestNs2ID)
 	require.True(t, ok)
 	require.Equal(t, defaultTestNs2Opts, ns2.Options())
@@ -770,7 +1171,7 @@ func testDatabaseNamespaceIndexFunctions(t *testing.T, commitlogEnabled bool) {
 		SetWritesToCommitLog(commitlogEnabled)
 
 	ns.EXPECT().GetOwnedShards().Return([]databaseShard{}).AnyTimes()
-	ns.EXPECT().Tick(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	ns.EXPECT().Tick(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 	ns.EXPECT().BootstrapState().Return(ShardBootstrapStates{}).AnyTimes()
 	ns.EXPECT().Options().Return(nsOptions).AnyTimes()
 	require.NoError(t, d.Open())
@@ -846,6 +1247,232 @@ func testDatabaseNamespaceIndexFunctions(t *testing.T, commitlogEnabled bool) {
 	assert.Equal(t, "root", spans[2].OperationName)
 }
 
+func TestDatabaseQueryIDsMultiNamespace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := defaultTestDatabase(t, ctrl, BootstrapNotStarted)
+	defer func() {
+		close(mapCh)
+	}()
+
+	var (
+		ctx = context.NewContext()
+		q   = index.Query{
+			Query: idx.NewTermQuery([]byte("foo"), []byte("bar")),
+		}
+		ns1     = dbAddNewMockNamespace(ctrl, d, "testns1")
+		ns2     = dbAddNewMockNamespace(ctrl, d, "testns2")
+		ns1Res  = index.NewQueryResults(ident.StringID("testns1"), index.QueryResultsOptions{}, d.opts.IndexOptions())
+		ns2Res  = index.NewQueryResults(ident.StringID("testns2"), index.QueryResultsOptions{}, d.opts.IndexOptions())
+		nsIDs   = []ident.ID{ident.StringID("testns1"), ident.StringID("testns2")}
+		limited = index.QueryOptions{Limit: 2}
+	)
+	defer ctx.Close()
+
+	_, err := ns1Res.AddDocuments([]doc.Document{{ID: []byte("a")}, {ID: []byte("b")}})
+	require.NoError(t, err)
+	_, err = ns2Res.AddDocuments([]doc.Document{{ID: []byte("a")}})
+	require.NoError(t, err)
+
+	// Results from all namespaces are returned.
+	ns1.EXPECT().QueryIDs(gomock.Any(), q, index.QueryOptions{}).
+		Return(index.QueryResult{Results: ns1Res, Exhaustive: true}, nil)
+	ns2.EXPECT().QueryIDs(gomock.Any(), q, index.QueryOptions{}).
+		Return(index.QueryResult{Results: ns2Res, Exhaustive: true}, nil)
+	res, err := d.QueryIDsMultiNamespace(ctx, nsIDs, q, index.QueryOptions{})
+	require.NoError(t, err)
+	require.True(t, res.Exhaustive)
+	require.Len(t, res.Results, 2)
+	require.Equal(t, "testns1", res.Results[0].Namespace().String())
+	require.Equal(t, 2, res.Results[0].Size())
+	require.Equal(t, "testns2", res.Results[1].Namespace().String())
+	require.Equal(t, 1, res.Results[1].Size())
+
+	// The limit applies across all the namespaces.
+	ns1.EXPECT().QueryIDs(gomock.Any(), q, limited).
+		Return(index.QueryResult{Results: ns1Res, Exhaustive: false}, nil)
+	res, err = d.QueryIDsMultiNamespace(ctx, nsIDs, q, limited)
+	require.NoError(t, err)
+	require.False(t, res.Exhaustive)
+	require.Len(t, res.Results, 1)
+
+	// Errors from any of the namespaces fail the query.
+	ns1.EXPECT().QueryIDs(gomock.Any(), q, index.QueryOptions{}).
+		Return(index.QueryResult{Results: ns1Res, Exhaustive: true}, nil)
+	ns2.EXPECT().QueryIDs(gomock.Any(), q, index.QueryOptions{}).
+		Return(index.QueryResult{}, fmt.Errorf("random err"))
+	_, err = d.QueryIDsMultiNamespace(ctx, nsIDs, q, index.QueryOptions{})
+	require.Error(t, err)
+
+	// Unknown namespaces fail the query before any namespace is queried.
+	_, err = d.QueryIDsMultiNamespace(ctx,
+		[]ident.ID{ident.StringID("testns1"), ident.StringID("unknown")}, q, index.QueryOptions{})
+	require.Error(t, err)
+}
+
+func TestDatabaseQueryIDsFederated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := defaultTestDatabase(t, ctrl, BootstrapNotStarted)
+	defer func() {
+		close(mapCh)
+	}()
+
+	var (
+		ctx = context.NewContext()
+		now = time.Now().Truncate(time.Hour)
+		q   = index.Query{
+			Query: idx.NewTermQuery([]byte("foo"), []byte("bar")),
+		}
+		unagg    = dbAddNewMockNamespace(ctrl, d, "unagg")
+		agg      = dbAddNewMockNamespace(ctrl, d, "agg")
+		unaggRes = index.NewQueryResults(ident.StringID("unagg"), index.QueryResultsOptions{}, d.opts.IndexOptions())
+		aggRes   = index.NewQueryResults(ident.StringID("agg"), index.QueryResultsOptions{}, d.opts.IndexOptions())
+		nsIDs    = []ident.ID{ident.StringID("unagg"), ident.StringID("agg")}
+		opts     = index.QueryOptions{
+			StartInclusive: now.Add(-7 * 24 * time.Hour),
+			EndExclusive:   now,
+		}
+	)
+	defer ctx.Close()
+
+	d.nowFn = func() time.Time { return now }
+	unagg.EXPECT().Options().Return(namespace.NewOptions().SetRetentionOptions(
+		retention.NewOptions().SetRetentionPeriod(2 * 24 * time.Hour))).AnyTimes()
+	agg.EXPECT().Options().Return(namespace.NewOptions().SetRetentionOptions(
+		retention.NewOptions().SetRetentionPeriod(365 * 24 * time.Hour))).AnyTimes()
+
+	_, err := unaggRes.AddDocuments([]doc.Document{{ID: []byte("a")}, {ID: []byte("b")}})
+	require.NoError(t, err)
+	_, err = aggRes.AddDocuments([]doc.Document{{ID: []byte("a")}})
+	require.NoError(t, err)
+
+	// Each namespace is only queried for the part of the range it serves.
+	unaggOpts := opts
+	unaggOpts.StartInclusive = now.Add(-2 * 24 * time.Hour)
+	aggOpts := opts
+	aggOpts.EndExclusive = now.Add(-2 * 24 * time.Hour)
+	unagg.EXPECT().QueryIDs(gomock.Any(), q, unaggOpts).
+		Return(index.QueryResult{Results: unaggRes, Exhaustive: true}, nil)
+	agg.EXPECT().QueryIDs(gomock.Any(), q, aggOpts).
+		Return(index.QueryResult{Results: aggRes, Exhaustive: true}, nil)
+	res, err := d.QueryIDsFederated(ctx, nsIDs, q, opts, FederatedQueryFinestResolution)
+	require.NoError(t, err)
+	require.True(t, res.Exhaustive)
+	require.Len(t, res.Results, 2)
+	require.Equal(t, 2, res.Results["unagg"].Results.Size())
+	require.Equal(t, unaggOpts.StartInclusive, res.Results["unagg"].Range.Start)
+	require.Equal(t, 1, res.Results["agg"].Results.Size())
+	require.Equal(t, aggOpts.EndExclusive, res.Results["agg"].Range.End)
+
+	// Namespaces not serving any part of the range are not queried.
+	recentOpts := index.QueryOptions{
+		StartInclusive: now.Add(-time.Hour),
+		EndExclusive:   now,
+	}
+	unagg.EXPECT().QueryIDs(gomock.Any(), q, recentOpts).
+		Return(index.QueryResult{Results: unaggRes, Exhaustive: true}, nil)
+	res, err = d.QueryIDsFederated(ctx, nsIDs, q, recentOpts, FederatedQueryFinestResolution)
+	require.NoError(t, err)
+	require.Len(t, res.Results, 1)
+	require.Equal(t, 2, res.Results["unagg"].Results.Size())
+
+	// Every namespace is queried for the whole range with all resolutions
+	// and the limit applies across all the namespaces.
+	limited := opts
+	limited.Limit = 2
+	unagg.EXPECT().QueryIDs(gomock.Any(), q, limited).
+		Return(index.QueryResult{Results: unaggRes, Exhaustive: false}, nil)
+	res, err = d.QueryIDsFederated(ctx, nsIDs, q, limited, FederatedQueryAllResolutions)
+	require.NoError(t, err)
+	require.False(t, res.Exhaustive)
+	require.Len(t, res.Results, 1)
+
+	// Invalid policies, duplicate and unknown namespaces fail the query
+	// before any namespace is queried.
+	_, err = d.QueryIDsFederated(ctx, nsIDs, q, opts, FederatedQueryPolicy(2))
+	require.True(t, xerrors.IsInvalidParams(err))
+	_, err = d.QueryIDsFederated(ctx, nil, q, opts, FederatedQueryAllResolutions)
+	require.True(t, xerrors.IsInvalidParams(err))
+	_, err = d.QueryIDsFederated(ctx,
+		[]ident.ID{ident.StringID("unagg"), ident.StringID("unagg")}, q, opts,
+		FederatedQueryAllResolutions)
+	require.True(t, xerrors.IsInvalidParams(err))
+	_, err = d.QueryIDsFederated(ctx,
+		[]ident.ID{ident.StringID("unagg"), ident.StringID("unknown")}, q, opts,
+		FederatedQueryAllResolutions)
+	require.Error(t, err)
+}
+
+func TestDatabaseReadEncodedMultiNamespace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := defaultTestDatabase(t, ctrl, BootstrapNotStarted)
+	defer func() {
+		close(mapCh)
+	}()
+
+	var (
+		ctx       = context.NewContext()
+		id        = ident.StringID("foo")
+		blockSize = 2 * time.Hour
+		start     = time.Now().Truncate(blockSize)
+		end       = start.Add(2 * blockSize)
+		nsOpts    = namespace.NewOptions().SetRetentionOptions(
+			retention.NewOptions().SetBlockSize(blockSize))
+		overlay    = dbAddNewMockNamespace(ctrl, d, "overlay")
+		raw        = dbAddNewMockNamespace(ctrl, d, "raw")
+		nsIDs      = []ident.ID{ident.StringID("overlay"), ident.StringID("raw")}
+		overlayBlk = xio.BlockReader{Start: start.Add(blockSize), BlockSize: blockSize}
+		rawBlks    = []xio.BlockReader{
+			{Start: start, BlockSize: blockSize},
+			{Start: start.Add(blockSize), BlockSize: blockSize},
+		}
+	)
+	defer ctx.Close()
+
+	overlay.EXPECT().Options().Return(nsOpts).AnyTimes()
+	raw.EXPECT().Options().Return(nsOpts).AnyTimes()
+	overlay.EXPECT().ReadEncoded(ctx, id, start, end).
+		Return([][]xio.BlockReader{{overlayBlk}}, nil).Times(2)
+	raw.EXPECT().ReadEncoded(ctx, id, start, end).
+		Return([][]xio.BlockReader{{rawBlks[0]}, {rawBlks[1]}}, nil).Times(2)
+
+	res, err := d.ReadEncodedMultiNamespace(ctx, nsIDs, id, start, end,
+		MultiNamespaceReadOverride)
+	require.NoError(t, err)
+	require.Equal(t, [][]xio.BlockReader{{rawBlks[0]}, {overlayBlk}}, res)
+
+	res, err = d.ReadEncodedMultiNamespace(ctx, nsIDs, id, start, end,
+		MultiNamespaceReadMerge)
+	require.NoError(t, err)
+	require.Equal(t, [][]xio.BlockReader{{rawBlks[0]}, {rawBlks[1], overlayBlk}}, res)
+
+	// Invalid policies, unknown namespaces and mismatched block sizes fail
+	// the read before any namespace is read.
+	_, err = d.ReadEncodedMultiNamespace(ctx, nsIDs, id, start, end,
+		MultiNamespaceReadPolicy(2))
+	require.True(t, xerrors.IsInvalidParams(err))
+	_, err = d.ReadEncodedMultiNamespace(ctx, nil, id, start, end,
+		MultiNamespaceReadOverride)
+	require.True(t, xerrors.IsInvalidParams(err))
+	_, err = d.ReadEncodedMultiNamespace(ctx,
+		[]ident.ID{ident.StringID("overlay"), ident.StringID("unknown")}, id, start, end,
+		MultiNamespaceReadOverride)
+	require.Error(t, err)
+
+	hourly := dbAddNewMockNamespace(ctrl, d, "hourly")
+	hourly.EXPECT().Options().Return(namespace.NewOptions().SetRetentionOptions(
+		retention.NewOptions().SetBlockSize(time.Hour))).AnyTimes()
+	_, err = d.ReadEncodedMultiNamespace(ctx,
+		[]ident.ID{ident.StringID("overlay"), ident.StringID("hourly")}, id, start, end,
+		MultiNamespaceReadOverride)
+	require.True(t, xerrors.IsInvalidParams(err))
+}
+
 func TestDatabaseWriteBatchNoNamespace(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -950,7 +1577,7 @@ func testDatabaseWriteBatch(t *testing.T,
 		SetWritesToCommitLog(commitlogEnabled)
 
 	ns.EXPECT().GetOwnedShards().Return([]databaseShard{}).AnyTimes()
-	ns.EXPECT().Tick(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	ns.EXPECT().Tick(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 	ns.EXPECT().BootstrapState().Return(ShardBootstrapStates{}).AnyTimes()
 	ns.EXPECT().Options().Return(nsOptions).AnyTimes()
 	ns.EXPECT().Close().Return(nil).Times(1)
@@ -1273,7 +1900,7 @@ func TestUpdateBatchWriterBasedOnShardResults(t *testing.T) {
 	nsOptions := namespace.NewOptions().
 		SetWritesToCommitLog(false)
 	ns.EXPECT().GetOwnedShards().Return([]databaseShard{}).AnyTimes()
-	ns.EXPECT().Tick(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	ns.EXPECT().Tick(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 	ns.EXPECT().BootstrapState().Return(ShardBootstrapStates{}).AnyTimes()
 	ns.EXPECT().Options().Return(nsOptions).AnyTimes()
 	ns.EXPECT().Close().Return(nil).Times(1)
@@ -1351,3 +1978,40 @@ func TestDatabaseIsOverloaded(t *testing.T) {
 	mockCL.EXPECT().QueueLength().Return(int64(90))
 	require.Equal(t, true, d.IsOverloaded())
 }
+
+func TestDatabaseIsOverloadedPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := defaultTestDatabase(t, ctrl, BootstrapNotStarted)
+	defer func() {
+		close(mapCh)
+	}()
+
+	var signals OverloadSignals
+	d.opts = d.opts.
+		SetCommitLogOptions(d.opts.CommitLogOptions().SetBacklogQueueSize(100)).
+		SetOverloadPolicy(OverloadPolicyFn(func(s OverloadSignals) bool {
+			signals = s
+			return s.InFlightQueries > 0
+		}))
+
+	mockCL := commitlog.NewMockCommitLog(ctrl)
+	mockCL.EXPECT().QueueLength().Return(int64(5)).AnyTimes()
+	d.commitLog = mockCL
+
+	require.False(t, d.IsOverloaded())
+	require.Equal(t, OverloadSignals{
+		CommitLogQueueLength:   5,
+		CommitLogQueueCapacity: 100,
+		MemoryPressure:         MemoryPressureNone,
+	}, signals)
+
+	ctx := context.NewContext()
+	d.drainer.trackQuery(ctx)
+	require.True(t, d.IsOverloaded())
+	require.Equal(t, int64(1), signals.InFlightQueries)
+
+	ctx.BlockingClose()
+	require.False(t, d.IsOverloaded())
+}
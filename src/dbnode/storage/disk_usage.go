@@ -0,0 +1,155 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/x/ident"
+)
+
+// diskUsageShardFiles are the files on disk that belong to a single shard.
+type diskUsageShardFiles struct {
+	shard     uint32
+	data      fs.FileSetFilesSlice
+	snapshots fs.FileSetFilesSlice
+}
+
+// diskUsageNamespaceFiles are the files on disk that belong to a namespace,
+// index filesets are shared by all the shards of the namespace.
+type diskUsageNamespaceFiles struct {
+	namespace ident.ID
+	index     fs.FileSetFilesSlice
+	shards    []diskUsageShardFiles
+}
+
+// diskUsageTracker maintains the disk usage breakdown of all namespaces. It is
+// refreshed by the cleanup manager after each cleanup so that the usage
+// reflects the files that remain once expired files have been removed.
+type diskUsageTracker struct {
+	sync.RWMutex
+
+	fileSetSizeFn fileSetSizeFn
+	usage         map[string]NamespaceDiskUsage
+}
+
+func newDiskUsageTracker() *diskUsageTracker {
+	return &diskUsageTracker{
+		fileSetSizeFn: fileSetSize,
+		usage:         make(map[string]NamespaceDiskUsage),
+	}
+}
+
+// Update replaces the tracked disk usage with the usage of the provided
+// namespace files. Index fileset bytes are apportioned evenly across the
+// shards of a namespace and commit log bytes evenly across the shards of all
+// namespaces since neither can be attributed to a single shard.
+func (t *diskUsageTracker) Update(
+	now time.Time,
+	namespaces []diskUsageNamespaceFiles,
+	commitLogFiles []string,
+) error {
+	commitLogBytes, err := t.fileSetSizeFn(commitLogFiles)
+	if err != nil {
+		return err
+	}
+
+	numShards := 0
+	for _, n := range namespaces {
+		numShards += len(n.shards)
+	}
+	commitLogShares := apportionBytes(commitLogBytes, numShards)
+
+	usage := make(map[string]NamespaceDiskUsage, len(namespaces))
+	for _, n := range namespaces {
+		indexBytes, err := t.fileSetSizeFn(n.index.Filepaths())
+		if err != nil {
+			return err
+		}
+
+		nsUsage := NamespaceDiskUsage{
+			Namespace:  n.namespace,
+			ComputedAt: now,
+			Total:      DiskUsage{IndexBytes: indexBytes},
+			Shards:     make(map[uint32]DiskUsage, len(n.shards)),
+		}
+		indexShares := apportionBytes(indexBytes, len(n.shards))
+		for i, shard := range n.shards {
+			dataBytes, err := t.fileSetSizeFn(shard.data.Filepaths())
+			if err != nil {
+				return err
+			}
+			snapshotBytes, err := t.fileSetSizeFn(shard.snapshots.Filepaths())
+			if err != nil {
+				return err
+			}
+
+			shardUsage := DiskUsage{
+				DataBytes:      dataBytes,
+				IndexBytes:     indexShares[i],
+				SnapshotBytes:  snapshotBytes,
+				CommitLogBytes: commitLogShares[0],
+			}
+			commitLogShares = commitLogShares[1:]
+
+			nsUsage.Shards[shard.shard] = shardUsage
+			nsUsage.Total.DataBytes += shardUsage.DataBytes
+			nsUsage.Total.SnapshotBytes += shardUsage.SnapshotBytes
+			nsUsage.Total.CommitLogBytes += shardUsage.CommitLogBytes
+		}
+		usage[n.namespace.String()] = nsUsage
+	}
+
+	t.Lock()
+	t.usage = usage
+	t.Unlock()
+	return nil
+}
+
+// Usage returns the last computed disk usage for a namespace.
+func (t *diskUsageTracker) Usage(namespace ident.ID) (NamespaceDiskUsage, bool) {
+	t.RLock()
+	usage, ok := t.usage[namespace.String()]
+	t.RUnlock()
+	return usage, ok
+}
+
+// apportionBytes splits bytes into n shares that sum to bytes, with any
+// remainder distributed one byte at a time to the first shares.
+func apportionBytes(bytes int64, n int) []int64 {
+	shares := make([]int64, n)
+	if n == 0 {
+		return shares
+	}
+	var (
+		share     = bytes / int64(n)
+		remainder = bytes % int64(n)
+	)
+	for i := range shares {
+		shares[i] = share
+		if int64(i) < remainder {
+			shares[i]++
+		}
+	}
+	return shares
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDiskUsageFiles(paths ...string) fs.FileSetFilesSlice {
+	files := make(fs.FileSetFilesSlice, 0, len(paths))
+	for _, path := range paths {
+		file := fs.NewFileSetFile(fs.FileSetFileIdentifier{}, "")
+		file.AbsoluteFilepaths = []string{path}
+		files = append(files, file)
+	}
+	return files
+}
+
+func TestDiskUsageTrackerUpdate(t *testing.T) {
+	var (
+		ns1     = ident.StringID("ns1")
+		ns2     = ident.StringID("ns2")
+		now     = time.Now().Truncate(time.Hour)
+		tracker = newDiskUsageTracker()
+		sizes   = map[string]int64{
+			"data-a": 100, "data-b": 200, "snap-a": 10,
+			"index": 31, "commitlog-a": 50, "commitlog-b": 50,
+		}
+	)
+	tracker.fileSetSizeFn = func(files []string) (int64, error) {
+		var size int64
+		for _, file := range files {
+			size += sizes[file]
+		}
+		return size, nil
+	}
+
+	_, ok := tracker.Usage(ns1)
+	require.False(t, ok)
+
+	require.NoError(t, tracker.Update(now, []diskUsageNamespaceFiles{
+		{
+			namespace: ns1,
+			index:     newTestDiskUsageFiles("index"),
+			shards: []diskUsageShardFiles{
+				{
+					shard:     0,
+					data:      newTestDiskUsageFiles("data-a"),
+					snapshots: newTestDiskUsageFiles("snap-a"),
+				},
+				{
+					shard: 1,
+					data:  newTestDiskUsageFiles("data-b"),
+				},
+			},
+		},
+		{
+			namespace: ns2,
+			shards:    []diskUsageShardFiles{{shard: 0}},
+		},
+	}, []string{"commitlog-a", "commitlog-b"}))
+
+	usage, ok := tracker.Usage(ns1)
+	require.True(t, ok)
+	require.True(t, now.Equal(usage.ComputedAt))
+	require.Equal(t, DiskUsage{
+		DataBytes:      300,
+		IndexBytes:     31,
+		SnapshotBytes:  10,
+		CommitLogBytes: 67,
+	}, usage.Total)
+	require.Equal(t, DiskUsage{
+		DataBytes:      100,
+		IndexBytes:     16,
+		SnapshotBytes:  10,
+		CommitLogBytes: 34,
+	}, usage.Shards[0])
+	require.Equal(t, DiskUsage{
+		DataBytes:      200,
+		IndexBytes:     15,
+		CommitLogBytes: 33,
+	}, usage.Shards[1])
+
+	usage, ok = tracker.Usage(ns2)
+	require.True(t, ok)
+	require.Equal(t, DiskUsage{CommitLogBytes: 33}, usage.Total)
+
+	// Namespaces that are no longer present are forgotten.
+	require.NoError(t, tracker.Update(now, []diskUsageNamespaceFiles{
+		{namespace: ns2},
+	}, nil))
+	_, ok = tracker.Usage(ns1)
+	require.False(t, ok)
+}
+
+func TestApportionBytes(t *testing.T) {
+	require.Equal(t, []int64{}, apportionBytes(10, 0))
+	require.Equal(t, []int64{4, 3, 3}, apportionBytes(10, 3))
+	require.Equal(t, []int64{1, 1, 0}, apportionBytes(2, 3))
+}
@@ -0,0 +1,226 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/resource"
+)
+
+// DrainPhase is the phase of draining a database.
+type DrainPhase uint
+
+const (
+	// DrainNotStarted is the phase of a database that is not draining.
+	DrainNotStarted DrainPhase = iota
+	// DrainSnapshotting is the phase of waiting for a snapshot of the data
+	// written before the drain started.
+	DrainSnapshotting
+	// DrainWaitingForQueries is the phase of waiting for the queries that
+	// were in-flight once the snapshot completed.
+	DrainWaitingForQueries
+	// DrainComplete is the phase of a drained database.
+	DrainComplete
+)
+
+const (
+	// drainPollInterval is how often the progress of a drain is checked.
+	drainPollInterval = 100 * time.Millisecond
+)
+
+var (
+	// errDatabaseDraining is returned for writes to a draining database.
+	errDatabaseDraining = errors.New("database is draining")
+
+	errDatabaseDrainInProgress      = errors.New("database drain already in progress")
+	errDatabaseDrainNotBootstrapped = errors.New("database cannot be drained before it is bootstrapped")
+	errDatabaseDrainCancelled       = errors.New("database drain cancelled")
+)
+
+func (p DrainPhase) String() string {
+	switch p {
+	case DrainNotStarted:
+		return "not-started"
+	case DrainSnapshotting:
+		return "snapshotting"
+	case DrainWaitingForQueries:
+		return "waiting-for-queries"
+	case DrainComplete:
+		return "complete"
+	}
+	return fmt.Sprintf("unknown drain phase: %d", uint(p))
+}
+
+// DatabaseDrainState is the progress of draining a database.
+type DatabaseDrainState struct {
+	// Phase is the current phase of the drain.
+	Phase DrainPhase
+	// StartTime is when the drain started, if it has.
+	StartTime time.Time
+	// InFlightQueries is the number of queries being waited on when waiting
+	// for queries, otherwise the number of queries currently in-flight.
+	InFlightQueries int64
+}
+
+// drainQueries counts the in-flight queries of a generation.
+type drainQueries struct {
+	inFlight int64
+}
+
+// databaseDrainer rejects the writes of a draining database and tracks the
+// in-flight queries that a drain waits for.
+type databaseDrainer struct {
+	sync.RWMutex
+
+	draining   bool
+	inProgress bool
+	phase      DrainPhase
+	startTime  time.Time
+
+	// queries counts the queries started since the last generation was
+	// awaited, awaiting is the generation being waited on by the drain.
+	queries  *drainQueries
+	awaiting *drainQueries
+}
+
+func newDatabaseDrainer() *databaseDrainer {
+	return &databaseDrainer{
+		queries: &drainQueries{},
+	}
+}
+
+// checkWrite returns an error if writes are rejected due to a drain.
+func (d *databaseDrainer) checkWrite() error {
+	d.RLock()
+	draining := d.draining
+	d.RUnlock()
+	if draining {
+		return errDatabaseDraining
+	}
+	return nil
+}
+
+// trackQuery tracks the query of the context as in-flight until the
+// context is closed.
+func (d *databaseDrainer) trackQuery(ctx context.Context) {
+	d.RLock()
+	queries := d.queries
+	// NB: Increment under the lock so that a query is never missed by the
+	// generation being awaited.
+	atomic.AddInt64(&queries.inFlight, 1)
+	d.RUnlock()
+
+	ctx.RegisterFinalizer(resource.FinalizerFn(func() {
+		atomic.AddInt64(&queries.inFlight, -1)
+	}))
+}
+
+// inFlightQueries returns the number of queries currently in-flight,
+// including those still being waited on by a drain.
+func (d *databaseDrainer) inFlightQueries() int64 {
+	d.RLock()
+	defer d.RUnlock()
+	inFlight := atomic.LoadInt64(&d.queries.inFlight)
+	if d.awaiting != nil {
+		inFlight += atomic.LoadInt64(&d.awaiting.inFlight)
+	}
+	return inFlight
+}
+
+// begin starts a drain, rejecting writes from this point onwards.
+func (d *databaseDrainer) begin(now time.Time) error {
+	d.Lock()
+	defer d.Unlock()
+	if d.inProgress {
+		return errDatabaseDrainInProgress
+	}
+	d.draining = true
+	d.inProgress = true
+	d.phase = DrainSnapshotting
+	d.startTime = now
+	return nil
+}
+
+// awaitQueries starts a new generation of queries and returns the
+// generation of the queries currently in-flight to wait on.
+func (d *databaseDrainer) awaitQueries() *drainQueries {
+	d.Lock()
+	defer d.Unlock()
+	d.phase = DrainWaitingForQueries
+	d.awaiting = d.queries
+	d.queries = &drainQueries{}
+	return d.awaiting
+}
+
+// end ends the drain, writes continue to be rejected after a drain
+// completes while a drain that did not complete resumes accepting writes.
+func (d *databaseDrainer) end(complete bool) {
+	d.Lock()
+	if complete {
+		d.phase = DrainComplete
+	} else {
+		d.draining = false
+	}
+	d.inProgress = false
+	d.awaiting = nil
+	d.Unlock()
+}
+
+func (d *databaseDrainer) state() DatabaseDrainState {
+	d.RLock()
+	defer d.RUnlock()
+	queries := d.queries
+	if d.awaiting != nil {
+		queries = d.awaiting
+	}
+	return DatabaseDrainState{
+		Phase:           d.phase,
+		StartTime:       d.startTime,
+		InFlightQueries: atomic.LoadInt64(&queries.inFlight),
+	}
+}
+
+// waitUntil waits until the condition holds or the context is cancelled.
+func waitUntil(ctx context.Context, pollInterval time.Duration, cond func() bool) error {
+	var done <-chan struct{}
+	if goCtx, ok := ctx.GoContext(); ok {
+		done = goCtx.Done()
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for !cond() {
+		if ctx.IsCancelled() {
+			return errDatabaseDrainCancelled
+		}
+		select {
+		case <-done:
+			return errDatabaseDrainCancelled
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/x/context"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseDrainerAwaitsOnlyInFlightQueries(t *testing.T) {
+	d := newDatabaseDrainer()
+	require.NoError(t, d.checkWrite())
+
+	before := context.NewContext()
+	d.trackQuery(before)
+	require.Equal(t, int64(1), d.state().InFlightQueries)
+
+	now := time.Now()
+	require.NoError(t, d.begin(now))
+	require.Equal(t, errDatabaseDrainInProgress, d.begin(now))
+	require.Equal(t, errDatabaseDraining, d.checkWrite())
+
+	awaiting := d.awaitQueries()
+
+	// Queries started after the drain started waiting are not waited on.
+	after := context.NewContext()
+	d.trackQuery(after)
+	defer after.BlockingClose()
+
+	state := d.state()
+	require.Equal(t, DrainWaitingForQueries, state.Phase)
+	require.True(t, now.Equal(state.StartTime))
+	require.Equal(t, int64(1), state.InFlightQueries)
+	require.Equal(t, int64(2), d.inFlightQueries())
+
+	before.BlockingClose()
+	require.Equal(t, int64(0), awaiting.inFlight)
+	require.Equal(t, int64(0), d.state().InFlightQueries)
+
+	d.end(true)
+	state = d.state()
+	require.Equal(t, DrainComplete, state.Phase)
+	require.Equal(t, int64(1), state.InFlightQueries)
+	require.Equal(t, errDatabaseDraining, d.checkWrite())
+}
+
+func TestDatabaseDrainerIncompleteDrainAcceptsWrites(t *testing.T) {
+	d := newDatabaseDrainer()
+
+	now := time.Now()
+	require.NoError(t, d.begin(now))
+	require.Equal(t, errDatabaseDraining, d.checkWrite())
+
+	d.end(false)
+	require.NoError(t, d.checkWrite())
+	require.Equal(t, DrainSnapshotting, d.state().Phase)
+
+	// A drain can be started again after one did not complete.
+	require.NoError(t, d.begin(now))
+	require.Equal(t, errDatabaseDraining, d.checkWrite())
+}
+
+func TestDrainPhaseString(t *testing.T) {
+	require.Equal(t, "not-started", DrainNotStarted.String())
+	require.Equal(t, "snapshotting", DrainSnapshotting.String())
+	require.Equal(t, "waiting-for-queries", DrainWaitingForQueries.String())
+	require.Equal(t, "complete", DrainComplete.String())
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package durability describes the durability of the data read from a node.
+package durability
+
+import (
+	"fmt"
+	"time"
+)
+
+// Level is the durability of data that has been read.
+type Level uint
+
+const (
+	// BufferOnly is data that may only be in the in-memory buffer and commit
+	// log and has not yet been snapshotted or flushed.
+	BufferOnly Level = iota
+	// Snapshot is data that has been snapshotted but not yet flushed to a
+	// fileset.
+	Snapshot
+	// Flushed is data that has been flushed to a fileset.
+	Flushed
+)
+
+var validLevels = []Level{
+	BufferOnly,
+	Snapshot,
+	Flushed,
+}
+
+func (l Level) String() string {
+	switch l {
+	case BufferOnly:
+		return "buffer-only"
+	case Snapshot:
+		return "snapshot"
+	case Flushed:
+		return "flushed"
+	}
+	return "unknown"
+}
+
+// ParseLevel parses a durability level from its string representation.
+func ParseLevel(str string) (Level, error) {
+	for _, l := range validLevels {
+		if str == l.String() {
+			return l, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid data durability '%s': valid durabilities are %v",
+		str, validLevels)
+}
+
+// Range is the durability of the data within [Start, End).
+type Range struct {
+	Start time.Time
+	End   time.Time
+	Level Level
+}
+
+// Ranges are contiguous, time ascending durability ranges.
+type Ranges []Range
+
+// Add appends the durability of [start, end) to the ranges, merging it with
+// the last range if they are adjacent and have the same durability.
+func (r Ranges) Add(start, end time.Time, level Level) Ranges {
+	if !end.After(start) {
+		return r
+	}
+	if n := len(r); n > 0 {
+		last := &r[n-1]
+		if last.Level == level && last.End.Equal(start) {
+			last.End = end
+			return r
+		}
+	}
+	return append(r, Range{
+		Start: start,
+		End:   end,
+		Level: level,
+	})
+}
+
+// Min returns the durability of the time covered by both sets of ranges,
+// taking the lowest of the two durabilities at each point in time. It is
+// used to combine the durability of data merged from several nodes, where
+// the merged data is only as durable as the least durable node.
+func (r Ranges) Min(other Ranges) Ranges {
+	var result Ranges
+	for i, j := 0, 0; i < len(r) && j < len(other); {
+		a, b := r[i], other[j]
+		start, end := a.Start, a.End
+		if b.Start.After(start) {
+			start = b.Start
+		}
+		if b.End.Before(end) {
+			end = b.End
+		}
+		level := a.Level
+		if b.Level < level {
+			level = b.Level
+		}
+		result = result.Add(start, end, level)
+		if a.End.Before(b.End) {
+			i++
+		} else {
+			j++
+		}
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package durability
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	for _, l := range validLevels {
+		parsed, err := ParseLevel(l.String())
+		require.NoError(t, err)
+		require.Equal(t, l, parsed)
+	}
+
+	_, err := ParseLevel("durable")
+	require.Error(t, err)
+}
+
+func TestRangesAdd(t *testing.T) {
+	var (
+		start  = time.Now().Truncate(time.Hour)
+		ranges Ranges
+	)
+	ranges = ranges.Add(start, start.Add(time.Hour), Flushed)
+	// Empty ranges are ignored.
+	ranges = ranges.Add(start.Add(time.Hour), start.Add(time.Hour), Snapshot)
+	ranges = ranges.Add(start.Add(time.Hour), start.Add(2*time.Hour), BufferOnly)
+	// Adjacent ranges with the same durability are merged.
+	ranges = ranges.Add(start.Add(2*time.Hour), start.Add(3*time.Hour), BufferOnly)
+
+	require.Equal(t, Ranges{
+		{Start: start, End: start.Add(time.Hour), Level: Flushed},
+		{Start: start.Add(time.Hour), End: start.Add(3 * time.Hour), Level: BufferOnly},
+	}, ranges)
+}
+
+func TestRangesMin(t *testing.T) {
+	var (
+		start = time.Now().Truncate(time.Hour)
+		a     = Ranges{
+			{Start: start, End: start.Add(2 * time.Hour), Level: Flushed},
+			{Start: start.Add(2 * time.Hour), End: start.Add(4 * time.Hour), Level: Snapshot},
+		}
+		b = Ranges{
+			{Start: start.Add(30 * time.Minute), End: start.Add(time.Hour), Level: Flushed},
+			{Start: start.Add(time.Hour), End: start.Add(3 * time.Hour), Level: Snapshot},
+			{Start: start.Add(3 * time.Hour), End: start.Add(4 * time.Hour), Level: BufferOnly},
+		}
+	)
+
+	expected := Ranges{
+		{Start: start.Add(30 * time.Minute), End: start.Add(time.Hour), Level: Flushed},
+		{Start: start.Add(time.Hour), End: start.Add(3 * time.Hour), Level: Snapshot},
+		{Start: start.Add(3 * time.Hour), End: start.Add(4 * time.Hour), Level: BufferOnly},
+	}
+	require.Equal(t, expected, a.Min(b))
+	require.Equal(t, expected, b.Min(a))
+	require.Nil(t, a.Min(nil))
+}
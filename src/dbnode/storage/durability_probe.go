@@ -0,0 +1,570 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultDurabilityProbeTimeout        = time.Minute
+	defaultDurabilityProbeFlushTimeout   = time.Hour
+	defaultDurabilityProbeSeriesIDPrefix = "_m3db_durability_probe"
+
+	// durabilityProbeCanarySearchFactor bounds the number of candidate IDs
+	// hashed per shard when searching for canary series that land in each
+	// shard.
+	durabilityProbeCanarySearchFactor = 64
+
+	durabilityProbeStageDurable = "commitlog-durable"
+	durabilityProbeStageRead    = "read"
+	durabilityProbeStageFlush   = "flush"
+)
+
+var (
+	errDurabilityProbeInterval     = errors.New("durability probe interval must not be negative")
+	errDurabilityProbeTimeout      = errors.New("durability probe timeout must not be negative")
+	errDurabilityProbeFlushTimeout = errors.New("durability probe flush timeout must not be negative")
+
+	errDurabilityProbeNotDurable = errors.New("canary writes were not durable before the timeout")
+	errDurabilityProbeNotFound   = errors.New("canary datapoint not found")
+	errDurabilityProbeNotFlushed = errors.New("canary datapoint not flushed before the flush timeout")
+)
+
+// DurabilityProbeOptions are the options for the durability prober which
+// periodically writes a canary series to each owned shard of every owned
+// namespace and verifies each stage of the write pipeline for it: that the
+// writes become durable in the commit log, that they can be read back and
+// that they are present in the fileset once their block is flushed.
+type DurabilityProbeOptions struct {
+	// Interval is how often canaries are written, zero disables the prober.
+	Interval time.Duration
+
+	// Timeout is how long canary writes are waited on to become durable in
+	// the commit log, zero uses the default timeout.
+	Timeout time.Duration
+
+	// FlushTimeout is how long after their block can be flushed canaries must
+	// be found in a flushed fileset, zero uses the default timeout.
+	FlushTimeout time.Duration
+
+	// SeriesIDPrefix is the prefix of the IDs of the canary series, if empty
+	// the default prefix is used.
+	SeriesIDPrefix string
+}
+
+// Enabled returns whether the prober is enabled.
+func (o DurabilityProbeOptions) Enabled() bool {
+	return o.Interval > 0
+}
+
+// Validate validates the durability probe options.
+func (o DurabilityProbeOptions) Validate() error {
+	if o.Interval < 0 {
+		return errDurabilityProbeInterval
+	}
+	if o.Timeout < 0 {
+		return errDurabilityProbeTimeout
+	}
+	if o.FlushTimeout < 0 {
+		return errDurabilityProbeFlushTimeout
+	}
+	return nil
+}
+
+type durabilityProbeStageMetrics struct {
+	success tally.Counter
+	failure tally.Counter
+	latency tally.Timer
+}
+
+func newDurabilityProbeStageMetrics(
+	scope tally.Scope,
+	namespace ident.ID,
+	stage string,
+) durabilityProbeStageMetrics {
+	scope = scope.Tagged(map[string]string{
+		"namespace": namespace.String(),
+		"stage":     stage,
+	})
+	return durabilityProbeStageMetrics{
+		success: scope.Counter("success"),
+		failure: scope.Counter("failure"),
+		latency: scope.Timer("latency"),
+	}
+}
+
+// durabilityProbeReadFlushedFn reads the data of a series from a flushed
+// fileset volume.
+type durabilityProbeReadFlushedFn func(
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+	volume int,
+	id ident.ID,
+) ([]byte, error)
+
+type durabilityProbeFlushKey struct {
+	namespace  string
+	shard      uint32
+	blockStart xtime.UnixNano
+}
+
+// durabilityProbeCanary is a canary datapoint awaiting verification that it
+// was flushed.
+type durabilityProbeCanary struct {
+	namespace ident.ID
+	shard     uint32
+	id        ident.ID
+	timestamp time.Time
+	value     float64
+	writtenAt time.Time
+	deadline  time.Time
+}
+
+// durabilityProber writes canary series and verifies each stage of the write
+// pipeline for them, reporting the latency of each stage that succeeds.
+type durabilityProber struct {
+	sync.Mutex
+
+	database      database
+	opts          Options
+	probeOpts     DurabilityProbeOptions
+	fsOpts        fs.Options
+	nowFn         clock.NowFn
+	readFlushedFn durabilityProbeReadFlushedFn
+	logger        *zap.Logger
+	scope         tally.Scope
+
+	metrics    map[string]durabilityProbeStageMetrics
+	canaryIDs  map[uint32]ident.ID
+	nextCanary int
+	sequence   float64
+	pending    map[durabilityProbeFlushKey]durabilityProbeCanary
+}
+
+func newDurabilityProber(
+	database database,
+	opts Options,
+	scope tally.Scope,
+) *durabilityProber {
+	probeOpts := opts.DurabilityProbeOptions()
+	if probeOpts.Timeout <= 0 {
+		probeOpts.Timeout = defaultDurabilityProbeTimeout
+	}
+	if probeOpts.FlushTimeout <= 0 {
+		probeOpts.FlushTimeout = defaultDurabilityProbeFlushTimeout
+	}
+	if probeOpts.SeriesIDPrefix == "" {
+		probeOpts.SeriesIDPrefix = defaultDurabilityProbeSeriesIDPrefix
+	}
+	p := &durabilityProber{
+		database:  database,
+		opts:      opts,
+		probeOpts: probeOpts,
+		fsOpts:    opts.CommitLogOptions().FilesystemOptions(),
+		nowFn:     opts.ClockOptions().NowFn(),
+		logger:    opts.InstrumentOptions().Logger(),
+		scope:     scope,
+		metrics:   make(map[string]durabilityProbeStageMetrics),
+		canaryIDs: make(map[uint32]ident.ID),
+		pending:   make(map[durabilityProbeFlushKey]durabilityProbeCanary),
+	}
+	p.readFlushedFn = p.readFlushed
+	return p
+}
+
+// Enabled returns whether the prober is enabled.
+func (p *durabilityProber) Enabled() bool {
+	return p.probeOpts.Enabled()
+}
+
+// Interval returns how often the prober should be run.
+func (p *durabilityProber) Interval() time.Duration {
+	return p.probeOpts.Interval
+}
+
+// Probe writes a canary to each bootstrapped owned shard of every owned
+// namespace, verifying they become durable and can be read, then verifies
+// the canaries of earlier probes whose blocks have since been flushed.
+func (p *durabilityProber) Probe() {
+	p.Lock()
+	defer p.Unlock()
+
+	if !p.database.IsBootstrapped() {
+		return
+	}
+
+	namespaces, err := p.database.GetOwnedNamespaces()
+	if err != nil {
+		p.logger.Error("durability probe unable to get namespaces", zap.Error(err))
+		return
+	}
+
+	p.sequence++
+	for _, n := range namespaces {
+		p.probeNamespace(n)
+	}
+	for _, n := range namespaces {
+		p.verifyFlushed(n)
+	}
+}
+
+func (p *durabilityProber) probeNamespace(n databaseNamespace) {
+	var (
+		nsID     = n.ID()
+		nsOpts   = n.Options()
+		owned    = n.GetOwnedShards()
+		shards   = make([]uint32, 0, len(owned))
+		canaries []durabilityProbeCanary
+	)
+	for _, shard := range owned {
+		if shard.IsBootstrapped() {
+			shards = append(shards, shard.ID())
+		}
+	}
+
+	start := p.nowFn()
+	for shard, id := range p.canaryIDsFor(shards) {
+		canaries = append(canaries, durabilityProbeCanary{
+			namespace: nsID,
+			shard:     shard,
+			id:        id,
+			timestamp: start,
+			value:     p.sequence,
+			writtenAt: start,
+		})
+	}
+	if len(canaries) == 0 {
+		return
+	}
+
+	writer, err := p.database.BatchWriter(nsID, len(canaries))
+	if err != nil {
+		p.stageMetrics(nsID, durabilityProbeStageDurable).failure.Inc(1)
+		p.logger.Error("durability probe unable to create batch writer",
+			zap.Stringer("namespace", nsID), zap.Error(err))
+		return
+	}
+	for i, canary := range canaries {
+		writer.Add(i, canary.id, canary.timestamp, canary.value, xtime.Nanosecond, nil)
+	}
+
+	var durableCh chan error
+	if nsOpts.WritesToCommitLog() {
+		durableCh = make(chan error, 1)
+		writer.SetDurableFn(func(_ ts.BatchDurability, err error) {
+			durableCh <- err
+		})
+	}
+
+	writeErrs := make([]error, len(canaries))
+	ctx := p.opts.ContextPool().Get()
+	err = p.database.WriteBatch(ctx, nsID, writer,
+		durabilityProbeErrorHandler(func(index int, err error) {
+			writeErrs[index] = err
+		}))
+	ctx.BlockingClose()
+	if err != nil {
+		p.stageMetrics(nsID, durabilityProbeStageDurable).failure.Inc(1)
+		p.logger.Error("durability probe unable to write canaries",
+			zap.Stringer("namespace", nsID), zap.Error(err))
+		return
+	}
+
+	if durableCh != nil {
+		p.awaitDurable(nsID, start, durableCh)
+	}
+
+	var (
+		blockSize  = nsOpts.RetentionOptions().BlockSize()
+		bufferPast = nsOpts.RetentionOptions().BufferPast()
+	)
+	for i, canary := range canaries {
+		if writeErrs[i] != nil {
+			p.stageMetrics(nsID, durabilityProbeStageRead).failure.Inc(1)
+			p.logger.Error("durability probe canary write failed",
+				zap.Stringer("namespace", nsID),
+				zap.Uint32("shard", canary.shard),
+				zap.Error(writeErrs[i]))
+			continue
+		}
+
+		p.verifyRead(n, canary)
+
+		if !nsOpts.FlushEnabled() {
+			continue
+		}
+		blockStart := canary.timestamp.Truncate(blockSize)
+		key := durabilityProbeFlushKey{
+			namespace:  nsID.String(),
+			shard:      canary.shard,
+			blockStart: xtime.ToUnixNano(blockStart),
+		}
+		if _, ok := p.pending[key]; ok {
+			// Only the first canary of each block is verified once flushed.
+			continue
+		}
+		canary.deadline = blockStart.Add(blockSize).Add(bufferPast).
+			Add(p.probeOpts.FlushTimeout)
+		p.pending[key] = canary
+	}
+}
+
+func (p *durabilityProber) awaitDurable(
+	nsID ident.ID,
+	start time.Time,
+	durableCh <-chan error,
+) {
+	metrics := p.stageMetrics(nsID, durabilityProbeStageDurable)
+	timer := time.NewTimer(p.probeOpts.Timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case err = <-durableCh:
+	case <-timer.C:
+		err = errDurabilityProbeNotDurable
+	}
+	if err != nil {
+		metrics.failure.Inc(1)
+		p.logger.Error("durability probe canaries not durable",
+			zap.Stringer("namespace", nsID), zap.Error(err))
+		return
+	}
+	metrics.success.Inc(1)
+	metrics.latency.Record(p.nowFn().Sub(start))
+}
+
+func (p *durabilityProber) verifyRead(
+	n databaseNamespace,
+	canary durabilityProbeCanary,
+) {
+	metrics := p.stageMetrics(canary.namespace, durabilityProbeStageRead)
+
+	ctx := p.opts.ContextPool().Get()
+	defer ctx.BlockingClose()
+
+	readers, err := p.database.ReadEncoded(ctx, canary.namespace, canary.id,
+		canary.timestamp, canary.timestamp.Add(time.Nanosecond))
+	if err == nil {
+		iter := p.opts.MultiReaderIteratorPool().Get()
+		iter.ResetSliceOfSlices(xio.NewReaderSliceOfSlicesFromBlockReadersIterator(readers),
+			n.Schema())
+		err = durabilityProbeFind(iter, canary)
+		iter.Close()
+	}
+	if err != nil {
+		metrics.failure.Inc(1)
+		p.logger.Error("durability probe unable to read canary",
+			zap.Stringer("namespace", canary.namespace),
+			zap.Uint32("shard", canary.shard),
+			zap.Error(err))
+		return
+	}
+	metrics.success.Inc(1)
+	metrics.latency.Record(p.nowFn().Sub(canary.writtenAt))
+}
+
+// verifyFlushed checks the pending canaries of the namespace whose blocks
+// have been flushed are present in the flushed filesets, failing those that
+// are not flushed before their deadline.
+func (p *durabilityProber) verifyFlushed(n databaseNamespace) {
+	var (
+		nsID   = n.ID()
+		now    = p.nowFn()
+		shards = make(map[uint32]databaseShard)
+	)
+	for _, shard := range n.GetOwnedShards() {
+		shards[shard.ID()] = shard
+	}
+
+	for key, canary := range p.pending {
+		if key.namespace != nsID.String() {
+			continue
+		}
+
+		metrics := p.stageMetrics(nsID, durabilityProbeStageFlush)
+		shard, ok := shards[canary.shard]
+		if !ok {
+			// The shard is no longer owned.
+			delete(p.pending, key)
+			continue
+		}
+
+		blockStart := key.blockStart.ToTime()
+		flushState := shard.FlushState(blockStart)
+		if flushState.WarmStatus != fileOpSuccess {
+			if now.After(canary.deadline) {
+				delete(p.pending, key)
+				metrics.failure.Inc(1)
+				p.logger.Error("durability probe canary not flushed",
+					zap.Stringer("namespace", nsID),
+					zap.Uint32("shard", canary.shard),
+					zap.Time("blockStart", blockStart),
+					zap.Error(errDurabilityProbeNotFlushed))
+			}
+			continue
+		}
+
+		delete(p.pending, key)
+		data, err := p.readFlushedFn(nsID, canary.shard, blockStart,
+			flushState.ColdVersion, canary.id)
+		if err == nil {
+			iter := p.opts.ReaderIteratorPool().Get()
+			iter.Reset(bytes.NewReader(data), n.Schema())
+			err = durabilityProbeFind(iter, canary)
+			iter.Close()
+		}
+		if err != nil {
+			metrics.failure.Inc(1)
+			p.logger.Error("durability probe unable to read flushed canary",
+				zap.Stringer("namespace", nsID),
+				zap.Uint32("shard", canary.shard),
+				zap.Time("blockStart", blockStart),
+				zap.Int("volume", flushState.ColdVersion),
+				zap.Error(err))
+			continue
+		}
+		metrics.success.Inc(1)
+		metrics.latency.Record(now.Sub(canary.writtenAt))
+	}
+}
+
+// readFlushed reads the data of a series from a flushed fileset volume with
+// a seeker.
+func (p *durabilityProber) readFlushed(
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+	volume int,
+	id ident.ID,
+) ([]byte, error) {
+	seeker := fs.NewSeeker(
+		p.fsOpts.NamespaceFilePathPrefix(namespace),
+		p.fsOpts.DataReaderBufferSize(),
+		p.fsOpts.InfoReaderBufferSize(),
+		p.opts.BytesPool(),
+		false,
+		p.fsOpts,
+	)
+	resources := fs.NewReusableSeekerResources(p.fsOpts)
+	if err := seeker.Open(namespace, shard, blockStart, volume, resources); err != nil {
+		return nil, err
+	}
+	defer seeker.Close()
+
+	data, err := seeker.SeekByID(id, resources)
+	if err != nil {
+		return nil, err
+	}
+	data.IncRef()
+	result := append([]byte(nil), data.Bytes()...)
+	data.DecRef()
+	data.Finalize()
+	return result, nil
+}
+
+// canaryIDsFor returns the IDs of the canary series of the shards, searching
+// for IDs that hash to the shards not yet seen.
+func (p *durabilityProber) canaryIDsFor(shards []uint32) map[uint32]ident.ID {
+	var (
+		shardSet = p.database.ShardSet()
+		result   = make(map[uint32]ident.ID, len(shards))
+		missing  = 0
+	)
+	for _, shard := range shards {
+		if _, ok := p.canaryIDs[shard]; !ok {
+			missing++
+		}
+	}
+
+	for attempts := missing * durabilityProbeCanarySearchFactor; missing > 0 && attempts > 0; attempts-- {
+		id := ident.StringID(fmt.Sprintf("%s.%d", p.probeOpts.SeriesIDPrefix, p.nextCanary))
+		p.nextCanary++
+		shard := shardSet.Lookup(id)
+		if _, ok := p.canaryIDs[shard]; ok {
+			continue
+		}
+		p.canaryIDs[shard] = id
+		missing--
+	}
+
+	for _, shard := range shards {
+		if id, ok := p.canaryIDs[shard]; ok {
+			result[shard] = id
+		}
+	}
+	return result
+}
+
+func (p *durabilityProber) stageMetrics(
+	namespace ident.ID,
+	stage string,
+) durabilityProbeStageMetrics {
+	key := namespace.String() + "/" + stage
+	metrics, ok := p.metrics[key]
+	if !ok {
+		metrics = newDurabilityProbeStageMetrics(p.scope, namespace, stage)
+		p.metrics[key] = metrics
+	}
+	return metrics
+}
+
+type durabilityProbeIterator interface {
+	Next() bool
+	Current() (ts.Datapoint, xtime.Unit, ts.Annotation)
+	Err() error
+}
+
+// durabilityProbeFind returns an error if the iterator does not contain the
+// datapoint of the canary.
+func durabilityProbeFind(iter durabilityProbeIterator, canary durabilityProbeCanary) error {
+	for iter.Next() {
+		dp, _, _ := iter.Current()
+		if dp.Timestamp.Equal(canary.timestamp) && dp.Value == canary.value {
+			return nil
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	return errDurabilityProbeNotFound
+}
+
+type durabilityProbeErrorHandler func(index int, err error)
+
+func (fn durabilityProbeErrorHandler) HandleError(index int, err error) {
+	fn(index, err)
+}
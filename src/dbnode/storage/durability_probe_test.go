@@ -0,0 +1,236 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/shard"
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestDurabilityProbeOptionsValidate(t *testing.T) {
+	require.NoError(t, DurabilityProbeOptions{}.Validate())
+	require.False(t, DurabilityProbeOptions{}.Enabled())
+	require.True(t, DurabilityProbeOptions{Interval: time.Minute}.Enabled())
+	require.Equal(t, errDurabilityProbeInterval, DurabilityProbeOptions{
+		Interval: -time.Second,
+	}.Validate())
+	require.Equal(t, errDurabilityProbeTimeout, DurabilityProbeOptions{
+		Timeout: -time.Second,
+	}.Validate())
+	require.Equal(t, errDurabilityProbeFlushTimeout, DurabilityProbeOptions{
+		FlushTimeout: -time.Second,
+	}.Validate())
+}
+
+func encodeDurabilityProbeCanary(
+	t *testing.T,
+	opts Options,
+	dp ts.Datapoint,
+) []byte {
+	enc := opts.EncoderPool().Get()
+	enc.Reset(dp.Timestamp, 0, nil)
+	require.NoError(t, enc.Encode(dp, xtime.Nanosecond, nil))
+	stream, ok := enc.Stream(encoding.StreamOptions{})
+	require.True(t, ok)
+	seg, err := stream.Segment()
+	require.NoError(t, err)
+
+	var data []byte
+	if seg.Head != nil {
+		data = append(data, seg.Head.Bytes()...)
+	}
+	if seg.Tail != nil {
+		data = append(data, seg.Tail.Bytes()...)
+	}
+	return data
+}
+
+func durabilityProbeCounter(scope tally.TestScope, name, stage string) int64 {
+	key := name + "+namespace=testns1,stage=" + stage
+	if c, ok := scope.Snapshot().Counters()[key]; ok {
+		return c.Value()
+	}
+	return 0
+}
+
+func TestDurabilityProberProbe(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now().Truncate(time.Hour)
+	opts := DefaultTestOptions().
+		SetDurabilityProbeOptions(DurabilityProbeOptions{
+			Interval: time.Minute,
+		})
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return now
+	}))
+	require.NoError(t, opts.Validate())
+
+	shardSet, err := sharding.NewShardSet(
+		sharding.NewShards([]uint32{0}, shard.Available), sharding.DefaultHashFn(1))
+	require.NoError(t, err)
+
+	nsOpts := namespace.NewOptions()
+	mockShard := NewMockdatabaseShard(ctrl)
+	mockShard.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	mockShard.EXPECT().IsBootstrapped().Return(true).AnyTimes()
+	mockShard.EXPECT().FlushState(gomock.Any()).
+		Return(fileOpState{WarmStatus: fileOpSuccess, ColdVersion: 1})
+
+	mockNs := NewMockdatabaseNamespace(ctrl)
+	mockNs.EXPECT().ID().Return(defaultTestNs1ID).AnyTimes()
+	mockNs.EXPECT().Options().Return(nsOpts).AnyTimes()
+	mockNs.EXPECT().Schema().Return(nil).AnyTimes()
+	mockNs.EXPECT().GetOwnedShards().Return([]databaseShard{mockShard}).AnyTimes()
+
+	mockDB := NewMockdatabase(ctrl)
+	mockDB.EXPECT().IsBootstrapped().Return(true)
+	mockDB.EXPECT().ShardSet().Return(shardSet)
+	mockDB.EXPECT().GetOwnedNamespaces().Return([]databaseNamespace{mockNs}, nil)
+	mockDB.EXPECT().BatchWriter(defaultTestNs1ID, 1).
+		Return(ts.NewWriteBatch(1, defaultTestNs1ID, nil), nil)
+
+	var written ts.Datapoint
+	mockDB.EXPECT().WriteBatch(gomock.Any(), defaultTestNs1ID, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context,
+			_ ident.ID,
+			writer ts.BatchWriter,
+			_ IndexedErrorHandler,
+		) error {
+			writes := writer.(ts.WriteBatch)
+			iter := writes.Iter()
+			require.Equal(t, 1, len(iter))
+			written = iter[0].Write.Datapoint
+			writes.DurableFn()(ts.BatchDurability{NumWrites: 1}, nil)
+			return nil
+		})
+	mockDB.EXPECT().ReadEncoded(gomock.Any(), defaultTestNs1ID, gomock.Any(), now, now.Add(time.Nanosecond)).
+		DoAndReturn(func(
+			_ context.Context,
+			_ ident.ID,
+			_ ident.ID,
+			_, _ time.Time,
+		) ([][]xio.BlockReader, error) {
+			data := encodeDurabilityProbeCanary(t, opts, written)
+			seg := ts.NewSegment(checked.NewBytes(data, nil), nil, ts.FinalizeNone)
+			return [][]xio.BlockReader{{
+				xio.BlockReader{
+					SegmentReader: xio.NewSegmentReader(seg),
+					Start:         now,
+				},
+			}}, nil
+		})
+
+	scope := tally.NewTestScope("", nil)
+	prober := newDurabilityProber(mockDB, opts, scope)
+	require.True(t, prober.Enabled())
+	require.Equal(t, time.Minute, prober.Interval())
+
+	var flushedVolume int
+	prober.readFlushedFn = func(
+		namespace ident.ID,
+		shard uint32,
+		blockStart time.Time,
+		volume int,
+		id ident.ID,
+	) ([]byte, error) {
+		flushedVolume = volume
+		return encodeDurabilityProbeCanary(t, opts, written), nil
+	}
+
+	prober.Probe()
+
+	require.Equal(t, now, written.Timestamp)
+	require.Equal(t, 1, flushedVolume)
+	require.Equal(t, 0, len(prober.pending))
+	for _, stage := range []string{
+		durabilityProbeStageDurable,
+		durabilityProbeStageRead,
+		durabilityProbeStageFlush,
+	} {
+		require.Equal(t, int64(1), durabilityProbeCounter(scope, "success", stage), stage)
+		require.Equal(t, int64(0), durabilityProbeCounter(scope, "failure", stage), stage)
+	}
+}
+
+func TestDurabilityProberFlushDeadline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now().Truncate(time.Hour)
+	opts := DefaultTestOptions()
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return now
+	}))
+
+	mockShard := NewMockdatabaseShard(ctrl)
+	mockShard.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	mockShard.EXPECT().FlushState(now).
+		Return(fileOpState{WarmStatus: fileOpNotStarted}).Times(2)
+
+	mockNs := NewMockdatabaseNamespace(ctrl)
+	mockNs.EXPECT().ID().Return(defaultTestNs1ID).AnyTimes()
+	mockNs.EXPECT().GetOwnedShards().Return([]databaseShard{mockShard}).AnyTimes()
+
+	scope := tally.NewTestScope("", nil)
+	prober := newDurabilityProber(NewMockdatabase(ctrl), opts, scope)
+
+	key := durabilityProbeFlushKey{
+		namespace:  defaultTestNs1ID.String(),
+		shard:      0,
+		blockStart: xtime.ToUnixNano(now),
+	}
+	prober.pending[key] = durabilityProbeCanary{
+		namespace: defaultTestNs1ID,
+		id:        ident.StringID("canary"),
+		timestamp: now,
+		writtenAt: now,
+		deadline:  now.Add(time.Minute),
+	}
+
+	// Not flushed yet but still within the deadline.
+	prober.verifyFlushed(mockNs)
+	require.Equal(t, 1, len(prober.pending))
+	require.Equal(t, int64(0), durabilityProbeCounter(scope, "failure", durabilityProbeStageFlush))
+
+	// Not flushed by the deadline.
+	now = now.Add(2 * time.Minute)
+	prober.nowFn = func() time.Time { return now }
+	prober.verifyFlushed(mockNs)
+	require.Equal(t, 0, len(prober.pending))
+	require.Equal(t, int64(1), durabilityProbeCounter(scope, "failure", durabilityProbeStageFlush))
+}
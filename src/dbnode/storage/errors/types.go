@@ -39,6 +39,11 @@ var (
 	// excludes anything regarding the cold writes feature until its release.
 	ErrColdWritesNotEnabled = xerrors.NewInvalidParamsError(errors.New(
 		"datapoint is too far in the past or future"))
+
+	// ErrNotStrictlyIncreasing is returned for a strictly increasing write
+	// which is not after the last write of the series.
+	ErrNotStrictlyIncreasing = xerrors.NewInvalidParamsError(errors.New(
+		"datapoint is not after the last datapoint written to the series"))
 )
 
 // NewUnknownNamespaceError returns a new error indicating an unknown namespace parameter.
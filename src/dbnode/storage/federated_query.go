@@ -0,0 +1,98 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+var (
+	errFederatedQueryNoNamespaces        = errors.New("federated query requires at least one namespace")
+	errFederatedQueryDuplicateNamespaces = errors.New("federated query requires distinct namespaces")
+)
+
+// Validate validates the federated query policy.
+func (p FederatedQueryPolicy) Validate() error {
+	switch p {
+	case FederatedQueryAllResolutions, FederatedQueryFinestResolution:
+		return nil
+	}
+	return fmt.Errorf("invalid federated query policy: %d", p)
+}
+
+func (p FederatedQueryPolicy) String() string {
+	switch p {
+	case FederatedQueryAllResolutions:
+		return "all_resolutions"
+	case FederatedQueryFinestResolution:
+		return "finest_resolution"
+	}
+	return "unknown"
+}
+
+// federatedQueryRanges returns the part of the query range that each
+// namespace, given by its retention period, is selected to serve by the
+// policy. Namespaces that serve no part of the query range have an empty
+// range.
+func federatedQueryRanges(
+	retentions []time.Duration,
+	start, end, now time.Time,
+	policy FederatedQueryPolicy,
+) []xtime.Range {
+	ranges := make([]xtime.Range, len(retentions))
+	if policy == FederatedQueryAllResolutions {
+		for i := range ranges {
+			ranges[i] = xtime.Range{Start: start, End: end}
+		}
+		return ranges
+	}
+
+	// Visit the namespaces finest resolution first, each one serving the
+	// part of the query range it retains that a finer namespace does not.
+	order := make([]int, len(retentions))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return retentions[order[i]] < retentions[order[j]]
+	})
+
+	remainingEnd := end
+	for _, i := range order {
+		if !remainingEnd.After(start) {
+			break
+		}
+		nsStart := now.Add(-retentions[i])
+		if nsStart.Before(start) {
+			nsStart = start
+		}
+		if !nsStart.Before(remainingEnd) {
+			continue
+		}
+		ranges[i] = xtime.Range{Start: nsStart, End: remainingEnd}
+		remainingEnd = nsStart
+	}
+	return ranges
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"testing"
+	"time"
+
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFederatedQueryPolicyValidate(t *testing.T) {
+	require.NoError(t, FederatedQueryAllResolutions.Validate())
+	require.NoError(t, FederatedQueryFinestResolution.Validate())
+	require.Error(t, FederatedQueryPolicy(2).Validate())
+}
+
+func TestFederatedQueryRanges(t *testing.T) {
+	var (
+		now        = time.Now().Truncate(time.Hour)
+		start      = now.Add(-30 * 24 * time.Hour)
+		retentions = []time.Duration{
+			// Aggregated namespace listed first to check that the order of
+			// the namespaces does not matter.
+			365 * 24 * time.Hour,
+			2 * 24 * time.Hour,
+			40 * 24 * time.Hour,
+		}
+	)
+
+	// Every namespace is queried for the whole range.
+	require.Equal(t, []xtime.Range{
+		{Start: start, End: now},
+		{Start: start, End: now},
+		{Start: start, End: now},
+	}, federatedQueryRanges(retentions, start, now, now, FederatedQueryAllResolutions))
+
+	// Each part of the range is queried from the finest namespace retaining
+	// it, the yearly namespace is not needed as the monthly one retains the
+	// start of the range.
+	require.Equal(t, []xtime.Range{
+		{},
+		{Start: now.Add(-2 * 24 * time.Hour), End: now},
+		{Start: start, End: now.Add(-2 * 24 * time.Hour)},
+	}, federatedQueryRanges(retentions, start, now, now, FederatedQueryFinestResolution))
+
+	// A range retained by the finest namespace is only queried from it.
+	recent := now.Add(-time.Hour)
+	require.Equal(t, []xtime.Range{
+		{},
+		{Start: recent, End: now},
+		{},
+	}, federatedQueryRanges(retentions, recent, now, now, FederatedQueryFinestResolution))
+
+	// A range older than the retention of the finer namespaces is only
+	// queried from the coarsest namespace.
+	old := now.Add(-100 * 24 * time.Hour)
+	require.Equal(t, []xtime.Range{
+		{Start: old, End: old.Add(time.Hour)},
+		{},
+		{},
+	}, federatedQueryRanges(retentions, old, old.Add(time.Hour), now, FederatedQueryFinestResolution))
+}
@@ -24,11 +24,13 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/runtime"
 	xerrors "github.com/m3db/m3/src/x/errors"
 
 	"github.com/pborman/uuid"
@@ -50,9 +52,15 @@ const (
 	flushManagerColdFlushInProgress
 	flushManagerSnapshotInProgress
 	flushManagerIndexFlushInProgress
+	flushManagerIndexSnapshotInProgress
 )
 
 type flushManager struct {
+	// lastSuccessfulSnapshotStartNanos is accessed atomically, as it is read
+	// when checking whether the database is overloaded, and kept first for
+	// 64-bit alignment.
+	lastSuccessfulSnapshotStartNanos int64
+
 	sync.RWMutex
 
 	database  database
@@ -62,16 +70,19 @@ type flushManager struct {
 	// state is used to protect the flush manager against concurrent use,
 	// while flushInProgress and snapshotInProgress are more granular and
 	// are used for emitting granular gauges.
-	state           flushManagerState
-	isFlushing      tally.Gauge
-	isColdFlushing  tally.Gauge
-	isSnapshotting  tally.Gauge
-	isIndexFlushing tally.Gauge
+	state               flushManagerState
+	isFlushing          tally.Gauge
+	isColdFlushing      tally.Gauge
+	isSnapshotting      tally.Gauge
+	isIndexFlushing     tally.Gauge
+	isIndexSnapshotting tally.Gauge
 	// This is a "debug" metric for making sure that the snapshotting process
 	// is not overly aggressive.
 	maxBlocksSnapshottedByNamespace tally.Gauge
-
-	lastSuccessfulSnapshotStartTime time.Time
+	// lastColdFlushes is the start of the tick of the last successful cold
+	// flush of each namespace, only accessed by flushes which do not run
+	// concurrently.
+	lastColdFlushes map[string]time.Time
 }
 
 func newFlushManager(
@@ -86,7 +97,9 @@ func newFlushManager(
 		isColdFlushing:                  scope.Gauge("cold-flush"),
 		isSnapshotting:                  scope.Gauge("snapshot"),
 		isIndexFlushing:                 scope.Gauge("index-flush"),
+		isIndexSnapshotting:             scope.Gauge("index-snapshot"),
 		maxBlocksSnapshottedByNamespace: scope.Gauge("max-blocks-snapshotted-by-namespace"),
+		lastColdFlushes:                 make(map[string]time.Time),
 	}
 }
 
@@ -127,7 +140,7 @@ func (m *flushManager) Flush(
 
 	rotatedCommitlogID, err := m.commitlog.RotateLogs()
 	if err == nil {
-		if err = m.dataColdFlush(namespaces); err != nil {
+		if err = m.dataColdFlush(namespaces, tickStart); err != nil {
 			multiErr = multiErr.Add(err)
 			// If cold flush fails, we can't proceed to snapshotting because
 			// commit log cleanup logic uses the presence of a successful
@@ -151,6 +164,12 @@ func (m *flushManager) Flush(
 		multiErr = multiErr.Add(err)
 	}
 
+	// Snapshot the index after flushing it so that the blocks flushed are
+	// neither snapshotted nor have their snapshots kept.
+	if err = m.indexSnapshot(namespaces, tickStart); err != nil {
+		multiErr = multiErr.Add(err)
+	}
+
 	return multiErr.FinalError()
 }
 
@@ -194,6 +213,7 @@ func (m *flushManager) dataWarmFlush(
 
 func (m *flushManager) dataColdFlush(
 	namespaces []databaseNamespace,
+	tickStart time.Time,
 ) error {
 	flushPersist, err := m.pm.StartFlushPersist()
 	if err != nil {
@@ -201,11 +221,21 @@ func (m *flushManager) dataColdFlush(
 	}
 
 	m.setState(flushManagerColdFlushInProgress)
-	multiErr := xerrors.NewMultiError()
+	var (
+		opts     = effectiveColdFlushOptions(m.opts)
+		multiErr = xerrors.NewMultiError()
+	)
 	for _, ns := range namespaces {
+		// Cold writes not yet cold flushed remain in memory and are captured
+		// by snapshots so it is safe to defer the cold flush of a namespace.
+		if !m.shouldColdFlush(ns, tickStart, opts) {
+			continue
+		}
 		if err = ns.ColdFlush(flushPersist); err != nil {
 			multiErr = multiErr.Add(err)
+			continue
 		}
+		m.lastColdFlushes[ns.ID().String()] = tickStart
 	}
 
 	err = flushPersist.DoneFlush()
@@ -216,6 +246,29 @@ func (m *flushManager) dataColdFlush(
 	return multiErr.FinalError()
 }
 
+// shouldColdFlush returns whether a namespace is due a cold flush, either
+// because its cold flush interval has elapsed since its last cold flush or
+// because enough cold writes are pending to cold flush it early.
+func (m *flushManager) shouldColdFlush(
+	ns databaseNamespace,
+	tickStart time.Time,
+	opts runtime.ColdFlushOptions,
+) bool {
+	last, ok := m.lastColdFlushes[ns.ID().String()]
+	if opts.Interval <= 0 || !ok || !tickStart.Before(last.Add(opts.Interval)) {
+		return true
+	}
+	return opts.ColdWritesThreshold > 0 &&
+		ns.NumPendingColdWrites() >= opts.ColdWritesThreshold
+}
+
+// effectiveColdFlushOptions returns the cold flush options of the storage
+// options overridden by those set by the current runtime options.
+func effectiveColdFlushOptions(opts Options) runtime.ColdFlushOptions {
+	return opts.ColdFlushOptions().
+		Override(opts.RuntimeOptionsManager().Get().ColdFlushOptions())
+}
+
 func (m *flushManager) dataSnapshot(
 	namespaces []databaseNamespace,
 	tickStart time.Time,
@@ -257,7 +310,7 @@ func (m *flushManager) dataSnapshot(
 
 	finalErr := multiErr.FinalError()
 	if finalErr == nil {
-		m.lastSuccessfulSnapshotStartTime = tickStart
+		atomic.StoreInt64(&m.lastSuccessfulSnapshotStartNanos, tickStart.UnixNano())
 	}
 	return finalErr
 }
@@ -287,6 +340,32 @@ func (m *flushManager) indexFlush(
 	return multiErr.FinalError()
 }
 
+func (m *flushManager) indexSnapshot(
+	namespaces []databaseNamespace,
+	tickStart time.Time,
+) error {
+	if !m.opts.IndexOptions().SnapshotEnabled() {
+		return nil
+	}
+
+	indexFlush, err := m.pm.StartIndexPersist()
+	if err != nil {
+		return err
+	}
+
+	m.setState(flushManagerIndexSnapshotInProgress)
+	multiErr := xerrors.NewMultiError()
+	for _, ns := range namespaces {
+		if !ns.Options().IndexOptions().Enabled() {
+			continue
+		}
+		multiErr = multiErr.Add(ns.SnapshotIndex(tickStart, indexFlush))
+	}
+	multiErr = multiErr.Add(indexFlush.DoneIndex())
+
+	return multiErr.FinalError()
+}
+
 func (m *flushManager) Report() {
 	m.RLock()
 	state := m.state
@@ -315,6 +394,12 @@ func (m *flushManager) Report() {
 	} else {
 		m.isIndexFlushing.Update(0)
 	}
+
+	if state == flushManagerIndexSnapshotInProgress {
+		m.isIndexSnapshotting.Update(1)
+	} else {
+		m.isIndexSnapshotting.Update(0)
+	}
 }
 
 func (m *flushManager) setState(state flushManagerState) {
@@ -384,5 +469,9 @@ func (m *flushManager) flushNamespaceWithTimes(
 }
 
 func (m *flushManager) LastSuccessfulSnapshotStartTime() (time.Time, bool) {
-	return m.lastSuccessfulSnapshotStartTime, !m.lastSuccessfulSnapshotStartTime.IsZero()
+	nanos := atomic.LoadInt64(&m.lastSuccessfulSnapshotStartNanos)
+	if nanos == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, nanos), true
 }
@@ -0,0 +1,121 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"sort"
+	"time"
+
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+// BlockFlushState is the flush state of a block start of a shard.
+type BlockFlushState struct {
+	Shard      uint32
+	BlockStart time.Time
+	// WarmFlushed is whether the warm writes of the block have been flushed.
+	WarmFlushed bool
+	// ColdVersion is the volume index of the latest cold flush of the block,
+	// zero if the block has not been cold flushed.
+	ColdVersion int
+	NumFailures int
+}
+
+// ColdFlushed returns whether the cold writes of the block have been flushed
+// at least once, in which case reads of the block must merge its volumes.
+func (s BlockFlushState) ColdFlushed() bool {
+	return s.ColdVersion > 0
+}
+
+// NamespaceFlushStateSummary summarizes the flush states of the block starts
+// of the owned shards of a namespace.
+type NamespaceFlushStateSummary struct {
+	NumShards int
+	// NumBlocks is the number of block starts with a flush state summed
+	// across the shards.
+	NumBlocks      int
+	NumWarmFlushed int
+	NumColdFlushed int
+	// NumWarmFailed is the number of blocks whose warm flush failed and has
+	// not succeeded since.
+	NumWarmFailed int
+	// ColdBlockStarts are the block starts cold flushed by any shard, ordered
+	// from the earliest.
+	ColdBlockStarts []time.Time
+}
+
+func newBlockFlushState(
+	shard uint32,
+	blockStart time.Time,
+	state fileOpState,
+) BlockFlushState {
+	return BlockFlushState{
+		Shard:       shard,
+		BlockStart:  blockStart,
+		WarmFlushed: state.WarmStatus == fileOpSuccess,
+		ColdVersion: state.ColdVersion,
+		NumFailures: state.NumFailures,
+	}
+}
+
+// sortBlockFlushStates orders flush states by shard and then block start.
+func sortBlockFlushStates(states []BlockFlushState) {
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Shard != states[j].Shard {
+			return states[i].Shard < states[j].Shard
+		}
+		return states[i].BlockStart.Before(states[j].BlockStart)
+	})
+}
+
+// summarizeFlushStates summarizes the flush states of the shards of a
+// namespace.
+func summarizeFlushStates(
+	numShards int,
+	states []BlockFlushState,
+) NamespaceFlushStateSummary {
+	var (
+		summary = NamespaceFlushStateSummary{
+			NumShards: numShards,
+			NumBlocks: len(states),
+		}
+		cold = make(map[xtime.UnixNano]struct{})
+	)
+	for _, state := range states {
+		if state.WarmFlushed {
+			summary.NumWarmFlushed++
+		} else if state.NumFailures > 0 {
+			summary.NumWarmFailed++
+		}
+		if state.ColdFlushed() {
+			summary.NumColdFlushed++
+			cold[xtime.ToUnixNano(state.BlockStart)] = struct{}{}
+		}
+	}
+
+	summary.ColdBlockStarts = make([]time.Time, 0, len(cold))
+	for blockStart := range cold {
+		summary.ColdBlockStarts = append(summary.ColdBlockStarts, blockStart.ToTime())
+	}
+	sort.Slice(summary.ColdBlockStarts, func(i, j int) bool {
+		return summary.ColdBlockStarts[i].Before(summary.ColdBlockStarts[j])
+	})
+	return summary
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeFlushStates(t *testing.T) {
+	var (
+		t0 = time.Unix(0, 0)
+		t1 = t0.Add(2 * time.Hour)
+	)
+	summary := summarizeFlushStates(3, []BlockFlushState{
+		{Shard: 0, BlockStart: t0, WarmFlushed: true, ColdVersion: 1},
+		{Shard: 0, BlockStart: t1, NumFailures: 2},
+		{Shard: 1, BlockStart: t1, WarmFlushed: true, ColdVersion: 3, NumFailures: 1},
+		{Shard: 2, BlockStart: t1, ColdVersion: 1},
+	})
+	require.Equal(t, NamespaceFlushStateSummary{
+		NumShards:       3,
+		NumBlocks:       4,
+		NumWarmFlushed:  2,
+		NumColdFlushed:  3,
+		NumWarmFailed:   1,
+		ColdBlockStarts: []time.Time{t0, t1},
+	}, summary)
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/x/ident"
 	xtest "github.com/m3db/m3/src/x/test"
 
@@ -200,6 +201,59 @@ func TestFlushManagerFlushDoneFlushError(t *testing.T) {
 
 // TestFlushManagerFlushDoneSnapshotError makes sure that snapshot errors do not
 // impact flushing or index operations.
+func TestFlushManagerColdFlushSchedule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		mockPersistManager = persist.NewMockManager(ctrl)
+		mockFlushPersist   = persist.NewMockFlushPreparer(ctrl)
+		runtimeOptsMgr     = runtime.NewOptionsManager()
+	)
+	mockFlushPersist.EXPECT().DoneFlush().Return(nil).AnyTimes()
+	mockPersistManager.EXPECT().StartFlushPersist().Return(mockFlushPersist, nil).AnyTimes()
+
+	// The cold writes threshold set by the runtime options overrides that of
+	// the storage options.
+	require.NoError(t, runtimeOptsMgr.Update(runtime.NewOptions().
+		SetColdFlushOptions(runtime.ColdFlushOptions{ColdWritesThreshold: 10})))
+	testOpts := DefaultTestOptions().
+		SetPersistManager(mockPersistManager).
+		SetRuntimeOptionsManager(runtimeOptsMgr).
+		SetColdFlushOptions(runtime.ColdFlushOptions{
+			Interval:            time.Hour,
+			ColdWritesThreshold: 1000,
+		})
+
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().ID().Return(defaultTestNs1ID).AnyTimes()
+	db := NewMockdatabase(ctrl)
+	db.EXPECT().Options().Return(testOpts).AnyTimes()
+
+	fm := newFlushManager(db, nil, tally.NoopScope).(*flushManager)
+	fm.pm = mockPersistManager
+
+	// The first cold flush is not deferred.
+	now := time.Unix(0, 0)
+	ns.EXPECT().ColdFlush(mockFlushPersist).Return(nil)
+	require.NoError(t, fm.dataColdFlush([]databaseNamespace{ns}, now))
+
+	// Until the interval elapses the namespace is only cold flushed once
+	// enough cold writes are pending.
+	ns.EXPECT().NumPendingColdWrites().Return(int64(9))
+	require.NoError(t, fm.dataColdFlush([]databaseNamespace{ns}, now.Add(time.Minute)))
+
+	ns.EXPECT().NumPendingColdWrites().Return(int64(10))
+	ns.EXPECT().ColdFlush(mockFlushPersist).Return(nil)
+	require.NoError(t, fm.dataColdFlush([]databaseNamespace{ns}, now.Add(2*time.Minute)))
+
+	// A failed cold flush is retried on the next flush.
+	ns.EXPECT().ColdFlush(mockFlushPersist).Return(errors.New("fake error"))
+	require.Error(t, fm.dataColdFlush([]databaseNamespace{ns}, now.Add(2*time.Hour)))
+	ns.EXPECT().ColdFlush(mockFlushPersist).Return(nil)
+	require.NoError(t, fm.dataColdFlush([]databaseNamespace{ns}, now.Add(2*time.Hour+time.Minute)))
+}
+
 func TestFlushManagerFlushDoneSnapshotError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
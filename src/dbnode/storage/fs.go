@@ -21,14 +21,26 @@
 package storage
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
+	dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
 
 	"go.uber.org/zap"
 )
 
+var (
+	errFileOpsInProgress = errors.New("file operations already in progress")
+)
+
 type fileOpStatus int
 
 const (
@@ -76,6 +88,8 @@ type fileSystemManager struct {
 	opts     Options
 	status   fileOpStatus
 	enabled  bool
+	scrubber *fileSetScrubber
+	exporter *fileSetExporter
 }
 
 func newFileSystemManager(
@@ -96,6 +110,8 @@ func newFileSystemManager(
 		opts:     opts,
 		status:   fileOpNotStarted,
 		enabled:  true,
+		scrubber: newFileSetScrubber(database, opts, scope.SubScope("scrub")),
+		exporter: newFileSetExporter(database, opts),
 	}
 }
 
@@ -136,6 +152,10 @@ func (m *fileSystemManager) Run(
 	m.status = fileOpInProgress
 	m.Unlock()
 
+	// NB: Scrubbing runs in the background independently of cleanup and
+	// flushing since verifying every fileset takes much longer than a flush.
+	m.scrubber.MaybeStart(t)
+
 	// NB(xichen): perform data cleanup and flushing sequentially to minimize the impact of disk seeks.
 	flushFn := func() {
 		if err := m.Cleanup(t); err != nil {
@@ -157,6 +177,117 @@ func (m *fileSystemManager) Run(
 	return true
 }
 
+func (m *fileSystemManager) ExportFileSets(
+	namespace ident.ID,
+	start, end time.Time,
+	store fs.ObjectStore,
+) (FileSetExportManifest, error) {
+	return m.exporter.Export(namespace, start, end, store)
+}
+
+func (m *fileSystemManager) ImportFileSets(
+	namespace ident.ID,
+	store fs.ObjectStore,
+) (FileSetExportManifest, error) {
+	// NB: Imports change the retrievable volumes of blocks just as cold
+	// flushes do so must not run concurrently with other file operations.
+	m.Lock()
+	if m.status == fileOpInProgress {
+		m.Unlock()
+		return FileSetExportManifest{}, errFileOpsInProgress
+	}
+	m.status = fileOpInProgress
+	m.Unlock()
+
+	defer func() {
+		m.Lock()
+		m.status = fileOpNotStarted
+		m.Unlock()
+	}()
+
+	return m.exporter.Import(namespace, store)
+}
+
+func (m *fileSystemManager) PauseShardFlush(
+	namespace ident.ID,
+	shards []uint32,
+	task BackgroundTask,
+	until time.Time,
+) error {
+	n, err := ownedNamespace(m.database, namespace)
+	if err != nil {
+		return err
+	}
+	if len(shards) == 0 {
+		n.PauseBackgroundTask(task, until)
+		return nil
+	}
+
+	owned, err := ownedShards(n, shards)
+	if err != nil {
+		return err
+	}
+	for _, shard := range owned {
+		shard.PauseFlush(task, until)
+	}
+	return nil
+}
+
+func (m *fileSystemManager) ResumeShardFlush(
+	namespace ident.ID,
+	shards []uint32,
+	task BackgroundTask,
+) error {
+	n, err := ownedNamespace(m.database, namespace)
+	if err != nil {
+		return err
+	}
+	if len(shards) == 0 {
+		n.ResumeBackgroundTask(task)
+		return nil
+	}
+
+	owned, err := ownedShards(n, shards)
+	if err != nil {
+		return err
+	}
+	for _, shard := range owned {
+		shard.ResumeFlush(task)
+	}
+	return nil
+}
+
+func (m *fileSystemManager) PausedShardFlushes() []PausedShardFlush {
+	namespaces, err := m.database.GetOwnedNamespaces()
+	if err != nil {
+		return nil
+	}
+
+	var paused []PausedShardFlush
+	for _, n := range namespaces {
+		for _, shard := range n.GetOwnedShards() {
+			for task, until := range shard.PausedFlushes() {
+				paused = append(paused, PausedShardFlush{
+					Namespace: n.ID(),
+					Shard:     shard.ID(),
+					Task:      task,
+					Until:     until,
+				})
+			}
+		}
+	}
+	sort.Slice(paused, func(i, j int) bool {
+		if cmp := bytes.Compare(paused[i].Namespace.Bytes(), paused[j].Namespace.Bytes()); cmp != 0 {
+			return cmp < 0
+		}
+		if paused[i].Shard != paused[j].Shard {
+			return paused[i].Shard < paused[j].Shard
+		}
+		return paused[i].Task < paused[j].Task
+	})
+	return paused
+}
+
 func (m *fileSystemManager) Report() {
 	m.databaseCleanupManager.Report()
 	m.databaseFlushManager.Report()
@@ -165,3 +296,36 @@ func (m *fileSystemManager) Report() {
 func (m *fileSystemManager) shouldRunWithLock() bool {
 	return m.enabled && m.status != fileOpInProgress && m.database.IsBootstrapped()
 }
+
+func ownedNamespace(database database, namespace ident.ID) (databaseNamespace, error) {
+	namespaces, err := database.GetOwnedNamespaces()
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range namespaces {
+		if n.ID().Equal(namespace) {
+			return n, nil
+		}
+	}
+	return nil, dberrors.NewUnknownNamespaceError(namespace.String())
+}
+
+// ownedShards returns the given shards of the namespace, failing if any of
+// them is not owned so that a pause or resume is applied to all or none.
+func ownedShards(n databaseNamespace, shards []uint32) ([]databaseShard, error) {
+	owned := make(map[uint32]databaseShard)
+	for _, shard := range n.GetOwnedShards() {
+		owned[shard.ID()] = shard
+	}
+
+	result := make([]databaseShard, 0, len(shards))
+	for _, id := range shards {
+		shard, ok := owned[id]
+		if !ok {
+			return nil, xerrors.NewInvalidParamsError(fmt.Errorf(
+				"shard %d of namespace %s is not owned", id, n.ID().String()))
+		}
+		result = append(result, shard)
+	}
+	return result, nil
+}
@@ -0,0 +1,321 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/storage/block"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+)
+
+const (
+	fileSetExportManifestsDir = "manifests"
+)
+
+type fileSetUploadFn func(
+	store fs.ObjectStore,
+	filePathPrefix string,
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+	volume int,
+) error
+
+type fileSetImportFn func(
+	store fs.ObjectStore,
+	opts fs.Options,
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+	exportedVolume int,
+	volume int,
+) (bool, error)
+
+// fileSetExportLeaser holds a lease on each volume while it is exported so
+// that the cold flush of its block, and hence the cleanup of the volume once
+// superseded, waits for the volume to be exported in full.
+type fileSetExportLeaser struct {
+	sync.Mutex
+}
+
+func (l *fileSetExportLeaser) UpdateOpenLease(
+	descriptor block.LeaseDescriptor,
+	state block.LeaseState,
+) (block.UpdateOpenLeaseResult, error) {
+	// Wait for the volume being exported, if any, to be exported.
+	l.Lock()
+	l.Unlock()
+	return block.UpdateOpenLease, nil
+}
+
+// fileSetExporter exports the flushed fileset volumes of the owned shards of
+// a namespace to an object store and imports them into the owned shards of
+// the same namespace on another node, so that backups can be taken and
+// restored without copying the raw files of a live node.
+type fileSetExporter struct {
+	database     database
+	fsOpts       fs.Options
+	leaseManager block.LeaseManager
+	dataFilesFn  dataFilesFn
+	uploadFn     fileSetUploadFn
+	importFn     fileSetImportFn
+}
+
+func newFileSetExporter(database database, opts Options) *fileSetExporter {
+	return &fileSetExporter{
+		database:     database,
+		fsOpts:       opts.CommitLogOptions().FilesystemOptions(),
+		leaseManager: opts.BlockLeaseManager(),
+		dataFilesFn:  fs.DataFiles,
+		uploadFn:     fs.UploadDataFileSet,
+		importFn:     fs.ImportDataFileSet,
+	}
+}
+
+// Export exports the latest volume of each flushed block start within
+// [start, end) of the owned shards of a namespace to the object store,
+// followed by the manifest of the exported volumes.
+func (e *fileSetExporter) Export(
+	namespace ident.ID,
+	start, end time.Time,
+	store fs.ObjectStore,
+) (FileSetExportManifest, error) {
+	n, err := e.ownedNamespace(namespace)
+	if err != nil {
+		return FileSetExportManifest{}, err
+	}
+
+	leaser := &fileSetExportLeaser{}
+	if err := e.leaseManager.RegisterLeaser(leaser); err != nil {
+		return FileSetExportManifest{}, err
+	}
+	defer e.leaseManager.UnregisterLeaser(leaser)
+
+	var (
+		blockSize = n.Options().RetentionOptions().BlockSize()
+		manifest  = FileSetExportManifest{
+			Namespace: namespace.String(),
+			Start:     start,
+			End:       end,
+		}
+	)
+	for _, shard := range n.GetOwnedShards() {
+		for t := start.Truncate(blockSize); t.Before(end); t = t.Add(blockSize) {
+			if !statusIsRetrievable(shard.FlushState(t).WarmStatus) {
+				continue
+			}
+			volume, err := e.exportVolume(leaser, store, namespace, shard.ID(), t)
+			if err != nil {
+				return FileSetExportManifest{}, fmt.Errorf(
+					"unable to export shard %d block %s: %v", shard.ID(), t.String(), err)
+			}
+			manifest.Volumes = append(manifest.Volumes, FileSetExportVolume{
+				Shard:      shard.ID(),
+				BlockStart: t,
+				Volume:     volume,
+			})
+		}
+	}
+
+	// NB: The manifest is written last so that an export is only ever
+	// considered complete once every volume it lists has been exported.
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return FileSetExportManifest{}, err
+	}
+	err = store.Put(fileSetExportManifestKey(namespace), bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return FileSetExportManifest{}, err
+	}
+	return manifest, nil
+}
+
+func (e *fileSetExporter) exportVolume(
+	leaser *fileSetExportLeaser,
+	store fs.ObjectStore,
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+) (int, error) {
+	leaser.Lock()
+	defer leaser.Unlock()
+
+	state, err := e.leaseManager.OpenLatestLease(leaser, block.LeaseDescriptor{
+		Namespace:  namespace,
+		Shard:      shard,
+		BlockStart: blockStart,
+	})
+	if err != nil {
+		return 0, err
+	}
+	err = e.uploadFn(store, e.fsOpts.NamespaceFilePathPrefix(namespace), namespace, shard,
+		blockStart, state.Volume)
+	if err != nil {
+		return 0, err
+	}
+	return state.Volume, nil
+}
+
+// Import imports the volumes listed by the manifest of a namespace exported
+// to the object store into the owned shards of the namespace. Each volume is
+// imported as a volume newer than any on disk for its block start, so that
+// it never replaces a volume that block leasers may still hold, and then
+// replaces the data of the block in full. The manifest returned lists the
+// volumes that were imported by the volume they were imported as.
+func (e *fileSetExporter) Import(
+	namespace ident.ID,
+	store fs.ObjectStore,
+) (FileSetExportManifest, error) {
+	n, err := e.ownedNamespace(namespace)
+	if err != nil {
+		return FileSetExportManifest{}, err
+	}
+
+	manifest, err := readFileSetExportManifest(store, namespace)
+	if err != nil {
+		return FileSetExportManifest{}, err
+	}
+
+	shards := make(map[uint32]databaseShard)
+	for _, shard := range n.GetOwnedShards() {
+		shards[shard.ID()] = shard
+	}
+
+	var (
+		multiErr xerrors.MultiError
+		imported = FileSetExportManifest{
+			Namespace: manifest.Namespace,
+			Start:     manifest.Start,
+			End:       manifest.End,
+		}
+	)
+	for _, exported := range manifest.Volumes {
+		shard, ok := shards[exported.Shard]
+		if !ok {
+			multiErr = multiErr.Add(fmt.Errorf(
+				"unable to import shard %d block %s: shard is not owned",
+				exported.Shard, exported.BlockStart.String()))
+			continue
+		}
+		volume, err := e.importVolume(store, namespace, shard, exported)
+		if err != nil {
+			multiErr = multiErr.Add(fmt.Errorf(
+				"unable to import shard %d block %s: %v",
+				exported.Shard, exported.BlockStart.String(), err))
+			continue
+		}
+		imported.Volumes = append(imported.Volumes, FileSetExportVolume{
+			Shard:      exported.Shard,
+			BlockStart: exported.BlockStart,
+			Volume:     volume,
+		})
+	}
+
+	return imported, multiErr.FinalError()
+}
+
+func (e *fileSetExporter) importVolume(
+	store fs.ObjectStore,
+	namespace ident.ID,
+	shard databaseShard,
+	exported FileSetExportVolume,
+) (int, error) {
+	flushState := shard.FlushState(exported.BlockStart)
+	if !statusIsRetrievable(flushState.WarmStatus) {
+		return 0, errShardImportBlockNotFlushed
+	}
+
+	// Incomplete volumes are considered too since a cold flush may be about
+	// to complete them.
+	filePathPrefix := e.fsOpts.NamespaceFilePathPrefix(namespace)
+	files, err := e.dataFilesFn(filePathPrefix, namespace, shard.ID())
+	if err != nil {
+		return 0, err
+	}
+	volume := flushState.ColdVersion + 1
+	for _, file := range files {
+		if file.ID.BlockStart.Equal(exported.BlockStart) && file.ID.VolumeIndex >= volume {
+			volume = file.ID.VolumeIndex + 1
+		}
+	}
+
+	ok, err := e.importFn(store, e.fsOpts, namespace, shard.ID(),
+		exported.BlockStart, exported.Volume, volume)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, fmt.Errorf("exported volume %d not found", exported.Volume)
+	}
+
+	if err := shard.ImportFileSetVolume(exported.BlockStart, volume); err != nil {
+		// NB: Remove the volume so that it is not taken for a newer volume of
+		// the block by a later cold flush or bootstrap.
+		if deleteErr := fs.DeleteFileSetAt(filePathPrefix, namespace,
+			shard.ID(), exported.BlockStart, volume); deleteErr != nil {
+			return 0, fmt.Errorf("%v, unable to remove imported volume: %v", err, deleteErr)
+		}
+		return 0, err
+	}
+	return volume, nil
+}
+
+func (e *fileSetExporter) ownedNamespace(namespace ident.ID) (databaseNamespace, error) {
+	return ownedNamespace(e.database, namespace)
+}
+
+func fileSetExportManifestKey(namespace ident.ID) string {
+	return path.Join(fileSetExportManifestsDir, namespace.String()+".json")
+}
+
+func readFileSetExportManifest(
+	store fs.ObjectStore,
+	namespace ident.ID,
+) (FileSetExportManifest, error) {
+	r, err := store.Get(fileSetExportManifestKey(namespace))
+	if err != nil {
+		return FileSetExportManifest{}, err
+	}
+	data, err := ioutil.ReadAll(r)
+	// NB: Failure to read takes precedence over failure to close.
+	closeErr := r.Close()
+	if err != nil {
+		return FileSetExportManifest{}, err
+	}
+	if closeErr != nil {
+		return FileSetExportManifest{}, closeErr
+	}
+
+	var manifest FileSetExportManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return FileSetExportManifest{}, err
+	}
+	return manifest, nil
+}
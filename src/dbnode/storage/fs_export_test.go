@@ -0,0 +1,209 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/storage/block"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSetExporterExportAndImport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		nsOpts     = namespace.NewOptions()
+		blockSize  = nsOpts.RetentionOptions().BlockSize()
+		blockStart = time.Now().Truncate(blockSize)
+		nsID       = ident.StringID("nsID")
+	)
+
+	shard := NewMockdatabaseShard(ctrl)
+	shard.EXPECT().ID().Return(uint32(3)).AnyTimes()
+	shard.EXPECT().FlushState(gomock.Any()).DoAndReturn(func(t time.Time) fileOpState {
+		if !t.Equal(blockStart) {
+			return fileOpState{WarmStatus: fileOpNotStarted}
+		}
+		return fileOpState{WarmStatus: fileOpSuccess, ColdVersion: 1}
+	}).AnyTimes()
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().ID().Return(nsID).AnyTimes()
+	ns.EXPECT().Options().Return(nsOpts).AnyTimes()
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{shard}).AnyTimes()
+	db := newMockdatabase(ctrl, ns)
+
+	verifier := block.NewMockLeaseVerifier(ctrl)
+	verifier.EXPECT().LatestState(gomock.Any()).Return(block.LeaseState{Volume: 1}, nil)
+	opts := DefaultTestOptions().SetBlockLeaseManager(block.NewLeaseManager(verifier))
+	exporter := newFileSetExporter(db, opts)
+
+	store := newTestObjectStore()
+
+	var uploaded []int
+	exporter.uploadFn = func(
+		_ fs.ObjectStore,
+		_ string,
+		namespace ident.ID,
+		shard uint32,
+		start time.Time,
+		volume int,
+	) error {
+		require.Equal(t, "nsID", namespace.String())
+		require.Equal(t, uint32(3), shard)
+		require.True(t, blockStart.Equal(start))
+		uploaded = append(uploaded, volume)
+		return nil
+	}
+
+	// Only the latest volume of flushed blocks is exported.
+	manifest, err := exporter.Export(nsID, blockStart.Add(-blockSize),
+		blockStart.Add(blockSize), store)
+	require.NoError(t, err)
+	require.Equal(t, []int{1}, uploaded)
+	require.Equal(t, 1, len(manifest.Volumes))
+	require.Equal(t, uint32(3), manifest.Volumes[0].Shard)
+	require.True(t, blockStart.Equal(manifest.Volumes[0].BlockStart))
+	require.Equal(t, 1, manifest.Volumes[0].Volume)
+
+	written, err := readFileSetExportManifest(store, nsID)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(written.Volumes))
+	require.True(t, blockStart.Equal(written.Volumes[0].BlockStart))
+
+	// Volumes are imported as newer than any volume on disk, complete or not.
+	exporter.dataFilesFn = func(_ string, _ ident.ID, _ uint32) (fs.FileSetFilesSlice, error) {
+		return fs.FileSetFilesSlice{
+			fs.NewFileSetFile(fs.FileSetFileIdentifier{
+				BlockStart:  blockStart,
+				VolumeIndex: 1,
+			}, ""),
+			fs.NewFileSetFile(fs.FileSetFileIdentifier{
+				BlockStart:  blockStart,
+				VolumeIndex: 2,
+			}, ""),
+		}, nil
+	}
+	exporter.importFn = func(
+		_ fs.ObjectStore,
+		_ fs.Options,
+		namespace ident.ID,
+		shard uint32,
+		start time.Time,
+		exportedVolume int,
+		volume int,
+	) (bool, error) {
+		require.Equal(t, 1, exportedVolume)
+		require.Equal(t, 3, volume)
+		return true, nil
+	}
+	shard.EXPECT().ImportFileSetVolume(gomock.Any(), 3).Return(nil)
+
+	imported, err := exporter.Import(nsID, store)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(imported.Volumes))
+	require.Equal(t, 3, imported.Volumes[0].Volume)
+}
+
+func TestFileSetExporterImportUnownedShard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	nsID := ident.StringID("nsID")
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().ID().Return(nsID).AnyTimes()
+	ns.EXPECT().GetOwnedShards().Return(nil)
+	db := newMockdatabase(ctrl, ns)
+	exporter := newFileSetExporter(db, DefaultTestOptions())
+
+	store := newTestObjectStore()
+
+	// Imports require the manifest of an export.
+	_, err := exporter.Import(nsID, store)
+	require.Equal(t, fs.ErrObjectNotFound, err)
+
+	data, err := json.Marshal(FileSetExportManifest{
+		Namespace: nsID.String(),
+		Volumes:   []FileSetExportVolume{{Shard: 1, BlockStart: time.Now()}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.Put(fileSetExportManifestKey(nsID), bytes.NewReader(data), int64(len(data))))
+
+	imported, err := exporter.Import(nsID, store)
+	require.Error(t, err)
+	require.Equal(t, 0, len(imported.Volumes))
+}
+
+type testObjectStore struct {
+	sync.Mutex
+	objects map[string][]byte
+}
+
+func newTestObjectStore() *testObjectStore {
+	return &testObjectStore{objects: make(map[string][]byte)}
+}
+
+func (s *testObjectStore) Put(key string, r io.Reader, size int64) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.Lock()
+	s.objects[key] = data
+	s.Unlock()
+	return nil
+}
+
+func (s *testObjectStore) Get(key string) (io.ReadCloser, error) {
+	s.Lock()
+	data, ok := s.objects[key]
+	s.Unlock()
+	if !ok {
+		return nil, fs.ErrObjectNotFound
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *testObjectStore) Exists(key string) (bool, error) {
+	s.Lock()
+	_, ok := s.objects[key]
+	s.Unlock()
+	return ok, nil
+}
+
+func (s *testObjectStore) Delete(key string) error {
+	s.Lock()
+	delete(s.objects, key)
+	s.Unlock()
+	return nil
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+type fileSetVerifyFn func(
+	opts fs.Options,
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+	volume int,
+) (fs.VerifyResult, error)
+
+type fileSetScrubberMetrics struct {
+	scrubbing tally.Gauge
+	verified  tally.Counter
+	corrupt   tally.Counter
+	errors    tally.Counter
+	duration  tally.Timer
+}
+
+func newFileSetScrubberMetrics(scope tally.Scope) fileSetScrubberMetrics {
+	return fileSetScrubberMetrics{
+		scrubbing: scope.Gauge("scrubbing"),
+		verified:  scope.Counter("verified"),
+		corrupt:   scope.Counter("corrupt"),
+		errors:    scope.Counter("errors"),
+		duration:  scope.Timer("duration"),
+	}
+}
+
+// fileSetScrubber periodically verifies every flushed data fileset owned by
+// the node so that corruption of data at rest is detected before it is read.
+type fileSetScrubber struct {
+	sync.Mutex
+
+	database    database
+	fsOpts      fs.Options
+	interval    time.Duration
+	nowFn       clock.NowFn
+	dataFilesFn dataFilesFn
+	verifyFn    fileSetVerifyFn
+	logger      *zap.Logger
+	metrics     fileSetScrubberMetrics
+
+	scrubbing      bool
+	lastScrubStart time.Time
+}
+
+func newFileSetScrubber(
+	database database,
+	opts Options,
+	scope tally.Scope,
+) *fileSetScrubber {
+	return &fileSetScrubber{
+		database:    database,
+		fsOpts:      opts.CommitLogOptions().FilesystemOptions(),
+		interval:    opts.FileSetScrubInterval(),
+		nowFn:       opts.ClockOptions().NowFn(),
+		dataFilesFn: fs.DataFiles,
+		verifyFn:    fs.Verify,
+		logger:      opts.InstrumentOptions().Logger(),
+		metrics:     newFileSetScrubberMetrics(scope),
+	}
+}
+
+// MaybeStart starts a scrub in the background if scrubbing is enabled, no
+// scrub is in progress and the last scrub started at least an interval ago,
+// returning whether a scrub was started.
+func (s *fileSetScrubber) MaybeStart(t time.Time) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.interval <= 0 || s.scrubbing {
+		return false
+	}
+	if !s.lastScrubStart.IsZero() && t.Sub(s.lastScrubStart) < s.interval {
+		return false
+	}
+
+	s.scrubbing = true
+	s.lastScrubStart = t
+	go func() {
+		if err := s.Scrub(); err != nil {
+			s.logger.Error("error scrubbing filesets", zap.Error(err))
+		}
+		s.Lock()
+		s.scrubbing = false
+		s.Unlock()
+	}()
+	return true
+}
+
+// Scrub verifies every complete data fileset of the owned shards of all
+// owned namespaces, reporting the filesets found to be corrupt.
+func (s *fileSetScrubber) Scrub() error {
+	start := s.nowFn()
+	s.metrics.scrubbing.Update(1)
+	defer func() {
+		s.metrics.scrubbing.Update(0)
+		s.metrics.duration.Record(s.nowFn().Sub(start))
+	}()
+
+	namespaces, err := s.database.GetOwnedNamespaces()
+	if err != nil {
+		return err
+	}
+
+	for _, n := range namespaces {
+		filePathPrefix := s.fsOpts.NamespaceFilePathPrefix(n.ID())
+		for _, shard := range n.GetOwnedShards() {
+			files, err := s.dataFilesFn(filePathPrefix, n.ID(), shard.ID())
+			if err != nil {
+				s.metrics.errors.Inc(1)
+				s.logger.Error("unable to list filesets to scrub",
+					zap.Stringer("namespace", n.ID()),
+					zap.Uint32("shard", shard.ID()),
+					zap.Error(err))
+				continue
+			}
+			for i := range files {
+				if !files[i].HasCompleteCheckpointFile() {
+					continue
+				}
+				s.verify(n.ID(), shard.ID(), files[i].ID)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *fileSetScrubber) verify(
+	namespace ident.ID,
+	shard uint32,
+	fileSetID fs.FileSetFileIdentifier,
+) {
+	result, err := s.verifyFn(s.fsOpts, namespace, shard,
+		fileSetID.BlockStart, fileSetID.VolumeIndex)
+	if err == fs.ErrCheckpointFileNotFound {
+		// Removed by cleanup since it was listed.
+		return
+	}
+	if err != nil {
+		s.metrics.errors.Inc(1)
+		s.logger.Error("unable to verify fileset",
+			zap.Stringer("namespace", namespace),
+			zap.Uint32("shard", shard),
+			zap.Time("blockStart", fileSetID.BlockStart),
+			zap.Int("volume", fileSetID.VolumeIndex),
+			zap.Error(err))
+		return
+	}
+
+	s.metrics.verified.Inc(1)
+	if result.Healthy() {
+		return
+	}
+
+	s.metrics.corrupt.Inc(1)
+	issues := make([]string, 0, len(result.Issues))
+	for _, issue := range result.Issues {
+		if issue.ID == "" {
+			issues = append(issues, fmt.Sprintf("%s: %s",
+				issue.Type, issue.Message))
+			continue
+		}
+		issues = append(issues, fmt.Sprintf("%s: %s: %s",
+			issue.Type, issue.ID, issue.Message))
+	}
+	s.logger.Error("corrupt fileset found by scrub",
+		zap.Stringer("namespace", namespace),
+		zap.Uint32("shard", shard),
+		zap.Time("blockStart", fileSetID.BlockStart),
+		zap.Int("volume", fileSetID.VolumeIndex),
+		zap.Int("numIssues", result.NumIssues),
+		zap.Strings("issues", issues))
+}
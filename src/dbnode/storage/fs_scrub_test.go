@@ -0,0 +1,145 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestFileSetScrubberScrub(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	shard := NewMockdatabaseShard(ctrl)
+	shard.EXPECT().ID().Return(uint32(3)).AnyTimes()
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().ID().Return(ident.StringID("nsID")).AnyTimes()
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{shard})
+	namespaces := []databaseNamespace{ns}
+
+	db := newMockdatabase(ctrl, namespaces...)
+
+	scope := tally.NewTestScope("", nil)
+	scrubber := newFileSetScrubber(db, DefaultTestOptions(), scope)
+
+	blockStart := time.Now().Truncate(time.Hour)
+	newFile := func(volume int, complete bool) fs.FileSetFile {
+		file := fs.NewFileSetFile(fs.FileSetFileIdentifier{
+			BlockStart:  blockStart,
+			VolumeIndex: volume,
+		}, "")
+		file.CachedHasCompleteCheckpointFile = fs.EvalFalse
+		if complete {
+			file.CachedHasCompleteCheckpointFile = fs.EvalTrue
+		}
+		return file
+	}
+	scrubber.dataFilesFn = func(_ string, _ ident.ID, _ uint32) (fs.FileSetFilesSlice, error) {
+		return fs.FileSetFilesSlice{
+			newFile(0, true),
+			newFile(1, true),
+			newFile(2, true),
+			newFile(3, false),
+		}, nil
+	}
+
+	var verified []int
+	scrubber.verifyFn = func(
+		_ fs.Options,
+		namespace ident.ID,
+		shard uint32,
+		start time.Time,
+		volume int,
+	) (fs.VerifyResult, error) {
+		require.Equal(t, "nsID", namespace.String())
+		require.Equal(t, uint32(3), shard)
+		require.Equal(t, blockStart, start)
+		verified = append(verified, volume)
+
+		result := fs.VerifyResult{Volume: volume}
+		switch volume {
+		case 1:
+			result.Issues = []fs.VerifyIssue{{
+				Type:    fs.VerifyIssueIndexDataMismatch,
+				ID:      "foo",
+				Message: "checksum mismatch",
+			}}
+			result.NumIssues = 1
+		case 2:
+			return result, errors.New("an error")
+		}
+		return result, nil
+	}
+
+	require.NoError(t, scrubber.Scrub())
+
+	// Incomplete volumes are skipped.
+	require.Equal(t, []int{0, 1, 2}, verified)
+
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(2), counters["verified+"].Value())
+	require.Equal(t, int64(1), counters["corrupt+"].Value())
+	require.Equal(t, int64(1), counters["errors+"].Value())
+}
+
+func TestFileSetScrubberMaybeStart(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db := newMockdatabase(ctrl)
+	db.EXPECT().GetOwnedNamespaces().Return(nil, nil).Times(2)
+
+	// Scrubbing is disabled by default.
+	scrubber := newFileSetScrubber(db, DefaultTestOptions(), tally.NoopScope)
+	require.False(t, scrubber.MaybeStart(time.Now()))
+
+	opts := DefaultTestOptions().SetFileSetScrubInterval(time.Hour)
+	scrubber = newFileSetScrubber(db, opts, tally.NoopScope)
+	waitForScrub := func() {
+		for {
+			scrubber.Lock()
+			scrubbing := scrubber.scrubbing
+			scrubber.Unlock()
+			if !scrubbing {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	now := time.Now()
+	require.True(t, scrubber.MaybeStart(now))
+	waitForScrub()
+
+	// Does not start again until an interval has passed.
+	require.False(t, scrubber.MaybeStart(now.Add(time.Minute)))
+	require.True(t, scrubber.MaybeStart(now.Add(time.Hour)))
+	waitForScrub()
+}
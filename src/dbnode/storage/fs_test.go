@@ -25,6 +25,9 @@ import (
 	"testing"
 	"time"
 
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 )
@@ -92,3 +95,59 @@ func TestFileSystemManagerRun(t *testing.T) {
 	mgr.Run(ts, DatabaseBootstrapState{}, syncRun, noForce)
 	require.Equal(t, fileOpNotStarted, mgr.status)
 }
+
+func TestFileSystemManagerPauseShardFlush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	database := newMockdatabase(ctrl)
+	fsm := newFileSystemManager(database, nil, DefaultTestOptions())
+
+	until := time.Now().Add(time.Hour)
+	shard0 := NewMockdatabaseShard(ctrl)
+	shard0.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	shard1 := NewMockdatabaseShard(ctrl)
+	shard1.EXPECT().ID().Return(uint32(1)).AnyTimes()
+
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().ID().Return(ident.StringID("testns")).AnyTimes()
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{shard0, shard1}).AnyTimes()
+	database.EXPECT().GetOwnedNamespaces().Return([]databaseNamespace{ns}, nil).AnyTimes()
+
+	shard1.EXPECT().PauseFlush(ColdFlushBackgroundTask, until)
+	require.NoError(t, fsm.PauseShardFlush(ident.StringID("testns"),
+		[]uint32{1}, ColdFlushBackgroundTask, until))
+
+	// Nothing is paused if any of the shards is not owned.
+	err := fsm.PauseShardFlush(ident.StringID("testns"),
+		[]uint32{0, 2}, ColdFlushBackgroundTask, until)
+	require.True(t, xerrors.IsInvalidParams(err))
+
+	err = fsm.PauseShardFlush(ident.StringID("unknown"),
+		[]uint32{0}, ColdFlushBackgroundTask, until)
+	require.Error(t, err)
+
+	// Without shards the flushes of the whole namespace are paused.
+	ns.EXPECT().PauseBackgroundTask(WarmFlushBackgroundTask, until)
+	require.NoError(t, fsm.PauseShardFlush(ident.StringID("testns"),
+		nil, WarmFlushBackgroundTask, until))
+
+	shard0.EXPECT().PausedFlushes().Return(nil)
+	shard1.EXPECT().PausedFlushes().Return(map[BackgroundTask]time.Time{
+		ColdFlushBackgroundTask: until,
+	})
+	require.Equal(t, []PausedShardFlush{{
+		Namespace: ns.ID(),
+		Shard:     1,
+		Task:      ColdFlushBackgroundTask,
+		Until:     until,
+	}}, fsm.PausedShardFlushes())
+
+	shard1.EXPECT().ResumeFlush(ColdFlushBackgroundTask)
+	require.NoError(t, fsm.ResumeShardFlush(ident.StringID("testns"),
+		[]uint32{1}, ColdFlushBackgroundTask))
+
+	ns.EXPECT().ResumeBackgroundTask(WarmFlushBackgroundTask)
+	require.NoError(t, fsm.ResumeShardFlush(ident.StringID("testns"),
+		nil, WarmFlushBackgroundTask))
+}
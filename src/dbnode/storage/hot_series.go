@@ -0,0 +1,373 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	defaultHotSeriesHalfLife = 5 * time.Minute
+
+	// maxHotSeriesGrowth is the growth of the weights of events since the
+	// landmark after which the scores are decayed on the next event rather
+	// than on the next tick, so that weights cannot overflow if ticks stall.
+	maxHotSeriesGrowth = 1e12
+)
+
+var (
+	errHotSeriesCapacity    = errors.New("hot series capacity must not be negative")
+	errHotSeriesSampleEvery = errors.New("hot series sample every must not be negative")
+	errHotSeriesHalfLife    = errors.New("hot series half life must not be negative")
+	errHotSeriesLimit       = errors.New("hot series limit must not be negative")
+
+	// errHotSeriesDisabled is returned when the hot series are requested
+	// while they are not tracked.
+	errHotSeriesDisabled = errors.New("hot series tracking is disabled")
+)
+
+// HotSeriesOptions are the options for tracking the series of each shard with
+// the highest write and read rates.
+type HotSeriesOptions struct {
+	// Capacity is the number of series of each shard tracked for each of
+	// writes and reads, zero disables tracking.
+	Capacity int
+
+	// SampleEvery records one of every SampleEvery writes and reads of each
+	// shard, zero or one records all of them.
+	SampleEvery int
+
+	// HalfLife is how long it takes the rate of a series to halve once it
+	// stops being written or read, zero uses the default half life.
+	HalfLife time.Duration
+}
+
+// Enabled returns whether hot series are tracked.
+func (o HotSeriesOptions) Enabled() bool {
+	return o.Capacity > 0
+}
+
+// Validate validates the hot series options.
+func (o HotSeriesOptions) Validate() error {
+	if o.Capacity < 0 {
+		return errHotSeriesCapacity
+	}
+	if o.SampleEvery < 0 {
+		return errHotSeriesSampleEvery
+	}
+	if o.HalfLife < 0 {
+		return errHotSeriesHalfLife
+	}
+	return nil
+}
+
+// HotSeries is a series with one of the highest write or read rates of its
+// shard.
+type HotSeries struct {
+	ID    ident.ID
+	Shard uint32
+	// Rate is the estimated recent number of writes or reads per second.
+	Rate float64
+}
+
+// HotSeriesResult is the series of a namespace with the highest write and
+// read rates, ordered from the highest rate.
+type HotSeriesResult struct {
+	Writes []HotSeries
+	Reads  []HotSeries
+}
+
+// sortHotSeries orders hot series from the highest rate, breaking ties by
+// shard so that results are stable.
+func sortHotSeries(series []HotSeries) {
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].Rate != series[j].Rate {
+			return series[i].Rate > series[j].Rate
+		}
+		return series[i].Shard < series[j].Shard
+	})
+}
+
+// shardHotSeries tracks the series of a shard with the highest write and
+// read rates, all methods are no-ops on a nil shardHotSeries so that shards
+// need not check whether tracking is enabled.
+type shardHotSeries struct {
+	shard       uint32
+	sampleEvery uint64
+	nowFn       clock.NowFn
+	numWrites   uint64
+	numReads    uint64
+	writes      *hotSeriesTracker
+	reads       *hotSeriesTracker
+	metrics     shardHotSeriesMetrics
+}
+
+type shardHotSeriesMetrics struct {
+	maxWriteRate tally.Gauge
+	maxReadRate  tally.Gauge
+}
+
+func newShardHotSeries(
+	shard uint32,
+	opts HotSeriesOptions,
+	nowFn clock.NowFn,
+	scope tally.Scope,
+) *shardHotSeries {
+	if !opts.Enabled() {
+		return nil
+	}
+
+	sampleEvery := opts.SampleEvery
+	if sampleEvery < 1 {
+		sampleEvery = 1
+	}
+	halfLife := opts.HalfLife
+	if halfLife == 0 {
+		halfLife = defaultHotSeriesHalfLife
+	}
+
+	var (
+		now    = nowFn()
+		weight = float64(sampleEvery)
+	)
+	scope = scope.SubScope("hot-series").Tagged(map[string]string{
+		"shard": fmt.Sprintf("%d", shard),
+	})
+	return &shardHotSeries{
+		shard:       shard,
+		sampleEvery: uint64(sampleEvery),
+		nowFn:       nowFn,
+		writes:      newHotSeriesTracker(opts.Capacity, weight, halfLife, now),
+		reads:       newHotSeriesTracker(opts.Capacity, weight, halfLife, now),
+		metrics: shardHotSeriesMetrics{
+			maxWriteRate: scope.Gauge("max-write-rate"),
+			maxReadRate:  scope.Gauge("max-read-rate"),
+		},
+	}
+}
+
+// RecordWrite records a write of the series if it is sampled.
+func (h *shardHotSeries) RecordWrite(id ident.ID) {
+	if h == nil || atomic.AddUint64(&h.numWrites, 1)%h.sampleEvery != 0 {
+		return
+	}
+	h.writes.Record(id, h.nowFn())
+}
+
+// RecordRead records a read of the series if it is sampled.
+func (h *shardHotSeries) RecordRead(id ident.ID) {
+	if h == nil || atomic.AddUint64(&h.numReads, 1)%h.sampleEvery != 0 {
+		return
+	}
+	h.reads.Record(id, h.nowFn())
+}
+
+// Tick decays the tracked series and reports the highest rates.
+func (h *shardHotSeries) Tick() {
+	if h == nil {
+		return
+	}
+
+	now := h.nowFn()
+	h.writes.Decay(now)
+	h.reads.Decay(now)
+	h.metrics.maxWriteRate.Update(h.writes.MaxRate(now))
+	h.metrics.maxReadRate.Update(h.reads.MaxRate(now))
+}
+
+// Top returns up to limit of the series with the highest write and read rates.
+func (h *shardHotSeries) Top(limit int) (writes, reads []HotSeries) {
+	if h == nil {
+		return nil, nil
+	}
+
+	now := h.nowFn()
+	return h.writes.Top(h.shard, limit, now), h.reads.Top(h.shard, limit, now)
+}
+
+// hotSeriesTracker estimates the series with the highest rates using the
+// space saving algorithm: once at capacity, a series that is not tracked
+// replaces the tracked series with the lowest score and inherits its score.
+// Scores decay exponentially, which is implemented by weighting events by
+// their growth since a landmark time rather than decaying every score on
+// each event.
+type hotSeriesTracker struct {
+	sync.Mutex
+
+	capacity int
+	weight   float64
+	// tau is the time constant of the decay in seconds, a series recorded at
+	// a steady rate converges to a score of its rate times tau.
+	tau      float64
+	landmark time.Time
+	entries  map[string]*hotSeriesEntry
+	heap     hotSeriesHeap
+}
+
+type hotSeriesEntry struct {
+	id    string
+	score float64
+	index int
+}
+
+func newHotSeriesTracker(
+	capacity int,
+	weight float64,
+	halfLife time.Duration,
+	now time.Time,
+) *hotSeriesTracker {
+	return &hotSeriesTracker{
+		capacity: capacity,
+		weight:   weight,
+		tau:      halfLife.Seconds() / math.Ln2,
+		landmark: now,
+		entries:  make(map[string]*hotSeriesEntry, capacity),
+		heap:     make(hotSeriesHeap, 0, capacity),
+	}
+}
+
+// Record records an event of the series.
+func (t *hotSeriesTracker) Record(id ident.ID, now time.Time) {
+	t.Lock()
+	defer t.Unlock()
+
+	growth := t.growthWithLock(now)
+	if growth > maxHotSeriesGrowth {
+		t.decayWithLock(now)
+		growth = 1
+	}
+
+	weight := t.weight * growth
+	if entry, ok := t.entries[string(id.Bytes())]; ok {
+		entry.score += weight
+		heap.Fix(&t.heap, entry.index)
+		return
+	}
+
+	if len(t.heap) < t.capacity {
+		entry := &hotSeriesEntry{id: string(id.Bytes()), score: weight}
+		t.entries[entry.id] = entry
+		heap.Push(&t.heap, entry)
+		return
+	}
+
+	entry := t.heap[0]
+	delete(t.entries, entry.id)
+	entry.id = string(id.Bytes())
+	entry.score += weight
+	t.entries[entry.id] = entry
+	heap.Fix(&t.heap, 0)
+}
+
+// Decay moves the landmark to now, scaling the scores accordingly so that
+// the weights of events do not grow unbounded.
+func (t *hotSeriesTracker) Decay(now time.Time) {
+	t.Lock()
+	t.decayWithLock(now)
+	t.Unlock()
+}
+
+func (t *hotSeriesTracker) decayWithLock(now time.Time) {
+	if !now.After(t.landmark) {
+		return
+	}
+	decay := 1 / t.growthWithLock(now)
+	for _, entry := range t.heap {
+		entry.score *= decay
+	}
+	t.landmark = now
+}
+
+// MaxRate returns the highest rate of the tracked series.
+func (t *hotSeriesTracker) MaxRate(now time.Time) float64 {
+	t.Lock()
+	defer t.Unlock()
+
+	var max float64
+	for _, entry := range t.heap {
+		max = math.Max(max, entry.score)
+	}
+	return t.rateWithLock(max, now)
+}
+
+// Top returns up to limit of the tracked series with the highest rates.
+func (t *hotSeriesTracker) Top(shard uint32, limit int, now time.Time) []HotSeries {
+	t.Lock()
+	top := make([]HotSeries, 0, len(t.heap))
+	for _, entry := range t.heap {
+		top = append(top, HotSeries{
+			ID:    ident.StringID(entry.id),
+			Shard: shard,
+			Rate:  t.rateWithLock(entry.score, now),
+		})
+	}
+	t.Unlock()
+
+	sortHotSeries(top)
+	if limit > 0 && len(top) > limit {
+		top = top[:limit]
+	}
+	return top
+}
+
+func (t *hotSeriesTracker) growthWithLock(now time.Time) float64 {
+	return math.Exp(now.Sub(t.landmark).Seconds() / t.tau)
+}
+
+func (t *hotSeriesTracker) rateWithLock(score float64, now time.Time) float64 {
+	return score / t.growthWithLock(now) / t.tau
+}
+
+// hotSeriesHeap is a min heap of tracked series by score.
+type hotSeriesHeap []*hotSeriesEntry
+
+func (h hotSeriesHeap) Len() int           { return len(h) }
+func (h hotSeriesHeap) Less(i, j int) bool { return h[i].score < h[j].score }
+
+func (h hotSeriesHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *hotSeriesHeap) Push(x interface{}) {
+	entry := x.(*hotSeriesEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *hotSeriesHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
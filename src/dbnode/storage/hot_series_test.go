@@ -0,0 +1,124 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestHotSeriesOptionsValidate(t *testing.T) {
+	require.NoError(t, HotSeriesOptions{}.Validate())
+	require.False(t, HotSeriesOptions{}.Enabled())
+	require.True(t, HotSeriesOptions{Capacity: 10}.Enabled())
+	require.Error(t, HotSeriesOptions{Capacity: -1}.Validate())
+	require.Error(t, HotSeriesOptions{SampleEvery: -1}.Validate())
+	require.Error(t, HotSeriesOptions{HalfLife: -time.Second}.Validate())
+}
+
+func TestHotSeriesTrackerTop(t *testing.T) {
+	var (
+		now      = time.Now()
+		halfLife = time.Minute
+		tracker  = newHotSeriesTracker(2, 1, halfLife, now)
+	)
+	record := func(id string, n int) {
+		for i := 0; i < n; i++ {
+			tracker.Record(ident.StringID(id), now)
+		}
+	}
+	record("c", 1)
+	record("a", 10)
+	// Once at capacity "b" replaces "c", the series with the lowest score,
+	// inheriting its score.
+	record("b", 5)
+
+	top := tracker.Top(3, 0, now)
+	require.Equal(t, 2, len(top))
+	require.Equal(t, "a", top[0].ID.String())
+	require.Equal(t, "b", top[1].ID.String())
+	require.Equal(t, uint32(3), top[0].Shard)
+
+	tau := halfLife.Seconds() / math.Ln2
+	require.InDelta(t, 10/tau, top[0].Rate, 1e-9)
+	require.InDelta(t, 6/tau, top[1].Rate, 1e-9)
+	require.InDelta(t, 10/tau, tracker.MaxRate(now), 1e-9)
+
+	top = tracker.Top(3, 1, now)
+	require.Equal(t, 1, len(top))
+	require.Equal(t, "a", top[0].ID.String())
+}
+
+func TestHotSeriesTrackerDecay(t *testing.T) {
+	var (
+		now      = time.Now()
+		halfLife = time.Minute
+		tracker  = newHotSeriesTracker(2, 1, halfLife, now)
+	)
+	tracker.Record(ident.StringID("a"), now)
+	rate := tracker.MaxRate(now)
+
+	// Rates halve every half life whether or not the scores were decayed.
+	now = now.Add(halfLife)
+	require.InDelta(t, rate/2, tracker.MaxRate(now), 1e-9)
+	tracker.Decay(now)
+	require.InDelta(t, rate/2, tracker.MaxRate(now), 1e-9)
+
+	// A series recorded after the decay outranks one recorded before.
+	tracker.Record(ident.StringID("b"), now)
+	top := tracker.Top(0, 0, now)
+	require.Equal(t, "b", top[0].ID.String())
+	require.InDelta(t, rate, top[0].Rate, 1e-9)
+	require.Equal(t, "a", top[1].ID.String())
+}
+
+func TestShardHotSeriesSampling(t *testing.T) {
+	now := time.Now()
+	nowFn := func() time.Time { return now }
+
+	var disabled *shardHotSeries
+	require.Nil(t, newShardHotSeries(0, HotSeriesOptions{}, nowFn, tally.NoopScope))
+	disabled.RecordWrite(ident.StringID("a"))
+	disabled.Tick()
+	writes, reads := disabled.Top(10)
+	require.Nil(t, writes)
+	require.Nil(t, reads)
+
+	opts := HotSeriesOptions{Capacity: 4, SampleEvery: 2, HalfLife: time.Minute}
+	hot := newShardHotSeries(5, opts, nowFn, tally.NoopScope)
+	for i := 0; i < 4; i++ {
+		hot.RecordWrite(ident.StringID("a"))
+	}
+	hot.RecordRead(ident.StringID("b"))
+
+	// Two of the four writes are sampled, each weighted by the sample rate.
+	writes, reads = hot.Top(10)
+	require.Equal(t, 1, len(writes))
+	require.Equal(t, uint32(5), writes[0].Shard)
+	tau := time.Minute.Seconds() / math.Ln2
+	require.InDelta(t, 4/tau, writes[0].Rate, 1e-9)
+	require.Equal(t, 0, len(reads))
+}
@@ -40,6 +40,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/index/compaction"
 	"github.com/m3db/m3/src/dbnode/storage/index/convert"
+	"github.com/m3db/m3/src/dbnode/storage/index/segments"
 	"github.com/m3db/m3/src/dbnode/tracepoint"
 	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/m3ninx/idx"
@@ -67,14 +68,25 @@ var (
 	errDbIndexUnableToWriteClosed         = errors.New("unable to write to database index, already closed")
 	errDbIndexUnableToQueryClosed         = errors.New("unable to query database index, already closed")
 	errDbIndexUnableToFlushClosed         = errors.New("unable to flush database index, already closed")
+	errDbIndexUnableToSnapshotClosed      = errors.New("unable to snapshot database index, already closed")
+	errDbIndexUnableToReportFieldsClosed  = errors.New("unable to report database index field cardinality, already closed")
+	errDbIndexFieldCardinalityDisabled    = errors.New("database index field cardinality is disabled")
+	errDbIndexUnableToReportStateClosed   = errors.New("unable to report database index block states, already closed")
 	errDbIndexUnableToCleanupClosed       = errors.New("unable to cleanup database index, already closed")
 	errDbIndexTerminatingTickCancellation = errors.New("terminating tick early due to cancellation")
 	errDbIndexIsBootstrapping             = errors.New("index is already bootstrapping")
+	errDbIndexQueryCancelled              = errors.New("index query cancelled")
+	errDbIndexAggregateStreamQuery        = errors.New("aggregate query streaming only supports all and field queries")
+	errDbIndexUnableToMarkDeletedClosed   = errors.New("unable to mark series deleted in database index, already closed")
+	errDbIndexUnableToMarkExpiredClosed   = errors.New("unable to mark series expired in database index, already closed")
 )
 
 const (
 	defaultFlushReadDataBlocksBatchSize = int64(4096)
 	nsIndexReportStatsInterval          = 10 * time.Second
+	// aggregateStreamCheckEvery is how often, in fields and terms streamed, an
+	// aggregate query stream checks whether it was cancelled or timed out.
+	aggregateStreamCheckEvery = 1024
 )
 
 var (
@@ -85,20 +97,28 @@ var (
 type nsIndex struct {
 	state nsIndexState
 
+	// NB: the retention vars below are updated when the namespace retention
+	// changes at runtime and are protected under the state mutex.
+	retentionPeriod           time.Duration
+	futureRetentionPeriod     time.Duration
+	bufferPast                time.Duration
+	bufferFuture              time.Duration
+	expiredFileSetGracePeriod time.Duration
+
 	// all the vars below this line are not modified past the ctor
 	// and don't require a lock when being accessed.
-	nowFn                 clock.NowFn
-	blockSize             time.Duration
-	retentionPeriod       time.Duration
-	futureRetentionPeriod time.Duration
-	bufferPast            time.Duration
-	bufferFuture          time.Duration
-	coldWritesEnabled     bool
+	nowFn             clock.NowFn
+	blockSize         time.Duration
+	coldWritesEnabled bool
 
 	indexFilesetsBeforeFn indexFilesetsBeforeFn
 	deleteFilesFn         deleteFilesFn
+	readIndexInfoFilesFn  readIndexInfoFilesFn
+	readIndexSegmentsFn   readIndexSegmentsFn
+	indexSnapshotFilesFn  indexSnapshotFilesFn
 
 	newBlockFn          newBlockFn
+	blockOpts           index.BlockOptions
 	logger              *zap.Logger
 	opts                Options
 	nsMetadata          namespace.Metadata
@@ -130,6 +150,9 @@ type nsIndexState struct {
 	closeCh        chan struct{}
 	bootstrapState BootstrapState
 	bootstrapsDone uint
+	// disabled is set when indexing of the namespace is disabled at runtime,
+	// writes are then no longer indexed and no blocks are created for them.
+	disabled bool
 
 	runtimeOpts nsIndexRuntimeOptions
 
@@ -166,6 +189,21 @@ type newBlockFn func(
 	index.Options,
 ) (index.Block, error)
 
+type readIndexInfoFilesFn func(
+	filePathPrefix string,
+	namespace ident.ID,
+	readerBufferSize int,
+) []fs.ReadIndexInfoFileResult
+
+type readIndexSegmentsFn func(
+	opts fs.ReadIndexSegmentsOptions,
+) ([]segment.Segment, error)
+
+type indexSnapshotFilesFn func(
+	filePathPrefix string,
+	namespace ident.ID,
+) (fs.FileSetFilesSlice, error)
+
 // NB(prateek): the returned filesets are strictly before the given time, i.e. they
 // live in the period (-infinity, exclusiveTime).
 type indexFilesetsBeforeFn func(dir string,
@@ -178,6 +216,7 @@ type newNamespaceIndexOpts struct {
 	opts            Options
 	newIndexQueueFn newNamespaceIndexInsertQueueFn
 	newBlockFn      newBlockFn
+	blockOpts       index.BlockOptions
 }
 
 // execBlockQueryFn executes a query against the given block whilst tracking state.
@@ -212,6 +251,22 @@ func newNamespaceIndex(
 	})
 }
 
+// newNamespaceIndexWithBlockOptions returns a new namespaceIndex for the provided
+// namespace that allocates its blocks with the provided block options.
+func newNamespaceIndexWithBlockOptions(
+	nsMD namespace.Metadata,
+	blockOpts index.BlockOptions,
+	opts Options,
+) (namespaceIndex, error) {
+	return newNamespaceIndexWithOptions(newNamespaceIndexOpts{
+		md:              nsMD,
+		opts:            opts,
+		newIndexQueueFn: newNamespaceIndexInsertQueue,
+		newBlockFn:      index.NewBlock,
+		blockOpts:       blockOpts,
+	})
+}
+
 // newNamespaceIndexWithInsertQueueFn is a ctor used in tests to override the insert queue.
 func newNamespaceIndexWithInsertQueueFn(
 	nsMD namespace.Metadata,
@@ -256,6 +311,10 @@ func newNamespaceIndexWithOptions(
 		return nil, err
 	}
 
+	// The compiled regexps are cached across all namespaces since queries are
+	// compiled before they are routed to a namespace index.
+	m3ninxindex.SetRegexpCacheOptions(indexOpts.RegexpCacheOptions())
+
 	scope := instrumentOpts.MetricsScope().
 		SubScope("dbindex").
 		Tagged(map[string]string{
@@ -264,6 +323,19 @@ func newNamespaceIndexWithOptions(
 	instrumentOpts = instrumentOpts.SetMetricsScope(scope)
 	indexOpts = indexOpts.SetInstrumentOptions(instrumentOpts)
 
+	// Blocks of the namespace share the limit of concurrent background
+	// compactions.
+	blockOpts := newIndexOpts.blockOpts
+	if blockOpts.BackgroundCompactionLimiter == nil {
+		blockOpts.BackgroundCompactionLimiter = index.NewBackgroundCompactionLimiter(
+			indexOpts.MaxConcurrentBackgroundCompactions())
+	}
+	// Segments are searched by the query workers left idle by the blocks
+	// queried, which bounds the concurrency of a query as a whole.
+	if indexOpts.QuerySegmentsConcurrently() && blockOpts.QuerySegmentsWorkerPool == nil {
+		blockOpts.QuerySegmentsWorkerPool = newIndexOpts.opts.QueryIDsWorkerPool()
+	}
+
 	nowFn := indexOpts.ClockOptions().NowFn()
 	idx := &nsIndex{
 		state: nsIndexState{
@@ -275,18 +347,23 @@ func newNamespaceIndexWithOptions(
 			blocksByTime: make(map[xtime.UnixNano]index.Block),
 		},
 
-		nowFn:                 nowFn,
-		blockSize:             nsMD.Options().IndexOptions().BlockSize(),
-		retentionPeriod:       nsMD.Options().RetentionOptions().RetentionPeriod(),
-		futureRetentionPeriod: nsMD.Options().RetentionOptions().FutureRetentionPeriod(),
-		bufferPast:            nsMD.Options().RetentionOptions().BufferPast(),
-		bufferFuture:          nsMD.Options().RetentionOptions().BufferFuture(),
-		coldWritesEnabled:     nsMD.Options().ColdWritesEnabled(),
+		nowFn:                     nowFn,
+		blockSize:                 nsMD.Options().IndexOptions().BlockSize(),
+		retentionPeriod:           nsMD.Options().RetentionOptions().RetentionPeriod(),
+		futureRetentionPeriod:     nsMD.Options().RetentionOptions().FutureRetentionPeriod(),
+		bufferPast:                nsMD.Options().RetentionOptions().BufferPast(),
+		bufferFuture:              nsMD.Options().RetentionOptions().BufferFuture(),
+		expiredFileSetGracePeriod: nsMD.Options().RetentionOptions().ExpiredFileSetGracePeriod(),
+		coldWritesEnabled:         nsMD.Options().ColdWritesEnabled(),
 
 		indexFilesetsBeforeFn: fs.IndexFileSetsBefore,
 		deleteFilesFn:         fs.DeleteFiles,
+		readIndexInfoFilesFn:  fs.ReadIndexInfoFiles,
+		readIndexSegmentsFn:   fs.ReadIndexSegments,
+		indexSnapshotFilesFn:  fs.IndexSnapshotFiles,
 
 		newBlockFn: newBlockFn,
+		blockOpts:  blockOpts,
 		opts:       newIndexOpts.opts,
 		logger:     indexOpts.InstrumentOptions().Logger(),
 		nsMetadata: nsMD,
@@ -298,6 +375,10 @@ func newNamespaceIndexWithOptions(
 		metrics:          newNamespaceIndexMetrics(indexOpts, instrumentOpts),
 	}
 
+	if indexOpts.ColdBlockAge() > 0 && idx.blockOpts.ColdSegmentsLoaderFn == nil {
+		idx.blockOpts.ColdSegmentsLoaderFn = idx.readColdBlockSegments
+	}
+
 	if runtimeOptsMgr != nil {
 		idx.runtimeOptsListener = runtimeOptsMgr.RegisterListener(idx)
 	}
@@ -337,6 +418,12 @@ func (i *nsIndex) SetRuntimeOptions(value runtime.Options) {
 	i.state.runtimeOpts.defaultQueryTimeout = value.IndexDefaultQueryTimeout()
 	i.state.runtimeOpts.flushBlockNumSegments = value.FlushIndexBlockNumSegments()
 	i.state.Unlock()
+
+	maxCompactions := i.opts.IndexOptions().MaxConcurrentBackgroundCompactions()
+	if max := value.IndexCompactionOptions().MaxConcurrentBackgroundCompactions; max > 0 {
+		maxCompactions = max
+	}
+	i.blockOpts.BackgroundCompactionLimiter.SetMax(maxCompactions)
 }
 
 func (i *nsIndex) reportStatsUntilClosed() {
@@ -374,6 +461,13 @@ func (i *nsIndex) reportStats() error {
 	flushedLevels := i.metrics.BlockMetrics.FlushedSegments.Levels
 	flushedLevelStats := make([]nsIndexCompactionLevelStats, len(flushedLevels))
 
+	var (
+		compactionOpts          = i.opts.IndexOptions().BackgroundCompactionPlannerOptions()
+		compactionDebtSegments  int
+		compactionDebtDocs      int64
+		blockBackgroundSegments []compaction.Segment
+	)
+
 	// iterate known blocks in a defined order of time (newest first)
 	// for debug log ordering
 	for _, start := range i.state.blockStartsDescOrder {
@@ -382,6 +476,7 @@ func (i *nsIndex) reportStats() error {
 			return i.missingBlockInvariantError(start)
 		}
 
+		blockBackgroundSegments = blockBackgroundSegments[:0]
 		err := block.Stats(
 			index.BlockStatsReporterFn(func(s index.BlockSegmentStats) {
 				var (
@@ -395,6 +490,11 @@ func (i *nsIndex) reportStats() error {
 				case index.ActiveBackgroundSegment:
 					levels = backgroundLevels
 					levelStats = backgroundLevelStats
+					blockBackgroundSegments = append(blockBackgroundSegments, compaction.Segment{
+						Age:  s.Age,
+						Size: s.Size,
+						Type: segments.FSTType,
+					})
 				case index.FlushedSegment:
 					levels = flushedLevels
 					levelStats = flushedLevelStats
@@ -420,8 +520,20 @@ func (i *nsIndex) reportStats() error {
 		if err != nil {
 			return err
 		}
+
+		numSegments, numDocs, err := compactionDebt(blockBackgroundSegments, compactionOpts)
+		if err != nil {
+			return err
+		}
+		compactionDebtSegments += numSegments
+		compactionDebtDocs += numDocs
 	}
 
+	compactionMetrics := i.metrics.BlockMetrics.BackgroundCompaction
+	compactionMetrics.DebtSegments.Update(float64(compactionDebtSegments))
+	compactionMetrics.DebtDocs.Update(float64(compactionDebtDocs))
+	compactionMetrics.Running.Update(float64(i.blockOpts.BackgroundCompactionLimiter.Running()))
+
 	for _, elem := range []struct {
 		levels     []nsIndexBlocksSegmentsLevelMetrics
 		levelStats []nsIndexCompactionLevelStats
@@ -438,6 +550,28 @@ func (i *nsIndex) reportStats() error {
 	return nil
 }
 
+// compactionDebt returns the number of segments and documents of a block that
+// its background compactions have yet to compact.
+func compactionDebt(
+	segs []compaction.Segment,
+	opts compaction.PlannerOptions,
+) (int, int64, error) {
+	plan, err := compaction.NewPlan(segs, opts)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var (
+		numSegments int
+		numDocs     int64
+	)
+	for _, task := range plan.Tasks {
+		numSegments += len(task.Segments)
+		numDocs += task.Summary().CumulativeSize
+	}
+	return numSegments, numDocs, nil
+}
+
 func (i *nsIndex) BlockStartForWriteTime(writeTime time.Time) xtime.UnixNano {
 	return xtime.ToUnixNano(writeTime.Truncate(i.blockSize))
 }
@@ -474,6 +608,15 @@ func (i *nsIndex) WriteBatch(
 		batch.MarkUnmarkedEntriesError(err)
 		return err
 	}
+	if i.state.disabled {
+		i.state.RUnlock()
+		i.metrics.InsertWhileDisabled.Inc(1)
+		// NB: the series are finalized without being marked as indexed so
+		// they are indexed by their next write once indexing is enabled, the
+		// write itself still succeeds regardless of the insert mode.
+		batch.FinalizeUnmarkedEntries()
+		return nil
+	}
 
 	// NB(prateek): retrieving insertMode here while we have the RLock.
 	insertMode := i.state.runtimeOpts.insertMode
@@ -545,7 +688,11 @@ func (i *nsIndex) writeBatches(
 	// doc is valid. Add potential forward writes to the forwardWriteBatch.
 	batch.ForEach(
 		func(idx int, entry index.WriteBatchEntry,
-			d doc.Document, _ index.WriteBatchEntryResult) {
+			d doc.Document, result index.WriteBatchEntryResult) {
+			if result.Done {
+				// Already marked, i.e. deduplicated by the insert queue.
+				return
+			}
 			ts := entry.Timestamp
 			if !futureLimit.After(ts) {
 				batch.MarkUnmarkedEntryError(m3dberrors.ErrTooFuture, idx)
@@ -669,6 +816,22 @@ func (i *nsIndex) Bootstrap(
 	return multiErr.FinalError()
 }
 
+func (i *nsIndex) SetEnabled(value bool) {
+	i.state.Lock()
+	i.state.disabled = !value
+	i.state.Unlock()
+}
+
+func (i *nsIndex) UpdateRetentionOptions(ropts retention.Options) {
+	i.state.Lock()
+	i.retentionPeriod = ropts.RetentionPeriod()
+	i.futureRetentionPeriod = ropts.FutureRetentionPeriod()
+	i.bufferPast = ropts.BufferPast()
+	i.bufferFuture = ropts.BufferFuture()
+	i.expiredFileSetGracePeriod = ropts.ExpiredFileSetGracePeriod()
+	i.state.Unlock()
+}
+
 func (i *nsIndex) BootstrapsDone() uint {
 	i.state.RLock()
 	result := i.state.bootstrapsDone
@@ -677,18 +840,18 @@ func (i *nsIndex) BootstrapsDone() uint {
 }
 
 func (i *nsIndex) Tick(c context.Cancellable, tickStart time.Time) (namespaceIndexTickResult, error) {
-	var (
-		result                     = namespaceIndexTickResult{}
-		earliestBlockStartToRetain = retention.FlushTimeStartForRetentionPeriod(i.retentionPeriod, i.blockSize, tickStart)
-		lastSealableBlockStart     = retention.FlushTimeEndForBlockSize(i.blockSize, tickStart.Add(-i.bufferPast))
-	)
-
 	i.state.Lock()
 	defer func() {
 		i.updateBlockStartsWithLock()
 		i.state.Unlock()
 	}()
 
+	var (
+		result                     = namespaceIndexTickResult{}
+		earliestBlockStartToRetain = retention.FlushTimeStartForRetentionPeriod(i.retentionPeriod, i.blockSize, tickStart)
+		lastSealableBlockStart     = retention.FlushTimeEndForBlockSize(i.blockSize, tickStart.Add(-i.bufferPast))
+	)
+
 	result.NumBlocks = int64(len(i.state.blocksByTime))
 
 	var multiErr xerrors.MultiError
@@ -769,9 +932,262 @@ func (i *nsIndex) Flush(
 		}
 	}
 	i.metrics.BlocksEvictedMutableSegments.Inc(int64(evicted))
+
+	// Blocks loaded by queries since the last flush are evicted again.
+	i.evictColdBlocks(shards)
 	return nil
 }
 
+func (i *nsIndex) Snapshot(
+	shards []databaseShard,
+	snapshotTime time.Time,
+	flush persist.IndexFlush,
+) error {
+	i.state.RLock()
+	if !i.isOpenWithRLock() {
+		i.state.RUnlock()
+		return errDbIndexUnableToSnapshotClosed
+	}
+	blocks := make([]index.Block, 0, len(i.state.blocksByTime))
+	for _, block := range i.state.blocksByTime {
+		// Only blocks with data not yet flushed need snapshotting.
+		if !block.NeedsMutableSegmentsEvicted() {
+			continue
+		}
+		blocks = append(blocks, block)
+	}
+	i.state.RUnlock()
+
+	allShards := make(map[uint32]struct{}, len(shards))
+	for _, shard := range shards {
+		allShards[shard.ID()] = struct{}{}
+	}
+
+	var (
+		multiErr    xerrors.MultiError
+		snapshotted int
+	)
+	for _, block := range blocks {
+		ok, err := i.snapshotBlock(flush, block, allShards, snapshotTime)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		if ok {
+			snapshotted++
+		}
+	}
+	i.metrics.BlocksSnapshotted.Inc(int64(snapshotted))
+
+	if err := multiErr.FinalError(); err != nil {
+		// Keep the previous snapshots of blocks that failed to snapshot.
+		return err
+	}
+	return i.cleanupSnapshots(shards)
+}
+
+func (i *nsIndex) snapshotBlock(
+	flush persist.IndexFlush,
+	block index.Block,
+	shards map[uint32]struct{},
+	snapshotTime time.Time,
+) (bool, error) {
+	preparedPersist, err := flush.PrepareIndex(persist.IndexPrepareOptions{
+		NamespaceMetadata: i.nsMetadata,
+		BlockStart:        block.StartTime(),
+		FileSetType:       persist.FileSetSnapshotType,
+		Shards:            shards,
+		Snapshot: persist.DataPrepareSnapshotOptions{
+			SnapshotTime: snapshotTime,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	ok, err := block.SnapshotSegments(preparedPersist.Persist)
+	// NB: the segments returned by the prepared persist are only needed by
+	// flushes, snapshots are only read back when bootstrapping.
+	segs, closeErr := preparedPersist.Close()
+	for _, seg := range segs {
+		seg.Close()
+	}
+	if err != nil {
+		return false, err
+	}
+	return ok, closeErr
+}
+
+// cleanupSnapshots removes the index snapshots superseded by a later
+// snapshot of the same block, of blocks flushed for every owned shard
+// and of blocks out of retention.
+func (i *nsIndex) cleanupSnapshots(shards []databaseShard) error {
+	var (
+		nsID       = i.nsMetadata.ID()
+		pathPrefix = i.opts.CommitLogOptions().FilesystemOptions().NamespaceFilePathPrefix(nsID)
+	)
+	snapshotFiles, err := i.indexSnapshotFilesFn(pathPrefix, nsID)
+	if err != nil {
+		return err
+	}
+	if len(snapshotFiles) == 0 {
+		return nil
+	}
+
+	i.state.RLock()
+	earliestBlockStartToRetain := retention.FlushTimeStartForRetentionPeriod(
+		i.retentionPeriod, i.blockSize, i.nowFn())
+	i.state.RUnlock()
+
+	// NB: only the latest complete volume of each block start is kept.
+	latestVolumes := make(map[xtime.UnixNano]int, len(snapshotFiles))
+	for _, file := range snapshotFiles {
+		if !file.HasCompleteCheckpointFile() {
+			continue
+		}
+		blockStart := xtime.ToUnixNano(file.ID.BlockStart)
+		if volume, ok := latestVolumes[blockStart]; !ok || file.ID.VolumeIndex > volume {
+			latestVolumes[blockStart] = file.ID.VolumeIndex
+		}
+	}
+
+	var (
+		persisted = i.persistedIndexShards()
+		toDelete  []string
+	)
+	for _, file := range snapshotFiles {
+		blockStart := file.ID.BlockStart
+		latest, ok := latestVolumes[xtime.ToUnixNano(blockStart)]
+		if ok && latest <= file.ID.VolumeIndex &&
+			!blockStart.Before(earliestBlockStartToRetain) &&
+			!allShardsPersisted(persisted[xtime.ToUnixNano(blockStart)], shards) {
+			continue
+		}
+		toDelete = append(toDelete, file.AbsoluteFilepaths...)
+	}
+	if len(toDelete) == 0 {
+		return nil
+	}
+	return i.deleteFilesFn(toDelete)
+}
+
+func allShardsPersisted(
+	persisted map[uint32]struct{},
+	shards []databaseShard,
+) bool {
+	for _, shard := range shards {
+		if _, ok := persisted[shard.ID()]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// evictColdBlocks evicts the segments of the flushed blocks older than the
+// cold block age from memory, only once the index filesets of every shard
+// are on disk to load them back from.
+func (i *nsIndex) evictColdBlocks(shards []databaseShard) {
+	coldBlockAge := i.opts.IndexOptions().ColdBlockAge()
+	if coldBlockAge <= 0 || i.blockOpts.ColdSegmentsLoaderFn == nil {
+		return
+	}
+
+	now := i.nowFn()
+	i.state.RLock()
+	coldBlocks := make([]index.Block, 0, len(i.state.blocksByTime))
+	for _, block := range i.state.blocksByTime {
+		if block.EndTime().Add(coldBlockAge).After(now) || !block.IsSealed() ||
+			block.IsCold() || block.NeedsMutableSegmentsEvicted() {
+			continue
+		}
+		coldBlocks = append(coldBlocks, block)
+	}
+	i.state.RUnlock()
+	if len(coldBlocks) == 0 {
+		return
+	}
+
+	persisted := i.persistedIndexShards()
+	var evicted int
+	for _, block := range coldBlocks {
+		blockShards := persisted[xtime.ToUnixNano(block.StartTime())]
+		if !allShardsPersisted(blockShards, shards) {
+			continue
+		}
+
+		if err := block.EvictColdSegments(); err != nil {
+			i.logger.Warn("encountered error while evicting cold index block segments",
+				zap.Error(err),
+				zap.Time("blockStart", block.StartTime()),
+			)
+			continue
+		}
+		if block.IsCold() {
+			evicted++
+		}
+	}
+	i.metrics.BlocksEvictedColdSegments.Inc(int64(evicted))
+}
+
+// persistedIndexShards returns the shards of the index filesets on disk by
+// index block start.
+func (i *nsIndex) persistedIndexShards() map[xtime.UnixNano]map[uint32]struct{} {
+	var (
+		fsOpts    = i.opts.CommitLogOptions().FilesystemOptions()
+		infoFiles = i.readIndexInfoFilesFn(fsOpts.NamespaceFilePathPrefix(i.nsMetadata.ID()),
+			i.nsMetadata.ID(), fsOpts.InfoReaderBufferSize())
+		persisted = make(map[xtime.UnixNano]map[uint32]struct{})
+	)
+	for _, infoFile := range infoFiles {
+		if infoFile.Err.Error() != nil {
+			continue
+		}
+		blockStart := xtime.UnixNano(infoFile.Info.BlockStart)
+		shards, ok := persisted[blockStart]
+		if !ok {
+			shards = make(map[uint32]struct{})
+			persisted[blockStart] = shards
+		}
+		for _, shard := range infoFile.Info.Shards {
+			shards[shard] = struct{}{}
+		}
+	}
+	return persisted
+}
+
+// readColdBlockSegments reads the segments of every index fileset of a cold
+// block back from disk.
+func (i *nsIndex) readColdBlockSegments(blockStart time.Time) ([]segment.Segment, error) {
+	var (
+		fsOpts    = i.opts.CommitLogOptions().FilesystemOptions()
+		infoFiles = i.readIndexInfoFilesFn(fsOpts.NamespaceFilePathPrefix(i.nsMetadata.ID()),
+			i.nsMetadata.ID(), fsOpts.InfoReaderBufferSize())
+		segs []segment.Segment
+	)
+	for _, infoFile := range infoFiles {
+		if infoFile.Err.Error() != nil ||
+			!xtime.UnixNano(infoFile.Info.BlockStart).ToTime().Equal(blockStart) {
+			continue
+		}
+
+		fileSetSegments, err := i.readIndexSegmentsFn(fs.ReadIndexSegmentsOptions{
+			ReaderOptions: fs.IndexReaderOpenOptions{
+				Identifier:  infoFile.ID,
+				FileSetType: persist.FileSetFlushType,
+			},
+			FilesystemOptions: fsOpts,
+		})
+		if err != nil {
+			for _, seg := range segs {
+				seg.Close()
+			}
+			return nil, err
+		}
+		segs = append(segs, fileSetSegments...)
+	}
+	return segs, nil
+}
+
 func (i *nsIndex) flushableBlocks(
 	shards []databaseShard,
 ) ([]index.Block, error) {
@@ -951,14 +1367,17 @@ func (i *nsIndex) Query(
 		SizeLimit: opts.Limit,
 	})
 	ctx.RegisterFinalizer(results)
-	exhaustive, err := i.query(ctx, query, results, opts, i.execBlockQueryFn, logFields)
+	outcome, err := i.query(ctx, query, results, opts, i.execBlockQueryFn, logFields)
 	if err != nil {
 		sp.LogFields(opentracinglog.Error(err))
 		return index.QueryResult{}, err
 	}
 	return index.QueryResult{
-		Results:    results,
-		Exhaustive: exhaustive,
+		Results:     results,
+		Exhaustive:  outcome.exhaustive,
+		Cursor:      outcome.cursor,
+		LimitReason: outcome.limitReason,
+		Explain:     outcome.explain,
 	}, nil
 }
 
@@ -1001,16 +1420,320 @@ func (i *nsIndex) AggregateQuery(
 	}
 	aopts.FieldFilter = aopts.FieldFilter.SortAndDedupe()
 	results.Reset(i.nsMetadata.ID(), aopts)
-	exhaustive, err := i.query(ctx, query, results, opts.QueryOptions, fn, logFields)
+	outcome, err := i.query(ctx, query, results, opts.QueryOptions, fn, logFields)
 	if err != nil {
 		return index.AggregateQueryResult{}, err
 	}
 	return index.AggregateQueryResult{
-		Results:    results,
+		Results:     results,
+		Exhaustive:  outcome.exhaustive,
+		Cursor:      outcome.cursor,
+		LimitReason: outcome.limitReason,
+	}, nil
+}
+
+func (i *nsIndex) Completion(
+	ctx context.Context,
+	fieldPrefix []byte,
+	valuePrefix []byte,
+	opts index.CompletionOptions,
+) (index.CompletionResult, error) {
+	ctx, sp := ctx.StartTraceSpan(tracepoint.NSIdxCompletion)
+	sp.LogFields(
+		opentracinglog.String("fieldPrefix", string(fieldPrefix)),
+		opentracinglog.String("valuePrefix", string(valuePrefix)),
+		opentracinglog.String("namespace", i.nsMetadata.ID().String()),
+		opentracinglog.Int("limit", opts.Limit),
+		xopentracing.Time("queryStart", opts.StartInclusive),
+		xopentracing.Time("queryEnd", opts.EndExclusive),
+	)
+	defer sp.Finish()
+
+	i.state.RLock()
+	if !i.isOpenWithRLock() {
+		i.state.RUnlock()
+		return index.CompletionResult{}, errDbIndexUnableToQueryClosed
+	}
+
+	// Track this as an inflight query that needs to finish
+	// when the index is closed.
+	i.queriesWg.Add(1)
+	defer i.queriesWg.Done()
+
+	blocks, err := i.blocksForQueryWithRLock(xtime.NewRanges(xtime.Range{
+		Start: opts.StartInclusive,
+		End:   opts.EndExclusive,
+	}))
+	i.state.RUnlock()
+	if err != nil {
+		sp.LogFields(opentracinglog.Error(err))
+		return index.CompletionResult{}, err
+	}
+
+	cancellable := resource.NewCancellableLifetime()
+	defer cancellable.Cancel()
+
+	// NB: Completions only iterate the FSTs of the blocks which is cheap
+	// enough to query the blocks sequentially, newest first.
+	var (
+		results    = index.NewCompletionResults(fieldPrefix, valuePrefix, opts)
+		exhaustive = true
+	)
+	for _, block := range blocks {
+		if results.LimitExceeded() {
+			exhaustive = false
+			break
+		}
+		blockExhaustive, err := block.Completion(cancellable, results)
+		if err != nil {
+			sp.LogFields(opentracinglog.Error(err))
+			return index.CompletionResult{}, err
+		}
+		exhaustive = exhaustive && blockExhaustive
+	}
+
+	return index.CompletionResult{
+		Fields:     results.Fields(),
 		Exhaustive: exhaustive,
 	}, nil
 }
 
+func (i *nsIndex) AggregateQueryStream(
+	ctx context.Context,
+	query index.Query,
+	opts index.AggregationOptions,
+	fn index.AggregateStreamFn,
+) (index.AggregateStreamResult, error) {
+	logFields := []opentracinglog.Field{
+		opentracinglog.String("query", query.String()),
+		opentracinglog.String("namespace", i.nsMetadata.ID().String()),
+		opentracinglog.Int("limit", opts.Limit),
+		xopentracing.Time("queryStart", opts.StartInclusive),
+		xopentracing.Time("queryEnd", opts.EndExclusive),
+	}
+
+	ctx, sp := ctx.StartTraceSpan(tracepoint.NSIdxAggregateQueryStream)
+	sp.LogFields(logFields...)
+	defer sp.Finish()
+
+	// Only queries that can be aggregated purely from the FSTs are streamed,
+	// the fields and terms of the segments are merged in order so that they
+	// are distinct without holding them in memory.
+	aggOpts := index.AggregateResultsOptions{
+		FieldFilter: opts.FieldFilter,
+		Type:        opts.Type,
+	}
+	if field, isField := idx.FieldQuery(query.Query); isField {
+		aggOpts.FieldFilter = aggOpts.FieldFilter.AddIfMissing(field)
+	} else if !query.Equal(allQuery) {
+		err := xerrors.NewInvalidParamsError(errDbIndexAggregateStreamQuery)
+		sp.LogFields(opentracinglog.Error(err))
+		return index.AggregateStreamResult{}, err
+	}
+	aggOpts.FieldFilter = aggOpts.FieldFilter.SortAndDedupe()
+
+	result, err := i.aggregateQueryStream(ctx, opts.QueryOptions, aggOpts, fn)
+	if err != nil {
+		sp.LogFields(opentracinglog.Error(err))
+		return index.AggregateStreamResult{}, err
+	}
+	return result, nil
+}
+
+func (i *nsIndex) aggregateQueryStream(
+	ctx context.Context,
+	opts index.QueryOptions,
+	aggOpts index.AggregateResultsOptions,
+	fn index.AggregateStreamFn,
+) (index.AggregateStreamResult, error) {
+	// Capture start before needing to acquire lock.
+	start := i.nowFn()
+
+	i.state.RLock()
+	if !i.isOpenWithRLock() {
+		i.state.RUnlock()
+		return index.AggregateStreamResult{}, errDbIndexUnableToQueryClosed
+	}
+
+	// Track this as an inflight query that needs to finish
+	// when the index is closed.
+	i.queriesWg.Add(1)
+	defer i.queriesWg.Done()
+
+	// NB: The max query limit is not enforced since the streamed fields and
+	// terms are not held in memory.
+	timeout := i.timeoutForQueryWithRLock(ctx)
+	blocks, err := i.blocksForQueryWithRLock(xtime.NewRanges(xtime.Range{
+		Start: opts.StartInclusive,
+		End:   opts.EndExclusive,
+	}))
+	i.state.RUnlock()
+	if err != nil {
+		return index.AggregateStreamResult{}, err
+	}
+
+	if !opts.ResourceLimits.IsZero() {
+		opts.ResourceTracker = index.NewQueryResourceTracker(opts.ResourceLimits,
+			start, i.nowFn)
+	}
+
+	iters := make([]index.AggregateIterator, 0, len(blocks))
+	for _, block := range blocks {
+		blockIter, err := block.AggregateIter(opts, aggOpts)
+		if err == index.ErrUnableToQueryBlockClosed {
+			// The block expired since it was retrieved, same as for queries.
+			continue
+		}
+		if err != nil {
+			for _, blockIter := range iters {
+				blockIter.Close()
+			}
+			return index.AggregateStreamResult{}, err
+		}
+		iters = append(iters, blockIter)
+	}
+
+	// NB: The blocks are read locked until the iterator is closed.
+	iter := index.NewMergedAggregateIterator(iters)
+	defer iter.Close()
+
+	// The caller can cancel the query through the Go context, a nil channel
+	// never fires.
+	var cancelledCh <-chan struct{}
+	if goCtx, ok := ctx.GoContext(); ok {
+		cancelledCh = goCtx.Done()
+	}
+
+	var (
+		deadline = start.Add(timeout)
+		streamed int
+		limited  bool
+	)
+	for iter.Next() {
+		if streamed%aggregateStreamCheckEvery == 0 {
+			select {
+			case <-cancelledCh:
+				return index.AggregateStreamResult{}, errDbIndexQueryCancelled
+			default:
+			}
+			if timeout > 0 && !i.nowFn().Before(deadline) {
+				return index.AggregateStreamResult{},
+					fmt.Errorf("index query timed out: %s", timeout.String())
+			}
+		}
+
+		if opts.LimitExceeded(streamed) {
+			limited = true
+			break
+		}
+
+		field, term := iter.Current()
+		if !opts.ResourceTracker.TrackTerm(field, term) {
+			break
+		}
+		if err := fn(field, term); err != nil {
+			return index.AggregateStreamResult{}, err
+		}
+		streamed++
+	}
+	if err := iter.Err(); err != nil {
+		return index.AggregateStreamResult{}, err
+	}
+	if err := iter.Close(); err != nil {
+		return index.AggregateStreamResult{}, err
+	}
+
+	result := index.AggregateStreamResult{
+		LimitReason: opts.ResourceTracker.Exceeded(),
+	}
+	if limited && result.LimitReason == index.QueryLimitNone {
+		result.LimitReason = index.QueryLimitSeries
+	}
+	result.Exhaustive = result.LimitReason == index.QueryLimitNone
+	return result, nil
+}
+
+func (i *nsIndex) FieldCardinality(
+	start, end time.Time,
+) ([]index.BlockFieldCardinality, error) {
+	if i.opts.IndexOptions().FieldCardinalityTopN() <= 0 {
+		return nil, xerrors.NewInvalidParamsError(errDbIndexFieldCardinalityDisabled)
+	}
+
+	i.state.RLock()
+	if !i.isOpenWithRLock() {
+		i.state.RUnlock()
+		return nil, errDbIndexUnableToReportFieldsClosed
+	}
+	blocks, err := i.blocksForQueryWithRLock(xtime.NewRanges(xtime.Range{
+		Start: start,
+		End:   end,
+	}))
+	i.state.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]index.BlockFieldCardinality, 0, len(blocks))
+	for _, block := range blocks {
+		fields, err := block.FieldCardinality()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, index.BlockFieldCardinality{
+			BlockStart: block.StartTime(),
+			Fields:     fields,
+		})
+	}
+	return result, nil
+}
+
+func (i *nsIndex) BlockStates() ([]index.BlockState, error) {
+	i.state.RLock()
+	if !i.isOpenWithRLock() {
+		i.state.RUnlock()
+		return nil, errDbIndexUnableToReportStateClosed
+	}
+	blocks := make([]index.Block, 0, len(i.state.blockStartsDescOrder))
+	for _, start := range i.state.blockStartsDescOrder {
+		block, ok := i.state.blocksByTime[start]
+		if !ok {
+			i.state.RUnlock()
+			return nil, i.missingBlockInvariantError(start)
+		}
+		blocks = append(blocks, block)
+	}
+	i.state.RUnlock()
+
+	var (
+		fsOpts    = i.opts.CommitLogOptions().FilesystemOptions()
+		infoFiles = i.readIndexInfoFilesFn(fsOpts.NamespaceFilePathPrefix(i.nsMetadata.ID()),
+			i.nsMetadata.ID(), fsOpts.InfoReaderBufferSize())
+		volumes = make(map[xtime.UnixNano][]index.BlockFlushedVolume, len(infoFiles))
+	)
+	for _, infoFile := range infoFiles {
+		if infoFile.Err.Error() != nil {
+			continue
+		}
+		blockStart := xtime.UnixNano(infoFile.Info.BlockStart)
+		volumes[blockStart] = append(volumes[blockStart], index.BlockFlushedVolume{
+			VolumeIndex: infoFile.ID.VolumeIndex,
+			Shards:      infoFile.Info.Shards,
+		})
+	}
+
+	result := make([]index.BlockState, 0, len(blocks))
+	for _, block := range blocks {
+		state, err := block.State()
+		if err != nil {
+			return nil, err
+		}
+		state.FlushedVolumes = volumes[xtime.ToUnixNano(block.StartTime())]
+		result = append(result, state)
+	}
+	return result, nil
+}
+
 func (i *nsIndex) query(
 	ctx context.Context,
 	query index.Query,
@@ -1018,17 +1741,51 @@ func (i *nsIndex) query(
 	opts index.QueryOptions,
 	execBlockFn execBlockQueryFn,
 	logFields []opentracinglog.Field,
-) (bool, error) {
+) (indexQueryOutcome, error) {
 	ctx, sp := ctx.StartTraceSpan(tracepoint.NSIdxQueryHelper)
 	sp.LogFields(logFields...)
 	defer sp.Finish()
 
-	exhaustive, err := i.queryWithSpan(ctx, query, results, opts, execBlockFn, sp, logFields)
+	if !opts.ResourceLimits.IsZero() {
+		opts.ResourceTracker = index.NewQueryResourceTracker(opts.ResourceLimits,
+			i.nowFn(), i.nowFn)
+	}
+	if opts.Explain {
+		opts.ExplainTracker = index.NewQueryExplainTracker(i.nowFn())
+	}
+
+	var (
+		outcome indexQueryOutcome
+		err     error
+	)
+	if opts.Paginate {
+		outcome.exhaustive, outcome.cursor, err = i.queryPage(ctx, query, results,
+			opts, execBlockFn, logFields)
+	} else {
+		outcome.exhaustive, err = i.queryWithSpan(ctx, query, results, opts,
+			execBlockFn, sp, logFields)
+	}
 	if err != nil {
 		sp.LogFields(opentracinglog.Error(err))
+		return indexQueryOutcome{}, err
 	}
 
-	return exhaustive, err
+	// Results that are not exhaustive without exceeding a resource limit
+	// exceeded the limit of the series matched.
+	outcome.limitReason = opts.ResourceTracker.Exceeded()
+	if !outcome.exhaustive && outcome.limitReason == index.QueryLimitNone {
+		outcome.limitReason = index.QueryLimitSeries
+	}
+	outcome.explain = opts.ExplainTracker.Explain(i.nowFn())
+	return outcome, nil
+}
+
+// indexQueryOutcome is the outcome of the execution of a query.
+type indexQueryOutcome struct {
+	exhaustive  bool
+	cursor      []byte
+	limitReason index.QueryLimitReason
+	explain     *index.QueryExplain
 }
 
 func (i *nsIndex) queryWithSpan(
@@ -1087,10 +1844,23 @@ func (i *nsIndex) queryWithSpan(
 	cancellable := resource.NewCancellableLifetime()
 	defer cancellable.Cancel()
 
+	// The caller can cancel the query through the Go context, e.g. when the
+	// query is killed by ID or the RPC is abandoned, a nil channel never fires.
+	var cancelledCh <-chan struct{}
+	if goCtx, ok := ctx.GoContext(); ok {
+		cancelledCh = goCtx.Done()
+	}
+
 	for _, block := range blocks {
 		// Capture block for async query execution below.
 		block := block
 
+		select {
+		case <-cancelledCh:
+			return false, errDbIndexQueryCancelled
+		default:
+		}
+
 		// We're looping through all the blocks that we need to query and kicking
 		// off parallel queries which are bounded by the queryWorkersPool's maximum
 		// concurrency. This means that it's possible at this point that we've
@@ -1099,7 +1869,8 @@ func (i *nsIndex) queryWithSpan(
 		// is no value in kicking off more parallel queries, so we break out of
 		// the loop.
 		size := results.Size()
-		alreadyExceededLimit := opts.LimitExceeded(size)
+		alreadyExceededLimit := opts.LimitExceeded(size) ||
+			!opts.ResourceTracker.CheckDuration()
 		if alreadyExceededLimit {
 			state.Lock()
 			state.exhaustive = false
@@ -1142,36 +1913,35 @@ func (i *nsIndex) queryWithSpan(
 	}
 
 	// Wait for queries to finish.
-	if !(timeout > 0) {
-		// No timeout, just blockingly wait.
+	if !(timeout > 0) && cancelledCh == nil {
+		// No timeout and not cancellable, just blockingly wait.
 		wg.Wait()
 	} else {
-		// Need to abort early if timeout hit.
-		timeLeft := deadline.Sub(i.nowFn())
-		if timeLeft <= 0 {
-			return false, fmt.Errorf("index query timed out: %s", timeout.String())
+		// Need to abort early if timeout hit or the query is cancelled.
+		var timeoutCh <-chan time.Time
+		if timeout > 0 {
+			timeLeft := deadline.Sub(i.nowFn())
+			if timeLeft <= 0 {
+				return false, fmt.Errorf("index query timed out: %s", timeout.String())
+			}
+
+			ticker := time.NewTicker(timeLeft)
+			// Make sure to always free the timer/ticker so they don't sit around.
+			defer ticker.Stop()
+			timeoutCh = ticker.C
 		}
 
-		var (
-			ticker  = time.NewTicker(timeLeft)
-			doneCh  = make(chan struct{})
-			aborted bool
-		)
+		doneCh := make(chan struct{})
 		go func() {
 			wg.Wait()
 			close(doneCh)
 		}()
 		select {
-		case <-ticker.C:
-			aborted = true
-		case <-doneCh:
-		}
-
-		// Make sure to always free the timer/ticker so they don't sit around.
-		ticker.Stop()
-
-		if aborted {
+		case <-timeoutCh:
 			return false, fmt.Errorf("index query timed out: %s", timeout.String())
+		case <-cancelledCh:
+			return false, errDbIndexQueryCancelled
+		case <-doneCh:
 		}
 	}
 
@@ -1375,7 +2145,7 @@ func (i *nsIndex) ensureBlockPresentWithRLock(blockStart time.Time) (index.Block
 
 	// ok now we know for sure we have to alloc
 	block, err := i.newBlockFn(blockStart, i.nsMetadata,
-		index.BlockOptions{}, i.opts.IndexOptions())
+		i.blockOpts, i.opts.IndexOptions())
 	if err != nil { // unable to allocate the block, should never happen.
 		return nil, i.unableToAllocBlockInvariantError(err)
 	}
@@ -1417,6 +2187,42 @@ func (i *nsIndex) isOpenWithRLock() bool {
 	return !i.state.closed
 }
 
+func (i *nsIndex) MarkDeleted(ids []ident.ID) error {
+	i.state.RLock()
+	defer i.state.RUnlock()
+	if i.state.closed {
+		return errDbIndexUnableToMarkDeletedClosed
+	}
+
+	// NB: A series may be indexed in any of the blocks so mark it deleted in
+	// all of them.
+	var multiErr xerrors.MultiError
+	for _, block := range i.state.blocksByTime {
+		multiErr = multiErr.Add(block.MarkDeleted(ids))
+	}
+	// Ensure the series are indexed again if they are written to.
+	i.state.insertQueue.ResetRecentlyIndexed()
+	return multiErr.FinalError()
+}
+
+func (i *nsIndex) MarkExpired(ids []ident.ID) error {
+	i.state.RLock()
+	defer i.state.RUnlock()
+	if i.state.closed {
+		return errDbIndexUnableToMarkExpiredClosed
+	}
+
+	// NB: The series have no data within retention so they are expired in
+	// all of the blocks, the blocks they are written to again unmark them.
+	var multiErr xerrors.MultiError
+	for _, block := range i.state.blocksByTime {
+		multiErr = multiErr.Add(block.MarkExpired(ids))
+	}
+	// Ensure the series are indexed again if they are written to.
+	i.state.insertQueue.ResetRecentlyIndexed()
+	return multiErr.FinalError()
+}
+
 func (i *nsIndex) CleanupExpiredFileSets(t time.Time) error {
 	// we only expire data on drive that we don't hold a reference to, and is
 	// past the expiration period. the earliest data we have to retain is given
@@ -1428,8 +2234,10 @@ func (i *nsIndex) CleanupExpiredFileSets(t time.Time) error {
 		return errDbIndexUnableToCleanupClosed
 	}
 
-	// earliest block to retain based on retention period
-	earliestBlockStartToRetain := retention.FlushTimeStartForRetentionPeriod(i.retentionPeriod, i.blockSize, t)
+	// earliest block to retain based on retention period, filesets that
+	// expired within the grace period are kept so they can be undeleted
+	earliestBlockStartToRetain := retention.FlushTimeStartForRetentionPeriod(
+		i.retentionPeriod+i.expiredFileSetGracePeriod, i.blockSize, t)
 
 	// now we loop through the blocks we hold, to ensure we don't delete any data for them.
 	for t := range i.state.blocksByTime {
@@ -1440,8 +2248,8 @@ func (i *nsIndex) CleanupExpiredFileSets(t time.Time) error {
 
 	// know the earliest block to retain, find all blocks earlier than it
 	var (
-		pathPrefix = i.opts.CommitLogOptions().FilesystemOptions().FilePathPrefix()
 		nsID       = i.nsMetadata.ID()
+		pathPrefix = i.opts.CommitLogOptions().FilesystemOptions().NamespaceFilePathPrefix(nsID)
 	)
 	filesets, err := i.indexFilesetsBeforeFn(pathPrefix, nsID, earliestBlockStartToRetain)
 	if err != nil {
@@ -1515,9 +2323,12 @@ type nsIndexMetrics struct {
 	AsyncInsertSuccess           tally.Counter
 	AsyncInsertErrors            tally.Counter
 	InsertAfterClose             tally.Counter
+	InsertWhileDisabled          tally.Counter
 	QueryAfterClose              tally.Counter
 	InsertEndToEndLatency        tally.Timer
 	BlocksEvictedMutableSegments tally.Counter
+	BlocksEvictedColdSegments    tally.Counter
+	BlocksSnapshotted            tally.Counter
 	BlockMetrics                 nsIndexBlocksMetrics
 }
 
@@ -1535,6 +2346,9 @@ func newNamespaceIndexMetrics(
 		InsertAfterClose: scope.Tagged(map[string]string{
 			"error_type": "insert-closed",
 		}).Counter("insert-after-close"),
+		InsertWhileDisabled: scope.Tagged(map[string]string{
+			"error_type": "insert-disabled",
+		}).Counter("insert-while-disabled"),
 		QueryAfterClose: scope.Tagged(map[string]string{
 			"error_type": "query-closed",
 		}).Counter("query-after-error"),
@@ -1542,14 +2356,23 @@ func newNamespaceIndexMetrics(
 			scope.Timer("insert-end-to-end-latency"),
 			iopts.MetricsSamplingRate()),
 		BlocksEvictedMutableSegments: scope.Counter("blocks-evicted-mutable-segments"),
+		BlocksEvictedColdSegments:    scope.Counter("blocks-evicted-cold-segments"),
+		BlocksSnapshotted:            scope.Counter("blocks-snapshotted"),
 		BlockMetrics:                 newNamespaceIndexBlocksMetrics(opts, blocksScope),
 	}
 }
 
 type nsIndexBlocksMetrics struct {
-	ForegroundSegments nsIndexBlocksSegmentsMetrics
-	BackgroundSegments nsIndexBlocksSegmentsMetrics
-	FlushedSegments    nsIndexBlocksSegmentsMetrics
+	ForegroundSegments   nsIndexBlocksSegmentsMetrics
+	BackgroundSegments   nsIndexBlocksSegmentsMetrics
+	FlushedSegments      nsIndexBlocksSegmentsMetrics
+	BackgroundCompaction nsIndexBlocksCompactionMetrics
+}
+
+type nsIndexBlocksCompactionMetrics struct {
+	DebtSegments tally.Gauge
+	DebtDocs     tally.Gauge
+	Running      tally.Gauge
 }
 
 func newNamespaceIndexBlocksMetrics(
@@ -1572,6 +2395,20 @@ func newNamespaceIndexBlocksMetrics(
 			scope.Tagged(map[string]string{
 				"segment-type": "flushed",
 			})),
+		BackgroundCompaction: newNamespaceIndexBlocksCompactionMetrics(
+			scope.Tagged(map[string]string{
+				"compaction-type": "background",
+			})),
+	}
+}
+
+func newNamespaceIndexBlocksCompactionMetrics(
+	scope tally.Scope,
+) nsIndexBlocksCompactionMetrics {
+	return nsIndexBlocksCompactionMetrics{
+		DebtSegments: scope.Gauge("compaction-debt-segments"),
+		DebtDocs:     scope.Gauge("compaction-debt-docs"),
+		Running:      scope.Gauge("compactions-running"),
 	}
 }
 
@@ -0,0 +1,226 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package index
+
+import (
+	"bytes"
+	"container/heap"
+	"sort"
+
+	xerrors "github.com/m3db/m3/src/x/errors"
+)
+
+// NewMergedAggregateIterator returns an iterator over the distinct fields and
+// terms of iterators that each iterate in order, it takes ownership of the
+// iterators and closes them when closed.
+func NewMergedAggregateIterator(iters []AggregateIterator) AggregateIterator {
+	return &mergedAggregateIter{
+		iters: iters,
+	}
+}
+
+type mergedAggregateIter struct {
+	iters   []AggregateIterator
+	heap    aggregateIterHeap
+	started bool
+
+	// current is a copy of the current field and term since the iterator it
+	// came from is advanced past it to skip its duplicates.
+	current struct {
+		field []byte
+		term  []byte
+		valid bool
+	}
+
+	err    error
+	closed bool
+}
+
+func (it *mergedAggregateIter) Next() bool {
+	if it.err != nil || it.closed {
+		return false
+	}
+
+	if !it.started {
+		it.started = true
+		for _, iter := range it.iters {
+			if !it.advance(iter) {
+				if it.err != nil {
+					return false
+				}
+				continue
+			}
+			it.heap = append(it.heap, iter)
+		}
+		heap.Init(&it.heap)
+	}
+
+	for len(it.heap) > 0 {
+		top := it.heap[0]
+		field, term := top.Current()
+		duplicate := it.current.valid &&
+			bytes.Equal(field, it.current.field) &&
+			bytes.Equal(term, it.current.term)
+		if !duplicate {
+			it.current.field = append(it.current.field[:0], field...)
+			it.current.term = append(it.current.term[:0], term...)
+			it.current.valid = true
+		}
+
+		if it.advance(top) {
+			heap.Fix(&it.heap, 0)
+		} else if it.err != nil {
+			return false
+		} else {
+			heap.Pop(&it.heap)
+		}
+
+		if !duplicate {
+			return true
+		}
+	}
+	return false
+}
+
+// advance moves the iterator to its next element returning false once it is
+// exhausted, setting the error if it failed.
+func (it *mergedAggregateIter) advance(iter AggregateIterator) bool {
+	if iter.Next() {
+		return true
+	}
+	it.err = iter.Err()
+	return false
+}
+
+func (it *mergedAggregateIter) Current() (field, term []byte) {
+	if len(it.current.term) == 0 {
+		// Fields only iterators have no terms.
+		return it.current.field, nil
+	}
+	return it.current.field, it.current.term
+}
+
+func (it *mergedAggregateIter) Err() error {
+	return it.err
+}
+
+func (it *mergedAggregateIter) Close() error {
+	if it.closed {
+		return nil
+	}
+	it.closed = true
+
+	var multiErr xerrors.MultiError
+	for _, iter := range it.iters {
+		multiErr = multiErr.Add(iter.Close())
+	}
+	it.iters = nil
+	it.heap = nil
+	return multiErr.FinalError()
+}
+
+// aggregateIterHeap is a min heap of iterators ordered by their current field
+// and term.
+type aggregateIterHeap []AggregateIterator
+
+func (h aggregateIterHeap) Len() int { return len(h) }
+
+func (h aggregateIterHeap) Less(i, j int) bool {
+	fieldI, termI := h[i].Current()
+	fieldJ, termJ := h[j].Current()
+	return compareFieldAndTerm(fieldI, termI, fieldJ, termJ) < 0
+}
+
+func (h aggregateIterHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *aggregateIterHeap) Push(x interface{}) {
+	*h = append(*h, x.(AggregateIterator))
+}
+
+func (h *aggregateIterHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return x
+}
+
+func compareFieldAndTerm(fieldA, termA, fieldB, termB []byte) int {
+	if c := bytes.Compare(fieldA, fieldB); c != 0 {
+		return c
+	}
+	return bytes.Compare(termA, termB)
+}
+
+type fieldAndTerm struct {
+	field string
+	term  string
+}
+
+// sliceAggregateIter iterates over fields and terms held in memory.
+type sliceAggregateIter struct {
+	entries []fieldAndTerm
+	idx     int
+}
+
+// newSliceAggregateIter returns an iterator over the distinct fields and
+// terms in order.
+func newSliceAggregateIter(set map[fieldAndTerm]struct{}) *sliceAggregateIter {
+	entries := make([]fieldAndTerm, 0, len(set))
+	for entry := range set {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].field != entries[j].field {
+			return entries[i].field < entries[j].field
+		}
+		return entries[i].term < entries[j].term
+	})
+	return &sliceAggregateIter{
+		entries: entries,
+		idx:     -1,
+	}
+}
+
+func (it *sliceAggregateIter) Next() bool {
+	if it.idx >= len(it.entries) {
+		return false
+	}
+	it.idx++
+	return it.idx < len(it.entries)
+}
+
+func (it *sliceAggregateIter) Current() (field, term []byte) {
+	entry := it.entries[it.idx]
+	if entry.term == "" {
+		return []byte(entry.field), nil
+	}
+	return []byte(entry.field), []byte(entry.term)
+}
+
+func (it *sliceAggregateIter) Err() error {
+	return nil
+}
+
+func (it *sliceAggregateIter) Close() error {
+	it.entries = nil
+	return nil
+}
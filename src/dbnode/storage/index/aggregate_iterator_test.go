@@ -0,0 +1,113 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package index
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSliceAggregateIter(entries ...fieldAndTerm) *sliceAggregateIter {
+	set := make(map[fieldAndTerm]struct{}, len(entries))
+	for _, entry := range entries {
+		set[entry] = struct{}{}
+	}
+	return newSliceAggregateIter(set)
+}
+
+func collectAggregateIter(t *testing.T, iter AggregateIterator) []fieldAndTerm {
+	var result []fieldAndTerm
+	for iter.Next() {
+		field, term := iter.Current()
+		result = append(result, fieldAndTerm{field: string(field), term: string(term)})
+	}
+	require.NoError(t, iter.Err())
+	require.NoError(t, iter.Close())
+	return result
+}
+
+func TestMergedAggregateIterator(t *testing.T) {
+	iter := NewMergedAggregateIterator([]AggregateIterator{
+		newTestSliceAggregateIter(
+			fieldAndTerm{field: "a", term: "1"},
+			fieldAndTerm{field: "b", term: "2"},
+		),
+		newTestSliceAggregateIter(),
+		newTestSliceAggregateIter(
+			fieldAndTerm{field: "a", term: "1"},
+			fieldAndTerm{field: "a", term: "2"},
+			fieldAndTerm{field: "c", term: "1"},
+		),
+		newTestSliceAggregateIter(
+			fieldAndTerm{field: "b", term: "2"},
+		),
+	})
+
+	require.Equal(t, []fieldAndTerm{
+		{field: "a", term: "1"},
+		{field: "a", term: "2"},
+		{field: "b", term: "2"},
+		{field: "c", term: "1"},
+	}, collectAggregateIter(t, iter))
+}
+
+func TestMergedAggregateIteratorFieldsOnly(t *testing.T) {
+	iter := NewMergedAggregateIterator([]AggregateIterator{
+		newTestSliceAggregateIter(fieldAndTerm{field: "b"}),
+		newTestSliceAggregateIter(fieldAndTerm{field: "a"}, fieldAndTerm{field: "b"}),
+	})
+
+	var fields []string
+	for iter.Next() {
+		field, term := iter.Current()
+		require.Nil(t, term)
+		fields = append(fields, string(field))
+	}
+	require.NoError(t, iter.Err())
+	require.NoError(t, iter.Close())
+	require.Equal(t, []string{"a", "b"}, fields)
+}
+
+type testErrAggregateIter struct {
+	sliceAggregateIter
+	err error
+}
+
+func (it *testErrAggregateIter) Next() bool {
+	return false
+}
+
+func (it *testErrAggregateIter) Err() error {
+	return it.err
+}
+
+func TestMergedAggregateIteratorError(t *testing.T) {
+	expectedErr := errors.New("an error")
+	iter := NewMergedAggregateIterator([]AggregateIterator{
+		newTestSliceAggregateIter(fieldAndTerm{field: "a", term: "1"}),
+		&testErrAggregateIter{err: expectedErr},
+	})
+
+	require.False(t, iter.Next())
+	require.Equal(t, expectedErr, iter.Err())
+	require.NoError(t, iter.Close())
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/index/segments"
 	"github.com/m3db/m3/src/dbnode/tracepoint"
 	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/idx"
 	m3ninxindex "github.com/m3db/m3/src/m3ninx/index"
 	"github.com/m3db/m3/src/m3ninx/index/segment"
 	"github.com/m3db/m3/src/m3ninx/index/segment/builder"
@@ -44,6 +45,7 @@ import (
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/resource"
+	xsync "github.com/m3db/m3/src/x/sync"
 	xtime "github.com/m3db/m3/src/x/time"
 
 	"github.com/opentracing/opentracing-go"
@@ -68,6 +70,14 @@ var (
 	errForegroundCompactorBadPlanFirstTask     = errors.New("index foreground compactor generated plan without mutable segment in first task")
 	errForegroundCompactorBadPlanSecondaryTask = errors.New("index foreground compactor generated plan with mutable segment a secondary task")
 	errCancelledQuery                          = errors.New("query was cancelled")
+	errUnableToMarkDeletedBlockClosed          = errors.New("unable to mark series deleted, block is closed")
+	errUnableToMarkExpiredBlockClosed          = errors.New("unable to mark series expired, block is closed")
+	errUnableToEvictColdBlockNoLoader          = errors.New("unable to evict cold block segments, no cold segments loader")
+	errUnableToSnapshotBlockClosed             = errors.New("unable to snapshot, index block is closed")
+	errUnableToReportFieldCardinalityClosed    = errors.New("unable to report field cardinality, index block is closed")
+	errUnableToReportStateBlockClosed          = errors.New("unable to report state, index block is closed")
+
+	allQuery = Query{Query: idx.NewAllQuery()}
 
 	errUnableToSealBlockIllegalStateFmtString  = "unable to seal, index block state: %v"
 	errUnableToWriteBlockUnknownStateFmtString = "unable to write, unknown index block state: %v"
@@ -111,6 +121,22 @@ type block struct {
 	backgroundSegments  []*readableSeg
 	shardRangesSegments []blockShardRangesSegments
 
+	// deleted is the set of IDs of the series in the block that have been
+	// deleted, it is keyed by series ID rather than by postings ID since the
+	// postings IDs of a document change as its segment is compacted.
+	deleted map[string]struct{}
+	// expired is the set of IDs of the series in the block that no longer
+	// have any data within retention, keyed the same way as deleted.
+	expired map[string]struct{}
+
+	// cold is set while the flushed segments of the block are evicted from
+	// memory, coldShardTimeRanges are the ranges that they fulfilled.
+	cold                bool
+	coldShardTimeRanges result.ShardTimeRanges
+	// coldFieldCardinality is the cardinality of the fields of the evicted
+	// segments, it is kept so that it is not computed again on load.
+	coldFieldCardinality []segmentFieldCardinality
+
 	newFieldsAndTermsIteratorFn newFieldsAndTermsIteratorFn
 	newExecutorFn               newExecutorFn
 	blockStart                  time.Time
@@ -135,6 +161,11 @@ type blockMetrics struct {
 	foregroundCompactionTaskRunLatency tally.Timer
 	backgroundCompactionPlanRunLatency tally.Timer
 	backgroundCompactionTaskRunLatency tally.Timer
+	backgroundCompactionDeferred       tally.Counter
+	coldSegmentsEvicted                tally.Counter
+	coldSegmentsLoaded                 tally.Counter
+	coldSegmentsLoadLatency            tally.Timer
+	segmentsSnapshotted                tally.Counter
 }
 
 func newBlockMetrics(s tally.Scope) blockMetrics {
@@ -150,6 +181,11 @@ func newBlockMetrics(s tally.Scope) blockMetrics {
 		foregroundCompactionTaskRunLatency: foregroundScope.Timer("compaction-task-run-latency"),
 		backgroundCompactionPlanRunLatency: backgroundScope.Timer("compaction-plan-run-latency"),
 		backgroundCompactionTaskRunLatency: backgroundScope.Timer("compaction-task-run-latency"),
+		backgroundCompactionDeferred:       backgroundScope.Counter("compaction-deferred"),
+		coldSegmentsEvicted:                s.Counter("cold-segments-evicted"),
+		coldSegmentsLoaded:                 s.Counter("cold-segments-loaded"),
+		coldSegmentsLoadLatency:            s.Timer("cold-segments-load-latency"),
+		segmentsSnapshotted:                s.Counter("segments-snapshotted"),
 	}
 }
 
@@ -159,14 +195,43 @@ func newBlockMetrics(s tally.Scope) blockMetrics {
 type blockShardRangesSegments struct {
 	shardTimeRanges result.ShardTimeRanges
 	segments        []segment.Segment
+	// snapshotted is whether the segments were bootstrapped from an index
+	// snapshot and are yet to be flushed.
+	snapshotted bool
+	// fieldCardinality and mutableFieldCardinality are the cardinality of the
+	// fields of the immutable and mutable segments if computed as they were
+	// added, the latter is dropped when the mutable segments are evicted.
+	fieldCardinality        []segmentFieldCardinality
+	mutableFieldCardinality []segmentFieldCardinality
 }
 
 // BlockOptions is a set of options used when constructing an index block.
 type BlockOptions struct {
 	ForegroundCompactorMmapDocsData bool
 	BackgroundCompactorMmapDocsData bool
+
+	// BackgroundCompactionPausedFn if set is checked before kicking off a
+	// background compaction, no background compactions are started while it
+	// returns true.
+	BackgroundCompactionPausedFn func() bool
+
+	// BackgroundCompactionLimiter if set limits the number of blocks that are
+	// background compacted concurrently.
+	BackgroundCompactionLimiter *BackgroundCompactionLimiter
+
+	// ColdSegmentsLoaderFn if set loads the persisted segments of a cold
+	// block from disk, blocks can only be made cold when it is set.
+	ColdSegmentsLoaderFn ColdSegmentsLoaderFn
+
+	// QuerySegmentsWorkerPool if set searches the segments of the block
+	// concurrently using the workers of the pool that are available.
+	QuerySegmentsWorkerPool xsync.WorkerPool
 }
 
+// ColdSegmentsLoaderFn loads the persisted segments of the index block with
+// the given start.
+type ColdSegmentsLoaderFn func(blockStart time.Time) ([]segment.Segment, error)
+
 // NewBlock returns a new Block, representing a complete reverse index for the
 // duration of time specified. It is backed by one or more segments.
 func NewBlock(
@@ -182,6 +247,7 @@ func NewBlock(
 		blockStart: blockStart,
 		blockEnd:   blockStart.Add(blockSize),
 		blockSize:  blockSize,
+		blockOpts:  opts,
 		opts:       indexOpts,
 		iopts:      iopts,
 		nsMD:       md,
@@ -207,6 +273,12 @@ func (b *block) maybeBackgroundCompactWithLock() {
 		return
 	}
 
+	if fn := b.blockOpts.BackgroundCompactionPausedFn; fn != nil && fn() {
+		// Segments accumulate until compactions are resumed, the next
+		// foreground compaction once resumed will kick off a compaction.
+		return
+	}
+
 	// Create a logical plan.
 	segs := make([]compaction.Segment, 0, len(b.backgroundSegments))
 	for _, seg := range b.backgroundSegments {
@@ -230,10 +302,19 @@ func (b *block) maybeBackgroundCompactWithLock() {
 		return
 	}
 
+	limiter := b.blockOpts.BackgroundCompactionLimiter
+	if !limiter.TryAcquire() {
+		// Too many blocks are being compacted, the next foreground
+		// compaction will kick off a compaction once one has finished.
+		b.metrics.backgroundCompactionDeferred.Inc(1)
+		return
+	}
+
 	// Kick off compaction.
 	b.compact.compactingBackground = true
 	go func() {
 		b.backgroundCompactWithPlan(plan)
+		limiter.Release()
 
 		b.Lock()
 		b.compact.compactingBackground = false
@@ -356,12 +437,14 @@ func (b *block) backgroundCompactWithTask(
 		return err
 	}
 
+	fieldCardinality := b.segmentFieldCardinality(compacted)
+
 	// Rotate out the replaced frozen segments and add the compacted one.
 	b.Lock()
 	defer b.Unlock()
 
 	result := b.addCompactedSegmentFromSegments(b.backgroundSegments,
-		segments, compacted)
+		segments, compacted, fieldCardinality)
 	b.backgroundSegments = result
 
 	return nil
@@ -371,6 +454,7 @@ func (b *block) addCompactedSegmentFromSegments(
 	current []*readableSeg,
 	segmentsJustCompacted []segment.Segment,
 	compacted segment.Segment,
+	fieldCardinality segmentFieldCardinality,
 ) []*readableSeg {
 	result := make([]*readableSeg, 0, len(current))
 	for _, existing := range current {
@@ -397,8 +481,43 @@ func (b *block) addCompactedSegmentFromSegments(
 		}
 	}
 
-	// Return all the ones we kept plus the new compacted segment
-	return append(result, newReadableSeg(compacted, b.opts))
+	// Return all the ones we kept plus the new compacted segment, the read
+	// through cache entries of the segments just compacted were purged when
+	// they were closed above.
+	compactedSeg := newReadableSeg(b.readThroughCompactedSegment(compacted), b.opts)
+	compactedSeg.fieldCardinality = fieldCardinality
+	return append(result, compactedSeg)
+}
+
+// readThroughCompactedSegment wraps a segment compacted from the mutable
+// segments with the postings list cache if enabled so that repeated queries
+// against the open block are served from the cache until it is rotated out.
+func (b *block) readThroughCompactedSegment(seg segment.Segment) segment.Segment {
+	var (
+		plCache         = b.opts.PostingsListCache()
+		readThroughOpts = b.opts.ReadThroughSegmentOptions()
+	)
+	if plCache == nil || !readThroughOpts.CacheCompactedSegments {
+		return seg
+	}
+	return NewReadThroughSegment(seg, plCache, readThroughOpts)
+}
+
+// segmentFieldCardinality computes the cardinality of the fields of a segment
+// that was just built if enabled, errors are only logged since they leave the
+// cardinality reported for the block incomplete rather than the segment.
+func (b *block) segmentFieldCardinality(seg segment.Segment) segmentFieldCardinality {
+	topN := b.opts.FieldCardinalityTopN()
+	if topN <= 0 {
+		return nil
+	}
+
+	result, err := newSegmentFieldCardinality(seg, topN)
+	if err != nil {
+		b.logger.Error("unable to compute segment field cardinality", zap.Error(err))
+		return nil
+	}
+	return result
 }
 
 func (b *block) WriteBatch(inserts *WriteBatch) (WriteBatchResult, error) {
@@ -420,6 +539,14 @@ func (b *block) WriteBatch(inserts *WriteBatch) (WriteBatchResult, error) {
 
 	b.compact.compactingForeground = true
 	builder := b.compact.segmentBuilder
+	// Series that are written again after being deleted or expired are no
+	// longer deleted or expired.
+	if len(b.deleted) > 0 || len(b.expired) > 0 {
+		for _, d := range inserts.PendingDocs() {
+			delete(b.deleted, string(d.ID))
+			delete(b.expired, string(d.ID))
+		}
+	}
 	b.Unlock()
 
 	defer func() {
@@ -654,12 +781,14 @@ func (b *block) foregroundCompactWithTask(
 		return err
 	}
 
+	fieldCardinality := b.segmentFieldCardinality(compacted)
+
 	// Rotate in the ones we just compacted.
 	b.Lock()
 	defer b.Unlock()
 
 	result := b.addCompactedSegmentFromSegments(b.foregroundSegments,
-		segments, compacted)
+		segments, compacted, fieldCardinality)
 	b.foregroundSegments = result
 
 	return nil
@@ -732,6 +861,9 @@ func (b *block) executorWithRLock() (search.Executor, error) {
 	}
 
 	success = true
+	if pool := b.blockOpts.QuerySegmentsWorkerPool; pool != nil && len(readers) > 1 {
+		return executor.NewConcurrentExecutor(readers, pool), nil
+	}
 	return executor.NewExecutor(readers), nil
 }
 
@@ -779,12 +911,29 @@ func (b *block) Query(
 	sp.LogFields(logFields...)
 	defer sp.Finish()
 
-	exhaustive, err := b.queryWithSpan(ctx, cancellable, query, opts, results, sp, logFields)
+	nowFn := b.opts.ClockOptions().NowFn()
+	start := nowFn()
+
+	if err := b.maybeLoadColdSegments(); err != nil {
+		sp.LogFields(opentracinglog.Error(err))
+		return false, err
+	}
+
+	explain := BlockQueryExplain{BlockStart: b.blockStart}
+	exhaustive, err := b.queryWithSpan(ctx, cancellable, query, opts, results, sp, &explain)
 	if err != nil {
 		sp.LogFields(opentracinglog.Error(err))
+		return exhaustive, err
 	}
 
-	return exhaustive, err
+	explain.Duration = nowFn().Sub(start)
+	sp.LogFields(
+		opentracinglog.Int("docsMatched", explain.DocsMatched),
+		opentracinglog.Int("segments", len(explain.Segments)),
+	)
+	opts.ExplainTracker.TrackBlock(explain)
+
+	return exhaustive, nil
 }
 
 func (b *block) queryWithSpan(
@@ -794,7 +943,7 @@ func (b *block) queryWithSpan(
 	opts QueryOptions,
 	results BaseResults,
 	sp opentracing.Span,
-	logFields []opentracinglog.Field,
+	explain *BlockQueryExplain,
 ) (bool, error) {
 	b.RLock()
 	defer b.RUnlock()
@@ -803,6 +952,20 @@ func (b *block) queryWithSpan(
 		return false, ErrUnableToQueryBlockClosed
 	}
 
+	return b.queryWithRLock(cancellable, query, opts, results, sp, explain)
+}
+
+// queryWithRLock queries the segments of the block, when a span is set it
+// records a child span per segment searched and when explain is set it
+// records how the query was executed.
+func (b *block) queryWithRLock(
+	cancellable *resource.CancellableLifetime,
+	query Query,
+	opts QueryOptions,
+	results BaseResults,
+	sp opentracing.Span,
+	explain *BlockQueryExplain,
+) (bool, error) {
 	exec, err := b.newExecutorFn()
 	if err != nil {
 		return false, err
@@ -838,7 +1001,18 @@ func (b *block) queryWithSpan(
 			break
 		}
 
-		batch = append(batch, iter.Current())
+		current := iter.Current()
+		if explain != nil {
+			explain.DocsMatched++
+		}
+		if !opts.ResourceTracker.TrackDocument(current) {
+			break
+		}
+		if b.isDeletedWithRLock(current.ID, opts) || b.isExpiredWithRLock(current.ID) {
+			continue
+		}
+
+		batch = append(batch, current)
 		if len(batch) < batchSize {
 			continue
 		}
@@ -861,6 +1035,10 @@ func (b *block) queryWithSpan(
 		return false, err
 	}
 
+	if statsIter, ok := iter.(search.StatsIterator); ok {
+		b.traceSegmentsWithRLock(statsIter.ReaderStats(), sp, explain)
+	}
+
 	if err := iterCloser.Close(); err != nil {
 		return false, err
 	}
@@ -869,10 +1047,46 @@ func (b *block) queryWithSpan(
 		return false, err
 	}
 
-	exhaustive := !opts.LimitExceeded(size)
+	exhaustive := !opts.LimitExceeded(size) &&
+		opts.ResourceTracker.Exceeded() == QueryLimitNone
 	return exhaustive, nil
 }
 
+func (b *block) traceSegmentsWithRLock(
+	stats []search.ReaderStats,
+	sp opentracing.Span,
+	explain *BlockQueryExplain,
+) {
+	for i, s := range stats {
+		if s.SearchStart.IsZero() {
+			// The query stopped before searching the remaining segments.
+			break
+		}
+
+		if sp != nil {
+			segmentSp := sp.Tracer().StartSpan(tracepoint.BlockQuerySegment,
+				opentracing.ChildOf(sp.Context()),
+				opentracing.StartTime(s.SearchStart))
+			segmentSp.LogFields(
+				opentracinglog.Int("segment", i),
+				opentracinglog.Int("postingsCardinality", s.PostingsCardinality),
+				opentracinglog.Int("docsMatched", s.DocsMatched),
+			)
+			segmentSp.FinishWithOptions(opentracing.FinishOptions{
+				FinishTime: s.SearchStart.Add(s.SearchDuration),
+			})
+		}
+
+		if explain != nil {
+			explain.Segments = append(explain.Segments, SegmentQueryExplain{
+				SearchDuration:      s.SearchDuration,
+				PostingsCardinality: s.PostingsCardinality,
+				DocsMatched:         s.DocsMatched,
+			})
+		}
+	}
+}
+
 func (b *block) addQueryResults(
 	cancellable *resource.CancellableLifetime,
 	results BaseResults,
@@ -918,6 +1132,11 @@ func (b *block) Aggregate(
 	sp.LogFields(logFields...)
 	defer sp.Finish()
 
+	if err := b.maybeLoadColdSegments(); err != nil {
+		sp.LogFields(opentracinglog.Error(err))
+		return false, err
+	}
+
 	exhaustive, err := b.aggregateWithSpan(ctx, cancellable, opts, results, sp)
 	if err != nil {
 		sp.LogFields(opentracinglog.Error(err))
@@ -926,6 +1145,40 @@ func (b *block) Aggregate(
 	return exhaustive, err
 }
 
+// Completion acquires a read lock on the block so that the segments are
+// guaranteed to not be freed/released while collecting completions.
+func (b *block) Completion(
+	cancellable *resource.CancellableLifetime,
+	results *CompletionResults,
+) (bool, error) {
+	if err := b.maybeLoadColdSegments(); err != nil {
+		return false, err
+	}
+
+	b.RLock()
+	defer b.RUnlock()
+
+	if b.state == blockStateClosed {
+		return false, ErrUnableToQueryBlockClosed
+	}
+
+	// Checkout the lifetime of the query to not add results once cancelled.
+	if !cancellable.TryCheckout() {
+		return false, errCancelledQuery
+	}
+	defer cancellable.ReleaseCheckout()
+
+	for _, s := range b.segmentsWithRLock() {
+		if results.LimitExceeded() {
+			break
+		}
+		if err := results.addSegment(s); err != nil {
+			return false, err
+		}
+	}
+	return !results.LimitExceeded(), nil
+}
+
 func (b *block) aggregateWithSpan(
 	ctx context.Context,
 	cancellable *resource.CancellableLifetime,
@@ -940,35 +1193,16 @@ func (b *block) aggregateWithSpan(
 		return false, ErrUnableToQueryBlockClosed
 	}
 
-	aggOpts := results.AggregateResultsOptions()
-	iterateTerms := aggOpts.Type == AggregateTagNamesAndValues
-	iterateOpts := fieldsAndTermsIteratorOpts{
-		iterateTerms: iterateTerms,
-		allowFn: func(field []byte) bool {
-			// skip any field names that we shouldn't allow.
-			if bytes.Equal(field, doc.IDReservedFieldName) {
-				return false
-			}
-			return aggOpts.FieldFilter.Allow(field)
-		},
-		fieldIterFn: func(s segment.Segment) (segment.FieldsIterator, error) {
-			// NB(prateek): we default to using the regular (FST) fields iterator
-			// unless we have a predefined list of fields we know we need to restrict
-			// our search to, in which case we iterate that list and check if known values
-			// in the FST to restrict our search. This is going to be significantly faster
-			// while len(FieldsFilter) < 5-10 elements;
-			// but there will exist a ratio between the len(FieldFilter) v size(FST) after which
-			// iterating the entire FST is faster.
-			// Here, we chose to avoid factoring that in to our choice because almost all input
-			// to this function is expected to have (FieldsFilter) pretty small. If that changes
-			// in the future, we can revisit this.
-			if len(aggOpts.FieldFilter) == 0 {
-				return s.FieldsIterable().Fields()
-			}
-			return newFilterFieldsIterator(s, aggOpts.FieldFilter)
-		},
+	if (len(b.deleted) > 0 && !opts.IncludeDeleted) || len(b.expired) > 0 {
+		// NB: The FSTs of the segments still hold the fields and terms of the
+		// deleted and expired series so aggregate the documents of every
+		// series instead.
+		return b.queryWithRLock(cancellable, allQuery, opts, results, sp, nil)
 	}
 
+	iterateOpts := aggregateFieldsAndTermsIteratorOpts(results.AggregateResultsOptions())
+	iterateTerms := iterateOpts.iterateTerms
+
 	iter, err := b.newFieldsAndTermsIteratorFn(nil, iterateOpts)
 	if err != nil {
 		return false, err
@@ -994,7 +1228,7 @@ func (b *block) aggregateWithSpan(
 
 	segs := b.segmentsWithRLock()
 	for _, s := range segs {
-		if opts.LimitExceeded(size) {
+		if opts.LimitExceeded(size) || opts.ResourceTracker.Exceeded() != QueryLimitNone {
 			break
 		}
 
@@ -1010,6 +1244,9 @@ func (b *block) aggregateWithSpan(
 			}
 
 			field, term := iter.Current()
+			if !opts.ResourceTracker.TrackTerm(field, term) {
+				break
+			}
 			batch = b.appendFieldAndTermToBatch(batch, field, term, iterateTerms)
 			if len(batch) < batchSize {
 				continue
@@ -1039,10 +1276,151 @@ func (b *block) aggregateWithSpan(
 		}
 	}
 
-	exhaustive := !opts.LimitExceeded(size)
+	exhaustive := !opts.LimitExceeded(size) &&
+		opts.ResourceTracker.Exceeded() == QueryLimitNone
 	return exhaustive, nil
 }
 
+// aggregateFieldsAndTermsIteratorOpts returns the options to iterate the
+// fields and terms of segments for an aggregation.
+func aggregateFieldsAndTermsIteratorOpts(
+	aggOpts AggregateResultsOptions,
+) fieldsAndTermsIteratorOpts {
+	return fieldsAndTermsIteratorOpts{
+		iterateTerms: aggOpts.Type == AggregateTagNamesAndValues,
+		allowFn: func(field []byte) bool {
+			// skip any field names that we shouldn't allow.
+			if bytes.Equal(field, doc.IDReservedFieldName) {
+				return false
+			}
+			return aggOpts.FieldFilter.Allow(field)
+		},
+		fieldIterFn: func(s segment.Segment) (segment.FieldsIterator, error) {
+			// NB(prateek): we default to using the regular (FST) fields iterator
+			// unless we have a predefined list of fields we know we need to restrict
+			// our search to, in which case we iterate that list and check if known values
+			// in the FST to restrict our search. This is going to be significantly faster
+			// while len(FieldsFilter) < 5-10 elements;
+			// but there will exist a ratio between the len(FieldFilter) v size(FST) after which
+			// iterating the entire FST is faster.
+			// Here, we chose to avoid factoring that in to our choice because almost all input
+			// to this function is expected to have (FieldsFilter) pretty small. If that changes
+			// in the future, we can revisit this.
+			if len(aggOpts.FieldFilter) == 0 {
+				return s.FieldsIterable().Fields()
+			}
+			return newFilterFieldsIterator(s, aggOpts.FieldFilter)
+		},
+	}
+}
+
+func (b *block) AggregateIter(
+	opts QueryOptions,
+	aggOpts AggregateResultsOptions,
+) (AggregateIterator, error) {
+	if err := b.maybeLoadColdSegments(); err != nil {
+		return nil, err
+	}
+
+	b.RLock()
+	if b.state == blockStateClosed {
+		b.RUnlock()
+		return nil, ErrUnableToQueryBlockClosed
+	}
+
+	iter, err := b.aggregateIterWithRLock(opts, aggOpts)
+	if err != nil {
+		b.RUnlock()
+		return nil, err
+	}
+	return &blockAggregateIter{
+		AggregateIterator: iter,
+		unlockFn:          b.RUnlock,
+	}, nil
+}
+
+func (b *block) aggregateIterWithRLock(
+	opts QueryOptions,
+	aggOpts AggregateResultsOptions,
+) (AggregateIterator, error) {
+	iterateOpts := aggregateFieldsAndTermsIteratorOpts(aggOpts)
+	if (len(b.deleted) > 0 && !opts.IncludeDeleted) || len(b.expired) > 0 {
+		// NB: The FSTs of the segments still hold the fields and terms of the
+		// deleted and expired series so aggregate the documents of every
+		// series instead, same as when aggregating into results.
+		return b.aggregateDocsIterWithRLock(opts, iterateOpts)
+	}
+
+	segs := b.segmentsWithRLock()
+	iters := make([]AggregateIterator, 0, len(segs))
+	for _, s := range segs {
+		iter, err := b.newFieldsAndTermsIteratorFn(s, iterateOpts)
+		if err != nil {
+			for _, iter := range iters {
+				iter.Close()
+			}
+			return nil, err
+		}
+		iters = append(iters, iter)
+	}
+	return NewMergedAggregateIterator(iters), nil
+}
+
+func (b *block) aggregateDocsIterWithRLock(
+	opts QueryOptions,
+	iterateOpts fieldsAndTermsIteratorOpts,
+) (AggregateIterator, error) {
+	exec, err := b.newExecutorFn()
+	if err != nil {
+		return nil, err
+	}
+	defer exec.Close()
+
+	iter, err := exec.Execute(allQuery.Query.SearchQuery())
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	set := make(map[fieldAndTerm]struct{})
+	for iter.Next() {
+		current := iter.Current()
+		if b.isDeletedWithRLock(current.ID, opts) || b.isExpiredWithRLock(current.ID) {
+			continue
+		}
+		for _, f := range current.Fields {
+			if !iterateOpts.allow(f.Name) {
+				continue
+			}
+			entry := fieldAndTerm{field: string(f.Name)}
+			if iterateOpts.iterateTerms {
+				entry.term = string(f.Value)
+			}
+			set[entry] = struct{}{}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return newSliceAggregateIter(set), nil
+}
+
+// blockAggregateIter releases the read lock on the block once closed.
+type blockAggregateIter struct {
+	AggregateIterator
+	unlockFn func()
+}
+
+func (it *blockAggregateIter) Close() error {
+	if it.unlockFn == nil {
+		return nil
+	}
+	err := it.AggregateIterator.Close()
+	it.unlockFn()
+	it.unlockFn = nil
+	return err
+}
+
 func (b *block) appendFieldAndTermToBatch(
 	batch []AggregateResultsEntry,
 	field, term []byte,
@@ -1150,6 +1528,11 @@ func (b *block) AddResults(
 		return errUnableToBootstrapBlockClosed
 	}
 
+	// Load the evicted segments so that the results are combined with them.
+	if err := b.loadColdSegmentsWithLock(); err != nil {
+		return err
+	}
+
 	// First check fulfilled is correct
 	min, max := results.Fulfilled().MinMax()
 	if min.Before(b.blockStart) || max.After(b.blockEnd) {
@@ -1163,19 +1546,24 @@ func (b *block) AddResults(
 		readThroughOpts = b.opts.ReadThroughSegmentOptions()
 		segments        = results.Segments()
 	)
-	readThroughSegments := make([]segment.Segment, 0, len(segments))
+	entry := blockShardRangesSegments{
+		shardTimeRanges: results.Fulfilled(),
+		segments:        make([]segment.Segment, 0, len(segments)),
+		snapshotted:     results.Snapshotted(),
+	}
 	for _, seg := range segments {
-		readThroughSeg := seg
-		if _, ok := seg.(segment.MutableSegment); !ok {
-			// only wrap the immutable segments with a read through cache.
-			readThroughSeg = NewReadThroughSegment(seg, plCache, readThroughOpts)
+		fieldCardinality := b.segmentFieldCardinality(seg)
+		if _, ok := seg.(segment.MutableSegment); ok {
+			entry.segments = append(entry.segments, seg)
+			entry.mutableFieldCardinality = append(entry.mutableFieldCardinality,
+				fieldCardinality)
+			continue
 		}
-		readThroughSegments = append(readThroughSegments, readThroughSeg)
-	}
 
-	entry := blockShardRangesSegments{
-		shardTimeRanges: results.Fulfilled(),
-		segments:        readThroughSegments,
+		// only wrap the immutable segments with a read through cache.
+		entry.segments = append(entry.segments,
+			NewReadThroughSegment(seg, plCache, readThroughOpts))
+		entry.fieldCardinality = append(entry.fieldCardinality, fieldCardinality)
 	}
 
 	// first see if this block can cover all our current blocks covering shard
@@ -1209,6 +1597,56 @@ func (b *block) AddResults(
 	return multiErr.FinalError()
 }
 
+func (b *block) MarkDeleted(ids []ident.ID) error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.state == blockStateClosed {
+		return errUnableToMarkDeletedBlockClosed
+	}
+
+	if b.deleted == nil {
+		b.deleted = make(map[string]struct{}, len(ids))
+	}
+	for _, id := range ids {
+		b.deleted[id.String()] = struct{}{}
+	}
+	return nil
+}
+
+func (b *block) isDeletedWithRLock(id []byte, opts QueryOptions) bool {
+	if len(b.deleted) == 0 || opts.IncludeDeleted {
+		return false
+	}
+	_, ok := b.deleted[string(id)]
+	return ok
+}
+
+func (b *block) MarkExpired(ids []ident.ID) error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.state == blockStateClosed {
+		return errUnableToMarkExpiredBlockClosed
+	}
+
+	if b.expired == nil {
+		b.expired = make(map[string]struct{}, len(ids))
+	}
+	for _, id := range ids {
+		b.expired[id.String()] = struct{}{}
+	}
+	return nil
+}
+
+func (b *block) isExpiredWithRLock(id []byte) bool {
+	if len(b.expired) == 0 {
+		return false
+	}
+	_, ok := b.expired[string(id)]
+	return ok
+}
+
 func (b *block) Tick(c context.Cancellable, tickStart time.Time) (BlockTickResult, error) {
 	b.RLock()
 	defer b.RUnlock()
@@ -1295,6 +1733,74 @@ func (b *block) Stats(reporter BlockStatsReporter) error {
 	return nil
 }
 
+func (b *block) FieldCardinality() ([]FieldCardinality, error) {
+	b.RLock()
+	defer b.RUnlock()
+
+	if b.state == blockStateClosed {
+		return nil, errUnableToReportFieldCardinalityClosed
+	}
+
+	topN := b.opts.FieldCardinalityTopN()
+	if topN <= 0 {
+		return nil, nil
+	}
+
+	segments := make([]segmentFieldCardinality, 0,
+		len(b.foregroundSegments)+len(b.backgroundSegments))
+	for _, seg := range b.foregroundSegments {
+		segments = append(segments, seg.fieldCardinality)
+	}
+	for _, seg := range b.backgroundSegments {
+		segments = append(segments, seg.fieldCardinality)
+	}
+	for _, group := range b.shardRangesSegments {
+		segments = append(segments, group.fieldCardinality...)
+		segments = append(segments, group.mutableFieldCardinality...)
+	}
+	segments = append(segments, b.coldFieldCardinality...)
+
+	return mergeFieldCardinality(segments, topN), nil
+}
+
+func (b *block) State() (BlockState, error) {
+	b.RLock()
+	defer b.RUnlock()
+
+	if b.state == blockStateClosed {
+		return BlockState{}, errUnableToReportStateBlockClosed
+	}
+
+	state := BlockState{
+		BlockStart: b.blockStart,
+		Sealed:     b.state == blockStateSealed,
+		Cold:       b.cold,
+		NumDeleted: len(b.deleted),
+		NumExpired: len(b.expired),
+	}
+	for _, seg := range b.foregroundSegments {
+		state.ForegroundSegments.add(seg.Segment())
+	}
+	for _, seg := range b.backgroundSegments {
+		state.BackgroundSegments.add(seg.Segment())
+	}
+	for _, group := range b.shardRangesSegments {
+		groupState := BlockShardRangesSegmentsState{
+			Shards:      make([]uint32, 0, len(group.shardTimeRanges)),
+			Snapshotted: group.snapshotted,
+		}
+		for shard := range group.shardTimeRanges {
+			groupState.Shards = append(groupState.Shards, shard)
+		}
+		sortShards(groupState.Shards)
+		for _, seg := range group.segments {
+			groupState.Segments.add(seg)
+		}
+		state.ShardRangesSegments = append(state.ShardRangesSegments, groupState)
+	}
+	return state, nil
+}
+
 func (b *block) IsSealedWithRLock() bool {
 	return b.state == blockStateSealed
 }
@@ -1320,6 +1826,8 @@ func (b *block) NeedsMutableSegmentsEvicted() bool {
 
 	// Check boostrapped segments and to see if any of them need an eviction.
 	for _, shardRangeSegments := range b.shardRangesSegments {
+		// Segments bootstrapped from snapshots are immutable but not flushed.
+		anyMutableSegmentNeedsEviction = anyMutableSegmentNeedsEviction || shardRangeSegments.snapshotted
 		for _, seg := range shardRangeSegments.segments {
 			if mutableSeg, ok := seg.(segment.MutableSegment); ok {
 				anyMutableSegmentNeedsEviction = anyMutableSegmentNeedsEviction || mutableSeg.Size() > 0
@@ -1362,11 +1870,151 @@ func (b *block) EvictMutableSegments() error {
 			multiErr = multiErr.Add(mutableSeg.Close())
 		}
 		b.shardRangesSegments[idx].segments = segments
+		b.shardRangesSegments[idx].snapshotted = false
+		b.shardRangesSegments[idx].mutableFieldCardinality = nil
+	}
+
+	return multiErr.FinalError()
+}
+
+func (b *block) EvictColdSegments() error {
+	b.Lock()
+	defer b.Unlock()
+	if b.state != blockStateSealed {
+		return fmt.Errorf("unable to evict cold block segments, block must be sealed, found: %v", b.state)
+	}
+	if b.blockOpts.ColdSegmentsLoaderFn == nil {
+		return errUnableToEvictColdBlockNoLoader
+	}
+	if b.cold || !b.canEvictColdSegmentsWithLock() {
+		return nil
+	}
+
+	fulfilled := make(result.ShardTimeRanges)
+	multiErr := xerrors.NewMultiError()
+	var fieldCardinality []segmentFieldCardinality
+	for i, group := range b.shardRangesSegments {
+		fulfilled.AddRanges(group.shardTimeRanges)
+		fieldCardinality = append(fieldCardinality, group.fieldCardinality...)
+		for _, seg := range group.segments {
+			multiErr = multiErr.Add(seg.Close())
+		}
+		b.shardRangesSegments[i] = blockShardRangesSegments{}
 	}
+	b.shardRangesSegments = b.shardRangesSegments[:0]
+	b.cold = true
+	b.coldShardTimeRanges = fulfilled
+	b.coldFieldCardinality = fieldCardinality
+	b.metrics.coldSegmentsEvicted.Inc(1)
 
 	return multiErr.FinalError()
 }
 
+// canEvictColdSegmentsWithLock returns whether the block only holds flushed
+// segments, which are the only segments that can be loaded back from disk.
+func (b *block) canEvictColdSegmentsWithLock() bool {
+	if !b.hasEvictedMutableSegmentsAnyTimes ||
+		len(b.foregroundSegments) > 0 ||
+		len(b.backgroundSegments) > 0 ||
+		len(b.shardRangesSegments) == 0 {
+		return false
+	}
+	for _, group := range b.shardRangesSegments {
+		for _, seg := range group.segments {
+			if _, ok := seg.(segment.MutableSegment); ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (b *block) SnapshotSegments(persistFn func(segment.Builder) error) (bool, error) {
+	// NB: the read lock is held while persisting the same as while querying,
+	// compactions close the segments they replace.
+	b.RLock()
+	defer b.RUnlock()
+	if b.state == blockStateClosed {
+		return false, errUnableToSnapshotBlockClosed
+	}
+	if b.hasEvictedMutableSegmentsAnyTimes || b.cold {
+		// The segments of flushed blocks are already persisted.
+		return false, nil
+	}
+
+	segs := b.segmentsWithRLock()
+	if len(segs) == 0 {
+		return false, nil
+	}
+
+	segmentsBuilder := builder.NewBuilderFromSegments(b.opts.SegmentBuilderOptions())
+	if err := segmentsBuilder.AddSegments(segs); err != nil {
+		return false, err
+	}
+	if err := persistFn(segmentsBuilder); err != nil {
+		return false, err
+	}
+	b.metrics.segmentsSnapshotted.Inc(1)
+	return true, nil
+}
+
+func (b *block) IsCold() bool {
+	b.RLock()
+	defer b.RUnlock()
+	return b.cold
+}
+
+func (b *block) maybeLoadColdSegments() error {
+	b.RLock()
+	cold := b.cold
+	b.RUnlock()
+	if !cold {
+		return nil
+	}
+
+	b.Lock()
+	defer b.Unlock()
+	if b.state == blockStateClosed {
+		return ErrUnableToQueryBlockClosed
+	}
+	return b.loadColdSegmentsWithLock()
+}
+
+// loadColdSegmentsWithLock loads the evicted segments of a cold block back
+// from disk, they stay loaded until the block is evicted again.
+func (b *block) loadColdSegmentsWithLock() error {
+	if !b.cold {
+		return nil
+	}
+
+	sw := b.metrics.coldSegmentsLoadLatency.Start()
+	segs, err := b.blockOpts.ColdSegmentsLoaderFn(b.blockStart)
+	sw.Stop()
+	if err != nil {
+		return fmt.Errorf("unable to load cold block segments: %v", err)
+	}
+
+	var (
+		plCache         = b.opts.PostingsListCache()
+		readThroughOpts = b.opts.ReadThroughSegmentOptions()
+	)
+	readThroughSegments := make([]segment.Segment, 0, len(segs))
+	for _, seg := range segs {
+		readThroughSegments = append(readThroughSegments,
+			NewReadThroughSegment(seg, plCache, readThroughOpts))
+	}
+	b.shardRangesSegments = append(b.shardRangesSegments, blockShardRangesSegments{
+		shardTimeRanges:  b.coldShardTimeRanges,
+		segments:         readThroughSegments,
+		fieldCardinality: b.coldFieldCardinality,
+	})
+	b.cold = false
+	b.coldShardTimeRanges = nil
+	b.coldFieldCardinality = nil
+	b.metrics.coldSegmentsLoaded.Inc(1)
+	return nil
+}
+
 func (b *block) Close() error {
 	b.Lock()
 	defer b.Unlock()
@@ -0,0 +1,99 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package index
+
+import (
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/m3ninx/index/segment"
+	"github.com/m3db/m3/src/m3ninx/index/segment/fst"
+)
+
+// BlockState is the state of an index block, it is used to diagnose the
+// memory held by the index.
+type BlockState struct {
+	BlockStart time.Time
+	Sealed     bool
+	// Cold is whether the flushed segments of the block are evicted from
+	// memory, they are then not included in the state.
+	Cold bool
+
+	ForegroundSegments BlockSegmentsState
+	BackgroundSegments BlockSegmentsState
+	// ShardRangesSegments are the segments flushed or bootstrapped for
+	// the shard time ranges of the block.
+	ShardRangesSegments []BlockShardRangesSegmentsState
+
+	NumDeleted int
+	NumExpired int
+
+	// FlushedVolumes are the volumes of the block on disk, they are only set
+	// by the namespace index.
+	FlushedVolumes []BlockFlushedVolume
+}
+
+// BlockSegmentsState is the state of a set of segments of an index block.
+type BlockSegmentsState struct {
+	NumSegments        int
+	NumMutableSegments int
+	NumDocs            int64
+	// SizeBytes is the size of the segments held in memory, it only accounts
+	// for the immutable segments.
+	SizeBytes int64
+}
+
+// BlockShardRangesSegmentsState is the state of the segments of an index
+// block that fulfill a set of shard time ranges.
+type BlockShardRangesSegmentsState struct {
+	// Shards are the shards fulfilled by the segments in order.
+	Shards []uint32
+	// Snapshotted is whether the segments were bootstrapped from an index
+	// snapshot and are yet to be flushed.
+	Snapshotted bool
+	Segments    BlockSegmentsState
+}
+
+// BlockFlushedVolume is a volume of an index block on disk.
+type BlockFlushedVolume struct {
+	VolumeIndex int
+	Shards      []uint32
+}
+
+func (s *BlockSegmentsState) add(seg segment.Segment) {
+	if readThrough, ok := seg.(*ReadThroughSegment); ok {
+		seg = readThrough.segment
+	}
+
+	s.NumSegments++
+	if _, mutable := seg.(segment.MutableSegment); mutable {
+		s.NumMutableSegments++
+	}
+	s.NumDocs += seg.Size()
+	if fstSeg, ok := seg.(fst.Segment); ok {
+		s.SizeBytes += fstSeg.SizeBytes()
+	}
+}
+
+func sortShards(shards []uint32) {
+	sort.Slice(shards, func(i, j int) bool {
+		return shards[i] < shards[j]
+	})
+}
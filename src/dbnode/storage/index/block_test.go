@@ -35,12 +35,15 @@ import (
 	"github.com/m3db/m3/src/m3ninx/idx"
 	"github.com/m3db/m3/src/m3ninx/index"
 	"github.com/m3db/m3/src/m3ninx/index/segment"
+	"github.com/m3db/m3/src/m3ninx/index/segment/fst"
 	"github.com/m3db/m3/src/m3ninx/index/segment/mem"
 	"github.com/m3db/m3/src/m3ninx/search"
 	"github.com/m3db/m3/src/x/context"
 	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/pool"
 	"github.com/m3db/m3/src/x/resource"
+	xsync "github.com/m3db/m3/src/x/sync"
 	xtime "github.com/m3db/m3/src/x/time"
 
 	"github.com/golang/mock/gomock"
@@ -1079,6 +1082,27 @@ func TestBlockNeedsMutableSegmentsEvictedMutableSegments(t *testing.T) {
 	require.True(t, b.NeedsMutableSegmentsEvicted())
 }
 
+func TestBlockNeedsMutableSegmentsEvictedSnapshotted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testMD := newTestNSMetadata(t)
+	start := time.Now().Truncate(time.Hour)
+	blk, err := NewBlock(start, testMD, BlockOptions{}, testOpts)
+	require.NoError(t, err)
+	require.NoError(t, blk.Seal())
+
+	// Immutable segments bootstrapped from a snapshot still need flushing.
+	seg := segment.NewMockSegment(ctrl)
+	require.NoError(t, blk.AddResults(
+		result.NewIndexSnapshotBlock(start, []segment.Segment{seg},
+			result.NewShardTimeRanges(start, start.Add(time.Hour), 1, 2, 3))))
+	require.True(t, blk.NeedsMutableSegmentsEvicted())
+
+	require.NoError(t, blk.EvictMutableSegments())
+	require.False(t, blk.NeedsMutableSegmentsEvicted())
+}
+
 func TestBlockEvictMutableSegmentsSimple(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1126,6 +1150,92 @@ func TestBlockEvictMutableSegmentsAddResults(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestBlockEvictColdSegmentsNoLoader(t *testing.T) {
+	testMD := newTestNSMetadata(t)
+	start := time.Now().Truncate(time.Hour)
+	blk, err := NewBlock(start, testMD, BlockOptions{}, testOpts)
+	require.NoError(t, err)
+	require.NoError(t, blk.Seal())
+
+	require.Equal(t, errUnableToEvictColdBlockNoLoader, blk.EvictColdSegments())
+	require.False(t, blk.IsCold())
+}
+
+func TestBlockEvictColdSegmentsMutableSegments(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testMD := newTestNSMetadata(t)
+	start := time.Now().Truncate(time.Hour)
+	blk, err := NewBlock(start, testMD, BlockOptions{
+		ColdSegmentsLoaderFn: func(time.Time) ([]segment.Segment, error) {
+			require.FailNow(t, "unexpected cold segments load")
+			return nil, nil
+		},
+	}, testOpts)
+	require.NoError(t, err)
+	require.Error(t, blk.EvictColdSegments())
+
+	require.NoError(t, blk.Seal())
+	seg := segment.NewMockMutableSegment(ctrl)
+	require.NoError(t, blk.AddResults(
+		result.NewIndexBlock(start, []segment.Segment{seg},
+			result.NewShardTimeRanges(start, start.Add(time.Hour), 1, 2, 3))))
+
+	// Mutable segments can not be loaded back from disk.
+	require.NoError(t, blk.EvictColdSegments())
+	require.False(t, blk.IsCold())
+}
+
+func TestBlockE2EEvictColdSegmentsQuery(t *testing.T) {
+	testMD := newTestNSMetadata(t)
+	blockSize := time.Hour
+	start := time.Now().Truncate(blockSize)
+
+	var loads int
+	blk, err := NewBlock(start, testMD, BlockOptions{
+		ColdSegmentsLoaderFn: func(blockStart time.Time) ([]segment.Segment, error) {
+			require.True(t, start.Equal(blockStart))
+			loads++
+			return []segment.Segment{testSegment(t, testDoc1())}, nil
+		},
+	}, testOpts)
+	require.NoError(t, err)
+	require.NoError(t, blk.Seal())
+	require.NoError(t, blk.EvictMutableSegments())
+
+	seg := NewReadThroughSegment(testSegment(t, testDoc1()), nil,
+		testOpts.ReadThroughSegmentOptions())
+	require.NoError(t, blk.AddResults(
+		result.NewIndexBlock(start, []segment.Segment{seg},
+			result.NewShardTimeRanges(start, start.Add(blockSize), 1, 2, 3))))
+
+	require.NoError(t, blk.EvictColdSegments())
+	require.True(t, blk.IsCold())
+	require.Equal(t, 0, loads)
+
+	q, err := idx.NewRegexpQuery([]byte("bar"), []byte("b.*"))
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		results := NewQueryResults(nil, QueryResultsOptions{}, testOpts)
+		exhaustive, err := blk.Query(context.NewContext(), resource.NewCancellableLifetime(),
+			Query{q}, QueryOptions{}, results, emptyLogFields)
+		require.NoError(t, err)
+		require.True(t, exhaustive)
+		require.Equal(t, 1, results.Size())
+		_, ok := results.Map().Get(ident.StringID(string(testDoc1().ID)))
+		require.True(t, ok)
+		require.False(t, blk.IsCold())
+		require.Equal(t, 1, loads)
+	}
+
+	// The loaded segments still cover the ranges they fulfilled.
+	require.NoError(t, blk.EvictColdSegments())
+	require.True(t, blk.IsCold())
+	require.False(t, blk.NeedsMutableSegmentsEvicted())
+}
+
 func TestBlockE2EInsertQuery(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1451,6 +1561,45 @@ func TestBlockE2EInsertAddResultsMergeQuery(t *testing.T) {
 	require.Equal(t, tracepoint.BlockQuery, spans[0].OperationName)
 }
 
+func TestBlockE2EAddResultsQuerySegmentsConcurrently(t *testing.T) {
+	testMD := newTestNSMetadata(t)
+	blockSize := time.Hour
+	blockStart := time.Now().Truncate(blockSize)
+
+	pool := xsync.NewWorkerPool(2)
+	pool.Init()
+
+	blk, err := NewBlock(blockStart, testMD, BlockOptions{
+		QuerySegmentsWorkerPool: pool,
+	}, testOpts)
+	require.NoError(t, err)
+
+	shardRanges := result.NewShardTimeRanges(blockStart, blockStart.Add(blockSize), 1, 2, 3)
+	require.NoError(t, blk.AddResults(result.NewIndexBlock(blockStart,
+		[]segment.Segment{testSegment(t, testDoc1()), testSegment(t, testDoc2())},
+		shardRanges)))
+	require.NoError(t, blk.AddResults(result.NewIndexBlock(blockStart,
+		[]segment.Segment{testSegment(t, testDoc3())}, shardRanges)))
+
+	q, err := idx.NewRegexpQuery([]byte("bar"), []byte(".*"))
+	require.NoError(t, err)
+
+	results := NewQueryResults(nil, QueryResultsOptions{}, testOpts)
+	exhaustive, err := blk.Query(context.NewContext(), resource.NewCancellableLifetime(),
+		Query{q}, QueryOptions{}, results, emptyLogFields)
+	require.NoError(t, err)
+	require.True(t, exhaustive)
+	require.Equal(t, 3, results.Size())
+
+	// Limits are still respected with the segments searched concurrently.
+	results = NewQueryResults(nil, QueryResultsOptions{SizeLimit: 1}, testOpts)
+	exhaustive, err = blk.Query(context.NewContext(), resource.NewCancellableLifetime(),
+		Query{q}, QueryOptions{Limit: 1}, results, emptyLogFields)
+	require.NoError(t, err)
+	require.False(t, exhaustive)
+	require.Equal(t, 1, results.Size())
+}
+
 func TestBlockWriteBackgroundCompact(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1557,6 +1706,112 @@ func TestBlockWriteBackgroundCompact(t *testing.T) {
 	b.RUnlock()
 }
 
+func TestBlockCompactedSegmentsReadThroughCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testMD := newTestNSMetadata(t)
+	blockSize := time.Hour
+	blockStart := time.Now().Truncate(blockSize)
+
+	plCache, stopReporting, err := NewPostingsListCache(1000, PostingsListCacheOptions{
+		InstrumentOptions: instrument.NewOptions(),
+	})
+	require.NoError(t, err)
+	defer stopReporting()
+
+	opts := testOpts.
+		SetPostingsListCache(plCache).
+		SetReadThroughSegmentOptions(ReadThroughSegmentOptions{
+			CacheTerms:             true,
+			CacheCompactedSegments: true,
+		})
+	blk, err := NewBlock(blockStart, testMD, BlockOptions{}, opts)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, blk.Close())
+	}()
+
+	b, ok := blk.(*block)
+	require.True(t, ok)
+
+	write := func(d doc.Document) {
+		h := NewMockOnIndexSeries(ctrl)
+		h.EXPECT().OnIndexFinalize(xtime.ToUnixNano(blockStart))
+		h.EXPECT().OnIndexSuccess(xtime.ToUnixNano(blockStart))
+
+		batch := NewWriteBatch(WriteBatchOptions{
+			IndexBlockSize: blockSize,
+		})
+		batch.Append(WriteBatchEntry{
+			Timestamp:     blockStart.Add(time.Minute),
+			OnIndexSeries: h,
+		}, d)
+
+		res, err := b.WriteBatch(batch)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), res.NumSuccess)
+	}
+
+	query := func(expected int) {
+		q := idx.NewTermQuery([]byte("bar"), []byte("baz"))
+		results := NewQueryResults(nil, QueryResultsOptions{}, opts)
+		_, err := blk.Query(context.NewContext(), resource.NewCancellableLifetime(),
+			Query{q}, QueryOptions{}, results, emptyLogFields)
+		require.NoError(t, err)
+		require.Equal(t, expected, results.Size())
+	}
+
+	write(testDoc1())
+
+	// The compacted segment is read through the cache.
+	b.RLock()
+	require.Equal(t, 1, len(b.foregroundSegments))
+	_, ok = b.foregroundSegments[0].Segment().(*ReadThroughSegment)
+	require.True(t, ok)
+	b.RUnlock()
+
+	query(1)
+	require.Equal(t, 1, plCache.lru.Len())
+
+	// Rotate the segment out with a background compaction.
+	b.Lock()
+	b.maybeMoveForegroundSegmentsToBackgroundWithLock([]compaction.Segment{
+		{Segment: b.foregroundSegments[0].Segment()},
+	})
+	b.Unlock()
+
+	write(testDoc2())
+
+	b.Lock()
+	b.maybeMoveForegroundSegmentsToBackgroundWithLock([]compaction.Segment{
+		{Segment: b.foregroundSegments[0].Segment()},
+	})
+	require.True(t, b.compact.compactingBackground)
+	b.Unlock()
+
+	for {
+		b.RLock()
+		compacting := b.compact.compactingBackground
+		b.RUnlock()
+		if !compacting {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The entries of the rotated out segments were purged.
+	b.RLock()
+	require.Equal(t, 1, len(b.backgroundSegments))
+	_, ok = b.backgroundSegments[0].Segment().(*ReadThroughSegment)
+	require.True(t, ok)
+	b.RUnlock()
+	require.Equal(t, 0, plCache.lru.Len())
+
+	query(2)
+	require.Equal(t, 1, plCache.lru.Len())
+}
+
 func TestBlockAggregateAfterClose(t *testing.T) {
 	testMD := newTestNSMetadata(t)
 	start := time.Now().Truncate(time.Hour)
@@ -1875,6 +2130,51 @@ func assertAggregateResultsMapEquals(t *testing.T, expected map[string][]string,
 	}
 }
 
+func TestBlockSnapshotSegments(t *testing.T) {
+	testMD := newTestNSMetadata(t)
+	start := time.Now().Truncate(time.Hour)
+	blk, err := NewBlock(start, testMD, BlockOptions{}, testOpts)
+	require.NoError(t, err)
+
+	persistFn := func(segment.Builder) error {
+		require.FailNow(t, "unexpected persist of empty block")
+		return nil
+	}
+	ok, err := blk.SnapshotSegments(persistFn)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, blk.AddResults(
+		result.NewIndexBlock(start, []segment.Segment{
+			testSegment(t, testDoc1()),
+			testSegment(t, testDoc2()),
+		}, result.NewShardTimeRanges(start, start.Add(time.Hour), 1, 2, 3))))
+
+	var persisted []doc.Document
+	ok, err = blk.SnapshotSegments(func(b segment.Builder) error {
+		persisted = append(persisted, b.Docs()...)
+		return nil
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, persisted, 2)
+	ids := []string{string(persisted[0].ID), string(persisted[1].ID)}
+	require.ElementsMatch(t, []string{
+		string(testDoc1().ID), string(testDoc2().ID),
+	}, ids)
+
+	// Flushed blocks are not snapshotted.
+	require.NoError(t, blk.Seal())
+	require.NoError(t, blk.EvictMutableSegments())
+	ok, err = blk.SnapshotSegments(persistFn)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, blk.Close())
+	_, err = blk.SnapshotSegments(persistFn)
+	require.Equal(t, errUnableToSnapshotBlockClosed, err)
+}
+
 func testSegment(t *testing.T, docs ...doc.Document) segment.Segment {
 	seg, err := mem.NewSegment(0, testOpts.MemSegmentOptions())
 	require.NoError(t, err)
@@ -1887,6 +2187,486 @@ func testSegment(t *testing.T, docs ...doc.Document) segment.Segment {
 	return seg
 }
 
+func TestBlockE2EInsertMarkDeletedQueryAggregate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	blockSize := time.Hour
+
+	testMD := newTestNSMetadata(t)
+	now := time.Now()
+	blockStart := now.Truncate(blockSize)
+
+	nowNotBlockStartAligned := now.
+		Truncate(blockSize).
+		Add(time.Minute)
+
+	blk, err := NewBlock(blockStart, testMD, BlockOptions{}, testOpts)
+	require.NoError(t, err)
+	b, ok := blk.(*block)
+	require.True(t, ok)
+
+	batch := NewWriteBatch(WriteBatchOptions{
+		IndexBlockSize: blockSize,
+	})
+	for _, d := range []doc.Document{testDoc1(), testDoc2(), testDoc3()} {
+		h := NewMockOnIndexSeries(ctrl)
+		h.EXPECT().OnIndexFinalize(xtime.ToUnixNano(blockStart))
+		h.EXPECT().OnIndexSuccess(xtime.ToUnixNano(blockStart))
+		batch.Append(WriteBatchEntry{
+			Timestamp:     nowNotBlockStartAligned,
+			OnIndexSeries: h,
+		}, d)
+	}
+	res, err := b.WriteBatch(batch)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), res.NumSuccess)
+
+	require.NoError(t, b.MarkDeleted([]ident.ID{ident.StringID("bar")}))
+
+	q, err := idx.NewRegexpQuery([]byte("bar"), []byte("b.*"))
+	require.NoError(t, err)
+	ctx := context.NewContext()
+	query := func(opts QueryOptions) QueryResults {
+		results := NewQueryResults(nil, QueryResultsOptions{}, testOpts)
+		exhaustive, err := b.Query(ctx, resource.NewCancellableLifetime(),
+			Query{q}, opts, results, emptyLogFields)
+		require.NoError(t, err)
+		require.True(t, exhaustive)
+		return results
+	}
+	aggregate := func(opts QueryOptions) AggregateResults {
+		results := NewAggregateResults(ident.StringID("ns"), AggregateResultsOptions{
+			SizeLimit: 10,
+			Type:      AggregateTagNamesAndValues,
+		}, testOpts)
+		exhaustive, err := b.Aggregate(ctx, resource.NewCancellableLifetime(),
+			opts, results, emptyLogFields)
+		require.NoError(t, err)
+		require.True(t, exhaustive)
+		return results
+	}
+
+	// Deleted series are excluded unless requested.
+	results := query(QueryOptions{})
+	require.Equal(t, 2, results.Size())
+	_, ok = results.Map().Get(ident.StringID("bar"))
+	require.False(t, ok)
+	require.Equal(t, 3, query(QueryOptions{IncludeDeleted: true}).Size())
+
+	assertAggregateResultsMapEquals(t, map[string][]string{
+		"bar":  []string{"baz"},
+		"some": []string{"more"},
+	}, aggregate(QueryOptions{Limit: 10}))
+	assertAggregateResultsMapEquals(t, map[string][]string{
+		"bar":  []string{"baz", "qux"},
+		"some": []string{"more", "other"},
+	}, aggregate(QueryOptions{Limit: 10, IncludeDeleted: true}))
+
+	// Series written again are no longer deleted.
+	h := NewMockOnIndexSeries(ctrl)
+	h.EXPECT().OnIndexFinalize(xtime.ToUnixNano(blockStart))
+	h.EXPECT().OnIndexSuccess(xtime.ToUnixNano(blockStart))
+	batch = NewWriteBatch(WriteBatchOptions{
+		IndexBlockSize: blockSize,
+	})
+	batch.Append(WriteBatchEntry{
+		Timestamp:     nowNotBlockStartAligned,
+		OnIndexSeries: h,
+	}, testDoc3())
+	_, err = b.WriteBatch(batch)
+	require.NoError(t, err)
+	require.Equal(t, 3, query(QueryOptions{}).Size())
+
+	require.NoError(t, b.Close())
+	require.Equal(t, errUnableToMarkDeletedBlockClosed,
+		b.MarkDeleted([]ident.ID{ident.StringID("bar")}))
+}
+
+func TestBlockE2EInsertMarkExpiredQueryAggregate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	blockSize := time.Hour
+
+	testMD := newTestNSMetadata(t)
+	now := time.Now()
+	blockStart := now.Truncate(blockSize)
+
+	nowNotBlockStartAligned := now.
+		Truncate(blockSize).
+		Add(time.Minute)
+
+	blk, err := NewBlock(blockStart, testMD, BlockOptions{}, testOpts)
+	require.NoError(t, err)
+	b, ok := blk.(*block)
+	require.True(t, ok)
+
+	write := func(docs ...doc.Document) {
+		batch := NewWriteBatch(WriteBatchOptions{
+			IndexBlockSize: blockSize,
+		})
+		for _, d := range docs {
+			h := NewMockOnIndexSeries(ctrl)
+			h.EXPECT().OnIndexFinalize(xtime.ToUnixNano(blockStart))
+			h.EXPECT().OnIndexSuccess(xtime.ToUnixNano(blockStart))
+			batch.Append(WriteBatchEntry{
+				Timestamp:     nowNotBlockStartAligned,
+				OnIndexSeries: h,
+			}, d)
+		}
+		_, err := b.WriteBatch(batch)
+		require.NoError(t, err)
+	}
+	write(testDoc1(), testDoc2(), testDoc3())
+
+	require.NoError(t, b.MarkExpired([]ident.ID{ident.StringID("bar")}))
+
+	q, err := idx.NewRegexpQuery([]byte("bar"), []byte("b.*"))
+	require.NoError(t, err)
+	ctx := context.NewContext()
+	query := func(opts QueryOptions) QueryResults {
+		results := NewQueryResults(nil, QueryResultsOptions{}, testOpts)
+		exhaustive, err := b.Query(ctx, resource.NewCancellableLifetime(),
+			Query{q}, opts, results, emptyLogFields)
+		require.NoError(t, err)
+		require.True(t, exhaustive)
+		return results
+	}
+
+	// Expired series are excluded even when deleted series are requested.
+	results := query(QueryOptions{})
+	require.Equal(t, 2, results.Size())
+	_, ok = results.Map().Get(ident.StringID("bar"))
+	require.False(t, ok)
+	require.Equal(t, 2, query(QueryOptions{IncludeDeleted: true}).Size())
+
+	aggResults := NewAggregateResults(ident.StringID("ns"), AggregateResultsOptions{
+		SizeLimit: 10,
+		Type:      AggregateTagNamesAndValues,
+	}, testOpts)
+	exhaustive, err := b.Aggregate(ctx, resource.NewCancellableLifetime(),
+		QueryOptions{Limit: 10}, aggResults, emptyLogFields)
+	require.NoError(t, err)
+	require.True(t, exhaustive)
+	assertAggregateResultsMapEquals(t, map[string][]string{
+		"bar":  []string{"baz"},
+		"some": []string{"more"},
+	}, aggResults)
+
+	// Series written again are no longer expired.
+	write(testDoc3())
+	require.Equal(t, 3, query(QueryOptions{}).Size())
+
+	require.NoError(t, b.Close())
+	require.Equal(t, errUnableToMarkExpiredBlockClosed,
+		b.MarkExpired([]ident.ID{ident.StringID("bar")}))
+}
+
+func TestBlockE2EInsertQueryResourceLimits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	blockSize := time.Hour
+
+	testMD := newTestNSMetadata(t)
+	now := time.Now()
+	blockStart := now.Truncate(blockSize)
+
+	nowNotBlockStartAligned := now.
+		Truncate(blockSize).
+		Add(time.Minute)
+
+	blk, err := NewBlock(blockStart, testMD, BlockOptions{}, testOpts)
+	require.NoError(t, err)
+	b, ok := blk.(*block)
+	require.True(t, ok)
+
+	batch := NewWriteBatch(WriteBatchOptions{
+		IndexBlockSize: blockSize,
+	})
+	for _, d := range []doc.Document{testDoc1(), testDoc2(), testDoc3()} {
+		h := NewMockOnIndexSeries(ctrl)
+		h.EXPECT().OnIndexFinalize(xtime.ToUnixNano(blockStart))
+		h.EXPECT().OnIndexSuccess(xtime.ToUnixNano(blockStart))
+		batch.Append(WriteBatchEntry{
+			Timestamp:     nowNotBlockStartAligned,
+			OnIndexSeries: h,
+		}, d)
+	}
+	_, err = b.WriteBatch(batch)
+	require.NoError(t, err)
+
+	q, err := idx.NewRegexpQuery([]byte("bar"), []byte("b.*"))
+	require.NoError(t, err)
+	ctx := context.NewContext()
+
+	tracker := NewQueryResourceTracker(QueryResourceLimits{Docs: 1}, now, time.Now)
+	results := NewQueryResults(nil, QueryResultsOptions{}, testOpts)
+	exhaustive, err := b.Query(ctx, resource.NewCancellableLifetime(),
+		Query{q}, QueryOptions{ResourceTracker: tracker}, results, emptyLogFields)
+	require.NoError(t, err)
+	require.False(t, exhaustive)
+	require.Equal(t, 1, results.Size())
+	require.Equal(t, QueryLimitDocs, tracker.Exceeded())
+
+	tracker = NewQueryResourceTracker(QueryResourceLimits{BytesRead: 1}, now, time.Now)
+	aggResults := NewAggregateResults(ident.StringID("ns"), AggregateResultsOptions{
+		SizeLimit: 10,
+		Type:      AggregateTagNamesAndValues,
+	}, testOpts)
+	exhaustive, err = b.Aggregate(ctx, resource.NewCancellableLifetime(),
+		QueryOptions{Limit: 10, ResourceTracker: tracker}, aggResults, emptyLogFields)
+	require.NoError(t, err)
+	require.False(t, exhaustive)
+	require.Equal(t, QueryLimitBytesRead, tracker.Exceeded())
+}
+
+func TestBlockE2EInsertQueryExplain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	blockSize := time.Hour
+
+	testMD := newTestNSMetadata(t)
+	now := time.Now()
+	blockStart := now.Truncate(blockSize)
+
+	nowNotBlockStartAligned := now.
+		Truncate(blockSize).
+		Add(time.Minute)
+
+	blk, err := NewBlock(blockStart, testMD, BlockOptions{}, testOpts)
+	require.NoError(t, err)
+	b, ok := blk.(*block)
+	require.True(t, ok)
+
+	batch := NewWriteBatch(WriteBatchOptions{
+		IndexBlockSize: blockSize,
+	})
+	for _, d := range []doc.Document{testDoc1(), testDoc2(), testDoc3()} {
+		h := NewMockOnIndexSeries(ctrl)
+		h.EXPECT().OnIndexFinalize(xtime.ToUnixNano(blockStart))
+		h.EXPECT().OnIndexSuccess(xtime.ToUnixNano(blockStart))
+		batch.Append(WriteBatchEntry{
+			Timestamp:     nowNotBlockStartAligned,
+			OnIndexSeries: h,
+		}, d)
+	}
+	_, err = b.WriteBatch(batch)
+	require.NoError(t, err)
+
+	q, err := idx.NewRegexpQuery([]byte("bar"), []byte("b.*"))
+	require.NoError(t, err)
+	ctx := context.NewContext()
+
+	tracker := NewQueryExplainTracker(now)
+	results := NewQueryResults(nil, QueryResultsOptions{}, testOpts)
+	exhaustive, err := b.Query(ctx, resource.NewCancellableLifetime(),
+		Query{q}, QueryOptions{ExplainTracker: tracker}, results, emptyLogFields)
+	require.NoError(t, err)
+	require.True(t, exhaustive)
+	require.Equal(t, 3, results.Size())
+
+	explain := tracker.Explain(now.Add(time.Second))
+	require.Equal(t, time.Second, explain.Duration)
+	require.Equal(t, 1, len(explain.Blocks))
+
+	blockExplain := explain.Blocks[0]
+	require.True(t, blockStart.Equal(blockExplain.BlockStart))
+	require.Equal(t, 3, blockExplain.DocsMatched)
+	require.NotEmpty(t, blockExplain.Segments)
+
+	var postings, docs int
+	for _, s := range blockExplain.Segments {
+		postings += s.PostingsCardinality
+		docs += s.DocsMatched
+	}
+	require.Equal(t, 3, postings)
+	require.Equal(t, 3, docs)
+}
+
+func TestBlockE2EInsertFieldCardinality(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	blockSize := time.Hour
+
+	testMD := newTestNSMetadata(t)
+	now := time.Now()
+	blockStart := now.Truncate(blockSize)
+
+	nowNotBlockStartAligned := now.
+		Truncate(blockSize).
+		Add(time.Minute)
+
+	opts := testOpts.SetFieldCardinalityTopN(1)
+	blk, err := NewBlock(blockStart, testMD, BlockOptions{}, opts)
+	require.NoError(t, err)
+	b, ok := blk.(*block)
+	require.True(t, ok)
+
+	batch := NewWriteBatch(WriteBatchOptions{
+		IndexBlockSize: blockSize,
+	})
+	for _, d := range []doc.Document{testDoc1(), testDoc2(), testDoc3()} {
+		h := NewMockOnIndexSeries(ctrl)
+		h.EXPECT().OnIndexFinalize(xtime.ToUnixNano(blockStart))
+		h.EXPECT().OnIndexSuccess(xtime.ToUnixNano(blockStart))
+		batch.Append(WriteBatchEntry{
+			Timestamp:     nowNotBlockStartAligned,
+			OnIndexSeries: h,
+		}, d)
+	}
+	_, err = b.WriteBatch(batch)
+	require.NoError(t, err)
+
+	fields, err := b.FieldCardinality()
+	require.NoError(t, err)
+	require.Equal(t, []FieldCardinality{
+		{
+			Field:       []byte("bar"),
+			Cardinality: 2,
+			TopValues:   []FieldValueCardinality{{Value: []byte("baz"), Series: 2}},
+		},
+		{
+			Field:       []byte("some"),
+			Cardinality: 2,
+			TopValues:   []FieldValueCardinality{{Value: []byte("more"), Series: 1}},
+		},
+	}, fields)
+
+	require.NoError(t, b.Close())
+	_, err = b.FieldCardinality()
+	require.Equal(t, errUnableToReportFieldCardinalityClosed, err)
+}
+
+func TestBlockE2EInsertAggregateIter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	blockSize := time.Hour
+
+	testMD := newTestNSMetadata(t)
+	now := time.Now()
+	blockStart := now.Truncate(blockSize)
+
+	nowNotBlockStartAligned := now.
+		Truncate(blockSize).
+		Add(time.Minute)
+
+	blk, err := NewBlock(blockStart, testMD, BlockOptions{}, testOpts)
+	require.NoError(t, err)
+	b, ok := blk.(*block)
+	require.True(t, ok)
+
+	// Write the documents in separate batches so that they are spread across
+	// several segments.
+	for _, d := range []doc.Document{testDoc1(), testDoc2(), testDoc3()} {
+		batch := NewWriteBatch(WriteBatchOptions{
+			IndexBlockSize: blockSize,
+		})
+		h := NewMockOnIndexSeries(ctrl)
+		h.EXPECT().OnIndexFinalize(xtime.ToUnixNano(blockStart))
+		h.EXPECT().OnIndexSuccess(xtime.ToUnixNano(blockStart))
+		batch.Append(WriteBatchEntry{
+			Timestamp:     nowNotBlockStartAligned,
+			OnIndexSeries: h,
+		}, d)
+		_, err = b.WriteBatch(batch)
+		require.NoError(t, err)
+	}
+
+	aggOpts := AggregateResultsOptions{Type: AggregateTagNamesAndValues}
+	iter, err := b.AggregateIter(QueryOptions{}, aggOpts)
+	require.NoError(t, err)
+	require.Equal(t, []fieldAndTerm{
+		{field: "bar", term: "baz"},
+		{field: "bar", term: "qux"},
+		{field: "some", term: "more"},
+		{field: "some", term: "other"},
+	}, collectAggregateIter(t, iter))
+
+	iter, err = b.AggregateIter(QueryOptions{}, AggregateResultsOptions{
+		Type:        AggregateTagNames,
+		FieldFilter: AggregateFieldFilter{[]byte("some")},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []fieldAndTerm{{field: "some"}}, collectAggregateIter(t, iter))
+
+	// The fields and terms of deleted series are skipped, the block is
+	// unlocked once the iterators are closed.
+	require.NoError(t, b.MarkDeleted([]ident.ID{ident.StringID("bar")}))
+	iter, err = b.AggregateIter(QueryOptions{}, aggOpts)
+	require.NoError(t, err)
+	require.Equal(t, []fieldAndTerm{
+		{field: "bar", term: "baz"},
+		{field: "some", term: "more"},
+	}, collectAggregateIter(t, iter))
+
+	require.NoError(t, b.Close())
+	_, err = b.AggregateIter(QueryOptions{}, aggOpts)
+	require.Equal(t, ErrUnableToQueryBlockClosed, err)
+}
+
+func TestBlockE2EInsertCompletion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	blockSize := time.Hour
+
+	testMD := newTestNSMetadata(t)
+	now := time.Now()
+	blockStart := now.Truncate(blockSize)
+
+	nowNotBlockStartAligned := now.
+		Truncate(blockSize).
+		Add(time.Minute)
+
+	blk, err := NewBlock(blockStart, testMD, BlockOptions{}, testOpts)
+	require.NoError(t, err)
+	b, ok := blk.(*block)
+	require.True(t, ok)
+
+	batch := NewWriteBatch(WriteBatchOptions{
+		IndexBlockSize: blockSize,
+	})
+	for _, d := range []doc.Document{testDoc1(), testDoc2(), testDoc3()} {
+		h := NewMockOnIndexSeries(ctrl)
+		h.EXPECT().OnIndexFinalize(xtime.ToUnixNano(blockStart))
+		h.EXPECT().OnIndexSuccess(xtime.ToUnixNano(blockStart))
+		batch.Append(WriteBatchEntry{
+			Timestamp:     nowNotBlockStartAligned,
+			OnIndexSeries: h,
+		}, d)
+	}
+	_, err = b.WriteBatch(batch)
+	require.NoError(t, err)
+
+	results := NewCompletionResults([]byte("so"), []byte("m"), CompletionOptions{})
+	exhaustive, err := b.Completion(resource.NewCancellableLifetime(), results)
+	require.NoError(t, err)
+	require.True(t, exhaustive)
+	require.Equal(t, []CompletionField{
+		{Name: []byte("some"), Values: [][]byte{[]byte("more")}},
+	}, results.Fields())
+
+	results = NewCompletionResults(nil, nil, CompletionOptions{Limit: 1})
+	exhaustive, err = b.Completion(resource.NewCancellableLifetime(), results)
+	require.NoError(t, err)
+	require.False(t, exhaustive)
+
+	cancellable := resource.NewCancellableLifetime()
+	cancellable.Cancel()
+	_, err = b.Completion(cancellable, NewCompletionResults(nil, nil, CompletionOptions{}))
+	require.Equal(t, errCancelledQuery, err)
+
+	require.NoError(t, b.Close())
+	_, err = b.Completion(resource.NewCancellableLifetime(),
+		NewCompletionResults(nil, nil, CompletionOptions{}))
+	require.Equal(t, ErrUnableToQueryBlockClosed, err)
+}
+
 func testDoc1() doc.Document {
 	return doc.Document{
 		ID: []byte("foo"),
@@ -1946,3 +2726,80 @@ func testDoc3() doc.Document {
 		},
 	}
 }
+
+func TestBlockState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	blockSize := time.Hour
+
+	testMD := newTestNSMetadata(t)
+	now := time.Now()
+	blockStart := now.Truncate(blockSize)
+
+	nowNotBlockStartAligned := now.
+		Truncate(blockSize).
+		Add(time.Minute)
+
+	blk, err := NewBlock(blockStart, testMD, BlockOptions{}, testOpts)
+	require.NoError(t, err)
+	b, ok := blk.(*block)
+	require.True(t, ok)
+
+	batch := NewWriteBatch(WriteBatchOptions{
+		IndexBlockSize: blockSize,
+	})
+	for _, d := range []doc.Document{testDoc1(), testDoc2()} {
+		h := NewMockOnIndexSeries(ctrl)
+		h.EXPECT().OnIndexFinalize(xtime.ToUnixNano(blockStart))
+		h.EXPECT().OnIndexSuccess(xtime.ToUnixNano(blockStart))
+		batch.Append(WriteBatchEntry{
+			Timestamp:     nowNotBlockStartAligned,
+			OnIndexSeries: h,
+		}, d)
+	}
+	_, err = b.WriteBatch(batch)
+	require.NoError(t, err)
+	require.NoError(t, b.MarkDeleted([]ident.ID{ident.BytesID(testDoc1().ID)}))
+
+	seg := fst.NewMockSegment(ctrl)
+	seg.EXPECT().Size().Return(int64(5))
+	seg.EXPECT().SizeBytes().Return(int64(100))
+	require.NoError(t, b.AddResults(
+		result.NewIndexBlock(blockStart, []segment.Segment{seg},
+			result.NewShardTimeRanges(blockStart, blockStart.Add(blockSize), 3, 1, 2))))
+
+	state, err := b.State()
+	require.NoError(t, err)
+	require.True(t, blockStart.Equal(state.BlockStart))
+	require.False(t, state.Sealed)
+	require.False(t, state.Cold)
+	require.Equal(t, 1, state.NumDeleted)
+	require.Equal(t, 0, state.NumExpired)
+
+	active := state.ForegroundSegments
+	active.SizeBytes = 0
+	require.Equal(t, BlockSegmentsState{NumSegments: 1, NumDocs: 2}, active)
+	require.True(t, state.ForegroundSegments.SizeBytes > 0)
+	require.Equal(t, BlockSegmentsState{}, state.BackgroundSegments)
+	require.Equal(t, []BlockShardRangesSegmentsState{
+		{
+			Shards: []uint32{1, 2, 3},
+			Segments: BlockSegmentsState{
+				NumSegments: 1,
+				NumDocs:     5,
+				SizeBytes:   100,
+			},
+		},
+	}, state.ShardRangesSegments)
+
+	require.NoError(t, b.Seal())
+	state, err = b.State()
+	require.NoError(t, err)
+	require.True(t, state.Sealed)
+
+	seg.EXPECT().Close().Return(nil)
+	require.NoError(t, b.Close())
+	_, err = b.State()
+	require.Equal(t, errUnableToReportStateBlockClosed, err)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package index
+
+import "sync"
+
+// BackgroundCompactionLimiter limits the number of blocks that are background
+// compacted concurrently, it is shared by the blocks of a namespace index.
+// A nil limiter does not limit background compactions.
+type BackgroundCompactionLimiter struct {
+	sync.Mutex
+
+	max     int
+	running int
+}
+
+// NewBackgroundCompactionLimiter returns a new background compaction limiter
+// that allows max concurrent background compactions, zero is unlimited.
+func NewBackgroundCompactionLimiter(max int) *BackgroundCompactionLimiter {
+	return &BackgroundCompactionLimiter{max: max}
+}
+
+// SetMax sets the max number of concurrent background compactions, zero is
+// unlimited. Background compactions already running are not interrupted.
+func (l *BackgroundCompactionLimiter) SetMax(max int) {
+	if l == nil {
+		return
+	}
+	l.Lock()
+	l.max = max
+	l.Unlock()
+}
+
+// TryAcquire returns whether a background compaction may start, a background
+// compaction that started must release the limiter once done.
+func (l *BackgroundCompactionLimiter) TryAcquire() bool {
+	if l == nil {
+		return true
+	}
+	l.Lock()
+	defer l.Unlock()
+	if l.max > 0 && l.running >= l.max {
+		return false
+	}
+	l.running++
+	return true
+}
+
+// Release releases the limiter once a background compaction is done.
+func (l *BackgroundCompactionLimiter) Release() {
+	if l == nil {
+		return
+	}
+	l.Lock()
+	l.running--
+	l.Unlock()
+}
+
+// Running returns the number of background compactions running.
+func (l *BackgroundCompactionLimiter) Running() int {
+	if l == nil {
+		return 0
+	}
+	l.Lock()
+	defer l.Unlock()
+	return l.running
+}
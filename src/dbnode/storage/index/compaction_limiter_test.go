@@ -0,0 +1,62 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package index
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackgroundCompactionLimiter(t *testing.T) {
+	l := NewBackgroundCompactionLimiter(2)
+	require.True(t, l.TryAcquire())
+	require.True(t, l.TryAcquire())
+	require.False(t, l.TryAcquire())
+	require.Equal(t, 2, l.Running())
+
+	l.Release()
+	require.Equal(t, 1, l.Running())
+	require.True(t, l.TryAcquire())
+
+	// Lowering the max does not interrupt running compactions.
+	l.SetMax(1)
+	require.False(t, l.TryAcquire())
+	l.Release()
+	require.False(t, l.TryAcquire())
+	l.Release()
+	require.True(t, l.TryAcquire())
+
+	// Zero is unlimited.
+	l.SetMax(0)
+	for i := 0; i < 10; i++ {
+		require.True(t, l.TryAcquire())
+	}
+	require.Equal(t, 11, l.Running())
+}
+
+func TestBackgroundCompactionLimiterNil(t *testing.T) {
+	var l *BackgroundCompactionLimiter
+	l.SetMax(1)
+	require.True(t, l.TryAcquire())
+	require.True(t, l.TryAcquire())
+	l.Release()
+	require.Equal(t, 0, l.Running())
+}
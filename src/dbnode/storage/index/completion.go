@@ -0,0 +1,192 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package index
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/index/segment"
+	xerrors "github.com/m3db/m3/src/x/errors"
+)
+
+// CompletionOptions are the options for a completion query.
+type CompletionOptions struct {
+	StartInclusive time.Time
+	EndExclusive   time.Time
+	// Limit is the max number of tag names and values returned, zero is
+	// unlimited.
+	Limit int
+	// NamesOnly completes tag names only, without their values.
+	NamesOnly bool
+}
+
+// CompletionResult is the result of a completion query.
+type CompletionResult struct {
+	// Fields are the matching tag names, sorted by name.
+	Fields     []CompletionField
+	Exhaustive bool
+}
+
+// CompletionField is a tag name matching a completion query along with its
+// matching values, sorted by value.
+type CompletionField struct {
+	Name   []byte
+	Values [][]byte
+}
+
+// CompletionResults collects the tag names and values that start with the
+// field and value prefixes of a completion query, unless only names are
+// completed a tag name is collected with its first matching value. It is not
+// safe for concurrent use.
+type CompletionResults struct {
+	fieldPrefix []byte
+	valuePrefix []byte
+	opts        CompletionOptions
+
+	fields map[string]map[string]struct{}
+	size   int
+}
+
+// NewCompletionResults returns new completion results.
+func NewCompletionResults(
+	fieldPrefix []byte,
+	valuePrefix []byte,
+	opts CompletionOptions,
+) *CompletionResults {
+	return &CompletionResults{
+		fieldPrefix: fieldPrefix,
+		valuePrefix: valuePrefix,
+		opts:        opts,
+		fields:      make(map[string]map[string]struct{}),
+	}
+}
+
+// Size returns the number of tag names and values collected.
+func (r *CompletionResults) Size() int {
+	return r.size
+}
+
+// LimitExceeded returns whether the results hold as many tag names and values
+// as the limit.
+func (r *CompletionResults) LimitExceeded() bool {
+	return r.opts.Limit > 0 && r.size >= r.opts.Limit
+}
+
+// Fields returns the tag names and values collected, sorted by name and value.
+func (r *CompletionResults) Fields() []CompletionField {
+	fields := make([]CompletionField, 0, len(r.fields))
+	for name, values := range r.fields {
+		field := CompletionField{
+			Name:   []byte(name),
+			Values: make([][]byte, 0, len(values)),
+		}
+		for value := range values {
+			field.Values = append(field.Values, []byte(value))
+		}
+		sort.Slice(field.Values, func(i, j int) bool {
+			return bytes.Compare(field.Values[i], field.Values[j]) < 0
+		})
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return bytes.Compare(fields[i].Name, fields[j].Name) < 0
+	})
+	return fields
+}
+
+func (r *CompletionResults) addField(field []byte) {
+	if _, ok := r.fields[string(field)]; ok {
+		return
+	}
+	r.fields[string(field)] = make(map[string]struct{})
+	r.size++
+}
+
+func (r *CompletionResults) addValue(field, value []byte) {
+	r.addField(field)
+	values := r.fields[string(field)]
+	if _, ok := values[string(value)]; ok {
+		return
+	}
+	values[string(value)] = struct{}{}
+	r.size++
+}
+
+// addSegment adds the matching tag names and values of a segment, relying on
+// the fields and terms of segments being iterated in order to stop as soon as
+// they are past the prefixes.
+func (r *CompletionResults) addSegment(s segment.Segment) error {
+	fields, err := s.FieldsIterable().Fields()
+	if err != nil {
+		return err
+	}
+
+	multiErr := xerrors.NewMultiError()
+	for !r.LimitExceeded() && fields.Next() {
+		field := fields.Current()
+		if !bytes.HasPrefix(field, r.fieldPrefix) {
+			if bytes.Compare(field, r.fieldPrefix) > 0 {
+				break
+			}
+			continue
+		}
+		if bytes.Equal(field, doc.IDReservedFieldName) {
+			continue
+		}
+
+		if r.opts.NamesOnly {
+			r.addField(field)
+			continue
+		}
+		if err := r.addTerms(s, field); err != nil {
+			multiErr = multiErr.Add(err)
+			break
+		}
+	}
+	multiErr = multiErr.Add(fields.Err())
+	multiErr = multiErr.Add(fields.Close())
+	return multiErr.FinalError()
+}
+
+func (r *CompletionResults) addTerms(s segment.Segment, field []byte) error {
+	terms, err := s.TermsIterable().Terms(field)
+	if err != nil {
+		return err
+	}
+
+	for !r.LimitExceeded() && terms.Next() {
+		term, _ := terms.Current()
+		if !bytes.HasPrefix(term, r.valuePrefix) {
+			if bytes.Compare(term, r.valuePrefix) > 0 {
+				break
+			}
+			continue
+		}
+		r.addValue(field, term)
+	}
+
+	multiErr := xerrors.NewMultiError()
+	multiErr = multiErr.Add(terms.Err())
+	multiErr = multiErr.Add(terms.Close())
+	return multiErr.FinalError()
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package index
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletionResultsAddSegment(t *testing.T) {
+	seg := testSegment(t, testDoc1(), testDoc2(), testDoc3())
+
+	tests := []struct {
+		name        string
+		fieldPrefix string
+		valuePrefix string
+		opts        CompletionOptions
+		expected    map[string][]string
+		exceeded    bool
+	}{
+		{
+			name:     "all",
+			expected: map[string][]string{"bar": {"baz", "qux"}, "some": {"more", "other"}},
+		},
+		{
+			name:        "names only",
+			fieldPrefix: "s",
+			opts:        CompletionOptions{NamesOnly: true},
+			expected:    map[string][]string{"some": {}},
+		},
+		{
+			name:        "field prefix",
+			fieldPrefix: "ba",
+			expected:    map[string][]string{"bar": {"baz", "qux"}},
+		},
+		{
+			name:        "value prefix",
+			valuePrefix: "o",
+			expected:    map[string][]string{"some": {"other"}},
+		},
+		{
+			name:     "limit",
+			opts:     CompletionOptions{Limit: 2},
+			expected: map[string][]string{"bar": {"baz"}},
+			exceeded: true,
+		},
+		{
+			name:        "no match",
+			fieldPrefix: "z",
+			expected:    map[string][]string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := NewCompletionResults([]byte(test.fieldPrefix),
+				[]byte(test.valuePrefix), test.opts)
+			require.NoError(t, results.addSegment(seg))
+			require.NoError(t, results.addSegment(seg))
+			require.Equal(t, test.exceeded, results.LimitExceeded())
+
+			fields := results.Fields()
+			actual := make(map[string][]string, len(fields))
+			for _, field := range fields {
+				values := make([]string, 0, len(field.Values))
+				for _, value := range field.Values {
+					values = append(values, string(value))
+				}
+				actual[string(field.Name)] = values
+			}
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package index
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/index/segment"
+	xerrors "github.com/m3db/m3/src/x/errors"
+)
+
+// BlockFieldCardinality is the cardinality of the fields of an index block.
+type BlockFieldCardinality struct {
+	BlockStart time.Time
+	// Fields are the fields of the block ordered by name.
+	Fields []FieldCardinality
+}
+
+// FieldCardinality is the cardinality of a field (tag name) of an index
+// block. The cardinality of a block with several segments is an upper
+// bound since the same value or series may be in more than one segment.
+type FieldCardinality struct {
+	Field []byte
+	// Cardinality is the number of distinct values of the field.
+	Cardinality int
+	// TopValues are the values of the field with the most series ordered by
+	// descending number of series.
+	TopValues []FieldValueCardinality
+}
+
+// FieldValueCardinality is the number of series with a value of a field.
+type FieldValueCardinality struct {
+	Value  []byte
+	Series int
+}
+
+// segmentFieldCardinality is the cardinality of the fields of a segment
+// computed once as the segment is built since segments are immutable.
+type segmentFieldCardinality []FieldCardinality
+
+// newSegmentFieldCardinality computes the cardinality of the fields of a
+// segment keeping the topN values of each field with the most series.
+func newSegmentFieldCardinality(
+	seg segment.Segment,
+	topN int,
+) (segmentFieldCardinality, error) {
+	fieldsIter, err := seg.FieldsIterable().Fields()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		result        segmentFieldCardinality
+		termsIterable = seg.TermsIterable()
+		multiErr      xerrors.MultiError
+	)
+	for fieldsIter.Next() {
+		field := fieldsIter.Current()
+		if bytes.Equal(field, doc.IDReservedFieldName) {
+			continue
+		}
+
+		fieldCardinality, err := newFieldCardinality(termsIterable, field, topN)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			break
+		}
+		result = append(result, fieldCardinality)
+	}
+	multiErr = multiErr.Add(fieldsIter.Err())
+	multiErr = multiErr.Add(fieldsIter.Close())
+	if err := multiErr.FinalError(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func newFieldCardinality(
+	termsIterable segment.TermsIterable,
+	field []byte,
+	topN int,
+) (FieldCardinality, error) {
+	termsIter, err := termsIterable.Terms(field)
+	if err != nil {
+		return FieldCardinality{}, err
+	}
+
+	result := FieldCardinality{
+		Field: append([]byte(nil), field...),
+	}
+	for termsIter.Next() {
+		term, pl := termsIter.Current()
+		result.Cardinality++
+		result.TopValues = addTopValue(result.TopValues, term, pl.Len(), topN)
+	}
+
+	multiErr := xerrors.NewMultiError()
+	multiErr = multiErr.Add(termsIter.Err())
+	multiErr = multiErr.Add(termsIter.Close())
+	if err := multiErr.FinalError(); err != nil {
+		return FieldCardinality{}, err
+	}
+	return result, nil
+}
+
+// addTopValue adds a value to the values ordered by descending number of
+// series then by value if it is within the topN values, the value is copied
+// if added.
+func addTopValue(
+	values []FieldValueCardinality,
+	value []byte,
+	series int,
+	topN int,
+) []FieldValueCardinality {
+	idx := sort.Search(len(values), func(i int) bool {
+		return values[i].Series < series ||
+			(values[i].Series == series && bytes.Compare(values[i].Value, value) > 0)
+	})
+	if idx >= topN {
+		return values
+	}
+
+	if len(values) < topN {
+		values = append(values, FieldValueCardinality{})
+	}
+	copy(values[idx+1:], values[idx:])
+	values[idx] = FieldValueCardinality{
+		Value:  append([]byte(nil), value...),
+		Series: series,
+	}
+	return values
+}
+
+// mergeFieldCardinality merges the cardinality of the fields of segments
+// into the cardinality of the fields of a block ordered by name.
+func mergeFieldCardinality(
+	segments []segmentFieldCardinality,
+	topN int,
+) []FieldCardinality {
+	type fieldAccumulator struct {
+		field       []byte
+		cardinality int
+		series      map[string]int
+	}
+
+	fields := make(map[string]*fieldAccumulator)
+	for _, seg := range segments {
+		for _, f := range seg {
+			acc, ok := fields[string(f.Field)]
+			if !ok {
+				acc = &fieldAccumulator{
+					field:  f.Field,
+					series: make(map[string]int, len(f.TopValues)),
+				}
+				fields[string(f.Field)] = acc
+			}
+			acc.cardinality += f.Cardinality
+			for _, v := range f.TopValues {
+				acc.series[string(v.Value)] += v.Series
+			}
+		}
+	}
+
+	result := make([]FieldCardinality, 0, len(fields))
+	for _, acc := range fields {
+		fieldCardinality := FieldCardinality{
+			Field:       acc.field,
+			Cardinality: acc.cardinality,
+		}
+		for value, series := range acc.series {
+			fieldCardinality.TopValues = addTopValue(fieldCardinality.TopValues,
+				[]byte(value), series, topN)
+		}
+		result = append(result, fieldCardinality)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return bytes.Compare(result[i].Field, result[j].Field) < 0
+	})
+	return result
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package index
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/m3ninx/index/segment"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSegmentFieldCardinality(t *testing.T) {
+	seg := testSegment(t, testDoc1(), testDoc2(), testDoc3())
+
+	result, err := newSegmentFieldCardinality(seg, 1)
+	require.NoError(t, err)
+	require.Equal(t, segmentFieldCardinality{
+		{
+			Field:       []byte("bar"),
+			Cardinality: 2,
+			TopValues:   []FieldValueCardinality{{Value: []byte("baz"), Series: 2}},
+		},
+		{
+			Field:       []byte("some"),
+			Cardinality: 2,
+			TopValues:   []FieldValueCardinality{{Value: []byte("more"), Series: 1}},
+		},
+	}, result)
+}
+
+func TestMergeFieldCardinality(t *testing.T) {
+	var segments []segmentFieldCardinality
+	for _, seg := range []segment.Segment{
+		testSegment(t, testDoc1()),
+		testSegment(t, testDoc2(), testDoc3()),
+	} {
+		fieldCardinality, err := newSegmentFieldCardinality(seg, 2)
+		require.NoError(t, err)
+		segments = append(segments, fieldCardinality)
+	}
+
+	// Segments without computed field cardinality are skipped.
+	segments = append(segments, nil)
+
+	require.Equal(t, []FieldCardinality{
+		{
+			Field:       []byte("bar"),
+			Cardinality: 3,
+			TopValues: []FieldValueCardinality{
+				{Value: []byte("baz"), Series: 2},
+				{Value: []byte("qux"), Series: 1},
+			},
+		},
+		{
+			Field:       []byte("some"),
+			Cardinality: 2,
+			TopValues: []FieldValueCardinality{
+				{Value: []byte("more"), Series: 1},
+				{Value: []byte("other"), Series: 1},
+			},
+		},
+	}, mergeFieldCardinality(segments, 2))
+}
+
+func TestAddTopValue(t *testing.T) {
+	var values []FieldValueCardinality
+	values = addTopValue(values, []byte("a"), 1, 2)
+	values = addTopValue(values, []byte("b"), 3, 2)
+	values = addTopValue(values, []byte("c"), 2, 2)
+	values = addTopValue(values, []byte("d"), 2, 2)
+	require.Equal(t, []FieldValueCardinality{
+		{Value: []byte("b"), Series: 3},
+		{Value: []byte("c"), Series: 2},
+	}, values)
+}
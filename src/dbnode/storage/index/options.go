@@ -22,10 +22,12 @@ package index
 
 import (
 	"errors"
+	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/storage/index/compaction"
 	"github.com/m3db/m3/src/m3ninx/doc"
+	m3ninxindex "github.com/m3db/m3/src/m3ninx/index"
 	"github.com/m3db/m3/src/m3ninx/index/segment/builder"
 	"github.com/m3db/m3/src/m3ninx/index/segment/fst"
 	"github.com/m3db/m3/src/m3ninx/index/segment/mem"
@@ -65,6 +67,9 @@ var (
 	errOptionsAggResultsEntryPoolUnspecified = errors.New("aggregate results entry array pool is unset")
 	errIDGenerationDisabled                  = errors.New("id generation is disabled")
 	errPostingsListCacheUnspecified          = errors.New("postings list cache is unset")
+	errMaxConcurrentCompactionsNegative      = errors.New("max concurrent background compactions is negative")
+	errColdBlockAgeNegative                  = errors.New("cold block age is negative")
+	errFieldCardinalityTopNNegative          = errors.New("field cardinality top N is negative")
 
 	defaultForegroundCompactionOpts compaction.PlannerOptions
 	defaultBackgroundCompactionOpts compaction.PlannerOptions
@@ -119,6 +124,12 @@ type opts struct {
 	backgroundCompactionPlannerOpts compaction.PlannerOptions
 	postingsListCache               *PostingsListCache
 	readThroughSegmentOptions       ReadThroughSegmentOptions
+	regexpCacheOptions              m3ninxindex.RegexpCacheOptions
+	maxConcurrentCompactions        int
+	coldBlockAge                    time.Duration
+	querySegmentsConcurrently       bool
+	snapshotEnabled                 bool
+	fieldCardinalityTopN            int
 }
 
 var undefinedUUIDFn = func() ([]byte, error) { return nil, errIDGenerationDisabled }
@@ -205,6 +216,15 @@ func (o *opts) Validate() error {
 	if o.postingsListCache == nil {
 		return errPostingsListCacheUnspecified
 	}
+	if o.maxConcurrentCompactions < 0 {
+		return errMaxConcurrentCompactionsNegative
+	}
+	if o.coldBlockAge < 0 {
+		return errColdBlockAgeNegative
+	}
+	if o.fieldCardinalityTopN < 0 {
+		return errFieldCardinalityTopNNegative
+	}
 	return nil
 }
 
@@ -362,6 +382,56 @@ func (o *opts) BackgroundCompactionPlannerOptions() compaction.PlannerOptions {
 	return o.backgroundCompactionPlannerOpts
 }
 
+func (o *opts) SetMaxConcurrentBackgroundCompactions(value int) Options {
+	opts := *o
+	opts.maxConcurrentCompactions = value
+	return &opts
+}
+
+func (o *opts) MaxConcurrentBackgroundCompactions() int {
+	return o.maxConcurrentCompactions
+}
+
+func (o *opts) SetColdBlockAge(value time.Duration) Options {
+	opts := *o
+	opts.coldBlockAge = value
+	return &opts
+}
+
+func (o *opts) ColdBlockAge() time.Duration {
+	return o.coldBlockAge
+}
+
+func (o *opts) SetQuerySegmentsConcurrently(value bool) Options {
+	opts := *o
+	opts.querySegmentsConcurrently = value
+	return &opts
+}
+
+func (o *opts) QuerySegmentsConcurrently() bool {
+	return o.querySegmentsConcurrently
+}
+
+func (o *opts) SetSnapshotEnabled(value bool) Options {
+	opts := *o
+	opts.snapshotEnabled = value
+	return &opts
+}
+
+func (o *opts) SnapshotEnabled() bool {
+	return o.snapshotEnabled
+}
+
+func (o *opts) SetFieldCardinalityTopN(value int) Options {
+	opts := *o
+	opts.fieldCardinalityTopN = value
+	return &opts
+}
+
+func (o *opts) FieldCardinalityTopN() int {
+	return o.fieldCardinalityTopN
+}
+
 func (o *opts) SetPostingsListCache(value *PostingsListCache) Options {
 	opts := *o
 	opts.postingsListCache = value
@@ -382,6 +452,16 @@ func (o *opts) ReadThroughSegmentOptions() ReadThroughSegmentOptions {
 	return o.readThroughSegmentOptions
 }
 
+func (o *opts) SetRegexpCacheOptions(value m3ninxindex.RegexpCacheOptions) Options {
+	opts := *o
+	opts.regexpCacheOptions = value
+	return &opts
+}
+
+func (o *opts) RegexpCacheOptions() m3ninxindex.RegexpCacheOptions {
+	return o.regexpCacheOptions
+}
+
 func (o *opts) SetForwardIndexProbability(value float64) Options {
 	opts := *o
 	opts.forwardIndexProbability = value
@@ -0,0 +1,73 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"sync"
+
+	"github.com/m3db/m3/src/dbnode/storage/durability"
+)
+
+// QueryDurabilityTracker collects the durability of the data returned by a
+// query from each of the nodes that served it concurrently. A nil tracker
+// collects nothing.
+type QueryDurabilityTracker struct {
+	sync.Mutex
+
+	tracked bool
+	ranges  durability.Ranges
+}
+
+// NewQueryDurabilityTracker returns a new tracker of the durability of the
+// data returned by a query.
+func NewQueryDurabilityTracker() *QueryDurabilityTracker {
+	return &QueryDurabilityTracker{}
+}
+
+// Track combines the durability of the data returned by a node with that of
+// the nodes tracked so far, keeping the lowest durability at each point in
+// time since the data returned is merged from all of the nodes.
+func (t *QueryDurabilityTracker) Track(ranges durability.Ranges) {
+	if t == nil {
+		return
+	}
+
+	t.Lock()
+	if !t.tracked {
+		t.tracked = true
+		t.ranges = append(durability.Ranges(nil), ranges...)
+	} else {
+		t.ranges = t.ranges.Min(ranges)
+	}
+	t.Unlock()
+}
+
+// Ranges returns the durability of the data returned by the query, it
+// returns nil for a nil tracker or if no node reported its durability.
+func (t *QueryDurabilityTracker) Ranges() durability.Ranges {
+	if t == nil {
+		return nil
+	}
+
+	t.Lock()
+	defer t.Unlock()
+	return append(durability.Ranges(nil), t.ranges...)
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage/durability"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryDurabilityTracker(t *testing.T) {
+	var (
+		start = time.Now().Truncate(time.Hour)
+		mid   = start.Add(time.Hour)
+		end   = start.Add(2 * time.Hour)
+	)
+
+	var nilTracker *QueryDurabilityTracker
+	nilTracker.Track(durability.Ranges{{Start: start, End: end, Level: durability.Flushed}})
+	require.Nil(t, nilTracker.Ranges())
+
+	tracker := NewQueryDurabilityTracker()
+	require.Nil(t, tracker.Ranges())
+
+	tracker.Track(durability.Ranges{
+		{Start: start, End: mid, Level: durability.Flushed},
+		{Start: mid, End: end, Level: durability.Snapshot},
+	})
+	tracker.Track(durability.Ranges{
+		{Start: start, End: end, Level: durability.Snapshot},
+	})
+	require.Equal(t, durability.Ranges{
+		{Start: start, End: end, Level: durability.Snapshot},
+	}, tracker.Ranges())
+
+	// A node that did not report its durability leaves it unknown.
+	tracker.Track(nil)
+	require.Empty(t, tracker.Ranges())
+}
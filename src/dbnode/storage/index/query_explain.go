@@ -0,0 +1,108 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package index
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// QueryExplain describes how a query was executed across the index blocks
+// and segments it searched, it is meant for debugging slow queries.
+type QueryExplain struct {
+	// Duration is the time spent executing the query.
+	Duration time.Duration
+	// Blocks are the blocks queried ordered by block start.
+	Blocks []BlockQueryExplain
+}
+
+// BlockQueryExplain describes the execution of a query against an index
+// block.
+type BlockQueryExplain struct {
+	// BlockStart is the start of the block.
+	BlockStart time.Time
+	// Duration is the time spent querying the block, including waiting for
+	// its cold segments to be loaded.
+	Duration time.Duration
+	// DocsMatched is the number of documents matched by the segments of the
+	// block, including those then skipped as deleted or expired.
+	DocsMatched int
+	// Segments are the segments searched in the order they were searched.
+	Segments []SegmentQueryExplain
+}
+
+// SegmentQueryExplain describes the search of an index segment.
+type SegmentQueryExplain struct {
+	// SearchDuration is the time spent searching the segment for the postings
+	// list of the query, excluding the time spent reading the documents.
+	SearchDuration time.Duration
+	// PostingsCardinality is the cardinality of the postings list matched.
+	PostingsCardinality int
+	// DocsMatched is the number of documents read from the segment.
+	DocsMatched int
+}
+
+// QueryExplainTracker collects the explanation of a query from the blocks it
+// queries concurrently. A nil tracker collects nothing.
+type QueryExplainTracker struct {
+	sync.Mutex
+
+	start  time.Time
+	blocks []BlockQueryExplain
+}
+
+// NewQueryExplainTracker returns a new tracker of the explanation of a query
+// started at start.
+func NewQueryExplainTracker(start time.Time) *QueryExplainTracker {
+	return &QueryExplainTracker{start: start}
+}
+
+// TrackBlock adds the explanation of querying a block.
+func (t *QueryExplainTracker) TrackBlock(b BlockQueryExplain) {
+	if t == nil {
+		return
+	}
+
+	t.Lock()
+	t.blocks = append(t.blocks, b)
+	t.Unlock()
+}
+
+// Explain returns the explanation of the query finished at end, it returns
+// nil for a nil tracker.
+func (t *QueryExplainTracker) Explain(end time.Time) *QueryExplain {
+	if t == nil {
+		return nil
+	}
+
+	t.Lock()
+	blocks := make([]BlockQueryExplain, len(t.blocks))
+	copy(blocks, t.blocks)
+	t.Unlock()
+
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].BlockStart.Before(blocks[j].BlockStart)
+	})
+	return &QueryExplain{
+		Duration: end.Sub(t.start),
+		Blocks:   blocks,
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package index
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryExplainTrackerNil(t *testing.T) {
+	var tracker *QueryExplainTracker
+	tracker.TrackBlock(BlockQueryExplain{})
+	require.Nil(t, tracker.Explain(time.Now()))
+}
+
+func TestQueryExplainTrackerOrdersBlocks(t *testing.T) {
+	var (
+		start  = time.Now().Truncate(time.Hour)
+		first  = start.Add(-2 * time.Hour)
+		second = start.Add(-time.Hour)
+	)
+	tracker := NewQueryExplainTracker(start)
+	tracker.TrackBlock(BlockQueryExplain{BlockStart: second, DocsMatched: 2})
+	tracker.TrackBlock(BlockQueryExplain{BlockStart: first, DocsMatched: 1})
+
+	explain := tracker.Explain(start.Add(time.Minute))
+	require.Equal(t, time.Minute, explain.Duration)
+	require.Equal(t, []BlockQueryExplain{
+		{BlockStart: first, DocsMatched: 1},
+		{BlockStart: second, DocsMatched: 2},
+	}, explain.Blocks)
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package index
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/m3ninx/doc"
+)
+
+// queryResourceCheckDurationEvery is how often, in tracked documents or
+// terms, a query checks whether it has run for longer than its limit.
+const queryResourceCheckDurationEvery = 128
+
+// QueryLimitReason is the limit that caused a query to return partial
+// results.
+type QueryLimitReason uint32
+
+const (
+	// QueryLimitNone means the query did not exceed any limit.
+	QueryLimitNone QueryLimitReason = iota
+	// QueryLimitSeries means the query matched more series than its limit.
+	QueryLimitSeries
+	// QueryLimitDocs means the query scanned more documents than its limit.
+	QueryLimitDocs
+	// QueryLimitBytesRead means the query read more bytes of documents and
+	// terms than its limit.
+	QueryLimitBytesRead
+	// QueryLimitDuration means the query ran for longer than its limit.
+	QueryLimitDuration
+)
+
+func (r QueryLimitReason) String() string {
+	switch r {
+	case QueryLimitNone:
+		return "none"
+	case QueryLimitSeries:
+		return "series"
+	case QueryLimitDocs:
+		return "docs"
+	case QueryLimitBytesRead:
+		return "bytes-read"
+	case QueryLimitDuration:
+		return "duration"
+	}
+	return "unknown"
+}
+
+// QueryResourceLimits are limits on the resources a query may use before it
+// stops and returns the results found so far as non-exhaustive, a zero limit
+// is not enforced.
+type QueryResourceLimits struct {
+	// Docs is the maximum number of documents scanned.
+	Docs int64
+	// BytesRead is the maximum number of bytes of the documents scanned and,
+	// for aggregate queries, of the fields and terms read.
+	BytesRead int64
+	// Duration is the maximum wall time of the query.
+	Duration time.Duration
+}
+
+// IsZero returns whether no limit is set.
+func (l QueryResourceLimits) IsZero() bool {
+	return l.Docs <= 0 && l.BytesRead <= 0 && l.Duration <= 0
+}
+
+// QueryResourceTracker tracks the resources used by a query across the blocks
+// it queries concurrently along with the first limit it exceeded. A nil
+// tracker tracks nothing.
+type QueryResourceTracker struct {
+	// NB: The atomically accessed fields are first to keep them 64-bit
+	// aligned on 32-bit platforms.
+	docs      int64
+	bytesRead int64
+	calls     int64
+	reason    uint32
+
+	limits   QueryResourceLimits
+	deadline time.Time
+	nowFn    func() time.Time
+}
+
+// NewQueryResourceTracker returns a new tracker of the resources used by a
+// query started at start.
+func NewQueryResourceTracker(
+	limits QueryResourceLimits,
+	start time.Time,
+	nowFn func() time.Time,
+) *QueryResourceTracker {
+	t := &QueryResourceTracker{
+		limits: limits,
+		nowFn:  nowFn,
+	}
+	if limits.Duration > 0 {
+		t.deadline = start.Add(limits.Duration)
+	}
+	return t
+}
+
+// TrackDocument tracks a scanned document and returns whether the query may
+// continue.
+func (t *QueryResourceTracker) TrackDocument(d doc.Document) bool {
+	if t == nil {
+		return true
+	}
+
+	bytesRead := int64(len(d.ID))
+	for _, f := range d.Fields {
+		bytesRead += int64(len(f.Name) + len(f.Value))
+	}
+	docs := atomic.AddInt64(&t.docs, 1)
+	if t.limits.Docs > 0 && docs > t.limits.Docs {
+		t.exceed(QueryLimitDocs)
+		return false
+	}
+	return t.track(bytesRead)
+}
+
+// TrackTerm tracks a field and term read by an aggregate query and returns
+// whether the query may continue.
+func (t *QueryResourceTracker) TrackTerm(field, term []byte) bool {
+	if t == nil {
+		return true
+	}
+
+	return t.track(int64(len(field) + len(term)))
+}
+
+func (t *QueryResourceTracker) track(bytesRead int64) bool {
+	if t.Exceeded() != QueryLimitNone {
+		return false
+	}
+	if n := atomic.AddInt64(&t.bytesRead, bytesRead); t.limits.BytesRead > 0 && n > t.limits.BytesRead {
+		t.exceed(QueryLimitBytesRead)
+		return false
+	}
+	if !t.deadline.IsZero() && atomic.AddInt64(&t.calls, 1)%queryResourceCheckDurationEvery == 0 {
+		return t.CheckDuration()
+	}
+	return true
+}
+
+// CheckDuration returns whether the query may continue given its duration.
+func (t *QueryResourceTracker) CheckDuration() bool {
+	if t == nil {
+		return true
+	}
+	if t.Exceeded() != QueryLimitNone {
+		return false
+	}
+	if !t.deadline.IsZero() && !t.nowFn().Before(t.deadline) {
+		t.exceed(QueryLimitDuration)
+		return false
+	}
+	return true
+}
+
+// Exceeded returns the first limit the query exceeded, if any.
+func (t *QueryResourceTracker) Exceeded() QueryLimitReason {
+	if t == nil {
+		return QueryLimitNone
+	}
+	return QueryLimitReason(atomic.LoadUint32(&t.reason))
+}
+
+func (t *QueryResourceTracker) exceed(reason QueryLimitReason) {
+	atomic.CompareAndSwapUint32(&t.reason, uint32(QueryLimitNone), uint32(reason))
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package index
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/m3ninx/doc"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryLimitReasonString(t *testing.T) {
+	require.Equal(t, "none", QueryLimitNone.String())
+	require.Equal(t, "series", QueryLimitSeries.String())
+	require.Equal(t, "docs", QueryLimitDocs.String())
+	require.Equal(t, "bytes-read", QueryLimitBytesRead.String())
+	require.Equal(t, "duration", QueryLimitDuration.String())
+}
+
+func TestQueryResourceTrackerNil(t *testing.T) {
+	var tracker *QueryResourceTracker
+	require.True(t, tracker.TrackDocument(testDoc1()))
+	require.True(t, tracker.TrackTerm([]byte("bar"), []byte("baz")))
+	require.True(t, tracker.CheckDuration())
+	require.Equal(t, QueryLimitNone, tracker.Exceeded())
+}
+
+func TestQueryResourceTrackerDocs(t *testing.T) {
+	tracker := NewQueryResourceTracker(QueryResourceLimits{Docs: 2},
+		time.Now(), time.Now)
+	require.True(t, tracker.TrackDocument(testDoc1()))
+	require.True(t, tracker.TrackDocument(testDoc2()))
+	require.Equal(t, QueryLimitNone, tracker.Exceeded())
+
+	require.False(t, tracker.TrackDocument(testDoc3()))
+	require.Equal(t, QueryLimitDocs, tracker.Exceeded())
+
+	// The first limit exceeded is retained.
+	require.False(t, tracker.TrackTerm([]byte("bar"), []byte("baz")))
+	require.False(t, tracker.CheckDuration())
+	require.Equal(t, QueryLimitDocs, tracker.Exceeded())
+}
+
+func TestQueryResourceTrackerBytesRead(t *testing.T) {
+	d := doc.Document{
+		ID:     []byte("foo"),
+		Fields: []doc.Field{{Name: []byte("bar"), Value: []byte("baz")}},
+	}
+	tracker := NewQueryResourceTracker(QueryResourceLimits{BytesRead: 12},
+		time.Now(), time.Now)
+	require.True(t, tracker.TrackDocument(d))
+	require.True(t, tracker.TrackTerm([]byte("qu"), []byte("x")))
+	require.Equal(t, QueryLimitNone, tracker.Exceeded())
+
+	require.False(t, tracker.TrackTerm([]byte("q"), nil))
+	require.Equal(t, QueryLimitBytesRead, tracker.Exceeded())
+}
+
+func TestQueryResourceTrackerDuration(t *testing.T) {
+	start := time.Now()
+	now := start
+	nowFn := func() time.Time { return now }
+	tracker := NewQueryResourceTracker(QueryResourceLimits{Duration: time.Minute},
+		start, nowFn)
+
+	now = start.Add(time.Minute)
+	for i := 0; i < queryResourceCheckDurationEvery-1; i++ {
+		require.True(t, tracker.TrackTerm([]byte("bar"), []byte("baz")))
+	}
+	require.False(t, tracker.TrackTerm([]byte("bar"), []byte("baz")))
+	require.Equal(t, QueryLimitDuration, tracker.Exceeded())
+}
+
+func TestQueryResourceTrackerCheckDuration(t *testing.T) {
+	start := time.Now()
+	now := start
+	nowFn := func() time.Time { return now }
+	tracker := NewQueryResourceTracker(QueryResourceLimits{Duration: time.Minute},
+		start, nowFn)
+	require.True(t, tracker.CheckDuration())
+
+	now = start.Add(time.Minute)
+	require.False(t, tracker.CheckDuration())
+	require.Equal(t, QueryLimitDuration, tracker.Exceeded())
+}
@@ -64,6 +64,10 @@ type ReadThroughSegmentOptions struct {
 	CacheRegexp bool
 	// Whether the postings list for term queries should be cached.
 	CacheTerms bool
+	// Whether the postings lists of the segments compacted from the mutable
+	// segments of open blocks should be cached, their entries are purged when
+	// a later compaction rotates them out.
+	CacheCompactedSegments bool
 }
 
 // NewReadThroughSegment creates a new read through segment.
@@ -31,6 +31,9 @@ type readableSeg struct {
 	nowFn     clock.NowFn
 	createdAt time.Time
 	segment   segment.Segment
+	// fieldCardinality is the cardinality of the fields of the segment if
+	// computed as it was built.
+	fieldCardinality segmentFieldCardinality
 }
 
 func newReadableSeg(seg segment.Segment, opts Options) *readableSeg {
@@ -30,6 +30,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/index/compaction"
 	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/m3ninx/idx"
+	m3ninxindex "github.com/m3db/m3/src/m3ninx/index"
 	"github.com/m3db/m3/src/m3ninx/index/segment"
 	"github.com/m3db/m3/src/m3ninx/index/segment/builder"
 	"github.com/m3db/m3/src/m3ninx/index/segment/fst"
@@ -79,6 +80,37 @@ type QueryOptions struct {
 	StartInclusive time.Time
 	EndExclusive   time.Time
 	Limit          int
+	// IncludeDeleted includes series that have been marked deleted in the
+	// results, such as for audit queries.
+	IncludeDeleted bool
+	// Paginate returns the results a page of at most Limit results at a time
+	// along with a cursor to resume the query from. A page can only end
+	// within an index block once the block is sealed, pages that would end
+	// within a block still taking writes fail with an invalid params error.
+	Paginate bool
+	// Cursor is the opaque cursor returned with the previous page of a
+	// paginated query, it is empty for the first page.
+	Cursor []byte
+	// ResourceLimits limits the resources the query may use before returning
+	// partial results, Limit is the limit of the series matched.
+	ResourceLimits QueryResourceLimits
+	// ResourceTracker tracks the resources used by the query against its
+	// resource limits, it is set by the namespace index when executing the
+	// query.
+	ResourceTracker *QueryResourceTracker
+	// Explain collects how the query was executed across the blocks and
+	// segments it searched and returns it with the query result.
+	Explain bool
+	// ExplainTracker collects the explanation of the query, it is set by the
+	// namespace index when executing a query with Explain set.
+	ExplainTracker *QueryExplainTracker
+	// IncludeDurability requests the durability of the data returned by a
+	// fetch of the query from the nodes, which is collected by the
+	// DurabilityTracker set by the caller of the client fetch.
+	IncludeDurability bool
+	// DurabilityTracker collects the durability of the data returned by a
+	// client fetch with IncludeDurability set.
+	DurabilityTracker *QueryDurabilityTracker
 }
 
 // LimitExceeded returns whether a given size exceeds the limit
@@ -98,12 +130,25 @@ type AggregationOptions struct {
 type QueryResult struct {
 	Results    QueryResults
 	Exhaustive bool
+	// Cursor resumes a paginated query after the results, it is empty once
+	// the query has returned all of its results.
+	Cursor []byte
+	// LimitReason is the limit that caused the results to not be exhaustive.
+	LimitReason QueryLimitReason
+	// Explain describes how the query was executed, it is only set when the
+	// query options requested it.
+	Explain *QueryExplain
 }
 
 // AggregateQueryResult is the collection of results for an aggregate query.
 type AggregateQueryResult struct {
 	Results    AggregateResults
 	Exhaustive bool
+	// Cursor resumes a paginated query after the results, it is empty once
+	// the query has returned all of its results.
+	Cursor []byte
+	// LimitReason is the limit that caused the results to not be exhaustive.
+	LimitReason QueryLimitReason
 }
 
 // BaseResults is a collection of basic results for a generic query, it is
@@ -208,6 +253,36 @@ type AggregateResults interface {
 // filter are returned.
 type AggregateFieldFilter [][]byte
 
+// AggregateIterator iterates over the distinct fields and terms of index
+// segments in order, terms are nil when only aggregating tag names.
+type AggregateIterator interface {
+	// Next returns a bool indicating if there are any more elements.
+	Next() bool
+
+	// Current returns the current element.
+	// NB: the element returned is only valid until the subsequent call to Next().
+	Current() (field, term []byte)
+
+	// Err returns any errors encountered during iteration.
+	Err() error
+
+	// Close releases any resources held by the iterator.
+	Close() error
+}
+
+// AggregateStreamFn is called with each distinct field and term streamed by
+// an aggregate query, the bytes are only valid for the duration of the call
+// and returning an error stops the query. The query waits for the function
+// to return so that a slow consumer slows the query down.
+type AggregateStreamFn func(field, term []byte) error
+
+// AggregateStreamResult is the outcome of a streamed aggregate query.
+type AggregateStreamResult struct {
+	Exhaustive bool
+	// LimitReason is the limit that caused the results to not be exhaustive.
+	LimitReason QueryLimitReason
+}
+
 // AggregateResultsOptions is a set of options to use for results.
 type AggregateResultsOptions struct {
 	// SizeLimit will limit the total results set to a given limit and if
@@ -323,15 +398,52 @@ type Block interface {
 		logFields []opentracinglog.Field,
 	) (exhaustive bool, err error)
 
+	// AggregateIter returns an iterator over the distinct tag names and, if
+	// aggregating tag names and values, values of the block in order. The
+	// block is read locked until the iterator is closed.
+	AggregateIter(
+		opts QueryOptions,
+		aggOpts AggregateResultsOptions,
+	) (AggregateIterator, error)
+
+	// Completion adds the tag names and values of the block that match a
+	// completion query to the results.
+	// NB: like Aggregate it relies purely on the indexed FSTs, so it may
+	// include the tags of deleted and expired series.
+	Completion(
+		cancellable *resource.CancellableLifetime,
+		results *CompletionResults,
+	) (exhaustive bool, err error)
+
 	// AddResults adds bootstrap results to the block.
 	AddResults(results result.IndexBlock) error
 
+	// MarkDeleted marks the series with the given IDs as deleted so that they
+	// are excluded from the results of queries and aggregations that do not
+	// include deleted series, until the series are written to the block again.
+	MarkDeleted(ids []ident.ID) error
+
+	// MarkExpired marks the series with the given IDs as expired since they
+	// no longer have any data within retention so that they are excluded from
+	// the results of queries and aggregations, until the series are written
+	// to the block again.
+	MarkExpired(ids []ident.ID) error
+
 	// Tick does internal house keeping operations.
 	Tick(c context.Cancellable, tickStart time.Time) (BlockTickResult, error)
 
 	// Stats returns block stats.
 	Stats(reporter BlockStatsReporter) error
 
+	// FieldCardinality returns the cardinality of the fields of the block
+	// ordered by name, computed from the cardinality of its segments as they
+	// were built, it returns nil if computing the field cardinality is
+	// disabled.
+	FieldCardinality() ([]FieldCardinality, error)
+
+	// State returns the state of the block and of its segments.
+	State() (BlockState, error)
+
 	// Seal prevents the block from taking any more writes, but, it still permits
 	// addition of segments via Bootstrap().
 	Seal() error
@@ -351,6 +463,20 @@ type Block interface {
 	// data the mutable segments should have held at this time.
 	EvictMutableSegments() error
 
+	// EvictColdSegments closes the flushed segments of a sealed block that
+	// holds no other segments to release its memory, the segments are loaded
+	// back from disk the next time the block is queried. It requires the
+	// block options to set a cold segments loader.
+	EvictColdSegments() error
+
+	// IsCold returns whether the segments of the block are evicted.
+	IsCold() bool
+
+	// SnapshotSegments persists the documents of every segment of a block
+	// that has not been flushed yet with the given function, it returns
+	// false if there was nothing to snapshot.
+	SnapshotSegments(persistFn func(segment.Builder) error) (bool, error)
+
 	// Close will release any held resources and close the Block.
 	Close() error
 }
@@ -643,13 +769,18 @@ func (b *WriteBatch) SortByEnqueued() {
 // MarkUnmarkedEntriesSuccess marks all unmarked entries as success.
 func (b *WriteBatch) MarkUnmarkedEntriesSuccess() {
 	for idx := range b.entries {
-		if !b.entries[idx].result.Done {
-			blockStart := b.entries[idx].indexBlockStart(b.opts.IndexBlockSize)
-			b.entries[idx].OnIndexSeries.OnIndexSuccess(blockStart)
-			b.entries[idx].OnIndexSeries.OnIndexFinalize(blockStart)
-			b.entries[idx].result.Done = true
-			b.entries[idx].result.Err = nil
-		}
+		b.MarkUnmarkedEntrySuccess(idx)
+	}
+}
+
+// MarkUnmarkedEntrySuccess marks an unmarked entry at index as success.
+func (b *WriteBatch) MarkUnmarkedEntrySuccess(idx int) {
+	if !b.entries[idx].result.Done {
+		blockStart := b.entries[idx].indexBlockStart(b.opts.IndexBlockSize)
+		b.entries[idx].OnIndexSeries.OnIndexSuccess(blockStart)
+		b.entries[idx].OnIndexSeries.OnIndexFinalize(blockStart)
+		b.entries[idx].result.Done = true
+		b.entries[idx].result.Err = nil
 	}
 }
 
@@ -673,6 +804,20 @@ func (b *WriteBatch) MarkUnmarkedEntryError(
 	}
 }
 
+// FinalizeUnmarkedEntries finalizes all unmarked entries without marking
+// them as indexed so that they are indexed again by a later write.
+func (b *WriteBatch) FinalizeUnmarkedEntries() {
+	for idx := range b.entries {
+		if b.entries[idx].result.Done {
+			continue
+		}
+		blockStart := b.entries[idx].indexBlockStart(b.opts.IndexBlockSize)
+		b.entries[idx].OnIndexSeries.OnIndexFinalize(blockStart)
+		b.entries[idx].result.Done = true
+		b.entries[idx].result.Err = nil
+	}
+}
+
 // Ensure that WriteBatch meets the sort interface
 var _ sort.Interface = (*WriteBatch)(nil)
 
@@ -867,6 +1012,54 @@ type Options interface {
 	// BackgroundCompactionPlannerOptions returns the compaction planner options.
 	BackgroundCompactionPlannerOptions() compaction.PlannerOptions
 
+	// SetMaxConcurrentBackgroundCompactions sets the max number of blocks of
+	// a namespace that are background compacted concurrently, zero is
+	// unlimited.
+	SetMaxConcurrentBackgroundCompactions(value int) Options
+
+	// MaxConcurrentBackgroundCompactions returns the max number of blocks of
+	// a namespace that are background compacted concurrently, zero is
+	// unlimited.
+	MaxConcurrentBackgroundCompactions() int
+
+	// SetColdBlockAge sets the age, past the end of an index block, after
+	// which the flushed segments of the block are evicted from memory and
+	// only loaded from disk when queried, zero keeps every block in memory.
+	SetColdBlockAge(value time.Duration) Options
+
+	// ColdBlockAge returns the age, past the end of an index block, after
+	// which the flushed segments of the block are evicted from memory and
+	// only loaded from disk when queried, zero keeps every block in memory.
+	ColdBlockAge() time.Duration
+
+	// SetQuerySegmentsConcurrently sets whether the segments of an index block
+	// are searched concurrently by the query workers that are idle.
+	SetQuerySegmentsConcurrently(value bool) Options
+
+	// QuerySegmentsConcurrently returns whether the segments of an index block
+	// are searched concurrently by the query workers that are idle.
+	QuerySegmentsConcurrently() bool
+
+	// SetSnapshotEnabled sets whether the index blocks that have not been
+	// flushed yet are snapshotted so that bootstrapping loads them instead
+	// of indexing the commit log again.
+	SetSnapshotEnabled(value bool) Options
+
+	// SnapshotEnabled returns whether the index blocks that have not been
+	// flushed yet are snapshotted so that bootstrapping loads them instead
+	// of indexing the commit log again.
+	SnapshotEnabled() bool
+
+	// SetFieldCardinalityTopN sets the number of values with the most series
+	// kept per field when computing the cardinality of the fields of segments
+	// as they are built, zero disables computing the field cardinality.
+	SetFieldCardinalityTopN(value int) Options
+
+	// FieldCardinalityTopN returns the number of values with the most series
+	// kept per field when computing the cardinality of the fields of segments
+	// as they are built, zero disables computing the field cardinality.
+	FieldCardinalityTopN() int
+
 	// SetPostingsListCache sets the postings list cache.
 	SetPostingsListCache(value *PostingsListCache) Options
 
@@ -879,6 +1072,14 @@ type Options interface {
 	// ReadThroughSegmentOptions returns the read through segment cache options.
 	ReadThroughSegmentOptions() ReadThroughSegmentOptions
 
+	// SetRegexpCacheOptions sets the options for the cache of compiled regexps
+	// shared by all index queries.
+	SetRegexpCacheOptions(value m3ninxindex.RegexpCacheOptions) Options
+
+	// RegexpCacheOptions returns the options for the cache of compiled regexps
+	// shared by all index queries.
+	RegexpCacheOptions() m3ninxindex.RegexpCacheOptions
+
 	// SetForwardIndexProbability sets the probability chance for forward writes.
 	SetForwardIndexProbability(value float64) Options
 
@@ -0,0 +1,152 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/x/ident"
+
+	dpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+// tagsWithAnnotationFields returns the tags with the values of the given
+// fields of a proto annotation appended, fields that are not set, are not
+// scalars or are already tags are skipped. The tags are returned unchanged
+// if there is nothing to extract.
+func tagsWithAnnotationFields(
+	tags ident.TagIterator,
+	fields []string,
+	schema namespace.SchemaDescr,
+	annotation []byte,
+) (ident.TagIterator, error) {
+	if len(fields) == 0 || schema == nil || len(annotation) == 0 {
+		return tags, nil
+	}
+
+	md := schema.Get().MessageDescriptor
+	if md == nil {
+		return tags, nil
+	}
+	msg := dynamic.NewMessage(md)
+	if err := msg.Unmarshal(annotation); err != nil {
+		return nil, err
+	}
+
+	var (
+		iter   = tags.Duplicate()
+		result = make([]ident.Tag, 0, iter.Remaining()+len(fields))
+	)
+	defer iter.Close()
+	for iter.Next() {
+		tag := iter.Current()
+		result = append(result, ident.Tag{
+			Name:  ident.BytesID(tag.Name.Bytes()),
+			Value: ident.BytesID(tag.Value.Bytes()),
+		})
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	numTags := len(result)
+	for _, field := range fields {
+		value, ok := annotationFieldValue(msg, field)
+		if !ok || hasTagName(result[:numTags], field) {
+			continue
+		}
+		result = append(result, ident.StringTag(field, value))
+	}
+	if len(result) == numTags {
+		return tags, nil
+	}
+	return ident.NewTagsIterator(ident.NewTags(result...)), nil
+}
+
+// annotationFieldValue returns the value of the field at the dot separated
+// path formatted as a tag value.
+func annotationFieldValue(msg *dynamic.Message, path string) (string, bool) {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		fd := msg.GetMessageDescriptor().FindFieldByName(name)
+		if fd == nil || fd.IsRepeated() || !msg.HasField(fd) {
+			return "", false
+		}
+		value := msg.GetField(fd)
+		if i < len(names)-1 {
+			nested, ok := value.(*dynamic.Message)
+			if !ok {
+				return "", false
+			}
+			msg = nested
+			continue
+		}
+		return formatAnnotationField(fd, value)
+	}
+	return "", false
+}
+
+func formatAnnotationField(fd *desc.FieldDescriptor, value interface{}) (string, bool) {
+	var formatted string
+	switch v := value.(type) {
+	case string:
+		formatted = v
+	case []byte:
+		formatted = string(v)
+	case bool:
+		formatted = strconv.FormatBool(v)
+	case int32:
+		if fd.GetType() == dpb.FieldDescriptorProto_TYPE_ENUM {
+			enumValue := fd.GetEnumType().FindValueByNumber(v)
+			if enumValue == nil {
+				return "", false
+			}
+			formatted = enumValue.GetName()
+			break
+		}
+		formatted = strconv.FormatInt(int64(v), 10)
+	case int64:
+		formatted = strconv.FormatInt(v, 10)
+	case uint32:
+		formatted = strconv.FormatUint(uint64(v), 10)
+	case uint64:
+		formatted = strconv.FormatUint(v, 10)
+	case float32:
+		formatted = strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		formatted = strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return "", false
+	}
+	return formatted, formatted != ""
+}
+
+func hasTagName(tags []ident.Tag, name string) bool {
+	for _, tag := range tags {
+		if string(tag.Name.Bytes()) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/testdata/prototest"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagsWithAnnotationFields(t *testing.T) {
+	schemaHistory := prototest.NewSchemaHistory()
+	schema, ok := schemaHistory.GetLatest()
+	require.True(t, ok)
+
+	msg := dynamic.NewMessage(prototest.NewMessageDescriptor(schemaHistory))
+	msg.SetFieldByName("latitude", 0.5)
+	msg.SetFieldByName("epoch", int64(42))
+	msg.SetFieldByName("deliveryID", []byte("abc"))
+	msg.SetFieldByName("attributes", map[string]string{"key": "value"})
+	annotation, err := msg.Marshal()
+	require.NoError(t, err)
+
+	tags := ident.NewTagsIterator(ident.NewTags(
+		ident.StringTag("foo", "bar"),
+		ident.StringTag("epoch", "existing"),
+	))
+	fields := []string{"latitude", "epoch", "deliveryID", "longitude", "attributes", "unknown"}
+
+	result, err := tagsWithAnnotationFields(tags, fields, schema, annotation)
+	require.NoError(t, err)
+	require.Equal(t, 0, tags.CurrentIndex())
+
+	actual := make(map[string]string)
+	for result.Next() {
+		tag := result.Current()
+		actual[tag.Name.String()] = tag.Value.String()
+	}
+	require.NoError(t, result.Err())
+	require.Equal(t, map[string]string{
+		"foo":        "bar",
+		"epoch":      "existing",
+		"latitude":   "0.5",
+		"deliveryID": "abc",
+	}, actual)
+}
+
+func TestTagsWithAnnotationFieldsNothingToExtract(t *testing.T) {
+	schemaHistory := prototest.NewSchemaHistory()
+	schema, ok := schemaHistory.GetLatest()
+	require.True(t, ok)
+
+	tags := ident.NewTagsIterator(ident.NewTags(ident.StringTag("foo", "bar")))
+
+	result, err := tagsWithAnnotationFields(tags, nil, schema, []byte("annotation"))
+	require.NoError(t, err)
+	require.True(t, result == tags)
+
+	result, err = tagsWithAnnotationFields(tags, []string{"epoch"}, nil, []byte("annotation"))
+	require.NoError(t, err)
+	require.True(t, result == tags)
+
+	result, err = tagsWithAnnotationFields(tags, []string{"epoch"}, schema, nil)
+	require.NoError(t, err)
+	require.True(t, result == tags)
+
+	_, err = tagsWithAnnotationFields(tags, []string{"epoch"}, schema, []byte{0xff})
+	require.Error(t, err)
+}
@@ -27,13 +27,16 @@ import (
 	"testing"
 	"time"
 
+	indexpb "github.com/m3db/m3/src/dbnode/generated/proto/index"
 	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/m3ninx/idx"
 	"github.com/m3db/m3/src/m3ninx/index/segment"
 	"github.com/m3db/m3/src/x/context"
+	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
 	xtest "github.com/m3db/m3/src/x/test"
 	xtime "github.com/m3db/m3/src/x/time"
@@ -156,6 +159,70 @@ func TestNamespaceIndexNewBlockFn(t *testing.T) {
 	require.Equal(t, mockBlock, blk)
 }
 
+func TestNamespaceIndexMarkDeleted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	blockSize := time.Hour
+	now := time.Now().Truncate(blockSize).Add(2 * time.Minute)
+	nowFn := func() time.Time { return now }
+	opts := DefaultTestOptions()
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(nowFn))
+
+	ids := []ident.ID{ident.StringID("foo")}
+	mockBlock := index.NewMockBlock(ctrl)
+	mockBlock.EXPECT().Stats(gomock.Any()).Return(nil).AnyTimes()
+	mockBlock.EXPECT().MarkDeleted(ids).Return(nil)
+	mockBlock.EXPECT().Close().Return(nil)
+	newBlockFn := func(
+		ts time.Time,
+		md namespace.Metadata,
+		_ index.BlockOptions,
+		io index.Options,
+	) (index.Block, error) {
+		return mockBlock, nil
+	}
+	md := testNamespaceMetadata(blockSize, 4*time.Hour)
+	idx, err := newNamespaceIndexWithNewBlockFn(md, newBlockFn, opts)
+	require.NoError(t, err)
+
+	require.NoError(t, idx.MarkDeleted(ids))
+	require.NoError(t, idx.Close())
+	require.Equal(t, errDbIndexUnableToMarkDeletedClosed, idx.MarkDeleted(ids))
+}
+
+func TestNamespaceIndexMarkExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	blockSize := time.Hour
+	now := time.Now().Truncate(blockSize).Add(2 * time.Minute)
+	nowFn := func() time.Time { return now }
+	opts := DefaultTestOptions()
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(nowFn))
+
+	ids := []ident.ID{ident.StringID("foo")}
+	mockBlock := index.NewMockBlock(ctrl)
+	mockBlock.EXPECT().Stats(gomock.Any()).Return(nil).AnyTimes()
+	mockBlock.EXPECT().MarkExpired(ids).Return(nil)
+	mockBlock.EXPECT().Close().Return(nil)
+	newBlockFn := func(
+		ts time.Time,
+		md namespace.Metadata,
+		_ index.BlockOptions,
+		io index.Options,
+	) (index.Block, error) {
+		return mockBlock, nil
+	}
+	md := testNamespaceMetadata(blockSize, 4*time.Hour)
+	idx, err := newNamespaceIndexWithNewBlockFn(md, newBlockFn, opts)
+	require.NoError(t, err)
+
+	require.NoError(t, idx.MarkExpired(ids))
+	require.NoError(t, idx.Close())
+	require.Equal(t, errDbIndexUnableToMarkExpiredClosed, idx.MarkExpired(ids))
+}
+
 func TestNamespaceIndexNewBlockFnRandomErr(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -609,6 +676,325 @@ func TestNamespaceIndexBlockQuery(t *testing.T) {
 	require.Len(t, spans, 11)
 }
 
+func TestNamespaceIndexFieldCardinality(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{T: t})
+	defer ctrl.Finish()
+
+	retention := 2 * time.Hour
+	blockSize := time.Hour
+	now := time.Now().Truncate(blockSize).Add(10 * time.Minute)
+	t0 := now.Truncate(blockSize)
+	t0Nanos := xtime.ToUnixNano(t0)
+	t1 := t0.Add(1 * blockSize)
+	t1Nanos := xtime.ToUnixNano(t1)
+	t2 := t1.Add(1 * blockSize)
+	opts := DefaultTestOptions()
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return now
+	}))
+
+	newBlock := func(start time.Time) *index.MockBlock {
+		b := index.NewMockBlock(ctrl)
+		b.EXPECT().Stats(gomock.Any()).Return(nil).AnyTimes()
+		b.EXPECT().Close().Return(nil)
+		b.EXPECT().StartTime().Return(start).AnyTimes()
+		b.EXPECT().EndTime().Return(start.Add(blockSize)).AnyTimes()
+		return b
+	}
+	md := testNamespaceMetadata(blockSize, retention)
+
+	// Field cardinality is only reported once enabled.
+	idx, err := newNamespaceIndexWithNewBlockFn(md, func(
+		ts time.Time,
+		md namespace.Metadata,
+		_ index.BlockOptions,
+		io index.Options,
+	) (index.Block, error) {
+		return newBlock(ts), nil
+	}, opts)
+	require.NoError(t, err)
+	_, err = idx.FieldCardinality(t0, t2)
+	require.True(t, xerrors.IsInvalidParams(err))
+	require.NoError(t, idx.Close())
+
+	b0 := newBlock(t0)
+	b0.EXPECT().AddResults(gomock.Any()).Return(nil)
+	b1 := newBlock(t1)
+	b1.EXPECT().AddResults(gomock.Any()).Return(nil)
+	newBlockFn := func(
+		ts time.Time,
+		md namespace.Metadata,
+		_ index.BlockOptions,
+		io index.Options,
+	) (index.Block, error) {
+		if ts.Equal(t0) {
+			return b0, nil
+		}
+		return b1, nil
+	}
+	opts = opts.SetIndexOptions(opts.IndexOptions().SetFieldCardinalityTopN(1))
+	idx, err = newNamespaceIndexWithNewBlockFn(md, newBlockFn, opts)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, idx.Close())
+	}()
+
+	require.NoError(t, idx.Bootstrap(result.IndexResults{
+		t0Nanos: result.NewIndexBlock(t0, []segment.Segment{},
+			result.NewShardTimeRanges(t0, t1, 1)),
+		t1Nanos: result.NewIndexBlock(t1, []segment.Segment{},
+			result.NewShardTimeRanges(t1, t2, 1)),
+	}))
+
+	fields := []index.FieldCardinality{{Field: []byte("foo"), Cardinality: 1}}
+	b0.EXPECT().FieldCardinality().Return(fields, nil)
+	b1.EXPECT().FieldCardinality().Return(nil, nil)
+	res, err := idx.FieldCardinality(t0, t2)
+	require.NoError(t, err)
+	require.Equal(t, []index.BlockFieldCardinality{
+		{BlockStart: t1},
+		{BlockStart: t0, Fields: fields},
+	}, res)
+}
+
+func TestNamespaceIndexBlockStates(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{T: t})
+	defer ctrl.Finish()
+
+	retention := 2 * time.Hour
+	blockSize := time.Hour
+	now := time.Now().Truncate(blockSize).Add(10 * time.Minute)
+	t0 := now.Truncate(blockSize)
+	t0Nanos := xtime.ToUnixNano(t0)
+	t1 := t0.Add(1 * blockSize)
+	t1Nanos := xtime.ToUnixNano(t1)
+	t2 := t1.Add(1 * blockSize)
+	opts := DefaultTestOptions()
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return now
+	}))
+
+	newBlock := func(start time.Time) *index.MockBlock {
+		b := index.NewMockBlock(ctrl)
+		b.EXPECT().Stats(gomock.Any()).Return(nil).AnyTimes()
+		b.EXPECT().Close().Return(nil)
+		b.EXPECT().StartTime().Return(start).AnyTimes()
+		b.EXPECT().EndTime().Return(start.Add(blockSize)).AnyTimes()
+		b.EXPECT().AddResults(gomock.Any()).Return(nil)
+		return b
+	}
+	b0, b1 := newBlock(t0), newBlock(t1)
+	newBlockFn := func(
+		ts time.Time,
+		md namespace.Metadata,
+		_ index.BlockOptions,
+		io index.Options,
+	) (index.Block, error) {
+		if ts.Equal(t0) {
+			return b0, nil
+		}
+		return b1, nil
+	}
+	md := testNamespaceMetadata(blockSize, retention)
+	// The index filesets are read from the path prefix of the namespace.
+	fsOpts := opts.CommitLogOptions().FilesystemOptions()
+	nsPrefix := fsOpts.FilePathPrefix() + "-" + md.ID().String()
+	opts = opts.SetCommitLogOptions(opts.CommitLogOptions().SetFilesystemOptions(
+		fsOpts.SetNamespaceFilePathPrefixes(map[string]string{md.ID().String(): nsPrefix})))
+	nsIdx, err := newNamespaceIndexWithNewBlockFn(md, newBlockFn, opts)
+	require.NoError(t, err)
+
+	require.NoError(t, nsIdx.Bootstrap(result.IndexResults{
+		t0Nanos: result.NewIndexBlock(t0, []segment.Segment{},
+			result.NewShardTimeRanges(t0, t1, 1)),
+		t1Nanos: result.NewIndexBlock(t1, []segment.Segment{},
+			result.NewShardTimeRanges(t1, t2, 1)),
+	}))
+
+	nsIdx.(*nsIndex).readIndexInfoFilesFn = func(
+		filePathPrefix string,
+		_ ident.ID,
+		_ int,
+	) []fs.ReadIndexInfoFileResult {
+		require.Equal(t, nsPrefix, filePathPrefix)
+		return []fs.ReadIndexInfoFileResult{
+			{
+				ID:   fs.FileSetFileIdentifier{BlockStart: t0, VolumeIndex: 0},
+				Info: indexpb.IndexInfo{BlockStart: t0.UnixNano(), Shards: []uint32{1}},
+				Err:  testReadInfoFileResultError{},
+			},
+			{
+				ID:   fs.FileSetFileIdentifier{BlockStart: t0, VolumeIndex: 1},
+				Info: indexpb.IndexInfo{BlockStart: t0.UnixNano(), Shards: []uint32{2}},
+				Err:  testReadInfoFileResultError{},
+			},
+		}
+	}
+
+	b0.EXPECT().State().Return(index.BlockState{BlockStart: t0, Sealed: true}, nil)
+	b1.EXPECT().State().Return(index.BlockState{BlockStart: t1, NumDeleted: 1}, nil)
+	states, err := nsIdx.BlockStates()
+	require.NoError(t, err)
+	require.Equal(t, []index.BlockState{
+		{BlockStart: t1, NumDeleted: 1},
+		{
+			BlockStart: t0,
+			Sealed:     true,
+			FlushedVolumes: []index.BlockFlushedVolume{
+				{VolumeIndex: 0, Shards: []uint32{1}},
+				{VolumeIndex: 1, Shards: []uint32{2}},
+			},
+		},
+	}, states)
+
+	require.NoError(t, nsIdx.Close())
+	_, err = nsIdx.BlockStates()
+	require.Equal(t, errDbIndexUnableToReportStateClosed, err)
+}
+
+type testAggregateIter struct {
+	entries [][2]string
+	idx     int
+	closed  bool
+}
+
+func newTestAggregateIter(entries ...[2]string) *testAggregateIter {
+	return &testAggregateIter{entries: entries, idx: -1}
+}
+
+func (it *testAggregateIter) Next() bool {
+	it.idx++
+	return it.idx < len(it.entries)
+}
+
+func (it *testAggregateIter) Current() ([]byte, []byte) {
+	return []byte(it.entries[it.idx][0]), []byte(it.entries[it.idx][1])
+}
+
+func (it *testAggregateIter) Err() error {
+	return nil
+}
+
+func (it *testAggregateIter) Close() error {
+	it.closed = true
+	return nil
+}
+
+func TestNamespaceIndexAggregateQueryStream(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{T: t})
+	defer ctrl.Finish()
+
+	retention := 2 * time.Hour
+	blockSize := time.Hour
+	now := time.Now().Truncate(blockSize).Add(10 * time.Minute)
+	t0 := now.Truncate(blockSize)
+	t0Nanos := xtime.ToUnixNano(t0)
+	t1 := t0.Add(1 * blockSize)
+	t1Nanos := xtime.ToUnixNano(t1)
+	t2 := t1.Add(1 * blockSize)
+	opts := DefaultTestOptions()
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return now
+	}))
+
+	newBlock := func(start time.Time) *index.MockBlock {
+		b := index.NewMockBlock(ctrl)
+		b.EXPECT().Stats(gomock.Any()).Return(nil).AnyTimes()
+		b.EXPECT().Close().Return(nil)
+		b.EXPECT().StartTime().Return(start).AnyTimes()
+		b.EXPECT().EndTime().Return(start.Add(blockSize)).AnyTimes()
+		b.EXPECT().AddResults(gomock.Any()).Return(nil)
+		return b
+	}
+	b0 := newBlock(t0)
+	b1 := newBlock(t1)
+	newBlockFn := func(
+		ts time.Time,
+		md namespace.Metadata,
+		_ index.BlockOptions,
+		io index.Options,
+	) (index.Block, error) {
+		if ts.Equal(t0) {
+			return b0, nil
+		}
+		return b1, nil
+	}
+	md := testNamespaceMetadata(blockSize, retention)
+	nsIdx, err := newNamespaceIndexWithNewBlockFn(md, newBlockFn, opts)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, nsIdx.Close())
+	}()
+
+	require.NoError(t, nsIdx.Bootstrap(result.IndexResults{
+		t0Nanos: result.NewIndexBlock(t0, []segment.Segment{},
+			result.NewShardTimeRanges(t0, t1, 1)),
+		t1Nanos: result.NewIndexBlock(t1, []segment.Segment{},
+			result.NewShardTimeRanges(t1, t2, 1)),
+	}))
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	var (
+		aggOpts = index.AggregationOptions{
+			QueryOptions: index.QueryOptions{
+				StartInclusive: t0,
+				EndExclusive:   t2,
+			},
+			Type: index.AggregateTagNamesAndValues,
+		}
+		streamed [][2]string
+		streamFn = func(field, term []byte) error {
+			streamed = append(streamed, [2]string{string(field), string(term)})
+			return nil
+		}
+		iter0, iter1 *testAggregateIter
+	)
+	expectIters := func() {
+		iter0 = newTestAggregateIter([2]string{"a", "1"}, [2]string{"b", "1"})
+		iter1 = newTestAggregateIter([2]string{"a", "1"}, [2]string{"a", "2"})
+		b0.EXPECT().AggregateIter(gomock.Any(), gomock.Any()).Return(iter0, nil)
+		b1.EXPECT().AggregateIter(gomock.Any(), gomock.Any()).Return(iter1, nil)
+	}
+
+	// The tags of the blocks are streamed distinct and in order.
+	expectIters()
+	res, err := nsIdx.AggregateQueryStream(ctx, index.Query{Query: allQuery}, aggOpts, streamFn)
+	require.NoError(t, err)
+	require.True(t, res.Exhaustive)
+	require.Equal(t, [][2]string{{"a", "1"}, {"a", "2"}, {"b", "1"}}, streamed)
+	require.True(t, iter0.closed)
+	require.True(t, iter1.closed)
+
+	// Streams exceeding the limit are not exhaustive.
+	expectIters()
+	streamed = nil
+	aggOpts.Limit = 2
+	res, err = nsIdx.AggregateQueryStream(ctx, index.Query{Query: allQuery}, aggOpts, streamFn)
+	require.NoError(t, err)
+	require.False(t, res.Exhaustive)
+	require.Equal(t, index.QueryLimitSeries, res.LimitReason)
+	require.Equal(t, [][2]string{{"a", "1"}, {"a", "2"}}, streamed)
+
+	// Errors returned by the stream function stop the query.
+	expectIters()
+	expectedErr := errors.New("stream closed")
+	_, err = nsIdx.AggregateQueryStream(ctx, index.Query{Query: allQuery}, aggOpts,
+		func(field, term []byte) error {
+			return expectedErr
+		})
+	require.Equal(t, expectedErr, err)
+	require.True(t, iter0.closed)
+	require.True(t, iter1.closed)
+
+	// Only all and field queries are streamed.
+	q, err := idx.NewRegexpQuery([]byte("a"), []byte("b.*"))
+	require.NoError(t, err)
+	_, err = nsIdx.AggregateQueryStream(ctx, index.Query{Query: q}, aggOpts, streamFn)
+	require.True(t, xerrors.IsInvalidParams(err))
+}
+
 func TestNamespaceIndexBlockQueryReleasingContext(t *testing.T) {
 	ctrl := gomock.NewController(xtest.Reporter{T: t})
 	defer ctrl.Finish()
@@ -28,6 +28,8 @@ import (
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/m3ninx/doc"
+	xtime "github.com/m3db/m3/src/x/time"
 
 	"github.com/uber-go/tally"
 )
@@ -50,6 +52,12 @@ const (
 	defaultIndexPerSecondLimit = 1000000
 
 	indexResetAllInsertsEvery = 30 * time.Second
+
+	// NB: The recently indexed IDs are reset every epoch and whenever they
+	// grow too large so that the filter stays bounded, a reset only means
+	// that the next duplicates are indexed again.
+	indexResetRecentlyIndexedEvery = 30 * time.Second
+	indexRecentlyIndexedMaxSize    = 1 << 20
 )
 
 type nsIndexInsertQueue struct {
@@ -68,6 +76,12 @@ type nsIndexInsertQueue struct {
 	// active batch pending execution
 	currBatch *nsIndexInsertBatch
 
+	// recently indexed IDs, only accessed by the insert loop
+	recentlyIndexed recentlyIndexedIDs
+	// resetRecentlyIndexed is set when the recently indexed IDs can no
+	// longer be trusted, i.e. series were marked deleted or expired
+	resetRecentlyIndexed bool
+
 	indexBatchFn nsIndexInsertBatchFn
 	nowFn        clock.NowFn
 	sleepFn      func(time.Duration)
@@ -100,6 +114,7 @@ func newNamespaceIndexInsertQueue(
 		metrics:             newNamespaceIndexInsertQueueMetrics(subscope),
 	}
 	q.currBatch = q.newBatch()
+	q.recentlyIndexed.reset(nowFn())
 	return q
 }
 
@@ -120,9 +135,10 @@ func (q *nsIndexInsertQueue) insertLoop() {
 
 		// Rotate batches
 		var (
-			state   nsIndexInsertQueueState
-			backoff time.Duration
-			batch   *nsIndexInsertBatch
+			state       nsIndexInsertQueueState
+			backoff     time.Duration
+			batch       *nsIndexInsertBatch
+			resetRecent bool
 		)
 		q.Lock()
 		state = q.state
@@ -133,6 +149,7 @@ func (q *nsIndexInsertQueue) insertLoop() {
 			// No backoff required, rotate and go
 			batch = q.currBatch
 			q.currBatch = freeBatch
+			resetRecent = q.takeResetRecentlyIndexedWithLock()
 		}
 		q.Unlock()
 
@@ -142,12 +159,22 @@ func (q *nsIndexInsertQueue) insertLoop() {
 			// Rotate after backoff
 			batch = q.currBatch
 			q.currBatch = freeBatch
+			resetRecent = q.takeResetRecentlyIndexedWithLock()
 			q.Unlock()
 		}
 
+		now := q.nowFn()
+		if resetRecent ||
+			now.Sub(q.recentlyIndexed.lastReset) > indexResetRecentlyIndexedEvery ||
+			q.recentlyIndexed.size > indexRecentlyIndexedMaxSize {
+			q.recentlyIndexed.reset(now)
+		}
+
 		if len(batch.shardInserts) > 0 {
 			all := batch.AllInserts()
+			q.markRecentlyIndexedSuccess(all)
 			q.indexBatchFn(all)
+			q.addRecentlyIndexed(all)
 		}
 		batch.wg.Done()
 
@@ -163,6 +190,60 @@ func (q *nsIndexInsertQueue) insertLoop() {
 	}
 }
 
+func (q *nsIndexInsertQueue) takeResetRecentlyIndexedWithLock() bool {
+	reset := q.resetRecentlyIndexed
+	q.resetRecentlyIndexed = false
+	return reset
+}
+
+// markRecentlyIndexedSuccess marks the entries of series that were recently
+// indexed into the same block as successfully indexed so that they are
+// skipped when the batch is written to the index.
+func (q *nsIndexInsertQueue) markRecentlyIndexedSuccess(inserts *index.WriteBatch) {
+	blockSize := inserts.Options().IndexBlockSize
+	var numDuplicates int64
+	inserts.ForEach(func(
+		idx int,
+		entry index.WriteBatchEntry,
+		d doc.Document,
+		result index.WriteBatchEntryResult,
+	) {
+		if result.Done {
+			return
+		}
+		blockStart := xtime.ToUnixNano(entry.Timestamp.Truncate(blockSize))
+		if q.recentlyIndexed.contains(blockStart, d.ID) {
+			inserts.MarkUnmarkedEntrySuccess(idx)
+			numDuplicates++
+		}
+	})
+	q.metrics.numDuplicates.Inc(numDuplicates)
+}
+
+// addRecentlyIndexed adds the series of the entries that were successfully
+// indexed to the recently indexed IDs.
+func (q *nsIndexInsertQueue) addRecentlyIndexed(inserts *index.WriteBatch) {
+	blockSize := inserts.Options().IndexBlockSize
+	inserts.ForEach(func(
+		_ int,
+		entry index.WriteBatchEntry,
+		d doc.Document,
+		result index.WriteBatchEntryResult,
+	) {
+		if !result.Done || result.Err != nil {
+			return
+		}
+		blockStart := xtime.ToUnixNano(entry.Timestamp.Truncate(blockSize))
+		q.recentlyIndexed.add(blockStart, d.ID)
+	})
+}
+
+func (q *nsIndexInsertQueue) ResetRecentlyIndexed() {
+	q.Lock()
+	q.resetRecentlyIndexed = true
+	q.Unlock()
+}
+
 func (q *nsIndexInsertQueue) InsertBatch(
 	batch *index.WriteBatch,
 ) (*sync.WaitGroup, error) {
@@ -292,8 +373,41 @@ func (b *nsIndexInsertBatch) Reset() {
 	}
 }
 
+// recentlyIndexedIDs is the set of IDs of the series recently indexed per
+// index block start.
+type recentlyIndexedIDs struct {
+	ids       map[xtime.UnixNano]map[string]struct{}
+	size      int
+	lastReset time.Time
+}
+
+func (r *recentlyIndexedIDs) contains(blockStart xtime.UnixNano, id []byte) bool {
+	_, ok := r.ids[blockStart][string(id)]
+	return ok
+}
+
+func (r *recentlyIndexedIDs) add(blockStart xtime.UnixNano, id []byte) {
+	ids, ok := r.ids[blockStart]
+	if !ok {
+		ids = make(map[string]struct{})
+		r.ids[blockStart] = ids
+	}
+	if _, ok := ids[string(id)]; ok {
+		return
+	}
+	ids[string(id)] = struct{}{}
+	r.size++
+}
+
+func (r *recentlyIndexedIDs) reset(now time.Time) {
+	r.ids = make(map[xtime.UnixNano]map[string]struct{})
+	r.size = 0
+	r.lastReset = now
+}
+
 type nsIndexInsertQueueMetrics struct {
-	numPending tally.Counter
+	numPending    tally.Counter
+	numDuplicates tally.Counter
 }
 
 func newNamespaceIndexInsertQueueMetrics(
@@ -301,6 +415,7 @@ func newNamespaceIndexInsertQueueMetrics(
 ) nsIndexInsertQueueMetrics {
 	subScope := scope.SubScope("index-queue")
 	return nsIndexInsertQueueMetrics{
-		numPending: subScope.Counter("num-pending"),
+		numPending:    subScope.Counter("num-pending"),
+		numDuplicates: subScope.Counter("num-duplicates"),
 	}
 }
@@ -29,6 +29,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/x/ident"
 
 	"github.com/fortytw2/leaktest"
@@ -316,3 +317,62 @@ func TestIndexInsertQueueFlushedOnClose(t *testing.T) {
 	require.NoError(t, q.Stop())
 	require.Equal(t, int64(numInsertExpected), atomic.LoadInt64(&numInsertObserved))
 }
+
+func TestIndexInsertQueueSkipsRecentlyIndexed(t *testing.T) {
+	defer leaktest.CheckTimeout(t, time.Second)()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		q           = newTestIndexInsertQueue(newTestNamespaceMetadata(t))
+		insertLock  sync.Mutex
+		numIndexed  []int
+		callback    = index.NewMockOnIndexSeries(ctrl)
+		now         = time.Now()
+		insertBatch = func(id int) {
+			_, err := q.InsertBatch(testWriteBatch(testWriteBatchEntry(testID(id),
+				testTags(id), now, callback)))
+			require.NoError(t, err)
+		}
+		insertAndWait = func(id int) {
+			wg, err := q.InsertBatch(testWriteBatch(testWriteBatchEntry(testID(id),
+				testTags(id), now, callback)))
+			require.NoError(t, err)
+			wg.Wait()
+		}
+	)
+	callback.EXPECT().OnIndexSuccess(gomock.Any()).AnyTimes()
+	callback.EXPECT().OnIndexFinalize(gomock.Any()).AnyTimes()
+	q.indexBatchFn = func(inserts *index.WriteBatch) {
+		var n int
+		inserts.ForEach(func(_ int, _ index.WriteBatchEntry,
+			_ doc.Document, result index.WriteBatchEntryResult) {
+			if !result.Done {
+				n++
+			}
+		})
+		inserts.MarkUnmarkedEntriesSuccess()
+		insertLock.Lock()
+		numIndexed = append(numIndexed, n)
+		insertLock.Unlock()
+	}
+
+	require.NoError(t, q.Start())
+	defer func() {
+		require.NoError(t, q.Stop())
+	}()
+
+	insertAndWait(1)
+
+	// Recently indexed series are skipped.
+	insertBatch(1)
+	insertAndWait(2)
+
+	// Once reset the series are indexed again.
+	q.ResetRecentlyIndexed()
+	insertAndWait(1)
+
+	insertLock.Lock()
+	defer insertLock.Unlock()
+	require.Equal(t, []int{1, 1, 1}, numIndexed)
+}
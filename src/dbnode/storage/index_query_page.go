@@ -0,0 +1,305 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/x/context"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/resource"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	opentracinglog "github.com/opentracing/opentracing-go/log"
+)
+
+const indexQueryCursorVersion = 1
+
+var (
+	errIndexQueryCursorInvalid     = errors.New("index query cursor is invalid")
+	errIndexQueryPageLimitNotSet   = errors.New("index query must set a limit to paginate")
+	errIndexQueryPageUnsealedBlock = errors.New("index query page can not end within an index block that is not sealed, raise the limit or narrow the query range")
+)
+
+// indexQueryCursor is the progress of a paginated query, the blocks are
+// queried from the newest so the cursor is the block the next page resumes
+// from and the number of the results of that block returned by the previous
+// pages, which are skipped when the query resumes.
+type indexQueryCursor struct {
+	resume     bool
+	blockStart xtime.UnixNano
+	offset     int
+}
+
+// offsetFor returns the number of results of the block to skip.
+func (c indexQueryCursor) offsetFor(blockStart xtime.UnixNano) int {
+	if c.resume && blockStart == c.blockStart {
+		return c.offset
+	}
+	return 0
+}
+
+func (c indexQueryCursor) encode() []byte {
+	buf := make([]byte, 1+2*binary.MaxVarintLen64)
+	buf[0] = indexQueryCursorVersion
+	n := 1
+	n += binary.PutVarint(buf[n:], int64(c.blockStart))
+	n += binary.PutUvarint(buf[n:], uint64(c.offset))
+	return buf[:n]
+}
+
+func decodeIndexQueryCursor(b []byte) (indexQueryCursor, error) {
+	if len(b) == 0 {
+		return indexQueryCursor{}, nil
+	}
+	if b[0] != indexQueryCursorVersion {
+		return indexQueryCursor{}, errIndexQueryCursorInvalid
+	}
+	blockStart, n := binary.Varint(b[1:])
+	if n <= 0 {
+		return indexQueryCursor{}, errIndexQueryCursorInvalid
+	}
+	offset, m := binary.Uvarint(b[1+n:])
+	if m <= 0 || 1+n+m != len(b) {
+		return indexQueryCursor{}, errIndexQueryCursorInvalid
+	}
+	return indexQueryCursor{
+		resume:     true,
+		blockStart: xtime.UnixNano(blockStart),
+		offset:     int(offset),
+	}, nil
+}
+
+// queryPage executes a paginated query, the blocks are queried one after the
+// other rather than concurrently so that the page ends at a well defined
+// point. Series indexed in more than one block may be returned by more than
+// one page.
+//
+// The results of a block are resumed by their offset since the results of
+// a block are not sorted across its segments, this is only stable once the
+// block is sealed and no longer takes writes so a page can only end within
+// a sealed block and fails otherwise. Series deleted or cold written to in
+// between pages may still shift the results of a sealed block.
+func (i *nsIndex) queryPage(
+	ctx context.Context,
+	query index.Query,
+	results index.BaseResults,
+	opts index.QueryOptions,
+	execBlockFn execBlockQueryFn,
+	logFields []opentracinglog.Field,
+) (bool, []byte, error) {
+	cursor, err := decodeIndexQueryCursor(opts.Cursor)
+	if err != nil {
+		return false, nil, xerrors.NewInvalidParamsError(err)
+	}
+
+	// Capture start before needing to acquire lock.
+	start := i.nowFn()
+
+	i.state.RLock()
+	if !i.isOpenWithRLock() {
+		i.state.RUnlock()
+		return false, nil, errDbIndexUnableToQueryClosed
+	}
+
+	// Track this as an inflight query that needs to finish
+	// when the index is closed.
+	i.queriesWg.Add(1)
+	defer i.queriesWg.Done()
+
+	opts = i.overriddenOptsForQueryWithRLock(opts)
+	timeout := i.timeoutForQueryWithRLock(ctx)
+	blocks, err := i.blocksForQueryWithRLock(xtime.NewRanges(xtime.Range{
+		Start: opts.StartInclusive,
+		End:   opts.EndExclusive,
+	}))
+	i.state.RUnlock()
+
+	if err != nil {
+		return false, nil, err
+	}
+	if opts.Limit <= 0 {
+		return false, nil, xerrors.NewInvalidParamsError(errIndexQueryPageLimitNotSet)
+	}
+
+	cancellable := resource.NewCancellableLifetime()
+	defer cancellable.Cancel()
+
+	var cancelledCh <-chan struct{}
+	if goCtx, ok := ctx.GoContext(); ok {
+		cancelledCh = goCtx.Done()
+	}
+
+	for _, block := range blocks {
+		blockStart := xtime.ToUnixNano(block.StartTime())
+		if cursor.resume && blockStart > cursor.blockStart {
+			// Returned in full by the previous pages.
+			continue
+		}
+
+		if cursor.offsetFor(blockStart) > 0 && !block.IsSealed() {
+			// Cursors only resume within sealed blocks.
+			return false, nil, xerrors.NewInvalidParamsError(errIndexQueryCursorInvalid)
+		}
+
+		select {
+		case <-cancelledCh:
+			return false, nil, errDbIndexQueryCancelled
+		default:
+		}
+		if timeout > 0 && i.nowFn().Sub(start) >= timeout {
+			return false, nil, fmt.Errorf("index query timed out: %s", timeout.String())
+		}
+		if !opts.ResourceTracker.CheckDuration() {
+			next := indexQueryCursor{
+				blockStart: blockStart,
+				offset:     cursor.offsetFor(blockStart),
+			}
+			return false, next.encode(), nil
+		}
+
+		pager := &indexQueryPager{
+			limit: opts.Limit,
+			skip:  cursor.offsetFor(blockStart),
+		}
+		var (
+			state        = asyncQueryExecState{exhaustive: true}
+			pagedResults = newPagedIndexResults(results, pager)
+			skipped      = pager.skip
+		)
+		execBlockFn(ctx, cancellable, block, query, opts, &state, pagedResults, logFields)
+		if err := state.multiErr.FinalError(); err != nil {
+			return false, nil, err
+		}
+		// The page also ends when a resource limit is exceeded, the block
+		// then resumes after the results it returned.
+		if pager.full || opts.ResourceTracker.Exceeded() != index.QueryLimitNone {
+			next := indexQueryCursor{
+				blockStart: blockStart,
+				offset:     skipped + pager.consumed,
+			}
+			if next.offset > 0 && !block.IsSealed() {
+				// The results of a block still taking writes may shift before
+				// the next page is queried.
+				return false, nil, xerrors.NewInvalidParamsError(errIndexQueryPageUnsealedBlock)
+			}
+			return false, next.encode(), nil
+		}
+	}
+	return true, nil, nil
+}
+
+// indexQueryPager skips the results of a block returned by the previous pages
+// and counts the results of the block consumed by the current page, once the
+// page is full the remaining results are dropped so that the consumed results
+// are always a prefix of the results of the block.
+type indexQueryPager struct {
+	limit    int
+	skip     int
+	consumed int
+	full     bool
+}
+
+func (p *indexQueryPager) take() bool {
+	if p.skip > 0 {
+		p.skip--
+		return false
+	}
+	return !p.full
+}
+
+func (p *indexQueryPager) taken(size int) {
+	p.consumed++
+	if size >= p.limit {
+		p.full = true
+	}
+}
+
+func newPagedIndexResults(
+	results index.BaseResults,
+	pager *indexQueryPager,
+) index.BaseResults {
+	if aggResults, ok := results.(index.AggregateResults); ok {
+		return &pagedAggregateResults{AggregateResults: aggResults, pager: pager}
+	}
+	return &pagedQueryResults{BaseResults: results, pager: pager}
+}
+
+type pagedQueryResults struct {
+	index.BaseResults
+
+	pager *indexQueryPager
+}
+
+func (r *pagedQueryResults) AddDocuments(batch []doc.Document) (int, error) {
+	return addPagedDocuments(r.BaseResults, r.pager, batch)
+}
+
+type pagedAggregateResults struct {
+	index.AggregateResults
+
+	pager *indexQueryPager
+}
+
+func (r *pagedAggregateResults) AddDocuments(batch []doc.Document) (int, error) {
+	return addPagedDocuments(r.AggregateResults, r.pager, batch)
+}
+
+func (r *pagedAggregateResults) AddFields(batch []index.AggregateResultsEntry) int {
+	size := r.AggregateResults.Size()
+	for j, entry := range batch {
+		if !r.pager.take() {
+			// The results assume ownership of the entries so release the
+			// ones that are not added.
+			entry.Field.Finalize()
+			for _, term := range entry.Terms {
+				term.Finalize()
+			}
+			continue
+		}
+		size = r.AggregateResults.AddFields(batch[j : j+1])
+		r.pager.taken(size)
+	}
+	return size
+}
+
+func addPagedDocuments(
+	results index.BaseResults,
+	pager *indexQueryPager,
+	batch []doc.Document,
+) (int, error) {
+	size := results.Size()
+	for j := range batch {
+		if !pager.take() {
+			continue
+		}
+		var err error
+		size, err = results.AddDocuments(batch[j : j+1])
+		if err != nil {
+			return size, err
+		}
+		pager.taken(size)
+	}
+	return size, nil
+}
@@ -0,0 +1,294 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/index/segment"
+	"github.com/m3db/m3/src/x/context"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/resource"
+	xtest "github.com/m3db/m3/src/x/test"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+	opentracinglog "github.com/opentracing/opentracing-go/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexQueryCursorEncodeDecode(t *testing.T) {
+	cursor := indexQueryCursor{
+		resume:     true,
+		blockStart: xtime.ToUnixNano(time.Unix(1000, 0)),
+		offset:     42,
+	}
+	decoded, err := decodeIndexQueryCursor(cursor.encode())
+	require.NoError(t, err)
+	require.Equal(t, cursor, decoded)
+
+	decoded, err = decodeIndexQueryCursor(nil)
+	require.NoError(t, err)
+	require.False(t, decoded.resume)
+
+	encoded := cursor.encode()
+	for _, invalid := range [][]byte{
+		{indexQueryCursorVersion + 1},
+		encoded[:len(encoded)-1],
+		append(encoded, 0),
+	} {
+		_, err := decodeIndexQueryCursor(invalid)
+		require.Equal(t, errIndexQueryCursorInvalid, err)
+	}
+}
+
+func TestNamespaceIndexQueryPaginate(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{T: t})
+	defer ctrl.Finish()
+
+	retention := 2 * time.Hour
+	blockSize := time.Hour
+	now := time.Now().Truncate(blockSize).Add(10 * time.Minute)
+	t0 := now.Truncate(blockSize)
+	t1 := t0.Add(blockSize)
+	t2 := t1.Add(blockSize)
+	opts := DefaultTestOptions()
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return now
+	}))
+
+	newBlock := func(start time.Time, sealed bool, ids ...string) *index.MockBlock {
+		docs := make([]doc.Document, 0, len(ids))
+		for _, id := range ids {
+			docs = append(docs, doc.Document{ID: []byte(id)})
+		}
+		b := index.NewMockBlock(ctrl)
+		b.EXPECT().Stats(gomock.Any()).Return(nil).AnyTimes()
+		b.EXPECT().Close().Return(nil)
+		b.EXPECT().StartTime().Return(start).AnyTimes()
+		b.EXPECT().EndTime().Return(start.Add(blockSize)).AnyTimes()
+		b.EXPECT().IsSealed().Return(sealed).AnyTimes()
+		b.EXPECT().AddResults(gomock.Any()).Return(nil)
+		b.EXPECT().
+			Query(gomock.Any(), gomock.Any(), defaultQuery, gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(
+				_ context.Context,
+				_ *resource.CancellableLifetime,
+				_ index.Query,
+				opts index.QueryOptions,
+				results index.BaseResults,
+				_ []opentracinglog.Field,
+			) (bool, error) {
+				batch := make([]doc.Document, 0, len(docs))
+				for _, d := range docs {
+					if !opts.ResourceTracker.TrackDocument(d) {
+						break
+					}
+					batch = append(batch, d)
+				}
+				opts.ExplainTracker.TrackBlock(index.BlockQueryExplain{
+					BlockStart:  start,
+					DocsMatched: len(batch),
+				})
+				size, err := results.AddDocuments(batch)
+				exhaustive := len(batch) == len(docs) && !opts.LimitExceeded(size)
+				return exhaustive, err
+			}).
+			AnyTimes()
+		return b
+	}
+	b0 := newBlock(t0, true, "d")
+	b1 := newBlock(t1, true, "a", "b", "c")
+	b2 := newBlock(t2, false, "e", "f")
+	newBlockFn := func(
+		ts time.Time,
+		md namespace.Metadata,
+		_ index.BlockOptions,
+		io index.Options,
+	) (index.Block, error) {
+		switch {
+		case ts.Equal(t0):
+			return b0, nil
+		case ts.Equal(t1):
+			return b1, nil
+		}
+		return b2, nil
+	}
+	md := testNamespaceMetadata(blockSize, retention)
+	idx, err := newNamespaceIndexWithNewBlockFn(md, newBlockFn, opts)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, idx.Close())
+	}()
+
+	require.NoError(t, idx.Bootstrap(result.IndexResults{
+		xtime.ToUnixNano(t0): result.NewIndexBlock(t0, []segment.Segment{},
+			result.NewShardTimeRanges(t0, t1, 1)),
+		xtime.ToUnixNano(t1): result.NewIndexBlock(t1, []segment.Segment{},
+			result.NewShardTimeRanges(t1, t2, 1)),
+		xtime.ToUnixNano(t2): result.NewIndexBlock(t2, []segment.Segment{},
+			result.NewShardTimeRanges(t2, t2.Add(blockSize), 1)),
+	}))
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	qOpts := index.QueryOptions{
+		StartInclusive: t0,
+		EndExclusive:   t2,
+		Limit:          2,
+		Paginate:       true,
+	}
+	var pages [][]string
+	for {
+		res, err := idx.Query(ctx, defaultQuery, qOpts)
+		require.NoError(t, err)
+
+		var ids []string
+		for _, entry := range res.Results.Map().Iter() {
+			ids = append(ids, entry.Key().String())
+		}
+		pages = append(pages, ids)
+		if len(res.Cursor) == 0 {
+			require.True(t, res.Exhaustive)
+			require.Equal(t, index.QueryLimitNone, res.LimitReason)
+			break
+		}
+		require.False(t, res.Exhaustive)
+		require.Equal(t, index.QueryLimitSeries, res.LimitReason)
+		qOpts.Cursor = res.Cursor
+	}
+	require.Equal(t, 3, len(pages))
+	require.ElementsMatch(t, []string{"a", "b"}, pages[0])
+	require.ElementsMatch(t, []string{"c", "d"}, pages[1])
+	require.Equal(t, 0, len(pages[2]))
+
+	// Queries exceeding a resource limit return partial results.
+	res, err := idx.Query(ctx, defaultQuery, index.QueryOptions{
+		StartInclusive: t0,
+		EndExclusive:   t2,
+		ResourceLimits: index.QueryResourceLimits{Docs: 2},
+	})
+	require.NoError(t, err)
+	require.False(t, res.Exhaustive)
+	require.Equal(t, index.QueryLimitDocs, res.LimitReason)
+	require.Equal(t, 2, res.Results.Size())
+
+	// Pages exceeding a resource limit resume after the results returned.
+	res, err = idx.Query(ctx, defaultQuery, index.QueryOptions{
+		StartInclusive: t0,
+		EndExclusive:   t2,
+		Limit:          10,
+		Paginate:       true,
+		ResourceLimits: index.QueryResourceLimits{Docs: 1},
+	})
+	require.NoError(t, err)
+	require.False(t, res.Exhaustive)
+	require.Equal(t, index.QueryLimitDocs, res.LimitReason)
+	require.Equal(t, 1, res.Results.Size())
+	require.NotEmpty(t, res.Cursor)
+	require.Nil(t, res.Explain)
+
+	// Queries requesting an explanation return how the blocks were queried.
+	res, err = idx.Query(ctx, defaultQuery, index.QueryOptions{
+		StartInclusive: t0,
+		EndExclusive:   t2,
+		Explain:        true,
+	})
+	require.NoError(t, err)
+	require.True(t, res.Exhaustive)
+	require.NotNil(t, res.Explain)
+	require.Equal(t, []index.BlockQueryExplain{
+		{BlockStart: t0, DocsMatched: 1},
+		{BlockStart: t1, DocsMatched: 3},
+	}, res.Explain.Blocks)
+
+	// Paginating requires a limit and a valid cursor.
+	_, err = idx.Query(ctx, defaultQuery, index.QueryOptions{
+		StartInclusive: t0,
+		EndExclusive:   t2,
+		Paginate:       true,
+	})
+	require.True(t, xerrors.IsInvalidParams(err))
+	qOpts.Cursor = []byte{0}
+	_, err = idx.Query(ctx, defaultQuery, qOpts)
+	require.True(t, xerrors.IsInvalidParams(err))
+
+	// Pages can only end within sealed blocks, as the results of a block
+	// still taking writes may shift before the next page is queried.
+	res, err = idx.Query(ctx, defaultQuery, index.QueryOptions{
+		StartInclusive: t1,
+		EndExclusive:   t2.Add(blockSize),
+		Limit:          3,
+		Paginate:       true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, res.Results.Size())
+	require.NotEmpty(t, res.Cursor)
+	_, err = idx.Query(ctx, defaultQuery, index.QueryOptions{
+		StartInclusive: t1,
+		EndExclusive:   t2.Add(blockSize),
+		Limit:          1,
+		Paginate:       true,
+	})
+	require.True(t, xerrors.IsInvalidParams(err))
+	resumeUnsealed := indexQueryCursor{blockStart: xtime.ToUnixNano(t2), offset: 1}
+	_, err = idx.Query(ctx, defaultQuery, index.QueryOptions{
+		StartInclusive: t1,
+		EndExclusive:   t2.Add(blockSize),
+		Limit:          1,
+		Paginate:       true,
+		Cursor:         resumeUnsealed.encode(),
+	})
+	require.True(t, xerrors.IsInvalidParams(err))
+}
+
+func TestPagedAggregateResultsAddFields(t *testing.T) {
+	results := index.NewAggregateResults(ident.StringID("ns"), index.AggregateResultsOptions{
+		Type: index.AggregateTagNamesAndValues,
+	}, DefaultTestOptions().IndexOptions())
+	pager := &indexQueryPager{limit: 2, skip: 1}
+	paged := newPagedIndexResults(results, pager).(index.AggregateResults)
+
+	entry := func(field string, terms ...string) index.AggregateResultsEntry {
+		e := index.AggregateResultsEntry{Field: ident.StringID(field)}
+		for _, term := range terms {
+			e.Terms = append(e.Terms, ident.StringID(term))
+		}
+		return e
+	}
+	size := paged.AddFields([]index.AggregateResultsEntry{
+		entry("skipped", "a"),
+		entry("foo", "a"),
+		entry("bar", "b"),
+		entry("baz", "c"),
+	})
+	require.Equal(t, 2, size)
+	require.True(t, pager.full)
+	require.Equal(t, 2, pager.consumed)
+	_, ok := results.Map().Get(ident.StringID("baz"))
+	require.False(t, ok)
+}
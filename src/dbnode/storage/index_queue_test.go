@@ -142,6 +142,46 @@ func TestNamespaceIndexWriteAfterClose(t *testing.T) {
 		testWriteBatchBlockSizeOption(idx.blockSize))))
 }
 
+func TestNamespaceIndexWriteWhileDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dbIdx, q := newTestNamespaceIndex(t, ctrl)
+	idx, ok := dbIdx.(*nsIndex)
+	assert.True(t, ok)
+
+	defer func() {
+		q.EXPECT().Stop().Return(nil)
+		assert.NoError(t, idx.Close())
+	}()
+
+	id := ident.StringID("foo")
+	tags := ident.NewTags(
+		ident.StringTag("name", "value"),
+	)
+
+	idx.SetEnabled(false)
+
+	now := time.Now()
+	lifecycle := index.NewMockOnIndexSeries(ctrl)
+	lifecycle.EXPECT().
+		OnIndexFinalize(xtime.ToUnixNano(now.Truncate(idx.blockSize)))
+	entry, document := testWriteBatchEntry(id, tags, now, lifecycle)
+	batch := testWriteBatch(entry, document,
+		testWriteBatchBlockSizeOption(idx.blockSize))
+	assert.NoError(t, idx.WriteBatch(batch))
+	assert.Equal(t, 0, batch.NumErrs())
+
+	// Re-enabling indexing resumes enqueueing writes.
+	idx.SetEnabled(true)
+	lifecycle.EXPECT().
+		OnIndexFinalize(xtime.ToUnixNano(now.Truncate(idx.blockSize)))
+	entry, document = testWriteBatchEntry(id, tags, now, lifecycle)
+	q.EXPECT().InsertBatch(gomock.Any()).Return(&sync.WaitGroup{}, nil)
+	assert.NoError(t, idx.WriteBatch(testWriteBatch(entry, document,
+		testWriteBatchBlockSizeOption(idx.blockSize))))
+}
+
 func TestNamespaceIndexWriteQueueError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
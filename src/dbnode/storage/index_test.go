@@ -21,15 +21,21 @@
 package storage
 
 import (
+	stdlibctx "context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
+	indexpb "github.com/m3db/m3/src/dbnode/generated/proto/index"
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/storage/index/compaction"
+	"github.com/m3db/m3/src/dbnode/storage/index/segments"
 	"github.com/m3db/m3/src/m3ninx/idx"
 	"github.com/m3db/m3/src/m3ninx/index/segment"
 	"github.com/m3db/m3/src/x/context"
@@ -64,6 +70,24 @@ func TestNamespaceIndexCleanupExpiredFilesets(t *testing.T) {
 	require.NoError(t, idx.CleanupExpiredFileSets(now))
 }
 
+func TestNamespaceIndexCleanupExpiredFilesetsWithGracePeriod(t *testing.T) {
+	md := testNamespaceMetadata(time.Hour, time.Hour*8)
+	nsIdx, err := newNamespaceIndex(md, DefaultTestOptions())
+	require.NoError(t, err)
+
+	now := time.Now().Truncate(time.Hour)
+	idx := nsIdx.(*nsIndex)
+	idx.UpdateRetentionOptions(md.Options().RetentionOptions().
+		SetExpiredFileSetGracePeriod(time.Hour * 4))
+
+	oldestTime := now.Add(-time.Hour * 12)
+	idx.indexFilesetsBeforeFn = func(dir string, nsID ident.ID, exclusiveTime time.Time) ([]string, error) {
+		require.True(t, oldestTime.Equal(exclusiveTime), fmt.Sprintf("%v %v", exclusiveTime, oldestTime))
+		return nil, nil
+	}
+	require.NoError(t, idx.CleanupExpiredFileSets(now))
+}
+
 func TestNamespaceIndexCleanupExpiredFilesetsWithBlocks(t *testing.T) {
 	ctrl := gomock.NewController(xtest.Reporter{T: t})
 	defer ctrl.Finish()
@@ -319,6 +343,35 @@ func TestNamespaceIndexQueryNoMatchingBlocks(t *testing.T) {
 	assert.Equal(t, 0, aggResult.Results.Size())
 }
 
+func TestNamespaceIndexQueryCancelled(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{T: t})
+	defer ctrl.Finish()
+
+	test := newTestIndex(t, ctrl)
+
+	now := time.Now().Truncate(test.indexBlockSize)
+	query := index.Query{Query: idx.NewTermQuery([]byte("foo"), []byte("bar"))}
+	idx := test.index.(*nsIndex)
+
+	defer func() {
+		require.NoError(t, idx.Close())
+	}()
+
+	goCtx, cancel := stdlibctx.WithCancel(stdlibctx.Background())
+	cancel()
+
+	ctx := context.NewContext()
+	ctx.SetGoContext(goCtx)
+	defer ctx.Close()
+
+	// Query the current block which exists since the index was just created.
+	_, err := idx.Query(ctx, query, index.QueryOptions{
+		StartInclusive: now,
+		EndExclusive:   now.Add(test.indexBlockSize),
+	})
+	require.Equal(t, errDbIndexQueryCancelled, err)
+}
+
 type testIndex struct {
 	index          namespaceIndex
 	metadata       namespace.Metadata
@@ -354,3 +407,303 @@ func newTestIndex(t *testing.T, ctrl *gomock.Controller) testIndex {
 		retention:      retentionPeriod,
 	}
 }
+
+type testReadInfoFileResultError struct {
+	err error
+}
+
+func (e testReadInfoFileResultError) Error() error     { return e.err }
+func (e testReadInfoFileResultError) Filepath() string { return "" }
+
+func TestNamespaceIndexEvictColdBlocks(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{T: t})
+	defer ctrl.Finish()
+
+	test := newTestIndex(t, ctrl)
+	opts := test.opts.SetIndexOptions(
+		test.opts.IndexOptions().SetColdBlockAge(test.indexBlockSize))
+	newIdx, err := newNamespaceIndex(test.metadata, opts)
+	require.NoError(t, err)
+	idx := newIdx.(*nsIndex)
+	require.NoError(t, test.index.Close())
+	defer func() {
+		require.NoError(t, idx.Close())
+	}()
+
+	var (
+		now       = time.Now().Truncate(test.indexBlockSize)
+		coldTime  = now.Add(-3 * test.indexBlockSize)
+		otherTime = now.Add(-4 * test.indexBlockSize)
+		warmTime  = now.Add(-test.indexBlockSize)
+	)
+	idx.nowFn = func() time.Time { return now }
+
+	newMockBlock := func(blockStart time.Time) *index.MockBlock {
+		mockBlock := index.NewMockBlock(ctrl)
+		mockBlock.EXPECT().Stats(gomock.Any()).Return(nil).AnyTimes()
+		mockBlock.EXPECT().StartTime().Return(blockStart).AnyTimes()
+		mockBlock.EXPECT().EndTime().Return(blockStart.Add(test.indexBlockSize)).AnyTimes()
+		mockBlock.EXPECT().Close().Return(nil)
+		idx.state.blocksByTime[xtime.ToUnixNano(blockStart)] = mockBlock
+		return mockBlock
+	}
+
+	// Only the cold block with the index filesets of every shard on disk is evicted.
+	coldBlock := newMockBlock(coldTime)
+	coldBlock.EXPECT().IsSealed().Return(true)
+	coldBlock.EXPECT().IsCold().Return(false)
+	coldBlock.EXPECT().NeedsMutableSegmentsEvicted().Return(false)
+	coldBlock.EXPECT().EvictColdSegments().Return(nil)
+	coldBlock.EXPECT().IsCold().Return(true)
+
+	otherBlock := newMockBlock(otherTime)
+	otherBlock.EXPECT().IsSealed().Return(true)
+	otherBlock.EXPECT().IsCold().Return(false)
+	otherBlock.EXPECT().NeedsMutableSegmentsEvicted().Return(false)
+
+	newMockBlock(warmTime)
+
+	idx.readIndexInfoFilesFn = func(
+		filePathPrefix string,
+		namespace ident.ID,
+		readerBufferSize int,
+	) []fs.ReadIndexInfoFileResult {
+		require.True(t, test.metadata.ID().Equal(namespace))
+		return []fs.ReadIndexInfoFileResult{
+			{
+				Info: indexpb.IndexInfo{BlockStart: coldTime.UnixNano(), Shards: []uint32{0, 1}},
+				Err:  testReadInfoFileResultError{},
+			},
+			{
+				Info: indexpb.IndexInfo{BlockStart: otherTime.UnixNano(), Shards: []uint32{0}},
+				Err:  testReadInfoFileResultError{},
+			},
+			{
+				Info: indexpb.IndexInfo{BlockStart: otherTime.UnixNano(), Shards: []uint32{1}},
+				Err:  testReadInfoFileResultError{err: errors.New("corrupt")},
+			},
+		}
+	}
+
+	mockShard0 := NewMockdatabaseShard(ctrl)
+	mockShard0.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	mockShard1 := NewMockdatabaseShard(ctrl)
+	mockShard1.EXPECT().ID().Return(uint32(1)).AnyTimes()
+
+	idx.evictColdBlocks([]databaseShard{mockShard0, mockShard1})
+}
+
+func TestNamespaceIndexReadColdBlockSegments(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{T: t})
+	defer ctrl.Finish()
+
+	test := newTestIndex(t, ctrl)
+	idx := test.index.(*nsIndex)
+	defer func() {
+		require.NoError(t, idx.Close())
+	}()
+
+	var (
+		blockStart = time.Now().Truncate(test.indexBlockSize)
+		otherStart = blockStart.Add(-test.indexBlockSize)
+		seg1       = segment.NewMockSegment(ctrl)
+		seg2       = segment.NewMockSegment(ctrl)
+		fsOpts     = idx.opts.CommitLogOptions().FilesystemOptions()
+		nsPrefix   = fsOpts.FilePathPrefix() + "-" + test.metadata.ID().String()
+	)
+	// The index filesets are read from the path prefix of the namespace.
+	idx.opts = idx.opts.SetCommitLogOptions(idx.opts.CommitLogOptions().
+		SetFilesystemOptions(fsOpts.SetNamespaceFilePathPrefixes(map[string]string{
+			test.metadata.ID().String(): nsPrefix,
+		})))
+	idx.readIndexInfoFilesFn = func(
+		filePathPrefix string,
+		_ ident.ID,
+		_ int,
+	) []fs.ReadIndexInfoFileResult {
+		require.Equal(t, nsPrefix, filePathPrefix)
+		return []fs.ReadIndexInfoFileResult{
+			{
+				ID:   fs.FileSetFileIdentifier{BlockStart: blockStart, VolumeIndex: 0},
+				Info: indexpb.IndexInfo{BlockStart: blockStart.UnixNano()},
+				Err:  testReadInfoFileResultError{},
+			},
+			{
+				ID:   fs.FileSetFileIdentifier{BlockStart: otherStart, VolumeIndex: 0},
+				Info: indexpb.IndexInfo{BlockStart: otherStart.UnixNano()},
+				Err:  testReadInfoFileResultError{},
+			},
+			{
+				ID:   fs.FileSetFileIdentifier{BlockStart: blockStart, VolumeIndex: 1},
+				Info: indexpb.IndexInfo{BlockStart: blockStart.UnixNano()},
+				Err:  testReadInfoFileResultError{},
+			},
+		}
+	}
+
+	var volumes []int
+	idx.readIndexSegmentsFn = func(opts fs.ReadIndexSegmentsOptions) ([]segment.Segment, error) {
+		require.True(t, blockStart.Equal(opts.ReaderOptions.Identifier.BlockStart))
+		require.Equal(t, persist.FileSetFlushType, opts.ReaderOptions.FileSetType)
+		volumes = append(volumes, opts.ReaderOptions.Identifier.VolumeIndex)
+		if len(volumes) == 1 {
+			return []segment.Segment{seg1}, nil
+		}
+		return []segment.Segment{seg2}, nil
+	}
+
+	segs, err := idx.readColdBlockSegments(blockStart)
+	require.NoError(t, err)
+	require.Equal(t, []segment.Segment{seg1, seg2}, segs)
+	require.Equal(t, []int{0, 1}, volumes)
+
+	// Segments already read are closed when a later fileset fails to read.
+	volumes = nil
+	idx.readIndexSegmentsFn = func(opts fs.ReadIndexSegmentsOptions) ([]segment.Segment, error) {
+		volumes = append(volumes, opts.ReaderOptions.Identifier.VolumeIndex)
+		if len(volumes) == 1 {
+			return []segment.Segment{seg1}, nil
+		}
+		return nil, errors.New("unable to read")
+	}
+	seg1.EXPECT().Close().Return(nil)
+
+	_, err = idx.readColdBlockSegments(blockStart)
+	require.Error(t, err)
+}
+
+func TestNamespaceIndexSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{T: t})
+	defer ctrl.Finish()
+
+	test := newTestIndex(t, ctrl)
+	idx := test.index.(*nsIndex)
+	defer func() {
+		require.NoError(t, idx.Close())
+	}()
+
+	var (
+		now          = time.Now().Truncate(test.indexBlockSize)
+		snapshotTime = now.Add(time.Minute)
+		warmTime     = now.Add(-test.indexBlockSize)
+		flushedTime  = now.Add(-2 * test.indexBlockSize)
+		expiredTime  = now.Add(-test.retention - 2*test.indexBlockSize)
+	)
+	idx.nowFn = func() time.Time { return now }
+
+	newMockBlock := func(blockStart time.Time) *index.MockBlock {
+		mockBlock := index.NewMockBlock(ctrl)
+		mockBlock.EXPECT().Stats(gomock.Any()).Return(nil).AnyTimes()
+		mockBlock.EXPECT().StartTime().Return(blockStart).AnyTimes()
+		mockBlock.EXPECT().EndTime().Return(blockStart.Add(test.indexBlockSize)).AnyTimes()
+		mockBlock.EXPECT().Close().Return(nil)
+		idx.state.blocksByTime[xtime.ToUnixNano(blockStart)] = mockBlock
+		return mockBlock
+	}
+
+	// Only the block not yet flushed is snapshotted.
+	warmBlock := newMockBlock(warmTime)
+	warmBlock.EXPECT().NeedsMutableSegmentsEvicted().Return(true)
+	warmBlock.EXPECT().SnapshotSegments(gomock.Any()).DoAndReturn(
+		func(persistFn func(segment.Builder) error) (bool, error) {
+			return true, persistFn(nil)
+		})
+	flushedBlock := newMockBlock(flushedTime)
+	flushedBlock.EXPECT().NeedsMutableSegmentsEvicted().Return(false)
+
+	mockShard := NewMockdatabaseShard(ctrl)
+	mockShard.EXPECT().ID().Return(uint32(0)).AnyTimes()
+
+	var persistCalled, persistClosed bool
+	mockFlush := persist.NewMockIndexFlush(ctrl)
+	mockFlush.EXPECT().PrepareIndex(xtest.CmpMatcher(persist.IndexPrepareOptions{
+		NamespaceMetadata: test.metadata,
+		BlockStart:        warmTime,
+		FileSetType:       persist.FileSetSnapshotType,
+		Shards:            map[uint32]struct{}{0: struct{}{}},
+		Snapshot: persist.DataPrepareSnapshotOptions{
+			SnapshotTime: snapshotTime,
+		},
+	})).Return(persist.PreparedIndexPersist{
+		Persist: func(segment.Builder) error {
+			persistCalled = true
+			return nil
+		},
+		Close: func() ([]segment.Segment, error) {
+			persistClosed = true
+			return nil, nil
+		},
+	}, nil)
+
+	snapshotFile := func(blockStart time.Time, volume int) fs.FileSetFile {
+		return fs.FileSetFile{
+			ID: fs.FileSetFileIdentifier{
+				BlockStart:  blockStart,
+				VolumeIndex: volume,
+			},
+			AbsoluteFilepaths: []string{
+				fmt.Sprintf("%d-%d", blockStart.UnixNano(), volume),
+			},
+			CachedHasCompleteCheckpointFile: fs.EvalTrue,
+		}
+	}
+	idx.indexSnapshotFilesFn = func(
+		filePathPrefix string,
+		namespace ident.ID,
+	) (fs.FileSetFilesSlice, error) {
+		require.True(t, test.metadata.ID().Equal(namespace))
+		return fs.FileSetFilesSlice{
+			snapshotFile(warmTime, 0),
+			snapshotFile(warmTime, 1),
+			snapshotFile(flushedTime, 0),
+			snapshotFile(expiredTime, 0),
+		}, nil
+	}
+	idx.readIndexInfoFilesFn = func(string, ident.ID, int) []fs.ReadIndexInfoFileResult {
+		return []fs.ReadIndexInfoFileResult{
+			{
+				Info: indexpb.IndexInfo{BlockStart: flushedTime.UnixNano(), Shards: []uint32{0}},
+				Err:  testReadInfoFileResultError{},
+			},
+		}
+	}
+
+	var deleted []string
+	idx.deleteFilesFn = func(files []string) error {
+		deleted = append(deleted, files...)
+		return nil
+	}
+
+	require.NoError(t, idx.Snapshot([]databaseShard{mockShard}, snapshotTime, mockFlush))
+	require.True(t, persistCalled)
+	require.True(t, persistClosed)
+	require.ElementsMatch(t, []string{
+		fmt.Sprintf("%d-%d", warmTime.UnixNano(), 0),
+		fmt.Sprintf("%d-%d", flushedTime.UnixNano(), 0),
+		fmt.Sprintf("%d-%d", expiredTime.UnixNano(), 0),
+	}, deleted)
+}
+
+func TestNamespaceIndexCompactionDebt(t *testing.T) {
+	opts := index.NewOptions().BackgroundCompactionPlannerOptions()
+
+	numSegments, numDocs, err := compactionDebt(nil, opts)
+	require.NoError(t, err)
+	require.Equal(t, 0, numSegments)
+	require.Equal(t, int64(0), numDocs)
+
+	// A single segment in its level has nothing to be compacted with.
+	segs := []compaction.Segment{
+		{Size: 100, Type: segments.FSTType},
+	}
+	numSegments, numDocs, err = compactionDebt(segs, opts)
+	require.NoError(t, err)
+	require.Equal(t, 0, numSegments)
+	require.Equal(t, int64(0), numDocs)
+
+	segs = append(segs, compaction.Segment{Size: 200, Type: segments.FSTType})
+	numSegments, numDocs, err = compactionDebt(segs, opts)
+	require.NoError(t, err)
+	require.Equal(t, 2, numSegments)
+	require.Equal(t, int64(300), numDocs)
+}
@@ -73,6 +73,9 @@ type mediator struct {
 	databaseTickManager
 	databaseRepairer
 
+	memoryPressure  *memoryPressureMonitor
+	durabilityProbe *durabilityProber
+
 	opts     Options
 	nowFn    clock.NowFn
 	sleepFn  sleepFn
@@ -106,6 +109,10 @@ func newMediator(database database, commitlog commitlog.CommitLog, opts Options)
 	}
 
 	d.databaseTickManager = newTickManager(database, opts)
+	d.memoryPressure = newMemoryPressureMonitor(opts, d.databaseTickManager,
+		scope.SubScope("memory-pressure"))
+	d.durabilityProbe = newDurabilityProber(database,
+		opts, scope.SubScope("durability-probe"))
 	d.databaseBootstrapManager = newBootstrapManager(database, d, opts)
 	return d, nil
 }
@@ -119,6 +126,12 @@ func (m *mediator) Open() error {
 	m.state = mediatorOpen
 	go m.reportLoop()
 	go m.ongoingTick()
+	if m.memoryPressure.Enabled() {
+		go m.memoryPressureLoop()
+	}
+	if m.durabilityProbe.Enabled() {
+		go m.durabilityProbeLoop()
+	}
 	m.databaseRepairer.Start()
 	return nil
 }
@@ -212,6 +225,38 @@ func (m *mediator) ongoingTick() {
 	}
 }
 
+func (m *mediator) MemoryPressureLevel() MemoryPressureLevel {
+	return m.memoryPressure.Level()
+}
+
+func (m *mediator) memoryPressureLoop() {
+	t := time.NewTicker(m.memoryPressure.CheckInterval())
+
+	for {
+		select {
+		case <-t.C:
+			m.memoryPressure.Check()
+		case <-m.closedCh:
+			t.Stop()
+			return
+		}
+	}
+}
+
+func (m *mediator) durabilityProbeLoop() {
+	t := time.NewTicker(m.durabilityProbe.Interval())
+
+	for {
+		select {
+		case <-t.C:
+			m.durabilityProbe.Probe()
+		case <-m.closedCh:
+			t.Stop()
+			return
+		}
+	}
+}
+
 func (m *mediator) reportLoop() {
 	interval := m.opts.InstrumentOptions().ReportInterval()
 	t := time.NewTicker(interval)
@@ -0,0 +1,282 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage/block"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultMemoryPressureCheckInterval = 5 * time.Second
+
+	memoryPressureElevatedEvictFraction = 0.1
+	memoryPressureHighEvictFraction     = 0.25
+	memoryPressureCriticalEvictFraction = 1.0
+)
+
+var (
+	errMemoryPressureCheckInterval = errors.New("memory pressure check interval must not be negative")
+	errMemoryPressureWatermarks    = errors.New("memory pressure watermarks must be ascending from elevated to critical")
+)
+
+// MemoryPressureOptions are the options for the memory pressure monitor which
+// compares the heap in use against staged watermarks and relieves pressure by
+// evicting cached blocks and by bringing forward the next tick and flush, a
+// zero watermark disables its level.
+type MemoryPressureOptions struct {
+	// CheckInterval is how often the heap in use is checked, zero uses the
+	// default interval.
+	CheckInterval time.Duration
+
+	// ElevatedHeapBytes is the heap in use at which a small fraction of the
+	// least recently read cached blocks are evicted.
+	ElevatedHeapBytes uint64
+
+	// HighHeapBytes is the heap in use at which a larger fraction of the least
+	// recently read cached blocks are evicted and ticks are expedited so that
+	// buffer buckets are sealed and flushed sooner.
+	HighHeapBytes uint64
+
+	// CriticalHeapBytes is the heap in use at which all cached blocks are
+	// evicted and ticks are expedited.
+	CriticalHeapBytes uint64
+}
+
+// Enabled returns whether any memory pressure level is enabled.
+func (o MemoryPressureOptions) Enabled() bool {
+	return o.ElevatedHeapBytes > 0 || o.HighHeapBytes > 0 || o.CriticalHeapBytes > 0
+}
+
+// Validate validates the memory pressure options.
+func (o MemoryPressureOptions) Validate() error {
+	if o.CheckInterval < 0 {
+		return errMemoryPressureCheckInterval
+	}
+	var prev uint64
+	for _, watermark := range []uint64{
+		o.ElevatedHeapBytes,
+		o.HighHeapBytes,
+		o.CriticalHeapBytes,
+	} {
+		if watermark == 0 {
+			continue
+		}
+		if watermark <= prev {
+			return errMemoryPressureWatermarks
+		}
+		prev = watermark
+	}
+	return nil
+}
+
+// MemoryPressureLevel is the level of the heap in use relative to the
+// memory pressure watermarks.
+type MemoryPressureLevel int
+
+const (
+	// MemoryPressureNone is below every enabled watermark.
+	MemoryPressureNone MemoryPressureLevel = iota
+	// MemoryPressureElevated is at or above the elevated watermark.
+	MemoryPressureElevated
+	// MemoryPressureHigh is at or above the high watermark.
+	MemoryPressureHigh
+	// MemoryPressureCritical is at or above the critical watermark.
+	MemoryPressureCritical
+)
+
+func (l MemoryPressureLevel) String() string {
+	switch l {
+	case MemoryPressureNone:
+		return "none"
+	case MemoryPressureElevated:
+		return "elevated"
+	case MemoryPressureHigh:
+		return "high"
+	case MemoryPressureCritical:
+		return "critical"
+	}
+	return "unknown"
+}
+
+// ParseMemoryPressureLevel parses a memory pressure level from its string
+// representation.
+func ParseMemoryPressureLevel(str string) (MemoryPressureLevel, error) {
+	for level := MemoryPressureNone; level <= MemoryPressureCritical; level++ {
+		if str == level.String() {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid memory pressure level '%s'", str)
+}
+
+type heapInUseFn func() uint64
+
+func readHeapInUse() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapInuse
+}
+
+// tickExpediter is implemented by the tick manager.
+type tickExpediter interface {
+	Expedite()
+}
+
+type memoryPressureMetrics struct {
+	level         tally.Gauge
+	heapInUse     tally.Gauge
+	evictHinted   tally.Counter
+	evictDropped  tally.Counter
+	tickExpedited tally.Counter
+}
+
+func newMemoryPressureMetrics(scope tally.Scope) memoryPressureMetrics {
+	return memoryPressureMetrics{
+		level:         scope.Gauge("level"),
+		heapInUse:     scope.Gauge("heap-inuse"),
+		evictHinted:   scope.Counter("evict-hinted"),
+		evictDropped:  scope.Counter("evict-dropped"),
+		tickExpedited: scope.Counter("tick-expedited"),
+	}
+}
+
+// memoryPressureMonitor checks the heap in use against the configured
+// watermarks and takes the actions staged for the level reached.
+type memoryPressureMonitor struct {
+	sync.Mutex
+
+	opts        MemoryPressureOptions
+	heapInUseFn heapInUseFn
+	wiredList   *block.WiredList
+	expediter   tickExpediter
+	logger      *zap.Logger
+	metrics     memoryPressureMetrics
+
+	level MemoryPressureLevel
+}
+
+func newMemoryPressureMonitor(
+	opts Options,
+	expediter tickExpediter,
+	scope tally.Scope,
+) *memoryPressureMonitor {
+	return &memoryPressureMonitor{
+		opts:        opts.MemoryPressureOptions(),
+		heapInUseFn: readHeapInUse,
+		wiredList:   opts.DatabaseBlockOptions().WiredList(),
+		expediter:   expediter,
+		logger:      opts.InstrumentOptions().Logger(),
+		metrics:     newMemoryPressureMetrics(scope),
+	}
+}
+
+// Enabled returns whether the monitor has any level enabled.
+func (m *memoryPressureMonitor) Enabled() bool {
+	return m.opts.Enabled()
+}
+
+// CheckInterval returns how often the monitor should be checked.
+func (m *memoryPressureMonitor) CheckInterval() time.Duration {
+	if m.opts.CheckInterval <= 0 {
+		return defaultMemoryPressureCheckInterval
+	}
+	return m.opts.CheckInterval
+}
+
+// Check reads the heap in use, takes the actions for the memory pressure
+// level it falls in and returns that level.
+func (m *memoryPressureMonitor) Check() MemoryPressureLevel {
+	m.Lock()
+	defer m.Unlock()
+
+	heapInUse := m.heapInUseFn()
+	level := m.levelFor(heapInUse)
+	m.metrics.heapInUse.Update(float64(heapInUse))
+	m.metrics.level.Update(float64(level))
+
+	if level != m.level {
+		logFn := m.logger.Info
+		if level > m.level {
+			logFn = m.logger.Warn
+		}
+		logFn("memory pressure level changed",
+			zap.Stringer("from", m.level),
+			zap.Stringer("to", level),
+			zap.Uint64("heapInUse", heapInUse))
+		m.level = level
+	}
+
+	switch level {
+	case MemoryPressureElevated:
+		m.evict(memoryPressureElevatedEvictFraction)
+	case MemoryPressureHigh:
+		m.evict(memoryPressureHighEvictFraction)
+		m.expedite()
+	case MemoryPressureCritical:
+		m.evict(memoryPressureCriticalEvictFraction)
+		m.expedite()
+	}
+
+	return level
+}
+
+// Level returns the memory pressure level of the last check.
+func (m *memoryPressureMonitor) Level() MemoryPressureLevel {
+	m.Lock()
+	defer m.Unlock()
+	return m.level
+}
+
+func (m *memoryPressureMonitor) levelFor(heapInUse uint64) MemoryPressureLevel {
+	switch {
+	case m.opts.CriticalHeapBytes > 0 && heapInUse >= m.opts.CriticalHeapBytes:
+		return MemoryPressureCritical
+	case m.opts.HighHeapBytes > 0 && heapInUse >= m.opts.HighHeapBytes:
+		return MemoryPressureHigh
+	case m.opts.ElevatedHeapBytes > 0 && heapInUse >= m.opts.ElevatedHeapBytes:
+		return MemoryPressureElevated
+	}
+	return MemoryPressureNone
+}
+
+func (m *memoryPressureMonitor) evict(fraction float64) {
+	if m.wiredList == nil {
+		// Blocks are not cached with a wired list.
+		return
+	}
+	if !m.wiredList.Evict(fraction) {
+		m.metrics.evictDropped.Inc(1)
+		return
+	}
+	m.metrics.evictHinted.Inc(1)
+}
+
+func (m *memoryPressureMonitor) expedite() {
+	m.expediter.Expedite()
+	m.metrics.tickExpedited.Inc(1)
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/dbnode/storage/block"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestMemoryPressureOptionsValidate(t *testing.T) {
+	require.NoError(t, MemoryPressureOptions{}.Validate())
+	require.NoError(t, MemoryPressureOptions{
+		ElevatedHeapBytes: 10,
+		CriticalHeapBytes: 30,
+	}.Validate())
+	require.Equal(t, errMemoryPressureCheckInterval, MemoryPressureOptions{
+		CheckInterval: -time.Second,
+	}.Validate())
+	require.Equal(t, errMemoryPressureWatermarks, MemoryPressureOptions{
+		ElevatedHeapBytes: 20,
+		HighHeapBytes:     20,
+	}.Validate())
+	require.Equal(t, errMemoryPressureWatermarks, MemoryPressureOptions{
+		HighHeapBytes:     30,
+		CriticalHeapBytes: 10,
+	}.Validate())
+}
+
+func TestMemoryPressureMonitorCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	wiredList := block.NewWiredList(block.WiredListOptions{
+		RuntimeOptionsManager: runtime.NewOptionsManager(),
+		InstrumentOptions:     instrument.NewOptions(),
+		ClockOptions:          clock.NewOptions(),
+	})
+	require.NoError(t, wiredList.Start())
+	defer wiredList.Stop()
+
+	opts := DefaultTestOptions().
+		SetDatabaseBlockOptions(block.NewOptions().SetWiredList(wiredList)).
+		SetMemoryPressureOptions(MemoryPressureOptions{
+			ElevatedHeapBytes: 100,
+			HighHeapBytes:     200,
+			CriticalHeapBytes: 300,
+		})
+	require.NoError(t, opts.Validate())
+
+	tickMgr := NewMockdatabaseTickManager(ctrl)
+	scope := tally.NewTestScope("", nil)
+	monitor := newMemoryPressureMonitor(opts, tickMgr, scope)
+	require.True(t, monitor.Enabled())
+	require.Equal(t, defaultMemoryPressureCheckInterval, monitor.CheckInterval())
+
+	var heapInUse uint64
+	monitor.heapInUseFn = func() uint64 {
+		return heapInUse
+	}
+
+	heapInUse = 50
+	require.Equal(t, MemoryPressureNone, monitor.Check())
+
+	heapInUse = 150
+	require.Equal(t, MemoryPressureElevated, monitor.Check())
+
+	heapInUse = 250
+	tickMgr.EXPECT().Expedite()
+	require.Equal(t, MemoryPressureHigh, monitor.Check())
+
+	heapInUse = 350
+	tickMgr.EXPECT().Expedite()
+	require.Equal(t, MemoryPressureCritical, monitor.Check())
+
+	heapInUse = 50
+	require.Equal(t, MemoryPressureNone, monitor.Check())
+
+	counters := scope.Snapshot().Counters()
+	var hinted, dropped int64
+	if c, ok := counters["evict-hinted+"]; ok {
+		hinted = c.Value()
+	}
+	if c, ok := counters["evict-dropped+"]; ok {
+		dropped = c.Value()
+	}
+	require.Equal(t, int64(3), hinted+dropped)
+	require.Equal(t, int64(2), counters["tick-expedited+"].Value())
+	require.Equal(t, float64(MemoryPressureNone), scope.Snapshot().Gauges()["level+"].Value())
+}
+
+func TestMemoryPressureMonitorDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	monitor := newMemoryPressureMonitor(DefaultTestOptions(),
+		NewMockdatabaseTickManager(ctrl), tally.NoopScope)
+	require.False(t, monitor.Enabled())
+
+	monitor.heapInUseFn = func() uint64 {
+		return 1 << 40
+	}
+	require.Equal(t, MemoryPressureNone, monitor.Check())
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+var (
+	errMultiNamespaceReadNoNamespaces       = errors.New("multi namespace read requires at least one namespace")
+	errMultiNamespaceReadBlockSizesMismatch = errors.New("multi namespace read requires namespaces with the same block size")
+)
+
+// Validate validates the multi namespace read policy.
+func (p MultiNamespaceReadPolicy) Validate() error {
+	switch p {
+	case MultiNamespaceReadOverride, MultiNamespaceReadMerge:
+		return nil
+	}
+	return fmt.Errorf("invalid multi namespace read policy: %d", p)
+}
+
+func (p MultiNamespaceReadPolicy) String() string {
+	switch p {
+	case MultiNamespaceReadOverride:
+		return "override"
+	case MultiNamespaceReadMerge:
+		return "merge"
+	}
+	return "unknown"
+}
+
+// mergeMultiNamespaceReads merges the blocks read for the same series from
+// each namespace, ordered highest precedence first, into a single list of
+// blocks ordered by block start.
+func mergeMultiNamespaceReads(
+	reads [][][]xio.BlockReader,
+	policy MultiNamespaceReadPolicy,
+) [][]xio.BlockReader {
+	if len(reads) == 1 {
+		return reads[0]
+	}
+
+	var (
+		byBlockStart = make(map[xtime.UnixNano][]xio.BlockReader)
+		blockStarts  []xtime.UnixNano
+	)
+	// Visit the namespaces lowest precedence first so that the blocks of
+	// higher precedence namespaces replace or follow them.
+	for i := len(reads) - 1; i >= 0; i-- {
+		for _, readers := range reads[i] {
+			if len(readers) == 0 {
+				continue
+			}
+			blockStart := xtime.ToUnixNano(readers[0].Start)
+			existing, ok := byBlockStart[blockStart]
+			if !ok {
+				blockStarts = append(blockStarts, blockStart)
+			}
+			if ok && policy == MultiNamespaceReadMerge {
+				// Copy rather than append to the existing readers as they are
+				// owned by the namespace that they were read from.
+				all := make([]xio.BlockReader, 0, len(existing)+len(readers))
+				all = append(all, existing...)
+				readers = append(all, readers...)
+			}
+			byBlockStart[blockStart] = readers
+		}
+	}
+
+	sort.Slice(blockStarts, func(i, j int) bool {
+		return blockStarts[i] < blockStarts[j]
+	})
+	merged := make([][]xio.BlockReader, 0, len(blockStarts))
+	for _, blockStart := range blockStarts {
+		merged = append(merged, byBlockStart[blockStart])
+	}
+	return merged
+}
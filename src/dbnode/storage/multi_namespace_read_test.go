@@ -0,0 +1,77 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/x/xio"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiNamespaceReadPolicyValidate(t *testing.T) {
+	require.NoError(t, MultiNamespaceReadOverride.Validate())
+	require.NoError(t, MultiNamespaceReadMerge.Validate())
+	require.Error(t, MultiNamespaceReadPolicy(2).Validate())
+}
+
+func TestMergeMultiNamespaceReads(t *testing.T) {
+	var (
+		blockSize = 2 * time.Hour
+		start     = time.Now().Truncate(blockSize)
+		reader    = func(blockStart time.Time) xio.BlockReader {
+			return xio.BlockReader{Start: blockStart, BlockSize: blockSize}
+		}
+		overlay = [][]xio.BlockReader{
+			{reader(start.Add(blockSize))},
+		}
+		raw = [][]xio.BlockReader{
+			{reader(start)},
+			{reader(start.Add(blockSize)), reader(start.Add(blockSize))},
+			{},
+			{reader(start.Add(2 * blockSize))},
+		}
+		reads = [][][]xio.BlockReader{overlay, raw}
+	)
+
+	// Blocks of the overlay namespace replace those of the raw namespace.
+	require.Equal(t, [][]xio.BlockReader{
+		raw[0],
+		overlay[0],
+		raw[3],
+	}, mergeMultiNamespaceReads(reads, MultiNamespaceReadOverride))
+
+	// Blocks of both namespaces are read, raw namespace blocks first.
+	require.Equal(t, [][]xio.BlockReader{
+		raw[0],
+		{raw[1][0], raw[1][1], overlay[0][0]},
+		raw[3],
+	}, mergeMultiNamespaceReads(reads, MultiNamespaceReadMerge))
+
+	// The raw namespace reads are left untouched.
+	require.Len(t, raw[1], 2)
+
+	// A single namespace is returned as is.
+	require.Equal(t, raw, mergeMultiNamespaceReads([][][]xio.BlockReader{raw},
+		MultiNamespaceReadMerge))
+}
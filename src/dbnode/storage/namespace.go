@@ -29,14 +29,18 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/encoding/proto"
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/durability"
 	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
 	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/dbnode/tracepoint"
 	"github.com/m3db/m3/src/dbnode/ts"
@@ -56,8 +60,11 @@ import (
 )
 
 var (
-	errNamespaceAlreadyClosed    = errors.New("namespace already closed")
-	errNamespaceIndexingDisabled = errors.New("namespace indexing is disabled")
+	errNamespaceAlreadyClosed            = errors.New("namespace already closed")
+	errNamespaceIndexingDisabled         = errors.New("namespace indexing is disabled")
+	errNamespaceRetentionBlockSizeChange = errors.New("namespace retention block size cannot be changed at runtime")
+	errNamespaceNoExpiredFileSetGrace    = errors.New("namespace has no expired fileset grace period to undelete from")
+	errNamespaceIndexNotCreated          = errors.New("namespace index was not created at startup, indexing can only be enabled with a restart")
 )
 
 type commitLogWriter interface {
@@ -120,6 +127,9 @@ type dbNamespace struct {
 	// schemaDescr is updated whenever schema registry is updated.
 	schemaListener xclose.SimpleCloser
 	schemaDescr    namespace.SchemaDescr
+	// schemaMigrationPending is set when the schema changes until a cold
+	// flush has re-encoded the flushed filesets with the latest schema.
+	schemaMigrationPending bool
 
 	// Contains an entry to all shards for fast shard lookup, an
 	// entry will be nil when this shard does not belong to current database
@@ -128,11 +138,39 @@ type dbNamespace struct {
 	increasingIndex increasingIndex
 	commitLogWriter commitLogWriter
 	reverseIndex    namespaceIndex
+	// indexDisabled is set when indexing is disabled at runtime, the index
+	// then skips indexing writes and queries are rejected by the namespace.
+	indexDisabled bool
 
 	tickWorkers            xsync.WorkerPool
 	tickWorkersConcurrency int
 	statsLastTick          databaseNamespaceStatsLastTick
 
+	// taskPauses tracks the background tasks of the namespace paused by an
+	// operator, e.g. to mitigate an incident.
+	taskPauses *backgroundTaskPauses
+
+	// ingestLimiter rejects writes exceeding the runtime ingest limits of
+	// the namespace to protect the rest of the cluster from traffic spikes.
+	ingestLimiter         *namespaceIngestLimiter
+	ingestLimiterListener xclose.SimpleCloser
+
+	// writeBlackout rejects or drops writes during the runtime write
+	// blackout windows of the namespace, e.g. for maintenance.
+	writeBlackout         *namespaceWriteBlackout
+	writeBlackoutListener xclose.SimpleCloser
+
+	// readBlock rejects the reads, or only the index queries matching
+	// specific tags, per the runtime read block rules of the namespace.
+	readBlock         *namespaceReadBlock
+	readBlockListener xclose.SimpleCloser
+
+	// undeleteLock serializes undeletes of the expired filesets of the
+	// namespace, undeletedRetentionPeriod is the retention period applied by
+	// the last undelete so that repeated undeletes are no-ops.
+	undeleteLock             sync.Mutex
+	undeletedRetentionPeriod time.Duration
+
 	metrics databaseNamespaceMetrics
 }
 
@@ -155,6 +193,7 @@ type databaseNamespaceMetrics struct {
 	flushColdData       instrument.MethodMetrics
 	flushIndex          instrument.MethodMetrics
 	snapshot            instrument.MethodMetrics
+	snapshotIndex       instrument.MethodMetrics
 	write               instrument.MethodMetrics
 	writeTagged         instrument.MethodMetrics
 	read                instrument.MethodMetrics
@@ -186,6 +225,7 @@ type databaseNamespaceTickMetrics struct {
 	madeUnwiredBlocks      tally.Counter
 	madeExpiredBlocks      tally.Counter
 	mergedOutOfOrderBlocks tally.Counter
+	partiallyMergedBlocks  tally.Gauge
 	errors                 tally.Counter
 	index                  databaseNamespaceIndexTickMetrics
 	evictedBuckets         tally.Counter
@@ -207,6 +247,7 @@ type databaseNamespaceStatusMetrics struct {
 	activeSeries tally.Gauge
 	activeBlocks tally.Gauge
 	index        databaseNamespaceIndexStatusMetrics
+	paused       map[BackgroundTask]tally.Gauge
 }
 
 type databaseNamespaceIndexStatusMetrics struct {
@@ -228,11 +269,18 @@ func newDatabaseNamespaceMetrics(scope tally.Scope, samplingRate float64) databa
 	indexTickScope := tickScope.SubScope("index")
 	statusScope := scope.SubScope("status")
 	indexStatusScope := statusScope.SubScope("index")
+	pausedStatus := make(map[BackgroundTask]tally.Gauge, len(validBackgroundTasks))
+	for _, task := range validBackgroundTasks {
+		pausedStatus[task] = statusScope.Tagged(map[string]string{
+			"task": task.String(),
+		}).Gauge("background-task-paused")
+	}
 	return databaseNamespaceMetrics{
 		bootstrap:           instrument.NewMethodMetrics(scope, "bootstrap", samplingRate),
 		flushWarmData:       instrument.NewMethodMetrics(scope, "flushWarmData", samplingRate),
 		flushColdData:       instrument.NewMethodMetrics(scope, "flushColdData", samplingRate),
 		flushIndex:          instrument.NewMethodMetrics(scope, "flushIndex", samplingRate),
+		snapshotIndex:       instrument.NewMethodMetrics(scope, "snapshotIndex", samplingRate),
 		snapshot:            instrument.NewMethodMetrics(scope, "snapshot", samplingRate),
 		write:               instrument.NewMethodMetrics(scope, "write", overrideWriteSamplingRate),
 		writeTagged:         instrument.NewMethodMetrics(scope, "write-tagged", overrideWriteSamplingRate),
@@ -259,6 +307,7 @@ func newDatabaseNamespaceMetrics(scope tally.Scope, samplingRate float64) databa
 			madeUnwiredBlocks:      tickScope.Counter("made-unwired-blocks"),
 			madeExpiredBlocks:      tickScope.Counter("made-expired-blocks"),
 			mergedOutOfOrderBlocks: tickScope.Counter("merged-out-of-order-blocks"),
+			partiallyMergedBlocks:  tickScope.Gauge("partially-merged-blocks"),
 			errors:                 tickScope.Counter("errors"),
 			index: databaseNamespaceIndexTickMetrics{
 				numDocs:          indexTickScope.Gauge("num-docs"),
@@ -277,6 +326,7 @@ func newDatabaseNamespaceMetrics(scope tally.Scope, samplingRate float64) databa
 				numBlocks:   indexStatusScope.Gauge("num-blocks"),
 				numSegments: indexStatusScope.Gauge("num-segments"),
 			},
+			paused: pausedStatus,
 		},
 	}
 }
@@ -321,11 +371,13 @@ func newDatabaseNamespace(
 	}
 
 	var (
-		index namespaceIndex
-		err   error
+		taskPauses = newBackgroundTaskPauses(opts.ClockOptions().NowFn())
+		index      namespaceIndex
+		err        error
 	)
 	if metadata.Options().IndexOptions().Enabled() {
-		index, err = newNamespaceIndex(metadata, opts)
+		index, err = newNamespaceIndexWithBlockOptions(metadata,
+			taskPauses.indexBlockOptions(), opts)
 		if err != nil {
 			return nil, err
 		}
@@ -349,6 +401,10 @@ func newDatabaseNamespace(
 		reverseIndex:           index,
 		tickWorkers:            tickWorkers,
 		tickWorkersConcurrency: tickWorkersConcurrency,
+		taskPauses:             taskPauses,
+		ingestLimiter:          newNamespaceIngestLimiter(id, scope, opts.ClockOptions().NowFn()),
+		writeBlackout:          newNamespaceWriteBlackout(id, scope, opts.ClockOptions().NowFn()),
+		readBlock:              newNamespaceReadBlock(id, scope),
 		metrics:                newDatabaseNamespaceMetrics(scope, iops.MetricsSamplingRate()),
 	}
 
@@ -361,6 +417,9 @@ func newDatabaseNamespace(
 			metadata.ID().String(), err)
 	}
 	n.schemaListener = sl
+	n.ingestLimiterListener = opts.RuntimeOptionsManager().RegisterListener(n.ingestLimiter)
+	n.writeBlackoutListener = opts.RuntimeOptionsManager().RegisterListener(n.writeBlackout)
+	n.readBlockListener = opts.RuntimeOptionsManager().RegisterListener(n.readBlock)
 	n.initShards(nopts.BootstrapEnabled())
 	go n.reportStatusLoop(opts.InstrumentOptions().ReportInterval())
 
@@ -384,6 +443,22 @@ func (n *dbNamespace) SetSchemaHistory(value namespace.SchemaHistory) {
 		return
 	}
 
+	if n.schemaDescr != nil && n.schemaDescr.DeployId() != schema.DeployId() {
+		// Reads decode the flushed filesets with the version of the schema
+		// they were encoded with, re-encode them in the background so that
+		// earlier versions of the schema are no longer needed to read them.
+		for _, shard := range n.shardSet.AllIDs() {
+			if s := n.shards[shard]; s != nil {
+				s.MigrateSchema()
+			}
+		}
+		n.schemaMigrationPending = true
+		n.log.Info("namespace schema changed, re-encoding flushed data with the new schema",
+			zap.Stringer("namespace", n.ID()),
+			zap.String("prevDeployID", n.schemaDescr.DeployId()),
+			zap.String("deployID", schema.DeployId()))
+	}
+
 	n.schemaDescr = schema
 	n.metadata = metadata
 }
@@ -403,12 +478,23 @@ func (n *dbNamespace) reportStatusLoop(reportInterval time.Duration) {
 			n.metrics.status.index.numBlocks.Update(float64(n.statsLastTick.index.numBlocks))
 			n.metrics.status.index.numSegments.Update(float64(n.statsLastTick.index.numSegments))
 			n.statsLastTick.RUnlock()
+
+			for task, gauge := range n.metrics.status.paused {
+				var paused float64
+				if n.taskPauses.IsPaused(task) {
+					paused = 1
+				}
+				gauge.Update(paused)
+			}
 		}
 	}
 }
 
 func (n *dbNamespace) Options() namespace.Options {
-	return n.nopts
+	n.RLock()
+	nopts := n.nopts
+	n.RUnlock()
+	return nopts
 }
 
 func (n *dbNamespace) ID() ident.ID {
@@ -514,7 +600,15 @@ func (n *dbNamespace) closeShards(shards []databaseShard, blockUntilClosed bool)
 	}
 }
 
-func (n *dbNamespace) Tick(c context.Cancellable, tickStart time.Time) error {
+func (n *dbNamespace) Tick(
+	c context.Cancellable,
+	tickStart time.Time,
+	pacer *tickPacer,
+) error {
+	if n.taskPauses.IsPaused(TickBackgroundTask) {
+		return nil
+	}
+
 	// Allow the reader cache to tick
 	n.namespaceReaderMgr.tick()
 
@@ -551,6 +645,9 @@ func (n *dbNamespace) Tick(c context.Cancellable, tickStart time.Time) error {
 			r = r.merge(shardResult)
 			multiErr = multiErr.Add(err)
 			l.Unlock()
+
+			// Spread the tick across the target duration if paced.
+			pacer.pace(c)
 		})
 	}
 
@@ -593,6 +690,7 @@ func (n *dbNamespace) Tick(c context.Cancellable, tickStart time.Time) error {
 	n.metrics.tick.madeExpiredBlocks.Inc(int64(r.madeExpiredBlocks))
 	n.metrics.tick.madeUnwiredBlocks.Inc(int64(r.madeUnwiredBlocks))
 	n.metrics.tick.mergedOutOfOrderBlocks.Inc(int64(r.mergedOutOfOrderBlocks))
+	n.metrics.tick.partiallyMergedBlocks.Update(float64(r.partiallyMergedBlocks))
 	n.metrics.tick.evictedBuckets.Inc(int64(r.evictedBuckets))
 	n.metrics.tick.index.numDocs.Update(float64(indexTickResults.NumTotalDocs))
 	n.metrics.tick.index.numBlocks.Update(float64(indexTickResults.NumBlocks))
@@ -613,14 +711,23 @@ func (n *dbNamespace) Write(
 	annotation []byte,
 ) (ts.Series, bool, error) {
 	callStart := n.nowFn()
+	if drop, err := n.writeBlackout.Check(); err != nil || drop {
+		n.metrics.write.ReportSuccessOrError(err, n.nowFn().Sub(callStart))
+		return ts.Series{}, false, err
+	}
+	if err := n.ingestLimiter.Admit(1, approxWriteBytes(id, annotation)); err != nil {
+		n.metrics.write.ReportError(n.nowFn().Sub(callStart))
+		return ts.Series{}, false, err
+	}
 	shard, nsCtx, err := n.shardFor(id)
 	if err != nil {
 		n.metrics.write.ReportError(n.nowFn().Sub(callStart))
 		return ts.Series{}, false, err
 	}
 	opts := series.WriteOptions{
-		TruncateType: n.opts.TruncateType(),
-		SchemaDesc:   nsCtx.Schema,
+		TruncateType:       n.opts.TruncateType(),
+		SchemaDesc:         nsCtx.Schema,
+		StrictlyIncreasing: series.StrictlyIncreasingWrites(ctx),
 	}
 	series, wasWritten, err := shard.Write(ctx, id, timestamp,
 		value, unit, annotation, opts)
@@ -638,18 +745,30 @@ func (n *dbNamespace) WriteTagged(
 	annotation []byte,
 ) (ts.Series, bool, error) {
 	callStart := n.nowFn()
-	if n.reverseIndex == nil { // only happens if indexing is enabled.
+	// NB: only namespaces created without an index reject tagged writes,
+	// when indexing is disabled at runtime the write is accepted and the
+	// index skips indexing it.
+	if n.reverseIndex == nil {
 		n.metrics.writeTagged.ReportError(n.nowFn().Sub(callStart))
 		return ts.Series{}, false, errNamespaceIndexingDisabled
 	}
+	if drop, err := n.writeBlackout.Check(); err != nil || drop {
+		n.metrics.writeTagged.ReportSuccessOrError(err, n.nowFn().Sub(callStart))
+		return ts.Series{}, false, err
+	}
+	if err := n.ingestLimiter.Admit(1, approxWriteBytes(id, annotation)); err != nil {
+		n.metrics.writeTagged.ReportError(n.nowFn().Sub(callStart))
+		return ts.Series{}, false, err
+	}
 	shard, nsCtx, err := n.shardFor(id)
 	if err != nil {
 		n.metrics.writeTagged.ReportError(n.nowFn().Sub(callStart))
 		return ts.Series{}, false, err
 	}
 	opts := series.WriteOptions{
-		TruncateType: n.opts.TruncateType(),
-		SchemaDesc:   nsCtx.Schema,
+		TruncateType:       n.opts.TruncateType(),
+		SchemaDesc:         nsCtx.Schema,
+		StrictlyIncreasing: series.StrictlyIncreasingWrites(ctx),
 	}
 	series, wasWritten, err := shard.WriteTagged(ctx, id, tags, timestamp,
 		value, unit, annotation, opts)
@@ -657,6 +776,57 @@ func (n *dbNamespace) WriteTagged(
 	return series, wasWritten, err
 }
 
+func (n *dbNamespace) ValidateWrite(
+	id ident.ID,
+	tags ident.TagIterator,
+	timestamp time.Time,
+	annotation []byte,
+) error {
+	if tags != nil && n.reverseIndex == nil {
+		return errNamespaceIndexingDisabled
+	}
+	_, nsCtx, err := n.shardFor(id)
+	if err != nil {
+		return err
+	}
+
+	nopts := n.Options()
+	err = series.ValidateWriteTime(id, timestamp, n.nowFn(),
+		nopts.RetentionOptions(), nopts.ColdWritesEnabled())
+	if err != nil {
+		return err
+	}
+
+	if nsCtx.Schema != nil {
+		if err := proto.ValidateMessage(nsCtx.Schema, annotation); err != nil {
+			return xerrors.NewInvalidParamsError(err)
+		}
+	}
+
+	if tags == nil {
+		return nil
+	}
+
+	// Tags must be able to be serialized for the commit log and filesets and
+	// to be indexed.
+	encoder := n.opts.CommitLogOptions().FilesystemOptions().TagEncoderPool().Get()
+	defer encoder.Finalize()
+	if err := encoder.Encode(tags); err != nil {
+		return xerrors.NewInvalidParamsError(err)
+	}
+
+	dupTags := tags.Duplicate()
+	defer dupTags.Close()
+	d, err := convert.FromMetricIter(id, dupTags)
+	if err != nil {
+		return xerrors.NewInvalidParamsError(err)
+	}
+	if err := d.Validate(); err != nil {
+		return xerrors.NewInvalidParamsError(err)
+	}
+	return nil
+}
+
 func (n *dbNamespace) QueryIDs(
 	ctx context.Context,
 	query index.Query,
@@ -674,7 +844,13 @@ func (n *dbNamespace) QueryIDs(
 	defer sp.Finish()
 
 	callStart := n.nowFn()
-	if n.reverseIndex == nil { // only happens if indexing is enabled.
+	if err := n.readBlock.CheckQuery(query); err != nil {
+		n.metrics.queryIDs.ReportError(n.nowFn().Sub(callStart))
+		sp.LogFields(opentracinglog.Error(err))
+		return index.QueryResult{}, err
+	}
+
+	if !n.indexEnabled() {
 		n.metrics.queryIDs.ReportError(n.nowFn().Sub(callStart))
 		err := errNamespaceIndexingDisabled
 		sp.LogFields(opentracinglog.Error(err))
@@ -704,7 +880,12 @@ func (n *dbNamespace) AggregateQuery(
 	opts index.AggregationOptions,
 ) (index.AggregateQueryResult, error) {
 	callStart := n.nowFn()
-	if n.reverseIndex == nil { // only happens if indexing is enabled.
+	if err := n.readBlock.CheckQuery(query); err != nil {
+		n.metrics.aggregateQuery.ReportError(n.nowFn().Sub(callStart))
+		return index.AggregateQueryResult{}, err
+	}
+
+	if !n.indexEnabled() {
 		n.metrics.aggregateQuery.ReportError(n.nowFn().Sub(callStart))
 		return index.AggregateQueryResult{}, errNamespaceIndexingDisabled
 	}
@@ -727,6 +908,10 @@ func (n *dbNamespace) ReadEncoded(
 	start, end time.Time,
 ) ([][]xio.BlockReader, error) {
 	callStart := n.nowFn()
+	if err := n.readBlock.CheckRead(); err != nil {
+		n.metrics.read.ReportError(n.nowFn().Sub(callStart))
+		return nil, err
+	}
 	shard, nsCtx, err := n.readableShardFor(id)
 	if err != nil {
 		n.metrics.read.ReportError(n.nowFn().Sub(callStart))
@@ -737,6 +922,76 @@ func (n *dbNamespace) ReadEncoded(
 	return res, err
 }
 
+func (n *dbNamespace) ReadEncodedBatch(
+	ctx context.Context,
+	ids []ident.ID,
+	start, end time.Time,
+) []ReadEncodedResult {
+	callStart := n.nowFn()
+	results := make([]ReadEncodedResult, len(ids))
+	if err := n.readBlock.CheckRead(); err != nil {
+		for i := range results {
+			results[i].Err = err
+		}
+		n.metrics.read.ReportError(n.nowFn().Sub(callStart))
+		return results
+	}
+
+	// Group the ids by shard under a single acquisition of the namespace
+	// lock so that each shard is only visited once for the whole batch.
+	type shardBatch struct {
+		shard   databaseShard
+		err     error
+		indexes []int
+		ids     []ident.ID
+	}
+	var (
+		batches   = make(map[uint32]*shardBatch)
+		shardIDs  []uint32
+		anyFailed bool
+	)
+	n.RLock()
+	nsCtx := n.nsContextWithRLock()
+	for i, id := range ids {
+		shardID := n.shardSet.Lookup(id)
+		batch, ok := batches[shardID]
+		if !ok {
+			shard, err := n.readableShardAtWithRLock(shardID)
+			batch = &shardBatch{shard: shard, err: err}
+			batches[shardID] = batch
+			if err == nil {
+				shardIDs = append(shardIDs, shardID)
+			}
+		}
+		if batch.err != nil {
+			results[i].Err = batch.err
+			anyFailed = true
+			continue
+		}
+		batch.indexes = append(batch.indexes, i)
+		batch.ids = append(batch.ids, id)
+	}
+	n.RUnlock()
+
+	for _, shardID := range shardIDs {
+		batch := batches[shardID]
+		shardResults := batch.shard.ReadEncodedBatch(ctx, batch.ids, start, end, nsCtx)
+		for j, idx := range batch.indexes {
+			results[idx] = shardResults[j]
+			if shardResults[j].Err != nil {
+				anyFailed = true
+			}
+		}
+	}
+
+	if anyFailed {
+		n.metrics.read.ReportError(n.nowFn().Sub(callStart))
+	} else {
+		n.metrics.read.ReportSuccess(n.nowFn().Sub(callStart))
+	}
+	return results
+}
+
 func (n *dbNamespace) FetchBlocks(
 	ctx context.Context,
 	shardID uint32,
@@ -803,7 +1058,7 @@ func (n *dbNamespace) Bootstrap(start time.Time, process bootstrap.Process) erro
 		n.metrics.bootstrapEnd.Inc(1)
 	}()
 
-	if !n.nopts.BootstrapEnabled() {
+	if !n.Options().BootstrapEnabled() {
 		success = true
 		n.metrics.bootstrap.ReportSuccess(n.nowFn().Sub(callStart))
 		return nil
@@ -905,6 +1160,16 @@ func (n *dbNamespace) Bootstrap(start time.Time, process bootstrap.Process) erro
 	if n.reverseIndex != nil {
 		err := n.reverseIndex.Bootstrap(bootstrapResult.IndexResult.IndexResults())
 		multiErr = multiErr.Add(err)
+
+		// The index is bootstrapped from the filesets which may still hold
+		// series deleted before the node was restarted.
+		var deleted []ident.ID
+		for _, shard := range shards {
+			deleted = append(deleted, shard.DeletedSeries()...)
+		}
+		if len(deleted) > 0 {
+			multiErr = multiErr.Add(n.reverseIndex.MarkDeleted(deleted))
+		}
 	}
 
 	markAnyUnfulfilled := func(label string, unfulfilled result.ShardTimeRanges) {
@@ -948,19 +1213,34 @@ func (n *dbNamespace) WarmFlush(
 	nsCtx := n.nsContextWithRLock()
 	n.RUnlock()
 
-	if !n.nopts.FlushEnabled() {
+	if !n.Options().FlushEnabled() {
 		n.metrics.flushWarmData.ReportSuccess(n.nowFn().Sub(callStart))
 		return nil
 	}
 
+	if n.taskPauses.IsPaused(WarmFlushBackgroundTask) {
+		// The block remains in memory, and the commit logs covering it are
+		// retained, until the warm flush is resumed.
+		return nil
+	}
+
 	// check if blockStart is aligned with the namespace's retention options
-	bs := n.nopts.RetentionOptions().BlockSize()
+	bs := n.Options().RetentionOptions().BlockSize()
 	if t := blockStart.Truncate(bs); !blockStart.Equal(t) {
 		return fmt.Errorf("failed to flush at time %v, not aligned to blockSize", blockStart.String())
 	}
 
-	multiErr := xerrors.NewMultiError()
-	shards := n.GetOwnedShards()
+	// Flush shards concurrently, bounded by the number of data filesets that
+	// the persist manager persists concurrently.
+	workers := xsync.NewWorkerPool(n.opts.CommitLogOptions().FilesystemOptions().FlushConcurrency())
+	workers.Init()
+
+	var (
+		multiErr = xerrors.NewMultiError()
+		shards   = n.GetOwnedShards()
+		mutex    sync.Mutex
+		wg       sync.WaitGroup
+	)
 	for _, shard := range shards {
 		// This is different than calling shard.IsBootstrapped() because it was determined
 		// before the start of the tick that preceded this flush, meaning it can be reliably
@@ -984,14 +1264,25 @@ func (n *dbNamespace) WarmFlush(
 		if s := shard.FlushState(blockStart); s.WarmStatus == fileOpSuccess {
 			continue
 		}
-		// NB(xichen): we still want to proceed if a shard fails to flush its data.
-		// Probably want to emit a counter here, but for now just log it.
-		if err := shard.WarmFlush(blockStart, flushPersist, nsCtx); err != nil {
-			detailedErr := fmt.Errorf("shard %d failed to flush data: %v",
-				shard.ID(), err)
-			multiErr = multiErr.Add(detailedErr)
+		if shard.IsFlushPaused(WarmFlushBackgroundTask) {
+			continue
 		}
+		shard := shard
+		wg.Add(1)
+		workers.Go(func() {
+			defer wg.Done()
+			// NB(xichen): we still want to proceed if a shard fails to flush its data.
+			// Probably want to emit a counter here, but for now just log it.
+			if err := shard.WarmFlush(blockStart, flushPersist, nsCtx); err != nil {
+				detailedErr := fmt.Errorf("shard %d failed to flush data: %v",
+					shard.ID(), err)
+				mutex.Lock()
+				multiErr = multiErr.Add(detailedErr)
+				mutex.Unlock()
+			}
+		})
 	}
+	wg.Wait()
 
 	res := multiErr.FinalError()
 	n.metrics.flushWarmData.ReportSuccessOrError(res, n.nowFn().Sub(callStart))
@@ -1069,34 +1360,90 @@ func (n *dbNamespace) ColdFlush(
 		return errNamespaceNotBootstrapped
 	}
 	nsCtx := namespace.Context{Schema: n.schemaDescr}
+	schemaMigrationPending := n.schemaMigrationPending
 	n.RUnlock()
 
-	if !n.nopts.ColdWritesEnabled() {
+	// The cold flush also re-encodes the flushed filesets after a schema
+	// change, so it runs for namespaces without cold writes in that case.
+	if !n.Options().ColdWritesEnabled() && !schemaMigrationPending {
 		n.metrics.flushColdData.ReportSuccess(n.nowFn().Sub(callStart))
 		return nil
 	}
 
-	multiErr := xerrors.NewMultiError()
-	shards := n.GetOwnedShards()
+	if n.taskPauses.IsPaused(ColdFlushBackgroundTask) {
+		// Cold writes remain in memory, and are captured by snapshots, until
+		// the cold flush is resumed.
+		return nil
+	}
 
-	resources, err := newColdFlushReuseableResources(n.opts)
-	if err != nil {
-		return err
+	// Cold flush shards concurrently, each with its own resources as they
+	// are reused between the shards cold flushed one after the other.
+	concurrency := effectiveColdFlushOptions(n.opts).Concurrency
+	if concurrency < 1 {
+		concurrency = 1
 	}
-	for _, shard := range shards {
-		err := shard.ColdFlush(flushPersist, resources, nsCtx)
+	resourcesCh := make(chan coldFlushReuseableResources, concurrency)
+	for i := 0; i < concurrency; i++ {
+		resources, err := newColdFlushReuseableResources(n.opts)
 		if err != nil {
-			detailedErr := fmt.Errorf("shard %d failed to compact: %v", shard.ID(), err)
-			multiErr = multiErr.Add(detailedErr)
-			// Continue with remaining shards.
+			return err
+		}
+		resourcesCh <- resources
+	}
+
+	var (
+		multiErr = xerrors.NewMultiError()
+		shards   = n.GetOwnedShards()
+		skipped  bool
+		mutex    sync.Mutex
+		wg       sync.WaitGroup
+	)
+	for _, shard := range shards {
+		if shard.IsFlushPaused(ColdFlushBackgroundTask) {
+			skipped = true
+			continue
 		}
+		// Wait for resources to be available, bounding the concurrency.
+		resources := <-resourcesCh
+		shard := shard
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := shard.ColdFlush(flushPersist, resources, nsCtx)
+			if err != nil {
+				detailedErr := fmt.Errorf("shard %d failed to compact: %v", shard.ID(), err)
+				mutex.Lock()
+				multiErr = multiErr.Add(detailedErr)
+				mutex.Unlock()
+				// Continue with remaining shards.
+			}
+			resourcesCh <- resources
+		}()
 	}
+	wg.Wait()
 
 	res := multiErr.FinalError()
+	// The schema migration is only complete once every shard was flushed.
+	if res == nil && schemaMigrationPending && !skipped {
+		n.Lock()
+		if n.schemaDescr == nsCtx.Schema {
+			// Only clear if the schema did not change again during the flush.
+			n.schemaMigrationPending = false
+		}
+		n.Unlock()
+	}
 	n.metrics.flushColdData.ReportSuccessOrError(res, n.nowFn().Sub(callStart))
 	return res
 }
 
+func (n *dbNamespace) NumPendingColdWrites() int64 {
+	var pending int64
+	for _, shard := range n.GetOwnedShards() {
+		pending += shard.NumPendingColdWrites()
+	}
+	return pending
+}
+
 func (n *dbNamespace) FlushIndex(
 	flush persist.IndexFlush,
 ) error {
@@ -1109,7 +1456,7 @@ func (n *dbNamespace) FlushIndex(
 	}
 	n.RUnlock()
 
-	if !n.nopts.FlushEnabled() || !n.nopts.IndexOptions().Enabled() {
+	if nopts := n.Options(); !nopts.FlushEnabled() || !nopts.IndexOptions().Enabled() {
 		n.metrics.flushIndex.ReportSuccess(n.nowFn().Sub(callStart))
 		return nil
 	}
@@ -1120,6 +1467,31 @@ func (n *dbNamespace) FlushIndex(
 	return err
 }
 
+func (n *dbNamespace) SnapshotIndex(
+	snapshotTime time.Time,
+	flush persist.IndexFlush,
+) error {
+	callStart := n.nowFn()
+	n.RLock()
+	if n.bootstrapState != Bootstrapped {
+		n.RUnlock()
+		n.metrics.snapshotIndex.ReportError(n.nowFn().Sub(callStart))
+		return errNamespaceNotBootstrapped
+	}
+	n.RUnlock()
+
+	if nopts := n.Options(); !nopts.SnapshotEnabled() || !nopts.IndexOptions().Enabled() ||
+		!n.opts.IndexOptions().SnapshotEnabled() {
+		n.metrics.snapshotIndex.ReportSuccess(n.nowFn().Sub(callStart))
+		return nil
+	}
+
+	shards := n.GetOwnedShards()
+	err := n.reverseIndex.Snapshot(shards, snapshotTime, flush)
+	n.metrics.snapshotIndex.ReportSuccessOrError(err, n.nowFn().Sub(callStart))
+	return err
+}
+
 func (n *dbNamespace) Snapshot(
 	blockStart,
 	snapshotTime time.Time,
@@ -1139,7 +1511,7 @@ func (n *dbNamespace) Snapshot(
 	nsCtx = n.nsContextWithRLock()
 	n.RUnlock()
 
-	if !n.nopts.SnapshotEnabled() {
+	if !n.Options().SnapshotEnabled() {
 		// Note that we keep the ability to disable snapshots at the namespace level around for
 		// debugging / performance / flexibility reasons, but disabling it can / will cause data
 		// loss due to the commitlog cleanup logic assuming that a valid snapshot checkpoint file
@@ -1220,14 +1592,202 @@ func (n *dbNamespace) Truncate() (int64, error) {
 	return totalNumSeries, nil
 }
 
+func (n *dbNamespace) DeleteSeries(ctx context.Context, ids []ident.ID) error {
+	byShard := make(map[uint32][]ident.ID)
+	n.RLock()
+	for _, id := range ids {
+		shardID := n.shardSet.Lookup(id)
+		byShard[shardID] = append(byShard[shardID], id)
+	}
+	n.RUnlock()
+
+	var multiErr xerrors.MultiError
+	for shardID, shardIDs := range byShard {
+		n.RLock()
+		shard, err := n.shardAtWithRLock(shardID)
+		n.RUnlock()
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		if err := shard.DeleteSeries(shardIDs); err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		// Series are only excluded from queries once their tombstones have
+		// been recorded so that a failed delete is not partially applied.
+		if n.reverseIndex != nil {
+			multiErr = multiErr.Add(n.reverseIndex.MarkDeleted(shardIDs))
+		}
+	}
+	return multiErr.FinalError()
+}
+
+func (n *dbNamespace) UpdateRetentionOptions(ropts retention.Options) error {
+	if err := ropts.Validate(); err != nil {
+		return xerrors.NewInvalidParamsError(err)
+	}
+
+	n.Lock()
+	if n.closed {
+		n.Unlock()
+		return errNamespaceAlreadyClosed
+	}
+	if ropts.BlockSize() != n.nopts.RetentionOptions().BlockSize() {
+		n.Unlock()
+		return xerrors.NewInvalidParamsError(errNamespaceRetentionBlockSizeChange)
+	}
+	// NB: Build the metadata from the current metadata rather than the
+	// namespace options so that any schema updates are retained.
+	metadata, err := namespace.NewMetadata(n.ID(),
+		n.metadata.Options().SetRetentionOptions(ropts))
+	if err != nil {
+		n.Unlock()
+		return err
+	}
+	n.metadata = metadata
+	n.nopts = n.nopts.SetRetentionOptions(ropts)
+	n.seriesOpts = n.seriesOpts.SetRetentionOptions(ropts)
+	n.Unlock()
+
+	// The seekers are opened and closed to match the updated retention by the
+	// seeker manager, while expired filesets become eligible for cleanup as
+	// soon as the namespace options are updated.
+	var multiErr xerrors.MultiError
+	if n.blockRetriever != nil {
+		multiErr = multiErr.Add(n.blockRetriever.UpdateNamespaceMetadata(metadata))
+	}
+	if n.reverseIndex != nil {
+		n.reverseIndex.UpdateRetentionOptions(ropts)
+	}
+	for _, shard := range n.GetOwnedShards() {
+		shard.UpdateRetentionOptions(ropts)
+	}
+
+	n.log.Info("updated namespace retention",
+		zap.Stringer("namespace", n.ID()),
+		zap.Duration("retentionPeriod", ropts.RetentionPeriod()),
+		zap.Duration("futureRetentionPeriod", ropts.FutureRetentionPeriod()),
+		zap.Duration("bufferPast", ropts.BufferPast()),
+		zap.Duration("bufferFuture", ropts.BufferFuture()))
+	return multiErr.FinalError()
+}
+
+func (n *dbNamespace) UpdateIndexEnabled(enabled bool) error {
+	n.Lock()
+	if n.closed {
+		n.Unlock()
+		return errNamespaceAlreadyClosed
+	}
+	if n.reverseIndex == nil {
+		n.Unlock()
+		if !enabled {
+			return nil
+		}
+		// NB: The index is created along with the namespace so a namespace
+		// created without indexing needs a restart to enable it.
+		return xerrors.NewInvalidParamsError(errNamespaceIndexNotCreated)
+	}
+	mdOpts := n.metadata.Options()
+	metadata, err := namespace.NewMetadata(n.ID(),
+		mdOpts.SetIndexOptions(mdOpts.IndexOptions().SetEnabled(enabled)))
+	if err != nil {
+		n.Unlock()
+		return err
+	}
+	n.metadata = metadata
+	n.nopts = n.nopts.SetIndexOptions(n.nopts.IndexOptions().SetEnabled(enabled))
+	n.indexDisabled = !enabled
+	n.Unlock()
+
+	n.reverseIndex.SetEnabled(enabled)
+
+	n.log.Info("updated namespace indexing",
+		zap.Stringer("namespace", n.ID()),
+		zap.Bool("enabled", enabled))
+	return nil
+}
+
+// indexEnabled returns whether the namespace has an index and indexing has
+// not been disabled at runtime.
+func (n *dbNamespace) indexEnabled() bool {
+	n.RLock()
+	enabled := n.reverseIndex != nil && !n.indexDisabled
+	n.RUnlock()
+	return enabled
+}
+
+func (n *dbNamespace) UndeleteExpiredFileSets() (retention.Options, error) {
+	n.undeleteLock.Lock()
+	defer n.undeleteLock.Unlock()
+
+	ropts := n.Options().RetentionOptions()
+	grace := ropts.ExpiredFileSetGracePeriod()
+	if grace <= 0 {
+		return nil, xerrors.NewInvalidParamsError(errNamespaceNoExpiredFileSetGrace)
+	}
+	if ropts.RetentionPeriod() == n.undeletedRetentionPeriod {
+		// Already undeleted and the retention has not been updated since.
+		return ropts, nil
+	}
+
+	// Extending the retention by the grace period makes the blocks that fell
+	// out of retention but whose filesets are yet to be cleaned up queryable
+	// again, the grace period is retained so cleanup keeps trailing it.
+	restored := ropts.SetRetentionPeriod(ropts.RetentionPeriod() + grace)
+	if err := n.UpdateRetentionOptions(restored); err != nil {
+		return nil, err
+	}
+	n.undeletedRetentionPeriod = restored.RetentionPeriod()
+	return restored, nil
+}
+
+func (n *dbNamespace) RebootstrapShard(
+	process bootstrap.Process,
+	shardID uint32,
+	window xtime.Range,
+) error {
+	n.RLock()
+	shard, err := n.shardAtWithRLock(shardID)
+	n.RUnlock()
+	if err != nil {
+		return err
+	}
+	return shard.Rebootstrap(process, window)
+}
+
+func (n *dbNamespace) BootstrapDryRun(
+	start time.Time,
+	process bootstrap.Process,
+) (bootstrap.DryRunResult, error) {
+	if !n.Options().BootstrapEnabled() {
+		return bootstrap.DryRunResult{}, nil
+	}
+
+	n.RLock()
+	metadata := n.metadata
+	n.RUnlock()
+
+	owned := n.GetOwnedShards()
+	shardIDs := make([]uint32, 0, len(owned))
+	for _, shard := range owned {
+		shardIDs = append(shardIDs, shard.ID())
+	}
+	return process.DryRun(start, metadata, shardIDs)
+}
+
 func (n *dbNamespace) Repair(
 	repairer databaseShardRepairer,
 	tr xtime.Range,
 ) error {
-	if !n.nopts.RepairEnabled() {
+	if !n.Options().RepairEnabled() {
 		return nil
 	}
 
+	if n.taskPauses.IsPaused(RepairBackgroundTask) {
+		return errBackgroundTaskPaused
+	}
+
 	var (
 		wg                    sync.WaitGroup
 		mutex                 sync.Mutex
@@ -1404,6 +1964,15 @@ func (n *dbNamespace) Close() error {
 	n.namespaceReaderMgr.close()
 	n.closeShards(shards, true)
 	close(n.shutdownCh)
+	if n.ingestLimiterListener != nil {
+		n.ingestLimiterListener.Close()
+	}
+	if n.writeBlackoutListener != nil {
+		n.writeBlackoutListener.Close()
+	}
+	if n.readBlockListener != nil {
+		n.readBlockListener.Close()
+	}
 	if n.reverseIndex != nil {
 		return n.reverseIndex.Close()
 	}
@@ -1436,6 +2005,127 @@ func (n *dbNamespace) FlushState(shardID uint32, blockStart time.Time) (fileOpSt
 	return shard.FlushState(blockStart), nil
 }
 
+func (n *dbNamespace) FlushStates(shardID uint32) ([]BlockFlushState, error) {
+	n.RLock()
+	defer n.RUnlock()
+	shard, err := n.shardAtWithRLock(shardID)
+	if err != nil {
+		return nil, err
+	}
+	return shard.FlushStates(), nil
+}
+
+func (n *dbNamespace) FlushStateSummary() NamespaceFlushStateSummary {
+	var (
+		shards = n.GetOwnedShards()
+		states []BlockFlushState
+	)
+	for _, shard := range shards {
+		states = append(states, shard.FlushStates()...)
+	}
+	return summarizeFlushStates(len(shards), states)
+}
+
+func (n *dbNamespace) PauseBackgroundTask(task BackgroundTask, until time.Time) {
+	n.taskPauses.Pause(task, until)
+}
+
+func (n *dbNamespace) ResumeBackgroundTask(task BackgroundTask) {
+	n.taskPauses.Resume(task)
+}
+
+func (n *dbNamespace) PausedBackgroundTasks() map[BackgroundTask]time.Time {
+	return n.taskPauses.Paused()
+}
+
+func (n *dbNamespace) BlockCardinalities(
+	shardID uint32,
+	start, end time.Time,
+) (BlockCardinalities, error) {
+	shard, _, err := n.readableShardAt(shardID)
+	if err != nil {
+		return BlockCardinalities{}, err
+	}
+	return shard.BlockCardinalities(start, end), nil
+}
+
+func (n *dbNamespace) SeriesMetadata(id ident.ID) (SeriesMetadata, error) {
+	shard, _, err := n.shardFor(id)
+	if err != nil {
+		return SeriesMetadata{}, err
+	}
+	return shard.SeriesMetadata(id)
+}
+
+func (n *dbNamespace) HotSeries(limit int) HotSeriesResult {
+	var result HotSeriesResult
+	for _, shard := range n.GetOwnedShards() {
+		writes, reads := shard.HotSeries(limit)
+		result.Writes = append(result.Writes, writes...)
+		result.Reads = append(result.Reads, reads...)
+	}
+
+	sortHotSeries(result.Writes)
+	sortHotSeries(result.Reads)
+	if limit > 0 && len(result.Writes) > limit {
+		result.Writes = result.Writes[:limit]
+	}
+	if limit > 0 && len(result.Reads) > limit {
+		result.Reads = result.Reads[:limit]
+	}
+	return result
+}
+
+func (n *dbNamespace) DataDurability(
+	start, end, lastSnapshotStart time.Time,
+) durability.Ranges {
+	var (
+		ropts     = n.Options().RetentionOptions()
+		blockSize = ropts.BlockSize()
+		shards    = n.GetOwnedShards()
+		// NB: Only datapoints older than the buffer past at the start of the
+		// last successful snapshot must have been written before the snapshot
+		// started, so only those are considered snapshotted.
+		snapshotCutoff time.Time
+		ranges         durability.Ranges
+	)
+	if !lastSnapshotStart.IsZero() {
+		snapshotCutoff = lastSnapshotStart.Add(-ropts.BufferPast())
+	}
+
+	for blockStart := start.Truncate(blockSize); blockStart.Before(end); blockStart = blockStart.Add(blockSize) {
+		var (
+			rangeStart = xtime.MaxTime(blockStart, start)
+			rangeEnd   = xtime.MinTime(blockStart.Add(blockSize), end)
+		)
+		if blockFlushedByAllShards(shards, blockStart) {
+			ranges = ranges.Add(rangeStart, rangeEnd, durability.Flushed)
+			continue
+		}
+
+		snapshotEnd := xtime.MinTime(rangeEnd, snapshotCutoff)
+		ranges = ranges.Add(rangeStart, snapshotEnd, durability.Snapshot)
+		ranges = ranges.Add(xtime.MaxTime(rangeStart, snapshotEnd), rangeEnd,
+			durability.BufferOnly)
+	}
+
+	return ranges
+}
+
+// blockFlushedByAllShards returns whether the warm data of a block has been
+// flushed by all of the shards, which is never the case without any shards.
+func blockFlushedByAllShards(shards []databaseShard, blockStart time.Time) bool {
+	if len(shards) == 0 {
+		return false
+	}
+	for _, shard := range shards {
+		if shard.FlushState(blockStart).WarmStatus != fileOpSuccess {
+			return false
+		}
+	}
+	return true
+}
+
 func (n *dbNamespace) nsContextWithRLock() namespace.Context {
 	return namespace.Context{ID: n.id, Schema: n.schemaDescr}
 }
@@ -0,0 +1,169 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/runtime"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	// approxWriteOverheadBytes approximates the size of a write excluding its
+	// ID and annotation, i.e. its timestamp, value and unit.
+	approxWriteOverheadBytes = 17
+)
+
+var (
+	errNamespaceIngestLimitExceeded = errors.New("namespace ingest limit exceeded")
+)
+
+// namespaceIngestLimiter admits writes to a namespace using token buckets on
+// the datapoints and bytes written per second, the buckets hold up to a
+// second of capacity so that short bursts above the limit are admitted.
+type namespaceIngestLimiter struct {
+	sync.Mutex
+
+	namespace string
+	nowFn     clock.NowFn
+
+	// enabled is accessed atomically so that writes do not need to acquire
+	// the lock when no limit is enforced.
+	enabled    int32
+	limit      runtime.NamespaceIngestLimit
+	datapoints tokenBucket
+	bytes      tokenBucket
+
+	metrics namespaceIngestLimiterMetrics
+}
+
+type namespaceIngestLimiterMetrics struct {
+	admitted tally.Counter
+	rejected tally.Counter
+}
+
+func newNamespaceIngestLimiter(
+	namespace ident.ID,
+	scope tally.Scope,
+	nowFn clock.NowFn,
+) *namespaceIngestLimiter {
+	scope = scope.SubScope("ingest-limit")
+	return &namespaceIngestLimiter{
+		namespace: namespace.String(),
+		nowFn:     nowFn,
+		metrics: namespaceIngestLimiterMetrics{
+			admitted: scope.Counter("admitted"),
+			rejected: scope.Counter("rejected"),
+		},
+	}
+}
+
+func (l *namespaceIngestLimiter) SetRuntimeOptions(value runtime.Options) {
+	limit := value.NamespaceIngestLimits().Limit(l.namespace)
+
+	l.Lock()
+	if limit != l.limit {
+		// Start with full buckets whenever the limit changes.
+		now := l.nowFn()
+		l.limit = limit
+		l.datapoints.reset(limit.DatapointsPerSecond, now)
+		l.bytes.reset(limit.BytesPerSecond, now)
+	}
+	if limit.Enabled() {
+		atomic.StoreInt32(&l.enabled, 1)
+	} else {
+		atomic.StoreInt32(&l.enabled, 0)
+	}
+	l.Unlock()
+}
+
+// Admit admits writes of the given number of datapoints and bytes, returning
+// a resource exhausted error if either of the limits would be exceeded.
+func (l *namespaceIngestLimiter) Admit(datapoints, bytes int64) error {
+	if atomic.LoadInt32(&l.enabled) == 0 {
+		return nil
+	}
+
+	l.Lock()
+	now := l.nowFn()
+	l.datapoints.refill(now)
+	l.bytes.refill(now)
+	if !l.datapoints.has(datapoints) || !l.bytes.has(bytes) {
+		l.Unlock()
+		l.metrics.rejected.Inc(datapoints)
+		return xerrors.NewResourceExhaustedError(errNamespaceIngestLimitExceeded)
+	}
+	l.datapoints.take(datapoints)
+	l.bytes.take(bytes)
+	l.Unlock()
+
+	l.metrics.admitted.Inc(datapoints)
+	return nil
+}
+
+// tokenBucket is a token bucket refilled at a rate per second up to a
+// capacity of a second's worth of tokens, a zero rate disables the bucket.
+type tokenBucket struct {
+	rate       float64
+	tokens     float64
+	lastRefill time.Time
+}
+
+func (b *tokenBucket) reset(ratePerSecond int64, now time.Time) {
+	b.rate = float64(ratePerSecond)
+	b.tokens = b.rate
+	b.lastRefill = now
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if b.rate <= 0 {
+		return
+	}
+	elapsed := now.Sub(b.lastRefill)
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens = math.Min(b.rate, b.tokens+elapsed.Seconds()*b.rate)
+	b.lastRefill = now
+}
+
+func (b *tokenBucket) has(n int64) bool {
+	return b.rate <= 0 || b.tokens >= float64(n)
+}
+
+func (b *tokenBucket) take(n int64) {
+	if b.rate <= 0 {
+		return
+	}
+	b.tokens -= float64(n)
+}
+
+func approxWriteBytes(id ident.ID, annotation []byte) int64 {
+	return int64(approxWriteOverheadBytes + len(id.Bytes()) + len(annotation))
+}
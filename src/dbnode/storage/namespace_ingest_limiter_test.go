@@ -0,0 +1,93 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/runtime"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestNamespaceIngestLimiter(
+	namespace string,
+	limits runtime.NamespaceIngestLimits,
+) (*namespaceIngestLimiter, *time.Time) {
+	now := time.Unix(1000, 0)
+	l := newNamespaceIngestLimiter(ident.StringID(namespace), tally.NoopScope,
+		func() time.Time {
+			return now
+		})
+	l.SetRuntimeOptions(runtime.NewOptions().SetNamespaceIngestLimits(limits))
+	return l, &now
+}
+
+func TestNamespaceIngestLimiterDisabledByDefault(t *testing.T) {
+	l, _ := newTestNamespaceIngestLimiter("foo", runtime.NamespaceIngestLimits{})
+	for i := 0; i < 100; i++ {
+		require.NoError(t, l.Admit(1, 1<<20))
+	}
+}
+
+func TestNamespaceIngestLimiterRejectsExcessDatapoints(t *testing.T) {
+	l, now := newTestNamespaceIngestLimiter("foo", runtime.NamespaceIngestLimits{
+		Default: runtime.NamespaceIngestLimit{DatapointsPerSecond: 10},
+	})
+
+	require.NoError(t, l.Admit(6, 0))
+	require.NoError(t, l.Admit(4, 0))
+	err := l.Admit(1, 0)
+	require.True(t, xerrors.IsResourceExhaustedError(err))
+	require.Equal(t, errNamespaceIngestLimitExceeded, xerrors.GetInnerResourceExhaustedError(err))
+
+	// Tokens are refilled at the limit rate.
+	*now = now.Add(500 * time.Millisecond)
+	require.NoError(t, l.Admit(5, 0))
+	require.Error(t, l.Admit(1, 0))
+
+	// The bucket holds at most a second of capacity.
+	*now = now.Add(time.Minute)
+	require.NoError(t, l.Admit(10, 0))
+	require.Error(t, l.Admit(1, 0))
+}
+
+func TestNamespaceIngestLimiterRejectsExcessBytesWithOverride(t *testing.T) {
+	limits := runtime.NamespaceIngestLimits{
+		Default: runtime.NamespaceIngestLimit{DatapointsPerSecond: 1},
+		Overrides: map[string]runtime.NamespaceIngestLimit{
+			"foo": {BytesPerSecond: 100},
+		},
+	}
+	l, _ := newTestNamespaceIngestLimiter("foo", limits)
+	require.NoError(t, l.Admit(5, 80))
+	require.Error(t, l.Admit(1, 40))
+
+	// Updating the limits at runtime starts from full buckets.
+	l.SetRuntimeOptions(runtime.NewOptions().SetNamespaceIngestLimits(
+		runtime.NamespaceIngestLimits{}))
+	for i := 0; i < 10; i++ {
+		require.NoError(t, l.Admit(1, 1000))
+	}
+}
@@ -0,0 +1,836 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/storage/block"
+	dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/x/context"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	namespaceMigrationsDir         = "migrations"
+	namespaceMigrationStateFileExt = ".json"
+
+	// namespaceMigrationPageSize is the number of series of a shard read per
+	// page when backfilling or verifying a migration.
+	namespaceMigrationPageSize = 4096
+
+	// namespaceMigrationBootstrapWait is how long a migration waits between
+	// checks of whether the database has bootstrapped before backfilling.
+	namespaceMigrationBootstrapWait = time.Second
+)
+
+var (
+	errNamespaceMigrationExists             = errors.New("namespace is already being migrated")
+	errNamespaceMigrationNotFound           = errors.New("namespace is not being migrated")
+	errNamespaceMigrationSameNamespace      = errors.New("namespace migration target must differ from the source")
+	errNamespaceMigrationSameBlockSize      = errors.New("namespace migration target must have a different block size")
+	errNamespaceMigrationRetentionMismatch  = errors.New("namespace migration target must have the same retention period")
+	errNamespaceMigrationColdWritesDisabled = errors.New("namespace migration target must have cold writes enabled")
+	errNamespaceMigrationIndexDisabled      = errors.New("namespace migration target must have the index enabled")
+	errNamespaceMigrationNotReadyForCutover = errors.New("namespace migration is not ready for cutover")
+	errNamespaceCopySameNamespace           = errors.New("namespace copy target must differ from the source")
+	errNamespaceCopyInvalidRange            = errors.New("namespace copy start must be before its end")
+	errNamespaceCopyColdWritesDisabled      = errors.New("namespace copy target must have cold writes enabled")
+
+	// errNamespaceMigrationStopped is returned by the backfill or verification
+	// of a migration that was aborted or whose database was closed.
+	errNamespaceMigrationStopped = errors.New("namespace migration stopped")
+)
+
+func (p NamespaceMigrationPhase) String() string {
+	switch p {
+	case NamespaceMigrationBackfilling:
+		return "backfilling"
+	case NamespaceMigrationVerifying:
+		return "verifying"
+	case NamespaceMigrationReadyForCutover:
+		return "ready-for-cutover"
+	case NamespaceMigrationCutover:
+		return "cutover"
+	case NamespaceMigrationFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// NewNamespaceMigrationTarget returns the metadata of a namespace to migrate
+// a namespace to, identical to the source namespace other than its block
+// size and with cold writes enabled so that the history of the source
+// namespace can be backfilled into it. The target namespace must be added to
+// the namespace registry before the migration is started.
+func NewNamespaceMigrationTarget(
+	source namespace.Metadata,
+	target ident.ID,
+	blockSize time.Duration,
+) (namespace.Metadata, error) {
+	var (
+		opts      = source.Options()
+		idxOpts   = opts.IndexOptions()
+		retention = opts.RetentionOptions().SetBlockSize(blockSize)
+	)
+	if idxOpts.BlockSize()%blockSize != 0 {
+		idxOpts = idxOpts.SetBlockSize(blockSize)
+	}
+	opts = opts.
+		SetRetentionOptions(retention).
+		SetIndexOptions(idxOpts).
+		SetColdWritesEnabled(true)
+	return namespace.NewMetadata(target, opts)
+}
+
+type namespaceMigrationMetrics struct {
+	mirrorErrors         tally.Counter
+	backfilledDatapoints tally.Counter
+	failed               tally.Counter
+	copiedSeries         tally.Counter
+	copiedDatapoints     tally.Counter
+}
+
+func newNamespaceMigrationMetrics(scope tally.Scope) namespaceMigrationMetrics {
+	scope = scope.SubScope("namespace-migration")
+	return namespaceMigrationMetrics{
+		mirrorErrors:         scope.Counter("mirror-errors"),
+		backfilledDatapoints: scope.Counter("backfilled-datapoints"),
+		failed:               scope.Counter("failed"),
+		copiedSeries:         scope.Counter("copied-series"),
+		copiedDatapoints:     scope.Counter("copied-datapoints"),
+	}
+}
+
+type namespaceMigration struct {
+	state   NamespaceMigrationState
+	source  ident.ID
+	target  ident.ID
+	abortCh chan struct{}
+	doneCh  chan struct{}
+}
+
+// namespaceMigrationManager migrates namespaces to target namespaces with a
+// different block size. It mirrors the writes to each source namespace to
+// its target, backfills the history of the source namespace into the target
+// through the write path so that it is re-blocked to the block size of the
+// target, verifies that the target has every series of the source and, once
+// cutover, switches reads and queries of the source namespace to the target.
+// The state of each migration is persisted under the file path prefix so
+// that migrations resume where they left off when the node restarts.
+type namespaceMigrationManager struct {
+	sync.RWMutex
+
+	database      database
+	opts          Options
+	fsOpts        fs.Options
+	dir           string
+	nowFn         clock.NowFn
+	log           *zap.Logger
+	metrics       namespaceMigrationMetrics
+	bootstrapWait time.Duration
+
+	migrations map[string]*namespaceMigration
+	// numMigrations is read without the lock so that writes skip the lock
+	// altogether when no namespace is being migrated.
+	numMigrations int32
+	closeCh       chan struct{}
+	closed        bool
+}
+
+func newNamespaceMigrationManager(
+	database database,
+	opts Options,
+) *namespaceMigrationManager {
+	fsOpts := opts.CommitLogOptions().FilesystemOptions()
+	return &namespaceMigrationManager{
+		database:      database,
+		opts:          opts,
+		fsOpts:        fsOpts,
+		dir:           path.Join(fsOpts.FilePathPrefix(), namespaceMigrationsDir),
+		nowFn:         opts.ClockOptions().NowFn(),
+		log:           opts.InstrumentOptions().Logger(),
+		metrics:       newNamespaceMigrationMetrics(opts.InstrumentOptions().MetricsScope()),
+		bootstrapWait: namespaceMigrationBootstrapWait,
+		migrations:    make(map[string]*namespaceMigration),
+		closeCh:       make(chan struct{}),
+	}
+}
+
+// Open loads the persisted migrations and resumes the ones that have not
+// finished backfilling or verifying.
+func (m *namespaceMigrationManager) Open() error {
+	files, err := ioutil.ReadDir(m.dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), namespaceMigrationStateFileExt) {
+			continue
+		}
+		data, err := ioutil.ReadFile(path.Join(m.dir, f.Name()))
+		if err != nil {
+			return err
+		}
+		var state NamespaceMigrationState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return fmt.Errorf("unable to read namespace migration %s: %v", f.Name(), err)
+		}
+		m.addWithLock(state)
+	}
+	return nil
+}
+
+// Close stops the migrations that are backfilling or verifying without
+// waiting for them, they resume when the manager is next opened.
+func (m *namespaceMigrationManager) Close() {
+	m.Lock()
+	defer m.Unlock()
+	if m.closed {
+		return
+	}
+	m.closed = true
+	close(m.closeCh)
+}
+
+// Start validates and starts the migration of a namespace to a target.
+func (m *namespaceMigrationManager) Start(
+	source, target ident.ID,
+) (NamespaceMigrationState, error) {
+	if source.Equal(target) {
+		return NamespaceMigrationState{}, xerrors.NewInvalidParamsError(errNamespaceMigrationSameNamespace)
+	}
+	sourceNs, err := m.ownedNamespace(source)
+	if err != nil {
+		return NamespaceMigrationState{}, err
+	}
+	targetNs, err := m.ownedNamespace(target)
+	if err != nil {
+		return NamespaceMigrationState{}, err
+	}
+	if err := validateNamespaceMigration(sourceNs.Options(), targetNs.Options()); err != nil {
+		return NamespaceMigrationState{}, xerrors.NewInvalidParamsError(err)
+	}
+
+	var (
+		now       = m.nowFn()
+		retention = sourceNs.Options().RetentionOptions()
+		state     = NamespaceMigrationState{
+			Source:          source.String(),
+			Target:          target.String(),
+			Phase:           NamespaceMigrationBackfilling,
+			MirrorStart:     now,
+			BackfillStart:   now.Add(-retention.RetentionPeriod()).Truncate(retention.BlockSize()),
+			BackfillEnd:     now.Add(retention.BufferFuture()),
+			BackfilledUntil: make(map[uint32]time.Time),
+		}
+	)
+
+	m.Lock()
+	defer m.Unlock()
+	for _, mig := range m.migrations {
+		// Chained migrations would mirror writes more than once.
+		if mig.state.Source == state.Source || mig.state.Target == state.Source ||
+			mig.state.Source == state.Target || mig.state.Target == state.Target {
+			return NamespaceMigrationState{}, xerrors.NewInvalidParamsError(errNamespaceMigrationExists)
+		}
+	}
+	if err := m.persistWithLock(state); err != nil {
+		return NamespaceMigrationState{}, err
+	}
+	m.addWithLock(state)
+	m.log.Info("started namespace migration",
+		zap.String("source", state.Source),
+		zap.String("target", state.Target),
+		zap.Time("backfillStart", state.BackfillStart),
+		zap.Time("backfillEnd", state.BackfillEnd))
+	return state.copy(), nil
+}
+
+func validateNamespaceMigration(source, target namespace.Options) error {
+	var (
+		sourceRetention = source.RetentionOptions()
+		targetRetention = target.RetentionOptions()
+	)
+	if sourceRetention.BlockSize() == targetRetention.BlockSize() {
+		return errNamespaceMigrationSameBlockSize
+	}
+	if sourceRetention.RetentionPeriod() != targetRetention.RetentionPeriod() {
+		return errNamespaceMigrationRetentionMismatch
+	}
+	if !target.ColdWritesEnabled() {
+		return errNamespaceMigrationColdWritesDisabled
+	}
+	if source.IndexOptions().Enabled() && !target.IndexOptions().Enabled() {
+		return errNamespaceMigrationIndexDisabled
+	}
+	return nil
+}
+
+// Cutover switches reads of the source namespace of a migration that is
+// ready for cutover to its target namespace.
+// Copy copies the datapoints of the source namespace within [start, end)
+// to the target namespace, a block of the source namespace at a time. The
+// datapoints are written through the database so that they are re-encoded
+// to the block size and retention of the target namespace, the copy is
+// synchronous and does not mirror writes made to the source while copying.
+func (m *namespaceMigrationManager) Copy(
+	source, target ident.ID,
+	start, end time.Time,
+) (NamespaceCopyResult, error) {
+	if source.Equal(target) {
+		return NamespaceCopyResult{}, xerrors.NewInvalidParamsError(errNamespaceCopySameNamespace)
+	}
+	if !start.Before(end) {
+		return NamespaceCopyResult{}, xerrors.NewInvalidParamsError(errNamespaceCopyInvalidRange)
+	}
+	sourceNs, err := m.ownedNamespace(source)
+	if err != nil {
+		return NamespaceCopyResult{}, err
+	}
+	targetNs, err := m.ownedNamespace(target)
+	if err != nil {
+		return NamespaceCopyResult{}, err
+	}
+	if !targetNs.Options().ColdWritesEnabled() {
+		return NamespaceCopyResult{}, xerrors.NewInvalidParamsError(errNamespaceCopyColdWritesDisabled)
+	}
+
+	var (
+		result = NamespaceCopyResult{
+			Source: source.String(),
+			Target: target.String(),
+			Start:  start,
+			End:    end,
+		}
+		blockSize = sourceNs.Options().RetentionOptions().BlockSize()
+		tagged    = targetNs.Options().IndexOptions().Enabled()
+	)
+	m.log.Info("copying namespace",
+		zap.String("source", result.Source),
+		zap.String("target", result.Target),
+		zap.Time("start", start),
+		zap.Time("end", end))
+	for _, shard := range sourceNs.GetOwnedShards() {
+		// Series are counted once per shard even when they span blocks.
+		copied := make(map[string]struct{})
+		for blockStart := start.Truncate(blockSize); blockStart.Before(end); blockStart = blockStart.Add(blockSize) {
+			var (
+				copyStart = blockStart
+				copyEnd   = blockStart.Add(blockSize)
+			)
+			if copyStart.Before(start) {
+				copyStart = start
+			}
+			if copyEnd.After(end) {
+				copyEnd = end
+			}
+
+			err := m.forEachSeries(nil, sourceNs, shard.ID(), copyStart, copyEnd,
+				func(ctx context.Context, series block.FetchBlocksMetadataResult) error {
+					n, err := m.writeSeries(ctx, sourceNs, target, tagged, series,
+						copyStart, copyEnd)
+					if err != nil {
+						return err
+					}
+					if n == 0 {
+						return nil
+					}
+					result.Datapoints += n
+					m.metrics.copiedDatapoints.Inc(n)
+					if _, ok := copied[series.ID.String()]; !ok {
+						copied[series.ID.String()] = struct{}{}
+						result.Series++
+						m.metrics.copiedSeries.Inc(1)
+					}
+					return nil
+				})
+			if err != nil {
+				return result, err
+			}
+		}
+	}
+	m.log.Info("copied namespace",
+		zap.String("source", result.Source),
+		zap.String("target", result.Target),
+		zap.Int64("series", result.Series),
+		zap.Int64("datapoints", result.Datapoints))
+	return result, nil
+}
+
+func (m *namespaceMigrationManager) Cutover(source ident.ID) (NamespaceMigrationState, error) {
+	m.Lock()
+	defer m.Unlock()
+	mig, ok := m.migrations[source.String()]
+	if !ok {
+		return NamespaceMigrationState{}, xerrors.NewInvalidParamsError(errNamespaceMigrationNotFound)
+	}
+	if mig.state.Phase != NamespaceMigrationReadyForCutover {
+		return NamespaceMigrationState{}, xerrors.NewInvalidParamsError(errNamespaceMigrationNotReadyForCutover)
+	}
+
+	state := mig.state.copy()
+	state.Phase = NamespaceMigrationCutover
+	if err := m.persistWithLock(state); err != nil {
+		return NamespaceMigrationState{}, err
+	}
+	// Reads resolve the namespace to read under the lock, so they switch to
+	// the target namespace all at once.
+	mig.state = state
+	m.log.Info("cutover namespace migration",
+		zap.String("source", state.Source),
+		zap.String("target", state.Target))
+	return state.copy(), nil
+}
+
+// Abort stops the migration of a namespace and removes its state.
+func (m *namespaceMigrationManager) Abort(source ident.ID) error {
+	m.Lock()
+	mig, ok := m.migrations[source.String()]
+	if !ok {
+		m.Unlock()
+		return xerrors.NewInvalidParamsError(errNamespaceMigrationNotFound)
+	}
+	delete(m.migrations, mig.state.Source)
+	atomic.AddInt32(&m.numMigrations, -1)
+	m.Unlock()
+
+	// Wait for the migration to stop before removing its state, so that it
+	// is not persisted again as it stops.
+	close(mig.abortCh)
+	<-mig.doneCh
+	if err := os.Remove(m.statePath(mig.state.Source)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	m.log.Info("aborted namespace migration",
+		zap.String("source", mig.state.Source),
+		zap.String("target", mig.state.Target))
+	return nil
+}
+
+// States returns the state of every migration ordered by source namespace.
+func (m *namespaceMigrationManager) States() []NamespaceMigrationState {
+	m.RLock()
+	states := make([]NamespaceMigrationState, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		states = append(states, mig.state.copy())
+	}
+	m.RUnlock()
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Source < states[j].Source
+	})
+	return states
+}
+
+// MirrorTarget returns the namespace that writes to a namespace must also
+// be written to, if any.
+func (m *namespaceMigrationManager) MirrorTarget(namespace ident.ID) (ident.ID, bool) {
+	if atomic.LoadInt32(&m.numMigrations) == 0 {
+		return nil, false
+	}
+	m.RLock()
+	defer m.RUnlock()
+	mig, ok := m.migrations[string(namespace.Bytes())]
+	if !ok || mig.state.Phase == NamespaceMigrationFailed {
+		return nil, false
+	}
+	return mig.target, true
+}
+
+// ReadNamespace returns the namespace that reads of a namespace are served
+// from, which is the target of its migration once cutover.
+func (m *namespaceMigrationManager) ReadNamespace(namespace ident.ID) ident.ID {
+	if atomic.LoadInt32(&m.numMigrations) == 0 {
+		return namespace
+	}
+	m.RLock()
+	defer m.RUnlock()
+	mig, ok := m.migrations[string(namespace.Bytes())]
+	if !ok || mig.state.Phase != NamespaceMigrationCutover {
+		return namespace
+	}
+	return mig.target
+}
+
+func (m *namespaceMigrationManager) addWithLock(state NamespaceMigrationState) {
+	if state.BackfilledUntil == nil {
+		state.BackfilledUntil = make(map[uint32]time.Time)
+	}
+	mig := &namespaceMigration{
+		state:   state,
+		source:  ident.StringID(state.Source),
+		target:  ident.StringID(state.Target),
+		abortCh: make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+	m.migrations[state.Source] = mig
+	atomic.AddInt32(&m.numMigrations, 1)
+
+	switch state.Phase {
+	case NamespaceMigrationBackfilling, NamespaceMigrationVerifying:
+		go m.run(mig)
+	default:
+		close(mig.doneCh)
+	}
+}
+
+func (m *namespaceMigrationManager) run(mig *namespaceMigration) {
+	defer close(mig.doneCh)
+
+	err := m.backfillAndVerify(mig)
+	if err == nil || err == errNamespaceMigrationStopped {
+		return
+	}
+
+	m.metrics.failed.Inc(1)
+	m.log.Error("namespace migration failed",
+		zap.Stringer("source", mig.source),
+		zap.Stringer("target", mig.target),
+		zap.Error(err))
+	m.update(mig, func(state *NamespaceMigrationState) {
+		state.Phase = NamespaceMigrationFailed
+		state.Error = err.Error()
+	})
+}
+
+func (m *namespaceMigrationManager) backfillAndVerify(mig *namespaceMigration) error {
+	// The history of the source namespace is only complete once bootstrapped.
+	for !m.database.IsBootstrapped() {
+		select {
+		case <-m.closeCh:
+			return errNamespaceMigrationStopped
+		case <-mig.abortCh:
+			return errNamespaceMigrationStopped
+		case <-time.After(m.bootstrapWait):
+		}
+	}
+
+	source, err := m.ownedNamespace(mig.source)
+	if err != nil {
+		return err
+	}
+	target, err := m.ownedNamespace(mig.target)
+	if err != nil {
+		return err
+	}
+
+	if m.phase(mig) == NamespaceMigrationBackfilling {
+		if err := m.backfill(mig, source, target); err != nil {
+			return err
+		}
+		err := m.update(mig, func(state *NamespaceMigrationState) {
+			state.Phase = NamespaceMigrationVerifying
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	missing, err := m.verify(mig, source, target)
+	if err != nil {
+		return err
+	}
+	if missing > 0 {
+		return m.update(mig, func(state *NamespaceMigrationState) {
+			state.Phase = NamespaceMigrationFailed
+			state.MissingSeries = missing
+			state.Error = fmt.Sprintf("%d series missing from target namespace", missing)
+		})
+	}
+	return m.update(mig, func(state *NamespaceMigrationState) {
+		state.Phase = NamespaceMigrationReadyForCutover
+		state.MissingSeries = 0
+	})
+}
+
+// backfill writes the datapoints of each block of the source namespace not
+// yet backfilled to the target namespace, recording the progress of each
+// shard once each block has been backfilled.
+func (m *namespaceMigrationManager) backfill(
+	mig *namespaceMigration,
+	source, target databaseNamespace,
+) error {
+	var (
+		blockSize = source.Options().RetentionOptions().BlockSize()
+		tagged    = target.Options().IndexOptions().Enabled()
+	)
+	for _, shard := range source.GetOwnedShards() {
+		shardID := shard.ID()
+
+		m.RLock()
+		start, end := mig.state.BackfillStart, mig.state.BackfillEnd
+		if until, ok := mig.state.BackfilledUntil[shardID]; ok && until.After(start) {
+			start = until
+		}
+		m.RUnlock()
+
+		for ; start.Before(end); start = start.Add(blockSize) {
+			blockEnd := start.Add(blockSize)
+			if blockEnd.After(end) {
+				blockEnd = end
+			}
+
+			err := m.forEachSeries(mig.abortCh, source, shardID, start, blockEnd,
+				func(ctx context.Context, series block.FetchBlocksMetadataResult) error {
+					n, err := m.writeSeries(ctx, source, mig.target, tagged, series,
+						start, blockEnd)
+					m.metrics.backfilledDatapoints.Inc(n)
+					return err
+				})
+			if err != nil {
+				return err
+			}
+
+			err = m.update(mig, func(state *NamespaceMigrationState) {
+				state.BackfilledUntil[shardID] = blockEnd
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeSeries writes the datapoints of a series of the source namespace
+// within [start, end) to the target namespace, returning the number of
+// datapoints written.
+func (m *namespaceMigrationManager) writeSeries(
+	ctx context.Context,
+	source databaseNamespace,
+	target ident.ID,
+	tagged bool,
+	series block.FetchBlocksMetadataResult,
+	start, end time.Time,
+) (int64, error) {
+	readers, err := source.ReadEncoded(ctx, series.ID, start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	iter := m.opts.MultiReaderIteratorPool().Get()
+	iter.ResetSliceOfSlices(xio.NewReaderSliceOfSlicesFromBlockReadersIterator(readers),
+		source.Schema())
+	defer iter.Close()
+
+	var written int64
+	for iter.Next() {
+		dp, unit, annotation := iter.Current()
+		if dp.Timestamp.Before(start) || !dp.Timestamp.Before(end) {
+			continue
+		}
+
+		// Write through the database so that the target namespace re-blocks
+		// the datapoints and they are written to the commit log.
+		if tagged && series.Tags != nil {
+			tags := series.Tags.Duplicate()
+			err = m.database.WriteTagged(ctx, target, series.ID, tags,
+				dp.Timestamp, dp.Value, unit, annotation)
+			tags.Close()
+		} else {
+			err = m.database.Write(ctx, target, series.ID,
+				dp.Timestamp, dp.Value, unit, annotation)
+		}
+		if err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, iter.Err()
+}
+
+// verify returns the number of series of the source namespace with data
+// in the backfilled range that the target namespace does not have.
+func (m *namespaceMigrationManager) verify(
+	mig *namespaceMigration,
+	source, target databaseNamespace,
+) (int64, error) {
+	m.RLock()
+	start, end := mig.state.BackfillStart, mig.state.BackfillEnd
+	m.RUnlock()
+
+	var missing int64
+	for _, shard := range source.GetOwnedShards() {
+		shardID := shard.ID()
+
+		targetIDs := make(map[string]struct{})
+		err := m.forEachSeries(mig.abortCh, target, shardID, start, end,
+			func(_ context.Context, series block.FetchBlocksMetadataResult) error {
+				targetIDs[series.ID.String()] = struct{}{}
+				return nil
+			})
+		if err != nil {
+			return 0, err
+		}
+
+		err = m.forEachSeries(mig.abortCh, source, shardID, start, end,
+			func(_ context.Context, series block.FetchBlocksMetadataResult) error {
+				if _, ok := targetIDs[string(series.ID.Bytes())]; !ok {
+					missing++
+				}
+				return nil
+			})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return missing, nil
+}
+
+// forEachSeries calls fn with each series of a shard of a namespace with
+// data within [start, end), a page at a time, until the manager is closed
+// or the abort channel, if any, is closed.
+func (m *namespaceMigrationManager) forEachSeries(
+	abortCh <-chan struct{},
+	n databaseNamespace,
+	shardID uint32,
+	start, end time.Time,
+	fn func(ctx context.Context, series block.FetchBlocksMetadataResult) error,
+) error {
+	var pageToken PageToken
+	for {
+		select {
+		case <-m.closeCh:
+			return errNamespaceMigrationStopped
+		case <-abortCh:
+			return errNamespaceMigrationStopped
+		default:
+		}
+
+		ctx := m.opts.ContextPool().Get()
+		results, nextPageToken, err := n.FetchBlocksMetadataV2(ctx, shardID,
+			start, end, namespaceMigrationPageSize, pageToken, block.FetchBlocksMetadataOptions{})
+		if err != nil {
+			ctx.Close()
+			return err
+		}
+		for _, series := range results.Results() {
+			if err = fn(ctx, series); err != nil {
+				break
+			}
+		}
+		results.Close()
+		ctx.Close()
+		if err != nil {
+			return err
+		}
+
+		if nextPageToken == nil {
+			return nil
+		}
+		pageToken = nextPageToken
+	}
+}
+
+func (m *namespaceMigrationManager) phase(mig *namespaceMigration) NamespaceMigrationPhase {
+	m.RLock()
+	defer m.RUnlock()
+	return mig.state.Phase
+}
+
+// update updates and persists the state of a migration unless it has been
+// aborted in the meantime.
+func (m *namespaceMigrationManager) update(
+	mig *namespaceMigration,
+	fn func(state *NamespaceMigrationState),
+) error {
+	m.Lock()
+	defer m.Unlock()
+	if m.migrations[mig.state.Source] != mig {
+		return errNamespaceMigrationStopped
+	}
+
+	state := mig.state.copy()
+	fn(&state)
+	if err := m.persistWithLock(state); err != nil {
+		return err
+	}
+	mig.state = state
+	return nil
+}
+
+func (m *namespaceMigrationManager) persistWithLock(state NamespaceMigrationState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.dir, m.fsOpts.NewDirectoryMode()); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that the state is replaced in full.
+	statePath := m.statePath(state.Source)
+	tmpPath := statePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, m.fsOpts.NewFileMode()); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, statePath)
+}
+
+func (m *namespaceMigrationManager) statePath(source string) string {
+	return path.Join(m.dir, source+namespaceMigrationStateFileExt)
+}
+
+func (m *namespaceMigrationManager) ownedNamespace(id ident.ID) (databaseNamespace, error) {
+	namespaces, err := m.database.GetOwnedNamespaces()
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range namespaces {
+		if n.ID().Equal(id) {
+			return n, nil
+		}
+	}
+	return nil, dberrors.NewUnknownNamespaceError(id.String())
+}
+
+func (s NamespaceMigrationState) copy() NamespaceMigrationState {
+	backfilledUntil := make(map[uint32]time.Time, len(s.BackfilledUntil))
+	for shard, until := range s.BackfilledUntil {
+		backfilledUntil[shard] = until
+	}
+	s.BackfilledUntil = backfilledUntil
+	return s
+}
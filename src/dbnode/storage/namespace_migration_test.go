@@ -0,0 +1,363 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/storage/block"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/x/context"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+type namespaceMigrationTestSetup struct {
+	source, target *MockdatabaseNamespace
+	db             *Mockdatabase
+	opts           Options
+	dir            string
+	now            time.Time
+}
+
+func newNamespaceMigrationTestSetup(t *testing.T, ctrl *gomock.Controller) namespaceMigrationTestSetup {
+	dir, err := ioutil.TempDir("", "namespace-migration")
+	require.NoError(t, err)
+
+	var (
+		now   = time.Now().Truncate(2 * time.Hour).Add(time.Minute)
+		ropts = retention.NewOptions().
+			SetRetentionPeriod(4 * time.Hour).
+			SetBlockSize(2 * time.Hour)
+		sourceOpts = namespace.NewOptions().
+				SetRetentionOptions(ropts).
+				SetIndexOptions(namespace.NewIndexOptions().SetEnabled(true))
+		targetOpts = sourceOpts.
+				SetRetentionOptions(ropts.SetBlockSize(time.Hour)).
+				SetColdWritesEnabled(true)
+	)
+
+	shard := NewMockdatabaseShard(ctrl)
+	shard.EXPECT().ID().Return(uint32(0)).AnyTimes()
+
+	source := NewMockdatabaseNamespace(ctrl)
+	source.EXPECT().ID().Return(ident.StringID("source")).AnyTimes()
+	source.EXPECT().Options().Return(sourceOpts).AnyTimes()
+	source.EXPECT().Schema().Return(nil).AnyTimes()
+	source.EXPECT().GetOwnedShards().Return([]databaseShard{shard}).AnyTimes()
+
+	target := NewMockdatabaseNamespace(ctrl)
+	target.EXPECT().ID().Return(ident.StringID("target")).AnyTimes()
+	target.EXPECT().Options().Return(targetOpts).AnyTimes()
+
+	db := newMockdatabase(ctrl, source, target)
+	db.EXPECT().IsBootstrapped().Return(true).AnyTimes()
+
+	opts := DefaultTestOptions()
+	fsOpts := opts.CommitLogOptions().FilesystemOptions().SetFilePathPrefix(dir)
+	opts = opts.
+		SetCommitLogOptions(opts.CommitLogOptions().SetFilesystemOptions(fsOpts)).
+		SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+			return now
+		}))
+
+	return namespaceMigrationTestSetup{
+		source: source,
+		target: target,
+		db:     db,
+		opts:   opts,
+		dir:    dir,
+		now:    now,
+	}
+}
+
+func expectNamespaceMigrationSeries(n *MockdatabaseNamespace, ids ...string) {
+	n.EXPECT().
+		FetchBlocksMetadataV2(gomock.Any(), uint32(0), gomock.Any(), gomock.Any(),
+			int64(namespaceMigrationPageSize), nil, gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context,
+			_ uint32,
+			_, _ time.Time,
+			_ int64,
+			_ PageToken,
+			_ block.FetchBlocksMetadataOptions,
+		) (block.FetchBlocksMetadataResults, PageToken, error) {
+			results := block.NewFetchBlocksMetadataResults()
+			for _, id := range ids {
+				tags := ident.NewTagsIterator(ident.NewTags(ident.StringTag("city", "nyc")))
+				results.Add(block.NewFetchBlocksMetadataResult(ident.StringID(id), tags, nil))
+			}
+			return results, nil, nil
+		}).
+		AnyTimes()
+}
+
+func waitForNamespaceMigrationPhase(
+	t *testing.T,
+	m *namespaceMigrationManager,
+	phase NamespaceMigrationPhase,
+) NamespaceMigrationState {
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); {
+		states := m.States()
+		require.Equal(t, 1, len(states))
+		if states[0].Phase == phase {
+			return states[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.FailNow(t, "namespace migration did not reach phase", phase.String())
+	return NamespaceMigrationState{}
+}
+
+func TestNewNamespaceMigrationTarget(t *testing.T) {
+	opts := namespace.NewOptions().
+		SetRetentionOptions(retention.NewOptions().
+			SetRetentionPeriod(48 * time.Hour).
+			SetBlockSize(2 * time.Hour)).
+		SetIndexOptions(namespace.NewIndexOptions().
+			SetEnabled(true).
+			SetBlockSize(4 * time.Hour))
+	source, err := namespace.NewMetadata(ident.StringID("source"), opts)
+	require.NoError(t, err)
+
+	target, err := NewNamespaceMigrationTarget(source, ident.StringID("target"), 3*time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, "target", target.ID().String())
+	require.Equal(t, 3*time.Hour, target.Options().RetentionOptions().BlockSize())
+	require.Equal(t, 48*time.Hour, target.Options().RetentionOptions().RetentionPeriod())
+	require.Equal(t, 3*time.Hour, target.Options().IndexOptions().BlockSize())
+	require.True(t, target.Options().ColdWritesEnabled())
+}
+
+func TestNamespaceMigrationManagerStartValidatesTarget(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := newNamespaceMigrationTestSetup(t, ctrl)
+	defer os.RemoveAll(s.dir)
+
+	m := newNamespaceMigrationManager(s.db, s.opts)
+	defer m.Close()
+
+	_, err := m.Start(ident.StringID("source"), ident.StringID("source"))
+	require.Error(t, err)
+
+	_, err = m.Start(ident.StringID("source"), ident.StringID("unknown"))
+	require.Error(t, err)
+
+	// The target has cold writes enabled but the source does not.
+	_, err = m.Start(ident.StringID("target"), ident.StringID("source"))
+	require.Equal(t, errNamespaceMigrationColdWritesDisabled, xerrors.GetInnerInvalidParamsError(err))
+	require.Equal(t, 0, len(m.States()))
+}
+
+func TestNamespaceMigrationManagerBackfillVerifyAndCutover(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := newNamespaceMigrationTestSetup(t, ctrl)
+	defer os.RemoveAll(s.dir)
+
+	var (
+		blockSize  = 2 * time.Hour
+		firstBlock = s.now.Truncate(blockSize).Add(-2 * blockSize)
+		written    = firstBlock.Add(time.Minute)
+	)
+	expectNamespaceMigrationSeries(s.source, "foo")
+	expectNamespaceMigrationSeries(s.target, "foo")
+	s.source.EXPECT().
+		ReadEncoded(gomock.Any(), ident.NewIDMatcher("foo"), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context,
+			_ ident.ID,
+			start, _ time.Time,
+		) ([][]xio.BlockReader, error) {
+			if !start.Equal(firstBlock) {
+				return nil, nil
+			}
+			enc := m3tsz.NewEncoder(start, nil, m3tsz.DefaultIntOptimizationEnabled,
+				encoding.NewOptions())
+			require.NoError(t, enc.Encode(ts.Datapoint{Timestamp: written, Value: 42},
+				xtime.Second, nil))
+			stream, ok := enc.Stream(encoding.StreamOptions{})
+			require.True(t, ok)
+			return [][]xio.BlockReader{{{
+				SegmentReader: stream,
+				Start:         start,
+				BlockSize:     blockSize,
+			}}}, nil
+		}).
+		AnyTimes()
+	s.db.EXPECT().
+		WriteTagged(gomock.Any(), ident.NewIDMatcher("target"), ident.NewIDMatcher("foo"),
+			gomock.Any(), written, float64(42), xtime.Second, gomock.Any()).
+		Return(nil)
+
+	m := newNamespaceMigrationManager(s.db, s.opts)
+	defer m.Close()
+
+	started, err := m.Start(ident.StringID("source"), ident.StringID("target"))
+	require.NoError(t, err)
+	require.Equal(t, firstBlock, started.BackfillStart)
+
+	state := waitForNamespaceMigrationPhase(t, m, NamespaceMigrationReadyForCutover)
+	require.Equal(t, started.BackfillEnd, state.BackfilledUntil[0])
+	require.Equal(t, int64(0), state.MissingSeries)
+
+	target, ok := m.MirrorTarget(ident.StringID("source"))
+	require.True(t, ok)
+	require.Equal(t, "target", target.String())
+	require.Equal(t, "source", m.ReadNamespace(ident.StringID("source")).String())
+
+	_, err = m.Cutover(ident.StringID("source"))
+	require.NoError(t, err)
+	require.Equal(t, "target", m.ReadNamespace(ident.StringID("source")).String())
+
+	// The migration resumes cutover from its persisted state.
+	reopened := newNamespaceMigrationManager(s.db, s.opts)
+	defer reopened.Close()
+	require.NoError(t, reopened.Open())
+	states := reopened.States()
+	require.Equal(t, 1, len(states))
+	require.Equal(t, NamespaceMigrationCutover, states[0].Phase)
+	require.Equal(t, "target", reopened.ReadNamespace(ident.StringID("source")).String())
+
+	require.NoError(t, reopened.Abort(ident.StringID("source")))
+	require.Equal(t, 0, len(reopened.States()))
+	require.Equal(t, "source", reopened.ReadNamespace(ident.StringID("source")).String())
+	_, err = os.Stat(path.Join(s.dir, namespaceMigrationsDir, "source.json"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestNamespaceMigrationManagerVerifyFailsOnMissingSeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := newNamespaceMigrationTestSetup(t, ctrl)
+	defer os.RemoveAll(s.dir)
+
+	expectNamespaceMigrationSeries(s.source, "foo", "bar")
+	expectNamespaceMigrationSeries(s.target, "foo")
+	s.source.EXPECT().
+		ReadEncoded(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, nil).
+		AnyTimes()
+
+	m := newNamespaceMigrationManager(s.db, s.opts)
+	defer m.Close()
+
+	_, err := m.Start(ident.StringID("source"), ident.StringID("target"))
+	require.NoError(t, err)
+
+	state := waitForNamespaceMigrationPhase(t, m, NamespaceMigrationFailed)
+	require.Equal(t, int64(1), state.MissingSeries)
+
+	_, ok := m.MirrorTarget(ident.StringID("source"))
+	require.False(t, ok)
+	_, err = m.Cutover(ident.StringID("source"))
+	require.Error(t, err)
+}
+
+func TestNamespaceMigrationManagerCopy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := newNamespaceMigrationTestSetup(t, ctrl)
+	defer os.RemoveAll(s.dir)
+
+	var (
+		blockSize  = 2 * time.Hour
+		firstBlock = s.now.Truncate(blockSize).Add(-2 * blockSize)
+		start      = firstBlock.Add(30 * time.Minute)
+		end        = firstBlock.Add(3 * time.Hour)
+		written    = firstBlock.Add(time.Hour)
+	)
+	expectNamespaceMigrationSeries(s.source, "foo")
+	s.source.EXPECT().
+		ReadEncoded(gomock.Any(), ident.NewIDMatcher("foo"), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context,
+			_ ident.ID,
+			readStart, _ time.Time,
+		) ([][]xio.BlockReader, error) {
+			if !readStart.Equal(start) {
+				return nil, nil
+			}
+			enc := m3tsz.NewEncoder(firstBlock, nil, m3tsz.DefaultIntOptimizationEnabled,
+				encoding.NewOptions())
+			for _, dp := range []ts.Datapoint{
+				{Timestamp: firstBlock.Add(time.Minute), Value: 1},
+				{Timestamp: written, Value: 42},
+			} {
+				require.NoError(t, enc.Encode(dp, xtime.Second, nil))
+			}
+			stream, ok := enc.Stream(encoding.StreamOptions{})
+			require.True(t, ok)
+			return [][]xio.BlockReader{{{
+				SegmentReader: stream,
+				Start:         firstBlock,
+				BlockSize:     blockSize,
+			}}}, nil
+		}).
+		Times(2)
+	// Only the datapoints within the range are copied.
+	s.db.EXPECT().
+		WriteTagged(gomock.Any(), ident.NewIDMatcher("target"), ident.NewIDMatcher("foo"),
+			gomock.Any(), written, float64(42), xtime.Second, gomock.Any()).
+		Return(nil)
+
+	m := newNamespaceMigrationManager(s.db, s.opts)
+	defer m.Close()
+
+	_, err := m.Copy(ident.StringID("source"), ident.StringID("source"), start, end)
+	require.Equal(t, errNamespaceCopySameNamespace, xerrors.GetInnerInvalidParamsError(err))
+	_, err = m.Copy(ident.StringID("source"), ident.StringID("target"), end, start)
+	require.Equal(t, errNamespaceCopyInvalidRange, xerrors.GetInnerInvalidParamsError(err))
+	_, err = m.Copy(ident.StringID("target"), ident.StringID("source"), start, end)
+	require.Equal(t, errNamespaceCopyColdWritesDisabled, xerrors.GetInnerInvalidParamsError(err))
+
+	result, err := m.Copy(ident.StringID("source"), ident.StringID("target"), start, end)
+	require.NoError(t, err)
+	require.Equal(t, NamespaceCopyResult{
+		Source:     "source",
+		Target:     "target",
+		Start:      start,
+		End:        end,
+		Series:     1,
+		Datapoints: 1,
+	}, result)
+
+	// Copies are not tracked as migrations.
+	require.Equal(t, 0, len(m.States()))
+}
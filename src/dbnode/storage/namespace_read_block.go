@@ -0,0 +1,192 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/m3ninx/generated/proto/querypb"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/uber-go/tally"
+)
+
+var (
+	errNamespaceReadsBlocked   = errors.New("namespace reads are blocked")
+	errNamespaceQueriesBlocked = errors.New("namespace queries matching the tags are blocked")
+)
+
+// namespaceReadBlock rejects the reads of a namespace, or only its index
+// queries matching specific tags, according to the runtime read block rules
+// of the namespace.
+type namespaceReadBlock struct {
+	sync.RWMutex
+
+	namespace string
+
+	// enabled is accessed atomically so that reads do not need to acquire
+	// the lock when the namespace has no rules.
+	enabled int32
+	// blockAll is set when a rule without tags blocks every read.
+	blockAll bool
+	rules    runtime.ReadBlockRules
+
+	metrics namespaceReadBlockMetrics
+}
+
+type namespaceReadBlockMetrics struct {
+	readsRejected   tally.Counter
+	queriesRejected tally.Counter
+}
+
+func newNamespaceReadBlock(
+	namespace ident.ID,
+	scope tally.Scope,
+) *namespaceReadBlock {
+	scope = scope.SubScope("read-block")
+	return &namespaceReadBlock{
+		namespace: namespace.String(),
+		metrics: namespaceReadBlockMetrics{
+			readsRejected:   scope.Counter("reads-rejected"),
+			queriesRejected: scope.Counter("queries-rejected"),
+		},
+	}
+}
+
+func (b *namespaceReadBlock) SetRuntimeOptions(value runtime.Options) {
+	rules := value.ReadBlockRules().ForNamespace(b.namespace)
+	blockAll := false
+	for _, rule := range rules {
+		if len(rule.Tags) == 0 {
+			blockAll = true
+		}
+	}
+
+	b.Lock()
+	b.rules = rules
+	b.blockAll = blockAll
+	if len(rules) > 0 {
+		atomic.StoreInt32(&b.enabled, 1)
+	} else {
+		atomic.StoreInt32(&b.enabled, 0)
+	}
+	b.Unlock()
+}
+
+// CheckRead returns an error if the reads by ID of the namespace are blocked.
+func (b *namespaceReadBlock) CheckRead() error {
+	if atomic.LoadInt32(&b.enabled) == 0 {
+		return nil
+	}
+
+	b.RLock()
+	blockAll := b.blockAll
+	b.RUnlock()
+	if !blockAll {
+		return nil
+	}
+
+	b.metrics.readsRejected.Inc(1)
+	return xerrors.NewBlockedError(errNamespaceReadsBlocked)
+}
+
+// CheckQuery returns an error if the index query of the namespace is
+// blocked, either as every read of the namespace is or as the query matches
+// the tags of a rule.
+func (b *namespaceReadBlock) CheckQuery(query index.Query) error {
+	if atomic.LoadInt32(&b.enabled) == 0 {
+		return nil
+	}
+
+	b.RLock()
+	defer b.RUnlock()
+
+	if b.blockAll {
+		b.metrics.readsRejected.Inc(1)
+		return xerrors.NewBlockedError(errNamespaceReadsBlocked)
+	}
+
+	q := query.SearchQuery()
+	if q == nil {
+		return nil
+	}
+
+	matchers := queryTagMatchers(q.ToProto(), nil)
+	for _, rule := range b.rules {
+		if queryMatchesTags(matchers, rule.Tags) {
+			b.metrics.queriesRejected.Inc(1)
+			return xerrors.NewBlockedError(errNamespaceQueriesBlocked)
+		}
+	}
+	return nil
+}
+
+type queryTagMatcher struct {
+	name  string
+	value string
+}
+
+// queryTagMatchers returns the term and regexp matchers every document
+// matched by the query must satisfy, matchers under negations and
+// disjunctions are not required and are skipped.
+func queryTagMatchers(
+	q *querypb.Query,
+	matchers []queryTagMatcher,
+) []queryTagMatcher {
+	if term := q.GetTerm(); term != nil {
+		return append(matchers, queryTagMatcher{
+			name:  string(term.GetField()),
+			value: string(term.GetTerm()),
+		})
+	}
+	if regexp := q.GetRegexp(); regexp != nil {
+		return append(matchers, queryTagMatcher{
+			name:  string(regexp.GetField()),
+			value: string(regexp.GetRegexp()),
+		})
+	}
+	if conjunction := q.GetConjunction(); conjunction != nil {
+		for _, sub := range conjunction.GetQueries() {
+			matchers = queryTagMatchers(sub, matchers)
+		}
+	}
+	return matchers
+}
+
+func queryMatchesTags(matchers []queryTagMatcher, tags map[string]string) bool {
+	for name, value := range tags {
+		found := false
+		for _, matcher := range matchers {
+			if matcher.name == name && matcher.value == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/m3ninx/idx"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestNamespaceReadBlock(rules runtime.ReadBlockRules) *namespaceReadBlock {
+	b := newNamespaceReadBlock(ident.StringID("foo"), tally.NoopScope)
+	b.SetRuntimeOptions(runtime.NewOptions().SetReadBlockRules(rules))
+	return b
+}
+
+func TestNamespaceReadBlockNoRules(t *testing.T) {
+	b := newTestNamespaceReadBlock(runtime.ReadBlockRules{
+		{Namespace: "bar"},
+	})
+
+	require.NoError(t, b.CheckRead())
+	require.NoError(t, b.CheckQuery(index.Query{
+		Query: idx.NewTermQuery([]byte("app"), []byte("dashboards")),
+	}))
+}
+
+func TestNamespaceReadBlockAll(t *testing.T) {
+	b := newTestNamespaceReadBlock(runtime.ReadBlockRules{
+		{Namespace: "foo"},
+	})
+
+	err := b.CheckRead()
+	require.Error(t, err)
+	require.True(t, xerrors.IsBlockedError(err))
+
+	err = b.CheckQuery(index.Query{
+		Query: idx.NewTermQuery([]byte("app"), []byte("dashboards")),
+	})
+	require.Error(t, err)
+	require.True(t, xerrors.IsBlockedError(err))
+
+	// Removing the rules unblocks the namespace.
+	b.SetRuntimeOptions(runtime.NewOptions())
+	require.NoError(t, b.CheckRead())
+}
+
+func TestNamespaceReadBlockQueryTags(t *testing.T) {
+	b := newTestNamespaceReadBlock(runtime.ReadBlockRules{
+		{
+			Namespace: "foo",
+			Tags:      map[string]string{"app": "dashboards", "city": "n.*"},
+		},
+	})
+
+	// Reads by ID are not blocked by rules with tags.
+	require.NoError(t, b.CheckRead())
+
+	tests := []struct {
+		name    string
+		query   idx.Query
+		blocked bool
+	}{
+		{
+			name:    "single term",
+			query:   idx.NewTermQuery([]byte("app"), []byte("dashboards")),
+			blocked: false,
+		},
+		{
+			name: "conjunction matching all tags",
+			query: idx.NewConjunctionQuery(
+				idx.NewTermQuery([]byte("app"), []byte("dashboards")),
+				idx.MustCreateRegexpQuery([]byte("city"), []byte("n.*")),
+				idx.NewTermQuery([]byte("host"), []byte("a")),
+			),
+			blocked: true,
+		},
+		{
+			name: "conjunction with different value",
+			query: idx.NewConjunctionQuery(
+				idx.NewTermQuery([]byte("app"), []byte("alerts")),
+				idx.MustCreateRegexpQuery([]byte("city"), []byte("n.*")),
+			),
+			blocked: false,
+		},
+		{
+			name: "disjunction matching all tags",
+			query: idx.NewDisjunctionQuery(
+				idx.NewTermQuery([]byte("app"), []byte("dashboards")),
+				idx.MustCreateRegexpQuery([]byte("city"), []byte("n.*")),
+			),
+			blocked: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := b.CheckQuery(index.Query{Query: tt.query})
+			if !tt.blocked {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.True(t, xerrors.IsBlockedError(err))
+		})
+	}
+}
@@ -168,7 +168,7 @@ func (m *namespaceReaderManager) filesetExistsAt(
 ) (bool, error) {
 	// TODO(juchan): get the actual volume here.
 	vol := 0
-	return m.filesetExistsFn(m.fsOpts.FilePathPrefix(),
+	return m.filesetExistsFn(m.fsOpts.NamespaceFilePathPrefix(m.namespace.ID()),
 		m.namespace.ID(), shard, blockStart, vol)
 }
 
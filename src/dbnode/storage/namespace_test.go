@@ -36,12 +36,16 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/durability"
 	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
 	"github.com/m3db/m3/src/dbnode/storage/series"
+	"github.com/m3db/m3/src/dbnode/testdata/prototest"
 	"github.com/m3db/m3/src/dbnode/tracepoint"
 	"github.com/m3db/m3/src/dbnode/ts"
 	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
+	"github.com/m3db/m3/src/dbnode/x/xio"
 	xidx "github.com/m3db/m3/src/m3ninx/idx"
 	"github.com/m3db/m3/src/x/context"
 	xerrors "github.com/m3db/m3/src/x/errors"
@@ -157,7 +161,7 @@ func TestNamespaceTick(t *testing.T) {
 	}
 
 	// Only asserting the expected methods are called
-	require.NoError(t, ns.Tick(context.NewNoOpCanncellable(), time.Now()))
+	require.NoError(t, ns.Tick(context.NewNoOpCanncellable(), time.Now(), nil))
 }
 
 func TestNamespaceTickError(t *testing.T) {
@@ -178,11 +182,34 @@ func TestNamespaceTickError(t *testing.T) {
 		ns.shards[testShardIDs[i].ID()] = shard
 	}
 
-	err := ns.Tick(context.NewNoOpCanncellable(), time.Now())
+	err := ns.Tick(context.NewNoOpCanncellable(), time.Now(), nil)
 	require.NotNil(t, err)
 	require.Equal(t, fakeErr.Error(), err.Error())
 }
 
+func TestNamespaceTickPaused(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+	for i := range testShardIDs {
+		shard := NewMockdatabaseShard(ctrl)
+		ns.shards[testShardIDs[i].ID()] = shard
+	}
+
+	// Shards are not ticked while the tick is paused.
+	ns.PauseBackgroundTask(TickBackgroundTask, time.Now().Add(time.Hour))
+	require.NoError(t, ns.Tick(context.NewNoOpCanncellable(), time.Now(), nil))
+
+	ns.ResumeBackgroundTask(TickBackgroundTask)
+	for i := range testShardIDs {
+		shard := ns.shards[testShardIDs[i].ID()].(*MockdatabaseShard)
+		shard.EXPECT().Tick(context.NewNoOpCanncellable(), gomock.Any(), gomock.Any()).Return(tickResult{}, nil)
+	}
+	require.NoError(t, ns.Tick(context.NewNoOpCanncellable(), time.Now(), nil))
+}
+
 func TestNamespaceWriteShardNotOwned(t *testing.T) {
 	ctx := context.NewContext()
 	defer ctx.Close()
@@ -238,6 +265,118 @@ func TestNamespaceWriteShardOwned(t *testing.T) {
 	}
 }
 
+func TestNamespaceWriteStrictlyIncreasing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+	series.SetStrictlyIncreasingWrites(ctx)
+
+	id := ident.StringID("foo")
+	now := time.Now()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+	shard := NewMockdatabaseShard(ctrl)
+	opts := series.WriteOptions{
+		TruncateType:       ns.opts.TruncateType(),
+		StrictlyIncreasing: true,
+	}
+	shard.EXPECT().Write(ctx, id, now, 1.0, xtime.Second, []byte(nil), opts).
+		Return(ts.Series{}, true, nil)
+	ns.shards[testShardIDs[0].ID()] = shard
+
+	_, wasWritten, err := ns.Write(ctx, id, now, 1.0, xtime.Second, nil)
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+}
+
+func TestNamespaceWriteIngestLimitExceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	id := ident.StringID("foo")
+	now := time.Now()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	ns.ingestLimiter.SetRuntimeOptions(runtime.NewOptions().SetNamespaceIngestLimits(
+		runtime.NamespaceIngestLimits{
+			Overrides: map[string]runtime.NamespaceIngestLimit{
+				ns.ID().String(): {DatapointsPerSecond: 1},
+			},
+		}))
+
+	shard := NewMockdatabaseShard(ctrl)
+	shard.EXPECT().Write(ctx, id, now, 1.0, xtime.Second, []byte(nil), gomock.Any()).
+		Return(ts.Series{}, true, nil)
+	ns.shards[testShardIDs[0].ID()] = shard
+
+	_, wasWritten, err := ns.Write(ctx, id, now, 1.0, xtime.Second, nil)
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+
+	// The write exceeding the limit is rejected before reaching the shard.
+	_, wasWritten, err = ns.Write(ctx, id, now, 2.0, xtime.Second, nil)
+	require.Error(t, err)
+	require.True(t, xerrors.IsResourceExhaustedError(err))
+	require.False(t, wasWritten)
+}
+
+func TestNamespaceWriteBlackout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	id := ident.StringID("foo")
+	now := time.Now()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	shard := NewMockdatabaseShard(ctrl)
+	ns.shards[testShardIDs[0].ID()] = shard
+
+	setWindow := func(action runtime.WriteBlackoutAction) {
+		ns.writeBlackout.SetRuntimeOptions(runtime.NewOptions().SetWriteBlackoutWindows(
+			runtime.WriteBlackoutWindows{
+				{
+					Namespace: ns.ID().String(),
+					Start:     now.Add(-time.Minute),
+					End:       now.Add(time.Minute),
+					Action:    action,
+				},
+			}))
+	}
+
+	// Writes are rejected before reaching the shard.
+	setWindow(runtime.WriteBlackoutReject)
+	_, wasWritten, err := ns.Write(ctx, id, now, 1.0, xtime.Second, nil)
+	require.Equal(t, errNamespaceWriteBlackout, err)
+	require.False(t, wasWritten)
+
+	// Writes are acknowledged without being written.
+	setWindow(runtime.WriteBlackoutDrop)
+	_, wasWritten, err = ns.Write(ctx, id, now, 2.0, xtime.Second, nil)
+	require.NoError(t, err)
+	require.False(t, wasWritten)
+
+	// Writes go through once the windows are removed.
+	ns.writeBlackout.SetRuntimeOptions(runtime.NewOptions())
+	shard.EXPECT().Write(ctx, id, now, 3.0, xtime.Second, []byte(nil), gomock.Any()).
+		Return(ts.Series{}, true, nil)
+	_, wasWritten, err = ns.Write(ctx, id, now, 3.0, xtime.Second, nil)
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+}
+
 func TestNamespaceReadEncodedShardNotOwned(t *testing.T) {
 	ctx := context.NewContext()
 	defer ctx.Close()
@@ -281,6 +420,40 @@ func TestNamespaceReadEncodedShardOwned(t *testing.T) {
 	require.Equal(t, errShardNotBootstrappedToRead, xerrors.GetInnerRetryableError(err))
 }
 
+func TestNamespaceReadEncodedBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	ids := []ident.ID{ident.StringID("foo"), ident.StringID("bar")}
+	start := time.Now()
+	end := time.Now().Add(time.Second)
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	shard := NewMockdatabaseShard(ctrl)
+	ns.shards[testShardIDs[0].ID()] = shard
+
+	// Ids owned by the same shard are read with a single shard call.
+	expected := []ReadEncodedResult{
+		{Readers: [][]xio.BlockReader{{xio.BlockReader{Start: start}}}},
+		{Err: errors.New("read error")},
+	}
+	shard.EXPECT().IsBootstrapped().Return(true)
+	shard.EXPECT().ReadEncodedBatch(ctx, ids, start, end, gomock.Any()).Return(expected)
+	require.Equal(t, expected, ns.ReadEncodedBatch(ctx, ids, start, end))
+
+	shard.EXPECT().IsBootstrapped().Return(false)
+	results := ns.ReadEncodedBatch(ctx, ids, start, end)
+	require.Equal(t, len(ids), len(results))
+	for _, result := range results {
+		require.True(t, xerrors.IsRetryableError(result.Err))
+	}
+}
+
 func TestNamespaceFetchBlocksShardNotOwned(t *testing.T) {
 	ctx := context.NewContext()
 	defer ctx.Close()
@@ -475,6 +648,7 @@ func TestNamespaceFlushSkipFlushed(t *testing.T) {
 		shard.EXPECT().ID().Return(testShardIDs[i].ID())
 		shard.EXPECT().FlushState(blockStart).Return(s)
 		if s.WarmStatus != fileOpSuccess {
+			shard.EXPECT().IsFlushPaused(WarmFlushBackgroundTask).Return(false)
 			shard.EXPECT().WarmFlush(blockStart, gomock.Any(), gomock.Any()).Return(nil)
 		}
 		ns.shards[testShardIDs[i].ID()] = shard
@@ -488,6 +662,108 @@ func TestNamespaceFlushSkipFlushed(t *testing.T) {
 	require.NoError(t, ns.WarmFlush(blockStart, ShardBootstrapStates, nil))
 }
 
+func TestNamespaceFlushSkipPausedShards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	ns.bootstrapState = Bootstrapped
+	ns.schemaMigrationPending = true
+	blockStart := time.Now().Truncate(ns.Options().RetentionOptions().BlockSize())
+
+	paused := NewMockdatabaseShard(ctrl)
+	paused.EXPECT().ID().Return(testShardIDs[0].ID()).AnyTimes()
+	paused.EXPECT().FlushState(blockStart).Return(fileOpState{WarmStatus: fileOpNotStarted})
+	paused.EXPECT().IsFlushPaused(WarmFlushBackgroundTask).Return(true)
+	paused.EXPECT().IsFlushPaused(ColdFlushBackgroundTask).Return(true)
+	ns.shards[testShardIDs[0].ID()] = paused
+
+	unpaused := NewMockdatabaseShard(ctrl)
+	unpaused.EXPECT().ID().Return(testShardIDs[1].ID()).AnyTimes()
+	unpaused.EXPECT().FlushState(blockStart).Return(fileOpState{WarmStatus: fileOpNotStarted})
+	unpaused.EXPECT().IsFlushPaused(WarmFlushBackgroundTask).Return(false)
+	unpaused.EXPECT().WarmFlush(blockStart, gomock.Any(), gomock.Any()).Return(nil)
+	unpaused.EXPECT().IsFlushPaused(ColdFlushBackgroundTask).Return(false)
+	unpaused.EXPECT().ColdFlush(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	ns.shards[testShardIDs[1].ID()] = unpaused
+
+	shardBootstrapStates := ShardBootstrapStates{
+		testShardIDs[0].ID(): Bootstrapped,
+		testShardIDs[1].ID(): Bootstrapped,
+	}
+	require.NoError(t, ns.WarmFlush(blockStart, shardBootstrapStates, nil))
+	require.NoError(t, ns.ColdFlush(nil))
+
+	// The schema migration is incomplete until the paused shard is flushed.
+	require.True(t, ns.schemaMigrationPending)
+
+	// Pausing the warm flush of the namespace skips every shard.
+	ns.PauseBackgroundTask(WarmFlushBackgroundTask, time.Now().Add(time.Hour))
+	require.NoError(t, ns.WarmFlush(blockStart, shardBootstrapStates, nil))
+}
+
+func TestNamespaceSchemaChangeMigratesFlushedData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+	require.False(t, ns.Options().ColdWritesEnabled())
+
+	const (
+		protoFile = "test.proto"
+		msgName   = "TestMessage"
+		v1Proto   = `syntax = "proto3";
+
+message TestMessage {
+  int64 value = 1;
+}
+`
+		v2Proto = `syntax = "proto3";
+
+message TestMessage {
+  string value = 1;
+}
+`
+	)
+	schemaOpts, err := namespace.AppendSchemaOptions(nil, protoFile, msgName,
+		map[string]string{protoFile: v1Proto}, "v1")
+	require.NoError(t, err)
+	v1History, err := namespace.LoadSchemaHistory(schemaOpts)
+	require.NoError(t, err)
+	schemaOpts, err = namespace.AppendSchemaOptions(schemaOpts, protoFile, msgName,
+		map[string]string{protoFile: v2Proto}, "v2")
+	require.NoError(t, err)
+	v2History, err := namespace.LoadSchemaHistory(schemaOpts)
+	require.NoError(t, err)
+
+	ns.SetSchemaHistory(v1History)
+	ns.bootstrapState = Bootstrapped
+	for _, shardID := range ns.shardSet.AllIDs() {
+		shard := NewMockdatabaseShard(ctrl)
+		shard.EXPECT().MigrateSchema()
+		shard.EXPECT().IsFlushPaused(ColdFlushBackgroundTask).Return(false)
+		shard.EXPECT().ColdFlush(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		ns.shards[shardID] = shard
+	}
+
+	// Setting the same schema again does not migrate anything.
+	ns.SetSchemaHistory(v1History)
+	require.False(t, ns.schemaMigrationPending)
+
+	ns.SetSchemaHistory(v2History)
+	require.True(t, ns.schemaMigrationPending)
+	require.Equal(t, "v2", ns.Schema().DeployId())
+
+	// The cold flush re-encodes the flushed data even though cold writes are
+	// disabled, and only until it has succeeded once.
+	require.NoError(t, ns.ColdFlush(nil))
+	require.False(t, ns.schemaMigrationPending)
+	require.NoError(t, ns.ColdFlush(nil))
+}
+
 func TestNamespaceFlushSkipShardNotBootstrappedBeforeTick(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -627,6 +903,164 @@ func TestNamespaceTruncate(t *testing.T) {
 	require.True(t, ns.shards[testShardIDs[0].ID()].IsBootstrapped())
 }
 
+func TestNamespaceDeleteSeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	idx := NewMocknamespaceIndex(ctrl)
+	ns, closer := newTestNamespaceWithIndex(t, idx)
+	defer closer()
+
+	ids := []ident.ID{ident.StringID("foo"), ident.StringID("bar")}
+	byShard := make(map[uint32][]ident.ID)
+	for _, id := range ids {
+		shardID := ns.shardSet.Lookup(id)
+		byShard[shardID] = append(byShard[shardID], id)
+	}
+	for shardID, shardIDs := range byShard {
+		shard := NewMockdatabaseShard(ctrl)
+		shard.EXPECT().DeleteSeries(shardIDs).Return(nil)
+		ns.shards[shardID] = shard
+		idx.EXPECT().MarkDeleted(shardIDs).Return(nil)
+	}
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+	require.NoError(t, ns.DeleteSeries(ctx, ids))
+
+	// The index is not updated if the tombstones were not recorded.
+	shard := NewMockdatabaseShard(ctrl)
+	shard.EXPECT().DeleteSeries(ids[:1]).Return(errShardNotBootstrappedToDelete)
+	ns.shards[ns.shardSet.Lookup(ids[0])] = shard
+	require.Error(t, ns.DeleteSeries(ctx, ids[:1]))
+}
+
+func TestNamespaceUpdateRetentionOptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	idx := NewMocknamespaceIndex(ctrl)
+	ns, closer := newTestNamespaceWithIndex(t, idx)
+	defer closer()
+
+	retriever := block.NewMockDatabaseBlockRetriever(ctrl)
+	ns.blockRetriever = retriever
+
+	ropts := defaultTestRetentionOpts.SetRetentionPeriod(24 * time.Hour).
+		SetBufferPast(5 * time.Minute)
+	retriever.EXPECT().UpdateNamespaceMetadata(gomock.Any()).DoAndReturn(
+		func(md namespace.Metadata) error {
+			require.Equal(t, ropts, md.Options().RetentionOptions())
+			return nil
+		})
+	idx.EXPECT().UpdateRetentionOptions(ropts)
+	for _, shardID := range testShardIDs {
+		shard := NewMockdatabaseShard(ctrl)
+		shard.EXPECT().UpdateRetentionOptions(ropts)
+		ns.shards[shardID.ID()] = shard
+	}
+
+	require.NoError(t, ns.UpdateRetentionOptions(ropts))
+	require.Equal(t, ropts, ns.Options().RetentionOptions())
+	require.Equal(t, ropts, ns.metadata.Options().RetentionOptions())
+	require.Equal(t, ropts, ns.seriesOpts.RetentionOptions())
+
+	// The block size cannot be changed at runtime.
+	err := ns.UpdateRetentionOptions(ropts.SetBlockSize(4 * time.Hour))
+	require.Error(t, err)
+	require.True(t, xerrors.IsInvalidParams(err))
+	require.Equal(t, ropts, ns.Options().RetentionOptions())
+}
+
+func TestNamespaceUpdateIndexEnabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	idx := NewMocknamespaceIndex(ctrl)
+	ns, closer := newTestNamespaceWithIndex(t, idx)
+	defer closer()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+	query := index.Query{
+		Query: xidx.NewTermQuery([]byte("foo"), []byte("bar")),
+	}
+
+	idx.EXPECT().SetEnabled(false)
+	require.NoError(t, ns.UpdateIndexEnabled(false))
+	require.False(t, ns.Options().IndexOptions().Enabled())
+	require.False(t, ns.metadata.Options().IndexOptions().Enabled())
+
+	// Queries are rejected while indexing is disabled.
+	_, err := ns.QueryIDs(ctx, query, index.QueryOptions{})
+	require.Equal(t, errNamespaceIndexingDisabled, err)
+	_, err = ns.AggregateQuery(ctx, query, index.AggregationOptions{})
+	require.Equal(t, errNamespaceIndexingDisabled, err)
+
+	idx.EXPECT().SetEnabled(true)
+	require.NoError(t, ns.UpdateIndexEnabled(true))
+	require.True(t, ns.Options().IndexOptions().Enabled())
+
+	idx.EXPECT().BootstrapsDone().Return(uint(1))
+	idx.EXPECT().Query(gomock.Any(), query, index.QueryOptions{})
+	_, err = ns.QueryIDs(ctx, query, index.QueryOptions{})
+	require.NoError(t, err)
+}
+
+func TestNamespaceUpdateIndexEnabledNoIndex(t *testing.T) {
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	// Indexing can not be enabled without a restart when the namespace was
+	// created without an index.
+	err := ns.UpdateIndexEnabled(true)
+	require.Error(t, err)
+	require.True(t, xerrors.IsInvalidParams(err))
+	require.False(t, ns.Options().IndexOptions().Enabled())
+
+	require.NoError(t, ns.UpdateIndexEnabled(false))
+}
+
+func TestNamespaceUndeleteExpiredFileSets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	idx := NewMocknamespaceIndex(ctrl)
+	ns, closer := newTestNamespaceWithIndex(t, idx)
+	defer closer()
+
+	// Nothing to undelete without a grace period.
+	_, err := ns.UndeleteExpiredFileSets()
+	require.Error(t, err)
+	require.True(t, xerrors.IsInvalidParams(err))
+
+	var (
+		grace    = 12 * time.Hour
+		ropts    = defaultTestRetentionOpts.SetExpiredFileSetGracePeriod(grace)
+		restored = ropts.SetRetentionPeriod(ropts.RetentionPeriod() + grace)
+	)
+	idx.EXPECT().UpdateRetentionOptions(ropts)
+	idx.EXPECT().UpdateRetentionOptions(restored)
+	for _, shardID := range testShardIDs {
+		shard := NewMockdatabaseShard(ctrl)
+		shard.EXPECT().UpdateRetentionOptions(ropts)
+		shard.EXPECT().UpdateRetentionOptions(restored)
+		ns.shards[shardID.ID()] = shard
+	}
+	require.NoError(t, ns.UpdateRetentionOptions(ropts))
+
+	result, err := ns.UndeleteExpiredFileSets()
+	require.NoError(t, err)
+	require.Equal(t, restored, result)
+	require.Equal(t, restored, ns.Options().RetentionOptions())
+
+	// Undeleting again does not extend the retention any further.
+	result, err = ns.UndeleteExpiredFileSets()
+	require.NoError(t, err)
+	require.Equal(t, restored, result)
+	require.Equal(t, restored, ns.Options().RetentionOptions())
+}
+
 func TestNamespaceRepair(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -945,6 +1379,53 @@ func TestNamespaceNeedsFlushAllSuccess(t *testing.T) {
 	assert.False(t, ns.NeedsFlush(blockStart, blockStart))
 }
 
+func TestNamespaceDataDurability(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	var (
+		ropts     = ns.nopts.RetentionOptions()
+		blockSize = ropts.BlockSize()
+		start     = time.Unix(0, 0).Add(100 * blockSize)
+		end       = start.Add(3 * blockSize)
+		cutoff    = start.Add(blockSize + blockSize/2)
+		flushed   = map[uint32][]time.Time{
+			0: {start, start.Add(blockSize)},
+			1: {start},
+		}
+	)
+	for _, s := range testShardIDs {
+		shard := NewMockdatabaseShard(ctrl)
+		for _, blockStart := range flushed[s.ID()] {
+			shard.EXPECT().FlushState(blockStart).Return(fileOpState{
+				WarmStatus: fileOpSuccess,
+			}).AnyTimes()
+		}
+		shard.EXPECT().FlushState(gomock.Any()).Return(fileOpState{}).AnyTimes()
+		ns.shards[s.ID()] = shard
+	}
+
+	// Only datapoints older than the buffer past at the start of the last
+	// snapshot are snapshotted.
+	lastSnapshotStart := cutoff.Add(ropts.BufferPast())
+	require.Equal(t, durability.Ranges{
+		{Start: start, End: start.Add(blockSize), Level: durability.Flushed},
+		{Start: start.Add(blockSize), End: cutoff, Level: durability.Snapshot},
+		{Start: cutoff, End: end, Level: durability.BufferOnly},
+	}, ns.DataDurability(start, end, lastSnapshotStart))
+
+	// Ranges are bounded by the requested range and everything unflushed is
+	// in the buffer only without a snapshot.
+	rangeStart := start.Add(blockSize / 4)
+	require.Equal(t, durability.Ranges{
+		{Start: rangeStart, End: start.Add(blockSize), Level: durability.Flushed},
+		{Start: start.Add(blockSize), End: end, Level: durability.BufferOnly},
+	}, ns.DataDurability(rangeStart, end, time.Time{}))
+}
+
 func TestNamespaceNeedsFlushAnyFailed(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1212,7 +1693,7 @@ func TestNamespaceTicksIndex(t *testing.T) {
 
 	ctx := context.NewCancellable()
 	idx.EXPECT().Tick(ctx, gomock.Any()).Return(namespaceIndexTickResult{}, nil)
-	err := ns.Tick(ctx, time.Now())
+	err := ns.Tick(ctx, time.Now(), nil)
 	require.NoError(t, err)
 }
 
@@ -1232,6 +1713,60 @@ func TestNamespaceIndexDisabledQuery(t *testing.T) {
 	require.NoError(t, ns.Close())
 }
 
+func TestNamespaceValidateWrite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	idx := NewMocknamespaceIndex(ctrl)
+	ns, closer := newTestNamespaceWithIndex(t, idx)
+	defer closer()
+
+	var (
+		id    = ident.StringID("foo")
+		now   = ns.nowFn()
+		ropts = ns.nopts.RetentionOptions()
+	)
+	require.NoError(t, ns.ValidateWrite(id, nil, now, nil))
+
+	tags := ident.NewTagsIterator(ident.NewTags(ident.StringTag("bar", "baz")))
+	require.NoError(t, ns.ValidateWrite(id, tags, now, nil))
+	// Validation must not consume the tags.
+	require.Equal(t, 1, tags.Remaining())
+
+	err := ns.ValidateWrite(id, nil, now.Add(-2*ropts.RetentionPeriod()), nil)
+	require.Error(t, err)
+	require.True(t, xerrors.IsInvalidParams(err))
+
+	tags = ident.NewTagsIterator(ident.NewTags(
+		ident.StringTag(string(convert.ReservedFieldNameID), "baz")))
+	err = ns.ValidateWrite(id, tags, now, nil)
+	require.Error(t, err)
+	require.True(t, xerrors.IsInvalidParams(err))
+
+	// Annotations are validated against the schema when one is set.
+	schema, ok := testSchemaHistory.GetLatest()
+	require.True(t, ok)
+	ns.schemaDescr = schema
+	msg, err := prototest.NewProtoTestMessages(schema.Get().MessageDescriptor)[0].Marshal()
+	require.NoError(t, err)
+	require.NoError(t, ns.ValidateWrite(id, nil, now, msg))
+	err = ns.ValidateWrite(id, nil, now, []byte{0x0a, 0x05})
+	require.Error(t, err)
+	require.True(t, xerrors.IsInvalidParams(err))
+
+	ns.shards[testShardIDs[0].ID()] = nil
+	require.Error(t, ns.ValidateWrite(id, nil, now, nil))
+}
+
+func TestNamespaceValidateWriteIndexingDisabled(t *testing.T) {
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	tags := ident.NewTagsIterator(ident.NewTags(ident.StringTag("bar", "baz")))
+	err := ns.ValidateWrite(ident.StringID("foo"), tags, ns.nowFn(), nil)
+	require.Equal(t, errNamespaceIndexingDisabled, err)
+}
+
 func TestNamespaceBootstrapState(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -0,0 +1,110 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/uber-go/tally"
+)
+
+var (
+	errNamespaceWriteBlackout = errors.New("namespace writes are blacked out")
+)
+
+// namespaceWriteBlackout rejects or drops the writes to a namespace during
+// the runtime write blackout windows of the namespace.
+type namespaceWriteBlackout struct {
+	sync.RWMutex
+
+	namespace string
+	nowFn     clock.NowFn
+
+	// enabled is accessed atomically so that writes do not need to acquire
+	// the lock when the namespace has no windows.
+	enabled int32
+	windows runtime.WriteBlackoutWindows
+
+	metrics namespaceWriteBlackoutMetrics
+}
+
+type namespaceWriteBlackoutMetrics struct {
+	rejected tally.Counter
+	dropped  tally.Counter
+}
+
+func newNamespaceWriteBlackout(
+	namespace ident.ID,
+	scope tally.Scope,
+	nowFn clock.NowFn,
+) *namespaceWriteBlackout {
+	scope = scope.SubScope("write-blackout")
+	return &namespaceWriteBlackout{
+		namespace: namespace.String(),
+		nowFn:     nowFn,
+		metrics: namespaceWriteBlackoutMetrics{
+			rejected: scope.Counter("rejected"),
+			dropped:  scope.Counter("dropped"),
+		},
+	}
+}
+
+func (b *namespaceWriteBlackout) SetRuntimeOptions(value runtime.Options) {
+	windows := value.WriteBlackoutWindows().ForNamespace(b.namespace)
+
+	b.Lock()
+	b.windows = windows
+	if len(windows) > 0 {
+		atomic.StoreInt32(&b.enabled, 1)
+	} else {
+		atomic.StoreInt32(&b.enabled, 0)
+	}
+	b.Unlock()
+}
+
+// Check returns whether a write should be dropped, or an error if it should
+// be rejected, because a blackout window of the namespace is active.
+func (b *namespaceWriteBlackout) Check() (bool, error) {
+	if atomic.LoadInt32(&b.enabled) == 0 {
+		return false, nil
+	}
+
+	b.RLock()
+	window, ok := b.windows.Active(b.nowFn())
+	b.RUnlock()
+	if !ok {
+		return false, nil
+	}
+
+	switch window.Action {
+	case runtime.WriteBlackoutDrop:
+		b.metrics.dropped.Inc(1)
+		return true, nil
+	default:
+		b.metrics.rejected.Inc(1)
+		return false, errNamespaceWriteBlackout
+	}
+}
@@ -68,6 +68,9 @@ const (
 
 	// defaultIndexingEnabled disables indexing by default.
 	defaultIndexingEnabled = false
+
+	// defaultSnapshotRetentionCount retains only the most recent snapshot by default.
+	defaultSnapshotRetentionCount = 1
 )
 
 var (
@@ -86,6 +89,9 @@ var (
 	errIndexOptionsNotSet         = errors.New("index enabled but index options are not set")
 	errPersistManagerNotSet       = errors.New("persist manager is not set")
 	errBlockLeaserNotSet          = errors.New("block leaser is not set")
+	errSnapshotRetentionCount     = errors.New("snapshot retention count must be at least 1")
+	errSnapshotRetentionPeriod    = errors.New("snapshot retention period must not be negative")
+	errOverloadPolicyNotSet       = errors.New("overload policy is not set")
 )
 
 // NewSeriesOptionsFromOptions creates a new set of database series options from provided options.
@@ -147,6 +153,17 @@ type options struct {
 	bufferBucketVersionsPool       *series.BufferBucketVersionsPool
 	schemaReg                      namespace.SchemaRegistry
 	blockLeaseManager              block.LeaseManager
+	dataAgeBucketBoundaries        []time.Duration
+	fileSetScrubInterval           time.Duration
+	coldFlushMergeSources          []ColdFlushMergeSource
+	snapshotRetentionCount         int
+	snapshotRetentionPeriod        time.Duration
+	memoryPressureOpts             MemoryPressureOptions
+	durabilityProbeOpts            DurabilityProbeOptions
+	hotSeriesOpts                  HotSeriesOptions
+	overloadPolicy                 OverloadPolicy
+	coldFlushOpts                  m3dbruntime.ColdFlushOptions
+	cardinalityOpts                CardinalityOptions
 }
 
 // NewOptions creates a new set of storage options with defaults
@@ -205,6 +222,9 @@ func newOptions(poolOpts pool.ObjectPoolOptions) Options {
 		bufferBucketVersionsPool:       series.NewBufferBucketVersionsPool(poolOpts),
 		bufferBucketPool:               series.NewBufferBucketPool(poolOpts),
 		schemaReg:                      namespace.NewSchemaRegistry(false, nil),
+		dataAgeBucketBoundaries:        defaultDataAgeBucketBoundaries,
+		snapshotRetentionCount:         defaultSnapshotRetentionCount,
+		overloadPolicy:                 NewThresholdOverloadPolicy(DefaultOverloadThresholds()),
 	}
 	return o.SetEncodingM3TSZPooled()
 }
@@ -258,6 +278,32 @@ func (o *options) Validate() error {
 		return errBlockLeaserNotSet
 	}
 
+	if o.snapshotRetentionCount < 1 {
+		return errSnapshotRetentionCount
+	}
+	if o.snapshotRetentionPeriod < 0 {
+		return errSnapshotRetentionPeriod
+	}
+	if o.overloadPolicy == nil {
+		return errOverloadPolicyNotSet
+	}
+
+	if err := o.memoryPressureOpts.Validate(); err != nil {
+		return fmt.Errorf("unable to validate memory pressure options, err: %v", err)
+	}
+	if err := o.durabilityProbeOpts.Validate(); err != nil {
+		return fmt.Errorf("unable to validate durability probe options, err: %v", err)
+	}
+	if err := o.hotSeriesOpts.Validate(); err != nil {
+		return fmt.Errorf("unable to validate hot series options, err: %v", err)
+	}
+	if err := o.coldFlushOpts.Validate(); err != nil {
+		return fmt.Errorf("unable to validate cold flush options, err: %v", err)
+	}
+	if err := o.cardinalityOpts.Validate(); err != nil {
+		return fmt.Errorf("unable to validate cardinality options, err: %v", err)
+	}
+
 	return nil
 }
 
@@ -692,3 +738,113 @@ func (o *options) SetBlockLeaseManager(leaseMgr block.LeaseManager) Options {
 func (o *options) BlockLeaseManager() block.LeaseManager {
 	return o.blockLeaseManager
 }
+
+func (o *options) SetDataAgeBucketBoundaries(value []time.Duration) Options {
+	opts := *o
+	opts.dataAgeBucketBoundaries = value
+	return &opts
+}
+
+func (o *options) DataAgeBucketBoundaries() []time.Duration {
+	return o.dataAgeBucketBoundaries
+}
+
+func (o *options) SetColdFlushMergeSources(value []ColdFlushMergeSource) Options {
+	opts := *o
+	opts.coldFlushMergeSources = value
+	return &opts
+}
+
+func (o *options) ColdFlushMergeSources() []ColdFlushMergeSource {
+	return o.coldFlushMergeSources
+}
+
+func (o *options) SetFileSetScrubInterval(value time.Duration) Options {
+	opts := *o
+	opts.fileSetScrubInterval = value
+	return &opts
+}
+
+func (o *options) FileSetScrubInterval() time.Duration {
+	return o.fileSetScrubInterval
+}
+
+func (o *options) SetSnapshotRetentionCount(value int) Options {
+	opts := *o
+	opts.snapshotRetentionCount = value
+	return &opts
+}
+
+func (o *options) SnapshotRetentionCount() int {
+	return o.snapshotRetentionCount
+}
+
+func (o *options) SetSnapshotRetentionPeriod(value time.Duration) Options {
+	opts := *o
+	opts.snapshotRetentionPeriod = value
+	return &opts
+}
+
+func (o *options) SnapshotRetentionPeriod() time.Duration {
+	return o.snapshotRetentionPeriod
+}
+
+func (o *options) SetMemoryPressureOptions(value MemoryPressureOptions) Options {
+	opts := *o
+	opts.memoryPressureOpts = value
+	return &opts
+}
+
+func (o *options) MemoryPressureOptions() MemoryPressureOptions {
+	return o.memoryPressureOpts
+}
+
+func (o *options) SetDurabilityProbeOptions(value DurabilityProbeOptions) Options {
+	opts := *o
+	opts.durabilityProbeOpts = value
+	return &opts
+}
+
+func (o *options) DurabilityProbeOptions() DurabilityProbeOptions {
+	return o.durabilityProbeOpts
+}
+
+func (o *options) SetHotSeriesOptions(value HotSeriesOptions) Options {
+	opts := *o
+	opts.hotSeriesOpts = value
+	return &opts
+}
+
+func (o *options) HotSeriesOptions() HotSeriesOptions {
+	return o.hotSeriesOpts
+}
+
+func (o *options) SetOverloadPolicy(value OverloadPolicy) Options {
+	opts := *o
+	opts.overloadPolicy = value
+	return &opts
+}
+
+func (o *options) OverloadPolicy() OverloadPolicy {
+	return o.overloadPolicy
+}
+
+func (o *options) SetColdFlushOptions(value m3dbruntime.ColdFlushOptions) Options {
+	opts := *o
+	opts.coldFlushOpts = value
+	return &opts
+}
+
+func (o *options) ColdFlushOptions() m3dbruntime.ColdFlushOptions {
+	return o.coldFlushOpts
+}
+
+func (o *options) SetCardinalityOptions(value CardinalityOptions) Options {
+	opts := *o
+	opts.cardinalityOpts = value
+	return &opts
+}
+
+func (o *options) CardinalityOptions() CardinalityOptions {
+	return o.cardinalityOpts
+}
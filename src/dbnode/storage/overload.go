@@ -0,0 +1,147 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// The database is considered overloaded by default if the queue size is
+	// 90% or more of the maximum capacity. We set this below 1.0 because
+	// checking the queue lengthy is racey so we're gonna burst past this value
+	// anyways and the buffer gives us breathing room to recover.
+	defaultCommitLogQueueCapacityOverloadedFactor = 0.9
+)
+
+var (
+	errOverloadCommitLogQueueFactor = errors.New("overload commit log queue factor must be between 0 and 1")
+	errOverloadMaxFlushLag          = errors.New("overload max flush lag must not be negative")
+	errOverloadMemoryPressure       = errors.New("overload memory pressure level is invalid")
+	errOverloadMaxInFlightQueries   = errors.New("overload max in-flight queries must not be negative")
+)
+
+// OverloadSignals are the signals of the load of the database that an
+// overload policy decides whether the database is overloaded from.
+type OverloadSignals struct {
+	// CommitLogQueueLength is the number of writes queued to the commit log.
+	CommitLogQueueLength int64
+	// CommitLogQueueCapacity is the capacity of the commit log queue.
+	CommitLogQueueCapacity int64
+	// FlushLag is how long ago the last successful flush and snapshot
+	// started, zero if there has not been one yet.
+	FlushLag time.Duration
+	// MemoryPressure is the memory pressure level of the last check of the
+	// memory pressure monitor.
+	MemoryPressure MemoryPressureLevel
+	// InFlightQueries is the number of index queries in-flight.
+	InFlightQueries int64
+}
+
+// OverloadPolicy decides whether the database is overloaded, in which case
+// requests are rejected to shed load until it recovers.
+type OverloadPolicy interface {
+	// IsOverloaded returns whether the database is overloaded given the
+	// signals of its load.
+	IsOverloaded(signals OverloadSignals) bool
+}
+
+// OverloadPolicyFn is a function that implements OverloadPolicy.
+type OverloadPolicyFn func(signals OverloadSignals) bool
+
+// IsOverloaded returns whether the database is overloaded.
+func (fn OverloadPolicyFn) IsOverloaded(signals OverloadSignals) bool {
+	return fn(signals)
+}
+
+// OverloadThresholds are the thresholds of the threshold overload policy,
+// the database is overloaded as soon as any enabled threshold is reached.
+type OverloadThresholds struct {
+	// CommitLogQueueFactor is the fraction of the capacity of the commit log
+	// queue at which the database is overloaded, zero disables the threshold.
+	CommitLogQueueFactor float64
+
+	// MaxFlushLag is the flush lag at which the database is overloaded, zero
+	// disables the threshold.
+	MaxFlushLag time.Duration
+
+	// MemoryPressure is the memory pressure level at which the database is
+	// overloaded, MemoryPressureNone disables the threshold.
+	MemoryPressure MemoryPressureLevel
+
+	// MaxInFlightQueries is the number of in-flight index queries at which
+	// the database is overloaded, zero disables the threshold.
+	MaxInFlightQueries int64
+}
+
+// Validate validates the overload thresholds.
+func (t OverloadThresholds) Validate() error {
+	if t.CommitLogQueueFactor < 0 || t.CommitLogQueueFactor > 1 {
+		return errOverloadCommitLogQueueFactor
+	}
+	if t.MaxFlushLag < 0 {
+		return errOverloadMaxFlushLag
+	}
+	if t.MemoryPressure < MemoryPressureNone || t.MemoryPressure > MemoryPressureCritical {
+		return errOverloadMemoryPressure
+	}
+	if t.MaxInFlightQueries < 0 {
+		return errOverloadMaxInFlightQueries
+	}
+	return nil
+}
+
+// DefaultOverloadThresholds returns the default overload thresholds, which
+// only consider the database overloaded when its commit log queue is close
+// to capacity.
+func DefaultOverloadThresholds() OverloadThresholds {
+	return OverloadThresholds{
+		CommitLogQueueFactor: defaultCommitLogQueueCapacityOverloadedFactor,
+	}
+}
+
+type thresholdOverloadPolicy struct {
+	thresholds OverloadThresholds
+}
+
+// NewThresholdOverloadPolicy returns an overload policy that considers the
+// database overloaded as soon as any of the enabled thresholds is reached.
+func NewThresholdOverloadPolicy(thresholds OverloadThresholds) OverloadPolicy {
+	return thresholdOverloadPolicy{thresholds: thresholds}
+}
+
+func (p thresholdOverloadPolicy) IsOverloaded(signals OverloadSignals) bool {
+	t := p.thresholds
+	if t.CommitLogQueueFactor > 0 &&
+		float64(signals.CommitLogQueueLength) >= t.CommitLogQueueFactor*float64(signals.CommitLogQueueCapacity) {
+		return true
+	}
+	if t.MaxFlushLag > 0 && signals.FlushLag >= t.MaxFlushLag {
+		return true
+	}
+	if t.MemoryPressure > MemoryPressureNone && signals.MemoryPressure >= t.MemoryPressure {
+		return true
+	}
+	if t.MaxInFlightQueries > 0 && signals.InFlightQueries >= t.MaxInFlightQueries {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverloadThresholdsValidate(t *testing.T) {
+	require.NoError(t, DefaultOverloadThresholds().Validate())
+	require.NoError(t, OverloadThresholds{}.Validate())
+	require.Error(t, OverloadThresholds{CommitLogQueueFactor: 1.5}.Validate())
+	require.Error(t, OverloadThresholds{MaxFlushLag: -time.Second}.Validate())
+	require.Error(t, OverloadThresholds{MemoryPressure: MemoryPressureCritical + 1}.Validate())
+	require.Error(t, OverloadThresholds{MaxInFlightQueries: -1}.Validate())
+}
+
+func TestThresholdOverloadPolicy(t *testing.T) {
+	policy := NewThresholdOverloadPolicy(OverloadThresholds{
+		CommitLogQueueFactor: 0.5,
+		MaxFlushLag:          time.Hour,
+		MemoryPressure:       MemoryPressureHigh,
+		MaxInFlightQueries:   10,
+	})
+
+	healthy := OverloadSignals{
+		CommitLogQueueLength:   49,
+		CommitLogQueueCapacity: 100,
+		FlushLag:               59 * time.Minute,
+		MemoryPressure:         MemoryPressureElevated,
+		InFlightQueries:        9,
+	}
+	require.False(t, policy.IsOverloaded(healthy))
+
+	for _, fn := range []func(s *OverloadSignals){
+		func(s *OverloadSignals) { s.CommitLogQueueLength = 50 },
+		func(s *OverloadSignals) { s.FlushLag = time.Hour },
+		func(s *OverloadSignals) { s.MemoryPressure = MemoryPressureCritical },
+		func(s *OverloadSignals) { s.InFlightQueries = 10 },
+	} {
+		signals := healthy
+		fn(&signals)
+		require.True(t, policy.IsOverloaded(signals))
+	}
+
+	// Disabled thresholds never overload the database.
+	disabled := NewThresholdOverloadPolicy(OverloadThresholds{})
+	require.False(t, disabled.IsOverloaded(OverloadSignals{
+		CommitLogQueueLength:   100,
+		CommitLogQueueCapacity: 100,
+		FlushLag:               24 * time.Hour,
+		MemoryPressure:         MemoryPressureCritical,
+		InFlightQueries:        1000,
+	}))
+}
+
+func TestParseMemoryPressureLevel(t *testing.T) {
+	for level := MemoryPressureNone; level <= MemoryPressureCritical; level++ {
+		parsed, err := ParseMemoryPressureLevel(level.String())
+		require.NoError(t, err)
+		require.Equal(t, level, parsed)
+	}
+	_, err := ParseMemoryPressureLevel("extreme")
+	require.Error(t, err)
+}
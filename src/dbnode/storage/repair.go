@@ -412,7 +412,13 @@ func (r *dbRepairer) repairNamespaceWithTimeRange(n databaseNamespace, tr xtime.
 	)
 
 	// repair the namespace
-	if err = n.Repair(r.shardRepairer, tr); err != nil {
+	err = n.Repair(r.shardRepairer, tr)
+	if err == errBackgroundTaskPaused {
+		// Leave the repair state untouched so that the time range is
+		// repaired once the namespace repair is resumed.
+		return nil
+	}
+	if err != nil {
 		err = fmt.Errorf("namespace %s failed to repair time range %v: %v", n.ID().String(), tr, err)
 	}
 
@@ -30,6 +30,7 @@ type tickResult struct {
 	madeExpiredBlocks      int
 	madeUnwiredBlocks      int
 	mergedOutOfOrderBlocks int
+	partiallyMergedBlocks  int
 	errors                 int
 	evictedBuckets         int
 }
@@ -45,6 +46,7 @@ func (r tickResult) merge(other tickResult) tickResult {
 		madeExpiredBlocks:      r.madeExpiredBlocks + other.madeExpiredBlocks,
 		madeUnwiredBlocks:      r.madeUnwiredBlocks + other.madeUnwiredBlocks,
 		mergedOutOfOrderBlocks: r.mergedOutOfOrderBlocks + other.mergedOutOfOrderBlocks,
+		partiallyMergedBlocks:  r.partiallyMergedBlocks + other.partiallyMergedBlocks,
 		errors:                 r.errors + other.errors,
 		evictedBuckets:         r.evictedBuckets + other.evictedBuckets,
 	}
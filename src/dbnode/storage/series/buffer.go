@@ -32,6 +32,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/ts"
@@ -72,6 +73,9 @@ const (
 	// is sane.
 	optimizedTimesArraySize = 8
 	writableBucketVersion   = 0
+	// mergeBudgetCheckInterval is the number of datapoints merged between
+	// checks of whether the tick merge budget has been exhausted.
+	mergeBudgetCheckInterval = 1024
 )
 
 type databaseBuffer interface {
@@ -133,10 +137,20 @@ type databaseBuffer interface {
 
 	Stats() bufferStats
 
-	Tick(versions map[xtime.UnixNano]BlockState, nsCtx namespace.Context) bufferTickResult
+	Metadata() BufferMetadata
+
+	Tick(
+		versions map[xtime.UnixNano]BlockState,
+		budget *MergeBudget,
+		nsCtx namespace.Context,
+	) bufferTickResult
 
 	Bootstrap(bl block.DatabaseBlock)
 
+	Load(bl block.DatabaseBlock, writeType WriteType)
+
+	SetRetentionOptions(ropts retention.Options)
+
 	Reset(id ident.ID, opts Options)
 }
 
@@ -146,6 +160,7 @@ type bufferStats struct {
 
 type bufferTickResult struct {
 	mergedOutOfOrderBlocks int
+	partiallyMergedBlocks  int
 	evictedBucketTimes     OptimizedTimes
 }
 
@@ -230,6 +245,14 @@ type dbBuffer struct {
 	coldWritesEnabled     bool
 	retentionPeriod       time.Duration
 	futureRetentionPeriod time.Duration
+
+	// firstWriteTimestamp and lastWriteTimestamp are the earliest and latest
+	// timestamps written to the buffer, the latter is used to reject the
+	// writes that are not strictly increasing. They are only tracked in memory
+	// for writes since the series was last reset and are not seeded from
+	// bootstrapped or flushed data.
+	firstWriteTimestamp xtime.UnixNano
+	lastWriteTimestamp  xtime.UnixNano
 }
 
 // NB(prateek): databaseBuffer.Reset(...) must be called upon the returned
@@ -249,10 +272,16 @@ func (b *dbBuffer) Reset(id ident.ID, opts Options) {
 	ropts := opts.RetentionOptions()
 	b.bucketPool = opts.BufferBucketPool()
 	b.bucketVersionsPool = opts.BufferBucketVersionsPool()
+	b.coldWritesEnabled = opts.ColdWritesEnabled()
+	b.firstWriteTimestamp = 0
+	b.lastWriteTimestamp = 0
+	b.SetRetentionOptions(ropts)
+}
+
+func (b *dbBuffer) SetRetentionOptions(ropts retention.Options) {
 	b.blockSize = ropts.BlockSize()
 	b.bufferPast = ropts.BufferPast()
 	b.bufferFuture = ropts.BufferFuture()
-	b.coldWritesEnabled = opts.ColdWritesEnabled()
 	b.retentionPeriod = ropts.RetentionPeriod()
 	b.futureRetentionPeriod = ropts.FutureRetentionPeriod()
 }
@@ -265,33 +294,106 @@ func (b *dbBuffer) Write(
 	annotation []byte,
 	wOpts WriteOptions,
 ) (bool, error) {
+	writeType, err := b.writeWindow().writeType(b.id, timestamp, b.nowFn())
+	if err != nil {
+		return false, err
+	}
+
+	writeTimestamp := xtime.ToUnixNano(timestamp)
+	if wOpts.StrictlyIncreasing && b.lastWriteTimestamp != 0 &&
+		writeTimestamp <= b.lastWriteTimestamp {
+		return false, m3dberrors.ErrNotStrictlyIncreasing
+	}
+
+	blockStart := timestamp.Truncate(b.blockSize)
+	buckets := b.bucketVersionsAtCreate(blockStart)
+	b.putBucketVersionsInCache(buckets)
+
+	if wOpts.TruncateType == TypeBlock {
+		timestamp = blockStart
+	}
+
+	if wOpts.TransformOptions.ForceValueEnabled {
+		value = wOpts.TransformOptions.ForceValue
+	}
+
+	wasWritten, err := buckets.write(timestamp, value, unit, annotation, writeType, wOpts.SchemaDesc)
+	if err == nil {
+		if writeTimestamp > b.lastWriteTimestamp {
+			b.lastWriteTimestamp = writeTimestamp
+		}
+		if b.firstWriteTimestamp == 0 || writeTimestamp < b.firstWriteTimestamp {
+			b.firstWriteTimestamp = writeTimestamp
+		}
+	}
+	return wasWritten, err
+}
+
+func (b *dbBuffer) writeWindow() writeWindow {
+	return writeWindow{
+		bufferPast:            b.bufferPast,
+		bufferFuture:          b.bufferFuture,
+		retentionPeriod:       b.retentionPeriod,
+		futureRetentionPeriod: b.futureRetentionPeriod,
+		blockSize:             b.blockSize,
+		coldWritesEnabled:     b.coldWritesEnabled,
+	}
+}
+
+// writeWindow is the window of time in which a series accepts writes.
+type writeWindow struct {
+	bufferPast            time.Duration
+	bufferFuture          time.Duration
+	retentionPeriod       time.Duration
+	futureRetentionPeriod time.Duration
+	blockSize             time.Duration
+	coldWritesEnabled     bool
+}
+
+func newWriteWindow(ropts retention.Options, coldWritesEnabled bool) writeWindow {
+	return writeWindow{
+		bufferPast:            ropts.BufferPast(),
+		bufferFuture:          ropts.BufferFuture(),
+		retentionPeriod:       ropts.RetentionPeriod(),
+		futureRetentionPeriod: ropts.FutureRetentionPeriod(),
+		blockSize:             ropts.BlockSize(),
+		coldWritesEnabled:     coldWritesEnabled,
+	}
+}
+
+// writeType returns the type of write for a datapoint at the given timestamp,
+// or an error if the timestamp is outside of the window.
+func (w writeWindow) writeType(
+	id ident.ID,
+	timestamp time.Time,
+	now time.Time,
+) (WriteType, error) {
 	var (
-		now         = b.nowFn()
-		pastLimit   = now.Add(-1 * b.bufferPast)
-		futureLimit = now.Add(b.bufferFuture)
+		pastLimit   = now.Add(-1 * w.bufferPast)
+		futureLimit = now.Add(w.bufferFuture)
 		writeType   WriteType
 	)
 	switch {
 	case !pastLimit.Before(timestamp):
 		writeType = ColdWrite
-		if !b.coldWritesEnabled {
-			return false, xerrors.NewInvalidParamsError(
+		if !w.coldWritesEnabled {
+			return writeType, xerrors.NewInvalidParamsError(
 				fmt.Errorf("datapoint too far in past: "+
 					"id=%s, off_by=%s, timestamp=%s, past_limit=%s, "+
 					"timestamp_unix_nanos=%d, past_limit_unix_nanos=%d",
-					b.id.Bytes(), pastLimit.Sub(timestamp).String(),
+					id.Bytes(), pastLimit.Sub(timestamp).String(),
 					timestamp.Format(errTimestampFormat),
 					pastLimit.Format(errTimestampFormat),
 					timestamp.UnixNano(), pastLimit.UnixNano()))
 		}
 	case !futureLimit.After(timestamp):
 		writeType = ColdWrite
-		if !b.coldWritesEnabled {
-			return false, xerrors.NewInvalidParamsError(
+		if !w.coldWritesEnabled {
+			return writeType, xerrors.NewInvalidParamsError(
 				fmt.Errorf("datapoint too far in future: "+
 					"id=%s, off_by=%s, timestamp=%s, future_limit=%s, "+
 					"timestamp_unix_nanos=%d, future_limit_unix_nanos=%d",
-					b.id.Bytes(), timestamp.Sub(futureLimit).String(),
+					id.Bytes(), timestamp.Sub(futureLimit).String(),
 					timestamp.Format(errTimestampFormat),
 					futureLimit.Format(errTimestampFormat),
 					timestamp.UnixNano(), futureLimit.UnixNano()))
@@ -301,28 +403,29 @@ func (b *dbBuffer) Write(
 	}
 
 	if writeType == ColdWrite {
-		if now.Add(-b.retentionPeriod).After(timestamp) {
-			return false, m3dberrors.ErrTooPast
+		if now.Add(-w.retentionPeriod).After(timestamp) {
+			return writeType, m3dberrors.ErrTooPast
 		}
 
-		if !now.Add(b.futureRetentionPeriod).Add(b.blockSize).After(timestamp) {
-			return false, m3dberrors.ErrTooFuture
+		if !now.Add(w.futureRetentionPeriod).Add(w.blockSize).After(timestamp) {
+			return writeType, m3dberrors.ErrTooFuture
 		}
 	}
 
-	blockStart := timestamp.Truncate(b.blockSize)
-	buckets := b.bucketVersionsAtCreate(blockStart)
-	b.putBucketVersionsInCache(buckets)
-
-	if wOpts.TruncateType == TypeBlock {
-		timestamp = blockStart
-	}
-
-	if wOpts.TransformOptions.ForceValueEnabled {
-		value = wOpts.TransformOptions.ForceValue
-	}
+	return writeType, nil
+}
 
-	return buckets.write(timestamp, value, unit, annotation, writeType, wOpts.SchemaDesc)
+// ValidateWriteTime validates that a datapoint at the given timestamp would
+// be accepted by a write to a series with the given retention options.
+func ValidateWriteTime(
+	id ident.ID,
+	timestamp time.Time,
+	now time.Time,
+	ropts retention.Options,
+	coldWritesEnabled bool,
+) error {
+	_, err := newWriteWindow(ropts, coldWritesEnabled).writeType(id, timestamp, now)
+	return err
 }
 
 func (b *dbBuffer) IsEmpty() bool {
@@ -363,8 +466,29 @@ func (b *dbBuffer) Stats() bufferStats {
 	}
 }
 
-func (b *dbBuffer) Tick(blockStates map[xtime.UnixNano]BlockState, nsCtx namespace.Context) bufferTickResult {
-	mergedOutOfOrder := 0
+func (b *dbBuffer) Metadata() BufferMetadata {
+	metadata := BufferMetadata{
+		BlockStarts: make([]time.Time, len(b.inOrderBlockStarts)),
+	}
+	copy(metadata.BlockStarts, b.inOrderBlockStarts)
+	if b.firstWriteTimestamp != 0 {
+		metadata.FirstWrite = b.firstWriteTimestamp.ToTime()
+	}
+	if b.lastWriteTimestamp != 0 {
+		metadata.LastWrite = b.lastWriteTimestamp.ToTime()
+	}
+	return metadata
+}
+
+func (b *dbBuffer) Tick(
+	blockStates map[xtime.UnixNano]BlockState,
+	budget *MergeBudget,
+	nsCtx namespace.Context,
+) bufferTickResult {
+	var (
+		mergedOutOfOrder int
+		partiallyMerged  int
+	)
 	var evictedBucketTimes OptimizedTimes
 	for tNano, buckets := range b.bucketsMap {
 		// The blockStates map is never written to after creation, so this
@@ -408,8 +532,9 @@ func (b *dbBuffer) Tick(blockStates map[xtime.UnixNano]BlockState, nsCtx namespa
 		}
 
 		// Once we've evicted all eligible buckets, we merge duplicate encoders
-		// in the remaining ones to try and reclaim memory.
-		merges, err := buckets.merge(WarmWrite, nsCtx)
+		// in the remaining ones to try and reclaim memory. Merges that do not
+		// complete within the budget are continued on the next tick.
+		merges, pending, err := buckets.merge(WarmWrite, budget, nsCtx)
 		if err != nil {
 			log := b.opts.InstrumentOptions().Logger()
 			log.Error("buffer merge encode error", zap.Error(err))
@@ -417,17 +542,25 @@ func (b *dbBuffer) Tick(blockStates map[xtime.UnixNano]BlockState, nsCtx namespa
 		if merges > 0 {
 			mergedOutOfOrder++
 		}
+		if pending {
+			partiallyMerged++
+		}
 	}
 	return bufferTickResult{
 		mergedOutOfOrderBlocks: mergedOutOfOrder,
+		partiallyMergedBlocks:  partiallyMerged,
 		evictedBucketTimes:     evictedBucketTimes,
 	}
 }
 
 func (b *dbBuffer) Bootstrap(bl block.DatabaseBlock) {
+	b.Load(bl, BootstrapWriteType)
+}
+
+func (b *dbBuffer) Load(bl block.DatabaseBlock, writeType WriteType) {
 	blockStart := bl.StartTime()
 	buckets := b.bucketVersionsAtCreate(blockStart)
-	buckets.bootstrap(bl)
+	buckets.load(bl, writeType)
 }
 
 func (b *dbBuffer) Snapshot(
@@ -880,7 +1013,14 @@ func (b *BufferBucketVersions) streams(ctx context.Context, opts streamsOptions)
 	var res []xio.BlockReader
 	for _, bucket := range b.buckets {
 		if !opts.filterWriteType || bucket.writeType == opts.writeType {
-			res = append(res, bucket.streams(ctx)...)
+			source := xio.BlockSource{Type: xio.BufferWarmBlockSource}
+			if bucket.writeType == ColdWrite {
+				source.Type = xio.BufferColdBlockSource
+			}
+			for _, stream := range bucket.streams(ctx) {
+				stream.Source = source
+				res = append(res, stream)
+			}
 		}
 	}
 
@@ -906,20 +1046,31 @@ func (b *BufferBucketVersions) write(
 	return b.writableBucketCreate(writeType).write(timestamp, value, unit, annotation, schema)
 }
 
-func (b *BufferBucketVersions) merge(writeType WriteType, nsCtx namespace.Context) (int, error) {
-	res := 0
+// merge merges the writable buckets of the given write type, returning the
+// number of streams merged and whether any merge is left pending because
+// the budget was exhausted.
+func (b *BufferBucketVersions) merge(
+	writeType WriteType,
+	budget *MergeBudget,
+	nsCtx namespace.Context,
+) (int, bool, error) {
+	var (
+		res     = 0
+		pending = false
+	)
 	for _, bucket := range b.buckets {
 		// Only makes sense to merge buckets that are writable.
 		if bucket.version == writableBucketVersion && writeType == bucket.writeType {
-			merges, err := bucket.merge(nsCtx)
+			merges, bucketPending, err := bucket.merge(budget, nsCtx)
 			if err != nil {
-				return 0, err
+				return 0, false, err
 			}
 			res += merges
+			pending = pending || bucketPending
 		}
 	}
 
-	return res, nil
+	return res, pending, nil
 }
 
 func (b *BufferBucketVersions) removeBucketsUpToVersion(
@@ -955,8 +1106,8 @@ func (b *BufferBucketVersions) lastRead() time.Time {
 	return time.Unix(0, atomic.LoadInt64(&b.lastReadUnixNanos))
 }
 
-func (b *BufferBucketVersions) bootstrap(bl block.DatabaseBlock) {
-	bucket := b.writableBucketCreate(BootstrapWriteType)
+func (b *BufferBucketVersions) load(bl block.DatabaseBlock, writeType WriteType) {
+	bucket := b.writableBucketCreate(writeType)
 	bucket.bootstrapped = append(bucket.bootstrapped, bl)
 }
 
@@ -1021,6 +1172,7 @@ type BufferBucket struct {
 	bootstrapped []block.DatabaseBlock
 	version      int
 	writeType    WriteType
+	pendingMerge *bufferBucketMerge
 }
 
 type inOrderEncoder struct {
@@ -1028,6 +1180,22 @@ type inOrderEncoder struct {
 	lastWriteAt time.Time
 }
 
+// bufferBucketMerge is the state of a merge that ran out of budget before it
+// could complete. It merges the bootstrapped blocks and the first encoders of
+// the bucket as they were when the merge started, writes that arrive in the
+// meantime to new encoders are left in place while writes to any of the
+// merged encoders restart the merge.
+type bufferBucketMerge struct {
+	ctx                 context.Context
+	streams             []xio.SegmentReader
+	iter                encoding.MultiReaderIterator
+	encoder             encoding.Encoder
+	lastWriteAt         time.Time
+	merges              int
+	numBootstrapped     int
+	encoderLastWriteAts []time.Time
+}
+
 func (b *BufferBucket) resetTo(
 	start time.Time,
 	writeType WriteType,
@@ -1180,6 +1348,7 @@ func (b *BufferBucket) streamsLen() int {
 }
 
 func (b *BufferBucket) resetEncoders() {
+	b.discardPendingMerge()
 	var zeroed inOrderEncoder
 	for i := range b.encoders {
 		// Register when this bucket resets we close the encoder.
@@ -1191,6 +1360,7 @@ func (b *BufferBucket) resetEncoders() {
 }
 
 func (b *BufferBucket) resetBootstrapped() {
+	b.discardPendingMerge()
 	for i := range b.bootstrapped {
 		bl := b.bootstrapped[i]
 		bl.Close()
@@ -1212,61 +1382,178 @@ func (b *BufferBucket) hasJustSingleBootstrappedBlock() bool {
 	return encodersEmpty && len(b.bootstrapped) == 1
 }
 
-func (b *BufferBucket) merge(nsCtx namespace.Context) (int, error) {
-	if !b.needsMerge() {
-		// Save unnecessary work
-		return 0, nil
+// merge merges the bootstrapped blocks and encoders of the bucket into a
+// single encoder, returning the number of streams merged and whether the
+// merge is left pending because the budget was exhausted before completing.
+func (b *BufferBucket) merge(budget *MergeBudget, nsCtx namespace.Context) (int, bool, error) {
+	merges := 0
+	for {
+		n, pending, err := b.mergeStep(budget, nsCtx)
+		if err != nil {
+			return 0, false, err
+		}
+		merges += n
+		// Encoders created while a merge was pending are left unmerged by
+		// it, so keep merging until a single encoder remains.
+		if pending || !b.needsMerge() {
+			return merges, pending, nil
+		}
+	}
+}
+
+// mergeStep continues the pending merge, or starts one, until it completes
+// or the budget is exhausted.
+func (b *BufferBucket) mergeStep(budget *MergeBudget, nsCtx namespace.Context) (int, bool, error) {
+	if b.pendingMerge != nil && !b.pendingMergeValid() {
+		// Some of the inputs were written to since the merge started so the
+		// partially merged encoder is stale.
+		b.discardPendingMerge()
+	}
+
+	if b.pendingMerge == nil {
+		if !b.needsMerge() {
+			// Save unnecessary work
+			return 0, false, nil
+		}
+		if budget.Exhausted() {
+			return 0, true, nil
+		}
+		b.startPendingMerge(nsCtx)
 	}
 
 	var (
-		start   = b.start
-		readers = make([]xio.SegmentReader, 0, len(b.encoders)+len(b.bootstrapped))
-		streams = make([]xio.SegmentReader, 0, len(b.encoders))
-		ctx     = b.opts.ContextPool().Get()
-		merges  = 0
+		merge   = b.pendingMerge
+		encoded = 0
+		start   = budget.now()
 	)
-	defer func() {
-		ctx.Close()
-		// NB(r): Only need to close the mutable encoder streams as
-		// the context we created for reading the bootstrap blocks
-		// when closed will close those streams.
-		for _, stream := range streams {
-			stream.Finalize()
+	defer budget.spend(start)
+
+	for merge.iter.Next() {
+		dp, unit, annotation := merge.iter.Current()
+		if err := merge.encoder.Encode(dp, unit, annotation); err != nil {
+			b.discardPendingMerge()
+			return 0, false, err
+		}
+		merge.lastWriteAt = dp.Timestamp
+
+		encoded++
+		if encoded%mergeBudgetCheckInterval == 0 && budget.exceeded(start) {
+			return 0, true, nil
+		}
+	}
+	if err := merge.iter.Err(); err != nil {
+		b.discardPendingMerge()
+		return 0, false, err
+	}
+
+	// Replace the merged bootstrapped blocks and encoders with the merged
+	// encoder, keeping any encoders created since the merge started after it
+	// so that their values continue to take precedence.
+	var (
+		merges      = merge.merges
+		numMerged   = len(merge.encoderLastWriteAts)
+		numRetained = len(b.encoders) - numMerged
+		encoder     = merge.encoder
+		lastWriteAt = merge.lastWriteAt
+	)
+	merge.encoder = nil
+	b.discardPendingMerge()
+	b.resetBootstrapped()
+
+	var zeroed inOrderEncoder
+	for i := 0; i < numMerged; i++ {
+		b.encoders[i].encoder.Close()
+	}
+	if numMerged == 0 {
+		b.encoders = append(b.encoders, zeroed)
+	}
+	copy(b.encoders[1:], b.encoders[numMerged:])
+	for i := numRetained + 1; i < len(b.encoders); i++ {
+		b.encoders[i] = zeroed
+	}
+	b.encoders = b.encoders[:numRetained+1]
+	b.encoders[0] = inOrderEncoder{
+		encoder:     encoder,
+		lastWriteAt: lastWriteAt,
+	}
+
+	return merges, false, nil
+}
+
+func (b *BufferBucket) startPendingMerge(nsCtx namespace.Context) {
+	var (
+		readers = make([]xio.SegmentReader, 0, len(b.encoders)+len(b.bootstrapped))
+		merge   = &bufferBucketMerge{
+			ctx:                 b.opts.ContextPool().Get(),
+			streams:             make([]xio.SegmentReader, 0, len(b.encoders)),
+			numBootstrapped:     len(b.bootstrapped),
+			encoderLastWriteAts: make([]time.Time, 0, len(b.encoders)),
 		}
-	}()
+	)
 
 	// Rank bootstrapped blocks as data that has appeared before data that
 	// arrived locally in the buffer
 	for i := range b.bootstrapped {
-		block, err := b.bootstrapped[i].Stream(ctx)
+		block, err := b.bootstrapped[i].Stream(merge.ctx)
 		if err == nil && block.SegmentReader != nil {
-			merges++
+			merge.merges++
 			readers = append(readers, block.SegmentReader)
 		}
 	}
 
 	for i := range b.encoders {
+		merge.encoderLastWriteAts = append(merge.encoderLastWriteAts,
+			b.encoders[i].lastWriteAt)
 		if s, ok := b.encoders[i].encoder.Stream(encoding.StreamOptions{}); ok {
-			merges++
+			merge.merges++
 			readers = append(readers, s)
-			streams = append(streams, s)
+			merge.streams = append(merge.streams, s)
 		}
 	}
 
-	encoder, lastWriteAt, err := mergeStreamsToEncoder(start, readers, b.opts, nsCtx)
-	if err != nil {
-		return 0, err
-	}
+	bopts := b.opts.DatabaseBlockOptions()
+	merge.encoder = b.opts.EncoderPool().Get()
+	merge.encoder.Reset(b.start, bopts.DatabaseBlockAllocSize(), nsCtx.Schema)
+	merge.iter = b.opts.MultiReaderIteratorPool().Get()
+	merge.iter.Reset(readers, b.start, b.opts.RetentionOptions().BlockSize(), nsCtx.Schema)
 
-	b.resetEncoders()
-	b.resetBootstrapped()
+	b.pendingMerge = merge
+}
 
-	b.encoders = append(b.encoders, inOrderEncoder{
-		encoder:     encoder,
-		lastWriteAt: lastWriteAt,
-	})
+// pendingMergeValid returns whether the inputs of the pending merge are
+// unchanged since the merge started.
+func (b *BufferBucket) pendingMergeValid() bool {
+	merge := b.pendingMerge
+	if len(b.bootstrapped) != merge.numBootstrapped ||
+		len(b.encoders) < len(merge.encoderLastWriteAts) {
+		return false
+	}
+	for i, lastWriteAt := range merge.encoderLastWriteAts {
+		if !b.encoders[i].lastWriteAt.Equal(lastWriteAt) {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *BufferBucket) discardPendingMerge() {
+	merge := b.pendingMerge
+	if merge == nil {
+		return
+	}
+	b.pendingMerge = nil
 
-	return merges, nil
+	merge.iter.Close()
+	if merge.encoder != nil {
+		merge.encoder.Close()
+	}
+	merge.ctx.Close()
+	// NB(r): Only need to close the mutable encoder streams as
+	// the context we created for reading the bootstrap blocks
+	// when closed will close those streams.
+	for _, stream := range merge.streams {
+		stream.Finalize()
+	}
 }
 
 // mergeStreamsToEncoder merges streams to an encoder and returns the last
@@ -1327,7 +1614,8 @@ func (b *BufferBucket) mergeToStream(ctx context.Context, nsCtx namespace.Contex
 		return stream, true, nil
 	}
 
-	_, err := b.merge(nsCtx)
+	// Merge without a budget so that any pending merge is completed.
+	_, _, err := b.merge(nil, nsCtx)
 	if err != nil {
 		b.resetEncoders()
 		b.resetBootstrapped()
@@ -30,6 +30,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
 	"github.com/m3db/m3/src/dbnode/storage/block"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/x/context"
@@ -129,6 +130,32 @@ func TestBufferWriteTooPast(t *testing.T) {
 	assert.True(t, strings.Contains(err.Error(), "past_limit="))
 }
 
+func TestValidateWriteTime(t *testing.T) {
+	var (
+		id   = ident.StringID("foo")
+		rops = newBufferTestOptions().RetentionOptions()
+		curr = time.Now().Truncate(rops.BlockSize())
+	)
+
+	require.NoError(t, ValidateWriteTime(id, curr, curr, rops, false))
+
+	err := ValidateWriteTime(id, curr.Add(rops.BufferFuture()), curr, rops, false)
+	require.Error(t, err)
+	require.True(t, xerrors.IsInvalidParams(err))
+	require.True(t, strings.Contains(err.Error(), "datapoint too far in future"))
+
+	err = ValidateWriteTime(id, curr.Add(-1*rops.BufferPast()), curr, rops, false)
+	require.Error(t, err)
+	require.True(t, xerrors.IsInvalidParams(err))
+	require.True(t, strings.Contains(err.Error(), "datapoint too far in past"))
+
+	// With cold writes enabled only writes outside of retention are rejected.
+	require.NoError(t, ValidateWriteTime(id, curr.Add(-1*rops.BufferPast()), curr, rops, true))
+
+	err = ValidateWriteTime(id, curr.Add(-2*rops.RetentionPeriod()), curr, rops, true)
+	require.Equal(t, m3dberrors.ErrTooPast, err)
+}
+
 func TestBufferWriteError(t *testing.T) {
 	var (
 		opts   = newBufferTestOptions()
@@ -149,6 +176,80 @@ func TestBufferWriteError(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestBufferWriteStrictlyIncreasing(t *testing.T) {
+	var (
+		opts   = newBufferTestOptions()
+		rops   = opts.RetentionOptions()
+		curr   = time.Now().Truncate(rops.BlockSize())
+		ctx    = context.NewContext()
+		buffer = newDatabaseBuffer().(*dbBuffer)
+		wOpts  = WriteOptions{StrictlyIncreasing: true}
+	)
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return curr
+	}))
+	buffer.Reset(ident.StringID("foo"), opts)
+	defer ctx.Close()
+
+	wasWritten, err := buffer.Write(ctx, curr, 1, xtime.Second, nil, wOpts)
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+
+	// Writes at or before the last write are rejected.
+	for _, ts := range []time.Time{curr, curr.Add(-time.Second)} {
+		wasWritten, err = buffer.Write(ctx, ts, 2, xtime.Second, nil, wOpts)
+		require.Equal(t, m3dberrors.ErrNotStrictlyIncreasing, err)
+		require.False(t, wasWritten)
+	}
+
+	wasWritten, err = buffer.Write(ctx, curr.Add(time.Second), 2, xtime.Second, nil, wOpts)
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+
+	// Writes without the option upsert earlier datapoints.
+	wasWritten, err = buffer.Write(ctx, curr, 3, xtime.Second, nil, WriteOptions{})
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+
+	// Resetting the buffer forgets the last write.
+	buffer.Reset(ident.StringID("bar"), opts)
+	require.Equal(t, xtime.UnixNano(0), buffer.lastWriteTimestamp)
+}
+
+func TestBufferMetadata(t *testing.T) {
+	var (
+		opts   = newBufferTestOptions()
+		rops   = opts.RetentionOptions()
+		curr   = time.Now().Truncate(rops.BlockSize())
+		ctx    = context.NewContext()
+		buffer = newDatabaseBuffer().(*dbBuffer)
+	)
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return curr
+	}))
+	buffer.Reset(ident.StringID("foo"), opts)
+	defer ctx.Close()
+
+	require.Equal(t, BufferMetadata{BlockStarts: []time.Time{}}, buffer.Metadata())
+
+	for _, ts := range []time.Time{
+		curr.Add(time.Second),
+		curr.Add(-time.Second),
+		curr,
+	} {
+		wasWritten, err := buffer.Write(ctx, ts, 1, xtime.Second, nil, WriteOptions{})
+		require.NoError(t, err)
+		require.True(t, wasWritten)
+	}
+
+	metadata := buffer.Metadata()
+	require.True(t, curr.Add(-time.Second).Equal(metadata.FirstWrite))
+	require.True(t, curr.Add(time.Second).Equal(metadata.LastWrite))
+	require.Equal(t, 2, len(metadata.BlockStarts))
+	require.True(t, curr.Add(-rops.BlockSize()).Equal(metadata.BlockStarts[0]))
+	require.True(t, curr.Equal(metadata.BlockStarts[1]))
+}
+
 func TestBufferWriteRead(t *testing.T) {
 	opts := newBufferTestOptions()
 	testBufferWriteRead(t, opts, nil)
@@ -184,6 +285,11 @@ func testBufferWriteRead(t *testing.T, opts Options, setAnn setAnnotation) {
 	results, err := buffer.ReadEncoded(ctx, timeZero, timeDistantFuture, nsCtx)
 	assert.NoError(t, err)
 	assert.NotNil(t, results)
+	for _, readers := range results {
+		for _, reader := range readers {
+			require.Equal(t, xio.BufferWarmBlockSource, reader.Source.Type)
+		}
+	}
 
 	requireReaderValuesEqual(t, data, results, opts, nsCtx)
 }
@@ -445,13 +551,149 @@ func TestBufferBucketMergeNilEncoderStreams(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, stream)
 
-	mergeRes, err := b.merge(namespace.Context{})
+	mergeRes, pending, err := b.merge(nil, namespace.Context{})
 	require.NoError(t, err)
+	assert.False(t, pending)
 	assert.Equal(t, 1, mergeRes)
 	assert.Equal(t, 1, len(b.encoders))
 	assert.Equal(t, 0, len(b.bootstrapped))
 }
 
+func newTestBufferBucketForBudgetedMerge(t *testing.T, opts Options) (*BufferBucket, []value) {
+	rops := opts.RetentionOptions()
+	curr := time.Now().Truncate(rops.BlockSize())
+
+	// Interleave enough datapoints across two encoders that the merge
+	// checks the budget several times before completing.
+	bd := blockData{
+		start:     curr,
+		writeType: WarmWrite,
+		data:      make([][]value, 2),
+	}
+	for i := 1; i <= 3*mergeBudgetCheckInterval; i++ {
+		v := value{curr.Add(time.Duration(i) * 10 * time.Millisecond), float64(i), xtime.Millisecond, nil}
+		bd.data[i%2] = append(bd.data[i%2], v)
+	}
+
+	b, expected := newTestBufferBucketWithCustomData(t, bd, opts, nil)
+	for i := range b.encoders {
+		last := bd.data[i][len(bd.data[i])-1]
+		b.encoders[i].lastWriteAt = last.timestamp
+	}
+	return b, expected
+}
+
+func newTestMergeBudgetNowFn(start time.Time) func() time.Time {
+	now := start
+	return func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+}
+
+func requireBufferBucketValuesEqual(t *testing.T, b *BufferBucket, expected []value, opts Options) {
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	sr, ok, err := b.mergeToStream(ctx, namespace.Context{})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	requireReaderValuesEqual(t, expected, [][]xio.BlockReader{[]xio.BlockReader{
+		xio.BlockReader{
+			SegmentReader: sr,
+		},
+	}}, opts, namespace.Context{})
+}
+
+func TestBufferBucketMergeResumesAcrossBudgets(t *testing.T) {
+	opts := newBufferTestOptions()
+	b, expected := newTestBufferBucketForBudgetedMerge(t, opts)
+	nowFn := newTestMergeBudgetNowFn(b.start)
+
+	budget := NewMergeBudget(time.Millisecond, nowFn)
+	merges, pending, err := b.merge(budget, namespace.Context{})
+	require.NoError(t, err)
+	require.True(t, pending)
+	require.Equal(t, 0, merges)
+	require.True(t, budget.Exhausted())
+	require.NotNil(t, b.pendingMerge)
+	require.Equal(t, 2, len(b.encoders))
+
+	// An exhausted budget does not start merges of other buckets.
+	other, _ := newTestBufferBucketForBudgetedMerge(t, opts)
+	merges, pending, err = other.merge(budget, namespace.Context{})
+	require.NoError(t, err)
+	require.True(t, pending)
+	require.Equal(t, 0, merges)
+	require.Nil(t, other.pendingMerge)
+
+	// Writes to new encoders while the merge is pending are kept and merged
+	// once the pending merge completes.
+	written, err := b.write(b.start, 42, xtime.Millisecond, nil, nil)
+	require.NoError(t, err)
+	require.True(t, written)
+	require.Equal(t, 3, len(b.encoders))
+	require.True(t, b.pendingMergeValid())
+	expected = append([]value{{b.start, 42, xtime.Millisecond, nil}}, expected...)
+
+	ticks := 1
+	for pending {
+		budget = NewMergeBudget(time.Millisecond, nowFn)
+		merges, pending, err = b.merge(budget, namespace.Context{})
+		require.NoError(t, err)
+		ticks++
+	}
+	require.True(t, ticks > 2)
+	require.Equal(t, 2, merges)
+	require.Nil(t, b.pendingMerge)
+	require.Equal(t, 1, len(b.encoders))
+
+	requireBufferBucketValuesEqual(t, b, expected, opts)
+}
+
+func TestBufferBucketMergeRestartsAfterWriteToMergedEncoder(t *testing.T) {
+	opts := newBufferTestOptions()
+	b, expected := newTestBufferBucketForBudgetedMerge(t, opts)
+	nowFn := newTestMergeBudgetNowFn(b.start)
+
+	_, pending, err := b.merge(NewMergeBudget(time.Millisecond, nowFn), namespace.Context{})
+	require.NoError(t, err)
+	require.True(t, pending)
+
+	// Writing after the last datapoint appends to an encoder being merged
+	// which makes the partially merged encoder stale.
+	last := expected[len(expected)-1].timestamp.Add(time.Second)
+	written, err := b.write(last, 42, xtime.Millisecond, nil, nil)
+	require.NoError(t, err)
+	require.True(t, written)
+	require.False(t, b.pendingMergeValid())
+	expected = append(expected, value{last, 42, xtime.Millisecond, nil})
+
+	merges, pending, err := b.merge(nil, namespace.Context{})
+	require.NoError(t, err)
+	require.False(t, pending)
+	require.Equal(t, 2, merges)
+	require.Equal(t, 1, len(b.encoders))
+
+	requireBufferBucketValuesEqual(t, b, expected, opts)
+}
+
+func TestBufferBucketResetDiscardsPendingMerge(t *testing.T) {
+	opts := newBufferTestOptions()
+	b, _ := newTestBufferBucketForBudgetedMerge(t, opts)
+	nowFn := newTestMergeBudgetNowFn(b.start)
+
+	_, pending, err := b.merge(NewMergeBudget(time.Millisecond, nowFn), namespace.Context{})
+	require.NoError(t, err)
+	require.True(t, pending)
+	require.NotNil(t, b.pendingMerge)
+
+	b.reset()
+	require.Nil(t, b.pendingMerge)
+	require.Equal(t, 0, len(b.encoders))
+}
+
 func TestBufferBucketWriteDuplicateUpserts(t *testing.T) {
 	opts := newBufferTestOptions()
 	rops := opts.RetentionOptions()
@@ -830,7 +1072,7 @@ func TestBufferTickReordersOutOfOrderBuffers(t *testing.T) {
 		ColdVersion:     1,
 	}
 	// Perform a tick and ensure merged out of order blocks.
-	r := buffer.Tick(blockStates, namespace.Context{})
+	r := buffer.Tick(blockStates, nil, namespace.Context{})
 	assert.Equal(t, 1, r.mergedOutOfOrderBlocks)
 
 	// Check values correct.
@@ -910,7 +1152,7 @@ func TestBufferRemoveBucket(t *testing.T) {
 	// False because we just wrote to it.
 	assert.False(t, buffer.IsEmpty())
 	// Perform a tick to remove the bucket which has been flushed.
-	buffer.Tick(blockStates, namespace.Context{})
+	buffer.Tick(blockStates, nil, namespace.Context{})
 	// True because we just removed the bucket.
 	assert.True(t, buffer.IsEmpty())
 }
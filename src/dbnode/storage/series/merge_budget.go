@@ -0,0 +1,79 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package series
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+)
+
+// MergeBudget bounds the time that buffer bucket merges may spend during a
+// single tick, a nil budget is unlimited. Once the budget is exhausted merges
+// are left partially complete and are continued on the next tick.
+type MergeBudget struct {
+	remaining time.Duration
+	nowFn     clock.NowFn
+}
+
+// NewMergeBudget returns a merge budget of the given duration, a non-positive
+// duration returns an unlimited budget.
+func NewMergeBudget(budget time.Duration, nowFn clock.NowFn) *MergeBudget {
+	if budget <= 0 {
+		return nil
+	}
+	return &MergeBudget{
+		remaining: budget,
+		nowFn:     nowFn,
+	}
+}
+
+// NewExhaustedMergeBudget returns a merge budget that is already used up so
+// that any merges are left to a later tick.
+func NewExhaustedMergeBudget() *MergeBudget {
+	return &MergeBudget{nowFn: time.Now}
+}
+
+// Exhausted returns whether the budget has been used up.
+func (b *MergeBudget) Exhausted() bool {
+	return b != nil && b.remaining <= 0
+}
+
+func (b *MergeBudget) now() time.Time {
+	if b == nil {
+		return timeZero
+	}
+	return b.nowFn()
+}
+
+// exceeded returns whether merging since the given start has used up the
+// remaining budget.
+func (b *MergeBudget) exceeded(start time.Time) bool {
+	return b != nil && b.nowFn().Sub(start) >= b.remaining
+}
+
+// spend deducts the time spent merging since the given start.
+func (b *MergeBudget) spend(start time.Time) {
+	if b == nil {
+		return
+	}
+	b.remaining -= b.nowFn().Sub(start)
+}
@@ -149,6 +149,7 @@ func (r Reader) readersWithBlocksMapAndBuffer(
 					return nil, err
 				}
 				if streamedBlock.IsNotEmpty() {
+					streamedBlock.Source = xio.BlockSource{Type: xio.CacheBlockSource}
 					resultsBlock = append(resultsBlock, streamedBlock)
 					// NB(r): Mark this block as read now
 					block.SetLastReadTime(now)
@@ -259,6 +260,7 @@ func (r Reader) fetchBlocksWithBlocksMapAndBuffer(
 				}
 
 				if streamedBlock.IsNotEmpty() {
+					streamedBlock.Source = xio.BlockSource{Type: xio.CacheBlockSource}
 					blockReaders = append(blockReaders, streamedBlock)
 				}
 				retrievedFromDiskCache = true
@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/xio"
@@ -117,13 +118,18 @@ func (s *dbSeries) Tags() ident.Tags {
 	return tags
 }
 
-func (s *dbSeries) Tick(blockStates map[xtime.UnixNano]BlockState, nsCtx namespace.Context) (TickResult, error) {
+func (s *dbSeries) Tick(
+	blockStates map[xtime.UnixNano]BlockState,
+	mergeBudget *MergeBudget,
+	nsCtx namespace.Context,
+) (TickResult, error) {
 	var r TickResult
 
 	s.Lock()
 
-	bufferResult := s.buffer.Tick(blockStates, nsCtx)
+	bufferResult := s.buffer.Tick(blockStates, mergeBudget, nsCtx)
 	r.MergedOutOfOrderBlocks = bufferResult.mergedOutOfOrderBlocks
+	r.PartiallyMergedBlocks = bufferResult.partiallyMergedBlocks
 	r.EvictedBuckets = bufferResult.evictedBucketTimes.Len()
 	update, err := s.updateBlocksWithLock(blockStates, bufferResult.evictedBucketTimes)
 	if err != nil {
@@ -268,6 +274,13 @@ func (s *dbSeries) NumActiveBlocks() int {
 	return value
 }
 
+func (s *dbSeries) BufferMetadata() BufferMetadata {
+	s.RLock()
+	metadata := s.buffer.Metadata()
+	s.RUnlock()
+	return metadata
+}
+
 func (s *dbSeries) IsBootstrapped() bool {
 	s.RLock()
 	state := s.bs
@@ -434,6 +447,34 @@ func (s *dbSeries) Bootstrap(bootstrappedBlocks block.DatabaseSeriesBlocks) (Boo
 	return result, nil
 }
 
+func (s *dbSeries) Load(
+	blocks block.DatabaseSeriesBlocks,
+	blockStates map[xtime.UnixNano]BlockState,
+) (BootstrapResult, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	var result BootstrapResult
+	if s.bs != bootstrapped {
+		return result, errSeriesNotBootstrapped
+	}
+
+	if blocks == nil {
+		return result, nil
+	}
+
+	for _, block := range blocks.AllBlocks() {
+		writeType := WarmWrite
+		if blockStates[xtime.ToUnixNano(block.StartTime())].WarmRetrievable {
+			writeType = ColdWrite
+		}
+		s.buffer.Load(block, writeType)
+		result.NumBlocksMovedToBuffer++
+	}
+
+	return result, nil
+}
+
 func (s *dbSeries) OnRetrieveBlock(
 	id ident.ID,
 	tags ident.TagIterator,
@@ -573,6 +614,13 @@ func (s *dbSeries) ColdFlushBlockStarts(blockStates map[xtime.UnixNano]BlockStat
 	return s.buffer.ColdFlushBlockStarts(blockStates)
 }
 
+func (s *dbSeries) UpdateRetentionOptions(ropts retention.Options) {
+	s.Lock()
+	s.opts = s.opts.SetRetentionOptions(ropts)
+	s.buffer.SetRetentionOptions(ropts)
+	s.Unlock()
+}
+
 func (s *dbSeries) Close() {
 	s.Lock()
 	defer s.Unlock()
@@ -284,7 +284,7 @@ func TestSeriesTickEmptySeries(t *testing.T) {
 	series := NewDatabaseSeries(ident.StringID("foo"), ident.Tags{}, opts).(*dbSeries)
 	_, err := series.Bootstrap(nil)
 	assert.NoError(t, err)
-	_, err = series.Tick(nil, namespace.Context{})
+	_, err = series.Tick(nil, nil, namespace.Context{})
 	require.Equal(t, ErrSeriesAllDatapointsExpired, err)
 }
 
@@ -298,15 +298,55 @@ func TestSeriesTickDrainAndResetBuffer(t *testing.T) {
 	assert.NoError(t, err)
 	buffer := NewMockdatabaseBuffer(ctrl)
 	series.buffer = buffer
-	buffer.EXPECT().Tick(nil, gomock.Any()).Return(bufferTickResult{})
+	buffer.EXPECT().Tick(nil, nil, gomock.Any()).Return(bufferTickResult{})
 	buffer.EXPECT().Stats().Return(bufferStats{wiredBlocks: 1})
-	r, err := series.Tick(nil, namespace.Context{})
+	r, err := series.Tick(nil, nil, namespace.Context{})
 	require.NoError(t, err)
 	assert.Equal(t, 1, r.ActiveBlocks)
 	assert.Equal(t, 1, r.WiredBlocks)
 	assert.Equal(t, 0, r.UnwiredBlocks)
 }
 
+func TestSeriesLoad(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := newSeriesTestOptions()
+	series := NewDatabaseSeries(ident.StringID("foo"), ident.Tags{}, opts).(*dbSeries)
+
+	var (
+		blockSize    = opts.RetentionOptions().BlockSize()
+		flushedStart = time.Now().Truncate(blockSize).Add(-2 * blockSize)
+		bufferStart  = flushedStart.Add(blockSize)
+		blocks       = block.NewDatabaseSeriesBlocks(0)
+		flushedBlock = block.NewMockDatabaseBlock(ctrl)
+		bufferBlock  = block.NewMockDatabaseBlock(ctrl)
+		blockStates  = map[xtime.UnixNano]BlockState{
+			xtime.ToUnixNano(flushedStart): {WarmRetrievable: true},
+		}
+	)
+	flushedBlock.EXPECT().StartTime().Return(flushedStart).AnyTimes()
+	bufferBlock.EXPECT().StartTime().Return(bufferStart).AnyTimes()
+	blocks.AddBlock(flushedBlock)
+	blocks.AddBlock(bufferBlock)
+
+	// Only bootstrapped series can be loaded.
+	_, err := series.Load(blocks, blockStates)
+	require.Equal(t, errSeriesNotBootstrapped, err)
+
+	_, err = series.Bootstrap(nil)
+	require.NoError(t, err)
+
+	buffer := NewMockdatabaseBuffer(ctrl)
+	series.buffer = buffer
+	buffer.EXPECT().Load(flushedBlock, ColdWrite)
+	buffer.EXPECT().Load(bufferBlock, WarmWrite)
+
+	result, err := series.Load(blocks, blockStates)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.NumBlocksMovedToBuffer)
+}
+
 func TestSeriesTickNeedsBlockExpiry(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -333,7 +373,7 @@ func TestSeriesTickNeedsBlockExpiry(t *testing.T) {
 	require.Equal(t, 2, series.cachedBlocks.Len())
 	buffer := NewMockdatabaseBuffer(ctrl)
 	series.buffer = buffer
-	buffer.EXPECT().Tick(gomock.Any(), gomock.Any()).Return(bufferTickResult{})
+	buffer.EXPECT().Tick(gomock.Any(), gomock.Any(), gomock.Any()).Return(bufferTickResult{})
 	buffer.EXPECT().Stats().Return(bufferStats{wiredBlocks: 1})
 	blockStates := make(map[xtime.UnixNano]BlockState)
 	blockStates[xtime.ToUnixNano(blockStart)] = BlockState{
@@ -344,7 +384,7 @@ func TestSeriesTickNeedsBlockExpiry(t *testing.T) {
 		WarmRetrievable: false,
 		ColdVersion:     0,
 	}
-	r, err := series.Tick(blockStates, namespace.Context{})
+	r, err := series.Tick(blockStates, nil, namespace.Context{})
 	require.NoError(t, err)
 	require.Equal(t, 2, r.ActiveBlocks)
 	require.Equal(t, 2, r.WiredBlocks)
@@ -387,7 +427,7 @@ func TestSeriesTickRecentlyRead(t *testing.T) {
 		WarmRetrievable: true,
 		ColdVersion:     1,
 	}
-	tickResult, err := series.Tick(blockStates, namespace.Context{})
+	tickResult, err := series.Tick(blockStates, nil, namespace.Context{})
 	require.NoError(t, err)
 	require.Equal(t, 0, tickResult.UnwiredBlocks)
 	require.Equal(t, 1, tickResult.PendingMergeBlocks)
@@ -400,7 +440,7 @@ func TestSeriesTickRecentlyRead(t *testing.T) {
 	b.EXPECT().Close().Return()
 	series.cachedBlocks.AddBlock(b)
 
-	tickResult, err = series.Tick(blockStates, namespace.Context{})
+	tickResult, err = series.Tick(blockStates, nil, namespace.Context{})
 	require.NoError(t, err)
 	require.Equal(t, 1, tickResult.UnwiredBlocks)
 	require.Equal(t, 0, tickResult.PendingMergeBlocks)
@@ -416,7 +456,7 @@ func TestSeriesTickRecentlyRead(t *testing.T) {
 		WarmRetrievable: false,
 		ColdVersion:     0,
 	}
-	tickResult, err = series.Tick(blockStates, namespace.Context{})
+	tickResult, err = series.Tick(blockStates, nil, namespace.Context{})
 	require.NoError(t, err)
 	require.Equal(t, 0, tickResult.UnwiredBlocks)
 	require.Equal(t, 1, tickResult.PendingMergeBlocks)
@@ -454,7 +494,7 @@ func TestSeriesTickCacheLRU(t *testing.T) {
 		WarmRetrievable: true,
 		ColdVersion:     1,
 	}
-	tickResult, err := series.Tick(blockStates, namespace.Context{})
+	tickResult, err := series.Tick(blockStates, nil, namespace.Context{})
 	require.NoError(t, err)
 	require.Equal(t, 1, tickResult.UnwiredBlocks)
 	require.Equal(t, 0, tickResult.PendingMergeBlocks)
@@ -466,7 +506,7 @@ func TestSeriesTickCacheLRU(t *testing.T) {
 	b.EXPECT().WasRetrievedFromDisk().Return(true)
 	series.cachedBlocks.AddBlock(b)
 
-	tickResult, err = series.Tick(blockStates, namespace.Context{})
+	tickResult, err = series.Tick(blockStates, nil, namespace.Context{})
 	require.NoError(t, err)
 	require.Equal(t, 0, tickResult.UnwiredBlocks)
 	require.Equal(t, 1, tickResult.PendingMergeBlocks)
@@ -490,7 +530,7 @@ func TestSeriesTickCacheLRU(t *testing.T) {
 		WarmRetrievable: false,
 		ColdVersion:     0,
 	}
-	tickResult, err = series.Tick(blockStates, namespace.Context{})
+	tickResult, err = series.Tick(blockStates, nil, namespace.Context{})
 	require.NoError(t, err)
 	require.Equal(t, 0, tickResult.UnwiredBlocks)
 	require.Equal(t, 1, tickResult.PendingMergeBlocks)
@@ -528,7 +568,7 @@ func TestSeriesTickCacheNone(t *testing.T) {
 		WarmRetrievable: true,
 		ColdVersion:     1,
 	}
-	tickResult, err := series.Tick(blockStates, namespace.Context{})
+	tickResult, err := series.Tick(blockStates, nil, namespace.Context{})
 	require.NoError(t, err)
 	require.Equal(t, 1, tickResult.UnwiredBlocks)
 	require.Equal(t, 0, tickResult.PendingMergeBlocks)
@@ -544,7 +584,7 @@ func TestSeriesTickCacheNone(t *testing.T) {
 		WarmRetrievable: false,
 		ColdVersion:     0,
 	}
-	tickResult, err = series.Tick(blockStates, namespace.Context{})
+	tickResult, err = series.Tick(blockStates, nil, namespace.Context{})
 	require.NoError(t, err)
 	require.Equal(t, 0, tickResult.UnwiredBlocks)
 	require.Equal(t, 1, tickResult.PendingMergeBlocks)
@@ -583,7 +623,7 @@ func TestSeriesTickCachedBlockRemove(t *testing.T) {
 			wiredBlocks: 0,
 		})
 	buffer.EXPECT().
-		Tick(gomock.Any(), gomock.Any()).
+		Tick(gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(bufferTickResult{
 			// This means that (curr - 1 block) and (curr - 2 blocks) should
 			// be removed after the tick.
@@ -599,7 +639,7 @@ func TestSeriesTickCachedBlockRemove(t *testing.T) {
 
 	assert.Equal(t, 3, series.cachedBlocks.Len())
 	blockStates := make(map[xtime.UnixNano]BlockState)
-	_, err := series.Tick(blockStates, namespace.Context{})
+	_, err := series.Tick(blockStates, nil, namespace.Context{})
 	require.NoError(t, err)
 	assert.Equal(t, 1, series.cachedBlocks.Len())
 }
@@ -49,8 +49,13 @@ type DatabaseSeries interface {
 	// Tags return the tags of the series.
 	Tags() ident.Tags
 
-	// Tick executes async updates
-	Tick(blockStates map[xtime.UnixNano]BlockState, nsCtx namespace.Context) (TickResult, error)
+	// Tick executes async updates, buffer merges stop once the merge budget
+	// is exhausted and are continued on the next tick.
+	Tick(
+		blockStates map[xtime.UnixNano]BlockState,
+		mergeBudget *MergeBudget,
+		nsCtx namespace.Context,
+	) (TickResult, error)
 
 	// Write writes a new value.
 	Write(
@@ -99,12 +104,24 @@ type DatabaseSeries interface {
 	// NumActiveBlocks returns the number of active blocks the series currently holds.
 	NumActiveBlocks() int
 
+	// BufferMetadata returns the metadata of the buffer of the series.
+	BufferMetadata() BufferMetadata
+
 	// IsBootstrapped returns whether the series is bootstrapped or not.
 	IsBootstrapped() bool
 
 	// Bootstrap merges the raw series bootstrapped along with any buffered data.
 	Bootstrap(blocks block.DatabaseSeriesBlocks) (BootstrapResult, error)
 
+	// Load merges the raw series re-bootstrapped for a series that is already
+	// bootstrapped along with any buffered data, the blocks of block starts
+	// that are already warm flushed are loaded as cold writes so that they
+	// are persisted by the next cold flush.
+	Load(
+		blocks block.DatabaseSeriesBlocks,
+		blockStates map[xtime.UnixNano]BlockState,
+	) (BootstrapResult, error)
+
 	// WarmFlush flushes the WarmWrites of this series for a given start time.
 	WarmFlush(
 		ctx context.Context,
@@ -125,6 +142,11 @@ type DatabaseSeries interface {
 	// ColdFlushBlockStarts returns the block starts that need cold flushes.
 	ColdFlushBlockStarts(blockStates map[xtime.UnixNano]BlockState) OptimizedTimes
 
+	// UpdateRetentionOptions applies updated retention options to the series,
+	// adjusting the buffer windows and the retention period used to expire
+	// blocks. The block size must remain unchanged.
+	UpdateRetentionOptions(ropts retention.Options)
+
 	// Close will close the series and if pooled returned to the pool.
 	Close()
 
@@ -199,6 +221,9 @@ type TickResult struct {
 	MadeUnwiredBlocks int
 	// MergedOutOfOrderBlocks is count of blocks merged from out of order streams.
 	MergedOutOfOrderBlocks int
+	// PartiallyMergedBlocks is count of blocks with merges left pending
+	// because the merge budget was exhausted.
+	PartiallyMergedBlocks int
 	// EvictedBuckets is count of buckets just evicted from the buffer map.
 	EvictedBuckets int
 }
@@ -376,6 +401,18 @@ type WriteTransformOptions struct {
 	ForceValue float64
 }
 
+// BufferMetadata is the metadata of the buffer of a series.
+type BufferMetadata struct {
+	// FirstWrite and LastWrite are the earliest and latest timestamps written
+	// to the series since it was loaded in memory, zero if none were written.
+	FirstWrite time.Time
+	LastWrite  time.Time
+	// BlockStarts are the block starts with data in the buffer, including
+	// data that has been flushed but not yet evicted, ordered from the
+	// earliest.
+	BlockStarts []time.Time
+}
+
 // WriteOptions provides a set of options for a write.
 type WriteOptions struct {
 	// SchemaDesc is the schema description.
@@ -384,4 +421,11 @@ type WriteOptions struct {
 	TruncateType TruncateType
 	// TransformOptions describes transformation options for incoming writes.
 	TransformOptions WriteTransformOptions
+	// StrictlyIncreasing rejects writes whose timestamp is not after the last
+	// timestamp written to the series, so that series are append only rather
+	// than upserted. The last timestamp is only tracked in memory on this node
+	// since the series was created: it is not seeded from bootstrapped or
+	// flushed data, so the first write after a restart or after the series was
+	// evicted from memory is always accepted, as are writes to other replicas.
+	StrictlyIncreasing bool
 }
@@ -0,0 +1,51 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package series
+
+import (
+	stdctx "context"
+
+	"github.com/m3db/m3/src/x/context"
+)
+
+type strictlyIncreasingKey struct{}
+
+// SetStrictlyIncreasingWrites marks the writes made with the context as
+// strictly increasing, see WriteOptions.StrictlyIncreasing. The context is
+// per request so the option only applies to the writes of that request.
+func SetStrictlyIncreasingWrites(ctx context.Context) {
+	goCtx, ok := ctx.GoContext()
+	if !ok {
+		goCtx = stdctx.Background()
+	}
+	ctx.SetGoContext(stdctx.WithValue(goCtx, strictlyIncreasingKey{}, true))
+}
+
+// StrictlyIncreasingWrites returns whether the writes made with the context
+// were marked as strictly increasing.
+func StrictlyIncreasingWrites(ctx context.Context) bool {
+	goCtx, ok := ctx.GoContext()
+	if !ok {
+		return false
+	}
+	value, ok := goCtx.Value(strictlyIncreasingKey{}).(bool)
+	return ok && value
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage/series"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+// SeriesMetadata consolidates where the data of a series lives, in memory
+// and on disk, to help debug missing data.
+type SeriesMetadata struct {
+	ID    ident.ID
+	Shard uint32
+	// InMemory is whether the series is currently held in memory by the
+	// shard, the tags and writes are only known if it is.
+	InMemory bool
+	Tags     ident.Tags
+	// FirstWrite and LastWrite are the earliest and latest timestamps written
+	// to the series since it was loaded in memory, zero if none were written.
+	FirstWrite time.Time
+	LastWrite  time.Time
+	// Blocks are the blocks with data of the series, in memory or on disk,
+	// ordered by block start.
+	Blocks []SeriesBlockMetadata
+}
+
+// SeriesBlockMetadata is the metadata of a block of a series.
+type SeriesBlockMetadata struct {
+	BlockStart time.Time
+	// Buffered is whether the series holds data of the block in memory.
+	Buffered bool
+	// WarmFlushed and ColdVersion are the flush state of the block of the
+	// shard of the series.
+	WarmFlushed bool
+	ColdVersion int
+	// Volumes are the volume indexes of the complete filesets of the block
+	// that contain the series, in ascending order.
+	Volumes []int
+}
+
+// newSeriesMetadata merges the buffer metadata of a series with the flush
+// states of its shard and the fileset volumes containing it, flush states of
+// blocks without data of the series are omitted.
+func newSeriesMetadata(
+	id ident.ID,
+	shard uint32,
+	inMemory bool,
+	tags ident.Tags,
+	buffer series.BufferMetadata,
+	flushStates []BlockFlushState,
+	volumes map[xtime.UnixNano][]int,
+) SeriesMetadata {
+	var (
+		result = SeriesMetadata{
+			ID:         id,
+			Shard:      shard,
+			InMemory:   inMemory,
+			Tags:       tags,
+			FirstWrite: buffer.FirstWrite,
+			LastWrite:  buffer.LastWrite,
+		}
+		blocks = make(map[xtime.UnixNano]*SeriesBlockMetadata)
+	)
+	blockFor := func(blockStart xtime.UnixNano) *SeriesBlockMetadata {
+		block, ok := blocks[blockStart]
+		if !ok {
+			block = &SeriesBlockMetadata{BlockStart: blockStart.ToTime()}
+			blocks[blockStart] = block
+		}
+		return block
+	}
+
+	for _, blockStart := range buffer.BlockStarts {
+		blockFor(xtime.ToUnixNano(blockStart)).Buffered = true
+	}
+	for blockStart, blockVolumes := range volumes {
+		block := blockFor(blockStart)
+		block.Volumes = append(block.Volumes, blockVolumes...)
+		sort.Ints(block.Volumes)
+	}
+	for _, state := range flushStates {
+		block, ok := blocks[xtime.ToUnixNano(state.BlockStart)]
+		if !ok {
+			continue
+		}
+		block.WarmFlushed = state.WarmFlushed
+		block.ColdVersion = state.ColdVersion
+	}
+
+	result.Blocks = make([]SeriesBlockMetadata, 0, len(blocks))
+	for _, block := range blocks {
+		result.Blocks = append(result.Blocks, *block)
+	}
+	sort.Slice(result.Blocks, func(i, j int) bool {
+		return result.Blocks[i].BlockStart.Before(result.Blocks[j].BlockStart)
+	})
+	return result
+}
+
+// copySeriesTags copies tags so that they outlive the series they belong to.
+func copySeriesTags(tags ident.Tags) ident.Tags {
+	values := tags.Values()
+	result := make([]ident.Tag, 0, len(values))
+	for _, tag := range values {
+		result = append(result, ident.Tag{
+			Name:  ident.BytesID(append([]byte(nil), tag.Name.Bytes()...)),
+			Value: ident.BytesID(append([]byte(nil), tag.Value.Bytes()...)),
+		})
+	}
+	return ident.NewTags(result...)
+}
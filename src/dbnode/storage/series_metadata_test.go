@@ -0,0 +1,80 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage/series"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSeriesMetadata(t *testing.T) {
+	var (
+		t0   = time.Unix(0, 0)
+		t1   = t0.Add(2 * time.Hour)
+		t2   = t1.Add(2 * time.Hour)
+		t3   = t2.Add(2 * time.Hour)
+		id   = ident.StringID("foo")
+		tags = ident.NewTags(ident.StringTag("city", "nyc"))
+	)
+	metadata := newSeriesMetadata(id, 3, true, tags,
+		series.BufferMetadata{
+			FirstWrite:  t1.Add(time.Minute),
+			LastWrite:   t2.Add(time.Minute),
+			BlockStarts: []time.Time{t1, t2},
+		},
+		[]BlockFlushState{
+			{Shard: 3, BlockStart: t0, WarmFlushed: true, ColdVersion: 2},
+			{Shard: 3, BlockStart: t1, WarmFlushed: true},
+			{Shard: 3, BlockStart: t3, WarmFlushed: true},
+		},
+		map[xtime.UnixNano][]int{
+			xtime.ToUnixNano(t0): {2, 0},
+			xtime.ToUnixNano(t1): {0},
+		})
+
+	require.Equal(t, SeriesMetadata{
+		ID:         id,
+		Shard:      3,
+		InMemory:   true,
+		Tags:       tags,
+		FirstWrite: t1.Add(time.Minute),
+		LastWrite:  t2.Add(time.Minute),
+		Blocks: []SeriesBlockMetadata{
+			{BlockStart: t0, WarmFlushed: true, ColdVersion: 2, Volumes: []int{0, 2}},
+			{BlockStart: t1, Buffered: true, WarmFlushed: true, Volumes: []int{0}},
+			{BlockStart: t2, Buffered: true},
+		},
+	}, metadata)
+}
+
+func TestCopySeriesTags(t *testing.T) {
+	tags := ident.NewTags(ident.StringTag("city", "nyc"), ident.StringTag("host", "a"))
+	copied := copySeriesTags(tags)
+	require.True(t, tags.Equal(copied))
+
+	tags.Values()[0].Value.Bytes()[0] = 'l'
+	require.Equal(t, "nyc", copied.Values()[0].Value.String())
+}
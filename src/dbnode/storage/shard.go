@@ -27,6 +27,7 @@ import (
 	"io"
 	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
@@ -37,6 +38,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/storage/block"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/index/convert"
@@ -71,6 +73,13 @@ var (
 	errShardInvalidPageToken               = errors.New("shard could not unmarshal page token")
 	errNewShardEntryTagsTypeInvalid        = errors.New("new shard entry options error: tags type invalid")
 	errNewShardEntryTagsIterNotAtIndexZero = errors.New("new shard entry options error: tags iter not at index zero")
+	errShardNotBootstrappedToImport        = errors.New("shard is not yet bootstrapped to import")
+	errShardImportBlockNotFlushed          = errors.New("shard block is not yet flushed to import a volume for")
+	errShardImportVolumeNotNewer           = errors.New("shard import volume is not newer than the retrievable volume")
+	errShardNotBootstrappedToDelete        = errors.New("shard is not yet bootstrapped to delete series")
+	errShardNotBootstrappedToRebootstrap   = errors.New("shard is not yet bootstrapped to rebootstrap")
+	errShardRebootstrapRangeEmpty          = errors.New("shard rebootstrap time range is empty")
+	errShardRebootstrapColdWritesDisabled  = errors.New("shard cannot rebootstrap flushed blocks with cold writes disabled")
 )
 
 type filesetsFn func(
@@ -137,9 +146,15 @@ func newTagsArg(
 }
 
 type dbShard struct {
+	// numWrites and numPendingColdWrites are accessed atomically and kept
+	// first for 64-bit alignment.
+	numWrites            int64
+	numPendingColdWrites int64
+
 	sync.RWMutex
 	block.DatabaseBlockRetriever
 	opts                     Options
+	seriesOptsLock           sync.RWMutex
 	seriesOpts               series.Options
 	nowFn                    clock.NowFn
 	state                    dbShardState
@@ -164,6 +179,12 @@ type dbShard struct {
 	identifierPool           ident.Pool
 	contextPool              context.Pool
 	flushState               shardFlushState
+	tombstones               *shardTombstones
+	flushPauses              *backgroundTaskPauses
+	hotSeries                *shardHotSeries
+	cardinality              *shardCardinality
+	seriesExpiry             *shardSeriesExpiry
+	schemaMigrations         map[xtime.UnixNano]struct{}
 	tickWg                   *sync.WaitGroup
 	runtimeOptsListenClosers []xclose.SimpleCloser
 	currRuntimeOptions       dbShardRuntimeOptions
@@ -171,7 +192,11 @@ type dbShard struct {
 	metrics                  dbShardMetrics
 	newSeriesBootstrapped    bool
 	ticking                  bool
-	shard                    uint32
+	// tickMergeStart is the position in the series list from which the
+	// next tick spends its buffer merge budget, it is only accessed by the
+	// tick.
+	tickMergeStart int
+	shard          uint32
 }
 
 // NB(r): dbShardRuntimeOptions does not contain its own
@@ -183,6 +208,7 @@ type dbShardRuntimeOptions struct {
 	writeNewSeriesAsync      bool
 	tickSleepSeriesBatchSize int
 	tickSleepPerSeries       time.Duration
+	tickBufferMergeBudget    time.Duration
 }
 
 type dbShardMetrics struct {
@@ -193,6 +219,11 @@ type dbShardMetrics struct {
 	insertAsyncInsertErrors       tally.Counter
 	insertAsyncBootstrapErrors    tally.Counter
 	insertAsyncWriteErrors        tally.Counter
+	annotationFieldsErrors        tally.Counter
+	seriesDeleted                 tally.Counter
+	seriesPurged                  tally.Counter
+	seriesIndexExpired            tally.Counter
+	blocksSchemaMigrated          tally.Counter
 	seriesBootstrapBlocksToBuffer tally.Counter
 	seriesBootstrapBlocksMerged   tally.Counter
 	seriesTicked                  tally.Gauge
@@ -214,6 +245,11 @@ func newDatabaseShardMetrics(shardID uint32, scope tally.Scope) dbShardMetrics {
 		insertAsyncWriteErrors: scope.Tagged(map[string]string{
 			"error_type": "write-value",
 		}).Counter("insert-async.errors"),
+		annotationFieldsErrors:        scope.Counter("annotation-fields.errors"),
+		seriesDeleted:                 scope.Counter("series-deleted"),
+		seriesPurged:                  scope.Counter("series-purged"),
+		seriesIndexExpired:            scope.Counter("series-index-expired"),
+		blocksSchemaMigrated:          scope.Counter("blocks-schema-migrated"),
 		seriesBootstrapBlocksToBuffer: seriesBootstrapScope.Counter("blocks-to-buffer"),
 		seriesBootstrapBlocksMerged:   seriesBootstrapScope.Counter("blocks-merged"),
 		seriesTicked: scope.Tagged(map[string]string{
@@ -276,12 +312,29 @@ func newDatabaseShard(
 		identifierPool:       opts.IdentifierPool(),
 		contextPool:          opts.ContextPool(),
 		flushState:           newShardFlushState(),
+		flushPauses:          newBackgroundTaskPauses(opts.ClockOptions().NowFn()),
+		schemaMigrations:     make(map[xtime.UnixNano]struct{}),
 		tickWg:               &sync.WaitGroup{},
 		logger:               opts.InstrumentOptions().Logger(),
 		metrics:              newDatabaseShardMetrics(shard, scope),
 	}
 	s.insertQueue = newDatabaseShardInsertQueue(s.insertSeriesBatch,
 		s.nowFn, scope)
+	s.hotSeries = newShardHotSeries(shard, opts.HotSeriesOptions(), s.nowFn, scope)
+	s.cardinality = newShardCardinality(shard, opts.CardinalityOptions(), scope)
+	if reverseIndex != nil {
+		s.seriesExpiry = newShardSeriesExpiry()
+	}
+
+	s.tombstones = newShardTombstones(opts.CommitLogOptions().FilesystemOptions(),
+		namespaceMetadata.ID(), shard,
+		namespaceMetadata.Options().RetentionOptions().BlockSize())
+	if err := s.tombstones.Load(); err != nil {
+		s.logger.Error("unable to load shard tombstones, deleted series may be returned",
+			zap.Stringer("namespace", namespaceMetadata.ID()),
+			zap.Uint32("shard", shard),
+			zap.Error(err))
+	}
 
 	registerRuntimeOptionsListener := func(listener runtime.OptionsListener) {
 		elem := opts.RuntimeOptionsManager().RegisterListener(listener)
@@ -323,6 +376,7 @@ func (s *dbShard) SetRuntimeOptions(value runtime.Options) {
 		writeNewSeriesAsync:      value.WriteNewSeriesAsync(),
 		tickSleepSeriesBatchSize: value.TickSeriesBatchSize(),
 		tickSleepPerSeries:       value.TickPerSeriesSleepDuration(),
+		tickBufferMergeBudget:    value.TickBufferMergeBudget(),
 	}
 	s.Unlock()
 }
@@ -338,6 +392,38 @@ func (s *dbShard) NumSeries() int64 {
 	return int64(n)
 }
 
+func (s *dbShard) NumWrites() int64 {
+	return atomic.LoadInt64(&s.numWrites)
+}
+
+func (s *dbShard) NumPendingColdWrites() int64 {
+	return atomic.LoadInt64(&s.numPendingColdWrites)
+}
+
+func (s *dbShard) PauseFlush(task BackgroundTask, until time.Time) {
+	s.flushPauses.Pause(task, until)
+}
+
+func (s *dbShard) ResumeFlush(task BackgroundTask) {
+	s.flushPauses.Resume(task)
+}
+
+func (s *dbShard) IsFlushPaused(task BackgroundTask) bool {
+	return s.flushPauses.IsPaused(task)
+}
+
+func (s *dbShard) PausedFlushes() map[BackgroundTask]time.Time {
+	return s.flushPauses.Paused()
+}
+
+func (s *dbShard) HotSeries(limit int) ([]HotSeries, []HotSeries) {
+	return s.hotSeries.Top(limit)
+}
+
+func (s *dbShard) BlockCardinalities(start, end time.Time) BlockCardinalities {
+	return s.cardinality.Cardinalities(start, end)
+}
+
 // Stream implements series.QueryableBlockRetriever
 func (s *dbShard) Stream(
 	ctx context.Context,
@@ -346,7 +432,12 @@ func (s *dbShard) Stream(
 	onRetrieve block.OnRetrieveBlock,
 	nsCtx namespace.Context,
 ) (xio.BlockReader, error) {
-	return s.DatabaseBlockRetriever.Stream(ctx, s.shard, id, blockStart, onRetrieve, nsCtx)
+	reader, err := s.DatabaseBlockRetriever.Stream(ctx, s.shard, id, blockStart, onRetrieve, nsCtx)
+	if err != nil {
+		return reader, err
+	}
+	reader.Source = xio.NewDiskBlockSource(s.RetrievableBlockColdVersion(blockStart))
+	return reader, nil
 }
 
 // IsBlockRetrievable implements series.QueryableBlockRetriever
@@ -614,7 +705,44 @@ func (s *dbShard) isClosingWithLock() bool {
 
 func (s *dbShard) Tick(c context.Cancellable, tickStart time.Time, nsCtx namespace.Context) (tickResult, error) {
 	s.removeAnyFlushStatesTooEarly(tickStart)
-	return s.tickAndExpire(c, tickPolicyRegular, nsCtx)
+	s.hotSeries.Tick()
+	s.tickCardinality(tickStart)
+	result, err := s.tickAndExpire(c, tickPolicyRegular, nsCtx)
+	if err != nil {
+		return result, err
+	}
+	s.tickSeriesExpiry(tickStart)
+	return result, nil
+}
+
+// tickSeriesExpiry expires the series purged from memory whose last write
+// has fallen out of retention from the index blocks.
+func (s *dbShard) tickSeriesExpiry(tickStart time.Time) {
+	if s.seriesExpiry == nil {
+		return
+	}
+
+	ropts := s.currSeriesOpts().RetentionOptions()
+	expired := s.seriesExpiry.Expire(retention.FlushTimeStart(ropts, tickStart),
+		s.isInMemory)
+	if len(expired) == 0 {
+		return
+	}
+	if err := s.reverseIndex.MarkExpired(expired); err != nil {
+		s.logger.Error("unable to expire series from index, empty series may be returned",
+			zap.Uint32("shard", s.shard),
+			zap.Int("numSeries", len(expired)),
+			zap.Error(err))
+		return
+	}
+	s.metrics.seriesIndexExpired.Inc(int64(len(expired)))
+}
+
+func (s *dbShard) isInMemory(id ident.ID) bool {
+	s.RLock()
+	_, exists := s.lookup.Get(id)
+	s.RUnlock()
+	return exists
 }
 
 func (s *dbShard) tickAndExpire(
@@ -655,6 +783,7 @@ func (s *dbShard) tickAndExpire(
 	var (
 		r                             tickResult
 		terminatedTickingDueToClosing bool
+		cancelled                     bool
 		i                             int
 		slept                         time.Duration
 		expired                       []*lookup.Entry
@@ -662,10 +791,24 @@ func (s *dbShard) tickAndExpire(
 	s.RLock()
 	tickSleepBatch := s.currRuntimeOptions.tickSleepSeriesBatchSize
 	tickSleepPerSeries := s.currRuntimeOptions.tickSleepPerSeries
+	tickBufferMergeBudget := s.currRuntimeOptions.tickBufferMergeBudget
 	// Acquire snapshot of block states here to avoid releasing the
 	// RLock and acquiring it right after.
 	blockStates := s.BlockStatesSnapshot()
 	s.RUnlock()
+	// Bound the time spent merging series buffers so that large merges do
+	// not stall the tick, unfinished merges continue on the next tick. The
+	// budget is spent from where the previous tick exhausted it so that the
+	// series towards the end of the list are not starved of merges.
+	var (
+		mergeBudget          = series.NewMergeBudget(tickBufferMergeBudget, s.nowFn)
+		exhaustedMergeBudget = series.NewExhaustedMergeBudget()
+		mergeStart           = 0
+		mergeExhaustedAt     = -1
+	)
+	if mergeBudget != nil {
+		mergeStart = s.tickMergeStart
+	}
 	s.forEachShardEntryBatch(func(currEntries []*lookup.Entry) bool {
 		// re-using `expired` to amortize allocs, still need to reset it
 		// to be safe for re-use.
@@ -679,6 +822,7 @@ func (s *dbShard) tickAndExpire(
 				// The cancellation check is performed on every batch of entries
 				// instead of every entry to reduce load.
 				if c.IsCancelled() {
+					cancelled = true
 					return false
 				}
 				// NB(prateek): Also bail out early if the shard is closing,
@@ -702,7 +846,14 @@ func (s *dbShard) tickAndExpire(
 			)
 			switch policy {
 			case tickPolicyRegular:
-				result, err = entry.Series.Tick(blockStates, nsCtx)
+				budget := mergeBudget
+				if i < mergeStart {
+					budget = exhaustedMergeBudget
+				}
+				result, err = entry.Series.Tick(blockStates, budget, nsCtx)
+				if mergeExhaustedAt < 0 && mergeBudget.Exhausted() {
+					mergeExhaustedAt = i
+				}
 			case tickPolicyCloseShard:
 				err = series.ErrSeriesAllDatapointsExpired
 			}
@@ -722,6 +873,7 @@ func (s *dbShard) tickAndExpire(
 			r.madeExpiredBlocks += result.MadeExpiredBlocks
 			r.madeUnwiredBlocks += result.MadeUnwiredBlocks
 			r.mergedOutOfOrderBlocks += result.MergedOutOfOrderBlocks
+			r.partiallyMergedBlocks += result.PartiallyMergedBlocks
 			r.evictedBuckets += result.EvictedBuckets
 			i++
 		}
@@ -742,6 +894,15 @@ func (s *dbShard) tickAndExpire(
 		return tickResult{}, errShardClosingTickTerminated
 	}
 
+	// The next tick starts from the series that exhausted the budget, or
+	// from the start of the list once every series has been merged.
+	switch {
+	case mergeExhaustedAt >= 0:
+		s.tickMergeStart = mergeExhaustedAt
+	case !cancelled:
+		s.tickMergeStart = 0
+	}
+
 	return r, nil
 }
 
@@ -751,6 +912,11 @@ func (s *dbShard) tickAndExpire(
 // call. This satisfies the contract of all entries it operating upon being guaranteed to have a
 // readerWriterEntryCount of at least 1, by virtue of the implementation of `forEachShardEntryBatch`.
 func (s *dbShard) purgeExpiredSeries(expiredEntries []*lookup.Entry) {
+	var (
+		blockSize  = s.currSeriesOpts().RetentionOptions().BlockSize()
+		purgedIDs  []ident.ID
+		lastWrites []time.Time
+	)
 	// Remove all expired series from lookup and list.
 	s.Lock()
 	for _, entry := range expiredEntries {
@@ -783,11 +949,23 @@ func (s *dbShard) purgeExpiredSeries(expiredEntries []*lookup.Entry) {
 		// NB(xichen): if we get here, we are guaranteed that there can be
 		// no more reads/writes to this series while the lock is held, so it's
 		// safe to remove it.
+		if s.seriesExpiry != nil {
+			if lastWrite := series.BufferMetadata().LastWrite; !lastWrite.IsZero() {
+				purgedIDs = append(purgedIDs, ident.BytesID(append([]byte(nil), id.Bytes()...)))
+				lastWrites = append(lastWrites, lastWrite)
+			}
+		}
 		series.Close()
 		s.list.Remove(elem)
 		s.lookup.Delete(id)
 	}
 	s.Unlock()
+
+	// NB: Track the purged series outside of the shard lock since expiring
+	// them acquires the shard lock while holding the expiry lock.
+	for i, id := range purgedIDs {
+		s.seriesExpiry.Track(id, lastWrites[i].Truncate(blockSize))
+	}
 }
 
 func (s *dbShard) WriteTagged(
@@ -828,6 +1006,10 @@ func (s *dbShard) writeAndIndex(
 	wOpts series.WriteOptions,
 	shouldReverseIndex bool,
 ) (ts.Series, bool, error) {
+	// Capture whether the write is tagged as shouldReverseIndex is cleared
+	// once the series is indexed.
+	tagged := shouldReverseIndex
+
 	// Prepare write
 	entry, opts, err := s.tryRetrieveWritableSeries(id)
 	if err != nil {
@@ -835,6 +1017,16 @@ func (s *dbShard) writeAndIndex(
 	}
 
 	writable := entry != nil
+	if !writable {
+		// A deleted series written to again only has the data written
+		// before it was deleted hidden from then on.
+		if err := s.tombstones.MarkRewritten(id.Bytes()); err != nil {
+			return ts.Series{}, false, err
+		}
+	}
+	if !writable && shouldReverseIndex {
+		tags = s.tagsWithAnnotationFields(tags, annotation, wOpts)
+	}
 
 	// If no entry and we are not writing new series asynchronously.
 	if !writable && !opts.writeNewSeriesAsync {
@@ -947,9 +1139,86 @@ func (s *dbShard) writeAndIndex(
 		Shard:       s.shard,
 	}
 
+	if wasWritten {
+		atomic.AddInt64(&s.numWrites, 1)
+		if s.isColdWrite(timestamp) {
+			atomic.AddInt64(&s.numPendingColdWrites, 1)
+		}
+		s.hotSeries.RecordWrite(id)
+		s.recordCardinality(id, timestamp, tagged)
+	}
+
 	return series, wasWritten, nil
 }
 
+// recordCardinality records the write of the series in the cardinality
+// estimates of its data block and, for tagged writes, of its index block.
+func (s *dbShard) recordCardinality(id ident.ID, timestamp time.Time, tagged bool) {
+	if s.cardinality == nil {
+		return
+	}
+
+	var (
+		blockSize       = s.namespace.Options().RetentionOptions().BlockSize()
+		indexed         = tagged && s.reverseIndex != nil
+		indexBlockStart time.Time
+	)
+	if indexed {
+		indexBlockStart = s.reverseIndex.BlockStartForWriteTime(timestamp).ToTime()
+	}
+	s.cardinality.RecordWrite(id, timestamp.Truncate(blockSize), indexed, indexBlockStart)
+}
+
+// tickCardinality expires the cardinality estimates of the blocks that are
+// no longer retained.
+func (s *dbShard) tickCardinality(tickStart time.Time) {
+	if s.cardinality == nil {
+		return
+	}
+
+	var (
+		nsOpts         = s.namespace.Options()
+		ropts          = nsOpts.RetentionOptions()
+		indexBlockSize = nsOpts.IndexOptions().BlockSize()
+	)
+	s.cardinality.Tick(retention.FlushTimeStart(ropts, tickStart),
+		retention.FlushTimeStartForRetentionPeriod(ropts.RetentionPeriod(),
+			indexBlockSize, tickStart))
+}
+
+// isColdWrite returns whether a write at the timestamp is a cold write, i.e.
+// outside of the buffer past and future of the namespace.
+func (s *dbShard) isColdWrite(timestamp time.Time) bool {
+	nsOpts := s.namespace.Options()
+	if !nsOpts.ColdWritesEnabled() {
+		return false
+	}
+	var (
+		ropts = nsOpts.RetentionOptions()
+		now   = s.nowFn()
+	)
+	return !now.Add(-ropts.BufferPast()).Before(timestamp) ||
+		!now.Add(ropts.BufferFuture()).After(timestamp)
+}
+
+// tagsWithAnnotationFields returns the tags to index a new series with, which
+// include any fields of the annotation configured to be indexed.
+func (s *dbShard) tagsWithAnnotationFields(
+	tags ident.TagIterator,
+	annotation []byte,
+	wOpts series.WriteOptions,
+) ident.TagIterator {
+	fields := s.namespace.Options().IndexOptions().AnnotationFields()
+	result, err := tagsWithAnnotationFields(tags, fields, wOpts.SchemaDesc, annotation)
+	if err != nil {
+		// The annotation fields are best effort, index the series without
+		// them rather than failing the write.
+		s.metrics.annotationFieldsErrors.Inc(1)
+		return tags
+	}
+	return result
+}
+
 func (s *dbShard) ReadEncoded(
 	ctx context.Context,
 	id ident.ID,
@@ -966,25 +1235,95 @@ func (s *dbShard) ReadEncoded(
 	}
 	s.RUnlock()
 
+	return s.readEncodedEntry(ctx, id, entry, err, start, end, nsCtx)
+}
+
+func (s *dbShard) ReadEncodedBatch(
+	ctx context.Context,
+	ids []ident.ID,
+	start, end time.Time,
+	nsCtx namespace.Context,
+) []ReadEncodedResult {
+	var (
+		entries    = make([]*lookup.Entry, len(ids))
+		lookupErrs = make([]error, len(ids))
+	)
+	s.RLock()
+	for i, id := range ids {
+		entry, _, err := s.lookupEntryWithLock(id)
+		if entry != nil {
+			// NB: Same as a single read, hold a reader count on each series
+			// so that none are expired while the batch is being read.
+			entry.IncrementReaderWriterCount()
+		}
+		entries[i] = entry
+		lookupErrs[i] = err
+	}
+	s.RUnlock()
+
+	results := make([]ReadEncodedResult, len(ids))
+	for i, id := range ids {
+		results[i].Readers, results[i].Err = s.readEncodedEntry(ctx, id,
+			entries[i], lookupErrs[i], start, end, nsCtx)
+		if entries[i] != nil {
+			entries[i].DecrementReaderWriterCount()
+		}
+	}
+	return results
+}
+
+// readEncodedEntry reads the series for the given id, using the entry if the
+// series was found in memory and otherwise falling back to the retriever.
+func (s *dbShard) readEncodedEntry(
+	ctx context.Context,
+	id ident.ID,
+	entry *lookup.Entry,
+	err error,
+	start, end time.Time,
+	nsCtx namespace.Context,
+) ([][]xio.BlockReader, error) {
+	if err == nil || err == errShardEntryNotFound {
+		s.hotSeries.RecordRead(id)
+	}
 	if err == errShardEntryNotFound {
 		switch s.opts.SeriesCachePolicy() {
 		case series.CacheAll:
 			// No-op, would be in memory if cached
 			return nil, nil
 		}
+		if s.tombstones.IsDeleted(id.Bytes()) {
+			// Deleted series may have data on disk yet to be purged.
+			return nil, nil
+		}
 	} else if err != nil {
 		return nil, err
 	}
 
+	var results [][]xio.BlockReader
 	if entry != nil {
-		return entry.Series.ReadEncoded(ctx, start, end, nsCtx)
+		results, err = entry.Series.ReadEncoded(ctx, start, end, nsCtx)
+	} else {
+		retriever := s.seriesBlockRetriever
+		onRetrieve := s.seriesOnRetrieveBlock
+		opts := s.currSeriesOpts()
+		reader := series.NewReaderUsingRetriever(id, retriever, onRetrieve, nil, opts)
+		results, err = reader.ReadEncoded(ctx, start, end, nsCtx)
+	}
+	if err != nil || !s.tombstones.HasTombstone(id.Bytes()) {
+		return results, err
+	}
+
+	// A deleted series written to again may still have the data written
+	// before it was deleted in memory or on disk.
+	filtered := results[:0]
+	for _, blockReaders := range results {
+		if len(blockReaders) > 0 &&
+			s.tombstones.IsDeletedAt(id.Bytes(), blockReaders[0].Start) {
+			continue
+		}
+		filtered = append(filtered, blockReaders)
 	}
-
-	retriever := s.seriesBlockRetriever
-	onRetrieve := s.seriesOnRetrieveBlock
-	opts := s.seriesOpts
-	reader := series.NewReaderUsingRetriever(id, retriever, onRetrieve, nil, opts)
-	return reader.ReadEncoded(ctx, start, end, nsCtx)
+	return filtered, nil
 }
 
 // lookupEntryWithLock returns the entry for a given id while holding a read lock or a write lock.
@@ -1109,7 +1448,7 @@ func (s *dbShard) newShardEntry(
 
 	series := s.seriesPool.Get()
 	series.Reset(seriesID, seriesTags, s.seriesBlockRetriever,
-		s.seriesOnRetrieveBlock, s, s.seriesOpts)
+		s.seriesOnRetrieveBlock, s, s.currSeriesOpts())
 	uniqueIndex := s.increasingIndex.nextIndex()
 	return lookup.NewEntry(series, uniqueIndex), nil
 }
@@ -1447,17 +1786,33 @@ func (s *dbShard) FetchBlocks(
 			// No-op, would be in memory if cached
 			return nil, nil
 		}
+		if s.tombstones.IsDeleted(id.Bytes()) {
+			// Deleted series may have data on disk yet to be purged.
+			return nil, nil
+		}
 	} else if err != nil {
 		return nil, err
 	}
 
+	if s.tombstones.HasTombstone(id.Bytes()) {
+		// A deleted series written to again may still have the data written
+		// before it was deleted in memory or on disk.
+		filtered := make([]time.Time, 0, len(starts))
+		for _, start := range starts {
+			if !s.tombstones.IsDeletedAt(id.Bytes(), start) {
+				filtered = append(filtered, start)
+			}
+		}
+		starts = filtered
+	}
+
 	if entry != nil {
 		return entry.Series.FetchBlocks(ctx, starts, nsCtx)
 	}
 
 	retriever := s.seriesBlockRetriever
 	onRetrieve := s.seriesOnRetrieveBlock
-	opts := s.seriesOpts
+	opts := s.currSeriesOpts()
 	// Nil for onRead callback because we don't want peer bootstrapping to impact
 	// the behavior of the LRU
 	var onReadCb block.OnReadBlock
@@ -1653,7 +2008,7 @@ func (s *dbShard) FetchBlocksMetadataV2(
 	// flushed block and work backwards.
 	var (
 		result    = s.opts.FetchBlocksMetadataResultsPool().Get()
-		ropts     = s.namespace.Options().RetentionOptions()
+		ropts     = s.currSeriesOpts().RetentionOptions()
 		blockSize = ropts.BlockSize()
 		// Subtract one blocksize because all fetch requests are exclusive on the end side
 		blockStart      = end.Truncate(blockSize).Add(-1 * blockSize)
@@ -1789,6 +2144,14 @@ func (s *dbShard) Bootstrap(
 	for _, elem := range bootstrappedSeries.Iter() {
 		dbBlocks := elem.Value()
 
+		if s.tombstones.IsDeleted(dbBlocks.ID.Bytes()) {
+			// Deleted series are not restored from the bootstrapped data.
+			dbBlocks.Blocks.Close()
+			dbBlocks.Tags.Finalize()
+			continue
+		}
+		s.removeDeletedBlocks(dbBlocks)
+
 		// First lookup if series already exists
 		entry, _, err := s.tryRetrieveWritableSeries(dbBlocks.ID)
 		if err != nil {
@@ -1851,7 +2214,7 @@ func (s *dbShard) Bootstrap(
 	// Now iterate flushed time ranges to determine which blocks are
 	// retrievable before servicing reads
 	fsOpts := s.opts.CommitLogOptions().FilesystemOptions()
-	readInfoFilesResults := fs.ReadInfoFiles(fsOpts.FilePathPrefix(), s.namespace.ID(), s.shard,
+	readInfoFilesResults := fs.ReadInfoFiles(fsOpts.NamespaceFilePathPrefix(s.namespace.ID()), s.namespace.ID(), s.shard,
 		fsOpts.InfoReaderBufferSize(), fsOpts.DecodingOptions())
 
 	for _, result := range readInfoFilesResults {
@@ -1885,6 +2248,128 @@ func (s *dbShard) Bootstrap(
 	return multiErr.FinalError()
 }
 
+func (s *dbShard) Rebootstrap(
+	process bootstrap.Process,
+	window xtime.Range,
+) error {
+	if !window.Start.Before(window.End) {
+		return errShardRebootstrapRangeEmpty
+	}
+
+	// The blocks already warm flushed are loaded as cold writes, which are
+	// only ever persisted by cold flushes.
+	if !s.namespace.Options().ColdWritesEnabled() {
+		blockSize := s.namespace.Options().RetentionOptions().BlockSize()
+		for t := window.Start.Truncate(blockSize); t.Before(window.End); t = t.Add(blockSize) {
+			if s.hasWarmFlushed(t) {
+				return errShardRebootstrapColdWritesDisabled
+			}
+		}
+	}
+
+	// The shard is bootstrapping again so that it is neither read from nor
+	// flushed until the time range is loaded into its series.
+	s.Lock()
+	if s.bootstrapState != Bootstrapped {
+		s.Unlock()
+		return errShardNotBootstrappedToRebootstrap
+	}
+	s.bootstrapState = Bootstrapping
+	s.Unlock()
+
+	defer func() {
+		s.Lock()
+		s.bootstrapState = Bootstrapped
+		s.Unlock()
+	}()
+
+	bootstrapResult, err := process.RunRange(s.namespace, []uint32{s.ID()}, window)
+	if err != nil {
+		return err
+	}
+
+	multiErr := xerrors.NewMultiError()
+	if shardResult, ok := bootstrapResult.DataResult.ShardResults()[s.ID()]; ok {
+		multiErr = multiErr.Add(s.loadSeries(shardResult.AllSeries()))
+	}
+
+	if s.reverseIndex != nil {
+		err := s.reverseIndex.Bootstrap(bootstrapResult.IndexResult.IndexResults())
+		multiErr = multiErr.Add(err)
+
+		// As with a bootstrap the index filesets may still hold deleted series.
+		if deleted := s.DeletedSeries(); len(deleted) > 0 {
+			multiErr = multiErr.Add(s.reverseIndex.MarkDeleted(deleted))
+		}
+	}
+
+	return multiErr.FinalError()
+}
+
+// removeDeletedBlocks removes the bootstrapped blocks of a deleted series
+// written to again that hold the data written before it was deleted.
+func (s *dbShard) removeDeletedBlocks(dbBlocks result.DatabaseSeriesBlocks) {
+	if !s.tombstones.HasTombstone(dbBlocks.ID.Bytes()) {
+		return
+	}
+	for blockStart, dbBlock := range dbBlocks.Blocks.AllBlocks() {
+		if s.tombstones.IsDeletedAt(dbBlocks.ID.Bytes(), blockStart.ToTime()) {
+			dbBlocks.Blocks.RemoveBlockAt(blockStart.ToTime())
+			dbBlock.Close()
+		}
+	}
+}
+
+// loadSeries loads the series re-bootstrapped into the series of the shard,
+// creating the series that do not exist.
+func (s *dbShard) loadSeries(bootstrappedSeries *result.Map) error {
+	var (
+		shardBootstrapResult = dbShardBootstrapResult{}
+		multiErr             = xerrors.NewMultiError()
+		blockStates          = s.BlockStatesSnapshot()
+	)
+	for _, elem := range bootstrappedSeries.Iter() {
+		dbBlocks := elem.Value()
+
+		if s.tombstones.IsDeleted(dbBlocks.ID.Bytes()) {
+			dbBlocks.Blocks.Close()
+			dbBlocks.Tags.Finalize()
+			continue
+		}
+		s.removeDeletedBlocks(dbBlocks)
+
+		entry, _, err := s.tryRetrieveWritableSeries(dbBlocks.ID)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		if entry == nil {
+			// Series inserted once the shard is bootstrapped are bootstrapped
+			// on insert and so can be loaded.
+			entry, err = s.insertSeriesSync(dbBlocks.ID, newTagsArg(dbBlocks.Tags),
+				insertSyncIncReaderWriterCount)
+			if err != nil {
+				multiErr = multiErr.Add(err)
+				continue
+			}
+		} else {
+			dbBlocks.Tags.Finalize()
+		}
+
+		// Cannot close blocks once done as series takes ref to these
+		loadResult, err := entry.Series.Load(dbBlocks.Blocks, blockStates)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+		}
+		shardBootstrapResult.update(loadResult)
+
+		entry.DecrementReaderWriterCount()
+	}
+
+	s.emitBootstrapResult(shardBootstrapResult)
+	return multiErr.FinalError()
+}
+
 func (s *dbShard) WarmFlush(
 	blockStart time.Time,
 	flushPreparer persist.FlushPreparer,
@@ -1954,7 +2439,7 @@ func (s *dbShard) ColdFlush(
 	flushPreparer persist.FlushPreparer,
 	resources coldFlushReuseableResources,
 	nsCtx namespace.Context,
-) error {
+) (err error) {
 	// We don't flush data when the shard is still bootstrapping.
 	s.RLock()
 	if s.bootstrapState != Bootstrapped {
@@ -1963,6 +2448,16 @@ func (s *dbShard) ColdFlush(
 	}
 	s.RUnlock()
 
+	// The cold writes made from here on may not be flushed by this cold
+	// flush so they remain pending, the cold writes flushed are pending
+	// again if the cold flush fails.
+	pendingColdWrites := atomic.SwapInt64(&s.numPendingColdWrites, 0)
+	defer func() {
+		if err != nil {
+			atomic.AddInt64(&s.numPendingColdWrites, pendingColdWrites)
+		}
+	}()
+
 	resources.reset()
 	var (
 		multiErr           xerrors.MultiError
@@ -1999,18 +2494,47 @@ func (s *dbShard) ColdFlush(
 		return true
 	})
 
-	if dirtySeries.Len() == 0 {
+	// Then, add the blocks that the cold flush merge sources have data for.
+	sourceMergeWiths, sourcesByBlockStart, err := s.coldFlushMergeSources(
+		dirtySeriesToWrite, idElementPool)
+	if err != nil {
+		multiErr = multiErr.Add(err)
+	}
+
+	// Then, add the blocks that may still have data of deleted series so
+	// that they are rewritten without it.
+	purgeBlockStarts := s.coldFlushPurgeBlockStarts(dirtySeriesToWrite, idElementPool)
+
+	// Then, add the blocks that were flushed before the schema of the
+	// namespace changed so that they are re-encoded with the latest schema.
+	migrateBlockStarts := s.coldFlushSchemaMigrationBlockStarts(dirtySeriesToWrite, idElementPool)
+
+	if dirtySeries.Len() == 0 && len(sourceMergeWiths) == 0 &&
+		len(purgeBlockStarts) == 0 && len(migrateBlockStarts) == 0 {
 		// Early exit if there is nothing dirty to merge. dirtySeriesToWrite
 		// may be non-empty when dirtySeries is empty because we purposely
 		// leave empty seriesLists in the dirtySeriesToWrite map to avoid having
 		// to reallocate them in subsequent usages of the shared resource.
-		return nil
+		return multiErr.FinalError()
 	}
 
-	merger := s.newMergerFn(resources.fsReader, s.opts.DatabaseBlockOptions().DatabaseBlockAllocSize(),
+	// Series that have been deleted are skipped when reading the filesets
+	// being merged so that their data is purged.
+	var (
+		fsReader         = resources.fsReader
+		tombstonedReader *tombstonedSeriesReader
+	)
+	if s.tombstones.Len() > 0 {
+		tombstonedReader = newTombstonedSeriesReader(fsReader, s.tombstones)
+		fsReader = tombstonedReader
+	}
+	merger := s.newMergerFn(fsReader, s.opts.DatabaseBlockOptions().DatabaseBlockAllocSize(),
 		s.opts.SegmentReaderPool(), s.opts.MultiReaderIteratorPool(),
 		s.opts.IdentifierPool(), s.opts.EncoderPool(), s.namespace.Options())
-	mergeWithMem := s.newFSMergeWithMemFn(s, s, dirtySeries, dirtySeriesToWrite)
+	// The series in memory are merged last so that their data takes precedence
+	// over the data of the cold flush merge sources.
+	mergeWith := fs.NewMultiMergeWith(append(sourceMergeWiths,
+		s.newFSMergeWithMemFn(s, s, dirtySeries, dirtySeriesToWrite))...)
 	// Loop through each block that we know has ColdWrites. Since each block
 	// has its own fileset, if we encounter an error while trying to persist
 	// a block, we continue to try persisting other blocks.
@@ -2025,7 +2549,7 @@ func (s *dbShard) ColdFlush(
 		}
 
 		nextVersion := coldVersion + 1
-		err := merger.Merge(fsID, mergeWithMem, nextVersion, flushPreparer, nsCtx)
+		err := merger.Merge(fsID, mergeWith, nextVersion, flushPreparer, nsCtx)
 		if err != nil {
 			multiErr = multiErr.Add(err)
 			continue
@@ -2045,11 +2569,248 @@ func (s *dbShard) ColdFlush(
 			Shard:      s.ID(),
 			BlockStart: startTime,
 		}, block.LeaseState{Volume: nextVersion})
+
+		for _, source := range sourcesByBlockStart[blockStart] {
+			source.MarkMerged(s.namespace.ID(), s.ID(), blockStart)
+		}
+
+		if tombstonedReader != nil {
+			multiErr = multiErr.Add(s.tombstones.MarkPurged(startTime))
+		}
+
+		s.markSchemaMigrated(blockStart)
+	}
+
+	if tombstonedReader != nil {
+		s.metrics.seriesPurged.Inc(int64(tombstonedReader.skipped))
 	}
 
 	return multiErr.FinalError()
 }
 
+// coldFlushPurgeBlockStarts adds the flushed block starts that may still have
+// data of deleted series to the series to write so that they are merged even
+// if no series in memory are dirty for them, returning the block starts added.
+func (s *dbShard) coldFlushPurgeBlockStarts(
+	dirtySeriesToWrite map[xtime.UnixNano]*idList,
+	idElementPool *idElementPool,
+) []xtime.UnixNano {
+	var purge []xtime.UnixNano
+	for _, blockStart := range s.tombstones.PendingBlockStarts() {
+		if !s.hasWarmFlushed(blockStart.ToTime()) {
+			continue
+		}
+		if dirtySeriesToWrite[blockStart] == nil {
+			dirtySeriesToWrite[blockStart] = newIDList(idElementPool)
+		}
+		purge = append(purge, blockStart)
+	}
+	return purge
+}
+
+// coldFlushSchemaMigrationBlockStarts adds the flushed block starts that
+// were encoded with an earlier version of the schema of the namespace to the
+// series to write so that the merge re-encodes them with the latest schema,
+// returning the block starts added.
+func (s *dbShard) coldFlushSchemaMigrationBlockStarts(
+	dirtySeriesToWrite map[xtime.UnixNano]*idList,
+	idElementPool *idElementPool,
+) []xtime.UnixNano {
+	s.Lock()
+	migrate := make([]xtime.UnixNano, 0, len(s.schemaMigrations))
+	for blockStart := range s.schemaMigrations {
+		if !s.hasWarmFlushed(blockStart.ToTime()) {
+			// The fileset has expired since the schema changed.
+			delete(s.schemaMigrations, blockStart)
+			continue
+		}
+		migrate = append(migrate, blockStart)
+	}
+	s.Unlock()
+
+	for _, blockStart := range migrate {
+		if dirtySeriesToWrite[blockStart] == nil {
+			dirtySeriesToWrite[blockStart] = newIDList(idElementPool)
+		}
+	}
+	return migrate
+}
+
+func (s *dbShard) markSchemaMigrated(blockStart xtime.UnixNano) {
+	s.Lock()
+	_, ok := s.schemaMigrations[blockStart]
+	delete(s.schemaMigrations, blockStart)
+	s.Unlock()
+	if ok {
+		s.metrics.blocksSchemaMigrated.Inc(1)
+	}
+}
+
+func (s *dbShard) MigrateSchema() {
+	blockStates := s.BlockStatesSnapshot()
+	s.Lock()
+	for blockStart, state := range blockStates {
+		if state.WarmRetrievable {
+			s.schemaMigrations[blockStart] = struct{}{}
+		}
+	}
+	s.Unlock()
+}
+
+func (s *dbShard) DeleteSeries(ids []ident.ID) error {
+	s.RLock()
+	if s.bootstrapState != Bootstrapped {
+		s.RUnlock()
+		return errShardNotBootstrappedToDelete
+	}
+	s.RUnlock()
+
+	// The data of the series may be in any flushed fileset.
+	var flushed []time.Time
+	for blockStart, state := range s.BlockStatesSnapshot() {
+		if state.WarmRetrievable {
+			flushed = append(flushed, blockStart.ToTime())
+		}
+	}
+
+	// Persist the tombstones before removing the series from memory so that
+	// the delete is honored even if the node restarts before the data on
+	// disk is purged.
+	if err := s.tombstones.Add(ids, s.nowFn(), flushed); err != nil {
+		return err
+	}
+
+	s.Lock()
+	for _, id := range ids {
+		elem, exists := s.lookup.Get(id)
+		if !exists {
+			continue
+		}
+		entry := elem.Value.(*lookup.Entry)
+		s.list.Remove(elem)
+		s.lookup.Delete(id)
+		// Closing the series releases its buffer and cached blocks, if it is
+		// being ticked, written to or read from it is left to be garbage
+		// collected instead to keep a consistent view of the series for those.
+		if entry.ReaderWriterCount() == 0 {
+			entry.Series.Close()
+		}
+	}
+	s.Unlock()
+
+	s.metrics.seriesDeleted.Inc(int64(len(ids)))
+	return nil
+}
+
+func (s *dbShard) DeletedSeries() []ident.ID {
+	return s.tombstones.IDs()
+}
+
+// currSeriesOpts returns the current series options, these are guarded by
+// their own lock since they are updated when the namespace retention changes.
+func (s *dbShard) currSeriesOpts() series.Options {
+	s.seriesOptsLock.RLock()
+	opts := s.seriesOpts
+	s.seriesOptsLock.RUnlock()
+	return opts
+}
+
+func (s *dbShard) UpdateRetentionOptions(ropts retention.Options) {
+	s.seriesOptsLock.Lock()
+	s.seriesOpts = s.seriesOpts.SetRetentionOptions(ropts)
+	s.seriesOptsLock.Unlock()
+
+	// NB: Series created after this point use the updated options, so only
+	// the series currently held by the shard need to be updated.
+	s.forEachShardEntry(func(entry *lookup.Entry) bool {
+		entry.Series.UpdateRetentionOptions(ropts)
+		return true
+	})
+}
+
+func (s *dbShard) ImportFileSetVolume(blockStart time.Time, volume int) error {
+	s.RLock()
+	if s.bootstrapState != Bootstrapped {
+		s.RUnlock()
+		return errShardNotBootstrappedToImport
+	}
+	s.RUnlock()
+
+	// Only blocks that have been warm flushed can be imported into since the
+	// imported volume otherwise would not contain the warm writes in memory.
+	if !s.hasWarmFlushed(blockStart) {
+		return errShardImportBlockNotFlushed
+	}
+	if volume <= s.RetrievableBlockColdVersion(blockStart) {
+		return errShardImportVolumeNotNewer
+	}
+
+	// As with a cold flush, once the cold version is updated block leasers can
+	// only acquire leases on the imported volume and the previous volumes will
+	// be removed by cleanup once all leasers have relinquished their leases.
+	s.setFlushStateColdVersion(blockStart, volume)
+	_, err := s.opts.BlockLeaseManager().UpdateOpenLeases(block.LeaseDescriptor{
+		Namespace:  s.namespace.ID(),
+		Shard:      s.ID(),
+		BlockStart: blockStart,
+	}, block.LeaseState{Volume: volume})
+	return err
+}
+
+// coldFlushMergeSources returns the merge targets of the cold flush merge
+// sources that have data for the shard and the sources by the block starts
+// they have data for. Each of these block starts is added to the series to
+// write so that it is merged even if no series in memory are dirty for it.
+func (s *dbShard) coldFlushMergeSources(
+	dirtySeriesToWrite map[xtime.UnixNano]*idList,
+	idElementPool *idElementPool,
+) ([]fs.MergeWith, map[xtime.UnixNano][]ColdFlushMergeSource, error) {
+	var (
+		multiErr            xerrors.MultiError
+		mergeWiths          []fs.MergeWith
+		sourcesByBlockStart map[xtime.UnixNano][]ColdFlushMergeSource
+		nsID                = s.namespace.ID()
+	)
+	for _, source := range s.opts.ColdFlushMergeSources() {
+		blockStarts, err := source.ColdFlushBlockStarts(nsID, s.ID())
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+
+		var mergeable []xtime.UnixNano
+		for _, blockStart := range blockStarts {
+			// Same as for the series in memory, only blocks that have been
+			// warm flushed can be merged into.
+			if s.hasWarmFlushed(blockStart.ToTime()) {
+				mergeable = append(mergeable, blockStart)
+			}
+		}
+		if len(mergeable) == 0 {
+			continue
+		}
+
+		mergeWith, err := source.MergeWith(nsID, s.ID())
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		mergeWiths = append(mergeWiths, mergeWith)
+
+		if sourcesByBlockStart == nil {
+			sourcesByBlockStart = make(map[xtime.UnixNano][]ColdFlushMergeSource)
+		}
+		for _, blockStart := range mergeable {
+			if dirtySeriesToWrite[blockStart] == nil {
+				dirtySeriesToWrite[blockStart] = newIDList(idElementPool)
+			}
+			sourcesByBlockStart[blockStart] = append(sourcesByBlockStart[blockStart], source)
+		}
+	}
+
+	return mergeWiths, sourcesByBlockStart, multiErr.FinalError()
+}
+
 func (s *dbShard) Snapshot(
 	blockStart time.Time,
 	snapshotTime time.Time,
@@ -2124,6 +2885,18 @@ func (s *dbShard) FlushState(blockStart time.Time) fileOpState {
 	return state
 }
 
+func (s *dbShard) FlushStates() []BlockFlushState {
+	s.flushState.RLock()
+	states := make([]BlockFlushState, 0, len(s.flushState.statesByTime))
+	for blockStart, state := range s.flushState.statesByTime {
+		states = append(states, newBlockFlushState(s.shard, blockStart.ToTime(), state))
+	}
+	s.flushState.RUnlock()
+
+	sortBlockFlushStates(states)
+	return states
+}
+
 func (s *dbShard) markWarmFlushStateSuccessOrError(blockStart time.Time, err error) error {
 	// Track flush state for block state
 	if err == nil {
@@ -2170,7 +2943,8 @@ func (s *dbShard) setFlushStateColdVersion(blockStart time.Time, version int) {
 
 func (s *dbShard) removeAnyFlushStatesTooEarly(tickStart time.Time) {
 	s.flushState.Lock()
-	earliestFlush := retention.FlushTimeStart(s.namespace.Options().RetentionOptions(), tickStart)
+	ropts := s.currSeriesOpts().RetentionOptions()
+	earliestFlush := retention.FlushTimeStart(ropts, tickStart)
 	for t := range s.flushState.statesByTime {
 		if t.ToTime().Before(earliestFlush) {
 			delete(s.flushState.statesByTime, t)
@@ -2180,18 +2954,23 @@ func (s *dbShard) removeAnyFlushStatesTooEarly(tickStart time.Time) {
 }
 
 func (s *dbShard) CleanupExpiredFileSets(earliestToRetain time.Time) error {
-	filePathPrefix := s.opts.CommitLogOptions().FilesystemOptions().FilePathPrefix()
+	filePathPrefix := s.opts.CommitLogOptions().FilesystemOptions().
+		NamespaceFilePathPrefix(s.namespace.ID())
 	expired, err := s.filesetPathsBeforeFn(filePathPrefix, s.namespace.ID(), s.ID(), earliestToRetain)
 	if err != nil {
 		return fmt.Errorf("encountered errors when getting fileset files for prefix %s namespace %s shard %d: %v",
 			filePathPrefix, s.namespace.ID(), s.ID(), err)
 	}
 
-	return s.deleteFilesFn(expired)
+	var multiErr xerrors.MultiError
+	multiErr = multiErr.Add(s.deleteFileSetFiles(filePathPrefix, expired))
+	multiErr = multiErr.Add(s.tombstones.Expire(earliestToRetain))
+	return multiErr.FinalError()
 }
 
 func (s *dbShard) CleanupCompactedFileSets() error {
-	filePathPrefix := s.opts.CommitLogOptions().FilesystemOptions().FilePathPrefix()
+	filePathPrefix := s.opts.CommitLogOptions().FilesystemOptions().
+		NamespaceFilePathPrefix(s.namespace.ID())
 	filesets, err := s.filesetsFn(filePathPrefix, s.namespace.ID(), s.ID())
 	if err != nil {
 		return fmt.Errorf("encountered errors when getting fileset files for prefix %s namespace %s shard %d: %v",
@@ -2210,7 +2989,20 @@ func (s *dbShard) CleanupCompactedFileSets() error {
 		}
 	}
 
-	return s.deleteFilesFn(toDelete.Filepaths())
+	return s.deleteFileSetFiles(filePathPrefix, toDelete.Filepaths())
+}
+
+// deleteFileSetFiles deletes the given fileset files from local disk along
+// with their copies uploaded to the object store, if any.
+func (s *dbShard) deleteFileSetFiles(filePathPrefix string, files []string) error {
+	if store := s.opts.CommitLogOptions().FilesystemOptions().ObjectStore(); store != nil {
+		// NB: The copies are deleted first since cleanup only finds the
+		// files that are still on local disk to retry the deletion with.
+		if err := fs.DeleteDataFileSetObjects(store, filePathPrefix, files); err != nil {
+			return err
+		}
+	}
+	return s.deleteFilesFn(files)
 }
 
 func (s *dbShard) Repair(
@@ -2233,6 +3025,77 @@ func (s *dbShard) TagsFromSeriesID(seriesID ident.ID) (ident.Tags, bool, error)
 	return entry.Series.Tags(), true, nil
 }
 
+func (s *dbShard) SeriesMetadata(id ident.ID) (SeriesMetadata, error) {
+	var (
+		copiedID = ident.BytesID(append([]byte(nil), id.Bytes()...))
+		tags     ident.Tags
+		buffer   series.BufferMetadata
+	)
+	s.RLock()
+	entry, _, err := s.lookupEntryWithLock(id)
+	if entry != nil {
+		entry.IncrementReaderWriterCount()
+		defer entry.DecrementReaderWriterCount()
+	}
+	s.RUnlock()
+	if err != nil && err != errShardEntryNotFound {
+		return SeriesMetadata{}, err
+	}
+	if entry != nil {
+		tags = copySeriesTags(entry.Series.Tags())
+		buffer = entry.Series.BufferMetadata()
+	}
+
+	volumes, err := s.seriesVolumes(id)
+	if err != nil {
+		return SeriesMetadata{}, err
+	}
+	return newSeriesMetadata(copiedID, s.shard, entry != nil, tags, buffer,
+		s.FlushStates(), volumes), nil
+}
+
+// seriesVolumes returns the volumes of the complete filesets of the shard
+// that contain the series, keyed by block start.
+func (s *dbShard) seriesVolumes(id ident.ID) (map[xtime.UnixNano][]int, error) {
+	var (
+		fsOpts         = s.opts.CommitLogOptions().FilesystemOptions()
+		nsID           = s.namespace.ID()
+		filePathPrefix = fsOpts.NamespaceFilePathPrefix(nsID)
+	)
+	filesets, err := s.filesetsFn(filePathPrefix, nsID, s.shard)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		volumes   = make(map[xtime.UnixNano][]int)
+		resources = fs.NewReusableSeekerResources(fsOpts)
+	)
+	for _, fileset := range filesets {
+		if !fileset.HasCompleteCheckpointFile() {
+			continue
+		}
+		seeker := fs.NewSeeker(filePathPrefix, fsOpts.DataReaderBufferSize(),
+			fsOpts.InfoReaderBufferSize(), s.opts.BytesPool(), false, fsOpts)
+		blockStart := fileset.ID.BlockStart
+		if err := seeker.Open(nsID, s.shard, blockStart,
+			fileset.ID.VolumeIndex, resources); err != nil {
+			return nil, err
+		}
+		_, err := seeker.SeekIndexEntry(id, resources)
+		seeker.Close()
+		if fs.IsSeekIDNotFoundError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		key := xtime.ToUnixNano(blockStart)
+		volumes[key] = append(volumes[key], fileset.ID.VolumeIndex)
+	}
+	return volumes, nil
+}
+
 func (s *dbShard) BootstrapState() BootstrapState {
 	s.RLock()
 	bs := s.bootstrapState
@@ -0,0 +1,235 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+)
+
+const (
+	// defaultShardRebalanceMaxLoadSkew is the default multiple of the mean
+	// shard load above which a shard is recommended to be moved.
+	defaultShardRebalanceMaxLoadSkew = 2.0
+)
+
+var (
+	errShardRebalanceMaxLoadSkewInvalid = errors.New(
+		"shard rebalance max load skew must be greater than one")
+	errShardRebalanceMaxMovesInvalid = errors.New(
+		"shard rebalance max moves must not be negative")
+)
+
+// ShardRebalanceAdviceOptions controls how shard rebalance advice is
+// computed, zero values use the defaults.
+type ShardRebalanceAdviceOptions struct {
+	// MaxLoadSkew is the multiple of the mean shard load on the node above
+	// which a shard is recommended to be moved off the node.
+	MaxLoadSkew float64
+	// MaxMoves limits the number of recommended moves, zero means no limit.
+	MaxMoves int
+}
+
+// Validate validates the shard rebalance advice options.
+func (o ShardRebalanceAdviceOptions) Validate() error {
+	if o.MaxLoadSkew != 0 && o.MaxLoadSkew <= 1 {
+		return errShardRebalanceMaxLoadSkewInvalid
+	}
+	if o.MaxMoves < 0 {
+		return errShardRebalanceMaxMovesInvalid
+	}
+	return nil
+}
+
+func (o ShardRebalanceAdviceOptions) maxLoadSkew() float64 {
+	if o.MaxLoadSkew == 0 {
+		return defaultShardRebalanceMaxLoadSkew
+	}
+	return o.MaxLoadSkew
+}
+
+// ShardLoad is the load of a shard owned by the node summed across all
+// namespaces.
+type ShardLoad struct {
+	Shard           uint32
+	NumSeries       int64
+	WritesPerSecond float64
+	DiskBytes       int64
+	// Score is the load of the shard relative to the mean shard load on the
+	// node, a score of one is an average shard.
+	Score float64
+}
+
+// ShardMove is a recommended move of a shard off the node, moves are only
+// recommended and never executed.
+type ShardMove struct {
+	Shard  uint32
+	Score  float64
+	Reason string
+}
+
+// ShardRebalanceAdvice is the load of the shards owned by the node and the
+// shard moves recommended to even it out, hottest shard first.
+type ShardRebalanceAdvice struct {
+	ComputedAt time.Time
+	Shards     []ShardLoad
+	Moves      []ShardMove
+}
+
+type shardLoadSample struct {
+	numSeries int64
+	numWrites int64
+	diskBytes int64
+}
+
+// shardRebalanceAdvisor computes shard rebalance advice from the load of
+// the shards owned by the node. The write rate of a shard is computed over
+// the interval since the previous advice, or since the advisor was created
+// for the first advice.
+type shardRebalanceAdvisor struct {
+	sync.Mutex
+
+	nowFn      clock.NowFn
+	lastAt     time.Time
+	lastWrites map[uint32]int64
+}
+
+func newShardRebalanceAdvisor(nowFn clock.NowFn) *shardRebalanceAdvisor {
+	return &shardRebalanceAdvisor{
+		nowFn:      nowFn,
+		lastAt:     nowFn(),
+		lastWrites: make(map[uint32]int64),
+	}
+}
+
+func (a *shardRebalanceAdvisor) advise(
+	samples map[uint32]shardLoadSample,
+	opts ShardRebalanceAdviceOptions,
+) ShardRebalanceAdvice {
+	a.Lock()
+	now := a.nowFn()
+	elapsed := now.Sub(a.lastAt).Seconds()
+	loads := make([]ShardLoad, 0, len(samples))
+	lastWrites := make(map[uint32]int64, len(samples))
+	for shard, sample := range samples {
+		writes := sample.numWrites
+		if last, ok := a.lastWrites[shard]; ok && last <= writes {
+			writes -= last
+		}
+		var writesPerSecond float64
+		if elapsed > 0 {
+			writesPerSecond = float64(writes) / elapsed
+		}
+		loads = append(loads, ShardLoad{
+			Shard:           shard,
+			NumSeries:       sample.numSeries,
+			WritesPerSecond: writesPerSecond,
+			DiskBytes:       sample.diskBytes,
+		})
+		lastWrites[shard] = sample.numWrites
+	}
+	a.lastAt = now
+	a.lastWrites = lastWrites
+	a.Unlock()
+
+	sort.Slice(loads, func(i, j int) bool {
+		return loads[i].Shard < loads[j].Shard
+	})
+
+	var (
+		dimensions = []shardLoadDimension{
+			{name: "series count", valueFn: func(l ShardLoad) float64 { return float64(l.NumSeries) }},
+			{name: "write rate", valueFn: func(l ShardLoad) float64 { return l.WritesPerSecond }},
+			{name: "disk usage", valueFn: func(l ShardLoad) float64 { return float64(l.DiskBytes) }},
+		}
+		moves []ShardMove
+		skew  = opts.maxLoadSkew()
+	)
+	for i := range dimensions {
+		dimensions[i].computeMean(loads)
+	}
+	for i := range loads {
+		var (
+			sum      float64
+			n        int
+			dominant shardLoadDimension
+			maxRatio float64
+		)
+		for _, d := range dimensions {
+			if d.mean <= 0 {
+				continue
+			}
+			ratio := d.valueFn(loads[i]) / d.mean
+			sum += ratio
+			n++
+			if ratio > maxRatio {
+				maxRatio, dominant = ratio, d
+			}
+		}
+		if n == 0 {
+			continue
+		}
+		loads[i].Score = sum / float64(n)
+		if loads[i].Score > skew {
+			moves = append(moves, ShardMove{
+				Shard: loads[i].Shard,
+				Score: loads[i].Score,
+				Reason: fmt.Sprintf("load is %.1fx the mean shard load, %s is %.1fx the mean",
+					loads[i].Score, dominant.name, maxRatio),
+			})
+		}
+	}
+
+	sort.SliceStable(moves, func(i, j int) bool {
+		return moves[i].Score > moves[j].Score
+	})
+	if opts.MaxMoves > 0 && len(moves) > opts.MaxMoves {
+		moves = moves[:opts.MaxMoves]
+	}
+
+	return ShardRebalanceAdvice{
+		ComputedAt: now,
+		Shards:     loads,
+		Moves:      moves,
+	}
+}
+
+type shardLoadDimension struct {
+	name    string
+	valueFn func(l ShardLoad) float64
+	mean    float64
+}
+
+func (d *shardLoadDimension) computeMean(loads []ShardLoad) {
+	if len(loads) == 0 {
+		return
+	}
+	var sum float64
+	for _, l := range loads {
+		sum += d.valueFn(l)
+	}
+	d.mean = sum / float64(len(loads))
+}
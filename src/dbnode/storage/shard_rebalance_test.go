@@ -0,0 +1,98 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardRebalanceAdviceOptionsValidate(t *testing.T) {
+	require.NoError(t, ShardRebalanceAdviceOptions{}.Validate())
+	require.NoError(t, ShardRebalanceAdviceOptions{MaxLoadSkew: 1.5, MaxMoves: 2}.Validate())
+	require.Equal(t, errShardRebalanceMaxLoadSkewInvalid,
+		ShardRebalanceAdviceOptions{MaxLoadSkew: 1}.Validate())
+	require.Equal(t, errShardRebalanceMaxMovesInvalid,
+		ShardRebalanceAdviceOptions{MaxMoves: -1}.Validate())
+}
+
+func TestShardRebalanceAdvisorWriteRates(t *testing.T) {
+	now := time.Now()
+	a := newShardRebalanceAdvisor(func() time.Time { return now })
+
+	now = now.Add(10 * time.Second)
+	advice := a.advise(map[uint32]shardLoadSample{
+		0: {numWrites: 100},
+		1: {numWrites: 300},
+	}, ShardRebalanceAdviceOptions{})
+	require.Equal(t, now, advice.ComputedAt)
+	require.Equal(t, []ShardLoad{
+		{Shard: 0, WritesPerSecond: 10, Score: 0.5},
+		{Shard: 1, WritesPerSecond: 30, Score: 1.5},
+	}, advice.Shards)
+	require.Equal(t, 0, len(advice.Moves))
+
+	// Write rates of the next advice are computed since the previous advice.
+	now = now.Add(10 * time.Second)
+	advice = a.advise(map[uint32]shardLoadSample{
+		0: {numWrites: 200},
+		1: {numWrites: 400},
+		2: {numWrites: 50},
+	}, ShardRebalanceAdviceOptions{})
+	require.Equal(t, []float64{10, 10, 5}, []float64{
+		advice.Shards[0].WritesPerSecond,
+		advice.Shards[1].WritesPerSecond,
+		advice.Shards[2].WritesPerSecond,
+	})
+}
+
+func TestShardRebalanceAdvisorMoves(t *testing.T) {
+	now := time.Now()
+	a := newShardRebalanceAdvisor(func() time.Time { return now })
+
+	samples := map[uint32]shardLoadSample{
+		0: {numSeries: 10, diskBytes: 10},
+		1: {numSeries: 10, diskBytes: 10},
+		2: {numSeries: 10, diskBytes: 10},
+		3: {numSeries: 10, diskBytes: 10},
+		4: {numSeries: 100, diskBytes: 10},
+		5: {numSeries: 100, diskBytes: 200},
+	}
+
+	advice := a.advise(samples, ShardRebalanceAdviceOptions{})
+	require.Equal(t, 6, len(advice.Shards))
+	require.Equal(t, 1, len(advice.Moves))
+	require.Equal(t, uint32(5), advice.Moves[0].Shard)
+	require.Equal(t, advice.Shards[5].Score, advice.Moves[0].Score)
+	require.Contains(t, advice.Moves[0].Reason, "disk usage")
+
+	advice = a.advise(samples, ShardRebalanceAdviceOptions{MaxLoadSkew: 1.2})
+	require.Equal(t, 2, len(advice.Moves))
+	require.Equal(t, uint32(5), advice.Moves[0].Shard)
+	require.Equal(t, uint32(4), advice.Moves[1].Shard)
+	require.Contains(t, advice.Moves[1].Reason, "series count")
+
+	advice = a.advise(samples, ShardRebalanceAdviceOptions{MaxLoadSkew: 1.2, MaxMoves: 1})
+	require.Equal(t, 1, len(advice.Moves))
+	require.Equal(t, uint32(5), advice.Moves[0].Shard)
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+// shardSeriesExpiry tracks the series purged from memory by a shard along
+// with the block start of their last write, so that once that block falls
+// out of retention the series can be expired from the index blocks that
+// outlive it. Series that have not been written to since the node started
+// are not tracked and expire with their index blocks. A nil tracker tracks
+// nothing.
+type shardSeriesExpiry struct {
+	sync.Mutex
+
+	lastWriteBlockStarts map[string]xtime.UnixNano
+}
+
+func newShardSeriesExpiry() *shardSeriesExpiry {
+	return &shardSeriesExpiry{
+		lastWriteBlockStarts: make(map[string]xtime.UnixNano),
+	}
+}
+
+// Track tracks a series purged from memory whose last write was to the
+// block starting at blockStart.
+func (e *shardSeriesExpiry) Track(id ident.ID, blockStart time.Time) {
+	if e == nil {
+		return
+	}
+
+	e.Lock()
+	key := string(id.Bytes())
+	// NB: A series may be purged again after being loaded from disk without
+	// being written to, so never move its last write backwards.
+	if existing, ok := e.lastWriteBlockStarts[key]; !ok || existing.Before(xtime.ToUnixNano(blockStart)) {
+		e.lastWriteBlockStarts[key] = xtime.ToUnixNano(blockStart)
+	}
+	e.Unlock()
+}
+
+// Expire stops tracking and returns the series whose last write was to a
+// block before earliestBlockStart. Series for which inMemory returns true
+// are still tracked since they may be written to again.
+func (e *shardSeriesExpiry) Expire(
+	earliestBlockStart time.Time,
+	inMemory func(id ident.ID) bool,
+) []ident.ID {
+	if e == nil {
+		return nil
+	}
+
+	var (
+		earliest = xtime.ToUnixNano(earliestBlockStart)
+		expired  []ident.ID
+	)
+	e.Lock()
+	for key, blockStart := range e.lastWriteBlockStarts {
+		if !blockStart.Before(earliest) {
+			continue
+		}
+		id := ident.BytesID(key)
+		if inMemory(id) {
+			continue
+		}
+		expired = append(expired, id)
+		delete(e.lastWriteBlockStarts, key)
+	}
+	e.Unlock()
+	return expired
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardSeriesExpiry(t *testing.T) {
+	var (
+		t0       = time.Unix(0, 0)
+		t1       = t0.Add(2 * time.Hour)
+		t2       = t1.Add(2 * time.Hour)
+		e        = newShardSeriesExpiry()
+		inMemory = map[string]bool{}
+	)
+	isInMemory := func(id ident.ID) bool {
+		return inMemory[id.String()]
+	}
+	e.Track(ident.StringID("foo"), t0)
+	e.Track(ident.StringID("bar"), t1)
+	e.Track(ident.StringID("baz"), t0)
+	// The last write of a series never moves backwards.
+	e.Track(ident.StringID("bar"), t0)
+	inMemory["baz"] = true
+
+	expired := e.Expire(t1, isInMemory)
+	require.Equal(t, 1, len(expired))
+	require.Equal(t, "foo", expired[0].String())
+
+	// Series in memory are expired once they are purged again.
+	inMemory["baz"] = false
+	expired = e.Expire(t2, isInMemory)
+	require.Equal(t, 2, len(expired))
+	require.Equal(t, 0, len(e.lastWriteBlockStarts))
+}
+
+func TestShardSeriesExpiryNil(t *testing.T) {
+	var e *shardSeriesExpiry
+	e.Track(ident.StringID("foo"), time.Unix(0, 0))
+	require.Nil(t, e.Expire(time.Now(), func(ident.ID) bool { return false }))
+}
@@ -21,10 +21,12 @@
 package storage
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -39,6 +41,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/storage/block"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/dbnode/storage/series/lookup"
@@ -144,6 +147,148 @@ func TestShardFlushStateNotStarted(t *testing.T) {
 	}
 }
 
+func TestShardFlushStates(t *testing.T) {
+	opts := DefaultTestOptions()
+	s := testDatabaseShard(t, opts)
+	defer s.Close()
+
+	var (
+		blockSize = defaultTestRetentionOpts.BlockSize()
+		t0        = time.Now().Truncate(blockSize).Add(-2 * blockSize)
+		t1        = t0.Add(blockSize)
+	)
+	s.markWarmFlushStateFail(t1)
+	s.markWarmFlushStateSuccess(t0)
+	s.setFlushStateColdVersion(t0, 2)
+
+	require.Equal(t, []BlockFlushState{
+		{
+			Shard:       s.ID(),
+			BlockStart:  t0,
+			WarmFlushed: true,
+			ColdVersion: 2,
+		},
+		{
+			Shard:       s.ID(),
+			BlockStart:  t1,
+			NumFailures: 1,
+		},
+	}, s.FlushStates())
+}
+
+func TestShardSeriesMetadata(t *testing.T) {
+	var (
+		blockSize = defaultTestRetentionOpts.BlockSize()
+		now       = time.Now()
+		opts      = DefaultTestOptions()
+	)
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return now
+	}))
+	s := testDatabaseShard(t, opts)
+	defer s.Close()
+	s.filesetsFn = func(string, ident.ID, uint32) (fs.FileSetFilesSlice, error) {
+		return nil, nil
+	}
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	metadata, err := s.SeriesMetadata(ident.StringID("foo"))
+	require.NoError(t, err)
+	require.False(t, metadata.InMemory)
+	require.Equal(t, 0, len(metadata.Blocks))
+
+	for _, ts := range []time.Time{now, now.Add(-time.Second)} {
+		_, wasWritten, err := s.Write(ctx, ident.StringID("foo"), ts, 1,
+			xtime.Second, nil, series.WriteOptions{})
+		require.NoError(t, err)
+		require.True(t, wasWritten)
+	}
+	blockStart := now.Add(-time.Second).Truncate(blockSize)
+	s.markWarmFlushStateSuccess(blockStart)
+
+	metadata, err = s.SeriesMetadata(ident.StringID("foo"))
+	require.NoError(t, err)
+	require.True(t, metadata.InMemory)
+	require.Equal(t, "foo", metadata.ID.String())
+	require.Equal(t, s.ID(), metadata.Shard)
+	require.True(t, now.Add(-time.Second).Equal(metadata.FirstWrite))
+	require.True(t, now.Equal(metadata.LastWrite))
+	require.Equal(t, SeriesBlockMetadata{
+		BlockStart:  blockStart,
+		Buffered:    true,
+		WarmFlushed: true,
+	}, metadata.Blocks[0])
+}
+
+func TestShardTickExpiresPurgedSeriesFromIndex(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		blockSize = defaultTestRetentionOpts.BlockSize()
+		now       = time.Now()
+		lastWrite = now.Add(-time.Minute)
+		id        = ident.StringID("foo")
+	)
+	idx := NewMocknamespaceIndex(ctrl)
+	shard := testDatabaseShardWithIndexFn(t, DefaultTestOptions(), idx)
+	defer shard.Close()
+
+	s := series.NewMockDatabaseSeries(ctrl)
+	s.EXPECT().ID().Return(id).AnyTimes()
+	s.EXPECT().IsEmpty().Return(true)
+	s.EXPECT().Tick(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(series.TickResult{}, series.ErrSeriesAllDatapointsExpired)
+	s.EXPECT().BufferMetadata().Return(series.BufferMetadata{LastWrite: lastWrite})
+	s.EXPECT().Close()
+	shard.Lock()
+	shard.insertNewShardEntryWithLock(lookup.NewEntry(s, 0))
+	shard.Unlock()
+
+	// The purged series still has data within retention so it is not
+	// expired from the index.
+	r, err := shard.Tick(context.NewNoOpCanncellable(), now, namespace.Context{})
+	require.NoError(t, err)
+	require.Equal(t, 1, r.expiredSeries)
+	require.Equal(t, 0, shard.lookup.Len())
+	require.Equal(t, 1, len(shard.seriesExpiry.lastWriteBlockStarts))
+
+	idx.EXPECT().MarkExpired([]ident.ID{ident.BytesID("foo")}).Return(nil)
+	later := now.Add(defaultTestRetentionOpts.RetentionPeriod() + 2*blockSize)
+	_, err = shard.Tick(context.NewNoOpCanncellable(), later, namespace.Context{})
+	require.NoError(t, err)
+	require.Equal(t, 0, len(shard.seriesExpiry.lastWriteBlockStarts))
+}
+
+func TestShardIsColdWrite(t *testing.T) {
+	now := time.Now()
+	opts := DefaultTestOptions()
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return now
+	}))
+	s := testDatabaseShard(t, opts)
+	defer s.Close()
+
+	var (
+		ropts  = defaultTestNs1Opts.RetentionOptions()
+		past   = now.Add(-ropts.BufferPast())
+		future = now.Add(ropts.BufferFuture())
+	)
+	// Writes outside of the buffer are rejected when cold writes are disabled.
+	require.False(t, s.isColdWrite(past))
+
+	metadata, err := namespace.NewMetadata(defaultTestNs1ID,
+		defaultTestNs1Opts.SetColdWritesEnabled(true))
+	require.NoError(t, err)
+	s.namespace = metadata
+	require.True(t, s.isColdWrite(past))
+	require.True(t, s.isColdWrite(future))
+	require.False(t, s.isColdWrite(now))
+	require.False(t, s.isColdWrite(past.Add(time.Second)))
+}
+
 func TestShardBootstrapWithError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -520,6 +665,356 @@ func TestShardColdFlushNoMergeIfNothingDirty(t *testing.T) {
 	}
 }
 
+func TestShardColdFlushMigratesSchema(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	now := time.Now()
+	nowFn := func() time.Time {
+		return now
+	}
+	opts := DefaultTestOptions()
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(nowFn))
+	blockSize := opts.SeriesOptions().RetentionOptions().BlockSize()
+	shard := testDatabaseShard(t, opts)
+	shard.bootstrapState = Bootstrapped
+	shard.newMergerFn = newMergerTestFn
+	shard.newFSMergeWithMemFn = newFSMergeWithMemTestFn
+
+	t0 := now.Truncate(blockSize).Add(-10 * blockSize)
+	t1 := t0.Add(1 * blockSize)
+	t2 := t0.Add(2 * blockSize)
+	shard.markWarmFlushStateSuccess(t0)
+	shard.markWarmFlushStateSuccess(t1)
+
+	// Only the blocks flushed before the schema changed are migrated.
+	shard.MigrateSchema()
+	shard.markWarmFlushStateSuccess(t2)
+
+	preparer := persist.NewMockFlushPreparer(ctrl)
+	fsReader := fs.NewMockDataFileSetReader(ctrl)
+	resources := coldFlushReuseableResources{
+		dirtySeries:        newDirtySeriesMap(dirtySeriesMapOptions{}),
+		dirtySeriesToWrite: make(map[xtime.UnixNano]*idList),
+		idElementPool:      newIDElementPool(nil),
+		fsReader:           fsReader,
+	}
+	nsCtx := namespace.Context{}
+
+	require.NoError(t, shard.ColdFlush(preparer, resources, nsCtx))
+	assert.Equal(t, 1, shard.RetrievableBlockColdVersion(t0))
+	assert.Equal(t, 1, shard.RetrievableBlockColdVersion(t1))
+	assert.Equal(t, 0, shard.RetrievableBlockColdVersion(t2))
+	assert.Equal(t, 0, len(shard.schemaMigrations))
+
+	// Once migrated the blocks are not merged again.
+	require.NoError(t, shard.ColdFlush(preparer, resources, nsCtx))
+	assert.Equal(t, 1, shard.RetrievableBlockColdVersion(t0))
+	assert.Equal(t, 1, shard.RetrievableBlockColdVersion(t1))
+}
+
+func TestShardColdFlushMergeSources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	now := time.Now()
+	nowFn := func() time.Time {
+		return now
+	}
+
+	source := NewMockColdFlushMergeSource(ctrl)
+	opts := DefaultTestOptions().SetColdFlushMergeSources([]ColdFlushMergeSource{source})
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(nowFn))
+	blockSize := opts.SeriesOptions().RetentionOptions().BlockSize()
+	shard := testDatabaseShard(t, opts)
+	shard.bootstrapState = Bootstrapped
+	shard.newMergerFn = newMergerTestFn
+	shard.newFSMergeWithMemFn = newFSMergeWithMemTestFn
+
+	// Only t0 has been warm flushed so the data of the source for t1 can't be
+	// merged yet.
+	t0 := now.Truncate(blockSize).Add(-10 * blockSize)
+	t1 := t0.Add(blockSize)
+	shard.markWarmFlushStateSuccess(t0)
+
+	source.EXPECT().ColdFlushBlockStarts(shard.namespace.ID(), shard.ID()).
+		Return([]xtime.UnixNano{xtime.ToUnixNano(t0), xtime.ToUnixNano(t1)}, nil)
+	source.EXPECT().MergeWith(shard.namespace.ID(), shard.ID()).
+		Return(&noopMergeWith{}, nil)
+	source.EXPECT().MarkMerged(shard.namespace.ID(), shard.ID(), xtime.ToUnixNano(t0))
+
+	resources := coldFlushReuseableResources{
+		dirtySeries:        newDirtySeriesMap(dirtySeriesMapOptions{}),
+		dirtySeriesToWrite: make(map[xtime.UnixNano]*idList),
+		idElementPool:      newIDElementPool(nil),
+		fsReader:           fs.NewMockDataFileSetReader(ctrl),
+	}
+
+	// Even though no series in memory are dirty the block the source has data
+	// for is merged.
+	preparer := persist.NewMockFlushPreparer(ctrl)
+	require.NoError(t, shard.ColdFlush(preparer, resources, namespace.Context{}))
+	assert.Equal(t, 1, shard.RetrievableBlockColdVersion(t0))
+	assert.Equal(t, 0, shard.RetrievableBlockColdVersion(t1))
+}
+
+func TestShardImportFileSetVolume(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	leaseManager := block.NewMockLeaseManager(ctrl)
+	opts := DefaultTestOptions().SetBlockLeaseManager(leaseManager)
+	blockSize := opts.SeriesOptions().RetentionOptions().BlockSize()
+	shard := testDatabaseShard(t, opts)
+	blockStart := time.Now().Truncate(blockSize).Add(-10 * blockSize)
+
+	require.Equal(t, errShardNotBootstrappedToImport,
+		shard.ImportFileSetVolume(blockStart, 1))
+
+	shard.bootstrapState = Bootstrapped
+	require.Equal(t, errShardImportBlockNotFlushed,
+		shard.ImportFileSetVolume(blockStart, 1))
+
+	shard.markWarmFlushStateSuccess(blockStart)
+	shard.setFlushStateColdVersion(blockStart, 2)
+	require.Equal(t, errShardImportVolumeNotNewer,
+		shard.ImportFileSetVolume(blockStart, 2))
+
+	leaseManager.EXPECT().UpdateOpenLeases(block.LeaseDescriptor{
+		Namespace:  shard.namespace.ID(),
+		Shard:      shard.ID(),
+		BlockStart: blockStart,
+	}, block.LeaseState{Volume: 4}).Return(block.UpdateLeasesResult{}, nil)
+	require.NoError(t, shard.ImportFileSetVolume(blockStart, 4))
+	assert.Equal(t, 4, shard.RetrievableBlockColdVersion(blockStart))
+}
+
+func TestShardRebootstrap(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := DefaultTestOptions()
+	s := testDatabaseShard(t, opts)
+	defer s.Close()
+
+	var (
+		blockSize  = s.namespace.Options().RetentionOptions().BlockSize()
+		blockStart = time.Now().Truncate(blockSize).Add(-10 * blockSize)
+		window     = xtime.Range{Start: blockStart, End: blockStart.Add(blockSize)}
+		process    = bootstrap.NewMockProcess(ctrl)
+	)
+	require.Equal(t, errShardRebootstrapRangeEmpty,
+		s.Rebootstrap(process, xtime.Range{Start: blockStart, End: blockStart}))
+	require.Equal(t, errShardNotBootstrappedToRebootstrap,
+		s.Rebootstrap(process, window))
+
+	// Flushed blocks can only be rebootstrapped with cold writes enabled.
+	s.bootstrapState = Bootstrapped
+	s.markWarmFlushStateSuccess(blockStart)
+	require.Equal(t, errShardRebootstrapColdWritesDisabled,
+		s.Rebootstrap(process, window))
+
+	metadata, err := namespace.NewMetadata(defaultTestNs1ID,
+		defaultTestNs1Opts.SetColdWritesEnabled(true))
+	require.NoError(t, err)
+	s.namespace = metadata
+
+	fooID := ident.StringID("foo")
+	fooSeries := addMockSeries(ctrl, s, fooID, ident.Tags{}, 0)
+	fooSeries.EXPECT().Load(gomock.Any(), map[xtime.UnixNano]series.BlockState{
+		xtime.ToUnixNano(blockStart): {WarmRetrievable: true},
+	}).Return(series.BootstrapResult{NumBlocksMovedToBuffer: 1}, nil)
+
+	shardResult := result.NewShardResult(0, result.NewOptions())
+	shardResult.AddSeries(fooID, ident.Tags{}, block.NewDatabaseSeriesBlocks(0))
+	dataResult := result.NewDataBootstrapResult()
+	dataResult.Add(s.ID(), shardResult, xtime.Ranges{})
+
+	process.EXPECT().
+		RunRange(metadata, []uint32{s.ID()}, window).
+		DoAndReturn(func(
+			_ namespace.Metadata,
+			_ []uint32,
+			_ xtime.Range,
+		) (bootstrap.ProcessResult, error) {
+			// The shard is neither read from nor flushed while rebootstrapping.
+			require.Equal(t, Bootstrapping, s.BootstrapState())
+			return bootstrap.ProcessResult{
+				DataResult:  dataResult,
+				IndexResult: result.NewIndexBootstrapResult(),
+			}, nil
+		})
+
+	require.NoError(t, s.Rebootstrap(process, window))
+	require.Equal(t, Bootstrapped, s.BootstrapState())
+}
+
+func TestShardRebootstrapProcessError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := DefaultTestOptions()
+	s := testDatabaseShard(t, opts)
+	defer s.Close()
+	s.bootstrapState = Bootstrapped
+
+	var (
+		blockSize  = s.namespace.Options().RetentionOptions().BlockSize()
+		blockStart = time.Now().Truncate(blockSize)
+		window     = xtime.Range{Start: blockStart, End: blockStart.Add(blockSize)}
+		process    = bootstrap.NewMockProcess(ctrl)
+		processErr = errors.New("process error")
+	)
+	process.EXPECT().
+		RunRange(s.namespace, []uint32{s.ID()}, window).
+		Return(bootstrap.ProcessResult{}, processErr)
+
+	require.Equal(t, processErr, s.Rebootstrap(process, window))
+	require.Equal(t, Bootstrapped, s.BootstrapState())
+}
+
+func TestShardDeleteSeries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdir")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := DefaultTestOptions()
+	opts = opts.SetCommitLogOptions(opts.CommitLogOptions().
+		SetFilesystemOptions(opts.CommitLogOptions().FilesystemOptions().
+			SetFilePathPrefix(dir)))
+	blockSize := opts.SeriesOptions().RetentionOptions().BlockSize()
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+	shard.newMergerFn = newMergerTestFn
+	shard.newFSMergeWithMemFn = newFSMergeWithMemTestFn
+
+	ids := []ident.ID{ident.StringID("foo"), ident.StringID("bar")}
+	require.Equal(t, errShardNotBootstrappedToDelete, shard.DeleteSeries(ids))
+
+	shard.bootstrapState = Bootstrapped
+	t0 := time.Now().Truncate(blockSize).Add(-10 * blockSize)
+	shard.markWarmFlushStateSuccess(t0)
+
+	deleted := addMockSeries(ctrl, shard, ids[0], ident.Tags{}, 0)
+	deleted.EXPECT().Close()
+	addMockSeries(ctrl, shard, ident.StringID("baz"), ident.Tags{}, 1)
+
+	require.NoError(t, shard.DeleteSeries(ids))
+	_, exists := shard.lookup.Get(ids[0])
+	require.False(t, exists)
+	_, exists = shard.lookup.Get(ident.StringID("baz"))
+	require.True(t, exists)
+	require.Equal(t, int64(1), shard.NumSeries())
+	require.ElementsMatch(t, []string{"bar", "foo"},
+		[]string{shard.DeletedSeries()[0].String(), shard.DeletedSeries()[1].String()})
+	require.Equal(t, []xtime.UnixNano{xtime.ToUnixNano(t0)},
+		shard.tombstones.PendingBlockStarts())
+
+	// Deleted series are not read from disk.
+	ctx := context.NewContext()
+	defer ctx.Close()
+	result, err := shard.ReadEncoded(ctx, ids[0], t0, t0.Add(blockSize), namespace.Context{})
+	require.NoError(t, err)
+	require.Nil(t, result)
+
+	// The tombstones are honored after a restart.
+	restarted := testDatabaseShard(t, opts)
+	defer restarted.Close()
+	require.Equal(t, 2, len(restarted.DeletedSeries()))
+
+	// A cold flush rewrites the flushed block that may hold data of the
+	// deleted series even though no series in memory are dirty.
+	resources := coldFlushReuseableResources{
+		dirtySeries:        newDirtySeriesMap(dirtySeriesMapOptions{}),
+		dirtySeriesToWrite: make(map[xtime.UnixNano]*idList),
+		idElementPool:      newIDElementPool(nil),
+		fsReader:           fs.NewMockDataFileSetReader(ctrl),
+	}
+	preparer := persist.NewMockFlushPreparer(ctrl)
+	require.NoError(t, shard.ColdFlush(preparer, resources, namespace.Context{}))
+	assert.Equal(t, 1, shard.RetrievableBlockColdVersion(t0))
+	require.Equal(t, 0, len(shard.tombstones.PendingBlockStarts()))
+	require.Equal(t, 2, len(shard.DeletedSeries()))
+}
+
+func TestShardUpdateRetentionOptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := DefaultTestOptions()
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+
+	var (
+		ropts     = shard.currSeriesOpts().RetentionOptions()
+		blockSize = ropts.BlockSize()
+		now       = time.Now()
+		shorter   = ropts.SetRetentionPeriod(ropts.RetentionPeriod() / 2)
+		flushed   = retention.FlushTimeStart(ropts, now)
+	)
+	shard.markWarmFlushStateSuccess(flushed)
+
+	for i, id := range []string{"foo", "bar"} {
+		series := addMockSeries(ctrl, shard, ident.StringID(id), ident.Tags{}, uint64(i))
+		series.EXPECT().UpdateRetentionOptions(shorter)
+	}
+	shard.UpdateRetentionOptions(shorter)
+	require.Equal(t, shorter, shard.currSeriesOpts().RetentionOptions())
+
+	// Flush states of block starts past the shortened retention are removed.
+	shard.removeAnyFlushStatesTooEarly(now)
+	require.Equal(t, fileOpNotStarted, shard.FlushState(flushed).WarmStatus)
+	require.True(t, retention.FlushTimeStart(shorter, now).After(flushed.Add(blockSize)))
+}
+
+func TestShardReadEncodedBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := DefaultTestOptions().SetSeriesCachePolicy(series.CacheAll)
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	var (
+		end        = time.Now().Truncate(time.Second)
+		start      = end.Add(-time.Hour)
+		fooReaders = [][]xio.BlockReader{{xio.BlockReader{Start: start}}}
+		barErr     = errors.New("read error")
+		foo        = addMockSeries(ctrl, shard, ident.StringID("foo"), ident.Tags{}, 0)
+		bar        = addMockSeries(ctrl, shard, ident.StringID("bar"), ident.Tags{}, 1)
+		batchIDs   = []ident.ID{
+			ident.StringID("foo"),
+			ident.StringID("missing"),
+			ident.StringID("bar"),
+		}
+	)
+	foo.EXPECT().ReadEncoded(ctx, start, end, gomock.Any()).Return(fooReaders, nil)
+	bar.EXPECT().ReadEncoded(ctx, start, end, gomock.Any()).Return(nil, barErr)
+
+	results := shard.ReadEncodedBatch(ctx, batchIDs, start, end, namespace.Context{})
+	require.Equal(t, 3, len(results))
+
+	// Results are returned in the same order as the ids.
+	require.NoError(t, results[0].Err)
+	require.Equal(t, fooReaders, results[0].Readers)
+	require.NoError(t, results[1].Err)
+	require.Nil(t, results[1].Readers)
+	require.Equal(t, barErr, results[2].Err)
+
+	// The reader counts taken for the batch are all released.
+	shard.RLock()
+	for _, id := range []ident.ID{batchIDs[0], batchIDs[2]} {
+		entry, _, err := shard.lookupEntryWithLock(id)
+		require.NoError(t, err)
+		require.Equal(t, int32(0), entry.ReaderWriterCount())
+	}
+	shard.RUnlock()
+}
+
 func newMergerTestFn(
 	reader fs.DataFileSetReader,
 	blockAllocSize int,
@@ -911,6 +1406,7 @@ func testShardWriteAsync(t *testing.T, writes []testWrite) {
 	for _, write := range writes {
 		shard.Write(ctx, ident.StringID(write.id), nowFn(), write.value, write.unit, write.annotation, series.WriteOptions{})
 	}
+	require.Equal(t, int64(len(writes)), shard.NumWrites())
 
 	for {
 		counter, ok := testReporter.Counters()["dbshard.insert-queue.inserts"]
@@ -970,6 +1466,42 @@ func TestShardTickCleanupSmallBatchSize(t *testing.T) {
 	require.Equal(t, 0, shard.lookup.Len())
 }
 
+func TestShardTickRotatesBufferMergeBudget(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := DefaultTestOptions()
+	shard := testDatabaseShard(t, opts)
+	shard.currRuntimeOptions.tickBufferMergeBudget = time.Second
+
+	var (
+		foo       = addMockTestSeries(ctrl, shard, ident.StringID("foo"))
+		bar       = addMockTestSeries(ctrl, shard, ident.StringID("bar"))
+		exhausted []bool
+	)
+	recordBudget := func(_ map[xtime.UnixNano]series.BlockState, budget *series.MergeBudget, _ namespace.Context) {
+		exhausted = append(exhausted, budget.Exhausted())
+	}
+	foo.EXPECT().Tick(gomock.Any(), gomock.Any(), gomock.Any()).
+		Do(recordBudget).Return(series.TickResult{}, nil).Times(2)
+	bar.EXPECT().Tick(gomock.Any(), gomock.Any(), gomock.Any()).
+		Do(recordBudget).Return(series.TickResult{}, nil).Times(2)
+
+	// The series before where the previous tick exhausted the budget are
+	// left to merge on a later tick.
+	shard.tickMergeStart = 1
+	_, err := shard.Tick(context.NewNoOpCanncellable(), time.Now(), namespace.Context{})
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, false}, exhausted)
+
+	// Having not exhausted the budget the next tick starts from the start.
+	require.Equal(t, 0, shard.tickMergeStart)
+	exhausted = nil
+	_, err = shard.Tick(context.NewNoOpCanncellable(), time.Now(), namespace.Context{})
+	require.NoError(t, err)
+	require.Equal(t, []bool{false, false}, exhausted)
+}
+
 // This tests ensures the shard returns an error if two ticks are triggered concurrently.
 func TestShardReturnsErrorForConcurrentTicks(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -992,7 +1524,7 @@ func TestShardReturnsErrorForConcurrentTicks(t *testing.T) {
 	closeWg.Add(2)
 
 	// wait to return the other tick has returned error
-	foo.EXPECT().Tick(gomock.Any(), gomock.Any()).Do(func(interface{}, interface{}) {
+	foo.EXPECT().Tick(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(interface{}, interface{}, interface{}) {
 		tick1Wg.Done()
 		tick2Wg.Wait()
 	}).Return(series.TickResult{}, nil)
@@ -1051,7 +1583,7 @@ func TestShardTicksStopWhenClosing(t *testing.T) {
 	orderWg.Add(1)
 	gomock.InOrder(
 		// loop until the shard is marked for Closing
-		foo.EXPECT().Tick(gomock.Any(), gomock.Any()).Do(func(interface{}, interface{}) {
+		foo.EXPECT().Tick(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(interface{}, interface{}, interface{}) {
 			orderWg.Done()
 			for {
 				if shard.isClosing() {
@@ -1129,7 +1661,7 @@ func TestPurgeExpiredSeriesWriteAfterTicking(t *testing.T) {
 	defer shard.Close()
 	id := ident.StringID("foo")
 	s := addMockSeries(ctrl, shard, id, ident.Tags{}, 0)
-	s.EXPECT().Tick(gomock.Any(), gomock.Any()).Do(func(interface{}, interface{}) {
+	s.EXPECT().Tick(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(interface{}, interface{}, interface{}) {
 		// Emulate a write taking place just after tick for this series
 		s.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any(),
 			gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
@@ -1160,7 +1692,7 @@ func TestPurgeExpiredSeriesWriteAfterPurging(t *testing.T) {
 	defer shard.Close()
 	id := ident.StringID("foo")
 	s := addMockSeries(ctrl, shard, id, ident.Tags{}, 0)
-	s.EXPECT().Tick(gomock.Any(), gomock.Any()).Do(func(interface{}, interface{}) {
+	s.EXPECT().Tick(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(interface{}, interface{}, interface{}) {
 		// Emulate a write taking place and staying open just after tick for this series
 		var err error
 		entry, err = shard.writableSeries(id, ident.EmptyTagIterator)
@@ -1261,6 +1793,48 @@ func TestShardCleanupExpiredFileSets(t *testing.T) {
 	require.Equal(t, []string{defaultTestNs1ID.String(), "0"}, deletedFiles)
 }
 
+func TestShardCleanupExpiredFileSetsDeletesObjects(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdir")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		filePathPrefix = filepath.Join(dir, "local")
+		store          = newTestObjectStore()
+		infoKey        = "data/testns1/0/fileset-0-0-info.db"
+		dataKey        = "data/testns1/0/fileset-0-0-data.db"
+		opts           = DefaultTestOptions()
+	)
+	opts = opts.SetCommitLogOptions(opts.CommitLogOptions().
+		SetFilesystemOptions(opts.CommitLogOptions().FilesystemOptions().
+			SetFilePathPrefix(filePathPrefix).
+			SetObjectStore(store)))
+	require.NoError(t, store.Put(infoKey, bytes.NewReader([]byte{1}), 1))
+	require.NoError(t, store.Put(dataKey, bytes.NewReader([]byte{2}), 1))
+
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+	// The data file was evicted from local disk so only the info file is found.
+	shard.filesetPathsBeforeFn = func(string, ident.ID, uint32, time.Time) ([]string, error) {
+		return []string{filepath.Join(filePathPrefix, filepath.FromSlash(infoKey))}, nil
+	}
+	var deletedFiles []string
+	shard.deleteFilesFn = func(files []string) error {
+		deletedFiles = append(deletedFiles, files...)
+		return nil
+	}
+
+	// The uploaded copies of the expired volumes are deleted with them,
+	// including those of the evicted files.
+	require.NoError(t, shard.CleanupExpiredFileSets(time.Now()))
+	require.Equal(t, 1, len(deletedFiles))
+	for _, key := range []string{infoKey, dataKey} {
+		exists, err := store.Exists(key)
+		require.NoError(t, err)
+		require.False(t, exists)
+	}
+}
+
 type testCloser struct {
 	called int
 }
@@ -0,0 +1,413 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+const (
+	tombstonesDir           = "tombstones"
+	tombstonesFileSuffix    = ".json"
+	tombstonesTmpFileSuffix = ".tmp"
+)
+
+// seriesTombstone records the deletion of a series along with the block
+// starts of the filesets that may still hold its data and are yet to be
+// rewritten without it, and whether the series has been written to since.
+type seriesTombstone struct {
+	ID                 []byte      `json:"id"`
+	DeletedAt          time.Time   `json:"deletedAt"`
+	PendingBlockStarts []time.Time `json:"pendingBlockStarts,omitempty"`
+	Rewritten          bool        `json:"rewritten,omitempty"`
+}
+
+// covers returns whether the block with the given start may hold data of
+// the series written before it was deleted. Until the series is written to
+// again all of its data predates the delete, once written to again only the
+// blocks that ended before the delete and the flushed blocks yet to be
+// purged are covered so that the data written since is not hidden.
+func (t *seriesTombstone) covers(blockStart time.Time, blockSize time.Duration) bool {
+	if !t.Rewritten {
+		return true
+	}
+	if !blockStart.Add(blockSize).After(t.DeletedAt) {
+		return true
+	}
+	for _, pending := range t.PendingBlockStarts {
+		if pending.Equal(blockStart) {
+			return true
+		}
+	}
+	return false
+}
+
+// shardTombstones is the set of series deleted from a shard, the tombstones
+// are persisted so that deletes are honored across restarts until the data
+// of the deleted series has been purged from every fileset.
+type shardTombstones struct {
+	sync.RWMutex
+
+	path      string
+	dirMode   os.FileMode
+	newMode   os.FileMode
+	blockSize time.Duration
+	byID      map[string]*seriesTombstone
+}
+
+func newShardTombstones(
+	fsOpts fs.Options,
+	namespace ident.ID,
+	shard uint32,
+	blockSize time.Duration,
+) *shardTombstones {
+	return &shardTombstones{
+		path: path.Join(fsOpts.FilePathPrefix(), tombstonesDir,
+			namespace.String(), fmt.Sprintf("%d%s", shard, tombstonesFileSuffix)),
+		blockSize: blockSize,
+		dirMode:   fsOpts.NewDirectoryMode(),
+		newMode:   fsOpts.NewFileMode(),
+		byID:      make(map[string]*seriesTombstone),
+	}
+}
+
+// Load loads the persisted tombstones, if any.
+func (t *shardTombstones) Load() error {
+	data, err := ioutil.ReadFile(t.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var tombstones []seriesTombstone
+	if err := json.Unmarshal(data, &tombstones); err != nil {
+		return fmt.Errorf("unable to decode tombstones %s: %v", t.path, err)
+	}
+
+	t.Lock()
+	defer t.Unlock()
+	t.byID = make(map[string]*seriesTombstone, len(tombstones))
+	for i := range tombstones {
+		t.byID[string(tombstones[i].ID)] = &tombstones[i]
+	}
+	return nil
+}
+
+// Len returns the number of tombstones.
+func (t *shardTombstones) Len() int {
+	t.RLock()
+	n := len(t.byID)
+	t.RUnlock()
+	return n
+}
+
+// IsDeleted returns whether the series with the given ID has been deleted
+// and not written to since, in which case none of its data is returned.
+func (t *shardTombstones) IsDeleted(id []byte) bool {
+	t.RLock()
+	tombstone, ok := t.byID[string(id)]
+	t.RUnlock()
+	return ok && !tombstone.Rewritten
+}
+
+// IsDeletedAt returns whether the data of the series with the given ID for
+// the block with the given start was written before the series was deleted,
+// in which case it is not returned and is purged from the filesets.
+func (t *shardTombstones) IsDeletedAt(id []byte, blockStart time.Time) bool {
+	t.RLock()
+	tombstone, ok := t.byID[string(id)]
+	t.RUnlock()
+	// NB: Tombstones are copied on update so can be read without the lock.
+	return ok && tombstone.covers(blockStart, t.blockSize)
+}
+
+// HasTombstone returns whether the series with the given ID has a tombstone,
+// i.e. whether any of its data may need to be hidden.
+func (t *shardTombstones) HasTombstone(id []byte) bool {
+	t.RLock()
+	_, ok := t.byID[string(id)]
+	t.RUnlock()
+	return ok
+}
+
+// IDs returns the IDs of the deleted series that have not been written to
+// since they were deleted.
+func (t *shardTombstones) IDs() []ident.ID {
+	t.RLock()
+	defer t.RUnlock()
+	ids := make([]ident.ID, 0, len(t.byID))
+	for _, tombstone := range t.byID {
+		if tombstone.Rewritten {
+			continue
+		}
+		ids = append(ids, ident.BytesID(tombstone.ID))
+	}
+	return ids
+}
+
+// MarkRewritten records that the deleted series with the given ID has been
+// written to again so that only the data written before the delete is
+// hidden from then on.
+func (t *shardTombstones) MarkRewritten(id []byte) error {
+	if !t.IsDeleted(id) {
+		return nil
+	}
+	return t.update(func(tombstone *seriesTombstone) (bool, bool) {
+		if tombstone.Rewritten || !bytes.Equal(tombstone.ID, id) {
+			return false, false
+		}
+		tombstone.Rewritten = true
+		return true, false
+	})
+}
+
+// Add records the deletion of the series with the given IDs at the given
+// time, the data of the series is yet to be purged from the filesets of the
+// given block starts.
+func (t *shardTombstones) Add(
+	ids []ident.ID,
+	deletedAt time.Time,
+	pendingBlockStarts []time.Time,
+) error {
+	t.Lock()
+	defer t.Unlock()
+
+	byID := t.copyWithLock()
+	for _, id := range ids {
+		tombstone := &seriesTombstone{
+			ID:        append([]byte(nil), id.Bytes()...),
+			DeletedAt: deletedAt,
+		}
+		if existing, ok := byID[id.String()]; ok {
+			// Keep any block starts still pending from an earlier delete.
+			tombstone.PendingBlockStarts = existing.PendingBlockStarts
+		}
+		tombstone.PendingBlockStarts = unionBlockStarts(
+			tombstone.PendingBlockStarts, pendingBlockStarts)
+		byID[id.String()] = tombstone
+	}
+	return t.persistWithLock(byID)
+}
+
+// PendingBlockStarts returns the block starts of the filesets that may still
+// hold data of any deleted series.
+func (t *shardTombstones) PendingBlockStarts() []xtime.UnixNano {
+	t.RLock()
+	defer t.RUnlock()
+
+	var (
+		seen   = make(map[xtime.UnixNano]struct{})
+		result []xtime.UnixNano
+	)
+	for _, tombstone := range t.byID {
+		for _, blockStart := range tombstone.PendingBlockStarts {
+			key := xtime.ToUnixNano(blockStart)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+// MarkPurged records that the fileset of the given block start has been
+// rewritten without the data of any deleted series.
+func (t *shardTombstones) MarkPurged(blockStart time.Time) error {
+	return t.update(func(tombstone *seriesTombstone) (bool, bool) {
+		changed := removeBlockStarts(tombstone, func(t time.Time) bool {
+			return t.Equal(blockStart)
+		})
+		return changed, false
+	})
+}
+
+// Expire removes the pending block starts that are no longer retained and
+// the tombstones with no data pending to be purged that were deleted before
+// the earliest retained block start, since none of the data written before
+// their deletion can be retained any longer.
+func (t *shardTombstones) Expire(earliestToRetain time.Time) error {
+	return t.update(func(tombstone *seriesTombstone) (bool, bool) {
+		changed := removeBlockStarts(tombstone, func(t time.Time) bool {
+			return t.Before(earliestToRetain)
+		})
+		remove := len(tombstone.PendingBlockStarts) == 0 &&
+			tombstone.DeletedAt.Before(earliestToRetain)
+		return changed || remove, remove
+	})
+}
+
+// update applies the given function to a copy of each tombstone, which
+// returns whether it changed the tombstone and whether the tombstone should
+// be removed, and persists the result if any tombstone changed.
+func (t *shardTombstones) update(
+	fn func(tombstone *seriesTombstone) (changed bool, remove bool),
+) error {
+	t.Lock()
+	defer t.Unlock()
+
+	var (
+		byID       = t.copyWithLock()
+		anyChanged = false
+	)
+	for key, tombstone := range byID {
+		changed, remove := fn(tombstone)
+		if remove {
+			delete(byID, key)
+		}
+		anyChanged = anyChanged || changed
+	}
+	if !anyChanged {
+		return nil
+	}
+	return t.persistWithLock(byID)
+}
+
+func (t *shardTombstones) copyWithLock() map[string]*seriesTombstone {
+	byID := make(map[string]*seriesTombstone, len(t.byID))
+	for key, tombstone := range t.byID {
+		copied := *tombstone
+		copied.PendingBlockStarts = append([]time.Time(nil),
+			tombstone.PendingBlockStarts...)
+		byID[key] = &copied
+	}
+	return byID
+}
+
+func (t *shardTombstones) persistWithLock(byID map[string]*seriesTombstone) error {
+	tombstones := make([]seriesTombstone, 0, len(byID))
+	for _, tombstone := range byID {
+		tombstones = append(tombstones, *tombstone)
+	}
+	// Sort for deterministic output.
+	sort.Slice(tombstones, func(i, j int) bool {
+		return string(tombstones[i].ID) < string(tombstones[j].ID)
+	})
+
+	data, err := json.Marshal(tombstones)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(path.Dir(t.path), t.dirMode); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that the tombstones are replaced
+	// in full.
+	tmpPath := t.path + tombstonesTmpFileSuffix
+	if err := ioutil.WriteFile(tmpPath, data, t.newMode); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, t.path); err != nil {
+		return err
+	}
+
+	t.byID = byID
+	return nil
+}
+
+func unionBlockStarts(a, b []time.Time) []time.Time {
+	result := append([]time.Time(nil), a...)
+	for _, blockStart := range b {
+		found := false
+		for _, existing := range result {
+			if existing.Equal(blockStart) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, blockStart)
+		}
+	}
+	return result
+}
+
+func removeBlockStarts(
+	tombstone *seriesTombstone,
+	remove func(t time.Time) bool,
+) bool {
+	var (
+		n        = len(tombstone.PendingBlockStarts)
+		retained = tombstone.PendingBlockStarts[:0]
+	)
+	for _, blockStart := range tombstone.PendingBlockStarts {
+		if !remove(blockStart) {
+			retained = append(retained, blockStart)
+		}
+	}
+	tombstone.PendingBlockStarts = retained
+	return len(retained) != n
+}
+
+// tombstonedSeriesReader is a fileset reader that skips the series that have
+// been deleted since the block of the fileset so that merging a fileset
+// purges their data.
+type tombstonedSeriesReader struct {
+	fs.DataFileSetReader
+
+	tombstones *shardTombstones
+	skipped    int
+}
+
+func newTombstonedSeriesReader(
+	reader fs.DataFileSetReader,
+	tombstones *shardTombstones,
+) *tombstonedSeriesReader {
+	return &tombstonedSeriesReader{
+		DataFileSetReader: reader,
+		tombstones:        tombstones,
+	}
+}
+
+func (r *tombstonedSeriesReader) Read() (
+	ident.ID, ident.TagIterator, checked.Bytes, uint32, error,
+) {
+	for {
+		id, tags, data, checksum, err := r.DataFileSetReader.Read()
+		if err != nil {
+			return id, tags, data, checksum, err
+		}
+		if !r.tombstones.IsDeletedAt(id.Bytes(), r.Range().Start) {
+			return id, tags, data, checksum, nil
+		}
+
+		r.skipped++
+		id.Finalize()
+		tags.Close()
+		data.Finalize()
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardTombstonesPersistAndExpire(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdir")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fsOpts := fs.NewOptions().SetFilePathPrefix(dir)
+	tombstones := newShardTombstones(fsOpts, ident.StringID("ns"), 1, time.Hour)
+	require.NoError(t, tombstones.Load())
+	require.Equal(t, 0, tombstones.Len())
+
+	var (
+		now = time.Now().Truncate(time.Hour)
+		t0  = now.Add(-4 * time.Hour)
+		t1  = now.Add(-2 * time.Hour)
+	)
+	require.NoError(t, tombstones.Add([]ident.ID{ident.StringID("foo")},
+		now.Add(-3*time.Hour), []time.Time{t0}))
+	require.NoError(t, tombstones.Add([]ident.ID{ident.StringID("bar")},
+		now, []time.Time{t0, t1}))
+	require.True(t, tombstones.IsDeleted([]byte("foo")))
+	require.False(t, tombstones.IsDeleted([]byte("baz")))
+	require.ElementsMatch(t, []xtime.UnixNano{xtime.ToUnixNano(t0), xtime.ToUnixNano(t1)},
+		tombstones.PendingBlockStarts())
+
+	require.NoError(t, tombstones.MarkPurged(t1))
+	require.Equal(t, []xtime.UnixNano{xtime.ToUnixNano(t0)},
+		tombstones.PendingBlockStarts())
+
+	// Reloading restores the persisted tombstones.
+	loaded := newShardTombstones(fsOpts, ident.StringID("ns"), 1, time.Hour)
+	require.NoError(t, loaded.Load())
+	require.Equal(t, 2, loaded.Len())
+	require.Equal(t, []xtime.UnixNano{xtime.ToUnixNano(t0)},
+		loaded.PendingBlockStarts())
+
+	// Once t0 is no longer retained the tombstone of foo is no longer needed
+	// while bar was deleted after the earliest retained block start.
+	require.NoError(t, loaded.Expire(t1))
+	require.Equal(t, 0, len(loaded.PendingBlockStarts()))
+	require.False(t, loaded.IsDeleted([]byte("foo")))
+	require.True(t, loaded.IsDeleted([]byte("bar")))
+}
+
+func TestTombstonedSeriesReaderSkipsDeletedSeries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdir")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tombstones := newShardTombstones(fs.NewOptions().SetFilePathPrefix(dir),
+		ident.StringID("ns"), 0, time.Hour)
+	require.NoError(t, tombstones.Add([]ident.ID{ident.StringID("foo")},
+		time.Now(), nil))
+
+	reader := fs.NewMockDataFileSetReader(ctrl)
+	reader.EXPECT().Range().Return(xtime.Range{
+		Start: time.Now().Truncate(time.Hour).Add(-2 * time.Hour),
+	}).AnyTimes()
+	gomock.InOrder(
+		reader.EXPECT().Read().Return(ident.StringID("foo"),
+			ident.EmptyTagIterator, checked.NewBytes([]byte{1}, nil), uint32(1), nil),
+		reader.EXPECT().Read().Return(ident.StringID("bar"),
+			ident.EmptyTagIterator, checked.NewBytes([]byte{2}, nil), uint32(2), nil),
+		reader.EXPECT().Read().Return(nil, nil, nil, uint32(0), io.EOF),
+	)
+
+	filtered := newTombstonedSeriesReader(reader, tombstones)
+	id, _, _, checksum, err := filtered.Read()
+	require.NoError(t, err)
+	require.Equal(t, "bar", id.String())
+	require.Equal(t, uint32(2), checksum)
+
+	_, _, _, _, err = filtered.Read()
+	require.Equal(t, io.EOF, err)
+	require.Equal(t, 1, filtered.skipped)
+}
+
+func TestShardTombstonesRewrittenSeries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdir")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		fsOpts     = fs.NewOptions().SetFilePathPrefix(dir)
+		blockSize  = time.Hour
+		now        = time.Now().Truncate(blockSize)
+		deletedAt  = now.Add(blockSize / 2)
+		pending    = now.Add(-2 * blockSize)
+		tombstones = newShardTombstones(fsOpts, ident.StringID("ns"), 1, blockSize)
+		id         = []byte("foo")
+	)
+	require.NoError(t, tombstones.Add([]ident.ID{ident.BytesID(id)},
+		deletedAt, []time.Time{now}))
+
+	// All of the data of a deleted series is hidden until written to again.
+	require.True(t, tombstones.IsDeleted(id))
+	require.True(t, tombstones.IsDeletedAt(id, now.Add(blockSize)))
+	require.Equal(t, 1, len(tombstones.IDs()))
+
+	require.NoError(t, tombstones.MarkRewritten(id))
+	require.NoError(t, tombstones.MarkRewritten([]byte("bar")))
+	require.False(t, tombstones.IsDeleted(id))
+	require.True(t, tombstones.HasTombstone(id))
+	require.Equal(t, 0, len(tombstones.IDs()))
+
+	// Only the blocks that ended before the delete and the flushed blocks
+	// yet to be purged are hidden once written to again.
+	require.True(t, tombstones.IsDeletedAt(id, pending))
+	require.True(t, tombstones.IsDeletedAt(id, now))
+	require.False(t, tombstones.IsDeletedAt(id, now.Add(blockSize)))
+
+	require.NoError(t, tombstones.MarkPurged(now))
+	require.False(t, tombstones.IsDeletedAt(id, now))
+
+	// The rewrite is persisted and is reset by a subsequent delete.
+	loaded := newShardTombstones(fsOpts, ident.StringID("ns"), 1, blockSize)
+	require.NoError(t, loaded.Load())
+	require.False(t, loaded.IsDeleted(id))
+	require.NoError(t, loaded.Add([]ident.ID{ident.BytesID(id)}, now, nil))
+	require.True(t, loaded.IsDeleted(id))
+}
@@ -23,6 +23,7 @@ package storage
 import (
 	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
@@ -48,8 +49,10 @@ type tickManagerMetrics struct {
 	tickDuration       tally.Timer
 	tickWorkDuration   tally.Timer
 	tickCancelled      tally.Counter
+	tickExpedited      tally.Counter
 	tickDeadlineMissed tally.Counter
 	tickDeadlineMet    tally.Counter
+	tickPacingSleep    tally.Timer
 }
 
 func newTickManagerMetrics(scope tally.Scope) tickManagerMetrics {
@@ -57,21 +60,28 @@ func newTickManagerMetrics(scope tally.Scope) tickManagerMetrics {
 		tickDuration:       scope.Timer("duration"),
 		tickWorkDuration:   scope.Timer("work-duration"),
 		tickCancelled:      scope.Counter("cancelled"),
+		tickExpedited:      scope.Counter("expedited"),
 		tickDeadlineMissed: scope.Counter("deadline.missed"),
 		tickDeadlineMet:    scope.Counter("deadline.met"),
+		tickPacingSleep:    scope.Timer("pacing-sleep"),
 	}
 }
 
 type tickManager struct {
-	database database
-	opts     Options
-	nowFn    clock.NowFn
-	sleepFn  sleepFn
+	database  database
+	opts      Options
+	nowFn     clock.NowFn
+	sleepFn   sleepFn
+	cpuTimeFn cpuTimeFn
 
 	metrics tickManagerMetrics
 	c       context.Cancellable
 	tokenCh chan struct{}
 
+	// Set when the remaining wait of the tick min interval should be
+	// skipped, must use atomic store and load to access.
+	expedited int32
+
 	runtimeOpts tickManagerRuntimeOptions
 }
 
@@ -95,6 +105,7 @@ func (o *tickManagerRuntimeOptions) values() tickManagerRuntimeOptionsValues {
 
 type tickManagerRuntimeOptionsValues struct {
 	tickMinInterval time.Duration
+	tickPacing      runtime.TickPacingOptions
 }
 
 func newTickManager(database database, opts Options) databaseTickManager {
@@ -103,13 +114,14 @@ func newTickManager(database database, opts Options) databaseTickManager {
 	tokenCh <- struct{}{}
 
 	mgr := &tickManager{
-		database: database,
-		opts:     opts,
-		nowFn:    opts.ClockOptions().NowFn(),
-		sleepFn:  time.Sleep,
-		metrics:  newTickManagerMetrics(scope),
-		c:        context.NewCancellable(),
-		tokenCh:  tokenCh,
+		database:  database,
+		opts:      opts,
+		nowFn:     opts.ClockOptions().NowFn(),
+		sleepFn:   time.Sleep,
+		cpuTimeFn: processCPUTime,
+		metrics:   newTickManagerMetrics(scope),
+		c:         context.NewCancellable(),
+		tokenCh:   tokenCh,
 	}
 
 	runtimeOptsMgr := opts.RuntimeOptionsManager()
@@ -120,9 +132,14 @@ func newTickManager(database database, opts Options) databaseTickManager {
 func (mgr *tickManager) SetRuntimeOptions(opts runtime.Options) {
 	mgr.runtimeOpts.set(tickManagerRuntimeOptionsValues{
 		tickMinInterval: opts.TickMinimumInterval(),
+		tickPacing:      opts.TickPacingOptions(),
 	})
 }
 
+func (mgr *tickManager) Expedite() {
+	atomic.StoreInt32(&mgr.expedited, 1)
+}
+
 func (mgr *tickManager) Tick(forceType forceType, tickStart time.Time) error {
 	if forceType == force {
 		acquired := false
@@ -162,10 +179,14 @@ func (mgr *tickManager) Tick(forceType forceType, tickStart time.Time) error {
 	// Begin ticking
 	var (
 		start    = mgr.nowFn()
+		pacer    = mgr.newTickPacer(namespaces)
 		multiErr xerrors.MultiError
 	)
 	for _, n := range namespaces {
-		multiErr = multiErr.Add(n.Tick(mgr.c, tickStart))
+		multiErr = multiErr.Add(n.Tick(mgr.c, tickStart, pacer))
+	}
+	if pacer != nil {
+		mgr.metrics.tickPacingSleep.Record(pacer.sleptDuration())
 	}
 
 	// NB(r): Always sleep for some constant period since ticking
@@ -190,6 +211,10 @@ func (mgr *tickManager) Tick(forceType forceType, tickStart time.Time) error {
 		if mgr.c.IsCancelled() {
 			break
 		}
+		if atomic.CompareAndSwapInt32(&mgr.expedited, 1, 0) {
+			mgr.metrics.tickExpedited.Inc(1)
+			break
+		}
 		mgr.sleepFn(interval)
 		// Check again at the end of each sleep to see if it
 		// has changed. Particularly useful for integration tests.
@@ -207,3 +232,26 @@ func (mgr *tickManager) Tick(forceType forceType, tickStart time.Time) error {
 
 	return multiErr.FinalError()
 }
+
+// newTickPacer returns the pacer spreading the tick of the namespaces across
+// the target duration, or nil if the ticks are not paced.
+func (mgr *tickManager) newTickPacer(namespaces []databaseNamespace) *tickPacer {
+	pacing := mgr.runtimeOpts.values().tickPacing
+	if pacing.TargetDuration <= 0 && pacing.BlockSizeFraction <= 0 {
+		return nil
+	}
+
+	var (
+		numShards    int
+		minBlockSize time.Duration
+	)
+	for _, n := range namespaces {
+		numShards += len(n.GetOwnedShards())
+		blockSize := n.Options().RetentionOptions().BlockSize()
+		if minBlockSize == 0 || blockSize < minBlockSize {
+			minBlockSize = blockSize
+		}
+	}
+	return newTickPacer(pacing.Target(minBlockSize), pacing.CPUBudget,
+		numShards, mgr.nowFn, mgr.sleepFn, mgr.cpuTimeFn)
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/x/context"
+)
+
+const (
+	// maxTickPacingSlowdown bounds the sleep after ticking a shard while the
+	// CPU usage exceeds the CPU budget to a multiple of the shard's share of
+	// the target duration.
+	maxTickPacingSlowdown = 4
+)
+
+type cpuTimeFn func() time.Duration
+
+// processCPUTime returns the user and system CPU time used by the process.
+func processCPUTime() time.Duration {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+}
+
+// tickPacer spreads a tick across a target duration by sleeping after each
+// shard is ticked until the tick is back on pace, the sleeps are lengthened
+// while the CPU usage of the process exceeds the CPU budget. A nil pacer
+// does not pace the tick.
+type tickPacer struct {
+	sync.Mutex
+
+	target    time.Duration
+	cpuBudget float64
+	numShards int
+	numCPU    int
+	nowFn     clock.NowFn
+	sleepFn   sleepFn
+	cpuTimeFn cpuTimeFn
+
+	start     time.Time
+	numTicked int
+	lastWall  time.Time
+	lastCPU   time.Duration
+	slept     int64
+}
+
+func newTickPacer(
+	target time.Duration,
+	cpuBudget float64,
+	numShards int,
+	nowFn clock.NowFn,
+	sleepFn sleepFn,
+	cpuTimeFn cpuTimeFn,
+) *tickPacer {
+	if target <= 0 || numShards <= 0 {
+		return nil
+	}
+	now := nowFn()
+	return &tickPacer{
+		target:    target,
+		cpuBudget: cpuBudget,
+		numShards: numShards,
+		numCPU:    runtime.GOMAXPROCS(0),
+		nowFn:     nowFn,
+		sleepFn:   sleepFn,
+		cpuTimeFn: cpuTimeFn,
+		start:     now,
+		lastWall:  now,
+		lastCPU:   cpuTimeFn(),
+	}
+}
+
+// pace sleeps after a shard is ticked for as long as the tick is ahead of
+// pace, or longer if over the CPU budget, bailing out if the tick is
+// cancelled.
+func (p *tickPacer) pace(c context.Cancellable) {
+	if p == nil {
+		return
+	}
+	remaining := p.nextSleep()
+	for remaining > 0 && !c.IsCancelled() {
+		sleepFor := remaining
+		if sleepFor > cancellationCheckInterval {
+			sleepFor = cancellationCheckInterval
+		}
+		p.sleepFn(sleepFor)
+		atomic.AddInt64(&p.slept, int64(sleepFor))
+		remaining -= sleepFor
+	}
+}
+
+func (p *tickPacer) nextSleep() time.Duration {
+	p.Lock()
+	defer p.Unlock()
+
+	p.numTicked++
+	now := p.nowFn()
+	share := p.target / time.Duration(p.numShards)
+	sleep := time.Duration(p.numTicked)*share - now.Sub(p.start)
+	if p.cpuBudget <= 0 {
+		return sleep
+	}
+
+	cpu := p.cpuTimeFn()
+	if wall := now.Sub(p.lastWall); wall > 0 {
+		usage := float64(cpu-p.lastCPU) / (float64(wall) * float64(p.numCPU))
+		if usage > p.cpuBudget {
+			throttle := time.Duration(float64(share) * usage / p.cpuBudget)
+			if max := maxTickPacingSlowdown * share; throttle > max {
+				throttle = max
+			}
+			if throttle > sleep {
+				sleep = throttle
+			}
+		}
+	}
+	p.lastWall = now
+	p.lastCPU = cpu
+	return sleep
+}
+
+// sleptDuration returns the time slept pacing the tick.
+func (p *tickPacer) sleptDuration() time.Duration {
+	if p == nil {
+		return 0
+	}
+	return time.Duration(atomic.LoadInt64(&p.slept))
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/x/context"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testTickPacerClock struct {
+	now   time.Time
+	cpu   time.Duration
+	slept []time.Duration
+}
+
+func (c *testTickPacerClock) nowFn() time.Time {
+	return c.now
+}
+
+func (c *testTickPacerClock) cpuTimeFn() time.Duration {
+	return c.cpu
+}
+
+func (c *testTickPacerClock) sleepFn(d time.Duration) {
+	c.now = c.now.Add(d)
+	c.slept = append(c.slept, d)
+}
+
+func (c *testTickPacerClock) work(d time.Duration, cpu time.Duration) {
+	c.now = c.now.Add(d)
+	c.cpu += cpu
+}
+
+func TestTickPacerDisabled(t *testing.T) {
+	clk := &testTickPacerClock{now: time.Now()}
+	require.Nil(t, newTickPacer(0, 0, 4, clk.nowFn, clk.sleepFn, clk.cpuTimeFn))
+	require.Nil(t, newTickPacer(time.Minute, 0, 0, clk.nowFn, clk.sleepFn, clk.cpuTimeFn))
+
+	var pacer *tickPacer
+	pacer.pace(context.NewCancellable())
+	require.Equal(t, time.Duration(0), pacer.sleptDuration())
+}
+
+func TestTickPacerPace(t *testing.T) {
+	clk := &testTickPacerClock{now: time.Now()}
+	pacer := newTickPacer(10*time.Second, 0, 4, clk.nowFn, clk.sleepFn, clk.cpuTimeFn)
+	c := context.NewCancellable()
+
+	// Sleeps in cancellation check intervals until back on pace.
+	pacer.pace(c)
+	require.Equal(t, []time.Duration{time.Second, time.Second, 500 * time.Millisecond}, clk.slept)
+
+	// Ticking a shard taking longer than its share shortens the next sleep.
+	clk.slept = nil
+	clk.work(2*time.Second, 0)
+	pacer.pace(c)
+	require.Equal(t, []time.Duration{500 * time.Millisecond}, clk.slept)
+
+	// Behind pace does not sleep.
+	clk.slept = nil
+	clk.work(5*time.Second, 0)
+	pacer.pace(c)
+	require.Empty(t, clk.slept)
+	require.Equal(t, 3*time.Second, pacer.sleptDuration())
+
+	// Cancelled ticks do not sleep.
+	pacer = newTickPacer(10*time.Second, 0, 4, clk.nowFn, clk.sleepFn, clk.cpuTimeFn)
+	c.Cancel()
+	pacer.pace(c)
+	require.Empty(t, clk.slept)
+}
+
+func TestTickPacerPaceOverCPUBudget(t *testing.T) {
+	clk := &testTickPacerClock{now: time.Now()}
+	pacer := newTickPacer(10*time.Second, 0.5, 4, clk.nowFn, clk.sleepFn, clk.cpuTimeFn)
+	pacer.numCPU = 1
+	c := context.NewCancellable()
+
+	// Using all of the CPU, twice the budget, doubles the sleep of the shard
+	// share of the target.
+	clk.work(time.Second, time.Second)
+	pacer.pace(c)
+	require.Equal(t, 5*time.Second, pacer.sleptDuration())
+
+	// Under the budget, as the sleep counts towards the CPU usage, and behind
+	// pace does not sleep.
+	clk.work(time.Second, time.Second)
+	pacer.pace(c)
+	require.Equal(t, 5*time.Second, pacer.sleptDuration())
+
+	// The sleep is bounded when far over the budget.
+	clk.work(time.Second, 10*time.Second)
+	pacer.pace(c)
+	require.Equal(t, 15*time.Second, pacer.sleptDuration())
+}
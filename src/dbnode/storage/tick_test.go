@@ -26,6 +26,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/x/context"
 
 	"github.com/golang/mock/gomock"
@@ -40,7 +41,7 @@ func TestTickManagerTickNormalFlow(t *testing.T) {
 	c := context.NewCancellable()
 
 	namespace := NewMockdatabaseNamespace(ctrl)
-	namespace.EXPECT().Tick(c, gomock.Any())
+	namespace.EXPECT().Tick(c, gomock.Any(), gomock.Any())
 	db := newMockdatabase(ctrl, namespace)
 
 	tm := newTickManager(db, opts).(*tickManager)
@@ -62,7 +63,7 @@ func TestTickManagerTickCancelled(t *testing.T) {
 	c := context.NewCancellable()
 
 	namespace := NewMockdatabaseNamespace(ctrl)
-	namespace.EXPECT().Tick(c, gomock.Any()).Do(func(context.Cancellable, time.Time) {
+	namespace.EXPECT().Tick(c, gomock.Any(), gomock.Any()).Do(func(context.Cancellable, time.Time, *tickPacer) {
 		ch1 <- struct{}{}
 		<-ch2
 	})
@@ -87,6 +88,33 @@ func TestTickManagerTickCancelled(t *testing.T) {
 	wg.Wait()
 }
 
+func TestTickManagerTickExpedited(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := DefaultTestOptions()
+	c := context.NewCancellable()
+
+	namespace := NewMockdatabaseNamespace(ctrl)
+	namespace.EXPECT().Tick(c, gomock.Any(), gomock.Any())
+	db := newMockdatabase(ctrl, namespace)
+
+	tm := newTickManager(db, opts).(*tickManager)
+	tm.c = c
+	tm.SetRuntimeOptions(runtime.NewOptions().SetTickMinimumInterval(time.Hour))
+
+	sleeps := 0
+	tm.sleepFn = func(time.Duration) {
+		sleeps++
+		tm.Expedite()
+	}
+
+	require.NoError(t, tm.Tick(noForce, time.Now()))
+	require.Equal(t, 1, sleeps)
+	require.Equal(t, int32(0), tm.expedited)
+	require.Equal(t, 1, len(tm.tokenCh))
+}
+
 func TestTickManagerTickErrorFlow(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -96,7 +124,7 @@ func TestTickManagerTickErrorFlow(t *testing.T) {
 
 	fakeErr := errors.New("fake error")
 	namespace := NewMockdatabaseNamespace(ctrl)
-	namespace.EXPECT().Tick(c, gomock.Any()).Return(fakeErr)
+	namespace.EXPECT().Tick(c, gomock.Any(), gomock.Any()).Return(fakeErr)
 	db := newMockdatabase(ctrl, namespace)
 
 	tm := newTickManager(db, opts).(*tickManager)
@@ -120,7 +148,7 @@ func TestTickManagerNonForcedTickDuringOngoingTick(t *testing.T) {
 	c := context.NewCancellable()
 
 	namespace := NewMockdatabaseNamespace(ctrl)
-	namespace.EXPECT().Tick(c, gomock.Any()).Do(func(context.Cancellable, time.Time) {
+	namespace.EXPECT().Tick(c, gomock.Any(), gomock.Any()).Do(func(context.Cancellable, time.Time, *tickPacer) {
 		ch1 <- struct{}{}
 		<-ch2
 	})
@@ -159,11 +187,11 @@ func TestTickManagerForcedTickDuringOngoingTick(t *testing.T) {
 
 	namespace := NewMockdatabaseNamespace(ctrl)
 	gomock.InOrder(
-		namespace.EXPECT().Tick(c, gomock.Any()).Do(func(context.Cancellable, time.Time) {
+		namespace.EXPECT().Tick(c, gomock.Any(), gomock.Any()).Do(func(context.Cancellable, time.Time, *tickPacer) {
 			ch1 <- struct{}{}
 			<-ch2
 		}),
-		namespace.EXPECT().Tick(c, gomock.Any()),
+		namespace.EXPECT().Tick(c, gomock.Any(), gomock.Any()),
 	)
 	db := newMockdatabase(ctrl, namespace)
 
@@ -31,11 +31,13 @@ import (
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
+	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/durability"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
 	"github.com/m3db/m3/src/dbnode/storage/series"
@@ -112,6 +114,17 @@ type Database interface {
 		annotation []byte,
 	) error
 
+	// ValidateWrite validates that a value for an ID would be accepted by a
+	// write without writing it, checking the retention windows, schema and
+	// tags of the write. A nil tags iterator validates an untagged write.
+	ValidateWrite(
+		namespace ident.ID,
+		id ident.ID,
+		tags ident.TagIterator,
+		timestamp time.Time,
+		annotation []byte,
+	) error
+
 	// BatchWriter returns a batch writer for the provided namespace that can
 	// be used to issue a batch of writes to either WriteBatch
 	// or WriteTaggedBatch.
@@ -122,6 +135,8 @@ type Database interface {
 	// ts.WriteBatch itself and will be finalized when the entire ts.WriteBatch is finalized
 	// due to their lifecycle being more complicated. Callers can still control the pooling
 	// of the annotations by using the SetFinalizeAnnotationFn on the WriteBatch itself.
+	// Similarly callers can be notified once the writes are durable in the commit log
+	// by using the SetDurableFn on the WriteBatch.
 	BatchWriter(namespace ident.ID, batchSize int) (ts.BatchWriter, error)
 
 	// WriteBatch is the same as Write, but in batch.
@@ -148,6 +163,29 @@ type Database interface {
 		opts index.QueryOptions,
 	) (index.QueryResult, error)
 
+	// QueryIDsMultiNamespace resolves the given query into known IDs across
+	// multiple namespaces, returning the union of the results with the
+	// results of each namespace recorded separately. The limit of the query
+	// options applies to the total number of results across all namespaces.
+	QueryIDsMultiNamespace(
+		ctx context.Context,
+		namespaces []ident.ID,
+		query index.Query,
+		opts index.QueryOptions,
+	) (MultiNamespaceQueryResult, error)
+
+	// QueryIDsFederated resolves the given query into known IDs across
+	// multiple namespaces, e.g. an unaggregated and an aggregated namespace,
+	// querying each namespace only for the part of the query range that the
+	// policy selects it to serve and returning the results by namespace.
+	QueryIDsFederated(
+		ctx context.Context,
+		namespaces []ident.ID,
+		query index.Query,
+		opts index.QueryOptions,
+		policy FederatedQueryPolicy,
+	) (FederatedQueryResult, error)
+
 	// AggregateQuery resolves the given query into aggregated tags.
 	AggregateQuery(
 		ctx context.Context,
@@ -164,6 +202,27 @@ type Database interface {
 		start, end time.Time,
 	) ([][]xio.BlockReader, error)
 
+	// ReadEncodedBatch retrieves encoded segments for many IDs in a single
+	// call, returning a result per ID in the same order as the IDs given.
+	ReadEncodedBatch(
+		ctx context.Context,
+		namespace ident.ID,
+		ids []ident.ID,
+		start, end time.Time,
+	) ([]ReadEncodedResult, error)
+
+	// ReadEncodedMultiNamespace retrieves encoded segments for an ID from an
+	// ordered list of namespaces, highest precedence first, merging the blocks
+	// read from each namespace with the given policy. All the namespaces must
+	// share the same block size.
+	ReadEncodedMultiNamespace(
+		ctx context.Context,
+		namespaces []ident.ID,
+		id ident.ID,
+		start, end time.Time,
+		policy MultiNamespaceReadPolicy,
+	) ([][]xio.BlockReader, error)
+
 	// FetchBlocks retrieves data blocks for a given id and a list of block
 	// start times.
 	FetchBlocks(
@@ -193,6 +252,15 @@ type Database interface {
 	// IsBootstrapped determines whether the database is bootstrapped.
 	IsBootstrapped() bool
 
+	// BootstrapProgress returns the progress of the bootstrap of the database
+	// by namespace and shard.
+	BootstrapProgress() BootstrapProgress
+
+	// BootstrapDryRun determines the time ranges of the owned shards each
+	// bootstrapper could fulfill and the time ranges that would remain
+	// unfulfilled, without bootstrapping any data.
+	BootstrapDryRun() ([]NamespaceBootstrapDryRun, error)
+
 	// IsBootstrappedAndDurable determines whether the database is bootstrapped
 	// and durable, meaning that it could recover all data in memory using only
 	// the local disk.
@@ -201,18 +269,176 @@ type Database interface {
 	// IsOverloaded determines whether the database is overloaded.
 	IsOverloaded() bool
 
+	// TrackQuery tracks the query of the context as in-flight until the
+	// context is closed, it is called once per query by the caller so that
+	// drains can wait for in-flight queries.
+	TrackQuery(ctx context.Context)
+
+	// Drain prepares the database for shutdown: it stops accepting new
+	// writes, waits for a snapshot of all the data written before the drain
+	// unless snapshots are disabled and then for the in-flight queries to
+	// complete. Writes continue to be rejected once the drain completes, a
+	// drain that is cancelled by the context or fails accepts writes again.
+	Drain(ctx context.Context) error
+
+	// DrainState returns the progress of draining the database.
+	DrainState() DatabaseDrainState
+
 	// Repair will issue a repair and return nil on success or error on error.
 	Repair() error
 
 	// Truncate truncates data for the given namespace.
 	Truncate(namespace ident.ID) (int64, error)
 
+	// DeleteSeries deletes a series from the given namespace, removing it
+	// from memory and the index immediately and from the flushed filesets
+	// with the next cold flush of each block that may hold its data.
+	DeleteSeries(ctx context.Context, namespace ident.ID, id ident.ID) error
+
+	// DeleteSeriesBatch deletes the given series from the given namespace,
+	// see DeleteSeries.
+	DeleteSeriesBatch(ctx context.Context, namespace ident.ID, ids []ident.ID) error
+
 	// BootstrapState captures and returns a snapshot of the databases'
 	// bootstrap state.
 	BootstrapState() DatabaseBootstrapState
 
 	// FlushState returns the flush state for the specified shard and block start.
 	FlushState(namespace ident.ID, shardID uint32, blockStart time.Time) (fileOpState, error)
+
+	// FlushStates returns the warm and cold flush state of every block start
+	// of the specified shard of a namespace with a flush state.
+	FlushStates(namespace ident.ID, shardID uint32) ([]BlockFlushState, error)
+
+	// FlushStateSummary summarizes the flush states of the owned shards of a
+	// namespace, including the block starts with cold flushed volumes.
+	FlushStateSummary(namespace ident.ID) (NamespaceFlushStateSummary, error)
+
+	// DataAgeHeatmap returns the breakdown of fileset data bytes by block age
+	// and shard for the specified namespace as of the last cleanup.
+	DataAgeHeatmap(namespace ident.ID) (DataAgeHeatmap, error)
+
+	// DiskUsage returns the bytes used on disk by data filesets, index
+	// filesets, snapshots and an apportioned share of the commit log, both in
+	// total and per shard, for the specified namespace as of the last cleanup.
+	DiskUsage(namespace ident.ID) (NamespaceDiskUsage, error)
+
+	// ExportFileSets exports the latest volume of each flushed block start
+	// within [start, end) of the owned shards of a namespace to the object
+	// store, followed by the manifest of the exported volumes, such as for a
+	// backup of the namespace.
+	ExportFileSets(
+		namespace ident.ID,
+		start, end time.Time,
+		store fs.ObjectStore,
+	) (FileSetExportManifest, error)
+
+	// ImportFileSets imports the volumes of a namespace exported to the object
+	// store into the owned shards of the namespace, each replacing the data of
+	// its block start, returning the manifest of the volumes imported by the
+	// volume they were imported as.
+	ImportFileSets(namespace ident.ID, store fs.ObjectStore) (FileSetExportManifest, error)
+
+	// PauseBackgroundTask pauses a background task of the specified namespace
+	// for the given duration, after which the task is automatically resumed.
+	PauseBackgroundTask(namespace ident.ID, task BackgroundTask, duration time.Duration) error
+
+	// ResumeBackgroundTask resumes a paused background task of the specified
+	// namespace.
+	ResumeBackgroundTask(namespace ident.ID, task BackgroundTask) error
+
+	// UndeleteExpiredFileSets makes the blocks of the specified namespace that
+	// fell out of retention within its expired fileset grace period queryable
+	// again, returning the retention options applied to the namespace. Only
+	// namespaces fixed by the configuration can be undeleted, the retention of
+	// namespaces of the namespace registry is extended in the registry instead.
+	UndeleteExpiredFileSets(namespace ident.ID) (retention.Options, error)
+
+	// RebootstrapShard bootstraps a time range of a shard of the specified
+	// namespace again without bootstrapping the rest of the database, such as
+	// after data corruption is detected. The shard is not read from until
+	// the time range is bootstrapped.
+	RebootstrapShard(namespace ident.ID, shard uint32, window xtime.Range) error
+
+	// PauseShardFlush pauses the warm or cold flushes of the given shards of
+	// the specified namespace for the given duration, or of the whole
+	// namespace if no shards are given.
+	PauseShardFlush(
+		namespace ident.ID,
+		shards []uint32,
+		task BackgroundTask,
+		duration time.Duration,
+	) error
+
+	// ResumeShardFlush resumes the paused warm or cold flushes of the given
+	// shards of the specified namespace, or of the whole namespace if no
+	// shards are given.
+	ResumeShardFlush(namespace ident.ID, shards []uint32, task BackgroundTask) error
+
+	// PausedShardFlushes returns the currently paused flushes of the shards
+	// of the owned namespaces.
+	PausedShardFlushes() []PausedShardFlush
+
+	// HotSeries returns up to limit of the series of the specified namespace
+	// with the highest write and read rates, or all tracked series if the
+	// limit is zero.
+	HotSeries(namespace ident.ID, limit int) (HotSeriesResult, error)
+
+	// BlockCardinalities returns the estimated number of distinct series of
+	// the data and index blocks of a shard of the specified namespace that
+	// start in the range [start, end).
+	BlockCardinalities(
+		namespace ident.ID,
+		shard uint32,
+		start, end time.Time,
+	) (BlockCardinalities, error)
+
+	// SeriesMetadata returns the tags, first and last write, block flush
+	// states and fileset volumes of a series of the specified namespace.
+	SeriesMetadata(
+		ctx context.Context,
+		namespace ident.ID,
+		id ident.ID,
+	) (SeriesMetadata, error)
+
+	// PausedBackgroundTasks returns the currently paused background tasks of
+	// all namespaces.
+	PausedBackgroundTasks() []PausedBackgroundTask
+
+	// DataDurability returns the durability of the data of the specified
+	// namespace within [start, end) as time ascending ranges.
+	DataDurability(namespace ident.ID, start, end time.Time) (durability.Ranges, error)
+
+	// ShardRebalanceAdvice returns the load of the shards owned by the node
+	// and the shard moves recommended to even it out, the moves are not
+	// executed.
+	ShardRebalanceAdvice(opts ShardRebalanceAdviceOptions) (ShardRebalanceAdvice, error)
+
+	// StartNamespaceMigration starts migrating a namespace to a target
+	// namespace with a different block size: writes to the source namespace
+	// are mirrored to the target namespace while the history of the source
+	// namespace is backfilled into it and verified, after which the migration
+	// is ready for cutover.
+	StartNamespaceMigration(source, target ident.ID) (NamespaceMigrationState, error)
+
+	// CutoverNamespaceMigration atomically switches reads and queries of the
+	// source namespace of a migration that is ready for cutover to its
+	// target namespace, writes continue to be mirrored to both.
+	CutoverNamespaceMigration(source ident.ID) (NamespaceMigrationState, error)
+
+	// AbortNamespaceMigration stops the migration of a namespace, its writes
+	// are no longer mirrored and its reads are no longer switched to the
+	// target namespace, which is left as is.
+	AbortNamespaceMigration(source ident.ID) error
+
+	// NamespaceMigrations returns the state of the migrations of all
+	// namespaces.
+	NamespaceMigrations() []NamespaceMigrationState
+
+	// CopyNamespace copies the datapoints of a namespace within [start, end)
+	// to a target namespace, re-encoding them to the block size and
+	// retention of the target namespace.
+	CopyNamespace(source, target ident.ID, start, end time.Time) (NamespaceCopyResult, error)
 }
 
 // database is the internal database interface
@@ -269,7 +495,7 @@ type databaseNamespace interface {
 	GetIndex() (namespaceIndex, error)
 
 	// Tick performs any regular maintenance operations.
-	Tick(c context.Cancellable, tickStart time.Time) error
+	Tick(c context.Cancellable, tickStart time.Time, pacer *tickPacer) error
 
 	// Write writes a data point.
 	Write(
@@ -292,6 +518,15 @@ type databaseNamespace interface {
 		annotation []byte,
 	) (ts.Series, bool, error)
 
+	// ValidateWrite validates that a value for an ID would be accepted by a
+	// write without writing it.
+	ValidateWrite(
+		id ident.ID,
+		tags ident.TagIterator,
+		timestamp time.Time,
+		annotation []byte,
+	) error
+
 	// QueryIDs resolves the given query into known IDs.
 	QueryIDs(
 		ctx context.Context,
@@ -313,6 +548,14 @@ type databaseNamespace interface {
 		start, end time.Time,
 	) ([][]xio.BlockReader, error)
 
+	// ReadEncodedBatch reads data for many ids within [start, end),
+	// returning a result per id in the same order as the ids given.
+	ReadEncodedBatch(
+		ctx context.Context,
+		ids []ident.ID,
+		start, end time.Time,
+	) []ReadEncodedResult
+
 	// FetchBlocks retrieves data blocks for a given id and a list of block
 	// start times.
 	FetchBlocks(
@@ -347,11 +590,21 @@ type databaseNamespace interface {
 		flush persist.IndexFlush,
 	) error
 
+	// SnapshotIndex snapshots the in-memory index data not yet flushed.
+	SnapshotIndex(
+		snapshotTime time.Time,
+		flush persist.IndexFlush,
+	) error
+
 	// ColdFlush flushes unflushed in-memory ColdWrites.
 	ColdFlush(
 		flush persist.FlushPreparer,
 	) error
 
+	// NumPendingColdWrites returns the number of cold writes to the owned
+	// shards that are pending a cold flush.
+	NumPendingColdWrites() int64
+
 	// Snapshot snapshots unflushed in-memory WarmWrites.
 	Snapshot(blockStart, snapshotTime time.Time, flush persist.SnapshotPreparer) error
 
@@ -363,6 +616,41 @@ type databaseNamespace interface {
 	// Truncate truncates the in-memory data for this namespace.
 	Truncate() (int64, error)
 
+	// DeleteSeries deletes the given series from the namespace.
+	DeleteSeries(ctx context.Context, ids []ident.ID) error
+
+	// UpdateRetentionOptions applies updated retention options to the
+	// namespace, its shards, index and block retriever without a restart.
+	// The block size of the namespace cannot be changed.
+	UpdateRetentionOptions(ropts retention.Options) error
+
+	// UpdateIndexEnabled enables or disables indexing of the namespace
+	// without a restart. Indexing can only be enabled at runtime for
+	// namespaces that were created with indexing enabled.
+	UpdateIndexEnabled(enabled bool) error
+
+	// UndeleteExpiredFileSets extends the retention of the namespace by its
+	// expired fileset grace period so that the blocks which fell out of
+	// retention within the grace period are queryable again, returning the
+	// retention options applied. The extension is not persisted and lasts
+	// until the node restarts with the configured retention.
+	UndeleteExpiredFileSets() (retention.Options, error)
+
+	// RebootstrapShard bootstraps a time range of an owned shard that is
+	// already bootstrapped again using the bootstrap process.
+	RebootstrapShard(
+		process bootstrap.Process,
+		shardID uint32,
+		window xtime.Range,
+	) error
+
+	// BootstrapDryRun determines the time ranges of the owned shards the
+	// bootstrap process could fulfill without bootstrapping any data.
+	BootstrapDryRun(
+		start time.Time,
+		process bootstrap.Process,
+	) (bootstrap.DryRunResult, error)
+
 	// Repair repairs the namespace data for a given time range
 	Repair(repairer databaseShardRepairer, tr xtime.Range) error
 
@@ -372,6 +660,41 @@ type databaseNamespace interface {
 
 	// FlushState returns the flush state for the specified shard and block start.
 	FlushState(shardID uint32, blockStart time.Time) (fileOpState, error)
+
+	// FlushStates returns the flush state of every block start of the
+	// specified shard with a flush state.
+	FlushStates(shardID uint32) ([]BlockFlushState, error)
+
+	// FlushStateSummary summarizes the flush states of the owned shards.
+	FlushStateSummary() NamespaceFlushStateSummary
+
+	// PauseBackgroundTask pauses a background task of the namespace until
+	// the given time, replacing any existing pause of the task.
+	PauseBackgroundTask(task BackgroundTask, until time.Time)
+
+	// ResumeBackgroundTask resumes a paused background task of the namespace.
+	ResumeBackgroundTask(task BackgroundTask)
+
+	// HotSeries returns up to limit of the series of the owned shards with the
+	// highest write and read rates, if hot series are tracked.
+	HotSeries(limit int) HotSeriesResult
+
+	// BlockCardinalities returns the estimated number of distinct series of
+	// the data and index blocks of the shard that start in the range
+	// [start, end), if cardinalities are tracked.
+	BlockCardinalities(shardID uint32, start, end time.Time) (BlockCardinalities, error)
+
+	// SeriesMetadata returns the tags, writes, flush states and fileset
+	// volumes of a series of the namespace.
+	SeriesMetadata(id ident.ID) (SeriesMetadata, error)
+
+	// PausedBackgroundTasks returns the paused background tasks of the
+	// namespace and the time at which each pause expires.
+	PausedBackgroundTasks() map[BackgroundTask]time.Time
+
+	// DataDurability returns the durability of the data within [start, end)
+	// as time ascending ranges, given the start of the last successful snapshot.
+	DataDurability(start, end, lastSnapshotStart time.Time) durability.Ranges
 }
 
 // Shard is a time series database shard.
@@ -432,6 +755,15 @@ type databaseShard interface {
 		nsCtx namespace.Context,
 	) ([][]xio.BlockReader, error)
 
+	// ReadEncodedBatch reads data for many ids within [start, end) looking
+	// up all the series under a single acquisition of the shard lock.
+	ReadEncodedBatch(
+		ctx context.Context,
+		ids []ident.ID,
+		start, end time.Time,
+		nsCtx namespace.Context,
+	) []ReadEncodedResult
+
 	// FetchBlocks retrieves data blocks for a given id and a list of block
 	// start times.
 	FetchBlocks(
@@ -466,6 +798,14 @@ type databaseShard interface {
 		bootstrappedSeries *result.Map,
 	) error
 
+	// Rebootstrap bootstraps a time range of the shard again once it is
+	// bootstrapped using the bootstrap process, the shard is bootstrapping
+	// until the time range is loaded into its series and the index.
+	Rebootstrap(
+		process bootstrap.Process,
+		window xtime.Range,
+	) error
+
 	// WarmFlush flushes the WarmWrites in this shard.
 	WarmFlush(
 		blockStart time.Time,
@@ -491,6 +831,34 @@ type databaseShard interface {
 	// FlushState returns the flush state for this shard at block start.
 	FlushState(blockStart time.Time) fileOpState
 
+	// FlushStates returns the flush state of every block start of the shard
+	// with a flush state, ordered from the earliest.
+	FlushStates() []BlockFlushState
+
+	// ImportFileSetVolume makes a fileset volume written to disk other than by
+	// a flush, such as one restored from a backup, the retrievable volume of
+	// a block start that has been flushed. The volume must be newer than the
+	// retrievable volume and replaces it in full.
+	ImportFileSetVolume(blockStart time.Time, volume int) error
+
+	// MigrateSchema marks the flushed filesets of the shard to be re-encoded
+	// with the latest schema of the namespace by subsequent cold flushes.
+	MigrateSchema()
+
+	// DeleteSeries removes the given series from memory and records
+	// tombstones for them so that their data is purged from the flushed
+	// filesets by subsequent cold flushes. Series written to again after
+	// being deleted only have the data written before the delete hidden.
+	DeleteSeries(ids []ident.ID) error
+
+	// DeletedSeries returns the IDs of the series that have been deleted
+	// and not written to since.
+	DeletedSeries() []ident.ID
+
+	// UpdateRetentionOptions applies updated retention options to the shard
+	// and each of its series.
+	UpdateRetentionOptions(ropts retention.Options)
+
 	// CleanupExpiredFileSets removes expired fileset files.
 	CleanupExpiredFileSets(earliestToRetain time.Time) error
 
@@ -509,6 +877,42 @@ type databaseShard interface {
 
 	// TagsFromSeriesID returns the series tags from a series ID.
 	TagsFromSeriesID(seriesID ident.ID) (ident.Tags, bool, error)
+
+	// NumWrites returns the number of datapoints written to the shard since
+	// it was created.
+	NumWrites() int64
+
+	// NumPendingColdWrites returns the number of cold writes to the shard
+	// that are pending a cold flush.
+	NumPendingColdWrites() int64
+
+	// PauseFlush pauses the warm or cold flushes of the shard until the given
+	// time, replacing any existing pause.
+	PauseFlush(task BackgroundTask, until time.Time)
+
+	// ResumeFlush resumes the paused warm or cold flushes of the shard.
+	ResumeFlush(task BackgroundTask)
+
+	// IsFlushPaused returns whether the warm or cold flushes of the shard
+	// are paused.
+	IsFlushPaused(task BackgroundTask) bool
+
+	// PausedFlushes returns the paused flushes of the shard and when each
+	// pause expires.
+	PausedFlushes() map[BackgroundTask]time.Time
+
+	// HotSeries returns up to limit of the series of the shard with the
+	// highest write and read rates, if hot series are tracked.
+	HotSeries(limit int) (writes []HotSeries, reads []HotSeries)
+
+	// BlockCardinalities returns the estimated number of distinct series of
+	// the data and index blocks of the shard that start in the range
+	// [start, end), if cardinalities are tracked.
+	BlockCardinalities(start, end time.Time) BlockCardinalities
+
+	// SeriesMetadata returns the tags, writes, flush states and fileset
+	// volumes of a series of the shard.
+	SeriesMetadata(id ident.ID) (SeriesMetadata, error)
 }
 
 // namespaceIndex indexes namespace writes.
@@ -538,6 +942,34 @@ type namespaceIndex interface {
 		opts index.AggregationOptions,
 	) (index.AggregateQueryResult, error)
 
+	// AggregateQueryStream streams the distinct tags matched by an all or
+	// field query in order to the function instead of holding them in
+	// results.
+	AggregateQueryStream(
+		ctx context.Context,
+		query index.Query,
+		opts index.AggregationOptions,
+		fn index.AggregateStreamFn,
+	) (index.AggregateStreamResult, error)
+
+	// Completion returns the tag names that start with the field prefix
+	// along with their values that start with the value prefix, relying
+	// purely on the indexed FSTs to back tag autocompletion.
+	Completion(
+		ctx context.Context,
+		fieldPrefix []byte,
+		valuePrefix []byte,
+		opts index.CompletionOptions,
+	) (index.CompletionResult, error)
+
+	// FieldCardinality returns the cardinality of each field of the blocks
+	// overlapping the time range, newest block first.
+	FieldCardinality(start, end time.Time) ([]index.BlockFieldCardinality, error)
+
+	// BlockStates returns the state of every block of the index along with
+	// its volumes flushed to disk, newest block first.
+	BlockStates() ([]index.BlockState, error)
+
 	// Bootstrap bootstraps the index the provided segments.
 	Bootstrap(
 		bootstrapResults result.IndexResults,
@@ -546,6 +978,24 @@ type namespaceIndex interface {
 	// BootstrapsDone returns the number of completed bootstraps.
 	BootstrapsDone() uint
 
+	// UpdateRetentionOptions updates the retention used to determine which
+	// writes are accepted and when blocks expire.
+	UpdateRetentionOptions(ropts retention.Options)
+
+	// SetEnabled sets whether writes are indexed, while disabled writes are
+	// not indexed and no new blocks are created.
+	SetEnabled(value bool)
+
+	// MarkDeleted marks the series with the given IDs as deleted in every
+	// index block so that queries and aggregations exclude them unless their
+	// options include deleted series.
+	MarkDeleted(ids []ident.ID) error
+
+	// MarkExpired marks the series with the given IDs as expired in every
+	// index block since they no longer have any data within retention so that
+	// queries and aggregations exclude them.
+	MarkExpired(ids []ident.ID) error
+
 	// CleanupExpiredFileSets removes expired fileset files. Expiration is calcuated
 	// using the provided `t` as the frame of reference.
 	CleanupExpiredFileSets(t time.Time) error
@@ -561,6 +1011,15 @@ type namespaceIndex interface {
 		shards []databaseShard,
 	) error
 
+	// Snapshot persists the segments of the index blocks that have not been
+	// flushed yet so that bootstrapping does not need to index them again,
+	// and removes the snapshots no longer needed.
+	Snapshot(
+		shards []databaseShard,
+		snapshotTime time.Time,
+		flush persist.IndexFlush,
+	) error
+
 	// Close will release the index resources and close the index.
 	Close() error
 }
@@ -589,6 +1048,11 @@ type namespaceIndexInsertQueue interface {
 	// based on the result of the execution. The returned wait group can be used
 	// if the insert is required to be synchronous.
 	InsertBatch(batch *index.WriteBatch) (*sync.WaitGroup, error)
+
+	// ResetRecentlyIndexed resets the series recently indexed by the queue
+	// before the next batch is processed, it must be called when series are
+	// removed from the index so that they are indexed again when written.
+	ResetRecentlyIndexed()
 }
 
 // databaseBootstrapManager manages the bootstrap process.
@@ -603,6 +1067,18 @@ type databaseBootstrapManager interface {
 	// Bootstrap performs bootstrapping for all namespaces and shards owned.
 	Bootstrap() error
 
+	// BootstrapProgress returns the progress of the current or last bootstrap
+	// by namespace and shard.
+	BootstrapProgress() BootstrapProgress
+
+	// RebootstrapShard bootstraps a time range of a shard of the namespace
+	// again once the database is bootstrapped.
+	RebootstrapShard(ns databaseNamespace, shard uint32, window xtime.Range) error
+
+	// BootstrapDryRun determines the time ranges each bootstrapper could
+	// fulfill for all namespaces and shards owned, without bootstrapping.
+	BootstrapDryRun() ([]NamespaceBootstrapDryRun, error)
+
 	// Report reports runtime information.
 	Report()
 }
@@ -627,6 +1103,14 @@ type databaseCleanupManager interface {
 
 	// Report reports runtime information.
 	Report()
+
+	// DataAgeHeatmap returns the data age heatmap for a namespace computed
+	// during the last cleanup, if any.
+	DataAgeHeatmap(namespace ident.ID) (DataAgeHeatmap, bool)
+
+	// DiskUsage returns the disk usage breakdown for a namespace computed
+	// during the last cleanup, if any.
+	DiskUsage(namespace ident.ID) (NamespaceDiskUsage, bool)
 }
 
 // databaseFileSystemManager manages the database related filesystem activities.
@@ -662,6 +1146,46 @@ type databaseFileSystemManager interface {
 	// LastSuccessfulSnapshotStartTime returns the start time of the last
 	// successful snapshot, if any.
 	LastSuccessfulSnapshotStartTime() (time.Time, bool)
+
+	// DataAgeHeatmap returns the data age heatmap for a namespace computed
+	// during the last cleanup, if any.
+	DataAgeHeatmap(namespace ident.ID) (DataAgeHeatmap, bool)
+
+	// DiskUsage returns the disk usage breakdown for a namespace computed
+	// during the last cleanup, if any.
+	DiskUsage(namespace ident.ID) (NamespaceDiskUsage, bool)
+	// ExportFileSets exports the latest volume of each flushed block start
+	// within [start, end) of the owned shards of a namespace to the object
+	// store, followed by the manifest of the exported volumes.
+	ExportFileSets(
+		namespace ident.ID,
+		start, end time.Time,
+		store fs.ObjectStore,
+	) (FileSetExportManifest, error)
+
+	// ImportFileSets imports the volumes of a namespace exported to the object
+	// store into the owned shards of the namespace, returning the manifest of
+	// the volumes imported by the volume they were imported as.
+	ImportFileSets(namespace ident.ID, store fs.ObjectStore) (FileSetExportManifest, error)
+
+	// PauseShardFlush pauses the warm or cold flushes of the given owned
+	// shards of a namespace until the given time, or of the whole namespace
+	// if no shards are given.
+	PauseShardFlush(
+		namespace ident.ID,
+		shards []uint32,
+		task BackgroundTask,
+		until time.Time,
+	) error
+
+	// ResumeShardFlush resumes the paused warm or cold flushes of the given
+	// owned shards of a namespace, or of the whole namespace if no shards
+	// are given.
+	ResumeShardFlush(namespace ident.ID, shards []uint32, task BackgroundTask) error
+
+	// PausedShardFlushes returns the currently paused flushes of the shards
+	// of the owned namespaces.
+	PausedShardFlushes() []PausedShardFlush
 }
 
 // databaseShardRepairer repairs in-memory data for a shard.
@@ -699,6 +1223,10 @@ type databaseTickManager interface {
 	// tick if force is true. It returns nil if a new tick has
 	// completed successfully, and an error otherwise.
 	Tick(forceType forceType, tickStart time.Time) error
+
+	// Expedite hints that the next tick should begin without waiting out
+	// the remainder of the tick minimum interval.
+	Expedite()
 }
 
 // databaseMediator mediates actions among various database managers.
@@ -716,6 +1244,18 @@ type databaseMediator interface {
 	// Bootstrap bootstraps the database with file operations performed at the end.
 	Bootstrap() error
 
+	// BootstrapProgress returns the progress of the current or last bootstrap
+	// by namespace and shard.
+	BootstrapProgress() BootstrapProgress
+
+	// RebootstrapShard bootstraps a time range of a shard of the namespace
+	// again once the database is bootstrapped.
+	RebootstrapShard(ns databaseNamespace, shard uint32, window xtime.Range) error
+
+	// BootstrapDryRun determines the time ranges each bootstrapper could
+	// fulfill for all namespaces and shards owned, without bootstrapping.
+	BootstrapDryRun() ([]NamespaceBootstrapDryRun, error)
+
 	// DisableFileOps disables file operations.
 	DisableFileOps()
 
@@ -725,6 +1265,11 @@ type databaseMediator interface {
 	// Tick performs a tick.
 	Tick(runType runType, forceType forceType) error
 
+	// Expedite hints that the next tick, and the flush that follows it,
+	// should begin without waiting out the remainder of the tick minimum
+	// interval.
+	Expedite()
+
 	// Repair repairs the database.
 	Repair() error
 
@@ -737,6 +1282,50 @@ type databaseMediator interface {
 	// LastSuccessfulSnapshotStartTime returns the start time of the last
 	// successful snapshot, if any.
 	LastSuccessfulSnapshotStartTime() (time.Time, bool)
+
+	// MemoryPressureLevel returns the memory pressure level of the last check
+	// of the memory pressure monitor.
+	MemoryPressureLevel() MemoryPressureLevel
+
+	// DataAgeHeatmap returns the data age heatmap for a namespace computed
+	// during the last cleanup, if any.
+	DataAgeHeatmap(namespace ident.ID) (DataAgeHeatmap, bool)
+
+	// DiskUsage returns the disk usage breakdown for a namespace computed
+	// during the last cleanup, if any.
+	DiskUsage(namespace ident.ID) (NamespaceDiskUsage, bool)
+	// ExportFileSets exports the latest volume of each flushed block start
+	// within [start, end) of the owned shards of a namespace to the object
+	// store, followed by the manifest of the exported volumes.
+	ExportFileSets(
+		namespace ident.ID,
+		start, end time.Time,
+		store fs.ObjectStore,
+	) (FileSetExportManifest, error)
+
+	// ImportFileSets imports the volumes of a namespace exported to the object
+	// store into the owned shards of the namespace, returning the manifest of
+	// the volumes imported by the volume they were imported as.
+	ImportFileSets(namespace ident.ID, store fs.ObjectStore) (FileSetExportManifest, error)
+
+	// PauseShardFlush pauses the warm or cold flushes of the given owned
+	// shards of a namespace until the given time, or of the whole namespace
+	// if no shards are given.
+	PauseShardFlush(
+		namespace ident.ID,
+		shards []uint32,
+		task BackgroundTask,
+		until time.Time,
+	) error
+
+	// ResumeShardFlush resumes the paused warm or cold flushes of the given
+	// owned shards of a namespace, or of the whole namespace if no shards
+	// are given.
+	ResumeShardFlush(namespace ident.ID, shards []uint32, task BackgroundTask) error
+
+	// PausedShardFlushes returns the currently paused flushes of the shards
+	// of the owned namespaces.
+	PausedShardFlushes() []PausedShardFlush
 }
 
 // databaseNamespaceWatch watches for namespace updates.
@@ -751,6 +1340,25 @@ type databaseNamespaceWatch interface {
 	Close() error
 }
 
+// ColdFlushMergeSource is a source of series data other than the series in
+// memory that is merged into the filesets of a shard when it is cold flushed,
+// such as a dataset of corrections to historical data.
+type ColdFlushMergeSource interface {
+	// ColdFlushBlockStarts returns the block starts of a shard that the source
+	// has data to be merged into.
+	ColdFlushBlockStarts(nsID ident.ID, shard uint32) ([]xtime.UnixNano, error)
+
+	// MergeWith returns the merge target used to merge the data of the source
+	// into the filesets of a shard. Data from the source is overridden by data
+	// in memory for the same timestamp, and a series must be considered handled
+	// once it has been read so that it is not returned by ForEachRemaining.
+	MergeWith(nsID ident.ID, shard uint32) (fs.MergeWith, error)
+
+	// MarkMerged is called once the data of the source for a block start of a
+	// shard has been merged into a new fileset volume.
+	MarkMerged(nsID ident.ID, shard uint32, blockStart xtime.UnixNano)
+}
+
 // Options represents the options for storage.
 type Options interface {
 	// Validate validates assumptions baked into the code.
@@ -975,6 +1583,94 @@ type Options interface {
 
 	// BlockLeaseManager returns the block leaser.
 	BlockLeaseManager() block.LeaseManager
+
+	// SetDataAgeBucketBoundaries sets the block age boundaries used to bucket
+	// fileset data in the data age heatmaps.
+	SetDataAgeBucketBoundaries(value []time.Duration) Options
+
+	// DataAgeBucketBoundaries returns the block age boundaries used to bucket
+	// fileset data in the data age heatmaps.
+	DataAgeBucketBoundaries() []time.Duration
+
+	// SetColdFlushMergeSources sets the sources of data other than the series
+	// in memory that are merged into filesets during cold flushes.
+	SetColdFlushMergeSources(value []ColdFlushMergeSource) Options
+
+	// ColdFlushMergeSources returns the sources of data other than the series
+	// in memory that are merged into filesets during cold flushes.
+	ColdFlushMergeSources() []ColdFlushMergeSource
+
+	// SetFileSetScrubInterval sets the interval at which all flushed data
+	// filesets are verified in the background, zero disables scrubbing.
+	SetFileSetScrubInterval(value time.Duration) Options
+
+	// FileSetScrubInterval returns the interval at which all flushed data
+	// filesets are verified in the background, zero disables scrubbing.
+	FileSetScrubInterval() time.Duration
+
+	// SetSnapshotRetentionCount sets the number of most recent snapshots
+	// retained by cleanup, the most recent snapshot is always retained.
+	SetSnapshotRetentionCount(value int) Options
+
+	// SnapshotRetentionCount returns the number of most recent snapshots
+	// retained by cleanup, the most recent snapshot is always retained.
+	SnapshotRetentionCount() int
+
+	// SetSnapshotRetentionPeriod sets the age after which snapshots other than
+	// the most recent are deleted by cleanup, zero disables the age limit.
+	SetSnapshotRetentionPeriod(value time.Duration) Options
+
+	// SnapshotRetentionPeriod returns the age after which snapshots other than
+	// the most recent are deleted by cleanup, zero disables the age limit.
+	SnapshotRetentionPeriod() time.Duration
+
+	// SetMemoryPressureOptions sets the heap watermarks and check interval of
+	// the memory pressure monitor.
+	SetMemoryPressureOptions(value MemoryPressureOptions) Options
+
+	// MemoryPressureOptions returns the heap watermarks and check interval of
+	// the memory pressure monitor.
+	MemoryPressureOptions() MemoryPressureOptions
+
+	// SetDurabilityProbeOptions sets the options of the durability prober
+	// that writes and verifies canary series.
+	SetDurabilityProbeOptions(value DurabilityProbeOptions) Options
+
+	// DurabilityProbeOptions returns the options of the durability prober
+	// that writes and verifies canary series.
+	DurabilityProbeOptions() DurabilityProbeOptions
+
+	// SetHotSeriesOptions sets the options for tracking the series of each
+	// shard with the highest write and read rates.
+	SetHotSeriesOptions(value HotSeriesOptions) Options
+
+	// HotSeriesOptions returns the options for tracking the series of each
+	// shard with the highest write and read rates.
+	HotSeriesOptions() HotSeriesOptions
+
+	// SetOverloadPolicy sets the policy deciding from the load signals of the
+	// database whether it is overloaded and should shed load.
+	SetOverloadPolicy(value OverloadPolicy) Options
+
+	// OverloadPolicy returns the policy deciding from the load signals of the
+	// database whether it is overloaded and should shed load.
+	OverloadPolicy() OverloadPolicy
+
+	// SetColdFlushOptions sets the options scheduling the cold flushes of
+	// namespaces, those set by the runtime options take precedence.
+	SetColdFlushOptions(value runtime.ColdFlushOptions) Options
+
+	// ColdFlushOptions returns the options scheduling the cold flushes of
+	// namespaces, those set by the runtime options take precedence.
+	ColdFlushOptions() runtime.ColdFlushOptions
+
+	// SetCardinalityOptions sets the options for estimating the number of
+	// distinct series of the data and index blocks of each shard.
+	SetCardinalityOptions(value CardinalityOptions) Options
+
+	// CardinalityOptions returns the options for estimating the number of
+	// distinct series of the data and index blocks of each shard.
+	CardinalityOptions() CardinalityOptions
 }
 
 // DatabaseBootstrapState stores a snapshot of the bootstrap state for all shards across all
@@ -1003,6 +1699,217 @@ const (
 	Bootstrapped
 )
 
+// BootstrapProgress is the progress of the bootstrap of the database.
+type BootstrapProgress struct {
+	State           BootstrapState
+	Start           time.Time
+	PercentComplete float64
+	// EstimatedCompletion is zero when the completion cannot be estimated.
+	EstimatedCompletion time.Time
+	Namespaces          []NamespaceBootstrapProgress
+}
+
+// NamespaceBootstrapProgress is the progress of the bootstrap of a namespace.
+type NamespaceBootstrapProgress struct {
+	Namespace string
+	// Bootstrapper is the bootstrapper that last started bootstrapping the
+	// namespace.
+	Bootstrapper    string
+	PercentComplete float64
+	Shards          []ShardBootstrapProgress
+	// AvailableFrom is the start of the most recent time range bootstrapped
+	// for all the shards, zero until the most recent time range completes.
+	AvailableFrom time.Time
+}
+
+// ShardBootstrapProgress is the progress of the bootstrap of a shard.
+type ShardBootstrapProgress struct {
+	Shard           uint32
+	PercentComplete float64
+	RemainingRanges []xtime.Range
+	// AvailableRanges are the time ranges that completed bootstrapping both
+	// their data and index.
+	AvailableRanges []xtime.Range
+}
+
+// NamespaceBootstrapDryRun is the result of a bootstrap dry run of the owned
+// shards of a namespace.
+type NamespaceBootstrapDryRun struct {
+	Namespace   string
+	DataResult  bootstrap.AvailabilityResult
+	IndexResult bootstrap.AvailabilityResult
+}
+
+// DataAgeBucket is the number of bytes of fileset data for blocks whose age
+// is within [MinAge, MaxAge), a zero MaxAge means the bucket is unbounded.
+type DataAgeBucket struct {
+	MinAge time.Duration
+	MaxAge time.Duration
+	Bytes  int64
+}
+
+// DataAgeHeatmap is the breakdown of the fileset data bytes of a namespace
+// by block age, both in total and per shard.
+type DataAgeHeatmap struct {
+	Namespace  ident.ID
+	ComputedAt time.Time
+	Buckets    []DataAgeBucket
+	Shards     map[uint32][]DataAgeBucket
+}
+
+// DiskUsage is a breakdown of the bytes used on disk. Index and commit log
+// bytes are shared by shards so when reported per shard they are an evenly
+// apportioned share of the total.
+type DiskUsage struct {
+	DataBytes      int64
+	IndexBytes     int64
+	SnapshotBytes  int64
+	CommitLogBytes int64
+}
+
+// NamespaceDiskUsage is the disk usage of a namespace, both in total and per
+// shard.
+type NamespaceDiskUsage struct {
+	Namespace  ident.ID
+	ComputedAt time.Time
+	Total      DiskUsage
+	Shards     map[uint32]DiskUsage
+}
+
+// FileSetExportVolume is a fileset volume of a block start of a shard that
+// was exported or imported.
+type FileSetExportVolume struct {
+	Shard      uint32    `json:"shard"`
+	BlockStart time.Time `json:"blockStart"`
+	Volume     int       `json:"volume"`
+}
+
+// FileSetExportManifest is the manifest of the fileset volumes of a namespace
+// exported to an object store, which is written once every volume it lists
+// has been exported.
+type FileSetExportManifest struct {
+	Namespace string                `json:"namespace"`
+	Start     time.Time             `json:"start"`
+	End       time.Time             `json:"end"`
+	Volumes   []FileSetExportVolume `json:"volumes"`
+}
+
+// MultiNamespaceQueryResult is the union of the results of a query across
+// multiple namespaces.
+type MultiNamespaceQueryResult struct {
+	// Results are the results for each namespace queried, the namespace of each
+	// entry is recorded by the Namespace() of the results it belongs to.
+	Results    []index.QueryResults
+	Exhaustive bool
+}
+
+// NamespaceMigrationPhase is the phase of a namespace migration.
+type NamespaceMigrationPhase uint
+
+const (
+	// NamespaceMigrationBackfilling mirrors writes to the target namespace
+	// while backfilling the history of the source namespace into it.
+	NamespaceMigrationBackfilling NamespaceMigrationPhase = iota
+	// NamespaceMigrationVerifying mirrors writes to the target namespace
+	// while verifying that it has every series of the source namespace.
+	NamespaceMigrationVerifying
+	// NamespaceMigrationReadyForCutover mirrors writes to the target
+	// namespace, which has been verified, until cutover.
+	NamespaceMigrationReadyForCutover
+	// NamespaceMigrationCutover mirrors writes to the target namespace and
+	// switches reads and queries of the source namespace to it.
+	NamespaceMigrationCutover
+	// NamespaceMigrationFailed no longer mirrors writes after the backfill
+	// or verification of the target namespace failed.
+	NamespaceMigrationFailed
+)
+
+// NamespaceMigrationState is the state of the migration of a namespace to a
+// target namespace with a different block size, persisted so that the
+// migration resumes where it left off when the node restarts.
+type NamespaceMigrationState struct {
+	Source string                  `json:"source"`
+	Target string                  `json:"target"`
+	Phase  NamespaceMigrationPhase `json:"phase"`
+	// MirrorStart is when writes started being mirrored to the target.
+	MirrorStart time.Time `json:"mirrorStart"`
+	// BackfillStart and BackfillEnd bound the history of the source namespace
+	// backfilled into the target, writes with timestamps after BackfillEnd
+	// can only have been written once writes were mirrored.
+	BackfillStart time.Time `json:"backfillStart"`
+	BackfillEnd   time.Time `json:"backfillEnd"`
+	// BackfilledUntil is the end of the history backfilled so far by shard.
+	BackfilledUntil map[uint32]time.Time `json:"backfilledUntil"`
+	// MissingSeries is the number of series of the source namespace that
+	// verification did not find in the target namespace.
+	MissingSeries int64  `json:"missingSeries"`
+	Error         string `json:"error,omitempty"`
+}
+
+// NamespaceCopyResult is the result of copying the datapoints of a namespace
+// within a time range to a target namespace.
+type NamespaceCopyResult struct {
+	Source     string
+	Target     string
+	Start      time.Time
+	End        time.Time
+	Series     int64
+	Datapoints int64
+}
+
+// ReadEncodedResult is the result of reading the encoded segments for a
+// single series as part of a batch read.
+type ReadEncodedResult struct {
+	Readers [][]xio.BlockReader
+	Err     error
+}
+
+// MultiNamespaceReadPolicy is the policy used to merge the blocks read for the
+// same series from an ordered list of namespaces.
+type MultiNamespaceReadPolicy uint
+
+const (
+	// MultiNamespaceReadOverride reads each block from the highest precedence
+	// namespace that has data for it, lower precedence namespaces only fill in
+	// the blocks that higher precedence namespaces have no data for.
+	MultiNamespaceReadOverride MultiNamespaceReadPolicy = iota
+	// MultiNamespaceReadMerge reads each block from all the namespaces that
+	// have data for it, with the blocks of lower precedence namespaces first.
+	MultiNamespaceReadMerge
+)
+
+// FederatedQueryPolicy is the policy used to select the part of the query
+// range that each namespace of a federated query serves.
+type FederatedQueryPolicy uint
+
+const (
+	// FederatedQueryAllResolutions queries every namespace for the whole
+	// query range.
+	FederatedQueryAllResolutions FederatedQueryPolicy = iota
+	// FederatedQueryFinestResolution queries each part of the query range
+	// only from the namespace with the shortest retention that retains it,
+	// namespaces with shorter retentions being assumed to have the finer
+	// resolutions as is the case of unaggregated and aggregated namespaces.
+	FederatedQueryFinestResolution
+)
+
+// FederatedQueryResult is the result of a query across multiple namespaces.
+type FederatedQueryResult struct {
+	// Results are the results keyed by namespace, namespaces that were not
+	// selected to serve any part of the query range have no entry.
+	Results    map[string]FederatedNamespaceQueryResult
+	Exhaustive bool
+}
+
+// FederatedNamespaceQueryResult is the result of a federated query for a
+// single namespace.
+type FederatedNamespaceQueryResult struct {
+	// Range is the part of the query range the namespace was queried for.
+	Range      xtime.Range
+	Results    index.QueryResults
+	Exhaustive bool
+}
+
 type newFSMergeWithMemFn func(
 	shard databaseShard,
 	retriever series.QueryableBlockRetriever,
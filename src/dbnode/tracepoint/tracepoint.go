@@ -37,6 +37,12 @@ const (
 	// DBQueryIDs is the operation name for the db QueryIDs path.
 	DBQueryIDs = "storage.db.QueryIDs"
 
+	// DBQueryIDsMultiNamespace is the operation name for the db QueryIDsMultiNamespace path.
+	DBQueryIDsMultiNamespace = "storage.db.QueryIDsMultiNamespace"
+
+	// DBQueryIDsFederated is the operation name for the db QueryIDsFederated path.
+	DBQueryIDsFederated = "storage.db.QueryIDsFederated"
+
 	// NSQueryIDs is the operation name for the dbNamespace QueryIDs path.
 	NSQueryIDs = "storage.dbNamespace.QueryIDs"
 
@@ -46,6 +52,12 @@ const (
 	// NSIdxAggregateQuery is the operation name for the nsIndex AggregateQuery path.
 	NSIdxAggregateQuery = "storage.nsIndex.AggregateQuery"
 
+	// NSIdxAggregateQueryStream is the operation name for the nsIndex AggregateQueryStream path.
+	NSIdxAggregateQueryStream = "storage.nsIndex.AggregateQueryStream"
+
+	// NSIdxCompletion is the operation name for the nsIndex Completion path.
+	NSIdxCompletion = "storage.nsIndex.Completion"
+
 	// NSIdxQueryHelper is the operation name for the nsIndex query path.
 	NSIdxQueryHelper = "storage.nsIndex.query"
 
@@ -58,6 +70,10 @@ const (
 	// BlockQuery is the operation name for the index block query path.
 	BlockQuery = "storage/index.block.Query"
 
+	// BlockQuerySegment is the operation name for the search of a segment by
+	// the index block query path.
+	BlockQuerySegment = "storage/index.block.querySegment"
+
 	// BlockAggregate is the operation name for the index block aggregate path.
 	BlockAggregate = "storage/index.block.Aggregate"
 )
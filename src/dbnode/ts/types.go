@@ -31,6 +31,23 @@ import (
 // the WriteBatch itself is finalized.
 type FinalizeAnnotationFn func(b []byte)
 
+// BatchDurability is the durability metadata assigned to a WriteBatch by the
+// commitlog, it is passed to the durable callback of the WriteBatch.
+type BatchDurability struct {
+	// Sequence is the sequence number assigned to the batch by the commitlog,
+	// it increases monotonically for the lifetime of the commitlog.
+	Sequence uint64
+	// CommitLogIndex is the index of the commitlog file the batch was written to.
+	CommitLogIndex int64
+	// NumWrites is the number of writes of the batch written to the commitlog.
+	NumWrites int
+}
+
+// BatchDurableFn is a function that will be called once the writes of a
+// WriteBatch are durable, i.e. once the commitlog file they were written to
+// has been fsync'd, or with an error if they could not be made durable.
+type BatchDurableFn func(durability BatchDurability, err error)
+
 // Write is a write for the commitlog.
 type Write struct {
 	Series     Series
@@ -105,6 +122,9 @@ type WriteBatch interface {
 	Reset(batchSize int, ns ident.ID)
 	Finalize()
 
+	// DurableFn returns the function to call once the writes are durable.
+	DurableFn() BatchDurableFn
+
 	// Returns the WriteBatch's internal capacity. Used by the pool to throw
 	// away batches that have grown too large.
 	cap() int
@@ -133,4 +153,10 @@ type BatchWriter interface {
 	)
 
 	SetFinalizeAnnotationFn(f FinalizeAnnotationFn)
+
+	// SetDurableFn sets a function that is called exactly once after the writes
+	// of the batch are durable in the commitlog, given that writing the batch
+	// returned no error. It allows upstream consumers, e.g. of a queue, to only
+	// acknowledge the writes once they cannot be lost.
+	SetDurableFn(f BatchDurableFn)
 }
@@ -34,6 +34,7 @@ type writeBatch struct {
 	// provide a function to finalize all annotations once the
 	// writeBatch itself gets finalized.
 	finalizeAnnotationFn FinalizeAnnotationFn
+	durableFn            BatchDurableFn
 	finalizeFn           func(WriteBatch)
 }
 
@@ -91,6 +92,7 @@ func (b *writeBatch) Reset(
 	b.writes = writes
 	b.ns = ns
 	b.finalizeAnnotationFn = nil
+	b.durableFn = nil
 }
 
 func (b *writeBatch) Iter() []BatchWrite {
@@ -113,6 +115,16 @@ func (b *writeBatch) SetFinalizeAnnotationFn(f FinalizeAnnotationFn) {
 	b.finalizeAnnotationFn = f
 }
 
+// Set the function that will be called once the writes of the WriteBatch
+// are durable in the commitlog.
+func (b *writeBatch) SetDurableFn(f BatchDurableFn) {
+	b.durableFn = f
+}
+
+func (b *writeBatch) DurableFn() BatchDurableFn {
+	return b.durableFn
+}
+
 func (b *writeBatch) Finalize() {
 	if b.finalizeAnnotationFn != nil {
 		for _, write := range b.writes {
@@ -125,6 +137,7 @@ func (b *writeBatch) Finalize() {
 		}
 	}
 	b.finalizeAnnotationFn = nil
+	b.durableFn = nil
 
 	b.ns = nil
 
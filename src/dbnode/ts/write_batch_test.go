@@ -251,6 +251,7 @@ func TestBatchWriterFinalizer(t *testing.T) {
 
 	writeBatch := NewWriteBatch(batchSize, namespace, finalizeFn)
 	writeBatch.SetFinalizeAnnotationFn(finalizeAnnotationFn)
+	writeBatch.SetDurableFn(func(BatchDurability, error) {})
 
 	for i, write := range writes {
 		writeBatch.AddTagged(
@@ -264,8 +265,10 @@ func TestBatchWriterFinalizer(t *testing.T) {
 	}
 
 	require.Equal(t, 3, len(writeBatch.Iter()))
+	require.NotNil(t, writeBatch.DurableFn())
 	writeBatch.Finalize()
 	require.Equal(t, 0, len(writeBatch.Iter()))
+	require.Nil(t, writeBatch.DurableFn())
 	require.Equal(t, 1, numFinalized)
 	require.Equal(t, 3, numAnnotationsFinalized)
 }
@@ -0,0 +1,283 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package workload
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/instrument"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	syntheticMetricName = "synthetic"
+)
+
+var (
+	errGeneratorAlreadyStarted = errors.New("workload generator already started")
+	errGeneratorClosed         = errors.New("workload generator is closed")
+)
+
+type generator struct {
+	sync.Mutex
+
+	db           storage.Database
+	opts         Options
+	nowFn        clock.NowFn
+	logger       *zap.Logger
+	scope        tally.Scope
+	samplingRate float64
+
+	started bool
+	closed  bool
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewGenerator returns a new workload generator for the database.
+func NewGenerator(db storage.Database, opts Options) (Generator, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	iOpts := opts.InstrumentOptions()
+	return &generator{
+		db:           db,
+		opts:         opts,
+		nowFn:        opts.ClockOptions().NowFn(),
+		logger:       iOpts.Logger(),
+		scope:        iOpts.MetricsScope().SubScope("synthetic-workload"),
+		samplingRate: iOpts.MetricsSamplingRate(),
+		closeCh:      make(chan struct{}),
+	}, nil
+}
+
+func (g *generator) Start() error {
+	g.Lock()
+	defer g.Unlock()
+
+	if g.closed {
+		return errGeneratorClosed
+	}
+	if g.started {
+		return errGeneratorAlreadyStarted
+	}
+
+	workloads := g.opts.Workloads()
+	for _, w := range workloads {
+		if _, ok := g.db.Namespace(w.Namespace); !ok {
+			return fmt.Errorf("workload namespace does not exist: %s", w.Namespace.String())
+		}
+	}
+
+	g.started = true
+	for i, w := range workloads {
+		load := newNamespaceLoad(w, g.opts.Seed()+int64(i), g.nowFn(),
+			g.scope.Tagged(map[string]string{"namespace": w.Namespace.String()}),
+			g.samplingRate)
+		g.logger.Info("starting synthetic workload",
+			zap.String("namespace", w.Namespace.String()),
+			zap.Int("cardinality", w.Cardinality),
+			zap.Int("writesPerSecond", w.WritesPerSecond),
+			zap.Int("readsPerSecond", w.ReadsPerSecond))
+		g.wg.Add(1)
+		go g.run(load)
+	}
+	return nil
+}
+
+func (g *generator) Stop() error {
+	g.Lock()
+	if g.closed {
+		g.Unlock()
+		return errGeneratorClosed
+	}
+	g.closed = true
+	close(g.closeCh)
+	g.Unlock()
+
+	g.wg.Wait()
+	return nil
+}
+
+func (g *generator) run(load *namespaceLoad) {
+	defer g.wg.Done()
+
+	ticker := time.NewTicker(g.opts.TickInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.closeCh:
+			return
+		case <-ticker.C:
+			g.tick(load, g.nowFn())
+		}
+	}
+}
+
+// tick issues the writes and reads of a workload accrued since the previous
+// tick, replacing series first if the churn interval has elapsed.
+func (g *generator) tick(load *namespaceLoad, now time.Time) {
+	load.maybeChurn(now)
+
+	elapsed := now.Sub(load.lastTick)
+	load.lastTick = now
+
+	numWrites := load.writes.take(elapsed)
+	for i := 0; i < numWrites; i++ {
+		g.write(load, now)
+	}
+	numReads := load.reads.take(elapsed)
+	for i := 0; i < numReads; i++ {
+		g.read(load, now)
+	}
+}
+
+func (g *generator) write(load *namespaceLoad, now time.Time) {
+	id, tags := load.series(load.rand.Intn(load.workload.Cardinality))
+
+	ctx := context.NewContext()
+	start := g.nowFn()
+	err := g.db.WriteTagged(ctx, load.workload.Namespace, id,
+		ident.NewTagsIterator(tags), now, load.rand.Float64(), xtime.Second, nil)
+	load.metrics.write.ReportSuccessOrError(err, g.nowFn().Sub(start))
+	ctx.Close()
+}
+
+func (g *generator) read(load *namespaceLoad, now time.Time) {
+	id, _ := load.series(load.rand.Intn(load.workload.Cardinality))
+
+	ctx := context.NewContext()
+	start := g.nowFn()
+	_, err := g.db.ReadEncoded(ctx, load.workload.Namespace, id,
+		now.Add(-load.workload.ReadRange), now)
+	load.metrics.read.ReportSuccessOrError(err, g.nowFn().Sub(start))
+	ctx.Close()
+}
+
+type namespaceLoadMetrics struct {
+	write   instrument.MethodMetrics
+	read    instrument.MethodMetrics
+	churned tally.Counter
+}
+
+// namespaceLoad is the state of the load generated against a namespace, it
+// is only accessed by the goroutine generating the load.
+type namespaceLoad struct {
+	workload NamespaceWorkload
+	rand     *rand.Rand
+	metrics  namespaceLoadMetrics
+
+	// Each slot holds one series at a time, churning a slot increments its
+	// generation which replaces the series with a new one.
+	generations []uint64
+	churnCursor int
+	lastChurn   time.Time
+	lastTick    time.Time
+
+	writes rateCredit
+	reads  rateCredit
+}
+
+func newNamespaceLoad(
+	workload NamespaceWorkload,
+	seed int64,
+	now time.Time,
+	scope tally.Scope,
+	samplingRate float64,
+) *namespaceLoad {
+	return &namespaceLoad{
+		workload: workload,
+		rand:     rand.New(rand.NewSource(seed)),
+		metrics: namespaceLoadMetrics{
+			write:   instrument.NewMethodMetrics(scope, "write", samplingRate),
+			read:    instrument.NewMethodMetrics(scope, "read", samplingRate),
+			churned: scope.Counter("churned-series"),
+		},
+		generations: make([]uint64, workload.Cardinality),
+		lastChurn:   now,
+		lastTick:    now,
+		writes:      rateCredit{perSecond: workload.WritesPerSecond},
+		reads:       rateCredit{perSecond: workload.ReadsPerSecond},
+	}
+}
+
+func (l *namespaceLoad) maybeChurn(now time.Time) {
+	if l.workload.ChurnPercent <= 0 || now.Sub(l.lastChurn) < l.workload.ChurnInterval {
+		return
+	}
+	l.lastChurn = now
+
+	n := int(math.Ceil(l.workload.ChurnPercent * float64(len(l.generations))))
+	for i := 0; i < n; i++ {
+		l.generations[l.churnCursor]++
+		l.churnCursor = (l.churnCursor + 1) % len(l.generations)
+	}
+	l.metrics.churned.Inc(int64(n))
+}
+
+// series returns the ID and tags of the series currently held by a slot.
+func (l *namespaceLoad) series(slot int) (ident.ID, ident.Tags) {
+	var (
+		slotValue       = strconv.Itoa(slot)
+		generationValue = strconv.FormatUint(l.generations[slot], 10)
+	)
+	id := ident.StringID(syntheticMetricName + "." + slotValue + "." + generationValue)
+	tags := ident.NewTags(
+		ident.StringTag("__name__", syntheticMetricName),
+		ident.StringTag("slot", slotValue),
+		ident.StringTag("generation", generationValue),
+	)
+	return id, tags
+}
+
+// rateCredit accrues operations at a rate per second and carries over the
+// fractional remainder so that low rates are still honored with short ticks,
+// at most a second of operations is accrued so that a stall is not followed
+// by a burst of operations.
+type rateCredit struct {
+	perSecond int
+	credit    float64
+}
+
+func (c *rateCredit) take(elapsed time.Duration) int {
+	if c.perSecond <= 0 || elapsed <= 0 {
+		return 0
+	}
+	c.credit = math.Min(c.credit+float64(c.perSecond)*elapsed.Seconds(),
+		float64(c.perSecond))
+	n := math.Floor(c.credit)
+	c.credit -= n
+	return int(n)
+}
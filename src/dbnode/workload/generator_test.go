@@ -0,0 +1,169 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package workload
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestWorkloadOptions(workloads ...NamespaceWorkload) Options {
+	return NewOptions().
+		SetWorkloads(workloads).
+		SetSeed(1)
+}
+
+func TestOptionsValidate(t *testing.T) {
+	valid := NamespaceWorkload{
+		Namespace:       ident.StringID("testns"),
+		Cardinality:     10,
+		WritesPerSecond: 100,
+	}
+	require.NoError(t, newTestWorkloadOptions(valid).Validate())
+	require.Equal(t, errTickIntervalNotPositive,
+		newTestWorkloadOptions(valid).SetTickInterval(0).Validate())
+	require.Error(t, newTestWorkloadOptions(valid, valid).Validate())
+
+	for _, test := range []struct {
+		update   func(w *NamespaceWorkload)
+		expected error
+	}{
+		{func(w *NamespaceWorkload) { w.Namespace = nil }, errNamespaceNotSet},
+		{func(w *NamespaceWorkload) { w.Cardinality = 0 }, errCardinalityNotPositive},
+		{func(w *NamespaceWorkload) { w.ChurnPercent = 1.5 }, errChurnPercentInvalid},
+		{func(w *NamespaceWorkload) { w.ChurnPercent = 0.1 }, errChurnIntervalNotSet},
+		{func(w *NamespaceWorkload) { w.WritesPerSecond = -1 }, errRatesNegative},
+		{func(w *NamespaceWorkload) { w.ReadsPerSecond = 1 }, errReadRangeNotPositive},
+	} {
+		w := valid
+		test.update(&w)
+		require.Equal(t, test.expected, newTestWorkloadOptions(w).Validate())
+	}
+}
+
+func TestGeneratorStartUnknownNamespace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ns := ident.StringID("testns")
+	db := storage.NewMockDatabase(ctrl)
+	db.EXPECT().Namespace(ns).Return(nil, false)
+
+	g, err := NewGenerator(db, newTestWorkloadOptions(NamespaceWorkload{
+		Namespace:   ns,
+		Cardinality: 1,
+	}))
+	require.NoError(t, err)
+	require.Error(t, g.Start())
+	require.NoError(t, g.Stop())
+	require.Equal(t, errGeneratorClosed, g.Start())
+}
+
+func TestGeneratorTickWritesAndReads(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		ns       = ident.StringID("testns")
+		now      = time.Now().Truncate(time.Second)
+		db       = storage.NewMockDatabase(ctrl)
+		workload = NamespaceWorkload{
+			Namespace:       ns,
+			Cardinality:     4,
+			WritesPerSecond: 10,
+			ReadsPerSecond:  2,
+			ReadRange:       time.Hour,
+		}
+	)
+	g, err := NewGenerator(db, newTestWorkloadOptions(workload))
+	require.NoError(t, err)
+
+	written := make(map[string]struct{})
+	db.EXPECT().
+		WriteTagged(gomock.Any(), ns, gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Do(func(_, _ interface{}, id ident.ID, _, _, _, _, _ interface{}) {
+			written[id.String()] = struct{}{}
+		}).
+		Return(nil).
+		Times(5)
+	db.EXPECT().
+		ReadEncoded(gomock.Any(), ns, gomock.Any(), gomock.Any(), gomock.Any()).
+		Do(func(_, _, _ interface{}, start, end time.Time) {
+			require.Equal(t, time.Hour, end.Sub(start))
+		}).
+		Return(nil, nil).
+		Times(1)
+
+	// Half a second accrues half of the writes and reads per second.
+	load := newNamespaceLoad(workload, 1, now, g.(*generator).scope, 1)
+	g.(*generator).tick(load, now.Add(500*time.Millisecond))
+	require.True(t, len(written) > 0)
+	for id := range written {
+		require.Regexp(t, `^synthetic\.[0-3]\.0$`, id)
+	}
+}
+
+func TestNamespaceLoadChurn(t *testing.T) {
+	var (
+		now      = time.Now()
+		workload = NamespaceWorkload{
+			Namespace:     ident.StringID("testns"),
+			Cardinality:   4,
+			ChurnPercent:  0.5,
+			ChurnInterval: time.Minute,
+		}
+		load = newNamespaceLoad(workload, 1, now, tally.NoopScope, 1)
+	)
+
+	id, tags := load.series(0)
+	require.Equal(t, "synthetic.0.0", id.String())
+	require.Equal(t, 3, len(tags.Values()))
+
+	// Nothing is churned until the churn interval has elapsed.
+	load.maybeChurn(now.Add(time.Second))
+	require.Equal(t, []uint64{0, 0, 0, 0}, load.generations)
+
+	load.maybeChurn(now.Add(time.Minute))
+	require.Equal(t, []uint64{1, 1, 0, 0}, load.generations)
+	load.maybeChurn(now.Add(2 * time.Minute))
+	require.Equal(t, []uint64{1, 1, 1, 1}, load.generations)
+
+	id, _ = load.series(0)
+	require.Equal(t, "synthetic.0.1", id.String())
+}
+
+func TestRateCredit(t *testing.T) {
+	c := rateCredit{perSecond: 3}
+	require.Equal(t, 0, c.take(100*time.Millisecond))
+	require.Equal(t, 0, c.take(200*time.Millisecond))
+	require.Equal(t, 1, c.take(100*time.Millisecond))
+
+	// A stall accrues at most a second of operations.
+	require.Equal(t, 3, c.take(time.Minute))
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package workload
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+const (
+	// defaultTickInterval is the default interval at which each workload
+	// issues the operations accrued since the previous tick.
+	defaultTickInterval = 100 * time.Millisecond
+)
+
+var (
+	errTickIntervalNotPositive = errors.New("workload tick interval must be positive")
+	errNamespaceNotSet         = errors.New("workload namespace is not set")
+	errDuplicateNamespace      = errors.New("workload namespace is specified more than once")
+	errCardinalityNotPositive  = errors.New("workload cardinality must be positive")
+	errChurnPercentInvalid     = errors.New("workload churn percent must be >= 0 and <= 1")
+	errChurnIntervalNotSet     = errors.New("workload churn interval must be positive when churning series")
+	errRatesNegative           = errors.New("workload writes and reads per second must not be negative")
+	errReadRangeNotPositive    = errors.New("workload read range must be positive when reading series")
+)
+
+type options struct {
+	clockOpts      clock.Options
+	instrumentOpts instrument.Options
+	workloads      []NamespaceWorkload
+	tickInterval   time.Duration
+	seed           int64
+}
+
+// NewOptions creates a new set of workload generator options.
+func NewOptions() Options {
+	return &options{
+		clockOpts:      clock.NewOptions(),
+		instrumentOpts: instrument.NewOptions(),
+		tickInterval:   defaultTickInterval,
+		seed:           time.Now().UnixNano(),
+	}
+}
+
+func (o *options) Validate() error {
+	if o.tickInterval <= 0 {
+		return errTickIntervalNotPositive
+	}
+	namespaces := make(map[string]struct{}, len(o.workloads))
+	for _, w := range o.workloads {
+		if err := validateWorkload(w); err != nil {
+			return err
+		}
+		if _, ok := namespaces[w.Namespace.String()]; ok {
+			return fmt.Errorf("%v: %s", errDuplicateNamespace, w.Namespace.String())
+		}
+		namespaces[w.Namespace.String()] = struct{}{}
+	}
+	return nil
+}
+
+func validateWorkload(w NamespaceWorkload) error {
+	if w.Namespace == nil || len(w.Namespace.Bytes()) == 0 {
+		return errNamespaceNotSet
+	}
+	if w.Cardinality <= 0 {
+		return errCardinalityNotPositive
+	}
+	if w.ChurnPercent < 0 || w.ChurnPercent > 1 {
+		return errChurnPercentInvalid
+	}
+	if w.ChurnPercent > 0 && w.ChurnInterval <= 0 {
+		return errChurnIntervalNotSet
+	}
+	if w.WritesPerSecond < 0 || w.ReadsPerSecond < 0 {
+		return errRatesNegative
+	}
+	if w.ReadsPerSecond > 0 && w.ReadRange <= 0 {
+		return errReadRangeNotPositive
+	}
+	return nil
+}
+
+func (o *options) SetClockOptions(value clock.Options) Options {
+	opts := *o
+	opts.clockOpts = value
+	return &opts
+}
+
+func (o *options) ClockOptions() clock.Options {
+	return o.clockOpts
+}
+
+func (o *options) SetInstrumentOptions(value instrument.Options) Options {
+	opts := *o
+	opts.instrumentOpts = value
+	return &opts
+}
+
+func (o *options) InstrumentOptions() instrument.Options {
+	return o.instrumentOpts
+}
+
+func (o *options) SetWorkloads(value []NamespaceWorkload) Options {
+	opts := *o
+	opts.workloads = value
+	return &opts
+}
+
+func (o *options) Workloads() []NamespaceWorkload {
+	return o.workloads
+}
+
+func (o *options) SetTickInterval(value time.Duration) Options {
+	opts := *o
+	opts.tickInterval = value
+	return &opts
+}
+
+func (o *options) TickInterval() time.Duration {
+	return o.tickInterval
+}
+
+func (o *options) SetSeed(value int64) Options {
+	opts := *o
+	opts.seed = value
+	return &opts
+}
+
+func (o *options) Seed() int64 {
+	return o.seed
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package workload generates synthetic write and read load against test
+// namespaces from within the node, bypassing the network, so that the
+// performance of the storage path can be measured in situ.
+package workload
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+// Generator generates synthetic load against the database.
+type Generator interface {
+	// Start starts generating load, it returns an error if the namespace of
+	// any of the workloads does not exist.
+	Start() error
+
+	// Stop stops generating load and waits for in flight operations to
+	// complete, the generator cannot be started again once stopped.
+	Stop() error
+}
+
+// NamespaceWorkload is the synthetic load generated against a namespace.
+type NamespaceWorkload struct {
+	// Namespace is the namespace the load is generated against.
+	Namespace ident.ID
+
+	// Cardinality is the number of distinct series written at any time.
+	Cardinality int
+
+	// ChurnPercent is the fraction of the series that are replaced with new
+	// series every churn interval, zero means the series never change.
+	ChurnPercent float64
+
+	// ChurnInterval is the interval at which series are replaced.
+	ChurnInterval time.Duration
+
+	// WritesPerSecond is the number of datapoints written per second.
+	WritesPerSecond int
+
+	// ReadsPerSecond is the number of series read per second.
+	ReadsPerSecond int
+
+	// ReadRange is the range of time before now that each read covers.
+	ReadRange time.Duration
+}
+
+// Options are the options for the workload generator.
+type Options interface {
+	// Validate validates the options.
+	Validate() error
+
+	// SetClockOptions sets the clock options.
+	SetClockOptions(value clock.Options) Options
+
+	// ClockOptions returns the clock options.
+	ClockOptions() clock.Options
+
+	// SetInstrumentOptions sets the instrumentation options.
+	SetInstrumentOptions(value instrument.Options) Options
+
+	// InstrumentOptions returns the instrumentation options.
+	InstrumentOptions() instrument.Options
+
+	// SetWorkloads sets the workloads to generate, one per namespace.
+	SetWorkloads(value []NamespaceWorkload) Options
+
+	// Workloads returns the workloads to generate, one per namespace.
+	Workloads() []NamespaceWorkload
+
+	// SetTickInterval sets the interval at which each workload issues the
+	// operations accrued since the previous tick.
+	SetTickInterval(value time.Duration) Options
+
+	// TickInterval returns the interval at which each workload issues the
+	// operations accrued since the previous tick.
+	TickInterval() time.Duration
+
+	// SetSeed sets the seed of the random selection of series.
+	SetSeed(value int64) Options
+
+	// Seed returns the seed of the random selection of series.
+	Seed() int64
+}
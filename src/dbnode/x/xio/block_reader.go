@@ -37,6 +37,7 @@ func (b BlockReader) CloneBlock(pool pool.CheckedBytesPool) (BlockReader, error)
 		SegmentReader: sr,
 		Start:         b.Start,
 		BlockSize:     b.BlockSize,
+		Source:        b.Source,
 	}, nil
 }
 
@@ -42,7 +42,7 @@ func buildBlock(t *testing.T) (BlockReader, *MockSegmentReader) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	reader := NewMockSegmentReader(ctrl)
-	return BlockReader{reader, start, blockSize}, reader
+	return BlockReader{SegmentReader: reader, Start: start, BlockSize: blockSize}, reader
 }
 
 func TestCloneBlock(t *testing.T) {
@@ -66,6 +66,7 @@ func TestCloneBlock(t *testing.T) {
 		SegmentReader: reader,
 		Start:         start,
 		BlockSize:     blockSize,
+		Source:        NewDiskBlockSource(2),
 	}
 
 	read, err := b.Read(p)
@@ -88,6 +89,7 @@ func TestCloneBlock(t *testing.T) {
 
 	require.Equal(t, b2.Start, start)
 	require.Equal(t, b2.BlockSize, blockSize)
+	require.Equal(t, b2.Source, NewDiskBlockSource(2))
 
 	read, err = b2.Read(p)
 
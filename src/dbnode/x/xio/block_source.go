@@ -0,0 +1,68 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package xio
+
+import "fmt"
+
+// BlockSourceType is the type of source the data of a block was read from.
+type BlockSourceType uint8
+
+const (
+	// UnknownBlockSource is used when the source of a block is not known.
+	UnknownBlockSource BlockSourceType = iota
+	// BufferWarmBlockSource is a warm write bucket of the series buffer.
+	BufferWarmBlockSource
+	// BufferColdBlockSource is a cold write bucket of the series buffer.
+	BufferColdBlockSource
+	// CacheBlockSource is the in-memory blocks of the series, which are
+	// either wired or cached after being retrieved from disk.
+	CacheBlockSource
+	// DiskBlockSource is a fileset volume on disk.
+	DiskBlockSource
+)
+
+// BlockSource describes where the data of a block was read from.
+type BlockSource struct {
+	Type BlockSourceType
+	// Volume is the fileset volume index for blocks read from disk.
+	Volume int
+}
+
+// NewDiskBlockSource returns the source of a block read from the given
+// fileset volume.
+func NewDiskBlockSource(volume int) BlockSource {
+	return BlockSource{Type: DiskBlockSource, Volume: volume}
+}
+
+// String returns the string representation of the block source.
+func (s BlockSource) String() string {
+	switch s.Type {
+	case BufferWarmBlockSource:
+		return "buffer-warm"
+	case BufferColdBlockSource:
+		return "buffer-cold"
+	case CacheBlockSource:
+		return "cache"
+	case DiskBlockSource:
+		return fmt.Sprintf("disk-volume-%d", s.Volume)
+	default:
+		return "unknown"
+	}
+}
@@ -34,6 +34,8 @@ type BlockReader struct {
 	SegmentReader
 	Start     time.Time
 	BlockSize time.Duration
+	// Source is where the data of the block was read from, if known.
+	Source BlockSource
 }
 
 // EmptyBlockReader represents the default block reader.
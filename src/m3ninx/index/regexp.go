@@ -48,8 +48,28 @@ func DotStarCompiledRegex() CompiledRegex {
 }
 
 // CompileRegex compiles the provided regexp into an object that can be used to query the various
-// segment implementations.
+// segment implementations. If the regexp cache is enabled the compiled regexp may be shared with
+// other callers.
 func CompileRegex(r []byte) (CompiledRegex, error) {
+	cache := getRegexpCache()
+	if cache == nil {
+		return compileRegex(r)
+	}
+
+	if compiled, ok := cache.get(r); ok {
+		return compiled, nil
+	}
+
+	compiled, err := compileRegex(r)
+	if err != nil {
+		return CompiledRegex{}, err
+	}
+
+	cache.put(r, compiled)
+	return compiled, nil
+}
+
+func compileRegex(r []byte) (CompiledRegex, error) {
 	// NB(prateek): We currently use two segment implementations: map-backed, and fst-backed (Vellum).
 	// Due to peculiarities in the implementation of Vellum, we have to make certain modifications
 	// to all incoming regular expressions to ensure compatibility between them.
@@ -0,0 +1,200 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"container/list"
+	"regexp/syntax"
+	"sync"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	// regexpCacheBytesPerInst is the estimated number of bytes a compiled regexp
+	// uses for each instruction of its program, this is dominated by the DFA
+	// states of the FST regexp that each hold a transition for every byte value.
+	regexpCacheBytesPerInst = 2048
+)
+
+var (
+	regexpCacheLock sync.RWMutex
+	regexpCache     *compiledRegexCache
+	regexpCacheOpts RegexpCacheOptions
+)
+
+// RegexpCacheOptions are the options for the cache of compiled regexps shared
+// by all regexp queries.
+type RegexpCacheOptions struct {
+	// MaxBytes is the max estimated memory used by the cached compiled regexps,
+	// the least recently used regexps are evicted when it is exceeded. Zero
+	// disables the cache.
+	MaxBytes int64
+	// Scope is the metrics scope for the cache.
+	Scope tally.Scope
+}
+
+// SetRegexpCacheOptions sets the options for the cache of compiled regexps
+// used by CompileRegex, replacing any existing cache unless the options are
+// unchanged so that every index configured with the same options shares it.
+func SetRegexpCacheOptions(opts RegexpCacheOptions) {
+	regexpCacheLock.Lock()
+	defer regexpCacheLock.Unlock()
+
+	if opts == regexpCacheOpts {
+		return
+	}
+
+	var cache *compiledRegexCache
+	if opts.MaxBytes > 0 {
+		scope := opts.Scope
+		if scope == nil {
+			scope = tally.NoopScope
+		}
+		cache = newCompiledRegexCache(opts.MaxBytes, scope)
+	}
+
+	regexpCache = cache
+	regexpCacheOpts = opts
+}
+
+func getRegexpCache() *compiledRegexCache {
+	regexpCacheLock.RLock()
+	cache := regexpCache
+	regexpCacheLock.RUnlock()
+	return cache
+}
+
+// compiledRegexCache is an LRU cache of compiled regexps keyed by pattern
+// that is bounded by the estimated memory used by the compiled regexps.
+type compiledRegexCache struct {
+	sync.Mutex
+
+	maxBytes  int64
+	bytes     int64
+	evictList *list.List
+	items     map[string]*list.Element
+	metrics   compiledRegexCacheMetrics
+}
+
+type compiledRegexCacheEntry struct {
+	pattern  string
+	compiled CompiledRegex
+	bytes    int64
+}
+
+type compiledRegexCacheMetrics struct {
+	hits      tally.Counter
+	misses    tally.Counter
+	evictions tally.Counter
+	uncached  tally.Counter
+	entries   tally.Gauge
+	bytes     tally.Gauge
+}
+
+func newCompiledRegexCacheMetrics(scope tally.Scope) compiledRegexCacheMetrics {
+	scope = scope.SubScope("regexp-cache")
+	return compiledRegexCacheMetrics{
+		hits:      scope.Counter("hits"),
+		misses:    scope.Counter("misses"),
+		evictions: scope.Counter("evictions"),
+		uncached:  scope.Counter("uncached"),
+		entries:   scope.Gauge("entries"),
+		bytes:     scope.Gauge("bytes"),
+	}
+}
+
+func newCompiledRegexCache(maxBytes int64, scope tally.Scope) *compiledRegexCache {
+	return &compiledRegexCache{
+		maxBytes:  maxBytes,
+		evictList: list.New(),
+		items:     make(map[string]*list.Element),
+		metrics:   newCompiledRegexCacheMetrics(scope),
+	}
+}
+
+func (c *compiledRegexCache) get(pattern []byte) (CompiledRegex, bool) {
+	c.Lock()
+	elem, ok := c.items[string(pattern)]
+	if !ok {
+		c.Unlock()
+		c.metrics.misses.Inc(1)
+		return CompiledRegex{}, false
+	}
+	c.evictList.MoveToFront(elem)
+	compiled := elem.Value.(*compiledRegexCacheEntry).compiled
+	c.Unlock()
+
+	c.metrics.hits.Inc(1)
+	return compiled, true
+}
+
+func (c *compiledRegexCache) put(pattern []byte, compiled CompiledRegex) {
+	bytes := estimateCompiledRegexBytes(pattern, compiled)
+	if bytes > c.maxBytes {
+		// Caching would evict every other regexp and still exceed the limit.
+		c.metrics.uncached.Inc(1)
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if elem, ok := c.items[string(pattern)]; ok {
+		// Compiled concurrently by another query.
+		c.evictList.MoveToFront(elem)
+		return
+	}
+
+	entry := &compiledRegexCacheEntry{
+		pattern:  string(pattern),
+		compiled: compiled,
+		bytes:    bytes,
+	}
+	c.items[entry.pattern] = c.evictList.PushFront(entry)
+	c.bytes += bytes
+
+	for c.bytes > c.maxBytes {
+		oldest := c.evictList.Back()
+		oldestEntry := oldest.Value.(*compiledRegexCacheEntry)
+		c.evictList.Remove(oldest)
+		delete(c.items, oldestEntry.pattern)
+		c.bytes -= oldestEntry.bytes
+		c.metrics.evictions.Inc(1)
+	}
+
+	c.metrics.entries.Update(float64(c.evictList.Len()))
+	c.metrics.bytes.Update(float64(c.bytes))
+}
+
+// estimateCompiledRegexBytes returns a rough estimate of the memory used by a
+// compiled regexp based on the size of the program of its FST syntax.
+func estimateCompiledRegexBytes(pattern []byte, compiled CompiledRegex) int64 {
+	bytes := int64(2 * len(pattern))
+	if compiled.FSTSyntax == nil {
+		return bytes
+	}
+	prog, err := syntax.Compile(compiled.FSTSyntax)
+	if err != nil {
+		return bytes
+	}
+	return bytes + int64(len(prog.Inst))*regexpCacheBytesPerInst
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestCompileRegexCached(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	SetRegexpCacheOptions(RegexpCacheOptions{MaxBytes: 1 << 30, Scope: scope})
+	defer SetRegexpCacheOptions(RegexpCacheOptions{})
+
+	first, err := CompileRegex([]byte("foo.*bar"))
+	require.NoError(t, err)
+	second, err := CompileRegex([]byte("foo.*bar"))
+	require.NoError(t, err)
+
+	// The second compile is served from the cache.
+	require.True(t, first.Simple == second.Simple)
+	require.True(t, first.FST == second.FST)
+
+	// Invalid regexps are not cached.
+	_, err = CompileRegex([]byte("(foo"))
+	require.Error(t, err)
+
+	snapshot := scope.Snapshot()
+	require.Equal(t, int64(1), snapshot.Counters()["regexp-cache.hits+"].Value())
+	require.Equal(t, int64(2), snapshot.Counters()["regexp-cache.misses+"].Value())
+	require.Equal(t, float64(1), snapshot.Gauges()["regexp-cache.entries+"].Value())
+}
+
+func TestCompileRegexCacheEvictsByMemory(t *testing.T) {
+	compiled, err := compileRegex([]byte("foo.*"))
+	require.NoError(t, err)
+	size := estimateCompiledRegexBytes([]byte("foo.*"), compiled)
+
+	// Only a single regexp of the same size fits in the cache.
+	scope := tally.NewTestScope("", nil)
+	SetRegexpCacheOptions(RegexpCacheOptions{MaxBytes: size, Scope: scope})
+	defer SetRegexpCacheOptions(RegexpCacheOptions{})
+
+	_, err = CompileRegex([]byte("foo.*"))
+	require.NoError(t, err)
+	_, err = CompileRegex([]byte("bar.*"))
+	require.NoError(t, err)
+	_, err = CompileRegex([]byte("foo.*"))
+	require.NoError(t, err)
+
+	snapshot := scope.Snapshot()
+	require.Equal(t, int64(0), snapshot.Counters()["regexp-cache.hits+"].Value())
+	require.Equal(t, int64(3), snapshot.Counters()["regexp-cache.misses+"].Value())
+	require.Equal(t, int64(2), snapshot.Counters()["regexp-cache.evictions+"].Value())
+
+	// Regexps larger than the cache are never cached.
+	_, err = CompileRegex([]byte("foo.*bar.*baz.*qux"))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), scope.Snapshot().Counters()["regexp-cache.uncached+"].Value())
+}
+
+func TestCompileRegexCacheDisabled(t *testing.T) {
+	SetRegexpCacheOptions(RegexpCacheOptions{})
+
+	first, err := CompileRegex([]byte("foo.*bar"))
+	require.NoError(t, err)
+	second, err := CompileRegex([]byte("foo.*bar"))
+	require.NoError(t, err)
+	require.False(t, first.Simple == second.Simple)
+}
+
+func TestSetRegexpCacheOptionsUnchangedKeepsCache(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	opts := RegexpCacheOptions{MaxBytes: 1 << 30, Scope: scope}
+	SetRegexpCacheOptions(opts)
+	defer SetRegexpCacheOptions(RegexpCacheOptions{})
+
+	_, err := CompileRegex([]byte("foo.*bar"))
+	require.NoError(t, err)
+
+	// Setting the same options again keeps the cached regexps.
+	SetRegexpCacheOptions(opts)
+	_, err = CompileRegex([]byte("foo.*bar"))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), scope.Snapshot().Counters()["regexp-cache.hits+"].Value())
+
+	// Changing the options replaces the cache.
+	SetRegexpCacheOptions(RegexpCacheOptions{MaxBytes: 1 << 20, Scope: scope})
+	_, err = CompileRegex([]byte("foo.*bar"))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), scope.Snapshot().Counters()["regexp-cache.hits+"].Value())
+}
@@ -185,6 +185,31 @@ func (r *fsSegment) Size() int64 {
 	return r.numDocs
 }
 
+func (r *fsSegment) SizeBytes() int64 {
+	r.RLock()
+	defer r.RUnlock()
+	if r.closed {
+		return 0
+	}
+
+	size := len(r.data.Metadata) + len(r.data.DocsData) + len(r.data.DocsIdxData) +
+		len(r.data.PostingsData) + len(r.data.FSTTermsData) + len(r.data.FSTFieldsData)
+	if r.docsSliceReader != nil {
+		base := r.docsSliceReader.Base()
+		for i := 0; i < r.docsSliceReader.Len(); i++ {
+			d, err := r.docsSliceReader.Read(base + postings.ID(i))
+			if err != nil {
+				continue
+			}
+			size += len(d.ID)
+			for _, f := range d.Fields {
+				size += len(f.Name) + len(f.Value)
+			}
+		}
+	}
+	return int64(size)
+}
+
 func (r *fsSegment) ContainsID(docID []byte) (bool, error) {
 	r.RLock()
 	defer r.RUnlock()
@@ -57,6 +57,11 @@ var (
 type Segment interface {
 	sgmt.Segment
 	index.Readable
+
+	// SizeBytes returns the size in bytes of the data held by the segment,
+	// including the documents when they are held in memory. It returns 0 if
+	// the Segment has been closed.
+	SizeBytes() int64
 }
 
 // Writer writes out a FST segment from the provided elements.
@@ -165,6 +165,20 @@ func TestSizeEquals(t *testing.T) {
 	}
 }
 
+func TestSizeBytes(t *testing.T) {
+	for _, test := range testDocuments {
+		t.Run(test.name, func(t *testing.T) {
+			_, fstSeg := newTestSegments(t, test.docs)
+			seg := fstSeg.(Segment)
+			if len(test.docs) > 0 {
+				require.True(t, seg.SizeBytes() > 0)
+			}
+			require.NoError(t, seg.Close())
+			require.Equal(t, int64(0), seg.SizeBytes())
+		})
+	}
+}
+
 func TestFieldDoesNotExist(t *testing.T) {
 	for _, test := range testDocuments {
 		t.Run(test.name, func(t *testing.T) {
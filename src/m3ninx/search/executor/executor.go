@@ -27,6 +27,7 @@ import (
 	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/m3ninx/index"
 	"github.com/m3db/m3/src/m3ninx/search"
+	xsync "github.com/m3db/m3/src/x/sync"
 )
 
 var (
@@ -52,6 +53,18 @@ func NewExecutor(rs index.Readers) search.Executor {
 	}
 }
 
+// NewConcurrentExecutor returns a new Executor which searches the readers
+// concurrently using the workers of the pool that are available, searching
+// inline when none are so that it never waits for a worker.
+func NewConcurrentExecutor(rs index.Readers, pool xsync.WorkerPool) search.Executor {
+	return &executor{
+		newIteratorFn: func(s search.Searcher, rs index.Readers) (doc.Iterator, error) {
+			return newConcurrentIterator(s, rs, pool)
+		},
+		readers: rs,
+	}
+}
+
 func (e *executor) Execute(q search.Query) (doc.Iterator, error) {
 	e.RLock()
 	defer e.RUnlock()
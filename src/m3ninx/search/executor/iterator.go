@@ -21,15 +21,26 @@
 package executor
 
 import (
+	"sync"
+	"time"
+
 	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/m3ninx/index"
+	"github.com/m3db/m3/src/m3ninx/postings"
 	"github.com/m3db/m3/src/m3ninx/search"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	xsync "github.com/m3db/m3/src/x/sync"
 )
 
 type iterator struct {
 	searcher search.Searcher
 	readers  index.Readers
 
+	// postingsLists are the results of searching the readers upfront, if nil
+	// the readers are searched one at a time as they are iterated.
+	postingsLists []postings.List
+	stats         []search.ReaderStats
+
 	idx      int
 	currDoc  doc.Document
 	currIter doc.Iterator
@@ -42,6 +53,7 @@ func newIterator(s search.Searcher, rs index.Readers) (doc.Iterator, error) {
 	it := &iterator{
 		searcher: s,
 		readers:  rs,
+		stats:    make([]search.ReaderStats, len(rs)),
 		idx:      -1,
 	}
 
@@ -54,6 +66,56 @@ func newIterator(s search.Searcher, rs index.Readers) (doc.Iterator, error) {
 	return it, nil
 }
 
+// newConcurrentIterator searches all the readers concurrently before
+// iterating their documents in order.
+func newConcurrentIterator(
+	s search.Searcher,
+	rs index.Readers,
+	pool xsync.WorkerPool,
+) (doc.Iterator, error) {
+	var (
+		postingsLists = make([]postings.List, len(rs))
+		stats         = make([]search.ReaderStats, len(rs))
+		errs          = make([]error, len(rs))
+		wg            sync.WaitGroup
+	)
+	for i, reader := range rs {
+		i, reader := i, reader
+		wg.Add(1)
+		searchFn := func() {
+			postingsLists[i], errs[i] = searchReader(s, reader, &stats[i])
+			wg.Done()
+		}
+		// The last reader is always searched inline since this goroutine
+		// would otherwise only wait for it.
+		if i < len(rs)-1 && pool.GoIfAvailable(searchFn) {
+			continue
+		}
+		searchFn()
+	}
+	wg.Wait()
+
+	if err := xerrors.FirstError(errs...); err != nil {
+		return nil, err
+	}
+
+	it := &iterator{
+		searcher:      s,
+		readers:       rs,
+		postingsLists: postingsLists,
+		stats:         stats,
+		idx:           -1,
+	}
+
+	currIter, _, err := it.nextIter()
+	if err != nil {
+		return nil, err
+	}
+
+	it.currIter = currIter
+	return it, nil
+}
+
 func (it *iterator) Next() bool {
 	if it.closed || it.err != nil || it.idx == len(it.readers) {
 		return false
@@ -88,6 +150,7 @@ func (it *iterator) Next() bool {
 	}
 
 	it.currDoc = it.currIter.Current()
+	it.stats[it.idx].DocsMatched++
 	return true
 }
 
@@ -99,6 +162,10 @@ func (it *iterator) Err() error {
 	return it.err
 }
 
+func (it *iterator) ReaderStats() []search.ReaderStats {
+	return it.stats
+}
+
 func (it *iterator) Close() error {
 	var err error
 	if it.currIter != nil {
@@ -117,7 +184,15 @@ func (it *iterator) nextIter() (doc.Iterator, bool, error) {
 	}
 
 	reader := it.readers[it.idx]
-	pl, err := it.searcher.Search(reader)
+	if it.postingsLists != nil {
+		iter, err := reader.Docs(it.postingsLists[it.idx])
+		if err != nil {
+			return nil, false, err
+		}
+		return iter, true, nil
+	}
+
+	pl, err := searchReader(it.searcher, reader, &it.stats[it.idx])
 	if err != nil {
 		return nil, false, err
 	}
@@ -129,3 +204,19 @@ func (it *iterator) nextIter() (doc.Iterator, bool, error) {
 
 	return iter, true, nil
 }
+
+// searchReader searches the reader recording the statistics of the search.
+func searchReader(
+	s search.Searcher,
+	reader index.Reader,
+	stats *search.ReaderStats,
+) (postings.List, error) {
+	stats.SearchStart = time.Now()
+	pl, err := s.Search(reader)
+	stats.SearchDuration = time.Since(stats.SearchStart)
+	if err != nil {
+		return nil, err
+	}
+	stats.PostingsCardinality = pl.Len()
+	return pl, nil
+}
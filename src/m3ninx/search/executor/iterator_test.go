@@ -21,12 +21,15 @@
 package executor
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/m3ninx/index"
+	"github.com/m3db/m3/src/m3ninx/postings"
 	"github.com/m3db/m3/src/m3ninx/postings/roaring"
 	"github.com/m3db/m3/src/m3ninx/search"
+	xsync "github.com/m3db/m3/src/x/sync"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
@@ -118,4 +121,99 @@ func TestIterator(t *testing.T) {
 	require.False(t, iter.Next())
 	require.NoError(t, iter.Err())
 	require.NoError(t, iter.Close())
+
+	statsIter, ok := iter.(search.StatsIterator)
+	require.True(t, ok)
+	stats := statsIter.ReaderStats()
+	require.Equal(t, 2, len(stats))
+	require.Equal(t, 2, stats[0].PostingsCardinality)
+	require.Equal(t, 2, stats[0].DocsMatched)
+	require.Equal(t, 1, stats[1].PostingsCardinality)
+	require.Equal(t, 1, stats[1].DocsMatched)
+	for _, s := range stats {
+		require.False(t, s.SearchStart.IsZero())
+	}
+}
+
+func TestConcurrentIterator(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var (
+		firstPL  = roaring.NewPostingsList()
+		secondPL = roaring.NewPostingsList()
+		thirdPL  = roaring.NewPostingsList()
+		docs     = []doc.Document{
+			{ID: []byte("apple")},
+			{ID: []byte("banana")},
+			{ID: []byte("carrot")},
+		}
+	)
+	require.NoError(t, firstPL.Insert(42))
+	require.NoError(t, secondPL.Insert(47))
+	require.NoError(t, thirdPL.Insert(67))
+
+	var (
+		readers  index.Readers
+		searcher = search.NewMockSearcher(mockCtrl)
+	)
+	for i, pl := range []postings.List{firstPL, secondPL, thirdPL} {
+		docIter := doc.NewMockIterator(mockCtrl)
+		gomock.InOrder(
+			docIter.EXPECT().Next().Return(true),
+			docIter.EXPECT().Current().Return(docs[i]),
+			docIter.EXPECT().Next().Return(false),
+			docIter.EXPECT().Err().Return(nil),
+			docIter.EXPECT().Close().Return(nil),
+		)
+
+		reader := index.NewMockReader(mockCtrl)
+		reader.EXPECT().Docs(pl).Return(docIter, nil)
+		searcher.EXPECT().Search(reader).Return(pl, nil)
+		readers = append(readers, reader)
+	}
+
+	pool := xsync.NewWorkerPool(2)
+	pool.Init()
+
+	// The readers are all searched upfront, the documents are still iterated
+	// in the order of the readers.
+	iter, err := newConcurrentIterator(searcher, readers, pool)
+	require.NoError(t, err)
+
+	for _, d := range docs {
+		require.True(t, iter.Next())
+		require.Equal(t, d, iter.Current())
+	}
+
+	require.False(t, iter.Next())
+	require.NoError(t, iter.Err())
+	require.NoError(t, iter.Close())
+
+	stats := iter.(search.StatsIterator).ReaderStats()
+	require.Equal(t, len(readers), len(stats))
+	for _, s := range stats {
+		require.False(t, s.SearchStart.IsZero())
+		require.Equal(t, 1, s.PostingsCardinality)
+		require.Equal(t, 1, s.DocsMatched)
+	}
+}
+
+func TestConcurrentIteratorSearchError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var (
+		firstReader  = index.NewMockReader(mockCtrl)
+		secondReader = index.NewMockReader(mockCtrl)
+		searcher     = search.NewMockSearcher(mockCtrl)
+	)
+	searcher.EXPECT().Search(firstReader).Return(roaring.NewPostingsList(), nil)
+	searcher.EXPECT().Search(secondReader).Return(nil, errors.New("search error"))
+
+	pool := xsync.NewWorkerPool(1)
+	pool.Init()
+
+	_, err := newConcurrentIterator(searcher, index.Readers{firstReader, secondReader}, pool)
+	require.Error(t, err)
 }
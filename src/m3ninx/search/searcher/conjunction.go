@@ -21,6 +21,8 @@
 package searcher
 
 import (
+	"sort"
+
 	"github.com/m3db/m3/src/m3ninx/index"
 	"github.com/m3db/m3/src/m3ninx/postings"
 	"github.com/m3db/m3/src/m3ninx/search"
@@ -38,12 +40,31 @@ func NewConjunctionSearcher(searchers, negations search.Searchers) (search.Searc
 		return nil, errEmptySearchers
 	}
 
+	// Search the cheapest negations first so that the most expensive ones are
+	// skipped once the postings list is empty.
+	sorted := make(search.Searchers, len(negations))
+	copy(sorted, negations)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return negationCost(sorted[i]) < negationCost(sorted[j])
+	})
+
 	return &conjunctionSearcher{
 		searchers: searchers,
-		negations: negations,
+		negations: sorted,
 	}, nil
 }
 
+func negationCost(s search.Searcher) int {
+	switch s.(type) {
+	case *termSearcher, *fieldSearcher:
+		return 0
+	case *regexpSearcher:
+		return 1
+	default:
+		return 2
+	}
+}
+
 func (s *conjunctionSearcher) Search(r index.Reader) (postings.List, error) {
 	var pl postings.MutableList
 	for _, sr := range s.searchers {
@@ -68,6 +89,21 @@ func (s *conjunctionSearcher) Search(r index.Reader) (postings.List, error) {
 	}
 
 	for _, sr := range s.negations {
+		// Matching the few documents left against a negated regexp is cheaper
+		// than searching every term of its field.
+		if rs, ok := sr.(*regexpSearcher); ok {
+			excluded, err := rs.excludeMatches(r, pl)
+			if err != nil {
+				return nil, err
+			}
+			if excluded {
+				if pl.IsEmpty() {
+					break
+				}
+				continue
+			}
+		}
+
 		curr, err := sr.Search(r)
 		if err != nil {
 			return nil, err
@@ -21,8 +21,10 @@
 package searcher
 
 import (
+	re "regexp"
 	"testing"
 
+	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/m3ninx/index"
 	"github.com/m3db/m3/src/m3ninx/postings"
 	"github.com/m3db/m3/src/m3ninx/postings/roaring"
@@ -120,3 +122,107 @@ func TestConjunctionSearcherError(t *testing.T) {
 		})
 	}
 }
+
+func TestConjunctionSearcherNegationsOrder(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	field, err := NewFieldSearcher([]byte("color"))
+	require.NoError(t, err)
+
+	var (
+		positive  = search.NewMockSearcher(mockCtrl)
+		other     = search.NewMockSearcher(mockCtrl)
+		regexp    = NewRegexpSearcher([]byte("fruit"), index.CompiledRegex{})
+		term      = NewTermSearcher([]byte("fruit"), []byte("apple"))
+		negations = search.Searchers{other, regexp, term, field}
+	)
+
+	s, err := NewConjunctionSearcher(search.Searchers{positive}, negations)
+	require.NoError(t, err)
+
+	// The cheapest negations are searched first, the given negations are left
+	// untouched.
+	sorted := s.(*conjunctionSearcher).negations
+	for i, expected := range []search.Searcher{term, field, regexp, other} {
+		require.True(t, expected == sorted[i])
+	}
+	for i, expected := range []search.Searcher{other, regexp, term, field} {
+		require.True(t, expected == negations[i])
+	}
+}
+
+func TestConjunctionSearcherNegatedRegexpExcludeMatches(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	field, regexp := []byte("fruit"), "ap.*"
+	negation := NewRegexpSearcher(field, index.CompiledRegex{
+		Simple: re.MustCompile("^(?:" + regexp + ")$"),
+	})
+
+	positivePL := roaring.NewPostingsList()
+	for _, id := range []postings.ID{1, 2, 3, 4} {
+		require.NoError(t, positivePL.Insert(id))
+	}
+	fieldPL := roaring.NewPostingsList()
+	for _, id := range []postings.ID{1, 2, 4, 5} {
+		require.NoError(t, fieldPL.Insert(id))
+	}
+
+	positive := search.NewMockSearcher(mockCtrl)
+	reader := index.NewMockReader(mockCtrl)
+	positive.EXPECT().Search(reader).Return(positivePL, nil)
+	reader.EXPECT().MatchField(field).Return(fieldPL, nil)
+	reader.EXPECT().Doc(postings.ID(1)).Return(doc.Document{
+		Fields: []doc.Field{{Name: field, Value: []byte("apple")}},
+	}, nil)
+	reader.EXPECT().Doc(postings.ID(2)).Return(doc.Document{
+		Fields: []doc.Field{
+			{Name: []byte("color"), Value: []byte("apricot")},
+			{Name: field, Value: []byte("banana")},
+		},
+	}, nil)
+	reader.EXPECT().Doc(postings.ID(4)).Return(doc.Document{}, index.ErrDocNotFound)
+
+	s, err := NewConjunctionSearcher(search.Searchers{positive}, search.Searchers{negation})
+	require.NoError(t, err)
+
+	// Only the documents with the field are matched, the regexp is never
+	// searched.
+	expected := roaring.NewPostingsList()
+	for _, id := range []postings.ID{2, 3, 4} {
+		require.NoError(t, expected.Insert(id))
+	}
+	pl, err := s.Search(reader)
+	require.NoError(t, err)
+	require.True(t, pl.Equal(expected))
+}
+
+func TestConjunctionSearcherNegatedRegexpSearchesLargeLists(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	field := []byte("fruit")
+	compiled := index.CompiledRegex{Simple: re.MustCompile("^(?:ap.*)$")}
+	negation := NewRegexpSearcher(field, compiled)
+
+	positivePL := roaring.NewPostingsList()
+	require.NoError(t, positivePL.AddRange(0, maxExcludeMatchesDocs+1))
+	negationPL := roaring.NewPostingsList()
+	require.NoError(t, negationPL.AddRange(1, maxExcludeMatchesDocs+1))
+
+	positive := search.NewMockSearcher(mockCtrl)
+	reader := index.NewMockReader(mockCtrl)
+	positive.EXPECT().Search(reader).Return(positivePL, nil)
+	reader.EXPECT().MatchRegexp(field, compiled).Return(negationPL, nil)
+
+	s, err := NewConjunctionSearcher(search.Searchers{positive}, search.Searchers{negation})
+	require.NoError(t, err)
+
+	expected := roaring.NewPostingsList()
+	require.NoError(t, expected.Insert(0))
+	pl, err := s.Search(reader)
+	require.NoError(t, err)
+	require.True(t, pl.Equal(expected))
+}
@@ -21,11 +21,18 @@
 package searcher
 
 import (
+	"bytes"
+
+	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/m3ninx/index"
 	"github.com/m3db/m3/src/m3ninx/postings"
 	"github.com/m3db/m3/src/m3ninx/search"
 )
 
+// maxExcludeMatchesDocs is the max number of documents of a postings list
+// that are matched one at a time to exclude the matches of a negated regexp.
+const maxExcludeMatchesDocs = 256
+
 type regexpSearcher struct {
 	field    []byte
 	compiled index.CompiledRegex
@@ -43,3 +50,64 @@ func NewRegexpSearcher(field []byte, compiled index.CompiledRegex) search.Search
 func (s *regexpSearcher) Search(r index.Reader) (postings.List, error) {
 	return r.MatchRegexp(s.field, s.compiled)
 }
+
+// excludeMatches removes the documents which match the regexp from the given
+// postings list by matching the value of the field of each of its documents,
+// only the documents with the field are candidates. It returns false, leaving
+// the postings list untouched, when the list is too large for this to be
+// cheaper than searching the terms of the field.
+func (s *regexpSearcher) excludeMatches(
+	r index.Reader,
+	pl postings.MutableList,
+) (bool, error) {
+	if s.compiled.Simple == nil || pl.Len() > maxExcludeMatchesDocs {
+		return false, nil
+	}
+
+	fieldPl, err := r.MatchField(s.field)
+	if err != nil {
+		return false, err
+	}
+
+	candidates := pl.Clone()
+	if err := candidates.Intersect(fieldPl); err != nil {
+		return false, err
+	}
+
+	iter := candidates.Iterator()
+	for iter.Next() {
+		id := iter.Current()
+		d, err := r.Doc(id)
+		if err == index.ErrDocNotFound {
+			// Postings lists can reference documents past the end of the reader,
+			// which are never returned.
+			continue
+		}
+		if err != nil {
+			iter.Close()
+			return false, err
+		}
+		if !s.matchesDoc(d) {
+			continue
+		}
+		if err := pl.RemoveRange(id, id+1); err != nil {
+			iter.Close()
+			return false, err
+		}
+	}
+
+	if err := iter.Err(); err != nil {
+		iter.Close()
+		return false, err
+	}
+	return true, iter.Close()
+}
+
+func (s *regexpSearcher) matchesDoc(d doc.Document) bool {
+	for _, f := range d.Fields {
+		if bytes.Equal(f.Name, s.field) && s.compiled.Simple.Match(f.Value) {
+			return true
+		}
+	}
+	return false
+}
@@ -22,6 +22,7 @@ package search
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/m3ninx/generated/proto/querypb"
@@ -38,6 +39,29 @@ type Executor interface {
 	Close() error
 }
 
+// ReaderStats are the statistics of searching a single reader for a query.
+type ReaderStats struct {
+	// SearchStart is when the search of the reader started.
+	SearchStart time.Time
+	// SearchDuration is the time spent searching the reader for the postings
+	// list of the query.
+	SearchDuration time.Duration
+	// PostingsCardinality is the cardinality of the postings list matched.
+	PostingsCardinality int
+	// DocsMatched is the number of documents iterated from the reader.
+	DocsMatched int
+}
+
+// StatsIterator is a document iterator which records the statistics of
+// searching each of the readers it iterates.
+type StatsIterator interface {
+	doc.Iterator
+
+	// ReaderStats returns the statistics of the readers, readers that have not
+	// been searched yet have a zero search start.
+	ReaderStats() []ReaderStats
+}
+
 // Query is a search query for documents.
 type Query interface {
 	fmt.Stringer
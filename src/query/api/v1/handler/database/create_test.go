@@ -162,10 +162,15 @@ func testLocalType(t *testing.T, providedType string, placementExists bool) {
 						"snapshotEnabled": true,
 						"indexOptions": {
 							"enabled": true,
-							"blockSizeNanos": "3600000000000"
+							"blockSizeNanos": "3600000000000",
+							"annotationFields": []
 						},
 						"schemaOptions": null,
-						"coldWritesEnabled": false
+						"coldWritesEnabled": false,
+						"bloomFilterOptions": {
+							"enabled": true,
+							"falsePositivePercent": 0
+						}
 					}
 				}
 			}
@@ -320,10 +325,15 @@ func TestLocalTypeWithNumShards(t *testing.T) {
 						"snapshotEnabled": true,
 						"indexOptions": {
 							"enabled": true,
-							"blockSizeNanos": "3600000000000"
+							"blockSizeNanos": "3600000000000",
+							"annotationFields": []
 						},
 						"schemaOptions": null,
-						"coldWritesEnabled": false
+						"coldWritesEnabled": false,
+						"bloomFilterOptions": {
+							"enabled": true,
+							"falsePositivePercent": 0
+						}
 					}
 				}
 			}
@@ -430,10 +440,15 @@ func TestLocalWithBlockSizeNanos(t *testing.T) {
 						"snapshotEnabled": true,
 						"indexOptions": {
 							"enabled": true,
-							"blockSizeNanos": "10800000000000"
+							"blockSizeNanos": "10800000000000",
+							"annotationFields": []
 						},
 						"schemaOptions": null,
-						"coldWritesEnabled": false
+						"coldWritesEnabled": false,
+						"bloomFilterOptions": {
+							"enabled": true,
+							"falsePositivePercent": 0
+						}
 					}
 				}
 			}
@@ -544,10 +559,15 @@ func TestLocalWithBlockSizeExpectedSeriesDatapointsPerHour(t *testing.T) {
 						"snapshotEnabled": true,
 						"indexOptions": {
 							"enabled": true,
-							"blockSizeNanos": "%d"
+							"blockSizeNanos": "%d",
+							"annotationFields": []
 						},
 						"schemaOptions": null,
-						"coldWritesEnabled": false
+						"coldWritesEnabled": false,
+						"bloomFilterOptions": {
+							"enabled": true,
+							"falsePositivePercent": 0
+						}
 					}
 				}
 			}
@@ -792,10 +812,15 @@ func testClusterTypeHosts(t *testing.T, placementExists bool) {
 						"snapshotEnabled": true,
 						"indexOptions": {
 							"enabled": true,
-							"blockSizeNanos": "3600000000000"
+							"blockSizeNanos": "3600000000000",
+							"annotationFields": []
 						},
 						"schemaOptions": null,
-						"coldWritesEnabled": false
+						"coldWritesEnabled": false,
+						"bloomFilterOptions": {
+							"enabled": true,
+							"falsePositivePercent": 0
+						}
 					}
 				}
 			}
@@ -924,10 +949,15 @@ func TestClusterTypeHostsWithIsolationGroup(t *testing.T) {
 						"snapshotEnabled": true,
 						"indexOptions": {
 							"enabled": true,
-							"blockSizeNanos": "3600000000000"
+							"blockSizeNanos": "3600000000000",
+							"annotationFields": []
 						},
 						"schemaOptions": null,
-						"coldWritesEnabled": false
+						"coldWritesEnabled": false,
+						"bloomFilterOptions": {
+							"enabled": true,
+							"falsePositivePercent": 0
+						}
 					}
 				}
 			}
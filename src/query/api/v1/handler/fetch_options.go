@@ -21,9 +21,13 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/query/storage"
 	xhttp "github.com/m3db/m3/src/x/net/http"
 )
@@ -64,5 +68,32 @@ func (b fetchOptionsBuilder) NewFetchOptions(
 		fetchOpts.Limit = n
 	}
 
+	if str := req.Header.Get(IncludeDurabilityHeader); str != "" {
+		include, err := strconv.ParseBool(str)
+		if err != nil {
+			return nil, xhttp.NewParseError(err, http.StatusBadRequest)
+		}
+		if include {
+			fetchOpts.DurabilityTracker = index.NewQueryDurabilityTracker()
+		}
+	}
+
 	return fetchOpts, nil
 }
+
+// SetDurabilityHeader sets the durability header of the data returned if
+// its durability was requested.
+func SetDurabilityHeader(w http.ResponseWriter, opts *storage.FetchOptions) {
+	if opts.DurabilityTracker == nil {
+		return
+	}
+
+	ranges := opts.DurabilityTracker.Ranges()
+	values := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		values = append(values, fmt.Sprintf("%s/%s=%s",
+			r.Start.UTC().Format(time.RFC3339Nano),
+			r.End.UTC().Format(time.RFC3339Nano), r.Level))
+	}
+	w.Header().Set(DurabilityHeader, strings.Join(values, ","))
+}
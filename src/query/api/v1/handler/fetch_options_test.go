@@ -23,6 +23,11 @@ package handler
 import (
 	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage/durability"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/query/storage"
 
 	"github.com/stretchr/testify/require"
 )
@@ -81,3 +86,45 @@ func TestFetchOptionsBuilder(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchOptionsBuilderIncludeDurability(t *testing.T) {
+	builder := NewFetchOptionsBuilder(FetchOptionsBuilderOptions{})
+
+	req := httptest.NewRequest("GET", "/foo", nil)
+	opts, err := builder.NewFetchOptions(req)
+	require.NoError(t, err)
+	require.Nil(t, opts.DurabilityTracker)
+
+	req.Header.Set(IncludeDurabilityHeader, "true")
+	opts, err = builder.NewFetchOptions(req)
+	require.NoError(t, err)
+	require.NotNil(t, opts.DurabilityTracker)
+
+	req.Header.Set(IncludeDurabilityHeader, "not_a_bool")
+	_, err = builder.NewFetchOptions(req)
+	require.Error(t, err)
+}
+
+func TestSetDurabilityHeader(t *testing.T) {
+	var (
+		start = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		mid   = start.Add(time.Hour)
+		end   = start.Add(90 * time.Minute)
+		opts  = storage.NewFetchOptions()
+	)
+
+	w := httptest.NewRecorder()
+	SetDurabilityHeader(w, opts)
+	_, ok := w.Header()[DurabilityHeader]
+	require.False(t, ok)
+
+	opts.DurabilityTracker = index.NewQueryDurabilityTracker()
+	opts.DurabilityTracker.Track(durability.Ranges{
+		{Start: start, End: mid, Level: durability.Flushed},
+		{Start: mid, End: end, Level: durability.BufferOnly},
+	})
+	SetDurabilityHeader(w, opts)
+	require.Equal(t, "2020-01-01T00:00:00Z/2020-01-01T01:00:00Z=flushed,"+
+		"2020-01-01T01:00:00Z/2020-01-01T01:30:00Z=buffer-only",
+		w.Header().Get(DurabilityHeader))
+}
@@ -37,6 +37,14 @@ const (
 	// the number of time series returned by each storage node.
 	LimitMaxSeriesHeader = "M3-Limit-Max-Series"
 
+	// IncludeDurabilityHeader is the M3 header that requests the durability
+	// of the data returned by the storage nodes.
+	IncludeDurabilityHeader = "M3-Include-Durability"
+
+	// DurabilityHeader is the M3 durability header of the data returned,
+	// as comma separated "start/end=durability" ranges with RFC3339 times.
+	DurabilityHeader = "M3-Durability"
+
 	// DefaultServiceEnvironment is the default service ID environment.
 	DefaultServiceEnvironment = "default_env"
 	// DefaultServiceZone is the default service ID zone.
@@ -0,0 +1,153 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/livequery"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+const (
+	liveQueryIDVar = "id"
+
+	// LiveQueriesURL is the url to list the queries being executed.
+	LiveQueriesURL = RoutePrefixV1 + "/query/live"
+
+	// LiveQueriesHTTPMethod is the HTTP method used to list live queries.
+	LiveQueriesHTTPMethod = http.MethodGet
+
+	// CancelLiveQueryHTTPMethod is the HTTP method used to cancel a live query.
+	CancelLiveQueryHTTPMethod = http.MethodDelete
+)
+
+var (
+	// CancelLiveQueryURL is the url to cancel a query being executed by ID.
+	CancelLiveQueryURL = fmt.Sprintf("%s/{%s}", LiveQueriesURL, liveQueryIDVar)
+)
+
+// LiveQueriesHandler lists the queries being executed.
+type LiveQueriesHandler struct {
+	registry       livequery.Registry
+	instrumentOpts instrument.Options
+}
+
+// NewLiveQueriesHandler returns a new instance of the live queries handler.
+func NewLiveQueriesHandler(
+	registry livequery.Registry,
+	instrumentOpts instrument.Options,
+) http.Handler {
+	return &LiveQueriesHandler{
+		registry:       registry,
+		instrumentOpts: instrumentOpts,
+	}
+}
+
+type liveQueryJSON struct {
+	ID        string                 `json:"id"`
+	Query     string                 `json:"query"`
+	Start     time.Time              `json:"start"`
+	Elapsed   string                 `json:"elapsed"`
+	Cancelled bool                   `json:"cancelled"`
+	Resources liveQueryResourcesJSON `json:"resources"`
+}
+
+type liveQueryResourcesJSON struct {
+	Series     int64 `json:"series"`
+	Datapoints int64 `json:"datapoints"`
+	Bytes      int64 `json:"bytes"`
+}
+
+func (h *LiveQueriesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context(), h.instrumentOpts)
+
+	queries := h.registry.Queries()
+	results := make([]liveQueryJSON, 0, len(queries))
+	for _, q := range queries {
+		results = append(results, liveQueryJSON{
+			ID:        q.ID,
+			Query:     q.Query,
+			Start:     q.Start,
+			Elapsed:   q.Elapsed.String(),
+			Cancelled: q.Cancelled,
+			Resources: liveQueryResourcesJSON{
+				Series:     q.Resources.Series,
+				Datapoints: q.Resources.Datapoints,
+				Bytes:      q.Resources.Bytes,
+			},
+		})
+	}
+
+	xhttp.WriteJSONResponse(w, struct {
+		Queries []liveQueryJSON `json:"queries"`
+	}{
+		Queries: results,
+	}, logger)
+}
+
+// CancelLiveQueryHandler cancels a query being executed by ID.
+type CancelLiveQueryHandler struct {
+	registry       livequery.Registry
+	instrumentOpts instrument.Options
+}
+
+// NewCancelLiveQueryHandler returns a new instance of the cancel live
+// query handler.
+func NewCancelLiveQueryHandler(
+	registry livequery.Registry,
+	instrumentOpts instrument.Options,
+) http.Handler {
+	return &CancelLiveQueryHandler{
+		registry:       registry,
+		instrumentOpts: instrumentOpts,
+	}
+}
+
+func (h *CancelLiveQueryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context(), h.instrumentOpts)
+
+	id := strings.TrimSpace(mux.Vars(r)[liveQueryIDVar])
+	if err := h.registry.Cancel(id); err != nil {
+		if err == livequery.ErrQueryNotFound {
+			xhttp.Error(w, err, http.StatusNotFound)
+			return
+		}
+		logger.Error("unable to cancel query", zap.Error(err))
+		xhttp.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("cancelled live query", zap.String("id", id))
+	xhttp.WriteJSONResponse(w, struct {
+		Cancelled bool `json:"cancelled"`
+	}{
+		Cancelled: true,
+	}, logger)
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/livequery"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLiveQueriesRouter(registry livequery.Registry) *mux.Router {
+	r := mux.NewRouter()
+	r.Handle(LiveQueriesURL, NewLiveQueriesHandler(registry,
+		instrument.NewOptions())).Methods(LiveQueriesHTTPMethod)
+	r.Handle(CancelLiveQueryURL, NewCancelLiveQueryHandler(registry,
+		instrument.NewOptions())).Methods(CancelLiveQueryHTTPMethod)
+	return r
+}
+
+func TestLiveQueriesListAndCancel(t *testing.T) {
+	var (
+		start    = time.Now()
+		now      = start
+		registry = livequery.NewRegistry(func() time.Time { return now })
+		router   = newTestLiveQueriesRouter(registry)
+	)
+
+	ctx, h := registry.Register(context.Background(),
+		func() string { return "sum(foo)" },
+		func() livequery.Resources {
+			return livequery.Resources{Datapoints: 42}
+		})
+	defer h.Close()
+	now = start.Add(time.Minute)
+
+	req := httptest.NewRequest(LiveQueriesHTTPMethod, LiveQueriesURL, nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var listed struct {
+		Queries []liveQueryJSON `json:"queries"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &listed))
+	require.Equal(t, 1, len(listed.Queries))
+	q := listed.Queries[0]
+	assert.Equal(t, h.ID(), q.ID)
+	assert.Equal(t, "sum(foo)", q.Query)
+	assert.Equal(t, "1m0s", q.Elapsed)
+	assert.Equal(t, int64(42), q.Resources.Datapoints)
+	assert.False(t, q.Cancelled)
+
+	req = httptest.NewRequest(CancelLiveQueryHTTPMethod, LiveQueriesURL+"/"+h.ID(), nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, context.Canceled, ctx.Err())
+	require.Equal(t, livequery.ErrQueryCancelled, h.Err())
+
+	req = httptest.NewRequest(CancelLiveQueryHTTPMethod, LiveQueriesURL+"/unknown", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
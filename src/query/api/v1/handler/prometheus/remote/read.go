@@ -121,7 +121,14 @@ func (h *PromReadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.read(ctx, w, req, timeout, fetchOpts.Limit)
+	queryOpts := &executor.QueryOptions{
+		QueryContextOptions: models.QueryContextOptions{
+			LimitMaxTimeseries: fetchOpts.Limit,
+		},
+		DurabilityTracker: fetchOpts.DurabilityTracker,
+	}
+
+	result, err := h.read(ctx, w, req, timeout, queryOpts)
 	if err != nil {
 		h.promReadMetrics.fetchErrorsServer.Inc(1)
 		logger.Error("unable to fetch data", zap.Error(err))
@@ -143,6 +150,7 @@ func (h *PromReadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/x-protobuf")
 	w.Header().Set("Content-Encoding", "snappy")
+	handler.SetDurabilityHeader(w, fetchOpts)
 
 	compressed := snappy.Encode(nil, data)
 	if _, err := w.Write(compressed); err != nil {
@@ -177,16 +185,12 @@ func (h *PromReadHandler) read(
 	w http.ResponseWriter,
 	r *prompb.ReadRequest,
 	timeout time.Duration,
-	limit int,
+	queryOpts *executor.QueryOptions,
 ) ([]*prompb.QueryResult, error) {
 	var (
 		queryCount  = len(r.Queries)
 		promResults = make([]*prompb.QueryResult, queryCount)
 		cancelFuncs = make([]context.CancelFunc, queryCount)
-		queryOpts   = &executor.QueryOptions{
-			QueryContextOptions: models.QueryContextOptions{
-				LimitMaxTimeseries: limit,
-			}}
 
 		wg           sync.WaitGroup
 		multiErr     xerrors.MultiError
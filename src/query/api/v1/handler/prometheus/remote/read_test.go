@@ -52,6 +52,11 @@ import (
 var (
 	promReadTestMetrics     = newPromReadMetrics(tally.NewTestScope("", nil))
 	defaultLookbackDuration = time.Minute
+	testQueryOptions        = &executor.QueryOptions{
+		QueryContextOptions: models.QueryContextOptions{
+			LimitMaxTimeseries: 100,
+		},
+	}
 
 	timeoutOpts = &prometheus.TimeoutOpts{
 		FetchTimeout: 15 * time.Second,
@@ -157,7 +162,7 @@ func TestPromReadStorageWithFetchError(t *testing.T) {
 	promRead := readHandler(storage, timeoutOpts)
 	req := test.GeneratePromReadRequest()
 	_, err := promRead.read(context.TODO(), httptest.NewRecorder(),
-		req, time.Hour, 100)
+		req, time.Hour, testQueryOptions)
 	require.NotNil(t, err, "unable to read from storage")
 }
 
@@ -281,7 +286,7 @@ func TestMultipleRead(t *testing.T) {
 		Execute(gomock.Any(), qTwo, gomock.Any()).Return(rTwo, nil)
 
 	h := NewPromReadHandler(engine, nil, nil, true, instrument.NewOptions()).(*PromReadHandler)
-	result, err := h.read(context.TODO(), nil, req, 0, 100)
+	result, err := h.read(context.TODO(), nil, req, 0, testQueryOptions)
 	require.NoError(t, err)
 	expected := &prompb.QueryResult{
 		Timeseries: []*prompb.TimeSeries{
@@ -222,6 +222,16 @@ func (h *Handler) RegisterRoutes() error {
 		wrapped(m3json.NewWriteJSONHandler(h.storage, h.instrumentOpts)).ServeHTTP,
 	).Methods(m3json.JSONWriteHTTPMethod)
 
+	// Live query endpoints
+	h.router.HandleFunc(handler.LiveQueriesURL,
+		wrapped(handler.NewLiveQueriesHandler(h.engine.LiveQueries(),
+			h.instrumentOpts)).ServeHTTP,
+	).Methods(handler.LiveQueriesHTTPMethod)
+	h.router.HandleFunc(handler.CancelLiveQueryURL,
+		wrapped(handler.NewCancelLiveQueryHandler(h.engine.LiveQueries(),
+			h.instrumentOpts)).ServeHTTP,
+	).Methods(handler.CancelLiveQueryHTTPMethod)
+
 	// Tag completion endpoints
 	h.router.HandleFunc(native.CompleteTagsURL,
 		wrapped(native.NewCompleteTagsHandler(h.storage,
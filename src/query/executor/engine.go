@@ -24,10 +24,12 @@ import (
 	"context"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/storage/index"
 	qcost "github.com/m3db/m3/src/query/cost"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/parser"
 	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/x/livequery"
 	"github.com/m3db/m3/src/x/opentracing"
 
 	"github.com/uber-go/tally"
@@ -41,6 +43,9 @@ type engine struct {
 // QueryOptions can be used to pass custom flags to engine.
 type QueryOptions struct {
 	QueryContextOptions models.QueryContextOptions
+	// DurabilityTracker, if set, collects the durability of the data fetched
+	// by Execute from the storage nodes.
+	DurabilityTracker *index.QueryDurabilityTracker
 }
 
 // Query is the result after execution.
@@ -56,6 +61,9 @@ func NewEngine(
 	if engineOpts.GlobalEnforcer() == nil {
 		engineOpts = engineOpts.SetGlobalEnforcer(qcost.NoopChainedEnforcer())
 	}
+	if engineOpts.LiveQueries() == nil {
+		engineOpts = engineOpts.SetLiveQueries(livequery.NewRegistry(time.Now))
+	}
 
 	return &engine{
 		metrics: newEngineMetrics(engineOpts.InstrumentOptions().MetricsScope()),
@@ -114,9 +122,23 @@ func (e *engine) Execute(
 	query *storage.FetchQuery,
 	opts *QueryOptions,
 ) (*storage.FetchResult, error) {
+	ctx, liveQuery := e.opts.LiveQueries().Register(ctx, func() string {
+		return fetchQueryString(query)
+	}, nil)
+	defer liveQuery.Close()
+
 	fetchOpts := storage.NewFetchOptions()
 	fetchOpts.Limit = opts.QueryContextOptions.LimitMaxTimeseries
-	return e.opts.Store().Fetch(ctx, query, fetchOpts)
+	fetchOpts.DurabilityTracker = opts.DurabilityTracker
+	result, err := e.opts.Store().Fetch(ctx, query, fetchOpts)
+	if err != nil {
+		if cancelErr := liveQuery.Err(); cancelErr != nil {
+			return nil, cancelErr
+		}
+		return nil, err
+	}
+
+	return result, nil
 }
 
 func (e *engine) ExecuteExpr(
@@ -127,6 +149,19 @@ func (e *engine) ExecuteExpr(
 ) (Result, error) {
 	perQueryEnforcer := e.opts.GlobalEnforcer().Child(qcost.QueryLevel)
 	defer perQueryEnforcer.Close()
+
+	// The query stays registered until it has finished executing in the
+	// background, or until it fails before execution starts.
+	ctx, liveQuery := e.opts.LiveQueries().Register(ctx, func() string {
+		return params.Query
+	}, enforcerResourcesFn(perQueryEnforcer))
+	executing := false
+	defer func() {
+		if !executing {
+			liveQuery.Close()
+		}
+	}()
+
 	req := newRequest(e, params, e.opts.InstrumentOptions())
 
 	nodes, edges, err := req.compile(ctx, parser)
@@ -153,8 +188,13 @@ func (e *engine) ExecuteExpr(
 	queryCtx := models.NewQueryContext(ctx, scope, perQueryEnforcer,
 		opts.QueryContextOptions)
 
+	executing = true
 	go func() {
+		defer liveQuery.Close()
 		if err := state.Execute(queryCtx); err != nil {
+			if cancelErr := liveQuery.Err(); cancelErr != nil {
+				err = cancelErr
+			}
 			result.abort(err)
 		} else {
 			result.done()
@@ -164,6 +204,24 @@ func (e *engine) ExecuteExpr(
 	return result, nil
 }
 
+func (e *engine) LiveQueries() livequery.Registry {
+	return e.opts.LiveQueries()
+}
+
+func fetchQueryString(query *storage.FetchQuery) string {
+	if query.Raw != "" {
+		return query.Raw
+	}
+	return query.TagMatchers.String()
+}
+
+func enforcerResourcesFn(enforcer qcost.ChainedEnforcer) livequery.ResourcesFn {
+	return func() livequery.Resources {
+		report, _ := enforcer.State()
+		return livequery.Resources{Datapoints: int64(report.Cost)}
+	}
+}
+
 func (e *engine) Close() error {
 	return nil
 }
@@ -34,6 +34,7 @@ import (
 	"github.com/m3db/m3/src/query/storage/mock"
 	"github.com/m3db/m3/src/query/test/m3"
 	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/livequery"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -69,6 +70,29 @@ func TestEngine_Execute(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestEngine_ExecuteCancelledByID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store, session := m3.NewStorageAndSession(t, ctrl)
+	engine := newEngine(store, time.Minute, nil, instrument.NewOptions())
+
+	// Cancel the query by ID while it is fetching.
+	session.EXPECT().FetchTagged(gomock.Any(), gomock.Any(), gomock.Any()).
+		Do(func(_, _, _ interface{}) {
+			queries := engine.LiveQueries().Queries()
+			require.Equal(t, 1, len(queries))
+			require.NoError(t, engine.LiveQueries().Cancel(queries[0].ID))
+		}).
+		Return(nil, false, fmt.Errorf("dummy"))
+	session.EXPECT().IteratorPools().Return(nil, nil).AnyTimes()
+
+	_, err := engine.Execute(context.TODO(),
+		&storage.FetchQuery{}, &QueryOptions{})
+	require.Equal(t, livequery.ErrQueryCancelled, err)
+	require.Equal(t, 0, len(engine.LiveQueries().Queries()))
+}
+
 func TestEngine_ExecuteExpr(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
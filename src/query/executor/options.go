@@ -26,6 +26,7 @@ import (
 	qcost "github.com/m3db/m3/src/query/cost"
 	"github.com/m3db/m3/src/query/storage"
 	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/livequery"
 )
 
 type engineOptions struct {
@@ -33,6 +34,7 @@ type engineOptions struct {
 	globalEnforcer   qcost.ChainedEnforcer
 	store            storage.Storage
 	lookbackDuration time.Duration
+	liveQueries      livequery.Registry
 }
 
 // NewEngineOpts returns a new instance of options used to create an engine.
@@ -79,3 +81,13 @@ func (o *engineOptions) SetLookbackDuration(v time.Duration) EngineOptions {
 	opts.lookbackDuration = v
 	return &opts
 }
+
+func (o *engineOptions) LiveQueries() livequery.Registry {
+	return o.liveQueries
+}
+
+func (o *engineOptions) SetLiveQueries(v livequery.Registry) EngineOptions {
+	opts := *o
+	opts.liveQueries = v
+	return &opts
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/m3db/m3/src/query/parser"
 	"github.com/m3db/m3/src/query/storage"
 	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/livequery"
 )
 
 // Engine executes a Query.
@@ -48,6 +49,10 @@ type Engine interface {
 		params models.RequestParams,
 	) (Result, error)
 
+	// LiveQueries returns the registry of queries that are being executed
+	// which can be used to list them and to cancel them by ID.
+	LiveQueries() livequery.Registry
+
 	// Close kills all running queries and prevents new queries from being attached.
 	Close() error
 }
@@ -75,4 +80,9 @@ type EngineOptions interface {
 	LookbackDuration() time.Duration
 	// SetLookbackDuration sets the query lookback duration.
 	SetLookbackDuration(time.Duration) EngineOptions
+
+	// LiveQueries returns the registry executing queries are registered with.
+	LiveQueries() livequery.Registry
+	// SetLiveQueries sets the registry executing queries are registered with.
+	SetLiveQueries(livequery.Registry) EngineOptions
 }
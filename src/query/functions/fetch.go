@@ -21,6 +21,7 @@
 package functions
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -46,6 +47,11 @@ type FetchOp struct {
 	Range    time.Duration
 	Offset   time.Duration
 	Matchers models.Matchers
+	// IndexExistenceCheck consults the index before fetching any data and
+	// returns an empty block without fetching data if no series match. It is
+	// set for functions such as absent which can be resolved without data
+	// when there are no matching series.
+	IndexExistenceCheck bool
 }
 
 // FetchNode is the execution node
@@ -107,12 +113,46 @@ func (n *FetchNode) fetch(queryCtx *models.QueryContext) (block.Result, error) {
 	opts.Scope = queryCtx.Scope
 	opts.Enforcer = queryCtx.Enforcer
 	offset := n.op.Offset
-	return n.storage.FetchBlocks(ctx, &storage.FetchQuery{
+	query := &storage.FetchQuery{
 		Start:       startTime.Add(-1 * offset),
 		End:         endTime.Add(-1 * offset),
 		TagMatchers: n.op.Matchers,
 		Interval:    timeSpec.Step,
-	}, opts)
+	}
+
+	if n.op.IndexExistenceCheck {
+		exists, err := n.seriesExist(ctx, query, opts)
+		if err != nil {
+			return block.Result{}, err
+		}
+
+		if !exists {
+			return storage.FetchResultToBlockResult(&storage.FetchResult{
+				LocalOnly: true,
+			}, query, 0, opts.Enforcer)
+		}
+	}
+
+	return n.storage.FetchBlocks(ctx, query, opts)
+}
+
+// seriesExist returns whether any series match the query according to the
+// index. Index entries cover whole index blocks so a series that exists may
+// still have no data in a range that only partially covers an index block,
+// as such only a negative result can be used to skip fetching data.
+func (n *FetchNode) seriesExist(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	fetchOpts *storage.FetchOptions,
+) (bool, error) {
+	opts := *fetchOpts
+	opts.Limit = 1
+	result, err := n.storage.SearchSeries(ctx, query, &opts)
+	if err != nil {
+		return false, err
+	}
+
+	return len(result.Metrics) > 0, nil
 }
 
 // Execute runs the fetch node operation
@@ -21,6 +21,7 @@
 package functions
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -116,3 +117,59 @@ func TestOffsetFetch(t *testing.T) {
 	err := node.Execute(models.NoopQueryContext())
 	require.NoError(t, err)
 }
+
+func TestFetchIndexExistenceCheckNoSeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := storage.NewMockStorage(ctrl)
+	store.EXPECT().SearchSeries(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context,
+			_ *storage.FetchQuery,
+			opts *storage.FetchOptions,
+		) (*storage.SearchResults, error) {
+			assert.Equal(t, 1, opts.Limit)
+			return &storage.SearchResults{}, nil
+		})
+
+	_, bounds := test.GenerateValuesAndBounds(nil, nil)
+	opts := transformtest.Options(t, transform.OptionsParams{
+		TimeSpec: transform.TimeSpec{
+			Start: bounds.Start,
+			End:   bounds.End(),
+			Step:  bounds.StepSize,
+		},
+	})
+
+	c, sink := executor.NewControllerWithSink(parser.NodeID(1))
+	node := FetchOp{IndexExistenceCheck: true}.Node(c, store, opts)
+	err := node.Execute(models.NoopQueryContext())
+	require.NoError(t, err)
+
+	// No data is fetched and an empty block is processed.
+	assert.Len(t, sink.Values, 0)
+	assert.Equal(t, bounds.Start, sink.Meta.Bounds.Start)
+}
+
+func TestFetchIndexExistenceCheckSeriesExist(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	values, bounds := test.GenerateValuesAndBounds(nil, nil)
+	b := test.NewBlockFromValues(bounds, values)
+
+	store := storage.NewMockStorage(ctrl)
+	store.EXPECT().SearchSeries(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&storage.SearchResults{
+			Metrics: models.Metrics{{ID: []byte("foo")}},
+		}, nil)
+	store.EXPECT().FetchBlocks(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(block.Result{Blocks: []block.Block{b}}, nil)
+
+	c, sink := executor.NewControllerWithSink(parser.NodeID(1))
+	node := FetchOp{IndexExistenceCheck: true}.Node(c, store, transform.Options{})
+	err := node.Execute(models.NoopQueryContext())
+	require.NoError(t, err)
+	assert.Equal(t, values, sink.Values)
+}
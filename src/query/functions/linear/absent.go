@@ -17,49 +17,128 @@
 // LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
-
 package linear
 
 import (
+	"fmt"
 	"math"
 
+	"github.com/m3db/m3/src/query/block"
 	"github.com/m3db/m3/src/query/executor/transform"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/parser"
 )
 
-// AbsentType returns a timeseries with all NaNs if the timeseries passed in has any non NaNs,
-// and returns a timeseries with the value 1 if the timeseries passed in has no elements
-const AbsentType = "absent"
+const (
+	// AbsentType returns a timeseries with the value 1 at each step where none of
+	// the timeseries passed in have a value, and NaN at every other step.
+	AbsentType = "absent"
+
+	// AbsentOverTimeType returns a timeseries with the value 1 at each step where
+	// none of the timeseries passed in have a value in the specified interval, and
+	// NaN at every other step. It is applied to the count over the interval.
+	AbsentOverTimeType = "absent_over_time"
+)
 
-// NewAbsentOp creates a new base linear transform with an absent node
-func NewAbsentOp() BaseOp {
-	return BaseOp{
-		operatorType: AbsentType,
-		processorFn:  newAbsentNode,
+// NewAbsentOp creates a new absent operation, the tags are the tags of the
+// returned timeseries.
+func NewAbsentOp(opType string, tags models.Tags) (transform.Params, error) {
+	if opType != AbsentType && opType != AbsentOverTimeType {
+		return nil, fmt.Errorf("unknown absent type: %s", opType)
 	}
+
+	if tags.Opts == nil {
+		tags = models.NewTags(tags.Len(), nil).AddTags(tags.Tags)
+	}
+
+	return absentOp{
+		opType: opType,
+		tags:   tags,
+	}, nil
+}
+
+type absentOp struct {
+	opType string
+	tags   models.Tags
+}
+
+// OpType for the operator
+func (o absentOp) OpType() string {
+	return o.opType
 }
 
-func newAbsentNode(op BaseOp, controller *transform.Controller) Processor {
+// String representation
+func (o absentOp) String() string {
+	return fmt.Sprintf("type: %s", o.OpType())
+}
+
+// Node creates an execution node
+func (o absentOp) Node(
+	controller *transform.Controller,
+	_ transform.Options,
+) transform.OpNode {
 	return &absentNode{
-		op:         op,
+		op:         o,
 		controller: controller,
 	}
 }
 
 type absentNode struct {
-	op         BaseOp
+	op         absentOp
 	controller *transform.Controller
 }
 
-func (c *absentNode) Process(values []float64) []float64 {
-	num := 1.0
-	if !allNaNs(values) {
-		num = math.NaN()
+func (n *absentNode) Params() parser.Params {
+	return n.op
+}
+
+// Process the block
+func (n *absentNode) Process(queryCtx *models.QueryContext, ID parser.NodeID, b block.Block) error {
+	return transform.ProcessSimpleBlock(n, n.controller, queryCtx, ID, b)
+}
+
+// ProcessBlock returns a block with a single timeseries which is 1 at each
+// step where the input block has no values.
+func (n *absentNode) ProcessBlock(queryCtx *models.QueryContext, ID parser.NodeID, b block.Block) (block.Block, error) {
+	stepIter, err := b.StepIter()
+	if err != nil {
+		return nil, err
+	}
+
+	meta := stepIter.Meta()
+	meta.Tags = n.op.tags
+	seriesMetas := []block.SeriesMeta{
+		{
+			Tags: models.NewTags(0, n.op.tags.Opts),
+			Name: []byte(n.op.opType),
+		},
+	}
+
+	builder, err := n.controller.BlockBuilder(queryCtx, meta, seriesMetas)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := builder.AddCols(stepIter.StepCount()); err != nil {
+		return nil, err
 	}
 
-	for i := range values {
-		values[i] = num
+	for index := 0; stepIter.Next(); index++ {
+		value := 1.0
+		if !allNaNs(stepIter.Current().Values()) {
+			value = math.NaN()
+		}
+
+		if err := builder.AppendValue(index, value); err != nil {
+			return nil, err
+		}
 	}
-	return values
+
+	if err = stepIter.Err(); err != nil {
+		return nil, err
+	}
+
+	return builder.Build(), nil
 }
 
 func allNaNs(vals []float64) bool {
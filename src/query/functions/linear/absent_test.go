@@ -39,17 +39,21 @@ var (
 )
 
 func TestAbsentWithValues(t *testing.T) {
-	values, bounds := test.GenerateValuesAndBounds(nil, nil)
+	v := [][]float64{
+		{nan, 1, nan, nan, 2},
+		{nan, nan, 3, nan, nan},
+	}
+
+	values, bounds := test.GenerateValuesAndBounds(v, nil)
 	block := test.NewBlockFromValues(bounds, values)
 	c, sink := executor.NewControllerWithSink(parser.NodeID(1))
-	node := NewAbsentOp().Node(c, transform.Options{})
-	err := node.Process(models.NoopQueryContext(), parser.NodeID(0), block)
+	op, err := NewAbsentOp(AbsentType, models.EmptyTags())
 	require.NoError(t, err)
-	assert.Len(t, sink.Values, 2)
-	expected := [][]float64{
-		{nan, nan, nan, nan, nan},
-		{nan, nan, nan, nan, nan},
-	}
+	node := op.Node(c, transform.Options{})
+	err = node.Process(models.NoopQueryContext(), parser.NodeID(0), block)
+	require.NoError(t, err)
+	assert.Len(t, sink.Values, 1)
+	expected := [][]float64{{1, nan, nan, 1, nan}}
 	test.EqualsWithNans(t, expected, sink.Values)
 }
 
@@ -62,9 +66,31 @@ func TestAbsentWithNoValues(t *testing.T) {
 	values, bounds := test.GenerateValuesAndBounds(v, nil)
 	block := test.NewBlockFromValues(bounds, values)
 	c, sink := executor.NewControllerWithSink(parser.NodeID(1))
-	node := NewAbsentOp().Node(c, transform.Options{})
-	err := node.Process(models.NoopQueryContext(), parser.NodeID(0), block)
+	tags := models.EmptyTags().AddTag(models.Tag{Name: []byte("job"), Value: []byte("api")})
+	op, err := NewAbsentOp(AbsentType, tags)
 	require.NoError(t, err)
-	assert.Len(t, sink.Values, 2)
-	assert.Equal(t, [][]float64{{1, 1, 1, 1, 1}, {1, 1, 1, 1, 1}}, sink.Values)
+	node := op.Node(c, transform.Options{})
+	err = node.Process(models.NoopQueryContext(), parser.NodeID(0), block)
+	require.NoError(t, err)
+	assert.Len(t, sink.Values, 1)
+	assert.Equal(t, [][]float64{{1, 1, 1, 1, 1}}, sink.Values)
+	assert.Equal(t, tags, sink.Meta.Tags)
+}
+
+func TestAbsentWithNoSeries(t *testing.T) {
+	_, bounds := test.GenerateValuesAndBounds(nil, nil)
+	block := test.NewUnconsolidatedBlockFromDatapoints(bounds, [][]float64{})
+	c, sink := executor.NewControllerWithSink(parser.NodeID(1))
+	op, err := NewAbsentOp(AbsentOverTimeType, models.EmptyTags())
+	require.NoError(t, err)
+	node := op.Node(c, transform.Options{})
+	err = node.Process(models.NoopQueryContext(), parser.NodeID(0), block)
+	require.NoError(t, err)
+	assert.Len(t, sink.Values, 1)
+	assert.Equal(t, [][]float64{{1, 1, 1, 1, 1}}, sink.Values)
+}
+
+func TestNewAbsentOpUnknownType(t *testing.T) {
+	_, err := NewAbsentOp("absent_foo", models.EmptyTags())
+	require.Error(t, err)
 }
@@ -25,9 +25,12 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/functions"
 	"github.com/m3db/m3/src/query/functions/binary"
 	"github.com/m3db/m3/src/query/functions/lazy"
+	"github.com/m3db/m3/src/query/functions/linear"
 	"github.com/m3db/m3/src/query/functions/scalar"
+	"github.com/m3db/m3/src/query/functions/temporal"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/parser"
 
@@ -193,6 +196,10 @@ func (p *parseState) walk(node pql.Node) error {
 		return p.addLazyOffsetTransform(n.Offset)
 
 	case *pql.Call:
+		if n.Func.Name == linear.AbsentType || n.Func.Name == linear.AbsentOverTimeType {
+			return p.walkAbsent(n)
+		}
+
 		expressions := n.Args
 		argTypes := n.Func.ArgTypes
 		argValues := make([]interface{}, 0, len(expressions))
@@ -308,3 +315,63 @@ func (p *parseState) walk(node pql.Node) error {
 		return fmt.Errorf("promql.Walk: unhandled node type %T, %v", node, node)
 	}
 }
+
+// walkAbsent adds the transforms for absent functions. When applied directly
+// to a selector the fetch consults the index first so that no data is fetched
+// if no series match, and the returned series is labelled using the selector.
+func (p *parseState) walkAbsent(n *pql.Call) error {
+	if len(n.Args) != 1 {
+		return fmt.Errorf("invalid number of args for %s: %d", n.Func.Name, len(n.Args))
+	}
+
+	var (
+		expr     = n.Args[0]
+		fetchIdx = p.transformLen()
+		tags     = models.NewTags(0, p.tagOpts)
+		argRange time.Duration
+	)
+	if err := p.walk(expr); err != nil {
+		return err
+	}
+
+	_, isVector := expr.(*pql.VectorSelector)
+	matrix, isMatrix := expr.(*pql.MatrixSelector)
+	if isMatrix {
+		argRange = matrix.Range
+	}
+
+	if isVector || isMatrix {
+		if fetch, ok := p.transforms[fetchIdx].Op.(functions.FetchOp); ok {
+			fetch.IndexExistenceCheck = true
+			p.transforms[fetchIdx].Op = fetch
+			tags = absentTags(fetch.Matchers, p.tagOpts)
+		}
+	}
+
+	if n.Func.Name == linear.AbsentOverTimeType {
+		// Absent over time is absent applied to the count over the range.
+		countOp, err := temporal.NewAggOp([]interface{}{argRange}, temporal.CountType)
+		if err != nil {
+			return err
+		}
+
+		p.addTransform(countOp)
+	}
+
+	op, err := linear.NewAbsentOp(n.Func.Name, tags)
+	if err != nil {
+		return err
+	}
+
+	p.addTransform(op)
+	return nil
+}
+
+func (p *parseState) addTransform(op parser.Params) {
+	opTransform := parser.NewTransformFromOperation(op, p.transformLen())
+	p.edges = append(p.edges, parser.Edge{
+		ParentID: p.lastTransformID(),
+		ChildID:  opTransform.ID,
+	})
+	p.transforms = append(p.transforms, opTransform)
+}
@@ -222,6 +222,52 @@ func TestLinearParses(t *testing.T) {
 	}
 }
 
+func TestAbsentParsesWithIndexExistenceCheck(t *testing.T) {
+	tagOpts := models.NewTagOptions()
+	p, err := Parse(`absent(up{job="api",env=~"prod.*"})`, tagOpts)
+	require.NoError(t, err)
+	transforms, edges, err := p.DAG()
+	require.NoError(t, err)
+	require.Len(t, transforms, 2)
+
+	fetch, ok := transforms[0].Op.(functions.FetchOp)
+	require.True(t, ok)
+	assert.True(t, fetch.IndexExistenceCheck)
+
+	assert.Equal(t, linear.AbsentType, transforms[1].Op.OpType())
+	assert.Len(t, edges, 1)
+
+	expected := models.NewTags(1, tagOpts).
+		AddTag(models.Tag{Name: []byte("job"), Value: []byte("api")})
+	assert.Equal(t, expected, absentTags(fetch.Matchers, tagOpts))
+}
+
+func TestAbsentOfExpressionParsesWithoutIndexExistenceCheck(t *testing.T) {
+	p, err := Parse("absent(sum(up))", models.NewTagOptions())
+	require.NoError(t, err)
+	transforms, _, err := p.DAG()
+	require.NoError(t, err)
+	require.Len(t, transforms, 3)
+
+	fetch, ok := transforms[0].Op.(functions.FetchOp)
+	require.True(t, ok)
+	assert.False(t, fetch.IndexExistenceCheck)
+	assert.Equal(t, linear.AbsentType, transforms[2].Op.OpType())
+}
+
+func TestAbsentTagsSkipsRepeatedNames(t *testing.T) {
+	tagOpts := models.NewTagOptions()
+	matchers := models.Matchers{
+		{Type: models.MatchEqual, Name: []byte("job"), Value: []byte("a")},
+		{Type: models.MatchEqual, Name: []byte("job"), Value: []byte("b")},
+		{Type: models.MatchEqual, Name: []byte("env"), Value: []byte("prod")},
+	}
+
+	expected := models.NewTags(1, tagOpts).
+		AddTag(models.Tag{Name: []byte("env"), Value: []byte("prod")})
+	assert.Equal(t, expected, absentTags(matchers, tagOpts))
+}
+
 var sortTests = []struct {
 	q            string
 	expectedType string
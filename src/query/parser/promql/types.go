@@ -21,6 +21,7 @@
 package promql
 
 import (
+	"bytes"
 	"fmt"
 	"time"
 
@@ -181,8 +182,8 @@ func NewFunctionExpr(
 		p, err = linear.NewMathOp(name)
 		return p, true, err
 
-	case linear.AbsentType:
-		p = linear.NewAbsentOp()
+	case linear.AbsentType, linear.AbsentOverTimeType:
+		p, err = linear.NewAbsentOp(name, models.EmptyTags())
 		return p, true, err
 
 	case linear.ClampMinType, linear.ClampMaxType:
@@ -336,6 +337,29 @@ func LabelMatchersToModelMatcher(
 	return matchers, nil
 }
 
+// absentTags returns the tags of the series returned by absent functions,
+// which are taken from the equality matchers of the selector they are applied
+// to excluding the metric name and any name matched more than once.
+func absentTags(matchers models.Matchers, tagOpts models.TagOptions) models.Tags {
+	counts := make(map[string]int, len(matchers))
+	for _, m := range matchers {
+		counts[string(m.Name)]++
+	}
+
+	tags := models.NewTags(len(matchers), tagOpts)
+	for _, m := range matchers {
+		if m.Type != models.MatchEqual ||
+			bytes.Equal(m.Name, tagOpts.MetricName()) ||
+			counts[string(m.Name)] > 1 {
+			continue
+		}
+
+		tags = tags.AddTag(models.Tag{Name: m.Name, Value: m.Value})
+	}
+
+	return tags
+}
+
 // promTypeToM3 converts a prometheus label type to m3 matcher type.
 // TODO(nikunj): Consider merging with prompb code.
 func promTypeToM3(labelType labels.MatchType) (models.MatchType, error) {
@@ -236,3 +236,21 @@ func TestFetchOptionsToAggregateOptions(t *testing.T) {
 	require.Equal(t, 1, len(aggOpts.FieldFilter))
 	require.Equal(t, "filter", string(aggOpts.FieldFilter[0]))
 }
+
+func TestFetchOptionsToM3OptionsDurability(t *testing.T) {
+	end := time.Now()
+	start := end.Add(-1 * time.Hour)
+	query := &FetchQuery{Start: start, End: end}
+
+	opts := FetchOptionsToM3Options(&FetchOptions{Limit: 7}, query)
+	assert.Equal(t, 7, opts.Limit)
+	assert.Equal(t, start, opts.StartInclusive)
+	assert.Equal(t, end, opts.EndExclusive)
+	assert.False(t, opts.IncludeDurability)
+	assert.Nil(t, opts.DurabilityTracker)
+
+	tracker := index.NewQueryDurabilityTracker()
+	opts = FetchOptionsToM3Options(&FetchOptions{DurabilityTracker: tracker}, query)
+	assert.True(t, opts.IncludeDurability)
+	assert.True(t, tracker == opts.DurabilityTracker)
+}
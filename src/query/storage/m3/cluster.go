@@ -31,6 +31,7 @@ import (
 	"github.com/m3db/m3/src/query/storage"
 	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/instrument"
 )
 
 var (
@@ -42,6 +43,8 @@ var (
 	defaultClusterNamespaceDownsampleOptions = ClusterNamespaceDownsampleOptions{
 		All: true,
 	}
+
+	defaultFetchFanoutPolicy = NewPrimaryFetchFanoutPolicy(instrument.NewOptions())
 )
 
 // Clusters is a flattened collection of local storage clusters and namespaces.
@@ -72,14 +75,18 @@ type ClusterNamespace interface {
 	NamespaceID() ident.ID
 	Options() ClusterNamespaceOptions
 	Session() client.Session
+	// ReplicaSessions returns the sessions of the clusters holding replicas
+	// of the namespace that fetches may be fanned out to.
+	ReplicaSessions() []client.Session
 }
 
 // ClusterNamespaceOptions is a set of options
 type ClusterNamespaceOptions struct {
 	// Note: Don't allow direct access, as we want to provide defaults
 	// and/or error if call to access a field is not relevant/correct.
-	attributes storage.Attributes
-	downsample *ClusterNamespaceDownsampleOptions
+	attributes  storage.Attributes
+	downsample  *ClusterNamespaceDownsampleOptions
+	fetchFanout FetchFanoutPolicy
 }
 
 // Attributes returns the storage attributes of the cluster namespace.
@@ -102,6 +109,15 @@ func (o ClusterNamespaceOptions) DownsampleOptions() (
 	return *o.downsample, nil
 }
 
+// FetchFanoutPolicy returns the policy used to fan out fetches of the cluster
+// namespace to its replicas.
+func (o ClusterNamespaceOptions) FetchFanoutPolicy() FetchFanoutPolicy {
+	if o.fetchFanout == nil {
+		return defaultFetchFanoutPolicy
+	}
+	return o.fetchFanout
+}
+
 // ClusterNamespaceDownsampleOptions is the downsample options for
 // a cluster namespace.
 type ClusterNamespaceDownsampleOptions struct {
@@ -146,9 +162,11 @@ func (n ClusterNamespaces) NumAggregatedClusterNamespaces() int {
 // UnaggregatedClusterNamespaceDefinition is the definition for the
 // cluster namespace that holds unaggregated metrics data.
 type UnaggregatedClusterNamespaceDefinition struct {
-	NamespaceID ident.ID
-	Session     client.Session
-	Retention   time.Duration
+	NamespaceID       ident.ID
+	Session           client.Session
+	ReplicaSessions   []client.Session
+	FetchFanoutPolicy FetchFanoutPolicy
+	Retention         time.Duration
 }
 
 // Validate will validate the cluster namespace definition.
@@ -159,6 +177,11 @@ func (def UnaggregatedClusterNamespaceDefinition) Validate() error {
 	if def.Session == nil {
 		return errSessionNotSet
 	}
+	for _, session := range def.ReplicaSessions {
+		if session == nil {
+			return errSessionNotSet
+		}
+	}
 	if def.Retention <= 0 {
 		return errRetentionNotSet
 	}
@@ -169,11 +192,13 @@ func (def UnaggregatedClusterNamespaceDefinition) Validate() error {
 // cluster namespace that holds aggregated metrics data at a
 // specific retention and resolution.
 type AggregatedClusterNamespaceDefinition struct {
-	NamespaceID ident.ID
-	Session     client.Session
-	Retention   time.Duration
-	Resolution  time.Duration
-	Downsample  *ClusterNamespaceDownsampleOptions
+	NamespaceID       ident.ID
+	Session           client.Session
+	ReplicaSessions   []client.Session
+	FetchFanoutPolicy FetchFanoutPolicy
+	Retention         time.Duration
+	Resolution        time.Duration
+	Downsample        *ClusterNamespaceDownsampleOptions
 }
 
 // Validate validates the cluster namespace definition.
@@ -184,6 +209,11 @@ func (def AggregatedClusterNamespaceDefinition) Validate() error {
 	if def.Session == nil {
 		return errSessionNotSet
 	}
+	for _, session := range def.ReplicaSessions {
+		if session == nil {
+			return errSessionNotSet
+		}
+	}
 	if def.Retention <= 0 {
 		return errRetentionNotSet
 	}
@@ -269,17 +299,18 @@ func (c *clusters) Close() error {
 	)
 	// Collect unique sessions, some namespaces may share same
 	// client session (same cluster)
-	uniqueSessions = append(uniqueSessions, c.unaggregatedNamespace.Session())
-	for _, namespace := range c.aggregatedNamespaces {
-		unique := true
-		for _, session := range uniqueSessions {
-			if namespace.Session() == session {
-				unique = false
-				break
+	addUniqueSession := func(session client.Session) {
+		for _, existing := range uniqueSessions {
+			if session == existing {
+				return
 			}
 		}
-		if unique {
-			uniqueSessions = append(uniqueSessions, namespace.Session())
+		uniqueSessions = append(uniqueSessions, session)
+	}
+	for _, namespace := range c.namespaces {
+		addUniqueSession(namespace.Session())
+		for _, session := range namespace.ReplicaSessions() {
+			addUniqueSession(session)
 		}
 	}
 
@@ -299,9 +330,10 @@ func (c *clusters) Close() error {
 }
 
 type clusterNamespace struct {
-	namespaceID ident.ID
-	options     ClusterNamespaceOptions
-	session     client.Session
+	namespaceID     ident.ID
+	options         ClusterNamespaceOptions
+	session         client.Session
+	replicaSessions []client.Session
 }
 
 func newUnaggregatedClusterNamespace(
@@ -321,8 +353,10 @@ func newUnaggregatedClusterNamespace(
 				MetricsType: storage.UnaggregatedMetricsType,
 				Retention:   def.Retention,
 			},
+			fetchFanout: def.FetchFanoutPolicy,
 		},
-		session: def.Session,
+		session:         def.Session,
+		replicaSessions: def.ReplicaSessions,
 	}, nil
 }
 
@@ -344,9 +378,11 @@ func newAggregatedClusterNamespace(
 				Retention:   def.Retention,
 				Resolution:  def.Resolution,
 			},
-			downsample: def.Downsample,
+			downsample:  def.Downsample,
+			fetchFanout: def.FetchFanoutPolicy,
 		},
-		session: def.Session,
+		session:         def.Session,
+		replicaSessions: def.ReplicaSessions,
 	}, nil
 }
 
@@ -362,6 +398,10 @@ func (n *clusterNamespace) Session() client.Session {
 	return n.session
 }
 
+func (n *clusterNamespace) ReplicaSessions() []client.Session {
+	return n.replicaSessions
+}
+
 type syncMultiErrs struct {
 	sync.Mutex
 	multiErr xerrors.MultiError
@@ -54,16 +54,25 @@ type ClusterStaticConfiguration struct {
 	NewClientFromConfig NewClientFromConfig
 	Namespaces          []ClusterStaticNamespaceConfiguration `yaml:"namespaces"`
 	Client              client.Configuration                  `yaml:"client"`
+
+	// Replicas are the client configurations of the clusters holding replicas
+	// of the namespaces of the cluster, fetches are fanned out to them using
+	// the fetch fanout policy.
+	Replicas []client.Configuration `yaml:"replicas"`
+
+	// FetchFanout is the policy used to fan out fetches to the replicas.
+	FetchFanout *FetchFanoutPolicyConfiguration `yaml:"fetchFanout"`
 }
 
 func (c ClusterStaticConfiguration) newClient(
+	cfg client.Configuration,
 	params client.ConfigurationParameters,
 	custom ...client.CustomOption,
 ) (client.Client, error) {
 	if c.NewClientFromConfig != nil {
-		return c.NewClientFromConfig(c.Client, params, custom...)
+		return c.NewClientFromConfig(cfg, params, custom...)
 	}
-	return c.Client.NewClient(params, custom...)
+	return cfg.NewClient(params, custom...)
 }
 
 // ClusterStaticNamespaceConfiguration describes the namespaces in a
@@ -144,16 +153,39 @@ func (c DownsampleClusterStaticNamespaceConfiguration) downsampleOptions() Clust
 
 type unaggregatedClusterNamespaceConfiguration struct {
 	client    client.Client
+	replicas  clusterReplicasConfiguration
 	namespace ClusterStaticNamespaceConfiguration
 	result    clusterConnectResult
 }
 
 type aggregatedClusterNamespacesConfiguration struct {
 	client     client.Client
+	replicas   clusterReplicasConfiguration
 	namespaces []ClusterStaticNamespaceConfiguration
 	result     clusterConnectResult
 }
 
+type clusterReplicasConfiguration struct {
+	clients     []client.Client
+	results     []clusterConnectResult
+	fetchFanout FetchFanoutPolicy
+}
+
+func (c clusterReplicasConfiguration) sessions() ([]client.Session, error) {
+	if len(c.results) == 0 {
+		return nil, nil
+	}
+	sessions := make([]client.Session, 0, len(c.results))
+	for i, result := range c.results {
+		if result.err != nil {
+			return nil, fmt.Errorf("could not connect to replica cluster #%d: %v",
+				i, result.err)
+		}
+		sessions = append(sessions, result.session)
+	}
+	return sessions, nil
+}
+
 type clusterConnectResult struct {
 	session client.Session
 	err     error
@@ -181,22 +213,41 @@ func (c ClustersStaticConfiguration) NewClusters(
 	)
 	for _, clusterCfg := range c {
 		var (
-			result client.Client
-			err    error
+			result   client.Client
+			replicas clusterReplicasConfiguration
+			err      error
 		)
 
 		if opts.ProvidedSession == nil {
 			// NB(r): Only create client session if not already provided.
-			result, err = clusterCfg.newClient(client.ConfigurationParameters{
+			params := client.ConfigurationParameters{
 				InstrumentOptions: instrumentOpts,
-			})
+			}
+			result, err = clusterCfg.newClient(clusterCfg.Client, params)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, replicaCfg := range clusterCfg.Replicas {
+				replica, err := clusterCfg.newClient(replicaCfg, params)
+				if err != nil {
+					return nil, err
+				}
+				replicas.clients = append(replicas.clients, replica)
+			}
+			replicas.results = make([]clusterConnectResult, len(replicas.clients))
+		}
+
+		if clusterCfg.FetchFanout != nil {
+			replicas.fetchFanout, err = clusterCfg.FetchFanout.NewPolicy(instrumentOpts)
 			if err != nil {
 				return nil, err
 			}
 		}
 
 		aggregatedClusterNamespacesCfg := &aggregatedClusterNamespacesConfiguration{
-			client: result,
+			client:   result,
+			replicas: replicas,
 		}
 
 		for _, n := range clusterCfg.Namespaces {
@@ -214,6 +265,7 @@ func (c ClustersStaticConfiguration) NewClusters(
 				}
 
 				unaggregatedClusterNamespaceCfg.client = result
+				unaggregatedClusterNamespaceCfg.replicas = replicas
 				unaggregatedClusterNamespaceCfg.namespace = n
 
 			case storage.AggregatedMetricsType:
@@ -240,36 +292,31 @@ func (c ClustersStaticConfiguration) NewClusters(
 
 	// Connect to all clusters in parallel
 	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		cfg := unaggregatedClusterNamespaceCfg
-		if opts.ProvidedSession != nil {
-			cfg.result.session = opts.ProvidedSession
-		} else if !opts.AsyncSessions {
-			cfg.result.session, cfg.result.err = cfg.client.DefaultSession()
-		} else {
-			cfg.result.session = m3db.NewAsyncSession(func() (client.Client, error) {
-				return cfg.client, nil
-			}, nil)
-		}
-	}()
-	for _, cfg := range aggregatedClusterNamespacesCfgs {
-		cfg := cfg // Capture var
+	connect := func(c client.Client, result *clusterConnectResult) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			if opts.ProvidedSession != nil {
-				cfg.result.session = opts.ProvidedSession
+				result.session = opts.ProvidedSession
 			} else if !opts.AsyncSessions {
-				cfg.result.session, cfg.result.err = cfg.client.DefaultSession()
+				result.session, result.err = c.DefaultSession()
 			} else {
-				cfg.result.session = m3db.NewAsyncSession(func() (client.Client, error) {
-					return cfg.client, nil
+				result.session = m3db.NewAsyncSession(func() (client.Client, error) {
+					return c, nil
 				}, nil)
 			}
 		}()
 	}
+	connect(unaggregatedClusterNamespaceCfg.client, &unaggregatedClusterNamespaceCfg.result)
+	for i, c := range unaggregatedClusterNamespaceCfg.replicas.clients {
+		connect(c, &unaggregatedClusterNamespaceCfg.replicas.results[i])
+	}
+	for _, cfg := range aggregatedClusterNamespacesCfgs {
+		connect(cfg.client, &cfg.result)
+		for i, c := range cfg.replicas.clients {
+			connect(c, &cfg.replicas.results[i])
+		}
+	}
 
 	// Wait
 	wg.Wait()
@@ -278,11 +325,17 @@ func (c ClustersStaticConfiguration) NewClusters(
 		return nil, fmt.Errorf("could not connect to unaggregated cluster: %v",
 			unaggregatedClusterNamespaceCfg.result.err)
 	}
+	unaggregatedReplicaSessions, err := unaggregatedClusterNamespaceCfg.replicas.sessions()
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to unaggregated cluster replica: %v", err)
+	}
 
 	unaggregatedClusterNamespace = UnaggregatedClusterNamespaceDefinition{
-		NamespaceID: ident.StringID(unaggregatedClusterNamespaceCfg.namespace.Namespace),
-		Session:     unaggregatedClusterNamespaceCfg.result.session,
-		Retention:   unaggregatedClusterNamespaceCfg.namespace.Retention,
+		NamespaceID:       ident.StringID(unaggregatedClusterNamespaceCfg.namespace.Namespace),
+		Session:           unaggregatedClusterNamespaceCfg.result.session,
+		ReplicaSessions:   unaggregatedReplicaSessions,
+		FetchFanoutPolicy: unaggregatedClusterNamespaceCfg.replicas.fetchFanout,
+		Retention:         unaggregatedClusterNamespaceCfg.namespace.Retention,
 	}
 
 	for i, cfg := range aggregatedClusterNamespacesCfgs {
@@ -290,6 +343,11 @@ func (c ClustersStaticConfiguration) NewClusters(
 			return nil, fmt.Errorf("could not connect to aggregated cluster #%d: %v",
 				i, cfg.result.err)
 		}
+		replicaSessions, err := cfg.replicas.sessions()
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to aggregated cluster #%d replica: %v",
+				i, err)
+		}
 
 		for _, n := range cfg.namespaces {
 			downsampleOpts, err := n.downsampleOptions()
@@ -299,11 +357,13 @@ func (c ClustersStaticConfiguration) NewClusters(
 			}
 
 			def := AggregatedClusterNamespaceDefinition{
-				NamespaceID: ident.StringID(n.Namespace),
-				Session:     cfg.result.session,
-				Retention:   n.Retention,
-				Resolution:  n.Resolution,
-				Downsample:  &downsampleOpts,
+				NamespaceID:       ident.StringID(n.Namespace),
+				Session:           cfg.result.session,
+				ReplicaSessions:   replicaSessions,
+				FetchFanoutPolicy: cfg.replicas.fetchFanout,
+				Retention:         n.Retention,
+				Resolution:        n.Resolution,
+				Downsample:        &downsampleOpts,
 			}
 			aggregatedClusterNamespaces = append(aggregatedClusterNamespaces, def)
 		}
@@ -0,0 +1,397 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package m3
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	defaultHedgeThreshold = 100 * time.Millisecond
+)
+
+var (
+	errNoReplicasToFetch         = goerrors.New("no replicas to fetch from")
+	errHedgeThresholdNotPositive = goerrors.New("hedge threshold must be positive")
+
+	validFetchFanoutPolicyTypes = []FetchFanoutPolicyType{
+		PrimaryFetchFanoutPolicyType,
+		FastestReplicaFetchFanoutPolicyType,
+		HedgedFetchFanoutPolicyType,
+	}
+)
+
+// FetchFanoutPolicyType is a type of policy used to fan out the fetch of a
+// cluster namespace to the clusters holding replicas of its data.
+type FetchFanoutPolicyType uint
+
+const (
+	// PrimaryFetchFanoutPolicyType only fetches from the primary cluster.
+	PrimaryFetchFanoutPolicyType FetchFanoutPolicyType = iota
+	// FastestReplicaFetchFanoutPolicyType fetches from all the clusters
+	// concurrently and uses the first successful result.
+	FastestReplicaFetchFanoutPolicyType
+	// HedgedFetchFanoutPolicyType fetches from the primary cluster and issues
+	// a hedged fetch to the next replica cluster each time no fetch has
+	// completed after the hedge threshold, using the first successful result.
+	HedgedFetchFanoutPolicyType
+
+	// DefaultFetchFanoutPolicyType is the default fetch fanout policy type.
+	DefaultFetchFanoutPolicyType = PrimaryFetchFanoutPolicyType
+)
+
+func (t FetchFanoutPolicyType) String() string {
+	switch t {
+	case PrimaryFetchFanoutPolicyType:
+		return "primary"
+	case FastestReplicaFetchFanoutPolicyType:
+		return "fastestReplica"
+	case HedgedFetchFanoutPolicyType:
+		return "hedged"
+	default:
+		return "unknown"
+	}
+}
+
+// UnmarshalYAML unmarshals a fetch fanout policy type.
+func (t *FetchFanoutPolicyType) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	if str == "" {
+		*t = DefaultFetchFanoutPolicyType
+		return nil
+	}
+	for _, valid := range validFetchFanoutPolicyTypes {
+		if str == valid.String() {
+			*t = valid
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid FetchFanoutPolicyType '%s' valid types are: %v",
+		str, validFetchFanoutPolicyTypes)
+}
+
+// ReplicaFetchFn fetches the series of a query from a single replica.
+type ReplicaFetchFn func() (encoding.SeriesIterators, error)
+
+// FetchFanoutPolicy determines how the fetch of a cluster namespace is fanned
+// out to the clusters holding replicas of its data. The first replica is
+// always the primary cluster of the namespace.
+type FetchFanoutPolicy interface {
+	// Type returns the type of the policy.
+	Type() FetchFanoutPolicyType
+
+	// Fetch fetches from the replicas and returns the result to use, any
+	// results that are not returned are closed.
+	Fetch(
+		ctx context.Context,
+		replicas []ReplicaFetchFn,
+	) (encoding.SeriesIterators, error)
+}
+
+// FetchFanoutPolicyConfiguration is the configuration of a fetch fanout policy.
+type FetchFanoutPolicyConfiguration struct {
+	// Type is the type of the policy.
+	Type FetchFanoutPolicyType `yaml:"type"`
+
+	// HedgeThreshold is the duration after which a hedged fetch is issued to
+	// the next replica when using the hedged policy.
+	HedgeThreshold *time.Duration `yaml:"hedgeThreshold"`
+}
+
+// NewPolicy returns a new fetch fanout policy from the configuration.
+func (c FetchFanoutPolicyConfiguration) NewPolicy(
+	instrumentOpts instrument.Options,
+) (FetchFanoutPolicy, error) {
+	switch c.Type {
+	case PrimaryFetchFanoutPolicyType:
+		return NewPrimaryFetchFanoutPolicy(instrumentOpts), nil
+	case FastestReplicaFetchFanoutPolicyType:
+		return NewFastestReplicaFetchFanoutPolicy(instrumentOpts), nil
+	case HedgedFetchFanoutPolicyType:
+		threshold := defaultHedgeThreshold
+		if c.HedgeThreshold != nil {
+			threshold = *c.HedgeThreshold
+		}
+		return NewHedgedFetchFanoutPolicy(threshold, instrumentOpts)
+	default:
+		return nil, fmt.Errorf("unknown fetch fanout policy type: %v", c.Type)
+	}
+}
+
+type fetchFanoutPolicyMetrics struct {
+	fetches       tally.Counter
+	fetchErrors   tally.Counter
+	replicaWins   tally.Counter
+	hedgedFetches tally.Counter
+	discarded     tally.Counter
+	latency       tally.Timer
+}
+
+func newFetchFanoutPolicyMetrics(
+	policyType FetchFanoutPolicyType,
+	instrumentOpts instrument.Options,
+) fetchFanoutPolicyMetrics {
+	scope := instrumentOpts.MetricsScope().
+		SubScope("fetch-fanout").
+		Tagged(map[string]string{"policy": policyType.String()})
+	return fetchFanoutPolicyMetrics{
+		fetches:       scope.Counter("fetches"),
+		fetchErrors:   scope.Counter("fetch-errors"),
+		replicaWins:   scope.Counter("replica-wins"),
+		hedgedFetches: scope.Counter("hedged-fetches"),
+		discarded:     scope.Counter("discarded"),
+		latency:       scope.Timer("latency"),
+	}
+}
+
+type replicaFetchResult struct {
+	replica int
+	iters   encoding.SeriesIterators
+	err     error
+}
+
+type primaryFetchFanoutPolicy struct {
+	nowFn   func() time.Time
+	metrics fetchFanoutPolicyMetrics
+}
+
+// NewPrimaryFetchFanoutPolicy returns a policy that only fetches from the
+// primary cluster of a namespace.
+func NewPrimaryFetchFanoutPolicy(
+	instrumentOpts instrument.Options,
+) FetchFanoutPolicy {
+	return &primaryFetchFanoutPolicy{
+		nowFn:   time.Now,
+		metrics: newFetchFanoutPolicyMetrics(PrimaryFetchFanoutPolicyType, instrumentOpts),
+	}
+}
+
+func (p *primaryFetchFanoutPolicy) Type() FetchFanoutPolicyType {
+	return PrimaryFetchFanoutPolicyType
+}
+
+func (p *primaryFetchFanoutPolicy) Fetch(
+	_ context.Context,
+	replicas []ReplicaFetchFn,
+) (encoding.SeriesIterators, error) {
+	if len(replicas) == 0 {
+		return nil, errNoReplicasToFetch
+	}
+
+	start := p.nowFn()
+	p.metrics.fetches.Inc(1)
+	iters, err := replicas[0]()
+	if err != nil {
+		p.metrics.fetchErrors.Inc(1)
+		return nil, err
+	}
+	p.metrics.latency.Record(p.nowFn().Sub(start))
+	return iters, nil
+}
+
+type fastestReplicaFetchFanoutPolicy struct {
+	nowFn   func() time.Time
+	metrics fetchFanoutPolicyMetrics
+}
+
+// NewFastestReplicaFetchFanoutPolicy returns a policy that fetches from all
+// the replicas of a namespace concurrently and uses the first successful
+// result.
+func NewFastestReplicaFetchFanoutPolicy(
+	instrumentOpts instrument.Options,
+) FetchFanoutPolicy {
+	return &fastestReplicaFetchFanoutPolicy{
+		nowFn:   time.Now,
+		metrics: newFetchFanoutPolicyMetrics(FastestReplicaFetchFanoutPolicyType, instrumentOpts),
+	}
+}
+
+func (p *fastestReplicaFetchFanoutPolicy) Type() FetchFanoutPolicyType {
+	return FastestReplicaFetchFanoutPolicyType
+}
+
+func (p *fastestReplicaFetchFanoutPolicy) Fetch(
+	ctx context.Context,
+	replicas []ReplicaFetchFn,
+) (encoding.SeriesIterators, error) {
+	if len(replicas) == 0 {
+		return nil, errNoReplicasToFetch
+	}
+
+	var (
+		start = p.nowFn()
+		// NB: Buffered so that the fetches never block once a result is used.
+		resultCh = make(chan replicaFetchResult, len(replicas))
+	)
+	p.metrics.fetches.Inc(1)
+	for i, fetch := range replicas {
+		go issueReplicaFetch(i, fetch, resultCh)
+	}
+
+	var multiErr xerrors.MultiError
+	for pending := len(replicas); pending > 0; pending-- {
+		select {
+		case result := <-resultCh:
+			if result.err != nil {
+				multiErr = multiErr.Add(result.err)
+				continue
+			}
+			go discardReplicaFetches(resultCh, pending-1, p.metrics)
+			if result.replica > 0 {
+				p.metrics.replicaWins.Inc(1)
+			}
+			p.metrics.latency.Record(p.nowFn().Sub(start))
+			return result.iters, nil
+		case <-ctx.Done():
+			go discardReplicaFetches(resultCh, pending, p.metrics)
+			return nil, ctx.Err()
+		}
+	}
+
+	p.metrics.fetchErrors.Inc(1)
+	return nil, multiErr.FinalError()
+}
+
+type hedgedFetchFanoutPolicy struct {
+	threshold time.Duration
+	nowFn     func() time.Time
+	metrics   fetchFanoutPolicyMetrics
+}
+
+// NewHedgedFetchFanoutPolicy returns a policy that fetches from the primary
+// cluster of a namespace and issues a hedged fetch to the next replica each
+// time no fetch has completed after the hedge threshold. A failed fetch
+// issues the next hedged fetch immediately.
+func NewHedgedFetchFanoutPolicy(
+	threshold time.Duration,
+	instrumentOpts instrument.Options,
+) (FetchFanoutPolicy, error) {
+	if threshold <= 0 {
+		return nil, errHedgeThresholdNotPositive
+	}
+	return &hedgedFetchFanoutPolicy{
+		threshold: threshold,
+		nowFn:     time.Now,
+		metrics:   newFetchFanoutPolicyMetrics(HedgedFetchFanoutPolicyType, instrumentOpts),
+	}, nil
+}
+
+func (p *hedgedFetchFanoutPolicy) Type() FetchFanoutPolicyType {
+	return HedgedFetchFanoutPolicyType
+}
+
+func (p *hedgedFetchFanoutPolicy) Fetch(
+	ctx context.Context,
+	replicas []ReplicaFetchFn,
+) (encoding.SeriesIterators, error) {
+	if len(replicas) == 0 {
+		return nil, errNoReplicasToFetch
+	}
+
+	var (
+		start    = p.nowFn()
+		resultCh = make(chan replicaFetchResult, len(replicas))
+		timer    = time.NewTimer(p.threshold)
+		issued   = 0
+		pending  = 0
+		multiErr xerrors.MultiError
+	)
+	defer timer.Stop()
+
+	issueNext := func() {
+		go issueReplicaFetch(issued, replicas[issued], resultCh)
+		issued++
+		pending++
+	}
+
+	p.metrics.fetches.Inc(1)
+	issueNext()
+	for {
+		select {
+		case result := <-resultCh:
+			pending--
+			if result.err == nil {
+				go discardReplicaFetches(resultCh, pending, p.metrics)
+				if result.replica > 0 {
+					p.metrics.replicaWins.Inc(1)
+				}
+				p.metrics.latency.Record(p.nowFn().Sub(start))
+				return result.iters, nil
+			}
+
+			multiErr = multiErr.Add(result.err)
+			if issued < len(replicas) {
+				p.metrics.hedgedFetches.Inc(1)
+				issueNext()
+				continue
+			}
+			if pending == 0 {
+				p.metrics.fetchErrors.Inc(1)
+				return nil, multiErr.FinalError()
+			}
+		case <-timer.C:
+			if issued < len(replicas) {
+				p.metrics.hedgedFetches.Inc(1)
+				issueNext()
+				timer.Reset(p.threshold)
+			}
+		case <-ctx.Done():
+			go discardReplicaFetches(resultCh, pending, p.metrics)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func issueReplicaFetch(
+	replica int,
+	fetch ReplicaFetchFn,
+	resultCh chan<- replicaFetchResult,
+) {
+	iters, err := fetch()
+	resultCh <- replicaFetchResult{replica: replica, iters: iters, err: err}
+}
+
+// discardReplicaFetches waits for the outstanding fetches that lost the race
+// to complete and closes their results.
+func discardReplicaFetches(
+	resultCh <-chan replicaFetchResult,
+	pending int,
+	metrics fetchFanoutPolicyMetrics,
+) {
+	for i := 0; i < pending; i++ {
+		result := <-resultCh
+		if result.err == nil && result.iters != nil {
+			result.iters.Close()
+		}
+		metrics.discarded.Inc(1)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package m3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func newTestReplicaFetch(
+	iters encoding.SeriesIterators,
+	err error,
+	delay time.Duration,
+) ReplicaFetchFn {
+	return func() (encoding.SeriesIterators, error) {
+		time.Sleep(delay)
+		return iters, err
+	}
+}
+
+func requireFetchFanoutCounter(
+	t *testing.T,
+	scope tally.TestScope,
+	policy FetchFanoutPolicyType,
+	name string,
+	expected int64,
+) {
+	key := fmt.Sprintf("fetch-fanout.%s+policy=%s", name, policy.String())
+	counter, ok := scope.Snapshot().Counters()[key]
+	require.True(t, ok, "missing counter %s", key)
+	require.Equal(t, expected, counter.Value())
+}
+
+func TestPrimaryFetchFanoutPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scope := tally.NewTestScope("", nil)
+	policy := NewPrimaryFetchFanoutPolicy(instrument.NewOptions().SetMetricsScope(scope))
+
+	primary := encoding.NewMockSeriesIterators(ctrl)
+	replica := func() (encoding.SeriesIterators, error) {
+		assert.Fail(t, "replica should not be fetched")
+		return nil, nil
+	}
+	iters, err := policy.Fetch(context.Background(), []ReplicaFetchFn{
+		newTestReplicaFetch(primary, nil, 0), replica,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, primary, iters)
+
+	_, err = policy.Fetch(context.Background(), nil)
+	require.Equal(t, errNoReplicasToFetch, err)
+	requireFetchFanoutCounter(t, scope, PrimaryFetchFanoutPolicyType, "fetches", 1)
+}
+
+func TestFastestReplicaFetchFanoutPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scope := tally.NewTestScope("", nil)
+	policy := NewFastestReplicaFetchFanoutPolicy(instrument.NewOptions().SetMetricsScope(scope))
+
+	var (
+		primary = encoding.NewMockSeriesIterators(ctrl)
+		replica = encoding.NewMockSeriesIterators(ctrl)
+		closed  = make(chan struct{})
+	)
+	primary.EXPECT().Close().Do(func() { close(closed) })
+	iters, err := policy.Fetch(context.Background(), []ReplicaFetchFn{
+		newTestReplicaFetch(primary, nil, 50*time.Millisecond),
+		newTestReplicaFetch(replica, nil, 0),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, replica, iters)
+
+	// The slower result is closed once it completes.
+	<-closed
+	requireFetchFanoutCounter(t, scope, FastestReplicaFetchFanoutPolicyType, "replica-wins", 1)
+
+	// A failed fetch falls back to the other replicas.
+	iters, err = policy.Fetch(context.Background(), []ReplicaFetchFn{
+		newTestReplicaFetch(nil, errors.New("primary down"), 0),
+		newTestReplicaFetch(replica, nil, 10*time.Millisecond),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, replica, iters)
+
+	_, err = policy.Fetch(context.Background(), []ReplicaFetchFn{
+		newTestReplicaFetch(nil, errors.New("primary down"), 0),
+		newTestReplicaFetch(nil, errors.New("replica down"), 0),
+	})
+	require.Error(t, err)
+	requireFetchFanoutCounter(t, scope, FastestReplicaFetchFanoutPolicyType, "fetch-errors", 1)
+}
+
+func TestHedgedFetchFanoutPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, err := NewHedgedFetchFanoutPolicy(0, instrument.NewOptions())
+	require.Equal(t, errHedgeThresholdNotPositive, err)
+
+	scope := tally.NewTestScope("", nil)
+	policy, err := NewHedgedFetchFanoutPolicy(10*time.Millisecond,
+		instrument.NewOptions().SetMetricsScope(scope))
+	require.NoError(t, err)
+
+	// A fast primary does not issue a hedged fetch.
+	primary := encoding.NewMockSeriesIterators(ctrl)
+	replica := func() (encoding.SeriesIterators, error) {
+		assert.Fail(t, "replica should not be fetched")
+		return nil, nil
+	}
+	iters, err := policy.Fetch(context.Background(), []ReplicaFetchFn{
+		newTestReplicaFetch(primary, nil, 0), replica,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, primary, iters)
+
+	// A slow primary issues a hedged fetch after the threshold.
+	var (
+		slow   = encoding.NewMockSeriesIterators(ctrl)
+		hedged = encoding.NewMockSeriesIterators(ctrl)
+		closed = make(chan struct{})
+	)
+	slow.EXPECT().Close().Do(func() { close(closed) })
+	iters, err = policy.Fetch(context.Background(), []ReplicaFetchFn{
+		newTestReplicaFetch(slow, nil, 200*time.Millisecond),
+		newTestReplicaFetch(hedged, nil, 0),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, hedged, iters)
+	<-closed
+	requireFetchFanoutCounter(t, scope, HedgedFetchFanoutPolicyType, "hedged-fetches", 1)
+	requireFetchFanoutCounter(t, scope, HedgedFetchFanoutPolicyType, "replica-wins", 1)
+
+	// A cancelled fetch returns the context error.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = policy.Fetch(ctx, []ReplicaFetchFn{
+		newTestReplicaFetch(nil, errors.New("primary down"), 50*time.Millisecond),
+	})
+	require.Equal(t, context.Canceled, err)
+}
+
+func TestFetchFanoutPolicyConfiguration(t *testing.T) {
+	var cfg FetchFanoutPolicyConfiguration
+	require.NoError(t, yaml.Unmarshal([]byte("type: hedged\nhedgeThreshold: 20ms\n"), &cfg))
+	policy, err := cfg.NewPolicy(instrument.NewOptions())
+	require.NoError(t, err)
+	require.Equal(t, HedgedFetchFanoutPolicyType, policy.Type())
+	require.Equal(t, 20*time.Millisecond, policy.(*hedgedFetchFanoutPolicy).threshold)
+
+	for _, value := range validFetchFanoutPolicyTypes {
+		var cfg FetchFanoutPolicyConfiguration
+		str := fmt.Sprintf("type: %s\n", value.String())
+		require.NoError(t, yaml.Unmarshal([]byte(str), &cfg))
+		assert.Equal(t, value, cfg.Type)
+	}
+	require.Error(t, yaml.Unmarshal([]byte("type: not_a_known_type\n"), &cfg))
+}
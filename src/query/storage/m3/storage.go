@@ -298,9 +298,18 @@ func (s *m3storage) fetchCompressed(
 
 		wg.Add(1)
 		go func() {
-			session := namespace.Session()
 			ns := namespace.NamespaceID()
-			iters, _, err := session.FetchTagged(ns, m3query, opts)
+			replicas := make([]ReplicaFetchFn, 0, 1+len(namespace.ReplicaSessions()))
+			for _, session := range append([]client.Session{namespace.Session()},
+				namespace.ReplicaSessions()...) {
+				session := session // Capture var
+				replicas = append(replicas, func() (encoding.SeriesIterators, error) {
+					iters, _, err := session.FetchTagged(ns, m3query, opts)
+					return iters, err
+				})
+			}
+			policy := namespace.Options().FetchFanoutPolicy()
+			iters, err := policy.Fetch(ctx, replicas)
 			// Ignore error from getting iterator pools, since operation
 			// will not be dramatically impacted if pools is nil
 			result.Add(namespace.Options().Attributes(), iters, err)
@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/query/block"
 	"github.com/m3db/m3/src/query/cost"
 	"github.com/m3db/m3/src/query/models"
@@ -94,6 +95,9 @@ type FetchOptions struct {
 	Enforcer cost.ChainedEnforcer
 	// Scope is used to report metrics about the fetch.
 	Scope tally.Scope
+	// DurabilityTracker, if set, requests the durability of the data fetched
+	// from the storage nodes and collects it.
+	DurabilityTracker *index.QueryDurabilityTracker
 }
 
 // FanoutOptions describes which namespaces should be fanned out to for
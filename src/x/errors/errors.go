@@ -198,6 +198,76 @@ func GetInnerNonRetryableError(err error) error {
 	return nil
 }
 
+type resourceExhaustedError struct {
+	containedError
+}
+
+// NewResourceExhaustedError creates a new resource exhausted error, raised
+// when a request is rejected to apply backpressure to the caller.
+func NewResourceExhaustedError(inner error) error {
+	return resourceExhaustedError{containedError{inner}}
+}
+
+func (e resourceExhaustedError) Error() string {
+	return e.inner.Error()
+}
+
+func (e resourceExhaustedError) InnerError() error {
+	return e.inner
+}
+
+// IsResourceExhaustedError returns true if this is a resource exhausted error.
+func IsResourceExhaustedError(err error) bool {
+	return GetInnerResourceExhaustedError(err) != nil
+}
+
+// GetInnerResourceExhaustedError returns an inner resource exhausted error
+// if contained by this error, nil otherwise.
+func GetInnerResourceExhaustedError(err error) error {
+	for err != nil {
+		if _, ok := err.(resourceExhaustedError); ok {
+			return InnerError(err)
+		}
+		err = InnerError(err)
+	}
+	return nil
+}
+
+type blockedError struct {
+	containedError
+}
+
+// NewBlockedError creates a new blocked error, raised when a request is
+// rejected because an operator blocked it, it should not be retried.
+func NewBlockedError(inner error) error {
+	return blockedError{containedError{inner}}
+}
+
+func (e blockedError) Error() string {
+	return e.inner.Error()
+}
+
+func (e blockedError) InnerError() error {
+	return e.inner
+}
+
+// IsBlockedError returns true if this is a blocked error.
+func IsBlockedError(err error) bool {
+	return GetInnerBlockedError(err) != nil
+}
+
+// GetInnerBlockedError returns an inner blocked error if contained by this
+// error, nil otherwise.
+func GetInnerBlockedError(err error) error {
+	for err != nil {
+		if _, ok := err.(blockedError); ok {
+			return InnerError(err)
+		}
+		err = InnerError(err)
+	}
+	return nil
+}
+
 // MultiError is an immutable error that packages a list of errors.
 //
 // TODO(xichen): we may want to limit the number of errors included.
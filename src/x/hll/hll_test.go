@@ -0,0 +1,84 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package hll
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func requireEstimateWithin(t *testing.T, expected int, s *Sketch, tolerance float64) {
+	estimate := float64(s.Estimate())
+	require.True(t, math.Abs(estimate-float64(expected)) <= tolerance*float64(expected),
+		"estimate %v not within %v of %d", estimate, tolerance, expected)
+}
+
+func TestNewSketchPrecision(t *testing.T) {
+	_, err := NewSketch(MinPrecision - 1)
+	require.Error(t, err)
+	_, err = NewSketch(MaxPrecision + 1)
+	require.Error(t, err)
+
+	s, err := NewSketch(12)
+	require.NoError(t, err)
+	require.Equal(t, 12, s.Precision())
+	require.Equal(t, uint64(0), s.Estimate())
+}
+
+func TestSketchEstimate(t *testing.T) {
+	for _, n := range []int{10, 1000, 100000} {
+		s, err := NewSketch(14)
+		require.NoError(t, err)
+
+		// Duplicates must not be counted.
+		for j := 0; j < 2; j++ {
+			for i := 0; i < n; i++ {
+				s.Add([]byte(fmt.Sprintf("series-%d", i)))
+			}
+		}
+		requireEstimateWithin(t, n, s, 0.05)
+	}
+}
+
+func TestSketchMerge(t *testing.T) {
+	a, err := NewSketch(14)
+	require.NoError(t, err)
+	b, err := NewSketch(14)
+	require.NoError(t, err)
+
+	for i := 0; i < 20000; i++ {
+		a.Add([]byte(fmt.Sprintf("series-%d", i)))
+	}
+	for i := 10000; i < 30000; i++ {
+		b.Add([]byte(fmt.Sprintf("series-%d", i)))
+	}
+	require.NoError(t, a.Merge(b))
+	requireEstimateWithin(t, 30000, a, 0.05)
+
+	c, err := NewSketch(10)
+	require.NoError(t, err)
+	require.Error(t, a.Merge(c))
+
+	a.Reset()
+	require.Equal(t, uint64(0), a.Estimate())
+}
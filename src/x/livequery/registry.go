@@ -0,0 +1,183 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package livequery
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/x/clock"
+)
+
+type registry struct {
+	sync.RWMutex
+
+	nowFn   clock.NowFn
+	lastID  uint64
+	queries map[string]*Handle
+}
+
+// NewRegistry returns a new live query registry.
+func NewRegistry(nowFn clock.NowFn) Registry {
+	return &registry{
+		nowFn:   nowFn,
+		queries: make(map[string]*Handle),
+	}
+}
+
+func (r *registry) Register(
+	ctx context.Context,
+	queryFn QueryFn,
+	resourcesFn ResourcesFn,
+) (context.Context, *Handle) {
+	ctx, cancel := context.WithCancel(ctx)
+	h := &Handle{
+		registry:    r,
+		id:          strconv.FormatUint(atomic.AddUint64(&r.lastID, 1), 10),
+		queryFn:     queryFn,
+		start:       r.nowFn(),
+		cancel:      cancel,
+		resourcesFn: resourcesFn,
+	}
+
+	r.Lock()
+	r.queries[h.id] = h
+	r.Unlock()
+
+	return ctx, h
+}
+
+func (r *registry) Queries() []Query {
+	now := r.nowFn()
+
+	r.RLock()
+	queries := make([]Query, 0, len(r.queries))
+	for _, h := range r.queries {
+		queries = append(queries, h.snapshot(now))
+	}
+	r.RUnlock()
+
+	sort.Slice(queries, func(i, j int) bool {
+		if !queries[i].Start.Equal(queries[j].Start) {
+			return queries[i].Start.Before(queries[j].Start)
+		}
+		return queries[i].ID < queries[j].ID
+	})
+	return queries
+}
+
+func (r *registry) Cancel(id string) error {
+	r.RLock()
+	h, ok := r.queries[id]
+	r.RUnlock()
+	if !ok {
+		return ErrQueryNotFound
+	}
+
+	atomic.StoreInt32(&h.cancelled, 1)
+	h.cancel()
+	return nil
+}
+
+func (r *registry) remove(id string) {
+	r.Lock()
+	delete(r.queries, id)
+	r.Unlock()
+}
+
+// Handle is the handle of a registered live query.
+type Handle struct {
+	series     int64
+	datapoints int64
+	bytes      int64
+	cancelled  int32
+
+	registry    *registry
+	id          string
+	queryFn     QueryFn
+	start       time.Time
+	cancel      context.CancelFunc
+	resourcesFn ResourcesFn
+	closeOnce   sync.Once
+}
+
+// ID returns the ID of the query.
+func (h *Handle) ID() string {
+	return h.id
+}
+
+// AddSeries adds to the number of series read by the query.
+func (h *Handle) AddSeries(n int64) {
+	atomic.AddInt64(&h.series, n)
+}
+
+// AddDatapoints adds to the number of datapoints read by the query.
+func (h *Handle) AddDatapoints(n int64) {
+	atomic.AddInt64(&h.datapoints, n)
+}
+
+// AddBytes adds to the number of bytes read by the query.
+func (h *Handle) AddBytes(n int64) {
+	atomic.AddInt64(&h.bytes, n)
+}
+
+// Err returns ErrQueryCancelled if the query has been cancelled by ID.
+func (h *Handle) Err() error {
+	if atomic.LoadInt32(&h.cancelled) == 1 {
+		return ErrQueryCancelled
+	}
+	return nil
+}
+
+// Close removes the query from the registry and releases its context,
+// it is safe to call more than once.
+func (h *Handle) Close() {
+	h.closeOnce.Do(func() {
+		h.registry.remove(h.id)
+		h.cancel()
+	})
+}
+
+func (h *Handle) snapshot(now time.Time) Query {
+	resources := Resources{
+		Series:     atomic.LoadInt64(&h.series),
+		Datapoints: atomic.LoadInt64(&h.datapoints),
+		Bytes:      atomic.LoadInt64(&h.bytes),
+	}
+	if h.resourcesFn != nil {
+		fnResources := h.resourcesFn()
+		resources.Series += fnResources.Series
+		resources.Datapoints += fnResources.Datapoints
+		resources.Bytes += fnResources.Bytes
+	}
+	return Query{
+		ID:        h.id,
+		Query:     h.queryFn(),
+		Start:     h.start,
+		Elapsed:   now.Sub(h.start),
+		Resources: resources,
+		Cancelled: atomic.LoadInt32(&h.cancelled) == 1,
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package livequery
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryListAndCancel(t *testing.T) {
+	var (
+		start = time.Unix(1000, 0)
+		nowNs = start.UnixNano()
+		nowFn = func() time.Time { return time.Unix(0, atomic.LoadInt64(&nowNs)) }
+		r     = NewRegistry(nowFn)
+	)
+
+	ctx1, h1 := r.Register(context.Background(), queryString("first"), nil)
+	atomic.AddInt64(&nowNs, int64(time.Second))
+	ctx2, h2 := r.Register(context.Background(), queryString("second"), func() Resources {
+		return Resources{Datapoints: 100}
+	})
+	defer h2.Close()
+	atomic.AddInt64(&nowNs, int64(time.Second))
+
+	h1.AddSeries(2)
+	h1.AddBytes(64)
+	h2.AddDatapoints(5)
+
+	queries := r.Queries()
+	require.Equal(t, 2, len(queries))
+	assert.Equal(t, Query{
+		ID:        h1.ID(),
+		Query:     "first",
+		Start:     start,
+		Elapsed:   2 * time.Second,
+		Resources: Resources{Series: 2, Bytes: 64},
+	}, queries[0])
+	assert.Equal(t, Query{
+		ID:        h2.ID(),
+		Query:     "second",
+		Start:     start.Add(time.Second),
+		Elapsed:   time.Second,
+		Resources: Resources{Datapoints: 105},
+	}, queries[1])
+
+	// Cancelling a query cancels its context but leaves it listed
+	// until it is closed.
+	require.NoError(t, r.Cancel(h1.ID()))
+	require.Equal(t, context.Canceled, ctx1.Err())
+	require.Equal(t, ErrQueryCancelled, h1.Err())
+	require.NoError(t, ctx2.Err())
+	require.NoError(t, h2.Err())
+
+	queries = r.Queries()
+	require.Equal(t, 2, len(queries))
+	assert.True(t, queries[0].Cancelled)
+	assert.False(t, queries[1].Cancelled)
+
+	h1.Close()
+	h1.Close()
+	queries = r.Queries()
+	require.Equal(t, 1, len(queries))
+	assert.Equal(t, h2.ID(), queries[0].ID)
+
+	require.Equal(t, ErrQueryNotFound, r.Cancel(h1.ID()))
+}
+
+func TestRegistryCloseReleasesContext(t *testing.T) {
+	r := NewRegistry(time.Now)
+	ctx, h := r.Register(context.Background(), queryString("query"), nil)
+	h.Close()
+	require.Equal(t, context.Canceled, ctx.Err())
+	// Closing is not a cancellation by ID.
+	require.NoError(t, h.Err())
+	require.Empty(t, r.Queries())
+}
+
+func TestRegistryDescribesQueriesOnlyWhenListed(t *testing.T) {
+	var described int
+	r := NewRegistry(time.Now)
+	_, h := r.Register(context.Background(), func() string {
+		described++
+		return "query"
+	}, nil)
+	defer h.Close()
+	require.Equal(t, 0, described)
+
+	queries := r.Queries()
+	require.Equal(t, 1, len(queries))
+	require.Equal(t, "query", queries[0].Query)
+	require.Equal(t, 1, described)
+}
+
+func queryString(query string) QueryFn {
+	return func() string {
+		return query
+	}
+}
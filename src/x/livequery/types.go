@@ -0,0 +1,81 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package livequery provides a registry of the queries that are currently
+// executing so that operators can list them and cancel runaway queries.
+package livequery
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrQueryNotFound is returned when cancelling a query that is not live.
+	ErrQueryNotFound = errors.New("live query not found")
+
+	// ErrQueryCancelled is returned by queries that were cancelled by ID.
+	ErrQueryCancelled = errors.New("query cancelled")
+)
+
+// Registry is a registry of live queries, it is safe for concurrent use.
+type Registry interface {
+	// Register registers a live query and returns a context that is cancelled
+	// when the query is cancelled by ID, along with the handle of the query
+	// which must be closed once the query is done. The query function is
+	// only called when the query is listed. The resources function is
+	// optional and reports resources used on top of those added to the
+	// handle.
+	Register(
+		ctx context.Context,
+		queryFn QueryFn,
+		resourcesFn ResourcesFn,
+	) (context.Context, *Handle)
+
+	// Queries returns the live queries, oldest first.
+	Queries() []Query
+
+	// Cancel cancels the live query with the given ID.
+	Cancel(id string) error
+}
+
+// Query describes a live query.
+type Query struct {
+	ID        string
+	Query     string
+	Start     time.Time
+	Elapsed   time.Duration
+	Resources Resources
+	Cancelled bool
+}
+
+// Resources are the resources used by a query so far.
+type Resources struct {
+	Series     int64
+	Datapoints int64
+	Bytes      int64
+}
+
+// QueryFn returns the description of a query.
+type QueryFn func() string
+
+// ResourcesFn returns the resources used by a query so far.
+type ResourcesFn func() Resources
@@ -54,6 +54,22 @@ type Options struct {
 	HugeTLB HugeTLBOptions
 }
 
+// Advice is a hint about the expected access pattern of an mmap'd region
+type Advice int
+
+const (
+	// AdviceNormal is the default access pattern with moderate readahead
+	AdviceNormal Advice = iota
+	// AdviceRandom hints that pages will be accessed in random order so readahead
+	// should be disabled
+	AdviceRandom
+	// AdviceSequential hints that pages will be accessed sequentially so readahead
+	// can be aggressive
+	AdviceSequential
+	// AdviceWillNeed hints that pages will be accessed soon so they can be read ahead
+	AdviceWillNeed
+)
+
 // Result contains the results of a successful mmap
 type Result struct {
 	Result  []byte
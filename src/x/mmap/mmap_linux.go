@@ -109,3 +109,32 @@ func Munmap(b []byte) error {
 
 	return nil
 }
+
+// Madvise advises the kernel of the expected access pattern of a byte slice
+// that is backed by an mmap
+func Madvise(b []byte, advice Advice) error {
+	if len(b) == 0 {
+		// Never actually mmapd this, just returned empty slice
+		return nil
+	}
+
+	var flag int
+	switch advice {
+	case AdviceNormal:
+		flag = syscall.MADV_NORMAL
+	case AdviceRandom:
+		flag = syscall.MADV_RANDOM
+	case AdviceSequential:
+		flag = syscall.MADV_SEQUENTIAL
+	case AdviceWillNeed:
+		flag = syscall.MADV_WILLNEED
+	default:
+		return fmt.Errorf("unknown madvise advice: %d", advice)
+	}
+
+	if err := syscall.Madvise(b, flag); err != nil {
+		return fmt.Errorf("madvise error: %v", err)
+	}
+
+	return nil
+}
@@ -81,3 +81,8 @@ func Munmap(b []byte) error {
 
 	return nil
 }
+
+// Madvise is a no-op on platforms other than linux as the advice is only a hint
+func Madvise(b []byte, advice Advice) error {
+	return nil
+}
@@ -126,6 +126,33 @@ func TestMmapFilesHandlesWarnings(t *testing.T) {
 	assert.Equal(t, []byte("a"), bytes1)
 }
 
+func TestMadvise(t *testing.T) {
+	fd, err := ioutil.TempFile("", "testfile")
+	assert.NoError(t, err)
+	defer os.Remove(fd.Name())
+
+	data := []byte("some-data")
+	_, err = fd.Write(data)
+	assert.NoError(t, err)
+
+	result, err := File(fd, Options{Read: true})
+	assert.NoError(t, err)
+	defer Munmap(result.Result)
+
+	for _, advice := range []Advice{
+		AdviceRandom,
+		AdviceSequential,
+		AdviceWillNeed,
+		AdviceNormal,
+	} {
+		assert.NoError(t, Madvise(result.Result, advice))
+	}
+	assert.Equal(t, data, result.Result)
+
+	// Empty slices are never actually mmap'd.
+	assert.NoError(t, Madvise([]byte{}, AdviceRandom))
+}
+
 func mockMmapFdFunc(f mmapFdFuncType) func() {
 	old := mmapFdFn
 	mmapFdFn = f
@@ -0,0 +1,75 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package sync
+
+import (
+	"sync"
+)
+
+type concurrencyLimiter struct {
+	sync.Mutex
+	cond    *sync.Cond
+	limit   int
+	holders int
+}
+
+// NewConcurrencyLimiter creates a new concurrency limiter, a limit of zero
+// or less means unlimited.
+func NewConcurrencyLimiter(limit int) ConcurrencyLimiter {
+	l := &concurrencyLimiter{limit: limit}
+	l.cond = sync.NewCond(l)
+	return l
+}
+
+func (l *concurrencyLimiter) Acquire() {
+	l.Lock()
+	for l.limit > 0 && l.holders >= l.limit {
+		l.cond.Wait()
+	}
+	l.holders++
+	l.Unlock()
+}
+
+func (l *concurrencyLimiter) Release() {
+	l.Lock()
+	l.holders--
+	l.Unlock()
+	l.cond.Broadcast()
+}
+
+func (l *concurrencyLimiter) SetLimit(limit int) {
+	l.Lock()
+	l.limit = limit
+	l.Unlock()
+	// Raising the limit may allow those waiting to acquire.
+	l.cond.Broadcast()
+}
+
+func (l *concurrencyLimiter) Limit() int {
+	l.Lock()
+	defer l.Unlock()
+	return l.limit
+}
+
+func (l *concurrencyLimiter) Holders() int {
+	l.Lock()
+	defer l.Unlock()
+	return l.holders
+}
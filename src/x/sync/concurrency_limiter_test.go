@@ -0,0 +1,63 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyLimiterSetLimit(t *testing.T) {
+	l := NewConcurrencyLimiter(1)
+	l.Acquire()
+	require.Equal(t, 1, l.Holders())
+
+	acquired := make(chan struct{})
+	go func() {
+		l.Acquire()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		require.FailNow(t, "acquired beyond the limit")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// Raising the limit wakes up those waiting.
+	l.SetLimit(2)
+	<-acquired
+	require.Equal(t, 2, l.Holders())
+
+	l.Release()
+	l.Release()
+	require.Equal(t, 0, l.Holders())
+}
+
+func TestConcurrencyLimiterUnlimited(t *testing.T) {
+	l := NewConcurrencyLimiter(0)
+	for i := 0; i < 10; i++ {
+		l.Acquire()
+	}
+	require.Equal(t, 10, l.Holders())
+	require.Equal(t, 0, l.Limit())
+}
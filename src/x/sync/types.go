@@ -61,6 +61,28 @@ type PooledWorkerPool interface {
 	Go(work Work)
 }
 
+// ConcurrencyLimiter limits the number of concurrent holders of a resource,
+// unlike WorkerPool its limit can be changed while it is in use.
+type ConcurrencyLimiter interface {
+	// Acquire waits until the number of holders is below the limit and
+	// becomes a holder.
+	Acquire()
+
+	// Release stops being a holder, waking up those waiting to acquire.
+	Release()
+
+	// SetLimit sets the limit of concurrent holders, a limit of zero or
+	// less means unlimited. Lowering the limit does not affect the current
+	// holders, only those acquiring afterwards.
+	SetLimit(limit int)
+
+	// Limit returns the limit of concurrent holders.
+	Limit() int
+
+	// Holders returns the number of current holders.
+	Holders() int
+}
+
 // WorkerPool provides a pool for goroutines.
 type WorkerPool interface {
 	// Init initializes the pool.